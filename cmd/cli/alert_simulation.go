@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// alertTestStep is one synthetic reading of a test-fired rule
+type alertTestStep struct {
+	At     time.Time `json:"at"`
+	Value  float64   `json:"value"`
+	Active bool      `json:"active"`
+}
+
+// alertTestNotification is the outcome of sending the test message through
+// a channel of the rule
+type alertTestNotification struct {
+	Channel string `json:"channel"`
+	Sent    bool   `json:"sent"`
+	Error   string `json:"error,omitempty"`
+}
+
+// alertTestResult is the outcome of test-firing a rule
+type alertTestResult struct {
+	RuleID uuid.UUID `json:"rule_id"`
+	// Triggered reports whether the synthetic readings raised the rule
+	Triggered     bool                    `json:"triggered"`
+	Path          []alertTestStep         `json:"path"`
+	Notifications []alertTestNotification `json:"notifications"`
+}
+
+// simulationStore serves a single rule to the alert hook and records its
+// states instead of storing them
+type simulationStore struct {
+	rule  models.AlertRule
+	steps []alertTestStep
+}
+
+func (s *simulationStore) GetEnabledAlertRules(ctx context.Context, stationID uuid.UUID) ([]models.AlertRule, error) {
+	return []models.AlertRule{s.rule}, nil
+}
+
+func (s *simulationStore) SetAlertState(ctx context.Context, id uuid.UUID, active bool, value float64, at time.Time) error {
+	s.rule.Active = active
+	s.steps = append(s.steps, alertTestStep{At: at, Value: value, Active: active})
+	return nil
+}
+
+// simulatedStorms returns the storm severity of the synthetic path
+type simulatedStorms map[time.Time]int
+
+func (s simulatedStorms) StormSeverity(ctx context.Context, stationID uuid.UUID, at time.Time) (int, error) {
+	return s[at], nil
+}
+
+// simulatedBatteries returns the battery severity of the synthetic path,
+// which is the value of the reading
+type simulatedBatteries struct{}
+
+func (simulatedBatteries) BatterySeverity(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (int, error) {
+	return int(readings[len(readings)-1].Value), nil
+}
+
+// simulatedIndoorClimate returns a single room at the location of the rule
+// whose ventilation flag and mold risk are the value of the reading
+type simulatedIndoorClimate struct {
+	location string
+}
+
+func (s simulatedIndoorClimate) StationIndoorClimate(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (*models.StationIndoorClimate, error) {
+	value := readings[len(readings)-1].Value
+	room := models.IndoorClimate{Location: s.location, MoldRisk: value, Ventilate: value >= 1}
+	return &models.StationIndoorClimate{Rooms: []models.IndoorClimate{room}}, nil
+}
+
+// simulatedClockSkew returns the clock skew of the synthetic path, which is
+// set to the value of each step
+type simulatedClockSkew struct {
+	skew time.Duration
+}
+
+func (s *simulatedClockSkew) StationClockSkew(stationID uuid.UUID) (time.Duration, bool) {
+	return s.skew, true
+}
+
+// testFireAlertRule feeds the rule synthetic readings moving beyond its
+// threshold through the alert hook. When the rule is raised, a message
+// marked as test is sent through its channels. Neither the stored state of
+// the rule nor MQTT are touched.
+func testFireAlertRule(ctx context.Context, rule models.AlertRule, station *models.StationData, notifier *notify.Dispatcher, templates map[string]notify.Template, now time.Time) alertTestResult {
+	rule.Enabled, rule.Active, rule.ActiveSince = true, false, nil
+	result := alertTestResult{RuleID: rule.ID, Path: []alertTestStep{}, Notifications: []alertTestNotification{}}
+
+	listener := func(ctx context.Context, raised models.AlertRule) {
+		if !raised.Active {
+			return
+		}
+		result.Triggered = true
+		locale := alertLocale(raised)
+		for _, channel := range raised.Channels {
+			msg := alertMessage(raised, station, templates[channel])
+			msg.To = alertRecipients()
+			msg.Subject = i18n.T(locale, "alert.test_subject", msg.Subject)
+			msg.Body = i18n.T(locale, "alert.test_body") + "\n\n" + msg.Body
+			n := alertTestNotification{Channel: channel, Sent: true}
+			if err := notifier.Send(ctx, channel, msg); err != nil {
+				n.Sent, n.Error = false, err.Error()
+			}
+			result.Notifications = append(result.Notifications, n)
+		}
+	}
+
+	store := &simulationStore{rule: rule}
+	hook := ingest.NewAlertHook(store, listener)
+	sensor := models.Sensor{ID: uuid.New(), StationID: rule.StationID, SensorType: rule.SensorType, Location: rule.Location, Enabled: true}
+	storms := simulatedStorms{}
+	clockSkew := &simulatedClockSkew{}
+	switch {
+	case rule.IsStorm():
+		sensor.SensorType = models.SensorTypeWindSpeed
+		hook.SetStormDetector(storms)
+	case rule.IsBattery():
+		sensor.SensorType = models.SensorTypeBattery
+		hook.SetBatteryRater(simulatedBatteries{})
+	case rule.IsIndoorClimate():
+		hook.SetIndoorClimateRater(simulatedIndoorClimate{location: rule.Location})
+	case rule.IsClockSkew():
+		hook.SetClockSkewRater(clockSkew)
+	}
+
+	path := rule.TestPath()
+	start := now.UTC().Truncate(time.Second).Add(-time.Duration(len(path)-1) * time.Minute)
+	for i, value := range path {
+		at := start.Add(time.Duration(i) * time.Minute)
+		storms[at] = int(value)
+		clockSkew.skew = time.Duration(value * float64(time.Second))
+		batch := &ingest.Batch{
+			StationID:  rule.StationID,
+			Station:    station,
+			Sensors:    map[string]models.Sensor{sensor.SensorType: sensor},
+			Readings:   []models.SensorReading{{SensorID: sensor.ID, DateUTC: at, Value: value}},
+			Source:     "alert-test",
+			ReceivedAt: now,
+		}
+		if err := hook.Process(ctx, batch); err != nil || result.Triggered {
+			break
+		}
+	}
+	result.Path = append(result.Path, store.steps...)
+	return result
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/mqtt"
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// newAlertNotifier returns a listener sending raised and cleared alerts
+// through the channels of their rule. Emails go to the addresses in
+// ALERT_NOTIFY_TO (comma separated).
+func newAlertNotifier(dbManager *database.DatabaseManager, notifier *notify.Dispatcher, templates map[string]notify.Template) ingest.AlertListener {
+	to := alertRecipients()
+	return func(ctx context.Context, rule models.AlertRule) {
+		if len(rule.Channels) == 0 {
+			return
+		}
+		station, err := dbManager.LoadStation(rule.StationID)
+		if err != nil {
+			log.Printf("❌ Failed to load station of alert %s: %v", rule.Name, err)
+			return
+		}
+
+		for _, channel := range rule.Channels {
+			msg := alertMessage(rule, &station, templates[channel])
+			msg.To = to
+			if err := notifier.Send(ctx, channel, msg); err != nil {
+				log.Printf("⚠ Failed to send alert %s: %v", rule.Name, err)
+			}
+		}
+	}
+}
+
+// alertRecipients returns the email addresses in ALERT_NOTIFY_TO
+func alertRecipients() []string {
+	var to []string
+	for _, address := range strings.Split(getEnv("ALERT_NOTIFY_TO", ""), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+	return to
+}
+
+// alertTemplateData is the context of alert notification templates. Texts
+// are in the locale of the rule, the time in the station time zone.
+type alertTemplateData struct {
+	Locale    i18n.Locale
+	Rule      string
+	Name      string
+	Active    bool
+	State     string
+	StationID uuid.UUID
+	Station   string
+	// SensorType is the display name of the sensor type
+	SensorType string
+	Location   string
+	Value      *float64
+	Unit       string
+	Operator   string
+	Threshold  float64
+	// Severity names the storm severity of storm rules, the battery
+	// status of battery rules and whether ventilation is recommended for
+	// ventilation rules
+	Severity string
+	Time     *time.Time
+}
+
+// alertTemplateFuncs are the functions of alert notification templates
+var alertTemplateFuncs = template.FuncMap{
+	"t": func(locale i18n.Locale, key string, args ...interface{}) string {
+		return i18n.T(locale, key, args...)
+	},
+	"value": formatAlertValue,
+}
+
+// formatAlertValue formats a value of an alert, - when there is none
+func formatAlertValue(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", *value)
+}
+
+// alertLocale returns the locale of the notifications of a rule
+func alertLocale(rule models.AlertRule) i18n.Locale {
+	if locale, ok := i18n.Parse(rule.Locale); ok {
+		return locale
+	}
+	return defaultLocale()
+}
+
+// newAlertTemplateData returns the template context of a rule in its
+// current state
+func newAlertTemplateData(rule models.AlertRule, station *models.StationData) alertTemplateData {
+	locale := alertLocale(rule)
+	data := alertTemplateData{
+		Locale:     locale,
+		Rule:       rule.Name,
+		Name:       alertDisplayName(rule.Name),
+		Active:     rule.Active,
+		State:      i18n.T(locale, "alert.cleared"),
+		StationID:  rule.StationID,
+		Station:    stationDisplayName(station),
+		SensorType: i18n.SensorTypeName(locale, rule.SensorType),
+		Location:   rule.Location,
+		Value:      rule.LastValue,
+		Unit:       models.SensorTypeRegistry[rule.SensorType].Unit,
+		Operator:   i18n.T(locale, "alert.operator."+rule.Operator),
+		Threshold:  rule.Threshold,
+	}
+	if rule.Active {
+		data.State = i18n.T(locale, "alert.raised")
+	}
+	if rule.IsStorm() {
+		severity := analysis.StormNone
+		if rule.LastValue != nil {
+			severity = int(*rule.LastValue)
+		}
+		data.Severity = i18n.T(locale, "storm."+analysis.StormLevel(severity))
+	}
+	if rule.IsBattery() {
+		severity := 0
+		if rule.LastValue != nil {
+			severity = int(*rule.LastValue)
+		}
+		data.Severity = i18n.T(locale, "battery."+models.BatterySeverityStatus(severity))
+	}
+	switch rule.SensorType {
+	case models.AlertTypeVentilation:
+		data.Severity = i18n.T(locale, "ventilation.not_needed")
+		if rule.LastValue != nil && *rule.LastValue >= 1 {
+			data.Severity = i18n.T(locale, "ventilation.recommended")
+		}
+	case models.AlertTypeMoldRisk:
+		data.Unit = "%"
+	case models.AlertTypeClockSkew:
+		data.Unit = "s"
+	}
+	if rule.EvaluatedAt != nil {
+		at := rule.EvaluatedAt.In(stationLocation(station))
+		data.Time = &at
+	}
+	return data
+}
+
+// alertMessage returns the notification of a raised or cleared alert sent
+// through a channel with its template. Templates of the rule take
+// precedence; texts failing to render fall back to the default ones.
+func alertMessage(rule models.AlertRule, station *models.StationData, channelTemplate notify.Template) notify.Message {
+	data := newAlertTemplateData(rule, station)
+	locale := data.Locale
+	detail := i18n.T(locale, "alert.detail", data.SensorType, formatAlertValue(data.Value), data.Unit, data.Operator, data.Threshold, data.Unit)
+	if rule.IsStorm() {
+		threshold := i18n.T(locale, "storm."+analysis.StormLevel(int(rule.Threshold)))
+		detail = i18n.T(locale, "alert.storm_detail", data.Severity, data.Operator, threshold)
+	}
+	if rule.IsBattery() {
+		threshold := i18n.T(locale, "battery."+models.BatterySeverityStatus(int(rule.Threshold)))
+		detail = i18n.T(locale, "alert.battery_detail", data.Severity, data.Operator, threshold)
+	}
+	if rule.SensorType == models.AlertTypeVentilation {
+		detail = i18n.T(locale, "alert.ventilation_detail", data.Severity)
+	}
+	msg := notify.Message{
+		Subject: i18n.T(locale, "alert.subject", data.State, data.Name, data.Station),
+		Body:    i18n.T(locale, "alert.body", rule.Name, data.Station, data.State) + "\n\n" + detail + "\n",
+	}
+
+	tmpl := notify.Template{Subject: rule.SubjectTemplate, Body: rule.BodyTemplate}.Or(channelTemplate)
+	if tmpl.IsZero() {
+		return msg
+	}
+	rendered, err := tmpl.Render(msg, data, alertTemplateFuncs)
+	if err != nil {
+		log.Printf("⚠ Failed to render notification of alert %s: %v", rule.Name, err)
+		return msg
+	}
+	return rendered
+}
+
+// alertChannelTemplates loads the notification templates of the channels
+// from ALERT_TEMPLATE_DIR: <channel>.subject.tmpl and <channel>.body.tmpl,
+// e.g. email.body.tmpl. Invalid templates are skipped.
+func alertChannelTemplates(notifier *notify.Dispatcher) map[string]notify.Template {
+	templates := make(map[string]notify.Template)
+	dir := getEnv("ALERT_TEMPLATE_DIR", "")
+	if dir == "" {
+		return templates
+	}
+
+	for _, channel := range notifier.Channels() {
+		var tmpl notify.Template
+		for part, text := range map[string]*string{"subject": &tmpl.Subject, "body": &tmpl.Body} {
+			data, err := os.ReadFile(filepath.Join(dir, channel+"."+part+".tmpl"))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				log.Printf("⚠ Failed to read %s template of channel %s: %v", part, channel, err)
+				continue
+			}
+			*text = string(data)
+		}
+		if tmpl.IsZero() {
+			continue
+		}
+		if err := tmpl.Validate(alertTemplateFuncs); err != nil {
+			log.Printf("⚠ Ignoring alert templates of channel %s: %v", channel, err)
+			continue
+		}
+		templates[channel] = tmpl
+		log.Printf("✓ Custom alert templates for channel %s", channel)
+	}
+	return templates
+}
+
+// alertDisplayName turns a rule name like frost_warning into "Frost warning"
+func alertDisplayName(name string) string {
+	name = strings.ReplaceAll(name, "_", " ")
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// alertPublisher exposes alert states to Home Assistant as binary sensors
+// through MQTT discovery. It is configured with MQTT_BROKER; without a broker
+// newAlertPublisher returns nil and all methods do nothing.
+type alertPublisher struct {
+	db              *database.DatabaseManager
+	client          *mqtt.Client
+	topicPrefix     string
+	discoveryPrefix string
+}
+
+// newAlertPublisher creates a publisher configured from the environment
+func newAlertPublisher(dbManager *database.DatabaseManager) *alertPublisher {
+	broker := getEnv("MQTT_BROKER", "")
+	if broker == "" {
+		return nil
+	}
+
+	topicPrefix := strings.TrimRight(getEnv("MQTT_TOPIC_PREFIX", "weathermaestro"), "/")
+	status := topicPrefix + "/status"
+	client := mqtt.NewClient(mqtt.Config{
+		Broker:   broker,
+		ClientID: getEnv("MQTT_CLIENT_ID", "weathermaestro"),
+		Username: getEnv("MQTT_USERNAME", ""),
+		Password: getEnv("MQTT_PASSWORD", ""),
+		Will:     &mqtt.Message{Topic: status, Payload: []byte(mqtt.Offline), Retain: true},
+		Birth:    []mqtt.Message{{Topic: status, Payload: []byte(mqtt.Online), Retain: true}},
+	})
+
+	log.Printf("✓ Alert states are published to MQTT broker %s", broker)
+	return &alertPublisher{
+		db:              dbManager,
+		client:          client,
+		topicPrefix:     topicPrefix,
+		discoveryPrefix: strings.TrimRight(getEnv("MQTT_DISCOVERY_PREFIX", mqtt.DefaultDiscoveryPrefix), "/"),
+	}
+}
+
+// stateTopic returns the topic of the ON/OFF state of a rule
+func (p *alertPublisher) stateTopic(rule models.AlertRule) string {
+	return fmt.Sprintf("%s/%s/alerts/%s/state", p.topicPrefix, rule.StationID, rule.Name)
+}
+
+// attributesTopic returns the topic of the value and times of a rule
+func (p *alertPublisher) attributesTopic(rule models.AlertRule) string {
+	return fmt.Sprintf("%s/%s/alerts/%s/attributes", p.topicPrefix, rule.StationID, rule.Name)
+}
+
+// configTopic returns the discovery topic of a rule
+func (p *alertPublisher) configTopic(rule models.AlertRule) string {
+	return mqtt.DiscoveryTopic(p.discoveryPrefix, "binary_sensor", alertNodeID(rule.StationID), rule.Name)
+}
+
+// alertNodeID identifies the alerts of a station in discovery topics, which
+// only allow letters, digits, underscores and hyphens
+func alertNodeID(stationID uuid.UUID) string {
+	return "weathermaestro_" + strings.ReplaceAll(stationID.String(), "-", "")
+}
+
+// alertDeviceClass picks the Home Assistant device class of a rule, e.g.
+// cold for frost warnings
+func alertDeviceClass(rule models.AlertRule) string {
+	switch rule.SensorType {
+	case models.AlertTypeVentilation:
+		return "problem"
+	case models.AlertTypeMoldRisk:
+		return "moisture"
+	}
+	switch models.SensorTypeRegistry[rule.SensorType].Category {
+	case models.SensorCategoryTemperature:
+		if rule.Operator == models.AlertOperatorBelow {
+			return "cold"
+		}
+		return "heat"
+	case models.SensorCategoryRain, models.SensorCategoryHumidity:
+		return "moisture"
+	}
+	return "safety"
+}
+
+// Announce publishes the discovery config and state of a rule. Disabled
+// rules are removed from Home Assistant.
+func (p *alertPublisher) Announce(ctx context.Context, rule models.AlertRule) error {
+	if p == nil {
+		return nil
+	}
+	if !rule.Enabled {
+		return p.Remove(ctx, rule)
+	}
+
+	station, err := p.db.LoadStation(rule.StationID)
+	if err != nil {
+		return fmt.Errorf("failed to load station: %w", err)
+	}
+	sensor := mqtt.BinarySensor{
+		Name:                alertDisplayName(rule.Name),
+		UniqueID:            alertNodeID(rule.StationID) + "_" + rule.Name,
+		StateTopic:          p.stateTopic(rule),
+		PayloadOn:           mqtt.StateOn,
+		PayloadOff:          mqtt.StateOff,
+		DeviceClass:         alertDeviceClass(rule),
+		AvailabilityTopic:   p.topicPrefix + "/status",
+		JSONAttributesTopic: p.attributesTopic(rule),
+		Device: mqtt.Device{
+			Identifiers:  []string{alertNodeID(rule.StationID)},
+			Name:         stationDisplayName(&station),
+			Manufacturer: "WeatherMaestro",
+			Model:        station.Model,
+		},
+	}
+	msg, err := sensor.DiscoveryMessage(p.configTopic(rule))
+	if err != nil {
+		return err
+	}
+	if err := p.client.Publish(ctx, msg); err != nil {
+		return err
+	}
+	return p.PublishState(ctx, rule)
+}
+
+// Remove deletes the binary sensor of a rule from Home Assistant and
+// clears its retained state
+func (p *alertPublisher) Remove(ctx context.Context, rule models.AlertRule) error {
+	if p == nil {
+		return nil
+	}
+	for _, topic := range []string{p.configTopic(rule), p.stateTopic(rule), p.attributesTopic(rule)} {
+		if err := p.client.Publish(ctx, mqtt.RemovalMessage(topic)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishState publishes the ON/OFF state and attributes of a rule
+func (p *alertPublisher) PublishState(ctx context.Context, rule models.AlertRule) error {
+	if p == nil {
+		return nil
+	}
+	attributes, err := json.Marshal(map[string]interface{}{
+		"sensor_type":  rule.SensorType,
+		"operator":     rule.Operator,
+		"threshold":    rule.Threshold,
+		"last_value":   rule.LastValue,
+		"active_since": rule.ActiveSince,
+		"evaluated_at": rule.EvaluatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.client.Publish(ctx, mqtt.StateMessage(p.stateTopic(rule), rule.Active)); err != nil {
+		return err
+	}
+	return p.client.Publish(ctx, mqtt.Message{Topic: p.attributesTopic(rule), Payload: attributes, Retain: true})
+}
+
+// PublishStation publishes a retained payload below the topic of a
+// station, e.g. weathermaestro/<stationId>/irrigation
+func (p *alertPublisher) PublishStation(ctx context.Context, stationID uuid.UUID, name string, payload []byte) error {
+	if p == nil {
+		return nil
+	}
+	topic := fmt.Sprintf("%s/%s/%s", p.topicPrefix, stationID, name)
+	return p.client.Publish(ctx, mqtt.Message{Topic: topic, Payload: payload, Retain: true})
+}
+
+// Listener returns the ingest listener publishing changed alert states
+func (p *alertPublisher) Listener() ingest.AlertListener {
+	return func(ctx context.Context, rule models.AlertRule) {
+		if err := p.PublishState(ctx, rule); err != nil {
+			log.Printf("❌ Failed to publish state of alert %s: %v", rule.Name, err)
+		}
+	}
+}
+
+// AnnounceAll publishes the discovery configs and states of all rules, so
+// Home Assistant picks them up after a restart of either side
+func (p *alertPublisher) AnnounceAll(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	rules, err := p.db.GetAlertRules(ctx, nil)
+	if err != nil {
+		log.Printf("❌ Failed to load alert rules: %v", err)
+		return
+	}
+	for _, rule := range rules {
+		if err := p.Announce(ctx, rule); err != nil {
+			log.Printf("❌ Failed to announce alert %s: %v", rule.Name, err)
+			return
+		}
+	}
+	log.Printf("✓ Announced %d alerts to Home Assistant", len(rules))
+}
+
+// Close marks the alerts unavailable and disconnects from the broker
+func (p *alertPublisher) Close() {
+	if p == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	offline := mqtt.Message{Topic: p.topicPrefix + "/status", Payload: []byte(mqtt.Offline), Retain: true}
+	if err := p.client.Publish(ctx, offline); err != nil {
+		log.Printf("⚠ Failed to publish offline status: %v", err)
+	}
+	p.client.Close()
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/upload"
+)
+
+// restoreBatchSize is the number of readings inserted per batch on restore
+const restoreBatchSize = 10000
+
+// errArchiveNotConfigured is returned when ARCHIVE_S3_BUCKET is not set
+var errArchiveNotConfigured = errors.New("archive storage is not configured, set ARCHIVE_S3_BUCKET")
+
+// archiveRestoreParams are the parameters of restore jobs
+type archiveRestoreParams struct {
+	Month string `json:"month"`
+}
+
+// archiveService moves months of readings older than ARCHIVE_AFTER_MONTHS
+// to an S3-compatible bucket and restores them on request. Months are
+// stored as gzip compressed JSON lines, the format of the readings in
+// backups, rather than Parquet, so they can be restored and read without
+// further dependencies. The daily rollups stay in ClickHouse, so daily and
+// coarser charts keep covering archived months. Objects are streamed
+// through temporary files, so months of any size fit into memory.
+type archiveService struct {
+	db     *database.DatabaseManager
+	store  upload.ObjectStore
+	prefix string
+	months int
+
+	// mu serialises archive runs and restores of the same months
+	mu sync.Mutex
+}
+
+// newArchiveService creates an archive service configured from the
+// environment
+func newArchiveService(dbManager *database.DatabaseManager) (*archiveService, error) {
+	target := upload.Target{
+		Name:      "archive",
+		Type:      upload.TypeS3,
+		Endpoint:  getEnv("ARCHIVE_S3_ENDPOINT", ""),
+		Bucket:    getEnv("ARCHIVE_S3_BUCKET", ""),
+		Region:    getEnv("ARCHIVE_S3_REGION", ""),
+		AccessKey: getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		SecretKey: getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+	}
+	if target.Bucket == "" {
+		return nil, errArchiveNotConfigured
+	}
+	store, err := upload.NewObjectStore(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive storage: %w", err)
+	}
+
+	months := getEnvInt("ARCHIVE_AFTER_MONTHS", 12)
+	if months < 1 {
+		return nil, fmt.Errorf("ARCHIVE_AFTER_MONTHS must be at least 1, got %d", months)
+	}
+	return &archiveService{
+		db:     dbManager,
+		store:  store,
+		prefix: getEnv("ARCHIVE_S3_PREFIX", "readings"),
+		months: months,
+	}, nil
+}
+
+// Archive moves all months before the cutoff that are neither archived
+// nor restored to the bucket. progress may be nil.
+func (s *archiveService) Archive(ctx context.Context, progress *jobs.Progress) ([]models.ReadingArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	months, err := s.db.ArchivableMonths(ctx, models.ArchiveCutoff(time.Now(), s.months))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recoverPending(ctx); err != nil {
+		return nil, err
+	}
+	existing, err := s.db.GetReadingArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archived := make(map[time.Time]*time.Time, len(existing))
+	for _, a := range existing {
+		archived[a.Month] = a.RestoredAt
+	}
+
+	var due []time.Time
+	for _, month := range months {
+		restoredAt, ok := archived[month]
+		if !ok {
+			due = append(due, month)
+		} else if restoredAt == nil {
+			// Readings imported after the archive run; archiving again
+			// would replace the archived readings
+			log.Printf("⚠ Month %s is archived but has readings again, skipping it", month.Format("2006-01"))
+		}
+	}
+	if progress != nil {
+		progress.SetTotal(len(due))
+	}
+
+	var result []models.ReadingArchive
+	for _, month := range due {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		a, err := s.archiveMonth(ctx, month)
+		if err != nil {
+			return result, fmt.Errorf("failed to archive %s: %w", month.Format("2006-01"), err)
+		}
+		result = append(result, *a)
+		if progress != nil {
+			progress.Add(1)
+		}
+	}
+	return result, nil
+}
+
+// archiveMonth uploads the readings of a month, records the archive as
+// pending, drops the readings and completes the archive. The readings are
+// only dropped when the number of dropped readings matches the upload;
+// otherwise they are kept and the month is exported again by the next run.
+func (s *archiveService) archiveMonth(ctx context.Context, month time.Time) (*models.ReadingArchive, error) {
+	file, err := os.CreateTemp("", "archive-*.jsonl.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(file, hash)}
+	gz := gzip.NewWriter(counter)
+	n, err := s.db.WriteMonthReadingsJSON(ctx, month, gz)
+	if err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	a := &models.ReadingArchive{
+		Month:     month,
+		ObjectKey: models.ArchiveObjectKey(s.prefix, month),
+		Readings:  n,
+		Size:      counter.n,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Pending:   true,
+	}
+	if err := s.store.UploadStream(ctx, a.ObjectKey, file, a.Size, a.SHA256); err != nil {
+		return nil, fmt.Errorf("failed to upload: %w", err)
+	}
+	if err := s.db.SaveReadingArchive(ctx, a); err != nil {
+		return nil, err
+	}
+	if err := s.db.DropMonthReadings(ctx, month, n); err != nil {
+		if errors.Is(err, database.ErrArchiveChanged) {
+			if err := s.db.DeletePendingReadingArchive(ctx, month); err != nil {
+				log.Printf("❌ Failed to delete pending archive of %s: %v", month.Format("2006-01"), err)
+			}
+		}
+		return nil, err
+	}
+	if err := s.db.CompleteReadingArchive(ctx, month); err != nil {
+		return nil, fmt.Errorf("readings were moved to %s: %w", a.ObjectKey, err)
+	}
+	a.Pending = false
+	log.Printf("✓ Archived %d readings of %s to %s", n, month.Format("2006-01"), a.ObjectKey)
+	return a, nil
+}
+
+// recoverPending finishes archives interrupted while their readings were
+// dropped. Readings left in staging are moved back first. A month without
+// readings was dropped and its archive is completed; a month with all
+// archived readings was not and is exported again. Other months are left
+// pending to be checked by hand, as their readings are partly archived.
+func (s *archiveService) recoverPending(ctx context.Context) error {
+	archives, err := s.db.GetReadingArchives(ctx)
+	if err != nil {
+		return err
+	}
+	recovered := false
+	for _, a := range archives {
+		if !a.Pending {
+			continue
+		}
+		if !recovered {
+			if err := s.db.RecoverArchiveStaging(ctx); err != nil {
+				return err
+			}
+			recovered = true
+		}
+
+		count, err := s.db.CountMonthReadings(ctx, a.Month)
+		if err != nil {
+			return err
+		}
+		switch count {
+		case 0:
+			err = s.db.CompleteReadingArchive(ctx, a.Month)
+			log.Printf("✓ Completed interrupted archive of %s", a.Month.Format("2006-01"))
+		case a.Readings:
+			err = s.db.DeletePendingReadingArchive(ctx, a.Month)
+			log.Printf("⚠ Archive of %s was interrupted before its readings were dropped, exporting it again", a.Month.Format("2006-01"))
+		default:
+			log.Printf("⚠ Archive of %s is pending with %d of %d archived readings stored, check it by hand",
+				a.Month.Format("2006-01"), count, a.Readings)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Restore downloads an archived month, checks it against the recorded
+// checksum and stores its readings again. Months that have readings are
+// refused, so a restore never duplicates readings. Readings of sensors of
+// deleted users are skipped.
+func (s *archiveService) Restore(ctx context.Context, month time.Time) (*models.ReadingArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, err := s.db.GetReadingArchive(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+	if a.Pending {
+		return nil, fmt.Errorf("the archive of %s is pending, run the archive again first", month.Format("2006-01"))
+	}
+	if count, err := s.db.CountMonthReadings(ctx, month); err != nil {
+		return nil, err
+	} else if count > 0 {
+		return nil, fmt.Errorf("%s has %d readings, restoring would duplicate them", month.Format("2006-01"), count)
+	}
+
+	// The object is checked before any reading is stored, so it is
+	// downloaded to a temporary file first
+	file, err := s.download(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", a.ObjectKey, err)
+	}
+	defer gz.Close()
+
+	deleted, err := s.db.GetDeletedSensorIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := make(map[uuid.UUID]bool)
+	var restored, skipped int64
+	batch := make([]models.SensorReading, 0, restoreBatchSize)
+	flush := func() error {
+		if err := s.db.RestoreReadings(ctx, batch); err != nil {
+			return err
+		}
+		restored += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+	dec := json.NewDecoder(gz)
+	for {
+		var r models.SensorReading
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", a.ObjectKey, err)
+		}
+		if deleted[r.SensorID] {
+			skipped++
+			continue
+		}
+		sensors[r.SensorID] = true
+		batch = append(batch, r)
+		if len(batch) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	if restored+skipped != a.Readings {
+		return nil, fmt.Errorf("%s has %d readings, %d were archived", a.ObjectKey, restored+skipped, a.Readings)
+	}
+
+	// The rollups still count the readings, which were inserted again
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	for id := range sensors {
+		sensorIDs = append(sensorIDs, id)
+	}
+	if err := s.db.RecomputeDailyRollups(ctx, sensorIDs, month, month.AddDate(0, 1, -1)); err != nil {
+		return nil, err
+	}
+	if err := s.db.MarkReadingArchiveRestored(ctx, month); err != nil {
+		return nil, err
+	}
+	log.Printf("✓ Restored %d readings of %s", restored, month.Format("2006-01"))
+	if skipped > 0 {
+		log.Printf("  Skipped %d readings of deleted users", skipped)
+	}
+	return s.db.GetReadingArchive(ctx, month)
+}
+
+// download streams the object of an archive to a temporary file and checks
+// it against the recorded checksum. The file is positioned at its start.
+func (s *archiveService) download(ctx context.Context, a *models.ReadingArchive) (*os.File, error) {
+	body, err := s.store.Open(ctx, a.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "archive-*.jsonl.gz")
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), body)
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != a.SHA256 {
+		err = fmt.Errorf("%s does not match its checksum", a.ObjectKey)
+	} else if err != nil {
+		err = fmt.Errorf("failed to download %s: %w", a.ObjectKey, err)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// jobHandler runs archive runs as background jobs
+func (s *archiveService) jobHandler() jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		archived, err := s.Archive(ctx, progress)
+		if err != nil {
+			return err
+		}
+		log.Printf("✓ Archive run finished, %d months archived", len(archived))
+		return nil
+	}
+}
+
+// restoreJobHandler runs restores as background jobs
+func (s *archiveService) restoreJobHandler() jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		var params archiveRestoreParams
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return fmt.Errorf("invalid params: %w", err)
+		}
+		month, err := models.ParseArchiveMonth(params.Month)
+		if err != nil {
+			return err
+		}
+		_, err = s.Restore(ctx, month)
+		return err
+	}
+}
+
+// archivedMonths returns the archived months that are not restored; their
+// readings are only in the bucket
+func archivedMonths(ctx context.Context, dbManager *database.DatabaseManager) ([]time.Time, error) {
+	archives, err := dbManager.GetReadingArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var months []time.Time
+	for _, a := range archives {
+		if a.RestoredAt == nil {
+			months = append(months, a.Month)
+		}
+	}
+	return months, nil
+}
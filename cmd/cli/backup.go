@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/backup"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/upload"
+)
+
+// Archive entries of the readings and their daily checksums
+const (
+	backupReadingsEntry  = "clickhouse/sensor_readings.jsonl"
+	backupChecksumsEntry = "checksums/readings.jsonl"
+)
+
+// backupResult describes a written backup
+type backupResult struct {
+	File     string           `json:"file"`
+	Size     int64            `json:"size"`
+	Manifest *backup.Manifest `json:"manifest"`
+	Deleted  []string         `json:"deleted,omitempty"`
+}
+
+// backupService writes backups of all Postgres tables and the ClickHouse
+// readings to BACKUP_DIR, rotates them and uploads them to the targets of
+// BACKUP_UPLOAD_CONFIG.
+type backupService struct {
+	db         *database.DatabaseManager
+	dir        string
+	encryption backup.Encryption
+	retention  backup.Retention
+	syncers    []*upload.Syncer
+
+	// mu serialises backups so rotation and uploads don't race
+	mu sync.Mutex
+}
+
+// newBackupService creates a backup service configured from the environment
+func newBackupService(dbManager *database.DatabaseManager) (*backupService, error) {
+	s := &backupService{
+		db:  dbManager,
+		dir: getEnv("BACKUP_DIR", "data/backups"),
+		encryption: backup.Encryption{
+			Type:       getEnv("BACKUP_ENCRYPTION", backup.EncryptionNone),
+			KeyFile:    getEnv("BACKUP_GPG_KEY", ""),
+			Passphrase: getEnv("BACKUP_PASSPHRASE", ""),
+		},
+		retention: backup.Retention{
+			Daily:  getEnvInt("BACKUP_KEEP_DAILY", 7),
+			Weekly: getEnvInt("BACKUP_KEEP_WEEKLY", 4),
+		},
+	}
+	for _, recipient := range strings.Split(getEnv("BACKUP_AGE_RECIPIENTS", ""), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			s.encryption.Recipients = append(s.encryption.Recipients, recipient)
+		}
+	}
+
+	if err := s.encryption.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.retention.Validate(); err != nil {
+		return nil, err
+	}
+	if file := getEnv("BACKUP_UPLOAD_CONFIG", ""); file != "" {
+		targets, err := upload.LoadConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			s.syncers = append(s.syncers, upload.NewSyncer(target))
+		}
+	}
+	return s, nil
+}
+
+// Create writes a backup, deletes the backups the rotation expires and
+// uploads new backups. progress may be nil.
+func (s *backupService) Create(ctx context.Context, progress *jobs.Progress) (*backupResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables, err := s.db.BackupTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Taken before the sensors table, so readings of sensors created during
+	// the backup are left out of the checksums like in the archive
+	sensors, err := s.db.GetSensors(models.SensorQueryParams{})
+	if err != nil {
+		return nil, err
+	}
+	stationOf := make(map[uuid.UUID]uuid.UUID, len(sensors))
+	for _, sensor := range sensors {
+		stationOf[sensor.Sensor.ID] = sensor.Sensor.StationID
+	}
+	if progress != nil {
+		progress.SetTotal(len(tables) + 2)
+	}
+	step := func() {
+		if progress != nil {
+			progress.Add(1)
+		}
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// Written to a temporary file first, uploads skip .tmp files
+	now := time.Now().UTC()
+	name := backup.FileName(now, s.encryption)
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	enc, err := s.encryption.Encrypt(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+	w := backup.NewWriter(enc, now)
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err := w.Add("postgres/"+table+".jsonl", func(out io.Writer) (int64, error) {
+			return s.db.WriteTableJSON(ctx, table, out)
+		})
+		if err != nil {
+			return nil, err
+		}
+		step()
+	}
+	checksums := models.NewReadingChecksummer()
+	err = w.Add(backupReadingsEntry, func(out io.Writer) (int64, error) {
+		return s.db.WriteReadingsJSON(ctx, out, func(r models.SensorReading) {
+			if stationID, ok := stationOf[r.SensorID]; ok {
+				checksums.Add(stationID, r)
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = w.Add(backupChecksumsEntry, func(out io.Writer) (int64, error) {
+		return writeJSONLines(out, checksums.Checksums())
+	})
+	if err != nil {
+		return nil, err
+	}
+	step()
+
+	manifest, err := w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish backup: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(s.dir, name)
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+	result := &backupResult{File: file, Size: info.Size(), Manifest: manifest}
+
+	if result.Deleted, err = s.rotate(); err != nil {
+		log.Printf("⚠ Failed to rotate backups: %v", err)
+	}
+	if err := uploadDir(ctx, s.syncers, s.dir); err != nil {
+		return result, err
+	}
+	step()
+	return result, nil
+}
+
+// rotate deletes the local backups expired by the retention
+func (s *backupService) rotate() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[time.Time]string)
+	var created []time.Time
+	for _, entry := range entries {
+		if t, ok := backup.ParseFileName(entry.Name()); ok && entry.Type().IsRegular() {
+			files[t] = entry.Name()
+			created = append(created, t)
+		}
+	}
+
+	var deleted []string
+	for _, t := range s.retention.Expired(created) {
+		if err := os.Remove(filepath.Join(s.dir, files[t])); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, files[t])
+	}
+	return deleted, nil
+}
+
+// jobHandler runs backups as background jobs
+func (s *backupService) jobHandler() jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		result, err := s.Create(ctx, progress)
+		if err != nil {
+			return err
+		}
+		log.Printf("✓ Backup %s written (%d bytes, %d deleted by rotation)", result.File, result.Size, len(result.Deleted))
+		return nil
+	}
+}
+
+// verifyBackup decrypts a backup into a temporary file and checks that all
+// entries match the manifest
+func verifyBackup(file string, encryption backup.Encryption) (*backup.Manifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	encryption.Type = backup.EncryptionOf(file)
+	r, err := encryption.Decrypt(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+	}
+
+	// Zip archives are read from the end, so the plain archive is needed
+	tmp, err := os.CreateTemp("", "weathermaestro-verify-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+	}
+	manifest, err := backup.Verify(tmp, size)
+	if err != nil {
+		return manifest, err
+	}
+	return manifest, verifyReadingChecksums(tmp, size)
+}
+
+// writeJSONLines writes one JSON document per line
+func writeJSONLines[T any](w io.Writer, values []T) (int64, error) {
+	enc := json.NewEncoder(w)
+	for i, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return int64(i), err
+		}
+	}
+	return int64(len(values)), nil
+}
+
+// readJSONLines calls fn with every line of an archive entry decoded into T
+func readJSONLines[T any](r io.ReaderAt, size int64, name string, fn func(T)) error {
+	rc, err := backup.OpenEntry(r, size, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	dec := json.NewDecoder(rc)
+	for {
+		var v T
+		if err := dec.Decode(&v); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fn(v)
+	}
+}
+
+// verifyReadingChecksums recomputes the daily checksums from the readings of
+// an archive and compares them with the checksums recorded at backup time.
+// Archives written before checksums were added are skipped.
+func verifyReadingChecksums(r io.ReaderAt, size int64) error {
+	rc, err := backup.OpenEntry(r, size, backupChecksumsEntry)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rc.Close()
+
+	stationOf := make(map[uuid.UUID]uuid.UUID)
+	err = readJSONLines(r, size, "postgres/sensors.jsonl", func(s models.Sensor) {
+		stationOf[s.ID] = s.StationID
+	})
+	if err != nil {
+		return err
+	}
+
+	checksums := models.NewReadingChecksummer()
+	err = readJSONLines(r, size, backupReadingsEntry, func(reading models.SensorReading) {
+		if stationID, ok := stationOf[reading.SensorID]; ok {
+			checksums.Add(stationID, reading)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	recorded := make(map[string]models.ReadingChecksum)
+	err = readJSONLines(r, size, backupChecksumsEntry, func(c models.ReadingChecksum) {
+		recorded[c.StationID.String()+"/"+c.Day] = c
+	})
+	if err != nil {
+		return err
+	}
+
+	computed := checksums.Checksums()
+	for _, c := range computed {
+		key := c.StationID.String() + "/" + c.Day
+		if recorded[key] != c {
+			return fmt.Errorf("readings of station %s on %s do not match their checksum", c.StationID, c.Day)
+		}
+		delete(recorded, key)
+	}
+	for _, c := range recorded {
+		return fmt.Errorf("readings of station %s on %s are missing", c.StationID, c.Day)
+	}
+	return nil
+}
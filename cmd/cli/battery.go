@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// batteryRater aggregates the battery sensors of stations into one status
+// by the "battery" thresholds of their config
+type batteryRater struct {
+	db *database.DatabaseManager
+	// view applies the privacy settings to public responses; nil for
+	// alerts
+	view *privacyView
+}
+
+func newBatteryRater(dbManager *database.DatabaseManager) *batteryRater {
+	return &batteryRater{db: dbManager}
+}
+
+// batteryPolicy returns the thresholds of a station config. Invalid
+// settings are logged and replaced by the defaults.
+func batteryPolicy(stationID uuid.UUID, config map[string]interface{}) models.BatteryPolicy {
+	policy, err := models.ParseBatteryPolicy(config)
+	if err != nil {
+		log.Printf("⚠ Station %s: %v", stationID, err)
+		policy, _ = models.ParseBatteryPolicy(nil)
+	}
+	return policy
+}
+
+// batterySensors returns the enabled battery sensors with their latest
+// reading, of one station or all stations if stationID is nil
+func (b *batteryRater) batterySensors(stationID *uuid.UUID) ([]models.SensorWithLatestReading, error) {
+	enabled := true
+	sensors, err := b.db.GetSensors(models.SensorQueryParams{
+		StationID:     stationID,
+		SensorType:    models.SensorTypeBattery,
+		Enabled:       &enabled,
+		IncludeLatest: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.view.filterSensors(sensors), nil
+}
+
+// StationBattery returns the battery status of a station. Readings that
+// are newer than the stored ones replace them, so a batch is rated before
+// it is queryable.
+func (b *batteryRater) StationBattery(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (*models.StationBattery, error) {
+	station, err := b.db.LoadStation(stationID)
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := b.batterySensors(&stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sensors {
+		for _, r := range readings {
+			latest := sensors[i].LatestReading
+			if r.SensorID == sensors[i].Sensor.ID && (latest == nil || !r.DateUTC.Before(latest.DateUTC)) {
+				sensors[i].LatestReading = &r
+			}
+		}
+	}
+	return models.AggregateBattery(batteryPolicy(stationID, station.Config), sensors), nil
+}
+
+// BatterySeverity rates the battery status of a station for alert rules
+func (b *batteryRater) BatterySeverity(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (int, error) {
+	battery, err := b.StationBattery(ctx, stationID, readings)
+	if err != nil {
+		return 0, err
+	}
+	return battery.Severity(), nil
+}
+
+// StationBatteries returns the battery status of all stations with
+// battery sensors
+func (b *batteryRater) StationBatteries(ctx context.Context) (map[uuid.UUID]*models.StationBattery, error) {
+	stations, err := b.db.LoadStations()
+	if err != nil {
+		return nil, err
+	}
+	sensors, err := b.batterySensors(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byStation := make(map[uuid.UUID][]models.SensorWithLatestReading)
+	for _, s := range sensors {
+		byStation[s.Sensor.StationID] = append(byStation[s.Sensor.StationID], s)
+	}
+	batteries := make(map[uuid.UUID]*models.StationBattery)
+	for _, station := range stations {
+		if battery := models.AggregateBattery(batteryPolicy(station.ID, station.Config), byStation[station.ID]); battery != nil {
+			batteries[station.ID] = battery
+		}
+	}
+	return batteries, nil
+}
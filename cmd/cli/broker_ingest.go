@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/broker"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// brokerIngestSource names the broker in logs, as messages have no source
+// address
+const brokerIngestSource = "broker"
+
+// brokerIngestRetryDelay is the delay before a failed subscription is
+// renewed
+const brokerIngestRetryDelay = 10 * time.Second
+
+// brokerIngest subscribes to a NATS subject or Kafka topic of JSON readings
+// and stores them like pushes to the generic webhook. Each message is a
+// payload of the generic webhook.
+type brokerIngest struct {
+	rm         *RouteManager
+	subscriber broker.Subscriber
+	pusher     pusher.Pusher
+	decoder    pusher.BodyDecoder
+	subject    string
+	group      string
+
+	cancel   context.CancelFunc
+	doneChan chan struct{}
+}
+
+// newBrokerIngest creates a consumer configured from the environment. It
+// returns nil if INGEST_BROKER is not set.
+func newBrokerIngest(rm *RouteManager) (*brokerIngest, error) {
+	brokerType := getEnv("INGEST_BROKER", "")
+	if brokerType == "" {
+		return nil, nil
+	}
+	subject := getEnv("INGEST_BROKER_SUBJECT", "")
+	if subject == "" {
+		return nil, errors.New("INGEST_BROKER_SUBJECT is required")
+	}
+
+	p, ok := rm.registryManager.PusherRegistry.Get("Generic")
+	if !ok {
+		return nil, errors.New("generic pusher not registered")
+	}
+	decoder, ok := p.(pusher.BodyDecoder)
+	if !ok {
+		return nil, fmt.Errorf("pusher %s does not decode JSON", p.GetStationType())
+	}
+
+	subscriber, err := broker.NewSubscriber(broker.Config{
+		Type:     brokerType,
+		URL:      getEnv("INGEST_BROKER_URL", ""),
+		Username: getEnv("INGEST_BROKER_USERNAME", ""),
+		Password: getEnv("INGEST_BROKER_PASSWORD", ""),
+		Token:    getEnv("INGEST_BROKER_TOKEN", ""),
+		Name:     "weathermaestro-ingest",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &brokerIngest{
+		rm:         rm,
+		subscriber: subscriber,
+		pusher:     p,
+		decoder:    decoder,
+		subject:    subject,
+		group:      getEnv("INGEST_BROKER_GROUP", "weathermaestro"),
+		doneChan:   make(chan struct{}),
+	}, nil
+}
+
+// Start begins consuming in the background
+func (b *brokerIngest) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	go b.run(ctx)
+	log.Printf("✓ Broker ingest consumer started (%s)", b.subject)
+}
+
+// Stop ends the subscription and waits for the current message to be
+// stored
+func (b *brokerIngest) Stop() {
+	b.cancel()
+	<-b.doneChan
+	b.subscriber.Close()
+}
+
+// run keeps the subscription alive until the context is done
+func (b *brokerIngest) run(ctx context.Context) {
+	defer close(b.doneChan)
+
+	for {
+		if err := b.subscriber.Subscribe(ctx, b.subject, b.group, b.handle); err != nil {
+			log.Printf("❌ Broker ingest failed, retrying in %s: %v", brokerIngestRetryDelay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(brokerIngestRetryDelay):
+		}
+	}
+}
+
+// handle stores the readings of a message. Like pushes, messages are
+// queued first, so readings that could not be stored are replayed from the
+// ingest queue.
+func (b *brokerIngest) handle(msg broker.Message) {
+	receivedAt := time.Now().UTC()
+	params, err := b.decoder.DecodeBody(msg.Payload)
+	if err != nil {
+		log.Printf("❌ Rejected weather data from %s: %v", msg.Subject, err)
+		return
+	}
+
+	queue := b.rm.registryManager.IngestQueue
+	var queueID uint64
+	if queue != nil {
+		// Messages of the broker are trusted like signed pushes
+		id, err := queue.PutEntry(ingest.QueueEntry{
+			Source:      b.pusher.GetStationType(),
+			Payload:     params,
+			ReceivedAt:  receivedAt,
+			SourceIP:    brokerIngestSource,
+			Signed:      true,
+			Credentials: pusher.CredentialParamsOf(b.pusher),
+		})
+		if err != nil {
+			log.Printf("⚠ Failed to queue payload: %v", err)
+			queue = nil
+		}
+		queueID = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stationID, count, err := b.rm.processPush(ctx, b.pusher, params, receivedAt, brokerIngestSource)
+	if err != nil && !isPermanentPushError(err) && queue != nil {
+		log.Printf("⚠ Failed to store readings, payload queued for retry: %v", err)
+		return
+	}
+	if queue != nil {
+		if ackErr := queue.Ack(queueID); ackErr != nil {
+			log.Printf("❌ Failed to acknowledge queued payload: %v", ackErr)
+		}
+	}
+
+	if errors.Is(err, errStationPending) {
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to store weather data from %s: %v", msg.Subject, err)
+		return
+	}
+	log.Printf("✓ Consumed %d Weather readings for station %s from %s", count, stationID, msg.Subject)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const (
+	// buddyFillInterval is how often gaps are filled from buddy stations
+	buddyFillInterval = time.Minute
+
+	// buddyFillLookback limits how far back a gap is filled, e.g. after a
+	// buddy station was configured for a station offline for weeks
+	buddyFillLookback = 24 * time.Hour
+
+	// buddyFillLimit is the most buddy readings filled per sensor and run
+	buddyFillLimit = 10000
+)
+
+// buddyFiller fills the sensors of offline stations with the readings of
+// their buddy station, see models.BuddyPolicy. Filled readings are stored
+// as estimated; they skip the ingest pipeline, so they are neither
+// forwarded nor alerted on.
+type buddyFiller struct {
+	db *database.DatabaseManager
+	// warned holds the last logged config problems per station, so they
+	// are logged once rather than every run
+	warned map[uuid.UUID]string
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+func newBuddyFiller(dbManager *database.DatabaseManager) *buddyFiller {
+	return &buddyFiller{
+		db:       dbManager,
+		warned:   make(map[uuid.UUID]string),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins filling in the background
+func (f *buddyFiller) Start() {
+	go f.run()
+	log.Println("✓ Buddy station filler started")
+}
+
+// Stop halts the filler and waits for the current run to finish
+func (f *buddyFiller) Stop() {
+	close(f.stopChan)
+	<-f.doneChan
+}
+
+func (f *buddyFiller) run() {
+	defer close(f.doneChan)
+
+	ticker := time.NewTicker(buddyFillInterval)
+	defer ticker.Stop()
+
+	for {
+		f.fillAll(context.Background(), time.Now().UTC())
+
+		select {
+		case <-f.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fillAll fills the gaps of all stations with a buddy station
+func (f *buddyFiller) fillAll(ctx context.Context, now time.Time) {
+	stations, err := f.db.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to load stations: %v", err)
+		return
+	}
+
+	for i := range stations {
+		station := &stations[i]
+		policy, ok, err := models.ParseBuddyPolicy(station.Config)
+		if err != nil || !ok {
+			continue
+		}
+		if policy.StationID == station.ID {
+			f.warn(station.ID, "a station can't be its own buddy")
+			continue
+		}
+
+		filled, err := f.fillStation(ctx, station, policy, now)
+		if err != nil {
+			log.Printf("❌ Failed to fill station %s from buddy %s: %v", station.ID, policy.StationID, err)
+			continue
+		}
+		if filled > 0 {
+			log.Printf("✓ Filled %d readings of station %s from buddy %s", filled, station.ID, policy.StationID)
+		}
+	}
+}
+
+// fillStation stores estimated readings for the sensors of a station that
+// have no measured reading for the max gap, from the buddy readings after
+// the newest reading of each sensor. Sensors without any measured reading
+// aren't filled.
+func (f *buddyFiller) fillStation(ctx context.Context, station *models.StationData, policy models.BuddyPolicy, now time.Time) (int, error) {
+	targets, err := f.db.GetSensors(models.SensorQueryParams{StationID: &station.ID})
+	if err != nil {
+		return 0, err
+	}
+	sources, err := f.db.GetSensors(models.SensorQueryParams{StationID: &policy.StationID})
+	if err != nil {
+		return 0, err
+	}
+	pairs, problems := policy.Pairs(sensorsOf(targets), sensorsOf(sources))
+	f.warn(station.ID, strings.Join(problems, ", "))
+	if len(pairs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(pairs))
+	for i, pair := range pairs {
+		ids[i] = pair.Target.ID
+	}
+	states, err := f.db.GetFillStates(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	var estimates []models.SensorReading
+	for _, pair := range pairs {
+		state, ok := states[pair.Target.ID]
+		if !ok || state.LastMeasured.IsZero() || now.Sub(state.LastMeasured) < policy.MaxGap {
+			continue
+		}
+		since := state.LastMeasured
+		if state.LastEstimated.After(since) {
+			since = state.LastEstimated
+		}
+		if lookback := now.Add(-buddyFillLookback); since.Before(lookback) {
+			since = lookback
+		}
+
+		// Estimates of the buddy itself are not passed on
+		readings, err := f.db.GetMeasuredSensorReadings(pair.Source.ID, since.Add(time.Millisecond), now, buddyFillLimit)
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range readings {
+			estimate := pair.Estimate(r)
+			estimate.ReceivedAt = &now
+			estimates = append(estimates, estimate)
+		}
+	}
+	if err := f.db.StoreSensorReadingsBatch(ctx, estimates); err != nil {
+		return 0, err
+	}
+	return len(estimates), nil
+}
+
+// warn logs the config problems of a station when they changed since the
+// last run
+func (f *buddyFiller) warn(stationID uuid.UUID, problems string) {
+	if f.warned[stationID] == problems {
+		return
+	}
+	f.warned[stationID] = problems
+	if problems != "" {
+		log.Printf("⚠ Buddy station of station %s: %s", stationID, problems)
+	}
+}
+
+// sensorsOf returns the sensors without their latest readings
+func sensorsOf(sensors []models.SensorWithLatestReading) []models.Sensor {
+	result := make([]models.Sensor, len(sensors))
+	for i, s := range sensors {
+		result[i] = s.Sensor
+	}
+	return result
+}
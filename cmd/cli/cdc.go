@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/broker"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// cdcPageSize is the number of events published at once
+const cdcPageSize = 500
+
+// cdcReadingsPosition names the stored readings watermark of the publisher
+const cdcReadingsPosition = "readings"
+
+// cdcPublisher publishes the changes of readings, stations and sensors to
+// a NATS or Kafka broker. Station and sensor changes come from the outbox
+// the database triggers write, readings from the changes feed. Positions
+// only advance after the broker received the events, so every change is
+// delivered at least once.
+type cdcPublisher struct {
+	db        *database.DatabaseManager
+	publisher broker.Publisher
+	prefix    string
+	interval  time.Duration
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newCDCPublisher creates a publisher configured from the environment. It
+// returns nil if CDC_BROKER is not set.
+func newCDCPublisher(dbManager *database.DatabaseManager) (*cdcPublisher, error) {
+	brokerType := getEnv("CDC_BROKER", "")
+	if brokerType == "" {
+		return nil, nil
+	}
+	publisher, err := broker.NewPublisher(broker.Config{
+		Type:     brokerType,
+		URL:      getEnv("CDC_URL", ""),
+		Username: getEnv("CDC_USERNAME", ""),
+		Password: getEnv("CDC_PASSWORD", ""),
+		Token:    getEnv("CDC_TOKEN", ""),
+		Name:     "weathermaestro-cdc",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cdcPublisher{
+		db:        dbManager,
+		publisher: publisher,
+		prefix:    getEnv("CDC_SUBJECT_PREFIX", "weathermaestro"),
+		interval:  getEnvDuration("CDC_INTERVAL", 5*time.Second),
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+	}, nil
+}
+
+// Start begins publishing in the background
+func (p *cdcPublisher) Start() {
+	go p.run()
+	log.Printf("✓ Change data capture publisher started (%s.*)", p.prefix)
+}
+
+// Stop halts the publisher and waits for the current round to finish
+func (p *cdcPublisher) Stop() {
+	close(p.stopChan)
+	<-p.doneChan
+	p.publisher.Close()
+}
+
+func (p *cdcPublisher) run() {
+	defer close(p.doneChan)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx := context.Background()
+		if err := p.publishOutbox(ctx); err != nil {
+			log.Printf("❌ Failed to publish station and sensor changes: %v", err)
+		}
+		if err := p.publishReadings(ctx); err != nil {
+			log.Printf("❌ Failed to publish reading changes: %v", err)
+		}
+
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishOutbox publishes the outbox page by page and deletes the published
+// events
+func (p *cdcPublisher) publishOutbox(ctx context.Context) error {
+	for {
+		events, err := p.db.GetOutboxEvents(ctx, cdcPageSize)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		if err := p.publish(ctx, events); err != nil {
+			return err
+		}
+		if err := p.db.DeleteOutboxEvents(ctx, events[len(events)-1].OutboxID); err != nil {
+			return err
+		}
+		if len(events) < cdcPageSize {
+			return nil
+		}
+	}
+}
+
+// publishReadings publishes the readings stored after the stored watermark.
+// Without a stored watermark publishing starts with the readings stored
+// from now on.
+func (p *cdcPublisher) publishReadings(ctx context.Context) error {
+	settled := time.Now().Add(-changesSettleDelay)
+	position, ok, err := p.db.GetCDCPosition(ctx, cdcReadingsPosition)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		watermark, err := p.db.GetAllReadingsWatermark(ctx, settled)
+		if err != nil {
+			return err
+		}
+		log.Printf("✓ Publishing readings stored after %s", watermark)
+		return p.db.SetCDCPosition(ctx, cdcReadingsPosition, watermark.String())
+	}
+	watermark, err := models.ParseReadingWatermark(position)
+	if err != nil {
+		return err
+	}
+
+	for {
+		changes, err := p.db.GetAllReadingChanges(ctx, watermark, settled, cdcPageSize)
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		events := make([]models.ChangeEvent, 0, len(changes))
+		for _, c := range changes {
+			event, err := models.ReadingChangeEvent(c)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		if err := p.publish(ctx, events); err != nil {
+			return err
+		}
+
+		watermark = changes[len(changes)-1].Watermark()
+		if err := p.db.SetCDCPosition(ctx, cdcReadingsPosition, watermark.String()); err != nil {
+			return err
+		}
+		if len(changes) < cdcPageSize {
+			return nil
+		}
+	}
+}
+
+// publish sends events to the subject of their entity, e.g.
+// weathermaestro.reading
+func (p *cdcPublisher) publish(ctx context.Context, events []models.ChangeEvent) error {
+	msgs := make([]broker.Message, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, broker.Message{Subject: fmt.Sprintf("%s.%s", p.prefix, e.Entity), Key: e.Key, Payload: payload})
+	}
+	return p.publisher.Publish(ctx, msgs)
+}
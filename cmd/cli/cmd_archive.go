@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive old readings to S3-compatible storage",
+	Long: `Move months of readings older than ARCHIVE_AFTER_MONTHS to the bucket
+ARCHIVE_S3_BUCKET and restore archived months. Daily rollups are kept, so
+daily and coarser charts still cover archived months.`,
+}
+
+var archiveRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Archive all months before the cutoff",
+	RunE:  runArchiveRun,
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List archived months",
+	RunE:  runArchiveList,
+}
+
+var archiveRestoreCmd = &cobra.Command{
+	Use:   "restore <YYYY-MM>",
+	Short: "Restore the readings of an archived month",
+	Long: `Download an archived month, verify its checksum and store its readings
+again. Restored months are not archived again automatically.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runArchiveRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.AddCommand(archiveRunCmd)
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveRestoreCmd)
+}
+
+func runArchiveRun(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	service, err := newArchiveService(dbManager)
+	if err != nil {
+		return err
+	}
+	archived, err := service.Archive(cmd.Context(), nil)
+	for _, a := range archived {
+		fmt.Printf("✓ %s: %d readings archived to %s (%d bytes)\n", a.Month.Format("2006-01"), a.Readings, a.ObjectKey, a.Size)
+	}
+	if err == nil && len(archived) == 0 {
+		fmt.Println("✓ No months to archive")
+	}
+	return err
+}
+
+func runArchiveList(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	archives, err := dbManager.GetReadingArchives(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		fmt.Println("No archived months")
+		return nil
+	}
+	fmt.Printf("%-8s %12s %12s  %-20s  %s\n", "MONTH", "READINGS", "BYTES", "ARCHIVED", "OBJECT")
+	for _, a := range archives {
+		archived := a.ArchivedAt.Format("2006-01-02 15:04")
+		if a.Pending {
+			archived = "pending"
+		} else if a.RestoredAt != nil {
+			archived = "restored " + a.RestoredAt.Format("2006-01-02")
+		}
+		fmt.Printf("%-8s %12d %12d  %-20s  %s\n", a.Month.Format("2006-01"), a.Readings, a.Size, archived, a.ObjectKey)
+	}
+	return nil
+}
+
+func runArchiveRestore(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	month, err := models.ParseArchiveMonth(args[0])
+	if err != nil {
+		return err
+	}
+	service, err := newArchiveService(dbManager)
+	if err != nil {
+		return err
+	}
+	a, err := service.Restore(cmd.Context(), month)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Restored %d readings of %s\n", a.Readings, a.Month.Format("2006-01"))
+	return nil
+}
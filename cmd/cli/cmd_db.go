@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Maintain the databases",
+	Long: `Run the database maintenance the server schedules every
+DB_MAINTENANCE_INTERVAL and inspect the indexes it rebuilds.`,
+}
+
+var dbMaintainCmd = &cobra.Command{
+	Use:   "maintain",
+	Short: "Run the database maintenance",
+	Long: `Vacuum Postgres tables with many dead rows, analyze all tables, rebuild
+bloated indexes and merge the ClickHouse parts of finished months.
+Without --tasks the tasks of DB_MAINTENANCE_TASKS run.`,
+	RunE: runDBMaintain,
+}
+
+var dbIndexesCmd = &cobra.Command{
+	Use:   "indexes",
+	Short: "List indexes with their estimated bloat",
+	RunE:  runDBIndexes,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbMaintainCmd)
+	dbCmd.AddCommand(dbIndexesCmd)
+
+	dbMaintainCmd.Flags().String("tasks", "", "comma separated tasks: vacuum, analyze, reindex, merge")
+}
+
+func runDBMaintain(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	maintenance, err := newDBMaintenance(dbManager)
+	if err != nil {
+		return err
+	}
+	tasks := maintenance.tasks
+	if value, _ := cmd.Flags().GetString("tasks"); value != "" {
+		if tasks, err = models.ParseDBMaintenanceTasks(value); err != nil {
+			return err
+		}
+	}
+	if err := maintenance.Run(cmd.Context(), tasks, nil); err != nil {
+		return err
+	}
+	fmt.Println("✓ Database maintenance finished")
+	return nil
+}
+
+func runDBIndexes(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	indexes, err := dbManager.GetIndexStats(cmd.Context())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-48s %-28s %12s %12s %7s\n", "INDEX", "TABLE", "BYTES", "ROWS", "BLOAT")
+	for _, index := range indexes {
+		marker := ""
+		if index.Bytes >= reindexMinBytes && index.Bloat() >= reindexMinBloat {
+			marker = "  rebuilt by reindex"
+		}
+		fmt.Printf("%-48s %-28s %12d %12d %6.1fx%s\n", index.Name, index.Table, index.Bytes, index.Rows, index.Bloat(), marker)
+	}
+	return nil
+}
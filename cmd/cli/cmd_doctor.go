@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+// doctorMaxListed limits the number of problems printed per check
+const doctorMaxListed = 20
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check stored data for consistency problems",
+	Long: `Scan for readings of deleted sensors, sensors without readings, stations
+without sensors, duplicated readings, daily rollups that drifted from the raw
+readings and invalid station configs.
+
+With --fix, readings of deleted sensors and duplicates with identical values
+are deleted and drifted rollups are rebuilt. Everything else is only reported.`,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool("fix", false, "repair problems that can be fixed safely")
+	doctorCmd.Flags().Duration("since", 0, "only compare rollups of this recent period (0 = all)")
+	doctorCmd.Flags().Int("limit", 10000, "max number of duplicated timestamps handled per run")
+}
+
+// doctor runs the consistency checks and counts the remaining problems
+type doctor struct {
+	db       *database.DatabaseManager
+	fix      bool
+	problems int
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	d := &doctor{db: cmd.Context().Value("dbManager").(*database.DatabaseManager)}
+	d.fix, _ = cmd.Flags().GetBool("fix")
+	since, _ := cmd.Flags().GetDuration("since")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	var sinceTime time.Time
+	if since > 0 {
+		sinceTime = time.Now().Add(-since)
+	}
+
+	ctx := cmd.Context()
+	checks := []struct {
+		name string
+		run  func(context.Context) error
+	}{
+		{"Readings of deleted sensors", d.checkOrphanedReadings},
+		{"Sensors without readings", d.checkSensorsWithoutReadings},
+		{"Stations without sensors", d.checkStationsWithoutSensors},
+		{"Duplicated readings", func(ctx context.Context) error { return d.checkDuplicateReadings(ctx, limit) }},
+		{"Rollup drift", func(ctx context.Context) error { return d.checkRollupDrift(ctx, sinceTime) }},
+		{"Station configs", d.checkStationConfigs},
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("WeatherMaestro Doctor")
+	fmt.Println(strings.Repeat("=", 80))
+
+	for _, check := range checks {
+		fmt.Printf("\n%s\n", check.name)
+		if err := check.run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(check.name), err)
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80) + "\n")
+
+	if d.problems > 0 {
+		if d.fix {
+			return fmt.Errorf("%d problems remain", d.problems)
+		}
+		return fmt.Errorf("%d problems found, run with --fix to repair what can be repaired safely", d.problems)
+	}
+	fmt.Println("✓ No problems found")
+	return nil
+}
+
+// report prints a problem line, up to doctorMaxListed per check
+func report(index int, format string, args ...interface{}) {
+	if index < doctorMaxListed {
+		fmt.Printf("  ⚠ "+format+"\n", args...)
+	} else if index == doctorMaxListed {
+		fmt.Println("  ...")
+	}
+}
+
+func (d *doctor) checkOrphanedReadings(ctx context.Context) error {
+	orphaned, err := d.db.FindOrphanedReadings(ctx)
+	if err != nil {
+		return err
+	}
+	if len(orphaned) == 0 {
+		fmt.Println("  ✓ OK")
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(orphaned))
+	for i, o := range orphaned {
+		report(i, "%d readings of deleted sensor %s", o.Count, o.SensorID)
+		ids = append(ids, o.SensorID)
+	}
+	if !d.fix {
+		d.problems += len(orphaned)
+		return nil
+	}
+
+	deleted, err := d.db.DeleteOrphanedReadings(ctx, ids)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ Deleted the readings of %d sensors\n", deleted)
+	d.problems += len(orphaned) - deleted
+	return nil
+}
+
+func (d *doctor) checkSensorsWithoutReadings(ctx context.Context) error {
+	// Sensors of a station that was just added may not have data yet
+	sensors, err := d.db.FindSensorsWithoutReadings(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if len(sensors) == 0 {
+		fmt.Println("  ✓ OK")
+		return nil
+	}
+	for i, s := range sensors {
+		report(i, "sensor %s (%s, %s) of station %s has no readings", s.ID, s.SensorType, s.Location, s.StationID)
+	}
+	d.problems += len(sensors)
+	return nil
+}
+
+func (d *doctor) checkStationsWithoutSensors(ctx context.Context) error {
+	stations, err := d.db.FindStationsWithoutSensors(ctx)
+	if err != nil {
+		return err
+	}
+	if len(stations) == 0 {
+		fmt.Println("  ✓ OK")
+		return nil
+	}
+	for i, st := range stations {
+		report(i, "station %s (%s, %s) has no sensors", st.ID, st.PassKey, st.ServiceName)
+	}
+	d.problems += len(stations)
+	return nil
+}
+
+func (d *doctor) checkDuplicateReadings(ctx context.Context, limit int) error {
+	duplicates, err := d.db.FindDuplicateReadings(ctx, limit)
+	if err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		fmt.Println("  ✓ OK")
+		return nil
+	}
+
+	conflicting := 0
+	for i, dup := range duplicates {
+		note := ""
+		if dup.Conflicting {
+			note = " with different values"
+			conflicting++
+		}
+		report(i, "%d readings of sensor %s at %s%s", len(dup.IDs), dup.SensorID, dup.DateUTC.Format(time.RFC3339), note)
+	}
+	if len(duplicates) == limit {
+		fmt.Printf("  Only the first %d duplicated timestamps were checked, run again for more\n", limit)
+	}
+	if !d.fix {
+		d.problems += len(duplicates)
+		return nil
+	}
+
+	deleted, err := d.db.DeleteDuplicateReadings(ctx, duplicates)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ Deleted %d duplicated readings\n", deleted)
+	if conflicting > 0 {
+		fmt.Printf("  ⚠ %d timestamps have readings with different values and were kept\n", conflicting)
+		d.problems += conflicting
+	}
+	return nil
+}
+
+func (d *doctor) checkRollupDrift(ctx context.Context, since time.Time) error {
+	found, err := d.db.FindRollupDrift(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	// The rollups of archived months outlive their readings on purpose
+	months, err := archivedMonths(ctx, d.db)
+	if err != nil {
+		return err
+	}
+	archived := make(map[time.Time]bool, len(months))
+	for _, month := range months {
+		archived[month] = true
+	}
+	var drifts []models.RollupDrift
+	for _, drift := range found {
+		day := drift.Day.UTC()
+		if !archived[time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)] {
+			drifts = append(drifts, drift)
+		}
+	}
+	if len(drifts) == 0 {
+		fmt.Println("  ✓ OK")
+		return nil
+	}
+
+	days := make(map[uuid.UUID][2]time.Time)
+	for i, drift := range drifts {
+		report(i, "sensor %s on %s: %d readings (sum %g), rollup %d (sum %g)",
+			drift.SensorID, drift.Day.Format(time.DateOnly), drift.RawCount, drift.RawSum, drift.RollupCount, drift.RollupSum)
+
+		r, ok := days[drift.SensorID]
+		if !ok || drift.Day.Before(r[0]) {
+			r[0] = drift.Day
+		}
+		if !ok || drift.Day.After(r[1]) {
+			r[1] = drift.Day
+		}
+		days[drift.SensorID] = r
+	}
+	if !d.fix {
+		d.problems += len(drifts)
+		return nil
+	}
+
+	for sensorID, r := range days {
+		for _, unarchived := range models.UnarchivedRanges(r[0], r[1], months) {
+			if err := d.db.RecomputeDailyRollups(ctx, []uuid.UUID{sensorID}, unarchived[0], unarchived[1]); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("  ✓ Rebuilt the rollups of %d sensors\n", len(days))
+	return nil
+}
+
+func (d *doctor) checkStationConfigs(ctx context.Context) error {
+	stations, err := d.db.GetStationsData()
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, station := range stations {
+		config, err := d.db.GetStationConfig(station.ID)
+		if err != nil {
+			report(count, "station %s (%s): %v", station.ID, station.PassKey, err)
+			count++
+			continue
+		}
+		for _, problem := range stationConfigProblems(station, config) {
+			report(count, "station %s (%s): %s", station.ID, station.PassKey, problem)
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Println("  ✓ OK")
+	}
+	d.problems += count
+	return nil
+}
+
+// stationConfigProblems validates the mode, service and config of a station
+func stationConfigProblems(station models.StationData, config map[string]interface{}) []string {
+	var problems []string
+
+	switch station.Mode {
+	case "push", "pull":
+		schema := providerConfigSchema(station.Mode, station.ServiceName)
+		if schema == nil {
+			problems = append(problems, fmt.Sprintf("unknown %s service %q", station.Mode, station.ServiceName))
+			break
+		}
+		for _, e := range schema.Validate(config) {
+			problems = append(problems, "config "+e.Message)
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown mode %q", station.Mode))
+	}
+
+	if _, err := models.ParsePrivacyPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.ParseSharingPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, err := models.ParseIrrigationPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, err := models.ParseHeatingPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := models.ValidateRequireNonce(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := ingest.ValidateForwardingConfig(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if buddy, _, err := models.ParseBuddyPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	} else if buddy.StationID == station.ID {
+		problems = append(problems, fmt.Sprintf("invalid %s config: a station can't be its own buddy", models.BuddyConfigKey))
+	}
+	if _, err := models.ParseBatteryPolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.StationIPAllowlist(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.ParseLuxConversion(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.ParseHighFrequencySensors(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.ParseRainGauge(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := models.ParseIndoorClimatePolicy(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, _, err := models.StationCoordinates(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, _, err := models.ReferenceStationID(config); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if tz, ok := config["timezone"]; ok {
+		name, _ := tz.(string)
+		if _, err := time.LoadLocation(name); err != nil || name == "" {
+			problems = append(problems, fmt.Sprintf("invalid timezone %v", tz))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage background jobs",
+	Long:  `List background jobs, show their progress and cancel them.`,
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List background jobs",
+	Long:  `Display the most recent background jobs, newest first.`,
+	RunE:  runJobsList,
+}
+
+var jobsStatusCmd = &cobra.Command{
+	Use:   "status <job-id>",
+	Short: "Show the status of a job",
+	Long:  `Display status, progress and parameters of a background job.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runJobsStatus,
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel <job-id>",
+	Short: "Cancel a job",
+	Long: `Cancel a pending job or stop a running one.
+A running job stops at its next progress update.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runJobsCancel,
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsStatusCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+
+	jobsListCmd.Flags().String("type", "", "only list jobs of this type")
+	jobsListCmd.Flags().String("status", "", "only list jobs in this status")
+	jobsListCmd.Flags().Int("limit", 20, "max number of jobs")
+}
+
+func runJobsList(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	params := models.JobQueryParams{}
+	params.Type, _ = cmd.Flags().GetString("type")
+	params.Status, _ = cmd.Flags().GetString("status")
+	params.Limit, _ = cmd.Flags().GetInt("limit")
+
+	jobs, err := dbManager.GetJobs(cmd.Context(), params)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jobs: %w", err)
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Background Jobs")
+	fmt.Println(strings.Repeat("=", 80))
+
+	for _, job := range jobs {
+		fmt.Printf("\n%s  %-12s %-10s %s  %s\n",
+			job.ID,
+			job.Type,
+			job.Status,
+			formatJobProgress(&job),
+			job.CreatedAt.Format("2006-01-02 15:04:05"),
+		)
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs found.")
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80) + "\n")
+
+	return nil
+}
+
+func runJobsStatus(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	job, err := dbManager.GetJob(cmd.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID:       %s\n", job.ID)
+	fmt.Printf("Type:     %s\n", job.Type)
+	fmt.Printf("Status:   %s\n", job.Status)
+	fmt.Printf("Progress: %s\n", formatJobProgress(job))
+	fmt.Printf("Created:  %s\n", job.CreatedAt.Format("2006-01-02 15:04:05"))
+	if job.StartedAt != nil {
+		fmt.Printf("Started:  %s\n", job.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+	if job.FinishedAt != nil {
+		fmt.Printf("Finished: %s\n", job.FinishedAt.Format("2006-01-02 15:04:05"))
+	}
+	if job.CancelRequested && !job.IsFinished() {
+		fmt.Println("Cancellation requested")
+	}
+	if job.Error != "" {
+		fmt.Printf("Error:    %s\n", job.Error)
+	}
+	fmt.Printf("Params:   %s\n", job.Params)
+
+	return nil
+}
+
+func runJobsCancel(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	job, err := dbManager.CancelJob(cmd.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrJobFinished) {
+			return fmt.Errorf("job %s already finished", id)
+		}
+		return err
+	}
+
+	if job.Status == models.JobStatusCancelled {
+		fmt.Printf("✓ Job %s cancelled\n", job.ID)
+	} else {
+		fmt.Printf("✓ Cancellation of job %s requested, it stops at its next progress update\n", job.ID)
+	}
+	return nil
+}
+
+// formatJobProgress formats the progress of a job as done/total and percent
+func formatJobProgress(job *models.Job) string {
+	if job.Total == 0 {
+		return fmt.Sprintf("%d", job.Done)
+	}
+	return fmt.Sprintf("%d/%d (%.0f%%)", job.Done, job.Total, float64(job.Done)/float64(job.Total)*100)
+}
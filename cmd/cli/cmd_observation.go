@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var observationCmd = &cobra.Command{
+	Use:   "observation",
+	Short: "Manage manual observations",
+	Long:  `Enter and list values observed by a person, like visibility or snowfall.`,
+}
+
+var observationAddCmd = &cobra.Command{
+	Use:   "add <station-id> <type=value>...",
+	Short: "Enter manual observations",
+	Long: `Store values observed at the same time, e.g.
+
+  weathermaestro observation add <station-id> Snowfall=12 CloudCover=8 --observer anna
+
+Observable types: ` + strings.Join(models.ObservationSensorTypes(), ", ") + `.
+Visibility is given in m, cloud cover in oktas (9 = sky obscured), snowfall
+in cm and present weather as WMO code 4677.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runObservationAdd,
+}
+
+var observationListCmd = &cobra.Command{
+	Use:   "list <station-id>",
+	Short: "List manual observations",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runObservationList,
+}
+
+func init() {
+	rootCmd.AddCommand(observationCmd)
+	observationCmd.AddCommand(observationAddCmd)
+	observationCmd.AddCommand(observationListCmd)
+
+	observationAddCmd.Flags().String("observer", os.Getenv("USER"), "name of the observer")
+	observationAddCmd.Flags().String("at", "", "observation time in RFC3339 (default: now)")
+	observationAddCmd.Flags().String("note", "", "remark on the observation")
+	observationListCmd.Flags().Int("days", 30, "number of days to list")
+}
+
+func runObservationAdd(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+
+	entry := models.ObservationEntry{Values: make(map[string]float64)}
+	for _, arg := range args[1:] {
+		sensorType, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid observation %q, expected type=value", arg)
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %w", sensorType, err)
+		}
+		entry.Values[sensorType] = value
+	}
+	if at, _ := cmd.Flags().GetString("at"); at != "" {
+		if entry.ObservedAt, err = time.Parse(time.RFC3339, at); err != nil {
+			return fmt.Errorf("invalid observation time: %w", err)
+		}
+	}
+	entry.Note, _ = cmd.Flags().GetString("note")
+	observer, _ := cmd.Flags().GetString("observer")
+
+	observations, err := entry.Observations(stationID, observer)
+	if err != nil {
+		return err
+	}
+	created, err := dbManager.CreateObservations(cmd.Context(), stationID, observations)
+	if err != nil {
+		return fmt.Errorf("failed to store observations: %w", err)
+	}
+
+	for _, o := range created {
+		fmt.Printf("✓ %s: %g %s\n", o.SensorType, o.Value, models.SensorTypeRegistry[o.SensorType].Unit)
+	}
+	return nil
+}
+
+func runObservationList(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+	days, _ := cmd.Flags().GetInt("days")
+
+	observations, err := dbManager.GetObservations(cmd.Context(), stationID, time.Now().AddDate(0, 0, -days), time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(observations) == 0 {
+		fmt.Println("No observations found.")
+		return nil
+	}
+
+	for _, o := range observations {
+		fmt.Printf("%s  %-15s %8g %-5s %s", o.ObservedAt.Local().Format("2006-01-02 15:04"), o.SensorType, o.Value,
+			models.SensorTypeRegistry[o.SensorType].Unit, o.Observer)
+		if o.Note != "" {
+			fmt.Printf("  %s", o.Note)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/upload"
+	"github.com/spf13/cobra"
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Generate a static website",
+	Long: `Render a static HTML site with current conditions, charts and monthly
+NOAA reports of all stations. The output can be served by any web server.
+With --interval the site is regenerated until the command is stopped.
+
+With --upload-config the generated files are uploaded to FTP, SFTP or
+S3-compatible targets after each run. Only changed files are uploaded again.`,
+	RunE: runPublish,
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+
+	publishCmd.Flags().String("out", "./public", "output directory")
+	publishCmd.Flags().String("title", "WeatherMaestro", "site title")
+	publishCmd.Flags().Duration("interval", 0, "regenerate the site in this interval (0 = run once)")
+	publishCmd.Flags().Int("noaa-months", 12, "number of months with NOAA reports")
+	publishCmd.Flags().String("upload-config", "", "JSON file with upload targets")
+	publishCmd.Flags().String("locale", "", "language of the site: en or de (default: DEFAULT_LOCALE or en)")
+}
+
+func runPublish(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	gen := &siteGenerator{dbManager: dbManager}
+	gen.outDir, _ = cmd.Flags().GetString("out")
+	gen.siteName, _ = cmd.Flags().GetString("title")
+	gen.noaaMonths, _ = cmd.Flags().GetInt("noaa-months")
+	interval, _ := cmd.Flags().GetDuration("interval")
+
+	if gen.noaaMonths < 0 {
+		return fmt.Errorf("noaa-months must not be negative")
+	}
+	locale, _ := cmd.Flags().GetString("locale")
+	var err error
+	if gen.locale, err = parseLocaleFlag(locale); err != nil {
+		return err
+	}
+
+	var syncers []*upload.Syncer
+	if file, _ := cmd.Flags().GetString("upload-config"); file != "" {
+		targets, err := upload.LoadConfig(file)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			syncers = append(syncers, upload.NewSyncer(target))
+		}
+	}
+
+	if interval <= 0 {
+		if err := gen.Generate(cmd.Context()); err != nil {
+			return fmt.Errorf("failed to generate site: %w", err)
+		}
+		fmt.Printf("✓ Site generated in %s\n", gen.outDir)
+		return uploadDir(cmd.Context(), syncers, gen.outDir)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("▶ Publishing site to %s every %s", gen.outDir, interval)
+	for {
+		start := time.Now()
+		if err := gen.Generate(cmd.Context()); err != nil {
+			log.Printf("❌ Failed to generate site: %v", err)
+		} else {
+			log.Printf("✓ Site generated in %s", time.Since(start).Round(time.Millisecond))
+			// Failed files are retried on the next run
+			uploadDir(cmd.Context(), syncers, gen.outDir)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-sigChan:
+			log.Println("Shutdown signal received")
+			return nil
+		}
+	}
+}
+
+// uploadDir syncs a directory to all upload targets. Every target is tried;
+// the error reports the targets with failed files.
+func uploadDir(ctx context.Context, syncers []*upload.Syncer, outDir string) error {
+	var failed []string
+	for _, syncer := range syncers {
+		report := syncer.Sync(ctx, outDir)
+		if err := report.Err(); err != nil {
+			log.Printf("❌ Upload to %s incomplete: %v", report.Target, err)
+			for _, f := range report.Failed[1:] {
+				log.Printf("   %s: %v", f.Name, f.Err)
+			}
+			failed = append(failed, report.Target)
+			continue
+		}
+		log.Printf("✓ Uploaded %d files to %s (%d unchanged) in %s",
+			report.Uploaded, report.Target, report.Skipped, report.Duration.Round(time.Millisecond))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("upload failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
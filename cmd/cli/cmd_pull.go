@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+	"github.com/sguter90/weathermaestro/pkg/puller/davis"
+	ecowittpull "github.com/sguter90/weathermaestro/pkg/puller/ecowitt"
+	"github.com/sguter90/weathermaestro/pkg/puller/email"
+	"github.com/sguter90/weathermaestro/pkg/puller/metar"
+	"github.com/spf13/cobra"
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Work with pulling providers",
+}
+
+var pullTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Pull once with a provider config without storing anything",
+	Long: `Validate a provider config and pull the sensors and latest readings once,
+e.g. to check credentials before creating the station:
+
+  weathermaestro pull test --provider netatmo --config netatmo.json
+
+The config file holds the station config as JSON. Nothing is written to the
+database; tokens refreshed by the provider are printed to update the file.`,
+	RunE: runPullTest,
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+	pullCmd.AddCommand(pullTestCmd)
+
+	pullTestCmd.Flags().String("provider", "", "provider type: netatmo, "+metar.ServiceName+", "+email.ServiceName+", "+davis.ServiceName+", "+ecowittpull.ServiceName)
+	pullTestCmd.Flags().String("config", "", "JSON file with the station config")
+	_ = pullTestCmd.MarkFlagRequired("provider")
+	_ = pullTestCmd.MarkFlagRequired("config")
+}
+
+func runPullTest(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+	provider, _ := cmd.Flags().GetString("provider")
+	path, _ := cmd.Flags().GetString("config")
+
+	registry := puller.NewPullerRegistry()
+	registerPuller(registry, provider, dbManager)
+	p, ok := registry.Get(provider)
+	if !ok {
+		return fmt.Errorf("unknown provider %q", provider)
+	}
+	tester, ok := p.(puller.Tester)
+	if !ok {
+		return fmt.Errorf("provider %s can't be tested", provider)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := p.ValidateConfig(config); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	fmt.Println("✓ Config is valid")
+
+	result, err := tester.Test(cmd.Context(), config)
+	if err != nil {
+		var reauth *puller.ReauthorizationError
+		if errors.As(err, &reauth) && reauth.URL != "" {
+			return fmt.Errorf("%w\nAuthorize again at %s", err, reauth.URL)
+		}
+		return err
+	}
+
+	remoteIDs := make([]string, 0, len(result.Sensors))
+	for remoteID := range result.Sensors {
+		remoteIDs = append(remoteIDs, remoteID)
+	}
+	sort.Strings(remoteIDs)
+
+	fmt.Printf("✓ Found %d sensors\n", len(remoteIDs))
+	fmt.Printf("%-40s %-24s %-16s %12s %-8s %s\n", "REMOTE ID", "TYPE", "LOCATION", "VALUE", "UNIT", "TIME")
+	for _, remoteID := range remoteIDs {
+		sensor := result.Sensors[remoteID]
+		readings := result.Readings[remoteID]
+		if len(readings) == 0 {
+			fmt.Printf("%-40s %-24s %-16s %12s\n", remoteID, sensor.SensorType, sensor.Location, "-")
+			continue
+		}
+		for _, reading := range readings {
+			fmt.Printf("%-40s %-24s %-16s %12.2f %-8s %s\n", remoteID, sensor.SensorType, sensor.Location,
+				reading.Value, reading.Unit, reading.DateUTC.Format("2006-01-02 15:04:05Z"))
+		}
+	}
+
+	if len(result.Config) > 0 {
+		updated, err := json.MarshalIndent(result.Config, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("⚠ The provider replaced these config values, update the config file:\n%s\n", updated)
+	}
+	return nil
+}
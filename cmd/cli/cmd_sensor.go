@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+var sensorCmd = &cobra.Command{
+	Use:   "sensor",
+	Short: "Manage sensors",
+	Long:  `Maintain the sensors of weather stations.`,
+}
+
+var sensorChangeTypeCmd = &cobra.Command{
+	Use:   "change-type <from> <to>",
+	Short: "Rename or merge a sensor type",
+	Long: `Change the sensor type of all sensors of type <from>, optionally limited to
+stations or sensors. A sensor colliding with an existing sensor of type <to> at
+the same station and location fails the change unless --merge is given; its
+readings, rollups and records are then moved into the existing sensor.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSensorChangeType,
+}
+
+func init() {
+	rootCmd.AddCommand(sensorCmd)
+	sensorCmd.AddCommand(sensorChangeTypeCmd)
+
+	sensorChangeTypeCmd.Flags().StringSlice("station", nil, "only change sensors of these station IDs")
+	sensorChangeTypeCmd.Flags().StringSlice("sensor", nil, "only change these sensor IDs")
+	sensorChangeTypeCmd.Flags().Bool("merge", false, "merge into existing sensors of the new type")
+	sensorChangeTypeCmd.Flags().Bool("dry-run", false, "show the changes without applying them")
+}
+
+func runSensorChangeType(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	change := models.SensorTypeChange{From: args[0], To: args[1]}
+	change.Merge, _ = cmd.Flags().GetBool("merge")
+	change.DryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	var err error
+	stations, _ := cmd.Flags().GetStringSlice("station")
+	if change.StationIDs, err = parseUUIDs(stations); err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+	sensors, _ := cmd.Flags().GetStringSlice("sensor")
+	if change.SensorIDs, err = parseUUIDs(sensors); err != nil {
+		return fmt.Errorf("invalid sensor ID: %w", err)
+	}
+
+	result, err := dbManager.ChangeSensorType(cmd.Context(), change)
+	if err != nil {
+		if errors.Is(err, database.ErrSensorTypeConflict) {
+			return fmt.Errorf("%w, use --merge to move its readings", err)
+		}
+		return err
+	}
+
+	rename, merge := "✓ Renamed", "✓ Merged"
+	if change.DryRun {
+		rename, merge = "Would rename", "Would merge"
+	}
+	for _, id := range result.Renamed {
+		fmt.Printf("%s sensor %s to %s\n", rename, id, change.To)
+	}
+	for _, m := range result.Merged {
+		fmt.Printf("%s sensor %s into %s (%d readings)\n", merge, m.SourceID, m.TargetID, m.Readings)
+	}
+	if len(result.Renamed) == 0 && len(result.Merged) == 0 {
+		fmt.Printf("No sensors of type %s found.\n", change.From)
+	}
+	return nil
+}
+
+// parseUUIDs parses a list of IDs
+func parseUUIDs(values []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(values))
+	for _, v := range values {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -8,26 +8,43 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
 	"github.com/sguter90/weathermaestro/pkg/puller"
+	"github.com/sguter90/weathermaestro/pkg/puller/davis"
+	ecowittpull "github.com/sguter90/weathermaestro/pkg/puller/ecowitt"
+	"github.com/sguter90/weathermaestro/pkg/puller/email"
+	"github.com/sguter90/weathermaestro/pkg/puller/metar"
 	"github.com/sguter90/weathermaestro/pkg/puller/netatmo"
 	"github.com/sguter90/weathermaestro/pkg/pusher"
 	"github.com/sguter90/weathermaestro/pkg/pusher/ecowitt"
+	"github.com/sguter90/weathermaestro/pkg/pusher/generic"
+	"github.com/sguter90/weathermaestro/pkg/pusher/wunderground"
 	"github.com/spf13/cobra"
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the WeatherMaestro server",
-	Long:  `Start the WeatherMaestro server to receive and manage weather data.`,
-	RunE:  runServe,
+	Long: `Start the WeatherMaestro server to receive and manage weather data.
+
+With --demo, the server runs in a new temporary schema and ClickHouse
+database seeded with two sample stations, 90 days of history, alert rules and
+a user whose password is logged. Scheduled jobs and connections to brokers,
+other instances and mail recipients are turned off. The schema and database
+are deleted on shutdown.`,
+	RunE: runServe,
 }
 
 func init() {
 	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().Bool("demo", false, "seed a temporary schema and database with sample data, deleted on shutdown")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -43,6 +60,14 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// Seed sample data in demo mode, whose schema was chosen on startup
+	if demo, _ := cmd.Flags().GetBool("demo"); demo {
+		defer dropDemo(dbManager)
+		if err := seedDemo(cmd.Context(), dbManager); err != nil {
+			return fmt.Errorf("failed to seed demo data: %w", err)
+		}
+	}
+
 	// Load stations from database
 	stations, err := dbManager.LoadStations()
 	if err != nil {
@@ -53,20 +78,136 @@ func runServe(cmd *cobra.Command, args []string) error {
 	pullerService := registryManager.PullerService
 	pullerService.Start()
 
+	// Start background jobs
+	jobRunner := registryManager.JobRunner
+	prepareJobs(cmd.Context(), dbManager)
+	jobRunner.Start()
+
+	// Queue automatic backups
+	var backups *jobScheduler
+	if interval := getEnvDuration("BACKUP_INTERVAL", 0); interval > 0 {
+		backups = newJobScheduler(dbManager, jobRunner, jobTypeBackup, interval)
+		backups.Start()
+	}
+
+	// Queue automatic archive runs
+	var archives *jobScheduler
+	if interval := getEnvDuration("ARCHIVE_INTERVAL", 0); interval > 0 && slices.Contains(jobRunner.Types(), jobTypeArchive) {
+		archives = newJobScheduler(dbManager, jobRunner, jobTypeArchive, interval)
+		archives.Start()
+	}
+
+	// Delete users whose deletion grace period has passed
+	var userPurge *jobScheduler
+	if interval := getEnvDuration("USER_PURGE_INTERVAL", time.Hour); interval > 0 {
+		userPurge = newJobScheduler(dbManager, jobRunner, jobTypeUserPurge, interval)
+		userPurge.Start()
+	}
+
+	// Queue database maintenance, optionally only in a nightly window
+	var dbMaintenance *jobScheduler
+	if interval := getEnvDuration("DB_MAINTENANCE_INTERVAL", 24*time.Hour); interval > 0 && slices.Contains(jobRunner.Types(), jobTypeDBMaintenance) {
+		if window, err := dbMaintenanceWindow(); err != nil {
+			log.Printf("❌ Database maintenance not scheduled: %v", err)
+		} else {
+			dbMaintenance = newJobScheduler(dbManager, jobRunner, jobTypeDBMaintenance, interval)
+			dbMaintenance.window = window
+			dbMaintenance.Start()
+		}
+	}
+
+	// Remind of due station maintenance
+	reminder := newMaintenanceReminder(dbManager, registryManager.Notifier)
+	reminder.Start()
+
+	// Announce alerts to Home Assistant
+	alertPublisher := registryManager.AlertPublisher
+	go alertPublisher.AnnounceAll(cmd.Context())
+
+	// Send the daily irrigation advice to controllers
+	var irrigation *irrigationPublisher
+	if registryManager.Features.Enabled(featureIrrigation) {
+		irrigation = newIrrigationPublisher(dbManager, alertPublisher)
+		irrigation.Start()
+	}
+
+	// Send the flow temperature of the heating curve to heat pumps
+	var heating *heatingPublisher
+	if registryManager.Features.Enabled(featureHeating) {
+		heating = newHeatingPublisher(dbManager, alertPublisher)
+		heating.Start()
+	}
+
+	// Fill the sensors of offline stations from their buddy stations
+	buddies := newBuddyFiller(dbManager)
+	buddies.Start()
+
+	// Delete expired idempotency keys and nonces of pushes
+	idempotency := newIdempotencyPruner(dbManager)
+	idempotency.Start()
+
+	// Publish reading, station and sensor changes to a broker
+	cdc, err := newCDCPublisher(dbManager)
+	if err != nil {
+		log.Printf("❌ Change data capture disabled: %v", err)
+	} else if cdc != nil {
+		cdc.Start()
+	}
+
 	// Setup Router
 	routeManager := NewRouteManager(dbManager, registryManager)
 	routeManager.Setup()
 
-	// Get server port
-	port := getEnv("SERVER_PORT", "8059")
-	addr := ":" + port
+	// Replay pushed payloads that could not be stored
+	var queueDrainer *ingest.Drainer
+	if queue := openIngestQueue(); queue != nil {
+		defer queue.Close()
+		registryManager.IngestQueue = queue
+		queueDrainer = ingest.NewDrainer(queue, routeManager.processQueueEntry, 1*time.Minute, getEnvInt("INGEST_QUEUE_MAX_ATTEMPTS", 1440))
+		queueDrainer.Start()
+	}
+
+	// Store readings streamed through a broker
+	consumer, err := newBrokerIngest(routeManager)
+	if err != nil {
+		log.Printf("❌ Broker ingest disabled: %v", err)
+	} else if consumer != nil {
+		consumer.Start()
+	}
+
+	// Resend readings buffered while the federated instance was unreachable
+	if registryManager.Federation != nil {
+		defer registryManager.Federation.Close()
+		registryManager.Federation.Start()
+	}
+
+	listeners, err := parseListeners(getEnv("SERVER_LISTENERS", ""), getEnv("SERVER_PORT", "8059"))
+	if err != nil {
+		return err
+	}
+
+	// Advertise the server on the local network
+	responders := startDiscovery(listeners, registryManager.PusherRegistry.All())
 
-	// Start server
-	server := &http.Server{
-		Handler:      routeManager.Router,
-		Addr:         addr,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	// Start servers
+	servers := make([]*http.Server, 0, len(listeners))
+	for _, l := range listeners {
+		servers = append(servers, &http.Server{
+			Handler:      routeManager.Handler(l.Routes),
+			Addr:         l.Addr,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		})
+	}
+
+	shutdown := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		for _, server := range servers {
+			if err := server.Shutdown(ctx); err != nil {
+				log.Printf("Server shutdown error: %v", err)
+			}
+		}
 	}
 
 	// Handle graceful shutdown
@@ -78,26 +219,78 @@ func runServe(cmd *cobra.Command, args []string) error {
 		log.Println("Shutdown signal received")
 
 		pullerService.Stop()
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := server.Shutdown(ctx); err != nil {
-			log.Printf("Server shutdown error: %v", err)
+		if backups != nil {
+			backups.Stop()
+		}
+		if archives != nil {
+			archives.Stop()
+		}
+		if userPurge != nil {
+			userPurge.Stop()
+		}
+		if dbMaintenance != nil {
+			dbMaintenance.Stop()
+		}
+		jobRunner.Stop()
+		reminder.Stop()
+		if irrigation != nil {
+			irrigation.Stop()
 		}
+		if heating != nil {
+			heating.Stop()
+		}
+		buddies.Stop()
+		idempotency.Stop()
+		if cdc != nil {
+			cdc.Stop()
+		}
+		alertPublisher.Close()
+		if consumer != nil {
+			consumer.Stop()
+		}
+		if queueDrainer != nil {
+			queueDrainer.Stop()
+		}
+		if registryManager.Federation != nil {
+			registryManager.Federation.Stop()
+		}
+		responders.Stop()
+
+		shutdown()
 	}()
 
-	log.Printf("Starting WeatherMaestro server on %s...", addr)
-	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return fmt.Errorf("failed to start server: %w", err)
+	errChan := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server *http.Server, routes string) {
+			log.Printf("Starting WeatherMaestro %s server on %s (%s routes)...", version, server.Addr, routes)
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errChan <- fmt.Errorf("failed to start server on %s: %w", server.Addr, err)
+				return
+			}
+			errChan <- nil
+		}(server, listeners[i].Routes)
 	}
 
-	return nil
+	// A listener that fails stops the others
+	var serveErr error
+	for range servers {
+		if err := <-errChan; err != nil && serveErr == nil {
+			serveErr = err
+			shutdown()
+		}
+	}
+
+	return serveErr
 }
 
 func registerPusher(registry *pusher.Registry, serviceName string) {
 	switch serviceName {
 	case "ecowitt":
 		registry.Register(&ecowitt.Pusher{})
+	case "generic":
+		registry.Register(&generic.Pusher{})
+	case wunderground.ServiceName:
+		registry.Register(&wunderground.Pusher{})
 		// case "ambient":
 		//     PusherRegistry.Register(&ambient.Pusher{})
 		// case "weatherflow":
@@ -109,5 +302,33 @@ func registerPuller(registry *puller.PullerRegistry, serviceName string, dbManag
 	switch serviceName {
 	case "netatmo":
 		registry.Register(netatmo.NewPuller(dbManager))
+	case metar.ServiceName:
+		registry.Register(metar.NewPuller(dbManager))
+	case email.ServiceName:
+		registry.Register(email.NewPuller(dbManager))
+	case davis.ServiceName:
+		registry.Register(davis.NewPuller(dbManager))
+	case ecowittpull.ServiceName:
+		registry.Register(ecowittpull.NewPuller(dbManager))
+	}
+}
+
+// providerConfigSchema returns the config schema of the pusher or puller of
+// a service, or nil if the service is unknown
+func providerConfigSchema(mode, serviceName string) *models.ConfigSchema {
+	switch mode {
+	case "push":
+		registry := pusher.NewRegistry()
+		registerPusher(registry, serviceName)
+		for _, p := range registry.All() {
+			return p.ConfigSchema()
+		}
+	case "pull":
+		registry := puller.NewPullerRegistry()
+		registerPuller(registry, serviceName, nil)
+		if p, ok := registry.Get(serviceName); ok {
+			return p.ConfigSchema()
+		}
 	}
+	return nil
 }
@@ -2,14 +2,19 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
 	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller/metar"
 	"github.com/spf13/cobra"
 )
 
@@ -40,11 +45,110 @@ var stationDeleteCmd = &cobra.Command{
 	RunE:  runStationDelete,
 }
 
+var stationConfigCmd = &cobra.Command{
+	Use:   "config <station-id> <key> [value]",
+	Short: "Show or change a station setting",
+	Long: `Show a single station config value or set it.
+Values are parsed as JSON when possible, e.g. "true" enables clock_correction.
+Credentials are masked unless --reveal is given.`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: runStationConfig,
+}
+
+var stationEncryptSecretsCmd = &cobra.Command{
+	Use:   "encrypt-secrets",
+	Short: "Encrypt stored station credentials",
+	Long: `Encrypt the plain text credentials in the config of all stations and
+the TOTP secrets of users with the key from SECRETS_KEY or SECRETS_KEY_FILE.`,
+	Args: cobra.NoArgs,
+	RunE: runStationEncryptSecrets,
+}
+
+var stationReferenceCmd = &cobra.Command{
+	Use:   "reference <station-id>",
+	Short: "Compare a station with a nearby official station",
+	Long: `Add the nearest airport reporting METAR observations as reference station
+and link it to a station. The airport is looked up by the latitude and
+longitude config of the station unless --icao is given. The reference station
+is pulled by the server after its next restart.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStationReference,
+}
+
+var stationPendingCmd = &cobra.Command{
+	Use:   "pending",
+	Short: "List stations awaiting approval",
+	Long: `Display the unknown stations that pushed data while STATION_REGISTRATION
+is manual-approval.`,
+	Args: cobra.NoArgs,
+	RunE: runStationPending,
+}
+
+var stationApproveCmd = &cobra.Command{
+	Use:   "approve <pass-key>",
+	Short: "Approve a pending station",
+	Long: `Create the station of a pending pass key. Its following pushes are stored.
+The config of --template is copied without its credentials.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStationApprove,
+}
+
+var stationRejectCmd = &cobra.Command{
+	Use:   "reject <pass-key>",
+	Short: "Reject a pending station",
+	Long:  `Refuse the pushes of a pending pass key. Rejected stations can still be approved later.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runStationReject,
+}
+
+var stationReparseCmd = &cobra.Command{
+	Use:   "reparse <station-id>",
+	Short: "Parse stored push payloads again and correct the readings",
+	Long: `Parse the raw payloads kept for RAW_PAYLOAD_RETENTION again with the current
+pusher, e.g. after a fix of a wrong conversion, and replace the stored readings
+that differ. Readings of sensors that don't exist yet and of high-frequency
+sensors are skipped. Daily rollups and records of the corrected sensors are
+recomputed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStationReparse,
+}
+
+var stationOwnerCmd = &cobra.Command{
+	Use:   "owner <station-id> [username]",
+	Short: "Show or change the owner of a station",
+	Long: `Show the user owning a station or assign it to a user. Only owned stations
+are exported and deleted with the data of their user; stations without owner,
+e.g. those added before registration approval existed, belong to the instance.
+--clear assigns a station to the instance again.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runStationOwner,
+}
+
 func init() {
 	rootCmd.AddCommand(stationCmd)
 	stationCmd.AddCommand(stationAddCmd)
 	stationCmd.AddCommand(stationListCmd)
 	stationCmd.AddCommand(stationDeleteCmd)
+	stationCmd.AddCommand(stationConfigCmd)
+	stationCmd.AddCommand(stationEncryptSecretsCmd)
+	stationCmd.AddCommand(stationReferenceCmd)
+	stationCmd.AddCommand(stationPendingCmd)
+	stationCmd.AddCommand(stationApproveCmd)
+	stationCmd.AddCommand(stationRejectCmd)
+	stationCmd.AddCommand(stationReparseCmd)
+	stationCmd.AddCommand(stationOwnerCmd)
+
+	stationConfigCmd.Flags().Bool("reveal", false, "show credentials in plain text")
+	stationReferenceCmd.Flags().String("icao", "", "ICAO code of the reference airport (default: nearest)")
+	stationReferenceCmd.Flags().Float64("radius", 50, "search radius for the nearest airport in km")
+	stationApproveCmd.Flags().String("name", "", "name of the station")
+	stationApproveCmd.Flags().String("site", "", "site of the station")
+	stationApproveCmd.Flags().String("owner", "", "ID of the user owning the station")
+	stationApproveCmd.Flags().String("template", "", "ID of a station whose config is copied")
+	stationReparseCmd.Flags().String("from", "", "only payloads received at or after this time in RFC3339")
+	stationReparseCmd.Flags().String("to", "", "only payloads received at or before this time in RFC3339")
+	stationReparseCmd.Flags().Bool("dry-run", false, "only count the readings that would change")
+	stationOwnerCmd.Flags().Bool("clear", false, "assign the station to the instance")
 }
 
 func runStationAdd(cmd *cobra.Command, args []string) error {
@@ -68,7 +172,7 @@ func runStationAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Service name
-	fmt.Print("Service name (ecowitt/netatmo/ambient/weatherflow): ")
+	fmt.Print("Service name (ecowitt/generic/wunderground/netatmo/ambient/weatherflow/email/davis): ")
 	serviceName, _ := reader.ReadString('\n')
 	serviceName = strings.TrimSpace(serviceName)
 
@@ -227,3 +331,364 @@ func runStationList(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runStationConfig(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+	key := args[1]
+
+	config, err := dbManager.GetStationConfig(stationID)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	if len(args) == 2 {
+		if _, ok := config[key]; !ok {
+			return fmt.Errorf("config key not set: %s", key)
+		}
+		if reveal, _ := cmd.Flags().GetBool("reveal"); !reveal {
+			config = models.RedactConfig(config)
+		}
+		encoded, _ := json.Marshal(config[key])
+		fmt.Println(string(encoded))
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(args[2]), &value); err != nil {
+		value = args[2]
+	}
+	station, err := dbManager.LoadStation(stationID)
+	if err != nil {
+		return err
+	}
+	if schema := providerConfigSchema(station.Mode, station.ServiceName); schema != nil {
+		// Text settings keep values that look like JSON, e.g. numeric user names
+		if property, ok := schema.Properties[key]; ok && property.Type == models.ConfigTypeString {
+			value = args[2]
+		}
+		if err := schema.ValidateValue(key, value); err != nil {
+			return err
+		}
+	}
+	config[key] = value
+	if key == models.PrivacyConfigKey {
+		if _, err := models.ParsePrivacyPolicy(config); err != nil {
+			return err
+		}
+	}
+	if key == models.SharingConfigKey {
+		if _, err := models.ParseSharingPolicy(config); err != nil {
+			return err
+		}
+	}
+	if key == models.IrrigationConfigKey {
+		if _, _, err := models.ParseIrrigationPolicy(config); err != nil {
+			return err
+		}
+	}
+	if key == models.HeatingConfigKey {
+		if _, _, err := models.ParseHeatingPolicy(config); err != nil {
+			return err
+		}
+	}
+	if key == models.RequireNonceConfigKey {
+		if err := models.ValidateRequireNonce(config); err != nil {
+			return err
+		}
+	}
+	if key == ingest.ForwardingConfigKey {
+		if err := ingest.ValidateForwardingConfig(config); err != nil {
+			return err
+		}
+	}
+	if key == models.BuddyConfigKey {
+		buddy, _, err := models.ParseBuddyPolicy(config)
+		if err != nil {
+			return err
+		}
+		if buddy.StationID == stationID {
+			return fmt.Errorf("invalid %s config: a station can't be its own buddy", models.BuddyConfigKey)
+		}
+	}
+	if key == models.BatteryConfigKey {
+		if _, err := models.ParseBatteryPolicy(config); err != nil {
+			return err
+		}
+	}
+	if key == models.AllowedIPsConfigKey {
+		if _, err := models.StationIPAllowlist(config); err != nil {
+			return err
+		}
+	}
+	if key == models.RainGaugeConfigKey {
+		if _, err := models.ParseRainGauge(config); err != nil {
+			return err
+		}
+	}
+	if key == models.IndoorClimateConfigKey {
+		if _, err := models.ParseIndoorClimatePolicy(config); err != nil {
+			return err
+		}
+	}
+
+	if err := dbManager.SetStationConfig(stationID, config); err != nil {
+		return fmt.Errorf("failed to update station config: %w", err)
+	}
+
+	fmt.Printf("✓ Set %s for station %s\n", key, stationID)
+	return nil
+}
+
+func runStationEncryptSecrets(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	count, err := dbManager.EncryptStationSecrets(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt station secrets: %w", err)
+	}
+
+	fmt.Printf("✓ Encrypted %d secret config values\n", count)
+
+	count, err = dbManager.EncryptTOTPSecrets(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt TOTP secrets: %w", err)
+	}
+	fmt.Printf("✓ Encrypted %d TOTP secrets\n", count)
+	return nil
+}
+
+func runStationReference(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+	ctx := cmd.Context()
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+	station, err := dbManager.LoadStation(stationID)
+	if err != nil {
+		return err
+	}
+
+	icao, _ := cmd.Flags().GetString("icao")
+	icao = strings.ToUpper(icao)
+	radius, _ := cmd.Flags().GetFloat64("radius")
+
+	client := metar.NewClient(metar.DefaultBaseURL)
+	var obs *metar.Observation
+	if icao == "" {
+		lat, lon, ok, err := models.StationCoordinates(station.Config)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("station has no coordinates, set %s and %s or use --icao", models.LatitudeConfigKey, models.LongitudeConfigKey)
+		}
+		nearest, err := client.Nearest(ctx, lat, lon, radius)
+		if err != nil {
+			return err
+		}
+		if len(nearest) == 0 {
+			return fmt.Errorf("no airport with METAR reports within %g km", radius)
+		}
+		obs = &nearest[0]
+		icao = obs.ICAO
+		fmt.Printf("Nearest airport: %s (%s), %.1f km away\n", icao, obs.Name, metar.DistanceKm(lat, lon, obs.Lat, obs.Lon))
+	} else if obs, err = client.Latest(ctx, icao); err != nil {
+		return err
+	}
+
+	reference := models.StationData{
+		PassKey:     metar.PassKey(icao),
+		StationType: metar.StationType,
+		Model:       obs.Name,
+		Mode:        "pull",
+		ServiceName: metar.ServiceName,
+		Config: map[string]interface{}{
+			"icao":                    icao,
+			models.LatitudeConfigKey:  obs.Lat,
+			models.LongitudeConfigKey: obs.Lon,
+		},
+	}
+	reference.ID, err = dbManager.GetStationIDByPassKey(ctx, reference.PassKey)
+	if errors.Is(err, database.ErrNotFound) {
+		reference.ID = uuid.New()
+		if err := dbManager.SaveStation(&reference); err != nil {
+			return fmt.Errorf("failed to save reference station: %w", err)
+		}
+		fmt.Printf("✓ Reference station created with ID: %s\n", reference.ID)
+	} else if err != nil {
+		return err
+	}
+
+	station.Config[models.ReferenceStationConfigKey] = reference.ID.String()
+	if err := dbManager.SetStationConfig(stationID, station.Config); err != nil {
+		return fmt.Errorf("failed to update station config: %w", err)
+	}
+
+	fmt.Printf("✓ Station %s is compared with %s, restart the server to start pulling it\n", stationID, icao)
+	return nil
+}
+
+func runStationPending(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	stations, err := dbManager.GetPendingStations(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Pending Weather Stations")
+	fmt.Println(strings.Repeat("=", 80))
+
+	for i, station := range stations {
+		fmt.Printf("\n[%d] %s (%s)\n", i+1, station.PassKey, station.Status)
+		fmt.Printf("    Type: %s\n", station.StationType)
+		fmt.Printf("    Model: %s\n", station.Model)
+		fmt.Printf("    Service: %s\n", station.ServiceName)
+		fmt.Printf("    Source: %s\n", station.SourceIP)
+		fmt.Printf("    Pushes: %d\n", station.PushCount)
+		fmt.Printf("    First Seen: %s\n", station.FirstSeenAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("    Last Seen: %s\n", station.LastSeenAt.Format("2006-01-02 15:04:05"))
+		for _, value := range station.Sample {
+			fmt.Printf("    %s (%s %s): %g %s\n", value.RemoteID, value.Location, value.SensorType, value.Value, value.Unit)
+		}
+	}
+
+	if len(stations) == 0 {
+		fmt.Println("No stations awaiting approval.")
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 80) + "\n")
+
+	return nil
+}
+
+func runStationApprove(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	var approval models.StationApproval
+	approval.Name, _ = cmd.Flags().GetString("name")
+	approval.Site, _ = cmd.Flags().GetString("site")
+	for flag, id := range map[string]**uuid.UUID{"owner": &approval.OwnerID, "template": &approval.TemplateID} {
+		value, _ := cmd.Flags().GetString(flag)
+		if value == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s ID: %w", flag, err)
+		}
+		*id = &parsed
+	}
+
+	stationID, err := dbManager.ApprovePendingStation(cmd.Context(), args[0], approval)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Station %s approved with ID: %s\n", args[0], stationID)
+	return nil
+}
+
+func runStationOwner(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+	clear, _ := cmd.Flags().GetBool("clear")
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+
+	switch {
+	case clear && len(args) == 2:
+		return errors.New("give either a username or --clear")
+	case clear:
+		if err := dbManager.SetStationOwner(cmd.Context(), stationID, nil); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Station %s belongs to the instance\n", stationID)
+	case len(args) == 2:
+		user, err := dbManager.GetUserByUsername(cmd.Context(), args[1])
+		if err != nil {
+			return err
+		}
+		if err := dbManager.SetStationOwner(cmd.Context(), stationID, &user.ID); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Station %s is owned by %s\n", stationID, user.Username)
+	default:
+		station, err := dbManager.LoadStation(stationID)
+		if err != nil {
+			return err
+		}
+		if station.OwnerID == nil {
+			fmt.Println("No owner, the station belongs to the instance")
+			return nil
+		}
+		user, err := dbManager.GetUser(cmd.Context(), *station.OwnerID)
+		if err != nil {
+			return err
+		}
+		fmt.Println(user.Username)
+	}
+	return nil
+}
+
+func runStationReject(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	if err := dbManager.RejectPendingStation(cmd.Context(), args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Station %s rejected\n", args[0])
+	return nil
+}
+
+func runStationReparse(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	stationID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid station ID: %w", err)
+	}
+	var start, end time.Time
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		if start, err = time.Parse(time.RFC3339, from); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+	if to, _ := cmd.Flags().GetString("to"); to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	rp, err := newReparser(cmd.Context(), dbManager, stationID, dryRun)
+	if err != nil {
+		return err
+	}
+	result, err := rp.Run(cmd.Context(), start, end)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Parsed %d payloads (%d failed)\n", result.Payloads, result.Failed)
+	if dryRun {
+		fmt.Printf("  %d readings would change, %d would be added\n", result.Changed, result.Added)
+		return nil
+	}
+	fmt.Printf("  %d readings corrected, %d added\n", result.Changed, result.Added)
+	return nil
+}
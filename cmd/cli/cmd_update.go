@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/sguter90/weathermaestro/pkg/update"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update the binary to the latest release",
+	Long: `Download the binary of the latest release for this platform and replace
+the running binary with it. The release checksums must be signed with the key
+built into the binary or set with UPDATE_PUBLIC_KEY; binaries are only
+replaced if their checksum matches and the version signed with the checksums
+is the release version and newer than the running one.
+
+Restart the server afterwards. Development builds are only updated with
+--force, which also reinstalls the running version.`,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().Bool("check", false, "only report whether an update is available")
+	selfUpdateCmd.Flags().Bool("force", false, "install the latest release even if it is not newer")
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+	force, _ := cmd.Flags().GetBool("force")
+
+	client := update.NewClient(getEnv("UPDATE_API_URL", ""), updateRepository())
+	release, err := client.Latest(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	newer := update.Newer(version, release.Version)
+	fmt.Printf("Current version: %s\nLatest version:  %s\n", version, release.Version)
+	if check {
+		if newer {
+			fmt.Printf("Update available: %s\n", release.URL)
+		} else {
+			fmt.Println("Up to date")
+		}
+		return nil
+	}
+	if !newer && !force {
+		fmt.Println("Up to date")
+		return nil
+	}
+
+	publicKey, err := updatePublicKeyFromEnv()
+	if err != nil {
+		return err
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate binary: %w", err)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return fmt.Errorf("failed to locate binary: %w", err)
+	}
+
+	current := version
+	if force {
+		current = ""
+	}
+	fmt.Printf("Downloading %s...\n", update.AssetName(runtime.GOOS, runtime.GOARCH))
+	binary, err := client.Download(cmd.Context(), release, runtime.GOOS, runtime.GOARCH, publicKey, current)
+	if err != nil {
+		return err
+	}
+	if err := update.Replace(path, binary); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Updated %s to %s, restart to use it\n", path, release.Version)
+	return nil
+}
@@ -3,11 +3,14 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"net/mail"
 	"os"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
@@ -25,9 +28,43 @@ var createUserCmd = &cobra.Command{
 	RunE:  runCreateUser,
 }
 
+var userEmailCmd = &cobra.Command{
+	Use:   "email <username> [email]",
+	Short: "Set the email address of a user",
+	Long: `Set the email address password reset tokens are sent to.
+Without an address the stored one is removed.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runUserEmail,
+}
+
+var userExportCmd = &cobra.Command{
+	Use:   "export <username> <file>",
+	Short: "Export all data of a user",
+	Long: `Write the profile, the stations the user owns with their sensors and
+readings, the sessions and the audit log of a user to a zip archive.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runUserExport,
+}
+
+var userDeleteCmd = &cobra.Command{
+	Use:   "delete <username>",
+	Short: "Delete a user with the stations it owns",
+	Long: `Schedule the deletion of a user after USER_DELETION_GRACE. The user,
+the stations it owns and their readings are then deleted irreversibly.
+--cancel withdraws the request, --now deletes without grace period.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runUserDelete,
+}
+
 func init() {
 	rootCmd.AddCommand(userCmd)
 	userCmd.AddCommand(createUserCmd)
+	userCmd.AddCommand(userEmailCmd)
+	userCmd.AddCommand(userExportCmd)
+	userCmd.AddCommand(userDeleteCmd)
+
+	userDeleteCmd.Flags().Bool("cancel", false, "withdraw the deletion request")
+	userDeleteCmd.Flags().Bool("now", false, "delete immediately")
 }
 
 func runCreateUser(cmd *cobra.Command, args []string) error {
@@ -85,3 +122,109 @@ func runCreateUser(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runUserEmail(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	email := ""
+	if len(args) == 2 {
+		email = strings.TrimSpace(args[1])
+		if _, err := mail.ParseAddress(email); err != nil {
+			return fmt.Errorf("invalid email address: %w", err)
+		}
+	}
+
+	if err := dbManager.SetUserEmail(cmd.Context(), args[0], email); err != nil {
+		return err
+	}
+
+	if email == "" {
+		fmt.Printf("✓ Removed email of user %s\n", args[0])
+	} else {
+		fmt.Printf("✓ Set email of user %s to %s\n", args[0], email)
+	}
+	return nil
+}
+
+func runUserExport(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+
+	user, err := dbManager.GetUserByUsername(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	manifest, err := writeUserExport(cmd.Context(), dbManager, user, f)
+	if err != nil {
+		return fmt.Errorf("failed to export user data: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	var rows int64
+	for _, entry := range manifest.Entries {
+		rows += entry.Rows
+	}
+	auditUser(cmd.Context(), dbManager, user, models.UserAuditExport, cliActor(), map[string]interface{}{"rows": rows})
+	fmt.Printf("✓ Exported data of user %s to %s\n", user.Username, args[1])
+	for _, entry := range manifest.Entries {
+		fmt.Printf("  %-20s %10d rows\n", entry.Name, entry.Rows)
+	}
+	return nil
+}
+
+func runUserDelete(cmd *cobra.Command, args []string) error {
+	dbManager := cmd.Context().Value("dbManager").(*database.DatabaseManager)
+	cancel, _ := cmd.Flags().GetBool("cancel")
+	now, _ := cmd.Flags().GetBool("now")
+
+	user, err := dbManager.GetUserByUsername(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cancel:
+		if err := dbManager.CancelUserDeletion(cmd.Context(), user.ID); err != nil {
+			return err
+		}
+		auditUser(cmd.Context(), dbManager, user, models.UserAuditDeletionCancelled, cliActor(), nil)
+		fmt.Printf("✓ Deletion of user %s cancelled\n", user.Username)
+	case now:
+		deletion, err := deleteUser(cmd.Context(), dbManager, user, cliActor())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Deleted user %s with %d stations and %d sensors\n", user.Username, deletion.Stations, deletion.Sensors)
+	default:
+		scheduled, err := dbManager.ScheduleUserDeletion(cmd.Context(), user.ID, time.Now().Add(userDeletionGrace()))
+		if err != nil {
+			return err
+		}
+		if user.DeletionScheduledAt == nil {
+			auditUser(cmd.Context(), dbManager, user, models.UserAuditDeletionScheduled, cliActor(), map[string]interface{}{
+				"deletion_scheduled_at": scheduled,
+			})
+		}
+		fmt.Printf("✓ User %s will be deleted at %s\n", user.Username, scheduled.Local().Format("2006-01-02 15:04"))
+		if ids, err := dbManager.GetOwnedStationIDs(cmd.Context(), user.ID); err == nil && len(ids) == 0 {
+			fmt.Println("  The user owns no stations, assign them with \"station owner\" to delete them with the user")
+		}
+	}
+	return nil
+}
+
+// cliActor names the operating system user running a command for the
+// audit log
+func cliActor() string {
+	if name := os.Getenv("USER"); name != "" {
+		return "cli:" + name
+	}
+	return "cli"
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// maxDatasetRows limits the timestamps of one dataset export
+const maxDatasetRows = 1000000
+
+// datasetParams are the params of a dataset export job
+type datasetParams struct {
+	StationID uuid.UUID `json:"station_id"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Interval  string    `json:"interval"`
+	Method    string    `json:"method"`
+	MaxGap    string    `json:"max_gap,omitempty"`
+}
+
+// datasetService writes dataset exports to DATASET_DIR: one wide CSV per job
+// with a timestamp column and one column per enabled sensor of the station,
+// resampled to an evenly spaced interval. Files of jobs older than
+// JOB_RETENTION are deleted before a new export is written.
+type datasetService struct {
+	db        *database.DatabaseManager
+	dir       string
+	retention time.Duration
+}
+
+// newDatasetService creates a dataset service configured from the environment
+func newDatasetService(dbManager *database.DatabaseManager) *datasetService {
+	return &datasetService{
+		db:        dbManager,
+		dir:       getEnv("DATASET_DIR", "data/datasets"),
+		retention: getEnvDuration("JOB_RETENTION", 7*24*time.Hour),
+	}
+}
+
+// path returns the file of the dataset written by a job
+func (s *datasetService) path(jobID uuid.UUID) string {
+	return filepath.Join(s.dir, jobID.String()+".csv")
+}
+
+// jobHandler runs dataset exports as background jobs. The file is written
+// to a temporary name and renamed once complete, so a download never sees
+// a partial dataset.
+func (s *datasetService) jobHandler() jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		var params datasetParams
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return fmt.Errorf("invalid dataset params: %w", err)
+		}
+		interval, err := time.ParseDuration(params.Interval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid dataset interval: %s", params.Interval)
+		}
+		var maxGap time.Duration
+		if params.MaxGap != "" {
+			if maxGap, err = time.ParseDuration(params.MaxGap); err != nil {
+				return fmt.Errorf("invalid dataset max_gap: %s", params.MaxGap)
+			}
+		}
+
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return err
+		}
+		s.expire()
+
+		enabled := true
+		sensors, err := s.db.GetSensors(models.SensorQueryParams{StationID: &params.StationID, Enabled: &enabled})
+		if err != nil {
+			return fmt.Errorf("failed to load sensors: %w", err)
+		}
+		progress.SetTotal(len(sensors) + 1)
+
+		columns := make([][]analysis.ResampledPoint, len(sensors))
+		for i, sensor := range sensors {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			readings, err := s.db.GetSensorSeries(ctx, sensor.Sensor.ID, params.Start, params.End)
+			if err != nil {
+				return fmt.Errorf("readings of sensor %s: %w", sensor.Sensor.ID, err)
+			}
+			points := make([]analysis.Point, len(readings))
+			for j, reading := range readings {
+				points[j] = analysis.Point{Time: reading.DateUTC, Value: reading.Value}
+			}
+			columns[i] = analysis.Resample(points, params.Start, params.End, interval, maxGap, params.Method)
+			progress.Add(1)
+		}
+
+		tmp := s.path(job.ID) + ".tmp"
+		if err := writeDatasetCSV(tmp, sensors, columns, params.Start, params.End, interval); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, s.path(job.ID)); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		progress.Add(1)
+
+		log.Printf("✓ Dataset %s of station %s written (%d sensors)", job.ID, params.StationID, len(sensors))
+		return nil
+	}
+}
+
+// expire deletes datasets older than the job retention
+func (s *datasetService) expire() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("❌ Failed to list datasets: %v", err)
+		return
+	}
+	cutoff := time.Now().Add(-s.retention)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.Type().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			log.Printf("❌ Failed to delete dataset %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+// writeDatasetCSV writes the resampled series of the sensors as one row per
+// timestamp. Missing values are empty cells.
+func writeDatasetCSV(file string, sensors []models.SensorWithLatestReading, columns [][]analysis.ResampledPoint, start, end time.Time, interval time.Duration) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out := csv.NewWriter(f)
+	out.Write(append([]string{"timestamp"}, datasetColumnNames(sensors)...))
+
+	row := make([]string, len(columns)+1)
+	for i, t := 0, start; !t.After(end); i, t = i+1, t.Add(interval) {
+		row[0] = t.UTC().Format(time.RFC3339)
+		for j, column := range columns {
+			row[j+1] = ""
+			if i < len(column) && column[i].Value != nil {
+				row[j+1] = strconv.FormatFloat(*column[i].Value, 'f', -1, 64)
+			}
+		}
+		out.Write(row)
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// datasetColumnNames names the columns of the sensors by sensor type and
+// location, e.g. temperature_outdoor. Sensors sharing a name get their ID
+// appended.
+func datasetColumnNames(sensors []models.SensorWithLatestReading) []string {
+	names := make([]string, len(sensors))
+	count := make(map[string]int, len(sensors))
+	for i, s := range sensors {
+		name := s.Sensor.SensorType
+		if s.Sensor.Location != "" {
+			name += "_" + s.Sensor.Location
+		}
+		names[i] = strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+		count[names[i]]++
+	}
+	for i, s := range sensors {
+		if count[names[i]] > 1 {
+			names[i] += "_" + s.Sensor.ID.String()
+		}
+	}
+	return names
+}
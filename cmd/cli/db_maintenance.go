@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Thresholds of the database maintenance tasks
+const (
+	// vacuumMinDeadRows and vacuumDeadRatio select the tables to vacuum:
+	// autovacuum handles small tables well, but lags behind on large ones
+	vacuumMinDeadRows = 10000
+	vacuumDeadRatio   = 0.1
+	// reindexMinBytes and reindexMinBloat select the indexes to rebuild;
+	// small indexes are cheap to scan however bloated they are
+	reindexMinBytes = 1 << 20
+	reindexMinBloat = 2
+)
+
+// dbMaintenanceParams are the parameters of a maintenance job. Without tasks
+// the tasks of DB_MAINTENANCE_TASKS run.
+type dbMaintenanceParams struct {
+	Tasks []string `json:"tasks,omitempty"`
+}
+
+// dbMaintenance runs the database maintenance tasks: vacuum and analyze of
+// the Postgres tables, rebuilds of bloated indexes and merges of the
+// ClickHouse parts of finished months
+type dbMaintenance struct {
+	db    *database.DatabaseManager
+	tasks []string
+}
+
+// newDBMaintenance creates the maintenance running the tasks of
+// DB_MAINTENANCE_TASKS, all by default
+func newDBMaintenance(dbManager *database.DatabaseManager) (*dbMaintenance, error) {
+	tasks, err := models.ParseDBMaintenanceTasks(getEnv("DB_MAINTENANCE_TASKS", "all"))
+	if err != nil {
+		return nil, fmt.Errorf("DB_MAINTENANCE_TASKS: %w", err)
+	}
+	return &dbMaintenance{db: dbManager, tasks: tasks}, nil
+}
+
+// dbMaintenanceWindow returns the window of DB_MAINTENANCE_WINDOW in which
+// scheduled maintenance starts, or nil to start at any time
+func dbMaintenanceWindow() (*models.DBMaintenanceWindow, error) {
+	value := getEnv("DB_MAINTENANCE_WINDOW", "")
+	if value == "" {
+		return nil, nil
+	}
+	window, err := models.ParseDBMaintenanceWindow(value)
+	if err != nil {
+		return nil, fmt.Errorf("DB_MAINTENANCE_WINDOW: %w", err)
+	}
+	return window, nil
+}
+
+// Run runs the given tasks in their order. A failing task doesn't stop the
+// others; all errors are returned.
+func (m *dbMaintenance) Run(ctx context.Context, tasks []string, progress *jobs.Progress) error {
+	if progress != nil {
+		progress.SetTotal(len(tasks))
+	}
+
+	var errs []error
+	for _, task := range models.DBMaintenanceTasks {
+		if !slices.Contains(tasks, task) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		var err error
+		switch task {
+		case models.DBMaintenanceVacuum:
+			err = m.vacuum(ctx)
+		case models.DBMaintenanceAnalyze:
+			err = m.analyze(ctx)
+		case models.DBMaintenanceReindex:
+			err = m.reindex(ctx)
+		case models.DBMaintenanceMerge:
+			err = m.merge(ctx)
+		}
+		if err != nil {
+			log.Printf("❌ Maintenance task %s failed: %v", task, err)
+			errs = append(errs, fmt.Errorf("%s: %w", task, err))
+		} else {
+			log.Printf("✓ Maintenance task %s finished in %s", task, time.Since(start).Round(time.Millisecond))
+		}
+		if progress != nil {
+			progress.Add(1)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *dbMaintenance) vacuum(ctx context.Context) error {
+	tables, err := m.db.GetVacuumCandidates(ctx, vacuumMinDeadRows, vacuumDeadRatio)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if err := m.db.VacuumTable(ctx, table); err != nil {
+			return err
+		}
+		log.Printf("  Vacuumed %s", table)
+	}
+	return nil
+}
+
+func (m *dbMaintenance) analyze(ctx context.Context) error {
+	tables, err := m.db.AnalyzeTables(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("  Analyzed %d tables", len(tables))
+	return nil
+}
+
+// reindex rebuilds the indexes that are much larger than a fresh build.
+// Failed concurrent rebuilds leave an invalid index behind, which is
+// rebuilt by the next run as well.
+func (m *dbMaintenance) reindex(ctx context.Context) error {
+	indexes, err := m.db.GetIndexStats(ctx)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		bloat := index.Bloat()
+		if index.Bytes < reindexMinBytes || bloat < reindexMinBloat {
+			continue
+		}
+		if err := m.db.ReindexIndex(ctx, index.Name); err != nil {
+			return err
+		}
+		log.Printf("  Rebuilt index %s of %s (%.1fx its estimated size)", index.Name, index.Table, bloat)
+	}
+	return nil
+}
+
+// merge merges the parts of the readings and rollups of finished months.
+// Months still receiving readings keep being merged by ClickHouse itself.
+func (m *dbMaintenance) merge(ctx context.Context) error {
+	now := time.Now().UTC()
+	partitions, err := m.db.GetUnmergedPartitions(ctx, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		if err := m.db.MergePartition(ctx, p.Table, p.Partition); err != nil {
+			return err
+		}
+		log.Printf("  Merged %d parts of %s partition %s", p.Parts, p.Table, p.Partition)
+	}
+	return nil
+}
+
+// jobHandler runs the tasks of the job params or the configured ones
+func (m *dbMaintenance) jobHandler() jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		var params dbMaintenanceParams
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return fmt.Errorf("invalid params: %w", err)
+		}
+		tasks := m.tasks
+		if len(params.Tasks) > 0 {
+			tasks = params.Tasks
+		}
+		return m.Run(ctx, tasks, progress)
+	}
+}
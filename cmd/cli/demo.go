@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Demo mode seeds a temporary schema with sample stations, so the API and
+// UI can be explored without hardware
+const (
+	demoFlag     = "--demo"
+	demoHistory  = 90 * 24 * time.Hour
+	demoInterval = 10 * time.Minute
+	demoUsername = "demo"
+	// demoBatchSize is the number of readings stored per insert
+	demoBatchSize = 10000
+)
+
+// demoStation describes the climate of a sample station
+type demoStation struct {
+	PassKey   string
+	Model     string
+	Timezone  string
+	Latitude  float64
+	Longitude float64
+	// MeanTemp is the mean temperature at the start of the history in °C,
+	// Trend the change until now and DailyRange the day/night difference
+	MeanTemp   float64
+	Trend      float64
+	DailyRange float64
+	// Windiness scales the wind speed
+	Windiness float64
+	Seed      int64
+}
+
+var demoStations = []demoStation{
+	{
+		PassKey: "demo-garden", Model: "GW2000A_V3.1.4", Timezone: "Europe/Vienna",
+		Latitude: 48.2082, Longitude: 16.3738,
+		MeanTemp: 19, Trend: -9, DailyRange: 10, Windiness: 1, Seed: 1,
+	},
+	{
+		PassKey: "demo-alpine", Model: "GW1100B_V2.3.2", Timezone: "Europe/Vienna",
+		Latitude: 47.0742, Longitude: 12.6947,
+		MeanTemp: 8, Trend: -10, DailyRange: 8, Windiness: 2.2, Seed: 2,
+	},
+}
+
+// demoSensors are the sensors of every sample station, with the remote IDs
+// of an Ecowitt gateway. Their readings are stored like pushed ones: in
+// metric units with the imperial value sent by the gateway as raw value, see
+// demoRawUnits.
+var demoSensors = []models.Sensor{
+	{Name: "Temperature", SensorType: models.SensorTypeTemperature, Location: "Outdoor", RemoteID: "tempf"},
+	{Name: "Humidity", SensorType: models.SensorTypeHumidity, Location: "Outdoor", RemoteID: "humidity"},
+	{Name: "Temperature", SensorType: models.SensorTypeTemperature, Location: "Indoor", RemoteID: "tempinf"},
+	{Name: "Humidity", SensorType: models.SensorTypeHumidity, Location: "Indoor", RemoteID: "humidityin"},
+	{Name: "Barometric Pressure (Relative)", SensorType: models.SensorTypePressureRelative, Location: "Indoor", RemoteID: "baromrelin"},
+	{Name: "Wind Direction", SensorType: models.SensorTypeWindDirection, Location: "Outdoor", RemoteID: "winddir"},
+	{Name: "Wind Speed", SensorType: models.SensorTypeWindSpeed, Location: "Outdoor", RemoteID: "windspeedmph"},
+	{Name: "Wind Gust", SensorType: models.SensorTypeWindGust, Location: "Outdoor", RemoteID: "windgustmph"},
+	{Name: "Solar Radiation", SensorType: models.SensorTypeSolarRadiation, Location: "Outdoor", RemoteID: "solarradiation"},
+	{Name: "UV Index", SensorType: models.SensorTypeUVIndex, Location: "Outdoor", RemoteID: "uv"},
+	{Name: "Rain Rate", SensorType: models.SensorTypeRainfallRate, Location: "Outdoor", RemoteID: "rainratein"},
+	{Name: "Rain (Daily)", SensorType: models.SensorTypeRainfallDaily, Location: "Outdoor", RemoteID: "dailyrainin"},
+}
+
+// demoRawUnits are the units the Ecowitt gateway sends the values of remote
+// IDs in, where they differ from the stored unit
+var demoRawUnits = map[string]string{
+	"tempf":        "°F",
+	"tempinf":      "°F",
+	"baromrelin":   "inHg",
+	"windspeedmph": "mph",
+	"windgustmph":  "mph",
+	"rainratein":   "in/h",
+	"dailyrainin":  "in",
+}
+
+// demoAlertRules are created for every sample station
+var demoAlertRules = []models.AlertRule{
+	{Name: "frost_warning", SensorType: models.SensorTypeTemperature, Location: "Outdoor", Operator: models.AlertOperatorBelow, Threshold: 0.5, Hysteresis: 0.5},
+	{Name: "storm_gusts", SensorType: models.SensorTypeWindGust, Location: "Outdoor", Operator: models.AlertOperatorAbove, Threshold: 15, Hysteresis: 3},
+	{Name: "dry_indoor_air", SensorType: models.SensorTypeHumidity, Location: "Indoor", Operator: models.AlertOperatorBelow, Threshold: 40, Hysteresis: 2},
+}
+
+// demoRequested reports whether the server is started with --demo. It is
+// checked before the database is connected, as demo mode uses its own
+// schema.
+func demoRequested(args []string) bool {
+	if len(args) < 2 || args[1] != serveCmd.Name() {
+		return false
+	}
+	return slices.Contains(args[2:], demoFlag) || slices.Contains(args[2:], demoFlag+"=true")
+}
+
+// demoDisabledEnv turns off the schedulers and the connections to other
+// systems in demo mode: jobs would back up, archive or purge the sample data
+// like real data, and the sample readings must not reach brokers, other
+// instances or mail recipients
+var demoDisabledEnv = map[string]string{
+	"BACKUP_INTERVAL":         "0",
+	"ARCHIVE_INTERVAL":        "0",
+	"USER_PURGE_INTERVAL":     "0",
+	"DB_MAINTENANCE_INTERVAL": "0",
+	"CDC_BROKER":              "",
+	"INGEST_BROKER":           "",
+	"FEDERATION_URL":          "",
+	"MAINTENANCE_NOTIFY_TO":   "",
+	"MQTT_BROKER":             "",
+}
+
+// prepareDemoEnv points DB_SCHEMA and CH_DATABASE to a new temporary schema
+// and database, keeps the ingest queue apart from the one of the instance,
+// turns off schedulers and sets a JWT secret if none is configured
+func prepareDemoEnv() error {
+	name := fmt.Sprintf("demo_%d", time.Now().Unix())
+	env := map[string]string{
+		"DB_SCHEMA":          name,
+		"CH_DATABASE":        name,
+		"CH_CREATE_DATABASE": "true",
+		"INGEST_QUEUE_PATH":  demoQueuePath(name),
+	}
+	for key, value := range demoDisabledEnv {
+		env[key] = value
+	}
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	if secret := getEnv("JWT_SECRET", ""); secret == "" || secret == "change_me_in_production" {
+		return os.Setenv("JWT_SECRET", randomDemoSecret())
+	}
+	return nil
+}
+
+// demoQueuePath returns the path of the temporary ingest queue
+func demoQueuePath(name string) string {
+	return filepath.Join(os.TempDir(), name+"-ingest-queue.log")
+}
+
+// randomDemoSecret returns 32 random hex characters
+func randomDemoSecret() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// seedDemo creates the sample stations with their history and alerts and a
+// user to log in with
+func seedDemo(ctx context.Context, dbManager *database.DatabaseManager) error {
+	log.Printf("Seeding demo data into schema %s...", dbManager.Schema())
+	end := time.Now().UTC().Truncate(demoInterval)
+
+	for _, station := range demoStations {
+		count, err := seedDemoStation(ctx, dbManager, station, end)
+		if err != nil {
+			return fmt.Errorf("failed to seed station %s: %w", station.PassKey, err)
+		}
+		log.Printf("✓ Demo station %s with %d readings", station.PassKey, count)
+	}
+
+	password := randomDemoSecret()[:12]
+	if _, err := dbManager.CreateUser(ctx, demoUsername, password); err != nil {
+		return err
+	}
+	log.Printf("✓ Demo user %q with password %q", demoUsername, password)
+	return nil
+}
+
+// seedDemoStation stores a station, its sensors, readings and alert rules
+// and returns the number of readings
+func seedDemoStation(ctx context.Context, dbManager *database.DatabaseManager, station demoStation, end time.Time) (int, error) {
+	stationID, err := dbManager.EnsureStation(&models.StationData{
+		PassKey:     station.PassKey,
+		StationType: "Ecowitt",
+		Model:       station.Model,
+		Mode:        "push",
+		ServiceName: "ecowitt",
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = dbManager.SetStationConfig(stationID, map[string]interface{}{
+		"timezone":                station.Timezone,
+		models.LatitudeConfigKey:  station.Latitude,
+		models.LongitudeConfigKey: station.Longitude,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sensors := make(map[string]models.Sensor, len(demoSensors))
+	for _, s := range demoSensors {
+		s.Enabled = true
+		sensors[s.RemoteID] = s
+	}
+	if sensors, err = dbManager.EnsureSensorsByRemoteId(stationID, sensors); err != nil {
+		return 0, err
+	}
+
+	readings := newDemoWeather(station, end.Add(-demoHistory)).Readings(sensors, end)
+	for start := 0; start < len(readings); start += demoBatchSize {
+		batch := readings[start:min(start+demoBatchSize, len(readings))]
+		if err := dbManager.StoreSensorReadingsBatch(ctx, batch); err != nil {
+			return 0, err
+		}
+	}
+
+	// The alerts are evaluated against the latest readings, so active ones
+	// show up right away
+	latest := make(map[uuid.UUID]models.SensorReading)
+	for _, r := range readings {
+		latest[r.SensorID] = r
+	}
+	for _, rule := range demoAlertRules {
+		rule.StationID = stationID
+		rule.Enabled = true
+		if err := rule.Validate(); err != nil {
+			return 0, err
+		}
+		if err := dbManager.CreateAlertRule(ctx, &rule); err != nil {
+			return 0, err
+		}
+		for _, s := range sensors {
+			if r, ok := latest[s.ID]; ok && rule.Matches(s) {
+				if err := dbManager.SetAlertState(ctx, rule.ID, rule.Evaluate(r.Value), r.Value, r.DateUTC); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return len(readings), nil
+}
+
+// demoWeather generates plausible readings: a seasonal trend with a daily
+// cycle, fronts moving the pressure, wind and rain, and clouds dimming the
+// sun. The same station and start always give the same readings.
+type demoWeather struct {
+	station  demoStation
+	start    time.Time
+	location *time.Location
+	rng      *mathrand.Rand
+
+	// front is a slow random walk between -1 (low pressure, wet, windy)
+	// and 1 (high pressure, dry, calm)
+	front     float64
+	windDir   float64
+	dailyRain float64
+	day       int
+}
+
+func newDemoWeather(station demoStation, start time.Time) *demoWeather {
+	location, err := time.LoadLocation(station.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return &demoWeather{
+		station:  station,
+		start:    start,
+		location: location,
+		rng:      mathrand.New(mathrand.NewSource(station.Seed)),
+		front:    0.3,
+		windDir:  270,
+	}
+}
+
+// Readings generates the readings of the sensors from the start until end
+func (w *demoWeather) Readings(sensors map[string]models.Sensor, end time.Time) []models.SensorReading {
+	steps := int(end.Sub(w.start) / demoInterval)
+	readings := make([]models.SensorReading, 0, steps*len(sensors))
+	for t := w.start; !t.After(end); t = t.Add(demoInterval) {
+		values := w.next(t)
+		for remoteID, sensor := range sensors {
+			value, ok := values[remoteID]
+			if !ok {
+				continue
+			}
+			reading := models.SensorReading{
+				SensorID: sensor.ID,
+				Value:    math.Round(value*10) / 10,
+				Unit:     models.SensorTypeRegistry[sensor.SensorType].Unit,
+				DateUTC:  t,
+			}
+			if rawUnit, ok := demoRawUnits[remoteID]; ok {
+				if raw, err := models.ConvertUnit(reading.Value, reading.Unit, rawUnit); err == nil {
+					raw = math.Round(raw*100) / 100
+					reading.RawValue = &raw
+					reading.RawUnit = rawUnit
+				}
+			}
+			readings = append(readings, reading)
+		}
+	}
+	return readings
+}
+
+// next advances the weather to t and returns the metric values by remote ID
+func (w *demoWeather) next(t time.Time) map[string]float64 {
+	local := t.In(w.location)
+	hour := float64(local.Hour()) + float64(local.Minute())/60
+	progress := t.Sub(w.start).Hours() / demoHistory.Hours()
+
+	w.front = math.Max(-1, math.Min(1, w.front+w.rng.NormFloat64()*0.04-w.front*0.005))
+	w.windDir = math.Mod(w.windDir+w.rng.NormFloat64()*8+360, 360)
+	if day := local.YearDay(); day != w.day {
+		w.day, w.dailyRain = day, 0
+	}
+
+	// Clouds come with low pressure and damp the daily cycle
+	clouds := math.Max(0, math.Min(1, 0.5-w.front*0.6+w.rng.NormFloat64()*0.05))
+	daily := math.Sin(2 * math.Pi * (hour - 9) / 24)
+	temp := w.station.MeanTemp + w.station.Trend*progress +
+		daily*w.station.DailyRange/2*(1-clouds*0.6) + w.front*2 + w.rng.NormFloat64()*0.2
+	humidity := math.Max(20, math.Min(100, 75-daily*15*(1-clouds*0.5)+clouds*15+w.rng.NormFloat64()*2))
+
+	var solar float64
+	if hour > 6.5 && hour < 18.5 {
+		solar = math.Max(0, 750*(1-0.3*progress)*math.Sin(math.Pi*(hour-6.5)/12)*(1-clouds*0.75))
+	}
+
+	wind := math.Max(0, w.station.Windiness*(2.5-w.front*1.5+math.Max(0, daily)*1.5+w.rng.NormFloat64()*0.6))
+	gust := wind * (1.4 + w.rng.Float64()*0.5)
+
+	var rainRate float64
+	if w.front < -0.3 && w.rng.Float64() < -w.front*0.5 {
+		rainRate = w.rng.ExpFloat64() * 2 * -w.front
+	}
+	w.dailyRain += rainRate * demoInterval.Hours()
+
+	return map[string]float64{
+		"tempf":          temp,
+		"humidity":       humidity,
+		"tempinf":        21.5 + w.rng.NormFloat64()*0.3 + math.Max(0, daily)*0.8,
+		"humidityin":     math.Max(25, 48-math.Max(0, -temp)*1.5+w.rng.NormFloat64()),
+		"baromrelin":     1013 + w.front*18 + w.rng.NormFloat64()*0.3,
+		"winddir":        w.windDir,
+		"windspeedmph":   wind,
+		"windgustmph":    gust,
+		"solarradiation": solar,
+		"uv":             math.Round(solar / 90),
+		"rainratein":     rainRate,
+		"dailyrainin":    w.dailyRain,
+	}
+}
+
+// dropDemo deletes the temporary schema, database and ingest queue of demo
+// mode
+func dropDemo(dbManager *database.DatabaseManager) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := dbManager.DropSchema(ctx); err != nil {
+		log.Printf("❌ Failed to delete demo data: %v", err)
+		return
+	}
+	if err := os.Remove(demoQueuePath(dbManager.Schema())); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("❌ Failed to delete demo ingest queue: %v", err)
+	}
+	log.Printf("✓ Demo data deleted")
+}
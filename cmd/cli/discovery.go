@@ -0,0 +1,100 @@
+package main
+
+import (
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/discovery"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// discoveryResponder answers one discovery protocol on the local network
+type discoveryResponder interface {
+	Start() error
+	Stop()
+}
+
+// discoveryResponders are the started responders
+type discoveryResponders []discoveryResponder
+
+// Stop stops all responders
+func (d discoveryResponders) Stop() {
+	for _, r := range d {
+		r.Stop()
+	}
+}
+
+// discoveryService returns the advertised name of the server, set by
+// DISCOVERY_MDNS_NAME and DISCOVERY_MDNS_HOST for all protocols
+func discoveryService() discovery.Service {
+	return discovery.Service{
+		Instance: getEnv("DISCOVERY_MDNS_NAME", "WeatherMaestro"),
+		Host:     getEnv("DISCOVERY_MDNS_HOST", ""),
+	}
+}
+
+// startDiscovery advertises the ingest endpoints on the local network via
+// mDNS (DISCOVERY_MDNS), SSDP (DISCOVERY_SSDP) and the gateway discovery
+// of the Ecowitt apps (DISCOVERY_ECOWITT). Responders that could not be
+// started are logged and skipped.
+func startDiscovery(listeners []listenerConfig, pushers []pusher.Pusher) discoveryResponders {
+	// Consoles should use the push-only listener when there is one
+	listener := listeners[0]
+	for _, l := range listeners {
+		if l.Routes == listenerRoutesPush {
+			listener = l
+			break
+		}
+	}
+	_, portStr, _ := net.SplitHostPort(listener.Addr)
+	port, _ := strconv.Atoi(portStr)
+
+	service := discoveryService()
+	service.Port = port
+	service.TXT = []string{"api=/api/v1"}
+	for _, p := range pushers {
+		service.TXT = append(service.TXT, strings.ToLower(p.GetStationType())+"=/api/v1"+p.GetEndpoint())
+	}
+
+	var started discoveryResponders
+	start := func(protocol string, responder discoveryResponder, err error) {
+		if err == nil {
+			err = responder.Start()
+		}
+		if err != nil {
+			log.Printf("⚠ %s discovery disabled: %v", protocol, err)
+			return
+		}
+		started = append(started, responder)
+	}
+	if getEnvBool("DISCOVERY_MDNS", false) {
+		responder, err := discovery.NewResponder(service)
+		start("mDNS", responder, err)
+	}
+	if getEnvBool("DISCOVERY_SSDP", false) {
+		responder, err := discovery.NewSSDPResponder(service)
+		start("SSDP", responder, err)
+	}
+	if getEnvBool("DISCOVERY_ECOWITT", false) {
+		responder, err := discovery.NewEcowittResponder(service)
+		start("Ecowitt", responder, err)
+	}
+	return started
+}
+
+// setupDiscoveryRoutes serves the UPnP device description SSDP answers
+// point to when DISCOVERY_SSDP is enabled
+func setupDiscoveryRoutes(r *mux.Router) {
+	if !getEnvBool("DISCOVERY_SSDP", false) {
+		return
+	}
+	handler, err := discovery.DescriptionHandler(discoveryService())
+	if err != nil {
+		log.Printf("⚠ UPnP device description disabled: %v", err)
+		return
+	}
+	r.Handle(discovery.DescriptionPath, handler).Methods("GET")
+}
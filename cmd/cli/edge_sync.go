@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// edgeLocalConfigKeys are station config keys that only apply to the edge
+// instance and are never synced. Credentials aren't synced either, see
+// isEdgeLocalConfigKey.
+var edgeLocalConfigKeys = []string{ingest.FederationPassKeyConfigKey}
+
+// isEdgeLocalConfigKey reports whether a config key is kept out of the
+// sync. Credentials are served redacted by the central instance, so each
+// instance keeps its own.
+func isEdgeLocalConfigKey(key string) bool {
+	return slices.Contains(edgeLocalConfigKeys, key) || models.IsSecretConfigKey(key)
+}
+
+// errNotFederated marks stations the central instance does not know yet;
+// they are created there by the first forwarded readings
+var errNotFederated = errors.New("station not known to the central instance")
+
+// configSyncer syncs the station configs of an edge instance with the
+// central instance both ways. The config of both sides after the last sync
+// is kept in a state file as base of the three-way merge, see
+// models.MergeConfig.
+type configSyncer struct {
+	db        *database.DatabaseManager
+	client    *http.Client
+	baseURL   string
+	apiKey    string
+	statePath string
+	interval  time.Duration
+	stopChan  chan struct{}
+	doneChan  chan struct{}
+}
+
+// newConfigSyncer creates a syncer with the central instance at baseURL.
+// EDGE_CONFIG_SYNC_INTERVAL sets how often configs are synced, 0 disables
+// syncing.
+func newConfigSyncer(dbManager *database.DatabaseManager, baseURL, apiKey string) *configSyncer {
+	interval := getEnvDuration("EDGE_CONFIG_SYNC_INTERVAL", 5*time.Minute)
+	if interval <= 0 {
+		return nil
+	}
+	return &configSyncer{
+		db:        dbManager,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		apiKey:    apiKey,
+		statePath: getEnv("EDGE_SYNC_STATE_PATH", "data/edge-sync.json"),
+		interval:  interval,
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+	}
+}
+
+// Start syncs in the background
+func (s *configSyncer) Start() {
+	go s.run()
+	log.Printf("✓ Config sync with %s started (every %s)", s.baseURL, s.interval)
+}
+
+// Stop halts the syncer and waits for the current sync to finish
+func (s *configSyncer) Stop() {
+	close(s.stopChan)
+	<-s.doneChan
+}
+
+func (s *configSyncer) run() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.SyncAll(context.Background())
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs the configs of all local stations. Failed stations are
+// retried with the next sync.
+func (s *configSyncer) SyncAll(ctx context.Context) {
+	stations, err := s.db.LoadStations()
+	if err != nil {
+		log.Printf("❌ Config sync failed to load stations: %v", err)
+		return
+	}
+	state, err := s.loadState()
+	if err != nil {
+		log.Printf("❌ Config sync failed to load state: %v", err)
+		return
+	}
+
+	changed := false
+	for _, station := range stations {
+		merged, err := s.syncStation(ctx, station, state[station.ID.String()])
+		if errors.Is(err, errNotFederated) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠ Config sync of station %s failed: %v", station.ID, err)
+			continue
+		}
+		state[station.ID.String()] = merged
+		changed = true
+	}
+
+	if changed {
+		if err := s.saveState(state); err != nil {
+			log.Printf("❌ Config sync failed to save state: %v", err)
+		}
+	}
+}
+
+// syncStation merges the local and central config of a station and writes
+// the result to the sides that differ from it. It returns the merged
+// config, the base of the next sync.
+func (s *configSyncer) syncStation(ctx context.Context, station models.StationData, base map[string]interface{}) (map[string]interface{}, error) {
+	local, localVersion, err := s.db.GetStationConfigVersion(ctx, station.ID)
+	if err != nil {
+		return nil, err
+	}
+	passKey := station.PassKey
+	if key, ok := local[ingest.FederationPassKeyConfigKey].(string); ok && key != "" {
+		passKey = key
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/sync/stations/%s/config", s.baseURL, url.PathEscape(passKey))
+
+	var remote syncConfig
+	status, err := s.request(ctx, http.MethodGet, endpoint, nil, &remote)
+	if status == http.StatusNotFound {
+		return nil, errNotFederated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys of the edge instance are kept out of the merge
+	localOnly := make(map[string]interface{})
+	shared := make(map[string]interface{}, len(local))
+	for key, value := range local {
+		shared[key] = value
+	}
+	for key, value := range local {
+		if isEdgeLocalConfigKey(key) {
+			localOnly[key] = value
+			delete(shared, key)
+		}
+	}
+	for key := range remote.Config {
+		if isEdgeLocalConfigKey(key) {
+			delete(remote.Config, key)
+		}
+	}
+	for key := range base {
+		if isEdgeLocalConfigKey(key) {
+			delete(base, key)
+		}
+	}
+
+	merged, conflicts := models.MergeConfig(base, shared, remote.Config)
+	for _, c := range conflicts {
+		log.Printf("⚠ Config conflict of station %s at %q: local %v, central %v kept", station.ID, c.Key, c.Local, c.Remote)
+	}
+
+	if !reflect.DeepEqual(merged, remote.Config) {
+		status, err := s.request(ctx, http.MethodPut, endpoint, syncConfig{Config: merged, Version: remote.Version}, nil)
+		if status == http.StatusConflict {
+			return nil, fmt.Errorf("config changed on the central instance during the sync")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !reflect.DeepEqual(merged, shared) {
+		config := make(map[string]interface{}, len(merged)+len(localOnly))
+		for key, value := range merged {
+			config[key] = value
+		}
+		for key, value := range localOnly {
+			config[key] = value
+		}
+		if _, err := s.db.ReplaceStationConfig(ctx, station.ID, config, localVersion); err != nil {
+			return nil, err
+		}
+		log.Printf("✓ Config of station %s updated from the central instance", station.ID)
+	}
+	return merged, nil
+}
+
+// request sends a JSON request to the central instance and decodes the
+// response into result. It returns the status code of the response.
+func (s *configSyncer) request(ctx context.Context, method, endpoint string, body, result interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("X-API-Key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if result == nil {
+		return resp.StatusCode, nil
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return resp.StatusCode, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, json.Unmarshal(envelope.Data, result)
+}
+
+// loadState reads the merged configs of the last sync keyed by station ID
+func (s *configSyncer) loadState() (map[string]map[string]interface{}, error) {
+	state := make(map[string]map[string]interface{})
+	data, err := os.ReadFile(s.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+// saveState atomically replaces the state file
+func (s *configSyncer) saveState(state map[string]map[string]interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0o750); err != nil {
+		return err
+	}
+	tmp := s.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.statePath)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// Feature flags of optional subsystems
+const (
+	featureForwarders = "forwarders"
+	featureIrrigation = "irrigation"
+	featureHeating    = "heating"
+)
+
+// featureFlag switches an optional subsystem on or off per deployment.
+// Experimental subsystems start disabled.
+type featureFlag struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Experimental bool   `json:"experimental"`
+	Default      bool   `json:"default"`
+	Enabled      bool   `json:"enabled"`
+}
+
+// featureFlags are the known flags with their defaults
+var featureFlags = []featureFlag{
+	{Name: featureForwarders, Description: "Forward readings to Sensor.Community, openSenseMap, PWSWeather and AWEKAS", Default: true},
+	{Name: featureIrrigation, Description: "Irrigation advice from the evapotranspiration, published daily", Experimental: true},
+	{Name: featureHeating, Description: "Heating curve flow temperatures for heat pump controllers", Experimental: true},
+}
+
+// features are the flags of a deployment
+type features []featureFlag
+
+// loadFeatures applies FEATURES (comma separated) to the defaults: a name
+// enables a flag, a name prefixed with - disables it, e.g.
+// FEATURES=irrigation,-forwarders. Unknown names are logged and ignored.
+func loadFeatures() features {
+	flags := make(features, len(featureFlags))
+	copy(flags, featureFlags)
+	for i := range flags {
+		flags[i].Enabled = flags[i].Default
+	}
+
+	for _, name := range strings.Split(getEnv("FEATURES", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		name, disable := strings.CutPrefix(name, "-")
+		flag := flags.find(name)
+		if flag == nil {
+			log.Printf("⚠ Unknown feature flag: %s", name)
+			continue
+		}
+		flag.Enabled = !disable
+	}
+
+	for _, flag := range flags {
+		if flag.Enabled && flag.Experimental {
+			log.Printf("✓ Experimental feature enabled: %s", flag.Name)
+		} else if !flag.Enabled && flag.Default {
+			log.Printf("✓ Feature disabled: %s", flag.Name)
+		}
+	}
+	return flags
+}
+
+// find returns the flag with a name, nil if it is unknown
+func (f features) find(name string) *featureFlag {
+	for i := range f {
+		if f[i].Name == name {
+			return &f[i]
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether a flag is enabled
+func (f features) Enabled(name string) bool {
+	flag := f.find(name)
+	return flag != nil && flag.Enabled
+}
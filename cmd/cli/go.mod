@@ -10,6 +10,7 @@ require (
 	github.com/sguter90/weathermaestro/pkg/models v0.1.0
 	github.com/sguter90/weathermaestro/pkg/puller v0.0.0-20260204072708-47cd9d9a8178
 	github.com/sguter90/weathermaestro/pkg/pusher v0.1.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/cobra v1.7.0
 	golang.org/x/term v0.39.0
 )
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// recomputeRequest is the body of POST /admin/recompute.
+// Without station_id and sensor_ids all sensors are recomputed; without
+// start/end the whole history; without artifacts all artifacts.
+type recomputeRequest struct {
+	StationID *uuid.UUID  `json:"station_id"`
+	SensorIDs []uuid.UUID `json:"sensor_ids"`
+	Start     *time.Time  `json:"start"`
+	End       *time.Time  `json:"end"`
+	Artifacts []string    `json:"artifacts"`
+}
+
+// handleRecompute starts an asynchronous rebuild of derived artifacts
+func (rm *RouteManager) handleRecompute(w http.ResponseWriter, r *http.Request) {
+	var req recomputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	scope := recomputeScope{
+		StationID: req.StationID,
+		SensorIDs: req.SensorIDs,
+		Start:     time.Unix(0, 0).UTC(),
+		End:       time.Now().UTC(),
+		Artifacts: req.Artifacts,
+	}
+	if req.Start != nil {
+		scope.Start = req.Start.UTC()
+	}
+	if req.End != nil {
+		scope.End = req.End.UTC()
+	}
+	if scope.End.Before(scope.Start) {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "start must be before end")
+		return
+	}
+
+	if len(scope.Artifacts) == 0 {
+		scope.Artifacts = recomputeArtifacts
+	}
+	for _, artifact := range scope.Artifacts {
+		if artifact != artifactDailyRollups && artifact != artifactRecords {
+			respondErrorWithDetails(w, http.StatusBadRequest, ErrCodeValidation, "Unknown artifact: "+artifact, map[string]interface{}{
+				"artifacts": recomputeArtifacts,
+			})
+			return
+		}
+	}
+
+	sensorIDs, ok := rm.resolveRecomputeSensors(w, scope)
+	if !ok {
+		return
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeRecompute, recomputeParams{recomputeScope: scope, Sensors: sensorIDs})
+	if err != nil {
+		log.Printf("❌ Failed to queue recompute job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue recompute job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, newRecomputeJob(job))
+}
+
+// resolveRecomputeSensors returns the sensor IDs selected by the scope.
+// It writes an error response and returns false if the scope is invalid.
+func (rm *RouteManager) resolveRecomputeSensors(w http.ResponseWriter, scope recomputeScope) ([]uuid.UUID, bool) {
+	if len(scope.SensorIDs) > 0 {
+		for _, id := range scope.SensorIDs {
+			sensor, err := rm.dbManager.GetSensor(id, false)
+			if err != nil {
+				respondDBError(w, err, "Sensor not found: "+id.String())
+				return nil, false
+			}
+			if scope.StationID != nil && sensor.Sensor.StationID != *scope.StationID {
+				respondError(w, http.StatusBadRequest, ErrCodeValidation, "Sensor "+id.String()+" does not belong to the station")
+				return nil, false
+			}
+		}
+		return scope.SensorIDs, true
+	}
+
+	if scope.StationID != nil {
+		if _, err := rm.dbManager.GetStation(*scope.StationID); err != nil {
+			respondDBError(w, err, "Station not found")
+			return nil, false
+		}
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: scope.StationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return nil, false
+	}
+
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	for _, s := range sensors {
+		sensorIDs = append(sensorIDs, s.Sensor.ID)
+	}
+	return sensorIDs, true
+}
+
+// handleGetRecomputeJobs returns recent recompute jobs, newest first
+func (rm *RouteManager) handleGetRecomputeJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := rm.dbManager.GetJobs(r.Context(), models.JobQueryParams{Type: jobTypeRecompute, Limit: 100})
+	if err != nil {
+		log.Printf("❌ Failed to query jobs: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query jobs")
+		return
+	}
+
+	result := make([]recomputeJob, len(list))
+	for i := range list {
+		result[i] = newRecomputeJob(&list[i])
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleGetRecomputeJob returns the status of a recompute job
+func (rm *RouteManager) handleGetRecomputeJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid job id format")
+		return
+	}
+
+	job, err := rm.dbManager.GetJob(r.Context(), id)
+	if err == nil && job.Type != jobTypeRecompute {
+		err = database.ErrNotFound
+	}
+	if err != nil {
+		respondDBError(w, err, "Recompute job not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, newRecomputeJob(job))
+}
+
+// handleBackup queues a backup job
+func (rm *RouteManager) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if runner := rm.registryManager.JobRunner; runner == nil || !slices.Contains(runner.Types(), jobTypeBackup) {
+		respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Backups are not configured correctly, see the server log")
+		return
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeBackup, struct{}{})
+	if err != nil {
+		log.Printf("❌ Failed to queue backup job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue backup job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// handleGetBackupJobs returns recent backup jobs
+func (rm *RouteManager) handleGetBackupJobs(w http.ResponseWriter, r *http.Request) {
+	rm.respondJobs(w, r, models.JobQueryParams{Type: jobTypeBackup, Limit: 100})
+}
+
+// handleDBMaintenance queues a database maintenance job. The optional body
+// selects the tasks; without it the configured tasks run.
+func (rm *RouteManager) handleDBMaintenance(w http.ResponseWriter, r *http.Request) {
+	if runner := rm.registryManager.JobRunner; runner == nil || !slices.Contains(runner.Types(), jobTypeDBMaintenance) {
+		respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Database maintenance is not configured correctly, see the server log")
+		return
+	}
+
+	var params dbMaintenanceParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if len(params.Tasks) > 0 {
+		tasks, err := models.ParseDBMaintenanceTasks(strings.Join(params.Tasks, ","))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+			return
+		}
+		params.Tasks = tasks
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeDBMaintenance, params)
+	if err != nil {
+		log.Printf("❌ Failed to queue database maintenance job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue database maintenance job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// handleGetDBMaintenanceJobs returns recent database maintenance jobs
+func (rm *RouteManager) handleGetDBMaintenanceJobs(w http.ResponseWriter, r *http.Request) {
+	rm.respondJobs(w, r, models.JobQueryParams{Type: jobTypeDBMaintenance, Limit: 100})
+}
+
+// maxStorageGrowthDays limits the days averaged for the storage growth
+const maxStorageGrowthDays = 365
+
+// handleGetStorage reports table sizes, readings per station and sensor and
+// the projected disk usage. days sets the period the growth is averaged
+// over, 30 by default.
+func (rm *RouteManager) handleGetStorage(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	days := q.Int("days", 30, 1, maxStorageGrowthDays)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	report, err := rm.dbManager.GetStorageReport(r.Context(), days)
+	if err != nil {
+		log.Printf("❌ Failed to get storage report: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to get storage report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleChangeSensorType renames or merges the sensor type of sensors
+func (rm *RouteManager) handleChangeSensorType(w http.ResponseWriter, r *http.Request) {
+	var change models.SensorTypeChange
+	if err := json.NewDecoder(r.Body).Decode(&change); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if err := change.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	result, err := rm.dbManager.ChangeSensorType(r.Context(), change)
+	if err != nil {
+		if errors.Is(err, database.ErrSensorTypeConflict) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, err.Error()+", set merge to move its readings")
+			return
+		}
+		log.Printf("❌ Failed to change sensor type %s to %s: %v", change.From, change.To, err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to change sensor type")
+		return
+	}
+
+	if !change.DryRun {
+		log.Printf("✓ Changed sensor type %s to %s: %d renamed, %d merged", change.From, change.To, len(result.Renamed), len(result.Merged))
+	}
+	respondJSON(w, http.StatusOK, result)
+}
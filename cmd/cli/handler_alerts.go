@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// getAlertsHandler returns the alert rules of all stations with their state
+// Query params:
+//   - active: true to only return raised alerts
+func (rm *RouteManager) getAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	activeOnly := q.Bool("active", false)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	rules, err := rm.dbManager.GetAlertRules(r.Context(), nil)
+	if err != nil {
+		log.Printf("❌ Failed to query alert rules: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query alert rules")
+		return
+	}
+
+	if activeOnly {
+		active := make([]models.AlertRule, 0, len(rules))
+		for _, rule := range rules {
+			if rule.Active {
+				active = append(active, rule)
+			}
+		}
+		rules = active
+	}
+
+	respondJSON(w, http.StatusOK, rules)
+}
+
+// getStationAlertsHandler returns the alert rules of a station
+func (rm *RouteManager) getStationAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	rules, err := rm.dbManager.GetAlertRules(r.Context(), &stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query alert rules: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query alert rules")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rules)
+}
+
+// decodeAlertRule decodes and validates an alert rule of a station. It
+// writes the error response and returns false when the rule is invalid.
+func (rm *RouteManager) decodeAlertRule(w http.ResponseWriter, r *http.Request, rule *models.AlertRule) bool {
+	if err := json.NewDecoder(r.Body).Decode(rule); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return false
+	}
+	if err := rule.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return false
+	}
+	for _, channel := range rule.Channels {
+		if !rm.registryManager.Notifier.Has(channel) {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Notification channel not configured: "+channel)
+			return false
+		}
+	}
+	if rule.Locale != "" {
+		locale, ok := i18n.Parse(rule.Locale)
+		if !ok {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Unsupported locale: "+rule.Locale)
+			return false
+		}
+		rule.Locale = string(locale)
+	}
+	tmpl := notify.Template{Subject: rule.SubjectTemplate, Body: rule.BodyTemplate}
+	if err := tmpl.Validate(alertTemplateFuncs); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return false
+	}
+	return true
+}
+
+// createAlertRuleHandler creates an alert rule of a station
+func (rm *RouteManager) createAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	rule := models.AlertRule{Enabled: true}
+	if !rm.decodeAlertRule(w, r, &rule) {
+		return
+	}
+	rule.StationID = stationID
+
+	if err := rm.dbManager.CreateAlertRule(r.Context(), &rule); err != nil {
+		if errors.Is(err, database.ErrAlertRuleExists) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Alert rule "+rule.Name+" already exists")
+			return
+		}
+		log.Printf("❌ Failed to create alert rule: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	if err := rm.registryManager.AlertPublisher.Announce(r.Context(), rule); err != nil {
+		log.Printf("⚠ Failed to announce alert %s: %v", rule.Name, err)
+	}
+
+	log.Printf("✓ Created alert %s of station %s", rule.Name, stationID)
+	respondJSON(w, http.StatusCreated, rule)
+}
+
+// updateAlertRuleHandler changes an alert rule. A changed condition clears
+// the alert until the next reading is evaluated.
+func (rm *RouteManager) updateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, ruleID, ok := stationAndID(w, r, "alertId")
+	if !ok {
+		return
+	}
+
+	previous, err := rm.dbManager.GetAlertRule(r.Context(), stationID, ruleID)
+	if err != nil {
+		respondDBError(w, err, "Alert rule not found")
+		return
+	}
+
+	// Fields missing in the body keep their current value
+	rule := *previous
+	if !rm.decodeAlertRule(w, r, &rule) {
+		return
+	}
+	rule.StationID = stationID
+	rule.ID = ruleID
+
+	if err := rm.dbManager.UpdateAlertRule(r.Context(), &rule); err != nil {
+		if errors.Is(err, database.ErrAlertRuleExists) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Alert rule "+rule.Name+" already exists")
+			return
+		}
+		log.Printf("❌ Failed to update alert rule: %v", err)
+		respondDBError(w, err, "Alert rule not found")
+		return
+	}
+
+	// A renamed rule is a new entity in Home Assistant
+	publisher := rm.registryManager.AlertPublisher
+	if previous.Name != rule.Name {
+		if err := publisher.Remove(r.Context(), *previous); err != nil {
+			log.Printf("⚠ Failed to remove alert %s: %v", previous.Name, err)
+		}
+	}
+	if err := publisher.Announce(r.Context(), rule); err != nil {
+		log.Printf("⚠ Failed to announce alert %s: %v", rule.Name, err)
+	}
+
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// deleteAlertRuleHandler deletes an alert rule
+func (rm *RouteManager) deleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, ruleID, ok := stationAndID(w, r, "alertId")
+	if !ok {
+		return
+	}
+
+	rule, err := rm.dbManager.DeleteAlertRule(r.Context(), stationID, ruleID)
+	if err != nil {
+		respondDBError(w, err, "Alert rule not found")
+		return
+	}
+
+	if err := rm.registryManager.AlertPublisher.Remove(r.Context(), *rule); err != nil {
+		log.Printf("⚠ Failed to remove alert %s: %v", rule.Name, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// testAlertRuleHandler test-fires an alert rule: synthetic readings moving
+// beyond the threshold are evaluated and a message marked as test is sent
+// through the channels of the rule. The stored state is not changed.
+func (rm *RouteManager) testAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid alert rule ID format")
+		return
+	}
+
+	rule, err := rm.dbManager.GetAlertRuleByID(r.Context(), ruleID)
+	if err != nil {
+		respondDBError(w, err, "Alert rule not found")
+		return
+	}
+	station, err := rm.dbManager.LoadStation(rule.StationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	result := testFireAlertRule(r.Context(), *rule, &station, rm.registryManager.Notifier, rm.registryManager.AlertTemplates, time.Now())
+	log.Printf("✓ Test-fired alert %s of station %s (triggered: %t)", rule.Name, rule.StationID, result.Triggered)
+	respondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ambientMaxRecords is the maximum number of 5 minute records per request,
+// as in the Ambient Weather API
+const ambientMaxRecords = 288
+
+// ambientField maps a sensor type to a field of the Ambient Weather format
+type ambientField struct {
+	name string
+	// convert turns the metric value into the unit of the field
+	convert func(float64) float64
+}
+
+// ambientFields lists the fields of outdoor sensors. Indoor temperature and
+// humidity are mapped by ambientFieldFor.
+var ambientFields = map[string]ambientField{
+	models.SensorTypeTemperatureOutdoor: {"tempf", celsiusToFahrenheit},
+	models.SensorTypeHumidityOutdoor:    {"humidity", math.Round},
+	models.SensorTypePressureRelative:   {"baromrelin", hPaToInHg},
+	models.SensorTypePressure:           {"baromrelin", hPaToInHg},
+	models.SensorTypePressureAbsolute:   {"baromabsin", hPaToInHg},
+	models.SensorTypeWindSpeed:          {"windspeedmph", msToMph},
+	models.SensorTypeWindGust:           {"windgustmph", msToMph},
+	models.SensorTypeWindGustMaxDaily:   {"maxdailygust", msToMph},
+	models.SensorTypeWindDirection:      {"winddir", math.Round},
+	models.SensorTypeWindGustAngle:      {"windgustdir", math.Round},
+	models.SensorTypeRainfallRate:       {"hourlyrainin", mmToInch},
+	models.SensorTypeRainfallEvent:      {"eventrainin", mmToInch},
+	models.SensorTypeRainfallDaily:      {"dailyrainin", mmToInch},
+	models.SensorTypeRainfallWeekly:     {"weeklyrainin", mmToInch},
+	models.SensorTypeRainfallMonthly:    {"monthlyrainin", mmToInch},
+	models.SensorTypeRainfallYearly:     {"yearlyrainin", mmToInch},
+	models.SensorTypeRainfallTotal:      {"totalrainin", mmToInch},
+	models.SensorTypeSolarRadiation:     {"solarradiation", round1},
+	models.SensorTypeUVIndex:            {"uv", math.Round},
+	models.SensorTypePM25:               {"pm25", round1},
+	models.SensorTypeCO2:                {"co2", math.Round},
+}
+
+// ambientDevice is a station in the format of the Ambient Weather devices
+// endpoint
+type ambientDevice struct {
+	MacAddress string                 `json:"macAddress"`
+	Info       ambientDeviceInfo      `json:"info"`
+	LastData   map[string]interface{} `json:"lastData"`
+}
+
+// ambientDeviceInfo describes a station
+type ambientDeviceInfo struct {
+	Name string `json:"name"`
+}
+
+// setupAmbientRoutes configures the endpoints compatible with the Ambient
+// Weather API, so display apps made for it can use WeatherMaestro. Apps
+// send a share token as apiKey; the application key is ignored.
+func (rm *RouteManager) setupAmbientRoutes(r *mux.Router) {
+	r.HandleFunc("/ambient/v1/devices", rm.handleAmbientDevices).Methods("GET")
+	r.HandleFunc("/ambient/v1/devices/{macAddress}", rm.handleAmbientDeviceData).Methods("GET")
+}
+
+// respondAmbientError writes an error in the format of the Ambient Weather API
+func respondAmbientError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// ambientStation authenticates the share token of a request and loads its
+// station. It writes the error response and returns false on failure.
+func (rm *RouteManager) ambientStation(w http.ResponseWriter, r *http.Request) (*models.ShareToken, models.StationData, bool) {
+	apiKey := r.URL.Query().Get("apiKey")
+	if apiKey == "" {
+		respondAmbientError(w, http.StatusUnauthorized, "apiKey-missing")
+		return nil, models.StationData{}, false
+	}
+	shareToken, err := rm.dbManager.AuthenticateShareToken(r.Context(), apiKey)
+	if errors.Is(err, database.ErrNotFound) {
+		respondAmbientError(w, http.StatusUnauthorized, "apiKey-invalid")
+		return nil, models.StationData{}, false
+	}
+	if err != nil {
+		log.Printf("❌ Failed to query share token: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return nil, models.StationData{}, false
+	}
+	station, err := rm.dbManager.LoadStation(shareToken.StationID)
+	if err != nil {
+		log.Printf("❌ Failed to load station %s: %v", shareToken.StationID, err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return nil, models.StationData{}, false
+	}
+	return shareToken, station, true
+}
+
+// handleAmbientDevices lists the station of the share token with its latest
+// readings, like GET /v1/devices of the Ambient Weather API
+func (rm *RouteManager) handleAmbientDevices(w http.ResponseWriter, r *http.Request) {
+	shareToken, station, ok := rm.ambientStation(w, r)
+	if !ok {
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID, IncludeLatest: true})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return
+	}
+	view, err := loadPrivacyView(rm.dbManager)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return
+	}
+
+	var readings []models.SensorReading
+	var visible []models.Sensor
+	for _, s := range view.filterSensors(sharedSensors(shareToken, preferredRainSensors(&station, sensors))) {
+		if s.LatestReading != nil && s.Sensor.Enabled {
+			readings = append(readings, *s.LatestReading)
+			visible = append(visible, s.Sensor)
+		}
+	}
+
+	lastData := map[string]interface{}{}
+	if records := ambientRecords(visible, readings); len(records) > 0 {
+		lastData = records[0]
+	}
+	respondJSON(w, http.StatusOK, []ambientDevice{{
+		MacAddress: station.ID.String(),
+		Info:       ambientDeviceInfo{Name: stationDisplayName(&station)},
+		LastData:   lastData,
+	}})
+}
+
+// handleAmbientDeviceData returns 5 minute records of the station of the
+// share token, newest first, like GET /v1/devices/{macAddress}. The station
+// ID takes the place of the MAC address.
+// Query params:
+//   - limit: number of records (default and max: 288)
+//   - endDate: end of the records (RFC3339 or epoch milliseconds, default: now)
+func (rm *RouteManager) handleAmbientDeviceData(w http.ResponseWriter, r *http.Request) {
+	shareToken, station, ok := rm.ambientStation(w, r)
+	if !ok {
+		return
+	}
+	if id, err := uuid.Parse(mux.Vars(r)["macAddress"]); err != nil || id != station.ID {
+		respondAmbientError(w, http.StatusNotFound, "device-not-found")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := ambientMaxRecords
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondAmbientError(w, http.StatusBadRequest, "limit-invalid")
+			return
+		}
+		limit = min(n, ambientMaxRecords)
+	}
+	end := time.Now().UTC()
+	if v := query.Get("endDate"); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			end = time.UnixMilli(ms).UTC()
+		} else if end, err = time.Parse(time.RFC3339, v); err != nil {
+			respondAmbientError(w, http.StatusBadRequest, "endDate-invalid")
+			return
+		}
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return
+	}
+	view, err := loadPrivacyView(rm.dbManager)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return
+	}
+
+	var visible []models.Sensor
+	for _, s := range view.filterSensors(sharedSensors(shareToken, preferredRainSensors(&station, sensors))) {
+		if _, ok := ambientFieldFor(s.Sensor); ok && s.Sensor.Enabled {
+			visible = append(visible, s.Sensor)
+		}
+	}
+
+	series, err := getAveragedSensorReadings(r.Context(), rm.dbManager, visible, end.Add(-time.Duration(limit)*5*time.Minute), end, "5m")
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondAmbientError(w, http.StatusInternalServerError, "database-error")
+		return
+	}
+	var readings []models.SensorReading
+	for _, s := range visible {
+		for _, reading := range series[s.ID] {
+			reading.Value = view.round(s.ID, reading.Value)
+			readings = append(readings, reading)
+		}
+	}
+
+	records := ambientRecords(visible, readings)
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	respondJSON(w, http.StatusOK, records)
+}
+
+// ambientFieldFor returns the Ambient Weather field of a sensor. Temperature
+// and humidity sensors not placed outdoors are indoor values.
+func ambientFieldFor(sensor models.Sensor) (ambientField, bool) {
+	outdoor := strings.EqualFold(sensor.Location, "outdoor")
+	switch sensor.SensorType {
+	case models.SensorTypeTemperature:
+		if outdoor {
+			return ambientFields[models.SensorTypeTemperatureOutdoor], true
+		}
+		return ambientField{"tempinf", celsiusToFahrenheit}, true
+	case models.SensorTypeHumidity:
+		if outdoor {
+			return ambientFields[models.SensorTypeHumidityOutdoor], true
+		}
+		return ambientField{"humidityin", math.Round}, true
+	}
+	field, ok := ambientFields[sensor.SensorType]
+	return field, ok
+}
+
+// ambientRecords groups readings by time into records of the Ambient
+// Weather format, newest first. The first sensor of a field wins; dew point
+// and feels like temperature are derived from the outdoor values.
+func ambientRecords(sensors []models.Sensor, readings []models.SensorReading) []map[string]interface{} {
+	fields := make(map[uuid.UUID]ambientField, len(sensors))
+	order := make(map[uuid.UUID]int, len(sensors))
+	for i, s := range sensors {
+		if field, ok := ambientFieldFor(s); ok {
+			fields[s.ID] = field
+			order[s.ID] = i
+		}
+	}
+
+	// The latest readings of different sensors differ by seconds, so they
+	// are combined into one record of the newest time
+	byTime := make(map[int64]map[string]float64)
+	owner := make(map[int64]map[string]int)
+	var times []int64
+	for _, reading := range readings {
+		field, ok := fields[reading.SensorID]
+		if !ok {
+			continue
+		}
+		t := reading.DateUTC.UnixMilli()
+		values, ok := byTime[t]
+		if !ok {
+			values = make(map[string]float64)
+			byTime[t] = values
+			owner[t] = make(map[string]int)
+			times = append(times, t)
+		}
+		if prev, taken := owner[t][field.name]; taken && prev <= order[reading.SensorID] {
+			continue
+		}
+		owner[t][field.name] = order[reading.SensorID]
+		values[field.name] = field.convert(reading.Value)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] > times[j] })
+
+	if len(readings) > 0 && isLatestSnapshot(readings) {
+		merged := make(map[string]float64)
+		for i := len(times) - 1; i >= 0; i-- {
+			for k, v := range byTime[times[i]] {
+				merged[k] = v
+			}
+		}
+		byTime = map[int64]map[string]float64{times[0]: merged}
+		times = times[:1]
+	}
+
+	records := make([]map[string]interface{}, 0, len(times))
+	for _, t := range times {
+		values := byTime[t]
+		record := map[string]interface{}{
+			"dateutc": t,
+			"date":    time.UnixMilli(t).UTC().Format("2006-01-02T15:04:05.000Z"),
+		}
+		for k, v := range values {
+			record[k] = v
+		}
+		if tempF, ok := values["tempf"]; ok {
+			if humidity, ok := values["humidity"]; ok && humidity > 0 {
+				record["dewPoint"] = round1(celsiusToFahrenheit(dewPoint(fahrenheitToCelsius(tempF), humidity)))
+				wind := values["windspeedmph"]
+				record["feelsLike"] = round1(feelsLike(tempF, humidity, wind))
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// isLatestSnapshot reports whether the readings are one latest reading per
+// sensor rather than a time series
+func isLatestSnapshot(readings []models.SensorReading) bool {
+	seen := make(map[uuid.UUID]bool, len(readings))
+	for _, reading := range readings {
+		if seen[reading.SensorID] {
+			return false
+		}
+		seen[reading.SensorID] = true
+	}
+	return true
+}
+
+// dewPoint returns the dew point in °C with the Magnus formula
+func dewPoint(tempC, humidity float64) float64 {
+	const b, c = 17.625, 243.04
+	gamma := math.Log(humidity/100) + b*tempC/(c+tempC)
+	return c * gamma / (b - gamma)
+}
+
+// feelsLike returns the wind chill below 50 °F with wind, the heat index
+// above 80 °F and the temperature otherwise, as used by Ambient Weather
+func feelsLike(tempF, humidity, windMph float64) float64 {
+	switch {
+	case tempF <= 50 && windMph > 3:
+		v := math.Pow(windMph, 0.16)
+		return 35.74 + 0.6215*tempF - 35.75*v + 0.4275*tempF*v
+	case tempF >= 80:
+		t, h := tempF, humidity
+		return -42.379 + 2.04901523*t + 10.14333127*h - 0.22475541*t*h - 0.00683783*t*t -
+			0.05481717*h*h + 0.00122874*t*t*h + 0.00085282*t*h*h - 0.00000199*t*t*h*h
+	}
+	return tempF
+}
+
+func celsiusToFahrenheit(c float64) float64 { return round1(c*9/5 + 32) }
+func fahrenheitToCelsius(f float64) float64 { return (f - 32) * 5 / 9 }
+func hPaToInHg(hPa float64) float64         { return math.Round(hPa/33.8639*100) / 100 }
+func msToMph(ms float64) float64            { return round1(ms / 0.44704) }
+func mmToInch(mm float64) float64           { return math.Round(mm/25.4*1000) / 1000 }
+func round1(v float64) float64              { return math.Round(v*10) / 10 }
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// defaultDivergenceThresholds is the difference per sensor type above which
+// co-located sensors are considered to disagree
+var defaultDivergenceThresholds = map[string]float64{
+	models.SensorTypeTemperature:      1.0,
+	models.SensorTypeHumidity:         5.0,
+	models.SensorTypePressureRelative: 1.0,
+	models.SensorTypePressureAbsolute: 1.0,
+	models.SensorTypeWindSpeed:        1.0,
+	models.SensorTypeWindGust:         2.0,
+	models.SensorTypeWindDirection:    30.0,
+	models.SensorTypeSolarRadiation:   50.0,
+}
+
+// alignmentIntervals are the bucket sizes supported for aligning series
+var alignmentIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"12h": 12 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// crossValidationGroup is the comparison of sensors measuring the same
+// quantity at the same location
+type crossValidationGroup struct {
+	SensorType string                         `json:"sensor_type"`
+	Category   string                         `json:"category"`
+	Location   string                         `json:"location"`
+	Unit       string                         `json:"unit,omitempty"`
+	Threshold  float64                        `json:"threshold"`
+	Sensors    []models.Sensor                `json:"sensors"`
+	Report     analysis.CrossValidationReport `json:"report"`
+}
+
+// crossValidationMeta describes the compared time range
+type crossValidationMeta struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Interval string    `json:"interval"`
+}
+
+// handleStationCrossValidation compares co-located sensors of a station.
+// Sensors are grouped by measured quantity and location; only groups with at
+// least two sensors are reported.
+// Query params:
+//   - sensor_id: compare only these sensors (comma-separated list)
+//   - category: only compare sensors of this category (e.g. Temperature)
+//   - start: start time (RFC3339, default: 7 days ago)
+//   - end: end time (RFC3339, default: now)
+//   - interval: alignment interval (1m, 5m, 15m, 30m, 1h, 6h, 12h, 1d, default: 15m)
+//   - threshold: divergence threshold (default depends on the sensor type)
+//   - min_duration: minimum length of a divergence period (default: 1h)
+func (rm *RouteManager) handleStationCrossValidation(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+
+	now := time.Now().UTC()
+	start := q.Time("start", now.Add(-7*24*time.Hour))
+	end := q.Time("end", now)
+	if q.Err() == nil && !start.Before(end) {
+		q.Invalid("start", "start must be before end")
+	}
+
+	interval := q.String("interval")
+	if interval == "" {
+		interval = "15m"
+	}
+	intervalDuration, ok := alignmentIntervals[interval]
+	if !ok {
+		q.Invalid("interval", "invalid interval: %s", interval)
+	}
+
+	var threshold *float64
+	if q.String("threshold") != "" {
+		if t := q.Float("threshold", 0); t < 0 {
+			q.Invalid("threshold", "threshold must not be negative")
+		} else {
+			threshold = &t
+		}
+	}
+
+	minDuration := q.Duration("min_duration", time.Hour)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	groups := groupCoLocatedSensors(view.filterSensors(sensors), parseSensorIDList(q.String("sensor_id")), q.String("category"))
+
+	var groupSensors []models.Sensor
+	for _, g := range groups {
+		groupSensors = append(groupSensors, g.Sensors...)
+	}
+
+	series, err := getAveragedSensorReadings(r.Context(), rm.dbManager, groupSensors, start, end, interval)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+	view.roundSeries(series)
+
+	for i := range groups {
+		g := &groups[i]
+		g.Threshold = defaultDivergenceThresholds[g.SensorType]
+		if threshold != nil {
+			g.Threshold = *threshold
+		}
+
+		input := make([]analysis.Series, 0, len(g.Sensors))
+		for _, s := range g.Sensors {
+			points := make([]analysis.Point, 0, len(series[s.ID]))
+			for _, reading := range series[s.ID] {
+				points = append(points, analysis.Point{Time: reading.DateUTC, Value: reading.Value})
+			}
+			input = append(input, analysis.Series{SensorID: s.ID, Points: points})
+		}
+
+		g.Report = analysis.CrossValidate(input, analysis.CrossValidationOptions{
+			Threshold:     g.Threshold,
+			MinDivergence: minDuration,
+			Interval:      intervalDuration,
+			Circular:      models.IsDirection(g.SensorType),
+		})
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, groups, crossValidationMeta{
+		Start:    start,
+		End:      end,
+		Interval: interval,
+	})
+}
+
+// getAveragedSensorReadings returns the per-bucket averages of sensors,
+// with the vector mean for directions
+func getAveragedSensorReadings(ctx context.Context, dbManager *database.DatabaseManager, sensors []models.Sensor, start, end time.Time, interval string) (map[uuid.UUID][]models.SensorReading, error) {
+	var linear, circular []uuid.UUID
+	for _, s := range sensors {
+		if models.IsDirection(s.SensorType) {
+			circular = append(circular, s.ID)
+		} else {
+			linear = append(linear, s.ID)
+		}
+	}
+
+	series, err := dbManager.GetAveragedReadings(ctx, linear, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	directions, err := dbManager.GetCircularAveragedReadings(ctx, circular, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	for id, readings := range directions {
+		series[id] = readings
+	}
+	return series, nil
+}
+
+// groupCoLocatedSensors groups sensors by measured quantity and location and
+// drops groups with fewer than two sensors. Indoor and outdoor variants of a
+// sensor type (e.g. Temperature and TemperatureOutdoor) measure the same
+// quantity, so the location decides whether they are compared.
+func groupCoLocatedSensors(sensors []models.SensorWithLatestReading, only []uuid.UUID, category string) []crossValidationGroup {
+	selected := make(map[uuid.UUID]bool, len(only))
+	for _, id := range only {
+		selected[id] = true
+	}
+
+	type groupKey struct{ sensorType, location string }
+	byKey := make(map[groupKey]*crossValidationGroup)
+	var keys []groupKey
+
+	for _, s := range sensors {
+		if len(selected) > 0 && !selected[s.Sensor.ID] {
+			continue
+		}
+		info, ok := models.SensorTypeRegistry[s.Sensor.SensorType]
+		if !ok || info.Category == models.SensorCategorySystem {
+			continue
+		}
+		if category != "" && !strings.EqualFold(info.Category, category) {
+			continue
+		}
+
+		sensorType := strings.TrimSuffix(s.Sensor.SensorType, "Outdoor")
+		k := groupKey{sensorType: sensorType, location: strings.ToLower(s.Sensor.Location)}
+		g, ok := byKey[k]
+		if !ok {
+			g = &crossValidationGroup{SensorType: sensorType, Category: info.Category, Location: s.Sensor.Location, Unit: info.Unit}
+			byKey[k] = g
+			keys = append(keys, k)
+		}
+		g.Sensors = append(g.Sensors, s.Sensor)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].sensorType != keys[j].sensorType {
+			return keys[i].sensorType < keys[j].sensorType
+		}
+		return keys[i].location < keys[j].location
+	})
+
+	groups := []crossValidationGroup{}
+	for _, k := range keys {
+		if g := byKey[k]; len(g.Sensors) >= 2 {
+			groups = append(groups, *g)
+		}
+	}
+	return groups
+}
+
+// parseSensorIDList parses a comma-separated list of sensor IDs
+func parseSensorIDList(value string) []uuid.UUID {
+	var ids []uuid.UUID
+	if value == "" {
+		return ids
+	}
+	for _, idStr := range strings.Split(value, ",") {
+		if id, err := uuid.Parse(strings.TrimSpace(idStr)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sensorCompleteness is the completeness report of a single sensor
+type sensorCompleteness struct {
+	Sensor models.Sensor `json:"sensor"`
+	analysis.CompletenessReport
+}
+
+// completenessMeta describes the reported period
+type completenessMeta struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Period   string    `json:"period"`
+	Expected int       `json:"expected"`
+	Received int       `json:"received"`
+}
+
+// handleStationCompleteness reports expected vs. received readings per sensor
+// of a station, including the largest gaps.
+// Query params:
+//   - period: length of the reported period (e.g. 24h, 7d, 30d, default: 30d)
+//   - end: end of the period (RFC3339, default: now)
+//   - interval: expected reporting interval (e.g. 60s, default: estimated per sensor)
+//   - gaps: number of largest gaps reported per sensor (default: 5, max: 50)
+func (rm *RouteManager) handleStationCompleteness(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	period := q.String("period")
+	if period == "" {
+		period = "30d"
+	}
+	periodDuration := q.Period("period", 30*24*time.Hour)
+	end := q.Time("end", time.Now().UTC())
+	start := end.Add(-periodDuration)
+	interval := q.Duration("interval", 0)
+	maxGaps := q.Int("gaps", 5, 0, 50)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	sensors = view.filterSensors(sensors)
+
+	// Sensors added during the period are only expected to report since then
+	counters := make(map[uuid.UUID]*analysis.CompletenessCounter, len(sensors))
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	for _, s := range sensors {
+		sensorStart := start
+		if s.Sensor.CreatedAt.After(sensorStart) {
+			sensorStart = s.Sensor.CreatedAt.UTC()
+		}
+		if sensorStart.After(end) {
+			sensorStart = end
+		}
+		counters[s.Sensor.ID] = analysis.NewCompletenessCounter(sensorStart, end, maxGaps)
+		sensorIDs = append(sensorIDs, s.Sensor.ID)
+	}
+
+	err = rm.dbManager.ForEachReadingTime(r.Context(), sensorIDs, start, end, func(sensorID uuid.UUID, dateUTC time.Time) {
+		if c, ok := counters[sensorID]; ok {
+			c.Add(dateUTC)
+		}
+	})
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	meta := completenessMeta{Start: start, End: end, Period: period}
+	result := make([]sensorCompleteness, 0, len(sensors))
+	for _, s := range sensors {
+		report := counters[s.Sensor.ID].Result(interval)
+		meta.Expected += report.Expected
+		meta.Received += report.Received
+		result = append(result, sensorCompleteness{Sensor: s.Sensor, CompletenessReport: report})
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, result, meta)
+}
+
+// handleStationTendency reports the pressure tendency and wind trend of a
+// station over the last three hours and the storm event they raise
+// Query params:
+//   - at: end of the window (RFC3339, default: now)
+func (rm *RouteManager) handleStationTendency(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	at := q.Time("at", time.Now().UTC())
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	detector := newStormDetector(rm.dbManager)
+	detector.view = view
+	tendency, err := detector.Tendency(r.Context(), stationID, at)
+	if err != nil {
+		log.Printf("❌ Failed to compute tendency: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tendency)
+}
+
+// parsePeriod parses a period like "30d", "2w" or any Go duration ("12h")
+func parsePeriod(value string) (time.Duration, error) {
+	var d time.Duration
+	switch {
+	case strings.HasSuffix(value, "d"), strings.HasSuffix(value, "w"):
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid period: %s", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		if strings.HasSuffix(value, "w") {
+			d *= 7
+		}
+	default:
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid period: %s", value)
+		}
+	}
+
+	if d <= 0 || d > 366*24*time.Hour {
+		return 0, fmt.Errorf("period must be between 1s and 366d")
+	}
+	return d, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// createAPIKeyRequest is the body of POST /keys
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// createAPIKeyResponse contains the new key, which is only shown once
+type createAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// handleGetAPIKeys lists all API keys without their secrets
+func (rm *RouteManager) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := rm.dbManager.GetAPIKeys(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to query API keys: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query API keys")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, keys)
+}
+
+// handleCreateAPIKey creates a key with the requested scopes
+func (rm *RouteManager) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "name is required")
+		return
+	}
+	if err := models.ValidateScopes(req.Scopes); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	apiKey, key, err := rm.dbManager.CreateAPIKey(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		log.Printf("❌ Failed to create API key: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to create API key")
+		return
+	}
+
+	log.Printf("✓ Created API key %s (%s) with scopes %v", apiKey.Name, apiKey.Prefix, apiKey.Scopes)
+	respondJSON(w, http.StatusCreated, createAPIKeyResponse{APIKey: *apiKey, Key: key})
+}
+
+// handleRevokeAPIKey revokes a key; it stays listed with its revocation time
+func (rm *RouteManager) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid key id format")
+		return
+	}
+
+	// Keys must not revoke themselves by accident
+	if current := GetAPIKeyFromContext(r.Context()); current != nil && current.ID == id {
+		respondError(w, http.StatusConflict, ErrCodeConflict, "API key cannot revoke itself")
+		return
+	}
+
+	apiKey, err := rm.dbManager.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		respondDBError(w, err, "API key not found")
+		return
+	}
+
+	log.Printf("✓ Revoked API key %s (%s)", apiKey.Name, apiKey.Prefix)
+	respondJSON(w, http.StatusOK, apiKey)
+}
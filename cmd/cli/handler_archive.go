@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// archiveConfigured reports whether the archive jobs are registered and
+// responds 503 otherwise
+func (rm *RouteManager) archiveConfigured(w http.ResponseWriter) bool {
+	if runner := rm.registryManager.JobRunner; runner == nil || !slices.Contains(runner.Types(), jobTypeArchive) {
+		respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "The archive is not configured, see the server log")
+		return false
+	}
+	return true
+}
+
+// handleArchive queues an archive run
+func (rm *RouteManager) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if !rm.archiveConfigured(w) {
+		return
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeArchive, struct{}{})
+	if err != nil {
+		log.Printf("❌ Failed to queue archive job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue archive job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
+
+// handleGetArchives returns the archived months
+func (rm *RouteManager) handleGetArchives(w http.ResponseWriter, r *http.Request) {
+	archives, err := rm.dbManager.GetReadingArchives(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to load archives: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load archives")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, archives)
+}
+
+// handleRestoreArchive queues the restore of an archived month
+func (rm *RouteManager) handleRestoreArchive(w http.ResponseWriter, r *http.Request) {
+	if !rm.archiveConfigured(w) {
+		return
+	}
+	month, err := models.ParseArchiveMonth(mux.Vars(r)["month"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeBadRequest, err.Error())
+		return
+	}
+	archive, err := rm.dbManager.GetReadingArchive(r.Context(), month)
+	if err != nil {
+		respondDBError(w, err, "Archive not found")
+		return
+	}
+	if archive.RestoredAt != nil {
+		respondError(w, http.StatusConflict, ErrCodeConflict, "The month is already restored")
+		return
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeRestore, archiveRestoreParams{Month: mux.Vars(r)["month"]})
+	if err != nil {
+		log.Printf("❌ Failed to queue restore job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue restore job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, job)
+}
@@ -2,125 +2,313 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// OTP is the TOTP or recovery code of users with two-factor authentication
+	OTP string `json:"otp,omitempty"`
 }
 
 type LoginResponse struct {
-	Success   bool      `json:"success"`
-	Token     string    `json:"token,omitempty"`
-	ExpiresAt time.Time `json:"expires_at,omitempty"`
-	User      UserInfo  `json:"user,omitempty"`
-	Message   string    `json:"message,omitempty"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	User         UserInfo  `json:"user"`
 }
 
 type UserInfo struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
+	Locale   string `json:"locale"`
+	// DeletionScheduledAt is set while a deletion request is pending
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
 }
 
 func (rm *RouteManager) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Message: "Invalid request body",
-		})
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
 		return
 	}
 
 	// Validate credentials
 	user, err := rm.dbManager.ValidateUser(r.Context(), req.Username, req.Password)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Message: "Invalid username or password",
-		})
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid username or password")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		if req.OTP == "" {
+			respondError(w, http.StatusUnauthorized, ErrCodeOTPRequired, "One-time code required")
+			return
+		}
+		if err := rm.dbManager.VerifyOTP(r.Context(), user.ID, req.OTP); err != nil {
+			if errors.Is(err, database.ErrInvalidOTP) {
+				respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid one-time code")
+				return
+			}
+			if errors.Is(err, database.ErrOTPLocked) {
+				log.Printf("❌ One-time codes of user %s locked after too many invalid codes", user.Username)
+				respondOTPLocked(w)
+				return
+			}
+			log.Printf("❌ Failed to verify one-time code: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to verify one-time code")
+			return
+		}
+	}
+
+	// Start a session, which can be listed and revoked
+	session, refreshToken, err := rm.dbManager.CreateSession(r.Context(), user.ID, r.UserAgent(), rm.clientIP(r), getEnvDuration("AUTH_SESSION_TTL", 30*24*time.Hour))
+	if err != nil {
+		log.Printf("❌ Failed to create session: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to create session")
 		return
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := GenerateJWT(user)
+	rm.respondToken(w, user, session.ID, refreshToken)
+}
+
+// respondToken issues a JWT for a session
+func (rm *RouteManager) respondToken(w http.ResponseWriter, user *models.User, sessionID uuid.UUID, refreshToken string) {
+	token, expiresAt, err := GenerateJWT(user, sessionID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Message: "Failed to generate token",
-		})
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to generate token")
 		return
 	}
 
-	// Return success response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(LoginResponse{
-		Success:   true,
-		Token:     token,
-		ExpiresAt: expiresAt,
+	respondJSON(w, http.StatusOK, LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
 		User: UserInfo{
 			ID:       user.ID.String(),
 			Username: user.Username,
+			Locale:   user.Locale,
 		},
 	})
 }
 
+// handleLogout revokes the session of the token
 func (rm *RouteManager) handleLogout(w http.ResponseWriter, r *http.Request) {
-	// With JWT, logout is handled client-side by removing the token
-	// Optionally, you could implement a token blacklist here
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	if tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		if user, sessionID, err := rm.authenticateJWT(r.Context(), tokenString); err == nil {
+			if err := rm.dbManager.RevokeSession(r.Context(), user.ID, sessionID); err != nil {
+				log.Printf("❌ Failed to revoke session: %v", err)
+			}
+		}
+	}
+
+	// Clients remove the token anyway
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
 }
 
 func (rm *RouteManager) handleMe(w http.ResponseWriter, r *http.Request) {
 	user := GetUserFromContext(r.Context())
 	if user == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// The token only carries the user ID and name
+	stored, err := rm.dbManager.GetUser(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to query user: %v", err)
+		respondDBError(w, err, "User not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(UserInfo{
-		ID:       user.ID.String(),
-		Username: user.Username,
+	respondJSON(w, http.StatusOK, UserInfo{
+		ID:                  stored.ID.String(),
+		Username:            stored.Username,
+		Locale:              stored.Locale,
+		DeletionScheduledAt: stored.DeletionScheduledAt,
 	})
 }
 
+// handleRefreshToken issues a new JWT. With a refresh token in the body the
+// refresh token is rotated as well, otherwise the current JWT is required.
 func (rm *RouteManager) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		session, refreshToken, err := rm.dbManager.RefreshSession(r.Context(), req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid or revoked refresh token")
+				return
+			}
+			log.Printf("❌ Failed to refresh session: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to refresh session")
+			return
+		}
+		user, err := rm.dbManager.GetUser(r.Context(), session.UserID)
+		if err != nil {
+			respondDBError(w, err, "User not found")
+			return
+		}
+		rm.respondToken(w, user, session.ID, refreshToken)
+		return
+	}
+
+	// Generate new token for the session of the current one
+	rm.JWTAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rm.respondToken(w, GetUserFromContext(r.Context()), GetSessionIDFromContext(r.Context()), "")
+	})).ServeHTTP(w, r)
+}
+
+// handleGetSessions lists the active sessions of the user
+func (rm *RouteManager) handleGetSessions(w http.ResponseWriter, r *http.Request) {
 	user := GetUserFromContext(r.Context())
-	if user == nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+	sessions, err := rm.dbManager.GetSessions(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Failed to query sessions: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sessions")
 		return
 	}
 
-	// Generate new token
-	token, expiresAt, err := GenerateJWT(user)
+	current := GetSessionIDFromContext(r.Context())
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+// handleRevokeSession logs out one of the user's sessions
+func (rm *RouteManager) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Message: "Failed to generate token",
-		})
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid session id format")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(LoginResponse{
-		Success:   true,
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User: UserInfo{
-			ID:       user.ID.String(),
-			Username: user.Username,
-		},
-	})
+	user := GetUserFromContext(r.Context())
+	if err := rm.dbManager.RevokeSession(r.Context(), user.ID, id); err != nil {
+		respondDBError(w, err, "Session not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// handleChangePassword sets a new password after checking the current one.
+// All other sessions of the user are logged out.
+func (rm *RouteManager) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.NewPassword == "" {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "new_password is required")
+		return
+	}
+
+	user := GetUserFromContext(r.Context())
+	if _, err := rm.dbManager.ValidateUser(r.Context(), user.Username, req.CurrentPassword); err != nil {
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Current password is incorrect")
+		return
+	}
+
+	if err := rm.dbManager.SetUserPassword(r.Context(), user.ID, req.NewPassword); err != nil {
+		log.Printf("❌ Failed to change password: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to change password")
+		return
+	}
+	revoked, err := rm.dbManager.RevokeUserSessions(r.Context(), user.ID, GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		log.Printf("❌ Failed to revoke sessions: %v", err)
+	}
+
+	log.Printf("✓ Password of user %s changed, %d other sessions revoked", user.Username, revoked)
+	respondJSON(w, http.StatusOK, map[string]interface{}{"success": true, "revoked_sessions": revoked})
+}
+
+// handleResetPassword sets a new password with a reset token issued by an
+// admin
+func (rm *RouteManager) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "token and new_password are required")
+		return
+	}
+
+	user, err := rm.dbManager.ResetPassword(r.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid or expired reset token")
+			return
+		}
+		log.Printf("❌ Failed to reset password: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to reset password")
+		return
+	}
+
+	log.Printf("✓ Password of user %s reset", user.Username)
+	respondJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// clientIP returns the address of the client without port. Behind trusted
+// proxies the last address in X-Forwarded-For that is not a trusted proxy
+// is used.
+func (rm *RouteManager) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if len(rm.trustedProxies) == 0 || !rm.trustedProxies.Contains(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(addr) == nil {
+			break
+		}
+		host = addr
+		if !rm.trustedProxies.Contains(addr) {
+			break
+		}
+	}
+	return host
 }
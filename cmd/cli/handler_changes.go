@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// changesSettleDelay is the time readings must be stored before they are
+// part of the changes feed. Storage times have second precision and
+// inserts are buffered, so a reading stored later in the same second could
+// otherwise end up behind a watermark a consumer already passed.
+const changesSettleDelay = 10 * time.Second
+
+// stationWatermark is the newest observation of a station and the watermark
+// of its changes feed
+type stationWatermark struct {
+	StationID uuid.UUID  `json:"station_id"`
+	DateUTC   *time.Time `json:"date_utc"`
+	ReadingID *uuid.UUID `json:"reading_id"`
+	// Watermark is the position after the last stored reading, empty
+	// without readings
+	Watermark models.ReadingWatermark `json:"watermark"`
+}
+
+// changesMeta describes a page of the changes feed
+type changesMeta struct {
+	Since     models.ReadingWatermark `json:"since"`
+	Watermark models.ReadingWatermark `json:"watermark"`
+	Count     int                     `json:"count"`
+	HasMore   bool                    `json:"has_more"`
+}
+
+// getStationWatermarkHandler returns the newest observation time of a
+// station with its reading ID and the watermark of its changes feed
+func (rm *RouteManager) getStationWatermarkHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, sensorIDs, ok := rm.changesSensors(w, r)
+	if !ok {
+		return
+	}
+
+	result := stationWatermark{StationID: stationID}
+	latest, err := rm.dbManager.GetLatestReadingOfSensors(r.Context(), sensorIDs)
+	if err != nil {
+		log.Printf("❌ Failed to query latest reading: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+	if latest != nil {
+		result.DateUTC, result.ReadingID = &latest.DateUTC, &latest.ID
+	}
+	result.Watermark, err = rm.dbManager.GetReadingWatermark(r.Context(), sensorIDs, time.Now().Add(-changesSettleDelay))
+	if err != nil {
+		log.Printf("❌ Failed to query watermark: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// getStationChangesHandler returns the readings of a station stored after a
+// watermark in the order they were stored, regardless of their observation
+// time, so incremental consumers also receive backfilled readings.
+// Query params:
+//   - since: watermark of the last page (default: from the first reading)
+//   - sensor_id: limit to these sensors (comma separated)
+//   - limit: readings per page (default: 1000, max: 10000)
+func (rm *RouteManager) getStationChangesHandler(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	since, err := models.ParseReadingWatermark(q.String("since"))
+	if err != nil {
+		q.Invalid("since", "since must be a watermark of the changes feed")
+	}
+	limit := q.Int("limit", 1000, 1, 10000)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	_, sensorIDs, ok := rm.changesSensors(w, r)
+	if !ok {
+		return
+	}
+
+	changes, err := rm.dbManager.GetReadingChanges(r.Context(), sensorIDs, since, time.Now().Add(-changesSettleDelay), limit+1)
+	if err != nil {
+		log.Printf("❌ Failed to query reading changes: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	meta := changesMeta{Since: since, Watermark: since, HasMore: len(changes) > limit}
+	if meta.HasMore {
+		changes = changes[:limit]
+	}
+	if len(changes) > 0 {
+		meta.Watermark = changes[len(changes)-1].Watermark()
+	} else {
+		changes = []models.ReadingChange{}
+	}
+	meta.Count = len(changes)
+
+	respondJSONWithMeta(w, http.StatusOK, changes, meta)
+}
+
+// changesSensors returns the station of the path and its sensors, limited to
+// the sensor_id query params. It responds with an error if the station or a
+// sensor doesn't exist.
+func (rm *RouteManager) changesSensors(w http.ResponseWriter, r *http.Request) (uuid.UUID, []uuid.UUID, bool) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return uuid.Nil, nil, false
+	}
+	q := newQueryParams(r)
+	selected := q.UUIDs("sensor_id")
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return uuid.Nil, nil, false
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return uuid.Nil, nil, false
+	}
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return uuid.Nil, nil, false
+	}
+
+	stationSensors := make(map[uuid.UUID]bool, len(sensors))
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	for _, s := range sensors {
+		stationSensors[s.Sensor.ID] = true
+		sensorIDs = append(sensorIDs, s.Sensor.ID)
+	}
+	if len(selected) == 0 {
+		return stationID, sensorIDs, true
+	}
+	for _, id := range selected {
+		if !stationSensors[id] {
+			respondError(w, http.StatusNotFound, ErrCodeNotFound, "Sensor not found")
+			return uuid.Nil, nil, false
+		}
+	}
+	return stationID, selected, true
+}
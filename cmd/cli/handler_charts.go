@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/chart"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// chartIntervals are the aggregation intervals a chart picks from, finest first
+var chartIntervals = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+	{"12h", 12 * time.Hour},
+	{"1d", 24 * time.Hour},
+	{"1w", 7 * 24 * time.Hour},
+	{"1M", 30 * 24 * time.Hour},
+}
+
+// handleGetChart renders a line chart of sensor readings as PNG or SVG.
+// Charts can be embedded in static websites and emails without JavaScript.
+// Query params:
+//   - sensor_id: sensors to plot (comma-separated list)
+//   - station_id: plot sensors of this station, filtered by sensor_type and location
+//   - sensor_type: filter by sensor type
+//   - location: filter by sensor location
+//   - start: start time (RFC3339, default: end - period)
+//   - end: end time (RFC3339, default: now)
+//   - period: time range when start is not set (e.g. 24h, 7d, 4w, default: 24h)
+//   - aggregate: aggregation interval (default: chosen from the time range and width)
+//   - aggregate_func: aggregation function (default: avg)
+//   - format: png or svg (default: png)
+//   - width, height: image size in pixels (default: 800x400)
+//   - title: chart title
+//   - tz: IANA time zone of the time axis labels (default: UTC)
+func (rm *RouteManager) handleGetChart(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	format := q.OneOf("format", "png", "png", "svg")
+	width := q.Int("width", chart.DefaultWidth, 100, 2000)
+	height := q.Int("height", chart.DefaultHeight, 100, 2000)
+
+	loc := time.UTC
+	if tz := q.String("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			q.Invalid("tz", "invalid time zone: %s", tz)
+			loc = time.UTC
+		}
+	}
+
+	end := q.Time("end", time.Now().UTC())
+	start := end.Add(-24 * time.Hour)
+	if q.String("start") != "" {
+		start = q.Time("start", start)
+	} else if v := q.String("period"); v != "" {
+		if period, err := parsePeriod(v); err != nil {
+			q.Invalid("period", "%s", err)
+		} else {
+			start = end.Add(-period)
+		}
+	}
+	if q.Err() == nil && !start.Before(end) {
+		q.Invalid("start", "start must be before end")
+	}
+
+	params, err := parseReadingQueryParams(r)
+	q.Check(err)
+	if len(params.SensorIDs) == 0 && params.StationID == nil && !q.errs.Has("sensor_id") && !q.errs.Has("station_id") {
+		q.Invalid("sensor_id", "sensor_id or station_id is required")
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	params.StartTime = start.UTC().Format(time.RFC3339)
+	params.EndTime = end.UTC().Format(time.RFC3339)
+	params.GroupBy = "sensor"
+	params.Order = "asc"
+	params.Limit = 10000
+	params.Page = 1
+	if params.Aggregate == "" {
+		params.Aggregate = chartInterval(end.Sub(start), width)
+	}
+	if params.AggregateFunc == "" {
+		params.AggregateFunc = "avg"
+	}
+
+	if err := params.Validate(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	view.restrictQuery(&params)
+
+	result, err := rm.dbManager.GetAggregatedReadings(params)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+	view.applyReadings(result, params)
+
+	c, err := buildChart(rm.dbManager, result.Data.([]models.AggregatedReading))
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+	c.Title = q.String("title")
+	c.Width = width
+	c.Height = height
+	c.Location = loc
+
+	// Render into a buffer so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+		err = c.RenderSVG(&buf)
+	} else {
+		err = c.RenderPNG(&buf)
+	}
+	if err != nil {
+		log.Printf("❌ Failed to render chart: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to render chart")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// buildChart turns aggregated readings grouped by sensor into chart series
+func buildChart(dbManager *database.DatabaseManager, readings []models.AggregatedReading) (*chart.Chart, error) {
+	bySensor := make(map[uuid.UUID][]chart.Point)
+	var sensorIDs []uuid.UUID
+	for _, reading := range readings {
+		if _, ok := bySensor[reading.SensorID]; !ok {
+			sensorIDs = append(sensorIDs, reading.SensorID)
+		}
+		bySensor[reading.SensorID] = append(bySensor[reading.SensorID], chart.Point{Time: reading.DateUTC, Value: reading.Value})
+	}
+
+	c := &chart.Chart{}
+	units := make(map[string]bool)
+	for _, id := range sensorIDs {
+		sensor, err := dbManager.GetSensor(id, false)
+		if err != nil {
+			return nil, err
+		}
+
+		points := bySensor[id]
+		sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+		c.Series = append(c.Series, chart.Series{Name: chartSeriesName(sensor.Sensor), Points: points})
+
+		if info, ok := models.SensorTypeRegistry[sensor.Sensor.SensorType]; ok {
+			units[info.Unit] = true
+		}
+	}
+	sort.SliceStable(c.Series, func(i, j int) bool { return c.Series[i].Name < c.Series[j].Name })
+
+	// A unit is only shown when all series share it
+	if len(units) == 1 {
+		for unit := range units {
+			c.Unit = unit
+		}
+	}
+	return c, nil
+}
+
+// chartSeriesName returns the legend label of a sensor
+func chartSeriesName(sensor models.Sensor) string {
+	if sensor.Name != "" {
+		return sensor.Name
+	}
+	if sensor.Location != "" {
+		return fmt.Sprintf("%s (%s)", sensor.SensorType, sensor.Location)
+	}
+	return sensor.SensorType
+}
+
+// chartInterval picks the finest aggregation interval that yields at most
+// one point per two pixels
+func chartInterval(span time.Duration, width int) string {
+	maxPoints := time.Duration(width / 2)
+	for _, interval := range chartIntervals {
+		if span/interval.duration <= maxPoints {
+			return interval.name
+		}
+	}
+	return chartIntervals[len(chartIntervals)-1].name
+}
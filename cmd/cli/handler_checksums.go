@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// maxChecksumDays limits the days of one checksum request
+const maxChecksumDays = 366
+
+// checksumsMeta describes the requested UTC days
+type checksumsMeta struct {
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Algorithm string `json:"algorithm"`
+}
+
+// getReadingChecksumsHandler returns the daily content checksums of the
+// readings of all sensors of a station, to verify archived exports
+// Query params:
+//   - start: first UTC day (YYYY-MM-DD, default: 30 days before end)
+//   - end: last UTC day, inclusive (YYYY-MM-DD, default: today)
+func (rm *RouteManager) getReadingChecksumsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	end := q.Date("end", time.Now().UTC().Truncate(24*time.Hour))
+	start := q.Date("start", end.AddDate(0, 0, -29))
+	switch {
+	case q.Err() != nil:
+	case end.Before(start):
+		q.Invalid("end", "end must not be before start")
+	case end.Sub(start) >= maxChecksumDays*24*time.Hour:
+		q.Invalid("start", "at most %d days can be requested", maxChecksumDays)
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	for _, s := range sensors {
+		sensorIDs = append(sensorIDs, s.Sensor.ID)
+	}
+
+	checksums, err := rm.dbManager.GetReadingChecksums(r.Context(), stationID, sensorIDs, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		log.Printf("❌ Failed to compute reading checksums: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to compute reading checksums")
+		return
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, checksums, checksumsMeta{
+		Start:     start.Format(time.DateOnly),
+		End:       end.Format(time.DateOnly),
+		Algorithm: "sha256",
+	})
+}
@@ -14,29 +14,27 @@ import (
 func (rm *RouteManager) handleGetPublicDashboards(w http.ResponseWriter, r *http.Request) {
 	dashboards, err := rm.dbManager.GetDashboards(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to retrieve dashboards", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to retrieve dashboards")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dashboards)
+	respondJSON(w, http.StatusOK, dashboards)
 }
 
 // Public endpoint - get default dashboard
 func (rm *RouteManager) handleGetDefaultDashboard(w http.ResponseWriter, r *http.Request) {
 	dashboard, err := rm.dbManager.GetDefaultDashboard(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to retrieve default dashboard", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to retrieve default dashboard")
 		return
 	}
 
 	if dashboard == nil {
-		http.Error(w, "No default dashboard configured", http.StatusNotFound)
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "No default dashboard configured")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dashboard)
+	respondJSON(w, http.StatusOK, dashboard)
 }
 
 // Public endpoint - get single dashboard
@@ -44,60 +42,57 @@ func (rm *RouteManager) handleGetDashboard(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	dashboardID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid dashboard ID", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid dashboard ID")
 		return
 	}
 
 	dashboard, err := rm.dbManager.GetDashboard(r.Context(), dashboardID)
 	if err != nil {
-		http.Error(w, "Dashboard not found", http.StatusNotFound)
+		respondDBError(w, err, "Dashboard not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dashboard)
+	respondJSON(w, http.StatusOK, dashboard)
 }
 
 // Protected endpoint - requires auth
 func (rm *RouteManager) handleCreateDashboard(w http.ResponseWriter, r *http.Request) {
 	if !IsAuthenticated(r.Context()) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	var dashboard models.Dashboard
 	if err := json.NewDecoder(r.Body).Decode(&dashboard); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
 		return
 	}
 
 	if err := rm.dbManager.CreateDashboard(r.Context(), &dashboard); err != nil {
-		http.Error(w, "Failed to create dashboard", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to create dashboard")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(dashboard)
+	respondJSON(w, http.StatusCreated, dashboard)
 }
 
 // Protected endpoint - requires auth
 func (rm *RouteManager) handleUpdateDashboard(w http.ResponseWriter, r *http.Request) {
 	if !IsAuthenticated(r.Context()) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	vars := mux.Vars(r)
 	dashboardID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid dashboard ID", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid dashboard ID")
 		return
 	}
 
 	var dashboard models.Dashboard
 	if err := json.NewDecoder(r.Body).Decode(&dashboard); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
 		return
 	}
 
@@ -105,31 +100,30 @@ func (rm *RouteManager) handleUpdateDashboard(w http.ResponseWriter, r *http.Req
 
 	if err := rm.dbManager.UpdateDashboard(r.Context(), &dashboard); err != nil {
 		fmt.Printf("Failed to update dashboard: %v\n", err)
-		http.Error(w, "Failed to update dashboard", http.StatusInternalServerError)
+		respondDBError(w, err, "Dashboard not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(dashboard)
+	respondJSON(w, http.StatusOK, dashboard)
 }
 
 // Protected endpoint - requires auth
 func (rm *RouteManager) handleDeleteDashboard(w http.ResponseWriter, r *http.Request) {
 	if !IsAuthenticated(r.Context()) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	vars := mux.Vars(r)
 	dashboardID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid dashboard ID", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid dashboard ID")
 		return
 	}
 
 	if err := rm.dbManager.DeleteDashboard(r.Context(), dashboardID); err != nil {
 		fmt.Printf("Failed to delete dashboard: %v\n", err)
-		http.Error(w, "Failed to delete dashboard", http.StatusInternalServerError)
+		respondDBError(w, err, "Dashboard not found")
 		return
 	}
 
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// datasetRequest is the body of a dataset export request
+type datasetRequest struct {
+	Start    *time.Time `json:"start"`
+	End      *time.Time `json:"end"`
+	Interval string     `json:"interval"`
+	Method   string     `json:"method"`
+	MaxGap   string     `json:"max_gap"`
+}
+
+// datasetResponse is the queued export job and the URL the dataset can be
+// downloaded from once the job completed
+type datasetResponse struct {
+	Job         *models.Job `json:"job"`
+	DownloadURL string      `json:"download_url"`
+}
+
+// createDatasetHandler queues the export of a station as a wide CSV with a
+// timestamp column and one column per enabled sensor, resampled to an evenly
+// spaced interval.
+// Body:
+//   - start, end: RFC3339 time range (required); start is the first row
+//   - interval: distance of the rows (Go duration, default: 1m, min: 1s)
+//   - method: linear or previous (default: linear)
+//   - max_gap: do not interpolate over gaps between readings longer than this (Go duration, default: no limit)
+func (rm *RouteManager) createDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var req datasetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	params, err := req.params(stationID)
+	if err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	job, err := rm.submitJob(r.Context(), jobTypeDataset, params)
+	if err != nil {
+		log.Printf("❌ Failed to queue dataset job: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to queue dataset job")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, datasetResponse{
+		Job:         job,
+		DownloadURL: fmt.Sprintf("/api/v1/datasets/%s", job.ID),
+	})
+}
+
+// params validates the request and applies the defaults
+func (req datasetRequest) params(stationID uuid.UUID) (datasetParams, error) {
+	var errs models.ValidationErrors
+	params := datasetParams{
+		StationID: stationID,
+		Interval:  req.Interval,
+		Method:    req.Method,
+		MaxGap:    req.MaxGap,
+	}
+	if params.Interval == "" {
+		params.Interval = "1m"
+	}
+	if params.Method == "" {
+		params.Method = analysis.ResampleLinear
+	}
+
+	if req.Start == nil {
+		errs.Add("start", "start is required")
+	}
+	if req.End == nil {
+		errs.Add("end", "end is required")
+	}
+	if req.Start != nil && req.End != nil {
+		params.Start, params.End = req.Start.UTC(), req.End.UTC()
+		if params.End.Before(params.Start) {
+			errs.Add("start", "start must not be after end")
+		}
+	}
+
+	interval, err := time.ParseDuration(params.Interval)
+	switch {
+	case err != nil:
+		errs.Add("interval", "invalid duration: %s (e.g. 30s, 10m, 1h)", params.Interval)
+	case interval < time.Second:
+		errs.Add("interval", "interval must be at least 1s")
+	case !errs.Has("start") && !errs.Has("end") && params.End.Sub(params.Start)/interval >= maxDatasetRows:
+		errs.Add("interval", "interval yields more than %d rows, use a longer interval or a shorter time range", maxDatasetRows)
+	}
+	if params.Method != analysis.ResampleLinear && params.Method != analysis.ResamplePrevious {
+		errs.Add("method", "invalid method: %s (valid: %s, %s)", params.Method, analysis.ResampleLinear, analysis.ResamplePrevious)
+	}
+	if params.MaxGap != "" {
+		if gap, err := time.ParseDuration(params.MaxGap); err != nil || gap < 0 {
+			errs.Add("max_gap", "invalid duration: %s (e.g. 30s, 10m, 1h)", params.MaxGap)
+		}
+	}
+	return params, errs.Err()
+}
+
+// downloadDatasetHandler serves the CSV written by a completed dataset job
+func (rm *RouteManager) downloadDatasetHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid job id format")
+		return
+	}
+
+	job, err := rm.dbManager.GetJob(r.Context(), id)
+	if err != nil {
+		respondDBError(w, err, "Dataset not found")
+		return
+	}
+	if job.Type != jobTypeDataset {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Dataset not found")
+		return
+	}
+	if job.Status != models.JobStatusCompleted {
+		respondErrorWithDetails(w, http.StatusConflict, ErrCodeConflict, "Dataset is not ready", map[string]interface{}{
+			"status": job.Status,
+		})
+		return
+	}
+
+	f, err := os.Open(newDatasetService(rm.dbManager).path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			respondError(w, http.StatusGone, ErrCodeNotFound, "Dataset expired")
+			return
+		}
+		log.Printf("❌ Failed to open dataset %s: %v", id, err)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to open dataset")
+		return
+	}
+	defer f.Close()
+
+	var params datasetParams
+	json.Unmarshal(job.Params, &params)
+	var modified time.Time
+	if job.FinishedAt != nil {
+		modified = *job.FinishedAt
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="dataset-%s-%s.csv"`, params.StationID, params.Start.Format("20060102")))
+	http.ServeContent(w, r, "", modified, f)
+}
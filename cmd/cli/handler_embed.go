@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+//go:embed widget/*.html
+var widgetFiles embed.FS
+
+// widgetTemplates holds the templates of the embeddable widgets
+var widgetTemplates = template.Must(template.New("").Funcs(localeFuncs).ParseFS(widgetFiles, "widget/*.html"))
+
+// widgetThemes are the color sets selected by the theme parameter
+var widgetThemes = map[string]widgetTheme{
+	"light": {Background: "#ffffff", Foreground: "#1f2933", Accent: "#d9480f"},
+	"dark":  {Background: "#1f2933", Foreground: "#f5f7fa", Accent: "#ffa94d"},
+}
+
+// widgetColorPattern matches hex colors, the # is optional to keep URLs short
+var widgetColorPattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// widgetTheme holds the colors of a widget
+type widgetTheme struct {
+	Background string
+	Foreground string
+	Accent     string
+}
+
+// widgetCurrent holds the values of the current conditions widget. Missing
+// values are empty.
+type widgetCurrent struct {
+	Name          string
+	Locale        i18n.Locale
+	Theme         widgetTheme
+	Temperature   string
+	Wind          string
+	WindDirection string
+	// WindArrow rotates the arrow to point where the wind blows to
+	WindArrow float64
+	RainToday string
+	Updated   *time.Time
+}
+
+// setupEmbedRoutes configures the widgets that can be embedded in other
+// websites. They are addressed by a share token instead of a login.
+func (rm *RouteManager) setupEmbedRoutes(r *mux.Router) {
+	r.HandleFunc("/embed/{token}/current", rm.handleEmbedCurrent).Methods("GET")
+}
+
+// handleEmbedCurrent renders the current temperature, wind and rain of the
+// station of a share token as HTML page for an iframe or as SVG image.
+// Query params:
+//   - format: html or svg (default: html)
+//   - theme: light or dark (default: light)
+//   - bg, fg, accent: hex colors overriding the theme (e.g. ffffff)
+//   - lang: en or de (default: Accept-Language of the visitor)
+func (rm *RouteManager) handleEmbedCurrent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "svg" {
+		http.Error(w, "format must be html or svg", http.StatusBadRequest)
+		return
+	}
+
+	themeName := query.Get("theme")
+	if themeName == "" {
+		themeName = "light"
+	}
+	theme, ok := widgetThemes[themeName]
+	if !ok {
+		http.Error(w, "theme must be light or dark", http.StatusBadRequest)
+		return
+	}
+	for param, color := range map[string]*string{"bg": &theme.Background, "fg": &theme.Foreground, "accent": &theme.Accent} {
+		v := query.Get(param)
+		if v == "" {
+			continue
+		}
+		if !widgetColorPattern.MatchString(v) {
+			http.Error(w, param+" must be a hex color", http.StatusBadRequest)
+			return
+		}
+		*color = "#" + strings.TrimPrefix(v, "#")
+	}
+
+	shareToken, err := rm.dbManager.AuthenticateShareToken(r.Context(), mux.Vars(r)["token"])
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, "Share token not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to query share token: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(shareToken.StationID)
+	if err != nil {
+		log.Printf("❌ Failed to load station %s: %v", shareToken.StationID, err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID, IncludeLatest: true})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Widgets are public, so only shared sensors are shown and the privacy
+	// settings of the station apply
+	view, err := loadPrivacyView(rm.dbManager)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	locale := rm.requestLocale(r)
+	current := currentConditions(view.filterSensors(sharedSensors(shareToken, preferredRainSensors(&station, sensors))), stationLocation(&station), locale)
+	current.Name = stationDisplayName(&station)
+	current.Theme = theme
+
+	var buf bytes.Buffer
+	if err := widgetTemplates.ExecuteTemplate(&buf, "current."+format, current); err != nil {
+		log.Printf("❌ Failed to render widget: %v", err)
+		http.Error(w, "Failed to render widget", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Set("Vary", "Accept-Language")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Write(buf.Bytes())
+}
+
+// currentConditions picks the outdoor temperature, wind and daily rain from
+// the latest readings of a station's sensors
+func currentConditions(sensors []models.SensorWithLatestReading, loc *time.Location, locale i18n.Locale) widgetCurrent {
+	current := widgetCurrent{Locale: locale}
+	var temperature *models.SensorReading
+	for _, s := range sensors {
+		reading := s.LatestReading
+		if reading == nil || !s.Sensor.Enabled {
+			continue
+		}
+		switch s.Sensor.SensorType {
+		case models.SensorTypeTemperatureOutdoor:
+			temperature = reading
+		case models.SensorTypeTemperature:
+			// Indoor temperatures are only shown without an outdoor sensor
+			if temperature == nil || strings.EqualFold(s.Sensor.Location, "outdoor") {
+				temperature = reading
+			}
+		case models.SensorTypeWindSpeed:
+			current.Wind = formatSiteValue(reading.Value)
+		case models.SensorTypeWindDirection:
+			current.WindDirection = i18n.CompassPoint(locale, reading.Value, 8)
+			current.WindArrow = math.Mod(reading.Value+180, 360)
+		case models.SensorTypeRainfallDaily:
+			current.RainToday = formatSiteValue(reading.Value)
+		default:
+			continue
+		}
+		if updated := reading.DateUTC.In(loc); current.Updated == nil || updated.After(*current.Updated) {
+			current.Updated = &updated
+		}
+	}
+	if temperature != nil {
+		current.Temperature = formatSiteValue(temperature.Value)
+	}
+	return current
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// freezeThawInterval averages the temperatures before counting, so single
+// noisy readings don't add transitions
+const freezeThawInterval = "5m"
+
+// freezeThawMeta describes the counted period and the totals
+type freezeThawMeta struct {
+	SensorID   uuid.UUID `json:"sensor_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Period     string    `json:"period"`
+	Threshold  float64   `json:"threshold"`
+	Hysteresis float64   `json:"hysteresis"`
+	Freezes    int       `json:"freezes"`
+	Thaws      int       `json:"thaws"`
+	Cycles     int       `json:"cycles"`
+}
+
+// handleStationFreezeThaw counts the daily freeze/thaw transitions of the
+// outdoor temperature of a station, in its time zone
+// Query params:
+//   - period: length of the period (e.g. 30d, default: 30d)
+//   - end: end of the period (RFC3339, default: now)
+//   - sensor_id: temperature sensor (default: the outdoor temperature)
+//   - threshold: freezing point in °C (default: 0)
+//   - hysteresis: distance from the threshold a crossing must reach in °C (default: 0.5)
+//   - format: json or csv (default: json)
+func (rm *RouteManager) handleStationFreezeThaw(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	period := q.String("period")
+	if period == "" {
+		period = "30d"
+	}
+	periodDuration := q.Period("period", 30*24*time.Hour)
+	end := q.Time("end", time.Now().UTC())
+	start := end.Add(-periodDuration)
+
+	threshold := q.Float("threshold", float64(analysis.DefaultFreezeThreshold))
+	hysteresis := q.Float("hysteresis", analysis.DefaultFreezeHysteresis)
+	if hysteresis < 0 {
+		q.Invalid("hysteresis", "hysteresis must not be negative")
+	}
+	format := q.OneOf("format", "", "json", "csv")
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	sensorID, ok, err := rm.freezeThawSensor(stationID, q.String("sensor_id"))
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	if !ok || view.hides(sensorID) {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "No temperature sensor found")
+		return
+	}
+
+	series, err := rm.dbManager.GetAveragedReadings(r.Context(), []uuid.UUID{sensorID}, start, end, freezeThawInterval)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	counter := analysis.NewFreezeThawCounter(threshold, hysteresis, stationLocation(&station))
+	for _, reading := range series[sensorID] {
+		counter.Add(reading.DateUTC, reading.Value)
+	}
+	days := counter.Days()
+
+	if format == "csv" {
+		writeFreezeThawCSV(w, days)
+		return
+	}
+
+	meta := freezeThawMeta{
+		SensorID:   sensorID,
+		Start:      start,
+		End:        end,
+		Period:     period,
+		Threshold:  threshold,
+		Hysteresis: hysteresis,
+	}
+	for _, d := range days {
+		meta.Freezes += d.Freezes
+		meta.Thaws += d.Thaws
+		meta.Cycles += d.Cycles
+	}
+	respondJSONWithMeta(w, http.StatusOK, days, meta)
+}
+
+// freezeThawSensor returns the requested temperature sensor of a station
+// or its outdoor temperature sensor
+func (rm *RouteManager) freezeThawSensor(stationID uuid.UUID, requested string) (uuid.UUID, bool, error) {
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	if requested != "" {
+		for _, s := range sensors {
+			if s.Sensor.ID.String() == requested {
+				return s.Sensor.ID, true, nil
+			}
+		}
+		return uuid.Nil, false, nil
+	}
+
+	if id, ok := sensorOfType(sensors, models.SensorTypeTemperatureOutdoor); ok {
+		return id, true, nil
+	}
+	for _, s := range sensors {
+		if s.Sensor.SensorType == models.SensorTypeTemperature && s.Sensor.Location == "Outdoor" {
+			return s.Sensor.ID, true, nil
+		}
+	}
+	return uuid.Nil, false, nil
+}
+
+// writeFreezeThawCSV writes the daily counts as CSV
+func writeFreezeThawCSV(w http.ResponseWriter, days []analysis.FreezeThawDay) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="freeze-thaw.csv"`)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"day", "freezes", "thaws", "cycles", "min", "max"})
+	for _, d := range days {
+		out.Write([]string{
+			d.Day,
+			strconv.Itoa(d.Freezes),
+			strconv.Itoa(d.Thaws),
+			strconv.Itoa(d.Cycles),
+			fmt.Sprintf("%.1f", d.Min),
+			fmt.Sprintf("%.1f", d.Max),
+		})
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Printf("❌ Failed to write CSV: %v", err)
+	}
+}
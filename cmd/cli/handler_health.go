@@ -1,12 +1,55 @@
 package main
 
 import (
-	"encoding/json"
+	"log"
 	"net/http"
 )
 
 // healthHandler returns server health status
 func (rm *RouteManager) healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// versionHandler returns the build, schema version and enabled features of
+// the server, for bug reports and compatibility checks of clients
+func (rm *RouteManager) versionHandler(w http.ResponseWriter, r *http.Request) {
+	info := buildVersion()
+
+	var err error
+	if info.SchemaVersion, err = rm.dbManager.SchemaVersion(r.Context()); err != nil {
+		log.Printf("❌ Failed to query schema version: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query schema version")
+		return
+	}
+
+	info.Features = []string{"clickhouse"}
+	if timescale, err := rm.dbManager.HasExtension(r.Context(), "timescaledb"); err != nil {
+		log.Printf("⚠ Failed to query extensions: %v", err)
+	} else if timescale {
+		info.Features = append(info.Features, "timescale")
+	}
+	if rm.registryManager.AlertPublisher != nil {
+		info.Features = append(info.Features, "mqtt")
+	}
+	info.Features = append(info.Features, rm.registryManager.Notifier.Channels()...)
+
+	respondJSON(w, http.StatusOK, info)
+}
+
+// updateCheckHandler reports whether a newer release is available, so the
+// UI can show a notice. The latest release is cached for a few hours.
+func (rm *RouteManager) updateCheckHandler(w http.ResponseWriter, r *http.Request) {
+	status, err := rm.updates.Status(r.Context())
+	if err != nil {
+		log.Printf("⚠ Failed to check for updates: %v", err)
+		respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Failed to query the latest release")
+		return
+	}
+	respondJSON(w, http.StatusOK, status)
+}
+
+// featuresHandler lists the feature flags of the deployment, so clients can
+// hide what is disabled
+func (rm *RouteManager) featuresHandler(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, rm.registryManager.Features)
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getHeatingHandler returns the flow temperature the heating curve of a
+// station suggests for the current average outdoor temperature
+func (rm *RouteManager) getHeatingHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	if _, _, err := models.ParseHeatingPolicy(station.Config); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, ErrCodeValidation, err.Error())
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	hint, err := computeHeatingHint(r.Context(), rm.dbManager, &station, time.Now(), view)
+	switch {
+	case errors.Is(err, errNoHeatingConfig):
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Heating curve is not configured for this station")
+		return
+	case errors.Is(err, errNoOutdoorTemperature):
+		respondError(w, http.StatusUnprocessableEntity, ErrCodeValidation, "Outdoor temperature readings are required for the heating curve")
+		return
+	case err != nil:
+		log.Printf("❌ Failed to compute heating hint: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to compute heating hint")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, hint)
+}
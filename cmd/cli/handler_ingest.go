@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetIngestHooks returns all ingest hooks with their metrics
+func (rm *RouteManager) handleGetIngestHooks(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, rm.registryManager.IngestPipeline.Metrics())
+}
+
+// handleUpdateIngestHook enables or disables a single ingest hook
+func (rm *RouteManager) handleUpdateIngestHook(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if err := rm.registryManager.IngestPipeline.SetEnabled(name, *req.Enabled); err != nil {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Ingest hook not found")
+		return
+	}
+
+	for _, m := range rm.registryManager.IngestPipeline.Metrics() {
+		if m.Name == name {
+			respondJSON(w, http.StatusOK, m)
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getIrrigationHandler returns today's irrigation advice of a station with
+// the water balance of the configured days
+func (rm *RouteManager) getIrrigationHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	if _, _, err := models.ParseIrrigationPolicy(station.Config); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, ErrCodeValidation, err.Error())
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	advice, err := computeIrrigationAdvice(r.Context(), rm.dbManager, &station, time.Now(), view)
+	switch {
+	case errors.Is(err, errNoIrrigationConfig):
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Irrigation advice is not configured for this station")
+		return
+	case errors.Is(err, errNoCoordinates):
+		respondError(w, http.StatusUnprocessableEntity, ErrCodeValidation, "Station coordinates are required for irrigation advice")
+		return
+	case err != nil:
+		log.Printf("❌ Failed to compute irrigation advice: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to compute irrigation advice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, advice)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// handleGetJobs lists background jobs, newest first.
+// Query parameters:
+//   - type: only jobs of this type
+//   - status: only jobs in this status
+//   - limit: max number of jobs (default: 100, max: 1000)
+func (rm *RouteManager) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	params := models.JobQueryParams{
+		Type:   q.String("type"),
+		Status: q.String("status"),
+		Limit:  q.Int("limit", 100, 1, 1000),
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	rm.respondJobs(w, r, params)
+}
+
+// respondJobs writes the jobs matching params
+func (rm *RouteManager) respondJobs(w http.ResponseWriter, r *http.Request, params models.JobQueryParams) {
+	jobs, err := rm.dbManager.GetJobs(r.Context(), params)
+	if err != nil {
+		log.Printf("❌ Failed to query jobs: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query jobs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, jobs)
+}
+
+// handleGetJob returns the status of a background job
+func (rm *RouteManager) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid job id format")
+		return
+	}
+
+	job, err := rm.dbManager.GetJob(r.Context(), id)
+	if err != nil {
+		respondDBError(w, err, "Job not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
+
+// handleCancelJob cancels a pending job or stops a running one
+func (rm *RouteManager) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid job id format")
+		return
+	}
+
+	job, err := rm.dbManager.CancelJob(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.ErrJobFinished) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Job already finished")
+			return
+		}
+		respondDBError(w, err, "Job not found")
+		return
+	}
+
+	// Stop the job right away if it runs in this process
+	if runner := rm.registryManager.JobRunner; runner != nil {
+		runner.Cancel(id)
+	}
+
+	respondJSON(w, http.StatusOK, job)
+}
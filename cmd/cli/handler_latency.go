@@ -0,0 +1,137 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// latencyMeta describes the time range and threshold of latency statistics
+type latencyMeta struct {
+	Window    string `json:"window"`
+	Threshold string `json:"threshold"`
+	// Status is degraded if any station is
+	Status string `json:"status,omitempty"`
+}
+
+// stationLatency is the ingest latency of a station and its course
+type stationLatency struct {
+	models.StationIngestLatency
+	Series []models.IngestLatencyPoint `json:"series"`
+}
+
+// getIngestLatencyHandler rates the ingest latency of all stations: the
+// median and 95th percentile of the time between the observation of their
+// readings and the arrival at the server. Stations whose 95th percentile
+// exceeds INGEST_LATENCY_THRESHOLD are degraded, e.g. gateways buffering
+// readings behind a flaky network.
+// Query params:
+//   - window: time of arrival the statistics cover (default: 24h)
+func (rm *RouteManager) getIngestLatencyHandler(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	window := q.Duration("window", 24*time.Hour)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	stations, err := rm.dbManager.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to query stations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query stations")
+		return
+	}
+
+	since := time.Now().Add(-window)
+	meta := latencyMeta{Window: window.String(), Threshold: rm.latencyThreshold.String(), Status: models.IngestLatencyOK}
+	result := make([]models.StationIngestLatency, 0, len(stations))
+	for _, station := range stations {
+		stationID := station.ID
+		sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+		if err != nil {
+			log.Printf("❌ Failed to query sensors: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+			return
+		}
+		sensorIDs := make([]uuid.UUID, 0, len(sensors))
+		for _, s := range sensors {
+			sensorIDs = append(sensorIDs, s.Sensor.ID)
+		}
+
+		latency, err := rm.dbManager.GetIngestLatency(r.Context(), sensorIDs, since)
+		if err != nil {
+			log.Printf("❌ Failed to query ingest latency of station %s: %v", stationID, err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query ingest latency")
+			return
+		}
+		rated := models.StationIngestLatency{
+			StationID:     stationID,
+			Name:          stationDisplayName(&station),
+			Status:        models.RateIngestLatency(latency, rm.latencyThreshold),
+			IngestLatency: latency,
+		}
+		if rated.Status == models.IngestLatencyDegraded {
+			meta.Status = models.IngestLatencyDegraded
+		}
+		result = append(result, rated)
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, result, meta)
+}
+
+// getStationLatencyHandler returns the ingest latency of a station and its
+// course per interval
+// Query params:
+//   - window: time of arrival the statistics cover (default: 24h)
+//   - interval: length of the intervals of the series (default: 1h)
+//   - sensor_id: limit to these sensors (comma separated)
+func (rm *RouteManager) getStationLatencyHandler(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	window := q.Duration("window", 24*time.Hour)
+	interval := q.Duration("interval", time.Hour)
+	if interval < time.Minute {
+		q.Invalid("interval", "interval must be at least 1m")
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	stationID, sensorIDs, ok := rm.changesSensors(w, r)
+	if !ok {
+		return
+	}
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	since := time.Now().Add(-window)
+	latency, err := rm.dbManager.GetIngestLatency(r.Context(), sensorIDs, since)
+	if err != nil {
+		log.Printf("❌ Failed to query ingest latency of station %s: %v", stationID, err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query ingest latency")
+		return
+	}
+	series, err := rm.dbManager.GetIngestLatencySeries(r.Context(), sensorIDs, since, interval)
+	if err != nil {
+		log.Printf("❌ Failed to query ingest latency of station %s: %v", stationID, err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query ingest latency")
+		return
+	}
+
+	result := stationLatency{
+		StationIngestLatency: models.StationIngestLatency{
+			StationID:     stationID,
+			Name:          stationDisplayName(&station),
+			Status:        models.RateIngestLatency(latency, rm.latencyThreshold),
+			IngestLatency: latency,
+		},
+		Series: series,
+	}
+	respondJSONWithMeta(w, http.StatusOK, result, latencyMeta{Window: window.String(), Threshold: rm.latencyThreshold.String()})
+}
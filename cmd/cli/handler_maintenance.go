@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// stationAndID parses the station ID and a second ID from the path. It
+// writes the error response and returns false when one is invalid.
+func stationAndID(w http.ResponseWriter, r *http.Request, key string) (uuid.UUID, uuid.UUID, bool) {
+	vars := mux.Vars(r)
+	stationID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	id, err := uuid.Parse(vars[key])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid "+key+" format")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return stationID, id, true
+}
+
+// getMaintenanceTasksHandler returns the maintenance tasks of a station,
+// the next due first
+func (rm *RouteManager) getMaintenanceTasksHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	tasks, err := rm.dbManager.GetMaintenanceTasks(r.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query maintenance tasks: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query maintenance tasks")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tasks)
+}
+
+// createMaintenanceTaskHandler schedules a maintenance task of a station
+func (rm *RouteManager) createMaintenanceTaskHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var task models.MaintenanceTask
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	task.StationID = stationID
+	if err := task.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := rm.dbManager.CreateMaintenanceTask(r.Context(), &task); err != nil {
+		log.Printf("❌ Failed to create maintenance task: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	log.Printf("✓ Scheduled maintenance %q of station %s every %d days", task.Name, stationID, task.IntervalDays)
+	respondJSON(w, http.StatusCreated, task)
+}
+
+// updateMaintenanceTaskHandler changes a maintenance task
+func (rm *RouteManager) updateMaintenanceTaskHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, taskID, ok := stationAndID(w, r, "taskId")
+	if !ok {
+		return
+	}
+
+	var task models.MaintenanceTask
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	task.StationID = stationID
+	task.ID = taskID
+	if err := task.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := rm.dbManager.UpdateMaintenanceTask(r.Context(), &task); err != nil {
+		log.Printf("❌ Failed to update maintenance task: %v", err)
+		respondDBError(w, err, "Maintenance task not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, task)
+}
+
+// deleteMaintenanceTaskHandler deletes a maintenance task; its log is kept
+func (rm *RouteManager) deleteMaintenanceTaskHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, taskID, ok := stationAndID(w, r, "taskId")
+	if !ok {
+		return
+	}
+
+	if err := rm.dbManager.DeleteMaintenanceTask(r.Context(), stationID, taskID); err != nil {
+		respondDBError(w, err, "Maintenance task not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// completeMaintenanceTaskHandler logs a completed maintenance task as
+// annotation and schedules the next one. The body is optional.
+func (rm *RouteManager) completeMaintenanceTaskHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, taskID, ok := stationAndID(w, r, "taskId")
+	if !ok {
+		return
+	}
+
+	var completion models.MaintenanceCompletion
+	if err := json.NewDecoder(r.Body).Decode(&completion); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if completion.DateUTC.After(time.Now().Add(time.Minute)) {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "date_utc must not be in the future")
+		return
+	}
+
+	annotation, err := rm.dbManager.CompleteMaintenanceTask(r.Context(), stationID, taskID, completion)
+	if err != nil {
+		log.Printf("❌ Failed to complete maintenance task: %v", err)
+		respondDBError(w, err, "Maintenance task not found")
+		return
+	}
+
+	log.Printf("✓ Logged maintenance of station %s: %s", stationID, annotation.Text)
+	respondJSON(w, http.StatusCreated, annotation)
+}
+
+// getAnnotationsHandler returns the annotations of a station for the data
+// timeline
+// Query params:
+//   - start, end: RFC3339 time range (default: last 30 days)
+//   - kind: note or maintenance
+func (rm *RouteManager) getAnnotationsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	start, end := q.TimeRange(30 * 24 * time.Hour)
+	kind := q.OneOf("kind", "", models.AnnotationKindNote, models.AnnotationKindMaintenance)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	annotations, err := rm.dbManager.GetAnnotations(r.Context(), stationID, start, end, kind)
+	if err != nil {
+		log.Printf("❌ Failed to query annotations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query annotations")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, view.filterAnnotations(annotations))
+}
+
+// createAnnotationHandler adds a note to the timeline of a station
+func (rm *RouteManager) createAnnotationHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var annotation models.Annotation
+	if err := json.NewDecoder(r.Body).Decode(&annotation); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	// Maintenance is logged by completing its task
+	annotation.StationID = stationID
+	annotation.Kind = models.AnnotationKindNote
+	annotation.TaskID = nil
+	if err := annotation.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := rm.dbManager.CreateAnnotation(r.Context(), &annotation); err != nil {
+		log.Printf("❌ Failed to create annotation: %v", err)
+		respondDBError(w, err, "Station or sensor not found")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, annotation)
+}
+
+// deleteAnnotationHandler deletes an annotation of a station
+func (rm *RouteManager) deleteAnnotationHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, annotationID, ok := stationAndID(w, r, "annotationId")
+	if !ok {
+		return
+	}
+
+	if err := rm.dbManager.DeleteAnnotation(r.Context(), stationID, annotationID); err != nil {
+		respondDBError(w, err, "Annotation not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/gorilla/mux"
 )
 
 // corsMiddleware handles CORS headers
@@ -67,3 +69,13 @@ func (rm *RouteManager) contextMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// apiVersionMiddleware announces the API version of the matched route
+func apiVersionMiddleware(version string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-API-Version", version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
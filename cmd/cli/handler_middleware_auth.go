@@ -2,24 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
 	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
 type contextKey string
 
-const userContextKey contextKey = "user"
+const (
+	userContextKey    contextKey = "user"
+	sessionContextKey contextKey = "session"
+	apiKeyContextKey  contextKey = "api_key"
+)
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -28,52 +36,164 @@ func (rm *RouteManager) JWTAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Authorization header required")
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		const prefix = "Bearer "
 		if !strings.HasPrefix(authHeader, prefix) {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+			respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid authorization header format")
 			return
 		}
 
 		tokenString := authHeader[len(prefix):]
 
 		// Parse and validate token
-		token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(getJWTSecret()), nil
-		})
-
+		user, sessionID, err := rm.authenticateJWT(r.Context(), tokenString)
 		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			var authErr authError
+			if errors.As(err, &authErr) {
+				respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, err.Error())
+				return
+			}
+			log.Printf("❌ Failed to check session: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to check session")
 			return
 		}
 
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok || !token.Valid {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
+		// Add user and session to context
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, sessionContextKey, sessionID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authError is an authentication failure whose message is shown to clients
+type authError string
+
+func (e authError) Error() string { return string(e) }
+
+// authenticateJWT validates a token and its session and returns the user of
+// its claims. Revoked and expired sessions fail with an authError.
+func (rm *RouteManager) authenticateJWT(ctx context.Context, tokenString string) (*models.User, uuid.UUID, error) {
+	user, claims, err := parseJWTUser(tokenString)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, uuid.Nil, authError("Invalid token claims")
+	}
+	if _, err := rm.dbManager.TouchSession(ctx, sessionID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, uuid.Nil, authError("Session expired or revoked")
 		}
+		return nil, uuid.Nil, err
+	}
+	return user, sessionID, nil
+}
 
-		// Create user object from claims (no DB lookup needed for every request)
-		user := &models.User{
-			Username: claims.Username,
+// parseJWTUser validates a token and returns the user of its claims
+func parseJWTUser(tokenString string) (*models.User, *JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return []byte(getJWTSecret()), nil
+	})
+	if err != nil {
+		return nil, nil, authError("Invalid or expired token")
+	}
 
-		// Parse UUID
-		if id, err := uuid.Parse(claims.UserID); err == nil {
-			user.ID = id
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, nil, authError("Invalid token claims")
+	}
+
+	user := &models.User{
+		Username: claims.Username,
+	}
+	if id, err := uuid.Parse(claims.UserID); err == nil {
+		user.ID = id
+	}
+	return user, claims, nil
+}
+
+// RequireScope returns a middleware that admits logged in users and API keys
+// with the given scope. API keys are sent in the X-API-Key header or as
+// bearer token; everything else is treated as JWT.
+func (rm *RouteManager) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		jwtAuth := rm.JWTAuthMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := apiKeyFromRequest(r)
+			if key == "" {
+				jwtAuth.ServeHTTP(w, r)
+				return
+			}
+
+			apiKey, ok := rm.authenticateAPIKey(w, r, key, scope)
+			if !ok {
+				return
+			}
+			ctx := context.WithValue(r.Context(), apiKeyContextKey, apiKey)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authenticateAPIKey looks up an API key and checks its scope. Failures are
+// written to w.
+func (rm *RouteManager) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key, scope string) (*models.APIKey, bool) {
+	apiKey, err := rm.dbManager.AuthenticateAPIKey(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid or revoked API key")
+			return nil, false
 		}
+		log.Printf("❌ Failed to authenticate API key: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to authenticate API key")
+		return nil, false
+	}
+	if !apiKey.HasScope(scope) {
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "API key lacks scope "+scope)
+		return nil, false
+	}
+	return apiKey, true
+}
 
-		// Add user to context
-		ctx := context.WithValue(r.Context(), userContextKey, user)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// apiKeyFromRequest returns the API key of a request or an empty string
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(token, models.APIKeyPrefix) {
+		return token
+	}
+	return ""
+}
+
+// requestHasScope reports whether a request on a route without auth
+// middleware carries a valid user token or an API key with the scope
+func (rm *RouteManager) requestHasScope(r *http.Request, scope string) bool {
+	if GetUserFromContext(r.Context()) != nil {
+		return true
+	}
+	if apiKey := GetAPIKeyFromContext(r.Context()); apiKey != nil {
+		return apiKey.HasScope(scope)
+	}
+	if key := apiKeyFromRequest(r); key != "" {
+		apiKey, err := rm.dbManager.AuthenticateAPIKey(r.Context(), key)
+		return err == nil && apiKey.HasScope(scope)
+	}
+	tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	_, _, err := rm.authenticateJWT(r.Context(), tokenString)
+	return err == nil
 }
 
 // GetUserFromContext retrieves user from request context
@@ -85,18 +205,35 @@ func GetUserFromContext(ctx context.Context) *models.User {
 	return user
 }
 
-// IsAuthenticated checks if request has valid user
+// GetSessionIDFromContext retrieves the session ID of a user token from
+// request context
+func GetSessionIDFromContext(ctx context.Context) uuid.UUID {
+	sessionID, _ := ctx.Value(sessionContextKey).(uuid.UUID)
+	return sessionID
+}
+
+// GetAPIKeyFromContext retrieves the API key from request context
+func GetAPIKeyFromContext(ctx context.Context) *models.APIKey {
+	apiKey, ok := ctx.Value(apiKeyContextKey).(*models.APIKey)
+	if !ok {
+		return nil
+	}
+	return apiKey
+}
+
+// IsAuthenticated checks if request has valid user or API key
 func IsAuthenticated(ctx context.Context) bool {
-	return GetUserFromContext(ctx) != nil
+	return GetUserFromContext(ctx) != nil || GetAPIKeyFromContext(ctx) != nil
 }
 
-// GenerateJWT creates a new JWT token for a user
-func GenerateJWT(user *models.User) (string, time.Time, error) {
+// GenerateJWT creates a new JWT token for a user session
+func GenerateJWT(user *models.User, sessionID uuid.UUID) (string, time.Time, error) {
 	expiresAt := time.Now().Add(24 * time.Hour)
 
 	claims := JWTClaims{
-		UserID:   user.ID.String(),
-		Username: user.Username,
+		UserID:    user.ID.String(),
+		Username:  user.Username,
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
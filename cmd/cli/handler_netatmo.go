@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
 	"time"
@@ -18,7 +17,7 @@ func (rm *RouteManager) netatmoCallbackHandler(w http.ResponseWriter, r *http.Re
 	stationIDStr := vars["stationID"]
 	stationID, err := uuid.Parse(stationIDStr)
 	if err != nil {
-		http.Error(w, "Invalid station_id format", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
 		return
 	}
 
@@ -26,44 +25,44 @@ func (rm *RouteManager) netatmoCallbackHandler(w http.ResponseWriter, r *http.Re
 	state := r.URL.Query().Get("state")
 
 	if code == "" {
-		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Missing authorization code")
 		return
 	}
 
 	if state == "" {
-		http.Error(w, "Missing state parameter", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Missing state parameter")
 		return
 	}
 
 	// Get current config
 	config, err := rm.dbManager.GetStationConfig(stationID)
 	if err != nil {
-		http.Error(w, "Config error: "+err.Error(), http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Config error: "+err.Error())
 		return
 	}
 
 	// Exchange code for access token
 	clientID, ok := config["client_id"].(string)
 	if !ok {
-		http.Error(w, "Invalid client_id in config", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Invalid client_id in config")
 		return
 	}
 
 	clientSecret, ok := config["client_secret"].(string)
 	if !ok {
-		http.Error(w, "Invalid client_secret in config", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Invalid client_secret in config")
 		return
 	}
 
 	redirectURI, ok := config["redirect_uri"].(string)
 	if !ok {
-		http.Error(w, "Invalid redirect_uri in config", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Invalid redirect_uri in config")
 		return
 	}
 
 	dbState, ok := config["state"].(string)
 	if !ok {
-		http.Error(w, "Invalid state in config", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Invalid state in config")
 		return
 	}
 
@@ -74,7 +73,7 @@ func (rm *RouteManager) netatmoCallbackHandler(w http.ResponseWriter, r *http.Re
 
 	if err := client.GetAccessTokenFromCode(ctx, code, state); err != nil {
 		log.Printf("Failed to get access token: %v", err)
-		http.Error(w, "Failed to get access token", http.StatusInternalServerError)
+		respondError(w, http.StatusBadGateway, ErrCodeUnavailable, "Failed to get access token")
 		return
 	}
 
@@ -86,13 +85,12 @@ func (rm *RouteManager) netatmoCallbackHandler(w http.ResponseWriter, r *http.Re
 	err = rm.dbManager.SetStationConfig(stationID, config)
 	if err != nil {
 		log.Printf("Failed to update station config: %v", err)
-		http.Error(w, "Failed to save access token", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to save access token")
 		return
 	}
 
 	log.Printf("Successfully saved access token for station %s", stationID)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
+	respondJSON(w, http.StatusOK, map[string]string{
 		"status":  "success",
 		"message": "Authorization successful! You can close this window.",
 	})
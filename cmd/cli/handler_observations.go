@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getObservationsHandler returns the manual observations of a station,
+// newest first
+// Query params:
+//   - start, end: RFC3339 time range (default: last 30 days)
+func (rm *RouteManager) getObservationsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	start, end := q.TimeRange(30 * 24 * time.Hour)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	observations, err := rm.dbManager.GetObservations(r.Context(), stationID, start, end)
+	if err != nil {
+		log.Printf("❌ Failed to query observations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query observations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, observations)
+}
+
+// createObservationsHandler stores the values of an observation form, e.g.
+// {"values": {"Snowfall": 12, "CloudCover": 8}}. The logged in user or the
+// name of the API key is recorded as observer.
+func (rm *RouteManager) createObservationsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var entry models.ObservationEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	observations, err := entry.Observations(stationID, requestObserver(r))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	created, err := rm.dbManager.CreateObservations(r.Context(), stationID, observations)
+	if err != nil {
+		log.Printf("❌ Failed to store observations: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	log.Printf("✓ Stored %d observations of station %s by %s", len(created), stationID, created[0].Observer)
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// requestObserver names the person or API key entering observations
+func requestObserver(r *http.Request) string {
+	if user := GetUserFromContext(r.Context()); user != nil {
+		return user.Username
+	}
+	if apiKey := GetAPIKeyFromContext(r.Context()); apiKey != nil {
+		return apiKey.Name
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getPendingStationsHandler lists the unknown stations that pushed data
+// while STATION_REGISTRATION is manual-approval, with the parsed values of
+// their last push
+func (rm *RouteManager) getPendingStationsHandler(w http.ResponseWriter, r *http.Request) {
+	stations, err := rm.dbManager.GetPendingStations(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to query pending stations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query pending stations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, stations)
+}
+
+// approvePendingStationHandler creates the station of a pending passkey;
+// its following pushes are stored. The optional body assigns a name, site,
+// owner and a template station whose config is copied.
+func (rm *RouteManager) approvePendingStationHandler(w http.ResponseWriter, r *http.Request) {
+	passKey := mux.Vars(r)["passkey"]
+
+	var req models.StationApproval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var errs models.ValidationErrors
+	var template map[string]interface{}
+	if req.TemplateID != nil {
+		station, err := rm.dbManager.LoadStation(*req.TemplateID)
+		if errors.Is(err, database.ErrNotFound) {
+			errs.Add("template_id", "template station not found")
+		}
+		template = station.Config
+	}
+	if req.OwnerID != nil {
+		if _, err := rm.dbManager.GetUser(r.Context(), *req.OwnerID); errors.Is(err, database.ErrNotFound) {
+			errs.Add("owner_id", "owner not found")
+		}
+	}
+	if schema := rm.pendingStationSchema(r.Context(), passKey); schema != nil && !errs.Has("template_id") {
+		for _, e := range schema.Validate(req.Config(template)) {
+			errs.Add("config."+e.Field, "%s", e.Message)
+		}
+	}
+	if err := errs.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	stationID, err := rm.dbManager.ApprovePendingStation(r.Context(), passKey, req)
+	if errors.Is(err, database.ErrStationExists) {
+		respondError(w, http.StatusConflict, ErrCodeConflict, "A station with passkey "+passKey+" already exists")
+		return
+	}
+	if err != nil {
+		respondDBError(w, err, "Pending station not found")
+		return
+	}
+
+	log.Printf("✓ Approved station %s (%s)", passKey, stationID)
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	station.Config = models.RedactConfig(station.Config)
+	respondJSON(w, http.StatusCreated, station)
+}
+
+// rejectPendingStationHandler refuses the pushes of a pending passkey
+func (rm *RouteManager) rejectPendingStationHandler(w http.ResponseWriter, r *http.Request) {
+	passKey := mux.Vars(r)["passkey"]
+
+	if err := rm.dbManager.RejectPendingStation(r.Context(), passKey); err != nil {
+		respondDBError(w, err, "Pending station not found")
+		return
+	}
+
+	log.Printf("✓ Rejected station %s", passKey)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pendingStationSchema returns the config schema of the pusher a pending
+// station pushed to, or nil if it is unknown
+func (rm *RouteManager) pendingStationSchema(ctx context.Context, passKey string) *models.ConfigSchema {
+	pending, err := rm.dbManager.GetPendingStations(ctx)
+	if err != nil {
+		log.Printf("⚠ Config of pending station %s not validated: %v", passKey, err)
+		return nil
+	}
+	for _, station := range pending {
+		if station.PassKey != passKey {
+			continue
+		}
+		p, ok := rm.stationPusher(&models.StationData{ServiceName: station.ServiceName})
+		if !ok {
+			return nil
+		}
+		return p.ConfigSchema()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// providerInfo describes a registered pusher or puller for setup forms
+type providerInfo struct {
+	Type         string               `json:"type"`
+	Mode         string               `json:"mode"`
+	Protocol     string               `json:"protocol,omitempty"`
+	Endpoints    []string             `json:"endpoints,omitempty"`
+	ConfigSchema *models.ConfigSchema `json:"config_schema"`
+	// SensorTypes is left out when the sensors are defined by the pushed
+	// data or the station config
+	SensorTypes []string `json:"sensor_types,omitempty"`
+	Testable    bool     `json:"testable"`
+}
+
+// getProvidersHandler lists the registered pushers and pullers with their
+// config schemas, sensor types and endpoints
+func (rm *RouteManager) getProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	providers := []providerInfo{}
+
+	for _, p := range rm.registryManager.PusherRegistry.All() {
+		info := providerInfo{
+			Type:         strings.ToLower(p.GetStationType()),
+			Mode:         "push",
+			Endpoints:    []string{"/api/v1" + p.GetEndpoint(), p.GetEndpoint()},
+			ConfigSchema: p.ConfigSchema(),
+		}
+		if describer, ok := p.(pusher.Describer); ok {
+			info.SensorTypes = describer.SensorTypes()
+		}
+		if describer, ok := p.(pusher.SetupDescriber); ok {
+			info.Protocol = describer.Setup().Protocol
+		}
+		providers = append(providers, info)
+	}
+
+	for _, p := range rm.registryManager.PullerRegistry.All() {
+		info := providerInfo{
+			Type:         p.GetProviderType(),
+			Mode:         "pull",
+			ConfigSchema: p.ConfigSchema(),
+		}
+		if describer, ok := p.(puller.Describer); ok {
+			info.SensorTypes = describer.SensorTypes()
+		}
+		_, info.Testable = p.(puller.Tester)
+		providers = append(providers, info)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		if providers[i].Mode != providers[j].Mode {
+			return providers[i].Mode < providers[j].Mode
+		}
+		return providers[i].Type < providers[j].Type
+	})
+	respondJSON(w, http.StatusOK, providers)
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handleGetPullerQuotas returns the request budgets of rate limited pullers
+func (rm *RouteManager) handleGetPullerQuotas(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, rm.registryManager.PullerService.Budgets())
+}
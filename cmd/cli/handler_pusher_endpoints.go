@@ -1,71 +1,587 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
 	"github.com/sguter90/weathermaestro/pkg/pusher"
 )
 
+// errInvalidPayload marks push errors caused by the payload itself.
+// Such payloads are rejected and never retried from the ingest queue.
+var errInvalidPayload = errors.New("invalid payload")
+
+// errSourceNotAllowed marks pushes from an address outside the allowlist
+// of the station
+var errSourceNotAllowed = errors.New("source address not allowed")
+
+// errStationNotRegistered marks pushes of unknown passkeys refused by the
+// registration policy
+var errStationNotRegistered = errors.New("station not registered")
+
+// errStationPending marks pushes of unknown stations waiting for approval
+var errStationPending = errors.New("station awaits approval")
+
+// errInvalidPassword marks pushes without the push password of the station
+var errInvalidPassword = errors.New("invalid station password")
+
+// errSignatureUnavailable marks pushes whose signature could not be checked,
+// e.g. while the database is unavailable. They are refused so the station
+// retries, instead of being stored unverified.
+var errSignatureUnavailable = errors.New("webhook signature cannot be checked")
+
+// errUnsignedReplay marks queued pushes without verified signature to a
+// station that requires one
+var errUnsignedReplay = errors.New("unsigned payload for a station with webhook secret")
+
+// errPushExpired marks pushes with readings older than the maximum push age,
+// e.g. replayed payloads
+var errPushExpired = errors.New("payload too old")
+
+// maxPushBodySize limits bodies decoded by pushers
+const maxPushBodySize = 1 << 20
+
 // weatherUpdateHandler handles incoming weather data from stations
 func (rm *RouteManager) weatherUpdateHandler(p pusher.Pusher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		receivedAt := time.Now().UTC()
+		sourceIP := rm.clientIP(r)
+
+		// Signatures cover the raw body, which parsing consumes
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxPushBodySize+1))
+		if err != nil || len(body) > maxPushBodySize {
+			respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
 		// ParseWeatherData query parameters
-		if err := r.ParseForm(); err != nil {
-			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		if err := parsePushForm(r, p); err != nil {
+			respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Failed to parse form")
 			return
 		}
 
-		stationData := p.ParseStation(r.Form)
+		// Nonce and timestamp are signed with the payload
+		nonce := r.Header.Get(nonceHeader)
+		if nonce == "" {
+			nonce = r.Form.Get("nonce")
+		}
+		timestamp := r.Header.Get(pushTimestampHeader)
+		if timestamp == "" {
+			timestamp = r.Form.Get(pushTimestampParam)
+		}
+		r.Form.Del("nonce")
+		r.Form.Del(pushTimestampParam)
 
-		// Ensure station exists
-		stationID, err := rm.dbManager.EnsureStation(stationData)
-		if err != nil {
-			log.Printf("❌ Failed to ensure station: %v", err)
-			http.Error(w, "Failed to ensure station", http.StatusInternalServerError)
+		station, secret, err := rm.pushStationSecret(r.Context(), p, r.Form)
+		signed := false
+		if err == nil {
+			signed, err = verifyPushSignature(r, p, station, secret, pusher.SignedContent(signedPushContent(r, body), nonce, timestamp))
+		}
+		if errors.Is(err, errSignatureUnavailable) {
+			log.Printf("❌ Refused weather data from %s: %v", sourceIP, err)
+			w.Header().Set("Retry-After", "60")
+			respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Webhook signature cannot be checked, retry later")
 			return
 		}
-
-		sensors := p.ParseSensors(r.Form)
-		// Ensure sensors exist
-		sensors, err = rm.dbManager.EnsureSensorsByRemoteId(stationID, sensors)
 		if err != nil {
-			log.Printf("❌ F Failed to ensure sensors: %v", err)
-			http.Error(w, "Failed to ensure sensors", http.StatusInternalServerError)
+			log.Printf("❌ Rejected weather data from %s: %v", sourceIP, err)
+			respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid webhook signature")
 			return
 		}
-		if len(sensors) == 0 {
-			log.Printf("❌ No sensors found for station ID: %s", stationID.String())
-			http.Error(w, "No sensors found for station ID", http.StatusBadRequest)
+		if station != nil && models.StationRequiresNonce(station.Config) && (!signed || nonce == "" || timestamp == "") {
+			log.Printf("❌ Rejected weather data from %s: station %s requires a signed nonce and timestamp", sourceIP, station.ID)
+			respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Signed nonce and timestamp required")
 			return
 		}
-
-		// ParseWeatherData weather data using pusher
-		readings, err := p.ParseWeatherData(r.Form, sensors)
-		if err != nil {
-			log.Printf("❌ Failed to parse weather data: %v", err)
-			http.Error(w, "Failed to parse weather data", http.StatusBadRequest)
-			return
+		if timestamp != "" {
+			if err := rm.checkPushTimestamp(timestamp, receivedAt); err != nil {
+				log.Printf("❌ Rejected weather data from %s: %v", sourceIP, err)
+				respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Push timestamp is missing or too far off the server time")
+				return
+			}
 		}
 
-		// Store weather data
-		for _, reading := range readings {
-			if err := rm.dbManager.StoreSensorReading(reading.SensorID, reading.Value, reading.DateUTC); err != nil {
-				log.Printf("❌ Failed to store reading: %v", err)
-				http.Error(w, "Failed to store readings", http.StatusInternalServerError)
+		// Stations that cannot send headers pass the key as parameter,
+		// which must not end up in the stored payload
+		key := apiKeyFromRequest(r)
+		if key == "" {
+			key = r.Form.Get("api_key")
+		}
+		r.Form.Del("api_key")
+		if rm.ingestKeyRequired {
+			// Consoles that cannot send a key are authenticated by the
+			// signature, the password or the allowlist of their station instead
+			if key == "" && !signed && !rm.pushAllowedByPassword(r.Context(), p, r.Form) &&
+				!rm.pushAllowedByAddress(r.Context(), p, r.Form, sourceIP) {
+				respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "API key required")
 				return
 			}
+			if key != "" {
+				if _, ok := rm.authenticateAPIKey(w, r, key, models.ScopeWriteIngest); !ok {
+					return
+				}
+			}
 		}
 
-		log.Printf("✓ Pushed %d Weather readings for station: %s", len(readings), stationData.StationType)
+		var raw *models.RawPayload
+		if rm.rawPayloads != nil {
+			raw = newRawPayload(r, body, p, receivedAt, sourceIP)
+		}
+
+		// Retried pushes with the same Idempotency-Key are only stored once
+		source := pushSource(p, station, r.Form)
+		rm.serveIdempotent(w, r, source, r.Form, func(w http.ResponseWriter, r *http.Request) {
+			rm.serveOnce(w, r, source, nonce, func(w http.ResponseWriter, r *http.Request) {
+				rm.storePush(w, r, p, receivedAt, sourceIP, signed, raw)
+			})
+		})
+	}
+}
+
+// parsePushForm fills r.Form with the query parameters and the form or, for
+// pushers decoding their own body, the decoded body
+func parsePushForm(r *http.Request, p pusher.Pusher) error {
+	decoder, ok := p.(pusher.BodyDecoder)
+	if !ok || !decoder.DecodesBody(r.Header.Get("Content-Type")) {
+		return r.ParseForm()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPushBodySize))
+	if err != nil {
+		return err
+	}
+	params, err := decoder.DecodeBody(body)
+	if err != nil {
+		return err
+	}
+	r.Form = r.URL.Query()
+	for key, values := range params {
+		r.Form[key] = values
+	}
+	return nil
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":     "success",
-			"message":    "Weather data stored successfully",
-			"station_id": stationID.String(),
+// storePush queues and processes a pushed payload and writes the response.
+// The raw payload, if not nil, is kept for pushes of known stations that
+// were stored or failed to parse. signed tells replays of the queued
+// payload that its webhook signature was verified.
+func (rm *RouteManager) storePush(w http.ResponseWriter, r *http.Request, p pusher.Pusher, receivedAt time.Time, sourceIP string, signed bool, raw *models.RawPayload) {
+	// Persist the raw payload before processing so it survives storage outages
+	queue := rm.registryManager.IngestQueue
+	var queueID uint64
+	if queue != nil {
+		id, err := queue.PutEntry(ingest.QueueEntry{
+			Source:      p.GetStationType(),
+			Payload:     r.Form,
+			ReceivedAt:  receivedAt,
+			SourceIP:    sourceIP,
+			Signed:      signed,
+			Credentials: pusher.CredentialParamsOf(p),
 		})
+		if err != nil {
+			log.Printf("⚠ Failed to queue payload: %v", err)
+			queue = nil
+		}
+		queueID = id
+	}
+
+	stationID, count, err := rm.processPush(r.Context(), p, r.Form, receivedAt, sourceIP)
+	if err != nil && !isPermanentPushError(err) && queue != nil {
+		log.Printf("⚠ Failed to store readings, payload queued for retry: %v", err)
+
+		respondPushStored(w, p, http.StatusAccepted, map[string]string{
+			"status":  "queued",
+			"message": "Weather data queued for storage",
+		})
+		return
+	}
+
+	if queue != nil {
+		if ackErr := queue.Ack(queueID); ackErr != nil {
+			log.Printf("❌ Failed to acknowledge queued payload: %v", ackErr)
+		}
+	}
+
+	if raw != nil && stationID != uuid.Nil && (err == nil || errors.Is(err, errInvalidPayload)) {
+		raw.StationID = stationID
+		rm.rawPayloads.Store(r.Context(), raw)
+	}
+
+	if errors.Is(err, errInvalidPayload) {
+		log.Printf("❌ Rejected weather data: %v", err)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidPayload, "Invalid weather data")
+		return
+	}
+	if errors.Is(err, errPushExpired) {
+		log.Printf("❌ Rejected weather data: %v", err)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidPayload, "Weather data is older than the maximum push age")
+		return
+	}
+	if errors.Is(err, errSourceNotAllowed) {
+		log.Printf("❌ Rejected weather data: %v", err)
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Source address not allowed for this station")
+		return
+	}
+	if errors.Is(err, errInvalidPassword) {
+		log.Printf("❌ Rejected weather data: %v", err)
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid station password")
+		return
+	}
+	if errors.Is(err, errStationPending) {
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Station awaits approval by an administrator")
+		return
+	}
+	if errors.Is(err, errStationNotRegistered) {
+		log.Printf("❌ Rejected weather data: %v", err)
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Station is not registered")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to store readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to store readings")
+		return
+	}
+
+	log.Printf("✓ Pushed %d Weather readings for station: %s from %s", count, p.GetStationType(), sourceIP)
+
+	respondPushStored(w, p, http.StatusCreated, map[string]string{
+		"status":     "success",
+		"message":    "Weather data stored successfully",
+		"station_id": stationID.String(),
+	})
+}
+
+// respondPushStored acknowledges a stored or queued push, in plain text for
+// firmwares that expect a fixed response
+func respondPushStored(w http.ResponseWriter, p pusher.Pusher, status int, data map[string]string) {
+	if ack, ok := p.(pusher.Acknowledger); ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, ack.Acknowledgement())
+		return
+	}
+	respondJSON(w, status, data)
+}
+
+// isPermanentPushError reports whether a push can never succeed and must not
+// be retried from the ingest queue
+func isPermanentPushError(err error) bool {
+	return errors.Is(err, errInvalidPayload) || errors.Is(err, errPushExpired) || errors.Is(err, errSourceNotAllowed) ||
+		errors.Is(err, errInvalidPassword) || errors.Is(err, errStationNotRegistered) || errors.Is(err, errStationPending) ||
+		errors.Is(err, errUnsignedReplay)
+}
+
+// checkRegistration applies the registration policy to the station of a
+// push. Unknown stations are refused, or listed for approval in
+// manual-approval mode; their data is not stored.
+func (rm *RouteManager) checkRegistration(ctx context.Context, p pusher.Pusher, params url.Values, station *models.StationData, sourceIP string) error {
+	_, err := rm.dbManager.GetStationIDByPassKey(ctx, station.PassKey)
+	if !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+	if rm.registrationPolicy == models.RegistrationClosed {
+		return fmt.Errorf("%w: %s from %s", errStationNotRegistered, station.PassKey, sourceIP)
+	}
+
+	status, err := rm.dbManager.RecordPendingStation(ctx, station, sourceIP, pendingSample(p, params), rm.pendingLimit)
+	if errors.Is(err, database.ErrPendingStationsFull) {
+		return fmt.Errorf("%w: %s from %s, %d stations are pending already", errStationNotRegistered, station.PassKey, sourceIP, rm.pendingLimit)
+	}
+	if err != nil {
+		return err
+	}
+	if status == models.PendingStationRejected {
+		return fmt.Errorf("%w: %s was rejected", errStationNotRegistered, station.PassKey)
+	}
+	return fmt.Errorf("%w: %s", errStationPending, station.PassKey)
+}
+
+// pendingSample parses the values of a push from an unknown station, shown
+// to admins deciding on its approval. Sensors get temporary IDs as they are
+// not stored.
+func pendingSample(p pusher.Pusher, params url.Values) []models.PendingSampleValue {
+	sensors := p.ParseSensors(params)
+	remoteIDs := make(map[uuid.UUID]string, len(sensors))
+	for remoteID, sensor := range sensors {
+		sensor.ID = uuid.New()
+		sensors[remoteID] = sensor
+		remoteIDs[sensor.ID] = remoteID
+	}
+
+	readings, err := p.ParseWeatherData(params, sensors)
+	if err != nil {
+		return nil
+	}
+	sample := make([]models.PendingSampleValue, 0, len(readings))
+	for sensorID, reading := range readings {
+		sensor := sensors[remoteIDs[sensorID]]
+		sample = append(sample, models.PendingSampleValue{
+			RemoteID:   remoteIDs[sensorID],
+			SensorType: sensor.SensorType,
+			Location:   sensor.Location,
+			Value:      reading.Value,
+			Unit:       reading.Unit,
+		})
+	}
+	sort.Slice(sample, func(i, j int) bool { return sample[i].RemoteID < sample[j].RemoteID })
+	return sample
+}
+
+// pushAllowedByAddress reports whether the station of a payload exists and
+// has an allowlist containing the source address
+func (rm *RouteManager) pushAllowedByAddress(ctx context.Context, p pusher.Pusher, params url.Values, sourceIP string) bool {
+	stationID, err := rm.dbManager.GetStationIDByPassKey(ctx, p.ParseStation(params).PassKey)
+	if err != nil {
+		return false
+	}
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		return false
+	}
+	allowlist, err := models.StationIPAllowlist(station.Config)
+	return err == nil && allowlist.Contains(sourceIP)
+}
+
+// pushAllowedByPassword reports whether the station of a payload exists, has
+// a push password and the payload carries it
+func (rm *RouteManager) pushAllowedByPassword(ctx context.Context, p pusher.Pusher, params url.Values) bool {
+	param := pusher.PasswordParamOf(p)
+	if param == "" {
+		return false
+	}
+	stationID, err := rm.dbManager.GetStationIDByPassKey(ctx, p.ParseStation(params).PassKey)
+	if err != nil {
+		return false
+	}
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil || pusher.StationPassword(station.Config) == "" {
+		return false
+	}
+	return pusher.VerifyPassword(station.Config, params.Get(param)) == nil
+}
+
+// pushStationSecret returns the station of a push and its webhook secret.
+// Unknown stations have neither; failed lookups return
+// errSignatureUnavailable.
+func (rm *RouteManager) pushStationSecret(ctx context.Context, p pusher.Pusher, params url.Values) (*models.StationData, string, error) {
+	stationID, err := rm.dbManager.GetStationIDByPassKey(ctx, p.ParseStation(params).PassKey)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, "", nil
+	}
+	var station models.StationData
+	if err == nil {
+		station, err = rm.dbManager.LoadStation(stationID)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", errSignatureUnavailable, err)
+	}
+	secret, _ := station.Config[models.WebhookSecretConfigKey].(string)
+	return &station, secret, nil
+}
+
+// signedPushContent returns the body of a push, or the query string of
+// requests without body, which the webhook signature covers
+func signedPushContent(r *http.Request, body []byte) []byte {
+	if len(body) == 0 {
+		return []byte(r.URL.RawQuery)
+	}
+	return body
+}
+
+// verifyPushSignature checks the webhook signature of a push to a station
+// with a webhook_secret over content, see pusher.SignedContent. It reports
+// whether the push was signed; pushes to stations without secret are not.
+func verifyPushSignature(r *http.Request, p pusher.Pusher, station *models.StationData, secret string, content []byte) (bool, error) {
+	if secret == "" {
+		return false, nil
+	}
+
+	signature := pusher.SignatureOf(p)
+	if header, _ := station.Config[models.WebhookSignatureHeaderConfigKey].(string); header != "" {
+		signature.Header = header
+	}
+	if err := signature.Verify(secret, content, r.Header.Get(signature.Header)); err != nil {
+		return false, fmt.Errorf("%w in %s for station %s", err, signature.Header, station.ID)
+	}
+	return true, nil
+}
+
+// processPush parses a pushed payload and runs its readings through the
+// ingest pipeline. It returns the station ID and the number of readings.
+func (rm *RouteManager) processPush(ctx context.Context, p pusher.Pusher, params url.Values, receivedAt time.Time, sourceIP string) (uuid.UUID, int, error) {
+	stationData := p.ParseStation(params)
+	if rm.registrationPolicy != models.RegistrationAuto {
+		if err := rm.checkRegistration(ctx, p, params, stationData, sourceIP); err != nil {
+			return uuid.Nil, 0, err
+		}
+	}
+
+	// Ensure station exists
+	stationID, err := rm.dbManager.EnsureStation(stationData)
+	if err != nil {
+		return uuid.Nil, 0, fmt.Errorf("failed to ensure station: %w", err)
+	}
+
+	// Load the stored station so hooks see its configuration
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		return stationID, 0, fmt.Errorf("failed to load station: %w", err)
+	}
+
+	// Stations with an allowlist only accept pushes from its addresses
+	allowlist, err := models.StationIPAllowlist(station.Config)
+	if err != nil {
+		return stationID, 0, fmt.Errorf("%w: station %s: %v", errSourceNotAllowed, stationID, err)
+	}
+	if allowlist != nil && !allowlist.Contains(sourceIP) {
+		return stationID, 0, fmt.Errorf("%w: %s for station %s", errSourceNotAllowed, sourceIP, stationID)
+	}
+
+	// Stations with a push password only accept pushes carrying it
+	if param := pusher.PasswordParamOf(p); param != "" {
+		if err := pusher.VerifyPassword(station.Config, params.Get(param)); err != nil {
+			return stationID, 0, fmt.Errorf("%w: station %s from %s", errInvalidPassword, stationID, sourceIP)
+		}
+	}
+
+	sensors, err := rm.knownSensors(ctx, stationID, p.ParseSensors(params))
+	if err != nil {
+		return stationID, 0, err
+	}
+
+	// Ensure sensors exist
+	sensors, err = rm.dbManager.EnsureSensorsByRemoteId(stationID, sensors)
+	if err != nil {
+		return stationID, 0, fmt.Errorf("failed to ensure sensors: %w", err)
+	}
+	if len(sensors) == 0 {
+		return stationID, 0, fmt.Errorf("%w: no sensors found for station ID %s", errInvalidPayload, stationID)
+	}
+
+	// ParseWeatherData weather data using pusher
+	var readings map[uuid.UUID]models.SensorReading
+	if op, ok := p.(pusher.OptionsParser); ok {
+		readings, err = op.ParseWeatherDataWithOptions(params, sensors, stationParseOptions(&station, receivedAt))
+	} else {
+		readings, err = p.ParseWeatherData(params, sensors)
+	}
+	if err != nil {
+		return stationID, 0, fmt.Errorf("%w: %v", errInvalidPayload, err)
+	}
+
+	batch := &ingest.Batch{
+		StationID:  stationID,
+		Station:    &station,
+		Sensors:    sensors,
+		Readings:   make([]models.SensorReading, 0, len(readings)),
+		Source:     "push",
+		ReceivedAt: receivedAt,
+		SourceIP:   sourceIP,
+	}
+	for _, reading := range readings {
+		batch.Readings = append(batch.Readings, reading)
+	}
+
+	// Old payloads may be replayed or backdated; stations uploading their
+	// memory need backfill enabled
+	if rm.maxPushAge > 0 && !models.StationBackfill(station.Config) {
+		if age := models.PushAge(batch.Readings, receivedAt); age > rm.maxPushAge {
+			return stationID, 0, fmt.Errorf("%w: readings of station %s are %s old", errPushExpired, stationID, age.Round(time.Second))
+		}
+	}
+
+	// Run readings through the ingest pipeline and store them
+	if err := rm.registryManager.IngestPipeline.Process(ctx, batch); err != nil {
+		return stationID, 0, err
+	}
+
+	return stationID, len(batch.Readings), nil
+}
+
+// knownSensors drops sensors whose type is neither built-in nor a custom
+// sensor type of the station
+func (rm *RouteManager) knownSensors(ctx context.Context, stationID uuid.UUID, sensors map[string]models.Sensor) (map[string]models.Sensor, error) {
+	var custom []models.CustomSensorType
+	loaded := false
+	for remoteID, sensor := range sensors {
+		if _, ok := models.SensorTypeRegistry[sensor.SensorType]; ok {
+			continue
+		}
+		if !loaded {
+			var err error
+			custom, err = rm.dbManager.GetCustomSensorTypes(ctx, stationID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load custom sensor types: %w", err)
+			}
+			loaded = true
+		}
+		if _, ok := models.LookupSensorType(sensor.SensorType, custom); !ok {
+			log.Printf("⚠ Ignoring sensor %s of station %s: unknown sensor type %q", remoteID, stationID, sensor.SensorType)
+			delete(sensors, remoteID)
+		}
+	}
+	return sensors, nil
+}
+
+// stationParseOptions builds the pusher parse options for a station.
+// The "timezone" config key overrides UTC for stations that send local time.
+func stationParseOptions(station *models.StationData, receivedAt time.Time) pusher.ParseOptions {
+	opts := pusher.ParseOptions{Fallback: receivedAt}
+
+	if tz, _ := station.Config["timezone"].(string); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			log.Printf("⚠ Invalid timezone %q for station %s: %v", tz, station.ID, err)
+		} else {
+			opts.Location = loc
+		}
+	}
+
+	return opts
+}
+
+// processQueueEntry replays a queued push payload.
+// Payloads that can never succeed are dropped instead of blocking the queue.
+func (rm *RouteManager) processQueueEntry(entry ingest.QueueEntry) error {
+	p, ok := rm.registryManager.PusherRegistry.Get(entry.Source)
+	if !ok {
+		log.Printf("⚠ Dropping queued payload %d: no pusher for %s", entry.ID, entry.Source)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The signature is not queued with the payload; payloads to stations
+	// with a webhook secret are only replayed if it was verified on receipt
+	if !entry.Signed {
+		station, secret, err := rm.pushStationSecret(ctx, p, entry.Payload)
+		if err != nil {
+			return err
+		}
+		if secret != "" {
+			log.Printf("⚠ Dropping queued payload %d: %v, station %s", entry.ID, errUnsignedReplay, station.ID)
+			return nil
+		}
+	}
+
+	_, _, err := rm.processPush(ctx, p, entry.Payload, entry.ReceivedAt, entry.SourceIP)
+	if isPermanentPushError(err) {
+		log.Printf("⚠ Dropping queued payload %d: %v", entry.ID, err)
+		return nil
 	}
+	return err
 }
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"log"
+	"math"
 	"net/http"
-	"strconv"
-	"strings"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
 	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
@@ -22,19 +24,47 @@ import (
 //   - limit: max number of results (default: 100, max: 10000)
 //   - offset: pagination offset
 //   - order: sort order (asc/desc, default: desc)
-//   - aggregate: aggregation interval (1m, 5m, 15m, 1h, 6h, 1d, 1w, 1M)
+//   - aggregate: aggregation interval, any number of minutes or hours (e.g. 10m, 3h)
+//     or calendar days, weeks and months (e.g. 1d, 2w, 1mo)
+//   - tz: IANA time zone calendar intervals are aligned to and timestamps are
+//     returned in (default: the station time zone, timestamps in UTC)
 //   - aggregate_func: aggregation function (avg, min, max, sum, count, first, last)
 //   - group_by: group results by (sensor, sensor_type, location)
+//   - pivot: one row per timestamp with a column per sensor or group (true/false)
+//   - points: downsample each series to at most N points (LTTB), requires start
 func (rm *RouteManager) getReadingsHandler(w http.ResponseWriter, r *http.Request) {
-	params := parseReadingQueryParams(r)
+	params, err := parseReadingQueryParams(r)
+	if err != nil {
+		respondValidation(w, err)
+		return
+	}
 
-	// Validate parameters
-	if err := params.Validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
 		return
 	}
 
-	var result interface{}
+	data, meta, err := rm.queryReadings(view, params)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, data, meta)
+}
+
+// queryReadings runs validated reading query params restricted to a privacy
+// view and returns the response data and meta
+func (rm *RouteManager) queryReadings(view *privacyView, params models.ReadingQueryParams) (interface{}, readingsMeta, error) {
+	view.restrictQuery(&params)
+	if params.Timezone == "" {
+		params.Timezone = rm.readingsTimezone(params)
+	}
+
+	var result *models.ReadingsResponse
 	var err error
 
 	// Handle different query modes
@@ -43,72 +73,200 @@ func (rm *RouteManager) getReadingsHandler(w http.ResponseWriter, r *http.Reques
 	} else {
 		result, err = rm.dbManager.GetReadings(params)
 	}
+	if err != nil {
+		return nil, readingsMeta{}, err
+	}
+
+	view.applyReadings(result, params)
+	if params.Points > 0 {
+		result.Data = downsampleReadings(result.Data, params.Points, params.Order)
+	}
 
+	meta := readingsMeta{
+		Total:        result.Total,
+		Page:         result.Page,
+		TotalPages:   result.TotalPages,
+		Limit:        result.Limit,
+		HasMore:      result.HasMore,
+		IsAggregated: result.IsAggregated,
+		Downsampled:  params.Points > 0,
+	}
+	displays, err := rm.dbManager.GetSensorDisplays(context.Background(), readingSensorIDs(result.Data))
 	if err != nil {
-		log.Printf("❌ Failed to query readings: %v", err)
-		http.Error(w, "Failed to query readings", http.StatusInternalServerError)
-		return
+		return nil, readingsMeta{}, err
+	}
+	if len(displays) > 0 {
+		meta.Displays = displays
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	data := result.Data
+	if params.Pivot {
+		meta.Columns, data = models.PivotReadings(result.Data)
+		meta.IsPivoted = true
+	}
+	return data, meta, nil
 }
 
-// parseReadingQueryParams extracts and parses query parameters from the request
-func parseReadingQueryParams(r *http.Request) models.ReadingQueryParams {
-	params := models.ReadingQueryParams{
-		SensorType:    r.URL.Query().Get("sensor_type"),
-		Location:      r.URL.Query().Get("location"),
-		StartTime:     r.URL.Query().Get("start"),
-		EndTime:       r.URL.Query().Get("end"),
-		Limit:         100,    // default
-		Page:          1,      // default
-		Order:         "desc", // default
-		Aggregate:     r.URL.Query().Get("aggregate"),
-		AggregateFunc: r.URL.Query().Get("aggregate_func"),
-		Latest:        r.URL.Query().Get("latest") == "true",
-		GroupBy:       r.URL.Query().Get("group_by"),
-	}
-
-	// Parse station_id
-	if stationIDStr := r.URL.Query().Get("station_id"); stationIDStr != "" {
-		if id, err := uuid.Parse(stationIDStr); err == nil {
-			params.StationID = &id
-		}
+// readingsTimezone returns the time zone calendar intervals of an
+// aggregated query are aligned to by default: the time zone of the queried
+// station, or of the station of the first queried sensor. It is empty (UTC)
+// for other queries and stations without a time zone.
+func (rm *RouteManager) readingsTimezone(params models.ReadingQueryParams) string {
+	interval, err := models.ParseAggregateInterval(params.Aggregate)
+	if err != nil || !interval.Calendar() {
+		return ""
 	}
 
-	// Parse sensor_id (can be comma-separated)
-	if sensorIDStr := r.URL.Query().Get("sensor_id"); sensorIDStr != "" {
-		for _, idStr := range strings.Split(sensorIDStr, ",") {
-			if id, err := uuid.Parse(strings.TrimSpace(idStr)); err == nil {
-				params.SensorIDs = append(params.SensorIDs, id)
-			}
+	stationID := params.StationID
+	if stationID == nil && len(params.SensorIDs) > 0 {
+		sensor, err := rm.dbManager.GetSensor(params.SensorIDs[0], false)
+		if err != nil {
+			return ""
 		}
+		stationID = &sensor.Sensor.StationID
+	}
+	if stationID == nil {
+		return ""
 	}
+	station, err := rm.dbManager.LoadStation(*stationID)
+	if err != nil {
+		return ""
+	}
+	tz, _ := station.Config["timezone"].(string)
+	if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+		return ""
+	}
+	return tz
+}
 
-	// Parse limit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 10000 {
-			params.Limit = l
+// readingsMeta contains the pagination info of a readings response
+type readingsMeta struct {
+	Total        int  `json:"total"`
+	Page         int  `json:"page"`
+	TotalPages   int  `json:"total_pages"`
+	Limit        int  `json:"limit"`
+	HasMore      bool `json:"has_more"`
+	IsAggregated bool `json:"is_aggregated"`
+	IsPivoted    bool `json:"is_pivoted,omitempty"`
+	Downsampled  bool `json:"is_downsampled,omitempty"`
+	// Columns lists the series of pivoted rows
+	Columns []string `json:"columns,omitempty"`
+	// Displays holds the display settings of the returned sensors that
+	// have any
+	Displays map[uuid.UUID]*models.SensorDisplay `json:"displays,omitempty"`
+}
+
+// readingSensorIDs returns the sensors of readings, aggregated readings
+// grouped by type or location have none
+func readingSensorIDs(data interface{}) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	add := func(id uuid.UUID) {
+		if id != uuid.Nil && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
 		}
 	}
-
-	// Parse offset
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			params.Page = o
+	switch readings := data.(type) {
+	case []models.SensorReading:
+		for _, r := range readings {
+			add(r.SensorID)
+		}
+	case []models.AggregatedReading:
+		for _, r := range readings {
+			add(r.SensorID)
 		}
 	}
+	return ids
+}
+
+// parseReadingQueryParams extracts, parses and validates the query
+// parameters of the request. The error lists every invalid parameter.
+func parseReadingQueryParams(r *http.Request) (models.ReadingQueryParams, error) {
+	q := newQueryParams(r)
+	params := readingQueryParams(q)
+	q.Check(params.Validate())
+	return params, q.Err()
+}
 
-	// Parse order
-	if orderStr := r.URL.Query().Get("order"); orderStr == "asc" || orderStr == "desc" {
-		params.Order = orderStr
+// readingQueryParams reads the reading query params shared by paginated and
+// streamed queries
+func readingQueryParams(q *queryParams) models.ReadingQueryParams {
+	params := models.ReadingQueryParams{
+		StationID:     q.UUID("station_id"),
+		SensorIDs:     q.UUIDs("sensor_id"),
+		SensorType:    q.String("sensor_type"),
+		Location:      q.String("location"),
+		StartTime:     q.String("start"),
+		EndTime:       q.String("end"),
+		Limit:         q.Int("limit", 100, 1, 10000),
+		Page:          q.Int("offset", 1, 1, math.MaxInt32),
+		Order:         q.OneOf("order", "desc", "asc", "desc"),
+		Aggregate:     q.String("aggregate"),
+		AggregateFunc: q.String("aggregate_func"),
+		Timezone:      q.String("tz"),
+		Latest:        q.Bool("latest", false),
+		GroupBy:       q.String("group_by"),
+		Pivot:         q.Bool("pivot", false),
+		Points:        q.Int("points", 0, 3, 10000),
 	}
 
 	// Default aggregate function
 	if params.Aggregate != "" && params.AggregateFunc == "" {
 		params.AggregateFunc = "avg"
 	}
-
 	return params
 }
+
+// downsampleReadings reduces each series of a readings result to at most
+// points readings with LTTB and returns them in the requested order
+func downsampleReadings(data interface{}, points int, order string) interface{} {
+	switch readings := data.(type) {
+	case []models.SensorReading:
+		return downsampleSeries(readings, points, order,
+			func(r models.SensorReading) string { return r.SensorID.String() },
+			func(r models.SensorReading) analysis.Point { return analysis.Point{Time: r.DateUTC, Value: r.Value} })
+	case []models.AggregatedReading:
+		return downsampleSeries(readings, points, order,
+			models.AggregatedReading.Series,
+			func(r models.AggregatedReading) analysis.Point {
+				return analysis.Point{Time: r.DateUTC, Value: r.Value}
+			})
+	}
+	return data
+}
+
+// downsampleSeries groups readings by series, downsamples every series on
+// its own and merges the kept readings sorted by time
+func downsampleSeries[T any](readings []T, points int, order string, series func(T) string, point func(T) analysis.Point) []T {
+	grouped := make(map[string][]T)
+	var keys []string
+	for _, r := range readings {
+		key := series(r)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], r)
+	}
+
+	result := make([]T, 0, points*len(keys))
+	for _, key := range keys {
+		group := grouped[key]
+		sort.SliceStable(group, func(i, j int) bool { return point(group[i]).Time.Before(point(group[j]).Time) })
+		values := make([]analysis.Point, len(group))
+		for i, r := range group {
+			values[i] = point(r)
+		}
+		for _, i := range analysis.LTTB(values, points) {
+			result = append(result, group[i])
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if order == "asc" {
+			return point(result[i]).Time.Before(point(result[j]).Time)
+		}
+		return point(result[i]).Time.After(point(result[j]).Time)
+	})
+	return result
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const (
+	// maxReadingsClauses limits the clauses of one bulk readings query
+	maxReadingsClauses = 50
+
+	// readingsQueryWorkers is the number of clauses executed in parallel
+	readingsQueryWorkers = 8
+)
+
+// readingsQueryRequest is the body of a bulk readings query
+type readingsQueryRequest struct {
+	Queries []readingsClause `json:"queries"`
+}
+
+// readingsClause is one query of a bulk readings request. The fields match
+// the query params of GET /readings.
+type readingsClause struct {
+	ID            string      `json:"id"`
+	StationID     *uuid.UUID  `json:"station_id,omitempty"`
+	SensorIDs     []uuid.UUID `json:"sensor_id,omitempty"`
+	SensorType    string      `json:"sensor_type,omitempty"`
+	Location      string      `json:"location,omitempty"`
+	Start         string      `json:"start,omitempty"`
+	End           string      `json:"end,omitempty"`
+	Limit         int         `json:"limit,omitempty"`
+	Offset        int         `json:"offset,omitempty"`
+	Order         string      `json:"order,omitempty"`
+	Aggregate     string      `json:"aggregate,omitempty"`
+	AggregateFunc string      `json:"aggregate_func,omitempty"`
+	Timezone      string      `json:"tz,omitempty"`
+	GroupBy       string      `json:"group_by,omitempty"`
+	Pivot         bool        `json:"pivot,omitempty"`
+	Points        int         `json:"points,omitempty"`
+}
+
+// params converts the clause to query params with the defaults of GET /readings
+func (c readingsClause) params() models.ReadingQueryParams {
+	params := models.ReadingQueryParams{
+		StationID:     c.StationID,
+		SensorIDs:     c.SensorIDs,
+		SensorType:    c.SensorType,
+		Location:      c.Location,
+		StartTime:     c.Start,
+		EndTime:       c.End,
+		Limit:         c.Limit,
+		Page:          c.Offset,
+		Order:         c.Order,
+		Aggregate:     c.Aggregate,
+		AggregateFunc: c.AggregateFunc,
+		Timezone:      c.Timezone,
+		GroupBy:       c.GroupBy,
+		Pivot:         c.Pivot,
+		Points:        c.Points,
+	}
+	if params.Limit == 0 {
+		params.Limit = 100
+	}
+	if params.Page == 0 {
+		params.Page = 1
+	}
+	if params.Order == "" {
+		params.Order = "desc"
+	}
+	if params.Aggregate != "" && params.AggregateFunc == "" {
+		params.AggregateFunc = "avg"
+	}
+	return params
+}
+
+// readingsClauseResult is the result of one clause: data and meta as
+// returned by GET /readings, or the error of the clause
+type readingsClauseResult struct {
+	Data  interface{}   `json:"data,omitempty"`
+	Meta  *readingsMeta `json:"meta,omitempty"`
+	Error *APIError     `json:"error,omitempty"`
+}
+
+// queryReadingsHandler executes several reading queries in parallel and
+// returns their results keyed by clause id. A failing clause does not fail
+// the others.
+func (rm *RouteManager) queryReadingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req readingsQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	if len(req.Queries) == 0 {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "At least one query is required")
+		return
+	}
+	if len(req.Queries) > maxReadingsClauses {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("At most %d queries are allowed", maxReadingsClauses))
+		return
+	}
+	seen := make(map[string]bool, len(req.Queries))
+	for _, clause := range req.Queries {
+		if clause.ID == "" {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Every query needs an id")
+			return
+		}
+		if seen[clause.ID] {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, fmt.Sprintf("Duplicate query id: %s", clause.ID))
+			return
+		}
+		seen[clause.ID] = true
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	results := make(map[string]readingsClauseResult, len(req.Queries))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, readingsQueryWorkers)
+
+	for _, clause := range req.Queries {
+		wg.Add(1)
+		go func(clause readingsClause) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := rm.runReadingsClause(view, clause)
+			mu.Lock()
+			results[clause.ID] = result
+			mu.Unlock()
+		}(clause)
+	}
+	wg.Wait()
+
+	respondJSON(w, http.StatusOK, results)
+}
+
+// runReadingsClause validates and executes a single clause
+func (rm *RouteManager) runReadingsClause(view *privacyView, clause readingsClause) readingsClauseResult {
+	params := clause.params()
+	if err := params.Validate(); err != nil {
+		return readingsClauseResult{Error: validationError(err)}
+	}
+
+	data, meta, err := rm.queryReadings(view, params)
+	if err != nil {
+		log.Printf("❌ Failed to query readings for clause %s: %v", clause.ID, err)
+		return readingsClauseResult{Error: &APIError{Code: ErrCodeDatabase, Message: "Failed to query readings"}}
+	}
+	return readingsClauseResult{Data: data, Meta: &meta}
+}
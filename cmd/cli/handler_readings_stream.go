@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// streamWriteTimeout is the time a client has to receive a chunk of a
+// stream; slower clients are disconnected
+const streamWriteTimeout = 30 * time.Second
+
+// getReadingsStreamHandler streams aggregated readings of a whole time range
+// as NDJSON, one aggregated reading per line, e.g. a year of hourly buckets
+// for a dashboard. The range is read in chunks and the next chunk is only
+// queried after the previous one was written, so slow clients slow down the
+// queries instead of filling the memory.
+// Query params: those of getReadingsHandler; aggregate and start are
+// required, limit and offset are ignored, pivot and points not supported.
+func (rm *RouteManager) getReadingsStreamHandler(w http.ResponseWriter, r *http.Request) {
+	q := newQueryParams(r)
+	params := readingQueryParams(q)
+	q.Check(params.ValidateStream())
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+	if params.Timezone == "" {
+		params.Timezone = rm.readingsTimezone(params)
+	}
+
+	rc := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	started := false
+	start := func() {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+
+	count := 0
+	err := rm.dbManager.StreamAggregatedReadings(r.Context(), params, func(chunk []models.AggregatedReading) error {
+		// Servers without support keep their write timeout
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		start()
+		for _, reading := range chunk {
+			if err := encoder.Encode(reading); err != nil {
+				return err
+			}
+		}
+		count += len(chunk)
+		return rc.Flush()
+	})
+
+	switch {
+	case err == nil:
+		start()
+	case errors.Is(err, context.Canceled):
+		log.Printf("⚠ Readings stream canceled by the client after %d readings", count)
+	case !started:
+		log.Printf("❌ Failed to stream readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+	default:
+		// The status is sent; a last line tells the client the stream is
+		// incomplete
+		log.Printf("❌ Failed to stream readings after %d readings: %v", count, err)
+		encoder.Encode(APIResponse{Error: &APIError{Code: ErrCodeDatabase, Message: "Failed to query readings"}})
+	}
+}
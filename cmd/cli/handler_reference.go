@@ -0,0 +1,230 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// referenceBias compares a sensor with the sensor of its reference station
+// measuring the same quantity. A positive bias means the station reads
+// higher than the reference.
+type referenceBias struct {
+	Quantity  string              `json:"quantity"`
+	Unit      string              `json:"unit,omitempty"`
+	Sensor    models.Sensor       `json:"sensor"`
+	Reference models.Sensor       `json:"reference"`
+	Windows   []referenceWindow   `json:"windows"`
+	HourOfDay []analysis.HourBias `json:"hour_of_day"`
+}
+
+// referenceWindow is the comparison over the most recent part of the period
+type referenceWindow struct {
+	Window      string    `json:"window"`
+	Start       time.Time `json:"start"`
+	Samples     int       `json:"samples"`
+	Bias        float64   `json:"bias"`
+	MeanAbsDiff float64   `json:"mean_abs_diff"`
+	RMSE        float64   `json:"rmse"`
+	MaxAbsDiff  float64   `json:"max_abs_diff"`
+	Correlation *float64  `json:"correlation,omitempty"`
+}
+
+// referenceBiasMeta describes the compared stations and time range
+type referenceBiasMeta struct {
+	ReferenceStationID uuid.UUID `json:"reference_station_id"`
+	End                time.Time `json:"end"`
+	Interval           string    `json:"interval"`
+}
+
+// handleStationReferenceBias compares a station with its official reference
+// station. Readings are averaged per interval and compared on common buckets
+// over each window; the hour of day profile covers the longest window.
+// Query params:
+//   - windows: comma-separated periods (e.g. 24h, 7d, 30d, default: 24h,7d,30d)
+//   - end: end of the windows (RFC3339, default: now)
+//   - interval: alignment interval (15m, 30m, 1h, 6h, 12h, 1d, default: 1h)
+func (rm *RouteManager) handleStationReferenceBias(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+
+	windowsParam := q.String("windows")
+	if windowsParam == "" {
+		windowsParam = "24h,7d,30d"
+	}
+	var windowNames []string
+	var windows []time.Duration
+	var longest time.Duration
+	for _, name := range strings.Split(windowsParam, ",") {
+		name = strings.TrimSpace(name)
+		d, err := parsePeriod(name)
+		if err != nil {
+			q.Invalid("windows", "%s", err)
+			break
+		}
+		windowNames = append(windowNames, name)
+		windows = append(windows, d)
+		longest = max(longest, d)
+	}
+
+	end := q.Time("end", time.Now().UTC())
+
+	// Official reports are issued every 30 or 60 minutes
+	interval := q.String("interval")
+	if interval == "" {
+		interval = "1h"
+	}
+	if d, ok := alignmentIntervals[interval]; !ok || d < 15*time.Minute {
+		q.Invalid("interval", "invalid interval: %s", interval)
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	referenceID, ok, err := models.ReferenceStationID(station.Config)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+	if !ok {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "Station has no reference station")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+	referenceSensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &referenceID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+
+	result := pairReferenceSensors(view.filterSensors(sensors), referenceSensors)
+
+	var sensorIDs []uuid.UUID
+	for _, b := range result {
+		sensorIDs = append(sensorIDs, b.Sensor.ID, b.Reference.ID)
+	}
+	series, err := rm.dbManager.GetAveragedReadings(r.Context(), sensorIDs, end.Add(-longest), end, interval)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	loc := stationLocation(&station)
+	for i := range result {
+		b := &result[i]
+		reference, own := readingSeries(b.Reference.ID, series), readingSeries(b.Sensor.ID, series)
+		for j, window := range windows {
+			start := end.Add(-window)
+			c := analysis.ComparePair(reference.Between(start, end), own.Between(start, end), analysis.CrossValidationOptions{})
+			b.Windows = append(b.Windows, referenceWindow{
+				Window:      windowNames[j],
+				Start:       start,
+				Samples:     c.Samples,
+				Bias:        c.Bias,
+				MeanAbsDiff: c.MeanAbsDiff,
+				RMSE:        c.RMSE,
+				MaxAbsDiff:  c.MaxAbsDiff,
+				Correlation: c.Correlation,
+			})
+		}
+		b.HourOfDay = analysis.BiasByHour(reference, own, loc)
+	}
+
+	respondJSONWithMeta(w, http.StatusOK, result, referenceBiasMeta{
+		ReferenceStationID: referenceID,
+		End:                end,
+		Interval:           interval,
+	})
+}
+
+// referenceQuantity returns the quantity a sensor is compared by, or an
+// empty string for sensors an official station doesn't measure. Reference
+// stations only measure outdoors, and wind direction can't be averaged
+// linearly.
+func referenceQuantity(sensor models.Sensor) string {
+	outdoor := strings.HasSuffix(sensor.SensorType, "Outdoor") || strings.EqualFold(sensor.Location, "outdoor")
+	switch sensor.SensorType {
+	case models.SensorTypeTemperature, models.SensorTypeTemperatureOutdoor:
+		if outdoor {
+			return models.SensorTypeTemperature
+		}
+	case models.SensorTypeHumidity, models.SensorTypeHumidityOutdoor:
+		if outdoor {
+			return models.SensorTypeHumidity
+		}
+	case models.SensorTypePressure, models.SensorTypePressureRelative:
+		return models.SensorTypePressureRelative
+	case models.SensorTypeWindSpeed, models.SensorTypeWindGust:
+		return sensor.SensorType
+	}
+	return ""
+}
+
+// pairReferenceSensors pairs every sensor of a station with the reference
+// sensor measuring the same quantity
+func pairReferenceSensors(sensors, referenceSensors []models.SensorWithLatestReading) []referenceBias {
+	references := make(map[string]models.Sensor)
+	for _, s := range referenceSensors {
+		if q := referenceQuantity(s.Sensor); q != "" {
+			references[q] = s.Sensor
+		}
+	}
+
+	pairs := []referenceBias{}
+	for _, s := range sensors {
+		q := referenceQuantity(s.Sensor)
+		reference, ok := references[q]
+		if q == "" || !ok {
+			continue
+		}
+		pairs = append(pairs, referenceBias{
+			Quantity:  q,
+			Unit:      models.SensorTypeRegistry[q].Unit,
+			Sensor:    s.Sensor,
+			Reference: reference,
+			Windows:   []referenceWindow{},
+		})
+	}
+	return pairs
+}
+
+// readingSeries converts the averaged readings of a sensor to a series
+func readingSeries(sensorID uuid.UUID, series map[uuid.UUID][]models.SensorReading) analysis.Series {
+	points := make([]analysis.Point, 0, len(series[sensorID]))
+	for _, reading := range series[sensorID] {
+		points = append(points, analysis.Point{Time: reading.DateUTC, Value: reading.Value})
+	}
+	return analysis.Series{SensorID: sensorID, Points: points}
+}
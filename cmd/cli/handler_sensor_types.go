@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getCustomSensorTypesHandler returns the custom sensor types of a station
+func (rm *RouteManager) getCustomSensorTypesHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	types, err := rm.dbManager.GetCustomSensorTypes(r.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query custom sensor types: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query custom sensor types")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, types)
+}
+
+// putCustomSensorTypeHandler creates or replaces a custom sensor type of a
+// station. The name is taken from the path.
+func (rm *RouteManager) putCustomSensorTypeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	stationID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var sensorType models.CustomSensorType
+	if err := json.NewDecoder(r.Body).Decode(&sensorType); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	sensorType.StationID = stationID
+	sensorType.Name = vars["name"]
+	if err := sensorType.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := rm.dbManager.SaveCustomSensorType(r.Context(), &sensorType); err != nil {
+		log.Printf("❌ Failed to save custom sensor type %s: %v", sensorType.Name, err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	log.Printf("✓ Saved custom sensor type %s of station %s", sensorType.Name, stationID)
+	respondJSON(w, http.StatusOK, sensorType)
+}
+
+// deleteCustomSensorTypeHandler deletes a custom sensor type of a station
+func (rm *RouteManager) deleteCustomSensorTypeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	stationID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	if err := rm.dbManager.DeleteCustomSensorType(r.Context(), stationID, vars["name"]); err != nil {
+		respondDBError(w, err, "Custom sensor type not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
 	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
@@ -17,11 +19,15 @@ import (
 //   - enabled: filter by enabled status (true/false)
 //   - include_latest: include latest reading for each sensor (true/false)
 func (rm *RouteManager) getSensorsHandler(w http.ResponseWriter, r *http.Request) {
-	params := parseSensorQueryParams(r)
+	params, err := parseSensorQueryParams(r)
+	if err != nil {
+		respondValidation(w, err)
+		return
+	}
 	vars := mux.Vars(r)
 	stationId, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid sensor_id format", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
 		return
 	}
 
@@ -30,12 +36,18 @@ func (rm *RouteManager) getSensorsHandler(w http.ResponseWriter, r *http.Request
 	sensors, err := rm.dbManager.GetSensors(params)
 	if err != nil {
 		log.Printf("❌ Failed to query sensors: %v", err)
-		http.Error(w, "Failed to query sensors", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sensors)
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, view.filterSensors(sensors))
 }
 
 // getSensorHandler returns a single sensor by ID
@@ -45,7 +57,7 @@ func (rm *RouteManager) getSensorHandler(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	sensorID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid sensor_id format", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid sensor_id format")
 		return
 	}
 
@@ -54,34 +66,204 @@ func (rm *RouteManager) getSensorHandler(w http.ResponseWriter, r *http.Request)
 	sensor, err := rm.dbManager.GetSensor(sensorID, includeLatest)
 	if err != nil {
 		log.Printf("❌ Failed to query sensor: %v", err)
-		http.Error(w, "Sensor not found", http.StatusNotFound)
+		respondDBError(w, err, "Sensor not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sensor)
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	if view.hides(sensorID) {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Sensor not found")
+		return
+	}
+	view.roundLatest(sensor)
+
+	respondJSON(w, http.StatusOK, sensor)
 }
 
-// parseSensorQueryParams extracts and parses query parameters from the request
-func parseSensorQueryParams(r *http.Request) models.SensorQueryParams {
-	params := models.SensorQueryParams{
-		SensorType:    r.URL.Query().Get("sensor_type"),
-		Location:      r.URL.Query().Get("location"),
-		IncludeLatest: r.URL.Query().Get("include_latest") == "true",
+// putSensorDisplayHandler sets how frontends show the readings of a sensor:
+// the preferred unit, decimal places, display name and icon. An empty object
+// resets the defaults of the sensor type.
+func (rm *RouteManager) putSensorDisplayHandler(w http.ResponseWriter, r *http.Request) {
+	sensorID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid sensor_id format")
+		return
 	}
 
-	// Parse station_id
-	if stationIDStr := r.URL.Query().Get("station_id"); stationIDStr != "" {
-		if id, err := uuid.Parse(stationIDStr); err == nil {
-			params.StationID = &id
+	var display models.SensorDisplay
+	if err := json.NewDecoder(r.Body).Decode(&display); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	sensor, err := rm.dbManager.GetSensor(sensorID, false)
+	if err != nil {
+		respondDBError(w, err, "Sensor not found")
+		return
+	}
+	custom, err := rm.dbManager.GetCustomSensorTypes(r.Context(), sensor.Sensor.StationID)
+	if err != nil {
+		log.Printf("❌ Failed to load custom sensor types: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load sensor types")
+		return
+	}
+	info, _ := models.LookupSensorType(sensor.Sensor.SensorType, custom)
+	if err := display.Validate(info.Unit); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, err.Error())
+		return
+	}
+
+	if err := rm.dbManager.SetSensorDisplay(r.Context(), sensorID, &display); err != nil {
+		log.Printf("❌ Failed to save display of sensor %s: %v", sensorID, err)
+		respondDBError(w, err, "Sensor not found")
+		return
+	}
+
+	sensor.Sensor.Display = nil
+	if !display.IsZero() {
+		sensor.Sensor.Display = &display
+	}
+	respondJSON(w, http.StatusOK, sensor.Sensor)
+}
+
+// getHighFrequencyReadingsHandler returns the raw readings of a
+// high-frequency sensor kept in memory, oldest first. sensor_readings only
+// holds their minute aggregates.
+// Query params:
+//   - since: only readings at or after this time (RFC3339, default: HIGH_FREQUENCY_RETENTION ago)
+func (rm *RouteManager) getHighFrequencyReadingsHandler(w http.ResponseWriter, r *http.Request) {
+	sensorID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid sensor_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	since := q.Time("since", time.Time{})
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	buffer := rm.registryManager.HighFrequency
+	if view.hides(sensorID) || buffer == nil || !buffer.Has(sensorID) {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "No high-frequency readings for this sensor")
+		return
+	}
+
+	readings := buffer.Readings(sensorID, since)
+	for i := range readings {
+		readings[i].Value = view.round(sensorID, readings[i].Value)
+	}
+	respondJSON(w, http.StatusOK, readings)
+}
+
+// maxResamplePoints limits the length of a resampled series
+const maxResamplePoints = 10000
+
+// resampleMeta describes a resampled series
+type resampleMeta struct {
+	SensorID uuid.UUID `json:"sensor_id"`
+	Unit     string    `json:"unit,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Interval string    `json:"interval"`
+	Method   string    `json:"method"`
+	Points   int       `json:"points"`
+}
+
+// getResampledReadingsHandler returns the readings of a sensor as an evenly
+// spaced series, e.g. as input for models that need regular time steps
+// Query params:
+//   - interval: distance of the points (Go duration, e.g. 30s, 1m, 1h, default: 1m)
+//   - method: linear or previous (default: linear)
+//   - start, end: RFC3339 time range (default: last 24 hours); start is the first point
+//   - max_gap: do not interpolate over gaps between readings longer than this (Go duration, default: no limit)
+func (rm *RouteManager) getResampledReadingsHandler(w http.ResponseWriter, r *http.Request) {
+	sensorID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid sensor_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	interval := q.Duration("interval", time.Minute)
+	method := q.OneOf("method", analysis.ResampleLinear, analysis.ResampleLinear, analysis.ResamplePrevious)
+	start, end := q.TimeRange(24 * time.Hour)
+	maxGap := q.Duration("max_gap", 0)
+	if interval < time.Second {
+		q.Invalid("interval", "interval must be at least 1s")
+	} else if q.Err() == nil && end.Sub(start)/interval >= maxResamplePoints {
+		q.Invalid("interval", "interval yields more than %d points, use a longer interval or a shorter time range", maxResamplePoints)
+	}
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	if _, err := rm.dbManager.GetSensor(sensorID, false); err != nil {
+		respondDBError(w, err, "Sensor not found")
+		return
+	}
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+	if view.hides(sensorID) {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Sensor not found")
+		return
+	}
+
+	readings, err := rm.dbManager.GetSensorSeries(r.Context(), sensorID, start, end)
+	if err != nil {
+		log.Printf("❌ Failed to query readings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	meta := resampleMeta{SensorID: sensorID, Start: start, End: end, Interval: interval.String(), Method: method}
+	points := make([]analysis.Point, len(readings))
+	for i, reading := range readings {
+		points[i] = analysis.Point{Time: reading.DateUTC, Value: reading.Value}
+		meta.Unit = reading.Unit
+	}
+	series := analysis.Resample(points, start, end, interval, maxGap, method)
+	for _, p := range series {
+		if p.Value != nil {
+			*p.Value = view.round(sensorID, *p.Value)
 		}
 	}
+	meta.Points = len(series)
 
-	// Parse enabled
-	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
-		enabled := enabledStr == "true"
+	respondJSONWithMeta(w, http.StatusOK, series, meta)
+}
+
+// parseSensorQueryParams extracts and parses query parameters from the
+// request. The error lists every invalid parameter.
+func parseSensorQueryParams(r *http.Request) (models.SensorQueryParams, error) {
+	q := newQueryParams(r)
+	params := models.SensorQueryParams{
+		StationID:     q.UUID("station_id"),
+		SensorType:    q.String("sensor_type"),
+		Location:      q.String("location"),
+		IncludeLatest: q.Bool("include_latest", false),
+	}
+	if q.String("enabled") != "" {
+		enabled := q.Bool("enabled", false)
 		params.Enabled = &enabled
 	}
-
-	return params
+	return params, q.Err()
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// createShareTokenRequest is the body of POST /stations/{id}/share-tokens
+type createShareTokenRequest struct {
+	Name      string      `json:"name"`
+	SensorIDs []uuid.UUID `json:"sensor_ids"`
+}
+
+// shareTokenSensorsRequest is the body of PUT /stations/{id}/share-tokens/{tokenId}
+type shareTokenSensorsRequest struct {
+	SensorIDs []uuid.UUID `json:"sensor_ids"`
+}
+
+// createShareTokenResponse contains the new token, which is only shown once,
+// and the path of the widget using it
+type createShareTokenResponse struct {
+	models.ShareToken
+	Token     string `json:"token"`
+	EmbedPath string `json:"embed_path"`
+}
+
+// getShareTokensHandler lists the share tokens of a station without their
+// secrets
+func (rm *RouteManager) getShareTokensHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	tokens, err := rm.dbManager.GetShareTokens(r.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query share tokens: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query share tokens")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// createShareTokenHandler creates a share token of a station
+func (rm *RouteManager) createShareTokenHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	var req createShareTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "name is required")
+		return
+	}
+
+	if !rm.validateShareSensors(w, stationID, req.SensorIDs) {
+		return
+	}
+
+	shareToken, token, err := rm.dbManager.CreateShareToken(r.Context(), stationID, req.Name, req.SensorIDs)
+	if err != nil {
+		log.Printf("❌ Failed to create share token: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	log.Printf("✓ Created share token %s (%s) for station %s", shareToken.Name, shareToken.Prefix, stationID)
+	respondJSON(w, http.StatusCreated, createShareTokenResponse{
+		ShareToken: *shareToken,
+		Token:      token,
+		EmbedPath:  "/embed/" + token + "/current",
+	})
+}
+
+// updateShareTokenHandler changes the sensors shared by a token
+func (rm *RouteManager) updateShareTokenHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, id, ok := stationAndID(w, r, "tokenId")
+	if !ok {
+		return
+	}
+
+	var req shareTokenSensorsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if !rm.validateShareSensors(w, stationID, req.SensorIDs) {
+		return
+	}
+
+	shareToken, err := rm.dbManager.SetShareTokenSensors(r.Context(), stationID, id, req.SensorIDs)
+	if err != nil {
+		respondDBError(w, err, "Share token not found")
+		return
+	}
+
+	log.Printf("✓ Share token %s (%s) shares %d sensors", shareToken.Name, shareToken.Prefix, len(shareToken.SensorIDs))
+	respondJSON(w, http.StatusOK, shareToken)
+}
+
+// validateShareSensors checks that the sensors to share belong to the
+// station. It writes the error response and returns false otherwise.
+func (rm *RouteManager) validateShareSensors(w http.ResponseWriter, stationID uuid.UUID, sensorIDs []uuid.UUID) bool {
+	if len(sensorIDs) == 0 {
+		return true
+	}
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return false
+	}
+	known := make(map[uuid.UUID]bool, len(sensors))
+	for _, s := range sensors {
+		known[s.Sensor.ID] = true
+	}
+	for _, id := range sensorIDs {
+		if !known[id] {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Sensor "+id.String()+" does not belong to the station")
+			return false
+		}
+	}
+	return true
+}
+
+// sharedSensors keeps the sensors a share token shares
+func sharedSensors(token *models.ShareToken, sensors []models.SensorWithLatestReading) []models.SensorWithLatestReading {
+	shared := make([]models.SensorWithLatestReading, 0, len(sensors))
+	for _, s := range sensors {
+		if token.Allows(s.Sensor.ID) {
+			shared = append(shared, s)
+		}
+	}
+	return shared
+}
+
+// revokeShareTokenHandler revokes a share token; it stays listed with its
+// revocation time
+func (rm *RouteManager) revokeShareTokenHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, id, ok := stationAndID(w, r, "tokenId")
+	if !ok {
+		return
+	}
+
+	shareToken, err := rm.dbManager.RevokeShareToken(r.Context(), stationID, id)
+	if err != nil {
+		respondDBError(w, err, "Share token not found")
+		return
+	}
+
+	log.Printf("✓ Revoked share token %s (%s)", shareToken.Name, shareToken.Prefix)
+	respondJSON(w, http.StatusOK, shareToken)
+}
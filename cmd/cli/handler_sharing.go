@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// geoJSONFeatureCollection is the GeoJSON map feed of shared stations
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is a station with its coordinates as point
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         uuid.UUID              `json:"id"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoJSONPoint holds longitude and latitude, in this order
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// stationSharingPolicies returns the sharing settings of all stations that
+// have some. Invalid settings are logged and skipped.
+func (rm *RouteManager) stationSharingPolicies() (map[uuid.UUID]models.SharingPolicy, error) {
+	stations, err := rm.dbManager.LoadStations()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[uuid.UUID]models.SharingPolicy)
+	for _, station := range stations {
+		policy, err := models.ParseSharingPolicy(station.Config)
+		if err != nil {
+			log.Printf("⚠ Station %s: %v", station.ID, err)
+			continue
+		}
+		if !policy.IsZero() {
+			policies[station.ID] = policy
+		}
+	}
+	return policies, nil
+}
+
+// getStationsGeoJSONHandler returns the stations whose owners agreed to be
+// shown on maps as GeoJSON, with license and attribution as properties.
+// Coordinates are rounded by the privacy settings.
+func (rm *RouteManager) getStationsGeoJSONHandler(w http.ResponseWriter, r *http.Request) {
+	stations, err := rm.dbManager.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to load stations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load stations")
+		return
+	}
+
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, station := range stations {
+		sharing, err := models.ParseSharingPolicy(station.Config)
+		if err != nil || !sharing.Consent.Map {
+			continue
+		}
+		lat, lon, ok, err := models.StationCoordinates(station.Config)
+		if err != nil || !ok {
+			continue
+		}
+		privacy, err := models.ParsePrivacyPolicy(station.Config)
+		if err != nil {
+			log.Printf("⚠ Station %s: %v", station.ID, err)
+			continue
+		}
+
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type: "Feature",
+			ID:   station.ID,
+			Geometry: geoJSONPoint{
+				Type:        "Point",
+				Coordinates: [2]float64{privacy.RoundCoordinate(lon), privacy.RoundCoordinate(lat)},
+			},
+			Properties: map[string]interface{}{
+				"name":           stationDisplayName(&station),
+				"model":          station.Model,
+				"license":        sharing.License,
+				"license_url":    sharing.LicenseURL,
+				"attribution":    sharing.Attribution,
+				"commercial_use": sharing.Consent.Commercial,
+				"last_update":    station.LastUpdate,
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(collection); err != nil {
+		log.Printf("❌ Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getSiteCurrentHandler merges the current conditions of the stations whose
+// site config is the site of the path into one value per sensor type and
+// location. Readings of the primary station (site_primary) take precedence
+// while they are not older than max_age, otherwise the newest reading wins.
+// Query params:
+//   - max_age: age after which other stations replace the primary station (default: 30m)
+func (rm *RouteManager) getSiteCurrentHandler(w http.ResponseWriter, r *http.Request) {
+	site := mux.Vars(r)["id"]
+
+	q := newQueryParams(r)
+	maxAge := q.Duration("max_age", 30*time.Minute)
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	stations, err := rm.dbManager.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to query stations: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query stations")
+		return
+	}
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	current := models.SiteCurrent{Site: site, Stations: []uuid.UUID{}}
+	var readings []models.SiteReading
+	for _, station := range stations {
+		if s, _ := station.Config[models.StationSiteConfigKey].(string); s != site {
+			continue
+		}
+		current.Stations = append(current.Stations, station.ID)
+
+		primary, err := models.ParseSitePrimary(station.Config)
+		if err != nil {
+			log.Printf("⚠ Station %s: %v", station.ID, err)
+		}
+		stationID := station.ID
+		sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID, IncludeLatest: true})
+		if err != nil {
+			log.Printf("❌ Failed to query sensors: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+			return
+		}
+		for _, s := range view.filterSensors(sensors) {
+			if !s.Sensor.Enabled || s.LatestReading == nil {
+				continue
+			}
+			reading := *s.LatestReading
+			if reading.Unit == "" {
+				reading.Unit = models.SensorTypeRegistry[s.Sensor.SensorType].Unit
+			}
+			readings = append(readings, models.SiteReading{
+				Sensor:  s.Sensor,
+				Reading: reading,
+				Primary: primary.Covers(s.Sensor.SensorType),
+			})
+		}
+	}
+	if len(current.Stations) == 0 {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Site not found")
+		return
+	}
+
+	current.Values = models.MergeSiteReadings(readings, time.Now().UTC(), maxAge)
+	respondJSON(w, http.StatusOK, current)
+}
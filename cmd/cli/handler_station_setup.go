@@ -0,0 +1,136 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+	"github.com/skip2/go-qrcode"
+)
+
+// stationSetup lists the settings entered in a station console to upload
+// to this server
+type stationSetup struct {
+	StationID    uuid.UUID `json:"station_id"`
+	Protocol     string    `json:"protocol"`
+	Server       string    `json:"server"`
+	Port         int       `json:"port"`
+	Path         string    `json:"path"`
+	Interval     int       `json:"upload_interval_seconds"`
+	StationKey   string    `json:"station_key"`
+	URL          string    `json:"url"`
+	Instructions string    `json:"instructions,omitempty"`
+	Notes        []string  `json:"notes,omitempty"`
+}
+
+// getStationSetupHandler returns the console settings of a pushing station.
+// format=png returns a QR code of the upload URL instead.
+func (rm *RouteManager) getStationSetupHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		log.Printf("❌ Failed to load station: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	if station.Mode == "pull" {
+		respondError(w, http.StatusConflict, ErrCodeConflict, "Pulled stations are set up with their provider")
+		return
+	}
+	p, ok := rm.stationPusher(&station)
+	if !ok {
+		respondError(w, http.StatusConflict, ErrCodeConflict, "No pusher found for station")
+		return
+	}
+
+	setup := rm.buildStationSetup(r, &station, p)
+
+	if r.URL.Query().Get("format") == "png" {
+		png, err := qrcode.Encode(setup.URL, qrcode.Medium, 256)
+		if err != nil {
+			log.Printf("❌ Failed to render QR code: %v", err)
+			respondError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to render QR code")
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(png)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, setup)
+}
+
+// stationPusher returns the pusher of a station by its service name.
+// Stations created by their first push have none; they belong to the only
+// registered pusher.
+func (rm *RouteManager) stationPusher(station *models.StationData) (pusher.Pusher, bool) {
+	pushers := rm.registryManager.PusherRegistry.All()
+	for _, p := range pushers {
+		if strings.EqualFold(p.GetStationType(), station.ServiceName) {
+			return p, true
+		}
+	}
+	if station.ServiceName == "" && len(pushers) == 1 {
+		return pushers[0], true
+	}
+	return nil, false
+}
+
+// buildStationSetup derives the upload settings from the public server URL
+func (rm *RouteManager) buildStationSetup(r *http.Request, station *models.StationData, p pusher.Pusher) stationSetup {
+	base, err := url.Parse(getEnv("SERVER_PUBLIC_URL", ""))
+	if err != nil || base.Host == "" {
+		base = &url.URL{Scheme: "http", Host: r.Host}
+	}
+
+	port, _ := strconv.Atoi(base.Port())
+	if port == 0 {
+		port = 80
+		if base.Scheme == "https" {
+			port = 443
+		}
+	}
+
+	path := strings.TrimRight(base.Path, "/") + "/api/v1" + p.GetEndpoint()
+	setup := stationSetup{
+		StationID:  station.ID,
+		Protocol:   p.GetStationType(),
+		Server:     base.Hostname(),
+		Port:       port,
+		StationKey: station.PassKey,
+	}
+	if describer, ok := p.(pusher.SetupDescriber); ok {
+		s := describer.Setup()
+		setup.Protocol = s.Protocol
+		setup.Interval = s.Interval
+		setup.Instructions = s.Instructions
+	}
+
+	if rm.ingestKeyRequired {
+		path += "?api_key=<api-key>"
+		setup.Notes = append(setup.Notes, "Replace <api-key> with an API key with the write:ingest scope.")
+	}
+	if base.Scheme == "https" {
+		setup.Notes = append(setup.Notes, "Most station consoles only upload via plain HTTP; make the server reachable via HTTP if uploads fail.")
+	}
+	if setup.StationKey != "" {
+		setup.Notes = append(setup.Notes, "The station sends its own key; uploads with a different key create a new station.")
+	}
+
+	setup.Path = path
+	setup.URL = base.Scheme + "://" + base.Host + path
+	return setup
+}
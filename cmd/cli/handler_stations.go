@@ -1,12 +1,16 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
 )
 
 // getStationsHandler returns all registered weather stations
@@ -14,12 +18,39 @@ func (rm *RouteManager) getStationsHandler(w http.ResponseWriter, r *http.Reques
 	stations, err := rm.dbManager.GetStationList()
 	if err != nil {
 		log.Printf("❌ Failed to query stations: %v", err)
-		http.Error(w, "Failed to query stations", http.StatusInternalServerError)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query stations")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stations)
+	policies, err := rm.stationSharingPolicies()
+	if err != nil {
+		log.Printf("❌ Failed to load sharing settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load sharing settings")
+		return
+	}
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	rater := newBatteryRater(rm.dbManager)
+	rater.view = view
+	batteries, err := rater.StationBatteries(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to rate batteries: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to rate batteries")
+		return
+	}
+	for i := range stations {
+		if policy, ok := policies[stations[i].ID]; ok {
+			stations[i].Sharing = &policy
+		}
+		stations[i].Battery = batteries[stations[i].ID]
+	}
+
+	respondJSON(w, http.StatusOK, stations)
 }
 
 // getStationHandler returns details for a specific station
@@ -28,17 +59,183 @@ func (rm *RouteManager) getStationHandler(w http.ResponseWriter, r *http.Request
 	stationIDStr := vars["id"]
 	stationID, err := uuid.Parse(stationIDStr)
 	if err != nil {
-		http.Error(w, "Invalid station_id format", http.StatusBadRequest)
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
 		return
 	}
 
 	station, err := rm.dbManager.GetStation(stationID)
 	if err != nil {
 		log.Printf("❌ Failed to query station: %v", err)
-		http.Error(w, "Station not found", http.StatusNotFound)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	config, err := rm.dbManager.GetStationConfig(stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query station config: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	if policy, err := models.ParseSharingPolicy(config); err != nil {
+		log.Printf("⚠ Station %s: %v", stationID, err)
+	} else if !policy.IsZero() {
+		station.Sharing = &policy
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	station.PullStatus = view.pullStatus(stationID, rm.visiblePullStatus(r, station.PullStatus))
+
+	rater := newBatteryRater(rm.dbManager)
+	rater.view = view
+	if station.Battery, err = rater.StationBattery(r.Context(), stationID, nil); err != nil {
+		log.Printf("❌ Failed to rate batteries: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to rate batteries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, station)
+}
+
+// getStationPullStatusHandler returns the outcome of the last pull of a station
+func (rm *RouteManager) getStationPullStatusHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	status, err := rm.dbManager.GetStationPullStatus(r.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query pull status: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	if status == nil {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, "Station has not been pulled yet")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, view.pullStatus(stationID, rm.visiblePullStatus(r, status)))
+}
+
+// visiblePullStatus removes the authorization URL for anonymous requests,
+// as it lets anyone connect their own provider account to the station
+func (rm *RouteManager) visiblePullStatus(r *http.Request, status *models.PullStatus) *models.PullStatus {
+	if status == nil || status.AuthorizationURL == "" || rm.requestHasScope(r, models.ScopeAdmin) {
+		return status
+	}
+	visible := *status
+	visible.AuthorizationURL = ""
+	return &visible
+}
+
+// getStationRecordsHandler returns the all-time extremes of a station's sensors
+func (rm *RouteManager) getStationRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	if _, err := rm.dbManager.GetStation(stationID); err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	records, err := rm.dbManager.GetStationRecords(r.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to query records: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query records")
+		return
+	}
+
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, view.filterRecords(records))
+}
+
+// getStationConfigHandler returns the config of a station with credentials
+// masked. reveal=true returns them in plain text.
+func (rm *RouteManager) getStationConfigHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		log.Printf("❌ Failed to load station config: %v", err)
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	if r.URL.Query().Get("reveal") != "true" {
+		respondJSON(w, http.StatusOK, models.RedactConfig(station.Config))
+		return
+	}
+
+	requester := "unknown"
+	if user := GetUserFromContext(r.Context()); user != nil {
+		requester = "user " + user.Username
+	} else if apiKey := GetAPIKeyFromContext(r.Context()); apiKey != nil {
+		requester = "API key " + apiKey.Name
+	}
+	log.Printf("⚠ Credentials of station %s revealed to %s", stationID, requester)
+	respondJSON(w, http.StatusOK, station.Config)
+}
+
+// pullResult is the response of a manual pull
+type pullResult struct {
+	Status   models.PullStatus      `json:"status"`
+	Readings []models.SensorReading `json:"readings"`
+}
+
+// pullStationHandler pulls a station immediately and returns the stored
+// readings. Failed pulls are reported in the status.
+func (rm *RouteManager) pullStationHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(station)
+	readings, status, err := rm.registryManager.PullerService.PullStation(r.Context(), stationID)
+	if err != nil {
+		var tooSoon *puller.PullTooSoonError
+		switch {
+		case errors.As(err, &tooSoon):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(tooSoon.RetryAfter.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, ErrCodeRateLimited, err.Error())
+		case errors.Is(err, puller.ErrStationNotPulled):
+			respondError(w, http.StatusNotFound, ErrCodeNotFound, "Station is not a pulled station")
+		default:
+			log.Printf("❌ Failed to pull station %s: %v", stationID, err)
+			respondDBError(w, err, "Station not found")
+		}
+		return
+	}
+
+	if readings == nil {
+		readings = []models.SensorReading{}
+	}
+	respondJSON(w, http.StatusOK, pullResult{Status: status, Readings: readings})
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// getStationSummaryHandler describes the current conditions of a station in
+// a short localized text for voice assistants and status bars, e.g.
+// "Currently 14.2 °C and falling, light NW wind, 2.4 mm rain since midnight"
+// Query params:
+//   - max_age: age after which readings are left out (default: 1h)
+//   - format: json or text (default: json)
+//   - lang: locale of the text (default: Accept-Language)
+func (rm *RouteManager) getStationSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid station_id format")
+		return
+	}
+
+	q := newQueryParams(r)
+	maxAge := q.Duration("max_age", time.Hour)
+	format := q.OneOf("format", "json", "json", "text")
+	if err := q.Err(); err != nil {
+		respondValidation(w, err)
+		return
+	}
+
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	view, err := rm.publicView(r)
+	if err != nil {
+		log.Printf("❌ Failed to load privacy settings: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to load privacy settings")
+		return
+	}
+
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID, IncludeLatest: true})
+	if err != nil {
+		log.Printf("❌ Failed to query sensors: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query sensors")
+		return
+	}
+
+	locale := rm.requestLocale(r)
+	summary, err := summarizeStation(r.Context(), rm.dbManager, &station, view.filterSensors(preferredRainSensors(&station, sensors)), locale, time.Now().UTC(), maxAge)
+	if err != nil {
+		log.Printf("❌ Failed to summarize station %s: %v", stationID, err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query readings")
+		return
+	}
+
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Set("Vary", "Accept-Language")
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(summary.Text + "\n"))
+		return
+	}
+	respondJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// syncConfig is a station config with its version, exchanged with edge
+// instances
+type syncConfig struct {
+	Config  map[string]interface{} `json:"config"`
+	Version string                 `json:"version"`
+}
+
+// getSyncConfigHandler returns the config of the station with a passkey.
+// Credentials are redacted unless ?reveal=true is requested with the admin
+// scope; revealing them is recorded in the audit log.
+// in plain text and its version, for edge instances syncing their config
+func (rm *RouteManager) getSyncConfigHandler(w http.ResponseWriter, r *http.Request) {
+	stationID, err := rm.dbManager.GetStationIDByPassKey(r.Context(), mux.Vars(r)["passkey"])
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	config, version, err := rm.dbManager.GetStationConfigVersion(r.Context(), stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	if r.URL.Query().Get("reveal") != "true" {
+		respondJSON(w, http.StatusOK, syncConfig{Config: models.RedactConfig(config), Version: version})
+		return
+	}
+	if !rm.requestHasScope(r, models.ScopeAdmin) {
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Revealing credentials requires the admin scope")
+		return
+	}
+	rm.auditSecretsRevealed(r, stationID, config)
+	respondJSON(w, http.StatusOK, syncConfig{Config: config, Version: version})
+}
+
+// auditSecretsRevealed records credentials of a station read in plain text
+// in the audit log of the station owner, or of the requesting user for
+// stations without owner
+func (rm *RouteManager) auditSecretsRevealed(r *http.Request, stationID uuid.UUID, config map[string]interface{}) {
+	var keys []string
+	for key, value := range config {
+		if models.IsSecretConfigKey(key) && value != nil && value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	actor := requestActor(r)
+	log.Printf("🔑 Credentials %v of station %s revealed to %s", keys, stationID, actor)
+
+	user := GetUserFromContext(r.Context())
+	if station, err := rm.dbManager.LoadStation(stationID); err == nil && station.OwnerID != nil {
+		if owner, err := rm.dbManager.GetUser(r.Context(), *station.OwnerID); err == nil {
+			user = owner
+		}
+	}
+	if user != nil {
+		auditUser(r.Context(), rm.dbManager, user, models.UserAuditSecretsRevealed, actor, map[string]interface{}{
+			"station_id": stationID,
+			"keys":       keys,
+		})
+	}
+}
+
+// putSyncConfigHandler replaces the config of the station with a passkey.
+// The version must be the one returned by GET, otherwise the config was
+// changed in between and 409 Conflict is returned. Stored credentials that
+// are missing or redacted in the config are kept, as they are not synced.
+func (rm *RouteManager) putSyncConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req syncConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.Config == nil || req.Version == "" {
+		respondError(w, http.StatusBadRequest, ErrCodeValidation, "config and version are required")
+		return
+	}
+
+	stationID, err := rm.dbManager.GetStationIDByPassKey(r.Context(), mux.Vars(r)["passkey"])
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	stored, _, err := rm.dbManager.GetStationConfigVersion(r.Context(), stationID)
+	if err != nil {
+		respondDBError(w, err, "Station not found")
+		return
+	}
+	for key, value := range stored {
+		if models.IsSecretConfigKey(key) {
+			if current, ok := req.Config[key]; !ok || current == models.RedactedValue {
+				req.Config[key] = value
+			}
+		}
+	}
+
+	version, err := rm.dbManager.ReplaceStationConfig(r.Context(), stationID, req.Config, req.Version)
+	if err != nil {
+		if errors.Is(err, database.ErrConfigChanged) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Station config changed since it was read")
+			return
+		}
+		respondDBError(w, err, "Station not found")
+		return
+	}
+
+	log.Printf("✓ Config of station %s synced from edge instance", stationID)
+	respondJSON(w, http.StatusOK, syncConfig{Config: models.RedactConfig(req.Config), Version: version})
+}
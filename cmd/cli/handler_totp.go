@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+)
+
+// totpIssuer is the account issuer shown in authenticator apps
+const totpIssuer = "WeatherMaestro"
+
+type EnrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ActivateTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type ActivateTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	// RevokedSessions is the number of other sessions logged out, which
+	// were started without a second factor
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// handleEnrollTOTP creates a new TOTP secret for the user. It is only used
+// for logins after it was activated with a code.
+func (rm *RouteManager) handleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromContext(r.Context())
+
+	secret, uri, err := rm.dbManager.EnrollTOTP(r.Context(), user.ID, totpIssuer)
+	if err != nil {
+		if errors.Is(err, database.ErrTOTPEnabled) {
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		log.Printf("❌ Failed to enroll TOTP: %v", err)
+		respondDBError(w, err, "User not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, EnrollTOTPResponse{Secret: secret, OTPAuthURI: uri})
+}
+
+// handleActivateTOTP enables two-factor authentication with a code of the
+// enrolled secret and returns the recovery codes
+func (rm *RouteManager) handleActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	var req ActivateTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	user := GetUserFromContext(r.Context())
+	codes, err := rm.dbManager.ActivateTOTP(r.Context(), user.ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrInvalidOTP):
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "Invalid one-time code")
+		case errors.Is(err, database.ErrOTPLocked):
+			respondOTPLocked(w)
+		case errors.Is(err, database.ErrTOTPEnabled):
+			respondError(w, http.StatusConflict, ErrCodeConflict, "Two-factor authentication is already enabled")
+		default:
+			log.Printf("❌ Failed to activate TOTP: %v", err)
+			respondDBError(w, err, "User not found")
+		}
+		return
+	}
+
+	revoked, err := rm.dbManager.RevokeUserSessions(r.Context(), user.ID, GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		log.Printf("❌ Failed to revoke sessions: %v", err)
+	}
+
+	log.Printf("✓ Two-factor authentication enabled for user %s, %d other sessions revoked", user.Username, revoked)
+	respondJSON(w, http.StatusOK, ActivateTOTPResponse{RecoveryCodes: codes, RevokedSessions: revoked})
+}
+
+// handleDisableTOTP turns off two-factor authentication after checking the
+// password and a one-time code
+func (rm *RouteManager) handleDisableTOTP(w http.ResponseWriter, r *http.Request) {
+	var req DisableTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	user := GetUserFromContext(r.Context())
+	if _, err := rm.dbManager.ValidateUser(r.Context(), user.Username, req.Password); err != nil {
+		respondError(w, http.StatusForbidden, ErrCodeForbidden, "Password is incorrect")
+		return
+	}
+	if err := rm.dbManager.VerifyOTP(r.Context(), user.ID, req.Code); err != nil {
+		if errors.Is(err, database.ErrInvalidOTP) {
+			respondError(w, http.StatusForbidden, ErrCodeForbidden, "Invalid one-time code")
+			return
+		}
+		if errors.Is(err, database.ErrOTPLocked) {
+			respondOTPLocked(w)
+			return
+		}
+		log.Printf("❌ Failed to verify one-time code: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to verify one-time code")
+		return
+	}
+
+	if err := rm.dbManager.DisableTOTP(r.Context(), user.ID); err != nil {
+		log.Printf("❌ Failed to disable TOTP: %v", err)
+		respondDBError(w, err, "User not found")
+		return
+	}
+
+	revoked, err := rm.dbManager.RevokeUserSessions(r.Context(), user.ID, GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		log.Printf("❌ Failed to revoke sessions: %v", err)
+	}
+
+	log.Printf("✓ Two-factor authentication disabled for user %s, %d other sessions revoked", user.Username, revoked)
+	respondJSON(w, http.StatusOK, map[string]interface{}{"success": true, "revoked_sessions": revoked})
+}
+
+// respondOTPLocked answers codes refused after too many wrong codes
+func respondOTPLocked(w http.ResponseWriter) {
+	respondError(w, http.StatusTooManyRequests, ErrCodeRateLimited, "Too many invalid one-time codes, try again later")
+}
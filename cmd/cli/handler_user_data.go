@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// userDeletionResponse is the response of a deletion request
+type userDeletionResponse struct {
+	UserID              uuid.UUID `json:"user_id"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
+
+// handleExportMyData returns all data of the logged in user as zip archive
+func (rm *RouteManager) handleExportMyData(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.sessionUser(w, r); ok {
+		rm.exportUser(w, r, user)
+	}
+}
+
+// handleScheduleMyDeletion requests the deletion of the logged in user
+func (rm *RouteManager) handleScheduleMyDeletion(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.sessionUser(w, r); ok {
+		rm.scheduleUserDeletion(w, r, user)
+	}
+}
+
+// handleCancelMyDeletion withdraws the deletion request of the logged in user
+func (rm *RouteManager) handleCancelMyDeletion(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.sessionUser(w, r); ok {
+		rm.cancelUserDeletion(w, r, user)
+	}
+}
+
+// handleExportUser returns all data of a user as zip archive
+func (rm *RouteManager) handleExportUser(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.pathUser(w, r); ok {
+		rm.exportUser(w, r, user)
+	}
+}
+
+// handleScheduleUserDeletion requests the deletion of a user
+func (rm *RouteManager) handleScheduleUserDeletion(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.pathUser(w, r); ok {
+		rm.scheduleUserDeletion(w, r, user)
+	}
+}
+
+// handleCancelUserDeletion withdraws the deletion request of a user
+func (rm *RouteManager) handleCancelUserDeletion(w http.ResponseWriter, r *http.Request) {
+	if user, ok := rm.pathUser(w, r); ok {
+		rm.cancelUserDeletion(w, r, user)
+	}
+}
+
+// handleGetUserAudit returns the audit log of a user, also after the user
+// was deleted
+func (rm *RouteManager) handleGetUserAudit(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid user id format")
+		return
+	}
+
+	entries, err := rm.dbManager.GetUserAuditLog(r.Context(), id)
+	if err != nil {
+		log.Printf("❌ Failed to query audit log: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query audit log")
+		return
+	}
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// sessionUser loads the logged in user
+func (rm *RouteManager) sessionUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	current := GetUserFromContext(r.Context())
+	if current == nil {
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	user, err := rm.dbManager.GetUser(r.Context(), current.ID)
+	if err != nil {
+		respondDBError(w, err, "User not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// pathUser loads the user of the id path variable
+func (rm *RouteManager) pathUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid user id format")
+		return nil, false
+	}
+	user, err := rm.dbManager.GetUser(r.Context(), id)
+	if err != nil {
+		respondDBError(w, err, "User not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// exportUser streams the data export of a user. Errors after the first
+// byte can't change the status anymore; the archive is incomplete then and
+// fails verification.
+func (rm *RouteManager) exportUser(w http.ResponseWriter, r *http.Request, user *models.User) {
+	name := fmt.Sprintf("weathermaestro-%s-%s.zip", user.ID, time.Now().UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	manifest, err := writeUserExport(r.Context(), rm.dbManager, user, w)
+	if err != nil {
+		log.Printf("❌ Failed to export data of user %s: %v", user.Username, err)
+		return
+	}
+
+	rows := int64(0)
+	for _, entry := range manifest.Entries {
+		rows += entry.Rows
+	}
+	auditUser(r.Context(), rm.dbManager, user, models.UserAuditExport, requestActor(r), map[string]interface{}{"rows": rows})
+	log.Printf("✓ Exported data of user %s (%d rows)", user.Username, rows)
+}
+
+// scheduleUserDeletion schedules the deletion of a user after the grace
+// period
+func (rm *RouteManager) scheduleUserDeletion(w http.ResponseWriter, r *http.Request, user *models.User) {
+	scheduled, err := rm.dbManager.ScheduleUserDeletion(r.Context(), user.ID, time.Now().Add(userDeletionGrace()))
+	if err != nil {
+		log.Printf("❌ Failed to schedule deletion of user %s: %v", user.Username, err)
+		respondDBError(w, err, "User not found")
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		auditUser(r.Context(), rm.dbManager, user, models.UserAuditDeletionScheduled, requestActor(r), map[string]interface{}{
+			"deletion_scheduled_at": scheduled,
+		})
+		log.Printf("✓ Deletion of user %s scheduled for %s", user.Username, scheduled.Format(time.RFC3339))
+	}
+	respondJSON(w, http.StatusAccepted, userDeletionResponse{UserID: user.ID, DeletionScheduledAt: scheduled})
+}
+
+// cancelUserDeletion withdraws a deletion request during the grace period
+func (rm *RouteManager) cancelUserDeletion(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if err := rm.dbManager.CancelUserDeletion(r.Context(), user.ID); err != nil {
+		respondDBError(w, err, "No deletion scheduled")
+		return
+	}
+	auditUser(r.Context(), rm.dbManager, user, models.UserAuditDeletionCancelled, requestActor(r), nil)
+	log.Printf("✓ Deletion of user %s cancelled", user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// passwordResetTTL is how long a password reset token stays valid
+const passwordResetTTL = time.Hour
+
+// passwordResetResponse reports how a reset token was delivered. The token
+// is only included if it could not be emailed to the user.
+type passwordResetResponse struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Sent      bool      `json:"sent"`
+	Token     string    `json:"token,omitempty"`
+}
+
+// handleGetUsers lists all users
+func (rm *RouteManager) handleGetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := rm.dbManager.GetUsers(r.Context())
+	if err != nil {
+		log.Printf("❌ Failed to query users: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to query users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+// handleCreatePasswordReset issues a password reset token for a user and
+// emails it when the user has an address and email is configured
+func (rm *RouteManager) handleCreatePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidID, "Invalid user id format")
+		return
+	}
+
+	user, err := rm.dbManager.GetUser(r.Context(), id)
+	if err != nil {
+		respondDBError(w, err, "User not found")
+		return
+	}
+
+	token, expiresAt, err := rm.dbManager.CreatePasswordResetToken(r.Context(), user.ID, passwordResetTTL)
+	if err != nil {
+		log.Printf("❌ Failed to create reset token: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Failed to create reset token")
+		return
+	}
+
+	response := passwordResetResponse{UserID: user.ID, ExpiresAt: expiresAt}
+	notifier := rm.registryManager.Notifier
+	if user.Email != "" && notifier.Has(notify.ChannelEmail) {
+		err := notifier.Send(r.Context(), notify.ChannelEmail, notify.Message{
+			To:      []string{user.Email},
+			Subject: "WeatherMaestro password reset",
+			Body:    passwordResetMessage(user.Username, token, expiresAt),
+		})
+		if err != nil {
+			log.Printf("⚠ Failed to email reset token to %s: %v", user.Username, err)
+		}
+		response.Sent = err == nil
+	}
+	if !response.Sent {
+		response.Token = token
+	}
+
+	log.Printf("✓ Password reset issued for user %s (emailed: %t)", user.Username, response.Sent)
+	respondJSON(w, http.StatusCreated, response)
+}
+
+// passwordResetMessage returns the email body with the reset instructions
+func passwordResetMessage(username, token string, expiresAt time.Time) string {
+	baseURL := strings.TrimRight(getEnv("SERVER_PUBLIC_URL", ""), "/")
+	return fmt.Sprintf(`Hello %s,
+
+an administrator requested a password reset for your WeatherMaestro account.
+
+Reset token: %s
+
+Set a new password until %s:
+
+POST %s/api/v1/auth/password-reset
+{"token": "%s", "new_password": "<new password>"}
+
+All your sessions are logged out after the reset. If you did not expect
+this message, you can ignore it.
+`, username, token, expiresAt.UTC().Format(time.RFC1123), baseURL, token)
+}
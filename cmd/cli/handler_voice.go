@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// voiceMaxAge is the age after which readings are not spoken anymore
+const voiceMaxAge = time.Hour
+
+// Intents answered by the voice webhook. Alexa skills and Dialogflow agents
+// must use these intent names; other intents, e.g. CurrentWeatherIntent, get
+// the whole summary.
+const (
+	voiceIntentTemperature = "TemperatureIntent"
+	voiceIntentWind        = "WindIntent"
+	voiceIntentRain        = "RainIntent"
+	voiceIntentHelp        = "AMAZON.HelpIntent"
+	voiceIntentStop        = "AMAZON.StopIntent"
+	voiceIntentCancel      = "AMAZON.CancelIntent"
+)
+
+// voiceRequest is the part of an Alexa skill request or a Dialogflow
+// webhook request the webhook reads
+type voiceRequest struct {
+	// Alexa
+	Request *struct {
+		Type   string `json:"type"`
+		Locale string `json:"locale"`
+		Intent struct {
+			Name string `json:"name"`
+		} `json:"intent"`
+	} `json:"request"`
+	// Dialogflow
+	QueryResult *struct {
+		LanguageCode string `json:"languageCode"`
+		Intent       struct {
+			DisplayName string `json:"displayName"`
+		} `json:"intent"`
+	} `json:"queryResult"`
+}
+
+// alexaResponse is the response of an Alexa skill
+type alexaResponse struct {
+	Version  string `json:"version"`
+	Response struct {
+		OutputSpeech *alexaSpeech `json:"outputSpeech,omitempty"`
+		EndSession   bool         `json:"shouldEndSession"`
+	} `json:"response"`
+}
+
+// alexaSpeech is the text Alexa speaks
+type alexaSpeech struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// dialogflowResponse is the response of a Dialogflow webhook
+type dialogflowResponse struct {
+	FulfillmentText string `json:"fulfillmentText"`
+}
+
+// voiceWebhookHandler answers voice assistant intents like "what's the
+// temperature outside" with the summary of the station of a share token.
+// The token in the path authenticates the skill, as Alexa and Dialogflow
+// can't send an API key; only the sensors it shares are spoken. Alexa skill
+// requests are answered in the Alexa format, requests with a queryResult in
+// the Dialogflow format.
+func (rm *RouteManager) voiceWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req voiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+	if req.Request == nil && req.QueryResult == nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Expected an Alexa or Dialogflow request")
+		return
+	}
+
+	shareToken, err := rm.dbManager.AuthenticateShareToken(r.Context(), mux.Vars(r)["token"])
+	if errors.Is(err, database.ErrNotFound) {
+		respondError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Invalid token")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to query share token: %v", err)
+		respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Database error")
+		return
+	}
+
+	requestType, intent, tag := "IntentRequest", "", ""
+	if req.Request != nil {
+		requestType, intent, tag = req.Request.Type, req.Request.Intent.Name, req.Request.Locale
+	} else {
+		intent, tag = req.QueryResult.Intent.DisplayName, req.QueryResult.LanguageCode
+	}
+	locale, ok := i18n.Parse(tag)
+	if !ok {
+		locale = defaultLocale()
+	}
+
+	var text string
+	endSession := true
+	switch {
+	case requestType == "SessionEndedRequest":
+		// Alexa doesn't accept speech when the session ended
+	case intent == voiceIntentHelp:
+		text, endSession = i18n.T(locale, "voice.help"), false
+	case intent == voiceIntentStop, intent == voiceIntentCancel:
+		text = i18n.T(locale, "voice.goodbye")
+	default:
+		text, err = rm.voiceAnswer(r, shareToken, intent, locale)
+		if err != nil {
+			log.Printf("❌ Failed to summarize station %s: %v", shareToken.StationID, err)
+			text = i18n.T(locale, "voice.error")
+		}
+	}
+
+	if req.QueryResult != nil {
+		respondJSON(w, http.StatusOK, dialogflowResponse{FulfillmentText: text})
+		return
+	}
+	response := alexaResponse{Version: "1.0"}
+	if text != "" {
+		response.Response.OutputSpeech = &alexaSpeech{Type: "PlainText", Text: text}
+	}
+	response.Response.EndSession = endSession
+	respondJSON(w, http.StatusOK, response)
+}
+
+// voiceAnswer speaks the part of the summary an intent asks for
+func (rm *RouteManager) voiceAnswer(r *http.Request, shareToken *models.ShareToken, intent string, locale i18n.Locale) (string, error) {
+	stationID := shareToken.StationID
+	station, err := rm.dbManager.LoadStation(stationID)
+	if err != nil {
+		return "", err
+	}
+	sensors, err := rm.dbManager.GetSensors(models.SensorQueryParams{StationID: &stationID, IncludeLatest: true})
+	if err != nil {
+		return "", err
+	}
+	// Voice assistants are public like widgets
+	view, err := loadPrivacyView(rm.dbManager)
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := summarizeStation(r.Context(), rm.dbManager, &station, view.filterSensors(sharedSensors(shareToken, preferredRainSensors(&station, sensors))), locale, time.Now().UTC(), voiceMaxAge)
+	if err != nil {
+		return "", err
+	}
+
+	var part, missing string
+	switch intent {
+	case voiceIntentTemperature:
+		part, missing = summaryTemperature(summary, locale), "voice.no_temperature"
+	case voiceIntentWind:
+		part, missing = summaryWind(summary, locale), "voice.no_wind"
+	case voiceIntentRain:
+		part, missing = summaryRain(summary, locale), "voice.no_rain"
+	default:
+		return summary.Text + ".", nil
+	}
+	if part == "" {
+		return i18n.T(locale, missing), nil
+	}
+	return capitalize(part) + ".", nil
+}
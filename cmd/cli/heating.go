@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// heatingPublishInterval is how often the flow temperature of stations with
+// a heating config is published
+const heatingPublishInterval = 15 * time.Minute
+
+// errNoHeatingConfig and errNoOutdoorTemperature explain why a station gets
+// no heating hint
+var (
+	errNoHeatingConfig      = errors.New("station has no heating config")
+	errNoOutdoorTemperature = errors.New("station has no outdoor temperature readings")
+)
+
+// heatingHint is the flow temperature suggested by the heating curve for the
+// average outdoor temperature
+type heatingHint struct {
+	StationID uuid.UUID `json:"station_id"`
+	// OutdoorAverage is the mean outdoor temperature of the last
+	// AverageHours
+	OutdoorAverage float64 `json:"outdoor_average"`
+	AverageHours   int     `json:"average_hours"`
+	// Heating is false from the heating limit on; the flow temperature is
+	// still given
+	Heating         bool      `json:"heating"`
+	FlowTemperature float64   `json:"flow_temperature"`
+	GeneratedAt     time.Time `json:"generated_at"`
+}
+
+// computeHeatingHint computes the flow temperature of a station from the
+// outdoor temperature averaged over the hours before now. The view applies
+// the privacy settings to public hints.
+func computeHeatingHint(ctx context.Context, dbManager *database.DatabaseManager, station *models.StationData, now time.Time, view *privacyView) (*heatingHint, error) {
+	policy, ok, err := models.ParseHeatingPolicy(station.Config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errNoHeatingConfig
+	}
+
+	sensors, err := dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID})
+	if err != nil {
+		return nil, err
+	}
+	// The outdoor temperature sensor is picked like for the irrigation advice
+	sensor := selectIrrigationSensors(view.filterSensors(sensors)).temp
+	if sensor == nil {
+		return nil, errNoOutdoorTemperature
+	}
+
+	start := now.Add(-time.Duration(policy.AverageHours) * time.Hour)
+	series, err := dbManager.GetAveragedReadings(ctx, []uuid.UUID{sensor.ID}, start, now, "1h")
+	if err != nil {
+		return nil, err
+	}
+	hours := series[sensor.ID]
+	if len(hours) == 0 {
+		return nil, errNoOutdoorTemperature
+	}
+	// Hourly means weigh hours with many readings like hours with few
+	sum := 0.0
+	for _, r := range hours {
+		sum += r.Value
+	}
+	average := view.round(sensor.ID, math.Round(sum/float64(len(hours))*10)/10)
+
+	return &heatingHint{
+		StationID:       station.ID,
+		OutdoorAverage:  average,
+		AverageHours:    policy.AverageHours,
+		Heating:         policy.Heating(average),
+		FlowTemperature: policy.FlowTemperature(average),
+		GeneratedAt:     now.UTC(),
+	}, nil
+}
+
+// heatingPublisher sends the heating hint of every station with a heating
+// config to MQTT and the webhook of the station, so heat pump controllers
+// can follow the curve of the station instead of their own outdoor sensor
+type heatingPublisher struct {
+	db     *database.DatabaseManager
+	mqtt   *alertPublisher
+	client *http.Client
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newHeatingPublisher creates a publisher. Without a broker hints are only
+// sent to webhooks.
+func newHeatingPublisher(dbManager *database.DatabaseManager, mqttPublisher *alertPublisher) *heatingPublisher {
+	return &heatingPublisher{
+		db:       dbManager,
+		mqtt:     mqttPublisher,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins publishing in the background
+func (p *heatingPublisher) Start() {
+	go p.run()
+	log.Println("✓ Heating curve publisher started")
+}
+
+// Stop halts the publisher and waits for the current run to finish
+func (p *heatingPublisher) Stop() {
+	close(p.stopChan)
+	<-p.doneChan
+}
+
+func (p *heatingPublisher) run() {
+	defer close(p.doneChan)
+
+	ticker := time.NewTicker(heatingPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		p.publishAll(context.Background(), time.Now())
+
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishAll publishes the hints of all stations with a heating config
+func (p *heatingPublisher) publishAll(ctx context.Context, now time.Time) {
+	stations, err := p.db.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to load stations: %v", err)
+		return
+	}
+
+	for i := range stations {
+		station := &stations[i]
+		if _, ok, err := models.ParseHeatingPolicy(station.Config); err != nil || !ok {
+			continue
+		}
+
+		hint, err := computeHeatingHint(ctx, p.db, station, now, nil)
+		if err != nil {
+			log.Printf("❌ Failed to compute heating hint of station %s: %v", station.ID, err)
+			continue
+		}
+		if err := p.Publish(ctx, station, hint); err != nil {
+			log.Printf("⚠ Failed to publish heating hint of station %s: %v", station.ID, err)
+		}
+	}
+}
+
+// Publish sends a hint to MQTT and the webhook of the station
+func (p *heatingPublisher) Publish(ctx context.Context, station *models.StationData, hint *heatingHint) error {
+	payload, err := json.Marshal(hint)
+	if err != nil {
+		return err
+	}
+
+	if err := p.mqtt.PublishStation(ctx, station.ID, "heating", payload); err != nil {
+		return err
+	}
+
+	policy, _, err := models.ParseHeatingPolicy(station.Config)
+	if err != nil || policy.WebhookURL == "" {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, policy.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+const (
+	// idempotencyHeader carries the key clients reuse when retrying a request
+	idempotencyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength is the longest key that is stored
+	maxIdempotencyKeyLength = 255
+
+	// nonceHeader carries a value clients use for one push only, so a
+	// captured request can't be replayed
+	nonceHeader = "X-Push-Nonce"
+
+	// pushTimestampHeader and pushTimestampParam carry the Unix time a push
+	// with nonce was sent at; it is signed with the nonce
+	pushTimestampHeader = "X-Push-Timestamp"
+	pushTimestampParam  = "push_timestamp"
+
+	// idempotencyPruneInterval is how often expired idempotency keys and
+	// nonces are deleted
+	idempotencyPruneInterval = time.Hour
+)
+
+// errPushTimestamp marks pushes with a missing or stale timestamp
+var errPushTimestamp = errors.New("invalid push timestamp")
+
+// responseCapture records the status and body written by a handler
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// payloadHash identifies a payload independent of the parameter order
+func payloadHash(payload url.Values) string {
+	hash := sha256.Sum256([]byte(payload.Encode()))
+	return hex.EncodeToString(hash[:])
+}
+
+// pushSource scopes the idempotency keys and nonces of a push to its
+// station, so pushes of different stations of a type can neither block
+// each other nor get each other's responses. Pushes of unknown stations are
+// scoped by a hash of their pass key.
+func pushSource(p pusher.Pusher, station *models.StationData, params url.Values) string {
+	if station != nil {
+		return "station:" + station.ID.String()
+	}
+	hash := sha256.Sum256([]byte(p.ParseStation(params).PassKey))
+	return p.GetStationType() + ":" + hex.EncodeToString(hash[:])
+}
+
+// serveIdempotent runs handle once per Idempotency-Key of a source. Retries
+// with the same key and payload get the stored response; requests without
+// key are always handled. Server errors release the key so the request can
+// be retried.
+func (rm *RouteManager) serveIdempotent(w http.ResponseWriter, r *http.Request, source string, payload url.Values, handle http.HandlerFunc) {
+	key := r.Header.Get(idempotencyHeader)
+	if key == "" {
+		handle(w, r)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Idempotency-Key is too long")
+		return
+	}
+
+	hash := payloadHash(payload)
+	record, reserved, err := rm.dbManager.ReserveIdempotencyKey(r.Context(), source, key, hash, rm.idempotencyTTL)
+	if err != nil {
+		// Storing readings matters more than deduplicating them
+		log.Printf("⚠ Failed to reserve idempotency key, processing without: %v", err)
+		handle(w, r)
+		return
+	}
+
+	if !reserved {
+		switch {
+		case record.RequestHash != hash:
+			respondError(w, http.StatusUnprocessableEntity, ErrCodeConflict, "Idempotency-Key was used for a different payload")
+		case !record.IsComplete():
+			respondError(w, http.StatusConflict, ErrCodeConflict, "A request with this Idempotency-Key is in progress")
+		default:
+			log.Printf("✓ Replayed response for idempotency key %s of %s", key, source)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Response)
+		}
+		return
+	}
+
+	capture := &responseCapture{ResponseWriter: w}
+	handle(capture, r)
+
+	// The request may have been cancelled, the key must be updated anyway
+	ctx := context.WithoutCancel(r.Context())
+	if capture.status >= http.StatusInternalServerError || capture.status == 0 {
+		if err := rm.dbManager.ReleaseIdempotencyKey(ctx, source, key); err != nil {
+			log.Printf("❌ Failed to release idempotency key: %v", err)
+		}
+		return
+	}
+	if err := rm.dbManager.CompleteIdempotencyKey(ctx, source, key, capture.status, capture.body.Bytes()); err != nil {
+		log.Printf("❌ Failed to store idempotent response: %v", err)
+	}
+}
+
+// checkPushTimestamp checks that the timestamp of a push is within
+// INGEST_NONCE_MAX_AGE of now, so a push is only accepted while its nonce
+// is kept
+func (rm *RouteManager) checkPushTimestamp(timestamp string, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q is no Unix time", errPushTimestamp, timestamp)
+	}
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > rm.nonceMaxAge {
+		return fmt.Errorf("%w: %s off the server time", errPushTimestamp, skew.Round(time.Second))
+	}
+	return nil
+}
+
+// serveOnce runs handle unless the nonce of the source was used before;
+// requests without nonce are handled unless the station requires one.
+// Nonces are kept as idempotency keys of their own source for the longest
+// of INGEST_IDEMPOTENCY_TTL, INGEST_MAX_PUSH_AGE and INGEST_NONCE_MAX_AGE.
+// Server errors release the nonce like an idempotency key; pushes whose
+// nonce can't be reserved are refused.
+func (rm *RouteManager) serveOnce(w http.ResponseWriter, r *http.Request, source, nonce string, handle http.HandlerFunc) {
+	if nonce == "" {
+		handle(w, r)
+		return
+	}
+	if len(nonce) > maxIdempotencyKeyLength {
+		respondError(w, http.StatusBadRequest, ErrCodeBadRequest, "Nonce is too long")
+		return
+	}
+
+	source = "nonce:" + source
+	ttl := max(rm.idempotencyTTL, rm.maxPushAge, rm.nonceMaxAge)
+	_, reserved, err := rm.dbManager.ReserveIdempotencyKey(r.Context(), source, nonce, payloadHash(r.Form), ttl)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		log.Printf("❌ Failed to reserve nonce, push refused: %v", err)
+		w.Header().Set("Retry-After", "60")
+		respondError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Nonce cannot be checked, retry later")
+		return
+	}
+	if !reserved {
+		log.Printf("❌ Rejected replayed push of %s: nonce %s was used before", source, nonce)
+		respondError(w, http.StatusConflict, ErrCodeConflict, "Nonce was already used")
+		return
+	}
+
+	capture := &responseCapture{ResponseWriter: w}
+	handle(capture, r)
+	if capture.status >= http.StatusInternalServerError || capture.status == 0 {
+		if err := rm.dbManager.ReleaseIdempotencyKey(context.WithoutCancel(r.Context()), source, nonce); err != nil {
+			log.Printf("❌ Failed to release nonce: %v", err)
+		}
+	}
+}
+
+// idempotencyPruner deletes expired idempotency keys and nonces, which
+// ReserveIdempotencyKey only takes over when the same key is used again
+type idempotencyPruner struct {
+	db *database.DatabaseManager
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+func newIdempotencyPruner(dbManager *database.DatabaseManager) *idempotencyPruner {
+	return &idempotencyPruner{
+		db:       dbManager,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins pruning in the background
+func (p *idempotencyPruner) Start() {
+	go p.run()
+	log.Println("✓ Idempotency key pruner started")
+}
+
+// Stop halts the pruner and waits for the current run to finish
+func (p *idempotencyPruner) Stop() {
+	close(p.stopChan)
+	<-p.doneChan
+}
+
+func (p *idempotencyPruner) run() {
+	defer close(p.doneChan)
+
+	ticker := time.NewTicker(idempotencyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		p.prune()
+
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune deletes the expired keys
+func (p *idempotencyPruner) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deleted, err := p.db.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to delete expired idempotency keys: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("✓ Deleted %d expired idempotency keys", deleted)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// indoorClimateRater derives the indoor climate of stations by the
+// "indoor_climate" thresholds of their config
+type indoorClimateRater struct {
+	db *database.DatabaseManager
+}
+
+func newIndoorClimateRater(dbManager *database.DatabaseManager) *indoorClimateRater {
+	return &indoorClimateRater{db: dbManager}
+}
+
+// indoorClimatePolicy returns the thresholds of a station config. Invalid
+// settings are logged and replaced by the defaults.
+func indoorClimatePolicy(stationID uuid.UUID, config map[string]interface{}) models.IndoorClimatePolicy {
+	policy, err := models.ParseIndoorClimatePolicy(config)
+	if err != nil {
+		log.Printf("⚠ Station %s: %v", stationID, err)
+		policy, _ = models.ParseIndoorClimatePolicy(nil)
+	}
+	return policy
+}
+
+// StationIndoorClimate returns the indoor climate of a station. Readings
+// that are newer than the stored ones replace them, so a batch is rated
+// before it is queryable.
+func (i *indoorClimateRater) StationIndoorClimate(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (*models.StationIndoorClimate, error) {
+	station, err := i.db.LoadStation(stationID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := true
+	sensors, err := i.db.GetSensors(models.SensorQueryParams{
+		StationID:     &stationID,
+		Enabled:       &enabled,
+		IncludeLatest: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for s := range sensors {
+		for _, r := range readings {
+			latest := sensors[s].LatestReading
+			if r.SensorID == sensors[s].Sensor.ID && (latest == nil || !r.DateUTC.Before(latest.DateUTC)) {
+				sensors[s].LatestReading = &r
+			}
+		}
+	}
+	return models.ComputeIndoorClimate(indoorClimatePolicy(stationID, station.Config), sensors), nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+)
+
+// newIngestPipeline creates the ingest pipeline used by pushers and pullers.
+// Hooks listed in INGEST_DISABLED_HOOKS (comma separated) start disabled,
+// forwarding hooks to open sensor and weather networks are only registered
+// with the forwarders feature. Readings are sent to another instance if federation
+// is not nil. The alert listeners are called when an alert is raised or
+// cleared. Raw readings of high-frequency sensors are kept in highFrequency.
+func newIngestPipeline(dbManager *database.DatabaseManager, features features, highFrequency *ingest.HighFrequencyBuffer, federation *ingest.FederationHook, alertListeners ...ingest.AlertListener) *ingest.Pipeline {
+	pipeline := ingest.NewPipeline(func(ctx context.Context, batch *ingest.Batch) error {
+		// The arrival time measures the ingest latency of the station
+		if !batch.ReceivedAt.IsZero() {
+			for i := range batch.Readings {
+				if batch.Readings[i].ReceivedAt == nil {
+					batch.Readings[i].ReceivedAt = &batch.ReceivedAt
+				}
+			}
+		}
+		return dbManager.StoreSensorReadingsBatch(ctx, batch.Readings)
+	})
+
+	// QC
+	pipeline.Register(ingest.NewCustomSensorsHook(dbManager))
+
+	// Calibration
+	clockSkew := ingest.NewClockSkewHook(dbManager, getEnvDuration("INGEST_CLOCK_SKEW_THRESHOLD", 5*time.Minute))
+	pipeline.Register(clockSkew)
+	pipeline.Register(ingest.NewLuxConversionHook())
+
+	// Derivation
+	pipeline.Register(ingest.NewHighFrequencyHook(highFrequency))
+	pipeline.Register(ingest.NewRecordsHook(dbManager))
+
+	// Forwarding
+	if features.Enabled(featureForwarders) {
+		pipeline.Register(ingest.NewSensorCommunityHook(dbManager))
+		pipeline.Register(ingest.NewOpenSenseMapHook(dbManager))
+		pipeline.Register(ingest.NewPWSWeatherHook(dbManager))
+		pipeline.Register(ingest.NewAwekasHook(dbManager))
+	}
+	if federation != nil {
+		pipeline.Register(federation)
+	}
+
+	// Alerting
+	alertHook := ingest.NewAlertHook(dbManager, alertListeners...)
+	alertHook.SetStormDetector(newStormDetector(dbManager))
+	alertHook.SetBatteryRater(newBatteryRater(dbManager))
+	alertHook.SetIndoorClimateRater(newIndoorClimateRater(dbManager))
+	alertHook.SetClockSkewRater(clockSkew)
+	pipeline.Register(alertHook)
+
+	applyDisabledHooks(pipeline)
+
+	return pipeline
+}
+
+// newHighFrequencyBuffer creates the in-memory buffer of raw high-frequency
+// readings. HIGH_FREQUENCY_RETENTION sets how long they are returned,
+// HIGH_FREQUENCY_BUFFER_SIZE how many are kept per sensor (default: one hour
+// of readings every 3 seconds).
+func newHighFrequencyBuffer() *ingest.HighFrequencyBuffer {
+	return ingest.NewHighFrequencyBuffer(
+		getEnvInt("HIGH_FREQUENCY_BUFFER_SIZE", 1200),
+		getEnvDuration("HIGH_FREQUENCY_RETENTION", time.Hour),
+	)
+}
+
+// openIngestQueue opens the durable queue for pushed payloads.
+// The server keeps running without a queue if the file cannot be opened.
+func openIngestQueue() *ingest.Queue {
+	path := getEnv("INGEST_QUEUE_PATH", "data/ingest-queue.log")
+
+	queue, err := ingest.OpenQueue(path)
+	if err != nil {
+		log.Printf("⚠ Ingest queue disabled: %v", err)
+		return nil
+	}
+
+	log.Printf("✓ Ingest queue opened: %s (%d pending)", path, queue.Len())
+	return queue
+}
+
+// federation forwards all readings to another instance configured by
+// FEDERATION_URL and FEDERATION_API_KEY. Requests are buffered in
+// FEDERATION_QUEUE_PATH while the other instance cannot be reached. An
+// instance forwarding its readings runs as edge instance: station configs
+// are synced with the other, central instance both ways.
+type federation struct {
+	hook    *ingest.FederationHook
+	queue   *ingest.Queue
+	drainer *ingest.Drainer
+	syncer  *configSyncer
+}
+
+// newFederation opens the federation queue. It returns nil if federation is
+// not configured or the queue cannot be opened.
+func newFederation(dbManager *database.DatabaseManager) *federation {
+	baseURL := getEnv("FEDERATION_URL", "")
+	if baseURL == "" {
+		return nil
+	}
+	path := getEnv("FEDERATION_QUEUE_PATH", "data/federation-queue.log")
+	queue, err := ingest.OpenQueue(path)
+	if err != nil {
+		log.Printf("❌ Federation disabled: %v", err)
+		return nil
+	}
+
+	apiKey := getEnv("FEDERATION_API_KEY", "")
+	f := &federation{
+		hook:   ingest.NewFederationHook(dbManager, baseURL, apiKey, queue),
+		queue:  queue,
+		syncer: newConfigSyncer(dbManager, baseURL, apiKey),
+	}
+	// Requests are kept until the other instance takes them
+	f.drainer = ingest.NewDrainer(queue, f.hook.Send, getEnvDuration("FEDERATION_RETRY_INTERVAL", time.Minute), 0)
+	log.Printf("✓ Federation to %s enabled (%d requests queued)", baseURL, queue.Len())
+	return f
+}
+
+// Start resends buffered requests and syncs configs in the background
+func (f *federation) Start() {
+	f.drainer.Start()
+	if f.syncer != nil {
+		f.syncer.Start()
+	}
+}
+
+// Stop stops resending and syncing, buffered requests are sent after the
+// next start
+func (f *federation) Stop() {
+	f.drainer.Stop()
+	if f.syncer != nil {
+		f.syncer.Stop()
+	}
+}
+
+// Close closes the queue once no more readings are ingested
+func (f *federation) Close() error {
+	return f.queue.Close()
+}
+
+// applyDisabledHooks disables all hooks configured in INGEST_DISABLED_HOOKS
+func applyDisabledHooks(pipeline *ingest.Pipeline) {
+	for _, name := range strings.Split(getEnv("INGEST_DISABLED_HOOKS", ""), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := pipeline.SetEnabled(name, false); err != nil {
+			log.Printf("⚠ Cannot disable ingest hook: %v", err)
+			continue
+		}
+		log.Printf("✓ Ingest hook disabled: %s", name)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// irrigationCheckInterval is how often the publisher checks for stations
+// whose daily advice is due
+const irrigationCheckInterval = 15 * time.Minute
+
+// errNoIrrigationConfig and errNoCoordinates explain why a station gets no
+// irrigation advice
+var (
+	errNoIrrigationConfig = errors.New("station has no irrigation config")
+	errNoCoordinates      = errors.New("station coordinates are required for the evapotranspiration")
+)
+
+// irrigationAdvice is the irrigation recommended for a day with the water
+// balance of the days before
+type irrigationAdvice struct {
+	StationID uuid.UUID `json:"station_id"`
+	// Date is the day the advice is for
+	Date string `json:"date"`
+	// Irrigation is the advised amount in mm
+	Irrigation  float64                  `json:"irrigation"`
+	Deficit     float64                  `json:"deficit"`
+	GeneratedAt time.Time                `json:"generated_at"`
+	Days        []analysis.IrrigationDay `json:"days"`
+}
+
+// irrigationSensors are the outdoor sensors the water balance is built from
+type irrigationSensors struct {
+	temp, humidity, wind, solar, rain, pressure *models.Sensor
+}
+
+// selectIrrigationSensors picks the sensors of a station used for the
+// evapotranspiration and rain
+func selectIrrigationSensors(sensors []models.SensorWithLatestReading) irrigationSensors {
+	find := func(sensorType, location string) *models.Sensor {
+		for i := range sensors {
+			s := &sensors[i].Sensor
+			if s.SensorType == sensorType && s.Enabled && (location == "" || s.Location == location) {
+				return s
+			}
+		}
+		return nil
+	}
+
+	var result irrigationSensors
+	if result.temp = find(models.SensorTypeTemperatureOutdoor, ""); result.temp == nil {
+		result.temp = find(models.SensorTypeTemperature, "Outdoor")
+	}
+	if result.humidity = find(models.SensorTypeHumidityOutdoor, ""); result.humidity == nil {
+		result.humidity = find(models.SensorTypeHumidity, "Outdoor")
+	}
+	result.wind = find(models.SensorTypeWindSpeed, "")
+	result.solar = find(models.SensorTypeSolarRadiation, "")
+	result.rain = find(models.SensorTypeRainfallDaily, "")
+	result.pressure = find(models.SensorTypePressureAbsolute, "")
+	return result
+}
+
+// computeIrrigationAdvice computes the advice of a station for the day of
+// now in the station time zone from the water balance of the days before.
+// The view applies the privacy settings to public advice.
+func computeIrrigationAdvice(ctx context.Context, dbManager *database.DatabaseManager, station *models.StationData, now time.Time, view *privacyView) (*irrigationAdvice, error) {
+	policy, ok, err := models.ParseIrrigationPolicy(station.Config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errNoIrrigationConfig
+	}
+	latitude, _, ok, err := models.StationCoordinates(station.Config)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errNoCoordinates
+	}
+
+	sensors, err := dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID})
+	if err != nil {
+		return nil, err
+	}
+	sources := selectIrrigationSensors(preferredRainSensors(station, view.filterSensors(sensors)))
+
+	loc := stationLocation(station)
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	advice := &irrigationAdvice{
+		StationID:   station.ID,
+		Date:        today.Format(time.DateOnly),
+		GeneratedAt: now.UTC(),
+		Days:        []analysis.IrrigationDay{},
+	}
+	if sources.temp == nil {
+		return advice, nil
+	}
+
+	var sensorIDs []uuid.UUID
+	for _, s := range []*models.Sensor{sources.temp, sources.humidity, sources.wind, sources.solar, sources.rain, sources.pressure} {
+		if s != nil {
+			sensorIDs = append(sensorIDs, s.ID)
+		}
+	}
+	stats, err := dbManager.GetDailyStats(ctx, sensorIDs, today.AddDate(0, 0, -policy.Days), today, loc)
+	if err != nil {
+		return nil, err
+	}
+	view.roundDailyStats(stats)
+
+	byDay := func(s *models.Sensor) map[string]models.DailyStat {
+		days := make(map[string]models.DailyStat)
+		if s != nil {
+			for _, stat := range stats[s.ID] {
+				days[stat.Day.Format(time.DateOnly)] = stat
+			}
+		}
+		return days
+	}
+	humidity, wind, solar, rain, pressure := byDay(sources.humidity), byDay(sources.wind), byDay(sources.solar), byDay(sources.rain), byDay(sources.pressure)
+
+	var days []analysis.WeatherDay
+	for _, t := range stats[sources.temp.ID] {
+		key := t.Day.Format(time.DateOnly)
+		d := analysis.WeatherDay{Day: t.Day, TempMin: t.Min, TempMax: t.Max}
+		if s, ok := humidity[key]; ok {
+			d.HumidityMin, d.HumidityMax = &s.Min, &s.Max
+		}
+		if s, ok := wind[key]; ok {
+			u2 := analysis.WindAt2m(s.Avg, policy.WindHeight)
+			d.Wind = &u2
+		}
+		if s, ok := solar[key]; ok {
+			// Mean W/m² to MJ/m²/day
+			radiation := s.Avg * 0.0864
+			d.Solar = &radiation
+		}
+		if s, ok := pressure[key]; ok {
+			kPa := s.Avg / 10
+			d.Pressure = &kPa
+		}
+		if s, ok := rain[key]; ok {
+			// The daily rain counter resets at midnight, its maximum is the day's rain
+			d.Rain = s.Max
+		}
+		days = append(days, d)
+	}
+
+	advice.Days = analysis.IrrigationSchedule(days, analysis.IrrigationSettings{
+		Latitude:         latitude,
+		Elevation:        policy.Elevation,
+		CropCoefficients: policy.CropCoefficients,
+		RainEfficiency:   policy.RainEfficiency,
+		Threshold:        policy.Threshold,
+	})
+	if n := len(advice.Days); n > 0 {
+		advice.Irrigation = advice.Days[n-1].Irrigation
+		advice.Deficit = advice.Days[n-1].Deficit
+	}
+	return advice, nil
+}
+
+// irrigationPublisher sends the irrigation advice of every station with an
+// irrigation config once a day to MQTT and the webhook of the station, so
+// irrigation controllers can consume it directly. Advice is sent at
+// IRRIGATION_PUBLISH_HOUR (station time zone, default 5).
+type irrigationPublisher struct {
+	db        *database.DatabaseManager
+	mqtt      *alertPublisher
+	client    *http.Client
+	hour      int
+	published map[uuid.UUID]string
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newIrrigationPublisher creates a publisher configured from the
+// environment. Without a broker advice is only sent to webhooks.
+func newIrrigationPublisher(dbManager *database.DatabaseManager, mqttPublisher *alertPublisher) *irrigationPublisher {
+	return &irrigationPublisher{
+		db:        dbManager,
+		mqtt:      mqttPublisher,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		hour:      getEnvInt("IRRIGATION_PUBLISH_HOUR", 5),
+		published: make(map[uuid.UUID]string),
+		stopChan:  make(chan struct{}),
+		doneChan:  make(chan struct{}),
+	}
+}
+
+// Start begins publishing in the background
+func (p *irrigationPublisher) Start() {
+	go p.run()
+	log.Println("✓ Irrigation advice publisher started")
+}
+
+// Stop halts the publisher and waits for the current run to finish
+func (p *irrigationPublisher) Stop() {
+	close(p.stopChan)
+	<-p.doneChan
+}
+
+func (p *irrigationPublisher) run() {
+	defer close(p.doneChan)
+
+	ticker := time.NewTicker(irrigationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		p.publishDue(context.Background(), time.Now())
+
+		select {
+		case <-p.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDue publishes the advice of stations that did not get today's
+// advice yet and reached the publish hour
+func (p *irrigationPublisher) publishDue(ctx context.Context, now time.Time) {
+	stations, err := p.db.LoadStations()
+	if err != nil {
+		log.Printf("❌ Failed to load stations: %v", err)
+		return
+	}
+
+	for i := range stations {
+		station := &stations[i]
+		if _, ok, err := models.ParseIrrigationPolicy(station.Config); err != nil || !ok {
+			continue
+		}
+		local := now.In(stationLocation(station))
+		today := local.Format(time.DateOnly)
+		if p.published[station.ID] == today || local.Hour() < p.hour {
+			continue
+		}
+
+		advice, err := computeIrrigationAdvice(ctx, p.db, station, now, nil)
+		if err != nil {
+			log.Printf("❌ Failed to compute irrigation advice of station %s: %v", station.ID, err)
+			continue
+		}
+		if err := p.Publish(ctx, station, advice); err != nil {
+			log.Printf("⚠ Failed to publish irrigation advice of station %s: %v", station.ID, err)
+			continue
+		}
+		p.published[station.ID] = today
+		log.Printf("✓ Published irrigation advice of station %s: %.1f mm", station.ID, advice.Irrigation)
+	}
+}
+
+// Publish sends an advice to MQTT and the webhook of the station
+func (p *irrigationPublisher) Publish(ctx context.Context, station *models.StationData, advice *irrigationAdvice) error {
+	payload, err := json.Marshal(advice)
+	if err != nil {
+		return err
+	}
+
+	if err := p.mqtt.PublishStation(ctx, station.ID, "irrigation", payload); err != nil {
+		return err
+	}
+
+	policy, _, err := models.ParseIrrigationPolicy(station.Config)
+	if err != nil || policy.WebhookURL == "" {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, policy.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return nil
+}
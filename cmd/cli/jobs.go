@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Job types executed by the job runner
+const (
+	jobTypeRecompute     = "recompute"
+	jobTypeBackup        = "backup"
+	jobTypeDataset       = "dataset"
+	jobTypeArchive       = "archive"
+	jobTypeRestore       = "archive-restore"
+	jobTypeUserPurge     = "user-purge"
+	jobTypeDBMaintenance = "db-maintenance"
+)
+
+// schedulerCheckInterval is how often schedulers check whether a job is due
+const schedulerCheckInterval = 15 * time.Minute
+
+// jobHeartbeatTimeout is how long a running job of another runner may go
+// without progress update before it counts as interrupted. Progress is
+// flushed every few seconds while a job runs.
+const jobHeartbeatTimeout = 5 * time.Minute
+
+// jobRunnerID identifies the job runner of this server among the servers
+// sharing the database. JOB_RUNNER_ID defaults to the host name, which must
+// then be stable across restarts and unique per server.
+func jobRunnerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "weathermaestro"
+	}
+	return getEnv("JOB_RUNNER_ID", hostname)
+}
+
+// newJobRunner creates the background job runner with all job handlers.
+// JOB_WORKERS sets the number of jobs that run in parallel.
+func newJobRunner(dbManager *database.DatabaseManager) *jobs.Runner {
+	runner := jobs.NewRunner(dbManager, jobRunnerID(), getEnvInt("JOB_WORKERS", 2), 2*time.Second)
+
+	runner.Register(jobTypeRecompute, recomputeJobHandler(dbManager))
+	if backups, err := newBackupService(dbManager); err != nil {
+		log.Printf("❌ Backups disabled: %v", err)
+	} else {
+		runner.Register(jobTypeBackup, backups.jobHandler())
+	}
+	runner.Register(jobTypeDataset, newDatasetService(dbManager).jobHandler())
+	runner.Register(jobTypeUserPurge, userPurgeJobHandler(dbManager))
+	if archive, err := newArchiveService(dbManager); err == nil {
+		runner.Register(jobTypeArchive, archive.jobHandler())
+		runner.Register(jobTypeRestore, archive.restoreJobHandler())
+	} else if !errors.Is(err, errArchiveNotConfigured) {
+		log.Printf("❌ Archive disabled: %v", err)
+	}
+	if maintenance, err := newDBMaintenance(dbManager); err != nil {
+		log.Printf("❌ Database maintenance disabled: %v", err)
+	} else {
+		runner.Register(jobTypeDBMaintenance, maintenance.jobHandler())
+	}
+
+	return runner
+}
+
+// prepareJobs cleans up the job table before the runner starts: jobs left
+// running by a previous process of this runner or without heartbeat for
+// jobHeartbeatTimeout are failed and finished jobs older than JOB_RETENTION
+// are deleted
+func prepareJobs(ctx context.Context, dbManager *database.DatabaseManager) {
+	if n, err := dbManager.FailInterruptedJobs(ctx, jobRunnerID(), time.Now().Add(-jobHeartbeatTimeout)); err != nil {
+		log.Printf("❌ Failed to reset interrupted jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("⚠ Marked %d interrupted jobs as failed", n)
+	}
+
+	retention := getEnvDuration("JOB_RETENTION", 7*24*time.Hour)
+	if n, err := dbManager.DeleteFinishedJobs(ctx, time.Now().Add(-retention)); err != nil {
+		log.Printf("❌ Failed to delete old jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("✓ Deleted %d finished jobs older than %s", n, retention)
+	}
+}
+
+// submitJob queues a job and wakes the runner
+func (rm *RouteManager) submitJob(ctx context.Context, jobType string, params interface{}) (*models.Job, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job params: %w", err)
+	}
+
+	job := &models.Job{Type: jobType, Params: data}
+	if err := rm.dbManager.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if runner := rm.registryManager.JobRunner; runner != nil {
+		runner.Notify()
+	}
+	return job, nil
+}
+
+// jobScheduler queues a job of one type every interval, e.g. backups every
+// BACKUP_INTERVAL. The interval counts from the last job of the type, so
+// restarts don't cause extra runs.
+type jobScheduler struct {
+	db       *database.DatabaseManager
+	runner   *jobs.Runner
+	jobType  string
+	interval time.Duration
+	// window optionally limits the time of day jobs are queued at
+	window *models.DBMaintenanceWindow
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newJobScheduler creates a scheduler queueing jobs of a type every interval
+func newJobScheduler(dbManager *database.DatabaseManager, runner *jobs.Runner, jobType string, interval time.Duration) *jobScheduler {
+	return &jobScheduler{
+		db:       dbManager,
+		runner:   runner,
+		jobType:  jobType,
+		interval: interval,
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start begins scheduling in the background
+func (s *jobScheduler) Start() {
+	go s.run()
+	if s.window != nil {
+		log.Printf("✓ Scheduler of %s jobs started, every %s between %s", s.jobType, s.interval, s.window)
+		return
+	}
+	log.Printf("✓ Scheduler of %s jobs started, every %s", s.jobType, s.interval)
+}
+
+// Stop halts the scheduler
+func (s *jobScheduler) Stop() {
+	close(s.stopChan)
+	<-s.doneChan
+}
+
+func (s *jobScheduler) run() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(min(s.interval, schedulerCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		if err := s.queueDue(context.Background(), time.Now()); err != nil {
+			log.Printf("❌ Failed to schedule %s job: %v", s.jobType, err)
+		}
+
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueDue queues a job unless one is queued, running or was
+// created less than an interval ago, or now is outside of the window
+func (s *jobScheduler) queueDue(ctx context.Context, now time.Time) error {
+	if s.window != nil && !s.window.Contains(now) {
+		return nil
+	}
+	latest, err := s.db.GetJobs(ctx, models.JobQueryParams{Type: s.jobType, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 && (!latest[0].IsFinished() || now.Before(latest[0].CreatedAt.Add(s.interval))) {
+		return nil
+	}
+
+	job := &models.Job{Type: s.jobType, Params: []byte("{}")}
+	if err := s.db.CreateJob(ctx, job); err != nil {
+		return err
+	}
+	s.runner.Notify()
+	log.Printf("▶ Queued %s job %s", s.jobType, job.ID)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Route sets served by a listener
+const (
+	// listenerRoutesAll serves the complete API and UI
+	listenerRoutesAll = "all"
+
+	// listenerRoutesPush only serves the upload endpoints of stations, the
+	// health check and the UPnP device description, e.g. on port 80 for
+	// consoles that cannot use another port
+	listenerRoutesPush = "push"
+)
+
+// listenerConfig is an address the server listens on and the routes served there
+type listenerConfig struct {
+	Addr   string
+	Routes string
+}
+
+// parseListeners parses a comma separated list of listen addresses with an
+// optional route set, e.g. ":8059,:80=push,[::1]:9000=all". An empty list
+// listens on port with all routes.
+func parseListeners(spec, port string) ([]listenerConfig, error) {
+	if strings.TrimSpace(spec) == "" {
+		return []listenerConfig{{Addr: ":" + port, Routes: listenerRoutesAll}}, nil
+	}
+
+	var listeners []listenerConfig
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		addr, routes, _ := strings.Cut(part, "=")
+		if routes == "" {
+			routes = listenerRoutesAll
+		}
+		if routes != listenerRoutesAll && routes != listenerRoutesPush {
+			return nil, fmt.Errorf("invalid routes %q for listener %s (valid: %s, %s)", routes, addr, listenerRoutesAll, listenerRoutesPush)
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid listener address %q: %w", addr, err)
+		}
+		if seen[addr] {
+			return nil, fmt.Errorf("duplicate listener address %s", addr)
+		}
+		seen[addr] = true
+		listeners = append(listeners, listenerConfig{Addr: addr, Routes: routes})
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("no listener configured")
+	}
+	return listeners, nil
+}
+
+// Handler returns the handler for a listener route set
+func (rm *RouteManager) Handler(routes string) http.Handler {
+	if routes != listenerRoutesPush {
+		return rm.Router
+	}
+
+	r := mux.NewRouter()
+	r.Use(rm.contextMiddleware)
+
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.Use(apiVersionMiddleware("1"))
+	v1.HandleFunc("/health", rm.healthHandler).Methods("GET")
+	rm.setupPusherEndpoints(v1)
+
+	r.HandleFunc("/health", rm.healthHandler).Methods("GET")
+	rm.setupPusherEndpoints(r)
+	setupDiscoveryRoutes(r)
+	return r
+}
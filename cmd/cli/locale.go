@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// localeFuncs are the template functions translating the site and widgets
+var localeFuncs = template.FuncMap{
+	"t": func(locale i18n.Locale, key string, args ...interface{}) string {
+		return i18n.T(locale, key, args...)
+	},
+}
+
+// enumsResponse holds the display names of the API enums in one locale
+type enumsResponse struct {
+	Locale        i18n.Locale      `json:"locale"`
+	Locales       []i18n.Locale    `json:"locales"`
+	SensorTypes   []sensorTypeName `json:"sensor_types"`
+	Categories    []enumName       `json:"categories"`
+	CompassPoints []string         `json:"compass_points"`
+	Beaufort      []string         `json:"beaufort"`
+	UVRisks       []enumName       `json:"uv_risks"`
+}
+
+// sensorTypeName is a built-in sensor type with its display names
+type sensorTypeName struct {
+	Name         string `json:"name"`
+	DisplayName  string `json:"display_name"`
+	Category     string `json:"category"`
+	CategoryName string `json:"category_name"`
+	Unit         string `json:"unit"`
+}
+
+// enumName is an enum value, e.g. a sensor category, with its display
+// name
+type enumName struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// defaultLocale returns the locale of requests and sites without a
+// preference, configured with DEFAULT_LOCALE
+func defaultLocale() i18n.Locale {
+	if locale, ok := i18n.Parse(getEnv("DEFAULT_LOCALE", "")); ok {
+		return locale
+	}
+	return i18n.Default
+}
+
+// parseLocaleFlag parses a locale given on the command line; an empty value
+// selects the default locale
+func parseLocaleFlag(value string) (i18n.Locale, error) {
+	if value == "" {
+		return defaultLocale(), nil
+	}
+	locale, ok := i18n.Parse(value)
+	if !ok {
+		return "", fmt.Errorf("unsupported locale %q, use one of %s", value, supportedLocales())
+	}
+	return locale, nil
+}
+
+// supportedLocales lists the supported locales for messages
+func supportedLocales() string {
+	var names []string
+	for _, locale := range i18n.Supported() {
+		names = append(names, string(locale))
+	}
+	return strings.Join(names, ", ")
+}
+
+// requestLocale picks the locale of a response: the lang query parameter,
+// the locale of the logged in user, the Accept-Language header and finally
+// the default locale.
+func (rm *RouteManager) requestLocale(r *http.Request) i18n.Locale {
+	if locale, ok := i18n.Parse(r.URL.Query().Get("lang")); ok {
+		return locale
+	}
+
+	user := GetUserFromContext(r.Context())
+	if user == nil {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			user, _, _ = parseJWTUser(token)
+		}
+	}
+	if user != nil {
+		// The token only carries the user ID and name
+		if stored, err := rm.dbManager.GetUser(r.Context(), user.ID); err == nil {
+			if locale, ok := i18n.Parse(stored.Locale); ok {
+				return locale
+			}
+		}
+	}
+
+	if locale, ok := i18n.Negotiate(r.Header.Get("Accept-Language")); ok {
+		return locale
+	}
+	return defaultLocale()
+}
+
+// sensorDisplayName returns the display name or name of a sensor, or the
+// translated name of its type and location
+func sensorDisplayName(locale i18n.Locale, sensor models.Sensor) string {
+	if sensor.Display != nil && sensor.Display.Name != "" {
+		return sensor.Display.Name
+	}
+	if sensor.Name != "" {
+		return sensor.Name
+	}
+	name := i18n.SensorTypeName(locale, sensor.SensorType)
+	if sensor.Location == "" {
+		return name
+	}
+	location := i18n.T(locale, "location."+sensor.Location)
+	if strings.HasPrefix(location, "location.") {
+		location = sensor.Location
+	}
+	return fmt.Sprintf("%s (%s)", name, location)
+}
+
+// getEnumsHandler returns the display names of sensor types, categories,
+// compass points, Beaufort numbers and UV risk categories in the locale of
+// the request
+func (rm *RouteManager) getEnumsHandler(w http.ResponseWriter, r *http.Request) {
+	locale := rm.requestLocale(r)
+
+	response := enumsResponse{
+		Locale:        locale,
+		Locales:       i18n.Supported(),
+		SensorTypes:   make([]sensorTypeName, 0, len(models.SensorTypeRegistry)),
+		CompassPoints: i18n.CompassPoints(locale),
+	}
+	for number := range models.BeaufortDescriptions {
+		response.Beaufort = append(response.Beaufort, i18n.T(locale, fmt.Sprintf("beaufort.%d", number)))
+	}
+	for _, risk := range []string{models.UVRiskLow, models.UVRiskModerate, models.UVRiskHigh, models.UVRiskVeryHigh, models.UVRiskExtreme} {
+		response.UVRisks = append(response.UVRisks, enumName{Name: risk, DisplayName: i18n.T(locale, "uv_risk."+risk)})
+	}
+	categories := make(map[string]bool)
+	for name, info := range models.SensorTypeRegistry {
+		response.SensorTypes = append(response.SensorTypes, sensorTypeName{
+			Name:         name,
+			DisplayName:  i18n.SensorTypeName(locale, name),
+			Category:     info.Category,
+			CategoryName: i18n.CategoryName(locale, info.Category),
+			Unit:         info.Unit,
+		})
+		categories[info.Category] = true
+	}
+	sort.Slice(response.SensorTypes, func(i, j int) bool { return response.SensorTypes[i].Name < response.SensorTypes[j].Name })
+	for category := range categories {
+		response.Categories = append(response.Categories, enumName{Name: category, DisplayName: i18n.CategoryName(locale, category)})
+	}
+	sort.Slice(response.Categories, func(i, j int) bool { return response.Categories[i].Name < response.Categories[j].Name })
+
+	w.Header().Set("Content-Language", string(locale))
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleSetLocale sets the preferred language of the logged in user. An
+// empty locale negotiates it per request again.
+func (rm *RouteManager) handleSetLocale(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, ErrCodeInvalidBody, "Invalid request body")
+		return
+	}
+
+	var locale i18n.Locale
+	if req.Locale != "" {
+		var ok bool
+		if locale, ok = i18n.Parse(req.Locale); !ok {
+			respondError(w, http.StatusBadRequest, ErrCodeValidation, "locale must be one of "+supportedLocales())
+			return
+		}
+	}
+
+	user := GetUserFromContext(r.Context())
+	if err := rm.dbManager.SetUserLocale(r.Context(), user.ID, string(locale)); err != nil {
+		log.Printf("❌ Failed to set locale: %v", err)
+		respondDBError(w, err, "User not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"locale": string(locale)})
+}
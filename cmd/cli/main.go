@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/sguter90/weathermaestro/pkg/database"
@@ -16,7 +17,37 @@ var rootCmd = &cobra.Command{
 that supports multiple weather station types and data sources.`,
 }
 
+func init() {
+	rootCmd.Version = version
+	rootCmd.SetVersionTemplate(versionTemplate())
+}
+
 func main() {
+	log.SetOutput(redactingWriter{w: os.Stderr})
+
+	// The version is shown and the binary updated without a database
+	if len(os.Args) == 2 && (os.Args[1] == "--version" || os.Args[1] == "-v") {
+		if err := rootCmd.Execute(); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == selfUpdateCmd.Name() {
+		if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Demo mode connects to its own temporary schema
+	if demoRequested(os.Args) {
+		if err := prepareDemoEnv(); err != nil {
+			fmt.Printf("Failed to prepare demo mode: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	dbManager, err := database.NewDatabaseManager()
 	if err != nil {
 		fmt.Printf("Failed to initialize database: %v\n", err)
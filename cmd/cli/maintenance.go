@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// maintenanceCheckInterval is how often due maintenance tasks are checked
+const maintenanceCheckInterval = 15 * time.Minute
+
+// maintenanceReminder sends one reminder per due date of a maintenance task
+// through all configured notification channels. Emails go to the addresses
+// in MAINTENANCE_NOTIFY_TO (comma separated); MAINTENANCE_REMIND_BEFORE
+// sends reminders ahead of the due date.
+type maintenanceReminder struct {
+	db       *database.DatabaseManager
+	notifier *notify.Dispatcher
+	to       []string
+	lead     time.Duration
+
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+// newMaintenanceReminder creates a reminder configured from the environment
+func newMaintenanceReminder(dbManager *database.DatabaseManager, notifier *notify.Dispatcher) *maintenanceReminder {
+	var to []string
+	for _, address := range strings.Split(getEnv("MAINTENANCE_NOTIFY_TO", ""), ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+
+	return &maintenanceReminder{
+		db:       dbManager,
+		notifier: notifier,
+		to:       to,
+		lead:     getEnvDuration("MAINTENANCE_REMIND_BEFORE", 24*time.Hour),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// channels returns the channels reminders can be sent through
+func (m *maintenanceReminder) channels() []string {
+	var channels []string
+	for _, name := range m.notifier.Channels() {
+		if name == notify.ChannelEmail && len(m.to) == 0 {
+			continue
+		}
+		channels = append(channels, name)
+	}
+	return channels
+}
+
+// Start begins checking for due tasks in the background
+func (m *maintenanceReminder) Start() {
+	go m.run()
+	if len(m.channels()) == 0 {
+		log.Println("⚠ Maintenance reminders disabled: no notification channel configured")
+	} else {
+		log.Println("✓ Maintenance reminders started")
+	}
+}
+
+// Stop halts the reminder and waits for the current check to finish
+func (m *maintenanceReminder) Stop() {
+	close(m.stopChan)
+	<-m.doneChan
+}
+
+func (m *maintenanceReminder) run() {
+	defer close(m.doneChan)
+
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		m.remind(context.Background())
+
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remind notifies about tasks due within the lead time. Tasks stay pending
+// until a reminder was delivered through at least one channel.
+func (m *maintenanceReminder) remind(ctx context.Context) {
+	channels := m.channels()
+	if len(channels) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	tasks, err := m.db.GetDueMaintenanceTasks(ctx, now.Add(m.lead))
+	if err != nil {
+		log.Printf("❌ Failed to query due maintenance tasks: %v", err)
+		return
+	}
+
+	for _, task := range tasks {
+		station, err := m.db.LoadStation(task.StationID)
+		if err != nil {
+			log.Printf("❌ Failed to load station of maintenance task %s: %v", task.ID, err)
+			continue
+		}
+
+		msg := maintenanceMessage(task, station, now)
+		msg.To = m.to
+		sent := false
+		for _, channel := range channels {
+			if err := m.notifier.Send(ctx, channel, msg); err != nil {
+				log.Printf("⚠ Failed to send maintenance reminder: %v", err)
+				continue
+			}
+			sent = true
+		}
+		if !sent {
+			continue
+		}
+
+		if err := m.db.MarkMaintenanceNotified(ctx, task.ID, now); err != nil {
+			log.Printf("❌ %v", err)
+			continue
+		}
+		log.Printf("✓ Sent maintenance reminder for %q of station %s", task.Name, station.PassKey)
+	}
+}
+
+// maintenanceMessage returns the reminder of a due task
+func maintenanceMessage(task models.MaintenanceTask, station models.StationData, now time.Time) notify.Message {
+	when := "is due on " + task.NextDueAt.UTC().Format(time.DateOnly)
+	if task.Due(now) {
+		when = "was due on " + task.NextDueAt.UTC().Format(time.DateOnly)
+	}
+
+	baseURL := strings.TrimRight(getEnv("SERVER_PUBLIC_URL", ""), "/")
+	body := fmt.Sprintf(`Maintenance of station %s %s:
+
+%s
+%s
+Log the completed maintenance to schedule the next one in %d days:
+
+POST %s/api/v1/stations/%s/maintenance/%s/complete
+{"note": "<optional note>"}
+`, station.PassKey, when, task.Name, task.Description, task.IntervalDays, baseURL, station.ID, task.ID)
+
+	return notify.Message{
+		Subject: fmt.Sprintf("WeatherMaestro maintenance: %s (%s)", task.Name, station.PassKey),
+		Body:    body,
+	}
+}
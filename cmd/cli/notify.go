@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log"
+
+	"github.com/sguter90/weathermaestro/pkg/notify"
+)
+
+// newNotifier creates the notification channels configured in the
+// environment. Channels without configuration are left out.
+func newNotifier() *notify.Dispatcher {
+	var channels []notify.Channel
+
+	if host := getEnv("NOTIFY_SMTP_HOST", ""); host != "" {
+		email, err := notify.NewEmailChannel(notify.EmailConfig{
+			Host:     host,
+			Port:     getEnvInt("NOTIFY_SMTP_PORT", 587),
+			Username: getEnv("NOTIFY_SMTP_USER", ""),
+			Password: getEnv("NOTIFY_SMTP_PASSWORD", ""),
+			From:     getEnv("NOTIFY_SMTP_FROM", ""),
+		})
+		if err != nil {
+			log.Printf("⚠ Email notifications disabled: %v", err)
+		} else {
+			channels = append(channels, email)
+		}
+	}
+	if url := getEnv("NOTIFY_WEBHOOK_URL", ""); url != "" {
+		channels = append(channels, notify.NewWebhookChannel(url))
+	}
+
+	notifier := notify.NewDispatcher(channels...)
+	for _, name := range notifier.Channels() {
+		log.Printf("✓ Notification channel enabled: %s", name)
+	}
+	return notifier
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// queryParams reads typed query parameters of a request. Invalid values
+// are collected instead of silently falling back to the default, so the
+// handler can report all of them with respondValidation.
+type queryParams struct {
+	values url.Values
+	errs   models.ValidationErrors
+}
+
+// newQueryParams returns the query parameters of a request
+func newQueryParams(r *http.Request) *queryParams {
+	return &queryParams{values: r.URL.Query()}
+}
+
+// String returns a parameter, empty if it is missing
+func (q *queryParams) String(name string) string {
+	return q.values.Get(name)
+}
+
+// Int returns an integer parameter between min and max, def if missing
+func (q *queryParams) Int(name string, def, min, max int) int {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		q.errs.Add(name, "%s must be an integer between %d and %d", name, min, max)
+		return def
+	}
+	return n
+}
+
+// Float returns a number parameter, def if missing
+func (q *queryParams) Float(name string, def float64) float64 {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		q.errs.Add(name, "%s must be a number", name)
+		return def
+	}
+	return f
+}
+
+// Bool returns a boolean parameter, def if missing
+func (q *queryParams) Bool(name string, def bool) bool {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		q.errs.Add(name, "%s must be true or false", name)
+		return def
+	}
+	return b
+}
+
+// OneOf returns a parameter that must be one of the allowed values, def if
+// missing
+func (q *queryParams) OneOf(name, def string, allowed ...string) string {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	if !slices.Contains(allowed, v) {
+		q.errs.Add(name, "%s must be one of %s", name, strings.Join(allowed, ", "))
+		return def
+	}
+	return v
+}
+
+// UUID returns a UUID parameter, nil if missing
+func (q *queryParams) UUID(name string) *uuid.UUID {
+	v := q.values.Get(name)
+	if v == "" {
+		return nil
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		q.errs.Add(name, "%s must be a UUID", name)
+		return nil
+	}
+	return &id
+}
+
+// UUIDs returns a comma separated list of UUIDs
+func (q *queryParams) UUIDs(name string) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, v := range strings.Split(q.values.Get(name), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		id, err := uuid.Parse(v)
+		if err != nil {
+			q.errs.Add(name, "%s must be a comma separated list of UUIDs", name)
+			return nil
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Duration returns a positive Go duration parameter (e.g. 90s), def if
+// missing
+func (q *queryParams) Duration(name string, def time.Duration) time.Duration {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		q.errs.Add(name, "%s must be a positive duration, e.g. 90s", name)
+		return def
+	}
+	return d
+}
+
+// Period returns a period parameter like 30d or 12h (see parsePeriod), def
+// if missing
+func (q *queryParams) Period(name string, def time.Duration) time.Duration {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	d, err := parsePeriod(v)
+	if err != nil {
+		q.errs.Add(name, "%s", err)
+		return def
+	}
+	return d
+}
+
+// Time returns an RFC3339 time parameter, def if missing
+func (q *queryParams) Time(name string, def time.Time) time.Time {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		q.errs.Add(name, "invalid %s time, expected RFC3339", name)
+		return def
+	}
+	return t
+}
+
+// Date returns a YYYY-MM-DD date parameter at midnight UTC, def if missing
+func (q *queryParams) Date(name string, def time.Time) time.Time {
+	v := q.values.Get(name)
+	if v == "" {
+		return def
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		q.errs.Add(name, "invalid %s, expected YYYY-MM-DD", name)
+		return def
+	}
+	return t
+}
+
+// TimeRange returns the RFC3339 parameters start and end. end defaults to
+// now, start to end minus span; start must be before end.
+func (q *queryParams) TimeRange(span time.Duration) (start, end time.Time) {
+	end = q.Time("end", time.Now().UTC())
+	start = q.Time("start", end.Add(-span))
+	if !q.errs.Has("start") && !q.errs.Has("end") && !start.Before(end) {
+		q.errs.Add("start", "start must be before end")
+	}
+	return start, end
+}
+
+// Invalid records an invalid parameter found by the handler itself
+func (q *queryParams) Invalid(name, format string, args ...interface{}) {
+	q.errs.Add(name, format, args...)
+}
+
+// Check adds the errors of a Validate method, e.g. of
+// models.ReadingQueryParams. Fields that are already invalid are not
+// reported twice.
+func (q *queryParams) Check(err error) {
+	var errs models.ValidationErrors
+	if errors.As(err, &errs) {
+		for _, f := range errs {
+			if !q.errs.Has(f.Field) {
+				q.errs = append(q.errs, f)
+			}
+		}
+	} else if err != nil {
+		q.errs.Add("", "%s", err)
+	}
+}
+
+// Err returns the invalid parameters, nil if all are valid
+func (q *queryParams) Err() error {
+	return q.errs.Err()
+}
+
+// validationError converts an error of a Validate method to a
+// validation_error, listing every invalid field of a
+// models.ValidationErrors in the details
+func validationError(err error) *APIError {
+	var errs models.ValidationErrors
+	if !errors.As(err, &errs) {
+		return &APIError{Code: ErrCodeValidation, Message: err.Error()}
+	}
+	return &APIError{
+		Code:    ErrCodeValidation,
+		Message: "Invalid parameters: " + errs.Error(),
+		Details: map[string]interface{}{"fields": errs},
+	}
+}
+
+// respondValidation writes the validationError of err as 400 response
+func respondValidation(w http.ResponseWriter, err error) {
+	apiErr := validationError(err)
+	respondErrorWithDetails(w, http.StatusBadRequest, apiErr.Code, apiErr.Message, apiErr.Details)
+}
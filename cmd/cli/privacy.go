@@ -0,0 +1,259 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// privacyView applies the privacy settings of the stations to public
+// responses. All methods accept a nil view, which leaves data unchanged.
+type privacyView struct {
+	policies map[uuid.UUID]models.PrivacyPolicy
+	sensors  map[uuid.UUID]models.Sensor
+}
+
+// publicView returns the privacy view for anonymous requests. Logged in
+// users, API keys with read:readings and servers without privacy settings
+// get a nil view.
+func (rm *RouteManager) publicView(r *http.Request) (*privacyView, error) {
+	if rm.requestHasScope(r, models.ScopeReadReadings) {
+		return nil, nil
+	}
+	return loadPrivacyView(rm.dbManager)
+}
+
+// loadPrivacyView reads the privacy settings of all stations
+func loadPrivacyView(dbManager *database.DatabaseManager) (*privacyView, error) {
+	stations, err := dbManager.LoadStations()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[uuid.UUID]models.PrivacyPolicy)
+	for _, station := range stations {
+		policy, err := models.ParsePrivacyPolicy(station.Config)
+		if err != nil {
+			log.Printf("⚠ Station %s: %v", station.ID, err)
+			continue
+		}
+		if !policy.IsZero() {
+			policies[station.ID] = policy
+		}
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	sensors, err := dbManager.GetSensors(models.SensorQueryParams{})
+	if err != nil {
+		return nil, err
+	}
+	view := &privacyView{policies: policies, sensors: make(map[uuid.UUID]models.Sensor, len(sensors))}
+	for _, s := range sensors {
+		view.sensors[s.Sensor.ID] = s.Sensor
+	}
+	return view, nil
+}
+
+// hides reports whether a sensor is suppressed
+func (v *privacyView) hides(sensorID uuid.UUID) bool {
+	if v == nil {
+		return false
+	}
+	sensor, ok := v.sensors[sensorID]
+	return ok && v.policies[sensor.StationID].Hides(sensor)
+}
+
+// round reduces the precision of a sensor value
+func (v *privacyView) round(sensorID uuid.UUID, value float64) float64 {
+	if v == nil {
+		return value
+	}
+	sensor, ok := v.sensors[sensorID]
+	if !ok {
+		return value
+	}
+	return v.policies[sensor.StationID].Round(sensor.SensorType, value)
+}
+
+// typeStep returns the coarsest step configured for a sensor type, used for
+// values grouped across stations
+func (v *privacyView) typeStep(sensorType string) float64 {
+	if v == nil {
+		return 0
+	}
+	var step float64
+	for _, policy := range v.policies {
+		step = max(step, policy.Step(sensorType))
+	}
+	return step
+}
+
+// filterSensors removes hidden sensors and rounds the latest readings
+func (v *privacyView) filterSensors(sensors []models.SensorWithLatestReading) []models.SensorWithLatestReading {
+	if v == nil {
+		return sensors
+	}
+	visible := make([]models.SensorWithLatestReading, 0, len(sensors))
+	for _, s := range sensors {
+		if v.hides(s.Sensor.ID) {
+			continue
+		}
+		v.roundLatest(&s)
+		visible = append(visible, s)
+	}
+	return visible
+}
+
+// roundLatest rounds the latest reading of a sensor
+func (v *privacyView) roundLatest(s *models.SensorWithLatestReading) {
+	if v == nil || s.LatestReading == nil {
+		return
+	}
+	reading := *s.LatestReading
+	reading.Value = v.round(s.Sensor.ID, reading.Value)
+	s.LatestReading = &reading
+}
+
+// roundSeries rounds averaged readings per sensor in place
+func (v *privacyView) roundSeries(series map[uuid.UUID][]models.SensorReading) {
+	if v == nil {
+		return
+	}
+	for id, readings := range series {
+		for i := range readings {
+			readings[i].Value = v.round(id, readings[i].Value)
+		}
+	}
+}
+
+// roundDailyStats rounds daily statistics per sensor in place
+func (v *privacyView) roundDailyStats(stats map[uuid.UUID][]models.DailyStat) {
+	if v == nil {
+		return
+	}
+	for id, days := range stats {
+		for i := range days {
+			days[i].Min = v.round(id, days[i].Min)
+			days[i].Max = v.round(id, days[i].Max)
+			days[i].Avg = v.round(id, days[i].Avg)
+		}
+	}
+}
+
+// pullStatus removes the error of the last pull of stations with privacy
+// settings, as provider errors may quote readings or account details
+func (v *privacyView) pullStatus(stationID uuid.UUID, status *models.PullStatus) *models.PullStatus {
+	if v == nil || status == nil || status.Error == "" {
+		return status
+	}
+	if _, ok := v.policies[stationID]; !ok {
+		return status
+	}
+	visible := *status
+	visible.Error = ""
+	return &visible
+}
+
+// restrictQuery limits a readings query to visible sensors
+func (v *privacyView) restrictQuery(params *models.ReadingQueryParams) {
+	if v == nil {
+		return
+	}
+	hidden := false
+	for id := range v.sensors {
+		if v.hides(id) {
+			hidden = true
+			break
+		}
+	}
+	if !hidden {
+		return
+	}
+
+	var visible []uuid.UUID
+	if len(params.SensorIDs) > 0 {
+		for _, id := range params.SensorIDs {
+			if !v.hides(id) {
+				visible = append(visible, id)
+			}
+		}
+	} else {
+		for id := range v.sensors {
+			if !v.hides(id) {
+				visible = append(visible, id)
+			}
+		}
+	}
+	if len(visible) == 0 {
+		// An empty list would match all sensors
+		visible = []uuid.UUID{uuid.Nil}
+	}
+	params.SensorIDs = visible
+}
+
+// applyReadings rounds the values of a readings result
+func (v *privacyView) applyReadings(result *models.ReadingsResponse, params models.ReadingQueryParams) {
+	if v == nil {
+		return
+	}
+	switch data := result.Data.(type) {
+	case []models.SensorReading:
+		for i := range data {
+			data[i].Value = v.round(data[i].SensorID, data[i].Value)
+		}
+	case []models.AggregatedReading:
+		for i := range data {
+			r := &data[i]
+			if r.SensorID != uuid.Nil {
+				r.Value = v.round(r.SensorID, r.Value)
+				r.MinValue = v.round(r.SensorID, r.MinValue)
+				r.MaxValue = v.round(r.SensorID, r.MaxValue)
+				continue
+			}
+			sensorType := r.SensorType
+			if sensorType == "" {
+				sensorType = params.SensorType
+			}
+			step := v.typeStep(sensorType)
+			r.Value = models.RoundToStep(r.Value, step)
+			r.MinValue = models.RoundToStep(r.MinValue, step)
+			r.MaxValue = models.RoundToStep(r.MaxValue, step)
+		}
+	}
+}
+
+// filterRecords removes records of hidden sensors and rounds the values
+func (v *privacyView) filterRecords(records []models.SensorRecord) []models.SensorRecord {
+	if v == nil {
+		return records
+	}
+	visible := make([]models.SensorRecord, 0, len(records))
+	for _, record := range records {
+		if v.hides(record.SensorID) {
+			continue
+		}
+		record.Value = v.round(record.SensorID, record.Value)
+		visible = append(visible, record)
+	}
+	return visible
+}
+
+// filterAnnotations removes annotations of hidden sensors
+func (v *privacyView) filterAnnotations(annotations []models.Annotation) []models.Annotation {
+	if v == nil {
+		return annotations
+	}
+	visible := make([]models.Annotation, 0, len(annotations))
+	for _, annotation := range annotations {
+		if annotation.SensorID != nil && v.hides(*annotation.SensorID) {
+			continue
+		}
+		visible = append(visible, annotation)
+	}
+	return visible
+}
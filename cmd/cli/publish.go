@@ -0,0 +1,448 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+//go:embed site/*.html site/*.css
+var siteFiles embed.FS
+
+// siteTemplates holds the parsed page templates of the static site
+var siteTemplates = template.Must(template.New("").Funcs(localeFuncs).ParseFS(siteFiles, "site/*.html"))
+
+// siteChartSkipped lists sensor types without a chart on the static site:
+// system values and counters that only restart periodically
+var siteChartSkipped = map[string]bool{
+	models.SensorTypeBattery:           true,
+	models.SensorTypeSignalStrength:    true,
+	models.SensorTypeWindSpeedMaxDaily: true,
+	models.SensorTypeWindGustMaxDaily:  true,
+	models.SensorTypeRainfallEvent:     true,
+	models.SensorTypeRainfallHourly:    true,
+	models.SensorTypeRainfallWeekly:    true,
+	models.SensorTypeRainfallMonthly:   true,
+	models.SensorTypeRainfallYearly:    true,
+	models.SensorTypeRainfallTotal:     true,
+}
+
+// siteGenerator renders a static HTML site of all stations that can be
+// hosted on any web server
+type siteGenerator struct {
+	dbManager  *database.DatabaseManager
+	outDir     string
+	siteName   string
+	noaaMonths int
+	locale     i18n.Locale
+}
+
+// sitePage holds the values shared by all page templates
+type sitePage struct {
+	Title     string
+	SiteName  string
+	Root      string
+	Generated time.Time
+	Locale    i18n.Locale
+}
+
+// siteStation is a station as listed on the index page
+type siteStation struct {
+	ID         uuid.UUID
+	Name       string
+	LastUpdate *time.Time
+}
+
+// siteCurrent is a row of the current conditions table
+type siteCurrent struct {
+	Name  string
+	Value string
+	Unit  string
+	Time  time.Time
+}
+
+// siteChart references the chart images of a sensor type
+type siteChart struct {
+	Title string
+	Day   string
+	Week  string
+}
+
+// siteReport references a NOAA report file
+type siteReport struct {
+	File  string
+	Label string
+}
+
+// Generate renders the whole site into the output directory
+func (g *siteGenerator) Generate(ctx context.Context) error {
+	stations, err := g.dbManager.LoadStations()
+	if err != nil {
+		return fmt.Errorf("failed to fetch stations: %w", err)
+	}
+	sort.Slice(stations, func(i, j int) bool { return stationDisplayName(&stations[i]) < stationDisplayName(&stations[j]) })
+
+	now := time.Now()
+	var listed []siteStation
+	for i := range stations {
+		station := &stations[i]
+		if err := g.generateStation(ctx, station, now); err != nil {
+			return fmt.Errorf("station %s: %w", station.ID, err)
+		}
+		listed = append(listed, siteStation{ID: station.ID, Name: stationDisplayName(station), LastUpdate: station.LastUpdate})
+	}
+
+	css, err := siteFiles.ReadFile("site/style.css")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(g.outDir, "style.css"), css); err != nil {
+		return err
+	}
+
+	return g.renderPage(filepath.Join(g.outDir, "index.html"), "index.html", struct {
+		sitePage
+		Stations []siteStation
+	}{
+		sitePage: sitePage{Title: g.siteName, SiteName: g.siteName, Root: "", Generated: now, Locale: g.locale},
+		Stations: listed,
+	})
+}
+
+// generateStation renders the page, charts and NOAA reports of a station
+func (g *siteGenerator) generateStation(ctx context.Context, station *models.StationData, now time.Time) error {
+	dir := filepath.Join(g.outDir, station.ID.String())
+	loc := stationLocation(station)
+
+	sensors, err := g.dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID, IncludeLatest: true})
+	if err != nil {
+		return fmt.Errorf("failed to fetch sensors: %w", err)
+	}
+
+	// The site is public, so the privacy settings of the station apply
+	policy, err := models.ParsePrivacyPolicy(station.Config)
+	if err != nil {
+		return err
+	}
+
+	var current []siteCurrent
+	byType := make(map[string][]uuid.UUID)
+	var types []string
+	for _, s := range sensors {
+		if !s.Sensor.Enabled || policy.Hides(s.Sensor) {
+			continue
+		}
+		info := models.SensorTypeRegistry[s.Sensor.SensorType]
+		if s.LatestReading != nil && info.Category != models.SensorCategorySystem {
+			value, unit := s.Sensor.Display.Format(policy.Round(s.Sensor.SensorType, s.LatestReading.Value), info.Unit, 1)
+			current = append(current, siteCurrent{
+				Name:  sensorDisplayName(g.locale, s.Sensor),
+				Value: value,
+				Unit:  unit,
+				Time:  s.LatestReading.DateUTC.In(loc),
+			})
+		}
+		if !siteChartSkipped[s.Sensor.SensorType] {
+			if _, ok := byType[s.Sensor.SensorType]; !ok {
+				types = append(types, s.Sensor.SensorType)
+			}
+			byType[s.Sensor.SensorType] = append(byType[s.Sensor.SensorType], s.Sensor.ID)
+		}
+	}
+
+	var charts []siteChart
+	for _, sensorType := range types {
+		c := siteChart{
+			Title: i18n.SensorTypeName(g.locale, sensorType),
+			Day:   sensorType + "-day.svg",
+			Week:  sensorType + "-week.svg",
+		}
+		dayTitle := c.Title + " - " + i18n.T(g.locale, "site.last_24_hours")
+		if err := g.renderChart(filepath.Join(dir, "charts", c.Day), byType[sensorType], policy.Step(sensorType), dayTitle, now.Add(-24*time.Hour), now, loc); err != nil {
+			return err
+		}
+		weekTitle := c.Title + " - " + i18n.T(g.locale, "site.last_7_days")
+		if err := g.renderChart(filepath.Join(dir, "charts", c.Week), byType[sensorType], policy.Step(sensorType), weekTitle, now.Add(-7*24*time.Hour), now, loc); err != nil {
+			return err
+		}
+		charts = append(charts, c)
+	}
+
+	reports, err := g.generateNOAAReports(ctx, station, sensors, filepath.Join(dir, "NOAA"), now.In(loc))
+	if err != nil {
+		return err
+	}
+
+	name := stationDisplayName(station)
+	return g.renderPage(filepath.Join(dir, "index.html"), "station.html", struct {
+		sitePage
+		Station siteStation
+		Current []siteCurrent
+		Charts  []siteChart
+		Reports []siteReport
+	}{
+		sitePage: sitePage{Title: name + " - " + g.siteName, SiteName: g.siteName, Root: "../", Generated: now, Locale: g.locale},
+		Station:  siteStation{ID: station.ID, Name: name, LastUpdate: station.LastUpdate},
+		Current:  current,
+		Charts:   charts,
+		Reports:  reports,
+	})
+}
+
+// renderChart writes an SVG chart of the given sensors with values rounded
+// to step
+func (g *siteGenerator) renderChart(path string, sensorIDs []uuid.UUID, step float64, title string, start, end time.Time, loc *time.Location) error {
+	params := models.ReadingQueryParams{
+		SensorIDs:     sensorIDs,
+		StartTime:     start.UTC().Format(time.RFC3339),
+		EndTime:       end.UTC().Format(time.RFC3339),
+		Aggregate:     chartInterval(end.Sub(start), 800),
+		AggregateFunc: "avg",
+		GroupBy:       "sensor",
+		Order:         "asc",
+		Limit:         10000,
+		Page:          1,
+	}
+	result, err := g.dbManager.GetAggregatedReadings(params)
+	if err != nil {
+		return fmt.Errorf("failed to query readings: %w", err)
+	}
+
+	readings := result.Data.([]models.AggregatedReading)
+	for i := range readings {
+		readings[i].Value = models.RoundToStep(readings[i].Value, step)
+	}
+
+	c, err := buildChart(g.dbManager, readings)
+	if err != nil {
+		return fmt.Errorf("failed to query sensors: %w", err)
+	}
+	c.Title = title
+	c.Location = loc
+
+	var buf bytes.Buffer
+	if err := c.RenderSVG(&buf); err != nil {
+		return fmt.Errorf("failed to render chart: %w", err)
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// generateNOAAReports writes the monthly NOAA reports of the last months.
+// Reports of completed months are only written once.
+func (g *siteGenerator) generateNOAAReports(ctx context.Context, station *models.StationData, sensors []models.SensorWithLatestReading, dir string, now time.Time) ([]siteReport, error) {
+	sources := selectNOAASensors(preferredRainSensors(station, sensors))
+	if sources.temp == nil {
+		return nil, nil
+	}
+
+	var reports []siteReport
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	for i := 0; i < g.noaaMonths; i++ {
+		month := thisMonth.AddDate(0, -i, 0)
+		file := fmt.Sprintf("NOAA-%s.txt", month.Format("2006-01"))
+		path := filepath.Join(dir, file)
+
+		if _, err := os.Stat(path); i > 0 && err == nil {
+			reports = append(reports, siteReport{File: file, Label: g.monthLabel(month)})
+			continue
+		}
+
+		report, err := g.noaaMonth(ctx, station, sources, month)
+		if err != nil {
+			return nil, err
+		}
+		if report == nil {
+			continue
+		}
+		if err := writeFileAtomic(path, []byte(report.Format())); err != nil {
+			return nil, err
+		}
+		reports = append(reports, siteReport{File: file, Label: g.monthLabel(month)})
+	}
+	return reports, nil
+}
+
+// monthLabel names the month of a NOAA report, e.g. "March 2026". The
+// reports themselves keep the English NOAA format tools expect.
+func (g *siteGenerator) monthLabel(month time.Time) string {
+	return fmt.Sprintf("%s %d", i18n.MonthName(g.locale, month.Month()), month.Year())
+}
+
+// noaaSensors are the sensors a NOAA report is built from
+type noaaSensors struct {
+	temp, wind, gust, dir, rain *models.Sensor
+	// snow holds manually observed snowfall
+	snow *models.Sensor
+}
+
+// selectNOAASensors picks the outdoor sensors of a station used for the report
+func selectNOAASensors(sensors []models.SensorWithLatestReading) noaaSensors {
+	find := func(sensorType, location string) *models.Sensor {
+		for i := range sensors {
+			s := &sensors[i].Sensor
+			if s.SensorType == sensorType && (location == "" || s.Location == location) {
+				return s
+			}
+		}
+		return nil
+	}
+
+	var result noaaSensors
+	if result.temp = find(models.SensorTypeTemperature, "Outdoor"); result.temp == nil {
+		result.temp = find(models.SensorTypeTemperatureOutdoor, "")
+	}
+	result.wind = find(models.SensorTypeWindSpeed, "")
+	if result.gust = find(models.SensorTypeWindGust, ""); result.gust == nil {
+		result.gust = result.wind
+	}
+	result.dir = find(models.SensorTypeWindDirection, "")
+	result.rain = find(models.SensorTypeRainfallDaily, "")
+	result.snow = find(models.SensorTypeSnowfall, "")
+	return result
+}
+
+// noaaMonth builds the NOAA report of a month or returns nil without data
+func (g *siteGenerator) noaaMonth(ctx context.Context, station *models.StationData, sources noaaSensors, month time.Time) (*analysis.NOAAMonth, error) {
+	var sensorIDs []uuid.UUID
+	for _, s := range []*models.Sensor{sources.temp, sources.wind, sources.gust, sources.dir, sources.rain, sources.snow} {
+		if s != nil {
+			sensorIDs = append(sensorIDs, s.ID)
+		}
+	}
+
+	next := month.AddDate(0, 1, 0)
+	stats, err := g.dbManager.GetDailyStats(ctx, sensorIDs, month, next, month.Location())
+	if err != nil {
+		return nil, err
+	}
+	if len(stats[sources.temp.ID]) == 0 {
+		return nil, nil
+	}
+
+	byDay := func(s *models.Sensor) map[int]models.DailyStat {
+		days := make(map[int]models.DailyStat)
+		if s != nil {
+			for _, stat := range stats[s.ID] {
+				days[stat.Day.Day()] = stat
+			}
+		}
+		return days
+	}
+	temp, wind, gust, dir, rain := byDay(sources.temp), byDay(sources.wind), byDay(sources.gust), byDay(sources.dir), byDay(sources.rain)
+	snow := byDay(sources.snow)
+
+	unit := func(s *models.Sensor) string {
+		if s == nil {
+			return ""
+		}
+		return models.SensorTypeRegistry[s.SensorType].Unit
+	}
+	report := &analysis.NOAAMonth{
+		Station:  stationDisplayName(station),
+		Year:     month.Year(),
+		Month:    month.Month(),
+		TempUnit: unit(sources.temp),
+		RainUnit: unit(sources.rain),
+		WindUnit: unit(sources.wind),
+		SnowUnit: unit(sources.snow),
+		HeatBase: analysis.DefaultHeatBase,
+		CoolBase: analysis.DefaultCoolBase,
+	}
+
+	for day := 1; day <= next.AddDate(0, 0, -1).Day(); day++ {
+		d := analysis.NOAADay{Day: day}
+		if s, ok := temp[day]; ok {
+			d.MeanTemp, d.HighTemp, d.LowTemp = &s.Avg, &s.Max, &s.Min
+			d.HighTime, d.LowTime = s.MaxTime.In(month.Location()), s.MinTime.In(month.Location())
+		}
+		if s, ok := wind[day]; ok {
+			d.MeanWind = &s.Avg
+		}
+		if s, ok := gust[day]; ok {
+			d.HighWind, d.HighWindTime = &s.Max, s.MaxTime.In(month.Location())
+		}
+		if s, ok := dir[day]; ok {
+			d.DomDir = &s.CircularMean
+		}
+		// The daily rain counter peaks at the day's total
+		if s, ok := rain[day]; ok {
+			d.Rain = &s.Max
+		}
+		// Each snowfall observation measures new snow since the last one
+		if s, ok := snow[day]; ok {
+			d.Snow = &s.Sum
+		}
+		report.Days = append(report.Days, d)
+	}
+	return report, nil
+}
+
+// renderPage executes a page template into a file
+func (g *siteGenerator) renderPage(path, name string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := siteTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// writeFileAtomic writes a file via a temporary file so a web server never
+// serves a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// stationLocation returns the time zone of a station from its "timezone"
+// config, falling back to the server's local time zone
+func stationLocation(station *models.StationData) *time.Location {
+	if tz, _ := station.Config["timezone"].(string); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+		log.Printf("⚠ Invalid timezone %q for station %s", tz, station.ID)
+	}
+	return time.Local
+}
+
+// stationDisplayName returns a human readable name of a station
+func stationDisplayName(station *models.StationData) string {
+	if name, _ := station.Config["name"].(string); name != "" {
+		return name
+	}
+	if station.Model != "" {
+		return station.Model
+	}
+	return station.ID.String()
+}
+
+// formatSiteValue rounds a reading for display
+func formatSiteValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
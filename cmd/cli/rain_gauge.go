@@ -0,0 +1,19 @@
+package main
+
+import (
+	"log"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// preferredRainSensors drops the rain sensors of the gauge a station with
+// both a tipping bucket and a piezo gauge doesn't prefer. An invalid
+// rain_gauge config is logged and the default gauge is used.
+func preferredRainSensors(station *models.StationData, sensors []models.SensorWithLatestReading) []models.SensorWithLatestReading {
+	gauge, err := models.ParseRainGauge(station.Config)
+	if err != nil {
+		log.Printf("⚠ Station %s: %v", station.ID, err)
+		gauge = models.RainGaugeTippingBucket
+	}
+	return models.PreferRainGauge(sensors, gauge)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// rawPayloadPruneInterval limits how often expired payloads are deleted
+const rawPayloadPruneInterval = time.Hour
+
+// rawPayloadStore keeps the requests of pushes for RAW_PAYLOAD_RETENTION, so
+// their readings can be parsed again with "station reparse" after a pusher
+// fix. Expired payloads are deleted while new ones are stored.
+type rawPayloadStore struct {
+	db        *database.DatabaseManager
+	retention time.Duration
+
+	mu       sync.Mutex
+	prunedAt time.Time
+}
+
+// newRawPayloadStore returns nil if RAW_PAYLOAD_RETENTION is 0
+func newRawPayloadStore(dbManager *database.DatabaseManager) *rawPayloadStore {
+	retention := getEnvDuration("RAW_PAYLOAD_RETENTION", 30*24*time.Hour)
+	if retention <= 0 {
+		return nil
+	}
+	return &rawPayloadStore{db: dbManager, retention: retention}
+}
+
+// Store stores a payload. Failures are logged only, as the readings of the
+// push are stored already.
+func (s *rawPayloadStore) Store(ctx context.Context, payload *models.RawPayload) {
+	if err := s.db.StoreRawPayload(ctx, payload); err != nil {
+		log.Printf("⚠ Failed to keep raw payload of station %s: %v", payload.StationID, err)
+	}
+
+	s.mu.Lock()
+	due := time.Since(s.prunedAt) >= rawPayloadPruneInterval
+	if due {
+		s.prunedAt = time.Now()
+	}
+	s.mu.Unlock()
+	if due {
+		go s.prune()
+	}
+}
+
+// prune deletes the payloads older than the retention
+func (s *rawPayloadStore) prune() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deleted, err := s.db.DeleteRawPayloadsBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		log.Printf("❌ Failed to delete expired raw payloads: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("✓ Deleted %d expired raw payloads", deleted)
+	}
+}
+
+// newRawPayload captures the query and body of a push. API keys, nonces and
+// station passwords are removed from the query and from form bodies.
+func newRawPayload(r *http.Request, body []byte, p pusher.Pusher, receivedAt time.Time, sourceIP string) *models.RawPayload {
+	password := pusher.PasswordParamOf(p)
+	payload := &models.RawPayload{
+		Source:      p.GetStationType(),
+		ContentType: r.Header.Get("Content-Type"),
+		Query:       withoutCredentials(r.URL.Query(), password).Encode(),
+		Body:        body,
+		SourceIP:    sourceIP,
+		ReceivedAt:  receivedAt,
+	}
+	if mediaType, _, _ := mime.ParseMediaType(payload.ContentType); mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			payload.Body = []byte(withoutCredentials(form, password).Encode())
+		}
+	}
+	return payload
+}
+
+// withoutCredentials removes the push parameters that must not be stored and
+// the password parameter of the pusher, if any
+func withoutCredentials(params url.Values, password string) url.Values {
+	params.Del("api_key")
+	params.Del("nonce")
+	params.Del(pushTimestampParam)
+	if password != "" {
+		params.Del(password)
+	}
+	return params
+}
+
+// rawPayloadRequest rebuilds the request of a stored payload
+func rawPayloadRequest(payload models.RawPayload) (*http.Request, error) {
+	method := http.MethodGet
+	if len(payload.Body) > 0 {
+		method = http.MethodPost
+	}
+	r, err := http.NewRequest(method, "/?"+payload.Query, bytes.NewReader(payload.Body))
+	if err != nil {
+		return nil, err
+	}
+	if payload.ContentType != "" {
+		r.Header.Set("Content-Type", payload.ContentType)
+	}
+	return r, nil
+}
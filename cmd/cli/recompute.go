@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Artifacts derived from raw readings that can be recomputed
+const (
+	artifactDailyRollups = "daily_rollups"
+	artifactRecords      = "records"
+)
+
+// recomputeArtifacts lists all artifacts in the order they are rebuilt
+var recomputeArtifacts = []string{artifactDailyRollups, artifactRecords}
+
+// recomputeScope selects the sensors, time range and artifacts to rebuild
+type recomputeScope struct {
+	StationID *uuid.UUID  `json:"station_id,omitempty"`
+	SensorIDs []uuid.UUID `json:"sensor_ids,omitempty"`
+	Start     time.Time   `json:"start"`
+	End       time.Time   `json:"end"`
+	Artifacts []string    `json:"artifacts"`
+}
+
+// recomputeParams are the params of a recompute job: the requested scope and
+// the sensors it resolved to when the job was queued
+type recomputeParams struct {
+	recomputeScope
+	Sensors []uuid.UUID `json:"sensors"`
+}
+
+// recomputeJob is the response of the recompute endpoints, which predate
+// the generic job API and keep their own format: the scope and sensor count
+// instead of the raw params
+type recomputeJob struct {
+	ID         uuid.UUID      `json:"id"`
+	Status     string         `json:"status"`
+	Scope      recomputeScope `json:"scope"`
+	Sensors    int            `json:"sensors"`
+	Done       int            `json:"done"`
+	Total      int            `json:"total"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// newRecomputeJob converts a job of type recompute. The total is known
+// from the params before the job runs.
+func newRecomputeJob(job *models.Job) recomputeJob {
+	var params recomputeParams
+	_ = json.Unmarshal(job.Params, &params)
+	result := recomputeJob{
+		ID:         job.ID,
+		Status:     job.Status,
+		Scope:      params.recomputeScope,
+		Sensors:    len(params.Sensors),
+		Done:       job.Done,
+		Total:      job.Total,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if result.Total == 0 {
+		result.Total = len(params.Sensors) * len(params.Artifacts)
+	}
+	return result
+}
+
+// recomputeMu serialises recompute jobs so overlapping scopes do not race
+var recomputeMu sync.Mutex
+
+// recomputeJobHandler rebuilds the artifacts sensor by sensor so progress can
+// be reported and the job can be cancelled between sensors
+func recomputeJobHandler(dbManager *database.DatabaseManager) jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		var params recomputeParams
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return fmt.Errorf("invalid recompute params: %w", err)
+		}
+
+		recomputeMu.Lock()
+		defer recomputeMu.Unlock()
+
+		progress.SetTotal(len(params.Sensors) * len(params.Artifacts))
+		for _, artifact := range params.Artifacts {
+			for _, sensorID := range params.Sensors {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := recompute(ctx, dbManager, artifact, sensorID, params.recomputeScope); err != nil {
+					return fmt.Errorf("%s of sensor %s: %w", artifact, sensorID, err)
+				}
+				progress.Add(1)
+			}
+		}
+		return nil
+	}
+}
+
+// recompute rebuilds a single artifact of a single sensor
+func recompute(ctx context.Context, dbManager *database.DatabaseManager, artifact string, sensorID uuid.UUID, scope recomputeScope) error {
+	sensorIDs := []uuid.UUID{sensorID}
+	switch artifact {
+	case artifactDailyRollups:
+		// The rollups of archived months can't be rebuilt without their readings
+		archived, err := archivedMonths(ctx, dbManager)
+		if err != nil {
+			return err
+		}
+		for _, r := range models.UnarchivedRanges(scope.Start, scope.End, archived) {
+			if err := dbManager.RecomputeDailyRollups(ctx, sensorIDs, r[0], r[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case artifactRecords:
+		// Records are all-time extremes and always rebuilt from all readings
+		return dbManager.RecomputeSensorRecords(ctx, sensorIDs)
+	}
+	return fmt.Errorf("unknown artifact: %s", artifact)
+}
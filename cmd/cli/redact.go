@@ -0,0 +1,19 @@
+package main
+
+import (
+	"io"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// redactingWriter masks credentials in log output before writing it
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (rw redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, models.RedactString(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
 	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/notify"
 	"github.com/sguter90/weathermaestro/pkg/puller"
 	"github.com/sguter90/weathermaestro/pkg/pusher"
 )
@@ -14,6 +17,15 @@ type RegistryManager struct {
 	PusherRegistry *pusher.Registry
 	PullerRegistry *puller.PullerRegistry
 	PullerService  *puller.PullerService
+	IngestPipeline *ingest.Pipeline
+	IngestQueue    *ingest.Queue
+	HighFrequency  *ingest.HighFrequencyBuffer
+	Federation     *federation
+	JobRunner      *jobs.Runner
+	Features       features
+	Notifier       *notify.Dispatcher
+	AlertTemplates map[string]notify.Template
+	AlertPublisher *alertPublisher
 }
 
 func InitRegistryManager(dbManager *database.DatabaseManager, stations []models.StationData) *RegistryManager {
@@ -31,8 +43,28 @@ func InitRegistryManager(dbManager *database.DatabaseManager, stations []models.
 		}
 	}
 
+	features := loadFeatures()
+
+	// Notify of alerts and publish their states to Home Assistant
+	notifier := newNotifier()
+	alertTemplates := alertChannelTemplates(notifier)
+	alertPublisher := newAlertPublisher(dbManager)
+	alertListeners := []ingest.AlertListener{newAlertNotifier(dbManager, notifier, alertTemplates)}
+	if alertPublisher != nil {
+		alertListeners = append(alertListeners, alertPublisher.Listener())
+	}
+
+	// Initialize ingest pipeline shared by pushers and pullers
+	highFrequency := newHighFrequencyBuffer()
+	federation := newFederation(dbManager)
+	var federationHook *ingest.FederationHook
+	if federation != nil {
+		federationHook = federation.hook
+	}
+	ingestPipeline := newIngestPipeline(dbManager, features, highFrequency, federationHook, alertListeners...)
+
 	// Initialize puller service
-	pullerService := puller.NewPullerService(dbManager, pullerRegistry, 1*time.Minute)
+	pullerService := puller.NewPullerService(dbManager, pullerRegistry, ingestPipeline, 1*time.Minute)
 
 	// Add stations to puller service
 	for _, station := range stations {
@@ -41,9 +73,20 @@ func InitRegistryManager(dbManager *database.DatabaseManager, stations []models.
 		}
 	}
 
+	// Initialize background job runner
+	jobRunner := newJobRunner(dbManager)
+
 	return &RegistryManager{
 		PusherRegistry: pusherRegistry,
 		PullerRegistry: pullerRegistry,
 		PullerService:  pullerService,
+		IngestPipeline: ingestPipeline,
+		HighFrequency:  highFrequency,
+		Federation:     federation,
+		JobRunner:      jobRunner,
+		Features:       features,
+		Notifier:       notifier,
+		AlertTemplates: alertTemplates,
+		AlertPublisher: alertPublisher,
 	}
 }
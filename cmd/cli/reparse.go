@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// reparsePageSize is the number of payloads parsed and corrected at once
+const reparsePageSize = 500
+
+// reparseResult counts the outcome of a re-parse
+type reparseResult struct {
+	Payloads int
+	Failed   int
+	Changed  int
+	Added    int
+}
+
+// reparser parses the stored raw payloads of a station again with the
+// current pusher and corrects the stored readings
+type reparser struct {
+	db       *database.DatabaseManager
+	station  models.StationData
+	pusher   pusher.Pusher
+	pipeline *ingest.Pipeline
+	dryRun   bool
+
+	// sensorIDs maps the remote IDs to the existing sensors; readings of
+	// sensors that don't exist are skipped
+	sensorIDs map[string]uuid.UUID
+	// highFrequency are the sensors whose readings are stored as minute
+	// aggregates and can't be corrected from single payloads
+	highFrequency models.HighFrequencySensors
+}
+
+// newReparser prepares the re-parse of a station. Readings run through the
+// hooks that adjust them before they are stored: custom sensor types, clock
+// skew correction and lux conversion. Forwarding and alerting hooks don't
+// run, the measured clock skew of the station is not updated.
+func newReparser(ctx context.Context, dbManager *database.DatabaseManager, stationID uuid.UUID, dryRun bool) (*reparser, error) {
+	station, err := dbManager.LoadStation(stationID)
+	if err != nil {
+		return nil, err
+	}
+	if station.Mode != "push" {
+		return nil, fmt.Errorf("station %s is not a push station", stationID)
+	}
+	registry := pusher.NewRegistry()
+	registerPusher(registry, station.ServiceName)
+	if len(registry.All()) == 0 {
+		return nil, fmt.Errorf("unknown push service %q", station.ServiceName)
+	}
+
+	sensorIDs, err := dbManager.GetSensorIDsByRemoteID(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+	highFrequency, err := models.ParseHighFrequencySensors(station.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := ingest.NewPipeline(nil)
+	pipeline.Register(ingest.NewCustomSensorsHook(dbManager))
+	pipeline.Register(ingest.NewClockSkewHook(nil, getEnvDuration("INGEST_CLOCK_SKEW_THRESHOLD", 5*time.Minute)))
+	pipeline.Register(ingest.NewLuxConversionHook())
+	applyDisabledHooks(pipeline)
+
+	return &reparser{
+		db:            dbManager,
+		station:       station,
+		pusher:        registry.All()[0],
+		pipeline:      pipeline,
+		dryRun:        dryRun,
+		sensorIDs:     sensorIDs,
+		highFrequency: highFrequency,
+	}, nil
+}
+
+// Run re-parses the payloads received within a time range page by page
+func (rp *reparser) Run(ctx context.Context, start, end time.Time) (reparseResult, error) {
+	var result reparseResult
+	var afterID int64
+	for {
+		payloads, err := rp.db.GetRawPayloads(ctx, rp.station.ID, start, end, afterID, reparsePageSize)
+		if err != nil {
+			return result, err
+		}
+		if len(payloads) == 0 {
+			return result, nil
+		}
+		afterID = payloads[len(payloads)-1].ID
+
+		var readings []models.SensorReading
+		for _, payload := range payloads {
+			result.Payloads++
+			parsed, err := rp.parse(ctx, payload)
+			if err != nil {
+				log.Printf("⚠ Payload %d received at %s: %v", payload.ID, payload.ReceivedAt.Format(time.RFC3339), err)
+				result.Failed++
+				continue
+			}
+			readings = append(readings, parsed...)
+		}
+
+		correction, err := rp.correction(ctx, readings)
+		if err != nil {
+			return result, err
+		}
+		result.Changed += correction.Changed
+		result.Added += correction.Added
+		if !rp.dryRun {
+			if err := rp.db.CorrectSensorReadings(ctx, correction); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+// parse returns the readings of a payload as they are stored
+func (rp *reparser) parse(ctx context.Context, payload models.RawPayload) ([]models.SensorReading, error) {
+	if payload.Source != rp.pusher.GetStationType() {
+		return nil, fmt.Errorf("pushed to %s instead of %s", payload.Source, rp.pusher.GetStationType())
+	}
+	r, err := rawPayloadRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsePushForm(r, rp.pusher); err != nil {
+		return nil, err
+	}
+
+	sensors := make(map[string]models.Sensor)
+	for remoteID, sensor := range rp.pusher.ParseSensors(r.Form) {
+		id, ok := rp.sensorIDs[remoteID]
+		if !ok || rp.highFrequency.Contains(sensor) {
+			continue
+		}
+		sensor.ID = id
+		sensors[remoteID] = sensor
+	}
+	if len(sensors) == 0 {
+		return nil, nil
+	}
+
+	var readings map[uuid.UUID]models.SensorReading
+	if op, ok := rp.pusher.(pusher.OptionsParser); ok {
+		readings, err = op.ParseWeatherDataWithOptions(r.Form, sensors, stationParseOptions(&rp.station, payload.ReceivedAt))
+	} else {
+		readings, err = rp.pusher.ParseWeatherData(r.Form, sensors)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ingest.Batch{
+		StationID:  rp.station.ID,
+		Station:    &rp.station,
+		Sensors:    sensors,
+		Readings:   make([]models.SensorReading, 0, len(readings)),
+		Source:     "push",
+		ReceivedAt: payload.ReceivedAt,
+		SourceIP:   payload.SourceIP,
+	}
+	for _, reading := range readings {
+		batch.Readings = append(batch.Readings, reading)
+	}
+	if err := rp.pipeline.Process(ctx, batch); err != nil {
+		return nil, err
+	}
+	return batch.Readings, nil
+}
+
+// correction compares re-parsed readings with the stored readings of their
+// sensors and time range
+func (rp *reparser) correction(ctx context.Context, readings []models.SensorReading) (models.ReadingCorrection, error) {
+	if len(readings) == 0 {
+		return models.ReadingCorrection{}, nil
+	}
+
+	start, end := readings[0].DateUTC, readings[0].DateUTC
+	seen := make(map[uuid.UUID]bool)
+	var sensorIDs []uuid.UUID
+	for _, r := range readings {
+		if r.DateUTC.Before(start) {
+			start = r.DateUTC
+		}
+		if r.DateUTC.After(end) {
+			end = r.DateUTC
+		}
+		if !seen[r.SensorID] {
+			seen[r.SensorID] = true
+			sensorIDs = append(sensorIDs, r.SensorID)
+		}
+	}
+
+	stored, err := rp.db.GetReadingsOfSensors(ctx, sensorIDs, start, end)
+	if err != nil {
+		return models.ReadingCorrection{}, err
+	}
+	return models.CorrectReadings(stored, readings), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+)
+
+// Error codes returned in the "error.code" field of API responses.
+// Clients should branch on these codes instead of the message text.
+const (
+	ErrCodeBadRequest     = "bad_request"
+	ErrCodeInvalidID      = "invalid_id"
+	ErrCodeInvalidBody    = "invalid_body"
+	ErrCodeValidation     = "validation_failed"
+	ErrCodeUnauthorized   = "unauthorized"
+	ErrCodeOTPRequired    = "otp_required"
+	ErrCodeForbidden      = "forbidden"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeConflict       = "conflict"
+	ErrCodeRateLimited    = "rate_limited"
+	ErrCodeDatabase       = "database_error"
+	ErrCodeInternal       = "internal_error"
+	ErrCodeUnavailable    = "service_unavailable"
+	ErrCodeInvalidPayload = "invalid_payload"
+)
+
+// APIResponse is the envelope for all JSON API responses
+type APIResponse struct {
+	Data  interface{} `json:"data"`
+	Meta  interface{} `json:"meta,omitempty"`
+	Error *APIError   `json:"error,omitempty"`
+}
+
+// APIError describes a failed request
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// respondJSON writes data wrapped in the response envelope
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	respondJSONWithMeta(w, status, data, nil)
+}
+
+// respondJSONWithMeta writes data and meta wrapped in the response envelope
+func respondJSONWithMeta(w http.ResponseWriter, status int, data interface{}, meta interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(APIResponse{Data: data, Meta: meta}); err != nil {
+		log.Printf("❌ Failed to encode response: %v", err)
+	}
+}
+
+// respondError writes an error wrapped in the response envelope
+func respondError(w http.ResponseWriter, status int, code string, message string) {
+	respondErrorWithDetails(w, status, code, message, nil)
+}
+
+// respondErrorWithDetails writes an error with additional details
+func respondErrorWithDetails(w http.ResponseWriter, status int, code string, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIResponse{
+		Error: &APIError{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
+
+// respondDBError maps a database error to a not_found or database_error response
+func respondDBError(w http.ResponseWriter, err error, notFoundMessage string) {
+	if errors.Is(err, database.ErrNotFound) {
+		respondError(w, http.StatusNotFound, ErrCodeNotFound, notFoundMessage)
+		return
+	}
+	respondError(w, http.StatusInternalServerError, ErrCodeDatabase, "Database error")
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
 // RouteManager handles all API routes
@@ -13,14 +15,74 @@ type RouteManager struct {
 	dbManager       *database.DatabaseManager
 	registryManager *RegistryManager
 	Router          *mux.Router
+
+	// ingestKeyRequired rejects pushes without a write:ingest API key
+	ingestKeyRequired bool
+
+	// idempotencyTTL is how long responses to pushes with an
+	// Idempotency-Key are replayed
+	idempotencyTTL time.Duration
+
+	// maxPushAge rejects pushes with readings older than this unless the
+	// station has backfill enabled (0 = any age)
+	maxPushAge time.Duration
+
+	// nonceMaxAge is how far the signed timestamp of a push with nonce may
+	// be off the server time
+	nonceMaxAge time.Duration
+
+	// latencyThreshold is the 95th percentile of the ingest latency above
+	// which a station is reported as degraded
+	latencyThreshold time.Duration
+
+	// trustedProxies are the reverse proxies whose X-Forwarded-For header
+	// is used as client address
+	trustedProxies models.IPAllowlist
+
+	// registrationPolicy decides whether pushes of unknown passkeys create
+	// a station, wait for approval or are refused
+	registrationPolicy string
+
+	// pendingLimit is the most stations listed for approval, so pushes of
+	// made-up passkeys can't fill the pending list; 0 for no limit
+	pendingLimit int
+
+	// rawPayloads keeps the requests of pushes for re-parsing, nil if
+	// disabled
+	rawPayloads *rawPayloadStore
+
+	// updates caches the latest release, nil if UPDATE_CHECK is disabled
+	updates *updateChecker
 }
 
 // NewRouteManager creates a new RouteManager instance
 func NewRouteManager(dbManager *database.DatabaseManager, registryManager *RegistryManager) *RouteManager {
+	trustedProxies, err := models.ParseIPAllowlist(getEnv("SERVER_TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Printf("⚠ Ignoring SERVER_TRUSTED_PROXIES: %v", err)
+	}
+	registrationPolicy, err := models.ParseRegistrationPolicy(getEnv("STATION_REGISTRATION", ""))
+	if err != nil {
+		log.Printf("❌ %v, unknown stations need approval", err)
+		registrationPolicy = models.RegistrationManual
+	}
+
 	return &RouteManager{
 		dbManager:       dbManager,
 		registryManager: registryManager,
 		Router:          mux.NewRouter(),
+
+		ingestKeyRequired: getEnvBool("INGEST_REQUIRE_API_KEY", false),
+		idempotencyTTL:    getEnvDuration("INGEST_IDEMPOTENCY_TTL", 24*time.Hour),
+		maxPushAge:        getEnvDuration("INGEST_MAX_PUSH_AGE", 0),
+		nonceMaxAge:       getEnvDuration("INGEST_NONCE_MAX_AGE", 5*time.Minute),
+		latencyThreshold:  getEnvDuration("INGEST_LATENCY_THRESHOLD", 5*time.Minute),
+		trustedProxies:    trustedProxies,
+
+		registrationPolicy: registrationPolicy,
+		pendingLimit:       getEnvInt("STATION_PENDING_LIMIT", 100),
+		rawPayloads:        newRawPayloadStore(dbManager),
+		updates:            newUpdateChecker(),
 	}
 }
 
@@ -35,17 +97,49 @@ func (rm *RouteManager) Setup() {
 		w.WriteHeader(http.StatusNoContent)
 	})
 
-	// Health check
-	r.HandleFunc("/health", rm.healthHandler).Methods("GET")
+	// Versioned API
+	v1 := r.PathPrefix("/api/v1").Subrouter()
+	v1.Use(apiVersionMiddleware(apiVersion))
+	v1.Use(timezoneMiddleware)
+	rm.setupV1Routes(v1)
 
-	// Dynamic pusher endpoints
-	rm.setupPusherEndpoints(r)
+	// Legacy aliases for hardware and OAuth redirects that cannot change their URL
+	rm.setupLegacyRoutes(r)
+
+	// Device description of the SSDP advertisement
+	setupDiscoveryRoutes(r)
 
-	// API v1 routes
-	api := r.PathPrefix("/api/v1").Subrouter()
-	rm.setupAPIRoutes(api)
+	// Widgets embedded in other websites
+	rm.setupEmbedRoutes(r)
+
+	// Ambient Weather API format for third-party display apps
+	rm.setupAmbientRoutes(r)
+}
+
+// setupV1Routes configures all routes of API version 1
+func (rm *RouteManager) setupV1Routes(v1 *mux.Router) {
+	// Health check, version, update check and feature flags
+	v1.HandleFunc("/health", rm.healthHandler).Methods("GET")
+	v1.HandleFunc("/version", rm.versionHandler).Methods("GET")
+	v1.HandleFunc("/update-check", rm.updateCheckHandler).Methods("GET")
+	v1.HandleFunc("/features", rm.featuresHandler).Methods("GET")
+
+	// Dynamic pusher endpoints
+	rm.setupPusherEndpoints(v1)
 
 	// OAuth callbacks
+	rm.setupOAuthRoutes(v1)
+
+	// Query and management API
+	rm.setupAPIRoutes(v1)
+}
+
+// setupLegacyRoutes registers the unversioned paths used before /api/v1.
+// Weather stations are configured with a fixed upload path and OAuth apps
+// with a fixed redirect URI, so these aliases must keep working.
+func (rm *RouteManager) setupLegacyRoutes(r *mux.Router) {
+	r.HandleFunc("/health", rm.healthHandler).Methods("GET")
+	rm.setupPusherEndpoints(r)
 	rm.setupOAuthRoutes(r)
 }
 
@@ -58,39 +152,181 @@ func (rm *RouteManager) setupPusherEndpoints(r *mux.Router) {
 	}
 }
 
-// setupAPIRoutes configures all API v1 routes
+// setupAPIRoutes configures the query and management routes
 func (rm *RouteManager) setupAPIRoutes(api *mux.Router) {
 	// Public auth endpoints (no auth required)
 	api.HandleFunc("/auth/login", rm.handleLogin).Methods("POST")
 	api.HandleFunc("/auth/logout", rm.handleLogout).Methods("POST")
+	api.HandleFunc("/auth/refresh", rm.handleRefreshToken).Methods("POST")
+	api.HandleFunc("/auth/password-reset", rm.handleResetPassword).Methods("POST")
 
 	// Stations
 	api.HandleFunc("/stations", rm.getStationsHandler).Methods("GET")
+	api.HandleFunc("/stations.geojson", rm.getStationsGeoJSONHandler).Methods("GET")
+	api.Handle("/stations/pending", rm.RequireScope(models.ScopeAdmin)(http.HandlerFunc(rm.getPendingStationsHandler))).Methods("GET")
 	api.HandleFunc("/stations/{id}", rm.getStationHandler).Methods("GET")
+	api.HandleFunc("/stations/{id}/cross-validation", rm.handleStationCrossValidation).Methods("GET")
+	api.HandleFunc("/stations/{id}/completeness", rm.handleStationCompleteness).Methods("GET")
+	api.HandleFunc("/stations/{id}/tendency", rm.handleStationTendency).Methods("GET")
+	api.HandleFunc("/stations/{id}/summary", rm.getStationSummaryHandler).Methods("GET")
+	api.HandleFunc("/stations/{id}/freeze-thaw", rm.handleStationFreezeThaw).Methods("GET")
+	if rm.registryManager.Features.Enabled(featureIrrigation) {
+		api.HandleFunc("/stations/{id}/irrigation", rm.getIrrigationHandler).Methods("GET")
+	}
+	if rm.registryManager.Features.Enabled(featureHeating) {
+		api.HandleFunc("/stations/{id}/heating", rm.getHeatingHandler).Methods("GET")
+	}
+	api.HandleFunc("/stations/{id}/reference-bias", rm.handleStationReferenceBias).Methods("GET")
+	api.HandleFunc("/stations/{id}/records", rm.getStationRecordsHandler).Methods("GET")
+	api.HandleFunc("/stations/{id}/pull-status", rm.getStationPullStatusHandler).Methods("GET")
+	api.HandleFunc("/stations/{id}/annotations", rm.getAnnotationsHandler).Methods("GET")
+	api.Handle("/stations/{id}/watermark", rm.RequireScope(models.ScopeReadReadings)(http.HandlerFunc(rm.getStationWatermarkHandler))).Methods("GET")
+	api.Handle("/stations/{id}/changes", rm.RequireScope(models.ScopeReadReadings)(http.HandlerFunc(rm.getStationChangesHandler))).Methods("GET")
+	api.Handle("/stations/{id}/latency", rm.RequireScope(models.ScopeReadReadings)(http.HandlerFunc(rm.getStationLatencyHandler))).Methods("GET")
+	api.Handle("/health/latency", rm.RequireScope(models.ScopeReadReadings)(http.HandlerFunc(rm.getIngestLatencyHandler))).Methods("GET")
+
+	// Voice assistant webhook, authenticated by a share token
+	api.HandleFunc("/voice/{token}", rm.voiceWebhookHandler).Methods("POST")
+
+	// Sites
+	api.HandleFunc("/sites/{id}/current", rm.getSiteCurrentHandler).Methods("GET")
 
 	// Sensors
+	api.HandleFunc("/enums", rm.getEnumsHandler).Methods("GET")
+	api.HandleFunc("/providers", rm.getProvidersHandler).Methods("GET")
 	api.HandleFunc("/stations/{id}/sensors", rm.getSensorsHandler).Methods("GET")
+	api.HandleFunc("/stations/{id}/sensor-types", rm.getCustomSensorTypesHandler).Methods("GET")
 	api.HandleFunc("/sensors/{id}", rm.getSensorHandler).Methods("GET")
+	api.HandleFunc("/sensors/{id}/high-frequency", rm.getHighFrequencyReadingsHandler).Methods("GET")
+	api.HandleFunc("/sensors/{id}/resample", rm.getResampledReadingsHandler).Methods("GET")
 
 	// Readings
 	api.HandleFunc("/readings", rm.getReadingsHandler).Methods("GET")
+	api.HandleFunc("/readings/query", rm.queryReadingsHandler).Methods("POST")
+	api.Handle("/readings/stream", rm.RequireScope(models.ScopeReadReadings)(http.HandlerFunc(rm.getReadingsStreamHandler))).Methods("GET")
+	api.HandleFunc("/charts", rm.handleGetChart).Methods("GET")
 
 	// Dashboards
 	api.HandleFunc("/dashboards", rm.handleGetPublicDashboards).Methods("GET")
 	api.HandleFunc("/dashboards/{id}", rm.handleGetDashboard).Methods("GET")
 
-	// Protected endpoints (auth required)
-	protected := api.PathPrefix("").Subrouter()
-	protected.Use(rm.JWTAuthMiddleware)
+	// User endpoints (login required)
+	session := api.PathPrefix("").Subrouter()
+	session.Use(rm.JWTAuthMiddleware)
 
 	// User info
-	protected.HandleFunc("/auth/me", rm.handleMe).Methods("GET")
-	protected.HandleFunc("/auth/refresh", rm.handleRefreshToken).Methods("POST")
+	session.HandleFunc("/auth/me", rm.handleMe).Methods("GET")
+	session.HandleFunc("/auth/password", rm.handleChangePassword).Methods("POST")
+	session.HandleFunc("/auth/locale", rm.handleSetLocale).Methods("PUT")
+
+	// Data export and deletion of the own account
+	session.HandleFunc("/auth/me/export", rm.handleExportMyData).Methods("GET")
+	session.HandleFunc("/auth/me/deletion", rm.handleScheduleMyDeletion).Methods("POST")
+	session.HandleFunc("/auth/me/deletion", rm.handleCancelMyDeletion).Methods("DELETE")
+
+	// Two-factor authentication
+	session.HandleFunc("/auth/2fa/enroll", rm.handleEnrollTOTP).Methods("POST")
+	session.HandleFunc("/auth/2fa/activate", rm.handleActivateTOTP).Methods("POST")
+	session.HandleFunc("/auth/2fa/disable", rm.handleDisableTOTP).Methods("POST")
+
+	// Sessions
+	session.HandleFunc("/auth/sessions", rm.handleGetSessions).Methods("GET")
+	session.HandleFunc("/auth/sessions/{id}", rm.handleRevokeSession).Methods("DELETE")
+
+	// Protected endpoints (login or API key with admin scope required)
+	protected := api.PathPrefix("").Subrouter()
+	protected.Use(rm.RequireScope(models.ScopeAdmin))
+
+	// Users
+	protected.HandleFunc("/users", rm.handleGetUsers).Methods("GET")
+	protected.HandleFunc("/users/{id}/password-reset", rm.handleCreatePasswordReset).Methods("POST")
+	protected.HandleFunc("/users/{id}/export", rm.handleExportUser).Methods("GET")
+	protected.HandleFunc("/users/{id}/deletion", rm.handleScheduleUserDeletion).Methods("POST")
+	protected.HandleFunc("/users/{id}/deletion", rm.handleCancelUserDeletion).Methods("DELETE")
+	protected.HandleFunc("/users/{id}/audit", rm.handleGetUserAudit).Methods("GET")
+
+	// API keys
+	protected.HandleFunc("/keys", rm.handleGetAPIKeys).Methods("GET")
+	protected.HandleFunc("/keys", rm.handleCreateAPIKey).Methods("POST")
+	protected.HandleFunc("/keys/{id}", rm.handleRevokeAPIKey).Methods("DELETE")
+
+	// Registration of unknown stations, the list is registered before
+	// /stations/{id}
+	protected.HandleFunc("/stations/pending/{passkey}/approve", rm.approvePendingStationHandler).Methods("POST")
+	protected.HandleFunc("/stations/pending/{passkey}/reject", rm.rejectPendingStationHandler).Methods("POST")
+
+	// Station configuration
+	protected.HandleFunc("/stations/{id}/config", rm.getStationConfigHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/pull", rm.pullStationHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/setup", rm.getStationSetupHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/checksums", rm.getReadingChecksumsHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/datasets", rm.createDatasetHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/sensor-types/{name}", rm.putCustomSensorTypeHandler).Methods("PUT")
+	protected.HandleFunc("/stations/{id}/sensor-types/{name}", rm.deleteCustomSensorTypeHandler).Methods("DELETE")
+	protected.HandleFunc("/sensors/{id}/display", rm.putSensorDisplayHandler).Methods("PUT")
+
+	// Share tokens for embedded widgets
+	protected.HandleFunc("/stations/{id}/share-tokens", rm.getShareTokensHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/share-tokens", rm.createShareTokenHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/share-tokens/{tokenId}", rm.updateShareTokenHandler).Methods("PUT")
+	protected.HandleFunc("/stations/{id}/share-tokens/{tokenId}", rm.revokeShareTokenHandler).Methods("DELETE")
+
+	// Station maintenance and notes
+	protected.HandleFunc("/stations/{id}/maintenance", rm.getMaintenanceTasksHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/maintenance", rm.createMaintenanceTaskHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/maintenance/{taskId}", rm.updateMaintenanceTaskHandler).Methods("PUT")
+	protected.HandleFunc("/stations/{id}/maintenance/{taskId}", rm.deleteMaintenanceTaskHandler).Methods("DELETE")
+	protected.HandleFunc("/stations/{id}/maintenance/{taskId}/complete", rm.completeMaintenanceTaskHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/annotations", rm.createAnnotationHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/annotations/{annotationId}", rm.deleteAnnotationHandler).Methods("DELETE")
+
+	// Manual observations
+	protected.HandleFunc("/stations/{id}/observations", rm.getObservationsHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/observations", rm.createObservationsHandler).Methods("POST")
+
+	// Alerts
+	protected.HandleFunc("/alerts", rm.getAlertsHandler).Methods("GET")
+	protected.HandleFunc("/alerts/{id}/test", rm.testAlertRuleHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/alerts", rm.getStationAlertsHandler).Methods("GET")
+	protected.HandleFunc("/stations/{id}/alerts", rm.createAlertRuleHandler).Methods("POST")
+	protected.HandleFunc("/stations/{id}/alerts/{alertId}", rm.updateAlertRuleHandler).Methods("PUT")
+	protected.HandleFunc("/stations/{id}/alerts/{alertId}", rm.deleteAlertRuleHandler).Methods("DELETE")
 
 	// Dashboard management
 	protected.HandleFunc("/dashboards", rm.handleCreateDashboard).Methods("POST")
 	protected.HandleFunc("/dashboards/{id}", rm.handleUpdateDashboard).Methods("PUT")
 	protected.HandleFunc("/dashboards/{id}", rm.handleDeleteDashboard).Methods("DELETE")
+
+	// Ingest pipeline
+	protected.HandleFunc("/ingest/hooks", rm.handleGetIngestHooks).Methods("GET")
+	protected.HandleFunc("/ingest/hooks/{name}", rm.handleUpdateIngestHook).Methods("PUT")
+
+	// Pullers
+	protected.HandleFunc("/pullers/quotas", rm.handleGetPullerQuotas).Methods("GET")
+
+	// Administration
+	protected.HandleFunc("/admin/recompute", rm.handleRecompute).Methods("POST")
+	protected.HandleFunc("/admin/recompute", rm.handleGetRecomputeJobs).Methods("GET")
+	protected.HandleFunc("/admin/recompute/{id}", rm.handleGetRecomputeJob).Methods("GET")
+	protected.HandleFunc("/admin/backups", rm.handleBackup).Methods("POST")
+	protected.HandleFunc("/admin/backups", rm.handleGetBackupJobs).Methods("GET")
+	protected.HandleFunc("/admin/storage", rm.handleGetStorage).Methods("GET")
+	protected.HandleFunc("/admin/db-maintenance", rm.handleDBMaintenance).Methods("POST")
+	protected.HandleFunc("/admin/db-maintenance", rm.handleGetDBMaintenanceJobs).Methods("GET")
+	protected.HandleFunc("/admin/archive", rm.handleArchive).Methods("POST")
+	protected.HandleFunc("/admin/archive", rm.handleGetArchives).Methods("GET")
+	protected.HandleFunc("/admin/archive/{month}/restore", rm.handleRestoreArchive).Methods("POST")
+	protected.HandleFunc("/admin/sensor-types", rm.handleChangeSensorType).Methods("POST")
+
+	// Config sync of edge instances
+	protected.HandleFunc("/sync/stations/{passkey}/config", rm.getSyncConfigHandler).Methods("GET")
+	protected.HandleFunc("/sync/stations/{passkey}/config", rm.putSyncConfigHandler).Methods("PUT")
+
+	// Background jobs
+	protected.HandleFunc("/jobs", rm.handleGetJobs).Methods("GET")
+	protected.HandleFunc("/jobs/{id}", rm.handleGetJob).Methods("GET")
+	protected.HandleFunc("/jobs/{id}/cancel", rm.handleCancelJob).Methods("POST")
+	protected.HandleFunc("/datasets/{id}", rm.downloadDatasetHandler).Methods("GET")
 }
 
 // setupOAuthRoutes configures OAuth callback routes
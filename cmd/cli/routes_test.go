@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// matchedRoute returns the path template of the route a request is routed
+// to and the code pointer of its handler without middlewares, 0 if no
+// route matches
+func matchedRoute(t *testing.T, router *mux.Router, method, path string) (string, uintptr) {
+	t.Helper()
+	var match mux.RouteMatch
+	if !router.Match(httptest.NewRequest(method, path, nil), &match) || match.Route == nil || match.Route.GetHandler() == nil {
+		return "", 0
+	}
+	template, _ := match.Route.GetPathTemplate()
+	return template, reflect.ValueOf(match.Route.GetHandler()).Pointer()
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	registry := pusher.NewRegistry()
+	for _, name := range []string{"ecowitt", "generic"} {
+		registerPusher(registry, name)
+	}
+	rm := NewRouteManager(nil, &RegistryManager{PusherRegistry: registry, Features: loadFeatures()})
+	rm.Setup()
+
+	paths := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/health"},
+		{http.MethodGet, "/netatmo/callback/6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+	}
+	for _, p := range registry.All() {
+		paths = append(paths, struct {
+			method string
+			path   string
+		}{http.MethodPost, p.GetEndpoint()})
+	}
+
+	for _, p := range paths {
+		t.Run(p.path, func(t *testing.T) {
+			legacyTemplate, legacy := matchedRoute(t, rm.Router, p.method, p.path)
+			versionedTemplate, versioned := matchedRoute(t, rm.Router, p.method, "/api/v1"+p.path)
+			if legacy == 0 || versioned == 0 {
+				t.Fatalf("Expected %s and /api/v1%s to be routed", p.path, p.path)
+			}
+			if legacy != versioned || versionedTemplate != "/api/v1"+legacyTemplate {
+				t.Errorf("Expected %s (%s) and /api/v1%s (%s) to reach the same handler", p.path, legacyTemplate, p.path, versionedTemplate)
+			}
+		})
+	}
+
+	// Different handlers are told apart
+	_, health := matchedRoute(t, rm.Router, http.MethodGet, "/api/v1/health")
+	_, version := matchedRoute(t, rm.Router, http.MethodGet, "/api/v1/version")
+	if health == version {
+		t.Error("Expected /api/v1/health and /api/v1/version to reach different handlers")
+	}
+}
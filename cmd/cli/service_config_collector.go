@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
 	"github.com/sguter90/weathermaestro/pkg/puller/netatmo"
 )
 
@@ -31,8 +33,6 @@ func (scc *ServiceConfigCollector) Collect(serviceName, mode string, stationID u
 	config := make(map[string]interface{})
 
 	switch serviceName {
-	case "ecowitt":
-		config = scc.collectEcowittConfig()
 	case "netatmo":
 		config = scc.collectNetatmoConfig(mode, stationID)
 	case "ambient":
@@ -40,21 +40,12 @@ func (scc *ServiceConfigCollector) Collect(serviceName, mode string, stationID u
 	case "weatherflow":
 		config = scc.collectWeatherflowConfig()
 	default:
-		fmt.Printf("Unknown service: %s\n", serviceName)
-	}
-
-	return config
-}
-
-// collectEcowittConfig gathers Ecowitt-specific configuration
-func (scc *ServiceConfigCollector) collectEcowittConfig() map[string]interface{} {
-	config := make(map[string]interface{})
-
-	fmt.Println("\nEcowitt Configuration:")
-	fmt.Print("  API Key (optional): ")
-	apiKey, _ := scc.reader.ReadString('\n')
-	if strings.TrimSpace(apiKey) != "" {
-		config["api_key"] = strings.TrimSpace(apiKey)
+		schema := providerConfigSchema(mode, serviceName)
+		if schema == nil {
+			fmt.Printf("Unknown service: %s\n", serviceName)
+			break
+		}
+		config = scc.collectSchemaConfig(serviceName, schema)
 	}
 
 	return config
@@ -91,7 +82,9 @@ func (scc *ServiceConfigCollector) collectNetatmoConfig(mode string, stationID u
 			publicURL = "http://localhost:8059"
 		}
 
-		// Build redirect URI with station ID
+		// Build redirect URI with station ID. Netatmo requires it to match
+		// the URI registered with the app exactly, so the unversioned alias
+		// registered by existing apps is kept.
 		redirectURI := publicURL + "/netatmo/callback/" + stationID.String()
 		config["redirect_uri"] = redirectURI
 
@@ -178,6 +171,100 @@ func (scc *ServiceConfigCollector) collectWeatherflowConfig() map[string]interfa
 	return config
 }
 
+// collectSchemaConfig asks for the properties of a config schema in their
+// order. Values set by the server are skipped, as are objects and lists,
+// which are set with "station config". Optional values left empty stay
+// unset so the provider default applies.
+func (scc *ServiceConfigCollector) collectSchemaConfig(serviceName string, schema *models.ConfigSchema) map[string]interface{} {
+	config := make(map[string]interface{})
+
+	fmt.Printf("\n%s Configuration:\n", strings.ToUpper(serviceName[:1])+serviceName[1:])
+
+	var structured []string
+	for _, name := range schema.Names() {
+		property := schema.Properties[name]
+		required := schema.IsRequired(name)
+		if property.ReadOnly {
+			continue
+		}
+		if property.Type == models.ConfigTypeObject || property.Type == models.ConfigTypeArray {
+			if required {
+				structured = append(structured, name)
+			}
+			continue
+		}
+
+		for {
+			fmt.Print("  " + configPrompt(name, property, required))
+			input, err := scc.reader.ReadString('\n')
+			input = strings.TrimSpace(input)
+			if input == "" {
+				if required && err == nil {
+					fmt.Println("  ⚠️  A value is required")
+					continue
+				}
+				break
+			}
+			value, err := parseConfigInput(property, input)
+			if err == nil {
+				err = schema.ValidateValue(name, value)
+			}
+			if err != nil {
+				fmt.Printf("  ⚠️  %v\n", err)
+				continue
+			}
+			config[name] = value
+			break
+		}
+	}
+
+	for _, name := range structured {
+		fmt.Printf("\n  ⚠️  Set %s with: station config <station-id> %s '<json>'\n", name, name)
+	}
+
+	return config
+}
+
+// configPrompt returns the prompt of a config property with its default
+func configPrompt(name string, property *models.ConfigProperty, required bool) string {
+	label := name
+	if property.Description != "" {
+		label = fmt.Sprintf("%s (%s)", property.Description, name)
+	}
+	switch {
+	case property.Default != nil:
+		label += fmt.Sprintf(" [%v]", property.Default)
+	case !required:
+		label += " [optional]"
+	}
+	return label + ": "
+}
+
+// parseConfigInput converts an entered value to the type of the property
+func parseConfigInput(property *models.ConfigProperty, input string) (interface{}, error) {
+	switch property.Type {
+	case models.ConfigTypeInteger:
+		value, err := strconv.Atoi(input)
+		if err != nil {
+			return nil, fmt.Errorf("enter a whole number")
+		}
+		return value, nil
+	case models.ConfigTypeNumber:
+		value, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return nil, fmt.Errorf("enter a number")
+		}
+		return value, nil
+	case models.ConfigTypeBoolean:
+		value, err := strconv.ParseBool(input)
+		if err != nil {
+			return nil, fmt.Errorf("enter true or false")
+		}
+		return value, nil
+	}
+	return input, nil
+}
+
 // waitForAccessToken waits for the OAuth2 access token to be set via callback
 func (scc *ServiceConfigCollector) waitForAccessToken(stationID uuid.UUID) error {
 	fmt.Print("  Press Enter once you've authorized the application: ")
@@ -213,7 +300,7 @@ func (scc *ServiceConfigCollector) selectNetatmoDevice(config map[string]interfa
 	}
 	tokenExpiry, err := time.Parse(time.RFC3339, tokenExpiryString)
 	if err != nil {
-		return config, fmt.Errorf("token expiry invalid: " + tokenExpiryString)
+		return config, fmt.Errorf("token expiry invalid: %s", tokenExpiryString)
 	}
 
 	// Create Netatmo client and fetch devices
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/i18n"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// summaryTrendWindow is the time the temperature trend of a summary is
+// measured over
+const summaryTrendWindow = time.Hour
+
+// summaryTrendSteady is the largest temperature change in °C per window
+// that counts as steady
+const summaryTrendSteady = 0.5
+
+// stationSummary is a short text describing the current conditions of a
+// station and the values it is composed of
+type stationSummary struct {
+	StationID        uuid.UUID   `json:"station_id"`
+	Locale           i18n.Locale `json:"locale"`
+	Text             string      `json:"text"`
+	Temperature      *float64    `json:"temperature,omitempty"`
+	TemperatureTrend string      `json:"temperature_trend,omitempty"`
+	WindSpeed        *float64    `json:"wind_speed,omitempty"`
+	WindDirection    *float64    `json:"wind_direction,omitempty"`
+	Beaufort         *int        `json:"beaufort,omitempty"`
+	RainToday        *float64    `json:"rain_today,omitempty"`
+	Updated          *time.Time  `json:"updated,omitempty"`
+	// Indoor holds the climate of the indoor locations, see
+	// models.ComputeIndoorClimate
+	Indoor *models.StationIndoorClimate `json:"indoor,omitempty"`
+}
+
+// summarizeStation builds the summary of a station from the latest readings
+// of its visible sensors. Readings older than maxAge at now are left out.
+func summarizeStation(ctx context.Context, dbManager *database.DatabaseManager, station *models.StationData, sensors []models.SensorWithLatestReading, locale i18n.Locale, now time.Time, maxAge time.Duration) (*stationSummary, error) {
+	summary := &stationSummary{StationID: station.ID, Locale: locale}
+	var temperature *models.SensorWithLatestReading
+	var current []models.SensorWithLatestReading
+	for _, s := range sensors {
+		reading := s.LatestReading
+		if reading == nil || !s.Sensor.Enabled || now.Sub(reading.DateUTC) > maxAge {
+			continue
+		}
+		current = append(current, s)
+		value := reading.Value
+		switch s.Sensor.SensorType {
+		case models.SensorTypeTemperatureOutdoor:
+			temperature = &s
+		case models.SensorTypeTemperature:
+			// Indoor temperatures are only used without an outdoor sensor
+			if temperature == nil || strings.EqualFold(s.Sensor.Location, "outdoor") {
+				temperature = &s
+			}
+		case models.SensorTypeWindSpeed:
+			beaufort := models.Beaufort(value)
+			summary.WindSpeed, summary.Beaufort = &value, &beaufort
+		case models.SensorTypeWindDirection:
+			summary.WindDirection = &value
+		case models.SensorTypeRainfallDaily:
+			summary.RainToday = &value
+		default:
+			continue
+		}
+		if updated := reading.DateUTC; summary.Updated == nil || updated.After(*summary.Updated) {
+			summary.Updated = &updated
+		}
+	}
+
+	if temperature != nil {
+		value := temperature.LatestReading.Value
+		summary.Temperature = &value
+
+		series, err := dbManager.GetAveragedReadings(ctx, []uuid.UUID{temperature.Sensor.ID}, now.Add(-summaryTrendWindow), now, tendencyInterval)
+		if err != nil {
+			return nil, err
+		}
+		summary.TemperatureTrend = analysis.Trend(readingPoints(series[temperature.Sensor.ID]), summaryTrendSteady)
+	}
+
+	summary.Indoor = models.ComputeIndoorClimate(indoorClimatePolicy(station.ID, station.Config), current)
+	summary.Text = summaryText(summary, locale)
+	return summary, nil
+}
+
+// summaryText composes the text of a summary, e.g. "Currently 14.2 °C and
+// falling, light NW wind, 2.4 mm rain since midnight"
+func summaryText(s *stationSummary, locale i18n.Locale) string {
+	var parts []string
+	for _, part := range []string{summaryTemperature(s, locale), summaryWind(s, locale), summaryRain(s, locale)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, i18n.T(locale, "summary.no_readings"))
+	}
+	return capitalize(strings.Join(parts, ", "))
+}
+
+// summaryTemperature describes the temperature and its trend; it is empty
+// without a temperature
+func summaryTemperature(s *stationSummary, locale i18n.Locale) string {
+	if s.Temperature == nil {
+		return ""
+	}
+	temperature := i18n.FormatNumber(locale, *s.Temperature, 1)
+	if s.TemperatureTrend == "" {
+		return i18n.T(locale, "summary.temperature", temperature)
+	}
+	return i18n.T(locale, "summary.temperature_trend", temperature, i18n.T(locale, "summary.trend."+s.TemperatureTrend))
+}
+
+// summaryRain describes the rain since midnight; it is empty without a
+// daily rain sensor
+func summaryRain(s *stationSummary, locale i18n.Locale) string {
+	switch {
+	case s.RainToday == nil:
+		return ""
+	case *s.RainToday > 0:
+		return i18n.T(locale, "summary.rain", i18n.FormatNumber(locale, *s.RainToday, 1))
+	}
+	return i18n.T(locale, "summary.no_rain")
+}
+
+// summaryWind describes the wind by its strength on the Beaufort scale and
+// the compass point it comes from; it is empty without a wind speed
+func summaryWind(s *stationSummary, locale i18n.Locale) string {
+	if s.Beaufort == nil {
+		return ""
+	}
+	var strength string
+	switch beaufort := *s.Beaufort; {
+	case beaufort == 0:
+		return i18n.T(locale, "summary.wind_calm")
+	case beaufort <= 3:
+		strength = "light"
+	case beaufort <= 5:
+		strength = "moderate"
+	case beaufort <= 7:
+		strength = "strong"
+	default:
+		strength = "stormy"
+	}
+	strength = i18n.T(locale, "summary.wind_strength."+strength)
+	if s.WindDirection == nil {
+		return i18n.T(locale, "summary.wind_no_direction", strength)
+	}
+	return i18n.T(locale, "summary.wind", strength, i18n.CompassPoint(locale, *s.WindDirection, 8))
+}
+
+// capitalize upper cases the first letter of a text
+func capitalize(text string) string {
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[size:]
+}
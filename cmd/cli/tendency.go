@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/analysis"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// tendencyInterval averages the readings of the tendency window, smoothing
+// noisy pressure sensors
+const tendencyInterval = "5m"
+
+// tendencyPressureTypes are the pressure sensor types used for the tendency
+// in order of preference
+var tendencyPressureTypes = []string{
+	models.SensorTypePressureRelative,
+	models.SensorTypePressureAbsolute,
+	models.SensorTypePressure,
+}
+
+// stationTendency is the pressure and wind tendency of a station with the
+// sensors it was computed from
+type stationTendency struct {
+	StationID        uuid.UUID  `json:"station_id"`
+	At               time.Time  `json:"at"`
+	PressureSensorID *uuid.UUID `json:"pressure_sensor_id,omitempty"`
+	WindSensorID     *uuid.UUID `json:"wind_sensor_id,omitempty"`
+	analysis.Tendency
+}
+
+// stormDetector computes tendencies and storm events from the stored
+// readings of a station. It is the storm detector of the alert hook.
+type stormDetector struct {
+	db         *database.DatabaseManager
+	thresholds analysis.StormThresholds
+	// view applies the privacy settings to public tendencies; nil for
+	// alerts
+	view *privacyView
+}
+
+// newStormDetector creates a detector with the default thresholds
+func newStormDetector(dbManager *database.DatabaseManager) *stormDetector {
+	return &stormDetector{db: dbManager, thresholds: analysis.DefaultStormThresholds}
+}
+
+// Tendency returns the tendency of the window ending at the given time
+func (d *stormDetector) Tendency(ctx context.Context, stationID uuid.UUID, at time.Time) (*stationTendency, error) {
+	enabled := true
+	sensors, err := d.db.GetSensors(models.SensorQueryParams{StationID: &stationID, Enabled: &enabled})
+	if err != nil {
+		return nil, err
+	}
+	sensors = d.view.filterSensors(sensors)
+
+	tendency := &stationTendency{StationID: stationID, At: at}
+	var sensorIDs []uuid.UUID
+	for _, sensorType := range tendencyPressureTypes {
+		if id, ok := sensorOfType(sensors, sensorType); ok {
+			tendency.PressureSensorID = &id
+			sensorIDs = append(sensorIDs, id)
+			break
+		}
+	}
+	if id, ok := sensorOfType(sensors, models.SensorTypeWindSpeed); ok {
+		tendency.WindSensorID = &id
+		sensorIDs = append(sensorIDs, id)
+	}
+
+	series, err := d.db.GetAveragedReadings(ctx, sensorIDs, at.Add(-d.thresholds.Window), at, tendencyInterval)
+	if err != nil {
+		return nil, err
+	}
+	d.view.roundSeries(series)
+	var pressure, wind []analysis.Point
+	if tendency.PressureSensorID != nil {
+		pressure = readingPoints(series[*tendency.PressureSensorID])
+	}
+	if tendency.WindSensorID != nil {
+		wind = readingPoints(series[*tendency.WindSensorID])
+	}
+
+	tendency.Tendency = analysis.DetectStorm(pressure, wind, at, d.thresholds)
+	return tendency, nil
+}
+
+// StormSeverity returns the severity of the storm event at the given time
+func (d *stormDetector) StormSeverity(ctx context.Context, stationID uuid.UUID, at time.Time) (int, error) {
+	tendency, err := d.Tendency(ctx, stationID, at)
+	if err != nil || tendency.Storm == nil {
+		return analysis.StormNone, err
+	}
+	return tendency.Storm.Severity, nil
+}
+
+// sensorOfType returns the first sensor of a type, preferring outdoor ones
+func sensorOfType(sensors []models.SensorWithLatestReading, sensorType string) (uuid.UUID, bool) {
+	var found *models.Sensor
+	for i := range sensors {
+		s := &sensors[i].Sensor
+		if s.SensorType != sensorType {
+			continue
+		}
+		if found == nil || (s.Location == "Outdoor" && found.Location != "Outdoor") {
+			found = s
+		}
+	}
+	if found == nil {
+		return uuid.Nil, false
+	}
+	return found.ID, true
+}
+
+// readingPoints converts readings to analysis points
+func readingPoints(readings []models.SensorReading) []analysis.Point {
+	points := make([]analysis.Point, 0, len(readings))
+	for _, r := range readings {
+		points = append(points, analysis.Point{Time: r.DateUTC, Value: r.Value})
+	}
+	return points
+}
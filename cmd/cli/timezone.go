@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Values of the tz_fields query parameter
+const (
+	tzFieldsLocal = "local"
+	tzFieldsBoth  = "both"
+)
+
+// timestampFields are the JSON fields holding timestamps besides the ones
+// named like one, see isTimestampField
+var timestampFields = map[string]bool{
+	"start":         true,
+	"end":           true,
+	"from":          true,
+	"to":            true,
+	"at":            true,
+	"time":          true,
+	"date":          true,
+	"since":         true,
+	"updated":       true,
+	"timestamp":     true,
+	"active_since":  true,
+	"first_reading": true,
+	"last_reading":  true,
+	"last_update":   true,
+	"last_run":      true,
+}
+
+// timezoneMiddleware converts the timestamps of JSON responses of GET
+// requests with a tz parameter to the requested IANA time zone. Stored data
+// stays UTC. With tz_fields=both the UTC values are kept and a local copy
+// is added next to each timestamp field; fields named UTC always get a
+// local copy instead of being converted.
+func timezoneMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Query().Get("tz") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		q := newQueryParams(r)
+		tz := q.String("tz")
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			q.Invalid("tz", "invalid time zone: %s", tz)
+		}
+		fields := q.OneOf("tz_fields", tzFieldsLocal, tzFieldsLocal, tzFieldsBoth)
+		if err := q.Err(); err != nil {
+			respondValidation(w, err)
+			return
+		}
+
+		lw := &localizingWriter{ResponseWriter: w}
+		next.ServeHTTP(lw, r)
+		if !lw.buffering {
+			return
+		}
+
+		body := lw.buf.Bytes()
+		if localized, err := localizeJSON(body, loc, fields == tzFieldsBoth); err != nil {
+			log.Printf("⚠ Failed to localize timestamps of %s: %v", r.URL.Path, err)
+		} else {
+			body = localized
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(lw.status)
+		w.Write(body)
+	})
+}
+
+// localizingWriter buffers JSON responses to rewrite their timestamps. Other
+// content types, like CSV exports and charts, are passed through.
+type localizingWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	buffering   bool
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to extend
+// the write deadline of streams
+func (lw *localizingWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
+
+func (lw *localizingWriter) WriteHeader(status int) {
+	if lw.wroteHeader {
+		return
+	}
+	lw.wroteHeader = true
+	lw.status = status
+	if strings.HasPrefix(lw.Header().Get("Content-Type"), "application/json") {
+		lw.buffering = true
+		return
+	}
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+func (lw *localizingWriter) Write(p []byte) (int, error) {
+	if !lw.wroteHeader {
+		lw.WriteHeader(http.StatusOK)
+	}
+	if lw.buffering {
+		return lw.buf.Write(p)
+	}
+	return lw.ResponseWriter.Write(p)
+}
+
+// Flush keeps streamed responses working
+func (lw *localizingWriter) Flush() {
+	if lw.buffering {
+		return
+	}
+	if f, ok := lw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// localizeJSON rewrites the RFC3339 timestamps of the timestamp fields of a
+// JSON document to loc, see isTimestampField. Other strings, like names,
+// notes or config values, are never touched. Key order and numbers are
+// kept as they are. With both, timestamps stay UTC and a <field>_local copy
+// is added. Fields named UTC, e.g. date_utc, always stay UTC and get a
+// local copy without the suffix, e.g. date_local.
+func localizeJSON(data []byte, loc *time.Location, both bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	l := localizer{dec: dec, out: &out, loc: loc, both: both}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if err := l.value(tok); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// localizer copies JSON tokens from dec to out
+type localizer struct {
+	dec  *json.Decoder
+	out  *bytes.Buffer
+	loc  *time.Location
+	both bool
+}
+
+// value writes the value starting with tok
+func (l localizer) value(tok json.Token) error {
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return l.object()
+		case '[':
+			return l.array()
+		}
+		return fmt.Errorf("unexpected %s", t)
+	case string:
+		return l.string(t)
+	case json.Number:
+		l.out.WriteString(t.String())
+	case bool:
+		fmt.Fprint(l.out, t)
+	case nil:
+		l.out.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected token %v", t)
+	}
+	return nil
+}
+
+func (l localizer) object() error {
+	l.out.WriteByte('{')
+	first := true
+	for l.dec.More() {
+		tok, err := l.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected key %v", tok)
+		}
+		if tok, err = l.dec.Token(); err != nil {
+			return err
+		}
+
+		if !first {
+			l.out.WriteByte(',')
+		}
+		first = false
+		if err := l.field(key, tok); err != nil {
+			return err
+		}
+	}
+	if _, err := l.dec.Token(); err != nil {
+		return err
+	}
+	l.out.WriteByte('}')
+	return nil
+}
+
+// field writes one object field and its local copy
+func (l localizer) field(key string, tok json.Token) error {
+	if err := l.string(key); err != nil {
+		return err
+	}
+	l.out.WriteByte(':')
+
+	s, ok := tok.(string)
+	if !ok {
+		return l.value(tok)
+	}
+	if !isTimestampField(key) {
+		return l.string(s)
+	}
+	ts, ok := l.timestamp(s)
+	if !ok {
+		return l.string(s)
+	}
+	if !l.both && !isUTCField(key) {
+		return l.string(ts)
+	}
+
+	if err := l.string(s); err != nil {
+		return err
+	}
+	l.out.WriteByte(',')
+	if err := l.string(localKey(key)); err != nil {
+		return err
+	}
+	l.out.WriteByte(':')
+	return l.string(ts)
+}
+
+func (l localizer) array() error {
+	l.out.WriteByte('[')
+	first := true
+	for l.dec.More() {
+		tok, err := l.dec.Token()
+		if err != nil {
+			return err
+		}
+		if !first {
+			l.out.WriteByte(',')
+		}
+		first = false
+		if err := l.value(tok); err != nil {
+			return err
+		}
+	}
+	if _, err := l.dec.Token(); err != nil {
+		return err
+	}
+	l.out.WriteByte(']')
+	return nil
+}
+
+func (l localizer) string(s string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	l.out.Write(data)
+	return nil
+}
+
+// timestamp converts an RFC3339 timestamp to the local time zone. Dates
+// without a time, like the days of daily statistics, are left alone.
+func (l localizer) timestamp(s string) (string, bool) {
+	if len(s) < len("2006-01-02T15:04:05Z") || s[10] != 'T' {
+		return "", false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return "", false
+	}
+	return t.In(l.loc).Format(time.RFC3339Nano), true
+}
+
+// isTimestampField reports whether a field holds a timestamp: the fields
+// ending in _at, _time or utc and the timestampFields
+func isTimestampField(key string) bool {
+	return strings.HasSuffix(key, "_at") || strings.HasSuffix(key, "_time") || isUTCField(key) || timestampFields[key]
+}
+
+// isUTCField reports whether the name of a field says it is UTC, like
+// date_utc and dateutc
+func isUTCField(key string) bool {
+	return strings.HasSuffix(key, "utc")
+}
+
+// localKey names the local copy of a timestamp field, e.g. date_utc and
+// dateutc get date_local
+func localKey(key string) string {
+	if isUTCField(key) {
+		key = strings.TrimSuffix(strings.TrimSuffix(key, "utc"), "_")
+	}
+	return key + "_local"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/update"
+)
+
+// updatePublicKey is the base64 encoded Ed25519 key the checksums of
+// releases are signed with, set when building releases:
+//
+//	go build -ldflags "-X main.updatePublicKey=$(cat release.pub)"
+//
+// UPDATE_PUBLIC_KEY overrides it.
+var updatePublicKey = ""
+
+// updateCheckTTL is how long the latest release is cached, so the UI can
+// poll without hitting the rate limit of the release API
+const updateCheckTTL = 6 * time.Hour
+
+// updateErrorTTL is how long a failed check is cached, so an unreachable
+// release API isn't queried on every poll
+const updateErrorTTL = 15 * time.Minute
+
+// updateRepository is the repository releases are queried from
+func updateRepository() string {
+	return getEnv("UPDATE_REPOSITORY", "sguter90/weathermaestro")
+}
+
+// updatePublicKeyFromEnv returns the key releases must be signed with
+func updatePublicKeyFromEnv() (ed25519.PublicKey, error) {
+	key := getEnv("UPDATE_PUBLIC_KEY", updatePublicKey)
+	if key == "" {
+		return nil, errors.New("no release signing key, set UPDATE_PUBLIC_KEY")
+	}
+	return update.ParsePublicKey(key)
+}
+
+// updateStatus is the response of the update check
+type updateStatus struct {
+	Enabled         bool       `json:"enabled"`
+	CurrentVersion  string     `json:"current_version"`
+	LatestVersion   string     `json:"latest_version,omitempty"`
+	UpdateAvailable bool       `json:"update_available"`
+	ReleaseURL      string     `json:"release_url,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	CheckedAt       *time.Time `json:"checked_at,omitempty"`
+}
+
+// updateChecker caches the latest release. It returns nil if UPDATE_CHECK
+// is disabled.
+type updateChecker struct {
+	client *update.Client
+
+	mu        sync.Mutex
+	release   *update.Release
+	checkedAt time.Time
+	// err is the last failed check, cached for updateErrorTTL
+	err      error
+	failedAt time.Time
+}
+
+func newUpdateChecker() *updateChecker {
+	if !getEnvBool("UPDATE_CHECK", true) {
+		return nil
+	}
+	return &updateChecker{client: update.NewClient(getEnv("UPDATE_API_URL", ""), updateRepository())}
+}
+
+// Status compares the running version with the latest release
+func (c *updateChecker) Status(ctx context.Context) (updateStatus, error) {
+	status := updateStatus{CurrentVersion: version}
+	if c == nil {
+		return status, nil
+	}
+	status.Enabled = true
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil && time.Since(c.failedAt) < updateErrorTTL {
+		return status, c.err
+	}
+	if c.release == nil || time.Since(c.checkedAt) >= updateCheckTTL {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		release, err := c.client.Latest(ctx)
+		if err != nil {
+			c.err, c.failedAt = err, time.Now().UTC()
+			return status, err
+		}
+		c.release, c.checkedAt, c.err = release, time.Now().UTC(), nil
+	}
+
+	checkedAt, publishedAt := c.checkedAt, c.release.PublishedAt
+	status.LatestVersion = c.release.Version
+	status.UpdateAvailable = update.Newer(version, c.release.Version)
+	status.ReleaseURL = c.release.URL
+	status.CheckedAt = &checkedAt
+	if !publishedAt.IsZero() {
+		status.PublishedAt = &publishedAt
+	}
+	return status, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/backup"
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/jobs"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// userDeletionActor is the actor of deletions run after the grace period
+const userDeletionActor = "system"
+
+// userDeletionGrace is the time between a deletion request and the
+// deletion, configured with USER_DELETION_GRACE
+func userDeletionGrace() time.Duration {
+	return getEnvDuration("USER_DELETION_GRACE", 30*24*time.Hour)
+}
+
+// writeUserExport writes all data of a user to a zip archive with the
+// manifest of backups: the profile, the stations the user owns with their
+// sensors and readings, the active sessions and the audit log. Secrets in
+// station configs are redacted.
+func writeUserExport(ctx context.Context, dbManager *database.DatabaseManager, user *models.User, out io.Writer) (*backup.Manifest, error) {
+	stationIDs, err := dbManager.GetOwnedStationIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	stations := make([]models.StationData, 0, len(stationIDs))
+	var sensors []models.Sensor
+	for _, id := range stationIDs {
+		station, err := dbManager.LoadStation(id)
+		if err != nil {
+			return nil, err
+		}
+		station.Config = models.RedactConfig(station.Config)
+		stations = append(stations, station)
+
+		stationSensors, err := dbManager.GetSensors(models.SensorQueryParams{StationID: &station.ID})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range stationSensors {
+			sensors = append(sensors, s.Sensor)
+		}
+	}
+	sensorIDs := make([]uuid.UUID, len(sensors))
+	for i, s := range sensors {
+		sensorIDs[i] = s.ID
+	}
+	sessions, err := dbManager.GetSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := dbManager.GetUserAuditLog(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	w := backup.NewWriter(out, time.Now().UTC())
+	entries := []struct {
+		name  string
+		write func(io.Writer) (int64, error)
+	}{
+		{"user.json", func(w io.Writer) (int64, error) { return 1, json.NewEncoder(w).Encode(user) }},
+		{"stations.jsonl", func(w io.Writer) (int64, error) { return writeJSONLines(w, stations) }},
+		{"sensors.jsonl", func(w io.Writer) (int64, error) { return writeJSONLines(w, sensors) }},
+		{"readings.jsonl", func(w io.Writer) (int64, error) { return dbManager.WriteSensorReadingsJSON(ctx, sensorIDs, w) }},
+		{"sessions.jsonl", func(w io.Writer) (int64, error) { return writeJSONLines(w, sessions) }},
+		{"audit.jsonl", func(w io.Writer) (int64, error) { return writeJSONLines(w, audit) }},
+	}
+	for _, entry := range entries {
+		if err := w.Add(entry.name, entry.write); err != nil {
+			return nil, err
+		}
+	}
+	return w.Close()
+}
+
+// deleteUser irreversibly deletes a user with the stations it owns and
+// their readings and records the deletion in the audit log
+func deleteUser(ctx context.Context, dbManager *database.DatabaseManager, user *models.User, actor string) (*models.UserDeletion, error) {
+	deletion, err := dbManager.DeleteUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	auditUser(ctx, dbManager, user, models.UserAuditDeleted, actor, map[string]interface{}{
+		"stations": deletion.Stations,
+		"sensors":  deletion.Sensors,
+	})
+
+	// The sensors are gone, so their readings are orphans now; the doctor
+	// removes them should this fail
+	if _, err := dbManager.DeleteOrphanedReadings(ctx, deletion.SensorIDs); err != nil {
+		return deletion, fmt.Errorf("user deleted, but failed to delete readings: %w", err)
+	}
+	log.Printf("✓ Deleted user %s with %d stations and %d sensors", user.Username, deletion.Stations, deletion.Sensors)
+	return deletion, nil
+}
+
+// auditUser records an action in the user audit log. Failures are logged,
+// the action itself already happened.
+func auditUser(ctx context.Context, dbManager *database.DatabaseManager, user *models.User, action, actor string, details map[string]interface{}) {
+	entry := &models.UserAuditEntry{
+		UserID:   user.ID,
+		Username: user.Username,
+		Action:   action,
+		Actor:    actor,
+		Details:  details,
+	}
+	if err := dbManager.AddUserAuditEntry(ctx, entry); err != nil {
+		log.Printf("❌ Failed to record %s of user %s in the audit log: %v", action, user.Username, err)
+	}
+}
+
+// requestActor names the user or API key of a request for the audit log
+func requestActor(r *http.Request) string {
+	if user := GetUserFromContext(r.Context()); user != nil {
+		return user.Username
+	}
+	if apiKey := GetAPIKeyFromContext(r.Context()); apiKey != nil {
+		return "api-key:" + apiKey.Name
+	}
+	return "unknown"
+}
+
+// userPurgeJobHandler deletes the users whose grace period has passed
+func userPurgeJobHandler(dbManager *database.DatabaseManager) jobs.Handler {
+	return func(ctx context.Context, job *models.Job, progress *jobs.Progress) error {
+		users, err := dbManager.GetUsersDueForDeletion(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		progress.SetTotal(len(users))
+		for i := range users {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := deleteUser(ctx, dbManager, &users[i], userDeletionActor); err != nil {
+				return fmt.Errorf("user %s: %w", users[i].Username, err)
+			}
+			progress.Add(1)
+		}
+		return nil
+	}
+}
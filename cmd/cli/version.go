@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+)
+
+// Build information, set when building releases:
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+//
+// Without them the commit and time of the VCS stamp of the binary are used.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// apiVersion is the version of the /api/v1 routes
+const apiVersion = "1"
+
+// versionInfo describes the build and the database schema of a server
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	API       string `json:"api_version"`
+	// SchemaVersion is the newest applied migration, SchemaExpected the
+	// newest one of this build
+	SchemaVersion  int `json:"schema_version,omitempty"`
+	SchemaExpected int `json:"schema_expected"`
+	// Features lists the enabled optional features, e.g. mqtt
+	Features []string `json:"features,omitempty"`
+}
+
+// buildVersion returns the build information of the binary
+func buildVersion() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		API:       apiVersion,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	info.SchemaExpected, _ = database.LatestMigration()
+	return info
+}
+
+// versionTemplate is the output of --version
+func versionTemplate() string {
+	info := buildVersion()
+	text := fmt.Sprintf("WeatherMaestro %s\n", info.Version)
+	if info.Commit != "" {
+		text += fmt.Sprintf("Commit:  %s\n", info.Commit)
+	}
+	if info.BuildDate != "" {
+		text += fmt.Sprintf("Built:   %s\n", info.BuildDate)
+	}
+	return text + fmt.Sprintf("Go:      %s\nAPI:     v%s\nSchema:  %d\n", info.GoVersion, info.API, info.SchemaExpected)
+}
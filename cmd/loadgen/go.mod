@@ -0,0 +1,3 @@
+module github.com/sguter90/weathermaestro/cmd/loadgen
+
+go 1.25
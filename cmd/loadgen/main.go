@@ -0,0 +1,247 @@
+// Command loadgen simulates weather stations pushing Ecowitt payloads to a
+// WeatherMaestro server and reports the achieved throughput and latency.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// config holds the command line flags
+type config struct {
+	url      string
+	stations int
+	interval time.Duration
+	duration time.Duration
+	apiKey   string
+	timeout  time.Duration
+	report   time.Duration
+	prefix   string
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.url, "url", "http://localhost:8059/api/v1/data/report", "push endpoint of the server")
+	flag.IntVar(&cfg.stations, "stations", 100, "number of simulated stations")
+	flag.DurationVar(&cfg.interval, "interval", time.Minute, "upload interval of each station")
+	flag.DurationVar(&cfg.duration, "duration", 5*time.Minute, "duration of the test")
+	flag.StringVar(&cfg.apiKey, "api-key", "", "API key with write:ingest scope")
+	flag.DurationVar(&cfg.timeout, "timeout", 10*time.Second, "timeout of a single push")
+	flag.DurationVar(&cfg.report, "report", 10*time.Second, "interval of progress reports")
+	flag.StringVar(&cfg.prefix, "prefix", "loadgen", "pass key prefix of the simulated stations")
+	flag.Parse()
+
+	if cfg.stations < 1 || cfg.interval <= 0 {
+		fmt.Fprintln(os.Stderr, "stations and interval must be positive")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.duration)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Simulating %d stations every %s (%.1f pushes/s) against %s for %s",
+		cfg.stations, cfg.interval, float64(cfg.stations)/cfg.interval.Seconds(), cfg.url, cfg.duration)
+
+	stats := &stats{started: time.Now()}
+	client := &http.Client{Timeout: cfg.timeout}
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.stations; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := &station{passKey: fmt.Sprintf("%s-%04d", cfg.prefix, i), rng: rand.New(rand.NewSource(int64(i)))}
+			s.run(ctx, client, cfg, stats)
+		}(i)
+	}
+
+	ticker := time.NewTicker(cfg.report)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			log.Println(stats.summary())
+		case <-done:
+			log.Println("Finished: " + stats.summary())
+			if stats.failed() > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+	}
+}
+
+// station is a simulated station with slowly changing weather
+type station struct {
+	passKey string
+	rng     *rand.Rand
+	rain    float64
+}
+
+// run pushes a payload every interval until ctx is done. The first push is
+// delayed randomly so the stations do not post at the same time.
+func (s *station) run(ctx context.Context, client *http.Client, cfg config, stats *stats) {
+	delay := time.Duration(s.rng.Int63n(int64(cfg.interval)))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		start := time.Now()
+		err := s.push(ctx, client, cfg)
+		if ctx.Err() != nil {
+			return
+		}
+		stats.record(time.Since(start), err)
+
+		// Real consoles drift a little around their interval
+		jitter := (s.rng.Float64() - 0.5) * 0.1 * float64(cfg.interval)
+		delay = cfg.interval + time.Duration(jitter)
+	}
+}
+
+// push sends one payload and returns an error for non-2xx responses
+func (s *station) push(ctx context.Context, client *http.Client, cfg config) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.url, strings.NewReader(s.payload(time.Now().UTC()).Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cfg.apiKey != "" {
+		req.Header.Set("X-API-Key", cfg.apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// payload builds an Ecowitt upload with a daily temperature cycle and noise
+func (s *station) payload(now time.Time) url.Values {
+	hour := float64(now.Hour()) + float64(now.Minute())/60
+	daily := math.Sin((hour - 9) / 24 * 2 * math.Pi)
+	noise := func(scale float64) float64 { return (s.rng.Float64() - 0.5) * scale }
+	f := func(v float64, decimals int) string { return strconv.FormatFloat(v, 'f', decimals, 64) }
+
+	if s.rng.Float64() < 0.05 {
+		s.rain += 0.01
+	}
+
+	return url.Values{
+		"PASSKEY":        {s.passKey},
+		"stationtype":    {"EasyWeatherPro_V5.1.6"},
+		"model":          {"GW2000A_V3.1.2"},
+		"dateutc":        {now.Format("2006-01-02 15:04:05")},
+		"tempinf":        {f(70+noise(1), 1)},
+		"humidityin":     {f(45+noise(2), 0)},
+		"baromrelin":     {f(29.92+noise(0.05), 3)},
+		"baromabsin":     {f(29.70+noise(0.05), 3)},
+		"tempf":          {f(55+15*daily+noise(1), 1)},
+		"humidity":       {f(70-20*daily+noise(4), 0)},
+		"winddir":        {f(s.rng.Float64()*360, 0)},
+		"windspeedmph":   {f(math.Abs(5+noise(6)), 1)},
+		"windgustmph":    {f(math.Abs(8+noise(10)), 1)},
+		"solarradiation": {f(math.Max(0, 600*daily+noise(50)), 2)},
+		"uv":             {f(math.Max(0, math.Round(6*daily)), 0)},
+		"rainratein":     {"0.000"},
+		"dailyrainin":    {f(s.rain, 3)},
+		"wh65batt":       {"0"},
+	}
+}
+
+// stats collects the outcome of all pushes
+type stats struct {
+	mu        sync.Mutex
+	started   time.Time
+	ok        int
+	errors    map[string]int
+	latencies []time.Duration
+}
+
+func (s *stats) record(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies = append(s.latencies, latency)
+	if err == nil {
+		s.ok++
+		return
+	}
+	if s.errors == nil {
+		s.errors = make(map[string]int)
+	}
+	s.errors[err.Error()]++
+}
+
+func (s *stats) failed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for _, n := range s.errors {
+		total += n
+	}
+	return total
+}
+
+// summary returns counts, throughput and latency percentiles
+func (s *stats) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) time.Duration {
+		if len(sorted) == 0 {
+			return 0
+		}
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+
+	failed := 0
+	var reasons []string
+	for reason, n := range s.errors {
+		failed += n
+		reasons = append(reasons, fmt.Sprintf("%s: %d", reason, n))
+	}
+	sort.Strings(reasons)
+
+	elapsed := time.Since(s.started).Seconds()
+	line := fmt.Sprintf("%d ok, %d failed, %.1f pushes/s, p50 %s, p95 %s, p99 %s",
+		s.ok, failed, float64(len(sorted))/elapsed,
+		percentile(0.50).Round(time.Millisecond), percentile(0.95).Round(time.Millisecond), percentile(0.99).Round(time.Millisecond))
+	if len(reasons) > 0 {
+		line += " (" + strings.Join(reasons, ", ") + ")"
+	}
+	return line
+}
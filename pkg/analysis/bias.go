@@ -0,0 +1,53 @@
+package analysis
+
+import "time"
+
+// HourBias is the mean difference of a series to its reference at one hour
+// of the day. A positive bias means the series reads higher.
+type HourBias struct {
+	Hour    int     `json:"hour"`
+	Samples int     `json:"samples"`
+	Bias    float64 `json:"bias"`
+}
+
+// Between returns the points of a series in [start, end)
+func (s Series) Between(start, end time.Time) Series {
+	result := Series{SensorID: s.SensorID, Points: []Point{}}
+	for _, p := range s.Points {
+		if !p.Time.Before(start) && p.Time.Before(end) {
+			result.Points = append(result.Points, p)
+		}
+	}
+	return result
+}
+
+// BiasByHour compares a series with a reference on their common timestamps
+// per hour of the day in loc. Siting problems like morning sun or a heat
+// source at night show up as a bias that depends on the hour. Hours without
+// common timestamps are omitted.
+func BiasByHour(reference, s Series, loc *time.Location) []HourBias {
+	byTime := make(map[int64]float64, len(reference.Points))
+	for _, p := range reference.Points {
+		byTime[p.Time.UnixNano()] = p.Value
+	}
+
+	var sums [24]float64
+	var counts [24]int
+	for _, p := range s.Points {
+		ref, ok := byTime[p.Time.UnixNano()]
+		if !ok {
+			continue
+		}
+		hour := p.Time.In(loc).Hour()
+		sums[hour] += p.Value - ref
+		counts[hour]++
+	}
+
+	hours := []HourBias{}
+	for hour := range counts {
+		if counts[hour] > 0 {
+			hours = append(hours, HourBias{Hour: hour, Samples: counts[hour], Bias: sums[hour] / float64(counts[hour])})
+		}
+	}
+	return hours
+}
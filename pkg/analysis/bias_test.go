@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSeries_Between(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	s := hourly(start, 1, 2, 3, 4)
+
+	got := s.Between(start.Add(time.Hour), start.Add(3*time.Hour))
+	if len(got.Points) != 2 || got.Points[0].Value != 2 || got.Points[1].Value != 3 {
+		t.Errorf("Between() = %+v", got.Points)
+	}
+	if got.SensorID != s.SensorID {
+		t.Error("expected sensor ID to be kept")
+	}
+}
+
+func TestBiasByHour(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	reference := Series{}
+	station := Series{}
+	// Two days of hourly values, the station reads 3 degrees high at 08:00 UTC
+	for i := 0; i < 48; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		reference.Points = append(reference.Points, Point{Time: at, Value: 15})
+		value := 15.5
+		if at.Hour() == 8 {
+			value = 18
+		}
+		station.Points = append(station.Points, Point{Time: at, Value: value})
+	}
+	// Points without a reference value are ignored
+	station.Points = append(station.Points, Point{Time: start.Add(48*time.Hour + 30*time.Minute), Value: 50})
+
+	hours := BiasByHour(reference, station, time.UTC)
+	if len(hours) != 24 {
+		t.Fatalf("BiasByHour() returned %d hours, want 24", len(hours))
+	}
+	for _, h := range hours {
+		want := 0.5
+		if h.Hour == 8 {
+			want = 3
+		}
+		if h.Samples != 2 || math.Abs(h.Bias-want) > 1e-9 {
+			t.Errorf("hour %d = %+v, want bias %v", h.Hour, h, want)
+		}
+	}
+
+	// Hours are counted in the given location
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	for _, h := range BiasByHour(reference, station, loc) {
+		if h.Hour == 10 && math.Abs(h.Bias-3) > 1e-9 {
+			t.Errorf("hour 10 in UTC+2 = %+v, want bias 3", h)
+		}
+	}
+
+	if hours := BiasByHour(reference, Series{}, time.UTC); len(hours) != 0 {
+		t.Errorf("BiasByHour() of empty series = %+v", hours)
+	}
+}
+
+func hourly(start time.Time, values ...float64) Series {
+	s := Series{}
+	for i, v := range values {
+		s.Points = append(s.Points, Point{Time: start.Add(time.Duration(i) * time.Hour), Value: v})
+	}
+	return s
+}
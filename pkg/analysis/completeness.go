@@ -0,0 +1,172 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// gapFactor is the multiple of the expected interval above which the time
+// between two readings counts as a gap
+const gapFactor = 2
+
+// Gap is a time range without readings
+type Gap struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	DurationSec float64   `json:"duration_seconds"`
+	Missing     int       `json:"missing"`
+}
+
+// CompletenessReport summarises expected and received readings of a sensor
+type CompletenessReport struct {
+	ExpectedIntervalSec float64    `json:"expected_interval_seconds"`
+	Expected            int        `json:"expected"`
+	Received            int        `json:"received"`
+	Completeness        float64    `json:"completeness"`
+	FirstReading        *time.Time `json:"first_reading,omitempty"`
+	LastReading         *time.Time `json:"last_reading,omitempty"`
+	LargestGaps         []Gap      `json:"largest_gaps"`
+}
+
+// CompletenessCounter accumulates reading timestamps of one sensor in
+// ascending order without keeping them in memory
+type CompletenessCounter struct {
+	start, end time.Time
+	maxGaps    int
+
+	received int
+	first    time.Time
+	last     time.Time
+
+	// intervals counts the time between consecutive readings in whole
+	// seconds; the mode is the sensor's reporting interval
+	intervals map[int64]int
+
+	// candidates holds the largest deltas between consecutive readings;
+	// which of them are gaps depends on the interval passed to Result
+	candidates []Gap
+}
+
+// NewCompletenessCounter creates a counter for readings between start and end.
+// Result reports at most maxGaps of the largest gaps.
+func NewCompletenessCounter(start, end time.Time, maxGaps int) *CompletenessCounter {
+	return &CompletenessCounter{
+		start:     start,
+		end:       end,
+		maxGaps:   maxGaps,
+		intervals: make(map[int64]int),
+	}
+}
+
+// Add records a reading timestamp. Timestamps must be added in ascending order.
+func (c *CompletenessCounter) Add(t time.Time) {
+	if t.Before(c.start) || t.After(c.end) {
+		return
+	}
+
+	if c.received > 0 {
+		delta := t.Sub(c.last)
+		if seconds := int64(math.Round(delta.Seconds())); seconds > 0 {
+			c.intervals[seconds]++
+		}
+		c.addCandidate(c.last, t)
+	} else {
+		c.first = t
+	}
+
+	c.last = t
+	c.received++
+}
+
+// addCandidate keeps a delta as a possible gap. The list is pruned to the
+// largest deltas so memory stays bounded for long periods.
+func (c *CompletenessCounter) addCandidate(from, to time.Time) {
+	c.candidates = append(c.candidates, Gap{Start: from, End: to, DurationSec: to.Sub(from).Seconds()})
+
+	limit := c.maxGaps * 4
+	if limit < 64 {
+		limit = 64
+	}
+	if len(c.candidates) > limit*2 {
+		sortGaps(c.candidates)
+		c.candidates = c.candidates[:limit]
+	}
+}
+
+// EstimatedInterval returns the most common time between readings, or zero
+// when fewer than two readings were recorded
+func (c *CompletenessCounter) EstimatedInterval() time.Duration {
+	var best int64
+	var bestCount int
+	for seconds, count := range c.intervals {
+		if count > bestCount || (count == bestCount && seconds < best) {
+			best, bestCount = seconds, count
+		}
+	}
+	return time.Duration(best) * time.Second
+}
+
+// Result builds the report. An interval of zero uses the estimated interval.
+func (c *CompletenessCounter) Result(interval time.Duration) CompletenessReport {
+	if interval <= 0 {
+		interval = c.EstimatedInterval()
+	}
+
+	report := CompletenessReport{
+		Received:    c.received,
+		LargestGaps: []Gap{},
+	}
+	if c.received > 0 {
+		first, last := c.first, c.last
+		report.FirstReading = &first
+		report.LastReading = &last
+	}
+	if interval <= 0 {
+		return report
+	}
+
+	report.ExpectedIntervalSec = interval.Seconds()
+	report.Expected = int(c.end.Sub(c.start) / interval)
+
+	threshold := gapFactor * interval
+	addGap := func(g Gap, leading bool) {
+		duration := g.End.Sub(g.Start)
+		if duration < threshold {
+			return
+		}
+		g.DurationSec = duration.Seconds()
+		g.Missing = int(duration/interval) - 1
+		if leading {
+			// The start of the period is not a reading itself
+			g.Missing++
+		}
+		report.LargestGaps = append(report.LargestGaps, g)
+	}
+
+	if c.received == 0 {
+		addGap(Gap{Start: c.start, End: c.end}, true)
+	} else {
+		addGap(Gap{Start: c.start, End: c.first}, true)
+		addGap(Gap{Start: c.last, End: c.end}, false)
+		for _, g := range c.candidates {
+			addGap(g, false)
+		}
+	}
+	sortGaps(report.LargestGaps)
+	if len(report.LargestGaps) > c.maxGaps {
+		report.LargestGaps = report.LargestGaps[:c.maxGaps]
+	}
+
+	if report.Expected > 0 {
+		report.Completeness = math.Min(100, math.Round(float64(c.received)/float64(report.Expected)*10000)/100)
+	}
+	return report
+}
+
+// sortGaps orders gaps by duration, longest first
+func sortGaps(gaps []Gap) {
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return gaps[i].End.Sub(gaps[i].Start) > gaps[j].End.Sub(gaps[j].Start)
+	})
+}
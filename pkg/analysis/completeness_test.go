@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompletenessCounter_Complete(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	c := NewCompletenessCounter(start, end, 5)
+	for i := 0; i < 60; i++ {
+		c.Add(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	report := c.Result(0)
+
+	if report.ExpectedIntervalSec != 60 {
+		t.Errorf("Expected estimated interval of 60s, got %f", report.ExpectedIntervalSec)
+	}
+	if report.Expected != 60 || report.Received != 60 {
+		t.Errorf("Expected 60/60 readings, got %d/%d", report.Received, report.Expected)
+	}
+	if report.Completeness != 100 {
+		t.Errorf("Expected 100%% completeness, got %f", report.Completeness)
+	}
+	if len(report.LargestGaps) != 0 {
+		t.Errorf("Expected no gaps, got %v", report.LargestGaps)
+	}
+}
+
+func TestCompletenessCounter_Gaps(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	c := NewCompletenessCounter(start, end, 2)
+	for i := 0; i < 60; i++ {
+		// 10 minute outage and a 3 minute outage
+		if (i >= 10 && i < 20) || (i >= 40 && i < 43) {
+			continue
+		}
+		c.Add(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	report := c.Result(time.Minute)
+
+	if report.Received != 47 {
+		t.Errorf("Expected 47 readings, got %d", report.Received)
+	}
+	if len(report.LargestGaps) != 2 {
+		t.Fatalf("Expected 2 gaps, got %d", len(report.LargestGaps))
+	}
+
+	largest := report.LargestGaps[0]
+	if !largest.Start.Equal(start.Add(9*time.Minute)) || !largest.End.Equal(start.Add(20*time.Minute)) {
+		t.Errorf("Unexpected largest gap %s - %s", largest.Start, largest.End)
+	}
+	if largest.Missing != 10 {
+		t.Errorf("Expected 10 missing readings, got %d", largest.Missing)
+	}
+	if report.LargestGaps[1].Missing != 3 {
+		t.Errorf("Expected 3 missing readings in second gap, got %d", report.LargestGaps[1].Missing)
+	}
+}
+
+func TestCompletenessCounter_TrailingOutage(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	c := NewCompletenessCounter(start, end, 5)
+	for i := 0; i < 30; i++ {
+		c.Add(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	report := c.Result(0)
+
+	if report.Completeness != 50 {
+		t.Errorf("Expected 50%% completeness, got %f", report.Completeness)
+	}
+	if len(report.LargestGaps) != 1 || !report.LargestGaps[0].End.Equal(end) {
+		t.Fatalf("Expected trailing gap until end of period, got %v", report.LargestGaps)
+	}
+	if report.LargestGaps[0].Missing != 30 {
+		t.Errorf("Expected 30 missing readings, got %d", report.LargestGaps[0].Missing)
+	}
+}
+
+func TestCompletenessCounter_NoReadings(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCompletenessCounter(start, start.Add(time.Hour), 5)
+
+	report := c.Result(time.Minute)
+
+	if report.Expected != 60 || report.Received != 0 || report.Completeness != 0 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if len(report.LargestGaps) != 1 || report.LargestGaps[0].Missing != 60 {
+		t.Errorf("Expected the whole period as gap, got %v", report.LargestGaps)
+	}
+	if report.FirstReading != nil {
+		t.Error("Expected no first reading")
+	}
+
+	// Without readings the interval cannot be estimated
+	if c.Result(0).Expected != 0 {
+		t.Error("Expected no expectation without interval")
+	}
+}
+
+func TestCompletenessCounter_IgnoresOutOfRange(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCompletenessCounter(start, start.Add(time.Hour), 5)
+
+	c.Add(start.Add(-time.Minute))
+	c.Add(start.Add(2 * time.Hour))
+
+	if report := c.Result(time.Minute); report.Received != 0 {
+		t.Errorf("Expected out of range readings to be ignored, got %d", report.Received)
+	}
+}
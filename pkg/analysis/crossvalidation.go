@@ -0,0 +1,301 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Point is a single value of a time aligned series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a time ordered list of points of one sensor
+type Series struct {
+	SensorID uuid.UUID
+	Points   []Point
+}
+
+// CrossValidationOptions controls how co-located sensors are compared
+type CrossValidationOptions struct {
+	// Threshold is the absolute difference above which two sensors diverge
+	Threshold float64
+
+	// MinDivergence is the minimum length of a reported divergence period
+	MinDivergence time.Duration
+
+	// Interval is the spacing of the aligned series. Consecutive divergent
+	// points further apart than this start a new period.
+	Interval time.Duration
+
+	// Circular compares the values as directions in degrees, the short way
+	// around the circle: 350° and 10° differ by 20°, not 340°
+	Circular bool
+}
+
+// diff returns the difference of b to a, within ±180 for circular values
+func (o CrossValidationOptions) diff(a, b float64) float64 {
+	if o.Circular {
+		return math.Remainder(b-a, 360)
+	}
+	return b - a
+}
+
+// DivergencePeriod is a time range during which two sensors disagree
+type DivergencePeriod struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Samples     int       `json:"samples"`
+	MaxAbsDiff  float64   `json:"max_abs_diff"`
+	MeanDiff    float64   `json:"mean_diff"`
+	DurationSec float64   `json:"duration_seconds"`
+}
+
+// PairComparison holds the statistics of sensor B relative to sensor A.
+// A positive bias means sensor B reads higher than sensor A.
+type PairComparison struct {
+	SensorA     uuid.UUID          `json:"sensor_a"`
+	SensorB     uuid.UUID          `json:"sensor_b"`
+	Samples     int                `json:"samples"`
+	Bias        float64            `json:"bias"`
+	MeanAbsDiff float64            `json:"mean_abs_diff"`
+	RMSE        float64            `json:"rmse"`
+	MaxAbsDiff  float64            `json:"max_abs_diff"`
+	Correlation *float64           `json:"correlation,omitempty"`
+	Divergences []DivergencePeriod `json:"divergences"`
+}
+
+// SensorConsensus describes how far a sensor deviates from the median of its
+// group. It is only computed for groups of three or more sensors, where the
+// median identifies the outlier; the sensor with the lowest deviation is the
+// most trustworthy reference.
+type SensorConsensus struct {
+	SensorID         uuid.UUID `json:"sensor_id"`
+	Samples          int       `json:"samples"`
+	MeanDeviation    float64   `json:"mean_deviation"`
+	MeanAbsDeviation float64   `json:"mean_abs_deviation"`
+}
+
+// CrossValidationReport is the result of comparing a group of sensors
+type CrossValidationReport struct {
+	Pairs     []PairComparison  `json:"pairs"`
+	Consensus []SensorConsensus `json:"consensus,omitempty"`
+}
+
+// CrossValidate compares every pair of series on their common timestamps and,
+// for groups of three or more, each series against the group median.
+func CrossValidate(series []Series, opts CrossValidationOptions) CrossValidationReport {
+	report := CrossValidationReport{Pairs: []PairComparison{}}
+
+	for i := 0; i < len(series); i++ {
+		for j := i + 1; j < len(series); j++ {
+			report.Pairs = append(report.Pairs, ComparePair(series[i], series[j], opts))
+		}
+	}
+
+	if len(series) >= 3 {
+		report.Consensus = consensus(series, opts)
+	}
+
+	return report
+}
+
+// ComparePair compares two series on their common timestamps
+func ComparePair(a, b Series, opts CrossValidationOptions) PairComparison {
+	result := PairComparison{
+		SensorA:     a.SensorID,
+		SensorB:     b.SensorID,
+		Divergences: []DivergencePeriod{},
+	}
+
+	byTime := make(map[int64]float64, len(a.Points))
+	for _, p := range a.Points {
+		byTime[p.Time.UnixNano()] = p.Value
+	}
+
+	var (
+		xs, ys []float64
+		times  []time.Time
+	)
+	for _, p := range b.Points {
+		if va, ok := byTime[p.Time.UnixNano()]; ok {
+			xs = append(xs, va)
+			ys = append(ys, p.Value)
+			times = append(times, p.Time)
+		}
+	}
+
+	result.Samples = len(xs)
+	if result.Samples == 0 {
+		return result
+	}
+
+	var sumDiff, sumAbs, sumSq float64
+	for i := range xs {
+		d := opts.diff(xs[i], ys[i])
+		// Directions are unwrapped next to a, so the correlation isn't
+		// broken by values crossing north
+		ys[i] = xs[i] + d
+		sumDiff += d
+		sumAbs += math.Abs(d)
+		sumSq += d * d
+		if math.Abs(d) > result.MaxAbsDiff {
+			result.MaxAbsDiff = math.Abs(d)
+		}
+	}
+	n := float64(result.Samples)
+	result.Bias = sumDiff / n
+	result.MeanAbsDiff = sumAbs / n
+	result.RMSE = math.Sqrt(sumSq / n)
+	result.Correlation = pearson(xs, ys)
+
+	if opts.Threshold > 0 {
+		result.Divergences = divergences(times, xs, ys, opts)
+	}
+
+	return result
+}
+
+// divergences groups consecutive points whose difference exceeds the threshold
+func divergences(times []time.Time, xs, ys []float64, opts CrossValidationOptions) []DivergencePeriod {
+	periods := []DivergencePeriod{}
+
+	var current *DivergencePeriod
+	var sum float64
+	var last time.Time
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.MeanDiff = sum / float64(current.Samples)
+		duration := current.End.Sub(current.Start) + opts.Interval
+		current.DurationSec = duration.Seconds()
+		if duration >= opts.MinDivergence {
+			periods = append(periods, *current)
+		}
+		current = nil
+		sum = 0
+	}
+
+	for i, t := range times {
+		d := opts.diff(xs[i], ys[i])
+		if math.Abs(d) <= opts.Threshold {
+			flush()
+			continue
+		}
+
+		if current != nil && opts.Interval > 0 && t.Sub(last) > opts.Interval {
+			flush()
+		}
+		if current == nil {
+			current = &DivergencePeriod{Start: t}
+		}
+		current.End = t
+		current.Samples++
+		sum += d
+		if math.Abs(d) > current.MaxAbsDiff {
+			current.MaxAbsDiff = math.Abs(d)
+		}
+		last = t
+	}
+	flush()
+
+	return periods
+}
+
+// consensus computes the deviation of every series from the group median.
+// Circular values are unwrapped next to the first value of their time
+// before taking the median.
+func consensus(series []Series, opts CrossValidationOptions) []SensorConsensus {
+	values := make(map[int64][]float64)
+	for _, s := range series {
+		for _, p := range s.Points {
+			v := p.Value
+			if first := values[p.Time.UnixNano()]; len(first) > 0 {
+				v = first[0] + opts.diff(first[0], v)
+			}
+			values[p.Time.UnixNano()] = append(values[p.Time.UnixNano()], v)
+		}
+	}
+
+	medians := make(map[int64]float64, len(values))
+	for t, v := range values {
+		// A median of two values is their mean and cannot single out an outlier
+		if len(v) >= 3 {
+			medians[t] = median(v)
+		}
+	}
+
+	result := make([]SensorConsensus, 0, len(series))
+	for _, s := range series {
+		c := SensorConsensus{SensorID: s.SensorID}
+		var sum, sumAbs float64
+		for _, p := range s.Points {
+			m, ok := medians[p.Time.UnixNano()]
+			if !ok {
+				continue
+			}
+			d := opts.diff(m, p.Value)
+			sum += d
+			sumAbs += math.Abs(d)
+			c.Samples++
+		}
+		if c.Samples > 0 {
+			c.MeanDeviation = sum / float64(c.Samples)
+			c.MeanAbsDeviation = sumAbs / float64(c.Samples)
+		}
+		result = append(result, c)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].MeanAbsDeviation < result[j].MeanAbsDeviation
+	})
+	return result
+}
+
+// pearson returns the correlation coefficient of xs and ys, or nil when it is
+// undefined because one of the series is constant
+func pearson(xs, ys []float64) *float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return nil
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return nil
+	}
+
+	r := cov / math.Sqrt(varX*varY)
+	return &r
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func makeSeries(start time.Time, interval time.Duration, values ...float64) Series {
+	s := Series{SensorID: uuid.New()}
+	for i, v := range values {
+		s.Points = append(s.Points, Point{Time: start.Add(time.Duration(i) * interval), Value: v})
+	}
+	return s
+}
+
+func TestComparePair_Statistics(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := makeSeries(start, time.Hour, 10, 11, 12, 13)
+	b := makeSeries(start, time.Hour, 10.5, 11.5, 12.5, 13.5)
+
+	result := ComparePair(a, b, CrossValidationOptions{})
+
+	if result.Samples != 4 {
+		t.Fatalf("Expected 4 samples, got %d", result.Samples)
+	}
+	if math.Abs(result.Bias-0.5) > 1e-9 {
+		t.Errorf("Expected bias 0.5, got %f", result.Bias)
+	}
+	if math.Abs(result.RMSE-0.5) > 1e-9 {
+		t.Errorf("Expected RMSE 0.5, got %f", result.RMSE)
+	}
+	if result.Correlation == nil || math.Abs(*result.Correlation-1) > 1e-9 {
+		t.Errorf("Expected correlation 1, got %v", result.Correlation)
+	}
+}
+
+func TestComparePair_OnlyCommonTimestamps(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := makeSeries(start, time.Hour, 1, 2, 3)
+	b := makeSeries(start.Add(2*time.Hour), time.Hour, 3, 4)
+
+	result := ComparePair(a, b, CrossValidationOptions{})
+
+	if result.Samples != 1 {
+		t.Errorf("Expected 1 common sample, got %d", result.Samples)
+	}
+	if result.Correlation != nil {
+		t.Errorf("Expected undefined correlation for a single sample, got %f", *result.Correlation)
+	}
+}
+
+func TestComparePair_Divergences(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := makeSeries(start, 15*time.Minute, 10, 10, 10, 10, 10, 10, 10, 10)
+	b := makeSeries(start, 15*time.Minute, 10, 13, 14, 13, 10, 13, 10, 10)
+
+	opts := CrossValidationOptions{Threshold: 2, MinDivergence: 30 * time.Minute, Interval: 15 * time.Minute}
+	result := ComparePair(a, b, opts)
+
+	if len(result.Divergences) != 1 {
+		t.Fatalf("Expected 1 divergence period, got %d", len(result.Divergences))
+	}
+
+	d := result.Divergences[0]
+	if !d.Start.Equal(start.Add(15*time.Minute)) || !d.End.Equal(start.Add(45*time.Minute)) {
+		t.Errorf("Unexpected period %s - %s", d.Start, d.End)
+	}
+	if d.Samples != 3 || d.MaxAbsDiff != 4 {
+		t.Errorf("Expected 3 samples with max diff 4, got %d and %f", d.Samples, d.MaxAbsDiff)
+	}
+}
+
+func TestComparePair_Circular(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	a := makeSeries(start, 15*time.Minute, 350, 355, 0, 5)
+	b := makeSeries(start, 15*time.Minute, 10, 5, 350, 15)
+
+	opts := CrossValidationOptions{Threshold: 30, MinDivergence: 15 * time.Minute, Interval: 15 * time.Minute, Circular: true}
+	result := ComparePair(a, b, opts)
+
+	if math.Abs(result.Bias-7.5) > 1e-9 || math.Abs(result.MaxAbsDiff-20) > 1e-9 {
+		t.Errorf("Expected bias 7.5 and max diff 20 around north, got %f and %f", result.Bias, result.MaxAbsDiff)
+	}
+	if len(result.Divergences) != 0 {
+		t.Errorf("Expected no divergence, got %v", result.Divergences)
+	}
+	if result.Correlation == nil || *result.Correlation < 0 {
+		t.Errorf("Expected a positive correlation of the unwrapped directions, got %v", result.Correlation)
+	}
+}
+
+func TestCrossValidate_CircularConsensus(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	good1 := makeSeries(start, time.Hour, 358, 2)
+	good2 := makeSeries(start, time.Hour, 2, 358)
+	faulty := makeSeries(start, time.Hour, 90, 90)
+
+	report := CrossValidate([]Series{faulty, good1, good2}, CrossValidationOptions{Circular: true})
+
+	if last := report.Consensus[2]; last.SensorID != faulty.SensorID {
+		t.Errorf("Expected faulty sensor to deviate most, got %s", last.SensorID)
+	}
+	for _, c := range report.Consensus[:2] {
+		if c.MeanAbsDeviation > 4+1e-9 {
+			t.Errorf("Expected sensors around north to deviate at most 4°, got %f", c.MeanAbsDeviation)
+		}
+	}
+}
+
+func TestCrossValidate_Consensus(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	good1 := makeSeries(start, time.Hour, 10, 11, 12)
+	good2 := makeSeries(start, time.Hour, 10.1, 11.1, 12.1)
+	faulty := makeSeries(start, time.Hour, 13, 14, 15)
+
+	report := CrossValidate([]Series{faulty, good1, good2}, CrossValidationOptions{})
+
+	if len(report.Pairs) != 3 {
+		t.Fatalf("Expected 3 pairs, got %d", len(report.Pairs))
+	}
+	if len(report.Consensus) != 3 {
+		t.Fatalf("Expected consensus for 3 sensors, got %d", len(report.Consensus))
+	}
+	if last := report.Consensus[2]; last.SensorID != faulty.SensorID {
+		t.Errorf("Expected faulty sensor to deviate most, got %s", last.SensorID)
+	}
+}
+
+func TestCrossValidate_NoConsensusForPairs(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	report := CrossValidate([]Series{
+		makeSeries(start, time.Hour, 1, 2),
+		makeSeries(start, time.Hour, 1, 2),
+	}, CrossValidationOptions{})
+
+	if report.Consensus != nil {
+		t.Errorf("Expected no consensus for two sensors, got %v", report.Consensus)
+	}
+}
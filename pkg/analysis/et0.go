@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"math"
+	"time"
+)
+
+// Methods of the reference evapotranspiration
+const (
+	// ET0PenmanMonteith is the FAO-56 Penman-Monteith equation, used when
+	// humidity, wind and solar radiation were measured
+	ET0PenmanMonteith = "penman-monteith"
+	// ET0Hargreaves only needs the temperature range
+	ET0Hargreaves = "hargreaves"
+)
+
+// stefanBoltzmann is the Stefan-Boltzmann constant in MJ/K⁴/m²/day
+const stefanBoltzmann = 4.903e-9
+
+// WeatherDay is the daily weather an evapotranspiration is computed from.
+// Nil values were not measured.
+type WeatherDay struct {
+	Day         time.Time
+	TempMin     float64
+	TempMax     float64
+	HumidityMin *float64
+	HumidityMax *float64
+	// Wind is the mean wind speed at 2 m in m/s
+	Wind *float64
+	// Solar is the solar radiation in MJ/m²/day
+	Solar *float64
+	// Pressure is the mean absolute pressure in kPa
+	Pressure *float64
+	// Rain in mm
+	Rain float64
+}
+
+// ExtraterrestrialRadiation returns the radiation at the top of the
+// atmosphere in MJ/m²/day for a latitude in degrees and a day of the year
+// (FAO-56 equation 21)
+func ExtraterrestrialRadiation(latitude float64, dayOfYear int) float64 {
+	phi := latitude * math.Pi / 180
+	j := float64(dayOfYear)
+	dr := 1 + 0.033*math.Cos(2*math.Pi/365*j)
+	delta := 0.409 * math.Sin(2*math.Pi/365*j-1.39)
+	// Polar day and night clamp the sunset hour angle
+	ws := math.Acos(math.Max(-1, math.Min(1, -math.Tan(phi)*math.Tan(delta))))
+	return 24 * 60 / math.Pi * 0.0820 * dr * (ws*math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Sin(ws))
+}
+
+// WindAt2m converts a wind speed measured at a height in m to 2 m
+// (FAO-56 equation 47)
+func WindAt2m(speed, height float64) float64 {
+	if height == 2 {
+		return speed
+	}
+	return speed * 4.87 / math.Log(67.8*height-5.42)
+}
+
+// ET0 returns the reference evapotranspiration of a day in mm and the
+// method it was computed with. Days without humidity, wind or solar
+// radiation fall back to Hargreaves.
+func ET0(day WeatherDay, latitude, elevation float64) (float64, string) {
+	ra := ExtraterrestrialRadiation(latitude, day.Day.YearDay())
+	tmean := (day.TempMax + day.TempMin) / 2
+
+	if day.HumidityMin == nil || day.HumidityMax == nil || day.Wind == nil || day.Solar == nil {
+		et0 := 0.0023 * (tmean + 17.8) * math.Sqrt(math.Max(0, day.TempMax-day.TempMin)) * 0.408 * ra
+		return math.Max(0, et0), ET0Hargreaves
+	}
+
+	pressure := 101.3 * math.Pow((293-0.0065*elevation)/293, 5.26)
+	if day.Pressure != nil {
+		pressure = *day.Pressure
+	}
+	gamma := 0.000665 * pressure
+
+	esMin, esMax := saturationVaporPressure(day.TempMin), saturationVaporPressure(day.TempMax)
+	es := (esMin + esMax) / 2
+	ea := (esMin**day.HumidityMax/100 + esMax**day.HumidityMin/100) / 2
+	slope := 4098 * saturationVaporPressure(tmean) / math.Pow(tmean+237.3, 2)
+
+	// Net radiation, the soil heat flux of a day is negligible
+	rs := *day.Solar
+	rso := (0.75 + 2e-5*elevation) * ra
+	relative := 1.0
+	if rso > 0 {
+		relative = math.Min(1, rs/rso)
+	}
+	rns := 0.77 * rs
+	rnl := stefanBoltzmann * (math.Pow(day.TempMax+273.16, 4) + math.Pow(day.TempMin+273.16, 4)) / 2 *
+		(0.34 - 0.14*math.Sqrt(ea)) * (1.35*relative - 0.35)
+	rn := rns - rnl
+
+	u2 := *day.Wind
+	et0 := (0.408*slope*rn + gamma*900/(tmean+273)*u2*(es-ea)) / (slope + gamma*(1+0.34*u2))
+	return math.Max(0, et0), ET0PenmanMonteith
+}
+
+// saturationVaporPressure returns the saturation vapour pressure in kPa at
+// a temperature in °C
+func saturationVaporPressure(temp float64) float64 {
+	return 0.6108 * math.Exp(17.27*temp/(temp+237.3))
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import "time"
+
+// Default freeze/thaw settings in °C
+const (
+	DefaultFreezeThreshold  = 0
+	DefaultFreezeHysteresis = 0.5
+)
+
+// freezeState is the state of a freeze/thaw counter
+type freezeState int
+
+const (
+	stateUnknown freezeState = iota
+	stateFrozen
+	stateThawed
+)
+
+// FreezeThawDay counts the freeze/thaw transitions of one calendar day
+type FreezeThawDay struct {
+	Day     string  `json:"day"`
+	Freezes int     `json:"freezes"`
+	Thaws   int     `json:"thaws"`
+	Cycles  int     `json:"cycles"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// FreezeThawCounter counts the temperature crossings of a threshold,
+// typically 0 °C. A crossing only counts once the temperature moved the
+// hysteresis beyond the threshold, so values hovering around it don't add
+// transitions. A cycle is a thaw following a counted freeze and counts on
+// the day of the thaw. Temperatures must be added in ascending order.
+type FreezeThawCounter struct {
+	threshold  float64
+	hysteresis float64
+	loc        *time.Location
+
+	state freezeState
+	// froze is set after a counted freeze, its thaw completes a cycle
+	froze bool
+	days  []FreezeThawDay
+}
+
+// NewFreezeThawCounter creates a counter grouping days in loc
+func NewFreezeThawCounter(threshold, hysteresis float64, loc *time.Location) *FreezeThawCounter {
+	return &FreezeThawCounter{threshold: threshold, hysteresis: hysteresis, loc: loc}
+}
+
+// Add records a temperature
+func (c *FreezeThawCounter) Add(t time.Time, value float64) {
+	day := t.In(c.loc).Format(time.DateOnly)
+	if len(c.days) == 0 || c.days[len(c.days)-1].Day != day {
+		c.days = append(c.days, FreezeThawDay{Day: day, Min: value, Max: value})
+	}
+	d := &c.days[len(c.days)-1]
+	d.Min = min(d.Min, value)
+	d.Max = max(d.Max, value)
+
+	switch {
+	case value <= c.threshold-c.hysteresis && c.state != stateFrozen:
+		if c.state == stateThawed {
+			d.Freezes++
+			c.froze = true
+		}
+		c.state = stateFrozen
+	case value >= c.threshold+c.hysteresis && c.state != stateThawed:
+		if c.state == stateFrozen {
+			d.Thaws++
+		}
+		if c.froze {
+			d.Cycles++
+			c.froze = false
+		}
+		c.state = stateThawed
+	}
+}
+
+// Days returns the counts of all days with temperatures in order
+func (c *FreezeThawCounter) Days() []FreezeThawDay {
+	days := make([]FreezeThawDay, len(c.days))
+	copy(days, c.days)
+	return days
+}
@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezeThawCounter(t *testing.T) {
+	start := time.Date(2026, 1, 10, 18, 0, 0, 0, time.UTC)
+	// Evening of day one and day two, hourly
+	temperatures := []float64{
+		// Day 1, 18:00 to 23:00: starts thawed, freezes at night
+		2, 1, 0.2, -0.2, -0.6, -1,
+		// Day 2, 00:00 to 11:00: hovers around 0 °C, thaws at noon, freezes again
+		-0.3, 0.3, -0.4, 0.4, -2, -3, -1, 0.1, 0.6, 2, -0.1, -0.7,
+	}
+
+	c := NewFreezeThawCounter(DefaultFreezeThreshold, DefaultFreezeHysteresis, time.UTC)
+	for i, v := range temperatures {
+		c.Add(start.Add(time.Duration(i)*time.Hour), v)
+	}
+
+	days := c.Days()
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	want := []FreezeThawDay{
+		{Day: "2026-01-10", Freezes: 1, Thaws: 0, Cycles: 0, Min: -1, Max: 2},
+		{Day: "2026-01-11", Freezes: 1, Thaws: 1, Cycles: 1, Min: -3, Max: 2},
+	}
+	for i := range want {
+		if days[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, days[i], want[i])
+		}
+	}
+}
+
+func TestFreezeThawCounter_StartsFrozen(t *testing.T) {
+	start := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	c := NewFreezeThawCounter(DefaultFreezeThreshold, DefaultFreezeHysteresis, time.UTC)
+	for i, v := range []float64{-3, -1, 1, 3} {
+		c.Add(start.Add(time.Duration(i)*time.Hour), v)
+	}
+
+	// The thaw counts, but without an observed freeze it is no cycle
+	days := c.Days()
+	if len(days) != 1 || days[0].Freezes != 0 || days[0].Thaws != 1 || days[0].Cycles != 0 {
+		t.Errorf("Days() = %+v, want one thaw without cycle", days)
+	}
+}
+
+func TestFreezeThawCounter_Location(t *testing.T) {
+	vienna, err := time.LoadLocation("Europe/Vienna")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	c := NewFreezeThawCounter(DefaultFreezeThreshold, DefaultFreezeHysteresis, vienna)
+	// 23:30 UTC is already the next day in Vienna
+	c.Add(time.Date(2026, 1, 10, 23, 30, 0, 0, time.UTC), 1)
+
+	if days := c.Days(); len(days) != 1 || days[0].Day != "2026-01-11" {
+		t.Errorf("Days() = %+v, want 2026-01-11", days)
+	}
+}
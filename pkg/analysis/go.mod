@@ -0,0 +1,5 @@
+module github.com/sguter90/weathermaestro/pkg/analysis
+
+go 1.25
+
+require github.com/google/uuid v1.6.0
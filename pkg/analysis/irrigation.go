@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"math"
+	"time"
+)
+
+// IrrigationSettings configures the water balance of IrrigationSchedule
+type IrrigationSettings struct {
+	Latitude  float64
+	Elevation float64
+	// CropCoefficients are the monthly crop coefficients, January first
+	CropCoefficients [12]float64
+	// RainEfficiency is the part of the rain that reaches the roots
+	RainEfficiency float64
+	// Threshold is the deficit in mm from which irrigation is advised
+	Threshold float64
+}
+
+// IrrigationDay is the water balance of one day
+type IrrigationDay struct {
+	Day             string  `json:"day"`
+	Method          string  `json:"method"`
+	ET0             float64 `json:"et0"`
+	CropCoefficient float64 `json:"crop_coefficient"`
+	ETc             float64 `json:"etc"`
+	Rain            float64 `json:"rain"`
+	EffectiveRain   float64 `json:"effective_rain"`
+	// Deficit is the water missing at the end of the day before irrigating
+	Deficit float64 `json:"deficit"`
+	// Irrigation is the advised amount in mm for the following morning
+	Irrigation float64 `json:"irrigation"`
+}
+
+// IrrigationSchedule computes the daily water balance of consecutive days:
+// the crop evapotranspiration (ET0 times the crop coefficient) adds to the
+// deficit, effective rain reduces it. Once the deficit reaches the
+// threshold, irrigating it is advised and it is assumed to be applied.
+func IrrigationSchedule(days []WeatherDay, settings IrrigationSettings) []IrrigationDay {
+	result := make([]IrrigationDay, 0, len(days))
+	deficit := 0.0
+	for _, d := range days {
+		et0, method := ET0(d, settings.Latitude, settings.Elevation)
+		kc := settings.CropCoefficients[d.Day.Month()-time.January]
+		day := IrrigationDay{
+			Day:             d.Day.Format(time.DateOnly),
+			Method:          method,
+			ET0:             round(et0, 2),
+			CropCoefficient: kc,
+			ETc:             round(et0*kc, 2),
+			Rain:            round(d.Rain, 2),
+			EffectiveRain:   round(d.Rain*settings.RainEfficiency, 2),
+		}
+
+		deficit = math.Max(0, deficit+et0*kc-d.Rain*settings.RainEfficiency)
+		day.Deficit = round(deficit, 1)
+		if day.Deficit > 0 && deficit >= settings.Threshold {
+			day.Irrigation = day.Deficit
+			deficit = 0
+		}
+		result = append(result, day)
+	}
+	return result
+}
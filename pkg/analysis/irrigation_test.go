@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestExtraterrestrialRadiation(t *testing.T) {
+	// FAO-56 example 8: 20°S on 3 September
+	if got := ExtraterrestrialRadiation(-20, 246); math.Abs(got-32.2) > 0.1 {
+		t.Errorf("ExtraterrestrialRadiation() = %.2f, want 32.2", got)
+	}
+	// Polar night
+	if got := ExtraterrestrialRadiation(80, 355); got > 0.1 {
+		t.Errorf("ExtraterrestrialRadiation() = %.2f, want 0 in the polar night", got)
+	}
+}
+
+func TestWindAt2m(t *testing.T) {
+	// FAO-56 example 14: 3.2 m/s at 10 m
+	if got := WindAt2m(3.2, 10); math.Abs(got-2.4) > 0.05 {
+		t.Errorf("WindAt2m() = %.2f, want 2.4", got)
+	}
+	if got := WindAt2m(3.2, 2); got != 3.2 {
+		t.Errorf("WindAt2m() = %.2f, want 3.2", got)
+	}
+}
+
+func TestET0(t *testing.T) {
+	// FAO-56 example 18: Brussels on 6 July at 100 m
+	brussels := WeatherDay{
+		Day:         time.Date(2026, 7, 6, 0, 0, 0, 0, time.UTC),
+		TempMin:     12.3,
+		TempMax:     21.5,
+		HumidityMin: ptr(63),
+		HumidityMax: ptr(84),
+		Wind:        ptr(2.078),
+		Solar:       ptr(22.07),
+		Pressure:    ptr(100.1),
+	}
+	latitude := 50 + 48.0/60
+
+	et0, method := ET0(brussels, latitude, 100)
+	if method != ET0PenmanMonteith || math.Abs(et0-3.9) > 0.1 {
+		t.Errorf("ET0() = %.2f (%s), want 3.9 (penman-monteith)", et0, method)
+	}
+
+	// Without humidity, wind and radiation only the temperatures are used
+	brussels.Wind = nil
+	et0, method = ET0(brussels, latitude, 100)
+	if method != ET0Hargreaves || et0 < 3 || et0 > 5 {
+		t.Errorf("ET0() = %.2f (%s), want about 4 (hargreaves)", et0, method)
+	}
+}
+
+func TestIrrigationSchedule(t *testing.T) {
+	settings := IrrigationSettings{Latitude: 48, RainEfficiency: 0.8, Threshold: 8}
+	for i := range settings.CropCoefficients {
+		settings.CropCoefficients[i] = 1
+	}
+	start := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	day := func(i int, rain float64) WeatherDay {
+		return WeatherDay{Day: start.AddDate(0, 0, i), TempMin: 15, TempMax: 28, Rain: rain}
+	}
+
+	schedule := IrrigationSchedule([]WeatherDay{day(0, 0), day(1, 0), day(2, 20), day(3, 0)}, settings)
+	if len(schedule) != 4 {
+		t.Fatalf("got %d days, want 4", len(schedule))
+	}
+
+	// About 5.5 mm evapotranspiration per day: the deficit reaches the
+	// threshold after two dry days, rain then covers the next day
+	if schedule[0].Irrigation != 0 || schedule[0].Deficit <= 0 {
+		t.Errorf("day 0 = %+v, want a deficit below the threshold", schedule[0])
+	}
+	if schedule[1].Irrigation < 8 {
+		t.Errorf("day 1 = %+v, want irrigation", schedule[1])
+	}
+	if schedule[2].Deficit != 0 || schedule[2].Irrigation != 0 || schedule[2].EffectiveRain != 16 {
+		t.Errorf("day 2 = %+v, want the rain to cover the deficit", schedule[2])
+	}
+	if schedule[3].Irrigation != 0 || schedule[3].Deficit <= 0 {
+		t.Errorf("day 3 = %+v, want a new deficit", schedule[3])
+	}
+}
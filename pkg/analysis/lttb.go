@@ -0,0 +1,62 @@
+package analysis
+
+import "math"
+
+// LTTB downsamples a time ordered series to at most threshold points with
+// the Largest-Triangle-Three-Buckets algorithm and returns the indices of
+// the kept points. The first and last points are always kept; series that
+// are already small enough are returned completely.
+func LTTB(points []Point, threshold int) []int {
+	n := len(points)
+	if threshold >= n || threshold < 3 {
+		indices := make([]int, n)
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	// Times relative to the first point keep the areas precise
+	origin := points[0].Time
+	x := func(i int) float64 { return points[i].Time.Sub(origin).Seconds() }
+
+	every := float64(n-2) / float64(threshold-2)
+	indices := make([]int, 0, threshold)
+	indices = append(indices, 0)
+	a := 0
+
+	for i := 0; i < threshold-2; i++ {
+		// Average of the next bucket is the third point of the triangle
+		avgStart := int(math.Floor(float64(i+1)*every)) + 1
+		avgEnd := int(math.Floor(float64(i+2)*every)) + 1
+		if avgEnd > n {
+			avgEnd = n
+		}
+		var avgX, avgY float64
+		for j := avgStart; j < avgEnd; j++ {
+			avgX += x(j)
+			avgY += points[j].Value
+		}
+		count := float64(avgEnd - avgStart)
+		avgX /= count
+		avgY /= count
+
+		// Keep the point of the current bucket with the largest triangle
+		start := int(math.Floor(float64(i)*every)) + 1
+		end := int(math.Floor(float64(i+1)*every)) + 1
+		ax, ay := x(a), points[a].Value
+		maxArea := -1.0
+		next := start
+		for j := start; j < end; j++ {
+			area := math.Abs((ax-avgX)*(points[j].Value-ay) - (ax-x(j))*(avgY-ay))
+			if area > maxArea {
+				maxArea = area
+				next = j
+			}
+		}
+		indices = append(indices, next)
+		a = next
+	}
+
+	return append(indices, n-1)
+}
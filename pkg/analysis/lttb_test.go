@@ -0,0 +1,46 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLTTB_KeepsSmallSeries(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := []Point{{start, 1}, {start.Add(time.Minute), 2}, {start.Add(2 * time.Minute), 3}}
+
+	indices := LTTB(points, 10)
+	if len(indices) != 3 {
+		t.Fatalf("Expected all 3 points, got %v", indices)
+	}
+}
+
+func TestLTTB_KeepsPeaks(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]Point, 1000)
+	for i := range points {
+		points[i] = Point{Time: start.Add(time.Duration(i) * time.Minute), Value: 10}
+	}
+	points[500].Value = 30
+
+	indices := LTTB(points, 50)
+	if len(indices) != 50 {
+		t.Fatalf("Expected 50 points, got %d", len(indices))
+	}
+	if indices[0] != 0 || indices[len(indices)-1] != 999 {
+		t.Errorf("Expected first and last point to be kept, got %d and %d", indices[0], indices[len(indices)-1])
+	}
+
+	peak := false
+	for i, idx := range indices {
+		if i > 0 && idx <= indices[i-1] {
+			t.Fatalf("Indices not ascending: %v", indices)
+		}
+		if idx == 500 {
+			peak = true
+		}
+	}
+	if !peak {
+		t.Error("Expected the peak to be kept")
+	}
+}
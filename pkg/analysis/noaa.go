@@ -0,0 +1,237 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Base temperatures of heating and cooling degree days in °C (65 °F)
+const (
+	DefaultHeatBase = 18.3
+	DefaultCoolBase = 18.3
+)
+
+// NOAADay holds the summary values of one day. Nil values are missing.
+type NOAADay struct {
+	Day          int
+	MeanTemp     *float64
+	HighTemp     *float64
+	HighTime     time.Time
+	LowTemp      *float64
+	LowTime      time.Time
+	Rain         *float64
+	MeanWind     *float64
+	HighWind     *float64
+	HighWindTime time.Time
+	DomDir       *float64
+	// Snow is the manually observed snowfall of the day
+	Snow *float64
+}
+
+// NOAAMonth is a monthly climatological summary in the layout of the NOAA
+// reports known from WeeWX and other weather station software
+type NOAAMonth struct {
+	Station  string
+	Year     int
+	Month    time.Month
+	TempUnit string
+	RainUnit string
+	WindUnit string
+	SnowUnit string
+	HeatBase float64
+	CoolBase float64
+	Days     []NOAADay
+}
+
+// noaaRule separates the header, days and totals
+var noaaRule = strings.Repeat("-", 87)
+
+// Format renders the report as fixed-width text
+func (m *NOAAMonth) Format() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "                   MONTHLY CLIMATOLOGICAL SUMMARY for %s %d\n\n", m.Month.String()[:3], m.Year)
+	fmt.Fprintf(&b, "NAME: %s\n\n", m.Station)
+	fmt.Fprintf(&b, "TEMPERATURE (%s), RAIN (%s), WIND SPEED (%s)\n\n", m.TempUnit, m.RainUnit, m.WindUnit)
+	writeNOAARow(&b, "", "", "", "", "", "", "HEAT", "COOL", "", "AVG", "", "", "")
+	writeNOAARow(&b, "", "MEAN", "", "", "", "", "DEG", "DEG", "", "WIND", "", "", "DOM")
+	writeNOAARow(&b, "DAY", "TEMP", "HIGH", "TIME", "LOW", "TIME", "DAYS", "DAYS", "RAIN", "SPEED", "HIGH", "TIME", "DIR")
+	b.WriteString(noaaRule + "\n")
+
+	var (
+		meanTemps, meanWinds   []float64
+		dirSin, dirCos         float64
+		dirCount               int
+		heatTotal, coolTotal   float64
+		rainTotal              float64
+		hasDegreeDays, hasRain bool
+		high, low, highWind    *float64
+		highDay, lowDay        int
+		highWindDay            int
+	)
+
+	for _, d := range m.Days {
+		heat, cool := m.degreeDays(d.MeanTemp)
+
+		writeNOAARow(&b,
+			fmt.Sprintf("%d", d.Day),
+			fmtValue(d.MeanTemp, 1),
+			fmtValue(d.HighTemp, 1),
+			fmtTime(d.HighTemp, d.HighTime),
+			fmtValue(d.LowTemp, 1),
+			fmtTime(d.LowTemp, d.LowTime),
+			fmtValue(heat, 1),
+			fmtValue(cool, 1),
+			fmtValue(d.Rain, 1),
+			fmtValue(d.MeanWind, 1),
+			fmtValue(d.HighWind, 1),
+			fmtTime(d.HighWind, d.HighWindTime),
+			fmtValue(d.DomDir, 0),
+		)
+
+		if d.MeanTemp != nil {
+			meanTemps = append(meanTemps, *d.MeanTemp)
+			heatTotal += *heat
+			coolTotal += *cool
+			hasDegreeDays = true
+		}
+		if d.HighTemp != nil && (high == nil || *d.HighTemp > *high) {
+			high, highDay = d.HighTemp, d.Day
+		}
+		if d.LowTemp != nil && (low == nil || *d.LowTemp < *low) {
+			low, lowDay = d.LowTemp, d.Day
+		}
+		if d.Rain != nil {
+			rainTotal += *d.Rain
+			hasRain = true
+		}
+		if d.MeanWind != nil {
+			meanWinds = append(meanWinds, *d.MeanWind)
+		}
+		if d.HighWind != nil && (highWind == nil || *d.HighWind > *highWind) {
+			highWind, highWindDay = d.HighWind, d.Day
+		}
+		if d.DomDir != nil {
+			rad := *d.DomDir * math.Pi / 180
+			dirSin += math.Sin(rad)
+			dirCos += math.Cos(rad)
+			dirCount++
+		}
+	}
+
+	b.WriteString(noaaRule + "\n")
+
+	var heat, cool, rain, dir *float64
+	if hasDegreeDays {
+		heat, cool = &heatTotal, &coolTotal
+	}
+	if hasRain {
+		rain = &rainTotal
+	}
+	if dirCount > 0 {
+		d := math.Mod(math.Atan2(dirSin, dirCos)*180/math.Pi+360, 360)
+		dir = &d
+	}
+
+	writeNOAARow(&b,
+		"",
+		fmtValue(mean(meanTemps), 1),
+		fmtValue(high, 1),
+		fmtDay(high, highDay),
+		fmtValue(low, 1),
+		fmtDay(low, lowDay),
+		fmtValue(heat, 1),
+		fmtValue(cool, 1),
+		fmtValue(rain, 1),
+		fmtValue(mean(meanWinds), 1),
+		fmtValue(highWind, 1),
+		fmtDay(highWind, highWindDay),
+		fmtValue(dir, 0),
+	)
+
+	m.writeSnowfall(&b)
+	return b.String()
+}
+
+// writeSnowfall appends the snowfall summary of months with observed snow
+func (m *NOAAMonth) writeSnowfall(b *strings.Builder) {
+	var total float64
+	var snowDays int
+	var highest *float64
+	var highestDay int
+	for _, d := range m.Days {
+		if d.Snow == nil {
+			continue
+		}
+		total += *d.Snow
+		if *d.Snow > 0 {
+			snowDays++
+		}
+		if highest == nil || *d.Snow > *highest {
+			highest, highestDay = d.Snow, d.Day
+		}
+	}
+	if highest == nil {
+		return
+	}
+	fmt.Fprintf(b, "\nSNOWFALL (%s): TOTAL %.1f, MAX %.1f ON DAY %d, DAYS WITH SNOW %d\n",
+		m.SnowUnit, total, *highest, highestDay, snowDays)
+}
+
+// writeNOAARow writes the 13 columns of a report row right-aligned
+func writeNOAARow(b *strings.Builder, columns ...string) {
+	line := fmt.Sprintf("%3s", columns[0])
+	for _, c := range columns[1:] {
+		line += fmt.Sprintf(" %6s", c)
+	}
+	b.WriteString(strings.TrimRight(line, " ") + "\n")
+}
+
+// degreeDays returns the heating and cooling degree days of a daily mean
+func (m *NOAAMonth) degreeDays(meanTemp *float64) (*float64, *float64) {
+	if meanTemp == nil {
+		return nil, nil
+	}
+	heat := math.Max(0, m.HeatBase-*meanTemp)
+	cool := math.Max(0, *meanTemp-m.CoolBase)
+	return &heat, &cool
+}
+
+// mean returns the mean of values or nil if there are none
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	m := sum / float64(len(values))
+	return &m
+}
+
+// fmtValue formats an optional value; missing values are left blank
+func fmtValue(v *float64, decimals int) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.*f", decimals, *v)
+}
+
+// fmtTime formats the time of an optional value as HH:MM
+func fmtTime(v *float64, t time.Time) string {
+	if v == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format("15:04")
+}
+
+// fmtDay formats the day of an optional extreme
+func fmtDay(v *float64, day int) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", day)
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestNOAAMonth_Format(t *testing.T) {
+	m := NOAAMonth{
+		Station:  "Home",
+		Year:     2026,
+		Month:    time.March,
+		TempUnit: "°C",
+		RainUnit: "mm",
+		WindUnit: "km/h",
+		HeatBase: DefaultHeatBase,
+		CoolBase: DefaultCoolBase,
+		Days: []NOAADay{
+			{
+				Day:      1,
+				MeanTemp: float(8.3),
+				HighTemp: float(12.5),
+				HighTime: time.Date(2026, 3, 1, 14, 5, 0, 0, time.UTC),
+				LowTemp:  float(-1.5),
+				LowTime:  time.Date(2026, 3, 1, 6, 30, 0, 0, time.UTC),
+				Rain:     float(2),
+				MeanWind: float(4),
+				HighWind: float(30),
+				DomDir:   float(350),
+			},
+			{Day: 2},
+			{Day: 3, MeanTemp: float(20.3), HighTemp: float(25), LowTemp: float(15), Rain: float(1), MeanWind: float(6), DomDir: float(10)},
+		},
+	}
+
+	report := m.Format()
+	lines := strings.Split(strings.TrimRight(report, "\n"), "\n")
+
+	if !strings.Contains(report, "MONTHLY CLIMATOLOGICAL SUMMARY for Mar 2026") {
+		t.Error("Expected report title")
+	}
+
+	day1 := "  1    8.3   12.5  14:05   -1.5  06:30   10.0    0.0    2.0    4.0   30.0           350"
+	if !strings.Contains(report, day1+"\n") {
+		t.Errorf("Expected day line %q in report:\n%s", day1, report)
+	}
+	if !strings.Contains(report, "\n  2\n") {
+		t.Error("Expected empty line for a day without data")
+	}
+
+	// Totals: mean of means, extremes with day, summed degree days and rain,
+	// vector mean of the dominant directions
+	totals := lines[len(lines)-1]
+	expected := "      14.3   25.0      3   -1.5      1   10.0    2.0    3.0    5.0   30.0      1      0"
+	if totals != expected {
+		t.Errorf("Expected totals\n%q, got\n%q", expected, totals)
+	}
+}
+
+func TestNOAAMonth_FormatSnowfall(t *testing.T) {
+	m := NOAAMonth{
+		Station:  "Home",
+		Year:     2026,
+		Month:    time.January,
+		TempUnit: "°C",
+		SnowUnit: "cm",
+		Days: []NOAADay{
+			{Day: 1, MeanTemp: float(-2), Snow: float(4.5)},
+			{Day: 2, MeanTemp: float(-1), Snow: float(0)},
+			{Day: 3, MeanTemp: float(-4), Snow: float(12)},
+			{Day: 4, MeanTemp: float(1)},
+		},
+	}
+
+	report := m.Format()
+	expected := "\nSNOWFALL (cm): TOTAL 16.5, MAX 12.0 ON DAY 3, DAYS WITH SNOW 2\n"
+	if !strings.HasSuffix(report, expected) {
+		t.Errorf("Expected snowfall summary %q in report:\n%s", expected, report)
+	}
+
+	m.Days = m.Days[3:]
+	if strings.Contains(m.Format(), "SNOWFALL") {
+		t.Error("Expected no snowfall summary without observations")
+	}
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import "time"
+
+// Interpolation methods of Resample
+const (
+	// ResampleLinear interpolates linearly between the surrounding points
+	ResampleLinear = "linear"
+	// ResamplePrevious repeats the last point at or before the time
+	ResamplePrevious = "previous"
+)
+
+// ResampledPoint is a value of an evenly spaced series. Value is nil where
+// no value could be interpolated.
+type ResampledPoint struct {
+	Time  time.Time `json:"time"`
+	Value *float64  `json:"value"`
+}
+
+// Resample converts a time ordered series into points at start,
+// start+step, ... up to and including end. Times before the first point,
+// after the last point (linear) or within a gap between points longer than
+// maxGap (0: no limit) have no value.
+func Resample(points []Point, start, end time.Time, step, maxGap time.Duration, method string) []ResampledPoint {
+	if step <= 0 || end.Before(start) {
+		return []ResampledPoint{}
+	}
+
+	result := make([]ResampledPoint, 0, int(end.Sub(start)/step)+1)
+	// next is the index of the first point after t
+	next := 0
+	for t := start; !t.After(end); t = t.Add(step) {
+		for next < len(points) && !points[next].Time.After(t) {
+			next++
+		}
+		result = append(result, ResampledPoint{Time: t, Value: interpolate(points, next, t, maxGap, method)})
+	}
+	return result
+}
+
+// interpolate returns the value at t between points[next-1] and
+// points[next]
+func interpolate(points []Point, next int, t time.Time, maxGap time.Duration, method string) *float64 {
+	if next == 0 {
+		return nil
+	}
+	prev := points[next-1]
+	if prev.Time.Equal(t) {
+		value := prev.Value
+		return &value
+	}
+
+	if method == ResamplePrevious {
+		if maxGap > 0 && t.Sub(prev.Time) > maxGap {
+			return nil
+		}
+		value := prev.Value
+		return &value
+	}
+
+	if next == len(points) {
+		return nil
+	}
+	after := points[next]
+	span := after.Time.Sub(prev.Time)
+	if maxGap > 0 && span > maxGap {
+		return nil
+	}
+	fraction := float64(t.Sub(prev.Time)) / float64(span)
+	value := prev.Value + (after.Value-prev.Value)*fraction
+	return &value
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Time: base.Add(30 * time.Second), Value: 10},
+		{Time: base.Add(90 * time.Second), Value: 16},
+		{Time: base.Add(10 * time.Minute), Value: 0},
+	}
+
+	values := func(resampled []ResampledPoint) []interface{} {
+		result := make([]interface{}, len(resampled))
+		for i, p := range resampled {
+			if p.Value != nil {
+				result[i] = *p.Value
+			}
+		}
+		return result
+	}
+	check := func(name string, got []ResampledPoint, want []interface{}) {
+		t.Helper()
+		g := values(got)
+		if len(g) != len(want) {
+			t.Fatalf("%s: got %d points, want %d", name, len(g), len(want))
+		}
+		for i := range want {
+			if g[i] != want[i] {
+				t.Errorf("%s: point %d = %v, want %v", name, i, g[i], want[i])
+			}
+		}
+	}
+
+	linear := Resample(points, base, base.Add(3*time.Minute), time.Minute, 0, ResampleLinear)
+	check("linear", linear, []interface{}{nil, 13.0, 16 - 16*30.0/510, 16 - 16*90.0/510})
+	if !linear[2].Time.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("point 2 at %s, want %s", linear[2].Time, base.Add(2*time.Minute))
+	}
+
+	previous := Resample(points, base, base.Add(3*time.Minute), time.Minute, 0, ResamplePrevious)
+	check("previous", previous, []interface{}{nil, 10.0, 16.0, 16.0})
+
+	// The gap from 1:30 to 10:00 is longer than max_gap
+	gapped := Resample(points, base, base.Add(3*time.Minute), time.Minute, 5*time.Minute, ResampleLinear)
+	check("linear with max gap", gapped, []interface{}{nil, 13.0, nil, nil})
+	gapped = Resample(points, base, base.Add(12*time.Minute), 6*time.Minute, 4*time.Minute, ResamplePrevious)
+	check("previous with max gap", gapped, []interface{}{nil, nil, 0.0})
+
+	// Linear has no value after the last point
+	after := Resample(points, base.Add(10*time.Minute), base.Add(11*time.Minute), time.Minute, 0, ResampleLinear)
+	check("after the last point", after, []interface{}{0.0, nil})
+}
@@ -0,0 +1,230 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Storm severities, raised by rapid pressure drops and escalated by a
+// sustained wind increase
+const (
+	StormNone = iota
+	StormModerate
+	StormStrong
+	StormSevere
+)
+
+// Pressure tendencies
+const (
+	TendencyRising  = "rising"
+	TendencyFalling = "falling"
+	TendencySteady  = "steady"
+)
+
+// stormLevels names the storm severities
+var stormLevels = []string{"none", "moderate", "strong", "severe"}
+
+// edgeDuration is the time at both ends of a window whose readings are
+// averaged, so single noisy readings don't decide the tendency
+const edgeDuration = 15 * time.Minute
+
+// StormThresholds configures the storm detection
+type StormThresholds struct {
+	// Window is the time the pressure change is measured over
+	Window time.Duration
+	// Drops are the pressure drops in hPa per window raising a moderate,
+	// strong and severe storm event
+	Drops [3]float64
+	// Steady is the largest pressure change in hPa per window that counts
+	// as steady
+	Steady float64
+	// WindIncrease is the increase of the average wind speed in m/s from
+	// the first to the last hour of the window that escalates a storm
+	// event by one severity
+	WindIncrease float64
+}
+
+// DefaultStormThresholds follow the common barometer rules: a drop of
+// 3 hPa in 3 hours announces a storm, 6 hPa a gale and 10 hPa a severe storm
+var DefaultStormThresholds = StormThresholds{
+	Window:       3 * time.Hour,
+	Drops:        [3]float64{3, 6, 10},
+	Steady:       1,
+	WindIncrease: 3,
+}
+
+// PressureTendency is the pressure change over the window
+type PressureTendency struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Start float64   `json:"start"`
+	End   float64   `json:"end"`
+	// Change is the change in hPa scaled to the window, negative when the
+	// pressure falls
+	Change float64 `json:"change"`
+	Trend  string  `json:"trend"`
+}
+
+// WindTrend compares the average wind speed of the first and the last hour
+// of the window
+type WindTrend struct {
+	Earlier  float64 `json:"earlier"`
+	Recent   float64 `json:"recent"`
+	Increase float64 `json:"increase"`
+	// Sustained is set when every reading of the last hour exceeds the
+	// earlier average and the increase reaches the threshold
+	Sustained bool `json:"sustained"`
+}
+
+// StormEvent is a "storm approaching" warning
+type StormEvent struct {
+	Severity int    `json:"severity"`
+	Level    string `json:"level"`
+	Message  string `json:"message"`
+}
+
+// Tendency holds the pressure and wind trends of a station and the storm
+// event they raise. Trends without enough readings are nil.
+type Tendency struct {
+	WindowSec float64           `json:"window_seconds"`
+	Pressure  *PressureTendency `json:"pressure"`
+	Wind      *WindTrend        `json:"wind"`
+	Storm     *StormEvent       `json:"storm"`
+}
+
+// StormLevel returns the name of a storm severity
+func StormLevel(severity int) string {
+	if severity < 0 || severity >= len(stormLevels) {
+		return stormLevels[StormNone]
+	}
+	return stormLevels[severity]
+}
+
+// DetectStorm computes the tendency of the pressure and wind readings of
+// the window ending at now. Points must be in ascending order.
+func DetectStorm(pressure, wind []Point, now time.Time, thresholds StormThresholds) Tendency {
+	start := now.Add(-thresholds.Window)
+	tendency := Tendency{
+		WindowSec: thresholds.Window.Seconds(),
+		Pressure:  pressureTendency(within(pressure, start, now), thresholds),
+		Wind:      windTrend(within(wind, start, now), thresholds),
+	}
+
+	if tendency.Pressure == nil {
+		return tendency
+	}
+	drop := -tendency.Pressure.Change
+	severity := StormNone
+	for i, limit := range thresholds.Drops {
+		if drop >= limit {
+			severity = i + 1
+		}
+	}
+	if severity == StormNone {
+		return tendency
+	}
+
+	message := fmt.Sprintf("Storm approaching: pressure fell %.1f hPa in %s", drop, formatWindow(thresholds.Window))
+	if tendency.Wind != nil && tendency.Wind.Sustained {
+		severity = min(severity+1, StormSevere)
+		message += fmt.Sprintf(", wind increased %.1f m/s", tendency.Wind.Increase)
+	}
+	tendency.Storm = &StormEvent{Severity: severity, Level: StormLevel(severity), Message: message}
+	return tendency
+}
+
+// within returns the points between start and end
+func within(points []Point, start, end time.Time) []Point {
+	var result []Point
+	for _, p := range points {
+		if !p.Time.Before(start) && !p.Time.After(end) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// pressureTendency compares the averages at both ends of the readings. At
+// least three quarters of the window must be covered.
+func pressureTendency(points []Point, thresholds StormThresholds) *PressureTendency {
+	if len(points) < 2 {
+		return nil
+	}
+	first, last := points[0].Time, points[len(points)-1].Time
+	span := last.Sub(first)
+	if span < thresholds.Window*3/4 {
+		return nil
+	}
+
+	startValue := average(within(points, first, first.Add(edgeDuration)))
+	endValue := average(within(points, last.Add(-edgeDuration), last))
+	change := (endValue - startValue) * thresholds.Window.Seconds() / span.Seconds()
+
+	trend := TendencySteady
+	if change > thresholds.Steady {
+		trend = TendencyRising
+	} else if change < -thresholds.Steady {
+		trend = TendencyFalling
+	}
+	return &PressureTendency{
+		From:   first,
+		To:     last,
+		Start:  round(startValue, 1),
+		End:    round(endValue, 1),
+		Change: round(change, 1),
+		Trend:  trend,
+	}
+}
+
+// windTrend compares the average wind speed of the first and the last hour
+func windTrend(points []Point, thresholds StormThresholds) *WindTrend {
+	if len(points) < 2 {
+		return nil
+	}
+	first, last := points[0].Time, points[len(points)-1].Time
+	if last.Sub(first) < thresholds.Window/2 {
+		return nil
+	}
+
+	earlier := within(points, first, first.Add(time.Hour))
+	recent := within(points, last.Add(-time.Hour), last)
+	trend := &WindTrend{Earlier: average(earlier), Recent: average(recent)}
+	trend.Increase = trend.Recent - trend.Earlier
+
+	trend.Sustained = trend.Increase >= thresholds.WindIncrease
+	for _, p := range recent {
+		if p.Value <= trend.Earlier {
+			trend.Sustained = false
+		}
+	}
+
+	trend.Earlier, trend.Recent, trend.Increase = round(trend.Earlier, 1), round(trend.Recent, 1), round(trend.Increase, 1)
+	return trend
+}
+
+// average returns the mean value of points
+func average(points []Point) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, p := range points {
+		sum += p.Value
+	}
+	return sum / float64(len(points))
+}
+
+// round rounds a value to the given decimals
+func round(v float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(v*factor) / factor
+}
+
+// formatWindow formats a window like "3h"
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return d.String()
+}
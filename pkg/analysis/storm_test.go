@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+// linear returns points every 10 minutes from start to end, changing
+// linearly from one value to the other
+func linear(start, end time.Time, from, to float64) []Point {
+	var points []Point
+	steps := int(end.Sub(start) / (10 * time.Minute))
+	for i := 0; i <= steps; i++ {
+		points = append(points, Point{
+			Time:  start.Add(time.Duration(i) * 10 * time.Minute),
+			Value: from + (to-from)*float64(i)/float64(steps),
+		})
+	}
+	return points
+}
+
+func TestDetectStorm(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-3 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		pressure     []Point
+		wind         []Point
+		wantTrend    string
+		wantSeverity int
+	}{
+		{
+			name:      "Steady",
+			pressure:  linear(start, now, 1013, 1013.5),
+			wantTrend: TendencySteady,
+		},
+		{
+			name:      "Rising",
+			pressure:  linear(start, now, 1005, 1010),
+			wantTrend: TendencyRising,
+		},
+		{
+			name:      "Slowly falling",
+			pressure:  linear(start, now, 1010, 1008),
+			wantTrend: TendencyFalling,
+		},
+		{
+			name:         "Moderate drop",
+			pressure:     linear(start, now, 1010, 1006),
+			wantTrend:    TendencyFalling,
+			wantSeverity: StormModerate,
+		},
+		{
+			name:         "Strong drop",
+			pressure:     linear(start, now, 1010, 1003),
+			wantTrend:    TendencyFalling,
+			wantSeverity: StormStrong,
+		},
+		{
+			name:         "Moderate drop with increasing wind",
+			pressure:     linear(start, now, 1010, 1006),
+			wind:         linear(start, now, 2, 10),
+			wantTrend:    TendencyFalling,
+			wantSeverity: StormStrong,
+		},
+		{
+			name:         "Severe drop with increasing wind",
+			pressure:     linear(start, now, 1010, 998),
+			wind:         linear(start, now, 2, 10),
+			wantTrend:    TendencyFalling,
+			wantSeverity: StormSevere,
+		},
+		{
+			name:      "Increasing wind alone",
+			pressure:  linear(start, now, 1010, 1010),
+			wind:      linear(start, now, 2, 10),
+			wantTrend: TendencySteady,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DetectStorm(tc.pressure, tc.wind, now, DefaultStormThresholds)
+			if got.Pressure == nil {
+				t.Fatal("expected a pressure tendency")
+			}
+			if got.Pressure.Trend != tc.wantTrend {
+				t.Errorf("trend = %s, want %s", got.Pressure.Trend, tc.wantTrend)
+			}
+			severity := StormNone
+			if got.Storm != nil {
+				severity = got.Storm.Severity
+				if got.Storm.Level != StormLevel(severity) || got.Storm.Message == "" {
+					t.Errorf("storm = %+v", got.Storm)
+				}
+			}
+			if severity != tc.wantSeverity {
+				t.Errorf("severity = %d, want %d", severity, tc.wantSeverity)
+			}
+		})
+	}
+}
+
+func TestDetectStorm_NotEnoughReadings(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// One hour of a rapid drop does not cover the window
+	got := DetectStorm(linear(now.Add(-time.Hour), now, 1010, 1000), nil, now, DefaultStormThresholds)
+	if got.Pressure != nil || got.Storm != nil {
+		t.Errorf("DetectStorm() = %+v, want no tendency", got)
+	}
+	if got.Wind != nil {
+		t.Errorf("wind = %+v, want nil without readings", got.Wind)
+	}
+}
+
+func TestWindTrend_Gusty(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	wind := linear(now.Add(-3*time.Hour), now, 2, 8)
+	// A lull in the last hour is not a sustained increase
+	wind[len(wind)-2].Value = 1
+
+	got := windTrend(wind, DefaultStormThresholds)
+	if got == nil || got.Sustained {
+		t.Errorf("windTrend() = %+v, want an unsustained increase", got)
+	}
+}
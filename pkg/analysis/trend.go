@@ -0,0 +1,22 @@
+package analysis
+
+// Trend compares the averages at both ends of a series, like the pressure
+// tendency, and returns TendencyRising, TendencyFalling or TendencySteady
+// when the change stays within steady. Series with less than two points
+// have no trend and return "". Points must be in ascending order.
+func Trend(points []Point, steady float64) string {
+	if len(points) < 2 {
+		return ""
+	}
+	first, last := points[0].Time, points[len(points)-1].Time
+	startValue := average(within(points, first, first.Add(edgeDuration)))
+	endValue := average(within(points, last.Add(-edgeDuration), last))
+
+	switch change := endValue - startValue; {
+	case change > steady:
+		return TendencyRising
+	case change < -steady:
+		return TendencyFalling
+	}
+	return TendencySteady
+}
@@ -0,0 +1,30 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrend(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name   string
+		points []Point
+		want   string
+	}{
+		{"Rising", linear(start, now, 10, 12), TendencyRising},
+		{"Falling", linear(start, now, 14.2, 12.8), TendencyFalling},
+		{"Steady", linear(start, now, 10, 10.3), TendencySteady},
+		{"Single point", []Point{{Time: now, Value: 10}}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Trend(tc.points, 0.5); got != tc.want {
+				t.Errorf("Expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
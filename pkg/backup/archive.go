@@ -0,0 +1,108 @@
+// Package backup writes and verifies backup archives, encrypts them with age
+// or GPG and decides which backups the rotation keeps.
+package backup
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// ManifestName is the archive entry listing all other entries
+const ManifestName = "manifest.json"
+
+// FormatVersion is the version of the archive layout
+const FormatVersion = 1
+
+// Entry describes a file of the archive. Data files hold one JSON document
+// per line, Rows counts them.
+type Entry struct {
+	Name   string `json:"name"`
+	Rows   int64  `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is written last and lists the checksums of all entries
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Writer writes a backup archive: a zip file with one entry per table and a
+// manifest. Entries are streamed, so tables don't have to fit in memory.
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+}
+
+// NewWriter starts an archive created at createdAt
+func NewWriter(w io.Writer, createdAt time.Time) *Writer {
+	return &Writer{
+		zw:       zip.NewWriter(w),
+		manifest: Manifest{Version: FormatVersion, CreatedAt: createdAt.UTC(), Entries: []Entry{}},
+	}
+}
+
+// Add writes an entry. write returns the number of rows it wrote.
+func (w *Writer) Add(name string, write func(io.Writer) (int64, error)) error {
+	if name == ManifestName {
+		return fmt.Errorf("entry name %s is reserved", ManifestName)
+	}
+
+	f, err := w.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: w.manifest.CreatedAt})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	counter := &countingWriter{w: f, hash: sha256.New()}
+	rows, err := write(counter)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	w.manifest.Entries = append(w.manifest.Entries, Entry{
+		Name:   name,
+		Rows:   rows,
+		Size:   counter.n,
+		SHA256: hex.EncodeToString(counter.hash.Sum(nil)),
+	})
+	return nil
+}
+
+// Close writes the manifest and finishes the archive
+func (w *Writer) Close() (*Manifest, error) {
+	f, err := w.zw.Create(ManifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.manifest); err != nil {
+		return nil, err
+	}
+	if err := w.zw.Close(); err != nil {
+		return nil, err
+	}
+	return &w.manifest, nil
+}
+
+// countingWriter hashes and counts what is written through it
+type countingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.hash.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,144 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeArchive(t *testing.T, tables map[string][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC))
+	for name, rows := range tables {
+		err := w.Add(name, func(out io.Writer) (int64, error) {
+			for _, row := range rows {
+				if _, err := fmt.Fprintln(out, row); err != nil {
+					return 0, err
+				}
+			}
+			return int64(len(rows)), nil
+		})
+		if err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if _, err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriterAndVerify(t *testing.T) {
+	data := writeArchive(t, map[string][]string{
+		"postgres/stations.jsonl":          {`{"id": "a"}`, `{"id": "b"}`},
+		"clickhouse/sensor_readings.jsonl": {},
+	})
+
+	manifest, err := Verify(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(manifest.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(manifest.Entries))
+	}
+	for _, entry := range manifest.Entries {
+		if entry.Name == "postgres/stations.jsonl" && (entry.Rows != 2 || len(entry.SHA256) != 64) {
+			t.Errorf("entry = %+v, want 2 rows with checksum", entry)
+		}
+	}
+}
+
+func TestWriter_ReservedName(t *testing.T) {
+	w := NewWriter(io.Discard, time.Now())
+	if err := w.Add(ManifestName, func(io.Writer) (int64, error) { return 0, nil }); err == nil {
+		t.Error("Add() accepted the manifest name")
+	}
+}
+
+func TestVerify_DetectsCorruption(t *testing.T) {
+	// Rewrite an archive with a changed entry but the original manifest
+	data := writeArchive(t, map[string][]string{"postgres/users.jsonl": {`{"name": "alice"}`}})
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := func(replace func(name, content string) string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, f := range zr.File {
+			rc, _ := f.Open()
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			out, _ := zw.Create(f.Name)
+			io.WriteString(out, replace(f.Name, string(content)))
+		}
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		replace func(name, content string) string
+		want    string
+	}{
+		{"changed row", func(name, content string) string {
+			return strings.Replace(content, "alice", "mallo", 1)
+		}, "checksum mismatch"},
+		{"added row", func(name, content string) string {
+			if name == ManifestName {
+				return content
+			}
+			return content + "{}\n"
+		}, "size"},
+		{"invalid json", func(name, content string) string {
+			if name == ManifestName {
+				return content
+			}
+			return strings.Replace(content, "}", ",", 1)
+		}, "not valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tampered(tt.replace)
+			_, err := Verify(bytes.NewReader(data), int64(len(data)))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify_MissingManifest(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	zw.Create("postgres/users.jsonl")
+	zw.Close()
+
+	if _, err := Verify(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err != errNoManifest {
+		t.Errorf("Verify() error = %v, want %v", err, errNoManifest)
+	}
+}
+
+func TestOpenEntry(t *testing.T) {
+	data := writeArchive(t, map[string][]string{"checksums/readings.jsonl": {`{"day": "2026-10-16"}`}})
+
+	rc, err := OpenEntry(bytes.NewReader(data), int64(len(data)), "checksums/readings.jsonl")
+	if err != nil {
+		t.Fatalf("OpenEntry() error = %v", err)
+	}
+	content, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(content) != "{\"day\": \"2026-10-16\"}\n" {
+		t.Errorf("content = %q", content)
+	}
+
+	if _, err := OpenEntry(bytes.NewReader(data), int64(len(data)), "missing.jsonl"); err == nil {
+		t.Error("OpenEntry() opened a missing entry")
+	}
+}
@@ -0,0 +1,202 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/openpgp"
+	// Keys without hash preferences fall back to RIPEMD-160
+	_ "golang.org/x/crypto/ripemd160"
+)
+
+// Encryption types
+const (
+	EncryptionNone = "none"
+	EncryptionAge  = "age"
+	EncryptionGPG  = "gpg"
+)
+
+// Encryption configures how archives are encrypted and decrypted
+type Encryption struct {
+	Type string
+	// Recipients are age public keys (age1...) archives are encrypted to
+	Recipients []string
+	// KeyFile is a GPG public key to encrypt to. For decryption it is the
+	// age identity file or the GPG secret key.
+	KeyFile string
+	// Passphrase encrypts symmetrically (age scrypt, GPG symmetric) when no
+	// recipient is set, and unlocks a protected GPG secret key otherwise
+	Passphrase string
+}
+
+// Validate checks that the settings required to encrypt are present
+func (e Encryption) Validate() error {
+	switch e.Type {
+	case "", EncryptionNone:
+		return nil
+	case EncryptionAge:
+		if len(e.Recipients) == 0 && e.Passphrase == "" {
+			return errors.New("age encryption requires recipients or a passphrase")
+		}
+		if len(e.Recipients) > 0 && e.Passphrase != "" {
+			return errors.New("age encryption uses either recipients or a passphrase")
+		}
+		_, err := e.ageRecipients()
+		return err
+	case EncryptionGPG:
+		if e.KeyFile == "" && e.Passphrase == "" {
+			return errors.New("gpg encryption requires a key file or a passphrase")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown encryption %q, expected none, age or gpg", e.Type)
+	}
+}
+
+// Extension returns the file extension added by the encryption
+func (e Encryption) Extension() string {
+	switch e.Type {
+	case EncryptionAge:
+		return ".age"
+	case EncryptionGPG:
+		return ".gpg"
+	}
+	return ""
+}
+
+// EncryptionOf returns the encryption type of a backup file name
+func EncryptionOf(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".age"):
+		return EncryptionAge
+	case strings.HasSuffix(name, ".gpg"):
+		return EncryptionGPG
+	}
+	return EncryptionNone
+}
+
+// Encrypt returns a writer encrypting to w. Closing it flushes the
+// encryption but does not close w.
+func (e Encryption) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	switch e.Type {
+	case "", EncryptionNone:
+		return nopWriteCloser{w}, nil
+	case EncryptionAge:
+		recipients, err := e.ageRecipients()
+		if err != nil {
+			return nil, err
+		}
+		return age.Encrypt(w, recipients...)
+	case EncryptionGPG:
+		hints := &openpgp.FileHints{IsBinary: true}
+		if e.KeyFile == "" {
+			return openpgp.SymmetricallyEncrypt(w, []byte(e.Passphrase), hints, nil)
+		}
+		keyring, err := readKeyRing(e.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return openpgp.Encrypt(w, keyring, nil, hints, nil)
+	}
+	return nil, fmt.Errorf("unknown encryption %q", e.Type)
+}
+
+// Decrypt returns a reader decrypting r
+func (e Encryption) Decrypt(r io.Reader) (io.Reader, error) {
+	switch e.Type {
+	case "", EncryptionNone:
+		return r, nil
+	case EncryptionAge:
+		var identities []age.Identity
+		if e.KeyFile != "" {
+			f, err := os.Open(e.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read age identity: %w", err)
+			}
+			defer f.Close()
+			if identities, err = age.ParseIdentities(f); err != nil {
+				return nil, fmt.Errorf("invalid age identity: %w", err)
+			}
+		} else {
+			identity, err := age.NewScryptIdentity(e.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			identities = append(identities, identity)
+		}
+		return age.Decrypt(r, identities...)
+	case EncryptionGPG:
+		var keyring openpgp.EntityList
+		if e.KeyFile != "" {
+			var err error
+			if keyring, err = readKeyRing(e.KeyFile); err != nil {
+				return nil, err
+			}
+		}
+		prompted := false
+		prompt := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+			// Called again when the passphrase was wrong
+			if prompted || e.Passphrase == "" {
+				return nil, errors.New("wrong or missing passphrase")
+			}
+			prompted = true
+			for _, key := range keys {
+				if key.PrivateKey != nil && key.PrivateKey.Encrypted {
+					_ = key.PrivateKey.Decrypt([]byte(e.Passphrase))
+				}
+			}
+			return []byte(e.Passphrase), nil
+		}
+		md, err := openpgp.ReadMessage(r, keyring, prompt, nil)
+		if err != nil {
+			return nil, err
+		}
+		return md.UnverifiedBody, nil
+	}
+	return nil, fmt.Errorf("unknown encryption %q", e.Type)
+}
+
+func (e Encryption) ageRecipients() ([]age.Recipient, error) {
+	if len(e.Recipients) == 0 {
+		recipient, err := age.NewScryptRecipient(e.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+
+	recipients := make([]age.Recipient, 0, len(e.Recipients))
+	for _, s := range e.Recipients {
+		recipient, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", s, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// readKeyRing reads an armored or binary GPG key ring
+func readKeyRing(file string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gpg key: %w", err)
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gpg key: %w", err)
+	}
+	return keyring, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"golang.org/x/crypto/openpgp"
+)
+
+func roundTrip(t *testing.T, encrypt, decrypt Encryption) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := encrypt.Encrypt(&buf)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	io.WriteString(w, "backup content")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("backup content")) && encrypt.Type != EncryptionNone {
+		t.Fatal("encrypted output contains the plain text")
+	}
+
+	r, err := decrypt.Decrypt(&buf)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	return data
+}
+
+func TestEncryption_Age(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600)
+
+	enc := Encryption{Type: EncryptionAge, Recipients: []string{identity.Recipient().String()}}
+	if err := enc.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	got := roundTrip(t, enc, Encryption{Type: EncryptionAge, KeyFile: identityFile})
+	if string(got) != "backup content" {
+		t.Errorf("got %q", got)
+	}
+
+	enc = Encryption{Type: EncryptionAge, Passphrase: "correct horse"}
+	if got := roundTrip(t, enc, enc); string(got) != "backup content" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEncryption_GPG(t *testing.T) {
+	enc := Encryption{Type: EncryptionGPG, Passphrase: "correct horse"}
+	if got := roundTrip(t, enc, enc); string(got) != "backup content" {
+		t.Errorf("got %q", got)
+	}
+
+	entity, err := openpgp.NewEntity("Backup", "", "backup@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var public, secret bytes.Buffer
+	entity.Serialize(&public)
+	entity.SerializePrivate(&secret, nil)
+	publicFile, secretFile := filepath.Join(dir, "public.gpg"), filepath.Join(dir, "secret.gpg")
+	os.WriteFile(publicFile, public.Bytes(), 0600)
+	os.WriteFile(secretFile, secret.Bytes(), 0600)
+
+	got := roundTrip(t, Encryption{Type: EncryptionGPG, KeyFile: publicFile}, Encryption{Type: EncryptionGPG, KeyFile: secretFile})
+	if string(got) != "backup content" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEncryption_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		enc     Encryption
+		wantErr bool
+	}{
+		{"none", Encryption{}, false},
+		{"age without recipient", Encryption{Type: EncryptionAge}, true},
+		{"age invalid recipient", Encryption{Type: EncryptionAge, Recipients: []string{"age1invalid"}}, true},
+		{"age recipient and passphrase", Encryption{Type: EncryptionAge, Recipients: []string{"x"}, Passphrase: "p"}, true},
+		{"gpg without key", Encryption{Type: EncryptionGPG}, true},
+		{"unknown", Encryption{Type: "zip"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.enc.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptionOf(t *testing.T) {
+	for name, want := range map[string]string{
+		"weathermaestro-20261016T030000Z.zip":     EncryptionNone,
+		"weathermaestro-20261016T030000Z.zip.age": EncryptionAge,
+		"weathermaestro-20261016T030000Z.zip.gpg": EncryptionGPG,
+	} {
+		if got := EncryptionOf(name); got != want {
+			t.Errorf("EncryptionOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/sguter90/weathermaestro/pkg/backup
+
+go 1.25
+
+require (
+	filippo.io/age v1.2.1
+	golang.org/x/crypto v0.48.0
+)
+
+require golang.org/x/sys v0.41.0 // indirect
@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// filePrefix and timeLayout make up backup file names
+const (
+	filePrefix = "weathermaestro-"
+	timeLayout = "20060102T150405Z"
+)
+
+// FileName returns the name of a backup created at t
+func FileName(t time.Time, e Encryption) string {
+	return filePrefix + t.UTC().Format(timeLayout) + ".zip" + e.Extension()
+}
+
+// ParseFileName returns the creation time of a backup file name
+func ParseFileName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) {
+		return time.Time{}, false
+	}
+	rest := strings.TrimPrefix(name, filePrefix)
+	stamp, ext, ok := strings.Cut(rest, ".")
+	if !ok || (ext != "zip" && ext != "zip.age" && ext != "zip.gpg") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Retention is how many backups the rotation keeps: the newest backup of
+// each of the last Daily days and of each of the last Weekly ISO weeks.
+// The newest backup is always kept; zero for both keeps everything.
+type Retention struct {
+	Daily  int
+	Weekly int
+}
+
+// Validate checks the retention counts
+func (r Retention) Validate() error {
+	if r.Daily < 0 || r.Weekly < 0 {
+		return fmt.Errorf("backup retention must not be negative")
+	}
+	return nil
+}
+
+// Expired returns the backups the rotation deletes, oldest first
+func (r Retention) Expired(backups []time.Time) []time.Time {
+	if r.Daily == 0 && r.Weekly == 0 {
+		return nil
+	}
+
+	sorted := append([]time.Time(nil), backups...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].After(sorted[j]) })
+
+	keep := make(map[int]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i, t := range sorted {
+		t = t.UTC()
+		if i == 0 {
+			keep[i] = true
+		}
+		day := t.Format(time.DateOnly)
+		if !days[day] && len(days) < r.Daily {
+			days[day] = true
+			keep[i] = true
+		}
+		year, w := t.ISOWeek()
+		week := fmt.Sprintf("%d-%02d", year, w)
+		if !weeks[week] && len(weeks) < r.Weekly {
+			weeks[week] = true
+			keep[i] = true
+		}
+	}
+
+	var expired []time.Time
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if !keep[i] {
+			expired = append(expired, sorted[i])
+		}
+	}
+	return expired
+}
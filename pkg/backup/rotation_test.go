@@ -0,0 +1,72 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileName(t *testing.T) {
+	created := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	name := FileName(created, Encryption{Type: EncryptionAge})
+	if name != "weathermaestro-20261016T030000Z.zip.age" {
+		t.Errorf("FileName() = %q", name)
+	}
+	if got, ok := ParseFileName(name); !ok || !got.Equal(created) {
+		t.Errorf("ParseFileName() = %v, %v", got, ok)
+	}
+	for _, invalid := range []string{"notes.txt", "weathermaestro-latest.zip", "weathermaestro-20261016T030000Z.tar"} {
+		if _, ok := ParseFileName(invalid); ok {
+			t.Errorf("ParseFileName(%q) accepted", invalid)
+		}
+	}
+}
+
+func TestRetention_Expired(t *testing.T) {
+	// Two backups a day for 30 days, newest first on Friday 16 October
+	end := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	var backups []time.Time
+	for i := 0; i < 60; i++ {
+		backups = append(backups, end.Add(-time.Duration(i)*12*time.Hour))
+	}
+
+	expired := Retention{Daily: 3, Weekly: 2}.Expired(backups)
+	deleted := make(map[time.Time]bool)
+	for _, t := range expired {
+		deleted[t] = true
+	}
+	var kept []time.Time
+	for _, b := range backups {
+		if !deleted[b] {
+			kept = append(kept, b)
+		}
+	}
+
+	// The newest of the last three days, plus the newest of last week
+	want := []time.Time{
+		end,
+		end.Add(-24 * time.Hour),
+		end.Add(-48 * time.Hour),
+		time.Date(2026, 10, 11, 15, 0, 0, 0, time.UTC),
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+	for i := range want {
+		if !kept[i].Equal(want[i]) {
+			t.Errorf("kept[%d] = %v, want %v", i, kept[i], want[i])
+		}
+	}
+	if !expired[0].Before(expired[len(expired)-1]) {
+		t.Error("expired backups are not sorted oldest first")
+	}
+}
+
+func TestRetention_KeepsNewest(t *testing.T) {
+	backups := []time.Time{time.Now()}
+	if expired := (Retention{Weekly: 1}).Expired(backups); len(expired) != 0 {
+		t.Errorf("Expired() = %v, want the only backup kept", expired)
+	}
+	if expired := (Retention{}).Expired(append(backups, time.Now().Add(-time.Hour))); expired != nil {
+		t.Errorf("Expired() = %v, want everything kept without retention", expired)
+	}
+}
@@ -0,0 +1,123 @@
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errNoManifest is returned for archives without a manifest
+var errNoManifest = errors.New("archive has no manifest")
+
+// maxLineSize is the longest row accepted when verifying
+const maxLineSize = 16 << 20
+
+// Verify checks that an archive can be restored: every entry of the manifest
+// is present with the recorded size and checksum, holds the recorded number
+// of rows and every row is valid JSON. Entries missing from the manifest are
+// reported as well.
+func Verify(r io.ReaderAt, size int64) (*Manifest, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	mf, ok := files[ManifestName]
+	if !ok {
+		return nil, errNoManifest
+	}
+	manifest, err := readManifest(mf)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", manifest.Version)
+	}
+
+	listed := map[string]bool{ManifestName: true}
+	for _, entry := range manifest.Entries {
+		listed[entry.Name] = true
+		f, ok := files[entry.Name]
+		if !ok {
+			return manifest, fmt.Errorf("%s is missing", entry.Name)
+		}
+		if err := verifyEntry(f, entry); err != nil {
+			return manifest, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+	}
+	for name := range files {
+		if !listed[name] {
+			return manifest, fmt.Errorf("%s is not listed in the manifest", name)
+		}
+	}
+	return manifest, nil
+}
+
+// OpenEntry opens an entry of an archive for reading
+func OpenEntry(r io.ReaderAt, size int64, name string) (io.ReadCloser, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	return zr.Open(name)
+}
+
+func readManifest(f *zip.File) (*Manifest, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// verifyEntry reads an entry once to compare checksum, size and rows
+func verifyEntry(f *zip.File, entry Entry) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	counter := &countingWriter{w: io.Discard, hash: h}
+	scanner := bufio.NewScanner(io.TeeReader(rc, counter))
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var rows int64
+	for scanner.Scan() {
+		rows++
+		if !json.Valid(scanner.Bytes()) {
+			return fmt.Errorf("row %d is not valid JSON", rows)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if counter.n != entry.Size {
+		return fmt.Errorf("size is %d bytes, manifest has %d", counter.n, entry.Size)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != entry.SHA256 {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if rows != entry.Rows {
+		return fmt.Errorf("has %d rows, manifest has %d", rows, entry.Rows)
+	}
+	return nil
+}
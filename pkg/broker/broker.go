@@ -0,0 +1,85 @@
+// Package broker publishes messages to and receives messages from NATS
+// subjects or Kafka topics. NATS
+// is spoken natively, Kafka through the Confluent REST proxy, so no client
+// libraries are needed.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Supported broker types
+const (
+	TypeNATS  = "nats"
+	TypeKafka = "kafka"
+)
+
+// timeout bounds connecting to the broker and each request
+const timeout = 10 * time.Second
+
+// Message is sent to a subject
+type Message struct {
+	// Subject is the NATS subject or Kafka topic
+	Subject string
+	// Key selects the Kafka partition, so messages with the same key stay
+	// in order. NATS ignores it.
+	Key     string
+	Payload []byte
+}
+
+// Config configures the connection to a broker
+type Config struct {
+	// Type is TypeNATS or TypeKafka
+	Type string
+	// URL is the NATS server (nats://host:4222, tls://host:4222) or the
+	// Kafka REST proxy (http://host:8082)
+	URL      string
+	Username string
+	Password string
+	// Token authenticates at a NATS server instead of a user name
+	Token string
+	// Name identifies the connection at the broker
+	Name string
+}
+
+// Publisher sends messages to a broker
+type Publisher interface {
+	// Publish sends the messages in order and returns once the broker
+	// received all of them
+	Publish(ctx context.Context, msgs []Message) error
+	Close() error
+}
+
+// NewPublisher creates the publisher of a broker type. It connects on the
+// first publish.
+func NewPublisher(cfg Config) (Publisher, error) {
+	switch cfg.Type {
+	case TypeNATS:
+		return NewNATSClient(cfg), nil
+	case TypeKafka:
+		return newKafkaPublisher(cfg)
+	}
+	return nil, fmt.Errorf("unsupported broker type %q, use %s or %s", cfg.Type, TypeNATS, TypeKafka)
+}
+
+// Subscriber receives messages from a broker
+type Subscriber interface {
+	// Subscribe calls handle for each message of the subject until ctx is
+	// done or the connection fails. Subscribers of the same group share the
+	// messages, each message is handled by one of them.
+	Subscribe(ctx context.Context, subject, group string, handle func(Message)) error
+	Close() error
+}
+
+// NewSubscriber creates the subscriber of a broker type
+func NewSubscriber(cfg Config) (Subscriber, error) {
+	switch cfg.Type {
+	case TypeNATS:
+		return NewNATSClient(cfg), nil
+	case TypeKafka:
+		return newKafkaConsumer(cfg)
+	}
+	return nil, fmt.Errorf("unsupported broker type %q, use %s or %s", cfg.Type, TypeNATS, TypeKafka)
+}
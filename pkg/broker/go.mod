@@ -0,0 +1,3 @@
+module github.com/sguter90/weathermaestro/pkg/broker
+
+go 1.25
@@ -0,0 +1,125 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Content types of the Kafka REST proxy API v2
+const (
+	kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAcceptType      = "application/vnd.kafka.v2+json"
+)
+
+// kafkaRecord is a record produced through the REST proxy. The value must
+// be JSON.
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaProduceResponse reports the offset or error of each record
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+// kafkaPublisher produces records through the Confluent REST proxy
+type kafkaPublisher struct {
+	cfg     Config
+	baseURL string
+	client  *http.Client
+}
+
+func newKafkaPublisher(cfg Config) (*kafkaPublisher, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %q", cfg.URL)
+	}
+	return &kafkaPublisher{
+		cfg:     cfg,
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Publish produces the messages of each topic with one request, keeping
+// the order of the messages within a topic
+func (p *kafkaPublisher) Publish(ctx context.Context, msgs []Message) error {
+	var topics []string
+	records := make(map[string][]kafkaRecord)
+	for _, msg := range msgs {
+		if _, ok := records[msg.Subject]; !ok {
+			topics = append(topics, msg.Subject)
+		}
+		records[msg.Subject] = append(records[msg.Subject], kafkaRecord{Key: msg.Key, Value: msg.Payload})
+	}
+
+	for _, topic := range topics {
+		if err := p.produce(ctx, topic, records[topic]); err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// produce sends the records of a topic and checks the result of each
+func (p *kafkaPublisher) produce(ctx context.Context, topic string, records []kafkaRecord) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{"records": records})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", kafkaAcceptType)
+	if p.cfg.Username != "" {
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("REST proxy returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid REST proxy response: %w", err)
+	}
+	if len(result.Offsets) != len(records) {
+		return fmt.Errorf("REST proxy acknowledged %d of %d records", len(result.Offsets), len(records))
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil || offset.Error != nil {
+			message := "unknown error"
+			if offset.Error != nil {
+				message = *offset.Error
+			}
+			return fmt.Errorf("record not produced: %s", message)
+		}
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (p *kafkaPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,159 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaPollTimeout is the time the REST proxy waits for records before it
+// answers a poll without records
+const kafkaPollTimeout = 5 * time.Second
+
+// kafkaConsumerInstance is the consumer instance created at the REST proxy
+type kafkaConsumerInstance struct {
+	InstanceID string `json:"instance_id"`
+	BaseURI    string `json:"base_uri"`
+}
+
+// kafkaConsumerRecord is a record fetched through the REST proxy
+type kafkaConsumerRecord struct {
+	Topic     string          `json:"topic"`
+	Key       json.RawMessage `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	Partition int             `json:"partition"`
+	Offset    int64           `json:"offset"`
+}
+
+// kafkaConsumer consumes records through the Confluent REST proxy. Offsets
+// are committed after the records were handled, so records are delivered
+// at least once.
+type kafkaConsumer struct {
+	cfg     Config
+	baseURL string
+	client  *http.Client
+}
+
+func newKafkaConsumer(cfg Config) (*kafkaConsumer, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %q", cfg.URL)
+	}
+	return &kafkaConsumer{
+		cfg:     cfg,
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		client:  &http.Client{Timeout: timeout + kafkaPollTimeout},
+	}, nil
+}
+
+// Subscribe creates a consumer instance in the consumer group, subscribes
+// it to the topic and polls records until ctx is done. New groups start
+// with the oldest records of the topic. The instance is deleted at the end.
+func (c *kafkaConsumer) Subscribe(ctx context.Context, topic, group string, handle func(Message)) error {
+	var instance kafkaConsumerInstance
+	err := c.request(ctx, http.MethodPost, c.baseURL+"/consumers/"+url.PathEscape(group), map[string]string{
+		"format":             "json",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}, &instance)
+	if err != nil {
+		return c.subscribeErr(ctx, fmt.Errorf("failed to create consumer: %w", err))
+	}
+	defer func() {
+		deleteCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		c.request(deleteCtx, http.MethodDelete, instance.BaseURI, nil, nil)
+	}()
+
+	err = c.request(ctx, http.MethodPost, instance.BaseURI+"/subscription", map[string][]string{"topics": {topic}}, nil)
+	if err != nil {
+		return c.subscribeErr(ctx, fmt.Errorf("failed to subscribe to %s: %w", topic, err))
+	}
+
+	recordsURL := fmt.Sprintf("%s/records?timeout=%d", instance.BaseURI, kafkaPollTimeout.Milliseconds())
+	for ctx.Err() == nil {
+		var records []kafkaConsumerRecord
+		if err := c.request(ctx, http.MethodGet, recordsURL, nil, &records); err != nil {
+			return c.subscribeErr(ctx, fmt.Errorf("failed to fetch records: %w", err))
+		}
+		if len(records) == 0 {
+			continue
+		}
+		for _, record := range records {
+			var key string
+			json.Unmarshal(record.Key, &key)
+			handle(Message{Subject: record.Topic, Key: key, Payload: record.Value})
+		}
+		// Without body the proxy commits all fetched records. Handled
+		// records are committed even if the subscription ends meanwhile.
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		err := c.request(commitCtx, http.MethodPost, instance.BaseURI+"/offsets", nil, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to commit offsets: %w", err)
+		}
+	}
+	return nil
+}
+
+// subscribeErr returns nil for errors caused by the end of a subscription
+func (c *kafkaConsumer) subscribeErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// request sends a request to the REST proxy and decodes the response into
+// result if not nil
+func (c *kafkaConsumer) request(ctx context.Context, method, rawURL string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaAcceptType)
+	req.Header.Set("Accept", kafkaAcceptType)
+	if method == http.MethodGet {
+		req.Header.Set("Accept", kafkaJSONContentType)
+	}
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("REST proxy returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid REST proxy response: %w", err)
+	}
+	return nil
+}
+
+// Close releases idle connections
+func (c *kafkaConsumer) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
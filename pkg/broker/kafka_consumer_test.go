@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKafkaConsumer_Subscribe(t *testing.T) {
+	var requests []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/consumers/weathermaestro":
+			json.NewEncoder(w).Encode(kafkaConsumerInstance{InstanceID: "c1", BaseURI: server.URL + "/consumers/weathermaestro/instances/c1"})
+		case r.Method == http.MethodGet:
+			if r.Header.Get("Accept") != kafkaJSONContentType {
+				http.Error(w, "not acceptable", http.StatusNotAcceptable)
+				return
+			}
+			w.Write([]byte(`[{"topic":"wm.ingest","key":"gw","value":{"passkey":"gw"},"partition":0,"offset":7}]`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	subscriber, err := NewSubscriber(Config{Type: TypeKafka, URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewSubscriber() error = %v", err)
+	}
+	defer subscriber.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var received []Message
+	err = subscriber.Subscribe(ctx, "wm.ingest", "weathermaestro", func(msg Message) {
+		received = append(received, msg)
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	if len(received) != 1 || received[0].Key != "gw" || string(received[0].Payload) != `{"passkey":"gw"}` {
+		t.Errorf("Unexpected messages %+v", received)
+	}
+	want := []string{
+		"POST /consumers/weathermaestro",
+		"POST /consumers/weathermaestro/instances/c1/subscription",
+		"GET /consumers/weathermaestro/instances/c1/records",
+		"POST /consumers/weathermaestro/instances/c1/offsets",
+		"DELETE /consumers/weathermaestro/instances/c1",
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("Expected requests %v, got %v", want, requests)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("Expected request %s, got %s", want[i], requests[i])
+		}
+	}
+}
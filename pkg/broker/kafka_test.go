@@ -0,0 +1,70 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != kafkaJSONContentType {
+			http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, r.URL.Path)
+
+		offsets := make([]map[string]interface{}, len(body.Records))
+		for i, record := range body.Records {
+			offsets[i] = map[string]interface{}{"partition": 0, "offset": i}
+			if record.Key == "rejected" {
+				offsets[i]["error_code"] = 50002
+				offsets[i]["error"] = "record too large"
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"offsets": offsets})
+	}))
+	defer server.Close()
+
+	publisher, err := NewPublisher(Config{Type: TypeKafka, URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.Publish(context.Background(), []Message{
+		{Subject: "wm.reading", Key: "a", Payload: []byte(`{"value":1}`)},
+		{Subject: "wm.station", Key: "b", Payload: []byte(`{"name":"Garden"}`)},
+		{Subject: "wm.reading", Key: "a", Payload: []byte(`{"value":2}`)},
+	})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(requests) != 2 || requests[0] != "/topics/wm.reading" || requests[1] != "/topics/wm.station" {
+		t.Errorf("Expected one request per topic, got %v", requests)
+	}
+
+	err = publisher.Publish(context.Background(), []Message{{Subject: "wm.reading", Key: "rejected", Payload: []byte(`{}`)}})
+	if err == nil {
+		t.Error("Expected an error for a rejected record")
+	}
+}
+
+func TestNewPublisher_Invalid(t *testing.T) {
+	if _, err := NewPublisher(Config{Type: "amqp", URL: "amqp://localhost"}); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+	if _, err := NewPublisher(Config{Type: TypeKafka, URL: "kafka://localhost:9092"}); err == nil {
+		t.Error("Expected an error for a non HTTP URL")
+	}
+}
@@ -0,0 +1,304 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsInfo is the part of the INFO line of a NATS server the client reads
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	MaxPayload  int  `json:"max_payload"`
+}
+
+// natsConnect are the options of the CONNECT command
+type natsConnect struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name,omitempty"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	Protocol  int    `json:"protocol"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+	AuthToken string `json:"auth_token,omitempty"`
+}
+
+// natsIdleTimeout is the time a subscription waits for data. Servers ping
+// idle clients every two minutes by default.
+const natsIdleTimeout = 5 * time.Minute
+
+// NATSClient speaks the NATS core protocol. Publishing waits for the reply
+// to a PING, so the server processed all messages before Publish returns.
+// A client either publishes or subscribes.
+type NATSClient struct {
+	cfg Config
+
+	mu         sync.Mutex
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int
+}
+
+// NewNATSClient creates a new NATSClient
+func NewNATSClient(cfg Config) *NATSClient {
+	return &NATSClient{cfg: cfg}
+}
+
+// Publish sends messages. A failed connection is replaced once.
+func (c *NATSClient) Publish(ctx context.Context, msgs []Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if c.conn == nil {
+			if err := c.connect(ctx); err != nil {
+				return err
+			}
+		}
+		err := c.publish(msgs)
+		if err == nil {
+			return nil
+		}
+		c.closeConn()
+		if attempt > 0 || errors.Is(err, errPayloadTooLarge) {
+			return fmt.Errorf("failed to publish to %s: %w", c.cfg.URL, err)
+		}
+	}
+}
+
+// errPayloadTooLarge is returned for messages the server doesn't accept
+var errPayloadTooLarge = errors.New("payload exceeds the maximum of the server")
+
+// publish writes the messages and a PING and waits for the PONG. The caller
+// holds mu.
+func (c *NATSClient) publish(msgs []Message) error {
+	var buf []byte
+	for _, msg := range msgs {
+		if c.maxPayload > 0 && len(msg.Payload) > c.maxPayload {
+			return errPayloadTooLarge
+		}
+		buf = fmt.Appendf(buf, "PUB %s %d\r\n", msg.Subject, len(msg.Payload))
+		buf = append(buf, msg.Payload...)
+		buf = append(buf, "\r\n"...)
+	}
+	buf = append(buf, "PING\r\n"...)
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	defer c.conn.SetDeadline(time.Time{})
+	if _, err := c.conn.Write(buf); err != nil {
+		return err
+	}
+	return c.awaitPong()
+}
+
+// awaitPong reads until the server answers a PING, replying to its own
+// PINGs. The caller holds mu and set a deadline.
+func (c *NATSClient) awaitPong() error {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := c.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		}
+		// +OK and INFO updates need no reply
+	}
+}
+
+// Subscribe joins the queue group of a subject, which may contain wildcards,
+// and handles its messages. Messages are delivered at most once: the server
+// doesn't resend messages that arrived while no subscriber was connected.
+func (c *NATSClient) Subscribe(ctx context.Context, subject, group string, handle func(Message)) error {
+	c.mu.Lock()
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	conn, reader := c.conn, c.reader
+	c.mu.Unlock()
+	defer c.Close()
+
+	// Closing the connection ends the read loop
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := fmt.Fprintf(conn, "SUB %s %s 1\r\n", subject, group); err != nil {
+		return c.subscribeErr(ctx, err)
+	}
+	for {
+		conn.SetReadDeadline(time.Now().Add(natsIdleTimeout))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return c.subscribeErr(ctx, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "MSG "):
+			msg, err := readNATSMessage(reader, line)
+			if err != nil {
+				return c.subscribeErr(ctx, err)
+			}
+			handle(msg)
+		case line == "PING":
+			if _, err := conn.Write([]byte("PONG\r\n")); err != nil {
+				return c.subscribeErr(ctx, err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("server error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		}
+	}
+}
+
+// subscribeErr returns nil for errors caused by the end of a subscription
+func (c *NATSClient) subscribeErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("subscription to %s failed: %w", c.cfg.URL, err)
+}
+
+// readNATSMessage reads the payload of a message. The line has the format
+// MSG <subject> <sid> [reply-to] <size>.
+func readNATSMessage(reader *bufio.Reader, line string) (Message, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || len(fields) > 5 {
+		return Message{}, fmt.Errorf("invalid message %q", line)
+	}
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 {
+		return Message{}, fmt.Errorf("invalid message size in %q", line)
+	}
+	payload := make([]byte, size+2)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: fields[1], Payload: payload[:size]}, nil
+}
+
+// Close disconnects from the server
+func (c *NATSClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeConn()
+	return nil
+}
+
+// connect opens the connection, upgrades it to TLS if required and
+// authenticates. The caller holds mu.
+func (c *NATSClient) connect(ctx context.Context) error {
+	address, host, useTLS, err := parseNATSURL(c.cfg.URL)
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.cfg.URL, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read INFO: %w", err)
+	}
+	payload, ok := strings.CutPrefix(strings.TrimRight(line, "\r\n"), "INFO ")
+	if !ok {
+		conn.Close()
+		return fmt.Errorf("unexpected greeting %q instead of INFO", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(payload), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("invalid INFO: %w", err)
+	}
+
+	// The server sends INFO in plain text and expects the TLS handshake
+	// afterwards
+	if useTLS || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn, reader = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	options, _ := json.Marshal(natsConnect{
+		Name:      c.cfg.Name,
+		Lang:      "go",
+		Version:   "1.0.0",
+		Protocol:  1,
+		User:      c.cfg.Username,
+		Pass:      c.cfg.Password,
+		AuthToken: c.cfg.Token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to %s: %w", c.cfg.URL, err)
+	}
+
+	c.conn, c.reader, c.maxPayload = conn, reader, info.MaxPayload
+	if err := c.awaitPong(); err != nil {
+		c.closeConn()
+		return fmt.Errorf("server refused connection: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return nil
+}
+
+// closeConn closes the open connection. The caller holds mu.
+func (c *NATSClient) closeConn() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
+
+// parseNATSURL returns the address of a server URL, its host name and
+// whether TLS is required. The port defaults to 4222.
+func parseNATSURL(rawURL string) (address, host string, useTLS bool, err error) {
+	address = rawURL
+	if scheme, rest, ok := strings.Cut(rawURL, "://"); ok {
+		switch scheme {
+		case "nats":
+		case "tls":
+			useTLS = true
+		default:
+			return "", "", false, fmt.Errorf("unsupported NATS scheme %q", scheme)
+		}
+		address = rest
+	}
+	address = strings.TrimSuffix(address, "/")
+	if address == "" {
+		return "", "", false, errors.New("NATS URL is empty")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "4222")
+	}
+	host, _, _ = net.SplitHostPort(address)
+	return address, host, useTLS, nil
+}
@@ -0,0 +1,173 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATS accepts connections and reports the commands it receives
+type fakeNATS struct {
+	listener net.Listener
+	commands chan string
+	// token is required in CONNECT if set
+	token string
+}
+
+func newFakeNATS(t *testing.T, token string) *fakeNATS {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Skipping test that requires a local listener: %v", err)
+	}
+	s := &fakeNATS{listener: listener, commands: make(chan string, 100), token: token}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeNATS) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeNATS) handle(conn net.Conn) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"max_payload\":1024}\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			if s.token != "" && !strings.Contains(line, `"auth_token":"`+s.token+`"`) {
+				fmt.Fprintf(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+			s.commands <- line
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.commands <- fields[1] + " " + string(payload[:size])
+		case strings.HasPrefix(line, "SUB "):
+			s.commands <- line
+			payload := `{"passkey":"gw"}`
+			fmt.Fprintf(conn, "PING\r\nMSG %s 1 %d\r\n%s\r\n", strings.Fields(line)[1], len(payload), payload)
+		case line == "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+		}
+	}
+}
+
+func (s *fakeNATS) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case c := <-s.commands:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for command")
+		return ""
+	}
+}
+
+func TestNATSClient_Publish(t *testing.T) {
+	server := newFakeNATS(t, "secret")
+	client := NewNATSClient(Config{URL: "nats://" + server.listener.Addr().String(), Token: "secret", Name: "weathermaestro"})
+	defer client.Close()
+
+	err := client.Publish(context.Background(), []Message{
+		{Subject: "wm.reading", Payload: []byte(`{"value":14.2}`)},
+		{Subject: "wm.sensor", Payload: []byte(`{"name":"Garden"}`)},
+	})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if connect := server.next(t); !strings.Contains(connect, `"name":"weathermaestro"`) {
+		t.Errorf("Expected the connection name in %s", connect)
+	}
+	for _, want := range []string{`wm.reading {"value":14.2}`, `wm.sensor {"name":"Garden"}`} {
+		if got := server.next(t); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+
+	if err := client.Publish(context.Background(), []Message{{Subject: "wm.reading", Payload: make([]byte, 2048)}}); err == nil {
+		t.Error("Expected an error for a payload above max_payload")
+	}
+}
+
+func TestNATSClient_Subscribe(t *testing.T) {
+	server := newFakeNATS(t, "")
+	client := NewNATSClient(Config{URL: server.listener.Addr().String()})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var received []Message
+	err := client.Subscribe(ctx, "wm.ingest", "weathermaestro", func(msg Message) {
+		received = append(received, msg)
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	server.next(t)
+	if sub := server.next(t); sub != "SUB wm.ingest weathermaestro 1" {
+		t.Errorf("Expected the subscription to the queue group, got %s", sub)
+	}
+	if len(received) != 1 || received[0].Subject != "wm.ingest" || string(received[0].Payload) != `{"passkey":"gw"}` {
+		t.Errorf("Unexpected messages %+v", received)
+	}
+}
+
+func TestNATSClient_AuthorizationViolation(t *testing.T) {
+	server := newFakeNATS(t, "secret")
+	client := NewNATSClient(Config{URL: server.listener.Addr().String(), Token: "wrong"})
+	defer client.Close()
+
+	err := client.Publish(context.Background(), []Message{{Subject: "wm.reading", Payload: []byte("{}")}})
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("Expected an authorization error, got %v", err)
+	}
+}
+
+func TestParseNATSURL(t *testing.T) {
+	testCases := []struct {
+		url     string
+		address string
+		tls     bool
+	}{
+		{"nats://localhost", "localhost:4222", false},
+		{"tls://nats.example.com:4443", "nats.example.com:4443", true},
+		{"10.0.0.5:4222", "10.0.0.5:4222", false},
+	}
+	for _, tc := range testCases {
+		address, _, useTLS, err := parseNATSURL(tc.url)
+		if err != nil || address != tc.address || useTLS != tc.tls {
+			t.Errorf("parseNATSURL(%q) = %s, %v, %v", tc.url, address, useTLS, err)
+		}
+	}
+	if _, _, _, err := parseNATSURL("http://localhost"); err == nil {
+		t.Error("Expected an error for an http URL")
+	}
+}
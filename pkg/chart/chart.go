@@ -0,0 +1,346 @@
+package chart
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Default chart size in pixels
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 400
+)
+
+// Margins around the plot area in pixels
+const (
+	marginLeft   = 64
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 36
+)
+
+// gapFactor is the multiple of the typical point spacing above which a line
+// is interrupted instead of bridging missing data
+const gapFactor = 3
+
+// palette holds the line colors as RGB, used in series order
+var palette = [][3]uint8{
+	{0x1f, 0x77, 0xb4},
+	{0xd6, 0x27, 0x28},
+	{0x2c, 0xa0, 0x2c},
+	{0xff, 0x7f, 0x0e},
+	{0x94, 0x67, 0xbd},
+	{0x8c, 0x56, 0x4b},
+	{0xe3, 0x77, 0xc2},
+	{0x17, 0xbe, 0xcf},
+}
+
+// Point is a single value of a series
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a named line of the chart. Points must be sorted by time.
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart is a time series line chart
+type Chart struct {
+	Title  string
+	Unit   string
+	Width  int
+	Height int
+	// Location is used for the time axis labels; nil means UTC
+	Location *time.Location
+	Series   []Series
+}
+
+// layout holds the computed geometry of a chart
+type layout struct {
+	width, height            int
+	left, top, right, bottom float64
+
+	tMin, tMax time.Time
+	vMin, vMax float64
+
+	yTicks    []float64
+	yDecimals int
+
+	xTicks     []time.Time
+	timeFormat string
+	loc        *time.Location
+
+	empty bool
+}
+
+// layout computes the plot area, the value and time ranges and the ticks
+func (c *Chart) layout() layout {
+	l := layout{
+		width:  c.Width,
+		height: c.Height,
+		loc:    c.Location,
+	}
+	if l.width <= 0 {
+		l.width = DefaultWidth
+	}
+	if l.height <= 0 {
+		l.height = DefaultHeight
+	}
+	if l.loc == nil {
+		l.loc = time.UTC
+	}
+	l.left = marginLeft
+	l.top = marginTop
+	l.right = float64(l.width - marginRight)
+	l.bottom = float64(l.height - marginBottom)
+
+	first := true
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			if first {
+				l.tMin, l.tMax = p.Time, p.Time
+				l.vMin, l.vMax = p.Value, p.Value
+				first = false
+				continue
+			}
+			if p.Time.Before(l.tMin) {
+				l.tMin = p.Time
+			}
+			if p.Time.After(l.tMax) {
+				l.tMax = p.Time
+			}
+			l.vMin = math.Min(l.vMin, p.Value)
+			l.vMax = math.Max(l.vMax, p.Value)
+		}
+	}
+	if first {
+		l.empty = true
+		return l
+	}
+
+	// A flat or single point range still needs a visible axis
+	if l.vMin == l.vMax {
+		pad := math.Max(math.Abs(l.vMin)*0.1, 1)
+		l.vMin -= pad
+		l.vMax += pad
+	}
+	if !l.tMax.After(l.tMin) {
+		l.tMin = l.tMin.Add(-30 * time.Minute)
+		l.tMax = l.tMax.Add(30 * time.Minute)
+	}
+
+	var step float64
+	l.yTicks, step = niceTicks(l.vMin, l.vMax, int(l.bottom-l.top)/50)
+	l.vMin = math.Min(l.vMin, l.yTicks[0])
+	l.vMax = math.Max(l.vMax, l.yTicks[len(l.yTicks)-1])
+	l.yDecimals = decimalsFor(step)
+
+	l.xTicks, l.timeFormat = timeTicks(l.tMin, l.tMax, int(l.right-l.left)/110, l.loc)
+
+	// Drop ticks whose centered label would be cut off at the image border
+	half := float64(textWidth(l.timeFormat, 1)) / 2
+	ticks := l.xTicks[:0]
+	for _, t := range l.xTicks {
+		if x := l.x(t); x-half >= 0 && x+half <= float64(l.width) {
+			ticks = append(ticks, t)
+		}
+	}
+	l.xTicks = ticks
+	return l
+}
+
+// x maps a time to a horizontal pixel position
+func (l *layout) x(t time.Time) float64 {
+	span := l.tMax.Sub(l.tMin).Seconds()
+	return l.left + t.Sub(l.tMin).Seconds()/span*(l.right-l.left)
+}
+
+// y maps a value to a vertical pixel position
+func (l *layout) y(v float64) float64 {
+	return l.bottom - (v-l.vMin)/(l.vMax-l.vMin)*(l.bottom-l.top)
+}
+
+// formatValue formats a y axis label
+func (l *layout) formatValue(v float64) string {
+	return formatFloat(v, l.yDecimals)
+}
+
+// niceTicks returns evenly spaced round values covering min to max with
+// roughly count ticks, and the tick step
+func niceTicks(min, max float64, count int) ([]float64, float64) {
+	if count < 2 {
+		count = 2
+	}
+	step := niceNumber((max-min)/float64(count-1), true)
+	start := math.Floor(min/step) * step
+	end := math.Ceil(max/step) * step
+
+	var ticks []float64
+	for v := start; v <= end+step/2; v += step {
+		// Avoid -0 and accumulated float noise in labels
+		ticks = append(ticks, math.Round(v/step)*step+0)
+	}
+	return ticks, step
+}
+
+// niceNumber rounds x to 1, 2, 5 or 10 times a power of ten
+func niceNumber(x float64, round bool) float64 {
+	exp := math.Floor(math.Log10(x))
+	f := x / math.Pow(10, exp)
+
+	var nice float64
+	switch {
+	case round && f < 1.5, !round && f <= 1:
+		nice = 1
+	case round && f < 3, !round && f <= 2:
+		nice = 2
+	case round && f < 7, !round && f <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+// decimalsFor returns the number of decimals needed to tell ticks apart
+func decimalsFor(step float64) int {
+	if step >= 1 {
+		return 0
+	}
+	return int(math.Ceil(-math.Log10(step)))
+}
+
+// timeStep is a candidate spacing of time axis ticks
+type timeStep struct {
+	duration time.Duration
+	months   int
+	format   string
+}
+
+var timeSteps = []timeStep{
+	{duration: time.Minute, format: "15:04"},
+	{duration: 5 * time.Minute, format: "15:04"},
+	{duration: 15 * time.Minute, format: "15:04"},
+	{duration: 30 * time.Minute, format: "15:04"},
+	{duration: time.Hour, format: "15:04"},
+	{duration: 3 * time.Hour, format: "15:04"},
+	{duration: 6 * time.Hour, format: "02.01. 15:04"},
+	{duration: 12 * time.Hour, format: "02.01. 15:04"},
+	{duration: 24 * time.Hour, format: "02.01."},
+	{duration: 2 * 24 * time.Hour, format: "02.01."},
+	{duration: 7 * 24 * time.Hour, format: "02.01."},
+	{duration: 14 * 24 * time.Hour, format: "02.01."},
+	{months: 1, format: "01/2006"},
+	{months: 3, format: "01/2006"},
+	{months: 6, format: "01/2006"},
+	{months: 12, format: "2006"},
+}
+
+// timeTicks returns tick times between min and max at the smallest step that
+// yields at most count ticks, aligned to the step in loc, and their label format
+func timeTicks(min, max time.Time, count int, loc *time.Location) ([]time.Time, string) {
+	if count < 2 {
+		count = 2
+	}
+	span := max.Sub(min)
+
+	step := timeSteps[len(timeSteps)-1]
+	for _, s := range timeSteps {
+		d := s.duration
+		if s.months > 0 {
+			d = time.Duration(s.months) * 30 * 24 * time.Hour
+		}
+		if span/d <= time.Duration(count) {
+			step = s
+			break
+		}
+	}
+
+	local := min.In(loc)
+	var t time.Time
+	if step.months > 0 {
+		month := (int(local.Month())-1)/step.months*step.months + 1
+		t = time.Date(local.Year(), time.Month(month), 1, 0, 0, 0, 0, loc)
+	} else {
+		t = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		if step.duration == 7*24*time.Hour || step.duration == 14*24*time.Hour {
+			// Weekly ticks start on Monday
+			t = t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+		}
+	}
+
+	var ticks []time.Time
+	for ; !t.After(max); t = nextTick(t, step) {
+		if !t.Before(min) {
+			ticks = append(ticks, t)
+		}
+	}
+
+	// Show the year when the range crosses a year boundary
+	format := step.format
+	if format == "02.01." && min.In(loc).Year() != max.In(loc).Year() {
+		format = "02.01.06"
+	}
+	return ticks, format
+}
+
+// nextTick advances t by one step. Day based steps use calendar days so
+// ticks stay at midnight across DST changes.
+func nextTick(t time.Time, step timeStep) time.Time {
+	switch {
+	case step.months > 0:
+		return t.AddDate(0, step.months, 0)
+	case step.duration >= 24*time.Hour:
+		return t.AddDate(0, 0, int(step.duration/(24*time.Hour)))
+	}
+	return t.Add(step.duration)
+}
+
+// segments splits the points of a series into continuous lines. A line is
+// interrupted where the time between two points is much larger than usual.
+func segments(points []Point) [][]Point {
+	var valid []Point
+	for _, p := range points {
+		if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+			valid = append(valid, p)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	deltas := make([]time.Duration, 0, len(valid)-1)
+	for i := 1; i < len(valid); i++ {
+		deltas = append(deltas, valid[i].Time.Sub(valid[i-1].Time))
+	}
+	var maxDelta time.Duration
+	if len(deltas) > 0 {
+		sorted := append([]time.Duration(nil), deltas...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		maxDelta = sorted[len(sorted)/2] * gapFactor
+	}
+
+	var result [][]Point
+	current := []Point{valid[0]}
+	for i, d := range deltas {
+		if maxDelta > 0 && d > maxDelta {
+			result = append(result, current)
+			current = nil
+		}
+		current = append(current, valid[i+1])
+	}
+	return append(result, current)
+}
+
+// formatFloat formats v with the given number of decimals
+func formatFloat(v float64, decimals int) string {
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
@@ -0,0 +1,155 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testChart(points int) *Chart {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	temperature := Series{Name: "Outdoor"}
+	humidity := Series{Name: "Indoor"}
+	for i := 0; i < points; i++ {
+		t := start.Add(time.Duration(i) * 10 * time.Minute)
+		temperature.Points = append(temperature.Points, Point{Time: t, Value: float64(i%20) - 5})
+		humidity.Points = append(humidity.Points, Point{Time: t, Value: 20})
+	}
+	return &Chart{
+		Title:  "Temperature <test>",
+		Unit:   "°C",
+		Width:  640,
+		Height: 320,
+		Series: []Series{temperature, humidity},
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	ticks, step := niceTicks(-3.2, 17.8, 5)
+
+	if step != 5 {
+		t.Errorf("Expected step 5, got %f", step)
+	}
+	expected := []float64{-5, 0, 5, 10, 15, 20}
+	if len(ticks) != len(expected) {
+		t.Fatalf("Expected ticks %v, got %v", expected, ticks)
+	}
+	for i := range expected {
+		if ticks[i] != expected[i] {
+			t.Errorf("Expected ticks %v, got %v", expected, ticks)
+			break
+		}
+	}
+
+	if _, step := niceTicks(1012.1, 1013.4, 5); decimalsFor(step) != 1 {
+		t.Errorf("Expected one decimal for step %f", step)
+	}
+}
+
+func TestTimeTicks_AlignedToStep(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 7, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	ticks, format := timeTicks(start, end, 6, time.UTC)
+
+	if format != "02.01. 15:04" {
+		t.Errorf("Unexpected format %q", format)
+	}
+	if len(ticks) == 0 || len(ticks) > 6 {
+		t.Fatalf("Expected up to 6 ticks, got %d", len(ticks))
+	}
+	for _, tick := range ticks {
+		if tick.Before(start) || tick.After(end) || tick.Hour()%6 != 0 || tick.Minute() != 0 {
+			t.Errorf("Tick %s not aligned to 6 hours within range", tick)
+		}
+	}
+}
+
+func TestTimeTicks_Months(t *testing.T) {
+	start := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	ticks, format := timeTicks(start, end, 6, time.UTC)
+
+	if format != "01/2006" {
+		t.Errorf("Unexpected format %q", format)
+	}
+	for _, tick := range ticks {
+		if tick.Day() != 1 {
+			t.Errorf("Expected ticks on the first of a month, got %s", tick)
+		}
+	}
+}
+
+func TestSegments_BreakOnGap(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, Point{Time: start.Add(time.Duration(i) * time.Minute), Value: 1})
+	}
+	// One hour outage
+	for i := 70; i < 75; i++ {
+		points = append(points, Point{Time: start.Add(time.Duration(i) * time.Minute), Value: 1})
+	}
+
+	segs := segments(points)
+
+	if len(segs) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(segs))
+	}
+	if len(segs[0]) != 10 || len(segs[1]) != 5 {
+		t.Errorf("Unexpected segment sizes %d and %d", len(segs[0]), len(segs[1]))
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testChart(100).RenderSVG(&buf); err != nil {
+		t.Fatalf("Failed to render SVG: %v", err)
+	}
+	svg := buf.String()
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(strings.TrimSpace(svg), "</svg>") {
+		t.Error("Expected a complete SVG document")
+	}
+	if strings.Count(svg, "<polyline") != 2 {
+		t.Errorf("Expected 2 lines, got %d", strings.Count(svg, "<polyline"))
+	}
+	if !strings.Contains(svg, "Temperature &lt;test&gt;") {
+		t.Error("Expected escaped title")
+	}
+	if !strings.Contains(svg, ">Outdoor<") || !strings.Contains(svg, ">Indoor<") {
+		t.Error("Expected legend with series names")
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testChart(100).RenderPNG(&buf); err != nil {
+		t.Fatalf("Failed to render PNG: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 640 || b.Dy() != 320 {
+		t.Errorf("Expected 640x320 image, got %dx%d", b.Dx(), b.Dy())
+	}
+}
+
+func TestRender_Empty(t *testing.T) {
+	c := &Chart{Title: "Empty"}
+
+	var svg bytes.Buffer
+	if err := c.RenderSVG(&svg); err != nil || !strings.Contains(svg.String(), "No data") {
+		t.Errorf("Expected empty SVG chart, got %v", err)
+	}
+
+	var img bytes.Buffer
+	if err := c.RenderPNG(&img); err != nil {
+		t.Errorf("Failed to render empty PNG: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Colors of the PNG chart elements
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorGrid       = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	colorAxis       = color.RGBA{0x99, 0x99, 0x99, 0xff}
+	colorLabel      = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	colorTitle      = color.RGBA{0x22, 0x22, 0x22, 0xff}
+)
+
+// RenderPNG writes the chart as a PNG image
+func (c *Chart) RenderPNG(w io.Writer) error {
+	l := c.layout()
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	fillRect(img, 0, 0, l.width, l.height, colorBackground)
+
+	if c.Title != "" {
+		drawText(img, marginLeft, 12, c.Title, 2, colorTitle)
+	}
+
+	if l.empty {
+		text := "No data"
+		drawText(img, (l.width-textWidth(text, 1))/2, l.height/2-glyphHeight/2, text, 1, colorAxis)
+		return png.Encode(w, img)
+	}
+
+	// Grid and y axis labels
+	for _, v := range l.yTicks {
+		y := int(math.Round(l.y(v)))
+		drawHLine(img, int(l.left), int(l.right), y, colorGrid)
+		label := l.formatValue(v)
+		drawText(img, int(l.left)-6-textWidth(label, 1), y-glyphHeight/2, label, 1, colorLabel)
+	}
+	if c.Unit != "" {
+		drawText(img, int(l.left)-6-textWidth(c.Unit, 1), int(l.top)-16, c.Unit, 1, colorLabel)
+	}
+
+	// Time axis labels
+	for _, t := range l.xTicks {
+		x := int(math.Round(l.x(t)))
+		label := t.In(l.loc).Format(l.timeFormat)
+		drawText(img, x-textWidth(label, 1)/2, int(l.bottom)+8, label, 1, colorLabel)
+	}
+
+	// Axes
+	drawHLine(img, int(l.left), int(l.right), int(l.bottom), colorAxis)
+	drawLine(img, l.left, l.top, l.left, l.bottom, colorAxis, 1)
+
+	// Lines
+	for i, s := range c.Series {
+		rgb := palette[i%len(palette)]
+		col := color.RGBA{rgb[0], rgb[1], rgb[2], 0xff}
+		for _, seg := range segments(s.Points) {
+			if len(seg) == 1 {
+				x, y := int(math.Round(l.x(seg[0].Time))), int(math.Round(l.y(seg[0].Value)))
+				fillRect(img, x-2, y-2, x+3, y+3, col)
+				continue
+			}
+			for j := 1; j < len(seg); j++ {
+				drawLine(img, l.x(seg[j-1].Time), l.y(seg[j-1].Value), l.x(seg[j].Time), l.y(seg[j].Value), col, 2)
+			}
+		}
+	}
+
+	// Legend for more than one series
+	if len(c.Series) > 1 {
+		x := int(l.right)
+		for i := len(c.Series) - 1; i >= 0; i-- {
+			name := c.Series[i].Name
+			x -= textWidth(name, 1) + 24
+			rgb := palette[i%len(palette)]
+			fillRect(img, x, int(l.top)-22, x+10, int(l.top)-12, color.RGBA{rgb[0], rgb[1], rgb[2], 0xff})
+			drawText(img, x+14, int(l.top)-21, name, 1, colorLabel)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// fillRect fills the rectangle from (x0, y0) to (x1, y1), exclusive
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	r := image.Rect(x0, y0, x1, y1).Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawHLine draws a horizontal line from x0 to x1
+func drawHLine(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	fillRect(img, x0, y, x1+1, y+1, c)
+}
+
+// drawLine draws a line with the given thickness using Bresenham's algorithm
+func drawLine(img *image.RGBA, fx0, fy0, fx1, fy1 float64, c color.RGBA, thickness int) {
+	x0, y0 := int(math.Round(fx0)), int(math.Round(fy0))
+	x1, y1 := int(math.Round(fx1)), int(math.Round(fy1))
+
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	offset := thickness / 2
+	err := dx + dy
+	for {
+		fillRect(img, x0-offset, y0-offset, x0-offset+thickness, y0-offset+thickness, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawText draws s with the bitmap font; (x, y) is the top left corner
+func drawText(img *image.RGBA, x, y int, s string, scale int, c color.RGBA) {
+	for _, r := range s {
+		g := glyph(r)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row]&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				fillRect(img, px, py, px+scale, py+scale, c)
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,90 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// RenderSVG writes the chart as an SVG document
+func (c *Chart) RenderSVG(w io.Writer) error {
+	l := c.layout()
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+
+	if c.Title != "" {
+		fmt.Fprintf(b, `<text x="%d" y="22" font-size="15" font-weight="bold" fill="#222222">%s</text>`+"\n", marginLeft, escape(c.Title))
+	}
+
+	if l.empty {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" fill="#888888">No data</text>`+"\n", l.width/2, l.height/2)
+		fmt.Fprintln(b, `</svg>`)
+		return b.Flush()
+	}
+
+	// Grid and y axis labels
+	for _, v := range l.yTicks {
+		y := l.y(v)
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e5e5e5"/>`+"\n", l.left, y, l.right, y)
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end" fill="#555555">%s</text>`+"\n", l.left-6, y+4, l.formatValue(v))
+	}
+	if c.Unit != "" {
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end" fill="#555555">%s</text>`+"\n", l.left-6, l.top-12, escape(c.Unit))
+	}
+
+	// Time axis labels
+	for _, t := range l.xTicks {
+		x := l.x(t)
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#f0f0f0"/>`+"\n", x, l.top, x, l.bottom)
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="middle" fill="#555555">%s</text>`+"\n", x, l.bottom+16, t.In(l.loc).Format(l.timeFormat))
+	}
+
+	// Axes
+	fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999999"/>`+"\n", l.left, l.bottom, l.right, l.bottom)
+	fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#999999"/>`+"\n", l.left, l.top, l.left, l.bottom)
+
+	// Lines
+	for i, s := range c.Series {
+		color := svgColor(palette[i%len(palette)])
+		for _, seg := range segments(s.Points) {
+			if len(seg) == 1 {
+				fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="2" fill="%s"/>`+"\n", l.x(seg[0].Time), l.y(seg[0].Value), color)
+				continue
+			}
+			points := make([]string, len(seg))
+			for j, p := range seg {
+				points[j] = fmt.Sprintf("%.1f,%.1f", l.x(p.Time), l.y(p.Value))
+			}
+			fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`+"\n", color, strings.Join(points, " "))
+		}
+	}
+
+	// Legend for more than one series
+	if len(c.Series) > 1 {
+		x := l.right
+		for i := len(c.Series) - 1; i >= 0; i-- {
+			name := c.Series[i].Name
+			x -= float64(len(name))*6.5 + 24
+			fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="10" height="10" fill="%s"/>`+"\n", x, l.top-22, svgColor(palette[i%len(palette)]))
+			fmt.Fprintf(b, `<text x="%.1f" y="%.1f" fill="#333333">%s</text>`+"\n", x+14, l.top-13, escape(name))
+		}
+	}
+
+	fmt.Fprintln(b, `</svg>`)
+	return b.Flush()
+}
+
+// svgColor formats an RGB color as hex
+func svgColor(c [3]uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
+
+// escape escapes text for use in SVG
+func escape(s string) string {
+	return html.EscapeString(s)
+}
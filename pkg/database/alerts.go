@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// alertRuleColumns are the columns scanned by scanAlertRule
+const alertRuleColumns = `id, station_id, name, sensor_type, location, operator, threshold, hysteresis, channels,
+    enabled, locale, subject_template, body_template, active, active_since, last_value, evaluated_at,
+    created_at, updated_at`
+
+// scanAlertRule scans a row selected with alertRuleColumns
+func scanAlertRule(row rowScanner) (*models.AlertRule, error) {
+	var r models.AlertRule
+	var activeSince, evaluatedAt sql.NullTime
+	var lastValue sql.NullFloat64
+	err := row.Scan(&r.ID, &r.StationID, &r.Name, &r.SensorType, &r.Location, &r.Operator,
+		&r.Threshold, &r.Hysteresis, pq.Array(&r.Channels), &r.Enabled,
+		&r.Locale, &r.SubjectTemplate, &r.BodyTemplate, &r.Active,
+		&activeSince, &lastValue, &evaluatedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if r.Channels == nil {
+		r.Channels = []string{}
+	}
+	if activeSince.Valid {
+		r.ActiveSince = &activeSince.Time
+	}
+	if lastValue.Valid {
+		r.LastValue = &lastValue.Float64
+	}
+	if evaluatedAt.Valid {
+		r.EvaluatedAt = &evaluatedAt.Time
+	}
+	return &r, nil
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// queryAlertRules runs a query selecting alertRuleColumns
+func (dm *DatabaseManager) queryAlertRules(ctx context.Context, query string, args ...interface{}) ([]models.AlertRule, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.AlertRule{}
+	for rows.Next() {
+		r, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert rule: %w", err)
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// CreateAlertRule creates an alert rule of a station. The alert starts
+// cleared and is evaluated with the next readings.
+func (dm *DatabaseManager) CreateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	query := insertForStation("alert_rules",
+		"name, sensor_type, location, operator, threshold, hysteresis, channels, enabled, locale, subject_template, body_template",
+		"$2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12",
+	) + `
+        RETURNING ` + alertRuleColumns
+
+	created, err := scanAlertRule(dm.QueryRowWithHealthCheck(ctx, query,
+		rule.StationID, rule.Name, rule.SensorType, rule.Location, rule.Operator,
+		rule.Threshold, rule.Hysteresis, pq.Array(rule.Channels), rule.Enabled,
+		rule.Locale, rule.SubjectTemplate, rule.BodyTemplate,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("station %w", ErrNotFound)
+	}
+	if isUniqueViolation(err) {
+		return ErrAlertRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	*rule = *created
+	return nil
+}
+
+// GetAlertRules retrieves the alert rules of a station, or of all stations
+// when stationID is nil, ordered by station and name
+func (dm *DatabaseManager) GetAlertRules(ctx context.Context, stationID *uuid.UUID) ([]models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules
+        WHERE $1::uuid IS NULL OR station_id = $1
+        ORDER BY station_id, name`
+	return dm.queryAlertRules(ctx, query, stationID)
+}
+
+// GetEnabledAlertRules retrieves the enabled alert rules of a station
+func (dm *DatabaseManager) GetEnabledAlertRules(ctx context.Context, stationID uuid.UUID) ([]models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE station_id = $1 AND enabled ORDER BY name`
+	return dm.queryAlertRules(ctx, query, stationID)
+}
+
+// GetAlertRule retrieves an alert rule of a station
+func (dm *DatabaseManager) GetAlertRule(ctx context.Context, stationID, id uuid.UUID) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE station_id = $1 AND id = $2`
+
+	rule, err := scanAlertRule(dm.QueryRowWithHealthCheck(ctx, query, stationID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("alert rule %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// GetAlertRuleByID retrieves an alert rule of any station
+func (dm *DatabaseManager) GetAlertRuleByID(ctx context.Context, id uuid.UUID) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1`
+
+	rule, err := scanAlertRule(dm.QueryRowWithHealthCheck(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("alert rule %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateAlertRule changes the condition, channels, notification texts and
+// enabled state of a rule. Changing the condition or disabling the rule
+// clears the alert, it is raised again by the next matching reading.
+func (dm *DatabaseManager) UpdateAlertRule(ctx context.Context, rule *models.AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE alert_rules
+        SET name = $3, sensor_type = $4, location = $5, operator = $6, threshold = $7, hysteresis = $8,
+            channels = $9, enabled = $10, locale = $11, subject_template = $12, body_template = $13,
+            active = active AND $10 AND sensor_type = $4 AND location = $5 AND operator = $6 AND threshold = $7,
+            active_since = CASE WHEN active AND $10 AND sensor_type = $4 AND location = $5 AND operator = $6 AND threshold = $7
+                THEN active_since END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE station_id = $1 AND id = $2
+        RETURNING ` + alertRuleColumns
+
+	updated, err := scanAlertRule(dm.QueryRowWithHealthCheck(ctx, query,
+		rule.StationID, rule.ID, rule.Name, rule.SensorType, rule.Location, rule.Operator,
+		rule.Threshold, rule.Hysteresis, pq.Array(rule.Channels), rule.Enabled,
+		rule.Locale, rule.SubjectTemplate, rule.BodyTemplate,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("alert rule %w", ErrNotFound)
+	}
+	if isUniqueViolation(err) {
+		return ErrAlertRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	*rule = *updated
+	return nil
+}
+
+// DeleteAlertRule deletes an alert rule and returns it
+func (dm *DatabaseManager) DeleteAlertRule(ctx context.Context, stationID, id uuid.UUID) (*models.AlertRule, error) {
+	query := `DELETE FROM alert_rules WHERE station_id = $1 AND id = $2 RETURNING ` + alertRuleColumns
+
+	rule, err := scanAlertRule(dm.QueryRowWithHealthCheck(ctx, query, stationID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("alert rule %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// SetAlertState stores the result of evaluating a rule. ActiveSince is
+// kept while the alert stays active.
+func (dm *DatabaseManager) SetAlertState(ctx context.Context, id uuid.UUID, active bool, value float64, at time.Time) error {
+	query := `
+        UPDATE alert_rules
+        SET active = $2, last_value = $3, evaluated_at = $4,
+            active_since = CASE WHEN NOT $2 THEN NULL WHEN active THEN active_since ELSE $4 END
+        WHERE id = $1`
+
+	if _, err := dm.ExecWithHealthCheck(ctx, query, id, active, value, at.UTC()); err != nil {
+		return fmt.Errorf("failed to store alert state: %w", err)
+	}
+	return nil
+}
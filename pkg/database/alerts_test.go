@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestAlertRules(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	rule := &models.AlertRule{
+		StationID:  station.ID,
+		Name:       "frost_warning",
+		SensorType: models.SensorTypeTemperatureOutdoor,
+		Operator:   models.AlertOperatorBelow,
+		Threshold:  0.5,
+		Hysteresis: 1,
+		Channels:   []string{"email"},
+		Enabled:    true,
+	}
+	if err := dm.CreateAlertRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAlertRule() error = %v", err)
+	}
+	if rule.ID == uuid.Nil || rule.Active || len(rule.Channels) != 1 {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	duplicate := *rule
+	if err := dm.CreateAlertRule(ctx, &duplicate); !errors.Is(err, ErrAlertRuleExists) {
+		t.Errorf("CreateAlertRule() for duplicate name error = %v, want ErrAlertRuleExists", err)
+	}
+	missing := &models.AlertRule{StationID: uuid.New(), Name: "frost", SensorType: "Temperature", Operator: models.AlertOperatorBelow}
+	if err := dm.CreateAlertRule(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CreateAlertRule() for missing station error = %v, want ErrNotFound", err)
+	}
+
+	raisedAt := time.Now().UTC().Truncate(time.Second)
+	if err := dm.SetAlertState(ctx, rule.ID, true, -1.5, raisedAt); err != nil {
+		t.Fatalf("SetAlertState() error = %v", err)
+	}
+	if err := dm.SetAlertState(ctx, rule.ID, true, -2, raisedAt.Add(time.Minute)); err != nil {
+		t.Fatalf("SetAlertState() error = %v", err)
+	}
+	rule, err := dm.GetAlertRule(ctx, station.ID, rule.ID)
+	if err != nil {
+		t.Fatalf("GetAlertRule() error = %v", err)
+	}
+	if !rule.Active || rule.ActiveSince == nil || !rule.ActiveSince.Equal(raisedAt) || *rule.LastValue != -2 {
+		t.Errorf("unexpected state: %+v", rule)
+	}
+
+	// Changing the channels or texts keeps the alert, changing the condition clears it
+	rule.Channels = []string{"email", "webhook"}
+	rule.Locale, rule.SubjectTemplate = "de", "Frost in {{.Station}}"
+	if err := dm.UpdateAlertRule(ctx, rule); err != nil || !rule.Active || rule.SubjectTemplate != "Frost in {{.Station}}" {
+		t.Fatalf("UpdateAlertRule() = %+v, error = %v", rule, err)
+	}
+	rule.Threshold = 0
+	if err := dm.UpdateAlertRule(ctx, rule); err != nil || rule.Active || rule.ActiveSince != nil {
+		t.Fatalf("UpdateAlertRule() = %+v, error = %v", rule, err)
+	}
+
+	all, err := dm.GetAlertRules(ctx, nil)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("GetAlertRules() = %+v, error = %v", all, err)
+	}
+	rule.Enabled = false
+	if err := dm.UpdateAlertRule(ctx, rule); err != nil {
+		t.Fatalf("UpdateAlertRule() error = %v", err)
+	}
+	if enabled, _ := dm.GetEnabledAlertRules(ctx, station.ID); len(enabled) != 0 {
+		t.Errorf("expected no enabled rules, got %+v", enabled)
+	}
+
+	if _, err := dm.DeleteAlertRule(ctx, station.ID, rule.ID); err != nil {
+		t.Fatalf("DeleteAlertRule() error = %v", err)
+	}
+	if _, err := dm.DeleteAlertRule(ctx, station.ID, rule.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteAlertRule() twice error = %v, want ErrNotFound", err)
+	}
+}
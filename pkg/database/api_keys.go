@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// apiKeyColumns are the columns scanned by scanAPIKey
+const apiKeyColumns = `id, name, prefix, scopes, created_at, last_used_at, revoked_at`
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var k models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		pq.Array(&k.Scopes),
+		&k.CreatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return &k, nil
+}
+
+// CreateAPIKey generates a new key with the given scopes. The returned key
+// string is only available here; the database keeps its hash.
+func (dm *DatabaseManager) CreateAPIKey(ctx context.Context, name string, scopes []string) (*models.APIKey, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("name must not be empty")
+	}
+	if err := models.ValidateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := models.APIKeyPrefix + secret
+
+	query := `
+        INSERT INTO api_keys (name, prefix, key_hash, scopes)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + apiKeyColumns
+
+	created, err := scanAPIKey(dm.QueryRowWithHealthCheck(ctx, query,
+		name,
+		key[:len(models.APIKeyPrefix)+8],
+		hashToken(key),
+		pq.Array(scopes),
+	))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return created, key, nil
+}
+
+// GetAPIKeys retrieves all API keys including revoked ones, newest first
+func (dm *DatabaseManager) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// AuthenticateAPIKey returns the active key matching the key string and
+// records its use. Unknown and revoked keys are reported as not found.
+func (dm *DatabaseManager) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	query := `
+        UPDATE api_keys SET last_used_at = NOW()
+        WHERE key_hash = $1 AND revoked_at IS NULL
+        RETURNING ` + apiKeyColumns
+
+	apiKey, err := scanAPIKey(dm.QueryRowWithHealthCheck(ctx, query, hashToken(key)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query API key: %w", err)
+	}
+	return apiKey, nil
+}
+
+// RevokeAPIKey revokes a key; revoking a revoked key keeps the first
+// revocation time
+func (dm *DatabaseManager) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	query := `
+        UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+        WHERE id = $1
+        RETURNING ` + apiKeyColumns
+
+	apiKey, err := scanAPIKey(dm.QueryRowWithHealthCheck(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return apiKey, nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+
+	created, key, err := dm.CreateAPIKey(ctx, "grafana", []string{models.ScopeReadReadings})
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if !strings.HasPrefix(key, created.Prefix) || !strings.HasPrefix(key, models.APIKeyPrefix) {
+		t.Errorf("Expected key %s to start with prefix %s", key, created.Prefix)
+	}
+
+	authenticated, err := dm.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("Failed to authenticate API key: %v", err)
+	}
+	if authenticated.ID != created.ID || authenticated.LastUsedAt == nil || !authenticated.HasScope(models.ScopeReadReadings) {
+		t.Errorf("Unexpected authenticated key %+v", authenticated)
+	}
+
+	if _, err := dm.AuthenticateAPIKey(ctx, key+"x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown key, got %v", err)
+	}
+
+	revoked, err := dm.RevokeAPIKey(ctx, created.ID)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("Failed to revoke API key: %+v, %v", revoked, err)
+	}
+	if _, err := dm.AuthenticateAPIKey(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected revoked key to be rejected, got %v", err)
+	}
+
+	keys, err := dm.GetAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list API keys: %v", err)
+	}
+	found := false
+	for _, k := range keys {
+		found = found || k.ID == created.ID
+	}
+	if !found {
+		t.Error("Expected revoked key in listing")
+	}
+
+	if _, err := dm.RevokeAPIKey(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCreateAPIKey_InvalidScope(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	if _, _, err := dm.CreateAPIKey(context.Background(), "station", []string{"write:all"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
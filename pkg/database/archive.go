@@ -0,0 +1,281 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ErrArchiveChanged is returned when readings of a month changed after it
+// was exported, so its archive is incomplete
+var ErrArchiveChanged = errors.New("readings changed since the export")
+
+// ArchivableMonths returns the first days of the months before cutoff that
+// have readings. cutoff must be the first day of a month.
+func (dm *DatabaseManager) ArchivableMonths(ctx context.Context, cutoff time.Time) ([]time.Time, error) {
+	rows, err := dm.ch.Conn().Query(ctx, `
+		SELECT DISTINCT toStartOfMonth(date_utc) AS month
+		FROM sensor_readings
+		WHERE date_utc < ?
+		ORDER BY month`, cutoff.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query months: %w", err)
+	}
+	defer rows.Close()
+
+	var months []time.Time
+	for rows.Next() {
+		var month time.Time
+		if err := rows.Scan(&month); err != nil {
+			return nil, err
+		}
+		months = append(months, time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC))
+	}
+	return months, rows.Err()
+}
+
+// CountMonthReadings returns the number of readings of a month
+func (dm *DatabaseManager) CountMonthReadings(ctx context.Context, month time.Time) (int64, error) {
+	var count uint64
+	err := dm.ch.Conn().QueryRow(ctx, `SELECT count() FROM sensor_readings WHERE date_utc >= ? AND date_utc < ?`,
+		month.UTC(), month.UTC().AddDate(0, 1, 0)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count readings: %w", err)
+	}
+	return int64(count), nil
+}
+
+// WriteMonthReadingsJSON writes the readings of a month as one JSON object
+// per line in the order of the backup export and returns their number
+func (dm *DatabaseManager) WriteMonthReadingsJSON(ctx context.Context, month time.Time, w io.Writer) (int64, error) {
+	return dm.writeReadingsJSON(ctx, w, nil, `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated
+		FROM sensor_readings
+		WHERE date_utc >= ? AND date_utc < ?
+		ORDER BY sensor_id, date_utc, value`, month.UTC(), month.UTC().AddDate(0, 1, 0))
+}
+
+// archiveStagingTable holds the partition of a month while it is dropped
+const archiveStagingTable = "sensor_readings_archiving"
+
+// DropMonthReadings deletes the readings of an archived month. expected is
+// the number of archived readings. The partition of the month is moved to a
+// staging table in one step and counted there, so readings inserted
+// meanwhile can't be dropped unarchived: with a different number the
+// partition is moved back and ErrArchiveChanged returned. The daily
+// rollups of the month are kept.
+func (dm *DatabaseManager) DropMonthReadings(ctx context.Context, month time.Time, expected int64) error {
+	if err := dm.RecoverArchiveStaging(ctx); err != nil {
+		return err
+	}
+	conn := dm.ch.Conn()
+	// Staging has the structure of the readings table at the time of the
+	// drop, which moving partitions requires
+	if err := conn.Exec(ctx, "DROP TABLE IF EXISTS "+archiveStagingTable); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", archiveStagingTable, err)
+	}
+	if err := conn.Exec(ctx, "CREATE TABLE "+archiveStagingTable+" AS sensor_readings"); err != nil {
+		return fmt.Errorf("failed to create %s: %w", archiveStagingTable, err)
+	}
+
+	// Partitions are months, see ensureSchema; the ID is digits only
+	partition := month.UTC().Format("200601")
+	query := fmt.Sprintf("ALTER TABLE sensor_readings MOVE PARTITION %s TO TABLE %s", partition, archiveStagingTable)
+	if err := conn.Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to move readings: %w", err)
+	}
+
+	var count uint64
+	if err := conn.QueryRow(ctx, "SELECT count() FROM "+archiveStagingTable).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count moved readings: %w", err)
+	}
+	if int64(count) != expected {
+		if err := dm.RecoverArchiveStaging(ctx); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %d readings archived, %d stored", ErrArchiveChanged, expected, count)
+	}
+
+	rows, err := conn.Query(ctx, "SELECT DISTINCT sensor_id FROM "+archiveStagingTable)
+	if err != nil {
+		return fmt.Errorf("failed to query sensors: %w", err)
+	}
+	var touched []models.SensorReading
+	for rows.Next() {
+		r := models.SensorReading{DateUTC: month.UTC()}
+		if err := rows.Scan(&r.SensorID); err != nil {
+			rows.Close()
+			return err
+		}
+		touched = append(touched, r)
+	}
+	rows.Close()
+
+	if err := conn.Exec(ctx, "DROP TABLE "+archiveStagingTable+" SYNC"); err != nil {
+		return fmt.Errorf("failed to drop readings: %w", err)
+	}
+	dm.invalidateBuckets(touched)
+	return nil
+}
+
+// RecoverArchiveStaging moves the readings left in the staging table by an
+// interrupted drop back to the readings table
+func (dm *DatabaseManager) RecoverArchiveStaging(ctx context.Context) error {
+	conn := dm.ch.Conn()
+	rows, err := conn.Query(ctx, `
+		SELECT DISTINCT partition_id
+		FROM system.parts
+		WHERE database = currentDatabase() AND table = ? AND active`, archiveStagingTable)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", archiveStagingTable, err)
+	}
+	var partitions []string
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			rows.Close()
+			return err
+		}
+		partitions = append(partitions, partition)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, partition := range partitions {
+		query := fmt.Sprintf("ALTER TABLE %s MOVE PARTITION ID '%s' TO TABLE sensor_readings", archiveStagingTable, partition)
+		if err := conn.Exec(ctx, query); err != nil {
+			return fmt.Errorf("failed to move readings of partition %s back: %w", partition, err)
+		}
+	}
+	return nil
+}
+
+// RestoreReadings stores archived readings with their IDs. The daily
+// rollups of the restored days must be recomputed afterwards, as they still
+// contain the readings.
+func (dm *DatabaseManager) RestoreReadings(ctx context.Context, readings []models.SensorReading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+
+	batch, err := dm.ch.Conn().PrepareBatch(ctx, `INSERT INTO sensor_readings (id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare reading batch: %w", err)
+	}
+	for _, r := range readings {
+		if err := batch.Append(r.ID, r.SensorID, r.Value, r.Unit, r.RawValue, r.RawUnit, r.DateUTC.UTC(), r.Estimated); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("failed to append reading: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send reading batch: %w", err)
+	}
+	dm.invalidateBuckets(readings)
+	return nil
+}
+
+// SaveReadingArchive records an archived month, replacing an earlier
+// archive of it. An archive is saved as pending before its readings are
+// dropped, see CompleteReadingArchive.
+func (dm *DatabaseManager) SaveReadingArchive(ctx context.Context, a *models.ReadingArchive) error {
+	err := dm.QueryRowWithHealthCheck(ctx, `
+        INSERT INTO reading_archives (month, object_key, readings, size, sha256, pending)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (month) DO UPDATE
+        SET object_key = $2, readings = $3, size = $4, sha256 = $5, pending = $6,
+            archived_at = CURRENT_TIMESTAMP, restored_at = NULL
+        RETURNING archived_at`,
+		a.Month.UTC(), a.ObjectKey, a.Readings, a.Size, a.SHA256, a.Pending,
+	).Scan(&a.ArchivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save reading archive: %w", err)
+	}
+	a.RestoredAt = nil
+	return nil
+}
+
+// CompleteReadingArchive records that the readings of a pending archive
+// were dropped
+func (dm *DatabaseManager) CompleteReadingArchive(ctx context.Context, month time.Time) error {
+	result, err := dm.ExecWithHealthCheck(ctx, `
+        UPDATE reading_archives SET pending = FALSE, archived_at = CURRENT_TIMESTAMP
+        WHERE month = $1 AND pending`, month.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to complete reading archive: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("pending reading archive %w", ErrNotFound)
+	}
+	return nil
+}
+
+// DeletePendingReadingArchive removes a pending archive whose readings
+// were kept, so the month is exported again
+func (dm *DatabaseManager) DeletePendingReadingArchive(ctx context.Context, month time.Time) error {
+	if _, err := dm.ExecWithHealthCheck(ctx, `DELETE FROM reading_archives WHERE month = $1 AND pending`, month.UTC()); err != nil {
+		return fmt.Errorf("failed to delete reading archive: %w", err)
+	}
+	return nil
+}
+
+// GetReadingArchives returns all archived months, oldest first
+func (dm *DatabaseManager) GetReadingArchives(ctx context.Context) ([]models.ReadingArchive, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT month, object_key, readings, size, sha256, archived_at, restored_at, pending
+        FROM reading_archives
+        ORDER BY month`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reading archives: %w", err)
+	}
+	defer rows.Close()
+
+	archives := []models.ReadingArchive{}
+	for rows.Next() {
+		var a models.ReadingArchive
+		if err := rows.Scan(&a.Month, &a.ObjectKey, &a.Readings, &a.Size, &a.SHA256, &a.ArchivedAt, &a.RestoredAt, &a.Pending); err != nil {
+			return nil, fmt.Errorf("failed to scan reading archive: %w", err)
+		}
+		a.Month = a.Month.UTC()
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+// GetReadingArchive returns the archive of a month
+func (dm *DatabaseManager) GetReadingArchive(ctx context.Context, month time.Time) (*models.ReadingArchive, error) {
+	var a models.ReadingArchive
+	err := dm.QueryRowWithHealthCheck(ctx, `
+        SELECT month, object_key, readings, size, sha256, archived_at, restored_at, pending
+        FROM reading_archives
+        WHERE month = $1`, month.UTC(),
+	).Scan(&a.Month, &a.ObjectKey, &a.Readings, &a.Size, &a.SHA256, &a.ArchivedAt, &a.RestoredAt, &a.Pending)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("reading archive %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reading archive: %w", err)
+	}
+	a.Month = a.Month.UTC()
+	return &a, nil
+}
+
+// MarkReadingArchiveRestored records that the readings of an archived month
+// were restored; restored months are not archived again automatically
+func (dm *DatabaseManager) MarkReadingArchiveRestored(ctx context.Context, month time.Time) error {
+	result, err := dm.ExecWithHealthCheck(ctx, `UPDATE reading_archives SET restored_at = CURRENT_TIMESTAMP WHERE month = $1`, month.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update reading archive: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("reading archive %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestArchiveMonthRoundTrip(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil || dm.ch == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	ctx := context.Background()
+	month := models.ArchiveCutoff(time.Now(), 14)
+	storeTestReadings(t, dm, sensor.ID, month.Add(36*time.Hour), 30, func(i int) float64 { return float64(i) })
+
+	months, err := dm.ArchivableMonths(ctx, month.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Failed to list months: %v", err)
+	}
+	if len(months) != 1 || !months[0].Equal(month) {
+		t.Fatalf("Expected month %s, got %v", month, months)
+	}
+
+	var buf bytes.Buffer
+	n, err := dm.WriteMonthReadingsJSON(ctx, month, &buf)
+	if err != nil || n != 30 {
+		t.Fatalf("Expected 30 exported readings, got %d, %v", n, err)
+	}
+
+	if err := dm.DropMonthReadings(ctx, month, 29); !errors.Is(err, ErrArchiveChanged) {
+		t.Errorf("Expected ErrArchiveChanged, got %v", err)
+	}
+	if count, _ := dm.CountMonthReadings(ctx, month); count != 30 {
+		t.Errorf("Expected the readings to be kept after a changed count, got %d", count)
+	}
+	if err := dm.DropMonthReadings(ctx, month, n); err != nil {
+		t.Fatalf("Failed to drop month: %v", err)
+	}
+	if count, _ := dm.CountMonthReadings(ctx, month); count != 0 {
+		t.Errorf("Expected no readings after drop, got %d", count)
+	}
+
+	var readings []models.SensorReading
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var r models.SensorReading
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Failed to decode reading: %v", err)
+		}
+		readings = append(readings, r)
+	}
+	if err := dm.RestoreReadings(ctx, readings); err != nil {
+		t.Fatalf("Failed to restore readings: %v", err)
+	}
+	if count, _ := dm.CountMonthReadings(ctx, month); count != 30 {
+		t.Errorf("Expected 30 restored readings, got %d", count)
+	}
+}
+
+func TestReadingArchiveState(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := dm.GetReadingArchive(ctx, month); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	archive := &models.ReadingArchive{Month: month, ObjectKey: models.ArchiveObjectKey("readings", month), Readings: 10, Size: 123, SHA256: "abc"}
+	if err := dm.SaveReadingArchive(ctx, archive); err != nil {
+		t.Fatalf("Failed to save archive: %v", err)
+	}
+	if err := dm.MarkReadingArchiveRestored(ctx, month); err != nil {
+		t.Fatalf("Failed to mark archive restored: %v", err)
+	}
+
+	archives, err := dm.GetReadingArchives(ctx)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("Expected one archive, got %v, %v", archives, err)
+	}
+	if !archives[0].Month.Equal(month) || archives[0].RestoredAt == nil || archives[0].Readings != 10 {
+		t.Errorf("Unexpected archive %+v", archives[0])
+	}
+
+	// Archiving again clears the restore
+	archive.Pending = true
+	if err := dm.SaveReadingArchive(ctx, archive); err != nil {
+		t.Fatalf("Failed to save archive: %v", err)
+	}
+	got, err := dm.GetReadingArchive(ctx, month)
+	if err != nil || got.RestoredAt != nil || !got.Pending {
+		t.Errorf("Expected unrestored pending archive, got %+v, %v", got, err)
+	}
+	if err := dm.CompleteReadingArchive(ctx, month); err != nil {
+		t.Fatalf("Failed to complete archive: %v", err)
+	}
+	if got, err := dm.GetReadingArchive(ctx, month); err != nil || got.Pending {
+		t.Errorf("Expected completed archive, got %+v, %v", got, err)
+	}
+	if err := dm.CompleteReadingArchive(ctx, month); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a completed archive, got %v", err)
+	}
+
+	// Only pending archives are deleted
+	if err := dm.DeletePendingReadingArchive(ctx, month); err != nil {
+		t.Fatalf("Failed to delete pending archive: %v", err)
+	}
+	if _, err := dm.GetReadingArchive(ctx, month); err != nil {
+		t.Errorf("Expected the completed archive to be kept, got %v", err)
+	}
+
+	if err := dm.MarkReadingArchiveRestored(ctx, month.AddDate(0, 1, 0)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
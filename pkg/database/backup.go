@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// BackupTables returns the Postgres tables of the instance schema by name
+func (dm *DatabaseManager) BackupTables(ctx context.Context) ([]string, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+		SELECT tablename FROM pg_tables
+		WHERE schemaname = current_schema()
+		ORDER BY tablename`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// WriteTableJSON writes every row of a Postgres table as one JSON object per
+// line and returns the number of rows
+func (dm *DatabaseManager) WriteTableJSON(ctx context.Context, table string, w io.Writer) (int64, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx,
+		fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t", pq.QuoteIdentifier(table)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var n int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return n, err
+		}
+		if _, err := io.WriteString(w, row+"\n"); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// WriteReadingsJSON writes all readings stored in ClickHouse as one JSON
+// object per line in the canonical order of the reading checksums. visit is
+// called with every reading if not nil.
+func (dm *DatabaseManager) WriteReadingsJSON(ctx context.Context, w io.Writer, visit func(models.SensorReading)) (int64, error) {
+	return dm.writeReadingsJSON(ctx, w, visit, `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated
+		FROM sensor_readings
+		ORDER BY sensor_id, date_utc, value`)
+}
+
+// writeReadingsJSON writes the readings selected by query as one JSON
+// object per line. The query must select the columns of WriteReadingsJSON.
+func (dm *DatabaseManager) writeReadingsJSON(ctx context.Context, w io.Writer, visit func(models.SensorReading), query string, args ...interface{}) (int64, error) {
+	rows, err := dm.ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sensor readings: %w", err)
+	}
+	defer rows.Close()
+
+	enc := json.NewEncoder(w)
+	var n int64
+	for rows.Next() {
+		var r models.SensorReading
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC, &r.Estimated); err != nil {
+			return n, err
+		}
+		if err := enc.Encode(r); err != nil {
+			return n, err
+		}
+		if visit != nil {
+			visit(r)
+		}
+		n++
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBackupTables(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	tables, err := dm.BackupTables(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list tables: %v", err)
+	}
+	if !slices.Contains(tables, "stations") || !slices.Contains(tables, "schema_migrations") {
+		t.Fatalf("Expected stations and schema_migrations, got %v", tables)
+	}
+
+	var buf bytes.Buffer
+	rows, err := dm.WriteTableJSON(ctx, "stations", &buf)
+	if err != nil {
+		t.Fatalf("Failed to write stations: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if rows != 1 || len(lines) != 1 {
+		t.Fatalf("Expected 1 row, got %d (%d lines)", rows, len(lines))
+	}
+
+	var row map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("Row is not valid JSON: %v", err)
+	}
+	if row["id"] != station.ID.String() {
+		t.Errorf("Expected station %s, got %v", station.ID, row["id"])
+	}
+}
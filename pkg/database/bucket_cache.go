@@ -0,0 +1,263 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// defaultBucketCacheRetention covers the common 24h chart with room for
+// slightly older start times
+const defaultBucketCacheRetention = 48 * time.Hour
+
+// cacheableStep returns the bucket length of aggregate intervals whose
+// buckets align with time.Truncate in UTC: fixed intervals that divide a
+// day (ClickHouse aligns hour intervals to midnight) and single days.
+func cacheableStep(interval string) (time.Duration, bool) {
+	iv, err := models.ParseAggregateInterval(interval)
+	if err != nil {
+		return 0, false
+	}
+	if iv.Count == 1 && iv.Unit == models.IntervalDay {
+		return 24 * time.Hour, true
+	}
+	step, ok := iv.Fixed()
+	if !ok || (24*time.Hour)%step != 0 {
+		return 0, false
+	}
+	return step, true
+}
+
+// bucketCache keeps closed per-sensor aggregation buckets in memory so
+// repeated queries over a sliding window only read the open bucket and
+// buckets not seen before from ClickHouse. Writes into past buckets
+// invalidate the affected buckets.
+type bucketCache struct {
+	mu        sync.Mutex
+	retention time.Duration
+	series    map[bucketSeriesKey]*bucketSeries
+	// generation changes on every invalidation so results of queries that
+	// raced with a backfill are not stored
+	generation uint64
+	lastSweep  time.Time
+	timeFunc   func() time.Time
+}
+
+type bucketSeriesKey struct {
+	sensorID uuid.UUID
+	interval string
+}
+
+// bucketSeries holds the buckets of one sensor and interval. Every bucket in
+// [from, to) is known; buckets without readings are absent.
+type bucketSeries struct {
+	step time.Duration
+	from time.Time
+	to   time.Time
+	// buckets are keyed by their Unix start time
+	buckets map[int64]bucketRow
+}
+
+// newBucketCache creates a cache keeping buckets for retention
+func newBucketCache(retention time.Duration) *bucketCache {
+	return &bucketCache{
+		retention: retention,
+		series:    make(map[bucketSeriesKey]*bucketSeries),
+		timeFunc:  time.Now,
+	}
+}
+
+// bucketCacheFromEnv creates the aggregation cache. READINGS_CACHE_RETENTION
+// sets how long closed buckets are kept; 0 disables the cache.
+func bucketCacheFromEnv() (*bucketCache, error) {
+	retention := defaultBucketCacheRetention
+	if value := os.Getenv("READINGS_CACHE_RETENTION"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid READINGS_CACHE_RETENTION: %w", err)
+		}
+		retention = d
+	}
+	if retention <= 0 {
+		return nil, nil
+	}
+	return newBucketCache(retention), nil
+}
+
+// now returns the current time of the cache
+func (c *bucketCache) now() time.Time {
+	return c.timeFunc().UTC()
+}
+
+// currentGeneration returns the generation to pass to put
+func (c *bucketCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// covered returns the part of [from, to) cached for all sensors. The range
+// is empty (start not before end) when any sensor lacks it.
+func (c *bucketCache) covered(sensorIDs []uuid.UUID, interval string, from, to time.Time) (time.Time, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range sensorIDs {
+		s, ok := c.series[bucketSeriesKey{id, interval}]
+		if !ok {
+			return from, from
+		}
+		if s.from.After(from) {
+			from = s.from
+		}
+		if s.to.Before(to) {
+			to = s.to
+		}
+	}
+	return from, to
+}
+
+// get returns the cached buckets of the sensors in [from, to)
+func (c *bucketCache) get(sensorIDs []uuid.UUID, interval string, from, to time.Time) []bucketRow {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var rows []bucketRow
+	for _, id := range sensorIDs {
+		s, ok := c.series[bucketSeriesKey{id, interval}]
+		if !ok {
+			continue
+		}
+		for bucket, row := range s.buckets {
+			if bucket >= from.Unix() && bucket < to.Unix() {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
+
+// put stores the complete buckets of the sensors in [from, to). It does
+// nothing when the cache was invalidated since generation was read.
+func (c *bucketCache) put(generation uint64, sensorIDs []uuid.UUID, interval string, from, to time.Time, rows []bucketRow) {
+	step, ok := cacheableStep(interval)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	cutoff := c.now().Add(-c.retention)
+	if cutoff.After(from) {
+		from = ceilTime(cutoff, step)
+	}
+	if !from.Before(to) {
+		return
+	}
+
+	for _, id := range sensorIDs {
+		key := bucketSeriesKey{id, interval}
+		s, ok := c.series[key]
+		if !ok || s.to.Before(from) || to.Before(s.from) {
+			// Not contiguous with the cached range, start over
+			s = &bucketSeries{step: step, from: from, to: to, buckets: make(map[int64]bucketRow)}
+			c.series[key] = s
+		}
+		if from.Before(s.from) {
+			s.from = from
+		}
+		if to.After(s.to) {
+			s.to = to
+		}
+	}
+	c.sweep(cutoff)
+
+	for _, row := range rows {
+		if row.TimeBucket.Before(from) || !row.TimeBucket.Before(to) {
+			continue
+		}
+		if s, ok := c.series[bucketSeriesKey{row.SensorID, interval}]; ok {
+			s.buckets[row.TimeBucket.Unix()] = row
+		}
+	}
+}
+
+// invalidate drops the cached buckets of a sensor from the bucket
+// containing t onwards
+func (c *bucketCache) invalidate(sensorID uuid.UUID, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Queries running now may have read the closed bucket without t
+	if t.Before(c.now().Truncate(time.Minute)) {
+		c.generation++
+	}
+
+	for key, s := range c.series {
+		if key.sensorID != sensorID || t.Before(s.from) || !t.Before(s.to) {
+			continue
+		}
+
+		bucket := t.UTC().Truncate(s.step)
+		if !bucket.After(s.from) {
+			delete(c.series, key)
+			continue
+		}
+		s.to = bucket
+		for b := range s.buckets {
+			if b >= bucket.Unix() {
+				delete(s.buckets, b)
+			}
+		}
+	}
+}
+
+// sweep prunes all series once a minute so sensors that are no longer
+// queried do not keep their buckets. Callers hold mu.
+func (c *bucketCache) sweep(cutoff time.Time) {
+	now := c.now()
+	if now.Sub(c.lastSweep) < time.Minute {
+		return
+	}
+	c.lastSweep = now
+
+	for key, s := range c.series {
+		s.prune(cutoff)
+		if !s.from.Before(s.to) {
+			delete(c.series, key)
+		}
+	}
+}
+
+// prune drops buckets starting before cutoff
+func (s *bucketSeries) prune(cutoff time.Time) {
+	if !cutoff.After(s.from) {
+		return
+	}
+	s.from = ceilTime(cutoff, s.step)
+	if s.to.Before(s.from) {
+		s.to = s.from
+	}
+	for b := range s.buckets {
+		if b < s.from.Unix() {
+			delete(s.buckets, b)
+		}
+	}
+}
+
+// ceilTime rounds t up to a multiple of step
+func ceilTime(t time.Time, step time.Duration) time.Time {
+	if floor := t.Truncate(step); floor.Before(t) {
+		return floor.Add(step)
+	}
+	return t
+}
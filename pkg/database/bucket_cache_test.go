@@ -0,0 +1,178 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testBucketCache(now time.Time) *bucketCache {
+	c := newBucketCache(48 * time.Hour)
+	c.timeFunc = func() time.Time { return now }
+	return c
+}
+
+func hourlyRows(sensorID uuid.UUID, from time.Time, hours int) []bucketRow {
+	rows := make([]bucketRow, 0, hours)
+	for i := 0; i < hours; i++ {
+		rows = append(rows, bucketRow{TimeBucket: from.Add(time.Duration(i) * time.Hour), SensorID: sensorID, Sum: float64(i), Count: 1})
+	}
+	return rows
+}
+
+func TestBucketCache_PutAndGet(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)
+	c := testBucketCache(now)
+	a, b := uuid.New(), uuid.New()
+	from := now.Add(-24 * time.Hour).Truncate(time.Hour).Add(time.Hour)
+	to := now.Truncate(time.Hour)
+
+	if f, t2 := c.covered([]uuid.UUID{a}, "1h", from, to); f.Before(t2) {
+		t.Fatalf("empty cache covers [%s, %s)", f, t2)
+	}
+
+	rows := append(hourlyRows(a, from, 23), hourlyRows(b, from, 23)...)
+	// Rows outside the range are ignored
+	rows = append(rows, bucketRow{TimeBucket: to, SensorID: a})
+	c.put(c.currentGeneration(), []uuid.UUID{a, b}, "1h", from, to, rows)
+
+	f, t2 := c.covered([]uuid.UUID{a, b}, "1h", from.Add(-time.Hour), to.Add(time.Hour))
+	if !f.Equal(from) || !t2.Equal(to) {
+		t.Errorf("covered() = [%s, %s), want [%s, %s)", f, t2, from, to)
+	}
+	if got := c.get([]uuid.UUID{a, b}, "1h", from, to); len(got) != 46 {
+		t.Errorf("get() returned %d rows, want 46", len(got))
+	}
+	if got := c.get([]uuid.UUID{a}, "1h", from.Add(time.Hour), from.Add(3*time.Hour)); len(got) != 2 {
+		t.Errorf("get() returned %d rows, want 2", len(got))
+	}
+	if f, t2 := c.covered([]uuid.UUID{a, uuid.New()}, "1h", from, to); f.Before(t2) {
+		t.Error("expected no coverage with an uncached sensor")
+	}
+	if f, t2 := c.covered([]uuid.UUID{a}, "5m", from, to); f.Before(t2) {
+		t.Error("expected no coverage for another interval")
+	}
+}
+
+func TestBucketCache_ExtendsContiguousRange(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)
+	c := testBucketCache(now)
+	a := uuid.New()
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	c.put(c.currentGeneration(), []uuid.UUID{a}, "1h", from, from.Add(10*time.Hour), hourlyRows(a, from, 10))
+	c.put(c.currentGeneration(), []uuid.UUID{a}, "1h", from.Add(10*time.Hour), from.Add(12*time.Hour), hourlyRows(a, from.Add(10*time.Hour), 2))
+
+	f, t2 := c.covered([]uuid.UUID{a}, "1h", from, from.Add(12*time.Hour))
+	if !f.Equal(from) || !t2.Equal(from.Add(12*time.Hour)) {
+		t.Errorf("covered() = [%s, %s)", f, t2)
+	}
+
+	// A disjoint range replaces the cached one
+	c.put(c.currentGeneration(), []uuid.UUID{a}, "1h", from.Add(-10*time.Hour), from.Add(-5*time.Hour), nil)
+	if f, t2 := c.covered([]uuid.UUID{a}, "1h", from, from.Add(12*time.Hour)); f.Before(t2) {
+		t.Errorf("expected old range to be dropped, covered [%s, %s)", f, t2)
+	}
+}
+
+func TestBucketCache_Invalidate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)
+	c := testBucketCache(now)
+	a, b := uuid.New(), uuid.New()
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(12 * time.Hour)
+	c.put(c.currentGeneration(), []uuid.UUID{a, b}, "1h", from, to, append(hourlyRows(a, from, 12), hourlyRows(b, from, 12)...))
+
+	// A backfilled reading at 05:20 drops the 05:00 bucket and everything after
+	c.invalidate(a, from.Add(5*time.Hour+20*time.Minute))
+	f, t2 := c.covered([]uuid.UUID{a}, "1h", from, to)
+	if !f.Equal(from) || !t2.Equal(from.Add(5*time.Hour)) {
+		t.Errorf("covered() = [%s, %s), want [%s, %s)", f, t2, from, from.Add(5*time.Hour))
+	}
+	if got := c.get([]uuid.UUID{a}, "1h", from, to); len(got) != 5 {
+		t.Errorf("get() returned %d rows, want 5", len(got))
+	}
+	if _, t2 := c.covered([]uuid.UUID{b}, "1h", from, to); !t2.Equal(to) {
+		t.Error("other sensor must not be invalidated")
+	}
+
+	// Readings in the open bucket or before the cached range change nothing
+	c.invalidate(b, now)
+	c.invalidate(b, from.Add(-time.Hour))
+	if _, t2 := c.covered([]uuid.UUID{b}, "1h", from, to); !t2.Equal(to) {
+		t.Error("unexpected invalidation")
+	}
+
+	// A reading in the first bucket drops the series
+	c.invalidate(b, from)
+	if f, t2 := c.covered([]uuid.UUID{b}, "1h", from, to); f.Before(t2) {
+		t.Error("expected series to be dropped")
+	}
+}
+
+func TestBucketCache_SkipsPutAfterInvalidation(t *testing.T) {
+	now := time.Date(2026, 3, 2, 12, 30, 0, 0, time.UTC)
+	c := testBucketCache(now)
+	a := uuid.New()
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	generation := c.currentGeneration()
+	c.invalidate(a, from.Add(time.Hour))
+	c.put(generation, []uuid.UUID{a}, "1h", from, from.Add(12*time.Hour), hourlyRows(a, from, 12))
+
+	if f, t2 := c.covered([]uuid.UUID{a}, "1h", from, from.Add(12*time.Hour)); f.Before(t2) {
+		t.Error("expected result of a query racing with a backfill to be discarded")
+	}
+}
+
+func TestBucketCache_Retention(t *testing.T) {
+	now := time.Date(2026, 3, 5, 12, 30, 0, 0, time.UTC)
+	c := testBucketCache(now)
+	a := uuid.New()
+	from := now.Add(-72 * time.Hour).Truncate(time.Hour)
+	to := now.Truncate(time.Hour)
+
+	c.put(c.currentGeneration(), []uuid.UUID{a}, "1h", from, to, hourlyRows(a, from, 72))
+
+	cutoff := ceilTime(now.Add(-48*time.Hour), time.Hour)
+	f, t2 := c.covered([]uuid.UUID{a}, "1h", from, to)
+	if !f.Equal(cutoff) || !t2.Equal(to) {
+		t.Errorf("covered() = [%s, %s), want [%s, %s)", f, t2, cutoff, to)
+	}
+	if got := c.get([]uuid.UUID{a}, "1h", from, to); len(got) != 47 {
+		t.Errorf("get() returned %d rows, want 47", len(got))
+	}
+}
+
+func TestCeilTime(t *testing.T) {
+	base := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	if got := ceilTime(base, time.Hour); !got.Equal(base) {
+		t.Errorf("ceilTime(aligned) = %s", got)
+	}
+	if got := ceilTime(base.Add(time.Second), time.Hour); !got.Equal(base.Add(time.Hour)) {
+		t.Errorf("ceilTime() = %s", got)
+	}
+}
+
+func TestCacheableStep(t *testing.T) {
+	testCases := []struct {
+		interval string
+		step     time.Duration
+	}{
+		{"10m", 10 * time.Minute},
+		{"3h", 3 * time.Hour},
+		{"1d", 24 * time.Hour},
+		{"7m", 0},
+		{"5h", 0},
+		{"2d", 0},
+		{"1w", 0},
+	}
+
+	for _, tc := range testCases {
+		step, ok := cacheableStep(tc.interval)
+		if ok != (tc.step > 0) || step != tc.step {
+			t.Errorf("cacheableStep(%s) = %v, %v; want %v", tc.interval, step, ok, tc.step)
+		}
+	}
+}
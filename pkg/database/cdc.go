@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetOutboxEvents returns up to limit station and sensor changes of the
+// outbox in the order they were captured
+func (dm *DatabaseManager) GetOutboxEvents(ctx context.Context, limit int) ([]models.ChangeEvent, error) {
+	query := `
+		SELECT id, entity, operation, entity_id, data, created_at
+		FROM cdc_outbox
+		ORDER BY id
+		LIMIT $1
+	`
+	rows, err := dm.QueryWithHealthCheck(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ChangeEvent
+	for rows.Next() {
+		var e models.ChangeEvent
+		var data []byte
+		if err := rows.Scan(&e.OutboxID, &e.Entity, &e.Operation, &e.EntityID, &data, &e.Time); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		e.ID = fmt.Sprintf("%s:%d", e.Entity, e.OutboxID)
+		e.Data = data
+		e.Key = e.EntityID.String()
+		e.Time = e.Time.UTC()
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// DeleteOutboxEvents deletes the published events up to an outbox ID
+func (dm *DatabaseManager) DeleteOutboxEvents(ctx context.Context, upToID int64) error {
+	query := `DELETE FROM cdc_outbox WHERE id <= $1`
+	if _, err := dm.ExecWithHealthCheck(ctx, query, upToID); err != nil {
+		return fmt.Errorf("failed to delete outbox events: %w", err)
+	}
+	return nil
+}
+
+// GetCDCPosition returns the stored position of a stream; ok is false if
+// none was stored yet
+func (dm *DatabaseManager) GetCDCPosition(ctx context.Context, name string) (position string, ok bool, err error) {
+	query := `SELECT position FROM cdc_positions WHERE name = $1`
+	err = dm.QueryRowWithHealthCheck(ctx, query, name).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query CDC position: %w", err)
+	}
+	return position, true, nil
+}
+
+// SetCDCPosition stores the position of a stream
+func (dm *DatabaseManager) SetCDCPosition(ctx context.Context, name, position string) error {
+	query := `
+		INSERT INTO cdc_positions (name, position, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET position = EXCLUDED.position, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := dm.ExecWithHealthCheck(ctx, query, name, position, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to store CDC position: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestOutbox(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	events, err := dm.GetOutboxEvents(ctx, 10000)
+	if err != nil {
+		t.Fatalf("GetOutboxEvents() error = %v", err)
+	}
+	var stationEvent, sensorEvent *models.ChangeEvent
+	for i, e := range events {
+		switch e.EntityID {
+		case station.ID:
+			stationEvent = &events[i]
+		case sensor.ID:
+			sensorEvent = &events[i]
+		}
+	}
+	if stationEvent == nil || stationEvent.Entity != models.ChangeEntityStation || stationEvent.Operation != models.ChangeOperationInsert {
+		t.Fatalf("Expected a station insert, got %+v", stationEvent)
+	}
+	if sensorEvent == nil || sensorEvent.Entity != models.ChangeEntitySensor {
+		t.Fatalf("Expected a sensor insert, got %+v", sensorEvent)
+	}
+	if string(stationEvent.Data) == "" || containsKey(stationEvent.Data, "pass_key") {
+		t.Errorf("Expected station data without pass key, got %s", stationEvent.Data)
+	}
+
+	// Updates of the pull status only are not captured
+	last := events[len(events)-1].OutboxID
+	if err := dm.UpdateStationPullStatus(ctx, station.ID, models.PullStatus{Status: models.PullStatusOK}); err != nil {
+		t.Fatalf("UpdateStationPullStatus() error = %v", err)
+	}
+	if err := dm.DeleteOutboxEvents(ctx, last); err != nil {
+		t.Fatalf("DeleteOutboxEvents() error = %v", err)
+	}
+	if events, _ := dm.GetOutboxEvents(ctx, 10000); len(events) != 0 {
+		t.Errorf("Expected an empty outbox, got %+v", events)
+	}
+}
+
+func TestCDCPosition(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	if _, ok, err := dm.GetCDCPosition(ctx, "test-missing"); err != nil || ok {
+		t.Errorf("Expected no position, got %v (%v)", ok, err)
+	}
+	for _, position := range []string{"1_a", "2_b"} {
+		if err := dm.SetCDCPosition(ctx, "test", position); err != nil {
+			t.Fatalf("SetCDCPosition() error = %v", err)
+		}
+	}
+	if position, ok, err := dm.GetCDCPosition(ctx, "test"); err != nil || !ok || position != "2_b" {
+		t.Errorf("Expected 2_b, got %q (%v)", position, err)
+	}
+}
+
+// containsKey reports whether JSON data has a top-level key
+func containsKey(data []byte, key string) bool {
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return false
+	}
+	_, ok := object[key]
+	return ok
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetReadingChecksums returns the daily content checksums of the readings of
+// a station's sensors between the UTC days of start (inclusive) and end
+// (exclusive). Days without readings are omitted.
+func (dm *DatabaseManager) GetReadingChecksums(ctx context.Context, stationID uuid.UUID, sensorIDs []uuid.UUID, start, end time.Time) ([]models.ReadingChecksum, error) {
+	checksums := models.NewReadingChecksummer()
+	if len(sensorIDs) == 0 {
+		return checksums.Checksums(), nil
+	}
+
+	// Same order as the backup export, so archives give the same checksums
+	const query = `
+		SELECT sensor_id, value, unit, raw_value, raw_unit, date_utc
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND date_utc >= ? AND date_utc < ?
+		ORDER BY sensor_id, date_utc, value
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sensor readings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.SensorReading
+		if err := rows.Scan(&r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC); err != nil {
+			return nil, err
+		}
+		checksums.Add(stationID, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return checksums.Checksums(), nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetReadingChecksums(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	// 23:30 to 00:29 UTC spans two days
+	start := time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC)
+	storeTestReadings(t, dm, sensor.ID, start, 60, func(i int) float64 { return float64(i) })
+
+	checksums, err := dm.GetReadingChecksums(ctx, station.ID, []uuid.UUID{sensor.ID}, start.Truncate(24*time.Hour), start.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get checksums: %v", err)
+	}
+	if len(checksums) != 2 || checksums[0].Readings != 30 || checksums[1].Day != "2026-03-02" {
+		t.Fatalf("Unexpected checksums %+v", checksums)
+	}
+
+	// The backup export yields the same checksums
+	exported := models.NewReadingChecksummer()
+	_, err = dm.WriteReadingsJSON(ctx, &bytes.Buffer{}, func(r models.SensorReading) {
+		exported.Add(station.ID, r)
+	})
+	if err != nil {
+		t.Fatalf("Failed to export readings: %v", err)
+	}
+	for i, c := range exported.Checksums() {
+		if c != checksums[i] {
+			t.Errorf("Export checksum %+v differs from %+v", c, checksums[i])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
@@ -12,16 +13,30 @@ import (
 // ClickHouseManager handles the ClickHouse connection used for sensor readings.
 type ClickHouseManager struct {
 	conn driver.Conn
+	// database is the database of CH_DATABASE; created is set when it was
+	// created on connect because CH_CREATE_DATABASE is set, e.g. for the
+	// temporary database of demo mode
+	database string
+	created  bool
 }
 
 // NewClickHouseManager establishes a connection to ClickHouse and ensures the schema exists.
 func NewClickHouseManager() (*ClickHouseManager, error) {
-	conn, err := connectClickHouse()
+	database := getEnv("CH_DATABASE", "weather")
+	created := false
+	if getEnv("CH_CREATE_DATABASE", "") == "true" {
+		if err := createClickHouseDatabase(database); err != nil {
+			return nil, err
+		}
+		created = true
+	}
+
+	conn, err := connectClickHouse(database)
 	if err != nil {
 		return nil, err
 	}
 
-	cm := &ClickHouseManager{conn: conn}
+	cm := &ClickHouseManager{conn: conn, database: database, created: created}
 
 	if err := cm.ensureSchema(context.Background()); err != nil {
 		_ = conn.Close()
@@ -41,6 +56,18 @@ func (cm *ClickHouseManager) Ping(ctx context.Context) error {
 	return cm.conn.Ping(ctx)
 }
 
+// DropDatabase drops the database with all readings. Only a database
+// created on connect is dropped.
+func (cm *ClickHouseManager) DropDatabase(ctx context.Context) error {
+	if !cm.created {
+		return fmt.Errorf("refusing to drop the clickhouse database %s, which was not created on connect", cm.database)
+	}
+	if err := cm.conn.Exec(ctx, "DROP DATABASE IF EXISTS "+quoteClickHouseIdent(cm.database)+" SYNC"); err != nil {
+		return fmt.Errorf("failed to drop clickhouse database %s: %w", cm.database, err)
+	}
+	return nil
+}
+
 // Close terminates the ClickHouse connection.
 func (cm *ClickHouseManager) Close() error {
 	if cm.conn == nil {
@@ -49,28 +76,77 @@ func (cm *ClickHouseManager) Close() error {
 	return cm.conn.Close()
 }
 
-// ensureSchema creates the sensor_readings table if it does not already exist.
+// ensureSchema creates the sensor_readings table if it does not already exist,
+// adds columns introduced after the table was first created and sets up the
+// daily rollups.
 func (cm *ClickHouseManager) ensureSchema(ctx context.Context) error {
 	const ddl = `
 		CREATE TABLE IF NOT EXISTS sensor_readings (
 			id         UUID DEFAULT generateUUIDv4(),
 			sensor_id  UUID,
 			value      Float64,
-			date_utc   DateTime64(3, 'UTC'),
-			created_at DateTime DEFAULT now()
+			unit       LowCardinality(String) DEFAULT '',
+			raw_value  Nullable(Float64),
+			raw_unit   LowCardinality(String) DEFAULT '',
+			date_utc    DateTime64(3, 'UTC'),
+			received_at Nullable(DateTime64(3, 'UTC')),
+			estimated   Bool DEFAULT false,
+			created_at  DateTime DEFAULT now()
 		) ENGINE = MergeTree()
 		PARTITION BY toYYYYMM(date_utc)
 		ORDER BY (sensor_id, date_utc)
 	`
-	return cm.conn.Exec(ctx, ddl)
+	if err := cm.conn.Exec(ctx, ddl); err != nil {
+		return err
+	}
+
+	// Unit columns, the arrival time, the storage time index of the
+	// changes feed and the estimated flag were added later; existing tables
+	// are upgraded in place
+	alters := []string{
+		`ALTER TABLE sensor_readings ADD COLUMN IF NOT EXISTS unit LowCardinality(String) DEFAULT '' AFTER value`,
+		`ALTER TABLE sensor_readings ADD COLUMN IF NOT EXISTS raw_value Nullable(Float64) AFTER unit`,
+		`ALTER TABLE sensor_readings ADD COLUMN IF NOT EXISTS raw_unit LowCardinality(String) DEFAULT '' AFTER raw_value`,
+		`ALTER TABLE sensor_readings ADD INDEX IF NOT EXISTS idx_created_at created_at TYPE minmax GRANULARITY 4`,
+		`ALTER TABLE sensor_readings ADD COLUMN IF NOT EXISTS received_at Nullable(DateTime64(3, 'UTC')) AFTER date_utc`,
+		`ALTER TABLE sensor_readings ADD INDEX IF NOT EXISTS idx_received_at received_at TYPE minmax GRANULARITY 4`,
+		`ALTER TABLE sensor_readings ADD COLUMN IF NOT EXISTS estimated Bool DEFAULT false AFTER received_at`,
+	}
+	for _, alter := range alters {
+		if err := cm.conn.Exec(ctx, alter); err != nil {
+			return err
+		}
+	}
+
+	return cm.ensureRollupSchema(ctx)
 }
 
-func connectClickHouse() (driver.Conn, error) {
+// createClickHouseDatabase creates a database through the default database
+func createClickHouseDatabase(database string) error {
+	conn, err := connectClickHouse("default")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := conn.Exec(ctx, "CREATE DATABASE IF NOT EXISTS "+quoteClickHouseIdent(database)); err != nil {
+		return fmt.Errorf("failed to create clickhouse database %s: %w", database, err)
+	}
+	return nil
+}
+
+// quoteClickHouseIdent quotes a database name for DDL statements
+func quoteClickHouseIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func connectClickHouse(database string) (driver.Conn, error) {
 	host := getEnv("CH_HOST", "localhost")
 	port := getEnv("CH_PORT", "9000")
 	user := getEnv("CH_USER", "weather")
 	password := getEnv("CH_PASSWORD", "weather")
-	database := getEnv("CH_DATABASE", "weather")
 
 	conn, err := clickhouse.Open(&clickhouse.Options{
 		Addr: []string{fmt.Sprintf("%s:%s", host, port)},
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// customSensorTypeColumns are the columns scanned by scanCustomSensorType
+const customSensorTypeColumns = `station_id, name, unit, category, min_value, max_value, created_at, updated_at`
+
+// scanCustomSensorType scans a row selected with customSensorTypeColumns
+func scanCustomSensorType(row rowScanner) (*models.CustomSensorType, error) {
+	var t models.CustomSensorType
+	var minValue, maxValue sql.NullFloat64
+	if err := row.Scan(&t.StationID, &t.Name, &t.Unit, &t.Category, &minValue, &maxValue, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if minValue.Valid {
+		t.MinValue = &minValue.Float64
+	}
+	if maxValue.Valid {
+		t.MaxValue = &maxValue.Float64
+	}
+	return &t, nil
+}
+
+// GetCustomSensorTypes retrieves the custom sensor types of a station
+func (dm *DatabaseManager) GetCustomSensorTypes(ctx context.Context, stationID uuid.UUID) ([]models.CustomSensorType, error) {
+	query := `SELECT ` + customSensorTypeColumns + ` FROM custom_sensor_types WHERE station_id = $1 ORDER BY name`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query custom sensor types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []models.CustomSensorType{}
+	for rows.Next() {
+		t, err := scanCustomSensorType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan custom sensor type: %w", err)
+		}
+		types = append(types, *t)
+	}
+	return types, rows.Err()
+}
+
+// SaveCustomSensorType creates or replaces a custom sensor type of a station
+func (dm *DatabaseManager) SaveCustomSensorType(ctx context.Context, t *models.CustomSensorType) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	query := insertForStation("custom_sensor_types", "name, unit, category, min_value, max_value", "$2, $3, $4, $5, $6") + `
+        ON CONFLICT (station_id, name) DO UPDATE
+        SET unit = $3, category = $4, min_value = $5, max_value = $6, updated_at = CURRENT_TIMESTAMP
+        RETURNING ` + customSensorTypeColumns
+
+	saved, err := scanCustomSensorType(dm.QueryRowWithHealthCheck(ctx, query,
+		t.StationID, t.Name, t.Unit, t.Category, t.MinValue, t.MaxValue,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("station %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save custom sensor type: %w", err)
+	}
+	*t = *saved
+	return nil
+}
+
+// DeleteCustomSensorType deletes a custom sensor type of a station. Sensors
+// of the type and their readings are kept.
+func (dm *DatabaseManager) DeleteCustomSensorType(ctx context.Context, stationID uuid.UUID, name string) error {
+	query := `DELETE FROM custom_sensor_types WHERE station_id = $1 AND name = $2`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, stationID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete custom sensor type: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("custom sensor type %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestCustomSensorTypes(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	maxValue := 100.0
+	soil := &models.CustomSensorType{StationID: station.ID, Name: "SoilMoisture", Unit: "%", MaxValue: &maxValue}
+	if err := dm.SaveCustomSensorType(ctx, soil); err != nil {
+		t.Fatalf("SaveCustomSensorType() error = %v", err)
+	}
+	if soil.Category != models.SensorCategoryCustom || soil.CreatedAt.IsZero() {
+		t.Errorf("unexpected saved type: %+v", soil)
+	}
+
+	// Saving again replaces the definition
+	soil.Unit = "vol%"
+	soil.MaxValue = nil
+	if err := dm.SaveCustomSensorType(ctx, soil); err != nil {
+		t.Fatalf("SaveCustomSensorType() error = %v", err)
+	}
+
+	types, err := dm.GetCustomSensorTypes(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("GetCustomSensorTypes() error = %v", err)
+	}
+	if len(types) != 1 || types[0].Unit != "vol%" || types[0].MaxValue != nil {
+		t.Fatalf("GetCustomSensorTypes() = %+v", types)
+	}
+
+	missing := &models.CustomSensorType{StationID: uuid.New(), Name: "Voltage"}
+	if err := dm.SaveCustomSensorType(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SaveCustomSensorType() for missing station error = %v, want ErrNotFound", err)
+	}
+
+	if err := dm.DeleteCustomSensorType(ctx, station.ID, "SoilMoisture"); err != nil {
+		t.Fatalf("DeleteCustomSensorType() error = %v", err)
+	}
+	if err := dm.DeleteCustomSensorType(ctx, station.ID, "SoilMoisture"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteCustomSensorType() twice error = %v, want ErrNotFound", err)
+	}
+}
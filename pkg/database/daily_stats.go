@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetDailyStats summarises the readings of the given sensors per calendar day
+// in loc between start (inclusive) and end (exclusive). Days without readings
+// are omitted. The result is ordered by day.
+func (dm *DatabaseManager) GetDailyStats(ctx context.Context, sensorIDs []uuid.UUID, start, end time.Time, loc *time.Location) (map[uuid.UUID][]models.DailyStat, error) {
+	result := make(map[uuid.UUID][]models.DailyStat)
+	if len(sensorIDs) == 0 {
+		return result, nil
+	}
+
+	const query = `
+		SELECT
+			sensor_id,
+			toDate(date_utc, ?)           AS day,
+			min(value)                    AS min_value,
+			argMin(date_utc, value)       AS min_time,
+			max(value)                    AS max_value,
+			argMax(date_utc, value)       AS max_time,
+			avg(value)                    AS avg_value,
+			sum(value)                    AS sum_value,
+			count()                       AS count_value,
+			avg(sin(radians(value)))      AS sin_value,
+			avg(cos(radians(value)))      AS cos_value
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND date_utc >= ? AND date_utc < ?
+		GROUP BY sensor_id, day
+		ORDER BY sensor_id, day
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, loc.String(), sensorIDs, start.UTC(), end.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			s        models.DailyStat
+			day      time.Time
+			count    uint64
+			sin, cos float64
+		)
+		if err := rows.Scan(&s.SensorID, &day, &s.Min, &s.MinTime, &s.Max, &s.MaxTime, &s.Avg, &s.Sum, &count, &sin, &cos); err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %w", err)
+		}
+		s.Day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		s.Count = int(count)
+		s.CircularMean = math.Mod(math.Atan2(sin, cos)*180/math.Pi+360, 360)
+		result[s.SensorID] = append(result[s.SensorID], s)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetDailyStats(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+
+	// 23:30 to 00:29 local time spans two days
+	start := time.Date(2026, 3, 1, 23, 30, 0, 0, berlin)
+	storeTestReadings(t, dm, sensor.ID, start.UTC(), 60, func(i int) float64 { return float64(i) })
+
+	stats, err := dm.GetDailyStats(context.Background(), []uuid.UUID{sensor.ID}, start.Add(-time.Hour), start.Add(2*time.Hour), berlin)
+	if err != nil {
+		t.Fatalf("Failed to get daily stats: %v", err)
+	}
+
+	days := stats[sensor.ID]
+	if len(days) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(days))
+	}
+	if days[0].Day.Day() != 1 || days[0].Count != 30 || days[0].Max != 29 {
+		t.Errorf("Unexpected first day %+v", days[0])
+	}
+	if days[1].Day.Day() != 2 || days[1].Min != 30 || !days[1].MinTime.Equal(start.Add(30*time.Minute)) {
+		t.Errorf("Unexpected second day %+v", days[1])
+	}
+}
@@ -88,7 +88,7 @@ func (dm *DatabaseManager) GetDashboard(ctx context.Context, id uuid.UUID) (*mod
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("dashboard not found")
+			return nil, fmt.Errorf("dashboard %w", ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to query dashboard: %w", err)
 	}
@@ -125,7 +125,7 @@ func (dm *DatabaseManager) UpdateDashboard(ctx context.Context, dashboard *model
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("dashboard not found")
+		return fmt.Errorf("dashboard %w", ErrNotFound)
 	}
 
 	return nil
@@ -146,7 +146,7 @@ func (dm *DatabaseManager) DeleteDashboard(ctx context.Context, id uuid.UUID) er
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("dashboard not found")
+		return fmt.Errorf("dashboard %w", ErrNotFound)
 	}
 
 	return nil
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -116,6 +117,9 @@ func TestGetDashboard_NotFound(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for non-existent dashboard")
 	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
 }
 
 func TestUpdateDashboard(t *testing.T) {
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// mergedTables are the ClickHouse tables whose parts of finished months are
+// merged by MergePartition
+var mergedTables = []string{"sensor_readings", "sensor_readings_daily"}
+
+// partitionIDPattern matches the IDs of monthly partitions, see ensureSchema
+var partitionIDPattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// AnalyzeTables refreshes the planner statistics of all tables of the
+// instance schema and returns their names
+func (dm *DatabaseManager) AnalyzeTables(ctx context.Context) ([]string, error) {
+	tables, err := dm.BackupTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if _, err := dm.ExecWithHealthCheck(ctx, "ANALYZE "+pq.QuoteIdentifier(table)); err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", table, err)
+		}
+	}
+	return tables, nil
+}
+
+// GetVacuumCandidates returns the tables of the instance schema with at
+// least minDead dead rows that make up more than ratio of their live rows
+func (dm *DatabaseManager) GetVacuumCandidates(ctx context.Context, minDead int64, ratio float64) ([]string, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT relname FROM pg_stat_user_tables
+        WHERE schemaname = current_schema() AND n_dead_tup >= $1 AND n_dead_tup > n_live_tup * $2
+        ORDER BY n_dead_tup DESC, relname`, minDead, ratio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table statistics: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// VacuumTable vacuums and analyzes a table of the instance schema
+func (dm *DatabaseManager) VacuumTable(ctx context.Context, table string) error {
+	if _, err := dm.ExecWithHealthCheck(ctx, "VACUUM (ANALYZE) "+pq.QuoteIdentifier(table)); err != nil {
+		return fmt.Errorf("failed to vacuum %s: %w", table, err)
+	}
+	return nil
+}
+
+// GetIndexStats returns the btree indexes of the instance schema with the
+// data to estimate their bloat, largest first. Partial and expression
+// indexes are left out as their size can't be estimated from the table.
+func (dm *DatabaseManager) GetIndexStats(ctx context.Context) ([]models.DBIndexStats, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT i.relname, t.relname, pg_relation_size(i.oid), GREATEST(t.reltuples, 0)::BIGINT,
+            COALESCE((
+                SELECT SUM(s.avg_width) FROM pg_attribute a
+                JOIN pg_stats s ON s.schemaname = n.nspname AND s.tablename = t.relname AND s.attname = a.attname
+                WHERE a.attrelid = t.oid AND a.attnum = ANY(x.indkey)
+            ), 0)::INT
+        FROM pg_index x
+        JOIN pg_class i ON i.oid = x.indexrelid
+        JOIN pg_class t ON t.oid = x.indrelid
+        JOIN pg_namespace n ON n.oid = i.relnamespace
+        JOIN pg_am am ON am.oid = i.relam
+        WHERE n.nspname = current_schema() AND am.amname = 'btree' AND x.indisvalid
+            AND x.indexprs IS NULL AND x.indpred IS NULL
+        ORDER BY 3 DESC, 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query index statistics: %w", err)
+	}
+	defer rows.Close()
+
+	indexes := []models.DBIndexStats{}
+	for rows.Next() {
+		var s models.DBIndexStats
+		if err := rows.Scan(&s.Name, &s.Table, &s.Bytes, &s.Rows, &s.KeyWidth); err != nil {
+			return nil, fmt.Errorf("failed to scan index statistics: %w", err)
+		}
+		indexes = append(indexes, s)
+	}
+	return indexes, rows.Err()
+}
+
+// ReindexIndex rebuilds an index of the instance schema without blocking
+// writes to its table
+func (dm *DatabaseManager) ReindexIndex(ctx context.Context, index string) error {
+	if _, err := dm.ExecWithHealthCheck(ctx, "REINDEX INDEX CONCURRENTLY "+pq.QuoteIdentifier(index)); err != nil {
+		return fmt.Errorf("failed to reindex %s: %w", index, err)
+	}
+	return nil
+}
+
+// GetUnmergedPartitions returns the monthly partitions of the readings and
+// daily rollups before the month of before that are stored in more than one
+// part. Inserts add a part each; merging the parts of finished months
+// improves their compression and speeds up queries.
+func (dm *DatabaseManager) GetUnmergedPartitions(ctx context.Context, before time.Time) ([]models.DBPartitionParts, error) {
+	rows, err := dm.ch.Conn().Query(ctx, `
+		SELECT table, partition_id, count()
+		FROM system.parts
+		WHERE database = currentDatabase() AND active AND table IN ? AND partition_id < ?
+		GROUP BY table, partition_id
+		HAVING count() > 1
+		ORDER BY table, partition_id`, mergedTables, before.UTC().Format("200601"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parts: %w", err)
+	}
+	defer rows.Close()
+
+	partitions := []models.DBPartitionParts{}
+	for rows.Next() {
+		var p models.DBPartitionParts
+		var parts uint64
+		if err := rows.Scan(&p.Table, &p.Partition, &parts); err != nil {
+			return nil, fmt.Errorf("failed to scan parts: %w", err)
+		}
+		p.Parts = int(parts)
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+// MergePartition merges all parts of a monthly partition into one
+func (dm *DatabaseManager) MergePartition(ctx context.Context, table, partition string) error {
+	if !slices.Contains(mergedTables, table) || !partitionIDPattern.MatchString(partition) {
+		return fmt.Errorf("invalid partition %s of %s", partition, table)
+	}
+	query := fmt.Sprintf("OPTIMIZE TABLE %s PARTITION ID '%s' FINAL", table, partition)
+	if err := dm.ch.Conn().Exec(ctx, query); err != nil {
+		return fmt.Errorf("failed to merge partition %s of %s: %w", partition, table, err)
+	}
+	return nil
+}
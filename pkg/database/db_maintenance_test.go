@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestAnalyzeAndReindex(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+	ctx := context.Background()
+
+	tables, err := dm.AnalyzeTables(ctx)
+	if err != nil {
+		t.Fatalf("Failed to analyze tables: %v", err)
+	}
+	if !slices.Contains(tables, "stations") {
+		t.Errorf("Expected stations to be analyzed, got %v", tables)
+	}
+
+	if _, err := dm.GetVacuumCandidates(ctx, 0, 0); err != nil {
+		t.Fatalf("Failed to get vacuum candidates: %v", err)
+	}
+	if err := dm.VacuumTable(ctx, "stations"); err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+
+	indexes, err := dm.GetIndexStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get index statistics: %v", err)
+	}
+	if len(indexes) == 0 {
+		t.Fatal("Expected indexes")
+	}
+	if err := dm.ReindexIndex(ctx, indexes[0].Name); err != nil {
+		t.Fatalf("Failed to reindex %s: %v", indexes[0].Name, err)
+	}
+}
+
+func TestMergePartition(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil || dm.ch == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+	ctx := context.Background()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	month := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	storeTestReadings(t, dm, sensor.ID, month, 5, func(i int) float64 { return float64(i) })
+	storeTestReadings(t, dm, sensor.ID, month.Add(time.Hour), 5, func(i int) float64 { return float64(i) })
+
+	partitions, err := dm.GetUnmergedPartitions(ctx, month.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Failed to get unmerged partitions: %v", err)
+	}
+	for _, p := range partitions {
+		if err := dm.MergePartition(ctx, p.Table, p.Partition); err != nil {
+			t.Fatalf("Failed to merge: %v", err)
+		}
+	}
+
+	partitions, err = dm.GetUnmergedPartitions(ctx, month.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Failed to get unmerged partitions: %v", err)
+	}
+	for _, p := range partitions {
+		if p.Partition == "202003" {
+			t.Errorf("Expected 202003 to be merged, got %+v", p)
+		}
+	}
+
+	if err := dm.MergePartition(ctx, "users", "202003"); err == nil {
+		t.Error("Expected an error for a table that is not merged")
+	}
+}
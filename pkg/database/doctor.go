@@ -0,0 +1,281 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// sensorsByID returns all sensors keyed by their ID
+func (dm *DatabaseManager) sensorsByID(ctx context.Context) (map[uuid.UUID]models.Sensor, error) {
+	const query = `
+		SELECT id, station_id, sensor_type, location, COALESCE(name, ''), COALESCE(remote_id, ''), created_at
+		FROM sensors
+	`
+	rows, err := dm.QueryWithHealthCheck(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sensors: %w", err)
+	}
+	defer rows.Close()
+
+	sensors := make(map[uuid.UUID]models.Sensor)
+	for rows.Next() {
+		var s models.Sensor
+		if err := rows.Scan(&s.ID, &s.StationID, &s.SensorType, &s.Location, &s.Name, &s.RemoteID, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sensor: %w", err)
+		}
+		sensors[s.ID] = s
+	}
+	return sensors, rows.Err()
+}
+
+// readingCountsBySensor returns the number of stored readings per sensor ID
+func (dm *DatabaseManager) readingCountsBySensor(ctx context.Context) (map[uuid.UUID]uint64, error) {
+	rows, err := dm.ch.Conn().Query(ctx, `SELECT sensor_id, count() FROM sensor_readings GROUP BY sensor_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count readings: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[uuid.UUID]uint64)
+	for rows.Next() {
+		var id uuid.UUID
+		var count uint64
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reading count: %w", err)
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+// FindOrphanedReadings returns the readings of sensors that do not exist
+// anymore. Deleting a station removes its sensors but not their readings.
+func (dm *DatabaseManager) FindOrphanedReadings(ctx context.Context) ([]models.OrphanedReadings, error) {
+	sensors, err := dm.sensorsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := dm.readingCountsBySensor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphaned []models.OrphanedReadings
+	for id, count := range counts {
+		if _, ok := sensors[id]; !ok {
+			orphaned = append(orphaned, models.OrphanedReadings{SensorID: id, Count: count})
+		}
+	}
+	return orphaned, nil
+}
+
+// DeleteOrphanedReadings deletes the readings and rollups of the given
+// sensors. Sensors that exist are skipped, so only readings that can't be
+// queried anymore are removed. It returns the number of sensors whose
+// readings were deleted.
+func (dm *DatabaseManager) DeleteOrphanedReadings(ctx context.Context, sensorIDs []uuid.UUID) (int, error) {
+	sensors, err := dm.sensorsByID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var orphaned []uuid.UUID
+	for _, id := range sensorIDs {
+		if _, ok := sensors[id]; !ok {
+			orphaned = append(orphaned, id)
+		}
+	}
+	if len(orphaned) == 0 {
+		return 0, nil
+	}
+
+	for _, table := range []string{"sensor_readings", "sensor_readings_daily"} {
+		query := "ALTER TABLE " + table + " DELETE WHERE sensor_id IN ? SETTINGS mutations_sync = 1"
+		if err := dm.ch.Conn().Exec(ctx, query, orphaned); err != nil {
+			return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	return len(orphaned), nil
+}
+
+// FindSensorsWithoutReadings returns sensors created before createdBefore
+// that have never stored a reading
+func (dm *DatabaseManager) FindSensorsWithoutReadings(ctx context.Context, createdBefore time.Time) ([]models.Sensor, error) {
+	sensors, err := dm.sensorsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := dm.readingCountsBySensor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []models.Sensor
+	for id, s := range sensors {
+		if counts[id] == 0 && s.CreatedAt.Before(createdBefore) {
+			unused = append(unused, s)
+		}
+	}
+	return unused, nil
+}
+
+// FindStationsWithoutSensors returns stations that have no sensors
+func (dm *DatabaseManager) FindStationsWithoutSensors(ctx context.Context) ([]models.StationData, error) {
+	const query = `
+		SELECT st.id, st.pass_key, st.station_type, st.mode, st.service_name, st.created_at
+		FROM stations st
+		WHERE NOT EXISTS (SELECT 1 FROM sensors s WHERE s.station_id = st.id)
+		ORDER BY st.created_at
+	`
+	rows, err := dm.QueryWithHealthCheck(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stations: %w", err)
+	}
+	defer rows.Close()
+
+	var stations []models.StationData
+	for rows.Next() {
+		var st models.StationData
+		if err := rows.Scan(&st.ID, &st.PassKey, &st.StationType, &st.Mode, &st.ServiceName, &st.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan station: %w", err)
+		}
+		stations = append(stations, st)
+	}
+	return stations, rows.Err()
+}
+
+// FindDuplicateReadings returns up to limit timestamps with more than one
+// reading of the same sensor
+func (dm *DatabaseManager) FindDuplicateReadings(ctx context.Context, limit int) ([]models.DuplicateReadings, error) {
+	const query = `
+		SELECT sensor_id, date_utc, groupArray(id), uniqExact(value) > 1
+		FROM sensor_readings
+		GROUP BY sensor_id, date_utc
+		HAVING count() > 1
+		ORDER BY sensor_id, date_utc
+		LIMIT ?
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, uint64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate readings: %w", err)
+	}
+	defer rows.Close()
+
+	var duplicates []models.DuplicateReadings
+	for rows.Next() {
+		var d models.DuplicateReadings
+		if err := rows.Scan(&d.SensorID, &d.DateUTC, &d.IDs, &d.Conflicting); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate readings: %w", err)
+		}
+		duplicates = append(duplicates, d)
+	}
+	return duplicates, rows.Err()
+}
+
+// DeleteDuplicateReadings keeps one reading of each duplicate that is not
+// conflicting, deletes the others and rebuilds the affected rollups.
+// Duplicates sharing their ID can't be told apart and are skipped. It
+// returns the number of deleted readings.
+func (dm *DatabaseManager) DeleteDuplicateReadings(ctx context.Context, duplicates []models.DuplicateReadings) (int, error) {
+	var ids []uuid.UUID
+	days := make(map[uuid.UUID][2]time.Time)
+	var backfilled []models.SensorReading
+	for _, d := range duplicates {
+		if d.Conflicting || len(d.IDs) < 2 || !distinctIDs(d.IDs) {
+			continue
+		}
+		ids = append(ids, d.IDs[1:]...)
+		backfilled = append(backfilled, models.SensorReading{SensorID: d.SensorID, DateUTC: d.DateUTC})
+
+		r, ok := days[d.SensorID]
+		if !ok || d.DateUTC.Before(r[0]) {
+			r[0] = d.DateUTC
+		}
+		if !ok || d.DateUTC.After(r[1]) {
+			r[1] = d.DateUTC
+		}
+		days[d.SensorID] = r
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	const query = `ALTER TABLE sensor_readings DELETE WHERE id IN ? SETTINGS mutations_sync = 1`
+	if err := dm.ch.Conn().Exec(ctx, query, ids); err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate readings: %w", err)
+	}
+	dm.invalidateBuckets(backfilled)
+
+	for sensorID, r := range days {
+		if err := dm.RecomputeDailyRollups(ctx, []uuid.UUID{sensorID}, r[0], r[1]); err != nil {
+			return len(ids), err
+		}
+	}
+	return len(ids), nil
+}
+
+// FindRollupDrift compares the daily rollups since the given day with the
+// raw readings and returns the days that differ. A zero since checks all
+// days.
+func (dm *DatabaseManager) FindRollupDrift(ctx context.Context, since time.Time) ([]models.RollupDrift, error) {
+	rawWhere, rollupWhere := "", ""
+	var args []interface{}
+	if !since.IsZero() {
+		since = since.UTC().Truncate(24 * time.Hour)
+		rawWhere, rollupWhere = "WHERE date_utc >= ?", "WHERE day >= toDate(?)"
+		args = append(args, since, since)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT sensor_id, day, r.count_value, d.count_value, r.sum_value, d.sum_value
+		FROM (
+			SELECT sensor_id, toDate(date_utc) AS day, count() AS count_value, sum(value) AS sum_value
+			FROM sensor_readings
+			%s
+			GROUP BY sensor_id, day
+		) AS r
+		FULL OUTER JOIN (
+			SELECT sensor_id, day, sum(count_value) AS count_value, sum(sum_value) AS sum_value
+			FROM sensor_readings_daily
+			%s
+			GROUP BY sensor_id, day
+		) AS d USING (sensor_id, day)
+		WHERE r.count_value != d.count_value OR abs(r.sum_value - d.sum_value) > 1e-6
+		ORDER BY sensor_id, day
+	`, rawWhere, rollupWhere)
+	rows, err := dm.ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var drifts []models.RollupDrift
+	for rows.Next() {
+		var d models.RollupDrift
+		if err := rows.Scan(&d.SensorID, &d.Day, &d.RawCount, &d.RollupCount, &d.RawSum, &d.RollupSum); err != nil {
+			return nil, fmt.Errorf("failed to scan rollup drift: %w", err)
+		}
+		// Sums of large values differ slightly depending on the order
+		if d.RawCount == d.RollupCount && math.Abs(d.RawSum-d.RollupSum) <= 1e-9*math.Max(math.Abs(d.RawSum), 1) {
+			continue
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, rows.Err()
+}
+
+// distinctIDs reports whether no ID occurs twice
+func distinctIDs(ids []uuid.UUID) bool {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestDistinctIDs(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	if !distinctIDs([]uuid.UUID{a, b}) {
+		t.Error("expected distinct IDs")
+	}
+	if distinctIDs([]uuid.UUID{a, b, a}) {
+		t.Error("expected repeated ID to be detected")
+	}
+}
+
+func TestFindStationsWithoutSensors(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	empty := setupTestStation(t, dm)
+	used := setupTestStation(t, dm)
+	setupTestSensor(t, dm, used.ID, models.SensorTypeTemperature, "outdoor")
+
+	stations, err := dm.FindStationsWithoutSensors(ctx)
+	if err != nil {
+		t.Fatalf("FindStationsWithoutSensors() error = %v", err)
+	}
+	if len(stations) != 1 || stations[0].ID != empty.ID {
+		t.Errorf("FindStationsWithoutSensors() = %v, want station %s", stations, empty.ID)
+	}
+}
+
+func TestOrphanedReadings(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	unused := setupTestSensor(t, dm, station.ID, models.SensorTypeHumidity, "outdoor")
+	deleted := uuid.New()
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	readings := []models.SensorReading{
+		{SensorID: sensor.ID, Value: 20, DateUTC: now},
+		{SensorID: deleted, Value: 21, DateUTC: now},
+		{SensorID: deleted, Value: 22, DateUTC: now.Add(time.Minute)},
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	orphaned, err := dm.FindOrphanedReadings(ctx)
+	if err != nil {
+		t.Fatalf("FindOrphanedReadings() error = %v", err)
+	}
+	if len(orphaned) != 1 || orphaned[0].SensorID != deleted || orphaned[0].Count != 2 {
+		t.Fatalf("FindOrphanedReadings() = %v", orphaned)
+	}
+
+	unusedSensors, err := dm.FindSensorsWithoutReadings(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("FindSensorsWithoutReadings() error = %v", err)
+	}
+	if len(unusedSensors) != 1 || unusedSensors[0].ID != unused.ID {
+		t.Errorf("FindSensorsWithoutReadings() = %v, want sensor %s", unusedSensors, unused.ID)
+	}
+
+	// Existing sensors are never deleted
+	count, err := dm.DeleteOrphanedReadings(ctx, []uuid.UUID{deleted, sensor.ID})
+	if err != nil {
+		t.Fatalf("DeleteOrphanedReadings() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("DeleteOrphanedReadings() = %d, want 1", count)
+	}
+	if orphaned, _ := dm.FindOrphanedReadings(ctx); len(orphaned) != 0 {
+		t.Errorf("orphaned readings left: %v", orphaned)
+	}
+	if kept, _ := dm.GetSensorReadings(sensor.ID, now.Add(-time.Hour), now.Add(time.Hour), 10); len(kept) != 1 {
+		t.Errorf("readings of existing sensor = %d, want 1", len(kept))
+	}
+}
+
+func TestDuplicateReadings(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	day := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	readings := []models.SensorReading{
+		{SensorID: sensor.ID, Value: 20, DateUTC: day.Add(time.Hour)},
+		{SensorID: sensor.ID, Value: 20, DateUTC: day.Add(time.Hour)},
+		{SensorID: sensor.ID, Value: 20, DateUTC: day.Add(time.Hour)},
+		{SensorID: sensor.ID, Value: 21, DateUTC: day.Add(2 * time.Hour)},
+		{SensorID: sensor.ID, Value: 25, DateUTC: day.Add(2 * time.Hour)},
+		{SensorID: sensor.ID, Value: 22, DateUTC: day.Add(3 * time.Hour)},
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	duplicates, err := dm.FindDuplicateReadings(ctx, 100)
+	if err != nil {
+		t.Fatalf("FindDuplicateReadings() error = %v", err)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("FindDuplicateReadings() = %d duplicates, want 2", len(duplicates))
+	}
+	if duplicates[0].Conflicting || len(duplicates[0].IDs) != 3 || !duplicates[1].Conflicting {
+		t.Errorf("unexpected duplicates: %+v", duplicates)
+	}
+
+	deleted, err := dm.DeleteDuplicateReadings(ctx, duplicates)
+	if err != nil {
+		t.Fatalf("DeleteDuplicateReadings() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteDuplicateReadings() = %d, want 2", deleted)
+	}
+
+	// The conflicting duplicate is kept
+	duplicates, _ = dm.FindDuplicateReadings(ctx, 100)
+	if len(duplicates) != 1 || !duplicates[0].DateUTC.Equal(day.Add(2*time.Hour)) {
+		t.Errorf("remaining duplicates = %+v", duplicates)
+	}
+
+	// Rollups were rebuilt from the remaining readings
+	drifts, err := dm.FindRollupDrift(ctx, day)
+	if err != nil {
+		t.Fatalf("FindRollupDrift() error = %v", err)
+	}
+	if len(drifts) != 0 {
+		t.Errorf("FindRollupDrift() = %+v, want none", drifts)
+	}
+}
+
+func TestFindRollupDrift(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	day := time.Now().UTC().Truncate(24 * time.Hour).Add(-48 * time.Hour)
+	readings := []models.SensorReading{
+		{SensorID: sensor.ID, Value: 10, DateUTC: day.Add(time.Hour)},
+		{SensorID: sensor.ID, Value: 12, DateUTC: day.Add(2 * time.Hour)},
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	if drifts, err := dm.FindRollupDrift(ctx, time.Time{}); err != nil || len(drifts) != 0 {
+		t.Fatalf("FindRollupDrift() = %+v, %v; want no drift", drifts, err)
+	}
+
+	// Deleting raw readings is not seen by the rollup view
+	const deleteQuery = `ALTER TABLE sensor_readings DELETE WHERE sensor_id = ? AND value = 12 SETTINGS mutations_sync = 1`
+	if err := dm.ch.Conn().Exec(ctx, deleteQuery, sensor.ID); err != nil {
+		t.Fatalf("failed to delete reading: %v", err)
+	}
+
+	drifts, err := dm.FindRollupDrift(ctx, day)
+	if err != nil {
+		t.Fatalf("FindRollupDrift() error = %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].RawCount != 1 || drifts[0].RollupCount != 2 || !drifts[0].Day.Equal(day) {
+		t.Fatalf("FindRollupDrift() = %+v", drifts)
+	}
+
+	if err := dm.RecomputeDailyRollups(ctx, []uuid.UUID{sensor.ID}, day, day); err != nil {
+		t.Fatalf("RecomputeDailyRollups() error = %v", err)
+	}
+	if drifts, _ := dm.FindRollupDrift(ctx, day); len(drifts) != 0 {
+		t.Errorf("drift after recompute: %+v", drifts)
+	}
+}
@@ -0,0 +1,39 @@
+package database
+
+import "errors"
+
+// ErrNotFound is returned when a requested record does not exist.
+// Errors are wrapped with the record type, e.g. "station not found".
+var ErrNotFound = errors.New("not found")
+
+// ErrJobFinished is returned when a job that already reached a final state
+// is cancelled.
+var ErrJobFinished = errors.New("job already finished")
+
+// ErrInvalidOTP is returned when a one-time code or recovery code is wrong,
+// expired or already used.
+var ErrInvalidOTP = errors.New("invalid one-time code")
+
+// ErrOTPLocked is returned when one-time codes of a user are refused for a
+// while after too many wrong codes.
+var ErrOTPLocked = errors.New("too many invalid one-time codes")
+
+// ErrTOTPEnabled is returned when two-factor authentication is enrolled
+// for a user that already has it enabled.
+var ErrTOTPEnabled = errors.New("two-factor authentication already enabled")
+
+// ErrAlertRuleExists is returned when an alert rule is named like another
+// rule of the same station.
+var ErrAlertRuleExists = errors.New("alert rule with this name already exists")
+
+// ErrStationExists is returned when a pending station is approved whose
+// passkey belongs to a station already.
+var ErrStationExists = errors.New("station with this passkey already exists")
+
+// ErrPendingStationsFull is returned when an unknown station is not listed
+// for approval as the limit of pending stations is reached.
+var ErrPendingStationsFull = errors.New("too many pending stations")
+
+// ErrConfigChanged is returned when a station config is replaced that was
+// changed since it was read.
+var ErrConfigChanged = errors.New("station config changed")
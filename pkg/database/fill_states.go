@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FillState is the time of the newest measured and the newest
+// estimated reading of a sensor, zero without such readings
+type FillState struct {
+	LastMeasured  time.Time
+	LastEstimated time.Time
+}
+
+// GetFillStates returns the fill states of sensors. Sensors without
+// readings are left out.
+func (dm *DatabaseManager) GetFillStates(ctx context.Context, sensorIDs []uuid.UUID) (map[uuid.UUID]FillState, error) {
+	states := make(map[uuid.UUID]FillState, len(sensorIDs))
+	if len(sensorIDs) == 0 {
+		return states, nil
+	}
+
+	// maxIf returns the epoch for sensors without matching readings
+	const query = `
+		SELECT sensor_id, maxIf(date_utc, NOT estimated), maxIf(date_utc, estimated)
+		FROM sensor_readings
+		WHERE sensor_id IN ?
+		GROUP BY sensor_id
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fill states: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sensorID uuid.UUID
+		var state FillState
+		if err := rows.Scan(&sensorID, &state.LastMeasured, &state.LastEstimated); err != nil {
+			return nil, fmt.Errorf("failed to scan fill state: %w", err)
+		}
+		for _, t := range []*time.Time{&state.LastMeasured, &state.LastEstimated} {
+			if t.Unix() <= 0 {
+				*t = time.Time{}
+			}
+		}
+		states[sensorID] = state
+	}
+	return states, rows.Err()
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetFillStates(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	filled := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	measured := setupTestSensor(t, dm, station.ID, models.SensorTypeHumidity, "outdoor")
+	empty := setupTestSensor(t, dm, station.ID, models.SensorTypePressure, "indoor")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	readings := []models.SensorReading{
+		{SensorID: filled.ID, Value: 12, DateUTC: now.Add(-time.Hour)},
+		{SensorID: filled.ID, Value: 11.6, DateUTC: now.Add(-10 * time.Minute), Estimated: true},
+		{SensorID: measured.ID, Value: 80, DateUTC: now},
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	states, err := dm.GetFillStates(ctx, []uuid.UUID{filled.ID, measured.ID, empty.ID})
+	if err != nil {
+		t.Fatalf("GetFillStates() error = %v", err)
+	}
+	if s := states[filled.ID]; !s.LastMeasured.Equal(now.Add(-time.Hour)) || !s.LastEstimated.Equal(now.Add(-10*time.Minute)) {
+		t.Errorf("state of filled sensor = %+v", s)
+	}
+	if s := states[measured.ID]; !s.LastMeasured.Equal(now) || !s.LastEstimated.IsZero() {
+		t.Errorf("state of measured sensor = %+v", s)
+	}
+	if _, ok := states[empty.ID]; ok {
+		t.Error("sensor without readings must be left out")
+	}
+
+	result, err := dm.GetReadings(models.ReadingQueryParams{SensorIDs: []uuid.UUID{filled.ID}, Limit: 10, Page: 1, Order: "asc"})
+	if err != nil {
+		t.Fatalf("GetReadings() error = %v", err)
+	}
+	data := result.Data.([]models.SensorReading)
+	if len(data) != 2 || data[0].Estimated || !data[1].Estimated {
+		t.Errorf("GetReadings() = %+v, want the second reading estimated", data)
+	}
+}
@@ -85,7 +85,11 @@ func (chc *HealthChecker) reconnect() error {
 	}
 
 	// Attempt to create new connection
-	newDB, err := connectDatabase()
+	schema, err := schemaFromEnv()
+	if err != nil {
+		return err
+	}
+	newDB, err := connectDatabase(schema)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ReserveIdempotencyKey claims an idempotency key of a source for ttl. It
+// returns true when the key was free or expired. Otherwise it returns the
+// stored record, which holds the response once the first request finished.
+// Expired keys are taken over here; DeleteExpiredIdempotencyKeys removes
+// the ones that are not reused.
+func (dm *DatabaseManager) ReserveIdempotencyKey(ctx context.Context, source, key, requestHash string, ttl time.Duration) (*models.IdempotencyRecord, bool, error) {
+	result, err := dm.ExecWithHealthCheck(ctx, `
+        INSERT INTO ingest_idempotency_keys (source, idempotency_key, request_hash, expires_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (source, idempotency_key) DO UPDATE SET
+            request_hash = EXCLUDED.request_hash,
+            status_code = NULL,
+            response = NULL,
+            created_at = CURRENT_TIMESTAMP,
+            expires_at = EXCLUDED.expires_at
+        WHERE ingest_idempotency_keys.expires_at < NOW()`,
+		source, key, requestHash, time.Now().Add(ttl),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 1 {
+		return nil, true, nil
+	}
+
+	var record models.IdempotencyRecord
+	var statusCode sql.NullInt64
+	err = dm.QueryRowWithHealthCheck(ctx, `
+        SELECT source, idempotency_key, request_hash, status_code, response, created_at, expires_at
+        FROM ingest_idempotency_keys
+        WHERE source = $1 AND idempotency_key = $2`,
+		source, key,
+	).Scan(
+		&record.Source,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&record.Response,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		// Released between insert and select; the caller may retry
+		return nil, false, fmt.Errorf("idempotency key %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	record.StatusCode = int(statusCode.Int64)
+	return &record, false, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that reserved a key
+func (dm *DatabaseManager) CompleteIdempotencyKey(ctx context.Context, source, key string, statusCode int, response []byte) error {
+	_, err := dm.ExecWithHealthCheck(ctx, `
+        UPDATE ingest_idempotency_keys SET status_code = $3, response = $4
+        WHERE source = $1 AND idempotency_key = $2`,
+		source, key, statusCode, response,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey frees a reserved key so a failed request can be retried
+func (dm *DatabaseManager) ReleaseIdempotencyKey(ctx context.Context, source, key string) error {
+	_, err := dm.ExecWithHealthCheck(ctx, `
+        DELETE FROM ingest_idempotency_keys WHERE source = $1 AND idempotency_key = $2`,
+		source, key,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes the expired idempotency keys and
+// nonces and returns how many were deleted
+func (dm *DatabaseManager) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := dm.ExecWithHealthCheck(ctx, `DELETE FROM ingest_idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	key := uuid.New().String()
+
+	_, reserved, err := dm.ReserveIdempotencyKey(ctx, "Ecowitt", key, "hash", time.Hour)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey() error = %v", err)
+	}
+	if !reserved {
+		t.Fatal("Expected new key to be reserved")
+	}
+
+	record, reserved, err := dm.ReserveIdempotencyKey(ctx, "Ecowitt", key, "hash", time.Hour)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey() error = %v", err)
+	}
+	if reserved || record.IsComplete() {
+		t.Fatalf("Expected in-progress record, got reserved=%v record=%+v", reserved, record)
+	}
+
+	if err := dm.CompleteIdempotencyKey(ctx, "Ecowitt", key, http.StatusCreated, []byte(`{"data":{}}`)); err != nil {
+		t.Fatalf("CompleteIdempotencyKey() error = %v", err)
+	}
+	record, _, err = dm.ReserveIdempotencyKey(ctx, "Ecowitt", key, "hash", time.Hour)
+	if err != nil {
+		t.Fatalf("ReserveIdempotencyKey() error = %v", err)
+	}
+	if record.StatusCode != http.StatusCreated || string(record.Response) != `{"data":{}}` {
+		t.Errorf("unexpected stored response: %d %s", record.StatusCode, record.Response)
+	}
+
+	// Keys are scoped by source
+	if _, reserved, _ := dm.ReserveIdempotencyKey(ctx, "Other", key, "hash", time.Hour); !reserved {
+		t.Error("Expected key of another source to be reserved")
+	}
+
+	if err := dm.ReleaseIdempotencyKey(ctx, "Ecowitt", key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey() error = %v", err)
+	}
+	if _, reserved, _ := dm.ReserveIdempotencyKey(ctx, "Ecowitt", key, "hash", time.Hour); !reserved {
+		t.Error("Expected released key to be reserved again")
+	}
+
+	// Expired keys are reused
+	expired := uuid.New().String()
+	dm.ReserveIdempotencyKey(ctx, "Ecowitt", expired, "hash", -time.Minute)
+	if _, reserved, _ := dm.ReserveIdempotencyKey(ctx, "Ecowitt", expired, "other", time.Hour); !reserved {
+		t.Error("Expected expired key to be reserved again")
+	}
+
+	// Expired keys that are not reused are deleted
+	pruned := uuid.New().String()
+	dm.ReserveIdempotencyKey(ctx, "Ecowitt", pruned, "hash", -time.Minute)
+	if _, err := dm.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+		t.Fatalf("DeleteExpiredIdempotencyKeys() error = %v", err)
+	}
+	record, reserved, err = dm.ReserveIdempotencyKey(ctx, "Ecowitt", key, "hash", time.Hour)
+	if err != nil || reserved || record == nil {
+		t.Errorf("Expected unexpired key to be kept, got reserved=%v err=%v", reserved, err)
+	}
+	if _, reserved, _ := dm.ReserveIdempotencyKey(ctx, "Ecowitt", pruned, "hash", time.Hour); !reserved {
+		t.Error("Expected deleted key to be reserved again")
+	}
+}
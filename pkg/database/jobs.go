@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// jobColumns are the columns scanned by scanJob
+const jobColumns = `id, type, status, params, progress_done, progress_total, COALESCE(error, ''),
+        cancel_requested, created_at, started_at, finished_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a row selected with jobColumns
+func scanJob(row rowScanner) (*models.Job, error) {
+	var j models.Job
+	var params []byte
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&j.ID,
+		&j.Type,
+		&j.Status,
+		&params,
+		&j.Done,
+		&j.Total,
+		&j.Error,
+		&j.CancelRequested,
+		&j.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	j.Params = json.RawMessage(params)
+	if startedAt.Valid {
+		j.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		j.FinishedAt = &finishedAt.Time
+	}
+	return &j, nil
+}
+
+// CreateJob queues a new pending job
+func (dm *DatabaseManager) CreateJob(ctx context.Context, job *models.Job) error {
+	params := job.Params
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+
+	query := `
+        INSERT INTO jobs (type, status, params, progress_total)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + jobColumns
+
+	created, err := scanJob(dm.QueryRowWithHealthCheck(ctx, query,
+		job.Type,
+		models.JobStatusPending,
+		[]byte(params),
+		job.Total,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	*job = *created
+	return nil
+}
+
+// GetJob retrieves a single job by ID
+func (dm *DatabaseManager) GetJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(dm.QueryRowWithHealthCheck(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query job: %w", err)
+	}
+	return job, nil
+}
+
+// GetJobs retrieves jobs matching the filter, newest first
+func (dm *DatabaseManager) GetJobs(ctx context.Context, params models.JobQueryParams) ([]models.Job, error) {
+	var conditions []string
+	var args []interface{}
+
+	if params.Type != "" {
+		args = append(args, params.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if params.Status != "" {
+		args = append(args, params.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if params.Limit > 0 {
+		args = append(args, params.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimJob marks the oldest pending job of one of the given types as running
+// by runnerID and returns it. Concurrent runners never claim the same job.
+// It returns nil when no job is pending.
+func (dm *DatabaseManager) ClaimJob(ctx context.Context, runnerID string, types []string) (*models.Job, error) {
+	query := `
+        UPDATE jobs SET status = $1, started_at = NOW(), runner_id = $4, heartbeat_at = NOW()
+        WHERE id = (
+            SELECT id FROM jobs
+            WHERE status = $2 AND type = ANY($3)
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + jobColumns
+
+	job, err := scanJob(dm.QueryRowWithHealthCheck(ctx, query,
+		models.JobStatusRunning,
+		models.JobStatusPending,
+		pq.Array(types),
+		runnerID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return job, nil
+}
+
+// UpdateJobProgress stores the progress of a running job, refreshes its
+// heartbeat and reports whether its cancellation was requested in the
+// meantime
+func (dm *DatabaseManager) UpdateJobProgress(ctx context.Context, id uuid.UUID, done, total int) (bool, error) {
+	query := `
+        UPDATE jobs SET progress_done = $1, progress_total = $2, heartbeat_at = NOW()
+        WHERE id = $3
+        RETURNING cancel_requested
+    `
+
+	var cancelRequested bool
+	if err := dm.QueryRowWithHealthCheck(ctx, query, done, total, id).Scan(&cancelRequested); err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("job %w", ErrNotFound)
+		}
+		return false, fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return cancelRequested, nil
+}
+
+// FinishJob stores the final status of a job and its error message, if any
+func (dm *DatabaseManager) FinishJob(ctx context.Context, id uuid.UUID, status, errMsg string) error {
+	query := `
+        UPDATE jobs SET status = $1, error = NULLIF($2, ''), finished_at = NOW()
+        WHERE id = $3
+    `
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, status, errMsg, id)
+	if err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("job %w", ErrNotFound)
+	}
+	return nil
+}
+
+// CancelJob cancels a pending job immediately and requests cancellation of a
+// running job, which stops at its next progress update. It returns
+// ErrJobFinished if the job already reached a final state.
+func (dm *DatabaseManager) CancelJob(ctx context.Context, id uuid.UUID) (*models.Job, error) {
+	query := `
+        UPDATE jobs SET
+            cancel_requested = true,
+            status = CASE WHEN status = $1 THEN $2 ELSE status END,
+            finished_at = CASE WHEN status = $1 THEN NOW() ELSE finished_at END
+        WHERE id = $3 AND status IN ($1, $4)
+        RETURNING ` + jobColumns
+
+	job, err := scanJob(dm.QueryRowWithHealthCheck(ctx, query,
+		models.JobStatusPending,
+		models.JobStatusCancelled,
+		id,
+		models.JobStatusRunning,
+	))
+	if err == nil {
+		return job, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+
+	// Distinguish unknown jobs from finished ones
+	if _, err := dm.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrJobFinished
+}
+
+// FailInterruptedJobs marks running jobs as failed that were claimed by
+// runnerID or whose heartbeat is older than staleBefore. It is called on
+// startup, when no job of a previous process of the runner can still be
+// running; jobs of other runners sharing the table are only failed once
+// they stopped sending heartbeats.
+func (dm *DatabaseManager) FailInterruptedJobs(ctx context.Context, runnerID string, staleBefore time.Time) (int64, error) {
+	query := `
+        UPDATE jobs SET status = $1, error = 'interrupted by server shutdown', finished_at = NOW()
+        WHERE status = $2 AND (runner_id = $3 OR heartbeat_at IS NULL OR heartbeat_at < $4)
+    `
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, models.JobStatusFailed, models.JobStatusRunning, runnerID, staleBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteFinishedJobs removes jobs that finished before the given time
+func (dm *DatabaseManager) DeleteFinishedJobs(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE finished_at IS NOT NULL AND finished_at < $1`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+
+	job := &models.Job{Type: "test", Params: json.RawMessage(`{"sensors":3}`)}
+	if err := dm.CreateJob(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+	if job.Status != models.JobStatusPending {
+		t.Errorf("Expected pending job, got %s", job.Status)
+	}
+
+	// Jobs of other types are not claimed
+	if claimed, err := dm.ClaimJob(ctx, "runner-a", []string{"other"}); err != nil || claimed != nil {
+		t.Fatalf("Expected no job to claim, got %v, %v", claimed, err)
+	}
+
+	claimed, err := dm.ClaimJob(ctx, "runner-a", []string{"test"})
+	if err != nil || claimed == nil {
+		t.Fatalf("Failed to claim job: %v", err)
+	}
+	if claimed.ID != job.ID || claimed.Status != models.JobStatusRunning || claimed.StartedAt == nil {
+		t.Errorf("Unexpected claimed job %+v", claimed)
+	}
+
+	if again, err := dm.ClaimJob(ctx, "runner-a", []string{"test"}); err != nil || again != nil {
+		t.Errorf("Expected running job not to be claimed again, got %v, %v", again, err)
+	}
+
+	cancelRequested, err := dm.UpdateJobProgress(ctx, job.ID, 1, 3)
+	if err != nil || cancelRequested {
+		t.Fatalf("Failed to update progress: %v, %v", cancelRequested, err)
+	}
+
+	// Cancelling a running job only requests it
+	cancelled, err := dm.CancelJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	if cancelled.Status != models.JobStatusRunning || !cancelled.CancelRequested {
+		t.Errorf("Expected cancellation request, got %+v", cancelled)
+	}
+	if cancelRequested, _ := dm.UpdateJobProgress(ctx, job.ID, 2, 3); !cancelRequested {
+		t.Error("Expected progress update to report the cancellation request")
+	}
+
+	if err := dm.FinishJob(ctx, job.ID, models.JobStatusCancelled, "cancelled on request"); err != nil {
+		t.Fatalf("Failed to finish job: %v", err)
+	}
+
+	stored, err := dm.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if stored.Status != models.JobStatusCancelled || stored.Done != 2 || stored.Total != 3 || stored.FinishedAt == nil {
+		t.Errorf("Unexpected finished job %+v", stored)
+	}
+
+	if _, err := dm.CancelJob(ctx, job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("Expected ErrJobFinished, got %v", err)
+	}
+}
+
+func TestCancelPendingJob(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+
+	job := &models.Job{Type: "test"}
+	if err := dm.CreateJob(ctx, job); err != nil {
+		t.Fatalf("Failed to create job: %v", err)
+	}
+
+	cancelled, err := dm.CancelJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	if cancelled.Status != models.JobStatusCancelled || cancelled.FinishedAt == nil {
+		t.Errorf("Expected pending job to be cancelled immediately, got %+v", cancelled)
+	}
+
+	if claimed, err := dm.ClaimJob(ctx, "runner-a", []string{"test"}); err != nil || claimed != nil {
+		t.Errorf("Expected cancelled job not to be claimed, got %v, %v", claimed, err)
+	}
+}
+
+func TestGetJobsAndCleanup(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+
+	for _, jobType := range []string{"a", "a", "b"} {
+		if err := dm.CreateJob(ctx, &models.Job{Type: jobType}); err != nil {
+			t.Fatalf("Failed to create job: %v", err)
+		}
+	}
+
+	jobs, err := dm.GetJobs(ctx, models.JobQueryParams{Type: "a"})
+	if err != nil {
+		t.Fatalf("Failed to get jobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Errorf("Expected 2 jobs of type a, got %d", len(jobs))
+	}
+
+	// A running job of another runner with a fresh heartbeat is kept
+	running, _ := dm.ClaimJob(ctx, "runner-a", []string{"b"})
+	if n, err := dm.FailInterruptedJobs(ctx, "runner-b", time.Now().Add(-time.Minute)); err != nil || n != 0 {
+		t.Fatalf("Expected no interrupted job of another runner, got %d, %v", n, err)
+	}
+	// A running job of a previous process of the runner is failed on startup
+	if n, err := dm.FailInterruptedJobs(ctx, "runner-a", time.Now().Add(-time.Minute)); err != nil || n != 1 {
+		t.Fatalf("Expected 1 interrupted job, got %d, %v", n, err)
+	}
+	if stored, _ := dm.GetJob(ctx, running.ID); stored.Status != models.JobStatusFailed {
+		t.Errorf("Expected interrupted job to fail, got %s", stored.Status)
+	}
+
+	if n, err := dm.DeleteFinishedJobs(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Errorf("Expected 1 deleted job, got %d, %v", n, err)
+	}
+}
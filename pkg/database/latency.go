@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// latencyExpression is the seconds between the observation of a reading and
+// its arrival. Readings stored before received_at was recorded have none.
+const latencyExpression = `(toUnixTimestamp64Milli(assumeNotNull(received_at)) - toUnixTimestamp64Milli(date_utc)) / 1000`
+
+// GetIngestLatency returns the latency of the readings of the given sensors
+// received since the given time
+func (dm *DatabaseManager) GetIngestLatency(ctx context.Context, sensorIDs []uuid.UUID, since time.Time) (models.IngestLatency, error) {
+	if len(sensorIDs) == 0 {
+		return models.IngestLatency{}, nil
+	}
+
+	query := `
+		SELECT count(), quantiles(0.5, 0.95)(` + latencyExpression + `), max(` + latencyExpression + `)
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND received_at >= ?
+	`
+	var latency models.IngestLatency
+	var quantiles []float64
+	err := dm.ch.Conn().QueryRow(ctx, query, sensorIDs, since.UTC()).Scan(&latency.Readings, &quantiles, &latency.Max)
+	if err != nil {
+		return models.IngestLatency{}, fmt.Errorf("failed to query ingest latency: %w", err)
+	}
+	if latency.Readings == 0 {
+		return models.IngestLatency{}, nil
+	}
+	latency.P50, latency.P95 = quantiles[0], quantiles[1]
+	return latency, nil
+}
+
+// GetIngestLatencySeries returns the latency of the readings of the given
+// sensors per interval of their arrival since the given time. Intervals
+// without readings are left out.
+func (dm *DatabaseManager) GetIngestLatencySeries(ctx context.Context, sensorIDs []uuid.UUID, since time.Time, interval time.Duration) ([]models.IngestLatencyPoint, error) {
+	points := []models.IngestLatencyPoint{}
+	if len(sensorIDs) == 0 {
+		return points, nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT toStartOfInterval(assumeNotNull(received_at), INTERVAL %d SECOND, 'UTC') AS bucket,
+		       count(), quantiles(0.5, 0.95)(%s), max(%s)
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND received_at >= ?
+		GROUP BY bucket
+		ORDER BY bucket
+	`, int64(interval.Seconds()), latencyExpression, latencyExpression)
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingest latency: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.IngestLatencyPoint
+		var quantiles []float64
+		if err := rows.Scan(&p.Time, &p.Readings, &quantiles, &p.Max); err != nil {
+			return nil, fmt.Errorf("failed to scan ingest latency: %w", err)
+		}
+		p.P50, p.P95 = quantiles[0], quantiles[1]
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetIngestLatency(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	ids := []uuid.UUID{sensor.ID}
+
+	receivedAt := time.Now().UTC().Truncate(time.Second)
+	readings := []models.SensorReading{
+		// Stored before the arrival time was recorded
+		{SensorID: sensor.ID, Value: 19, DateUTC: receivedAt.Add(-time.Hour)},
+	}
+	for i, delay := range []time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second, 10 * time.Minute} {
+		received := receivedAt.Add(time.Duration(i) * time.Second)
+		readings = append(readings, models.SensorReading{SensorID: sensor.ID, Value: 20, DateUTC: received.Add(-delay), ReceivedAt: &received})
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	latency, err := dm.GetIngestLatency(ctx, ids, receivedAt.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GetIngestLatency() error = %v", err)
+	}
+	if latency.Readings != 4 || latency.Max != 600 || latency.P50 < 4 || latency.P50 > 6 {
+		t.Errorf("Unexpected latency %+v", latency)
+	}
+
+	points, err := dm.GetIngestLatencySeries(ctx, ids, receivedAt.Add(-time.Minute), time.Hour)
+	if err != nil {
+		t.Fatalf("GetIngestLatencySeries() error = %v", err)
+	}
+	var total uint64
+	for _, p := range points {
+		total += p.Readings
+	}
+	if total != 4 {
+		t.Errorf("Expected 4 readings in the series, got %+v", points)
+	}
+
+	if latency, err := dm.GetIngestLatency(ctx, ids, receivedAt.Add(time.Hour)); err != nil || latency.Readings != 0 {
+		t.Errorf("Expected no readings, got %+v, %v", latency, err)
+	}
+}
@@ -0,0 +1,311 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// maintenanceTaskColumns are the columns scanned by scanMaintenanceTask
+const maintenanceTaskColumns = `id, station_id, name, description, interval_days, last_done_at, next_due_at, notified_at, created_at, updated_at`
+
+// annotationColumns are the columns scanned by scanAnnotation
+const annotationColumns = `id, station_id, sensor_id, task_id, kind, text, date_utc, created_at`
+
+// scanMaintenanceTask scans a row selected with maintenanceTaskColumns
+func scanMaintenanceTask(row rowScanner) (*models.MaintenanceTask, error) {
+	var t models.MaintenanceTask
+	var lastDoneAt, notifiedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.StationID, &t.Name, &t.Description, &t.IntervalDays,
+		&lastDoneAt, &t.NextDueAt, &notifiedAt, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastDoneAt.Valid {
+		t.LastDoneAt = &lastDoneAt.Time
+	}
+	if notifiedAt.Valid {
+		t.NotifiedAt = &notifiedAt.Time
+	}
+	return &t, nil
+}
+
+// scanAnnotation scans a row selected with annotationColumns
+func scanAnnotation(row rowScanner) (*models.Annotation, error) {
+	var a models.Annotation
+	var sensorID, taskID uuid.NullUUID
+	if err := row.Scan(&a.ID, &a.StationID, &sensorID, &taskID, &a.Kind, &a.Text, &a.DateUTC, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	if sensorID.Valid {
+		a.SensorID = &sensorID.UUID
+	}
+	if taskID.Valid {
+		a.TaskID = &taskID.UUID
+	}
+	return &a, nil
+}
+
+// queryMaintenanceTasks runs a query selecting maintenanceTaskColumns
+func (dm *DatabaseManager) queryMaintenanceTasks(ctx context.Context, query string, args ...interface{}) ([]models.MaintenanceTask, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.MaintenanceTask{}
+	for rows.Next() {
+		t, err := scanMaintenanceTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance task: %w", err)
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+// CreateMaintenanceTask schedules a new maintenance task of a station
+func (dm *DatabaseManager) CreateMaintenanceTask(ctx context.Context, task *models.MaintenanceTask) error {
+	if err := task.Validate(); err != nil {
+		return err
+	}
+
+	query := insertForStation("maintenance_tasks", "name, description, interval_days, next_due_at", "$2, $3, $4, $5") + `
+        RETURNING ` + maintenanceTaskColumns
+
+	created, err := scanMaintenanceTask(dm.QueryRowWithHealthCheck(ctx, query,
+		task.StationID, task.Name, task.Description, task.IntervalDays, task.NextDueAt,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("station %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance task: %w", err)
+	}
+	*task = *created
+	return nil
+}
+
+// GetMaintenanceTasks retrieves the maintenance tasks of a station, the
+// next due first
+func (dm *DatabaseManager) GetMaintenanceTasks(ctx context.Context, stationID uuid.UUID) ([]models.MaintenanceTask, error) {
+	query := `SELECT ` + maintenanceTaskColumns + ` FROM maintenance_tasks WHERE station_id = $1 ORDER BY next_due_at, name`
+	return dm.queryMaintenanceTasks(ctx, query, stationID)
+}
+
+// GetMaintenanceTask retrieves a maintenance task of a station
+func (dm *DatabaseManager) GetMaintenanceTask(ctx context.Context, stationID, id uuid.UUID) (*models.MaintenanceTask, error) {
+	query := `SELECT ` + maintenanceTaskColumns + ` FROM maintenance_tasks WHERE station_id = $1 AND id = $2`
+
+	task, err := scanMaintenanceTask(dm.QueryRowWithHealthCheck(ctx, query, stationID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("maintenance task %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance task: %w", err)
+	}
+	return task, nil
+}
+
+// UpdateMaintenanceTask changes the name, description, interval and due
+// date of a task. Moving the due date allows a new reminder.
+func (dm *DatabaseManager) UpdateMaintenanceTask(ctx context.Context, task *models.MaintenanceTask) error {
+	if err := task.Validate(); err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE maintenance_tasks
+        SET name = $3, description = $4, interval_days = $5, next_due_at = $6,
+            notified_at = CASE WHEN next_due_at = $6 THEN notified_at END,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE station_id = $1 AND id = $2
+        RETURNING ` + maintenanceTaskColumns
+
+	updated, err := scanMaintenanceTask(dm.QueryRowWithHealthCheck(ctx, query,
+		task.StationID, task.ID, task.Name, task.Description, task.IntervalDays, task.NextDueAt,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("maintenance task %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance task: %w", err)
+	}
+	*task = *updated
+	return nil
+}
+
+// DeleteMaintenanceTask deletes a maintenance task. Its logged completions
+// are kept as annotations.
+func (dm *DatabaseManager) DeleteMaintenanceTask(ctx context.Context, stationID, id uuid.UUID) error {
+	query := `DELETE FROM maintenance_tasks WHERE station_id = $1 AND id = $2`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, stationID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("maintenance task %w", ErrNotFound)
+	}
+	return nil
+}
+
+// CompleteMaintenanceTask logs a completed task as maintenance annotation
+// and schedules its next due date one interval after the completion
+func (dm *DatabaseManager) CompleteMaintenanceTask(ctx context.Context, stationID, id uuid.UUID, completion models.MaintenanceCompletion) (*models.Annotation, error) {
+	if err := dm.healthChecker.EnsureConnection(ctx); err != nil {
+		return nil, err
+	}
+	if completion.DateUTC.IsZero() {
+		completion.DateUTC = time.Now().UTC()
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name string
+	var intervalDays int
+	err = tx.QueryRowContext(ctx,
+		`SELECT name, interval_days FROM maintenance_tasks WHERE station_id = $1 AND id = $2 FOR UPDATE`,
+		stationID, id,
+	).Scan(&name, &intervalDays)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("maintenance task %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance task: %w", err)
+	}
+
+	text := name
+	if completion.Note != "" {
+		text += ": " + completion.Note
+	}
+	annotation, err := scanAnnotation(tx.QueryRowContext(ctx, `
+        INSERT INTO annotations (station_id, task_id, kind, text, date_utc)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING `+annotationColumns,
+		stationID, id, models.AnnotationKindMaintenance, text, completion.DateUTC,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to log maintenance: %w", err)
+	}
+
+	// Completions logged after the fact don't move the schedule backwards
+	_, err = tx.ExecContext(ctx, `
+        UPDATE maintenance_tasks
+        SET last_done_at = $3, next_due_at = $4, notified_at = NULL, updated_at = CURRENT_TIMESTAMP
+        WHERE station_id = $1 AND id = $2 AND (last_done_at IS NULL OR last_done_at < $3)
+    `, stationID, id, completion.DateUTC, completion.DateUTC.AddDate(0, 0, intervalDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reschedule maintenance task: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit maintenance: %w", err)
+	}
+	return annotation, nil
+}
+
+// GetDueMaintenanceTasks retrieves the tasks of all stations due before the
+// given time whose reminder was not sent yet
+func (dm *DatabaseManager) GetDueMaintenanceTasks(ctx context.Context, before time.Time) ([]models.MaintenanceTask, error) {
+	query := `
+        SELECT ` + maintenanceTaskColumns + `
+        FROM maintenance_tasks
+        WHERE next_due_at <= $1 AND notified_at IS NULL
+        ORDER BY next_due_at`
+	return dm.queryMaintenanceTasks(ctx, query, before)
+}
+
+// MarkMaintenanceNotified records that the reminder of a task was sent
+func (dm *DatabaseManager) MarkMaintenanceNotified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	if _, err := dm.ExecWithHealthCheck(ctx, `UPDATE maintenance_tasks SET notified_at = $2 WHERE id = $1`, id, at); err != nil {
+		return fmt.Errorf("failed to mark maintenance task notified: %w", err)
+	}
+	return nil
+}
+
+// CreateAnnotation adds an annotation to the timeline of a station
+func (dm *DatabaseManager) CreateAnnotation(ctx context.Context, annotation *models.Annotation) error {
+	if err := annotation.Validate(); err != nil {
+		return err
+	}
+
+	// Sensors must belong to the station
+	query := `
+        INSERT INTO annotations (station_id, sensor_id, task_id, kind, text, date_utc)
+        SELECT id, $2, $3, $4, $5, $6 FROM stations
+        WHERE id = $1 AND ($2::uuid IS NULL OR EXISTS (SELECT 1 FROM sensors WHERE id = $2 AND station_id = $1))
+        RETURNING ` + annotationColumns
+
+	created, err := scanAnnotation(dm.QueryRowWithHealthCheck(ctx, query,
+		annotation.StationID, annotation.SensorID, annotation.TaskID, annotation.Kind, annotation.Text, annotation.DateUTC,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("station or sensor %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create annotation: %w", err)
+	}
+	*annotation = *created
+	return nil
+}
+
+// GetAnnotations retrieves the annotations of a station in [start, end),
+// optionally of one kind, in chronological order
+func (dm *DatabaseManager) GetAnnotations(ctx context.Context, stationID uuid.UUID, start, end time.Time, kind string) ([]models.Annotation, error) {
+	query := `
+        SELECT ` + annotationColumns + `
+        FROM annotations
+        WHERE station_id = $1 AND date_utc >= $2 AND date_utc < $3 AND ($4 = '' OR kind = $4)
+        ORDER BY date_utc`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, stationID, start, end, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query annotations: %w", err)
+	}
+	defer rows.Close()
+
+	annotations := []models.Annotation{}
+	for rows.Next() {
+		a, err := scanAnnotation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan annotation: %w", err)
+		}
+		annotations = append(annotations, *a)
+	}
+	return annotations, rows.Err()
+}
+
+// DeleteAnnotation deletes an annotation of a station
+func (dm *DatabaseManager) DeleteAnnotation(ctx context.Context, stationID, id uuid.UUID) error {
+	query := `DELETE FROM annotations WHERE station_id = $1 AND id = $2`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, stationID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete annotation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("annotation %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestMaintenanceTasks(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	due := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	task := &models.MaintenanceTask{StationID: station.ID, Name: "Clean rain gauge", IntervalDays: 30, NextDueAt: due}
+	if err := dm.CreateMaintenanceTask(ctx, task); err != nil {
+		t.Fatalf("CreateMaintenanceTask() error = %v", err)
+	}
+	if task.ID == uuid.Nil {
+		t.Fatal("expected task ID to be set")
+	}
+
+	missing := &models.MaintenanceTask{StationID: uuid.New(), Name: "Replace batteries", IntervalDays: 365}
+	if err := dm.CreateMaintenanceTask(ctx, missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CreateMaintenanceTask() for missing station error = %v, want ErrNotFound", err)
+	}
+
+	dueTasks, err := dm.GetDueMaintenanceTasks(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("GetDueMaintenanceTasks() error = %v", err)
+	}
+	if len(dueTasks) != 1 || dueTasks[0].ID != task.ID {
+		t.Fatalf("GetDueMaintenanceTasks() = %+v", dueTasks)
+	}
+	if err := dm.MarkMaintenanceNotified(ctx, task.ID, time.Now()); err != nil {
+		t.Fatalf("MarkMaintenanceNotified() error = %v", err)
+	}
+	if dueTasks, _ := dm.GetDueMaintenanceTasks(ctx, time.Now()); len(dueTasks) != 0 {
+		t.Errorf("reminder sent twice: %+v", dueTasks)
+	}
+
+	doneAt := time.Now().UTC().Truncate(time.Second)
+	annotation, err := dm.CompleteMaintenanceTask(ctx, station.ID, task.ID, models.MaintenanceCompletion{DateUTC: doneAt, Note: "Spider removed"})
+	if err != nil {
+		t.Fatalf("CompleteMaintenanceTask() error = %v", err)
+	}
+	if annotation.Kind != models.AnnotationKindMaintenance || annotation.Text != "Clean rain gauge: Spider removed" || *annotation.TaskID != task.ID {
+		t.Errorf("unexpected annotation: %+v", annotation)
+	}
+
+	task, err = dm.GetMaintenanceTask(ctx, station.ID, task.ID)
+	if err != nil {
+		t.Fatalf("GetMaintenanceTask() error = %v", err)
+	}
+	if !task.NextDueAt.Equal(doneAt.AddDate(0, 0, 30)) || task.NotifiedAt != nil || !task.LastDoneAt.Equal(doneAt) {
+		t.Errorf("task not rescheduled: %+v", task)
+	}
+
+	// A completion logged after the fact keeps the schedule
+	if _, err := dm.CompleteMaintenanceTask(ctx, station.ID, task.ID, models.MaintenanceCompletion{DateUTC: doneAt.Add(-48 * time.Hour)}); err != nil {
+		t.Fatalf("CompleteMaintenanceTask() error = %v", err)
+	}
+	if again, _ := dm.GetMaintenanceTask(ctx, station.ID, task.ID); !again.NextDueAt.Equal(task.NextDueAt) {
+		t.Errorf("late completion moved due date to %s", again.NextDueAt)
+	}
+
+	log, err := dm.GetAnnotations(ctx, station.ID, doneAt.Add(-72*time.Hour), doneAt.Add(time.Second), models.AnnotationKindMaintenance)
+	if err != nil {
+		t.Fatalf("GetAnnotations() error = %v", err)
+	}
+	if len(log) != 2 || !log[0].DateUTC.Before(log[1].DateUTC) {
+		t.Errorf("GetAnnotations() = %+v", log)
+	}
+
+	// Deleting the task keeps its log
+	if err := dm.DeleteMaintenanceTask(ctx, station.ID, task.ID); err != nil {
+		t.Fatalf("DeleteMaintenanceTask() error = %v", err)
+	}
+	if log, _ := dm.GetAnnotations(ctx, station.ID, doneAt.Add(-72*time.Hour), doneAt.Add(time.Second), ""); len(log) != 2 || log[0].TaskID != nil {
+		t.Errorf("annotations after deleting task = %+v", log)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	other := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	foreign := setupTestSensor(t, dm, other.ID, models.SensorTypeTemperature, "outdoor")
+
+	note := &models.Annotation{StationID: station.ID, SensorID: &sensor.ID, Text: "Radiation shield replaced"}
+	if err := dm.CreateAnnotation(ctx, note); err != nil {
+		t.Fatalf("CreateAnnotation() error = %v", err)
+	}
+	if note.Kind != models.AnnotationKindNote || *note.SensorID != sensor.ID {
+		t.Errorf("unexpected annotation: %+v", note)
+	}
+
+	wrong := &models.Annotation{StationID: station.ID, SensorID: &foreign.ID, Text: "Wrong station"}
+	if err := dm.CreateAnnotation(ctx, wrong); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CreateAnnotation() with sensor of another station error = %v, want ErrNotFound", err)
+	}
+
+	if err := dm.DeleteAnnotation(ctx, other.ID, note.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteAnnotation() of another station error = %v, want ErrNotFound", err)
+	}
+	if err := dm.DeleteAnnotation(ctx, station.ID, note.ID); err != nil {
+		t.Fatalf("DeleteAnnotation() error = %v", err)
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
@@ -16,12 +20,38 @@ type DatabaseManager struct {
 	db            *sql.DB
 	healthChecker *HealthChecker
 	ch            *ClickHouseManager
+	keyWrapper    KeyWrapper
+	buckets       *bucketCache
+	schema        string
 }
 
+// DefaultSchema is the Postgres schema used without DB_SCHEMA
+const DefaultSchema = "public"
+
+// schemaPattern matches unquoted Postgres identifiers
+var schemaPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
 // NewDatabaseManager creates a new DatabaseManager instance
 func NewDatabaseManager() (*DatabaseManager, error) {
-	db, err := connectDatabase()
+	schema, err := schemaFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := connectDatabase(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	keyWrapper, err := keyWrapperFromEnv()
 	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	buckets, err := bucketCacheFromEnv()
+	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
@@ -35,6 +65,9 @@ func NewDatabaseManager() (*DatabaseManager, error) {
 		db:            db,
 		healthChecker: NewHealthChecker(db, 30*time.Second),
 		ch:            ch,
+		keyWrapper:    keyWrapper,
+		buckets:       buckets,
+		schema:        schema,
 	}
 
 	// Start health checking
@@ -48,6 +81,14 @@ func (dm *DatabaseManager) GetDB() *sql.DB {
 	return dm.db
 }
 
+// Schema returns the Postgres schema the tables of this instance live in
+func (dm *DatabaseManager) Schema() string {
+	if dm.schema == "" {
+		return DefaultSchema
+	}
+	return dm.schema
+}
+
 // Close closes the database connection and stops health checking
 func (dm *DatabaseManager) Close() error {
 	if dm.healthChecker != nil {
@@ -76,8 +117,9 @@ func (dm *DatabaseManager) QueryWithHealthCheck(ctx context.Context, query strin
 // QueryRowWithHealthCheck executes a query that returns a single row with health check
 func (dm *DatabaseManager) QueryRowWithHealthCheck(ctx context.Context, query string, args ...interface{}) *sql.Row {
 	if err := dm.healthChecker.EnsureConnection(ctx); err != nil {
-		// Return a row that will fail on scan
-		return dm.db.QueryRowContext(context.Background(), "SELECT NULL WHERE FALSE")
+		// Run the query anyway so the row carries the connection error
+		// instead of sql.ErrNoRows, which callers treat as "not found"
+		log.Printf("⚠ Database connection unhealthy: %v", err)
 	}
 
 	return dm.db.QueryRowContext(ctx, query, args...)
@@ -99,6 +141,10 @@ func (dm *DatabaseManager) IsConnectionHealthy() bool {
 
 // Init initializes the database with migrations
 func (dm *DatabaseManager) Init() error {
+	if err := ensureSchema(dm.db, dm.Schema()); err != nil {
+		return err
+	}
+
 	log.Println("Running database migrations...")
 
 	runner, err := NewMigrationsRunner(dm.db)
@@ -118,8 +164,99 @@ func (dm *DatabaseManager) Init() error {
 	return nil
 }
 
+// DropSchema deletes the readings of all sensors of the instance from
+// ClickHouse, or drops the ClickHouse database if it was created on connect,
+// and drops its Postgres schema with all tables. The default schema is never
+// dropped.
+func (dm *DatabaseManager) DropSchema(ctx context.Context) error {
+	schema := dm.Schema()
+	if schema == DefaultSchema {
+		return fmt.Errorf("refusing to drop the %s schema", schema)
+	}
+
+	if dm.ch != nil && dm.ch.created {
+		if err := dm.ch.DropDatabase(ctx); err != nil {
+			return err
+		}
+	} else if err := dm.deleteInstanceReadings(ctx); err != nil {
+		return err
+	}
+
+	if _, err := dm.ExecWithHealthCheck(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+		return fmt.Errorf("failed to drop schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// deleteInstanceReadings deletes the readings of all sensors of the
+// instance from ClickHouse
+func (dm *DatabaseManager) deleteInstanceReadings(ctx context.Context) error {
+	sensors, err := dm.sensorsByID(ctx)
+	if err != nil {
+		return err
+	}
+	if len(sensors) > 0 && dm.ch != nil {
+		ids := make([]uuid.UUID, 0, len(sensors))
+		for id := range sensors {
+			ids = append(ids, id)
+		}
+		for _, table := range []string{"sensor_readings", "sensor_readings_daily"} {
+			query := "ALTER TABLE " + table + " DELETE WHERE sensor_id IN ? SETTINGS mutations_sync = 1"
+			if err := dm.ch.Conn().Exec(ctx, query, ids); err != nil {
+				return fmt.Errorf("failed to delete from %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaFromEnv returns the schema configured in DB_SCHEMA. Separate schemas
+// let several instances share one database.
+func schemaFromEnv() (string, error) {
+	schema := getEnv("DB_SCHEMA", DefaultSchema)
+	if !schemaPattern.MatchString(schema) {
+		return "", fmt.Errorf("invalid DB_SCHEMA %q: use lowercase letters, digits and underscores", schema)
+	}
+	return schema, nil
+}
+
+// withSearchPath adds the search path of a schema to a connection string in
+// URL or key/value form, so every pooled connection resolves the tables of
+// the schema. Public stays on the path for the extension functions.
+func withSearchPath(connStr, schema string) string {
+	if schema == "" || schema == DefaultSchema {
+		return connStr
+	}
+	searchPath := schema + "," + DefaultSchema
+
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", searchPath)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return connStr + " search_path=" + searchPath
+}
+
+// ensureSchema creates the schema of the instance if it does not exist yet
+func ensureSchema(db *sql.DB, schema string) error {
+	if schema == DefaultSchema {
+		return nil
+	}
+	if !schemaPattern.MatchString(schema) {
+		return fmt.Errorf("invalid schema %q", schema)
+	}
+	if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + schema); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+	return nil
+}
+
 // connectDatabase establishes a connection to the database
-func connectDatabase() (*sql.DB, error) {
+func connectDatabase(schema string) (*sql.DB, error) {
 	host := getEnv("DB_HOST", "localhost")
 	port := getEnv("DB_PORT", "5432")
 	user := getEnv("DB_USER", "weather_user")
@@ -132,6 +269,7 @@ func connectDatabase() (*sql.DB, error) {
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		host, port, user, password, dbName, sslmode,
 	)
+	connStr = withSearchPath(connStr, schema)
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -223,3 +223,50 @@ func TestQueryWithHealthCheck_ContextTimeout(t *testing.T) {
 		t.Error("Expected error due to context timeout")
 	}
 }
+
+func TestWithSearchPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		connStr string
+		schema  string
+		want    string
+	}{
+		{"public", "host=db dbname=weather", "public", "host=db dbname=weather"},
+		{"key value", "host=db dbname=weather", "garden", "host=db dbname=weather search_path=garden,public"},
+		{"url", "postgres://user:pass@db:5432/weather?sslmode=disable", "garden",
+			"postgres://user:pass@db:5432/weather?search_path=garden%2Cpublic&sslmode=disable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withSearchPath(tt.connStr, tt.schema); got != tt.want {
+				t.Errorf("withSearchPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaFromEnv(t *testing.T) {
+	t.Setenv("DB_SCHEMA", "")
+	if schema, err := schemaFromEnv(); err != nil || schema != DefaultSchema {
+		t.Errorf("schemaFromEnv() = %q, %v, want public", schema, err)
+	}
+
+	t.Setenv("DB_SCHEMA", "garden_2")
+	if schema, err := schemaFromEnv(); err != nil || schema != "garden_2" {
+		t.Errorf("schemaFromEnv() = %q, %v, want garden_2", schema, err)
+	}
+
+	for _, invalid := range []string{"Garden", "garden; DROP TABLE users", "2garden", "gar-den"} {
+		t.Setenv("DB_SCHEMA", invalid)
+		if _, err := schemaFromEnv(); err == nil {
+			t.Errorf("schemaFromEnv() accepted %q", invalid)
+		}
+	}
+}
+
+func TestDropSchemaRefusesDefaultSchema(t *testing.T) {
+	dm := &DatabaseManager{}
+	if err := dm.DropSchema(context.Background()); err == nil {
+		t.Error("Expected the default schema not to be dropped")
+	}
+}
@@ -48,6 +48,10 @@ func (dm *DatabaseManager) migrateSensorReadingsFromPostgres() error {
 	if err := dm.ch.Conn().Exec(ctx, "TRUNCATE TABLE sensor_readings"); err != nil {
 		return fmt.Errorf("failed to truncate clickhouse sensor_readings: %w", err)
 	}
+	// The rollup view repopulates the daily rollups from the copied rows
+	if err := dm.ch.Conn().Exec(ctx, "TRUNCATE TABLE sensor_readings_daily"); err != nil {
+		return fmt.Errorf("failed to truncate clickhouse sensor_readings_daily: %w", err)
+	}
 
 	rows, err := dm.db.QueryContext(ctx,
 		"SELECT id, sensor_id, value, date_utc FROM sensor_readings ORDER BY date_utc ASC")
@@ -110,14 +114,14 @@ func (dm *DatabaseManager) migrateSensorReadingsFromPostgres() error {
 }
 
 // postgresTableExists returns true if a table with the given name exists in the
-// public schema. After Phase 5 drops the sensor_readings table this will return
+// schema of the instance. After Phase 5 drops the sensor_readings table this will return
 // false and the migration becomes a permanent no-op.
 func postgresTableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
 	var exists bool
 	err := db.QueryRowContext(ctx, `
 		SELECT EXISTS (
 			SELECT FROM information_schema.tables
-			WHERE table_schema = 'public' AND table_name = $1
+			WHERE table_schema = current_schema() AND table_name = $1
 		)`, name).Scan(&exists)
 	return exists, err
 }
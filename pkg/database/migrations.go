@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
@@ -208,3 +209,38 @@ func (r *MigrationsRunner) Run() error {
 	r.logger.Println("All migrations completed successfully")
 	return nil
 }
+
+// LatestMigration returns the version of the newest embedded migration,
+// which is the schema version this build expects
+func LatestMigration() (int, error) {
+	runner, err := NewMigrationsRunner(nil)
+	if err != nil {
+		return 0, err
+	}
+	if n := len(runner.migrations); n > 0 {
+		return runner.migrations[n-1].Version, nil
+	}
+	return 0, nil
+}
+
+// SchemaVersion returns the version of the newest applied migration, 0
+// before the first migration
+func (dm *DatabaseManager) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := dm.QueryRowWithHealthCheck(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	return version, nil
+}
+
+// HasExtension reports whether a Postgres extension, e.g. timescaledb, is
+// installed in the database
+func (dm *DatabaseManager) HasExtension(ctx context.Context, name string) (bool, error) {
+	var installed bool
+	err := dm.QueryRowWithHealthCheck(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)`, name).Scan(&installed)
+	if err != nil {
+		return false, fmt.Errorf("failed to query extension %s: %w", name, err)
+	}
+	return installed, nil
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -357,3 +358,24 @@ func TestMigrationFilesEmbedded(t *testing.T) {
 		}
 	}
 }
+
+func TestLatestMigration(t *testing.T) {
+	entries, err := migrationFiles.ReadDir("sql")
+	if err != nil {
+		t.Fatalf("Failed to read migrations: %v", err)
+	}
+	var newest string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".up.sql") && entry.Name() > newest {
+			newest = entry.Name()
+		}
+	}
+
+	latest, err := LatestMigration()
+	if err != nil {
+		t.Fatalf("LatestMigration failed: %v", err)
+	}
+	if want := fmt.Sprintf("%06d_", latest); !strings.HasPrefix(newest, want) {
+		t.Errorf("LatestMigration() = %d, want the version of %s", latest, newest)
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// observationColumns are the columns scanned by scanObservation, selected
+// from observations o joined with sensors s
+const observationColumns = `o.id, s.station_id, o.sensor_id, s.sensor_type, o.value, o.observed_at, o.observer, o.note, o.created_at`
+
+// scanObservation scans a row selected with observationColumns
+func scanObservation(row rowScanner) (*models.Observation, error) {
+	var o models.Observation
+	err := row.Scan(&o.ID, &o.StationID, &o.SensorID, &o.SensorType, &o.Value,
+		&o.ObservedAt, &o.Observer, &o.Note, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// manualSensorRemoteID identifies the manual sensor of a type at a station
+func manualSensorRemoteID(sensorType string) string {
+	return "manual:" + sensorType
+}
+
+// manualSensorQuery creates the manual sensor of a type at a station, or
+// returns the existing one
+var manualSensorQuery = insertForStation("sensors",
+	"sensor_type, location, name, enabled, remote_id, kind",
+	"$2, 'Outdoor', $3, TRUE, $4, $5",
+) + `
+        ON CONFLICT (station_id, remote_id) DO UPDATE SET kind = EXCLUDED.kind
+        RETURNING id`
+
+// CreateObservations stores manual observations of a station. Each sensor
+// type gets a manual sensor on first use; the values are stored as its
+// readings too, so they show up in charts and reports.
+func (dm *DatabaseManager) CreateObservations(ctx context.Context, stationID uuid.UUID, observations []models.Observation) ([]models.Observation, error) {
+	for i := range observations {
+		if err := observations[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := dm.healthChecker.EnsureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	created := make([]models.Observation, 0, len(observations))
+	readings := make([]models.SensorReading, 0, len(observations))
+	for _, o := range observations {
+		var sensorID uuid.UUID
+		err := tx.QueryRowContext(ctx, manualSensorQuery,
+			stationID, o.SensorType, o.SensorType+" (manual)", manualSensorRemoteID(o.SensorType), models.SensorKindManual,
+		).Scan(&sensorID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("station %w", ErrNotFound)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create manual sensor: %w", err)
+		}
+
+		var id uuid.UUID
+		var createdAt time.Time
+		err = tx.QueryRowContext(ctx, `
+            INSERT INTO observations (sensor_id, value, observed_at, observer, note)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, created_at
+        `, sensorID, o.Value, o.ObservedAt.UTC(), o.Observer, o.Note,
+		).Scan(&id, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create observation: %w", err)
+		}
+
+		o.ID, o.StationID, o.SensorID, o.CreatedAt = id, stationID, sensorID, createdAt
+		o.ObservedAt = o.ObservedAt.UTC()
+		created = append(created, o)
+		readings = append(readings, models.SensorReading{
+			SensorID: sensorID,
+			Value:    o.Value,
+			Unit:     models.SensorTypeRegistry[o.SensorType].Unit,
+			DateUTC:  o.ObservedAt,
+		})
+	}
+
+	// Readings are written before the commit, a failure leaves no
+	// observation without its reading
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit observations: %w", err)
+	}
+	return created, nil
+}
+
+// GetObservations retrieves the manual observations of a station within a
+// time range, newest first. A zero start or end leaves the range open.
+func (dm *DatabaseManager) GetObservations(ctx context.Context, stationID uuid.UUID, start, end time.Time) ([]models.Observation, error) {
+	query := `
+        SELECT ` + observationColumns + `
+        FROM observations o JOIN sensors s ON s.id = o.sensor_id
+        WHERE s.station_id = $1
+          AND ($2::timestamptz IS NULL OR o.observed_at >= $2)
+          AND ($3::timestamptz IS NULL OR o.observed_at < $3)
+        ORDER BY o.observed_at DESC, s.sensor_type`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, stationID, nullTime(start), nullTime(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query observations: %w", err)
+	}
+	defer rows.Close()
+
+	observations := []models.Observation{}
+	for rows.Next() {
+		o, err := scanObservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan observation: %w", err)
+		}
+		observations = append(observations, *o)
+	}
+	return observations, rows.Err()
+}
+
+// nullTime maps the zero time to NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
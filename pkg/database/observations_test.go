@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestObservations(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	observedAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	entry := models.ObservationEntry{
+		ObservedAt: observedAt,
+		Values:     map[string]float64{models.SensorTypeSnowfall: 4.5, models.SensorTypeCloudCover: 8},
+	}
+	observations, err := entry.Observations(station.ID, "anna")
+	if err != nil {
+		t.Fatalf("Observations() error = %v", err)
+	}
+	created, err := dm.CreateObservations(ctx, station.ID, observations)
+	if err != nil {
+		t.Fatalf("CreateObservations() error = %v", err)
+	}
+	if len(created) != 2 || created[0].ID == uuid.Nil || created[0].SensorID == uuid.Nil {
+		t.Fatalf("unexpected observations: %+v", created)
+	}
+
+	// A second observation reuses the manual sensor of its type
+	again, err := dm.CreateObservations(ctx, station.ID, []models.Observation{{
+		SensorType: models.SensorTypeSnowfall, Value: 2, Observer: "ben", ObservedAt: observedAt.Add(30 * time.Minute),
+	}})
+	if err != nil {
+		t.Fatalf("CreateObservations() error = %v", err)
+	}
+	if again[0].SensorID != created[1].SensorID {
+		t.Errorf("expected snowfall sensor %s to be reused, got %s", created[1].SensorID, again[0].SensorID)
+	}
+
+	sensor, err := dm.GetSensor(again[0].SensorID, true)
+	if err != nil {
+		t.Fatalf("GetSensor() error = %v", err)
+	}
+	if sensor.Sensor.Kind != models.SensorKindManual || sensor.LatestReading == nil || sensor.LatestReading.Value != 2 {
+		t.Errorf("unexpected manual sensor: %+v", sensor)
+	}
+
+	listed, err := dm.GetObservations(ctx, station.ID, observedAt, time.Time{})
+	if err != nil {
+		t.Fatalf("GetObservations() error = %v", err)
+	}
+	if len(listed) != 3 || listed[0].Observer != "ben" || listed[0].SensorType != models.SensorTypeSnowfall {
+		t.Errorf("unexpected listing: %+v", listed)
+	}
+	if listed, _ := dm.GetObservations(ctx, station.ID, time.Time{}, observedAt); len(listed) != 0 {
+		t.Errorf("expected no observations before %s, got %+v", observedAt, listed)
+	}
+
+	if _, err := dm.CreateObservations(ctx, uuid.New(), observations); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CreateObservations() for missing station error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// RecordPendingStation adds an unknown station to the pending list or
+// counts another push of a listed one. The sample replaces the values of
+// the previous push. It returns the status of the pending station. New
+// stations are only listed while fewer than limit stations are pending,
+// otherwise ErrPendingStationsFull is returned; a limit of 0 lists all.
+func (dm *DatabaseManager) RecordPendingStation(ctx context.Context, data *models.StationData, sourceIP string, sample []models.PendingSampleValue, limit int) (string, error) {
+	sampleJSON, err := json.Marshal(sample)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sample: %w", err)
+	}
+
+	var status string
+	err = dm.QueryRowWithHealthCheck(ctx, `
+        INSERT INTO pending_stations (pass_key, station_type, model, service_name, source_ip, sample)
+        SELECT $1::VARCHAR, $2::VARCHAR, $3::VARCHAR, $4::VARCHAR, $5::VARCHAR, $6::JSONB
+        WHERE $7::INTEGER <= 0
+            OR EXISTS (SELECT 1 FROM pending_stations WHERE pass_key = $1)
+            OR (SELECT COUNT(*) FROM pending_stations WHERE status = $8) < $7
+        ON CONFLICT (pass_key) DO UPDATE
+        SET station_type = EXCLUDED.station_type, model = EXCLUDED.model, service_name = EXCLUDED.service_name,
+            source_ip = EXCLUDED.source_ip, sample = EXCLUDED.sample,
+            push_count = pending_stations.push_count + 1, last_seen_at = CURRENT_TIMESTAMP
+        RETURNING status`,
+		data.PassKey, data.StationType, data.Model, data.ServiceName, sourceIP, sampleJSON, limit, models.PendingStationPending,
+	).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrPendingStationsFull
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to record pending station: %w", err)
+	}
+	return status, nil
+}
+
+// GetPendingStations returns the pending and rejected stations, most
+// recently seen first
+func (dm *DatabaseManager) GetPendingStations(ctx context.Context) ([]models.PendingStation, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT pass_key, station_type, model, service_name, status, source_ip, push_count, first_seen_at, last_seen_at, sample
+        FROM pending_stations
+        ORDER BY last_seen_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending stations: %w", err)
+	}
+	defer rows.Close()
+
+	stations := []models.PendingStation{}
+	for rows.Next() {
+		var s models.PendingStation
+		var sampleJSON []byte
+		if err := rows.Scan(&s.PassKey, &s.StationType, &s.Model, &s.ServiceName, &s.Status, &s.SourceIP, &s.PushCount, &s.FirstSeenAt, &s.LastSeenAt, &sampleJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan pending station: %w", err)
+		}
+		if sampleJSON != nil {
+			if err := json.Unmarshal(sampleJSON, &s.Sample); err != nil {
+				return nil, fmt.Errorf("failed to parse sample of pending station %s: %w", s.PassKey, err)
+			}
+		}
+		stations = append(stations, s)
+	}
+	return stations, rows.Err()
+}
+
+// ApprovePendingStation creates the station of a pending or rejected
+// passkey with the settings of the approval and removes it from the
+// pending list. If a station with the passkey was added meanwhile,
+// ErrStationExists is returned and the pending station is kept.
+func (dm *DatabaseManager) ApprovePendingStation(ctx context.Context, passKey string, approval models.StationApproval) (uuid.UUID, error) {
+	var template map[string]interface{}
+	if approval.TemplateID != nil {
+		station, err := dm.LoadStation(*approval.TemplateID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("template %w", err)
+		}
+		template = station.Config
+	}
+	if approval.OwnerID != nil {
+		if _, err := dm.GetUser(ctx, *approval.OwnerID); err != nil {
+			return uuid.Nil, fmt.Errorf("owner %w", err)
+		}
+	}
+	config, _, err := dm.encryptConfig(ctx, approval.Config(template))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var station models.StationData
+	err = tx.QueryRowContext(ctx, `
+        DELETE FROM pending_stations WHERE pass_key = $1
+        RETURNING pass_key, station_type, model, service_name`,
+		passKey,
+	).Scan(&station.PassKey, &station.StationType, &station.Model, &station.ServiceName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("pending station %w", ErrNotFound)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to remove pending station: %w", err)
+	}
+
+	var stationID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+        INSERT INTO stations (pass_key, station_type, model, mode, service_name, config, owner_id)
+        VALUES ($1, $2, $3, 'push', $4, $5, $6)
+        RETURNING id`,
+		station.PassKey, station.StationType, station.Model, station.ServiceName, configJSON, approval.OwnerID,
+	).Scan(&stationID)
+	if isUniqueViolation(err) {
+		return uuid.Nil, ErrStationExists
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create station: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return stationID, nil
+}
+
+// RejectPendingStation marks a pending station as rejected; its pushes are
+// refused without listing it again
+func (dm *DatabaseManager) RejectPendingStation(ctx context.Context, passKey string) error {
+	result, err := dm.ExecWithHealthCheck(ctx, `UPDATE pending_stations SET status = $2 WHERE pass_key = $1`, passKey, models.PendingStationRejected)
+	if err != nil {
+		return fmt.Errorf("failed to reject pending station: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("pending station %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestPendingStationLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	data := &models.StationData{PassKey: "pending-" + uuid.New().String(), StationType: "Ecowitt", Model: "GW2000", ServiceName: "ecowitt"}
+	sample := []models.PendingSampleValue{{RemoteID: "tempf", SensorType: "temperature", Location: "Outdoor", Value: 21.5, Unit: "°C"}}
+
+	for i := 0; i < 2; i++ {
+		status, err := dm.RecordPendingStation(ctx, data, "192.168.1.20", sample, 0)
+		if err != nil {
+			t.Fatalf("RecordPendingStation() error = %v", err)
+		}
+		if status != models.PendingStationPending {
+			t.Errorf("Expected status pending, got %s", status)
+		}
+	}
+
+	pending, err := dm.GetPendingStations(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingStations() error = %v", err)
+	}
+	var found *models.PendingStation
+	for i := range pending {
+		if pending[i].PassKey == data.PassKey {
+			found = &pending[i]
+		}
+	}
+	if found == nil || found.PushCount != 2 || found.SourceIP != "192.168.1.20" {
+		t.Fatalf("Unexpected pending station %+v", found)
+	}
+	if len(found.Sample) != 1 || found.Sample[0] != sample[0] {
+		t.Errorf("Expected sample %v, got %v", sample, found.Sample)
+	}
+
+	if err := dm.RejectPendingStation(ctx, data.PassKey); err != nil {
+		t.Fatalf("RejectPendingStation() error = %v", err)
+	}
+	if status, _ := dm.RecordPendingStation(ctx, data, "192.168.1.20", nil, 0); status != models.PendingStationRejected {
+		t.Errorf("Expected status rejected, got %s", status)
+	}
+
+	missing := uuid.New()
+	if _, err := dm.ApprovePendingStation(ctx, data.PassKey, models.StationApproval{OwnerID: &missing}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown owner, got %v", err)
+	}
+
+	stationID, err := dm.ApprovePendingStation(ctx, data.PassKey, models.StationApproval{Name: "Garden"})
+	if err != nil {
+		t.Fatalf("ApprovePendingStation() error = %v", err)
+	}
+	defer dm.DeleteStation(stationID)
+
+	// Approving a passkey of an existing station keeps the pending station
+	dm.RecordPendingStation(ctx, data, "192.168.1.20", nil, 0)
+	if _, err := dm.ApprovePendingStation(ctx, data.PassKey, models.StationApproval{Name: "Other"}); !errors.Is(err, ErrStationExists) {
+		t.Errorf("Expected ErrStationExists, got %v", err)
+	}
+	if err := dm.RejectPendingStation(ctx, data.PassKey); err != nil {
+		t.Errorf("Expected pending station to be kept, got %v", err)
+	}
+	dm.ExecWithHealthCheck(ctx, `DELETE FROM pending_stations WHERE pass_key = $1`, data.PassKey)
+	station, err := dm.LoadStation(stationID)
+	if err != nil {
+		t.Fatalf("LoadStation() error = %v", err)
+	}
+	if station.PassKey != data.PassKey || station.Config[models.StationNameConfigKey] != "Garden" || station.OwnerID != nil {
+		t.Errorf("Unexpected station %+v", station)
+	}
+
+	if _, err := dm.ApprovePendingStation(ctx, data.PassKey, models.StationApproval{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := dm.RejectPendingStation(ctx, data.PassKey); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecordPendingStation_Limit(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	first := &models.StationData{PassKey: "pending-" + uuid.New().String(), StationType: "Ecowitt"}
+	if _, err := dm.RecordPendingStation(ctx, first, "192.168.1.20", nil, 0); err != nil {
+		t.Fatalf("RecordPendingStation() error = %v", err)
+	}
+	defer dm.ExecWithHealthCheck(ctx, `DELETE FROM pending_stations WHERE pass_key = $1`, first.PassKey)
+
+	// A limit of 1 is reached by the first station
+	second := &models.StationData{PassKey: "pending-" + uuid.New().String(), StationType: "Ecowitt"}
+	if _, err := dm.RecordPendingStation(ctx, second, "192.168.1.21", nil, 1); !errors.Is(err, ErrPendingStationsFull) {
+		t.Errorf("Expected ErrPendingStationsFull, got %v", err)
+	}
+
+	// Listed stations are still counted
+	if status, err := dm.RecordPendingStation(ctx, first, "192.168.1.20", nil, 1); err != nil || status != models.PendingStationPending {
+		t.Errorf("Expected listed station to stay pending, got %s, %v", status, err)
+	}
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// StoreRawPayload stores a push request with its body gzip compressed
+func (dm *DatabaseManager) StoreRawPayload(ctx context.Context, payload *models.RawPayload) error {
+	var body []byte
+	if len(payload.Body) > 0 {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload.Body); err != nil {
+			return fmt.Errorf("failed to compress payload: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress payload: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	err := dm.QueryRowWithHealthCheck(ctx, `
+        INSERT INTO raw_payloads (station_id, source, content_type, query, body, source_ip, received_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id`,
+		payload.StationID, payload.Source, payload.ContentType, payload.Query, body, payload.SourceIP, payload.ReceivedAt.UTC(),
+	).Scan(&payload.ID)
+	if err != nil {
+		return fmt.Errorf("failed to store raw payload: %w", err)
+	}
+	return nil
+}
+
+// GetRawPayloads returns up to limit payloads of a station received within
+// a time range with an ID greater than afterID, oldest first. A zero start
+// or end leaves the range open.
+func (dm *DatabaseManager) GetRawPayloads(ctx context.Context, stationID uuid.UUID, startTime, endTime time.Time, afterID int64, limit int) ([]models.RawPayload, error) {
+	query := `
+        SELECT id, station_id, source, content_type, query, body, source_ip, received_at
+        FROM raw_payloads
+        WHERE station_id = $1 AND id > $2`
+	args := []interface{}{stationID, afterID}
+	if !startTime.IsZero() {
+		args = append(args, startTime.UTC())
+		query += fmt.Sprintf(" AND received_at >= $%d", len(args))
+	}
+	if !endTime.IsZero() {
+		args = append(args, endTime.UTC())
+		query += fmt.Sprintf(" AND received_at <= $%d", len(args))
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query raw payloads: %w", err)
+	}
+	defer rows.Close()
+
+	var payloads []models.RawPayload
+	for rows.Next() {
+		var p models.RawPayload
+		var body []byte
+		if err := rows.Scan(&p.ID, &p.StationID, &p.Source, &p.ContentType, &p.Query, &body, &p.SourceIP, &p.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan raw payload: %w", err)
+		}
+		if len(body) > 0 {
+			zr, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress raw payload %d: %w", p.ID, err)
+			}
+			if p.Body, err = io.ReadAll(zr); err != nil {
+				return nil, fmt.Errorf("failed to decompress raw payload %d: %w", p.ID, err)
+			}
+		}
+		payloads = append(payloads, p)
+	}
+	return payloads, rows.Err()
+}
+
+// DeleteRawPayloadsBefore deletes the payloads received before cutoff and
+// returns their number
+func (dm *DatabaseManager) DeleteRawPayloadsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := dm.ExecWithHealthCheck(ctx, `DELETE FROM raw_payloads WHERE received_at < $1`, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete raw payloads: %w", err)
+	}
+	return result.RowsAffected()
+}
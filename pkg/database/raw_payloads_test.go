@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestRawPayloads(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	stationID, err := dm.EnsureStation(&models.StationData{PassKey: "raw-" + uuid.New().String(), StationType: "Ecowitt", ServiceName: "ecowitt"})
+	if err != nil {
+		t.Fatalf("EnsureStation() error = %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	old := &models.RawPayload{StationID: stationID, Source: "Ecowitt", Query: "tempf=41", ReceivedAt: now.Add(-48 * time.Hour)}
+	recent := &models.RawPayload{
+		StationID:   stationID,
+		Source:      "Ecowitt",
+		ContentType: "application/x-www-form-urlencoded",
+		Body:        []byte("PASSKEY=abc&tempf=42.8"),
+		SourceIP:    "192.168.1.20",
+		ReceivedAt:  now,
+	}
+	for _, p := range []*models.RawPayload{old, recent} {
+		if err := dm.StoreRawPayload(ctx, p); err != nil {
+			t.Fatalf("StoreRawPayload() error = %v", err)
+		}
+	}
+
+	payloads, err := dm.GetRawPayloads(ctx, stationID, now.Add(-time.Hour), time.Time{}, 0, 10)
+	if err != nil {
+		t.Fatalf("GetRawPayloads() error = %v", err)
+	}
+	if len(payloads) != 1 || payloads[0].ID != recent.ID {
+		t.Fatalf("Expected the recent payload, got %+v", payloads)
+	}
+	if string(payloads[0].Body) != string(recent.Body) || payloads[0].ContentType != recent.ContentType {
+		t.Errorf("Expected the body to survive compression, got %q", payloads[0].Body)
+	}
+
+	if payloads, _ := dm.GetRawPayloads(ctx, stationID, time.Time{}, time.Time{}, old.ID, 10); len(payloads) != 1 {
+		t.Errorf("Expected payloads after the old one only, got %d", len(payloads))
+	}
+
+	deleted, err := dm.DeleteRawPayloadsBefore(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteRawPayloadsBefore() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 deleted payload, got %d", deleted)
+	}
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetLatestReadingOfSensors returns the reading of the given sensors with the
+// newest observation time, ties broken by the highest ID. It returns nil
+// without readings.
+func (dm *DatabaseManager) GetLatestReadingOfSensors(ctx context.Context, sensorIDs []uuid.UUID) (*models.SensorReading, error) {
+	if len(sensorIDs) == 0 {
+		return nil, nil
+	}
+
+	const query = `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated
+		FROM sensor_readings
+		WHERE sensor_id IN ?
+		ORDER BY date_utc DESC, id DESC
+		LIMIT 1
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest reading: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var r models.SensorReading
+	if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC, &r.Estimated); err != nil {
+		return nil, fmt.Errorf("failed to scan reading: %w", err)
+	}
+	return &r, nil
+}
+
+// GetReadingWatermark returns the watermark after the last reading of the
+// given sensors stored up to settled. Readings stored later are left out, so
+// concurrent inserts within the same second don't end up behind it.
+func (dm *DatabaseManager) GetReadingWatermark(ctx context.Context, sensorIDs []uuid.UUID, settled time.Time) (models.ReadingWatermark, error) {
+	if len(sensorIDs) == 0 {
+		return models.ReadingWatermark{}, nil
+	}
+	return dm.readingWatermark(ctx, sensorIDs, settled)
+}
+
+// GetAllReadingsWatermark returns the watermark after the last reading of
+// all sensors stored up to settled
+func (dm *DatabaseManager) GetAllReadingsWatermark(ctx context.Context, settled time.Time) (models.ReadingWatermark, error) {
+	return dm.readingWatermark(ctx, nil, settled)
+}
+
+// readingWatermark returns the watermark of the given sensors or of all
+// sensors if sensorIDs is nil
+func (dm *DatabaseManager) readingWatermark(ctx context.Context, sensorIDs []uuid.UUID, settled time.Time) (models.ReadingWatermark, error) {
+	query := `
+		SELECT created_at, id
+		FROM sensor_readings
+		WHERE created_at <= ?`
+	args := []interface{}{settled.UTC()}
+	if sensorIDs != nil {
+		query += ` AND sensor_id IN ?`
+		args = append(args, sensorIDs)
+	}
+	query += `
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+	rows, err := dm.ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return models.ReadingWatermark{}, fmt.Errorf("failed to query watermark: %w", err)
+	}
+	defer rows.Close()
+
+	var w models.ReadingWatermark
+	if rows.Next() {
+		if err := rows.Scan(&w.InsertedAt, &w.ID); err != nil {
+			return models.ReadingWatermark{}, fmt.Errorf("failed to scan watermark: %w", err)
+		}
+		w.InsertedAt = w.InsertedAt.UTC()
+	}
+	return w, rows.Err()
+}
+
+// GetReadingChanges returns up to limit readings of the given sensors stored
+// after a watermark and up to settled, in the order they were stored. Unlike
+// time range queries this includes backfilled readings with old
+// observation times.
+func (dm *DatabaseManager) GetReadingChanges(ctx context.Context, sensorIDs []uuid.UUID, since models.ReadingWatermark, settled time.Time, limit int) ([]models.ReadingChange, error) {
+	if len(sensorIDs) == 0 {
+		return nil, nil
+	}
+	return dm.readingChanges(ctx, sensorIDs, since, settled, limit)
+}
+
+// GetAllReadingChanges returns up to limit readings of all sensors stored
+// after a watermark and up to settled, in the order they were stored
+func (dm *DatabaseManager) GetAllReadingChanges(ctx context.Context, since models.ReadingWatermark, settled time.Time, limit int) ([]models.ReadingChange, error) {
+	return dm.readingChanges(ctx, nil, since, settled, limit)
+}
+
+// readingChanges returns the changes of the given sensors or of all sensors
+// if sensorIDs is nil
+func (dm *DatabaseManager) readingChanges(ctx context.Context, sensorIDs []uuid.UUID, since models.ReadingWatermark, settled time.Time, limit int) ([]models.ReadingChange, error) {
+	query := `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated, created_at
+		FROM sensor_readings
+		WHERE created_at <= ?`
+	args := []interface{}{settled.UTC()}
+	if sensorIDs != nil {
+		query += ` AND sensor_id IN ?`
+		args = append(args, sensorIDs)
+	}
+	if !since.IsZero() {
+		query += ` AND (created_at, id) > (?, ?)`
+		args = append(args, since.InsertedAt.UTC(), since.ID)
+	}
+	query += `
+		ORDER BY created_at, id
+		LIMIT ?`
+	args = append(args, uint64(limit))
+
+	rows, err := dm.ch.Conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reading changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []models.ReadingChange
+	for rows.Next() {
+		var c models.ReadingChange
+		if err := rows.Scan(&c.ID, &c.SensorID, &c.Value, &c.Unit, &c.RawValue, &c.RawUnit, &c.DateUTC, &c.Estimated, &c.InsertedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reading: %w", err)
+		}
+		c.InsertedAt = c.InsertedAt.UTC()
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
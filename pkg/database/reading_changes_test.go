@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetReadingChanges(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	ids := []uuid.UUID{sensor.ID}
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	if err := dm.StoreSensorReadingsBatch(ctx, []models.SensorReading{
+		{SensorID: sensor.ID, Value: 20, DateUTC: now},
+		{SensorID: sensor.ID, Value: 21, DateUTC: now.Add(time.Minute)},
+	}); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+	settled := time.Now().Add(time.Minute)
+
+	latest, err := dm.GetLatestReadingOfSensors(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetLatestReadingOfSensors() error = %v", err)
+	}
+	if latest == nil || latest.Value != 21 {
+		t.Fatalf("Expected the newest observation, got %+v", latest)
+	}
+
+	changes, err := dm.GetReadingChanges(ctx, ids, models.ReadingWatermark{}, settled, 10)
+	if err != nil {
+		t.Fatalf("GetReadingChanges() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d", len(changes))
+	}
+	watermark, err := dm.GetReadingWatermark(ctx, ids, settled)
+	if err != nil {
+		t.Fatalf("GetReadingWatermark() error = %v", err)
+	}
+	if watermark != changes[1].Watermark() {
+		t.Errorf("Expected watermark %s, got %s", changes[1].Watermark(), watermark)
+	}
+
+	// A backfilled reading with an old observation time comes after the
+	// watermark. Storage times have second precision, so the insert waits
+	// for the next second like the settle delay of the feed does.
+	time.Sleep(time.Second)
+	if err := dm.StoreSensorReadingsBatch(ctx, []models.SensorReading{{SensorID: sensor.ID, Value: 5, DateUTC: now.Add(-24 * time.Hour)}}); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+	changes, err = dm.GetReadingChanges(ctx, ids, watermark, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetReadingChanges() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Value != 5 {
+		t.Errorf("Expected the backfilled reading, got %+v", changes)
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// streamChunkBuckets is the number of buckets per series read at once by
+// StreamAggregatedReadings, e.g. about six weeks of hourly buckets
+const streamChunkBuckets = 1000
+
+// streamWindow is a range of bucket starts read in one query
+type streamWindow struct {
+	From time.Time
+	To   time.Time
+}
+
+// streamWindows splits the buckets of [start, end] into windows of
+// streamChunkBuckets nominal buckets in the requested order. The first
+// window starts one margin before start so that it holds the bucket
+// containing start, whose start may be earlier.
+func streamWindows(start, end time.Time, interval models.AggregateInterval, order string) []streamWindow {
+	span := interval.Nominal() * streamChunkBuckets
+	from := start.Add(-streamMargin(interval))
+
+	var windows []streamWindow
+	for !from.After(end) {
+		windows = append(windows, streamWindow{From: from, To: from.Add(span)})
+		from = from.Add(span)
+	}
+	if strings.ToUpper(order) != "ASC" {
+		for i, j := 0, len(windows)-1; i < j; i, j = i+1, j-1 {
+			windows[i], windows[j] = windows[j], windows[i]
+		}
+	}
+	return windows
+}
+
+// streamMargin is longer than any bucket of the interval, including months
+// of 31 days and days of 25 hours
+func streamMargin(interval models.AggregateInterval) time.Duration {
+	return 2 * interval.Nominal()
+}
+
+// StreamAggregatedReadings reads aggregated readings like
+// GetAggregatedReadings, but without pagination: the time range is read in
+// chunks of buckets and every non-empty chunk is passed to fn, sorted in the
+// requested order. The next chunk is only read after fn returned, so a slow
+// consumer slows down the queries. Buckets are selected by their start, so
+// a bucket is never split across chunks. StartTime is required; EndTime
+// defaults to now.
+func (dm *DatabaseManager) StreamAggregatedReadings(ctx context.Context, params models.ReadingQueryParams, fn func([]models.AggregatedReading) error) error {
+	interval, err := models.ParseAggregateInterval(params.Aggregate)
+	if err != nil {
+		return err
+	}
+	bucketExpr, ok := clickhouseBucketExpr(params.Aggregate, params.Timezone)
+	if !ok {
+		return fmt.Errorf("invalid aggregate interval %s or time zone %s", params.Aggregate, params.Timezone)
+	}
+	start, err := time.Parse(time.RFC3339, params.StartTime)
+	if err != nil {
+		return fmt.Errorf("invalid start_time: %w", err)
+	}
+	end := time.Now().UTC()
+	if params.EndTime != "" {
+		if end, err = time.Parse(time.RFC3339, params.EndTime); err != nil {
+			return fmt.Errorf("invalid end_time: %w", err)
+		}
+	}
+
+	sensors, err := dm.resolveSensors(params)
+	if err != nil {
+		return fmt.Errorf("failed to resolve sensors: %w", err)
+	}
+	if len(sensors) == 0 {
+		return nil
+	}
+	sensorIDs := make([]uuid.UUID, 0, len(sensors))
+	metaBySensor := make(map[uuid.UUID]sensorMetadata, len(sensors))
+	for _, s := range sensors {
+		sensorIDs = append(sensorIDs, s.SensorID)
+		metaBySensor[s.SensorID] = s
+	}
+
+	whereClause, args, err := buildReadingsWhere(sensorIDs, params.StartTime, params.EndTime)
+	if err != nil {
+		return err
+	}
+	aggFunc := params.AggregateFunc
+	if aggFunc == "" {
+		aggFunc = "avg"
+	}
+	asc := strings.ToUpper(params.Order) == "ASC"
+	margin := streamMargin(interval)
+
+	for _, window := range streamWindows(start.UTC(), end.UTC(), interval, params.Order) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The readings of buckets starting in the window lie before the
+		// window end plus one bucket
+		query := bucketQuery(bucketExpr, whereClause+" AND date_utc >= ? AND date_utc < ?") +
+			" HAVING time_bucket >= ? AND time_bucket < ?"
+		windowArgs := append(append([]interface{}{}, args...), window.From, window.To.Add(margin), window.From, window.To)
+		buckets, err := dm.queryBuckets(ctx, query, windowArgs)
+		if err != nil {
+			return err
+		}
+		if len(buckets) == 0 {
+			continue
+		}
+
+		aggregated := foldBuckets(buckets, metaBySensor, params.GroupBy, aggFunc)
+		sort.SliceStable(aggregated, func(i, j int) bool {
+			if asc {
+				return aggregated[i].DateUTC.Before(aggregated[j].DateUTC)
+			}
+			return aggregated[i].DateUTC.After(aggregated[j].DateUTC)
+		})
+		if err := fn(aggregated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestStreamWindows(t *testing.T) {
+	hourly := models.AggregateInterval{Count: 1, Unit: models.IntervalHour}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	windows := streamWindows(start, end, hourly, "asc")
+	// A year of hourly buckets plus the margin in chunks of 1000 hours
+	if len(windows) != 9 {
+		t.Fatalf("Expected 9 windows, got %d", len(windows))
+	}
+	if !windows[0].From.Before(start) {
+		t.Errorf("First window must start before start to hold its bucket, got %s", windows[0].From)
+	}
+	for i := 1; i < len(windows); i++ {
+		if !windows[i].From.Equal(windows[i-1].To) {
+			t.Errorf("Window %d starts at %s, expected the end of the previous one %s", i, windows[i].From, windows[i-1].To)
+		}
+	}
+	if last := windows[len(windows)-1]; !last.To.After(end) {
+		t.Errorf("Last window must cover end, ends at %s", last.To)
+	}
+
+	desc := streamWindows(start, end, hourly, "desc")
+	if len(desc) != len(windows) || !desc[0].From.Equal(windows[len(windows)-1].From) {
+		t.Error("Expected descending order to return the windows in reverse")
+	}
+}
+
+func TestStreamWindows_Monthly(t *testing.T) {
+	monthly := models.AggregateInterval{Count: 1, Unit: models.IntervalMonth}
+	start := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	windows := streamWindows(start, start.AddDate(1, 0, 0), monthly, "asc")
+	if len(windows) != 1 {
+		t.Fatalf("Expected one window, got %d", len(windows))
+	}
+	// The bucket of start begins on March 1
+	if !windows[0].From.Before(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Window must hold the month of start, starts at %s", windows[0].From)
+	}
+}
+
+func TestStreamAggregatedReadings(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "indoor")
+
+	// Store readings every minute for 2 hours
+	start := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+	storeTestReadings(t, dm, sensor.ID, start, 120, func(i int) float64 {
+		return float64(20 + (i % 10))
+	})
+
+	params := models.ReadingQueryParams{
+		StationID:     &station.ID,
+		StartTime:     start.Format(time.RFC3339),
+		Aggregate:     "1m",
+		AggregateFunc: "avg",
+		Order:         "asc",
+	}
+
+	// One minute buckets are read in chunks of 1000 minutes
+	var readings []models.AggregatedReading
+	err := dm.StreamAggregatedReadings(context.Background(), params, func(chunk []models.AggregatedReading) error {
+		readings = append(readings, chunk...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream aggregated readings: %v", err)
+	}
+	if len(readings) != 120 {
+		t.Fatalf("Expected 120 buckets, got %d", len(readings))
+	}
+	for i := 1; i < len(readings); i++ {
+		if !readings[i].DateUTC.After(readings[i-1].DateUTC) {
+			t.Fatalf("Bucket %d is not after the previous one", i)
+		}
+	}
+}
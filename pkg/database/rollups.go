@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ensureRollupSchema creates the daily rollup table and the materialized view
+// that keeps it up to date on insert. A newly created rollup table is filled
+// from the existing readings before the view starts feeding it.
+//
+// The view only sees inserts: readings that are deleted or imported with
+// ALTER/INSERT ... SELECT leave the rollup stale until it is recomputed with
+// RecomputeDailyRollups.
+func (cm *ClickHouseManager) ensureRollupSchema(ctx context.Context) error {
+	var exists uint8
+	if err := cm.conn.QueryRow(ctx, "EXISTS TABLE sensor_readings_daily").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check rollup table: %w", err)
+	}
+
+	const tableDDL = `
+		CREATE TABLE IF NOT EXISTS sensor_readings_daily (
+			sensor_id   UUID,
+			day         Date,
+			min_value   SimpleAggregateFunction(min, Float64),
+			max_value   SimpleAggregateFunction(max, Float64),
+			sum_value   SimpleAggregateFunction(sum, Float64),
+			count_value SimpleAggregateFunction(sum, UInt64),
+			estimated_count SimpleAggregateFunction(sum, UInt64) DEFAULT 0
+		) ENGINE = AggregatingMergeTree()
+		PARTITION BY toYYYYMM(day)
+		ORDER BY (sensor_id, day)
+	`
+	if err := cm.conn.Exec(ctx, tableDDL); err != nil {
+		return fmt.Errorf("failed to create rollup table: %w", err)
+	}
+	const addEstimated = `ALTER TABLE sensor_readings_daily ADD COLUMN IF NOT EXISTS estimated_count SimpleAggregateFunction(sum, UInt64) DEFAULT 0`
+	if err := cm.conn.Exec(ctx, addEstimated); err != nil {
+		return fmt.Errorf("failed to update rollup table: %w", err)
+	}
+
+	if exists == 0 {
+		if err := cm.conn.Exec(ctx, "INSERT INTO sensor_readings_daily "+dailyRollupSelect+" GROUP BY sensor_id, day"); err != nil {
+			return fmt.Errorf("failed to backfill rollup table: %w", err)
+		}
+	}
+
+	// Views created before the estimated count are replaced; rollups of
+	// inserts in between miss their readings until they are recomputed
+	var viewQuery string
+	err := cm.conn.QueryRow(ctx, `
+		SELECT create_table_query FROM system.tables
+		WHERE database = currentDatabase() AND name = 'sensor_readings_daily_mv'`).Scan(&viewQuery)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check rollup view: %w", err)
+	}
+	if viewQuery != "" && !strings.Contains(viewQuery, "estimated_count") {
+		if err := cm.conn.Exec(ctx, "DROP VIEW sensor_readings_daily_mv"); err != nil {
+			return fmt.Errorf("failed to replace rollup view: %w", err)
+		}
+	}
+
+	viewDDL := `
+		CREATE MATERIALIZED VIEW IF NOT EXISTS sensor_readings_daily_mv
+		TO sensor_readings_daily AS
+		` + dailyRollupSelect + ` GROUP BY sensor_id, day`
+	if err := cm.conn.Exec(ctx, viewDDL); err != nil {
+		return fmt.Errorf("failed to create rollup view: %w", err)
+	}
+	return nil
+}
+
+// dailyRollupSelect aggregates raw readings into daily rollup rows
+const dailyRollupSelect = `
+	SELECT
+		sensor_id,
+		toDate(date_utc) AS day,
+		min(value)       AS min_value,
+		max(value)       AS max_value,
+		sum(value)       AS sum_value,
+		count()          AS count_value,
+		countIf(estimated) AS estimated_count
+	FROM sensor_readings`
+
+// RecomputeDailyRollups rebuilds the daily rollups of the given sensors for
+// all days touched by the time range. It is needed after readings were
+// deleted or imported outside of the regular insert path.
+func (dm *DatabaseManager) RecomputeDailyRollups(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time) error {
+	if len(sensorIDs) == 0 {
+		return nil
+	}
+
+	startDay := startTime.UTC().Truncate(24 * time.Hour)
+	endDay := endTime.UTC().Truncate(24 * time.Hour)
+
+	// Synchronous mutation so the rebuilt rows are not deleted afterwards
+	const deleteQuery = `
+		ALTER TABLE sensor_readings_daily
+		DELETE WHERE sensor_id IN ? AND day >= toDate(?) AND day <= toDate(?)
+		SETTINGS mutations_sync = 1
+	`
+	if err := dm.ch.Conn().Exec(ctx, deleteQuery, sensorIDs, startDay, endDay); err != nil {
+		return fmt.Errorf("failed to delete stale rollups: %w", err)
+	}
+
+	insertQuery := "INSERT INTO sensor_readings_daily " + dailyRollupSelect + `
+		WHERE sensor_id IN ? AND toDate(date_utc) >= toDate(?) AND toDate(date_utc) <= toDate(?)
+		GROUP BY sensor_id, day`
+	if err := dm.ch.Conn().Exec(ctx, insertQuery, sensorIDs, startDay, endDay); err != nil {
+		return fmt.Errorf("failed to rebuild rollups: %w", err)
+	}
+
+	log.Printf("✓ Recomputed daily rollups of %d sensors from %s to %s", len(sensorIDs), startDay.Format(time.DateOnly), endDay.Format(time.DateOnly))
+	return nil
+}
+
+// rollupAggregateQuery returns the bucket query of GetAggregatedReadings
+// answered from the daily rollups. Per-reading columns (first/last) are not
+// available and returned as placeholders.
+func rollupAggregateQuery(interval string, sensorIDs []uuid.UUID, startTime, endTime string) (string, []interface{}, error) {
+	bucketExpr, ok := rollupBucketExpr(interval)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid rollup interval: %s", interval)
+	}
+
+	args := []interface{}{sensorIDs}
+	where := "WHERE sensor_id IN ?"
+	if startTime != "" {
+		t, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid start_time: %w", err)
+		}
+		where += " AND day >= toDate(?)"
+		args = append(args, t.UTC())
+	}
+	if endTime != "" {
+		t, err := time.Parse(time.RFC3339, endTime)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid end_time: %w", err)
+		}
+		// The end is a day boundary; that day itself is not included
+		where += " AND day < toDate(?)"
+		args = append(args, t.UTC())
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS time_bucket,
+			sensor_id,
+			sum(sum_value)                      AS sum_value,
+			sum(count_value)                    AS count_value,
+			min(min_value)                      AS min_value,
+			max(max_value)                      AS max_value,
+			toFloat64(0)                        AS first_value,
+			min(toDateTime64(day, 3, 'UTC'))    AS first_date,
+			toFloat64(0)                        AS last_value,
+			max(toDateTime64(day, 3, 'UTC'))    AS last_date,
+			sum(estimated_count)                AS estimated_count
+		FROM sensor_readings_daily
+		%s
+		GROUP BY time_bucket, sensor_id
+	`, bucketExpr, where)
+	return query, args, nil
+}
+
+// rollupBucketExpr returns the bucket expression over the daily rollup table
+// for calendar intervals (days, weeks and months) in UTC. The second return
+// value is false when the interval is shorter than a day.
+func rollupBucketExpr(interval string) (string, bool) {
+	iv, err := models.ParseAggregateInterval(interval)
+	if err != nil || !iv.Calendar() {
+		return "", false
+	}
+	if iv.Count == 1 && iv.Unit == models.IntervalDay {
+		return "toDateTime(day, 'UTC')", true
+	}
+	return fmt.Sprintf("toDateTime(toStartOfInterval(day, INTERVAL %d %s), 'UTC')", iv.Count, clickhouseIntervalUnit(iv)), true
+}
+
+// canUseRollups reports whether an aggregated query can be answered from the
+// daily rollups: the interval must be at least a day, the aggregate function
+// must not need individual readings and the time range must cover whole days.
+func canUseRollups(interval, aggFunc, startTime, endTime string) bool {
+	if _, ok := rollupBucketExpr(interval); !ok {
+		return false
+	}
+	if aggFunc == "first" || aggFunc == "last" {
+		return false
+	}
+	if startTime != "" && !isDayBoundary(startTime) {
+		return false
+	}
+	if endTime != "" && !isDayBoundary(endTime) {
+		return false
+	}
+	return true
+}
+
+// isDayBoundary reports whether an RFC3339 time is midnight UTC
+func isDayBoundary(value string) bool {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	t = t.UTC()
+	return t.Equal(t.Truncate(24 * time.Hour))
+}
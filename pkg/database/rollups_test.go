@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestCanUseRollups(t *testing.T) {
+	testCases := []struct {
+		name     string
+		interval string
+		aggFunc  string
+		start    string
+		end      string
+		expected bool
+	}{
+		{name: "Daily average without range", interval: "1d", aggFunc: "avg", expected: true},
+		{name: "Weekly max with day aligned range", interval: "1w", aggFunc: "max", start: "2026-01-01T00:00:00Z", end: "2026-02-01T00:00:00Z", expected: true},
+		{name: "Hourly interval", interval: "1h", aggFunc: "avg", expected: false},
+		{name: "First value needs raw readings", interval: "1d", aggFunc: "first", expected: false},
+		{name: "Partial day range", interval: "1d", aggFunc: "avg", start: "2026-01-01T12:00:00Z", expected: false},
+		{name: "Offset midnight is not UTC midnight", interval: "1d", aggFunc: "avg", end: "2026-01-02T00:00:00+01:00", expected: false},
+		{name: "Multiple days", interval: "3d", aggFunc: "sum", expected: true},
+		{name: "Former month spelling", interval: "1M", aggFunc: "avg", expected: true},
+		{name: "Arbitrary hours", interval: "36h", aggFunc: "avg", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := canUseRollups(tc.interval, tc.aggFunc, tc.start, tc.end); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecomputeDailyRollups(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	day := time.Now().UTC().Truncate(24 * time.Hour).Add(-48 * time.Hour)
+	storeTestReadings(t, dm, sensor.ID, day, 60, func(i int) float64 { return float64(i) })
+
+	ctx := context.Background()
+
+	// Remove half of the readings behind the rollup's back
+	err := dm.ch.Conn().Exec(ctx, "ALTER TABLE sensor_readings DELETE WHERE sensor_id = ? AND value >= 30 SETTINGS mutations_sync = 1", sensor.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete readings: %v", err)
+	}
+
+	if err := dm.RecomputeDailyRollups(ctx, []uuid.UUID{sensor.ID}, day, day); err != nil {
+		t.Fatalf("Failed to recompute rollups: %v", err)
+	}
+
+	var count uint64
+	var maxValue float64
+	row := dm.ch.Conn().QueryRow(ctx, "SELECT sum(count_value), max(max_value) FROM sensor_readings_daily WHERE sensor_id = ?", sensor.ID)
+	if err := row.Scan(&count, &maxValue); err != nil {
+		t.Fatalf("Failed to query rollups: %v", err)
+	}
+
+	if count != 30 || maxValue != 29 {
+		t.Errorf("Expected 30 readings with max 29, got %d with max %f", count, maxValue)
+	}
+}
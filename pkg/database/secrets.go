@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// secretPrefix marks encrypted config values. The value holds the wrapped
+// data key and the ciphertext, both base64 encoded.
+const secretPrefix = "enc:v1:"
+
+// ErrNoSecretsKey is returned when an encrypted config value is read or
+// existing values are encrypted without a configured secrets key
+var ErrNoSecretsKey = errors.New("no secrets key configured")
+
+// KeyWrapper encrypts the data keys of secret config values. The local
+// implementation uses a master key from the environment; a KMS can be used
+// by implementing this interface and passing it to SetKeyWrapper.
+type KeyWrapper interface {
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// localKeyWrapper wraps data keys with AES-256-GCM and a local master key
+type localKeyWrapper struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyWrapper creates a KeyWrapper for a 32 byte master key
+func NewLocalKeyWrapper(masterKey []byte) (KeyWrapper, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(masterKey))
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &localKeyWrapper{aead: aead}, nil
+}
+
+func (w *localKeyWrapper) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return seal(w.aead, dataKey)
+}
+
+func (w *localKeyWrapper) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return open(w.aead, wrapped)
+}
+
+// keyWrapperFromEnv reads the base64 encoded master key from SECRETS_KEY or
+// the file named by SECRETS_KEY_FILE. Without either secrets are stored in
+// plain text.
+func keyWrapperFromEnv() (KeyWrapper, error) {
+	encoded := os.Getenv("SECRETS_KEY")
+	if path := os.Getenv("SECRETS_KEY_FILE"); encoded == "" && path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+		}
+		encoded = string(content)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key: %w", err)
+	}
+	return NewLocalKeyWrapper(key)
+}
+
+// SetKeyWrapper sets the key used to encrypt secret config values. A nil
+// wrapper stores new values in plain text.
+func (dm *DatabaseManager) SetKeyWrapper(w KeyWrapper) {
+	dm.keyWrapper = w
+}
+
+// newGCM creates an AES-GCM cipher for a key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce prepended to the result
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts the output of seal
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// isEncryptedSecret reports whether a config value is encrypted
+func isEncryptedSecret(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, secretPrefix)
+}
+
+// encryptSecret encrypts a value with a new data key wrapped by w
+func encryptSecret(ctx context.Context, w KeyWrapper, plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := w.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(wrapped) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// decryptSecret decrypts a value created by encryptSecret
+func decryptSecret(ctx context.Context, w KeyWrapper, value string) (string, error) {
+	if w == nil {
+		return "", ErrNoSecretsKey
+	}
+	wrappedPart, ciphertextPart, ok := strings.Cut(strings.TrimPrefix(value, secretPrefix), ":")
+	if !ok {
+		return "", errors.New("malformed encrypted value")
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedPart)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextPart)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	dataKey, err := w.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// encryptConfig returns a copy of a station config with plain text secrets
+// encrypted and the number of encrypted values. Without key wrapper the
+// config is returned unchanged.
+func (dm *DatabaseManager) encryptConfig(ctx context.Context, config map[string]interface{}) (map[string]interface{}, int, error) {
+	if dm.keyWrapper == nil || config == nil {
+		return config, 0, nil
+	}
+
+	encrypted := make(map[string]interface{}, len(config))
+	count := 0
+	for key, value := range config {
+		s, ok := value.(string)
+		if ok && s != "" && models.IsSecretConfigKey(key) && !isEncryptedSecret(s) {
+			enc, err := encryptSecret(ctx, dm.keyWrapper, s)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to encrypt %s: %w", key, err)
+			}
+			value = enc
+			count++
+		}
+		encrypted[key] = value
+	}
+	return encrypted, count, nil
+}
+
+// decryptConfig decrypts the secrets of a station config in place
+func (dm *DatabaseManager) decryptConfig(ctx context.Context, config map[string]interface{}) error {
+	for key, value := range config {
+		if !isEncryptedSecret(value) {
+			continue
+		}
+		plaintext, err := decryptSecret(ctx, dm.keyWrapper, value.(string))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		config[key] = plaintext
+	}
+	return nil
+}
+
+// EncryptStationSecrets encrypts the plain text secrets stored in the
+// config of all stations and returns the number of encrypted values
+func (dm *DatabaseManager) EncryptStationSecrets(ctx context.Context) (int, error) {
+	if dm.keyWrapper == nil {
+		return 0, ErrNoSecretsKey
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, config FROM stations FOR UPDATE`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query stations: %w", err)
+	}
+	configs := make(map[uuid.UUID]map[string]interface{})
+	for rows.Next() {
+		var id uuid.UUID
+		var configJSON []byte
+		if err := rows.Scan(&id, &configJSON); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan station: %w", err)
+		}
+		var config map[string]interface{}
+		if err := json.Unmarshal(configJSON, &config); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to parse config of station %s: %w", id, err)
+		}
+		configs[id] = config
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query stations: %w", err)
+	}
+
+	total := 0
+	for id, config := range configs {
+		encrypted, count, err := dm.encryptConfig(ctx, config)
+		if err != nil {
+			return 0, fmt.Errorf("station %s: %w", id, err)
+		}
+		if count == 0 {
+			continue
+		}
+		configJSON, err := json.Marshal(encrypted)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE stations SET config = $1 WHERE id = $2`, configJSON, id); err != nil {
+			return 0, fmt.Errorf("failed to update config of station %s: %w", id, err)
+		}
+		total += count
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit encrypted secrets: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func testKeyWrapper(t *testing.T) KeyWrapper {
+	t.Helper()
+	w, err := NewLocalKeyWrapper(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatalf("NewLocalKeyWrapper() error = %v", err)
+	}
+	return w
+}
+
+func TestNewLocalKeyWrapper_KeyLength(t *testing.T) {
+	if _, err := NewLocalKeyWrapper([]byte("short")); err == nil {
+		t.Error("expected error for short key")
+	}
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	w := testKeyWrapper(t)
+
+	enc, err := encryptSecret(ctx, w, "refresh-token")
+	if err != nil {
+		t.Fatalf("encryptSecret() error = %v", err)
+	}
+	if !strings.HasPrefix(enc, secretPrefix) || strings.Contains(enc, "refresh-token") {
+		t.Fatalf("unexpected encrypted value %q", enc)
+	}
+
+	again, _ := encryptSecret(ctx, w, "refresh-token")
+	if again == enc {
+		t.Error("expected a new data key per value")
+	}
+
+	plain, err := decryptSecret(ctx, w, enc)
+	if err != nil {
+		t.Fatalf("decryptSecret() error = %v", err)
+	}
+	if plain != "refresh-token" {
+		t.Errorf("decryptSecret() = %q", plain)
+	}
+
+	other, _ := NewLocalKeyWrapper(bytes.Repeat([]byte{8}, 32))
+	if _, err := decryptSecret(ctx, other, enc); err == nil {
+		t.Error("expected error with wrong key")
+	}
+	if _, err := decryptSecret(ctx, nil, enc); !errors.Is(err, ErrNoSecretsKey) {
+		t.Errorf("expected ErrNoSecretsKey, got %v", err)
+	}
+}
+
+func TestEncryptConfig(t *testing.T) {
+	ctx := context.Background()
+	dm := &DatabaseManager{keyWrapper: testKeyWrapper(t)}
+
+	config := map[string]interface{}{
+		"client_id":     "id",
+		"client_secret": "secret",
+		"refresh_token": "",
+		"pull_interval": 300.0,
+	}
+	encrypted, count, err := dm.encryptConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("encryptConfig() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+	if config["client_secret"] != "secret" {
+		t.Error("encryptConfig() changed the input config")
+	}
+	if encrypted["client_id"] != "id" || encrypted["refresh_token"] != "" || encrypted["pull_interval"] != 300.0 {
+		t.Errorf("unexpected non-secret values: %v", encrypted)
+	}
+	if !isEncryptedSecret(encrypted["client_secret"]) {
+		t.Fatalf("client_secret not encrypted: %v", encrypted["client_secret"])
+	}
+
+	// Encrypted values are kept as they are
+	if _, count, _ := dm.encryptConfig(ctx, encrypted); count != 0 {
+		t.Errorf("re-encrypted %d values", count)
+	}
+
+	if err := dm.decryptConfig(ctx, encrypted); err != nil {
+		t.Fatalf("decryptConfig() error = %v", err)
+	}
+	if encrypted["client_secret"] != "secret" {
+		t.Errorf("client_secret = %v", encrypted["client_secret"])
+	}
+}
+
+func TestEncryptConfig_NoKey(t *testing.T) {
+	dm := &DatabaseManager{}
+	config := map[string]interface{}{"client_secret": "secret"}
+
+	encrypted, count, err := dm.encryptConfig(context.Background(), config)
+	if err != nil || count != 0 || encrypted["client_secret"] != "secret" {
+		t.Errorf("encryptConfig() = %v, %d, %v", encrypted, count, err)
+	}
+}
+
+func TestEncryptStationSecrets(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := &models.StationData{
+		ID:          uuid.New(),
+		PassKey:     "secrets-" + uuid.New().String(),
+		StationType: "netatmo",
+		Mode:        "pull",
+		ServiceName: "netatmo",
+		Config:      map[string]interface{}{"client_id": "id", "client_secret": "secret"},
+	}
+	if err := dm.SaveStation(station); err != nil {
+		t.Fatalf("SaveStation() error = %v", err)
+	}
+
+	if _, err := dm.EncryptStationSecrets(ctx); !errors.Is(err, ErrNoSecretsKey) {
+		t.Errorf("expected ErrNoSecretsKey, got %v", err)
+	}
+
+	dm.SetKeyWrapper(testKeyWrapper(t))
+	count, err := dm.EncryptStationSecrets(ctx)
+	if err != nil {
+		t.Fatalf("EncryptStationSecrets() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+
+	var raw []byte
+	if err := dm.db.QueryRowContext(ctx, `SELECT config FROM stations WHERE id = $1`, station.ID).Scan(&raw); err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	var stored map[string]interface{}
+	_ = json.Unmarshal(raw, &stored)
+	if !isEncryptedSecret(stored["client_secret"]) || stored["client_id"] != "id" {
+		t.Errorf("unexpected stored config: %v", stored)
+	}
+
+	config, err := dm.GetStationConfig(station.ID)
+	if err != nil {
+		t.Fatalf("GetStationConfig() error = %v", err)
+	}
+	if config["client_secret"] != "secret" {
+		t.Errorf("client_secret = %v", config["client_secret"])
+	}
+
+	loaded, err := dm.LoadStation(station.ID)
+	if err != nil {
+		t.Fatalf("LoadStation() error = %v", err)
+	}
+	if loaded.Config["client_secret"] != "secret" {
+		t.Errorf("client_secret = %v", loaded.Config["client_secret"])
+	}
+}
+
+func TestUpdateStationConfigValues_Encrypts(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+	dm.SetKeyWrapper(testKeyWrapper(t))
+
+	ctx := context.Background()
+	station := &models.StationData{
+		ID:          uuid.New(),
+		PassKey:     "secrets-" + uuid.New().String(),
+		StationType: "netatmo",
+		Mode:        "pull",
+		ServiceName: "netatmo",
+		Config:      map[string]interface{}{"client_id": "id"},
+	}
+	if err := dm.SaveStation(station); err != nil {
+		t.Fatalf("SaveStation() error = %v", err)
+	}
+
+	err := dm.UpdateStationConfigValues(ctx, station.ID, map[string]interface{}{"access_token": "new-token", "token_expiry": "2026-01-01T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("UpdateStationConfigValues() error = %v", err)
+	}
+
+	var raw []byte
+	if err := dm.db.QueryRowContext(ctx, `SELECT config FROM stations WHERE id = $1`, station.ID).Scan(&raw); err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	var stored map[string]interface{}
+	_ = json.Unmarshal(raw, &stored)
+	if !isEncryptedSecret(stored["access_token"]) || stored["client_id"] != "id" || stored["token_expiry"] != "2026-01-01T00:00:00Z" {
+		t.Errorf("unexpected stored config: %v", stored)
+	}
+
+	if err := dm.UpdateStationConfigValues(ctx, uuid.New(), map[string]interface{}{"state": "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// SetSensorDisplay stores the display settings of a sensor. Empty settings
+// reset the sensor to the defaults of its type.
+func (dm *DatabaseManager) SetSensorDisplay(ctx context.Context, sensorID uuid.UUID, display *models.SensorDisplay) error {
+	var data []byte
+	if !display.IsZero() {
+		var err error
+		if data, err = json.Marshal(display); err != nil {
+			return err
+		}
+	}
+
+	result, err := dm.ExecWithHealthCheck(ctx, `UPDATE sensors SET display = $2, updated_at = NOW() WHERE id = $1`, sensorID, data)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("sensor %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetSensorDisplays returns the display settings of the sensors that have
+// any, keyed by sensor
+func (dm *DatabaseManager) GetSensorDisplays(ctx context.Context, sensorIDs []uuid.UUID) (map[uuid.UUID]*models.SensorDisplay, error) {
+	displays := make(map[uuid.UUID]*models.SensorDisplay)
+	if len(sensorIDs) == 0 {
+		return displays, nil
+	}
+
+	rows, err := dm.QueryWithHealthCheck(ctx, `SELECT id, display FROM sensors WHERE id::text = ANY($1) AND display IS NOT NULL`, pq.Array(uuidStrings(sensorIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		display, err := parseSensorDisplay(data)
+		if err != nil {
+			return nil, err
+		}
+		if display != nil {
+			displays[id] = display
+		}
+	}
+	return displays, rows.Err()
+}
+
+// parseSensorDisplay decodes the display column, NULL is nil
+func parseSensorDisplay(data []byte) (*models.SensorDisplay, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var display models.SensorDisplay
+	if err := json.Unmarshal(data, &display); err != nil {
+		return nil, fmt.Errorf("invalid sensor display: %w", err)
+	}
+	return &display, nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestSetSensorDisplay(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := &models.Sensor{StationID: station.ID, SensorType: models.SensorTypeTemperatureOutdoor, Location: "outdoor", Enabled: true}
+	if err := dm.CreateSensor(sensor); err != nil {
+		t.Fatalf("Failed to create sensor: %v", err)
+	}
+
+	decimals := 0
+	display := &models.SensorDisplay{Unit: "°F", Decimals: &decimals, Name: "Garden", Icon: "mdi:thermometer"}
+	if err := dm.SetSensorDisplay(ctx, sensor.ID, display); err != nil {
+		t.Fatalf("SetSensorDisplay() error = %v", err)
+	}
+
+	stored, err := dm.GetSensor(sensor.ID, false)
+	if err != nil {
+		t.Fatalf("GetSensor() error = %v", err)
+	}
+	if d := stored.Sensor.Display; d == nil || d.Unit != "°F" || d.Decimals == nil || *d.Decimals != 0 || d.Icon != "mdi:thermometer" {
+		t.Errorf("Unexpected display %+v", d)
+	}
+
+	displays, err := dm.GetSensorDisplays(ctx, []uuid.UUID{sensor.ID, uuid.New()})
+	if err != nil {
+		t.Fatalf("GetSensorDisplays() error = %v", err)
+	}
+	if len(displays) != 1 || displays[sensor.ID].Name != "Garden" {
+		t.Errorf("Expected the display of the sensor, got %v", displays)
+	}
+
+	// Empty settings reset the sensor
+	if err := dm.SetSensorDisplay(ctx, sensor.ID, &models.SensorDisplay{}); err != nil {
+		t.Fatalf("SetSensorDisplay() error = %v", err)
+	}
+	if stored, _ := dm.GetSensor(sensor.ID, false); stored.Sensor.Display != nil {
+		t.Errorf("Expected no display, got %+v", stored.Sensor.Display)
+	}
+
+	if err := dm.SetSensorDisplay(ctx, uuid.New(), display); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
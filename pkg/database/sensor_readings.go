@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"sort"
 	"strings"
 	"time"
@@ -17,15 +18,81 @@ import (
 // flushes small inserts as larger MergeTree parts.
 func (dm *DatabaseManager) StoreSensorReading(sensorID uuid.UUID, value float64, dateUTC time.Time) error {
 	const query = `INSERT INTO sensor_readings (sensor_id, value, date_utc) VALUES (?, ?, ?)`
-	return dm.ch.Conn().AsyncInsert(context.Background(), query, false, sensorID, value, dateUTC.UTC())
+	if err := dm.ch.Conn().AsyncInsert(context.Background(), query, false, sensorID, value, dateUTC.UTC()); err != nil {
+		return err
+	}
+	if dm.buckets != nil {
+		dm.buckets.invalidate(sensorID, dateUTC)
+	}
+	return nil
+}
+
+// StoreSensorReadingsBatch stores multiple sensor readings in ClickHouse
+// using a single batch insert.
+func (dm *DatabaseManager) StoreSensorReadingsBatch(ctx context.Context, readings []models.SensorReading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+
+	batch, err := dm.ch.Conn().PrepareBatch(ctx, `INSERT INTO sensor_readings (sensor_id, value, unit, raw_value, raw_unit, date_utc, received_at, estimated)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare reading batch: %w", err)
+	}
+
+	for _, r := range readings {
+		var receivedAt *time.Time
+		if r.ReceivedAt != nil {
+			t := r.ReceivedAt.UTC()
+			receivedAt = &t
+		}
+		if err := batch.Append(r.SensorID, r.Value, r.Unit, r.RawValue, r.RawUnit, r.DateUTC.UTC(), receivedAt, r.Estimated); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("failed to append reading: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send reading batch: %w", err)
+	}
+	dm.invalidateBuckets(readings)
+	return nil
+}
+
+// invalidateBuckets drops cached aggregation buckets that readings were
+// written into
+func (dm *DatabaseManager) invalidateBuckets(readings []models.SensorReading) {
+	if dm.buckets == nil {
+		return
+	}
+	earliest := make(map[uuid.UUID]time.Time)
+	for _, r := range readings {
+		if t, ok := earliest[r.SensorID]; !ok || r.DateUTC.Before(t) {
+			earliest[r.SensorID] = r.DateUTC
+		}
+	}
+	for sensorID, t := range earliest {
+		dm.buckets.invalidate(sensorID, t)
+	}
 }
 
 // GetSensorReadings retrieves readings for a sensor within a time range.
 func (dm *DatabaseManager) GetSensorReadings(sensorID uuid.UUID, startTime, endTime time.Time, limit int) ([]models.SensorReading, error) {
-	const query = `
-		SELECT id, sensor_id, value, date_utc
+	return dm.sensorReadings(sensorID, startTime, endTime, limit, "")
+}
+
+// GetMeasuredSensorReadings retrieves the readings for a sensor within a
+// time range that were measured rather than estimated
+func (dm *DatabaseManager) GetMeasuredSensorReadings(sensorID uuid.UUID, startTime, endTime time.Time, limit int) ([]models.SensorReading, error) {
+	return dm.sensorReadings(sensorID, startTime, endTime, limit, " AND estimated = 0")
+}
+
+// sensorReadings retrieves the readings for a sensor within a time range
+// matching an extra condition
+func (dm *DatabaseManager) sensorReadings(sensorID uuid.UUID, startTime, endTime time.Time, limit int, condition string) ([]models.SensorReading, error) {
+	query := `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated
 		FROM sensor_readings
-		WHERE sensor_id = ? AND date_utc >= ? AND date_utc <= ?
+		WHERE sensor_id = ? AND date_utc >= ? AND date_utc <= ?` + condition + `
 		ORDER BY date_utc DESC
 		LIMIT ?
 	`
@@ -40,7 +107,52 @@ func (dm *DatabaseManager) GetSensorReadings(sensorID uuid.UUID, startTime, endT
 	var readings []models.SensorReading
 	for rows.Next() {
 		var r models.SensorReading
-		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.DateUTC); err != nil {
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC, &r.Estimated); err != nil {
+			log.Printf("Failed to scan reading: %v", err)
+			continue
+		}
+		readings = append(readings, r)
+	}
+	return readings, rows.Err()
+}
+
+// GetSensorSeries returns the readings of a sensor within a time range,
+// oldest first, together with the last reading before and the first
+// reading after the range, so values at the range bounds can be
+// interpolated.
+func (dm *DatabaseManager) GetSensorSeries(ctx context.Context, sensorID uuid.UUID, startTime, endTime time.Time) ([]models.SensorReading, error) {
+	const query = `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc FROM (
+			(SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc
+			FROM sensor_readings
+			WHERE sensor_id = ? AND date_utc < ?
+			ORDER BY date_utc DESC
+			LIMIT 1)
+			UNION ALL
+			(SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc
+			FROM sensor_readings
+			WHERE sensor_id = ? AND date_utc >= ? AND date_utc <= ?)
+			UNION ALL
+			(SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc
+			FROM sensor_readings
+			WHERE sensor_id = ? AND date_utc > ?
+			ORDER BY date_utc ASC
+			LIMIT 1)
+		)
+		ORDER BY date_utc ASC
+	`
+
+	start, end := startTime.UTC(), endTime.UTC()
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorID, start, sensorID, start, end, sensorID, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sensor series: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []models.SensorReading
+	for rows.Next() {
+		var r models.SensorReading
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC); err != nil {
 			log.Printf("Failed to scan reading: %v", err)
 			continue
 		}
@@ -49,6 +161,162 @@ func (dm *DatabaseManager) GetSensorReadings(sensorID uuid.UUID, startTime, endT
 	return readings, rows.Err()
 }
 
+// GetAveragedReadings returns the per-bucket average of each sensor within a
+// time range, ordered by time. The readings are aligned on the bucket start
+// so series of different sensors can be compared point by point.
+func (dm *DatabaseManager) GetAveragedReadings(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time, interval string) (map[uuid.UUID][]models.SensorReading, error) {
+	return dm.averagedReadings(ctx, sensorIDs, startTime, endTime, interval, false)
+}
+
+// GetCircularAveragedReadings is GetAveragedReadings for directions in
+// degrees, see models.IsDirection. Each bucket holds the vector mean, so
+// 350° and 10° average to 0° rather than 180°.
+func (dm *DatabaseManager) GetCircularAveragedReadings(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time, interval string) (map[uuid.UUID][]models.SensorReading, error) {
+	return dm.averagedReadings(ctx, sensorIDs, startTime, endTime, interval, true)
+}
+
+func (dm *DatabaseManager) averagedReadings(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time, interval string, circular bool) (map[uuid.UUID][]models.SensorReading, error) {
+	result := make(map[uuid.UUID][]models.SensorReading, len(sensorIDs))
+	if len(sensorIDs) == 0 {
+		return result, nil
+	}
+
+	bucketExpr, ok := clickhouseBucketExpr(interval, "")
+	if !ok {
+		return nil, fmt.Errorf("invalid aggregate interval: %s", interval)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s AS time_bucket, sensor_id, avg(value) AS avg_value,
+			avg(sin(radians(value))) AS sin_value, avg(cos(radians(value))) AS cos_value
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND date_utc >= ? AND date_utc <= ?
+		GROUP BY time_bucket, sensor_id
+		ORDER BY time_bucket ASC
+	`, bucketExpr)
+
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs, startTime.UTC(), endTime.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query averaged readings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.SensorReading
+		var sin, cos float64
+		if err := rows.Scan(&r.DateUTC, &r.SensorID, &r.Value, &sin, &cos); err != nil {
+			log.Printf("Failed to scan averaged reading: %v", err)
+			continue
+		}
+		if circular {
+			r.Value = math.Mod(math.Atan2(sin, cos)*180/math.Pi+360, 360)
+		}
+		result[r.SensorID] = append(result[r.SensorID], r)
+	}
+	return result, rows.Err()
+}
+
+// ForEachReadingTime calls fn for every reading timestamp of the given sensors
+// within a time range, ordered by sensor and time. Rows are streamed so long
+// ranges do not have to fit in memory.
+func (dm *DatabaseManager) ForEachReadingTime(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time, fn func(sensorID uuid.UUID, dateUTC time.Time)) error {
+	if len(sensorIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+		SELECT sensor_id, date_utc
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND date_utc >= ? AND date_utc <= ?
+		ORDER BY sensor_id, date_utc
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs, startTime.UTC(), endTime.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to query reading times: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sensorID uuid.UUID
+			dateUTC  time.Time
+		)
+		if err := rows.Scan(&sensorID, &dateUTC); err != nil {
+			return fmt.Errorf("failed to scan reading time: %w", err)
+		}
+		fn(sensorID, dateUTC)
+	}
+	return rows.Err()
+}
+
+// GetReadingsOfSensors returns the readings of the given sensors within a
+// time range with their IDs
+func (dm *DatabaseManager) GetReadingsOfSensors(ctx context.Context, sensorIDs []uuid.UUID, startTime, endTime time.Time) ([]models.SensorReading, error) {
+	if len(sensorIDs) == 0 {
+		return nil, nil
+	}
+
+	const query = `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc
+		FROM sensor_readings
+		WHERE sensor_id IN ? AND date_utc >= ? AND date_utc <= ?
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs, startTime.UTC(), endTime.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query readings: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []models.SensorReading
+	for rows.Next() {
+		var r models.SensorReading
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC); err != nil {
+			return nil, fmt.Errorf("failed to scan reading: %w", err)
+		}
+		readings = append(readings, r)
+	}
+	return readings, rows.Err()
+}
+
+// CorrectSensorReadings deletes the replaced readings of a correction,
+// stores the corrected ones and recomputes the daily rollups of the
+// affected days and the records of the affected sensors
+func (dm *DatabaseManager) CorrectSensorReadings(ctx context.Context, c models.ReadingCorrection) error {
+	if len(c.Store) == 0 {
+		return nil
+	}
+
+	if len(c.Delete) > 0 {
+		const query = `ALTER TABLE sensor_readings DELETE WHERE id IN ? SETTINGS mutations_sync = 1`
+		if err := dm.ch.Conn().Exec(ctx, query, c.Delete); err != nil {
+			return fmt.Errorf("failed to delete replaced readings: %w", err)
+		}
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, c.Store); err != nil {
+		return err
+	}
+
+	days := make(map[uuid.UUID][2]time.Time)
+	for _, r := range c.Store {
+		d, ok := days[r.SensorID]
+		if !ok || r.DateUTC.Before(d[0]) {
+			d[0] = r.DateUTC
+		}
+		if !ok || r.DateUTC.After(d[1]) {
+			d[1] = r.DateUTC
+		}
+		days[r.SensorID] = d
+	}
+	sensorIDs := make([]uuid.UUID, 0, len(days))
+	for sensorID, d := range days {
+		if err := dm.RecomputeDailyRollups(ctx, []uuid.UUID{sensorID}, d[0], d[1]); err != nil {
+			return err
+		}
+		sensorIDs = append(sensorIDs, sensorID)
+	}
+	return dm.RecomputeSensorRecords(ctx, sensorIDs)
+}
+
 // sensorMetadata is the per-sensor info from Postgres needed to resolve
 // readings-side filters (StationID/SensorType/Location) and to re-group
 // aggregated results by sensor_type or location.
@@ -147,7 +415,11 @@ func (dm *DatabaseManager) GetReadings(params models.ReadingQueryParams) (*model
 
 	ctx := context.Background()
 
+	// Pivoted results are paginated by timestamp so rows are never split
 	countQuery := "SELECT count() FROM sensor_readings " + whereClause
+	if params.Pivot {
+		countQuery = "SELECT uniqExact(date_utc) FROM sensor_readings " + whereClause
+	}
 	var totalCount uint64
 	if err := dm.ch.Conn().QueryRow(ctx, countQuery, args...).Scan(&totalCount); err != nil {
 		return nil, fmt.Errorf("failed to count readings: %w", err)
@@ -162,9 +434,24 @@ func (dm *DatabaseManager) GetReadings(params models.ReadingQueryParams) (*model
 	limit := uint64(params.Limit)
 
 	dataQuery := fmt.Sprintf(
-		`SELECT id, sensor_id, value, date_utc FROM sensor_readings %s ORDER BY date_utc %s LIMIT %d OFFSET %d`,
+		`SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated FROM sensor_readings %s ORDER BY date_utc %s LIMIT %d OFFSET %d`,
 		whereClause, order, limit, offset,
 	)
+	// Downsampling needs the whole range
+	if params.Points > 0 {
+		dataQuery = fmt.Sprintf(
+			`SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated FROM sensor_readings %s ORDER BY date_utc %s`,
+			whereClause, order,
+		)
+	}
+
+	if params.Pivot {
+		dataQuery = fmt.Sprintf(
+			`SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated FROM sensor_readings %s AND date_utc IN (SELECT DISTINCT date_utc FROM sensor_readings %s ORDER BY date_utc %s LIMIT %d OFFSET %d) ORDER BY date_utc %s`,
+			whereClause, whereClause, order, limit, offset, order,
+		)
+		args = append(args, args...)
+	}
 
 	rows, err := dm.ch.Conn().Query(ctx, dataQuery, args...)
 	if err != nil {
@@ -175,7 +462,7 @@ func (dm *DatabaseManager) GetReadings(params models.ReadingQueryParams) (*model
 	readings := []models.SensorReading{}
 	for rows.Next() {
 		var r models.SensorReading
-		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.DateUTC); err != nil {
+		if err := rows.Scan(&r.ID, &r.SensorID, &r.Value, &r.Unit, &r.RawValue, &r.RawUnit, &r.DateUTC, &r.Estimated); err != nil {
 			log.Printf("Failed to scan reading: %v", err)
 			continue
 		}
@@ -186,7 +473,7 @@ func (dm *DatabaseManager) GetReadings(params models.ReadingQueryParams) (*model
 	}
 
 	totalPages := int((totalCount + uint64(params.Limit) - 1) / uint64(params.Limit))
-	if totalPages == 0 {
+	if totalPages == 0 || params.Points > 0 {
 		totalPages = 1
 	}
 
@@ -199,6 +486,7 @@ func (dm *DatabaseManager) GetReadings(params models.ReadingQueryParams) (*model
 
 // buildReadingsWhere builds the WHERE clause for readings queries against ClickHouse.
 // Time range filters are optional. The sensor list is required (callers guard the empty case).
+// date_utc is compared as is, so ClickHouse skips the monthly partitions outside of the range.
 func buildReadingsWhere(sensorIDs []uuid.UUID, startTime, endTime string) (string, []interface{}, error) {
 	args := []interface{}{sensorIDs}
 	parts := []string{"sensor_id IN ?"}
@@ -237,14 +525,16 @@ type bucketRow struct {
 	FirstDate  time.Time
 	LastValue  float64
 	LastDate   time.Time
+	// Estimated is the number of estimated readings in the bucket
+	Estimated uint64
 }
 
 // GetAggregatedReadings retrieves aggregated readings grouped by a time bucket
 // and (sensor | sensor_type | location).
 func (dm *DatabaseManager) GetAggregatedReadings(params models.ReadingQueryParams) (*models.ReadingsResponse, error) {
-	bucketExpr, ok := clickhouseBucketExpr(params.Aggregate)
+	bucketExpr, ok := clickhouseBucketExpr(params.Aggregate, params.Timezone)
 	if !ok {
-		return nil, fmt.Errorf("invalid aggregate interval: %s", params.Aggregate)
+		return nil, fmt.Errorf("invalid aggregate interval %s or time zone %s", params.Aggregate, params.Timezone)
 	}
 
 	sensors, err := dm.resolveSensors(params)
@@ -273,12 +563,76 @@ func (dm *DatabaseManager) GetAggregatedReadings(params models.ReadingQueryParam
 		metaBySensor[s.SensorID] = s
 	}
 
-	whereClause, args, err := buildReadingsWhere(sensorIDs, params.StartTime, params.EndTime)
+	aggFunc := params.AggregateFunc
+	if aggFunc == "" {
+		aggFunc = "avg"
+	}
+
+	var buckets []bucketRow
+	if isUTC(params.Timezone) && canUseRollups(params.Aggregate, aggFunc, params.StartTime, params.EndTime) {
+		// Day and longer buckets are read from the much smaller daily rollups,
+		// which hold UTC days
+		var query string
+		var args []interface{}
+		query, args, err = rollupAggregateQuery(params.Aggregate, sensorIDs, params.StartTime, params.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		buckets, err = dm.queryBuckets(context.Background(), query, args)
+	} else {
+		buckets, err = dm.cachedBuckets(context.Background(), bucketExpr, sensorIDs, params)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	dataQuery := fmt.Sprintf(`
+	aggregated := foldBuckets(buckets, metaBySensor, params.GroupBy, aggFunc)
+
+	order := strings.ToUpper(params.Order)
+	if order != "ASC" && order != "DESC" {
+		order = "DESC"
+	}
+	sort.SliceStable(aggregated, func(i, j int) bool {
+		if order == "ASC" {
+			return aggregated[i].DateUTC.Before(aggregated[j].DateUTC)
+		}
+		return aggregated[i].DateUTC.After(aggregated[j].DateUTC)
+	})
+
+	if params.Pivot {
+		return pageAggregatedByTime(response, aggregated, params), nil
+	}
+	if params.Points > 0 {
+		response.Data = aggregated
+		response.Total = len(aggregated)
+		return response, nil
+	}
+
+	total := len(aggregated)
+	totalPages := (total + params.Limit - 1) / params.Limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	start := (params.Page - 1) * params.Limit
+	if start > total {
+		start = total
+	}
+	end := start + params.Limit
+	if end > total {
+		end = total
+	}
+
+	response.Data = aggregated[start:end]
+	response.Total = total
+	response.TotalPages = totalPages
+	response.HasMore = params.Page < totalPages
+	return response, nil
+}
+
+// bucketQuery returns the query for per-sensor buckets matching a WHERE clause
+func bucketQuery(bucketExpr, whereClause string) string {
+	return fmt.Sprintf(`
 		SELECT
 			%s AS time_bucket,
 			sensor_id,
@@ -289,13 +643,17 @@ func (dm *DatabaseManager) GetAggregatedReadings(params models.ReadingQueryParam
 			argMin(value, date_utc)  AS first_value,
 			min(date_utc)            AS first_date,
 			argMax(value, date_utc)  AS last_value,
-			max(date_utc)            AS last_date
+			max(date_utc)            AS last_date,
+			countIf(estimated)       AS estimated_count
 		FROM sensor_readings
 		%s
 		GROUP BY time_bucket, sensor_id
 	`, bucketExpr, whereClause)
+}
 
-	rows, err := dm.ch.Conn().Query(context.Background(), dataQuery, args...)
+// queryBuckets runs a bucket query
+func (dm *DatabaseManager) queryBuckets(ctx context.Context, query string, args []interface{}) ([]bucketRow, error) {
+	rows, err := dm.ch.Conn().Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +667,7 @@ func (dm *DatabaseManager) GetAggregatedReadings(params models.ReadingQueryParam
 			&b.Sum, &b.Count, &b.Min, &b.Max,
 			&b.FirstValue, &b.FirstDate,
 			&b.LastValue, &b.LastDate,
+			&b.Estimated,
 		); err != nil {
 			log.Printf("Failed to scan bucket row: %v", err)
 			continue
@@ -318,45 +677,95 @@ func (dm *DatabaseManager) GetAggregatedReadings(params models.ReadingQueryParam
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return buckets, nil
+}
 
-	aggFunc := params.AggregateFunc
-	if aggFunc == "" {
-		aggFunc = "avg"
+// cachedBuckets returns the per-sensor buckets of an aggregated query. With
+// the bucket cache enabled, closed buckets that lie completely inside the
+// time range are served from memory and only the remaining buckets, usually
+// just the open one, are read from ClickHouse.
+func (dm *DatabaseManager) cachedBuckets(ctx context.Context, bucketExpr string, sensorIDs []uuid.UUID, params models.ReadingQueryParams) ([]bucketRow, error) {
+	whereClause, args, err := buildReadingsWhere(sensorIDs, params.StartTime, params.EndTime)
+	if err != nil {
+		return nil, err
 	}
 
-	aggregated := foldBuckets(buckets, metaBySensor, params.GroupBy, aggFunc)
+	step, ok := cacheableStep(params.Aggregate)
+	if dm.buckets == nil || !ok || !isUTC(params.Timezone) || params.StartTime == "" {
+		return dm.queryBuckets(ctx, bucketQuery(bucketExpr, whereClause), args)
+	}
 
-	order := strings.ToUpper(params.Order)
-	if order != "ASC" && order != "DESC" {
-		order = "DESC"
+	// Buckets in [closedFrom, closedTo) are complete and will not change
+	// unless readings are backfilled
+	start, _ := time.Parse(time.RFC3339, params.StartTime)
+	upper := dm.buckets.now()
+	if params.EndTime != "" {
+		if end, _ := time.Parse(time.RFC3339, params.EndTime); end.Before(upper) {
+			upper = end
+		}
 	}
-	sort.SliceStable(aggregated, func(i, j int) bool {
-		if order == "ASC" {
-			return aggregated[i].DateUTC.Before(aggregated[j].DateUTC)
+	closedFrom := ceilTime(start.UTC(), step)
+	closedTo := upper.UTC().Truncate(step)
+	if !closedFrom.Before(closedTo) {
+		return dm.queryBuckets(ctx, bucketQuery(bucketExpr, whereClause), args)
+	}
+
+	generation := dm.buckets.currentGeneration()
+	var cached []bucketRow
+	from, to := dm.buckets.covered(sensorIDs, params.Aggregate, closedFrom, closedTo)
+	if from.Before(to) {
+		cached = dm.buckets.get(sensorIDs, params.Aggregate, from, to)
+		whereClause += " AND (date_utc < ? OR date_utc >= ?)"
+		args = append(args, from, to)
+	}
+
+	fetched, err := dm.queryBuckets(ctx, bucketQuery(bucketExpr, whereClause), args)
+	if err != nil {
+		return nil, err
+	}
+	dm.buckets.put(generation, sensorIDs, params.Aggregate, closedFrom, closedTo, fetched)
+	return append(cached, fetched...), nil
+}
+
+// pageAggregatedByTime paginates sorted aggregated readings by timestamp
+// for pivoted output, keeping all series of a timestamp on one page
+func pageAggregatedByTime(response *models.ReadingsResponse, aggregated []models.AggregatedReading, params models.ReadingQueryParams) *models.ReadingsResponse {
+	// Index of the first reading of each timestamp
+	var starts []int
+	for i, r := range aggregated {
+		if i == 0 || !r.DateUTC.Equal(aggregated[i-1].DateUTC) {
+			starts = append(starts, i)
 		}
-		return aggregated[i].DateUTC.After(aggregated[j].DateUTC)
-	})
+	}
 
-	total := len(aggregated)
+	total := len(starts)
 	totalPages := (total + params.Limit - 1) / params.Limit
 	if totalPages == 0 {
 		totalPages = 1
 	}
 
-	start := (params.Page - 1) * params.Limit
-	if start > total {
-		start = total
+	first := (params.Page - 1) * params.Limit
+	if first > total {
+		first = total
 	}
-	end := start + params.Limit
-	if end > total {
-		end = total
+	last := first + params.Limit
+	if last > total {
+		last = total
+	}
+
+	start, end := len(aggregated), len(aggregated)
+	if first < total {
+		start = starts[first]
+	}
+	if last < total {
+		end = starts[last]
 	}
 
 	response.Data = aggregated[start:end]
 	response.Total = total
 	response.TotalPages = totalPages
 	response.HasMore = params.Page < totalPages
-	return response, nil
+	return response
 }
 
 // foldBuckets re-aggregates per-sensor-per-bucket rows by the requested group key
@@ -375,6 +784,7 @@ func foldBuckets(buckets []bucketRow, meta map[uuid.UUID]sensorMetadata, groupBy
 		firstDate  time.Time
 		lastVal    float64
 		lastDate   time.Time
+		estimated  uint64
 		seen       bool
 	}
 
@@ -417,6 +827,7 @@ func foldBuckets(buckets []bucketRow, meta map[uuid.UUID]sensorMetadata, groupBy
 		}
 		f.sum += b.Sum
 		f.count += b.Count
+		f.estimated += b.Estimated
 		if b.Min < f.min {
 			f.min = b.Min
 		}
@@ -436,10 +847,11 @@ func foldBuckets(buckets []bucketRow, meta map[uuid.UUID]sensorMetadata, groupBy
 	out := make([]models.AggregatedReading, 0, len(groups))
 	for _, f := range groups {
 		r := models.AggregatedReading{
-			DateUTC:  f.dateUTC,
-			Count:    int(f.count),
-			MinValue: f.min,
-			MaxValue: f.max,
+			DateUTC:        f.dateUTC,
+			Count:          int(f.count),
+			MinValue:       f.min,
+			MaxValue:       f.max,
+			EstimatedCount: int(f.estimated),
 		}
 		switch groupBy {
 		case "sensor_type":
@@ -475,29 +887,42 @@ func foldBuckets(buckets []bucketRow, meta map[uuid.UUID]sensorMetadata, groupBy
 }
 
 // clickhouseBucketExpr returns the ClickHouse expression that buckets date_utc
-// at the requested resolution. The second return value is false for unknown intervals.
-func clickhouseBucketExpr(interval string) (string, bool) {
-	switch interval {
-	case "1m":
-		return "toStartOfInterval(date_utc, INTERVAL 1 MINUTE)", true
-	case "5m":
-		return "toStartOfInterval(date_utc, INTERVAL 5 MINUTE)", true
-	case "15m":
-		return "toStartOfInterval(date_utc, INTERVAL 15 MINUTE)", true
-	case "30m":
-		return "toStartOfInterval(date_utc, INTERVAL 30 MINUTE)", true
-	case "1h":
-		return "toStartOfInterval(date_utc, INTERVAL 1 HOUR)", true
-	case "6h":
-		return "toStartOfInterval(date_utc, INTERVAL 6 HOUR)", true
-	case "12h":
-		return "toStartOfInterval(date_utc, INTERVAL 12 HOUR)", true
-	case "1d":
-		return "toStartOfInterval(date_utc, INTERVAL 1 DAY)", true
-	case "1w":
-		return "toStartOfInterval(date_utc, INTERVAL 1 WEEK)", true
-	case "1M":
-		return "toStartOfInterval(date_utc, INTERVAL 1 MONTH)", true
-	}
-	return "", false
+// at the requested resolution, see models.ParseAggregateInterval. Calendar
+// intervals are aligned to midnight in tz (UTC if empty). The second return
+// value is false for invalid intervals and time zones.
+func clickhouseBucketExpr(interval, tz string) (string, bool) {
+	iv, err := models.ParseAggregateInterval(interval)
+	if err != nil {
+		return "", false
+	}
+	if !iv.Calendar() || isUTC(tz) {
+		return fmt.Sprintf("toStartOfInterval(date_utc, INTERVAL %d %s)", iv.Count, clickhouseIntervalUnit(iv)), true
+	}
+	if _, err := time.LoadLocation(tz); err != nil || strings.ContainsAny(tz, `'\`) {
+		return "", false
+	}
+	// Day, week and month starts are dates in tz; converting them back to
+	// a DateTime in tz yields the instant of local midnight
+	return fmt.Sprintf("toDateTime(toStartOfInterval(date_utc, INTERVAL %d %s, '%s'), '%s')", iv.Count, clickhouseIntervalUnit(iv), tz, tz), true
+}
+
+// clickhouseIntervalUnit returns the unit of an interval in a ClickHouse
+// INTERVAL expression
+func clickhouseIntervalUnit(iv models.AggregateInterval) string {
+	switch iv.Unit {
+	case models.IntervalMinute:
+		return "MINUTE"
+	case models.IntervalHour:
+		return "HOUR"
+	case models.IntervalDay:
+		return "DAY"
+	case models.IntervalWeek:
+		return "WEEK"
+	}
+	return "MONTH"
+}
+
+// isUTC reports whether a time zone name is UTC or empty
+func isUTC(tz string) bool {
+	return tz == "" || tz == "UTC" || tz == "Etc/UTC"
 }
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Target throughput on a 4 core machine with local PostgreSQL and ClickHouse:
+//   - StoreSensorReadingsBatch: at least 50,000 readings/s with batches of 100
+//   - GetAggregatedReadings: 1h buckets of 10 sensors over 7 days below 50ms
+//
+// Run with:
+//   go test ./pkg/database -run '^$' -bench . -benchtime 10x
+
+func BenchmarkStoreSensorReadingsBatch(b *testing.B) {
+	dm := setupTestDatabaseManager(b)
+	if dm == nil {
+		b.Skip("Skipping benchmark that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(b, dm)
+	sensor := setupTestSensor(b, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	ctx := context.Background()
+
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			start := time.Now().UTC().Add(-time.Duration(b.N*size) * time.Second)
+			batch := make([]models.SensorReading, size)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := range batch {
+					batch[j] = models.SensorReading{
+						SensorID: sensor.ID,
+						Value:    float64(j % 40),
+						DateUTC:  start.Add(time.Duration(i*size+j) * time.Second),
+					}
+				}
+				if err := dm.StoreSensorReadingsBatch(ctx, batch); err != nil {
+					b.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+				}
+			}
+			b.ReportMetric(float64(b.N*size)/b.Elapsed().Seconds(), "readings/s")
+		})
+	}
+}
+
+func BenchmarkGetAggregatedReadings(b *testing.B) {
+	dm := setupTestDatabaseManager(b)
+	if dm == nil {
+		b.Skip("Skipping benchmark that requires real database connection")
+	}
+	defer dm.Close()
+
+	// 10 sensors with a reading per minute for 7 days
+	station := setupTestStation(b, dm)
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-7 * 24 * time.Hour)
+	ctx := context.Background()
+	for s := 0; s < 10; s++ {
+		sensorType := models.SensorTypeTemperature
+		if s%2 == 1 {
+			sensorType = models.SensorTypeHumidity
+		}
+		sensor := setupTestSensor(b, dm, station.ID, sensorType, fmt.Sprintf("location-%d", s))
+
+		batch := make([]models.SensorReading, 0, 1440)
+		for t := start; t.Before(end); t = t.Add(time.Minute) {
+			batch = append(batch, models.SensorReading{SensorID: sensor.ID, Value: float64(t.Minute()), DateUTC: t})
+			if len(batch) == cap(batch) {
+				if err := dm.StoreSensorReadingsBatch(ctx, batch); err != nil {
+					b.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+				}
+				batch = batch[:0]
+			}
+		}
+		if err := dm.StoreSensorReadingsBatch(ctx, batch); err != nil {
+			b.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		aggregate string
+		groupBy   string
+	}{
+		{"1h", "1h", ""},
+		{"1h/sensor_type", "1h", "sensor_type"},
+		{"1d", "1d", ""},
+	}
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			params := models.ReadingQueryParams{
+				StationID:     &station.ID,
+				StartTime:     start.Format(time.RFC3339),
+				EndTime:       end.Format(time.RFC3339),
+				Aggregate:     c.aggregate,
+				AggregateFunc: "avg",
+				GroupBy:       c.groupBy,
+				Limit:         10000,
+				Page:          1,
+				Order:         "asc",
+			}
+			for i := 0; i < b.N; i++ {
+				if _, err := dm.GetAggregatedReadings(params); err != nil {
+					b.Fatalf("GetAggregatedReadings() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,7 +12,7 @@ import (
 )
 
 // setupTestStation creates a test station and returns it
-func setupTestStation(t *testing.T, dm *DatabaseManager) *models.StationData {
+func setupTestStation(t testing.TB, dm *DatabaseManager) *models.StationData {
 	t.Helper()
 
 	station := &models.StationData{
@@ -32,7 +35,7 @@ func setupTestStation(t *testing.T, dm *DatabaseManager) *models.StationData {
 }
 
 // setupTestSensor creates a test sensor for a given station
-func setupTestSensor(t *testing.T, dm *DatabaseManager, stationID uuid.UUID, sensorType string, location string) *models.Sensor {
+func setupTestSensor(t testing.TB, dm *DatabaseManager, stationID uuid.UUID, sensorType string, location string) *models.Sensor {
 	t.Helper()
 
 	sensor := &models.Sensor{
@@ -105,6 +108,51 @@ func TestStoreSensorReading(t *testing.T) {
 	}
 }
 
+func TestStoreSensorReadingsBatch(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "indoor")
+
+	now := time.Now().UTC()
+	rawF := 71.6
+	batch := []models.SensorReading{
+		{SensorID: sensor.ID, Value: 20.0, DateUTC: now.Add(-2 * time.Minute)},
+		{SensorID: sensor.ID, Value: 21.0, DateUTC: now.Add(-1 * time.Minute)},
+		{SensorID: sensor.ID, Value: 22.0, Unit: "°C", RawValue: &rawF, RawUnit: "°F", DateUTC: now},
+	}
+
+	if err := dm.StoreSensorReadingsBatch(context.Background(), batch); err != nil {
+		t.Fatalf("Failed to store reading batch: %v", err)
+	}
+
+	readings, err := dm.GetSensorReadings(sensor.ID, now.Add(-1*time.Hour), now.Add(1*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Failed to get sensor readings: %v", err)
+	}
+
+	if len(readings) != len(batch) {
+		t.Errorf("Expected %d readings, got %d", len(batch), len(readings))
+	}
+
+	// Readings are returned newest first; the raw value must survive the round trip
+	if len(readings) > 0 {
+		latest := readings[0]
+		if latest.RawValue == nil || *latest.RawValue != rawF || latest.RawUnit != "°F" || latest.Unit != "°C" {
+			t.Errorf("Expected raw value %.1f °F, got %v %q (unit %q)", rawF, latest.RawValue, latest.RawUnit, latest.Unit)
+		}
+	}
+
+	// An empty batch is a no-op
+	if err := dm.StoreSensorReadingsBatch(context.Background(), nil); err != nil {
+		t.Errorf("Expected no error for empty batch, got %v", err)
+	}
+}
+
 func TestGetSensorReadings(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -139,6 +187,31 @@ func TestGetSensorReadings(t *testing.T) {
 	}
 }
 
+func TestGetSensorSeries(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "indoor")
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	storeTestReadings(t, dm, sensor.ID, now, 10, func(i int) float64 {
+		return float64(i)
+	})
+
+	// Minutes 3 to 5 plus the neighbours 2 and 6
+	readings, err := dm.GetSensorSeries(context.Background(), sensor.ID, now.Add(2*time.Minute+30*time.Second), now.Add(5*time.Minute+30*time.Second))
+	if err != nil {
+		t.Fatalf("Failed to get sensor series: %v", err)
+	}
+	if len(readings) != 5 || readings[0].Value != 2 || readings[4].Value != 6 {
+		t.Errorf("Expected readings 2 to 6 in ascending order, got %+v", readings)
+	}
+}
+
 func TestGetSensorReadings_WithLimit(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -568,6 +641,84 @@ func TestGetAggregatedReadings_DifferentFunctions(t *testing.T) {
 	}
 }
 
+func TestGetAggregatedReadings_CacheInvalidatedByBackfill(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+	dm.buckets = newBucketCache(48 * time.Hour)
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	// Six closed hours with a reading every 10 minutes
+	start := time.Now().UTC().Truncate(time.Hour).Add(-6 * time.Hour)
+	var readings []models.SensorReading
+	for i := 0; i < 36; i++ {
+		readings = append(readings, models.SensorReading{SensorID: sensor.ID, Value: 10, DateUTC: start.Add(time.Duration(i) * 10 * time.Minute)})
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+
+	params := models.ReadingQueryParams{
+		StationID: &station.ID,
+		StartTime: start.Format(time.RFC3339),
+		Aggregate: "1h",
+		Page:      1,
+		Limit:     100,
+		Order:     "asc",
+	}
+	bucketAt := func(response *models.ReadingsResponse, at time.Time) models.AggregatedReading {
+		for _, r := range response.Data.([]models.AggregatedReading) {
+			if r.DateUTC.Equal(at) {
+				return r
+			}
+		}
+		t.Fatalf("no bucket at %s", at)
+		return models.AggregatedReading{}
+	}
+
+	response, err := dm.GetAggregatedReadings(params)
+	if err != nil {
+		t.Fatalf("GetAggregatedReadings() error = %v", err)
+	}
+	if response.Total != 6 {
+		t.Fatalf("Total = %d, want 6", response.Total)
+	}
+	if from, to := dm.buckets.covered([]uuid.UUID{sensor.ID}, "1h", start, start.Add(6*time.Hour)); !from.Equal(start) || !to.Equal(start.Add(6*time.Hour)) {
+		t.Fatalf("closed buckets not cached, covered [%s, %s)", from, to)
+	}
+
+	// The cached result is identical
+	cached, err := dm.GetAggregatedReadings(params)
+	if err != nil {
+		t.Fatalf("GetAggregatedReadings() error = %v", err)
+	}
+	if cached.Total != 6 || bucketAt(cached, start.Add(time.Hour)).Count != 6 {
+		t.Errorf("unexpected cached result: %+v", cached.Data)
+	}
+
+	// Backfilling into a past bucket is visible on the next query
+	backfill := []models.SensorReading{{SensorID: sensor.ID, Value: 80, DateUTC: start.Add(time.Hour + 5*time.Minute)}}
+	if err := dm.StoreSensorReadingsBatch(ctx, backfill); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+	response, err = dm.GetAggregatedReadings(params)
+	if err != nil {
+		t.Fatalf("GetAggregatedReadings() error = %v", err)
+	}
+	bucket := bucketAt(response, start.Add(time.Hour))
+	if bucket.Count != 7 || bucket.Value != 20 {
+		t.Errorf("backfilled bucket = count %d, value %v; want 7, 20", bucket.Count, bucket.Value)
+	}
+	if first := bucketAt(response, start); first.Count != 6 || first.Value != 10 {
+		t.Errorf("first bucket = count %d, value %v; want 6, 10", first.Count, first.Value)
+	}
+}
+
 func TestGetAggregatedReadings_Pagination(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -634,6 +785,41 @@ func TestGetAggregatedReadings_Pagination(t *testing.T) {
 	}
 }
 
+func TestPageAggregatedByTime(t *testing.T) {
+	t1 := time.Date(2026, 2, 9, 16, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t2.Add(time.Hour)
+	aggregated := []models.AggregatedReading{
+		{DateUTC: t1, SensorType: "Temperature"},
+		{DateUTC: t1, SensorType: "Humidity"},
+		{DateUTC: t2, SensorType: "Temperature"},
+		{DateUTC: t2, SensorType: "Humidity"},
+		{DateUTC: t3, SensorType: "Temperature"},
+	}
+	params := models.ReadingQueryParams{Page: 1, Limit: 2, Pivot: true}
+
+	response := pageAggregatedByTime(&models.ReadingsResponse{}, aggregated, params)
+	if response.Total != 3 || response.TotalPages != 2 || !response.HasMore {
+		t.Errorf("unexpected pagination: total %d, pages %d, more %v", response.Total, response.TotalPages, response.HasMore)
+	}
+	if readings := response.Data.([]models.AggregatedReading); len(readings) != 4 {
+		t.Errorf("Expected 4 readings on page 1, got %d", len(readings))
+	}
+
+	params.Page = 2
+	response = pageAggregatedByTime(&models.ReadingsResponse{}, aggregated, params)
+	readings := response.Data.([]models.AggregatedReading)
+	if len(readings) != 1 || !readings[0].DateUTC.Equal(t3) || response.HasMore {
+		t.Errorf("unexpected page 2: %v", readings)
+	}
+
+	params.Page = 3
+	response = pageAggregatedByTime(&models.ReadingsResponse{}, aggregated, params)
+	if readings := response.Data.([]models.AggregatedReading); len(readings) != 0 {
+		t.Errorf("Expected empty page 3, got %d readings", len(readings))
+	}
+}
+
 func TestGetAggregatedReadings_DifferentIntervals(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -654,9 +840,10 @@ func TestGetAggregatedReadings_DifferentIntervals(t *testing.T) {
 		interval      string
 		expectedCount int
 	}{
-		{"5m", 24}, // 120 minutes / 5 minutes
-		{"15m", 8}, // 120 minutes / 15 minutes
-		{"1h", 2},  // 120 minutes / 60 minutes
+		{"5m", 24},  // 120 minutes / 5 minutes
+		{"15m", 8},  // 120 minutes / 15 minutes
+		{"1h", 2},   // 120 minutes / 60 minutes
+		{"10m", 12}, // arbitrary durations
 	}
 
 	for _, tc := range testCases {
@@ -682,6 +869,31 @@ func TestGetAggregatedReadings_DifferentIntervals(t *testing.T) {
 	}
 }
 
+func TestClickhouseBucketExpr(t *testing.T) {
+	testCases := []struct {
+		interval string
+		tz       string
+		expected string
+	}{
+		{"5m", "", "toStartOfInterval(date_utc, INTERVAL 5 MINUTE)"},
+		{"3h", "Europe/Vienna", "toStartOfInterval(date_utc, INTERVAL 3 HOUR)"},
+		{"1d", "UTC", "toStartOfInterval(date_utc, INTERVAL 1 DAY)"},
+		{"1M", "", "toStartOfInterval(date_utc, INTERVAL 1 MONTH)"},
+		{"1w", "Europe/Vienna", "toDateTime(toStartOfInterval(date_utc, INTERVAL 1 WEEK, 'Europe/Vienna'), 'Europe/Vienna')"},
+		{"2x", "", ""},
+		{"1d", "Nowhere/City", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.interval+" "+tc.tz, func(t *testing.T) {
+			expr, ok := clickhouseBucketExpr(tc.interval, tc.tz)
+			if ok != (tc.expected != "") || expr != tc.expected {
+				t.Errorf("Expected %q, got %q (%v)", tc.expected, expr, ok)
+			}
+		})
+	}
+}
+
 func TestGetAggregatedReadings_MultipleSensors(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -1508,3 +1720,103 @@ func TestGetAggregatedReadings_TimeRangeFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestGetAveragedReadings(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor1 := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	sensor2 := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperatureOutdoor, "outdoor")
+
+	// Store readings every minute for 1 hour
+	now := time.Now().UTC().Truncate(time.Hour)
+	storeTestReadings(t, dm, sensor1.ID, now, 60, func(i int) float64 { return 20 })
+	storeTestReadings(t, dm, sensor2.ID, now, 60, func(i int) float64 { return 21 })
+
+	series, err := dm.GetAveragedReadings(context.Background(), []uuid.UUID{sensor1.ID, sensor2.ID}, now.Add(-time.Hour), now.Add(2*time.Hour), "15m")
+	if err != nil {
+		t.Fatalf("Failed to get averaged readings: %v", err)
+	}
+
+	if len(series[sensor1.ID]) != 4 || len(series[sensor2.ID]) != 4 {
+		t.Fatalf("Expected 4 buckets per sensor, got %d and %d", len(series[sensor1.ID]), len(series[sensor2.ID]))
+	}
+	for i := range series[sensor1.ID] {
+		if !series[sensor1.ID][i].DateUTC.Equal(series[sensor2.ID][i].DateUTC) {
+			t.Errorf("Expected aligned buckets, got %s and %s", series[sensor1.ID][i].DateUTC, series[sensor2.ID][i].DateUTC)
+		}
+	}
+
+	if _, err := dm.GetAveragedReadings(context.Background(), []uuid.UUID{sensor1.ID}, now, now, "7m"); err == nil {
+		t.Error("Expected error for invalid interval")
+	}
+
+	// Directions around north average to north, not south
+	direction := setupTestSensor(t, dm, station.ID, models.SensorTypeWindDirection, "outdoor")
+	storeTestReadings(t, dm, direction.ID, now, 60, func(i int) float64 { return float64(350 + 20*(i%2)) })
+	directions, err := dm.GetCircularAveragedReadings(context.Background(), []uuid.UUID{direction.ID}, now.Add(-time.Hour), now.Add(2*time.Hour), "15m")
+	if err != nil {
+		t.Fatalf("Failed to get circular averaged readings: %v", err)
+	}
+	for _, r := range directions[direction.ID] {
+		if math.Min(r.Value, 360-r.Value) > 0.5 {
+			t.Errorf("Expected north, got %f", r.Value)
+		}
+	}
+}
+
+func TestForEachReadingTime(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	storeTestReadings(t, dm, sensor.ID, now, 30, func(i int) float64 { return 20 })
+
+	var times []time.Time
+	err := dm.ForEachReadingTime(context.Background(), []uuid.UUID{sensor.ID}, now, now.Add(time.Hour), func(sensorID uuid.UUID, dateUTC time.Time) {
+		times = append(times, dateUTC)
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream reading times: %v", err)
+	}
+
+	if len(times) != 30 {
+		t.Fatalf("Expected 30 reading times, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if !times[i].After(times[i-1]) {
+			t.Errorf("Expected ascending order at index %d", i)
+		}
+	}
+}
+
+func TestReadingsPartitionedByMonth(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil || dm.ch == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	// Archives drop and maintenance merges whole months by partition ID
+	for _, table := range []string{"sensor_readings", "sensor_readings_daily"} {
+		var key string
+		err := dm.ch.Conn().QueryRow(context.Background(),
+			"SELECT partition_key FROM system.tables WHERE database = currentDatabase() AND name = ?", table).Scan(&key)
+		if err != nil {
+			t.Fatalf("Failed to query partition key of %s: %v", table, err)
+		}
+		if !strings.HasPrefix(key, "toYYYYMM(") {
+			t.Errorf("Expected %s to be partitioned by month, got %q", table, key)
+		}
+	}
+}
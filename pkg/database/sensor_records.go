@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// UpdateSensorRecords raises the stored all-time extremes of the sensors in
+// readings. Existing records are only replaced by more extreme values.
+func (dm *DatabaseManager) UpdateSensorRecords(ctx context.Context, readings []models.SensorReading) error {
+	if err := dm.healthChecker.EnsureConnection(ctx); err != nil {
+		return err
+	}
+	return upsertSensorRecords(ctx, dm.db, readings)
+}
+
+// upsertSensorRecords writes the extremes of readings using exec
+func upsertSensorRecords(ctx context.Context, exec execer, readings []models.SensorReading) error {
+	type extremes struct {
+		min, max models.SensorReading
+	}
+
+	bySensor := make(map[uuid.UUID]*extremes)
+	for _, r := range readings {
+		e, ok := bySensor[r.SensorID]
+		if !ok {
+			bySensor[r.SensorID] = &extremes{min: r, max: r}
+			continue
+		}
+		if r.Value < e.min.Value {
+			e.min = r
+		}
+		if r.Value > e.max.Value {
+			e.max = r
+		}
+	}
+	if len(bySensor) == 0 {
+		return nil
+	}
+
+	var values []string
+	var args []interface{}
+	for sensorID, e := range bySensor {
+		idx := len(args)
+		values = append(values,
+			fmt.Sprintf("($%d, '%s', $%d, $%d)", idx+1, models.RecordTypeMin, idx+2, idx+3),
+			fmt.Sprintf("($%d, '%s', $%d, $%d)", idx+1, models.RecordTypeMax, idx+4, idx+5),
+		)
+		args = append(args, sensorID, e.min.Value, e.min.DateUTC.UTC(), e.max.Value, e.max.DateUTC.UTC())
+	}
+
+	query := `
+		INSERT INTO sensor_records (sensor_id, record_type, value, date_utc)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (sensor_id, record_type) DO UPDATE
+		SET value = EXCLUDED.value, date_utc = EXCLUDED.date_utc, updated_at = CURRENT_TIMESTAMP
+		WHERE (sensor_records.record_type = 'min' AND EXCLUDED.value < sensor_records.value)
+		   OR (sensor_records.record_type = 'max' AND EXCLUDED.value > sensor_records.value)
+	`
+	if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update sensor records: %w", err)
+	}
+	return nil
+}
+
+// GetStationRecords returns the all-time extremes of all sensors of a station
+func (dm *DatabaseManager) GetStationRecords(ctx context.Context, stationID uuid.UUID) ([]models.SensorRecord, error) {
+	const query = `
+		SELECT r.sensor_id, s.sensor_type, s.location, r.record_type, r.value, r.date_utc, r.updated_at
+		FROM sensor_records r
+		JOIN sensors s ON s.id = r.sensor_id
+		WHERE s.station_id = $1
+		ORDER BY s.sensor_type, s.location, r.record_type
+	`
+	rows, err := dm.QueryWithHealthCheck(ctx, query, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sensor records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.SensorRecord{}
+	for rows.Next() {
+		var r models.SensorRecord
+		if err := rows.Scan(&r.SensorID, &r.SensorType, &r.Location, &r.RecordType, &r.Value, &r.DateUTC, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sensor record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// RecomputeSensorRecords rebuilds the all-time extremes of the given sensors
+// from the raw readings. Records of sensors without readings are removed.
+func (dm *DatabaseManager) RecomputeSensorRecords(ctx context.Context, sensorIDs []uuid.UUID) error {
+	if len(sensorIDs) == 0 {
+		return nil
+	}
+
+	const query = `
+		SELECT
+			sensor_id,
+			min(value)              AS min_value,
+			argMin(date_utc, value) AS min_date,
+			max(value)              AS max_value,
+			argMax(date_utc, value) AS max_date
+		FROM sensor_readings
+		WHERE sensor_id IN ?
+		GROUP BY sensor_id
+	`
+	rows, err := dm.ch.Conn().Query(ctx, query, sensorIDs)
+	if err != nil {
+		return fmt.Errorf("failed to query sensor extremes: %w", err)
+	}
+	defer rows.Close()
+
+	var readings []models.SensorReading
+	for rows.Next() {
+		var (
+			sensorID uuid.UUID
+			minValue float64
+			minDate  time.Time
+			maxValue float64
+			maxDate  time.Time
+		)
+		if err := rows.Scan(&sensorID, &minValue, &minDate, &maxValue, &maxDate); err != nil {
+			return fmt.Errorf("failed to scan sensor extremes: %w", err)
+		}
+		readings = append(readings,
+			models.SensorReading{SensorID: sensorID, Value: minValue, DateUTC: minDate},
+			models.SensorReading{SensorID: sensorID, Value: maxValue, DateUTC: maxDate},
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read sensor extremes: %w", err)
+	}
+
+	ids := make([]string, len(sensorIDs))
+	for i, id := range sensorIDs {
+		ids[i] = id.String()
+	}
+
+	// Replace the records atomically so readers never see them missing
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sensor_records WHERE sensor_id::text = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to delete sensor records: %w", err)
+	}
+	if err := upsertSensorRecords(ctx, tx, readings); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sensor records: %w", err)
+	}
+
+	log.Printf("✓ Recomputed records of %d sensors", len(sensorIDs))
+	return nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestUpdateSensorRecords(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	batches := [][]models.SensorReading{
+		{{SensorID: sensor.ID, Value: 10, DateUTC: now}, {SensorID: sensor.ID, Value: 20, DateUTC: now.Add(time.Minute)}},
+		// Neither a new minimum nor a new maximum
+		{{SensorID: sensor.ID, Value: 15, DateUTC: now.Add(2 * time.Minute)}},
+		{{SensorID: sensor.ID, Value: -5, DateUTC: now.Add(3 * time.Minute)}},
+	}
+	for _, batch := range batches {
+		if err := dm.UpdateSensorRecords(ctx, batch); err != nil {
+			t.Fatalf("Failed to update records: %v", err)
+		}
+	}
+
+	records, err := dm.GetStationRecords(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get records: %v", err)
+	}
+
+	expected := map[string]float64{models.RecordTypeMax: 20, models.RecordTypeMin: -5}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+	for _, r := range records {
+		if r.Value != expected[r.RecordType] {
+			t.Errorf("Expected %s record %f, got %f", r.RecordType, expected[r.RecordType], r.Value)
+		}
+	}
+}
+
+func TestRecomputeSensorRecords(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Hour)
+
+	storeTestReadings(t, dm, sensor.ID, now, 10, func(i int) float64 { return float64(i) })
+
+	// A bogus record that is not backed by any reading
+	if err := dm.UpdateSensorRecords(ctx, []models.SensorReading{{SensorID: sensor.ID, Value: 99, DateUTC: now}}); err != nil {
+		t.Fatalf("Failed to update records: %v", err)
+	}
+
+	if err := dm.RecomputeSensorRecords(ctx, []uuid.UUID{sensor.ID}); err != nil {
+		t.Fatalf("Failed to recompute records: %v", err)
+	}
+
+	records, err := dm.GetStationRecords(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get records: %v", err)
+	}
+	for _, r := range records {
+		if r.RecordType == models.RecordTypeMax && r.Value != 9 {
+			t.Errorf("Expected recomputed max 9, got %f", r.Value)
+		}
+		if r.RecordType == models.RecordTypeMin && r.Value != 0 {
+			t.Errorf("Expected recomputed min 0, got %f", r.Value)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ErrSensorTypeConflict is returned when a sensor type is changed to a type
+// that already exists at the same station and location without merging
+var ErrSensorTypeConflict = errors.New("sensor of the new type already exists")
+
+// typeChangeSensor is a sensor selected by a SensorTypeChange
+type typeChangeSensor struct {
+	id       uuid.UUID
+	remoteID string
+	// target is the existing sensor of the new type the sensor is merged into
+	target         uuid.UUID
+	targetRemoteID string
+}
+
+// mergeStats describes the readings of a source sensor that are moved to
+// the target
+type mergeStats struct {
+	count              uint64
+	first, last        time.Time
+	minValue, maxValue float64
+	minDate, maxDate   time.Time
+}
+
+// ChangeSensorType renames the type of the selected sensors. Sensors with an
+// existing sensor of the new type at the same station and location are
+// merged into it when change.Merge is set: their readings, rollups and
+// records are moved and the sensor is deleted. Sensors and records are
+// updated in one transaction; readings of merged sensors are removed from
+// ClickHouse after it was committed, so an interrupted merge can be repeated.
+func (dm *DatabaseManager) ChangeSensorType(ctx context.Context, change models.SensorTypeChange) (*models.SensorTypeChangeResult, error) {
+	if err := change.Validate(); err != nil {
+		return nil, err
+	}
+	if err := dm.healthChecker.EnsureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sensors, err := selectTypeChangeSensors(ctx, tx, change)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SensorTypeChangeResult{Renamed: []uuid.UUID{}, Merged: []models.SensorMerge{}, DryRun: change.DryRun}
+	var renamed []string
+	stats := make(map[uuid.UUID]mergeStats)
+	for _, s := range sensors {
+		if s.target == uuid.Nil {
+			result.Renamed = append(result.Renamed, s.id)
+			renamed = append(renamed, s.id.String())
+			continue
+		}
+		if !change.Merge {
+			return nil, fmt.Errorf("sensor %s: %w", s.id, ErrSensorTypeConflict)
+		}
+		st, err := dm.mergeStats(ctx, s.id, s.target)
+		if err != nil {
+			return nil, err
+		}
+		stats[s.id] = st
+		result.Merged = append(result.Merged, models.SensorMerge{SourceID: s.id, TargetID: s.target, Readings: st.count})
+	}
+	if change.DryRun {
+		return result, nil
+	}
+
+	if len(renamed) > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE sensors SET sensor_type = $1 WHERE id::text = ANY($2)`, change.To, pq.Array(renamed)); err != nil {
+			return nil, fmt.Errorf("failed to rename sensor type: %w", err)
+		}
+	}
+
+	for _, s := range sensors {
+		if s.target == uuid.Nil {
+			continue
+		}
+		if err := dm.mergeSensor(ctx, tx, s, stats[s.id]); err != nil {
+			return nil, fmt.Errorf("failed to merge sensor %s: %w", s.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit sensor type change: %w", err)
+	}
+
+	// The merged sensors are gone, their readings can't be queried anymore
+	for _, merge := range result.Merged {
+		for _, table := range []string{"sensor_readings", "sensor_readings_daily"} {
+			query := "ALTER TABLE " + table + " DELETE WHERE sensor_id = ? SETTINGS mutations_sync = 1"
+			if err := dm.ch.Conn().Exec(ctx, query, merge.SourceID); err != nil {
+				return result, fmt.Errorf("failed to delete readings of merged sensor %s from %s: %w", merge.SourceID, table, err)
+			}
+		}
+	}
+	return result, nil
+}
+
+// selectTypeChangeSensors locks the sensors selected by a change and finds
+// the sensors of the new type they collide with
+func selectTypeChangeSensors(ctx context.Context, tx *sql.Tx, change models.SensorTypeChange) ([]typeChangeSensor, error) {
+	conditions := []string{"sensor_type = $1"}
+	args := []interface{}{change.From}
+	if len(change.StationIDs) > 0 {
+		args = append(args, pq.Array(uuidStrings(change.StationIDs)))
+		conditions = append(conditions, fmt.Sprintf("station_id::text = ANY($%d)", len(args)))
+	}
+	if len(change.SensorIDs) > 0 {
+		args = append(args, pq.Array(uuidStrings(change.SensorIDs)))
+		conditions = append(conditions, fmt.Sprintf("id::text = ANY($%d)", len(args)))
+	}
+
+	query := `
+		SELECT id, station_id, location, COALESCE(remote_id, '')
+		FROM sensors
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id
+		FOR UPDATE
+	`
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sensors: %w", err)
+	}
+
+	type selected struct {
+		typeChangeSensor
+		stationID uuid.UUID
+		location  string
+	}
+	var matches []selected
+	for rows.Next() {
+		var s selected
+		if err := rows.Scan(&s.id, &s.stationID, &s.location, &s.remoteID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan sensor: %w", err)
+		}
+		matches = append(matches, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query sensors: %w", err)
+	}
+
+	const targetQuery = `
+		SELECT id, COALESCE(remote_id, '')
+		FROM sensors
+		WHERE station_id = $1 AND location = $2 AND sensor_type = $3
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE
+	`
+	sensors := make([]typeChangeSensor, 0, len(matches))
+	for _, m := range matches {
+		err := tx.QueryRowContext(ctx, targetQuery, m.stationID, m.location, change.To).Scan(&m.target, &m.targetRemoteID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to query sensors of the new type: %w", err)
+		}
+		sensors = append(sensors, m.typeChangeSensor)
+	}
+	return sensors, nil
+}
+
+// mergeStats returns the readings of source at timestamps target has no
+// reading for
+func (dm *DatabaseManager) mergeStats(ctx context.Context, source, target uuid.UUID) (mergeStats, error) {
+	const query = `
+		SELECT count(), min(date_utc), max(date_utc),
+		       min(value), argMin(date_utc, value), max(value), argMax(date_utc, value)
+		FROM sensor_readings
+		WHERE sensor_id = ? AND date_utc NOT IN (SELECT date_utc FROM sensor_readings WHERE sensor_id = ?)
+	`
+	var st mergeStats
+	err := dm.ch.Conn().QueryRow(ctx, query, source, target).Scan(
+		&st.count, &st.first, &st.last, &st.minValue, &st.minDate, &st.maxValue, &st.maxDate,
+	)
+	if err != nil {
+		return st, fmt.Errorf("failed to count readings to merge: %w", err)
+	}
+	return st, nil
+}
+
+// mergeSensor copies the readings of a sensor to its target, rebuilds the
+// affected rollups, raises the records of the target and deletes the sensor
+func (dm *DatabaseManager) mergeSensor(ctx context.Context, tx *sql.Tx, s typeChangeSensor, st mergeStats) error {
+	if st.count > 0 {
+		// Skipping timestamps the target has makes repeated merges idempotent
+		const copyQuery = `
+			INSERT INTO sensor_readings (sensor_id, value, unit, raw_value, raw_unit, date_utc, received_at, estimated)
+			SELECT ?, value, unit, raw_value, raw_unit, date_utc, received_at, estimated
+			FROM sensor_readings
+			WHERE sensor_id = ? AND date_utc NOT IN (SELECT date_utc FROM sensor_readings WHERE sensor_id = ?)
+		`
+		if err := dm.ch.Conn().Exec(ctx, copyQuery, s.target, s.id, s.target); err != nil {
+			return fmt.Errorf("failed to copy readings: %w", err)
+		}
+		if err := dm.RecomputeDailyRollups(ctx, []uuid.UUID{s.target}, st.first, st.last); err != nil {
+			return err
+		}
+		dm.invalidateBuckets([]models.SensorReading{{SensorID: s.target, DateUTC: st.first}})
+
+		extremes := []models.SensorReading{
+			{SensorID: s.target, Value: st.minValue, DateUTC: st.minDate},
+			{SensorID: s.target, Value: st.maxValue, DateUTC: st.maxDate},
+		}
+		if err := upsertSensorRecords(ctx, tx, extremes); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sensors WHERE id = $1`, s.id); err != nil {
+		return fmt.Errorf("failed to delete sensor: %w", err)
+	}
+
+	// Keep pushes of the merged sensor going to the target
+	if s.remoteID != "" && s.targetRemoteID == "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE sensors SET remote_id = $1 WHERE id = $2`, s.remoteID, s.target); err != nil {
+			return fmt.Errorf("failed to move remote ID: %w", err)
+		}
+	}
+	return nil
+}
+
+// uuidStrings converts IDs for use with pq.Array
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestChangeSensorType_Rename(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	first := setupTestStation(t, dm)
+	second := setupTestStation(t, dm)
+	a := setupTestSensor(t, dm, first.ID, "Temprature", "outdoor")
+	b := setupTestSensor(t, dm, second.ID, "Temprature", "outdoor")
+
+	change := models.SensorTypeChange{From: "Temprature", To: models.SensorTypeTemperature, StationIDs: []uuid.UUID{first.ID}, DryRun: true}
+	result, err := dm.ChangeSensorType(ctx, change)
+	if err != nil {
+		t.Fatalf("ChangeSensorType() error = %v", err)
+	}
+	if len(result.Renamed) != 1 || result.Renamed[0] != a.ID {
+		t.Fatalf("Renamed = %v, want [%s]", result.Renamed, a.ID)
+	}
+	if sensor, _ := dm.GetSensor(a.ID, false); sensor.Sensor.SensorType != "Temprature" {
+		t.Error("dry run changed the sensor")
+	}
+
+	change.DryRun = false
+	if _, err := dm.ChangeSensorType(ctx, change); err != nil {
+		t.Fatalf("ChangeSensorType() error = %v", err)
+	}
+	if sensor, _ := dm.GetSensor(a.ID, false); sensor.Sensor.SensorType != models.SensorTypeTemperature {
+		t.Errorf("sensor type = %s", sensor.Sensor.SensorType)
+	}
+	if sensor, _ := dm.GetSensor(b.ID, false); sensor.Sensor.SensorType != "Temprature" {
+		t.Error("sensor of another station was renamed")
+	}
+}
+
+func TestChangeSensorType_Merge(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+	target := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	source := setupTestSensor(t, dm, station.ID, "Temprature", "outdoor")
+
+	start := time.Now().UTC().Truncate(time.Hour).Add(-48 * time.Hour)
+	readings := []models.SensorReading{
+		{SensorID: target.ID, Value: 20, DateUTC: start},
+		{SensorID: source.ID, Value: 99, DateUTC: start},
+		{SensorID: source.ID, Value: -5, DateUTC: start.Add(time.Hour)},
+		{SensorID: source.ID, Value: 30, DateUTC: start.Add(25 * time.Hour)},
+	}
+	if err := dm.StoreSensorReadingsBatch(ctx, readings); err != nil {
+		t.Fatalf("StoreSensorReadingsBatch() error = %v", err)
+	}
+	if err := dm.UpdateSensorRecords(ctx, readings); err != nil {
+		t.Fatalf("UpdateSensorRecords() error = %v", err)
+	}
+
+	change := models.SensorTypeChange{From: "Temprature", To: models.SensorTypeTemperature, StationIDs: []uuid.UUID{station.ID}}
+	if _, err := dm.ChangeSensorType(ctx, change); !errors.Is(err, ErrSensorTypeConflict) {
+		t.Fatalf("expected ErrSensorTypeConflict, got %v", err)
+	}
+
+	change.Merge = true
+	result, err := dm.ChangeSensorType(ctx, change)
+	if err != nil {
+		t.Fatalf("ChangeSensorType() error = %v", err)
+	}
+	if len(result.Merged) != 1 || result.Merged[0].TargetID != target.ID || result.Merged[0].Readings != 2 {
+		t.Fatalf("Merged = %+v", result.Merged)
+	}
+
+	if _, err := dm.GetSensor(source.ID, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected merged sensor to be deleted, got %v", err)
+	}
+
+	// The reading of the source at an existing timestamp is dropped
+	merged, err := dm.GetSensorReadings(target.ID, start.Add(-time.Hour), start.Add(48*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetSensorReadings() error = %v", err)
+	}
+	if len(merged) != 3 {
+		t.Errorf("target has %d readings, want 3", len(merged))
+	}
+	if orphaned, _ := dm.FindOrphanedReadings(ctx); len(orphaned) != 0 {
+		t.Errorf("readings of merged sensor left: %v", orphaned)
+	}
+	if drifts, _ := dm.FindRollupDrift(ctx, time.Time{}); len(drifts) != 0 {
+		t.Errorf("rollups drifted: %+v", drifts)
+	}
+
+	records, err := dm.GetStationRecords(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("GetStationRecords() error = %v", err)
+	}
+	for _, r := range records {
+		if r.RecordType == models.RecordTypeMin && r.Value != -5 {
+			t.Errorf("min record = %v, want -5", r.Value)
+		}
+		if r.RecordType == models.RecordTypeMax && r.Value != 30 {
+			t.Errorf("max record = %v, want 30", r.Value)
+		}
+	}
+}
@@ -61,8 +61,8 @@ func (dm *DatabaseManager) latestReadingsForSensors(ctx context.Context, sensorI
 // CreateSensor creates a new sensor for a station
 func (dm *DatabaseManager) CreateSensor(sensor *models.Sensor) error {
 	query := `
-        INSERT INTO sensors (station_id, sensor_type, location, name, model, battery_level, signal_strength, enabled, remote_id)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO sensors (station_id, sensor_type, location, name, model, battery_level, signal_strength, enabled, remote_id, kind)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
         RETURNING id, created_at, updated_at
     `
 
@@ -70,6 +70,9 @@ func (dm *DatabaseManager) CreateSensor(sensor *models.Sensor) error {
 	if sensor.RemoteID != "" {
 		remoteID = sql.NullString{String: sensor.RemoteID, Valid: true}
 	}
+	if sensor.Kind == "" {
+		sensor.Kind = models.SensorKindAutomatic
+	}
 
 	err := dm.QueryRowWithHealthCheck(context.Background(), query,
 		sensor.StationID,
@@ -81,6 +84,7 @@ func (dm *DatabaseManager) CreateSensor(sensor *models.Sensor) error {
 		sensor.SignalStrength,
 		sensor.Enabled,
 		remoteID,
+		sensor.Kind,
 	).Scan(&sensor.ID, &sensor.CreatedAt, &sensor.UpdatedAt)
 
 	return err
@@ -91,21 +95,28 @@ func (dm *DatabaseManager) CreateSensor(sensor *models.Sensor) error {
 func (dm *DatabaseManager) GetSensor(sensorID uuid.UUID, includeLatest bool) (*models.SensorWithLatestReading, error) {
 	const query = `
 		SELECT id, station_id, sensor_type, location, name, model,
-		       battery_level, signal_strength, enabled, created_at, updated_at
+		       battery_level, signal_strength, enabled, kind, display, created_at, updated_at
 		FROM sensors
 		WHERE id = $1
 	`
 
 	var swr models.SensorWithLatestReading
+	var display []byte
 	err := dm.QueryRowWithHealthCheck(context.Background(), query, sensorID).Scan(
 		&swr.Sensor.ID, &swr.Sensor.StationID, &swr.Sensor.SensorType,
 		&swr.Sensor.Location, &swr.Sensor.Name, &swr.Sensor.Model,
 		&swr.Sensor.BatteryLevel, &swr.Sensor.SignalStrength, &swr.Sensor.Enabled,
-		&swr.Sensor.CreatedAt, &swr.Sensor.UpdatedAt,
+		&swr.Sensor.Kind, &display, &swr.Sensor.CreatedAt, &swr.Sensor.UpdatedAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("sensor %w", ErrNotFound)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if swr.Sensor.Display, err = parseSensorDisplay(display); err != nil {
+		return nil, err
+	}
 
 	if includeLatest {
 		latest, err := dm.latestReadingsForSensors(context.Background(), []uuid.UUID{sensorID})
@@ -150,7 +161,7 @@ func (dm *DatabaseManager) GetSensors(params models.SensorQueryParams) ([]models
 
 	query := `
 		SELECT id, station_id, sensor_type, location, name, model,
-		       battery_level, signal_strength, enabled, created_at, updated_at
+		       battery_level, signal_strength, enabled, kind, display, created_at, updated_at
 		FROM sensors`
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
@@ -167,12 +178,16 @@ func (dm *DatabaseManager) GetSensors(params models.SensorQueryParams) ([]models
 	var sensorIDs []uuid.UUID
 	for rows.Next() {
 		var swr models.SensorWithLatestReading
+		var display []byte
 		err := rows.Scan(
 			&swr.Sensor.ID, &swr.Sensor.StationID, &swr.Sensor.SensorType,
 			&swr.Sensor.Location, &swr.Sensor.Name, &swr.Sensor.Model,
 			&swr.Sensor.BatteryLevel, &swr.Sensor.SignalStrength, &swr.Sensor.Enabled,
-			&swr.Sensor.CreatedAt, &swr.Sensor.UpdatedAt,
+			&swr.Sensor.Kind, &display, &swr.Sensor.CreatedAt, &swr.Sensor.UpdatedAt,
 		)
+		if err == nil {
+			swr.Sensor.Display, err = parseSensorDisplay(display)
+		}
 		if err != nil {
 			log.Printf("Failed to scan sensor: %v", err)
 			continue
@@ -199,6 +214,31 @@ func (dm *DatabaseManager) GetSensors(params models.SensorQueryParams) ([]models
 	return sensors, nil
 }
 
+// GetSensorIDsByRemoteID returns the IDs of the sensors of a station that
+// have a remote ID, keyed by it
+func (dm *DatabaseManager) GetSensorIDsByRemoteID(ctx context.Context, stationID uuid.UUID) (map[string]uuid.UUID, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT remote_id, id FROM sensors
+        WHERE station_id = $1 AND remote_id IS NOT NULL AND remote_id <> ''`,
+		stationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sensors: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var remoteID string
+		var id uuid.UUID
+		if err := rows.Scan(&remoteID, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan sensor: %w", err)
+		}
+		ids[remoteID] = id
+	}
+	return ids, rows.Err()
+}
+
 func (dm *DatabaseManager) EnsureSensorsByRemoteId(stationID uuid.UUID, sensors map[string]models.Sensor) (map[string]models.Sensor, error) {
 	for remoteID, sensor := range sensors {
 		var existingSensorID string
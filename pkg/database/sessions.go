@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// generateToken returns a random hex token for keys and sessions
+func generateToken() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// hashToken returns the stored hash of a token. Tokens are random, so a
+// plain SHA-256 is sufficient and allows lookups by hash.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// sessionColumns are the columns scanned by scanSession
+const sessionColumns = `id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at`
+
+// scanSession scans a row selected with sessionColumns
+func scanSession(row rowScanner) (*models.Session, error) {
+	var s models.Session
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.UserAgent,
+		&s.IPAddress,
+		&s.CreatedAt,
+		&s.LastUsedAt,
+		&s.ExpiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return &s, nil
+}
+
+// CreateSession starts a session for a user and returns it with its refresh
+// token, which is only available here
+func (dm *DatabaseManager) CreateSession(ctx context.Context, userID uuid.UUID, userAgent, ipAddress string, ttl time.Duration) (*models.Session, string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	query := `
+        INSERT INTO user_sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING ` + sessionColumns
+
+	session, err := scanSession(dm.QueryRowWithHealthCheck(ctx, query,
+		userID,
+		hashToken(token),
+		userAgent,
+		ipAddress,
+		time.Now().Add(ttl),
+	))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, token, nil
+}
+
+// TouchSession records the use of an active session. Revoked and expired
+// sessions are reported as not found.
+func (dm *DatabaseManager) TouchSession(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	query := `
+        UPDATE user_sessions SET last_used_at = NOW()
+        WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        RETURNING ` + sessionColumns
+
+	session, err := scanSession(dm.QueryRowWithHealthCheck(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	return session, nil
+}
+
+// RefreshSession replaces the refresh token of an active session and returns
+// the session with its new token. The old token can't be used again.
+func (dm *DatabaseManager) RefreshSession(ctx context.Context, refreshToken string) (*models.Session, string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	query := `
+        UPDATE user_sessions SET refresh_token_hash = $1, last_used_at = NOW()
+        WHERE refresh_token_hash = $2 AND revoked_at IS NULL AND expires_at > NOW()
+        RETURNING ` + sessionColumns
+
+	session, err := scanSession(dm.QueryRowWithHealthCheck(ctx, query, hashToken(token), hashToken(refreshToken)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("session %w", ErrNotFound)
+		}
+		return nil, "", fmt.Errorf("failed to refresh session: %w", err)
+	}
+	return session, token, nil
+}
+
+// GetSessions retrieves the active sessions of a user, most recently used
+// first
+func (dm *DatabaseManager) GetSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	query := `
+        SELECT ` + sessionColumns + ` FROM user_sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+        ORDER BY last_used_at DESC`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession revokes an active session of a user
+func (dm *DatabaseManager) RevokeSession(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+        UPDATE user_sessions SET revoked_at = NOW()
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("session %w", ErrNotFound)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes all sessions of a user except the given one
+// (uuid.Nil revokes all) and returns the number of revoked sessions
+func (dm *DatabaseManager) RevokeUserSessions(ctx context.Context, userID, except uuid.UUID) (int64, error) {
+	query := `
+        UPDATE user_sessions SET revoked_at = NOW()
+        WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+    `
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, userID, except)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	user, err := dm.CreateUser(ctx, "session_"+generateRandomString(8), "password")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	laptop, refreshToken, err := dm.CreateSession(ctx, user.ID, "Firefox", "192.0.2.1", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	phone, _, err := dm.CreateSession(ctx, user.ID, "Safari", "192.0.2.2", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if _, err := dm.TouchSession(ctx, laptop.ID); err != nil {
+		t.Errorf("Failed to touch session: %v", err)
+	}
+
+	// Refresh tokens are rotated
+	refreshed, newToken, err := dm.RefreshSession(ctx, refreshToken)
+	if err != nil || refreshed.ID != laptop.ID || newToken == refreshToken {
+		t.Fatalf("Failed to refresh session: %+v, %v", refreshed, err)
+	}
+	if _, _, err := dm.RefreshSession(ctx, refreshToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected old refresh token to be rejected, got %v", err)
+	}
+
+	sessions, err := dm.GetSessions(ctx, user.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d, %v", len(sessions), err)
+	}
+
+	// Sessions of other users can't be revoked
+	if err := dm.RevokeSession(ctx, uuid.New(), phone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := dm.RevokeSession(ctx, user.ID, phone.ID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	if _, err := dm.TouchSession(ctx, phone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected revoked session to be rejected, got %v", err)
+	}
+
+	revoked, err := dm.RevokeUserSessions(ctx, user.ID, uuid.Nil)
+	if err != nil || revoked != 1 {
+		t.Errorf("Expected 1 revoked session, got %d, %v", revoked, err)
+	}
+	if _, _, err := dm.RefreshSession(ctx, newToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected refresh of revoked session to fail, got %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	username := "reset_" + generateRandomString(8)
+	user, err := dm.CreateUser(ctx, username, "old-password")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	session, _, err := dm.CreateSession(ctx, user.ID, "", "", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	token, _, err := dm.CreatePasswordResetToken(ctx, user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create reset token: %v", err)
+	}
+	if _, err := dm.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("Failed to reset password: %v", err)
+	}
+
+	if _, err := dm.ValidateUser(ctx, username, "new-password"); err != nil {
+		t.Errorf("Expected new password to be valid: %v", err)
+	}
+	if _, err := dm.ValidateUser(ctx, username, "old-password"); err == nil {
+		t.Error("Expected old password to be rejected")
+	}
+	if _, err := dm.TouchSession(ctx, session.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected sessions to be revoked, got %v", err)
+	}
+
+	// Tokens are single-use
+	if _, err := dm.ResetPassword(ctx, token, "another-password"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected used token to be rejected, got %v", err)
+	}
+
+	expired, _, err := dm.CreatePasswordResetToken(ctx, user.ID, -time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create reset token: %v", err)
+	}
+	if _, err := dm.ResetPassword(ctx, expired, "another-password"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// shareTokenColumns are the columns scanned by scanShareToken
+const shareTokenColumns = `id, station_id, name, prefix, sensor_ids, created_at, revoked_at`
+
+// scanShareToken scans a row selected with shareTokenColumns
+func scanShareToken(row rowScanner) (*models.ShareToken, error) {
+	var t models.ShareToken
+	var sensorIDs []string
+	var revokedAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.StationID, &t.Name, &t.Prefix, pq.Array(&sensorIDs), &t.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	t.SensorIDs = make([]uuid.UUID, 0, len(sensorIDs))
+	for _, id := range sensorIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		t.SensorIDs = append(t.SensorIDs, parsed)
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+// CreateShareToken generates a new share token of a station limited to
+// sensorIDs (all sensors if empty). The returned token string is only
+// available here; the database keeps its hash.
+func (dm *DatabaseManager) CreateShareToken(ctx context.Context, stationID uuid.UUID, name string, sensorIDs []uuid.UUID) (*models.ShareToken, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("name must not be empty")
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := models.ShareTokenPrefix + secret
+
+	query := insertForStation("share_tokens", "name, prefix, token_hash, sensor_ids", "$2, $3, $4, $5::uuid[]") + `
+        RETURNING ` + shareTokenColumns
+
+	created, err := scanShareToken(dm.QueryRowWithHealthCheck(ctx, query,
+		stationID,
+		name,
+		token[:len(models.ShareTokenPrefix)+8],
+		hashToken(token),
+		pq.Array(uuidStrings(sensorIDs)),
+	))
+	if err == sql.ErrNoRows {
+		return nil, "", fmt.Errorf("station %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create share token: %w", err)
+	}
+	return created, token, nil
+}
+
+// GetShareTokens retrieves all share tokens of a station including revoked
+// ones, newest first
+func (dm *DatabaseManager) GetShareTokens(ctx context.Context, stationID uuid.UUID) ([]models.ShareToken, error) {
+	query := `SELECT ` + shareTokenColumns + ` FROM share_tokens WHERE station_id = $1 ORDER BY created_at DESC`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query share tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.ShareToken{}
+	for rows.Next() {
+		token, err := scanShareToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// AuthenticateShareToken returns the active share token matching the token
+// string. Unknown and revoked tokens are reported as not found.
+func (dm *DatabaseManager) AuthenticateShareToken(ctx context.Context, token string) (*models.ShareToken, error) {
+	query := `SELECT ` + shareTokenColumns + ` FROM share_tokens WHERE token_hash = $1 AND revoked_at IS NULL`
+
+	shareToken, err := scanShareToken(dm.QueryRowWithHealthCheck(ctx, query, hashToken(token)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("share token %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query share token: %w", err)
+	}
+	return shareToken, nil
+}
+
+// SetShareTokenSensors changes the sensors shared by a token of a station,
+// all sensors if sensorIDs is empty
+func (dm *DatabaseManager) SetShareTokenSensors(ctx context.Context, stationID, id uuid.UUID, sensorIDs []uuid.UUID) (*models.ShareToken, error) {
+	query := `
+        UPDATE share_tokens SET sensor_ids = $3::uuid[]
+        WHERE id = $1 AND station_id = $2
+        RETURNING ` + shareTokenColumns
+
+	shareToken, err := scanShareToken(dm.QueryRowWithHealthCheck(ctx, query, id, stationID, pq.Array(uuidStrings(sensorIDs))))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("share token %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update share token: %w", err)
+	}
+	return shareToken, nil
+}
+
+// RevokeShareToken revokes a share token of a station; revoking a revoked
+// token keeps the first revocation time
+func (dm *DatabaseManager) RevokeShareToken(ctx context.Context, stationID, id uuid.UUID) (*models.ShareToken, error) {
+	query := `
+        UPDATE share_tokens SET revoked_at = COALESCE(revoked_at, NOW())
+        WHERE id = $1 AND station_id = $2
+        RETURNING ` + shareTokenColumns
+
+	shareToken, err := scanShareToken(dm.QueryRowWithHealthCheck(ctx, query, id, stationID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("share token %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to revoke share token: %w", err)
+	}
+	return shareToken, nil
+}
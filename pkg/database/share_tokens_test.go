@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestShareTokenLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	created, token, err := dm.CreateShareToken(ctx, station.ID, "club website", nil)
+	if err != nil {
+		t.Fatalf("Failed to create share token: %v", err)
+	}
+	if !strings.HasPrefix(token, created.Prefix) || !strings.HasPrefix(token, models.ShareTokenPrefix) {
+		t.Errorf("Expected token %s to start with prefix %s", token, created.Prefix)
+	}
+
+	authenticated, err := dm.AuthenticateShareToken(ctx, token)
+	if err != nil {
+		t.Fatalf("Failed to authenticate share token: %v", err)
+	}
+	if authenticated.ID != created.ID || authenticated.StationID != station.ID {
+		t.Errorf("Unexpected authenticated token %+v", authenticated)
+	}
+	if _, err := dm.AuthenticateShareToken(ctx, token+"x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown token, got %v", err)
+	}
+
+	// Limiting the token to a sensor
+	sensorID := uuid.New()
+	limited, err := dm.SetShareTokenSensors(ctx, station.ID, created.ID, []uuid.UUID{sensorID})
+	if err != nil || len(limited.SensorIDs) != 1 || limited.SensorIDs[0] != sensorID {
+		t.Fatalf("Failed to set share token sensors: %+v, %v", limited, err)
+	}
+	if authenticated, err = dm.AuthenticateShareToken(ctx, token); err != nil || authenticated.Allows(uuid.New()) {
+		t.Errorf("Expected token limited to %s, got %+v, %v", sensorID, authenticated, err)
+	}
+
+	// Tokens are revoked through their station
+	if _, err := dm.RevokeShareToken(ctx, uuid.New(), created.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for other station, got %v", err)
+	}
+	revoked, err := dm.RevokeShareToken(ctx, station.ID, created.ID)
+	if err != nil || revoked.RevokedAt == nil {
+		t.Fatalf("Failed to revoke share token: %+v, %v", revoked, err)
+	}
+	if _, err := dm.AuthenticateShareToken(ctx, token); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+
+	tokens, err := dm.GetShareTokens(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("Failed to list share tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].ID != created.ID {
+		t.Errorf("Expected revoked token in listing, got %+v", tokens)
+	}
+}
+
+func TestCreateShareToken_UnknownStation(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	if _, _, err := dm.CreateShareToken(context.Background(), uuid.New(), "widget", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	station := setupTestStation(t, dm)
+	if _, _, err := dm.CreateShareToken(context.Background(), station.ID, "", nil); err == nil {
+		t.Error("Expected error for empty name")
+	}
+}
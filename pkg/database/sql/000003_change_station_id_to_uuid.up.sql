@@ -1,5 +1,6 @@
--- Enable UUID extension if not already enabled
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+-- Enable UUID extension if not already enabled. Extensions are installed
+-- once per database, public keeps it visible to instances in other schemas.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp" SCHEMA public;
 
 -- Create a temporary column for the new UUID
 ALTER TABLE stations ADD COLUMN uuid_id UUID DEFAULT uuid_generate_v4();
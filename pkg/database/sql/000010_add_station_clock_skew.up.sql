@@ -0,0 +1,4 @@
+-- Track the measured offset between station clock and server clock
+ALTER TABLE stations
+    ADD COLUMN clock_skew_seconds DOUBLE PRECISION,
+    ADD COLUMN clock_skew_updated_at TIMESTAMPTZ;
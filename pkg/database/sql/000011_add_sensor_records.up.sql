@@ -0,0 +1,9 @@
+-- All-time extremes per sensor (record_type 'min' or 'max')
+CREATE TABLE IF NOT EXISTS sensor_records (
+    sensor_id UUID NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    record_type VARCHAR(16) NOT NULL,
+    value DOUBLE PRECISION NOT NULL,
+    date_utc TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (sensor_id, record_type)
+);
@@ -0,0 +1,7 @@
+-- Track the outcome of the last pull of pulled stations
+ALTER TABLE stations
+    ADD COLUMN pull_status TEXT,
+    ADD COLUMN pull_error TEXT,
+    ADD COLUMN pull_authorization_url TEXT,
+    ADD COLUMN last_pull_at TIMESTAMPTZ,
+    ADD COLUMN last_pull_success_at TIMESTAMPTZ;
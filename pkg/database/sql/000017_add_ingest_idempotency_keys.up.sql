@@ -0,0 +1,14 @@
+-- Idempotency keys of pushed payloads; retried requests get the stored
+-- response instead of storing the readings again
+CREATE TABLE IF NOT EXISTS ingest_idempotency_keys (
+    source VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (source, idempotency_key)
+);
+
+CREATE INDEX idx_ingest_idempotency_keys_expires_at ON ingest_idempotency_keys(expires_at);
@@ -0,0 +1,13 @@
+-- Sensor types defined per station for inputs without a built-in type,
+-- e.g. ADC inputs of DIY gateways
+CREATE TABLE IF NOT EXISTS custom_sensor_types (
+    station_id UUID NOT NULL REFERENCES stations(id) ON DELETE CASCADE,
+    name VARCHAR(20) NOT NULL,
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL DEFAULT 'Custom',
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (station_id, name)
+);
@@ -0,0 +1,31 @@
+-- Recurring maintenance of a station, e.g. cleaning the rain gauge
+CREATE TABLE IF NOT EXISTS maintenance_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    station_id UUID NOT NULL REFERENCES stations(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    interval_days INTEGER NOT NULL,
+    last_done_at TIMESTAMP WITH TIME ZONE,
+    next_due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_maintenance_tasks_station_id ON maintenance_tasks(station_id);
+CREATE INDEX idx_maintenance_tasks_next_due_at ON maintenance_tasks(next_due_at);
+
+-- Notes on the data timeline of a station; completed maintenance is logged
+-- as annotations of kind 'maintenance' linked to its task
+CREATE TABLE IF NOT EXISTS annotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    station_id UUID NOT NULL REFERENCES stations(id) ON DELETE CASCADE,
+    sensor_id UUID REFERENCES sensors(id) ON DELETE CASCADE,
+    task_id UUID REFERENCES maintenance_tasks(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL,
+    text TEXT NOT NULL,
+    date_utc TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_annotations_station_date ON annotations(station_id, date_utc);
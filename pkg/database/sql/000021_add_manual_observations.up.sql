@@ -0,0 +1,16 @@
+-- Sensors are measured by the station or observed by a person
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'automatic';
+
+-- Manual observations with their observer; the values are also stored as
+-- readings of their sensor so reports and charts include them
+CREATE TABLE IF NOT EXISTS observations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sensor_id UUID NOT NULL REFERENCES sensors(id) ON DELETE CASCADE,
+    value DOUBLE PRECISION NOT NULL,
+    observed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    observer VARCHAR(100) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_observations_sensor_observed_at ON observations(sensor_id, observed_at);
@@ -0,0 +1,20 @@
+-- Threshold alerts per station with their current state
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    station_id UUID NOT NULL REFERENCES stations(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    sensor_type VARCHAR(20) NOT NULL,
+    location VARCHAR(100) NOT NULL DEFAULT '',
+    operator VARCHAR(10) NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    hysteresis DOUBLE PRECISION NOT NULL DEFAULT 0,
+    channels TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    active_since TIMESTAMP WITH TIME ZONE,
+    last_value DOUBLE PRECISION,
+    evaluated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (station_id, name)
+);
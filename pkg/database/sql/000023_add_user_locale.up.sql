@@ -0,0 +1,2 @@
+-- Preferred language of a user, empty to negotiate it per request
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';
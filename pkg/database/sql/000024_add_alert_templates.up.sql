@@ -0,0 +1,4 @@
+-- Language and custom notification texts of alert rules, empty for the defaults
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS locale VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS subject_template TEXT NOT NULL DEFAULT '';
+ALTER TABLE alert_rules ADD COLUMN IF NOT EXISTS body_template TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Sensors shared by a token, empty to share all sensors of the station
+ALTER TABLE share_tokens ADD COLUMN IF NOT EXISTS sensor_ids UUID[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,13 @@
+-- Unknown stations that pushed data while the registration policy requires
+-- approval. Rejected stations are kept so their pushes stay refused.
+CREATE TABLE IF NOT EXISTS pending_stations (
+    pass_key VARCHAR(255) PRIMARY KEY,
+    station_type VARCHAR(50) NOT NULL,
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    service_name VARCHAR(50) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    source_ip VARCHAR(45) NOT NULL DEFAULT '',
+    push_count INTEGER NOT NULL DEFAULT 1,
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
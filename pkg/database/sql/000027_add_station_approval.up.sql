@@ -0,0 +1,5 @@
+-- Parsed values of the last push of a pending station, shown for approval
+ALTER TABLE pending_stations ADD COLUMN IF NOT EXISTS sample JSONB;
+
+-- User responsible for a station, assigned when approving it
+ALTER TABLE stations ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(id) ON DELETE SET NULL;
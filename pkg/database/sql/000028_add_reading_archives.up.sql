@@ -0,0 +1,10 @@
+-- Months of readings moved to object storage
+CREATE TABLE IF NOT EXISTS reading_archives (
+    month DATE PRIMARY KEY,
+    object_key TEXT NOT NULL,
+    readings BIGINT NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    restored_at TIMESTAMP WITH TIME ZONE
+);
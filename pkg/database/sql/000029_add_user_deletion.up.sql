@@ -0,0 +1,17 @@
+-- Users are deleted with the stations they own once the grace period of a
+-- deletion request has passed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+-- Audit trail of data exports and deletions. It is kept after the user is
+-- deleted, so it references users by ID and name only.
+CREATE TABLE IF NOT EXISTS user_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_audit_log_user_id ON user_audit_log(user_id, created_at);
@@ -0,0 +1,15 @@
+-- Push requests as received, gzip compressed, so readings can be parsed
+-- again after a pusher fix. Rows older than the retention are deleted.
+CREATE TABLE IF NOT EXISTS raw_payloads (
+    id BIGSERIAL PRIMARY KEY,
+    station_id UUID NOT NULL REFERENCES stations(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    query TEXT NOT NULL DEFAULT '',
+    body BYTEA,
+    source_ip VARCHAR(45) NOT NULL DEFAULT '',
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_raw_payloads_station_received ON raw_payloads(station_id, received_at);
+CREATE INDEX idx_raw_payloads_received ON raw_payloads(received_at);
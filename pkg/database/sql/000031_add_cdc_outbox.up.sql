@@ -0,0 +1,54 @@
+-- Changes of stations and sensors, written by triggers in the transaction of
+-- the change and deleted once the change data capture publisher sent them
+CREATE TABLE IF NOT EXISTS cdc_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    entity VARCHAR(20) NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    entity_id UUID NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Positions of the publisher in streams without outbox, e.g. the watermark
+-- of the readings
+CREATE TABLE IF NOT EXISTS cdc_positions (
+    name VARCHAR(50) PRIMARY KEY,
+    position TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Captures a row change. The trigger arguments are the columns left out of
+-- the event: secrets and columns every push or pull updates. Updates that
+-- only touch those columns are not captured.
+CREATE OR REPLACE FUNCTION cdc_capture()
+RETURNS TRIGGER AS $$
+DECLARE
+    new_data JSONB;
+    old_data JSONB;
+BEGIN
+    IF TG_OP <> 'DELETE' THEN
+        new_data := to_jsonb(NEW) - TG_ARGV;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        old_data := to_jsonb(OLD) - TG_ARGV;
+    END IF;
+    IF TG_OP = 'UPDATE' AND new_data = old_data THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO cdc_outbox (entity, operation, entity_id, data)
+    VALUES (left(TG_TABLE_NAME, -1), lower(TG_OP), (COALESCE(new_data, old_data)->>'id')::uuid, COALESCE(new_data, old_data));
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER cdc_stations
+    AFTER INSERT OR UPDATE OR DELETE ON stations
+    FOR EACH ROW
+    EXECUTE FUNCTION cdc_capture('pass_key', 'config', 'updated_at', 'clock_skew_seconds', 'clock_skew_updated_at',
+        'pull_status', 'pull_error', 'pull_authorization_url', 'last_pull_at', 'last_pull_success_at');
+
+CREATE TRIGGER cdc_sensors
+    AFTER INSERT OR UPDATE OR DELETE ON sensors
+    FOR EACH ROW
+    EXECUTE FUNCTION cdc_capture('updated_at');
@@ -0,0 +1,3 @@
+-- Display preferences of sensors: unit, decimal places, name and icon.
+-- NULL keeps the defaults of the sensor type.
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS display JSONB;
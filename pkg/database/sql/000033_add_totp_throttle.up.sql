@@ -0,0 +1,6 @@
+-- Encrypted TOTP secrets are longer than the plain base32 secret
+ALTER TABLE users ALTER COLUMN totp_secret TYPE TEXT;
+-- Failed one-time codes since the last accepted one, and the time until
+-- which codes are refused after too many failures
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMP WITH TIME ZONE;
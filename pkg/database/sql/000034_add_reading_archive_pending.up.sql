@@ -0,0 +1,2 @@
+-- Archives whose readings may not be dropped yet
+ALTER TABLE reading_archives ADD COLUMN IF NOT EXISTS pending BOOLEAN NOT NULL DEFAULT FALSE;
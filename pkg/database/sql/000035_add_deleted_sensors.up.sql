@@ -0,0 +1,6 @@
+-- Sensors of deleted users. Their readings may still be in archived months,
+-- which are not rewritten, so they are skipped when a month is restored.
+CREATE TABLE IF NOT EXISTS deleted_sensors (
+    sensor_id UUID PRIMARY KEY,
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
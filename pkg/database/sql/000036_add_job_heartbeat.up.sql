@@ -0,0 +1,5 @@
+-- Running jobs record the runner executing them and a heartbeat refreshed
+-- with their progress, so a starting runner only fails its own jobs and
+-- those of runners that stopped responding
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS runner_id VARCHAR(255);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP WITH TIME ZONE;
@@ -60,6 +60,9 @@ func (dm *DatabaseManager) LoadStations() ([]models.StationData, error) {
 		if err := json.Unmarshal(configJSON, &station.Config); err != nil {
 			log.Printf("Failed to parse config for station %s: %v", station.PassKey, err)
 		}
+		if err := dm.decryptConfig(context.Background(), station.Config); err != nil {
+			log.Printf("Failed to decrypt config for station %s: %v", station.PassKey, err)
+		}
 
 		stations = append(stations, station)
 	}
@@ -70,7 +73,7 @@ func (dm *DatabaseManager) LoadStations() ([]models.StationData, error) {
 // LoadStation loads specific station from the database
 func (dm *DatabaseManager) LoadStation(stationID uuid.UUID) (models.StationData, error) {
 	query := `
-		SELECT id, pass_key, station_type, model, freq, mode, service_name, config, updated_at
+		SELECT id, pass_key, station_type, model, freq, mode, service_name, config, owner_id, updated_at
         FROM stations
         WHERE id = $1
     `
@@ -87,9 +90,13 @@ func (dm *DatabaseManager) LoadStation(stationID uuid.UUID) (models.StationData,
 		&station.Mode,
 		&station.ServiceName,
 		&configJSON,
+		&station.OwnerID,
 		&station.UpdatedAt,
 	)
 
+	if errors.Is(err, sql.ErrNoRows) {
+		return station, fmt.Errorf("station %w", ErrNotFound)
+	}
 	if err != nil {
 		return station, fmt.Errorf("failed to scan station %s", err.Error())
 	}
@@ -105,8 +112,11 @@ func (dm *DatabaseManager) LoadStation(stationID uuid.UUID) (models.StationData,
 	if err := json.Unmarshal(configJSON, &station.Config); err != nil {
 		log.Printf("Failed to parse config for station %s: %v", station.PassKey, err)
 	}
+	if err := dm.decryptConfig(context.Background(), station.Config); err != nil {
+		return station, fmt.Errorf("failed to decrypt config for station %s: %w", station.PassKey, err)
+	}
 
-	return station, err
+	return station, nil
 }
 
 // EnsureStation checks if a station exists and creates it if not
@@ -137,6 +147,30 @@ func (dm *DatabaseManager) EnsureStation(data *models.StationData) (uuid.UUID, e
 	return stationID, err
 }
 
+// GetStationIDByPassKey returns the ID of the station with a pass key
+// without creating it
+func (dm *DatabaseManager) GetStationIDByPassKey(ctx context.Context, passKey string) (uuid.UUID, error) {
+	var stationID uuid.UUID
+	err := dm.QueryRowWithHealthCheck(ctx, `SELECT id FROM stations WHERE pass_key = $1`, passKey).Scan(&stationID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("station %w", ErrNotFound)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to query station ID: %w", err)
+	}
+	return stationID, nil
+}
+
+// insertForStation returns an INSERT of a row belonging to the station
+// with ID $1, taking the values from the select list, e.g. "$2, $3".
+// Selecting the station turns a missing station into no rows, which
+// callers report as ErrNotFound instead of a foreign key violation.
+func insertForStation(table, columns, values string) string {
+	return `
+        INSERT INTO ` + table + ` (station_id, ` + columns + `)
+        SELECT id, ` + values + ` FROM stations WHERE id = $1`
+}
+
 // GetStationList retrieves a list of all stations with reading statistics
 // (total/first/last) computed from ClickHouse.
 func (dm *DatabaseManager) GetStationList() ([]models.StationDetail, error) {
@@ -215,17 +249,29 @@ func (dm *DatabaseManager) GetStationList() ([]models.StationDetail, error) {
 // reading statistics aggregated from ClickHouse.
 func (dm *DatabaseManager) GetStation(stationID uuid.UUID) (models.StationDetail, error) {
 	const stationQuery = `
-		SELECT id, pass_key, station_type, model
+		SELECT id, pass_key, station_type, model, clock_skew_seconds, clock_skew_updated_at, ` + pullStatusColumns + `
 		FROM stations
 		WHERE id = $1
 	`
 	var station models.StationDetail
-	err := dm.QueryRowWithHealthCheck(context.Background(), stationQuery, stationID).Scan(
-		&station.ID, &station.PassKey, &station.StationType, &station.Model,
-	)
+	var clockSkew sql.NullFloat64
+	var clockSkewUpdatedAt sql.NullTime
+	var pull pullStatusRow
+	dest := append([]interface{}{&station.ID, &station.PassKey, &station.StationType, &station.Model, &clockSkew, &clockSkewUpdatedAt}, pull.dest()...)
+	err := dm.QueryRowWithHealthCheck(context.Background(), stationQuery, stationID).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return station, fmt.Errorf("station %w", ErrNotFound)
+	}
 	if err != nil {
 		return station, err
 	}
+	if clockSkew.Valid {
+		station.ClockSkewSeconds = &clockSkew.Float64
+	}
+	if clockSkewUpdatedAt.Valid {
+		station.ClockSkewUpdatedAt = &clockSkewUpdatedAt.Time
+	}
+	station.PullStatus = pull.pullStatus()
 
 	const sensorsQuery = `SELECT id FROM sensors WHERE station_id = $1`
 	rows, err := dm.QueryWithHealthCheck(context.Background(), sensorsQuery, stationID)
@@ -319,7 +365,8 @@ func applyStationStats(station *models.StationDetail, sensorIDs []uuid.UUID, sta
 	}
 }
 
-// GetStationConfig retrieves the configuration for a specific station
+// GetStationConfig retrieves the configuration for a specific station with
+// secret values decrypted
 func (dm *DatabaseManager) GetStationConfig(id uuid.UUID) (map[string]interface{}, error) {
 	var config map[string]interface{}
 
@@ -335,12 +382,21 @@ func (dm *DatabaseManager) GetStationConfig(id uuid.UUID) (map[string]interface{
 		err = errors.New("Failed to parse station config: " + err.Error())
 		return config, err
 	}
+	if err := dm.decryptConfig(context.Background(), config); err != nil {
+		return config, err
+	}
 
 	return config, nil
 }
 
-// SetStationConfig updates the configuration for a specific station
+// SetStationConfig updates the configuration for a specific station.
+// Secret values are encrypted when a secrets key is configured.
 func (dm *DatabaseManager) SetStationConfig(id uuid.UUID, config map[string]interface{}) error {
+	config, _, err := dm.encryptConfig(context.Background(), config)
+	if err != nil {
+		return err
+	}
+
 	updatedConfigJSON, err := json.Marshal(config)
 	if err != nil {
 		log.Printf("Failed to marshal config: %v", err)
@@ -357,9 +413,97 @@ func (dm *DatabaseManager) SetStationConfig(id uuid.UUID, config map[string]inte
 	return nil
 }
 
+// GetStationConfigVersion returns the config of a station with secret values
+// decrypted and its version, a hash of the stored config that changes with
+// every update
+func (dm *DatabaseManager) GetStationConfigVersion(ctx context.Context, id uuid.UUID) (map[string]interface{}, string, error) {
+	var configJSON []byte
+	var version string
+	err := dm.QueryRowWithHealthCheck(ctx, `SELECT config, md5(config::text) FROM stations WHERE id = $1`, id).Scan(&configJSON, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", fmt.Errorf("station %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query station config: %w", err)
+	}
+
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, "", fmt.Errorf("failed to parse station config: %w", err)
+	}
+	if err := dm.decryptConfig(ctx, config); err != nil {
+		return nil, "", err
+	}
+	return config, version, nil
+}
+
+// ReplaceStationConfig replaces the config of a station if it still has
+// the version returned by GetStationConfigVersion and returns the new
+// version. ErrConfigChanged is returned if the config was changed since.
+func (dm *DatabaseManager) ReplaceStationConfig(ctx context.Context, id uuid.UUID, config map[string]interface{}, version string) (string, error) {
+	config, _, err := dm.encryptConfig(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	query := `
+		UPDATE stations SET config = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND md5(config::text) = $3
+		RETURNING md5(config::text)
+	`
+	var newVersion string
+	err = dm.QueryRowWithHealthCheck(ctx, query, configJSON, id, version).Scan(&newVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, _, err := dm.GetStationConfigVersion(ctx, id); err != nil {
+			return "", err
+		}
+		return "", ErrConfigChanged
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update station config: %w", err)
+	}
+	return newVersion, nil
+}
+
+// UpdateStationConfigValues merges values into the config of a station,
+// encrypting secret values like SetStationConfig
+func (dm *DatabaseManager) UpdateStationConfigValues(ctx context.Context, id uuid.UUID, values map[string]interface{}) error {
+	values, _, err := dm.encryptConfig(ctx, values)
+	if err != nil {
+		return err
+	}
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	query := `UPDATE stations SET config = config || $1::jsonb, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	result, err := dm.ExecWithHealthCheck(ctx, query, valuesJSON, id)
+	if err != nil {
+		return fmt.Errorf("failed to update station config: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("station %w", ErrNotFound)
+	}
+	return nil
+}
+
 // SaveStation saves a station to the database
 func (dm *DatabaseManager) SaveStation(station *models.StationData) error {
-	configJSON, err := json.Marshal(station.Config)
+	config, _, err := dm.encryptConfig(context.Background(), station.Config)
+	if err != nil {
+		return err
+	}
+
+	configJSON, err := json.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -448,6 +592,84 @@ func (dm *DatabaseManager) GetStationsData() ([]models.StationData, error) {
 	return stations, rows.Err()
 }
 
+// UpdateStationClockSkew stores the measured clock skew of a station
+func (dm *DatabaseManager) UpdateStationClockSkew(ctx context.Context, stationID uuid.UUID, skewSeconds float64, measuredAt time.Time) error {
+	query := `UPDATE stations SET clock_skew_seconds = $1, clock_skew_updated_at = $2 WHERE id = $3`
+	_, err := dm.ExecWithHealthCheck(ctx, query, skewSeconds, measuredAt.UTC(), stationID)
+	if err != nil {
+		return fmt.Errorf("failed to update clock skew: %w", err)
+	}
+	return nil
+}
+
+// pullStatusColumns are the stations columns scanned by pullStatusRow
+const pullStatusColumns = `pull_status, pull_error, pull_authorization_url, last_pull_at, last_pull_success_at`
+
+// pullStatusRow scans the nullable pull status columns of a station
+type pullStatusRow struct {
+	status           sql.NullString
+	err              sql.NullString
+	authorizationURL sql.NullString
+	lastPullAt       sql.NullTime
+	lastSuccessAt    sql.NullTime
+}
+
+func (r *pullStatusRow) dest() []interface{} {
+	return []interface{}{&r.status, &r.err, &r.authorizationURL, &r.lastPullAt, &r.lastSuccessAt}
+}
+
+// pullStatus returns the pull status or nil for stations that were never pulled
+func (r *pullStatusRow) pullStatus() *models.PullStatus {
+	if !r.status.Valid {
+		return nil
+	}
+	status := &models.PullStatus{
+		Status:           r.status.String,
+		Error:            r.err.String,
+		AuthorizationURL: r.authorizationURL.String,
+		LastPullAt:       r.lastPullAt.Time,
+	}
+	if r.lastSuccessAt.Valid {
+		status.LastSuccessAt = &r.lastSuccessAt.Time
+	}
+	return status
+}
+
+// UpdateStationPullStatus stores the outcome of a pull. The time of the last
+// successful pull is kept when the pull failed.
+func (dm *DatabaseManager) UpdateStationPullStatus(ctx context.Context, stationID uuid.UUID, status models.PullStatus) error {
+	query := `
+        UPDATE stations
+        SET pull_status = $1,
+            pull_error = NULLIF($2, ''),
+            pull_authorization_url = NULLIF($3, ''),
+            last_pull_at = $4,
+            last_pull_success_at = CASE WHEN $5 THEN $4 ELSE last_pull_success_at END
+        WHERE id = $6
+    `
+	_, err := dm.ExecWithHealthCheck(ctx, query,
+		status.Status, status.Error, status.AuthorizationURL, status.LastPullAt.UTC(), status.Status == models.PullStatusOK, stationID)
+	if err != nil {
+		return fmt.Errorf("failed to update pull status: %w", err)
+	}
+	return nil
+}
+
+// GetStationPullStatus returns the outcome of the last pull of a station or
+// nil when it was never pulled
+func (dm *DatabaseManager) GetStationPullStatus(ctx context.Context, stationID uuid.UUID) (*models.PullStatus, error) {
+	query := `SELECT ` + pullStatusColumns + ` FROM stations WHERE id = $1`
+
+	var pull pullStatusRow
+	if err := dm.QueryRowWithHealthCheck(ctx, query, stationID).Scan(pull.dest()...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("station %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query pull status: %w", err)
+	}
+	return pull.pullStatus(), nil
+}
+
 // DeleteStation deletes a station and its associated weather data
 func (dm *DatabaseManager) DeleteStation(stationID uuid.UUID) error {
 	// Delete station
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -127,6 +130,14 @@ func TestSaveStation_UpdateOnConflict(t *testing.T) {
 	}
 }
 
+func TestInsertForStation(t *testing.T) {
+	query := strings.Join(strings.Fields(insertForStation("share_tokens", "name, prefix", "$2, $3")), " ")
+	expected := "INSERT INTO share_tokens (station_id, name, prefix) SELECT id, $2, $3 FROM stations WHERE id = $1"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+}
+
 func TestLoadStations(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -255,6 +266,9 @@ func TestLoadStation_NotFound(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when loading non-existent station")
 	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
 }
 
 func TestEnsureStation(t *testing.T) {
@@ -303,6 +317,67 @@ func TestEnsureStation(t *testing.T) {
 		t.Errorf("Expected model to be updated to 'Updated Model', got %s", loaded.Model)
 	}
 }
+
+func TestGetStationIDByPassKey(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+
+	id, err := dm.GetStationIDByPassKey(context.Background(), station.PassKey)
+	if err != nil {
+		t.Fatalf("GetStationIDByPassKey() error = %v", err)
+	}
+	if id != station.ID {
+		t.Errorf("Expected %s, got %s", station.ID, id)
+	}
+
+	if _, err := dm.GetStationIDByPassKey(context.Background(), "unknown-"+uuid.New().String()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+func TestReplaceStationConfig(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	_, version, err := dm.GetStationConfigVersion(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("GetStationConfigVersion() error = %v", err)
+	}
+	newVersion, err := dm.ReplaceStationConfig(ctx, station.ID, map[string]interface{}{"timezone": "Europe/Vienna"}, version)
+	if err != nil {
+		t.Fatalf("ReplaceStationConfig() error = %v", err)
+	}
+	if newVersion == version {
+		t.Error("Expected the version to change")
+	}
+
+	config, current, err := dm.GetStationConfigVersion(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("GetStationConfigVersion() error = %v", err)
+	}
+	if current != newVersion || config["timezone"] != "Europe/Vienna" {
+		t.Errorf("Unexpected config %v with version %s", config, current)
+	}
+
+	// A replace based on the old version must not overwrite the change
+	if _, err := dm.ReplaceStationConfig(ctx, station.ID, map[string]interface{}{}, version); !errors.Is(err, ErrConfigChanged) {
+		t.Errorf("Expected ErrConfigChanged, got %v", err)
+	}
+	if _, err := dm.ReplaceStationConfig(ctx, uuid.New(), map[string]interface{}{}, version); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestGetStationList(t *testing.T) {
 	dm := setupTestDatabaseManager(t)
 	if dm == nil {
@@ -457,6 +532,95 @@ func TestGetStation_NotFound(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error when getting non-existent station")
 	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUpdateStationClockSkew(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+
+	// No skew measured yet
+	detail, err := dm.GetStation(station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get station: %v", err)
+	}
+	if detail.ClockSkewSeconds != nil {
+		t.Errorf("Expected no clock skew, got %f", *detail.ClockSkewSeconds)
+	}
+
+	measuredAt := time.Now().UTC()
+	if err := dm.UpdateStationClockSkew(context.Background(), station.ID, -42.5, measuredAt); err != nil {
+		t.Fatalf("Failed to update clock skew: %v", err)
+	}
+
+	detail, err = dm.GetStation(station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get station: %v", err)
+	}
+	if detail.ClockSkewSeconds == nil || *detail.ClockSkewSeconds != -42.5 {
+		t.Errorf("Expected clock skew -42.5, got %v", detail.ClockSkewSeconds)
+	}
+	if detail.ClockSkewUpdatedAt == nil {
+		t.Error("Expected ClockSkewUpdatedAt to be set")
+	}
+}
+
+func TestUpdateStationPullStatus(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	station := setupTestStation(t, dm)
+
+	status, err := dm.GetStationPullStatus(ctx, station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get pull status: %v", err)
+	}
+	if status != nil {
+		t.Errorf("Expected no pull status, got %+v", status)
+	}
+
+	okAt := time.Now().UTC().Add(-time.Minute)
+	if err := dm.UpdateStationPullStatus(ctx, station.ID, models.PullStatus{Status: models.PullStatusOK, LastPullAt: okAt}); err != nil {
+		t.Fatalf("Failed to update pull status: %v", err)
+	}
+	failed := models.PullStatus{
+		Status:           models.PullStatusReauthorizationRequired,
+		Error:            "refresh token is invalid or expired",
+		AuthorizationURL: "https://example.com/authorize",
+		LastPullAt:       time.Now().UTC(),
+	}
+	if err := dm.UpdateStationPullStatus(ctx, station.ID, failed); err != nil {
+		t.Fatalf("Failed to update pull status: %v", err)
+	}
+
+	detail, err := dm.GetStation(station.ID)
+	if err != nil {
+		t.Fatalf("Failed to get station: %v", err)
+	}
+	if detail.PullStatus == nil {
+		t.Fatal("Expected pull status in station details")
+	}
+	if detail.PullStatus.Status != failed.Status || detail.PullStatus.Error != failed.Error || detail.PullStatus.AuthorizationURL != failed.AuthorizationURL {
+		t.Errorf("Unexpected pull status: %+v", detail.PullStatus)
+	}
+	if detail.PullStatus.LastSuccessAt == nil || !detail.PullStatus.LastSuccessAt.Round(time.Second).Equal(okAt.Round(time.Second)) {
+		t.Errorf("Expected last success at %v, got %v", okAt, detail.PullStatus.LastSuccessAt)
+	}
+
+	if _, err := dm.GetStationPullStatus(ctx, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
 }
 
 func TestGetStationConfig(t *testing.T) {
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetStorageReport returns the sizes of all tables, the readings per
+// station and sensor and the growth over the last days with a projection of
+// the disk usage
+func (dm *DatabaseManager) GetStorageReport(ctx context.Context, days int) (*models.StorageReport, error) {
+	now := time.Now().UTC()
+	report := &models.StorageReport{GeneratedAt: now}
+
+	var err error
+	if report.Postgres, err = dm.postgresTableStorage(ctx); err != nil {
+		return nil, err
+	}
+	if report.ClickHouse, err = dm.clickHouseTableStorage(ctx); err != nil {
+		return nil, err
+	}
+	for _, tables := range [][]models.TableStorage{report.Postgres, report.ClickHouse} {
+		for _, t := range tables {
+			report.TotalBytes += t.Bytes
+		}
+	}
+	for _, t := range report.ClickHouse {
+		if t.Name == "sensor_readings" && t.Rows > 0 {
+			report.BytesPerReading = float64(t.Bytes) / float64(t.Rows)
+		}
+	}
+
+	if report.Stations, err = dm.stationStorage(ctx, report.BytesPerReading); err != nil {
+		return nil, err
+	}
+
+	today := now.Truncate(24 * time.Hour)
+	daily, err := dm.dailyReadingCounts(ctx, today.AddDate(0, 0, -days), today)
+	if err != nil {
+		return nil, err
+	}
+	report.Growth = models.NewStorageGrowth(daily, days, report.BytesPerReading)
+	report.Projections = models.ProjectStorage(now, report.TotalBytes, report.Growth)
+	return report, nil
+}
+
+// postgresTableStorage returns the size of the tables of the instance schema
+// including indexes and TOAST data, largest first
+func (dm *DatabaseManager) postgresTableStorage(ctx context.Context) ([]models.TableStorage, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT c.relname, GREATEST(c.reltuples, 0)::BIGINT, pg_total_relation_size(c.oid)
+        FROM pg_class c
+        JOIN pg_namespace n ON n.oid = c.relnamespace
+        WHERE n.nspname = current_schema() AND c.relkind = 'r'
+        ORDER BY 3 DESC, 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []models.TableStorage{}
+	for rows.Next() {
+		var t models.TableStorage
+		if err := rows.Scan(&t.Name, &t.Rows, &t.Bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan table size: %w", err)
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// clickHouseTableStorage returns the size of the ClickHouse tables on disk
+// from their active parts, largest first
+func (dm *DatabaseManager) clickHouseTableStorage(ctx context.Context) ([]models.TableStorage, error) {
+	rows, err := dm.ch.Conn().Query(ctx, `
+		SELECT table, sum(rows), sum(bytes_on_disk)
+		FROM system.parts
+		WHERE database = currentDatabase() AND active
+		GROUP BY table
+		ORDER BY sum(bytes_on_disk) DESC, table`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []models.TableStorage{}
+	for rows.Next() {
+		var name string
+		var count, bytes uint64
+		if err := rows.Scan(&name, &count, &bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan table size: %w", err)
+		}
+		tables = append(tables, models.TableStorage{Name: name, Rows: int64(count), Bytes: int64(bytes)})
+	}
+	return tables, rows.Err()
+}
+
+// stationStorage returns the readings per station and sensor, most readings
+// first. Readings of deleted sensors are left out, see FindOrphanedReadings.
+func (dm *DatabaseManager) stationStorage(ctx context.Context, bytesPerReading float64) ([]models.StationStorage, error) {
+	sensors, err := dm.sensorsByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := dm.readingCountsBySensor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byStation := make(map[uuid.UUID]*models.StationStorage)
+	for id, sensor := range sensors {
+		station, ok := byStation[sensor.StationID]
+		if !ok {
+			station = &models.StationStorage{StationID: sensor.StationID, Sensors: []models.SensorStorage{}}
+			byStation[sensor.StationID] = station
+		}
+		readings := int64(counts[id])
+		bytes := int64(float64(readings) * bytesPerReading)
+		station.Sensors = append(station.Sensors, models.SensorStorage{
+			SensorID:   id,
+			SensorType: sensor.SensorType,
+			Location:   sensor.Location,
+			Readings:   readings,
+			Bytes:      bytes,
+		})
+		station.Readings += readings
+		station.Bytes += bytes
+	}
+
+	stations := make([]models.StationStorage, 0, len(byStation))
+	for _, station := range byStation {
+		sort.Slice(station.Sensors, func(i, j int) bool {
+			a, b := station.Sensors[i], station.Sensors[j]
+			if a.Readings != b.Readings {
+				return a.Readings > b.Readings
+			}
+			return a.SensorID.String() < b.SensorID.String()
+		})
+		stations = append(stations, *station)
+	}
+	sort.Slice(stations, func(i, j int) bool {
+		if stations[i].Readings != stations[j].Readings {
+			return stations[i].Readings > stations[j].Readings
+		}
+		return stations[i].StationID.String() < stations[j].StationID.String()
+	})
+	return stations, nil
+}
+
+// dailyReadingCounts returns the number of readings per day from start
+// (inclusive) to end (exclusive); days without readings are left out
+func (dm *DatabaseManager) dailyReadingCounts(ctx context.Context, start, end time.Time) ([]models.DailyReadingCount, error) {
+	rows, err := dm.ch.Conn().Query(ctx, `
+		SELECT toDate(date_utc) AS day, count()
+		FROM sensor_readings
+		WHERE date_utc >= ? AND date_utc < ?
+		GROUP BY day
+		ORDER BY day`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count daily readings: %w", err)
+	}
+	defer rows.Close()
+
+	daily := []models.DailyReadingCount{}
+	for rows.Next() {
+		var day time.Time
+		var count uint64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan daily count: %w", err)
+		}
+		daily = append(daily, models.DailyReadingCount{Day: day.UTC(), Readings: int64(count)})
+	}
+	return daily, rows.Err()
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestGetStorageReport(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil || dm.ch == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	station := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, station.ID, models.SensorTypeTemperature, "outdoor")
+	yesterday := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	storeTestReadings(t, dm, sensor.ID, yesterday, 20, func(i int) float64 { return float64(i) })
+
+	report, err := dm.GetStorageReport(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("Failed to get storage report: %v", err)
+	}
+
+	if len(report.Postgres) == 0 {
+		t.Error("Expected Postgres table sizes")
+	}
+	var found bool
+	for _, s := range report.Stations {
+		if s.StationID == station.ID {
+			found = true
+			if s.Readings != 20 || len(s.Sensors) != 1 || s.Sensors[0].SensorID != sensor.ID {
+				t.Errorf("Unexpected station storage %+v", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected the test station in the report")
+	}
+	if len(report.Growth.Daily) != 1 || report.Growth.Daily[0].Readings != 20 {
+		t.Errorf("Expected 20 readings yesterday, got %+v", report.Growth.Daily)
+	}
+	if len(report.Projections) != len(models.StorageProjectionDays) {
+		t.Errorf("Expected %d projections, got %d", len(models.StorageProjectionDays), len(report.Projections))
+	}
+}
@@ -0,0 +1,377 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTP parameters of RFC 6238 as used by common authenticator apps
+const (
+	totpPeriod        = 30
+	totpDigits        = 6
+	recoveryCodeCount = 10
+
+	// maxOTPAttempts wrong codes in a row lock the codes of a user for
+	// otpLockout, so the million codes can't be tried online
+	maxOTPAttempts = 5
+	otpLockout     = 15 * time.Minute
+)
+
+// totpEncoding encodes secrets as unpadded base32 like authenticator apps
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret returns a random 160 bit secret
+func generateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// totpCode returns the code of a secret for a time step
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// validateTOTP checks a code against the time steps around now, allowing
+// one step of clock drift. Steps up to lastStep were already used. It
+// returns the matching step.
+func validateTOTP(secret, code string, now time.Time, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - 1; step <= current+1; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI returns the otpauth:// URI for authenticator apps
+func totpURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// normalizeRecoveryCode makes recovery codes case and format insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+// sealTOTPSecret encrypts a TOTP secret like the secrets of station
+// configs. Without key wrapper the secret is stored in plain text.
+func (dm *DatabaseManager) sealTOTPSecret(ctx context.Context, secret string) (string, error) {
+	if dm.keyWrapper == nil {
+		return secret, nil
+	}
+	return encryptSecret(ctx, dm.keyWrapper, secret)
+}
+
+// openTOTPSecret decrypts a stored TOTP secret
+func (dm *DatabaseManager) openTOTPSecret(ctx context.Context, stored string) (string, error) {
+	if !isEncryptedSecret(stored) {
+		return stored, nil
+	}
+	secret, err := decryptSecret(ctx, dm.keyWrapper, stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// checkOTPLock returns ErrOTPLocked while the codes of a user are locked
+func checkOTPLock(lockedUntil sql.NullTime) error {
+	if lockedUntil.Valid && lockedUntil.Time.After(time.Now()) {
+		return ErrOTPLocked
+	}
+	return nil
+}
+
+// recordOTPFailure counts a wrong code of a user and locks the codes after
+// maxOTPAttempts failures in a row. It returns ErrInvalidOTP, or
+// ErrOTPLocked for the failure that locks the codes.
+func (dm *DatabaseManager) recordOTPFailure(ctx context.Context, userID uuid.UUID) error {
+	query := `
+        UPDATE users SET
+            totp_locked_until = CASE WHEN totp_failed_attempts + 1 >= $2
+                THEN NOW() + make_interval(secs => $3) ELSE totp_locked_until END,
+            totp_failed_attempts = CASE WHEN totp_failed_attempts + 1 >= $2
+                THEN 0 ELSE totp_failed_attempts + 1 END
+        WHERE id = $1
+        RETURNING totp_failed_attempts = 0
+    `
+
+	var locked bool
+	if err := dm.QueryRowWithHealthCheck(ctx, query, userID, maxOTPAttempts, otpLockout.Seconds()).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to count invalid one-time code: %w", err)
+	}
+	if locked {
+		return ErrOTPLocked
+	}
+	return ErrInvalidOTP
+}
+
+// EnrollTOTP creates a new pending TOTP secret for a user and returns it
+// with its otpauth:// URI. The secret is enabled by ActivateTOTP.
+func (dm *DatabaseManager) EnrollTOTP(ctx context.Context, userID uuid.UUID, issuer string) (string, string, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	stored, err := dm.sealTOTPSecret(ctx, secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	query := `
+        UPDATE users SET totp_secret = $1, totp_last_step = 0
+        WHERE id = $2 AND NOT totp_enabled
+        RETURNING username
+    `
+
+	var username string
+	if err := dm.QueryRowWithHealthCheck(ctx, query, stored, userID).Scan(&username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := dm.GetUser(ctx, userID); err != nil {
+				return "", "", err
+			}
+			return "", "", ErrTOTPEnabled
+		}
+		return "", "", fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+	return secret, totpURI(issuer, username, secret), nil
+}
+
+// ActivateTOTP enables the pending secret of a user after checking a code
+// of it and returns new recovery codes, which are only available here.
+// Wrong codes count towards the lock of VerifyOTP.
+func (dm *DatabaseManager) ActivateTOTP(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var secret sql.NullString
+	var enabled bool
+	var lockedUntil sql.NullTime
+	query := `SELECT totp_secret, totp_enabled, totp_locked_until FROM users WHERE id = $1`
+	if err := dm.QueryRowWithHealthCheck(ctx, query, userID).Scan(&secret, &enabled, &lockedUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	if enabled {
+		return nil, ErrTOTPEnabled
+	}
+	if !secret.Valid {
+		return nil, ErrInvalidOTP
+	}
+	if err := checkOTPLock(lockedUntil); err != nil {
+		return nil, err
+	}
+	plain, err := dm.openTOTPSecret(ctx, secret.String)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := validateTOTP(plain, code, time.Now(), 0)
+	if !ok {
+		return nil, dm.recordOTPFailure(ctx, userID)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		encoded := hex.EncodeToString(raw)
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET totp_enabled = true, totp_last_step = $1, totp_failed_attempts = 0 WHERE id = $2`, step, userID); err != nil {
+		return nil, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, c := range codes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hashToken(normalizeRecoveryCode(c))); err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit TOTP activation: %w", err)
+	}
+	return codes, nil
+}
+
+// VerifyOTP checks a TOTP code or an unused recovery code of a user with
+// enabled two-factor authentication. Accepted codes can't be used again.
+// After maxOTPAttempts wrong codes in a row, all codes are refused with
+// ErrOTPLocked for otpLockout.
+func (dm *DatabaseManager) VerifyOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	var secret sql.NullString
+	var enabled bool
+	var lastStep int64
+	var lockedUntil sql.NullTime
+	query := `SELECT totp_secret, totp_enabled, totp_last_step, totp_locked_until FROM users WHERE id = $1`
+	if err := dm.QueryRowWithHealthCheck(ctx, query, userID).Scan(&secret, &enabled, &lastStep, &lockedUntil); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %w", ErrNotFound)
+		}
+		return fmt.Errorf("failed to query user: %w", err)
+	}
+	if !enabled || !secret.Valid {
+		return ErrInvalidOTP
+	}
+	if err := checkOTPLock(lockedUntil); err != nil {
+		return err
+	}
+	plain, err := dm.openTOTPSecret(ctx, secret.String)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := validateTOTP(plain, code, time.Now(), lastStep); ok {
+		// The condition guards against concurrent use of the same code
+		result, err := dm.ExecWithHealthCheck(ctx, `UPDATE users SET totp_last_step = $1, totp_failed_attempts = 0 WHERE id = $2 AND totp_last_step < $1`, step, userID)
+		if err != nil {
+			return fmt.Errorf("failed to store TOTP step: %w", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return dm.recordOTPFailure(ctx, userID)
+		}
+		return nil
+	}
+
+	result, err := dm.ExecWithHealthCheck(ctx, `
+        UPDATE user_recovery_codes SET used_at = NOW()
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return dm.recordOTPFailure(ctx, userID)
+	}
+	if _, err := dm.ExecWithHealthCheck(ctx, `UPDATE users SET totp_failed_attempts = 0 WHERE id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to reset invalid one-time codes: %w", err)
+	}
+	return nil
+}
+
+// DisableTOTP turns off two-factor authentication of a user and removes its
+// secret and recovery codes
+func (dm *DatabaseManager) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = 0, totp_failed_attempts = 0, totp_locked_until = NULL WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit TOTP removal: %w", err)
+	}
+	return nil
+}
+
+// EncryptTOTPSecrets encrypts the plain text TOTP secrets of all users and
+// returns the number of encrypted secrets
+func (dm *DatabaseManager) EncryptTOTPSecrets(ctx context.Context) (int, error) {
+	if dm.keyWrapper == nil {
+		return 0, ErrNoSecretsKey
+	}
+
+	rows, err := dm.QueryWithHealthCheck(ctx, `SELECT id, totp_secret FROM users WHERE totp_secret IS NOT NULL AND totp_secret NOT LIKE $1`, secretPrefix+"%")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query TOTP secrets: %w", err)
+	}
+	secrets := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var secret string
+		if err := rows.Scan(&id, &secret); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan TOTP secret: %w", err)
+		}
+		secrets[id] = secret
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query TOTP secrets: %w", err)
+	}
+
+	count := 0
+	for id, secret := range secrets {
+		sealed, err := encryptSecret(ctx, dm.keyWrapper, secret)
+		if err != nil {
+			return count, fmt.Errorf("failed to encrypt TOTP secret of user %s: %w", id, err)
+		}
+		// The condition skips secrets changed in the meantime
+		result, err := dm.ExecWithHealthCheck(ctx, `UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_secret = $3`, sealed, id, secret)
+		if err != nil {
+			return count, fmt.Errorf("failed to store TOTP secret of user %s: %w", id, err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			count++
+		}
+	}
+	return count, nil
+}
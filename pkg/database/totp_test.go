@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test secret of RFC 6238 ("12345678901234567890")
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors of RFC 6238 truncated to 6 digits
+	testCases := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := totpCode(rfc6238Secret, tc.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("totpCode failed: %v", err)
+		}
+		if code != tc.expected {
+			t.Errorf("At %d: expected %s, got %s", tc.unix, tc.expected, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := now.Unix() / totpPeriod
+
+	previous, _ := totpCode(rfc6238Secret, current-1)
+	if step, ok := validateTOTP(rfc6238Secret, previous, now, 0); !ok || step != current-1 {
+		t.Errorf("Expected code of previous step to be accepted, got %d, %v", step, ok)
+	}
+	if _, ok := validateTOTP(rfc6238Secret, "081 804", now, 0); !ok {
+		t.Error("Expected code with space to be accepted")
+	}
+
+	// Used steps are rejected
+	if _, ok := validateTOTP(rfc6238Secret, "081804", now, current); ok {
+		t.Error("Expected used code to be rejected")
+	}
+
+	old, _ := totpCode(rfc6238Secret, current-3)
+	if _, ok := validateTOTP(rfc6238Secret, old, now, 0); ok {
+		t.Error("Expected expired code to be rejected")
+	}
+	if _, ok := validateTOTP(rfc6238Secret, "12345", now, 0); ok {
+		t.Error("Expected short code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("WeatherMaestro", "admin", rfc6238Secret)
+
+	if !strings.HasPrefix(uri, "otpauth://totp/WeatherMaestro:admin?") {
+		t.Errorf("Unexpected URI %s", uri)
+	}
+	if !strings.Contains(uri, "secret="+rfc6238Secret) || !strings.Contains(uri, "issuer=WeatherMaestro") {
+		t.Errorf("Expected secret and issuer in %s", uri)
+	}
+}
+
+func TestTOTPLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	username := "totp_" + generateRandomString(8)
+	user, err := dm.CreateUser(ctx, username, "password")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	secret, _, err := dm.EnrollTOTP(ctx, user.ID, "WeatherMaestro")
+	if err != nil {
+		t.Fatalf("Failed to enroll TOTP: %v", err)
+	}
+	if _, err := dm.ActivateTOTP(ctx, user.ID, "000000"); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("Expected ErrInvalidOTP for wrong code, got %v", err)
+	}
+
+	code, _ := totpCode(secret, time.Now().Unix()/totpPeriod)
+	recoveryCodes, err := dm.ActivateTOTP(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("Failed to activate TOTP: %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Errorf("Expected %d recovery codes, got %d", recoveryCodeCount, len(recoveryCodes))
+	}
+
+	validated, err := dm.ValidateUser(ctx, username, "password")
+	if err != nil || !validated.TwoFactorEnabled {
+		t.Errorf("Expected two-factor authentication to be enabled, got %+v, %v", validated, err)
+	}
+	if _, _, err := dm.EnrollTOTP(ctx, user.ID, "WeatherMaestro"); !errors.Is(err, ErrTOTPEnabled) {
+		t.Errorf("Expected ErrTOTPEnabled, got %v", err)
+	}
+
+	// The activation code can't be replayed
+	if err := dm.VerifyOTP(ctx, user.ID, code); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("Expected replayed code to be rejected, got %v", err)
+	}
+
+	// Recovery codes work once
+	if err := dm.VerifyOTP(ctx, user.ID, strings.ToUpper(recoveryCodes[0])); err != nil {
+		t.Errorf("Expected recovery code to be accepted: %v", err)
+	}
+	if err := dm.VerifyOTP(ctx, user.ID, recoveryCodes[0]); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("Expected used recovery code to be rejected, got %v", err)
+	}
+
+	if err := dm.DisableTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to disable TOTP: %v", err)
+	}
+	if err := dm.VerifyOTP(ctx, user.ID, recoveryCodes[1]); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("Expected recovery codes to be removed, got %v", err)
+	}
+}
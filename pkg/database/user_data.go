@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// GetUserByUsername retrieves a user by name
+func (dm *DatabaseManager) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	var id uuid.UUID
+	err := dm.QueryRowWithHealthCheck(ctx, `SELECT id FROM users WHERE username = $1`, username).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	return dm.GetUser(ctx, id)
+}
+
+// GetOwnedStationIDs returns the IDs of the stations owned by a user
+func (dm *DatabaseManager) GetOwnedStationIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `SELECT id FROM stations WHERE owner_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query owned stations: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan station: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// WriteSensorReadingsJSON writes all readings of the given sensors as one
+// JSON object per line
+func (dm *DatabaseManager) WriteSensorReadingsJSON(ctx context.Context, sensorIDs []uuid.UUID, w io.Writer) (int64, error) {
+	if len(sensorIDs) == 0 {
+		return 0, nil
+	}
+	return dm.writeReadingsJSON(ctx, w, nil, `
+		SELECT id, sensor_id, value, unit, raw_value, raw_unit, date_utc, estimated
+		FROM sensor_readings
+		WHERE sensor_id IN ?
+		ORDER BY sensor_id, date_utc, value`, sensorIDs)
+}
+
+// ScheduleUserDeletion requests the deletion of a user at the given time.
+// A pending request keeps its earlier time. It returns the scheduled time.
+func (dm *DatabaseManager) ScheduleUserDeletion(ctx context.Context, userID uuid.UUID, at time.Time) (time.Time, error) {
+	var scheduled time.Time
+	err := dm.QueryRowWithHealthCheck(ctx, `
+        UPDATE users SET deletion_scheduled_at = COALESCE(deletion_scheduled_at, $2)
+        WHERE id = $1
+        RETURNING deletion_scheduled_at`,
+		userID, at.UTC(),
+	).Scan(&scheduled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("user %w", ErrNotFound)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule user deletion: %w", err)
+	}
+	return scheduled, nil
+}
+
+// CancelUserDeletion withdraws the deletion request of a user
+func (dm *DatabaseManager) CancelUserDeletion(ctx context.Context, userID uuid.UUID) error {
+	result, err := dm.ExecWithHealthCheck(ctx, `
+        UPDATE users SET deletion_scheduled_at = NULL
+        WHERE id = $1 AND deletion_scheduled_at IS NOT NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel user deletion: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("scheduled deletion %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetUsersDueForDeletion returns the users whose deletion is scheduled
+// before now
+func (dm *DatabaseManager) GetUsersDueForDeletion(ctx context.Context, now time.Time) ([]models.User, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT id FROM users
+        WHERE deletion_scheduled_at <= $1
+        ORDER BY deletion_scheduled_at`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users due for deletion: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0, len(ids))
+	for _, id := range ids {
+		user, err := dm.GetUser(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, nil
+}
+
+// DeleteUser irreversibly deletes a user with the stations it owns and
+// everything stored for them in Postgres. The readings of the returned
+// sensors are left in ClickHouse and must be removed with
+// DeleteOrphanedReadings. The sensors are recorded as deleted, so their
+// archived readings aren't restored, see GetDeletedSensorIDs.
+func (dm *DatabaseManager) DeleteUser(ctx context.Context, userID uuid.UUID) (*models.UserDeletion, error) {
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+        SELECT s.id FROM sensors s
+        JOIN stations st ON st.id = s.station_id
+        WHERE st.owner_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query owned sensors: %w", err)
+	}
+	deletion := &models.UserDeletion{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan sensor: %w", err)
+		}
+		deletion.SensorIDs = append(deletion.SensorIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	deletion.Sensors = len(deletion.SensorIDs)
+
+	for _, id := range deletion.SensorIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO deleted_sensors (sensor_id) VALUES ($1) ON CONFLICT DO NOTHING`, id); err != nil {
+			return nil, fmt.Errorf("failed to record deleted sensor: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM stations WHERE owner_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete owned stations: %w", err)
+	}
+	stations, _ := result.RowsAffected()
+	deletion.Stations = int(stations)
+
+	result, err = tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return deletion, nil
+}
+
+// GetDeletedSensorIDs returns the sensors of deleted users
+func (dm *DatabaseManager) GetDeletedSensorIDs(ctx context.Context) (map[uuid.UUID]bool, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `SELECT sensor_id FROM deleted_sensors`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted sensors: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan sensor: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// SetStationOwner assigns a station to a user, or to the instance if
+// ownerID is nil
+func (dm *DatabaseManager) SetStationOwner(ctx context.Context, stationID uuid.UUID, ownerID *uuid.UUID) error {
+	result, err := dm.ExecWithHealthCheck(ctx, `UPDATE stations SET owner_id = $1 WHERE id = $2`, ownerID, stationID)
+	if err != nil {
+		return fmt.Errorf("failed to set station owner: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("station %w", ErrNotFound)
+	}
+	return nil
+}
+
+// AddUserAuditEntry records an entry in the user audit log
+func (dm *DatabaseManager) AddUserAuditEntry(ctx context.Context, entry *models.UserAuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = dm.QueryRowWithHealthCheck(ctx, `
+        INSERT INTO user_audit_log (user_id, username, action, actor, details)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`,
+		entry.UserID, entry.Username, entry.Action, entry.Actor, detailsJSON,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetUserAuditLog returns the audit entries of a user, oldest first. It
+// also works for deleted users.
+func (dm *DatabaseManager) GetUserAuditLog(ctx context.Context, userID uuid.UUID) ([]models.UserAuditEntry, error) {
+	rows, err := dm.QueryWithHealthCheck(ctx, `
+        SELECT id, user_id, username, action, actor, details, created_at
+        FROM user_audit_log
+        WHERE user_id = $1
+        ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.UserAuditEntry{}
+	for rows.Next() {
+		var e models.UserAuditEntry
+		var detailsJSON []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.Action, &e.Actor, &detailsJSON, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := json.Unmarshal(detailsJSON, &e.Details); err != nil {
+			return nil, fmt.Errorf("failed to parse audit details: %w", err)
+		}
+		if len(e.Details) == 0 {
+			e.Details = nil
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
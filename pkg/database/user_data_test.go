@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestUserDeletionLifecycle(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	user, err := dm.CreateUser(ctx, "deleted-user", "password")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	owned := setupTestStation(t, dm)
+	sensor := setupTestSensor(t, dm, owned.ID, models.SensorTypeTemperature, "outdoor")
+	other := setupTestStation(t, dm)
+	if err := dm.SetStationOwner(ctx, owned.ID, &user.ID); err != nil {
+		t.Fatalf("Failed to set owner: %v", err)
+	}
+
+	ids, err := dm.GetOwnedStationIDs(ctx, user.ID)
+	if err != nil || len(ids) != 1 || ids[0] != owned.ID {
+		t.Fatalf("Expected the owned station, got %v, %v", ids, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	scheduled, err := dm.ScheduleUserDeletion(ctx, user.ID, now.Add(time.Hour))
+	if err != nil || !scheduled.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Failed to schedule deletion: %s, %v", scheduled, err)
+	}
+	// A second request keeps the first date
+	if again, _ := dm.ScheduleUserDeletion(ctx, user.ID, now.Add(48*time.Hour)); !again.Equal(scheduled) {
+		t.Errorf("Expected %s to be kept, got %s", scheduled, again)
+	}
+
+	if due, err := dm.GetUsersDueForDeletion(ctx, now); err != nil || len(due) != 0 {
+		t.Errorf("Expected no user due yet, got %v, %v", due, err)
+	}
+	if err := dm.CancelUserDeletion(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to cancel deletion: %v", err)
+	}
+	if err := dm.CancelUserDeletion(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without a request, got %v", err)
+	}
+
+	if _, err := dm.ScheduleUserDeletion(ctx, user.ID, now.Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to schedule deletion: %v", err)
+	}
+	due, err := dm.GetUsersDueForDeletion(ctx, now)
+	if err != nil || len(due) != 1 || due[0].ID != user.ID || due[0].DeletionScheduledAt == nil {
+		t.Fatalf("Expected the user to be due, got %v, %v", due, err)
+	}
+
+	deletion, err := dm.DeleteUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if deletion.Stations != 1 || len(deletion.SensorIDs) != 1 || deletion.SensorIDs[0] != sensor.ID {
+		t.Errorf("Unexpected deletion %+v", deletion)
+	}
+	if _, err := dm.GetUser(ctx, user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the user to be deleted, got %v", err)
+	}
+	if _, err := dm.LoadStation(owned.ID); err == nil {
+		t.Error("Expected the owned station to be deleted")
+	}
+	if _, err := dm.LoadStation(other.ID); err != nil {
+		t.Errorf("Expected other stations to be kept, got %v", err)
+	}
+	if deleted, err := dm.GetDeletedSensorIDs(ctx); err != nil || !deleted[sensor.ID] {
+		t.Errorf("Expected the sensor to be recorded as deleted, got %v, %v", deleted, err)
+	}
+	if err := dm.SetStationOwner(ctx, owned.ID, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for the deleted station, got %v", err)
+	}
+}
+
+func TestUserAuditLog(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	user, err := dm.CreateUser(ctx, "audited-user", "password")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	entries := []models.UserAuditEntry{
+		{UserID: user.ID, Username: user.Username, Action: models.UserAuditExport, Actor: "admin"},
+		{UserID: user.ID, Username: user.Username, Action: models.UserAuditDeleted, Actor: "system", Details: map[string]interface{}{"stations": 2}},
+	}
+	for i := range entries {
+		if err := dm.AddUserAuditEntry(ctx, &entries[i]); err != nil {
+			t.Fatalf("Failed to add audit entry: %v", err)
+		}
+	}
+	if _, err := dm.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	// The log outlives the user
+	log, err := dm.GetUserAuditLog(ctx, user.ID)
+	if err != nil || len(log) != 2 {
+		t.Fatalf("Expected 2 entries, got %v, %v", log, err)
+	}
+	if log[0].Action != models.UserAuditExport || log[0].Details != nil {
+		t.Errorf("Unexpected first entry %+v", log[0])
+	}
+	if log[1].Username != "audited-user" || log[1].Details["stations"] != float64(2) {
+		t.Errorf("Unexpected second entry %+v", log[1])
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,22 +21,30 @@ func hashPassword(password string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// CreateUser creates a new user with hashed password
-func (dm *DatabaseManager) CreateUser(ctx context.Context, username, password string) (*models.User, error) {
-	if username == "" || password == "" {
-		return nil, errors.New("username and password must not be empty")
-	}
-
+// encodePassword returns the stored hash of a password in the v2 format
+func encodePassword(password string) (string, error) {
 	// Pre-hash password with SHA-256 to handle passwords longer than 72 bytes
 	preHashedPassword := hashPassword(password)
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(preHashedPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
 
 	// Mark as new format with prefix
-	finalHash := "v2:" + string(hashedPassword)
+	return "v2:" + string(hashedPassword), nil
+}
+
+// CreateUser creates a new user with hashed password
+func (dm *DatabaseManager) CreateUser(ctx context.Context, username, password string) (*models.User, error) {
+	if username == "" || password == "" {
+		return nil, errors.New("username and password must not be empty")
+	}
+
+	finalHash, err := encodePassword(password)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
         INSERT INTO users (username, password_hash)
@@ -56,7 +66,7 @@ func (dm *DatabaseManager) CreateUser(ctx context.Context, username, password st
 // ValidateUser checks username and password
 func (dm *DatabaseManager) ValidateUser(ctx context.Context, username, password string) (*models.User, error) {
 	query := `
-        SELECT id, username, password_hash, created_at
+        SELECT id, username, COALESCE(email, ''), locale, totp_enabled, password_hash, created_at
         FROM users
         WHERE username = $1
     `
@@ -65,7 +75,7 @@ func (dm *DatabaseManager) ValidateUser(ctx context.Context, username, password
 	var passwordHash string
 
 	err := dm.QueryRowWithHealthCheck(ctx, query, username).
-		Scan(&user.ID, &user.Username, &passwordHash, &user.CreatedAt)
+		Scan(&user.ID, &user.Username, &user.Email, &user.Locale, &user.TwoFactorEnabled, &passwordHash, &user.CreatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -105,14 +115,11 @@ func (dm *DatabaseManager) ValidateUser(ctx context.Context, username, password
 
 // migrateUserPassword updates a user's password to the new format
 func (dm *DatabaseManager) migrateUserPassword(ctx context.Context, userID interface{}, password string) error {
-	preHashedPassword := hashPassword(password)
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(preHashedPassword), bcrypt.DefaultCost)
+	finalHash, err := encodePassword(password)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return err
 	}
 
-	finalHash := "v2:" + string(hashedPassword)
-
 	query := `UPDATE users SET password_hash = $1 WHERE id = $2`
 	_, err = dm.ExecWithHealthCheck(ctx, query, finalHash, userID)
 	if err != nil {
@@ -121,3 +128,155 @@ func (dm *DatabaseManager) migrateUserPassword(ctx context.Context, userID inter
 
 	return nil
 }
+
+// GetUser retrieves a user by ID
+func (dm *DatabaseManager) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	query := `SELECT id, username, COALESCE(email, ''), locale, totp_enabled, created_at, deletion_scheduled_at FROM users WHERE id = $1`
+
+	var user models.User
+	err := dm.QueryRowWithHealthCheck(ctx, query, id).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Locale, &user.TwoFactorEnabled, &user.CreatedAt, &user.DeletionScheduledAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	return &user, nil
+}
+
+// GetUsers retrieves all users ordered by username
+func (dm *DatabaseManager) GetUsers(ctx context.Context) ([]models.User, error) {
+	query := `SELECT id, username, COALESCE(email, ''), locale, totp_enabled, created_at, deletion_scheduled_at FROM users ORDER BY username`
+
+	rows, err := dm.QueryWithHealthCheck(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Locale, &user.TwoFactorEnabled, &user.CreatedAt, &user.DeletionScheduledAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserEmail sets the email address used for notifications; an empty
+// address removes it
+func (dm *DatabaseManager) SetUserEmail(ctx context.Context, username, email string) error {
+	query := `UPDATE users SET email = NULLIF($1, '') WHERE username = $2`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, email, username)
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+	return nil
+}
+
+// SetUserLocale sets the preferred language of a user; an empty locale
+// negotiates it per request
+func (dm *DatabaseManager) SetUserLocale(ctx context.Context, userID uuid.UUID, locale string) error {
+	query := `UPDATE users SET locale = $1 WHERE id = $2`
+
+	result, err := dm.ExecWithHealthCheck(ctx, query, locale, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update locale: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+	return nil
+}
+
+// SetUserPassword replaces the password of a user
+func (dm *DatabaseManager) SetUserPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	if password == "" {
+		return errors.New("password must not be empty")
+	}
+	return dm.migrateUserPassword(ctx, userID, password)
+}
+
+// CreatePasswordResetToken issues a single-use token that sets a new
+// password for the user within ttl
+func (dm *DatabaseManager) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	query := `
+        INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+        VALUES ($1, $2, $3)
+    `
+	if _, err := dm.ExecWithHealthCheck(ctx, query, userID, hashToken(token), expiresAt); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create reset token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// ResetPassword sets a new password with a reset token and revokes all
+// sessions of the user. Unknown, used and expired tokens are reported as
+// not found.
+func (dm *DatabaseManager) ResetPassword(ctx context.Context, token, password string) (*models.User, error) {
+	if password == "" {
+		return nil, errors.New("password must not be empty")
+	}
+	finalHash, err := encodePassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := dm.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+        UPDATE password_reset_tokens SET used_at = NOW()
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING user_id`, hashToken(token)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("reset token %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to use reset token: %w", err)
+	}
+
+	var user models.User
+	err = tx.QueryRowContext(ctx, `
+        UPDATE users SET password_hash = $1 WHERE id = $2
+        RETURNING id, username, COALESCE(email, ''), totp_enabled, created_at`, finalHash, userID).
+		Scan(&user.ID, &user.Username, &user.Email, &user.TwoFactorEnabled, &user.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+	return &user, nil
+}
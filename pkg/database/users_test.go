@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"strings"
 	"testing"
 
@@ -662,3 +663,32 @@ func TestCreateUser_ConcurrentCreation(t *testing.T) {
 		t.Errorf("Expected exactly 1 failed creation, got %d", errorCount)
 	}
 }
+
+func TestSetUserLocale(t *testing.T) {
+	dm := setupTestDatabaseManager(t)
+	if dm == nil {
+		t.Skip("Skipping test that requires real database connection")
+	}
+	defer dm.Close()
+
+	ctx := context.Background()
+	user, err := dm.CreateUser(ctx, "locale_"+generateRandomString(8), "Password123!")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if err := dm.SetUserLocale(ctx, user.ID, "de"); err != nil {
+		t.Fatalf("Failed to set locale: %v", err)
+	}
+	loaded, err := dm.GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if loaded.Locale != "de" {
+		t.Errorf("Expected locale de, got %q", loaded.Locale)
+	}
+
+	if err := dm.SetUserLocale(ctx, uuid.New(), "de"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown user, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// DNS record types used by the responder
+const (
+	typeA   uint16 = 1
+	typePTR uint16 = 12
+	typeTXT uint16 = 16
+	typeSRV uint16 = 33
+	typeANY uint16 = 255
+)
+
+const (
+	classIN uint16 = 1
+	// classCacheFlush marks records this host is authoritative for
+	classCacheFlush uint16 = 0x8000
+	// flagResponse marks an authoritative response
+	flagResponse uint16 = 0x8400
+)
+
+var errMalformed = errors.New("malformed DNS message")
+
+// question is a single question of a DNS query
+type question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// record is a resource record of a DNS response
+type record struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	Data  []byte
+}
+
+// parseQuery returns the questions of a DNS query. Responses are ignored.
+func parseQuery(msg []byte) ([]question, error) {
+	if len(msg) < 12 {
+		return nil, errMalformed
+	}
+	flags := binary.BigEndian.Uint16(msg[2:4])
+	if flags&0x8000 != 0 {
+		return nil, nil
+	}
+	count := int(binary.BigEndian.Uint16(msg[4:6]))
+
+	questions := make([]question, 0, count)
+	offset := 12
+	for i := 0; i < count; i++ {
+		name, next, err := readName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(msg) {
+			return nil, errMalformed
+		}
+		questions = append(questions, question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[next : next+2]),
+			Class: binary.BigEndian.Uint16(msg[next+2 : next+4]),
+		})
+		offset = next + 4
+	}
+	return questions, nil
+}
+
+// readName reads a possibly compressed name at offset and returns it in
+// lower case with a trailing dot and the offset after it
+func readName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errMalformed
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")) + ".", end, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(msg) || jumps > 10 {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:offset+2]) & 0x3FFF)
+			jumps++
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// appendName appends a name in wire format without compression
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// buildResponse encodes an authoritative response with answers and
+// additional records
+func buildResponse(answers, additional []record) []byte {
+	msg := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(msg[2:4], flagResponse)
+	binary.BigEndian.PutUint16(msg[6:8], uint16(len(answers)))
+	binary.BigEndian.PutUint16(msg[10:12], uint16(len(additional)))
+
+	for _, r := range append(append([]record{}, answers...), additional...) {
+		msg = appendName(msg, r.Name)
+		msg = binary.BigEndian.AppendUint16(msg, r.Type)
+		msg = binary.BigEndian.AppendUint16(msg, r.Class)
+		msg = binary.BigEndian.AppendUint32(msg, r.TTL)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(r.Data)))
+		msg = append(msg, r.Data...)
+	}
+	return msg
+}
+
+// ptrData encodes the target of a PTR record
+func ptrData(target string) []byte {
+	return appendName(nil, target)
+}
+
+// srvData encodes the target host and port of a SRV record
+func srvData(host string, port int) []byte {
+	b := make([]byte, 6, 6+len(host)+2)
+	binary.BigEndian.PutUint16(b[4:6], uint16(port))
+	return appendName(b, host)
+}
+
+// txtData encodes key=value strings of a TXT record
+func txtData(entries []string) []byte {
+	if len(entries) == 0 {
+		return []byte{0}
+	}
+	var b []byte
+	for _, e := range entries {
+		if len(e) > 255 {
+			e = e[:255]
+		}
+		b = append(b, byte(len(e)))
+		b = append(b, e...)
+	}
+	return b
+}
+
+// aData encodes an IPv4 address
+func aData(ip net.IP) []byte {
+	return []byte(ip.To4())
+}
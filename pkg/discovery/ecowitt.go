@@ -0,0 +1,159 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+)
+
+// EcowittPort is the UDP port the Ecowitt apps (WS View Plus, Ecowitt) send
+// their gateway discovery broadcast to
+const EcowittPort = 46000
+
+// ecowittCmdBroadcast is the command of the discovery broadcast and of the
+// answers of the gateways
+const ecowittCmdBroadcast = 0x12
+
+// ecowittHeader starts every packet of the Ecowitt LAN protocol
+var ecowittHeader = []byte{0xFF, 0xFF}
+
+// EcowittResponder answers the gateway discovery of the Ecowitt apps like a
+// gateway, so the server is listed with its address and upload port next
+// to the gateways on the LAN. The apps can't configure the server; they
+// only show where to point the custom upload of a console at.
+type EcowittResponder struct {
+	service Service
+
+	conn *net.UDPConn
+	wg   sync.WaitGroup
+}
+
+// NewEcowittResponder creates a responder for a service and fills in the
+// defaults
+func NewEcowittResponder(service Service) (*EcowittResponder, error) {
+	service, err := service.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &EcowittResponder{service: service}, nil
+}
+
+// Start listens for discovery broadcasts and answers them until Stop is
+// called
+func (r *EcowittResponder) Start() error {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: EcowittPort})
+	if err != nil {
+		return fmt.Errorf("failed to listen for Ecowitt discovery: %w", err)
+	}
+	r.conn = conn
+
+	r.wg.Add(1)
+	go r.serve()
+
+	log.Printf("✓ Answering Ecowitt gateway discovery on port %d", EcowittPort)
+	return nil
+}
+
+// Stop stops answering broadcasts
+func (r *EcowittResponder) Stop() {
+	if r.conn == nil {
+		return
+	}
+	r.conn.Close()
+	r.wg.Wait()
+}
+
+// serve reads broadcasts until the connection is closed
+func (r *EcowittResponder) serve() {
+	defer r.wg.Done()
+
+	buf := make([]byte, 512)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("⚠ Ecowitt discovery read failed: %v", err)
+			continue
+		}
+		if !isEcowittBroadcast(buf[:n]) {
+			continue
+		}
+
+		ip := r.service.replyIP(from.IP)
+		reply := ecowittBroadcastResponse(interfaceMAC(ip), ip, r.service.Port, r.service.Instance)
+		if _, err := r.conn.WriteToUDP(reply, from); err != nil {
+			log.Printf("⚠ Ecowitt discovery response failed: %v", err)
+		}
+	}
+}
+
+// isEcowittBroadcast reports whether a packet is a discovery broadcast:
+// header, command, a size of 3 and the checksum. Answers of gateways carry
+// a payload and are ignored.
+func isEcowittBroadcast(msg []byte) bool {
+	return len(msg) == 5 && msg[0] == ecowittHeader[0] && msg[1] == ecowittHeader[1] &&
+		msg[2] == ecowittCmdBroadcast && msg[3] == 3 && msg[4] == ecowittChecksum(msg[2:4])
+}
+
+// ecowittBroadcastResponse encodes the answer of a gateway to a discovery
+// broadcast: MAC, IPv4 address, port and name, with a two-byte size
+// counting command, size, payload and checksum
+func ecowittBroadcastResponse(mac net.HardwareAddr, ip net.IP, port int, name string) []byte {
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	payload := make([]byte, 0, 13+len(name))
+	payload = append(payload, padMAC(mac)...)
+	payload = append(payload, ip.To4()...)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(port))
+	payload = append(payload, byte(len(name)))
+	payload = append(payload, name...)
+
+	msg := append([]byte{}, ecowittHeader...)
+	msg = append(msg, ecowittCmdBroadcast)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(1+2+len(payload)+1))
+	msg = append(msg, payload...)
+	return append(msg, ecowittChecksum(msg[2:]))
+}
+
+// ecowittChecksum is the sum of the bytes after the header
+func ecowittChecksum(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum += b
+	}
+	return sum
+}
+
+// padMAC returns a 6 byte MAC address, zeros if unknown
+func padMAC(mac net.HardwareAddr) []byte {
+	padded := make([]byte, 6)
+	copy(padded, mac)
+	return padded
+}
+
+// interfaceMAC returns the hardware address of the interface with an IP,
+// nil if there is none
+func interfaceMAC(ip net.IP) net.HardwareAddr {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.HardwareAddr
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func TestIsEcowittBroadcast(t *testing.T) {
+	testCases := []struct {
+		name     string
+		msg      []byte
+		expected bool
+	}{
+		{name: "Broadcast", msg: []byte{0xFF, 0xFF, 0x12, 0x03, 0x15}, expected: true},
+		{name: "Wrong checksum", msg: []byte{0xFF, 0xFF, 0x12, 0x03, 0x16}, expected: false},
+		{name: "Other command", msg: []byte{0xFF, 0xFF, 0x50, 0x03, 0x53}, expected: false},
+		{name: "Gateway answer", msg: ecowittBroadcastResponse(nil, net.IPv4(192, 168, 1, 10), 45000, "GW1000"), expected: false},
+		{name: "Truncated", msg: []byte{0xFF, 0xFF, 0x12}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isEcowittBroadcast(tc.msg); got != tc.expected {
+				t.Errorf("isEcowittBroadcast() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEcowittBroadcastResponse(t *testing.T) {
+	mac := net.HardwareAddr{0x48, 0x3F, 0xDA, 0x01, 0x02, 0x03}
+	msg := ecowittBroadcastResponse(mac, net.IPv4(192, 168, 1, 10), 8059, "WeatherMaestro")
+
+	if msg[0] != 0xFF || msg[1] != 0xFF || msg[2] != ecowittCmdBroadcast {
+		t.Fatalf("Unexpected header % X", msg[:3])
+	}
+	// The size counts everything after the header
+	if size := int(binary.BigEndian.Uint16(msg[3:5])); size != len(msg)-2 {
+		t.Errorf("size = %d, want %d", size, len(msg)-2)
+	}
+	data := msg[5 : len(msg)-1]
+	if got := net.HardwareAddr(data[0:6]); got.String() != mac.String() {
+		t.Errorf("MAC = %s, want %s", got, mac)
+	}
+	if got := net.IP(data[6:10]); !got.Equal(net.IPv4(192, 168, 1, 10)) {
+		t.Errorf("IP = %s", got)
+	}
+	if port := binary.BigEndian.Uint16(data[10:12]); port != 8059 {
+		t.Errorf("port = %d, want 8059", port)
+	}
+	if name := string(data[13 : 13+int(data[12])]); name != "WeatherMaestro" {
+		t.Errorf("name = %q", name)
+	}
+	if msg[len(msg)-1] != ecowittChecksum(msg[2:len(msg)-1]) {
+		t.Error("Invalid checksum")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/sguter90/weathermaestro/pkg/discovery
+
+go 1.25
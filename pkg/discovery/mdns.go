@@ -0,0 +1,284 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServiceType is the DNS-SD service type WeatherMaestro is advertised as
+const ServiceType = "_weathermaestro._tcp"
+
+const (
+	// servicesName lists all service types of a host (DNS-SD browsing)
+	servicesName = "_services._dns-sd._udp.local."
+
+	// hostTTL is the TTL of records bound to the host (A, SRV)
+	hostTTL = 120
+	// serviceTTL is the TTL of the other records (PTR, TXT)
+	serviceTTL = 4500
+)
+
+// mdnsAddr is the multicast group and port of mDNS
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service describes the advertised server
+type Service struct {
+	// Instance is the human readable name, e.g. "WeatherMaestro"
+	Instance string
+	// Host is the host name without ".local"; defaults to the system host name
+	Host string
+	// Port is the port of the ingest endpoints
+	Port int
+	// TXT holds key=value pairs, e.g. the upload paths
+	TXT []string
+	// IPs are the advertised IPv4 addresses; defaults to all up interfaces
+	IPs []net.IP
+}
+
+// Responder answers mDNS queries for a service on the local network
+type Responder struct {
+	service  Service
+	svcName  string
+	instName string
+	hostName string
+
+	conn *net.UDPConn
+	wg   sync.WaitGroup
+}
+
+// withDefaults checks the port and fills in the instance name, host name
+// and addresses
+func (s Service) withDefaults() (Service, error) {
+	if s.Port <= 0 || s.Port > 65535 {
+		return s, fmt.Errorf("invalid port %d", s.Port)
+	}
+	s, err := s.withName()
+	if err != nil {
+		return s, err
+	}
+	if len(s.IPs) == 0 {
+		ips, err := localIPv4s()
+		if err != nil {
+			return s, err
+		}
+		s.IPs = ips
+	}
+	if len(s.IPs) == 0 {
+		return s, errors.New("no IPv4 address to advertise")
+	}
+	return s, nil
+}
+
+// withName fills in the instance name and the host name without domain
+func (s Service) withName() (Service, error) {
+	if s.Instance == "" {
+		s.Instance = "WeatherMaestro"
+	}
+	if s.Host == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return s, fmt.Errorf("failed to get host name: %w", err)
+		}
+		s.Host = host
+	}
+	s.Host = strings.TrimSuffix(strings.Split(s.Host, ".")[0], ".")
+	return s, nil
+}
+
+// NewResponder creates a responder for a service and fills in the defaults
+func NewResponder(service Service) (*Responder, error) {
+	service, err := service.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	svcName := ServiceType + ".local."
+	return &Responder{
+		service:  service,
+		svcName:  svcName,
+		instName: strings.ReplaceAll(service.Instance, ".", "-") + "." + svcName,
+		hostName: service.Host + ".local.",
+	}, nil
+}
+
+// localIPv4s returns the IPv4 addresses of all up, non-loopback interfaces
+func localIPv4s() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+	var ips []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				ips = append(ips, ipNet.IP.To4())
+			}
+		}
+	}
+	return ips, nil
+}
+
+// replyIP returns the address of the service a client reaches it at: the
+// local address of the route to the client if the service has it, else the
+// first address of the service
+func (s Service) replyIP(client net.IP) net.IP {
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: client, Port: 9})
+	if err == nil {
+		local := conn.LocalAddr().(*net.UDPAddr).IP
+		conn.Close()
+		for _, ip := range s.IPs {
+			if ip.Equal(local) {
+				return ip.To4()
+			}
+		}
+	}
+	return s.IPs[0].To4()
+}
+
+// Start joins the mDNS group, announces the service and answers queries
+// until Stop is called
+func (r *Responder) Start() error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group: %w", err)
+	}
+	r.conn = conn
+
+	r.wg.Add(1)
+	go r.serve()
+
+	// Announce twice as recommended by RFC 6762
+	r.announce(false)
+	go func() {
+		time.Sleep(time.Second)
+		r.announce(false)
+	}()
+
+	log.Printf("✓ Advertising %s on port %d via mDNS", r.instName, r.service.Port)
+	return nil
+}
+
+// Stop sends a goodbye and stops answering queries
+func (r *Responder) Stop() {
+	if r.conn == nil {
+		return
+	}
+	r.announce(true)
+	r.conn.Close()
+	r.wg.Wait()
+}
+
+// serve reads queries until the connection is closed
+func (r *Responder) serve() {
+	defer r.wg.Done()
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("⚠ mDNS read failed: %v", err)
+			continue
+		}
+
+		questions, err := parseQuery(buf[:n])
+		if err != nil || len(questions) == 0 {
+			continue
+		}
+		answers, additional := r.answer(questions)
+		if len(answers) == 0 {
+			continue
+		}
+		if _, err := r.conn.WriteToUDP(buildResponse(answers, additional), mdnsAddr); err != nil {
+			log.Printf("⚠ mDNS response failed: %v", err)
+		}
+	}
+}
+
+// announce sends all records unsolicited; a goodbye sends them with TTL 0
+func (r *Responder) announce(goodbye bool) {
+	records := append([]record{r.ptrRecord()}, r.instanceRecords()...)
+	records = append(records, r.addressRecords()...)
+	if goodbye {
+		for i := range records {
+			records[i].TTL = 0
+		}
+	}
+	if _, err := r.conn.WriteToUDP(buildResponse(records, nil), mdnsAddr); err != nil {
+		log.Printf("⚠ mDNS announcement failed: %v", err)
+	}
+}
+
+// answer returns the answers and additional records for a query
+func (r *Responder) answer(questions []question) ([]record, []record) {
+	var answers, additional []record
+	matches := func(q question, t uint16) bool {
+		return q.Type == t || q.Type == typeANY
+	}
+
+	for _, q := range questions {
+		switch q.Name {
+		case servicesName:
+			if matches(q, typePTR) {
+				answers = append(answers, record{Name: servicesName, Type: typePTR, Class: classIN, TTL: serviceTTL, Data: ptrData(r.svcName)})
+			}
+		case strings.ToLower(r.svcName):
+			if matches(q, typePTR) {
+				answers = append(answers, r.ptrRecord())
+				additional = append(additional, r.instanceRecords()...)
+				additional = append(additional, r.addressRecords()...)
+			}
+		case strings.ToLower(r.instName):
+			for _, rec := range r.instanceRecords() {
+				if matches(q, rec.Type) {
+					answers = append(answers, rec)
+				}
+			}
+			if len(answers) > 0 {
+				additional = append(additional, r.addressRecords()...)
+			}
+		case strings.ToLower(r.hostName):
+			if matches(q, typeA) {
+				answers = append(answers, r.addressRecords()...)
+			}
+		}
+	}
+	return answers, additional
+}
+
+func (r *Responder) ptrRecord() record {
+	return record{Name: r.svcName, Type: typePTR, Class: classIN, TTL: serviceTTL, Data: ptrData(r.instName)}
+}
+
+func (r *Responder) instanceRecords() []record {
+	return []record{
+		{Name: r.instName, Type: typeSRV, Class: classIN | classCacheFlush, TTL: hostTTL, Data: srvData(r.hostName, r.service.Port)},
+		{Name: r.instName, Type: typeTXT, Class: classIN | classCacheFlush, TTL: serviceTTL, Data: txtData(r.service.TXT)},
+	}
+}
+
+func (r *Responder) addressRecords() []record {
+	records := make([]record, 0, len(r.service.IPs))
+	for _, ip := range r.service.IPs {
+		if ip.To4() == nil {
+			continue
+		}
+		records = append(records, record{Name: r.hostName, Type: typeA, Class: classIN | classCacheFlush, TTL: hostTTL, Data: aData(ip)})
+	}
+	return records
+}
@@ -0,0 +1,139 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildQuery encodes a query with one question per name
+func buildQuery(qtype uint16, names ...string) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:6], uint16(len(names)))
+	for _, name := range names {
+		msg = appendName(msg, name)
+		msg = binary.BigEndian.AppendUint16(msg, qtype)
+		msg = binary.BigEndian.AppendUint16(msg, classIN)
+	}
+	return msg
+}
+
+func testResponder(t *testing.T) *Responder {
+	t.Helper()
+	r, err := NewResponder(Service{
+		Host: "weather",
+		Port: 8059,
+		TXT:  []string{"path=/api/v1/data/report"},
+		IPs:  []net.IP{net.IPv4(192, 168, 1, 10)},
+	})
+	if err != nil {
+		t.Fatalf("NewResponder() error = %v", err)
+	}
+	return r
+}
+
+func TestParseQuery_Compressed(t *testing.T) {
+	msg := buildQuery(typePTR, "_weathermaestro._tcp.local.")
+	// Second question pointing to the first name
+	binary.BigEndian.PutUint16(msg[4:6], 2)
+	msg = append(msg, 0xC0, 12)
+	msg = binary.BigEndian.AppendUint16(msg, typeSRV)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+
+	questions, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery() error = %v", err)
+	}
+	if len(questions) != 2 {
+		t.Fatalf("Expected 2 questions, got %d", len(questions))
+	}
+	for _, q := range questions {
+		if q.Name != "_weathermaestro._tcp.local." {
+			t.Errorf("Unexpected name %q", q.Name)
+		}
+	}
+	if questions[1].Type != typeSRV {
+		t.Errorf("Expected SRV, got %d", questions[1].Type)
+	}
+}
+
+func TestParseQuery_Malformed(t *testing.T) {
+	msg := buildQuery(typePTR, "_weathermaestro._tcp.local.")
+	if _, err := parseQuery(msg[:len(msg)-3]); err == nil {
+		t.Error("Expected error for truncated query")
+	}
+
+	// Pointer loop
+	loop := make([]byte, 12)
+	binary.BigEndian.PutUint16(loop[4:6], 1)
+	loop = append(loop, 0xC0, 12)
+	if _, err := parseQuery(loop); err == nil {
+		t.Error("Expected error for pointer loop")
+	}
+}
+
+func TestResponder_AnswerService(t *testing.T) {
+	r := testResponder(t)
+
+	questions, _ := parseQuery(buildQuery(typePTR, "_WeatherMaestro._tcp.local."))
+	answers, additional := r.answer(questions)
+
+	if len(answers) != 1 || answers[0].Type != typePTR {
+		t.Fatalf("Expected one PTR answer, got %+v", answers)
+	}
+	target, _, err := readName(answers[0].Data, 0)
+	if err != nil || target != "weathermaestro._weathermaestro._tcp.local." {
+		t.Errorf("PTR target = %q, %v", target, err)
+	}
+
+	types := make(map[uint16]record)
+	for _, rec := range additional {
+		types[rec.Type] = rec
+	}
+	srv, ok := types[typeSRV]
+	if !ok || binary.BigEndian.Uint16(srv.Data[4:6]) != 8059 {
+		t.Errorf("Expected SRV with port 8059, got %+v", srv)
+	}
+	if a, ok := types[typeA]; !ok || !net.IP(a.Data).Equal(net.IPv4(192, 168, 1, 10)) {
+		t.Errorf("Expected A record, got %+v", a)
+	}
+	if txt, ok := types[typeTXT]; !ok || string(txt.Data[1:]) != "path=/api/v1/data/report" {
+		t.Errorf("Expected TXT record, got %+v", txt)
+	}
+}
+
+func TestResponder_AnswerHostAndUnknown(t *testing.T) {
+	r := testResponder(t)
+
+	questions, _ := parseQuery(buildQuery(typeA, "weather.local."))
+	if answers, _ := r.answer(questions); len(answers) != 1 {
+		t.Errorf("Expected one A answer, got %d", len(answers))
+	}
+
+	questions, _ = parseQuery(buildQuery(typePTR, "_http._tcp.local."))
+	if answers, _ := r.answer(questions); len(answers) != 0 {
+		t.Errorf("Expected no answers for other services, got %d", len(answers))
+	}
+}
+
+func TestBuildResponse(t *testing.T) {
+	r := testResponder(t)
+	msg := buildResponse([]record{r.ptrRecord()}, r.addressRecords())
+
+	if binary.BigEndian.Uint16(msg[2:4]) != flagResponse {
+		t.Error("Expected response flags")
+	}
+	if binary.BigEndian.Uint16(msg[6:8]) != 1 || binary.BigEndian.Uint16(msg[10:12]) != 1 {
+		t.Error("Unexpected record counts")
+	}
+	// Responses are not answered
+	if questions, err := parseQuery(msg); err != nil || questions != nil {
+		t.Errorf("parseQuery() of response = %v, %v", questions, err)
+	}
+}
+
+func TestNewResponder_InvalidPort(t *testing.T) {
+	if _, err := NewResponder(Service{Port: 0, IPs: []net.IP{net.IPv4(10, 0, 0, 1)}}); err == nil {
+		t.Error("Expected error for port 0")
+	}
+}
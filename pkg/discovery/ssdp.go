@@ -0,0 +1,228 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// SSDPDeviceType is the UPnP device type WeatherMaestro is advertised as
+	SSDPDeviceType = "urn:weathermaestro:device:WeatherServer:1"
+
+	// DescriptionPath is the path of the UPnP device description the SSDP
+	// answers point to
+	DescriptionPath = "/upnp/description.xml"
+
+	// ssdpRootDevice is the search target of all UPnP root devices
+	ssdpRootDevice = "upnp:rootdevice"
+	// ssdpAll is the search target of all devices and services
+	ssdpAll = "ssdp:all"
+
+	// ssdpMaxAge is how long clients may cache an advertisement, in seconds
+	ssdpMaxAge = 1800
+)
+
+// ssdpAddr is the multicast group and port of SSDP
+var ssdpAddr = &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: 1900}
+
+// SSDPResponder answers SSDP searches for the server on the local network,
+// as a UPnP root device whose description is served at DescriptionPath of
+// the service port
+type SSDPResponder struct {
+	service Service
+	udn     string
+
+	conn *net.UDPConn
+	wg   sync.WaitGroup
+}
+
+// NewSSDPResponder creates a responder for a service and fills in the
+// defaults
+func NewSSDPResponder(service Service) (*SSDPResponder, error) {
+	service, err := service.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return &SSDPResponder{service: service, udn: deviceUDN(service)}, nil
+}
+
+// deviceUDN returns the unique device name of a service, a name-based UUID
+// of its host and instance name that stays the same across restarts
+func deviceUDN(service Service) string {
+	hash := sha1.Sum([]byte(service.Host + "/" + service.Instance))
+	hash[6] = hash[6]&0x0F | 0x50
+	hash[8] = hash[8]&0x3F | 0x80
+	return fmt.Sprintf("uuid:%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
+
+// Start joins the SSDP group, announces the device and answers searches
+// until Stop is called
+func (r *SSDPResponder) Start() error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, ssdpAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join SSDP group: %w", err)
+	}
+	r.conn = conn
+
+	r.wg.Add(1)
+	go r.serve()
+
+	r.notify("ssdp:alive")
+	log.Printf("✓ Advertising %s on port %d via SSDP", r.udn, r.service.Port)
+	return nil
+}
+
+// Stop sends a byebye and stops answering searches
+func (r *SSDPResponder) Stop() {
+	if r.conn == nil {
+		return
+	}
+	r.notify("ssdp:byebye")
+	r.conn.Close()
+	r.wg.Wait()
+}
+
+// serve reads searches until the connection is closed
+func (r *SSDPResponder) serve() {
+	defer r.wg.Done()
+
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Printf("⚠ SSDP read failed: %v", err)
+			continue
+		}
+
+		for _, reply := range r.searchResponses(buf[:n], r.service.replyIP(from.IP)) {
+			if _, err := r.conn.WriteToUDP(reply, from); err != nil {
+				log.Printf("⚠ SSDP response failed: %v", err)
+			}
+		}
+	}
+}
+
+// notify sends an unsolicited alive or byebye for every target
+func (r *SSDPResponder) notify(nts string) {
+	location := r.location(r.service.IPs[0])
+	for _, target := range r.targets() {
+		var msg bytes.Buffer
+		msg.WriteString("NOTIFY * HTTP/1.1\r\n")
+		fmt.Fprintf(&msg, "HOST: %s\r\n", ssdpAddr)
+		fmt.Fprintf(&msg, "NT: %s\r\n", target)
+		fmt.Fprintf(&msg, "NTS: %s\r\n", nts)
+		fmt.Fprintf(&msg, "USN: %s\r\n", r.usn(target))
+		if nts == "ssdp:alive" {
+			fmt.Fprintf(&msg, "CACHE-CONTROL: max-age=%d\r\n", ssdpMaxAge)
+			fmt.Fprintf(&msg, "LOCATION: %s\r\n", location)
+			msg.WriteString("SERVER: WeatherMaestro UPnP/1.0\r\n")
+		}
+		msg.WriteString("\r\n")
+		if _, err := r.conn.WriteToUDP(msg.Bytes(), ssdpAddr); err != nil {
+			log.Printf("⚠ SSDP announcement failed: %v", err)
+		}
+	}
+}
+
+// searchResponses returns the answers to an M-SEARCH request, one per
+// matching target; other messages get none
+func (r *SSDPResponder) searchResponses(msg []byte, ip net.IP) [][]byte {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(msg)))
+	if err != nil || req.Method != "M-SEARCH" || strings.Trim(req.Header.Get("MAN"), `"`) != "ssdp:discover" {
+		return nil
+	}
+
+	st := req.Header.Get("ST")
+	var responses [][]byte
+	for _, target := range r.targets() {
+		if st != ssdpAll && !strings.EqualFold(st, target) {
+			continue
+		}
+		var resp bytes.Buffer
+		resp.WriteString("HTTP/1.1 200 OK\r\n")
+		fmt.Fprintf(&resp, "CACHE-CONTROL: max-age=%d\r\n", ssdpMaxAge)
+		resp.WriteString("EXT:\r\n")
+		fmt.Fprintf(&resp, "LOCATION: %s\r\n", r.location(ip))
+		resp.WriteString("SERVER: WeatherMaestro UPnP/1.0\r\n")
+		fmt.Fprintf(&resp, "ST: %s\r\n", target)
+		fmt.Fprintf(&resp, "USN: %s\r\n", r.usn(target))
+		resp.WriteString("\r\n")
+		responses = append(responses, resp.Bytes())
+	}
+	return responses
+}
+
+// targets are the search targets the device answers to
+func (r *SSDPResponder) targets() []string {
+	return []string{ssdpRootDevice, r.udn, SSDPDeviceType}
+}
+
+// usn returns the unique service name of a target
+func (r *SSDPResponder) usn(target string) string {
+	if target == r.udn {
+		return r.udn
+	}
+	return r.udn + "::" + target
+}
+
+// location returns the URL of the device description at an address
+func (r *SSDPResponder) location(ip net.IP) string {
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(ip.String(), fmt.Sprint(r.service.Port)), DescriptionPath)
+}
+
+// deviceDescription is the UPnP device description of the server
+type deviceDescription struct {
+	XMLName     xml.Name `xml:"urn:schemas-upnp-org:device-1-0 root"`
+	SpecVersion struct {
+		Major int `xml:"major"`
+		Minor int `xml:"minor"`
+	} `xml:"specVersion"`
+	Device struct {
+		DeviceType      string `xml:"deviceType"`
+		FriendlyName    string `xml:"friendlyName"`
+		Manufacturer    string `xml:"manufacturer"`
+		ModelName       string `xml:"modelName"`
+		UDN             string `xml:"UDN"`
+		PresentationURL string `xml:"presentationURL"`
+	} `xml:"device"`
+}
+
+// DescriptionHandler serves the UPnP device description of a service at
+// DescriptionPath. The presentation URL is the API base path.
+func DescriptionHandler(service Service) (http.Handler, error) {
+	service, err := service.withName()
+	if err != nil {
+		return nil, err
+	}
+
+	var desc deviceDescription
+	desc.SpecVersion.Major = 1
+	desc.Device.DeviceType = SSDPDeviceType
+	desc.Device.FriendlyName = service.Instance
+	desc.Device.Manufacturer = "WeatherMaestro"
+	desc.Device.ModelName = "WeatherMaestro"
+	desc.Device.UDN = deviceUDN(service)
+	desc.Device.PresentationURL = "/api/v1"
+	body, err := xml.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode device description: %w", err)
+	}
+	body = append([]byte(xml.Header), body...)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+		w.Write(body)
+	}), nil
+}
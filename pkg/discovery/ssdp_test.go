@@ -0,0 +1,96 @@
+package discovery
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testSSDPResponder(t *testing.T) *SSDPResponder {
+	t.Helper()
+	r, err := NewSSDPResponder(Service{
+		Host: "weather",
+		Port: 8059,
+		IPs:  []net.IP{net.IPv4(192, 168, 1, 10)},
+	})
+	if err != nil {
+		t.Fatalf("NewSSDPResponder() error = %v", err)
+	}
+	return r
+}
+
+func search(st string) []byte {
+	return []byte("M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nMX: 2\r\nST: " + st + "\r\n\r\n")
+}
+
+func TestSSDPResponder_SearchResponses(t *testing.T) {
+	r := testSSDPResponder(t)
+	ip := net.IPv4(192, 168, 1, 10)
+
+	testCases := []struct {
+		name     string
+		msg      []byte
+		expected int
+	}{
+		{name: "All", msg: search("ssdp:all"), expected: 3},
+		{name: "Root devices", msg: search("upnp:rootdevice"), expected: 1},
+		{name: "Device type", msg: search(SSDPDeviceType), expected: 1},
+		{name: "UDN", msg: search(r.udn), expected: 1},
+		{name: "Other device type", msg: search("urn:schemas-upnp-org:device:MediaRenderer:1"), expected: 0},
+		{name: "Notify", msg: []byte("NOTIFY * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nNT: upnp:rootdevice\r\nNTS: ssdp:alive\r\n\r\n"), expected: 0},
+		{name: "Garbage", msg: []byte{0xFF, 0xFF, 0x12, 0x03, 0x15}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := r.searchResponses(tc.msg, ip); len(got) != tc.expected {
+				t.Errorf("Expected %d responses, got %d", tc.expected, len(got))
+			}
+		})
+	}
+
+	resp := string(r.searchResponses(search(SSDPDeviceType), ip)[0])
+	for _, header := range []string{
+		"LOCATION: http://192.168.1.10:8059" + DescriptionPath + "\r\n",
+		"ST: " + SSDPDeviceType + "\r\n",
+		"USN: " + r.udn + "::" + SSDPDeviceType + "\r\n",
+	} {
+		if !strings.Contains(resp, header) {
+			t.Errorf("Expected %q in response:\n%s", header, resp)
+		}
+	}
+}
+
+func TestDeviceUDN_Stable(t *testing.T) {
+	a := deviceUDN(Service{Host: "weather", Instance: "WeatherMaestro"})
+	b := deviceUDN(Service{Host: "weather", Instance: "WeatherMaestro"})
+	c := deviceUDN(Service{Host: "other", Instance: "WeatherMaestro"})
+	if a != b || a == c {
+		t.Errorf("Expected UDN per host and instance, got %s, %s, %s", a, b, c)
+	}
+	if len(a) != len("uuid:")+36 || a[5+14] != '5' {
+		t.Errorf("Expected version 5 UUID, got %s", a)
+	}
+}
+
+func TestDescriptionHandler(t *testing.T) {
+	service := Service{Host: "weather", Instance: "Garden <Station>"}
+	handler, err := DescriptionHandler(service)
+	if err != nil {
+		t.Fatalf("DescriptionHandler() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", DescriptionPath, nil))
+	body := w.Body.String()
+	for _, part := range []string{
+		"<deviceType>" + SSDPDeviceType + "</deviceType>",
+		"<friendlyName>Garden &lt;Station&gt;</friendlyName>",
+		"<UDN>" + deviceUDN(service) + "</UDN>",
+	} {
+		if !strings.Contains(body, part) {
+			t.Errorf("Expected %q in description:\n%s", part, body)
+		}
+	}
+}
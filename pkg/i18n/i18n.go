@@ -0,0 +1,144 @@
+// Package i18n translates the human readable texts of API responses, widgets
+// and the static site. English is the fallback of every missing text.
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a supported language, e.g. "de"
+type Locale string
+
+// Supported locales
+const (
+	English Locale = "en"
+	German  Locale = "de"
+)
+
+// Default is the locale of texts without a translation
+const Default = English
+
+// Supported returns the supported locales in order
+func Supported() []Locale {
+	locales := make([]Locale, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Slice(locales, func(i, j int) bool { return locales[i] < locales[j] })
+	return locales
+}
+
+// Parse returns the supported locale of a language tag like "de-AT". ok is
+// false for unsupported languages.
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if base, _, found := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-"); found {
+		tag = base
+	}
+	if _, ok := catalogs[Locale(tag)]; !ok {
+		return "", false
+	}
+	return Locale(tag), true
+}
+
+// Negotiate picks the supported locale an Accept-Language header prefers.
+// ok is false when the header names no supported language.
+func Negotiate(acceptLanguage string) (Locale, bool) {
+	best, bestQ := Locale(""), 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		locale, ok := Parse(tag)
+		// Earlier entries win ties, like the header order suggests
+		if ok && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best, best != ""
+}
+
+// T returns the text of a key in a locale, formatted with args like
+// fmt.Sprintf. Missing texts fall back to English and then to the key.
+func T(locale Locale, key string, args ...interface{}) string {
+	text, ok := catalogs[locale][key]
+	if !ok {
+		if text, ok = catalogs[Default][key]; !ok {
+			text = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// SensorTypeName returns the display name of a sensor type. Unknown types,
+// e.g. custom ones, keep their name.
+func SensorTypeName(locale Locale, sensorType string) string {
+	key := "sensor_type." + sensorType
+	if name := T(locale, key); name != key {
+		return name
+	}
+	return sensorType
+}
+
+// CategoryName returns the display name of a sensor category
+func CategoryName(locale Locale, category string) string {
+	key := "category." + category
+	if name := T(locale, key); name != key {
+		return name
+	}
+	return category
+}
+
+// compassKeys are the 16 compass points clockwise from north
+var compassKeys = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// CompassPoints returns the names of the 16 compass points clockwise from
+// north
+func CompassPoints(locale Locale) []string {
+	points := make([]string, len(compassKeys))
+	for i, key := range compassKeys {
+		points[i] = T(locale, "compass."+key)
+	}
+	return points
+}
+
+// CompassPoint names a wind direction in degrees with one of 8 or 16
+// compass points
+func CompassPoint(locale Locale, degrees float64, points int) string {
+	if points != 8 {
+		points = 16
+	}
+	step := 360 / float64(points)
+	index := int(math.Round(math.Mod(math.Mod(degrees, 360)+360, 360)/step)) % points
+	return T(locale, "compass."+compassKeys[index*16/points])
+}
+
+// MonthName returns the name of a month
+func MonthName(locale Locale, month time.Month) string {
+	return T(locale, "month."+month.String())
+}
+
+// FormatNumber formats a value with at most the given number of decimals
+// and the decimal separator of a locale, e.g. "14,2" in German
+func FormatNumber(locale Locale, value float64, decimals int) string {
+	factor := math.Pow(10, float64(decimals))
+	text := strconv.FormatFloat(math.Round(value*factor)/factor, 'f', -1, 64)
+	return strings.Replace(text, ".", T(locale, "number.decimal_separator"), 1)
+}
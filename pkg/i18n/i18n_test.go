@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		tag      string
+		expected Locale
+		ok       bool
+	}{
+		{tag: "de", expected: German, ok: true},
+		{tag: "de-AT", expected: German, ok: true},
+		{tag: "EN_us", expected: English, ok: true},
+		{tag: "fr", ok: false},
+		{tag: "", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tag, func(t *testing.T) {
+			locale, ok := Parse(tc.tag)
+			if locale != tc.expected || ok != tc.ok {
+				t.Errorf("Parse(%q) = %q, %v, want %q, %v", tc.tag, locale, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected Locale
+		ok       bool
+	}{
+		{header: "de-AT,de;q=0.9,en;q=0.8", expected: German, ok: true},
+		{header: "fr-FR, en;q=0.5, de;q=0.7", expected: German, ok: true},
+		{header: "en, de", expected: English, ok: true},
+		{header: "fr, it;q=0.5", ok: false},
+		{header: "de;q=0", ok: false},
+		{header: "", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			locale, ok := Negotiate(tc.header)
+			if locale != tc.expected || ok != tc.ok {
+				t.Errorf("Negotiate(%q) = %q, %v, want %q, %v", tc.header, locale, ok, tc.expected, tc.ok)
+			}
+		})
+	}
+}
+
+func TestT_Fallback(t *testing.T) {
+	if got := T(German, "site.updated", "heute"); got != "aktualisiert heute" {
+		t.Errorf("Expected German text, got %q", got)
+	}
+	// Texts missing in German fall back to English, unknown keys to the key
+	if got := T(German, "sensor_type.PM25"); got != "PM2.5" {
+		t.Errorf("Expected English fallback, got %q", got)
+	}
+	if got := T(German, "unknown.key"); got != "unknown.key" {
+		t.Errorf("Expected key as fallback, got %q", got)
+	}
+	if got := SensorTypeName(German, "SoilMoisture"); got != "SoilMoisture" {
+		t.Errorf("Expected custom sensor type to keep its name, got %q", got)
+	}
+}
+
+func TestCompassPoint(t *testing.T) {
+	testCases := []struct {
+		degrees  float64
+		points   int
+		locale   Locale
+		expected string
+	}{
+		{degrees: 0, points: 16, locale: English, expected: "N"},
+		{degrees: 350, points: 16, locale: English, expected: "N"},
+		{degrees: 67.5, points: 16, locale: German, expected: "ONO"},
+		{degrees: 100, points: 8, locale: German, expected: "O"},
+		{degrees: 225, points: 8, locale: English, expected: "SW"},
+		{degrees: -45, points: 8, locale: English, expected: "NW"},
+		{degrees: 720, points: 16, locale: English, expected: "N"},
+	}
+
+	for _, tc := range testCases {
+		if got := CompassPoint(tc.locale, tc.degrees, tc.points); got != tc.expected {
+			t.Errorf("CompassPoint(%s, %g, %d) = %q, want %q", tc.locale, tc.degrees, tc.points, got, tc.expected)
+		}
+	}
+}
+
+func TestCatalogs_Complete(t *testing.T) {
+	// Every translated key must exist in English, the fallback of all texts
+	for locale, messages := range catalogs {
+		for key := range messages {
+			if _, ok := catalogs[English][key]; !ok {
+				t.Errorf("Key %s of locale %s is missing in English", key, locale)
+			}
+		}
+	}
+	if got := MonthName(German, time.March); got != "März" {
+		t.Errorf("Expected März, got %q", got)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	if got := FormatNumber(English, 14.16, 1); got != "14.2" {
+		t.Errorf("Expected 14.2, got %q", got)
+	}
+	if got := FormatNumber(German, 14.16, 1); got != "14,2" {
+		t.Errorf("Expected 14,2, got %q", got)
+	}
+	if got := FormatNumber(German, 3.0, 1); got != "3" {
+		t.Errorf("Expected 3, got %q", got)
+	}
+}
@@ -0,0 +1,346 @@
+package i18n
+
+// catalogs holds the texts of each locale by key. Keys are grouped by a
+// prefix: sensor types, categories, compass points, Beaufort numbers, UV
+// risk categories, sensor locations, months, number formats, the static
+// site, the widgets, the station summary, voice assistant answers, alert
+// notifications and storm severities.
+var catalogs = map[Locale]map[string]string{
+	English: {
+		"sensor_type.Temperature":        "Temperature",
+		"sensor_type.Humidity":           "Humidity",
+		"sensor_type.Pressure":           "Pressure",
+		"sensor_type.WindDirection":      "Wind direction",
+		"sensor_type.WindSpeed":          "Wind speed",
+		"sensor_type.WindSpeedMaxDaily":  "Max. wind speed today",
+		"sensor_type.WindGust":           "Wind gust",
+		"sensor_type.WindGustAngle":      "Gust direction",
+		"sensor_type.WindGustMaxDaily":   "Max. wind gust today",
+		"sensor_type.SolarRadiation":     "Solar radiation",
+		"sensor_type.UVIndex":            "UV index",
+		"sensor_type.RainfallRate":       "Rain rate",
+		"sensor_type.RainfallEvent":      "Rain event",
+		"sensor_type.RainfallHourly":     "Rain last hour",
+		"sensor_type.RainfallDaily":      "Rain today",
+		"sensor_type.RainfallWeekly":     "Rain this week",
+		"sensor_type.RainfallMonthly":    "Rain this month",
+		"sensor_type.RainfallYearly":     "Rain this year",
+		"sensor_type.RainfallTotal":      "Rain total",
+		"sensor_type.VPD":                "Vapor pressure deficit",
+		"sensor_type.Battery":            "Battery",
+		"sensor_type.PressureRelative":   "Relative pressure",
+		"sensor_type.PressureAbsolute":   "Absolute pressure",
+		"sensor_type.TemperatureOutdoor": "Outdoor temperature",
+		"sensor_type.HumidityOutdoor":    "Outdoor humidity",
+		"sensor_type.SignalStrength":     "Signal strength",
+		"sensor_type.CO2":                "CO₂",
+		"sensor_type.Noise":              "Noise",
+		"sensor_type.PM25":               "PM2.5",
+		"sensor_type.PM10":               "PM10",
+		"sensor_type.Visibility":         "Visibility",
+		"sensor_type.CloudCover":         "Cloud cover",
+		"sensor_type.Snowfall":           "Snowfall",
+		"sensor_type.PresentWeather":     "Present weather",
+		"sensor_type.Ventilation":        "Ventilation",
+		"sensor_type.MoldRisk":           "Mold risk",
+		"sensor_type.ClockSkew":          "Clock skew",
+
+		"category.Temperature": "Temperature",
+		"category.Humidity":    "Humidity",
+		"category.Pressure":    "Pressure",
+		"category.Wind":        "Wind",
+		"category.Rainfall":    "Rainfall",
+		"category.Solar":       "Solar",
+		"category.Vapor":       "Vapor",
+		"category.System":      "System",
+		"category.CO2":         "CO₂",
+		"category.Noise":       "Noise",
+		"category.AirQuality":  "Air quality",
+		"category.Custom":      "Custom",
+		"category.Observation": "Observation",
+
+		"compass.N":   "N",
+		"compass.NNE": "NNE",
+		"compass.NE":  "NE",
+		"compass.ENE": "ENE",
+		"compass.E":   "E",
+		"compass.ESE": "ESE",
+		"compass.SE":  "SE",
+		"compass.SSE": "SSE",
+		"compass.S":   "S",
+		"compass.SSW": "SSW",
+		"compass.SW":  "SW",
+		"compass.WSW": "WSW",
+		"compass.W":   "W",
+		"compass.WNW": "WNW",
+		"compass.NW":  "NW",
+		"compass.NNW": "NNW",
+
+		"beaufort.0":  "Calm",
+		"beaufort.1":  "Light air",
+		"beaufort.2":  "Light breeze",
+		"beaufort.3":  "Gentle breeze",
+		"beaufort.4":  "Moderate breeze",
+		"beaufort.5":  "Fresh breeze",
+		"beaufort.6":  "Strong breeze",
+		"beaufort.7":  "Near gale",
+		"beaufort.8":  "Gale",
+		"beaufort.9":  "Strong gale",
+		"beaufort.10": "Storm",
+		"beaufort.11": "Violent storm",
+		"beaufort.12": "Hurricane force",
+
+		"uv_risk.low":       "Low",
+		"uv_risk.moderate":  "Moderate",
+		"uv_risk.high":      "High",
+		"uv_risk.very_high": "Very high",
+		"uv_risk.extreme":   "Extreme",
+
+		"location.Indoor":  "Indoor",
+		"location.Outdoor": "Outdoor",
+
+		"month.January":   "January",
+		"month.February":  "February",
+		"month.March":     "March",
+		"month.April":     "April",
+		"month.May":       "May",
+		"month.June":      "June",
+		"month.July":      "July",
+		"month.August":    "August",
+		"month.September": "September",
+		"month.October":   "October",
+		"month.November":  "November",
+		"month.December":  "December",
+
+		"number.decimal_separator": ".",
+
+		"site.stations":      "Weather stations",
+		"site.no_stations":   "No stations registered yet.",
+		"site.updated":       "updated %s",
+		"site.current":       "Current conditions",
+		"site.sensor":        "Sensor",
+		"site.value":         "Value",
+		"site.time":          "Time",
+		"site.no_readings":   "No readings yet.",
+		"site.last_24_hours": "last 24 hours",
+		"site.last_7_days":   "last 7 days",
+		"site.noaa_reports":  "NOAA reports",
+		"site.generated":     "Generated %s by WeatherMaestro",
+
+		"widget.today":       "today",
+		"widget.no_wind":     "No wind data",
+		"widget.no_rain":     "No rain data",
+		"widget.updated":     "Updated %s",
+		"widget.no_readings": "No readings yet",
+
+		"summary.temperature":            "currently %s °C",
+		"summary.temperature_trend":      "currently %s °C and %s",
+		"summary.trend.rising":           "rising",
+		"summary.trend.falling":          "falling",
+		"summary.trend.steady":           "steady",
+		"summary.wind":                   "%[1]s %[2]s wind",
+		"summary.wind_no_direction":      "%s wind",
+		"summary.wind_calm":              "calm",
+		"summary.wind_strength.light":    "light",
+		"summary.wind_strength.moderate": "moderate",
+		"summary.wind_strength.strong":   "strong",
+		"summary.wind_strength.stormy":   "stormy",
+		"summary.rain":                   "%s mm rain since midnight",
+		"summary.no_rain":                "no rain since midnight",
+		"summary.no_readings":            "no current readings",
+
+		"voice.help":           "Ask me for the weather, the temperature, the wind or the rain.",
+		"voice.goodbye":        "Goodbye.",
+		"voice.error":          "Sorry, the weather station can't be reached right now.",
+		"voice.no_temperature": "There is no current temperature reading.",
+		"voice.no_wind":        "There is no current wind reading.",
+		"voice.no_rain":        "There is no current rain reading.",
+
+		"alert.raised":             "raised",
+		"alert.cleared":            "cleared",
+		"alert.operator.below":     "below",
+		"alert.operator.above":     "above",
+		"alert.subject":            "WeatherMaestro alert %s: %s (%s)",
+		"alert.body":               "Alert %s of station %s was %s.",
+		"alert.detail":             "%s is %s %s (%s %g %s).",
+		"alert.storm_detail":       "Storm severity is %s (%s %s).",
+		"alert.battery_detail":     "Battery status is %s (%s %s).",
+		"alert.ventilation_detail": "Ventilation is %s.",
+		"alert.test_subject":       "[TEST] %s",
+		"alert.test_body":          "This is a test of the alert, triggered with synthetic readings. No alert was raised.",
+
+		"storm.none":     "none",
+		"storm.moderate": "moderate",
+		"storm.strong":   "strong",
+		"storm.severe":   "severe",
+
+		"battery.ok":       "ok",
+		"battery.low":      "low",
+		"battery.critical": "critical",
+
+		"ventilation.recommended": "recommended",
+		"ventilation.not_needed":  "not needed",
+	},
+	German: {
+		"sensor_type.Temperature":        "Temperatur",
+		"sensor_type.Humidity":           "Luftfeuchtigkeit",
+		"sensor_type.Pressure":           "Luftdruck",
+		"sensor_type.WindDirection":      "Windrichtung",
+		"sensor_type.WindSpeed":          "Windgeschwindigkeit",
+		"sensor_type.WindSpeedMaxDaily":  "Max. Windgeschwindigkeit heute",
+		"sensor_type.WindGust":           "Windböe",
+		"sensor_type.WindGustAngle":      "Böenrichtung",
+		"sensor_type.WindGustMaxDaily":   "Max. Windböe heute",
+		"sensor_type.SolarRadiation":     "Globalstrahlung",
+		"sensor_type.UVIndex":            "UV-Index",
+		"sensor_type.RainfallRate":       "Regenrate",
+		"sensor_type.RainfallEvent":      "Regenereignis",
+		"sensor_type.RainfallHourly":     "Regen letzte Stunde",
+		"sensor_type.RainfallDaily":      "Regen heute",
+		"sensor_type.RainfallWeekly":     "Regen diese Woche",
+		"sensor_type.RainfallMonthly":    "Regen diesen Monat",
+		"sensor_type.RainfallYearly":     "Regen dieses Jahr",
+		"sensor_type.RainfallTotal":      "Regen gesamt",
+		"sensor_type.VPD":                "Dampfdruckdefizit",
+		"sensor_type.Battery":            "Batterie",
+		"sensor_type.PressureRelative":   "Relativer Luftdruck",
+		"sensor_type.PressureAbsolute":   "Absoluter Luftdruck",
+		"sensor_type.TemperatureOutdoor": "Außentemperatur",
+		"sensor_type.HumidityOutdoor":    "Luftfeuchtigkeit außen",
+		"sensor_type.SignalStrength":     "Signalstärke",
+		"sensor_type.Noise":              "Lärm",
+		"sensor_type.Visibility":         "Sichtweite",
+		"sensor_type.CloudCover":         "Bewölkung",
+		"sensor_type.Snowfall":           "Neuschnee",
+		"sensor_type.PresentWeather":     "Aktuelles Wetter",
+		"sensor_type.Ventilation":        "Lüften",
+		"sensor_type.MoldRisk":           "Schimmelrisiko",
+		"sensor_type.ClockSkew":          "Uhrabweichung",
+
+		"category.Temperature": "Temperatur",
+		"category.Humidity":    "Luftfeuchtigkeit",
+		"category.Pressure":    "Luftdruck",
+		"category.Wind":        "Wind",
+		"category.Rainfall":    "Niederschlag",
+		"category.Solar":       "Sonne",
+		"category.Vapor":       "Wasserdampf",
+		"category.System":      "System",
+		"category.Noise":       "Lärm",
+		"category.AirQuality":  "Luftqualität",
+		"category.Custom":      "Benutzerdefiniert",
+		"category.Observation": "Beobachtung",
+
+		"compass.NNE": "NNO",
+		"compass.NE":  "NO",
+		"compass.ENE": "ONO",
+		"compass.E":   "O",
+		"compass.ESE": "OSO",
+		"compass.SE":  "SO",
+		"compass.SSE": "SSO",
+
+		"beaufort.0":  "Windstille",
+		"beaufort.1":  "Leiser Zug",
+		"beaufort.2":  "Leichte Brise",
+		"beaufort.3":  "Schwache Brise",
+		"beaufort.4":  "Mäßige Brise",
+		"beaufort.5":  "Frische Brise",
+		"beaufort.6":  "Starker Wind",
+		"beaufort.7":  "Steifer Wind",
+		"beaufort.8":  "Stürmischer Wind",
+		"beaufort.9":  "Sturm",
+		"beaufort.10": "Schwerer Sturm",
+		"beaufort.11": "Orkanartiger Sturm",
+		"beaufort.12": "Orkan",
+
+		"uv_risk.low":       "Niedrig",
+		"uv_risk.moderate":  "Mäßig",
+		"uv_risk.high":      "Hoch",
+		"uv_risk.very_high": "Sehr hoch",
+		"uv_risk.extreme":   "Extrem",
+
+		"location.Indoor":  "Innen",
+		"location.Outdoor": "Außen",
+
+		"month.January":   "Januar",
+		"month.February":  "Februar",
+		"month.March":     "März",
+		"month.April":     "April",
+		"month.May":       "Mai",
+		"month.June":      "Juni",
+		"month.July":      "Juli",
+		"month.August":    "August",
+		"month.September": "September",
+		"month.October":   "Oktober",
+		"month.November":  "November",
+		"month.December":  "Dezember",
+
+		"number.decimal_separator": ",",
+
+		"site.stations":      "Wetterstationen",
+		"site.no_stations":   "Noch keine Stationen registriert.",
+		"site.updated":       "aktualisiert %s",
+		"site.current":       "Aktuelle Werte",
+		"site.sensor":        "Sensor",
+		"site.value":         "Wert",
+		"site.time":          "Zeit",
+		"site.no_readings":   "Noch keine Messwerte.",
+		"site.last_24_hours": "letzte 24 Stunden",
+		"site.last_7_days":   "letzte 7 Tage",
+		"site.noaa_reports":  "NOAA-Berichte",
+		"site.generated":     "Erstellt %s von WeatherMaestro",
+
+		"widget.today":       "heute",
+		"widget.no_wind":     "Keine Winddaten",
+		"widget.no_rain":     "Keine Regendaten",
+		"widget.updated":     "Aktualisiert %s",
+		"widget.no_readings": "Noch keine Messwerte",
+
+		"summary.temperature":            "aktuell %s °C",
+		"summary.temperature_trend":      "aktuell %s °C und %s",
+		"summary.trend.rising":           "steigend",
+		"summary.trend.falling":          "fallend",
+		"summary.trend.steady":           "gleichbleibend",
+		"summary.wind":                   "%[1]s Wind aus %[2]s",
+		"summary.wind_no_direction":      "%s Wind",
+		"summary.wind_calm":              "windstill",
+		"summary.wind_strength.light":    "schwacher",
+		"summary.wind_strength.moderate": "mäßiger",
+		"summary.wind_strength.strong":   "starker",
+		"summary.wind_strength.stormy":   "stürmischer",
+		"summary.rain":                   "%s mm Regen seit Mitternacht",
+		"summary.no_rain":                "kein Regen seit Mitternacht",
+		"summary.no_readings":            "keine aktuellen Messwerte",
+
+		"voice.help":           "Frag mich nach dem Wetter, der Temperatur, dem Wind oder dem Regen.",
+		"voice.goodbye":        "Auf Wiederhören.",
+		"voice.error":          "Die Wetterstation ist gerade leider nicht erreichbar.",
+		"voice.no_temperature": "Es gibt keinen aktuellen Temperaturwert.",
+		"voice.no_wind":        "Es gibt keinen aktuellen Windwert.",
+		"voice.no_rain":        "Es gibt keinen aktuellen Regenwert.",
+
+		"alert.raised":             "ausgelöst",
+		"alert.cleared":            "aufgehoben",
+		"alert.operator.below":     "unter",
+		"alert.operator.above":     "über",
+		"alert.subject":            "WeatherMaestro-Alarm %s: %s (%s)",
+		"alert.body":               "Alarm %s der Station %s wurde %s.",
+		"alert.detail":             "%s beträgt %s %s (%s %g %s).",
+		"alert.storm_detail":       "Sturmstärke ist %s (%s %s).",
+		"alert.battery_detail":     "Batteriestatus ist %s (%s %s).",
+		"alert.ventilation_detail": "Lüften ist %s.",
+		"alert.test_subject":       "[TEST] %s",
+		"alert.test_body":          "Dies ist ein Test des Alarms, ausgelöst durch simulierte Messwerte. Es wurde kein Alarm ausgelöst.",
+
+		"storm.none":     "keine",
+		"storm.moderate": "mäßig",
+		"storm.strong":   "stark",
+		"storm.severe":   "schwer",
+
+		"battery.ok":       "in Ordnung",
+		"battery.low":      "schwach",
+		"battery.critical": "kritisch",
+
+		"ventilation.recommended": "empfohlen",
+		"ventilation.not_needed":  "nicht nötig",
+	},
+}
@@ -0,0 +1,291 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// AlertStore loads alert rules and persists their state
+type AlertStore interface {
+	GetEnabledAlertRules(ctx context.Context, stationID uuid.UUID) ([]models.AlertRule, error)
+	SetAlertState(ctx context.Context, id uuid.UUID, active bool, value float64, at time.Time) error
+}
+
+// StormDetector returns the storm severity of a station at a time, see
+// models.AlertTypeStorm
+type StormDetector interface {
+	StormSeverity(ctx context.Context, stationID uuid.UUID, at time.Time) (int, error)
+}
+
+// BatteryRater returns the battery severity of a station after the readings
+// of a batch, see models.AlertTypeBattery
+type BatteryRater interface {
+	BatterySeverity(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (int, error)
+}
+
+// IndoorClimateRater returns the indoor climate of a station after the
+// readings of a batch, see models.ComputeIndoorClimate
+type IndoorClimateRater interface {
+	StationIndoorClimate(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (*models.StationIndoorClimate, error)
+}
+
+// ClockSkewRater returns the estimated clock skew of a station, see
+// models.AlertTypeClockSkew. It reports false while the skew is unknown.
+type ClockSkewRater interface {
+	StationClockSkew(stationID uuid.UUID) (time.Duration, bool)
+}
+
+// AlertListener is called when an alert is raised or cleared, with the rule
+// in its new state
+type AlertListener func(ctx context.Context, rule models.AlertRule)
+
+// AlertHook evaluates the alert rules of a station against the newest
+// reading of each matching sensor in a batch
+type AlertHook struct {
+	store     AlertStore
+	listeners []AlertListener
+	storms    StormDetector
+	batteries BatteryRater
+	indoor    IndoorClimateRater
+	clocks    ClockSkewRater
+}
+
+// NewAlertHook creates a new AlertHook
+func NewAlertHook(store AlertStore, listeners ...AlertListener) *AlertHook {
+	return &AlertHook{store: store, listeners: listeners}
+}
+
+// SetStormDetector enables rules of type models.AlertTypeStorm. Without a
+// detector they are never evaluated.
+func (h *AlertHook) SetStormDetector(detector StormDetector) {
+	h.storms = detector
+}
+
+// SetBatteryRater enables rules of type models.AlertTypeBattery. Without a
+// rater they are never evaluated.
+func (h *AlertHook) SetBatteryRater(rater BatteryRater) {
+	h.batteries = rater
+}
+
+// SetIndoorClimateRater enables rules of type models.AlertTypeVentilation
+// and models.AlertTypeMoldRisk. Without a rater they are never evaluated.
+func (h *AlertHook) SetIndoorClimateRater(rater IndoorClimateRater) {
+	h.indoor = rater
+}
+
+// SetClockSkewRater enables rules of type models.AlertTypeClockSkew.
+// Without a rater they are never evaluated.
+func (h *AlertHook) SetClockSkewRater(rater ClockSkewRater) {
+	h.clocks = rater
+}
+
+// Name returns the hook name
+func (h *AlertHook) Name() string { return "alerts" }
+
+// Stage returns the hook stage
+func (h *AlertHook) Stage() Stage { return StageAlerting }
+
+// Process evaluates the rules and notifies the listeners of changed alerts
+func (h *AlertHook) Process(ctx context.Context, batch *Batch) error {
+	if len(batch.Readings) == 0 {
+		return nil
+	}
+	rules, err := h.store.GetEnabledAlertRules(ctx, batch.StationID)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	sensors := make(map[uuid.UUID]models.Sensor, len(batch.Sensors))
+	for _, s := range batch.Sensors {
+		sensors[s.ID] = s
+	}
+
+	// The indoor climate is computed once per batch, by the first rule
+	// needing it
+	var indoor *indoorClimateReading
+	for _, rule := range rules {
+		var reading models.SensorReading
+		var ok bool
+		switch {
+		case rule.IsStorm():
+			reading, ok = h.stormReading(ctx, batch, sensors)
+		case rule.IsBattery():
+			reading, ok = h.batteryReading(ctx, batch, sensors)
+		case rule.IsIndoorClimate():
+			if indoor == nil {
+				indoor = h.indoorClimate(ctx, batch)
+			}
+			reading, ok = indoor.reading(rule)
+		case rule.IsClockSkew():
+			reading, ok = h.clockSkewReading(batch)
+		default:
+			reading, ok = latestMatchingReading(rule, sensors, batch.Readings)
+		}
+		if !ok {
+			continue
+		}
+
+		active := rule.Evaluate(reading.Value)
+		if err := h.store.SetAlertState(ctx, rule.ID, active, reading.Value, reading.DateUTC); err != nil {
+			log.Printf("❌ Failed to store state of alert %s: %v", rule.Name, err)
+			continue
+		}
+		if active == rule.Active {
+			continue
+		}
+
+		if active {
+			log.Printf("⚠ Alert %s of station %s raised at %g", rule.Name, batch.StationID, reading.Value)
+			activeSince := reading.DateUTC
+			rule.ActiveSince = &activeSince
+		} else {
+			log.Printf("✓ Alert %s of station %s cleared at %g", rule.Name, batch.StationID, reading.Value)
+			rule.ActiveSince = nil
+		}
+		value, evaluatedAt := reading.Value, reading.DateUTC
+		rule.Active, rule.LastValue, rule.EvaluatedAt = active, &value, &evaluatedAt
+		for _, listener := range h.listeners {
+			listener(ctx, rule)
+		}
+	}
+	return nil
+}
+
+// stormReading returns the storm severity of the station as a reading at
+// the time of the newest pressure or wind reading of the batch
+func (h *AlertHook) stormReading(ctx context.Context, batch *Batch, sensors map[uuid.UUID]models.Sensor) (models.SensorReading, bool) {
+	if h.storms == nil {
+		return models.SensorReading{}, false
+	}
+	var at time.Time
+	for _, r := range batch.Readings {
+		sensor, ok := sensors[r.SensorID]
+		if !ok {
+			continue
+		}
+		category := models.SensorTypeRegistry[sensor.SensorType].Category
+		if category != models.SensorCategoryPressure && sensor.SensorType != models.SensorTypeWindSpeed {
+			continue
+		}
+		if r.DateUTC.After(at) {
+			at = r.DateUTC
+		}
+	}
+	if at.IsZero() {
+		return models.SensorReading{}, false
+	}
+
+	severity, err := h.storms.StormSeverity(ctx, batch.StationID, at)
+	if err != nil {
+		log.Printf("❌ Failed to detect storms of station %s: %v", batch.StationID, err)
+		return models.SensorReading{}, false
+	}
+	return models.SensorReading{Value: float64(severity), DateUTC: at}, true
+}
+
+// batteryReading returns the battery severity of the station as a reading
+// at the time of the newest battery reading of the batch
+func (h *AlertHook) batteryReading(ctx context.Context, batch *Batch, sensors map[uuid.UUID]models.Sensor) (models.SensorReading, bool) {
+	if h.batteries == nil {
+		return models.SensorReading{}, false
+	}
+	var at time.Time
+	for _, r := range batch.Readings {
+		if sensor, ok := sensors[r.SensorID]; ok && sensor.SensorType == models.SensorTypeBattery && r.DateUTC.After(at) {
+			at = r.DateUTC
+		}
+	}
+	if at.IsZero() {
+		return models.SensorReading{}, false
+	}
+
+	severity, err := h.batteries.BatterySeverity(ctx, batch.StationID, batch.Readings)
+	if err != nil {
+		log.Printf("❌ Failed to rate batteries of station %s: %v", batch.StationID, err)
+		return models.SensorReading{}, false
+	}
+	return models.SensorReading{Value: float64(severity), DateUTC: at}, true
+}
+
+// clockSkewReading returns the clock skew of the station in seconds either
+// way as a reading at the time of the newest reading of the batch
+func (h *AlertHook) clockSkewReading(batch *Batch) (models.SensorReading, bool) {
+	if h.clocks == nil {
+		return models.SensorReading{}, false
+	}
+	skew, ok := h.clocks.StationClockSkew(batch.StationID)
+	if !ok {
+		return models.SensorReading{}, false
+	}
+	var at time.Time
+	for _, r := range batch.Readings {
+		if r.DateUTC.After(at) {
+			at = r.DateUTC
+		}
+	}
+	return models.SensorReading{Value: math.Round(absDuration(skew).Seconds()), DateUTC: at}, true
+}
+
+// indoorClimateReading is the indoor climate of a station at the time of a
+// batch
+type indoorClimateReading struct {
+	climate *models.StationIndoorClimate
+	at      time.Time
+}
+
+// indoorClimate returns the indoor climate of the station at the time of
+// the newest reading of the batch. Pulled batches come without sensors, so
+// every batch is rated.
+func (h *AlertHook) indoorClimate(ctx context.Context, batch *Batch) *indoorClimateReading {
+	indoor := &indoorClimateReading{}
+	if h.indoor == nil {
+		return indoor
+	}
+	for _, r := range batch.Readings {
+		if r.DateUTC.After(indoor.at) {
+			indoor.at = r.DateUTC
+		}
+	}
+
+	climate, err := h.indoor.StationIndoorClimate(ctx, batch.StationID, batch.Readings)
+	if err != nil {
+		log.Printf("❌ Failed to rate indoor climate of station %s: %v", batch.StationID, err)
+		return indoor
+	}
+	indoor.climate = climate
+	return indoor
+}
+
+// reading returns the value of an indoor climate rule as a reading
+func (i *indoorClimateReading) reading(rule models.AlertRule) (models.SensorReading, bool) {
+	value, ok := i.climate.AlertValue(rule)
+	if !ok {
+		return models.SensorReading{}, false
+	}
+	return models.SensorReading{Value: value, DateUTC: i.at}, true
+}
+
+// latestMatchingReading returns the newest reading of a sensor the rule
+// applies to
+func latestMatchingReading(rule models.AlertRule, sensors map[uuid.UUID]models.Sensor, readings []models.SensorReading) (models.SensorReading, bool) {
+	var latest models.SensorReading
+	found := false
+	for _, r := range readings {
+		sensor, ok := sensors[r.SensorID]
+		if !ok || !rule.Matches(sensor) {
+			continue
+		}
+		if !found || r.DateUTC.After(latest.DateUTC) {
+			latest, found = r, true
+		}
+	}
+	return latest, found
+}
@@ -0,0 +1,306 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+type fakeAlertStore struct {
+	rules  []models.AlertRule
+	states map[uuid.UUID]float64
+}
+
+func (s *fakeAlertStore) GetEnabledAlertRules(ctx context.Context, stationID uuid.UUID) ([]models.AlertRule, error) {
+	return s.rules, nil
+}
+
+func (s *fakeAlertStore) SetAlertState(ctx context.Context, id uuid.UUID, active bool, value float64, at time.Time) error {
+	s.states[id] = value
+	for i := range s.rules {
+		if s.rules[i].ID == id {
+			s.rules[i].Active = active
+		}
+	}
+	return nil
+}
+
+func TestAlertHook_Process(t *testing.T) {
+	outdoor := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature, Location: "Outdoor"}
+	indoor := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature, Location: "Indoor"}
+	frost := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "frost_warning",
+		SensorType: models.SensorTypeTemperature,
+		Location:   "Outdoor",
+		Operator:   models.AlertOperatorBelow,
+		Threshold:  0.5,
+		Hysteresis: 1,
+	}
+	store := &fakeAlertStore{rules: []models.AlertRule{frost}, states: make(map[uuid.UUID]float64)}
+
+	var changes []models.AlertRule
+	hook := NewAlertHook(store, func(ctx context.Context, rule models.AlertRule) {
+		changes = append(changes, rule)
+	})
+
+	now := time.Now().UTC()
+	batch := func(value float64, at time.Time) *Batch {
+		return &Batch{
+			Sensors: map[string]models.Sensor{"out": outdoor, "in": indoor},
+			Readings: []models.SensorReading{
+				{SensorID: outdoor.ID, Value: 5, DateUTC: at.Add(-time.Minute)},
+				{SensorID: outdoor.ID, Value: value, DateUTC: at},
+				{SensorID: indoor.ID, Value: -10, DateUTC: at},
+			},
+		}
+	}
+
+	steps := []struct {
+		value           float64
+		expectedChanges int
+		expectedActive  bool
+	}{
+		{value: 2, expectedChanges: 0},
+		{value: 0, expectedChanges: 1, expectedActive: true},
+		{value: -1, expectedChanges: 1, expectedActive: true},
+		{value: 1.2, expectedChanges: 1, expectedActive: true},
+		{value: 1.6, expectedChanges: 2, expectedActive: false},
+	}
+	for i, step := range steps {
+		at := now.Add(time.Duration(i) * time.Hour)
+		if err := hook.Process(context.Background(), batch(step.value, at)); err != nil {
+			t.Fatalf("step %d: Process() error = %v", i, err)
+		}
+		if store.states[frost.ID] != step.value {
+			t.Errorf("step %d: stored value %g, want %g", i, store.states[frost.ID], step.value)
+		}
+		if len(changes) != step.expectedChanges {
+			t.Fatalf("step %d: %d changes, want %d", i, len(changes), step.expectedChanges)
+		}
+		if store.rules[0].Active != step.expectedActive {
+			t.Errorf("step %d: active = %v, want %v", i, store.rules[0].Active, step.expectedActive)
+		}
+	}
+
+	if !changes[0].Active || changes[0].ActiveSince == nil || changes[1].Active || changes[1].ActiveSince != nil {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+type fakeStormDetector struct {
+	severity int
+	calls    int
+}
+
+func (d *fakeStormDetector) StormSeverity(ctx context.Context, stationID uuid.UUID, at time.Time) (int, error) {
+	d.calls++
+	return d.severity, nil
+}
+
+func TestAlertHook_Storm(t *testing.T) {
+	pressure := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypePressureRelative}
+	temperature := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature}
+	storm := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "storm_warning",
+		SensorType: models.AlertTypeStorm,
+		Operator:   models.AlertOperatorAbove,
+		Threshold:  0,
+	}
+	store := &fakeAlertStore{rules: []models.AlertRule{storm}, states: make(map[uuid.UUID]float64)}
+
+	var changes []models.AlertRule
+	hook := NewAlertHook(store, func(ctx context.Context, rule models.AlertRule) {
+		changes = append(changes, rule)
+	})
+	now := time.Now().UTC()
+	batch := &Batch{
+		Sensors:  map[string]models.Sensor{"p": pressure, "t": temperature},
+		Readings: []models.SensorReading{{SensorID: pressure.ID, Value: 1004, DateUTC: now}},
+	}
+
+	// Without a detector storm rules are skipped
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if _, ok := store.states[storm.ID]; ok {
+		t.Fatal("expected no evaluation without a detector")
+	}
+
+	detector := &fakeStormDetector{severity: 2}
+	hook.SetStormDetector(detector)
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if store.states[storm.ID] != 2 || len(changes) != 1 || !changes[0].Active {
+		t.Errorf("states = %v, changes = %+v, want raised alert with severity 2", store.states, changes)
+	}
+
+	// Batches without pressure or wind readings don't change the severity
+	other := &Batch{
+		Sensors:  batch.Sensors,
+		Readings: []models.SensorReading{{SensorID: temperature.ID, Value: 12, DateUTC: now}},
+	}
+	if err := hook.Process(context.Background(), other); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if detector.calls != 1 {
+		t.Errorf("detector called %d times, want 1", detector.calls)
+	}
+}
+
+type fakeBatteryRater struct {
+	severity int
+	calls    int
+}
+
+func (r *fakeBatteryRater) BatterySeverity(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (int, error) {
+	r.calls++
+	return r.severity, nil
+}
+
+func TestAlertHook_Battery(t *testing.T) {
+	battery := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeBattery}
+	temperature := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature}
+	rule := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "battery_low",
+		SensorType: models.AlertTypeBattery,
+		Operator:   models.AlertOperatorAbove,
+		Threshold:  0,
+	}
+	store := &fakeAlertStore{rules: []models.AlertRule{rule}, states: make(map[uuid.UUID]float64)}
+
+	var changes []models.AlertRule
+	hook := NewAlertHook(store, func(ctx context.Context, rule models.AlertRule) {
+		changes = append(changes, rule)
+	})
+	rater := &fakeBatteryRater{severity: 1}
+	hook.SetBatteryRater(rater)
+
+	now := time.Now().UTC()
+	sensors := map[string]models.Sensor{"b": battery, "t": temperature}
+
+	// Batches without battery readings don't change the status
+	other := &Batch{Sensors: sensors, Readings: []models.SensorReading{{SensorID: temperature.ID, Value: 12, DateUTC: now}}}
+	if err := hook.Process(context.Background(), other); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if rater.calls != 0 {
+		t.Errorf("rater called %d times, want 0", rater.calls)
+	}
+
+	batch := &Batch{Sensors: sensors, Readings: []models.SensorReading{{SensorID: battery.ID, Value: 20, DateUTC: now}}}
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if store.states[rule.ID] != 1 || len(changes) != 1 || !changes[0].Active {
+		t.Errorf("states = %v, changes = %+v, want raised alert with severity 1", store.states, changes)
+	}
+}
+
+type fakeIndoorClimateRater struct {
+	climate *models.StationIndoorClimate
+	calls   int
+}
+
+func (r *fakeIndoorClimateRater) StationIndoorClimate(ctx context.Context, stationID uuid.UUID, readings []models.SensorReading) (*models.StationIndoorClimate, error) {
+	r.calls++
+	return r.climate, nil
+}
+
+func TestAlertHook_IndoorClimate(t *testing.T) {
+	ventilate := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "ventilate_bathroom",
+		SensorType: models.AlertTypeVentilation,
+		Location:   "Bathroom",
+		Operator:   models.AlertOperatorAbove,
+		Threshold:  0.5,
+	}
+	mold := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "mold_risk",
+		SensorType: models.AlertTypeMoldRisk,
+		Operator:   models.AlertOperatorAbove,
+		Threshold:  80,
+	}
+	store := &fakeAlertStore{rules: []models.AlertRule{ventilate, mold}, states: make(map[uuid.UUID]float64)}
+
+	var changes []models.AlertRule
+	hook := NewAlertHook(store, func(ctx context.Context, rule models.AlertRule) {
+		changes = append(changes, rule)
+	})
+	rater := &fakeIndoorClimateRater{climate: &models.StationIndoorClimate{Rooms: []models.IndoorClimate{
+		{Location: "Bathroom", MoldRisk: 76, Ventilate: true},
+		{Location: "Bedroom", MoldRisk: 84},
+	}}}
+	hook.SetIndoorClimateRater(rater)
+
+	// Pulled batches carry no sensors
+	now := time.Now().UTC()
+	batch := &Batch{Readings: []models.SensorReading{{SensorID: uuid.New(), Value: 1250, DateUTC: now}}}
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if rater.calls != 1 {
+		t.Errorf("rater called %d times, want 1", rater.calls)
+	}
+	if store.states[ventilate.ID] != 1 || store.states[mold.ID] != 84 || len(changes) != 2 {
+		t.Errorf("states = %v, changes = %+v, want both alerts raised", store.states, changes)
+	}
+	if changes[0].EvaluatedAt == nil || !changes[0].EvaluatedAt.Equal(now) {
+		t.Errorf("EvaluatedAt = %v, want the time of the batch", changes[0].EvaluatedAt)
+	}
+}
+
+func TestAlertHook_ClockSkew(t *testing.T) {
+	stationID := uuid.New()
+	temperature := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature}
+	rule := models.AlertRule{
+		ID:         uuid.New(),
+		Name:       "clock_drift",
+		SensorType: models.AlertTypeClockSkew,
+		Operator:   models.AlertOperatorAbove,
+		Threshold:  300,
+	}
+	store := &fakeAlertStore{rules: []models.AlertRule{rule}, states: make(map[uuid.UUID]float64)}
+
+	var changes []models.AlertRule
+	hook := NewAlertHook(store, func(ctx context.Context, rule models.AlertRule) {
+		changes = append(changes, rule)
+	})
+	clocks := NewClockSkewHook(nil, time.Hour)
+	hook.SetClockSkewRater(clocks)
+
+	now := time.Now().UTC()
+	sensors := map[string]models.Sensor{"t": temperature}
+
+	// Stations that never pushed have no skew yet
+	pulled := &Batch{StationID: stationID, Sensors: sensors, Source: "pull", ReceivedAt: now,
+		Readings: []models.SensorReading{{SensorID: temperature.ID, Value: 12, DateUTC: now.Add(-10 * time.Minute)}}}
+	for _, h := range []Hook{clocks, hook} {
+		if err := h.Process(context.Background(), pulled); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+	if _, ok := store.states[rule.ID]; ok {
+		t.Errorf("states = %v, want rule not evaluated", store.states)
+	}
+
+	// A station clock running 10 minutes ahead raises the alert
+	pushed := &Batch{StationID: stationID, Sensors: sensors, Source: "push", ReceivedAt: now,
+		Readings: []models.SensorReading{{SensorID: temperature.ID, Value: 12, DateUTC: now.Add(10 * time.Minute)}}}
+	for _, h := range []Hook{clocks, hook} {
+		if err := h.Process(context.Background(), pushed); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+	}
+	if store.states[rule.ID] != 600 || len(changes) != 1 || !changes[0].Active {
+		t.Errorf("states = %v, changes = %+v, want raised alert with 600 s", store.states, changes)
+	}
+}
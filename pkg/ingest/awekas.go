@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const (
+	// AwekasUserConfigKey is the station config key with the AWEKAS user name
+	AwekasUserConfigKey = "awekas_username"
+
+	// AwekasPasswordConfigKey is the station config key with the AWEKAS
+	// password, which is sent as MD5 hash
+	AwekasPasswordConfigKey = "awekas_password"
+
+	// AwekasLanguageConfigKey is the station config key with the language of
+	// the AWEKAS station page (de, en, fr, it, nl; default en)
+	AwekasLanguageConfigKey = "awekas_language"
+
+	// awekasInterval matches the shortest upload interval of free AWEKAS
+	// accounts; more frequent uploads are rejected
+	awekasInterval = 5 * time.Minute
+
+	awekasEndpoint = "http://data.awekas.at/eingabe_pruefung.asp"
+)
+
+// awekasField maps a measurement to a position of the semicolon separated
+// value list of the upload API, in metric units
+type awekasField struct {
+	measurement string
+	position    int
+	unit        string
+}
+
+// Positions of the AWEKAS value list; positions without a field stay empty
+const (
+	awekasPositionLanguage  = 13
+	awekasPositionSoftware  = 22
+	awekasPositionLongitude = 23
+	awekasPositionLatitude  = 24
+	awekasValueCount        = 25
+)
+
+var awekasFields = []awekasField{
+	{measurementTemperature, 4, "°C"},
+	{measurementHumidity, 5, "%"},
+	{measurementBarometer, 6, "hPa"},
+	{measurementRainDaily, 7, "mm"},
+	{measurementWindSpeed, 8, "km/h"},
+	{measurementWindDirection, 9, "°"},
+	{measurementWindGust, 15, "km/h"},
+	{measurementSolarRadiation, 16, "W/m²"},
+	{measurementUVIndex, 17, "index"},
+	{measurementRainRate, 21, "mm/h"},
+}
+
+// awekasLanguages lists the languages of AWEKAS station pages
+var awekasLanguages = map[string]bool{"de": true, "en": true, "fr": true, "it": true, "nl": true}
+
+// AwekasHook forwards outdoor readings to AWEKAS for stations with AWEKAS
+// credentials in their config
+type AwekasHook struct {
+	*forwarder
+	endpoint string
+}
+
+// NewAwekasHook creates a new AwekasHook
+func NewAwekasHook(lookup SensorLookup) *AwekasHook {
+	return &AwekasHook{
+		forwarder: newForwarder(lookup, awekasInterval),
+		endpoint:  awekasEndpoint,
+	}
+}
+
+// Name returns the hook name
+func (h *AwekasHook) Name() string { return "awekas" }
+
+// Stage returns the hook stage
+func (h *AwekasHook) Stage() Stage { return StageForwarding }
+
+// Process sends the metric weather values of the batch to AWEKAS as the
+// semicolon separated list of the station API, signed with the user name
+// and the MD5 hash of the password. A station uploads every five minutes
+// at most; batches in between are dropped.
+func (h *AwekasHook) Process(ctx context.Context, batch *Batch) error {
+	user := configString(batch.Station, AwekasUserConfigKey)
+	password := configString(batch.Station, AwekasPasswordConfigKey)
+	if user == "" || password == "" {
+		return nil
+	}
+	return h.forward(h.Name(), batch, func(values map[string]measurement) (func(ctx context.Context) error, error) {
+		return h.build(batch, user, password, values), nil
+	})
+}
+
+// build returns the upload of the values, or nil without AWEKAS values
+func (h *AwekasHook) build(batch *Batch, user, password string, values map[string]measurement) func(ctx context.Context) error {
+	fields := make([]string, awekasValueCount)
+	found := false
+	for _, field := range awekasFields {
+		if m, ok := values[field.measurement]; ok {
+			if v, ok := m.in(field.unit); ok {
+				fields[field.position] = formatValue(v)
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	hash := md5.Sum([]byte(password))
+	date := latestDate(values).UTC()
+	fields[0] = user
+	fields[1] = hex.EncodeToString(hash[:])
+	fields[2] = date.Format("02.01.2006")
+	fields[3] = date.Format("15:04")
+	fields[awekasPositionLanguage] = "en"
+	if language := strings.ToLower(configString(batch.Station, AwekasLanguageConfigKey)); awekasLanguages[language] {
+		fields[awekasPositionLanguage] = language
+	}
+	fields[awekasPositionSoftware] = "WeatherMaestro"
+	if lat, lon, ok, err := models.StationCoordinates(batch.Station.Config); ok && err == nil {
+		fields[awekasPositionLongitude] = strconv.FormatFloat(lon, 'f', -1, 64)
+		fields[awekasPositionLatitude] = strconv.FormatFloat(lat, 'f', -1, 64)
+	}
+
+	rawURL := h.endpoint + "?val=" + url.QueryEscape(strings.Join(fields, ";"))
+	return func(ctx context.Context) error {
+		body, err := h.get(ctx, rawURL)
+		if err != nil {
+			return fmt.Errorf("failed to send to AWEKAS: %w", err)
+		}
+		// Rejected uploads are answered with status 200 and the reason
+		if body != "OK" {
+			return fmt.Errorf("failed to send to AWEKAS: %s", body)
+		}
+		return nil
+	}
+}
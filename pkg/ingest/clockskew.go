@@ -0,0 +1,186 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// skewSampleSize is the number of recent samples used to estimate the skew
+	skewSampleSize = 10
+
+	// skewTolerance is the skew below which timestamps are never corrected;
+	// it absorbs upload latency and sub-second clock jitter.
+	skewTolerance = 10 * time.Second
+
+	// skewPersistInterval limits how often an unchanged skew is written back
+	skewPersistInterval = 15 * time.Minute
+
+	// ClockCorrectionConfigKey is the station config key that enables timestamp correction
+	ClockCorrectionConfigKey = "clock_correction"
+)
+
+// SkewEstimator estimates the offset of a station clock from the server clock.
+// The estimate is the median of the most recent samples, which ignores
+// occasional delayed uploads.
+type SkewEstimator struct {
+	samples []time.Duration
+}
+
+// Add records the difference between the server receive time and the
+// timestamp reported by the station.
+func (e *SkewEstimator) Add(receivedAt, reported time.Time) {
+	e.samples = append(e.samples, receivedAt.Sub(reported))
+	if len(e.samples) > skewSampleSize {
+		e.samples = e.samples[len(e.samples)-skewSampleSize:]
+	}
+}
+
+// Estimate returns the estimated skew. A positive skew means the station
+// clock is behind the server clock.
+func (e *SkewEstimator) Estimate() time.Duration {
+	if len(e.samples) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(e.samples))
+	copy(sorted, e.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// ClockSkewStore persists the measured clock skew of a station
+type ClockSkewStore interface {
+	UpdateStationClockSkew(ctx context.Context, stationID uuid.UUID, skewSeconds float64, measuredAt time.Time) error
+}
+
+type stationSkew struct {
+	estimator   SkewEstimator
+	persisted   time.Duration
+	persistedAt time.Time
+	alerting    bool
+}
+
+// ClockSkewHook detects drifting station clocks on pushed data.
+// It stores the estimated skew per station, corrects reading timestamps for
+// stations with clock_correction enabled and logs a warning when the skew
+// exceeds the threshold. The AlertHook raises rules of type
+// models.AlertTypeClockSkew with the estimate, see StationClockSkew.
+type ClockSkewHook struct {
+	store     ClockSkewStore
+	threshold time.Duration
+
+	mu       sync.Mutex
+	stations map[uuid.UUID]*stationSkew
+}
+
+// NewClockSkewHook creates a new ClockSkewHook
+func NewClockSkewHook(store ClockSkewStore, threshold time.Duration) *ClockSkewHook {
+	return &ClockSkewHook{
+		store:     store,
+		threshold: threshold,
+		stations:  make(map[uuid.UUID]*stationSkew),
+	}
+}
+
+// Name returns the hook name
+func (h *ClockSkewHook) Name() string { return "clock_skew" }
+
+// Stage returns the hook stage
+func (h *ClockSkewHook) Stage() Stage { return StageCalibration }
+
+// Process estimates the station clock skew and optionally corrects the batch
+func (h *ClockSkewHook) Process(ctx context.Context, batch *Batch) error {
+	// Pulled data carries the provider's measurement time which can
+	// legitimately lag behind by several minutes
+	if batch.Source != "push" || len(batch.Readings) == 0 {
+		return nil
+	}
+
+	reported := batch.Readings[0].DateUTC
+	for _, r := range batch.Readings[1:] {
+		if r.DateUTC.After(reported) {
+			reported = r.DateUTC
+		}
+	}
+
+	h.mu.Lock()
+	state, ok := h.stations[batch.StationID]
+	if !ok {
+		state = &stationSkew{}
+		h.stations[batch.StationID] = state
+	}
+	state.estimator.Add(batch.ReceivedAt, reported)
+	skew := state.estimator.Estimate()
+
+	exceeded := absDuration(skew) > h.threshold
+	if exceeded && !state.alerting {
+		log.Printf("⚠ Station %s clock is off by %s (threshold %s)", batch.StationID, skew.Round(time.Second), h.threshold)
+	} else if !exceeded && state.alerting {
+		log.Printf("✓ Station %s clock skew back to %s", batch.StationID, skew.Round(time.Second))
+	}
+	state.alerting = exceeded
+
+	persist := absDuration(skew-state.persisted) >= time.Second ||
+		batch.ReceivedAt.Sub(state.persistedAt) >= skewPersistInterval
+	if persist {
+		state.persisted = skew
+		state.persistedAt = batch.ReceivedAt
+	}
+	h.mu.Unlock()
+
+	if persist && h.store != nil {
+		seconds := math.Round(skew.Seconds()*1000) / 1000
+		if err := h.store.UpdateStationClockSkew(ctx, batch.StationID, seconds, batch.ReceivedAt); err != nil {
+			log.Printf("❌ Failed to store clock skew for station %s: %v", batch.StationID, err)
+		}
+	}
+
+	if !clockCorrectionEnabled(batch) || absDuration(skew) < skewTolerance {
+		return nil
+	}
+
+	for i := range batch.Readings {
+		batch.Readings[i].DateUTC = batch.Readings[i].DateUTC.Add(skew)
+	}
+	return nil
+}
+
+// StationClockSkew returns the estimated clock skew of a station; it
+// reports false until the station pushed data
+func (h *ClockSkewHook) StationClockSkew(stationID uuid.UUID) (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state, ok := h.stations[stationID]
+	if !ok {
+		return 0, false
+	}
+	return state.estimator.Estimate(), true
+}
+
+// clockCorrectionEnabled reports whether the station config enables correction
+func clockCorrectionEnabled(batch *Batch) bool {
+	if batch.Station == nil || batch.Station.Config == nil {
+		return false
+	}
+	enabled, _ := batch.Station.Config[ClockCorrectionConfigKey].(bool)
+	return enabled
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+type fakeSkewStore struct {
+	updates []float64
+}
+
+func (s *fakeSkewStore) UpdateStationClockSkew(ctx context.Context, stationID uuid.UUID, skewSeconds float64, measuredAt time.Time) error {
+	s.updates = append(s.updates, skewSeconds)
+	return nil
+}
+
+func TestSkewEstimator_Estimate(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		offsets  []time.Duration
+		expected time.Duration
+	}{
+		{name: "No samples", offsets: nil, expected: 0},
+		{name: "Single sample", offsets: []time.Duration{90 * time.Second}, expected: 90 * time.Second},
+		{name: "Outlier ignored", offsets: []time.Duration{60 * time.Second, 61 * time.Second, 10 * time.Minute}, expected: 61 * time.Second},
+		{name: "Even count averages middle", offsets: []time.Duration{-2 * time.Second, -4 * time.Second}, expected: -3 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var e SkewEstimator
+			for _, offset := range tc.offsets {
+				e.Add(base.Add(offset), base)
+			}
+			if got := e.Estimate(); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSkewEstimator_KeepsRecentSamples(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var e SkewEstimator
+	for i := 0; i < skewSampleSize; i++ {
+		e.Add(base.Add(time.Hour), base)
+	}
+	for i := 0; i < skewSampleSize; i++ {
+		e.Add(base.Add(time.Minute), base)
+	}
+
+	if got := e.Estimate(); got != time.Minute {
+		t.Errorf("Expected old samples to be discarded, got %s", got)
+	}
+}
+
+func TestClockSkewHook_Process(t *testing.T) {
+	received := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reported := received.Add(-2 * time.Minute)
+
+	testCases := []struct {
+		name         string
+		source       string
+		config       map[string]interface{}
+		expectedDate time.Time
+		expectStore  bool
+	}{
+		{name: "Correction disabled", source: "push", config: map[string]interface{}{}, expectedDate: reported, expectStore: true},
+		{name: "Correction enabled", source: "push", config: map[string]interface{}{ClockCorrectionConfigKey: true}, expectedDate: received, expectStore: true},
+		{name: "Pulled data is ignored", source: "pull", config: map[string]interface{}{ClockCorrectionConfigKey: true}, expectedDate: reported, expectStore: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeSkewStore{}
+			hook := NewClockSkewHook(store, 5*time.Minute)
+
+			batch := &Batch{
+				StationID:  uuid.New(),
+				Station:    &models.StationData{Config: tc.config},
+				Readings:   []models.SensorReading{{SensorID: uuid.New(), Value: 1, DateUTC: reported}},
+				Source:     tc.source,
+				ReceivedAt: received,
+			}
+
+			if err := hook.Process(context.Background(), batch); err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+
+			if !batch.Readings[0].DateUTC.Equal(tc.expectedDate) {
+				t.Errorf("Expected date %s, got %s", tc.expectedDate, batch.Readings[0].DateUTC)
+			}
+			if tc.expectStore && (len(store.updates) != 1 || store.updates[0] != 120) {
+				t.Errorf("Expected stored skew of 120s, got %v", store.updates)
+			}
+			if !tc.expectStore && len(store.updates) != 0 {
+				t.Errorf("Expected no stored skew, got %v", store.updates)
+			}
+		})
+	}
+}
+
+func TestClockSkewHook_PersistsOnlyOnChange(t *testing.T) {
+	store := &fakeSkewStore{}
+	hook := NewClockSkewHook(store, 5*time.Minute)
+	stationID := uuid.New()
+	received := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		at := received.Add(time.Duration(i) * time.Minute)
+		batch := &Batch{
+			StationID:  stationID,
+			Readings:   []models.SensorReading{{DateUTC: at.Add(-30 * time.Second)}},
+			Source:     "push",
+			ReceivedAt: at,
+		}
+		if err := hook.Process(context.Background(), batch); err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+	}
+
+	if len(store.updates) != 1 {
+		t.Errorf("Expected a single store update for a stable skew, got %d", len(store.updates))
+	}
+}
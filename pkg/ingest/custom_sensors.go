@@ -0,0 +1,80 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// CustomSensorTypeStore provides the custom sensor types of a station
+type CustomSensorTypeStore interface {
+	GetCustomSensorTypes(ctx context.Context, stationID uuid.UUID) ([]models.CustomSensorType, error)
+}
+
+// CustomSensorsHook applies the custom sensor types of a station to pushed
+// readings: it sets their unit and drops values outside the type's bounds.
+type CustomSensorsHook struct {
+	store CustomSensorTypeStore
+}
+
+// NewCustomSensorsHook creates a new CustomSensorsHook
+func NewCustomSensorsHook(store CustomSensorTypeStore) *CustomSensorsHook {
+	return &CustomSensorsHook{store: store}
+}
+
+// Name returns the hook name
+func (h *CustomSensorsHook) Name() string { return "custom_sensors" }
+
+// Stage returns the hook stage
+func (h *CustomSensorsHook) Stage() Stage { return StageQC }
+
+// Process sets units and drops out of bounds readings of custom sensors
+func (h *CustomSensorsHook) Process(ctx context.Context, batch *Batch) error {
+	// Only pushed batches carry sensors and custom types are only pushed
+	if len(batch.Sensors) == 0 || len(batch.Readings) == 0 {
+		return nil
+	}
+
+	sensorTypes := make(map[uuid.UUID]string, len(batch.Sensors))
+	custom := false
+	for _, s := range batch.Sensors {
+		sensorTypes[s.ID] = s.SensorType
+		if _, ok := models.SensorTypeRegistry[s.SensorType]; !ok {
+			custom = true
+		}
+	}
+	if !custom {
+		return nil
+	}
+
+	types, err := h.store.GetCustomSensorTypes(ctx, batch.StationID)
+	if err != nil {
+		return fmt.Errorf("failed to load custom sensor types: %w", err)
+	}
+	byName := make(map[string]models.CustomSensorType, len(types))
+	for _, t := range types {
+		byName[t.Name] = t
+	}
+
+	kept := batch.Readings[:0]
+	for _, r := range batch.Readings {
+		t, ok := byName[sensorTypes[r.SensorID]]
+		if !ok {
+			kept = append(kept, r)
+			continue
+		}
+		if !t.InBounds(r.Value) {
+			log.Printf("⚠ Dropped %s reading %g of sensor %s: out of bounds", t.Name, r.Value, r.SensorID)
+			continue
+		}
+		if r.Unit == "" {
+			r.Unit = t.Unit
+		}
+		kept = append(kept, r)
+	}
+	batch.Readings = kept
+	return nil
+}
@@ -0,0 +1,64 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+type fakeCustomSensorTypeStore struct {
+	types []models.CustomSensorType
+	calls int
+}
+
+func (s *fakeCustomSensorTypeStore) GetCustomSensorTypes(ctx context.Context, stationID uuid.UUID) ([]models.CustomSensorType, error) {
+	s.calls++
+	return s.types, nil
+}
+
+func TestCustomSensorsHook(t *testing.T) {
+	low, high := 0.0, 100.0
+	store := &fakeCustomSensorTypeStore{types: []models.CustomSensorType{
+		{Name: "SoilMoisture", Unit: "%", MinValue: &low, MaxValue: &high},
+	}}
+	soil := models.Sensor{ID: uuid.New(), SensorType: "SoilMoisture", RemoteID: "adc0"}
+	temp := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature, RemoteID: "Temperature"}
+
+	batch := &Batch{
+		StationID: uuid.New(),
+		Sensors:   map[string]models.Sensor{soil.RemoteID: soil, temp.RemoteID: temp},
+		Readings: []models.SensorReading{
+			{SensorID: soil.ID, Value: 41.2},
+			{SensorID: soil.ID, Value: 140},
+			{SensorID: temp.ID, Value: 18.4, Unit: "°C"},
+		},
+	}
+
+	if err := NewCustomSensorsHook(store).Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if len(batch.Readings) != 2 {
+		t.Fatalf("expected out of bounds reading to be dropped, got %+v", batch.Readings)
+	}
+	if batch.Readings[0].Unit != "%" || batch.Readings[1].Unit != "°C" {
+		t.Errorf("unexpected units: %+v", batch.Readings)
+	}
+}
+
+func TestCustomSensorsHook_BuiltInOnly(t *testing.T) {
+	store := &fakeCustomSensorTypeStore{}
+	temp := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature, RemoteID: "tempf"}
+	batch := &Batch{
+		Sensors:  map[string]models.Sensor{temp.RemoteID: temp},
+		Readings: []models.SensorReading{{SensorID: temp.ID, Value: 20}},
+	}
+
+	if err := NewCustomSensorsHook(store).Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if store.calls != 0 {
+		t.Error("custom types must not be loaded for built-in sensors")
+	}
+}
@@ -0,0 +1,207 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FederationPassKeyConfigKey is the station config key with the passkey
+	// of the station on the remote instance, the local passkey if unset
+	FederationPassKeyConfigKey = "federation_passkey"
+
+	// FederationEndpoint is the generic webhook of the remote instance
+	FederationEndpoint = "/api/v1/data/generic"
+
+	// federationSource is the source of the entries of the federation queue
+	federationSource = "federation"
+)
+
+// FederationHook forwards all stored readings to a second WeatherMaestro
+// instance, e.g. from a station at a cabin to the server at home. Readings
+// are sent to the generic webhook of the remote instance, one request per
+// station and timestamp. Every request is written to a durable queue first
+// and carries an Idempotency-Key derived from its payload, so requests
+// that failed while the link was down can be resent by a Drainer without
+// storing readings twice.
+type FederationHook struct {
+	*forwarder
+	endpoint string
+	apiKey   string
+	queue    *Queue
+}
+
+// NewFederationHook creates a new FederationHook sending to the instance at
+// baseURL with a write:ingest API key. Requests are buffered in queue.
+func NewFederationHook(lookup SensorLookup, baseURL, apiKey string, queue *Queue) *FederationHook {
+	return &FederationHook{
+		forwarder: newForwarder(lookup, 0),
+		endpoint:  strings.TrimSuffix(baseURL, "/") + FederationEndpoint,
+		apiKey:    apiKey,
+		queue:     queue,
+	}
+}
+
+// Name returns the hook name
+func (h *FederationHook) Name() string { return "federation" }
+
+// Stage returns the hook stage
+func (h *FederationHook) Stage() Stage { return StageForwarding }
+
+// Process queues the readings of the batch and sends them right away.
+// While older requests are still queued the link is considered down and
+// sending is left to the Drainer, so pushes are not slowed down by timeouts.
+func (h *FederationHook) Process(ctx context.Context, batch *Batch) error {
+	if batch.Station == nil || len(batch.Readings) == 0 {
+		return nil
+	}
+	passKey := configString(batch.Station, FederationPassKeyConfigKey)
+	if passKey == "" {
+		passKey = batch.Station.PassKey
+	}
+	if passKey == "" {
+		return nil
+	}
+
+	backlog := h.queue.Len()
+	payloads := h.payloads(batch, passKey)
+	ids := make([]uint64, 0, len(payloads))
+	for _, payload := range payloads {
+		id, err := h.queue.Put(federationSource, payload, batch.ReceivedAt, "")
+		if err != nil {
+			return fmt.Errorf("failed to queue federation request: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if backlog > 0 {
+		return nil
+	}
+
+	for i, payload := range payloads {
+		err := h.send(ctx, payload)
+		if _, rejected := err.(*federationRejectedError); err != nil && !rejected {
+			return fmt.Errorf("failed to send to %s, %d requests queued: %w", h.endpoint, h.queue.Len(), err)
+		}
+		if ackErr := h.queue.Ack(ids[i]); ackErr != nil {
+			return ackErr
+		}
+		if err != nil {
+			return fmt.Errorf("request rejected by %s: %w", h.endpoint, err)
+		}
+	}
+	return nil
+}
+
+// Send resends a queued request; it is the ProcessFunc of the Drainer of
+// the federation queue. Requests the remote instance rejects as invalid are
+// logged and dropped, so they don't block the queue.
+func (h *FederationHook) Send(entry QueueEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := h.send(ctx, entry.Payload)
+	if rejected, ok := err.(*federationRejectedError); ok {
+		log.Printf("❌ Federation request %d dropped: %v", entry.ID, rejected)
+		return nil
+	}
+	return err
+}
+
+// payloads converts the readings of a batch into generic webhook payloads,
+// one per timestamp. Sensors are identified by their local ID, so the
+// remote instance creates one sensor per local sensor.
+func (h *FederationHook) payloads(batch *Batch, passKey string) []url.Values {
+	byDate := make(map[time.Time]url.Values)
+	var dates []time.Time
+	for _, r := range batch.Readings {
+		sensor, ok := h.sensor(batch, r.SensorID)
+		if !ok {
+			continue
+		}
+		date := r.DateUTC.UTC()
+		payload, ok := byDate[date]
+		if !ok {
+			payload = url.Values{}
+			payload.Set("passkey", passKey)
+			payload.Set("dateutc", date.Format(time.RFC3339))
+			if batch.Station.Model != "" {
+				payload.Set("model", batch.Station.Model)
+			}
+			byDate[date] = payload
+			dates = append(dates, date)
+		}
+
+		id := r.SensorID.String()
+		payload.Set(id, strconv.FormatFloat(r.Value, 'f', -1, 64))
+		payload.Set(id+".type", sensor.SensorType)
+		if sensor.Location != "" {
+			payload.Set(id+".location", sensor.Location)
+		}
+		if sensor.Name != "" {
+			payload.Set(id+".name", sensor.Name)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	payloads := make([]url.Values, len(dates))
+	for i, date := range dates {
+		payloads[i] = byDate[date]
+	}
+	return payloads
+}
+
+// federationRejectedError is a request the remote instance will never
+// accept, e.g. an invalid payload. Missing permissions are not permanent,
+// requests stay queued until the API key is fixed.
+type federationRejectedError struct {
+	status  int
+	message string
+}
+
+func (e *federationRejectedError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.status, e.message)
+}
+
+// send posts a payload as form to the remote instance
+func (h *FederationHook) send(ctx context.Context, payload url.Values) error {
+	body := payload.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", "federation-"+hex.EncodeToString(hash[:]))
+	if h.apiKey != "" {
+		req.Header.Set("X-API-Key", h.apiKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+	default:
+		if resp.StatusCode >= 400 && resp.StatusCode <= 499 {
+			return &federationRejectedError{status: resp.StatusCode, message: strings.TrimSpace(string(msg))}
+		}
+	}
+	return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
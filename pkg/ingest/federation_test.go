@@ -0,0 +1,138 @@
+package ingest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func newFederationTestHook(t *testing.T, baseURL string) *FederationHook {
+	queue, err := OpenQueue(filepath.Join(t.TempDir(), "federation.log"))
+	if err != nil {
+		t.Fatalf("OpenQueue failed: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+	return NewFederationHook(nil, baseURL, "wm_key", queue)
+}
+
+func TestFederationHook_Process(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := newFederationTestHook(t, server.URL+"/")
+
+	batch, ids := forwardTestBatch(nil)
+	batch.Station.PassKey = "cabin"
+	batch.Station.Model = "GW2000"
+	later := batch.ReceivedAt.Add(time.Minute)
+	batch.Readings = append(batch.Readings, models.SensorReading{SensorID: ids["tempf"], Value: 12.75, DateUTC: later})
+
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected one request per timestamp, got %d", len(requests))
+	}
+	if requests[0].path != FederationEndpoint || requests[0].header.Get("X-API-Key") != "wm_key" {
+		t.Errorf("Unexpected request %s %v", requests[0].path, requests[0].header)
+	}
+	if requests[0].header.Get("Idempotency-Key") == "" || requests[0].header.Get("Idempotency-Key") == requests[1].header.Get("Idempotency-Key") {
+		t.Errorf("Expected distinct idempotency keys, got %v and %v", requests[0].header, requests[1].header)
+	}
+
+	first, _ := url.ParseQuery(requests[0].body)
+	tempf := ids["tempf"].String()
+	if first.Get("passkey") != "cabin" || first.Get("model") != "GW2000" || first.Get("dateutc") != "2026-03-01T12:00:00Z" {
+		t.Errorf("Unexpected station params %v", first)
+	}
+	if first.Get(tempf) != "12.5" || first.Get(tempf+".type") != models.SensorTypeTemperature || first.Get(tempf+".location") != "Outdoor" {
+		t.Errorf("Unexpected sensor params %v", first)
+	}
+	second, _ := url.ParseQuery(requests[1].body)
+	if second.Get(tempf) != "12.75" || len(second) != 6 {
+		t.Errorf("Unexpected second request %v", second)
+	}
+	if hook.queue.Len() != 0 {
+		t.Errorf("Expected empty queue, got %d entries", hook.queue.Len())
+	}
+}
+
+func TestFederationHook_PassKeyOverride(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := newFederationTestHook(t, server.URL)
+
+	batch, _ := forwardTestBatch(map[string]interface{}{FederationPassKeyConfigKey: "remote-cabin"})
+	batch.Station.PassKey = "cabin"
+	if err := hook.Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	if params, _ := url.ParseQuery(requests[0].body); params.Get("passkey") != "remote-cabin" {
+		t.Errorf("Expected configured passkey, got %v", params)
+	}
+}
+
+func TestFederationHook_BuffersWhileDown(t *testing.T) {
+	status := http.StatusBadGateway
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	hook := newFederationTestHook(t, server.URL)
+
+	batch, _ := forwardTestBatch(nil)
+	batch.Station.PassKey = "cabin"
+	if err := hook.Process(context.Background(), batch); err == nil {
+		t.Error("Expected error while the remote instance is down")
+	}
+
+	// Further batches are only queued until the backlog is sent
+	next, _ := forwardTestBatch(nil)
+	next.Station.PassKey = "cabin"
+	if err := hook.Process(context.Background(), next); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(keys) != 1 || hook.queue.Len() != 2 {
+		t.Fatalf("Expected 1 attempt and 2 queued requests, got %d and %d", len(keys), hook.queue.Len())
+	}
+
+	status = http.StatusOK
+	for _, entry := range hook.queue.Pending() {
+		if err := hook.Send(entry); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if len(keys) != 3 || keys[0] != keys[1] {
+		t.Errorf("Expected the retry to reuse the idempotency key, got %v", keys)
+	}
+}
+
+func TestFederationHook_SendDropsRejected(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rejected", status)
+	}))
+	defer server.Close()
+	hook := newFederationTestHook(t, server.URL)
+
+	entry := QueueEntry{ID: 1, Payload: url.Values{"passkey": {"cabin"}}}
+	if err := hook.Send(entry); err == nil {
+		t.Error("Expected unauthorized requests to stay queued")
+	}
+
+	status = http.StatusBadRequest
+	if err := hook.Send(entry); err != nil {
+		t.Errorf("Expected invalid requests to be dropped, got %v", err)
+	}
+}
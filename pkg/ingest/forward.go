@@ -0,0 +1,482 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Measurements shared with open sensor networks
+const (
+	measurementTemperature = "temperature"
+	measurementHumidity    = "humidity"
+	measurementPressure    = "pressure"
+	measurementPM25        = "pm25"
+	measurementPM10        = "pm10"
+
+	// Measurements of weather networks
+	measurementBarometer      = "barometer"
+	measurementWindSpeed      = "wind_speed"
+	measurementWindGust       = "wind_gust"
+	measurementWindDirection  = "wind_direction"
+	measurementRainHourly     = "rain_hourly"
+	measurementRainDaily      = "rain_daily"
+	measurementRainRate       = "rain_rate"
+	measurementSolarRadiation = "solar_radiation"
+	measurementUVIndex        = "uv_index"
+)
+
+// pressureRank prefers absolute pressure, which is what the sensors of
+// open sensor networks measure
+var pressureRank = map[string]int{
+	models.SensorTypePressureRelative: 1,
+	models.SensorTypePressure:         2,
+	models.SensorTypePressureAbsolute: 3,
+}
+
+// barometerRank prefers sea level pressure, which is what weather networks
+// show
+var barometerRank = map[string]int{
+	models.SensorTypePressureAbsolute: 1,
+	models.SensorTypePressure:         2,
+	models.SensorTypePressureRelative: 3,
+}
+
+// weatherMeasurements maps the sensor types weather networks take
+// regardless of the location to their measurement
+var weatherMeasurements = map[string]string{
+	models.SensorTypeWindSpeed:      measurementWindSpeed,
+	models.SensorTypeWindGust:       measurementWindGust,
+	models.SensorTypeWindDirection:  measurementWindDirection,
+	models.SensorTypeRainfallHourly: measurementRainHourly,
+	models.SensorTypeRainfallDaily:  measurementRainDaily,
+	models.SensorTypeRainfallRate:   measurementRainRate,
+	models.SensorTypeSolarRadiation: measurementSolarRadiation,
+	models.SensorTypeUVIndex:        measurementUVIndex,
+}
+
+// ForwardingConfigKey is the station config key that enables or disables
+// single forwarders for a station by hook name, e.g. {"awekas": false}.
+// Forwarders are enabled by default once their credentials are set.
+const ForwardingConfigKey = "forwarding"
+
+// Asynchronous sending of the forwarders
+const (
+	// forwardQueueSize bounds the sends waiting per forwarder; batches
+	// arriving while it is full are not forwarded
+	forwardQueueSize = 100
+
+	// forwardAttempts is how often a send is tried on network errors,
+	// server errors and rate limits
+	forwardAttempts = 3
+
+	// forwardTimeout limits a single attempt
+	forwardTimeout = 10 * time.Second
+
+	// forwardRetryDelay is the wait before the first retry, doubled for
+	// each further retry
+	forwardRetryDelay = 5 * time.Second
+)
+
+// errForwardQueueFull is returned when a forwarder can't keep up with the
+// incoming batches, e.g. while its service is slow
+var errForwardQueueFull = errors.New("forwarding queue is full")
+
+// retryableError marks send errors worth retrying
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// forwardSend is a request prepared by a forwarder hook
+type forwardSend struct {
+	stationID uuid.UUID
+	send      func(ctx context.Context) error
+}
+
+// ForwarderNames lists the hook names of the forwarders
+var ForwarderNames = []string{"sensor_community", "opensensemap", "pwsweather", "awekas"}
+
+// SensorLookup resolves sensors of readings that are not part of a batch,
+// e.g. for pulled stations
+type SensorLookup interface {
+	GetSensor(sensorID uuid.UUID, includeLatest bool) (*models.SensorWithLatestReading, error)
+}
+
+// measurement is the latest value of a shared measurement
+type measurement struct {
+	Value    float64
+	Unit     string
+	DateUTC  time.Time
+	SensorID uuid.UUID
+}
+
+// in returns the value converted to a unit. It reports false if the unit of
+// the value can't be converted.
+func (m measurement) in(unit string) (float64, bool) {
+	v, err := models.ConvertUnit(m.Value, m.Unit, unit)
+	return v, err == nil
+}
+
+// forwarder contains the state shared by the hooks that send readings to
+// external services: a sensor cache, a per-station send interval and the
+// queue of a background worker sending the requests, so slow or
+// unavailable services don't hold up the ingest
+type forwarder struct {
+	client      *http.Client
+	lookup      SensorLookup
+	minInterval time.Duration
+
+	mu       sync.Mutex
+	sensors  map[uuid.UUID]models.Sensor
+	lastSent map[uuid.UUID]time.Time
+
+	sends      chan forwardSend
+	start      sync.Once
+	inFlight   sync.WaitGroup
+	retryDelay time.Duration
+	// onError reports sends that failed for good
+	onError func(stationID uuid.UUID, err error)
+}
+
+func newForwarder(lookup SensorLookup, minInterval time.Duration) *forwarder {
+	return &forwarder{
+		client:      &http.Client{Timeout: forwardTimeout},
+		lookup:      lookup,
+		minInterval: minInterval,
+		sensors:     make(map[uuid.UUID]models.Sensor),
+		lastSent:    make(map[uuid.UUID]time.Time),
+		sends:       make(chan forwardSend, forwardQueueSize),
+		retryDelay:  forwardRetryDelay,
+		onError: func(stationID uuid.UUID, err error) {
+			log.Printf("⚠ Forwarding readings of station %s failed: %v", stationID, err)
+		},
+	}
+}
+
+// enqueue hands a send to the background worker. It fails without
+// blocking if the queue is full.
+func (f *forwarder) enqueue(stationID uuid.UUID, send func(ctx context.Context) error) error {
+	f.start.Do(func() { go f.run() })
+
+	f.inFlight.Add(1)
+	select {
+	case f.sends <- forwardSend{stationID: stationID, send: send}:
+		return nil
+	default:
+		f.inFlight.Done()
+		return errForwardQueueFull
+	}
+}
+
+func (f *forwarder) run() {
+	for s := range f.sends {
+		f.deliver(s)
+		f.inFlight.Done()
+	}
+}
+
+// deliver tries a send until it succeeds, fails with an error not worth
+// retrying or runs out of attempts
+func (f *forwarder) deliver(s forwardSend) {
+	delay := f.retryDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), forwardTimeout)
+		err := s.send(ctx)
+		cancel()
+
+		var retryable *retryableError
+		if err == nil {
+			return
+		}
+		if !errors.As(err, &retryable) || attempt == forwardAttempts {
+			f.onError(s.stationID, err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// wait blocks until all queued sends are done
+func (f *forwarder) wait() {
+	f.inFlight.Wait()
+}
+
+// due reports whether the station may send again and records the attempt.
+// Failed sends are not retried before the interval passed either, so an
+// unavailable service is not flooded.
+func (f *forwarder) due(stationID uuid.UUID, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if last, ok := f.lastSent[stationID]; ok && now.Sub(last) < f.minInterval {
+		return false
+	}
+	f.lastSent[stationID] = now
+	return true
+}
+
+// forwardBuild prepares the send of a forwarder from the measurements of a
+// batch. A nil send means the batch has none of the values the service
+// takes.
+type forwardBuild func(values map[string]measurement) (func(ctx context.Context) error, error)
+
+// forward queues the outdoor values of a batch once per interval and
+// station if the owner consents to community forwarding and hasn't
+// disabled the forwarder. The interval only starts with a batch that has
+// values to send.
+func (f *forwarder) forward(name string, batch *Batch, build forwardBuild) error {
+	if len(batch.Readings) == 0 || !communityConsent(batch.Station) || !forwardingEnabled(batch.Station, name) {
+		return nil
+	}
+
+	send, err := build(f.measurements(batch))
+	if err != nil || send == nil || !f.due(batch.StationID, batch.ReceivedAt) {
+		return err
+	}
+	return f.enqueue(batch.StationID, send)
+}
+
+// sensor returns the sensor of a reading from the batch or the lookup
+func (f *forwarder) sensor(batch *Batch, sensorID uuid.UUID) (models.Sensor, bool) {
+	for _, s := range batch.Sensors {
+		if s.ID == sensorID {
+			return s, true
+		}
+	}
+
+	f.mu.Lock()
+	s, ok := f.sensors[sensorID]
+	f.mu.Unlock()
+	if ok || f.lookup == nil {
+		return s, ok
+	}
+
+	found, err := f.lookup.GetSensor(sensorID, false)
+	if err != nil {
+		return models.Sensor{}, false
+	}
+	f.mu.Lock()
+	f.sensors[sensorID] = found.Sensor
+	f.mu.Unlock()
+	return found.Sensor, true
+}
+
+// measurements maps the outdoor readings of a batch to the shared
+// measurements. Readings are additionally keyed by sensor ID, so single
+// sensors can be mapped explicitly.
+func (f *forwarder) measurements(batch *Batch) map[string]measurement {
+	result := make(map[string]measurement)
+	pressure, barometer := 0, 0
+
+	for _, r := range batch.Readings {
+		m := measurement{Value: r.Value, Unit: r.Unit, DateUTC: r.DateUTC, SensorID: r.SensorID}
+		result[r.SensorID.String()] = m
+
+		s, ok := f.sensor(batch, r.SensorID)
+		if !ok || !s.Enabled {
+			continue
+		}
+		if m.Unit == "" {
+			m.Unit = models.SensorTypeRegistry[s.SensorType].Unit
+		}
+		outdoor := !strings.EqualFold(s.Location, "Indoor")
+
+		if key, ok := weatherMeasurements[s.SensorType]; ok {
+			result[key] = m
+			continue
+		}
+		if barometerRank[s.SensorType] > barometer {
+			result[measurementBarometer] = m
+			barometer = barometerRank[s.SensorType]
+		}
+		switch s.SensorType {
+		case models.SensorTypeTemperatureOutdoor:
+			result[measurementTemperature] = m
+		case models.SensorTypeTemperature:
+			if outdoor {
+				result[measurementTemperature] = m
+			}
+		case models.SensorTypeHumidityOutdoor:
+			result[measurementHumidity] = m
+		case models.SensorTypeHumidity:
+			if outdoor {
+				result[measurementHumidity] = m
+			}
+		case models.SensorTypePressureAbsolute, models.SensorTypePressure, models.SensorTypePressureRelative:
+			if pressureRank[s.SensorType] > pressure {
+				result[measurementPressure] = m
+				pressure = pressureRank[s.SensorType]
+			}
+		case models.SensorTypePM25:
+			if outdoor {
+				result[measurementPM25] = m
+			}
+		case models.SensorTypePM10:
+			if outdoor {
+				result[measurementPM10] = m
+			}
+		}
+	}
+	return result
+}
+
+// post sends a JSON body and fails on non-2xx responses
+func (f *forwarder) post(ctx context.Context, url string, body []byte, header map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return statusError(resp.StatusCode, string(msg))
+	}
+	return nil
+}
+
+// get sends a request and returns the response body, failing on non-2xx
+// responses. Errors leave out the URL, which carries the credentials of
+// some services.
+func (f *forwarder) get(ctx context.Context, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", errors.New("invalid request URL")
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", statusError(resp.StatusCode, string(body))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// statusError returns the error of a non-2xx response; server errors and
+// rate limits are retried
+func statusError(status int, body string) error {
+	err := fmt.Errorf("status %d: %s", status, strings.TrimSpace(body))
+	if status >= 500 || status == http.StatusTooManyRequests {
+		return &retryableError{err: err}
+	}
+	return err
+}
+
+// configString returns a station config value as string; numeric IDs are
+// stored as numbers when set via the CLI
+func configString(station *models.StationData, key string) string {
+	if station == nil {
+		return ""
+	}
+	switch v := station.Config[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// communityConsent reports whether the owner of a station allows forwarding
+// its readings to community networks. Invalid sharing settings stop
+// forwarding, too.
+func communityConsent(station *models.StationData) bool {
+	if station == nil {
+		return false
+	}
+	policy, err := models.ParseSharingPolicy(station.Config)
+	return err == nil && policy.AllowsCommunity()
+}
+
+// forwardingEnabled reports whether a forwarder is enabled for a station,
+// see ForwardingConfigKey
+func forwardingEnabled(station *models.StationData, name string) bool {
+	if station == nil {
+		return false
+	}
+	targets, _ := station.Config[ForwardingConfigKey].(map[string]interface{})
+	enabled, ok := targets[name].(bool)
+	return !ok || enabled
+}
+
+// ValidateForwardingConfig checks the forwarding settings of a station
+// config: an object of forwarder names and booleans
+func ValidateForwardingConfig(config map[string]interface{}) error {
+	value, ok := config[ForwardingConfigKey]
+	if !ok || value == nil {
+		return nil
+	}
+	targets, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid %s config: must be an object of forwarder names and booleans", ForwardingConfigKey)
+	}
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		known := false
+		for _, n := range ForwarderNames {
+			known = known || n == name
+		}
+		if !known {
+			return fmt.Errorf("invalid %s config: unknown forwarder %q, expected one of %s", ForwardingConfigKey, name, strings.Join(ForwarderNames, ", "))
+		}
+		if _, ok := targets[name].(bool); !ok {
+			return fmt.Errorf("invalid %s config: %s must be true or false", ForwardingConfigKey, name)
+		}
+	}
+	return nil
+}
+
+// latestDate returns the time of the newest measurement
+func latestDate(values map[string]measurement) time.Time {
+	var latest time.Time
+	for _, m := range values {
+		if m.DateUTC.After(latest) {
+			latest = m.DateUTC
+		}
+	}
+	return latest
+}
+
+// formatValue formats a value for APIs that expect numbers as strings
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,508 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+type fakeSensorLookup struct {
+	sensors map[uuid.UUID]models.Sensor
+	calls   int
+}
+
+func (l *fakeSensorLookup) GetSensor(sensorID uuid.UUID, includeLatest bool) (*models.SensorWithLatestReading, error) {
+	l.calls++
+	return &models.SensorWithLatestReading{Sensor: l.sensors[sensorID]}, nil
+}
+
+// capturedRequest is a request received by the fake service
+type capturedRequest struct {
+	path   string
+	query  string
+	header http.Header
+	body   string
+}
+
+func newCaptureServer(t *testing.T, requests *[]capturedRequest) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, capturedRequest{path: r.URL.Path, query: r.URL.RawQuery, header: r.Header, body: string(body)})
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// processAndWait processes a batch and waits for the background sends of
+// the forwarder, returning the first error
+func processAndWait(hook Hook, f *forwarder, batch *Batch) error {
+	var sendErr error
+	f.onError = func(_ uuid.UUID, err error) {
+		if sendErr == nil {
+			sendErr = err
+		}
+	}
+	if err := hook.Process(context.Background(), batch); err != nil {
+		return err
+	}
+	f.wait()
+	return sendErr
+}
+
+// forwardTestBatch returns a pushed batch with indoor and outdoor sensors
+func forwardTestBatch(config map[string]interface{}) (*Batch, map[string]uuid.UUID) {
+	sensors := map[string]models.Sensor{
+		"tempinf":    {ID: uuid.New(), SensorType: models.SensorTypeTemperature, Location: "Indoor", Enabled: true},
+		"tempf":      {ID: uuid.New(), SensorType: models.SensorTypeTemperature, Location: "Outdoor", Enabled: true},
+		"humidity":   {ID: uuid.New(), SensorType: models.SensorTypeHumidity, Location: "Outdoor", Enabled: true},
+		"baromrelin": {ID: uuid.New(), SensorType: models.SensorTypePressureRelative, Location: "Indoor", Enabled: true},
+		"baromabsin": {ID: uuid.New(), SensorType: models.SensorTypePressureAbsolute, Location: "Indoor", Enabled: true},
+		"pm25_ch1":   {ID: uuid.New(), SensorType: models.SensorTypePM25, Location: "Outdoor", Enabled: true},
+		"windspeed":  {ID: uuid.New(), SensorType: models.SensorTypeWindSpeed, Enabled: true},
+		"dailyrain":  {ID: uuid.New(), SensorType: models.SensorTypeRainfallDaily, Enabled: true},
+	}
+	values := map[string]float64{"tempinf": 22, "tempf": 12.5, "humidity": 80, "baromrelin": 1020, "baromabsin": 980.5, "pm25_ch1": 7, "windspeed": 2, "dailyrain": 5}
+
+	ids := make(map[string]uuid.UUID)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	batch := &Batch{
+		StationID:  uuid.New(),
+		Station:    &models.StationData{Config: config},
+		Sensors:    sensors,
+		ReceivedAt: now,
+	}
+	for remoteID, s := range sensors {
+		ids[remoteID] = s.ID
+		batch.Readings = append(batch.Readings, models.SensorReading{SensorID: s.ID, Value: values[remoteID], DateUTC: now})
+	}
+	return batch, ids
+}
+
+func TestForwarder_Measurements(t *testing.T) {
+	batch, ids := forwardTestBatch(nil)
+
+	values := newForwarder(nil, 0).measurements(batch)
+
+	expected := map[string]float64{
+		measurementTemperature: 12.5,
+		measurementHumidity:    80,
+		measurementPressure:    980.5,
+		measurementPM25:        7,
+		measurementBarometer:   1020,
+		measurementWindSpeed:   2,
+		measurementRainDaily:   5,
+	}
+	for key, value := range expected {
+		if values[key].Value != value {
+			t.Errorf("Expected %s %.1f, got %.1f", key, value, values[key].Value)
+		}
+	}
+	if _, ok := values[measurementPM10]; ok {
+		t.Error("Expected no PM10 value")
+	}
+	if values[ids["tempinf"].String()].Value != 22 {
+		t.Error("Expected readings keyed by sensor ID")
+	}
+}
+
+func TestForwarder_MeasurementsWithLookup(t *testing.T) {
+	sensorID := uuid.New()
+	lookup := &fakeSensorLookup{sensors: map[uuid.UUID]models.Sensor{
+		sensorID: {ID: sensorID, SensorType: models.SensorTypeTemperatureOutdoor, Enabled: true},
+	}}
+	f := newForwarder(lookup, 0)
+	batch := &Batch{Readings: []models.SensorReading{{SensorID: sensorID, Value: 3}}}
+
+	f.measurements(batch)
+	values := f.measurements(batch)
+
+	if values[measurementTemperature].Value != 3 {
+		t.Errorf("Expected temperature from looked up sensor, got %v", values)
+	}
+	if lookup.calls != 1 {
+		t.Errorf("Expected sensor to be cached, got %d lookups", lookup.calls)
+	}
+}
+
+func TestSensorCommunityHook_Process(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewSensorCommunityHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{SensorCommunityIDConfigKey: "raspi-123"})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if requests[0].header.Get("X-Sensor") != "raspi-123" || requests[0].header.Get("X-Pin") != "1" {
+		t.Errorf("Unexpected headers %v", requests[0].header)
+	}
+
+	var body struct {
+		Values []struct {
+			ValueType string `json:"value_type"`
+			Value     string `json:"value"`
+		} `json:"sensordatavalues"`
+	}
+	json.Unmarshal([]byte(requests[1].body), &body)
+	values := make(map[string]string)
+	for _, v := range body.Values {
+		values[v.ValueType] = v.Value
+	}
+	if values["temperature"] != "12.50" || values["humidity"] != "80.00" || values["pressure"] != "98050.00" {
+		t.Errorf("Unexpected BME280 values %v", values)
+	}
+
+	// A second batch within the interval is not sent
+	batch.ReceivedAt = batch.ReceivedAt.Add(time.Minute)
+	processAndWait(hook, hook.forwarder, batch)
+	if len(requests) != 2 {
+		t.Errorf("Expected no requests within the interval, got %d", len(requests)-2)
+	}
+}
+
+func TestSensorCommunityHook_NotConfigured(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewSensorCommunityHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests, got %d", len(requests))
+	}
+}
+
+func TestSensorCommunityHook_WithoutConsent(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewSensorCommunityHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		SensorCommunityIDConfigKey: "raspi-1234",
+		models.SharingConfigKey:    map[string]interface{}{"license": "CC0-1.0", "consent": map[string]interface{}{"map": true}},
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests without community consent, got %d", len(requests))
+	}
+}
+
+func TestOpenSenseMapHook_Process(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewOpenSenseMapHook(nil)
+	hook.endpoint = server.URL
+
+	batch, ids := forwardTestBatch(nil)
+	batch.Station.Config = map[string]interface{}{
+		OpenSenseMapBoxConfigKey:   "box1",
+		OpenSenseMapTokenConfigKey: "secret",
+		OpenSenseMapSensorsConfigKey: map[string]interface{}{
+			"temperature":           "s-temp",
+			"pm10":                  "s-pm10",
+			ids["tempinf"].String(): "s-indoor",
+		},
+	}
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	if requests[0].path != "/boxes/box1/data" || requests[0].header.Get("Authorization") != "secret" {
+		t.Errorf("Unexpected request %s %v", requests[0].path, requests[0].header)
+	}
+
+	var data []struct {
+		Sensor    string `json:"sensor"`
+		Value     string `json:"value"`
+		CreatedAt string `json:"createdAt"`
+	}
+	json.Unmarshal([]byte(requests[0].body), &data)
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 values, got %v", data)
+	}
+	if data[0].Sensor != "s-indoor" || data[0].Value != "22.00" || data[1].Sensor != "s-temp" || data[1].Value != "12.50" {
+		t.Errorf("Unexpected values %v", data)
+	}
+	if data[1].CreatedAt != "2026-03-01T12:00:00Z" {
+		t.Errorf("Unexpected timestamp %s", data[1].CreatedAt)
+	}
+}
+
+func TestOpenSenseMapHook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusForbidden)
+	}))
+	defer server.Close()
+	hook := NewOpenSenseMapHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		OpenSenseMapBoxConfigKey:     "box1",
+		OpenSenseMapSensorsConfigKey: map[string]interface{}{"temperature": "s-temp"},
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
+
+func TestOpenSenseMapHook_Retry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	hook := NewOpenSenseMapHook(nil)
+	hook.endpoint = server.URL
+	hook.retryDelay = time.Millisecond
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		OpenSenseMapBoxConfigKey:     "box1",
+		OpenSenseMapSensorsConfigKey: map[string]interface{}{"temperature": "s-temp"},
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Expected the send to be retried, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 attempts, got %d", calls)
+	}
+}
+
+func TestForwarder_QueueFull(t *testing.T) {
+	f := newForwarder(nil, 0)
+	release := make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+
+	// One send is taken by the worker, the others fill the queue
+	var err error
+	for i := 0; i < forwardQueueSize+2 && err == nil; i++ {
+		err = f.enqueue(uuid.New(), block)
+	}
+	close(release)
+	f.wait()
+	if !errors.Is(err, errForwardQueueFull) {
+		t.Errorf("Expected errForwardQueueFull, got %v", err)
+	}
+}
+
+func TestForwarder_ForwardWithoutValues(t *testing.T) {
+	f := newForwarder(nil, time.Hour)
+	batch, _ := forwardTestBatch(map[string]interface{}{})
+	var sent int
+	send := func(ctx context.Context) error {
+		sent++
+		return nil
+	}
+
+	// A batch without values for the service doesn't start the interval
+	nothing := func(values map[string]measurement) (func(ctx context.Context) error, error) { return nil, nil }
+	if err := f.forward("test", batch, nothing); err != nil {
+		t.Fatalf("forward failed: %v", err)
+	}
+	something := func(values map[string]measurement) (func(ctx context.Context) error, error) { return send, nil }
+	if err := f.forward("test", batch, something); err != nil {
+		t.Fatalf("forward failed: %v", err)
+	}
+	f.wait()
+	if sent != 1 {
+		t.Errorf("Expected 1 send, got %d", sent)
+	}
+}
+
+func TestSensorCommunityHook_ForwardingDisabled(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewSensorCommunityHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		SensorCommunityIDConfigKey: "raspi-1234",
+		ForwardingConfigKey:        map[string]interface{}{"sensor_community": false},
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests with forwarding disabled, got %d", len(requests))
+	}
+}
+
+func TestPWSWeatherHook_Process(t *testing.T) {
+	var requests []capturedRequest
+	server := newCaptureServer(t, &requests)
+	hook := NewPWSWeatherHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		PWSWeatherIDConfigKey:  "VIENNA1",
+		PWSWeatherKeyConfigKey: "secret",
+		ForwardingConfigKey:    map[string]interface{}{"awekas": false},
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(requests))
+	}
+	params, _ := url.ParseQuery(requests[0].query)
+	expected := map[string]string{
+		"ID":           "VIENNA1",
+		"PASSWORD":     "secret",
+		"dateutc":      "2026-03-01 12:00:00",
+		"tempf":        "54.50",
+		"humidity":     "80.00",
+		"dewptf":       "48.46",
+		"baromin":      "30.12",
+		"windspeedmph": "4.47",
+		"dailyrainin":  "0.20",
+	}
+	for key, value := range expected {
+		if params.Get(key) != value {
+			t.Errorf("Expected %s %s, got %q", key, value, params.Get(key))
+		}
+	}
+
+	// A second batch within the interval is not sent
+	batch.ReceivedAt = batch.ReceivedAt.Add(time.Minute)
+	processAndWait(hook, hook.forwarder, batch)
+	if len(requests) != 1 {
+		t.Errorf("Expected no requests within the interval, got %d", len(requests)-1)
+	}
+}
+
+func TestPWSWeatherHook_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ERROR: Not a valid Station ID"))
+	}))
+	defer server.Close()
+	hook := NewPWSWeatherHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{PWSWeatherIDConfigKey: "VIENNA1", PWSWeatherKeyConfigKey: "secret"})
+	err := processAndWait(hook, hook.forwarder, batch)
+	if err == nil || !strings.Contains(err.Error(), "Not a valid Station ID") {
+		t.Errorf("Expected rejected upload error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected error without credentials, got %v", err)
+	}
+}
+
+func TestAwekasHook_Process(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+	hook := NewAwekasHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{
+		AwekasUserConfigKey:       "vienna",
+		AwekasPasswordConfigKey:   "secret",
+		AwekasLanguageConfigKey:   "de",
+		models.LatitudeConfigKey:  48.21,
+		models.LongitudeConfigKey: 16.37,
+	})
+	if err := processAndWait(hook, hook.forwarder, batch); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	params, _ := url.ParseQuery(query)
+	values := strings.Split(params.Get("val"), ";")
+	if len(values) != awekasValueCount {
+		t.Fatalf("Expected %d values, got %d: %v", awekasValueCount, len(values), values)
+	}
+	expected := map[int]string{
+		0:  "vienna",
+		1:  "5ebe2294ecd0e0f08eab7690d2a6ee69",
+		2:  "01.03.2026",
+		3:  "12:00",
+		4:  "12.50",
+		5:  "80.00",
+		6:  "1020.00",
+		7:  "5.00",
+		8:  "7.20",
+		13: "de",
+		23: "16.37",
+		24: "48.21",
+	}
+	for position, value := range expected {
+		if values[position] != value {
+			t.Errorf("Expected %q at position %d, got %q", value, position, values[position])
+		}
+	}
+	if values[9] != "" || values[15] != "" {
+		t.Errorf("Expected empty values without sensors, got %v", values)
+	}
+}
+
+func TestAwekasHook_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Benutzer/Passwort Fehler"))
+	}))
+	defer server.Close()
+	hook := NewAwekasHook(nil)
+	hook.endpoint = server.URL
+
+	batch, _ := forwardTestBatch(map[string]interface{}{AwekasUserConfigKey: "vienna", AwekasPasswordConfigKey: "wrong"})
+	if err := processAndWait(hook, hook.forwarder, batch); err == nil || !strings.Contains(err.Error(), "Passwort") {
+		t.Errorf("Expected rejected upload error, got %v", err)
+	}
+}
+
+func TestValidateForwardingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"unset", nil, false},
+		{"valid", map[string]interface{}{"pwsweather": true, "awekas": false}, false},
+		{"unknown forwarder", map[string]interface{}{"windy": false}, true},
+		{"not a boolean", map[string]interface{}{"awekas": "off"}, true},
+		{"not an object", "awekas", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateForwardingConfig(map[string]interface{}{ForwardingConfigKey: tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package ingest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// HighFrequencyBuffer keeps the latest raw readings of high-frequency
+// sensors in memory. Each sensor has a ring of fixed capacity; readings
+// older than the retention are not returned.
+type HighFrequencyBuffer struct {
+	mu        sync.Mutex
+	capacity  int
+	retention time.Duration
+	rings     map[uuid.UUID]*readingRing
+	timeFunc  func() time.Time
+}
+
+// readingRing is a fixed-size ring of readings in arrival order
+type readingRing struct {
+	readings []models.SensorReading
+	next     int
+	full     bool
+}
+
+// NewHighFrequencyBuffer creates a buffer keeping up to capacity readings
+// per sensor for retention
+func NewHighFrequencyBuffer(capacity int, retention time.Duration) *HighFrequencyBuffer {
+	return &HighFrequencyBuffer{
+		capacity:  max(capacity, 1),
+		retention: retention,
+		rings:     make(map[uuid.UUID]*readingRing),
+		timeFunc:  time.Now,
+	}
+}
+
+// Add stores a reading, overwriting the oldest one of a full ring
+func (b *HighFrequencyBuffer) Add(reading models.SensorReading) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ring, ok := b.rings[reading.SensorID]
+	if !ok {
+		ring = &readingRing{readings: make([]models.SensorReading, b.capacity)}
+		b.rings[reading.SensorID] = ring
+	}
+	ring.readings[ring.next] = reading
+	ring.next = (ring.next + 1) % b.capacity
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// Readings returns the buffered readings of a sensor since a time, oldest
+// first
+func (b *HighFrequencyBuffer) Readings(sensorID uuid.UUID, since time.Time) []models.SensorReading {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cutoff := b.timeFunc().Add(-b.retention); since.Before(cutoff) {
+		since = cutoff
+	}
+
+	result := []models.SensorReading{}
+	ring, ok := b.rings[sensorID]
+	if !ok {
+		return result
+	}
+	n := ring.next
+	start := 0
+	if ring.full {
+		n = b.capacity
+		start = ring.next
+	}
+	for i := 0; i < n; i++ {
+		r := ring.readings[(start+i)%b.capacity]
+		if !r.DateUTC.Before(since) {
+			result = append(result, r)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].DateUTC.Before(result[j].DateUTC) })
+	return result
+}
+
+// Has reports whether readings of a sensor were buffered
+func (b *HighFrequencyBuffer) Has(sensorID uuid.UUID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.rings[sensorID]
+	return ok
+}
+
+// highFrequencyLateness is how long a minute stays open after it ended, so
+// readings arriving out of order are folded into its aggregate
+const highFrequencyLateness = 30 * time.Second
+
+// minuteKey identifies the minute of a sensor
+type minuteKey struct {
+	sensorID uuid.UUID
+	minute   time.Time
+}
+
+// minuteAggregate collects the values of a sensor within one minute
+type minuteAggregate struct {
+	stationID  uuid.UUID
+	sensorType string
+	unit       string
+	values     []float64
+	// inFlight is set while the aggregate is part of a batch that is not
+	// stored yet
+	inFlight bool
+}
+
+// reading returns the aggregated reading of the minute, dated at its start
+func (a *minuteAggregate) reading(key minuteKey) models.SensorReading {
+	return models.SensorReading{
+		SensorID: key.sensorID,
+		Value:    models.AggregateHighFrequency(a.sensorType, a.values),
+		Unit:     a.unit,
+		DateUTC:  key.minute,
+	}
+}
+
+// HighFrequencyHook separates the readings of high-frequency sensors (the
+// high_frequency station config key) from the main store: raw readings go
+// to a HighFrequencyBuffer and are replaced by one aggregated reading per
+// minute. A minute is stored with the first batch of its station received
+// highFrequencyLateness after the minute ended; late readings of an open
+// minute are folded into its aggregate. Aggregates are kept until their
+// batch was stored and added to the next batch of the station otherwise.
+type HighFrequencyHook struct {
+	buffer  *HighFrequencyBuffer
+	mu      sync.Mutex
+	pending map[minuteKey]*minuteAggregate
+	// stored is the latest stored minute per sensor; readings of stored
+	// minutes only go to the buffer
+	stored map[uuid.UUID]time.Time
+	// batches are the aggregates added to batches not stored yet
+	batches map[*Batch][]minuteKey
+}
+
+// NewHighFrequencyHook creates a new HighFrequencyHook writing raw readings
+// to buffer
+func NewHighFrequencyHook(buffer *HighFrequencyBuffer) *HighFrequencyHook {
+	return &HighFrequencyHook{
+		buffer:  buffer,
+		pending: make(map[minuteKey]*minuteAggregate),
+		stored:  make(map[uuid.UUID]time.Time),
+		batches: make(map[*Batch][]minuteKey),
+	}
+}
+
+// Name returns the hook name
+func (h *HighFrequencyHook) Name() string { return "high_frequency" }
+
+// Stage returns the hook stage
+func (h *HighFrequencyHook) Stage() Stage { return StageDerivation }
+
+// Process moves the readings of high-frequency sensors into the buffer and
+// adds the aggregates of closed minutes to the batch
+func (h *HighFrequencyHook) Process(ctx context.Context, batch *Batch) error {
+	if batch.Station == nil {
+		return nil
+	}
+	designated, err := models.ParseHighFrequencySensors(batch.Station.Config)
+	if err != nil {
+		return err
+	}
+
+	// Pulled batches carry no sensors and can only be matched by sensor ID
+	sensors := make(map[uuid.UUID]models.Sensor, len(batch.Sensors))
+	for _, s := range batch.Sensors {
+		sensors[s.ID] = s
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	kept := batch.Readings[:0]
+	for _, r := range batch.Readings {
+		sensor, ok := sensors[r.SensorID]
+		if !ok {
+			sensor = models.Sensor{ID: r.SensorID}
+		}
+		if !designated.Contains(sensor) {
+			kept = append(kept, r)
+			continue
+		}
+
+		h.buffer.Add(r)
+		key := minuteKey{sensorID: r.SensorID, minute: r.DateUTC.UTC().Truncate(time.Minute)}
+		if last, ok := h.stored[r.SensorID]; ok && !key.minute.After(last) {
+			continue
+		}
+		agg, ok := h.pending[key]
+		if !ok {
+			agg = &minuteAggregate{stationID: batch.StationID, sensorType: sensor.SensorType, unit: r.Unit}
+			h.pending[key] = agg
+		}
+		agg.values = append(agg.values, r.Value)
+	}
+
+	// Closed minutes of the station, including those of batches that
+	// failed to store
+	var keys []minuteKey
+	for key, agg := range h.pending {
+		if agg.stationID == batch.StationID && !agg.inFlight && len(agg.values) > 0 &&
+			!batch.ReceivedAt.Before(key.minute.Add(time.Minute+highFrequencyLateness)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].minute.Before(keys[j].minute) })
+	for _, key := range keys {
+		h.pending[key].inFlight = true
+		kept = append(kept, h.pending[key].reading(key))
+	}
+	if len(keys) > 0 {
+		h.batches[batch] = keys
+	}
+
+	batch.Readings = kept
+	return nil
+}
+
+// Stored removes the aggregates of a stored batch. Aggregates of a batch
+// that failed are added to the next batch of the station.
+func (h *HighFrequencyHook) Stored(ctx context.Context, batch *Batch, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys, ok := h.batches[batch]
+	if !ok {
+		return
+	}
+	delete(h.batches, batch)
+	for _, key := range keys {
+		if err != nil {
+			h.pending[key].inFlight = false
+			continue
+		}
+		delete(h.pending, key)
+		if key.minute.After(h.stored[key.sensorID]) {
+			h.stored[key.sensorID] = key.minute
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestHighFrequencyBuffer(t *testing.T) {
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	buffer := NewHighFrequencyBuffer(3, time.Hour)
+	buffer.timeFunc = func() time.Time { return now }
+	sensorID := uuid.New()
+
+	for i := 0; i < 5; i++ {
+		buffer.Add(models.SensorReading{SensorID: sensorID, Value: float64(i), DateUTC: now.Add(time.Duration(i-5) * time.Second)})
+	}
+
+	readings := buffer.Readings(sensorID, time.Time{})
+	if len(readings) != 3 || readings[0].Value != 2 || readings[2].Value != 4 {
+		t.Errorf("Expected the latest 3 readings, got %+v", readings)
+	}
+	if readings := buffer.Readings(sensorID, now.Add(-time.Second)); len(readings) != 1 {
+		t.Errorf("Expected 1 reading since a second ago, got %d", len(readings))
+	}
+
+	// Readings older than the retention are not returned
+	now = now.Add(2 * time.Hour)
+	if readings := buffer.Readings(sensorID, time.Time{}); len(readings) != 0 {
+		t.Errorf("Expected no readings after the retention, got %d", len(readings))
+	}
+	if readings := buffer.Readings(uuid.New(), time.Time{}); readings == nil || len(readings) != 0 {
+		t.Errorf("Expected an empty list for an unknown sensor, got %v", readings)
+	}
+}
+
+func TestHighFrequencyHook(t *testing.T) {
+	stationID := uuid.New()
+	wind := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeWindSpeed, RemoteID: "rapid_wind"}
+	gust := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeWindGust, RemoteID: "rapid_gust"}
+	temp := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeTemperature, RemoteID: "temp"}
+	station := &models.StationData{ID: stationID, Config: map[string]interface{}{
+		models.HighFrequencyConfigKey: []interface{}{"rapid_wind", "rapid_gust"},
+	}}
+	sensors := map[string]models.Sensor{wind.RemoteID: wind, gust.RemoteID: gust, temp.RemoteID: temp}
+
+	minute := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	buffer := NewHighFrequencyBuffer(100, time.Hour)
+	buffer.timeFunc = func() time.Time { return minute.Add(2 * time.Minute) }
+	hook := NewHighFrequencyHook(buffer)
+
+	batch := func(at time.Time, readings ...models.SensorReading) *Batch {
+		b := &Batch{StationID: stationID, Station: station, Sensors: sensors, Readings: readings, ReceivedAt: at}
+		if err := hook.Process(context.Background(), b); err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		return b
+	}
+
+	// Raw readings within the minute are buffered, others pass through
+	b := batch(minute.Add(3*time.Second),
+		models.SensorReading{SensorID: wind.ID, Value: 2, DateUTC: minute.Add(3 * time.Second)},
+		models.SensorReading{SensorID: gust.ID, Value: 5, DateUTC: minute.Add(3 * time.Second)},
+		models.SensorReading{SensorID: temp.ID, Value: 21, DateUTC: minute.Add(3 * time.Second)},
+	)
+	if len(b.Readings) != 1 || b.Readings[0].SensorID != temp.ID {
+		t.Fatalf("Expected only the temperature to be stored, got %+v", b.Readings)
+	}
+	batch(minute.Add(6*time.Second),
+		models.SensorReading{SensorID: wind.ID, Value: 4, DateUTC: minute.Add(6 * time.Second)},
+		models.SensorReading{SensorID: gust.ID, Value: 8, DateUTC: minute.Add(6 * time.Second)},
+	)
+
+	// A late reading of the open minute is folded into its aggregate
+	b = batch(minute.Add(63*time.Second),
+		models.SensorReading{SensorID: wind.ID, Value: 10, DateUTC: minute.Add(63 * time.Second)},
+		models.SensorReading{SensorID: wind.ID, Value: 6, DateUTC: minute.Add(9 * time.Second)},
+	)
+	if len(b.Readings) != 0 {
+		t.Fatalf("Expected the minute to stay open for late readings, got %+v", b.Readings)
+	}
+
+	// The first batch after the lateness stores the aggregate of the minute
+	b = batch(minute.Add(93*time.Second),
+		models.SensorReading{SensorID: wind.ID, Value: 12, DateUTC: minute.Add(93 * time.Second)},
+	)
+	stored := make(map[uuid.UUID]models.SensorReading)
+	for _, r := range b.Readings {
+		stored[r.SensorID] = r
+	}
+	if r := stored[wind.ID]; r.Value != 4 || !r.DateUTC.Equal(minute) {
+		t.Errorf("Expected the mean wind of the first minute, got %+v", r)
+	}
+	if r := stored[gust.ID]; r.Value != 8 || !r.DateUTC.Equal(minute) {
+		t.Errorf("Expected the maximum gust of the first minute, got %+v", r)
+	}
+	if len(b.Readings) != 2 {
+		t.Errorf("Expected 2 aggregated readings, got %+v", b.Readings)
+	}
+
+	if readings := buffer.Readings(wind.ID, time.Time{}); len(readings) != 5 {
+		t.Errorf("Expected 5 buffered wind readings, got %d", len(readings))
+	}
+
+	// A failed store keeps the aggregates for the next batch
+	hook.Stored(context.Background(), b, errors.New("storage unavailable"))
+	b = batch(minute.Add(96 * time.Second))
+	if len(b.Readings) != 2 {
+		t.Fatalf("Expected the aggregates to be added again, got %+v", b.Readings)
+	}
+	hook.Stored(context.Background(), b, nil)
+	if b = batch(minute.Add(99 * time.Second)); len(b.Readings) != 0 {
+		t.Errorf("Expected stored aggregates to be removed, got %+v", b.Readings)
+	}
+
+	// Readings of a stored minute only go to the buffer
+	b = batch(minute.Add(3*time.Minute),
+		models.SensorReading{SensorID: wind.ID, Value: 1, DateUTC: minute.Add(30 * time.Second)},
+	)
+	for _, r := range b.Readings {
+		if r.DateUTC.Equal(minute) {
+			t.Errorf("Expected no reading of the stored minute, got %+v", r)
+		}
+	}
+}
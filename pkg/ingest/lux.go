@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// LuxConversionHook converts the readings of sensors that report
+// illuminance in lux to solar radiation in W/m². The sensors and their
+// factors are configured per station with the lux_conversion config key;
+// the lux value is kept as raw value.
+type LuxConversionHook struct{}
+
+// NewLuxConversionHook creates a new LuxConversionHook
+func NewLuxConversionHook() *LuxConversionHook {
+	return &LuxConversionHook{}
+}
+
+// Name returns the hook name
+func (h *LuxConversionHook) Name() string { return "lux_conversion" }
+
+// Stage returns the hook stage
+func (h *LuxConversionHook) Stage() Stage { return StageCalibration }
+
+// Process converts the readings of configured sensors
+func (h *LuxConversionHook) Process(ctx context.Context, batch *Batch) error {
+	if batch.Station == nil || len(batch.Readings) == 0 {
+		return nil
+	}
+	conversion, err := models.ParseLuxConversion(batch.Station.Config)
+	if err != nil || len(conversion) == 0 {
+		return err
+	}
+
+	// Pulled batches carry no sensors and can only be matched by sensor ID
+	sensors := make(map[uuid.UUID]models.Sensor, len(batch.Sensors))
+	for _, s := range batch.Sensors {
+		sensors[s.ID] = s
+	}
+
+	for i := range batch.Readings {
+		sensor, ok := sensors[batch.Readings[i].SensorID]
+		if !ok {
+			sensor = models.Sensor{ID: batch.Readings[i].SensorID}
+		}
+		if factor, ok := conversion.Factor(sensor); ok {
+			models.ConvertLux(&batch.Readings[i], factor)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestLuxConversionHook(t *testing.T) {
+	solar := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeSolarRadiation, RemoteID: "solarradiation"}
+	other := models.Sensor{ID: uuid.New(), SensorType: models.SensorTypeSolarRadiation, RemoteID: "solar2"}
+	pulled := uuid.New()
+
+	batch := &Batch{
+		Station: &models.StationData{Config: map[string]interface{}{
+			models.LuxConversionConfigKey: map[string]interface{}{
+				"solarradiation": 0.01,
+				pulled.String():  true,
+			},
+		}},
+		Sensors: map[string]models.Sensor{solar.RemoteID: solar, other.RemoteID: other},
+		Readings: []models.SensorReading{
+			{SensorID: solar.ID, Value: 50000},
+			{SensorID: other.ID, Value: 400},
+			{SensorID: pulled, Value: 10000},
+		},
+	}
+
+	if err := NewLuxConversionHook().Process(context.Background(), batch); err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+
+	converted := batch.Readings[0]
+	if converted.Value != 500 || converted.Unit != "W/m²" || converted.RawUnit != models.UnitLux || *converted.RawValue != 50000 {
+		t.Errorf("unexpected converted reading: %+v", converted)
+	}
+	if batch.Readings[1].Value != 400 || batch.Readings[1].RawValue != nil {
+		t.Errorf("unconfigured sensor was converted: %+v", batch.Readings[1])
+	}
+	if got := batch.Readings[2].Value; math.Abs(got-10000*models.DefaultLuxFactor) > 1e-9 {
+		t.Errorf("sensor matched by ID = %v, want default factor", got)
+	}
+}
+
+func TestLuxConversionHook_InvalidConfig(t *testing.T) {
+	batch := &Batch{
+		Station: &models.StationData{Config: map[string]interface{}{
+			models.LuxConversionConfigKey: map[string]interface{}{"solarradiation": -1.0},
+		}},
+		Readings: []models.SensorReading{{SensorID: uuid.New(), Value: 1}},
+	}
+	if err := NewLuxConversionHook().Process(context.Background(), batch); err == nil {
+		t.Error("expected error for negative factor")
+	}
+}
@@ -0,0 +1,113 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// OpenSenseMapBoxConfigKey is the station config key with the senseBox ID
+	OpenSenseMapBoxConfigKey = "opensensemap_box_id"
+
+	// OpenSenseMapTokenConfigKey is the station config key with the access
+	// token of the senseBox
+	OpenSenseMapTokenConfigKey = "opensensemap_token"
+
+	// OpenSenseMapSensorsConfigKey is the station config key that maps
+	// measurements (temperature, humidity, pressure, pm25, pm10) or
+	// WeatherMaestro sensor IDs to the sensor IDs of the senseBox
+	OpenSenseMapSensorsConfigKey = "opensensemap_sensors"
+
+	openSenseMapInterval = time.Minute
+	openSenseMapEndpoint = "https://api.opensensemap.org"
+)
+
+// OpenSenseMapHook forwards readings to the senseBox of a station on
+// openSenseMap
+type OpenSenseMapHook struct {
+	*forwarder
+	endpoint string
+}
+
+// NewOpenSenseMapHook creates a new OpenSenseMapHook
+func NewOpenSenseMapHook(lookup SensorLookup) *OpenSenseMapHook {
+	return &OpenSenseMapHook{
+		forwarder: newForwarder(lookup, openSenseMapInterval),
+		endpoint:  openSenseMapEndpoint,
+	}
+}
+
+// Name returns the hook name
+func (h *OpenSenseMapHook) Name() string { return "opensensemap" }
+
+// Stage returns the hook stage
+func (h *OpenSenseMapHook) Stage() Stage { return StageForwarding }
+
+// Process posts the measurements the station maps to openSenseMap sensor
+// IDs to its senseBox in one request, at most once a minute per station;
+// batches in between are dropped.
+func (h *OpenSenseMapHook) Process(ctx context.Context, batch *Batch) error {
+	if batch.Station == nil {
+		return nil
+	}
+	boxID := configString(batch.Station, OpenSenseMapBoxConfigKey)
+	mapping, _ := batch.Station.Config[OpenSenseMapSensorsConfigKey].(map[string]interface{})
+	if boxID == "" || len(mapping) == 0 {
+		return nil
+	}
+	token := configString(batch.Station, OpenSenseMapTokenConfigKey)
+	return h.forward(h.Name(), batch, func(values map[string]measurement) (func(ctx context.Context) error, error) {
+		return h.build(boxID, token, mapping, values)
+	})
+}
+
+// build returns the upload of the mapped values, or nil without mapped
+// values
+func (h *OpenSenseMapHook) build(boxID, token string, mapping map[string]interface{}, values map[string]measurement) (func(ctx context.Context) error, error) {
+	type dataValue struct {
+		Sensor    string `json:"sensor"`
+		Value     string `json:"value"`
+		CreatedAt string `json:"createdAt"`
+	}
+	var data []dataValue
+	for key, target := range mapping {
+		sensorID, ok := target.(string)
+		if !ok || sensorID == "" {
+			continue
+		}
+		m, ok := values[strings.ToLower(key)]
+		if !ok {
+			continue
+		}
+		data = append(data, dataValue{
+			Sensor:    sensorID,
+			Value:     formatValue(m.Value),
+			CreatedAt: m.DateUTC.UTC().Format(time.RFC3339),
+		})
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Sensor < data[j].Sensor })
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]string{}
+	if token != "" {
+		header["Authorization"] = token
+	}
+	endpoint := fmt.Sprintf("%s/boxes/%s/data", h.endpoint, url.PathEscape(boxID))
+	return func(ctx context.Context) error {
+		if err := h.post(ctx, endpoint, body, header); err != nil {
+			return fmt.Errorf("failed to send to openSenseMap: %w", err)
+		}
+		return nil
+	}, nil
+}
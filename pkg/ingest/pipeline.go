@@ -0,0 +1,275 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Stage determines where in the ingest path a hook runs.
+// Hooks run in ascending stage order; hooks within the same stage run in
+// registration order.
+type Stage int
+
+const (
+	// StageQC hooks validate readings and may drop implausible values.
+	StageQC Stage = iota
+	// StageCalibration hooks adjust raw values (offsets, scaling, clock skew).
+	StageCalibration
+	// StageDerivation hooks add readings computed from other readings.
+	StageDerivation
+	// StageForwarding hooks send stored readings to external services.
+	StageForwarding
+	// StageAlerting hooks evaluate stored readings against alert rules.
+	StageAlerting
+)
+
+// String returns the lower-case name of the stage.
+func (s Stage) String() string {
+	switch s {
+	case StageQC:
+		return "qc"
+	case StageCalibration:
+		return "calibration"
+	case StageDerivation:
+		return "derivation"
+	case StageForwarding:
+		return "forwarding"
+	case StageAlerting:
+		return "alerting"
+	default:
+		return fmt.Sprintf("stage(%d)", int(s))
+	}
+}
+
+// PreStore reports whether hooks of this stage run before readings are stored.
+func (s Stage) PreStore() bool {
+	return s < StageForwarding
+}
+
+// Batch is a set of readings for a single station travelling through the pipeline.
+type Batch struct {
+	StationID  uuid.UUID
+	Station    *models.StationData
+	Sensors    map[string]models.Sensor // keyed by remote ID
+	Readings   []models.SensorReading
+	Source     string // "push" or "pull"
+	ReceivedAt time.Time
+	SourceIP   string // address of the pushing station
+}
+
+// Hook is a single processing step in the ingest pipeline.
+// Pre-store hooks may modify batch.Readings in place; an error aborts the batch
+// before anything is stored. Errors from post-store hooks are logged and
+// recorded in the hook metrics but never fail the ingest.
+type Hook interface {
+	Name() string
+	Stage() Stage
+	Process(ctx context.Context, batch *Batch) error
+}
+
+// StoreObserver is implemented by pre-store hooks that keep state about the
+// batches they processed and must only commit it once the batch is stored.
+// Stored is called after the store with its error, or with the error of a
+// later pre-store hook that aborted the batch.
+type StoreObserver interface {
+	Stored(ctx context.Context, batch *Batch, err error)
+}
+
+// StoreFunc persists the readings of a batch.
+type StoreFunc func(ctx context.Context, batch *Batch) error
+
+// HookMetrics contains runtime statistics for a registered hook
+type HookMetrics struct {
+	Name          string    `json:"name"`
+	Stage         string    `json:"stage"`
+	Enabled       bool      `json:"enabled"`
+	Invocations   uint64    `json:"invocations"`
+	Failures      uint64    `json:"failures"`
+	TotalDuration float64   `json:"total_duration_ms"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastRun       time.Time `json:"last_run,omitempty"`
+}
+
+type hookEntry struct {
+	hook    Hook
+	enabled bool
+	metrics HookMetrics
+}
+
+// Pipeline runs batches through the registered hooks and the store function
+type Pipeline struct {
+	mu    sync.RWMutex
+	hooks []*hookEntry
+	store StoreFunc
+}
+
+// NewPipeline creates a new Pipeline that persists batches with store
+func NewPipeline(store StoreFunc) *Pipeline {
+	return &Pipeline{store: store}
+}
+
+// Register adds a hook to the pipeline. Registering a hook with a name that is
+// already in use replaces the previous hook but keeps its enabled state.
+func (p *Pipeline) Register(h Hook) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	enabled := true
+	for i, e := range p.hooks {
+		if e.hook.Name() == h.Name() {
+			enabled = e.enabled
+			p.hooks = append(p.hooks[:i], p.hooks[i+1:]...)
+			break
+		}
+	}
+
+	p.hooks = append(p.hooks, &hookEntry{hook: h, enabled: enabled})
+	sort.SliceStable(p.hooks, func(i, j int) bool {
+		return p.hooks[i].hook.Stage() < p.hooks[j].hook.Stage()
+	})
+}
+
+// SetEnabled enables or disables the hook with the given name
+func (p *Pipeline) SetEnabled(name string, enabled bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.hooks {
+		if e.hook.Name() == name {
+			e.enabled = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("hook not found: %s", name)
+}
+
+// Metrics returns a snapshot of all hook metrics in execution order
+func (p *Pipeline) Metrics() []HookMetrics {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]HookMetrics, 0, len(p.hooks))
+	for _, e := range p.hooks {
+		m := e.metrics
+		m.Name = e.hook.Name()
+		m.Stage = e.hook.Stage().String()
+		m.Enabled = e.enabled
+		result = append(result, m)
+	}
+	return result
+}
+
+// Process runs the batch through all enabled pre-store hooks, stores it and
+// then runs all enabled post-store hooks.
+func (p *Pipeline) Process(ctx context.Context, batch *Batch) error {
+	if batch.ReceivedAt.IsZero() {
+		batch.ReceivedAt = time.Now().UTC()
+	}
+
+	p.mu.RLock()
+	entries := make([]*hookEntry, 0, len(p.hooks))
+	for _, e := range p.hooks {
+		if e.enabled {
+			entries = append(entries, e)
+		}
+	}
+	p.mu.RUnlock()
+
+	stored := false
+	// ran are the pre-store hooks that processed the batch
+	var ran []*hookEntry
+	for _, e := range entries {
+		if !e.hook.Stage().PreStore() && !stored {
+			if err := p.storeAndNotify(ctx, batch, ran); err != nil {
+				return err
+			}
+			stored = true
+		}
+
+		err := p.runHook(ctx, e, batch)
+		if err == nil {
+			if e.hook.Stage().PreStore() {
+				ran = append(ran, e)
+			}
+			continue
+		}
+		if e.hook.Stage().PreStore() {
+			err = fmt.Errorf("ingest hook %s failed: %w", e.hook.Name(), err)
+			notifyStored(ctx, ran, batch, err)
+			return err
+		}
+		log.Printf("❌ Ingest hook %s failed: %v", e.hook.Name(), err)
+	}
+
+	if !stored {
+		return p.storeAndNotify(ctx, batch, ran)
+	}
+	return nil
+}
+
+// storeAndNotify stores a batch and tells the pre-store hooks that
+// processed it whether it was stored
+func (p *Pipeline) storeAndNotify(ctx context.Context, batch *Batch, ran []*hookEntry) error {
+	err := p.storeBatch(ctx, batch)
+	notifyStored(ctx, ran, batch, err)
+	return err
+}
+
+// notifyStored calls the hooks implementing StoreObserver
+func notifyStored(ctx context.Context, entries []*hookEntry, batch *Batch, err error) {
+	for _, e := range entries {
+		if o, ok := e.hook.(StoreObserver); ok {
+			o.Stored(ctx, batch, err)
+		}
+	}
+}
+
+func (p *Pipeline) storeBatch(ctx context.Context, batch *Batch) error {
+	if p.store == nil || len(batch.Readings) == 0 {
+		return nil
+	}
+	if err := p.store(ctx, batch); err != nil {
+		return fmt.Errorf("failed to store readings: %w", err)
+	}
+	return nil
+}
+
+func (p *Pipeline) runHook(ctx context.Context, e *hookEntry, batch *Batch) error {
+	start := time.Now()
+	err := e.hook.Process(ctx, batch)
+	elapsed := time.Since(start)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.metrics.Invocations++
+	e.metrics.TotalDuration += float64(elapsed.Microseconds()) / 1000
+	e.metrics.LastRun = start.UTC()
+	if err != nil {
+		e.metrics.Failures++
+		e.metrics.LastError = err.Error()
+	}
+	return err
+}
+
+// HookFunc adapts a plain function to the Hook interface
+type HookFunc struct {
+	HookName  string
+	HookStage Stage
+	Fn        func(ctx context.Context, batch *Batch) error
+}
+
+// Name returns the hook name
+func (h HookFunc) Name() string { return h.HookName }
+
+// Stage returns the hook stage
+func (h HookFunc) Stage() Stage { return h.HookStage }
+
+// Process calls the wrapped function
+func (h HookFunc) Process(ctx context.Context, batch *Batch) error { return h.Fn(ctx, batch) }
@@ -0,0 +1,189 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func recordingHook(name string, stage Stage, calls *[]string, err error) HookFunc {
+	return HookFunc{
+		HookName:  name,
+		HookStage: stage,
+		Fn: func(ctx context.Context, batch *Batch) error {
+			*calls = append(*calls, name)
+			return err
+		},
+	}
+}
+
+func testBatch() *Batch {
+	return &Batch{
+		StationID: uuid.New(),
+		Readings:  []models.SensorReading{{SensorID: uuid.New(), Value: 21.5}},
+		Source:    "push",
+	}
+}
+
+func TestPipeline_Order(t *testing.T) {
+	var calls []string
+	p := NewPipeline(func(ctx context.Context, batch *Batch) error {
+		calls = append(calls, "store")
+		return nil
+	})
+
+	p.Register(recordingHook("alert", StageAlerting, &calls, nil))
+	p.Register(recordingHook("forward", StageForwarding, &calls, nil))
+	p.Register(recordingHook("derive", StageDerivation, &calls, nil))
+	p.Register(recordingHook("qc", StageQC, &calls, nil))
+	p.Register(recordingHook("calibrate", StageCalibration, &calls, nil))
+
+	if err := p.Process(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	expected := "qc,calibrate,derive,store,forward,alert"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected call order %s, got %s", expected, got)
+	}
+}
+
+func TestPipeline_Errors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		stage       Stage
+		expectError bool
+		expectStore bool
+	}{
+		{name: "Pre-store failure aborts batch", stage: StageQC, expectError: true, expectStore: false},
+		{name: "Post-store failure is ignored", stage: StageAlerting, expectError: false, expectStore: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			stored := false
+			p := NewPipeline(func(ctx context.Context, batch *Batch) error {
+				stored = true
+				return nil
+			})
+			p.Register(recordingHook("failing", tc.stage, &calls, errors.New("boom")))
+
+			err := p.Process(context.Background(), testBatch())
+			if tc.expectError && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if stored != tc.expectStore {
+				t.Errorf("Expected stored=%v, got %v", tc.expectStore, stored)
+			}
+
+			metrics := p.Metrics()
+			if len(metrics) != 1 || metrics[0].Failures != 1 || metrics[0].LastError != "boom" {
+				t.Errorf("Unexpected metrics: %+v", metrics)
+			}
+		})
+	}
+}
+
+// observingHook records the store results it is notified of
+type observingHook struct {
+	HookFunc
+	results []error
+}
+
+func (h *observingHook) Stored(ctx context.Context, batch *Batch, err error) {
+	h.results = append(h.results, err)
+}
+
+func TestPipeline_StoreObserver(t *testing.T) {
+	storeErr := errors.New("storage unavailable")
+	testCases := []struct {
+		name      string
+		storeErr  error
+		failAfter bool
+		wantErr   bool
+	}{
+		{name: "Stored", storeErr: nil},
+		{name: "Store failed", storeErr: storeErr, wantErr: true},
+		{name: "Later hook failed", failAfter: true, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			p := NewPipeline(func(ctx context.Context, batch *Batch) error { return tc.storeErr })
+			hook := &observingHook{HookFunc: recordingHook("observer", StageCalibration, &calls, nil)}
+			p.Register(hook)
+			if tc.failAfter {
+				p.Register(recordingHook("failing", StageDerivation, &calls, errors.New("boom")))
+			}
+
+			err := p.Process(context.Background(), testBatch())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(hook.results) != 1 || (hook.results[0] != nil) != tc.wantErr {
+				t.Errorf("Expected one notification with error %v, got %v", tc.wantErr, hook.results)
+			}
+		})
+	}
+}
+
+func TestPipeline_SetEnabled(t *testing.T) {
+	var calls []string
+	p := NewPipeline(nil)
+	p.Register(recordingHook("qc", StageQC, &calls, nil))
+
+	if err := p.SetEnabled("qc", false); err != nil {
+		t.Fatalf("SetEnabled failed: %v", err)
+	}
+	if err := p.Process(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected disabled hook not to run, got calls %v", calls)
+	}
+	if p.Metrics()[0].Enabled {
+		t.Error("Expected metrics to report hook as disabled")
+	}
+
+	if err := p.SetEnabled("unknown", true); err == nil {
+		t.Error("Expected error for unknown hook")
+	}
+
+	// Re-registering keeps the enabled state
+	p.Register(recordingHook("qc", StageQC, &calls, nil))
+	if len(p.Metrics()) != 1 || p.Metrics()[0].Enabled {
+		t.Error("Expected re-registered hook to stay disabled")
+	}
+}
+
+func TestPipeline_PreStoreHookModifiesBatch(t *testing.T) {
+	var storedCount int
+	p := NewPipeline(func(ctx context.Context, batch *Batch) error {
+		storedCount = len(batch.Readings)
+		return nil
+	})
+	p.Register(HookFunc{
+		HookName:  "add-reading",
+		HookStage: StageQC,
+		Fn: func(ctx context.Context, batch *Batch) error {
+			batch.Readings = append(batch.Readings, models.SensorReading{SensorID: uuid.New(), Value: 1})
+			return nil
+		},
+	})
+
+	if err := p.Process(context.Background(), testBatch()); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if storedCount != 2 {
+		t.Errorf("Expected 2 stored readings, got %d", storedCount)
+	}
+}
@@ -0,0 +1,122 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const (
+	// PWSWeatherIDConfigKey is the station config key with the PWSWeather
+	// station ID
+	PWSWeatherIDConfigKey = "pwsweather_id"
+
+	// PWSWeatherKeyConfigKey is the station config key with the API key of
+	// the PWSWeather station
+	PWSWeatherKeyConfigKey = "pwsweather_api_key"
+
+	// pwsWeatherInterval keeps uploads within the rate limit of free
+	// PWSWeather accounts
+	pwsWeatherInterval = 5 * time.Minute
+
+	pwsWeatherEndpoint = "https://pwsupdate.pwsweather.com/api/v1/submitwx"
+)
+
+// pwsWeatherField maps a measurement to a parameter of the upload API,
+// which takes the imperial units of the Weather Underground protocol
+type pwsWeatherField struct {
+	measurement string
+	param       string
+	unit        string
+}
+
+var pwsWeatherFields = []pwsWeatherField{
+	{measurementTemperature, "tempf", "°F"},
+	{measurementHumidity, "humidity", "%"},
+	{measurementBarometer, "baromin", "inHg"},
+	{measurementWindSpeed, "windspeedmph", "mph"},
+	{measurementWindGust, "windgustmph", "mph"},
+	{measurementWindDirection, "winddir", "°"},
+	{measurementRainHourly, "rainin", "in"},
+	{measurementRainDaily, "dailyrainin", "in"},
+	{measurementSolarRadiation, "solarradiation", "W/m²"},
+	{measurementUVIndex, "UV", "index"},
+}
+
+// PWSWeatherHook forwards outdoor readings to PWSWeather for stations with
+// a PWSWeather station ID and API key in their config
+type PWSWeatherHook struct {
+	*forwarder
+	endpoint string
+}
+
+// NewPWSWeatherHook creates a new PWSWeatherHook
+func NewPWSWeatherHook(lookup SensorLookup) *PWSWeatherHook {
+	return &PWSWeatherHook{
+		forwarder: newForwarder(lookup, pwsWeatherInterval),
+		endpoint:  pwsWeatherEndpoint,
+	}
+}
+
+// Name returns the hook name
+func (h *PWSWeatherHook) Name() string { return "pwsweather" }
+
+// Stage returns the hook stage
+func (h *PWSWeatherHook) Stage() Stage { return StageForwarding }
+
+// Process sends the weather values of the batch in imperial units, plus
+// the dew point, to PWSWeather using the Weather Underground upload
+// protocol. A station uploads every five minutes at most; batches in
+// between are dropped.
+func (h *PWSWeatherHook) Process(ctx context.Context, batch *Batch) error {
+	stationID := configString(batch.Station, PWSWeatherIDConfigKey)
+	apiKey := configString(batch.Station, PWSWeatherKeyConfigKey)
+	if stationID == "" || apiKey == "" {
+		return nil
+	}
+	return h.forward(h.Name(), batch, func(values map[string]measurement) (func(ctx context.Context) error, error) {
+		return h.build(stationID, apiKey, values), nil
+	})
+}
+
+// build returns the upload of the values, or nil without PWSWeather values
+func (h *PWSWeatherHook) build(stationID, apiKey string, values map[string]measurement) func(ctx context.Context) error {
+	params := url.Values{}
+	for _, field := range pwsWeatherFields {
+		if m, ok := values[field.measurement]; ok {
+			if v, ok := m.in(field.unit); ok {
+				params.Set(field.param, formatValue(v))
+			}
+		}
+	}
+	temp, okTemp := values[measurementTemperature]
+	humidity, okHumidity := values[measurementHumidity]
+	if okTemp && okHumidity && temp.Unit == "°C" {
+		dewPoint, _ := models.ConvertUnit(models.DewPoint(temp.Value, humidity.Value), "°C", "°F")
+		params.Set("dewptf", formatValue(dewPoint))
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	params.Set("ID", stationID)
+	params.Set("PASSWORD", apiKey)
+	params.Set("dateutc", latestDate(values).UTC().Format("2006-01-02 15:04:05"))
+	params.Set("softwaretype", "WeatherMaestro")
+	rawURL := h.endpoint + "?" + params.Encode()
+	return func(ctx context.Context) error {
+		body, err := h.get(ctx, rawURL)
+		if err != nil {
+			return fmt.Errorf("failed to send to PWSWeather: %w", err)
+		}
+		// Rejected uploads are answered with status 200, too
+		if strings.HasPrefix(strings.ToLower(body), "error") || strings.Contains(body, `"error"`) {
+			return fmt.Errorf("failed to send to PWSWeather: %s", body)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,510 @@
+package ingest
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// compactThreshold is the number of acknowledged entries after which the
+// queue file is rewritten to contain only pending entries.
+const compactThreshold = 1000
+
+// minRetryAge is the minimum age of an entry before the drainer picks it up,
+// so it does not race the request that is still processing it.
+const minRetryAge = 30 * time.Second
+
+// QueueEntry is a raw station payload waiting to be processed
+type QueueEntry struct {
+	ID         uint64     `json:"id"`
+	Source     string     `json:"source"`
+	Payload    url.Values `json:"payload"`
+	ReceivedAt time.Time  `json:"received_at"`
+	// SourceIP is the address the payload was pushed from
+	SourceIP string `json:"source_ip,omitempty"`
+	// Signed is set when the webhook signature of the payload was verified
+	// on receipt, or the payload came from a trusted source like a broker
+	Signed bool `json:"signed,omitempty"`
+	// Credentials names the payload parameters holding credentials, like
+	// pass keys and passwords. They are encrypted in the queue file.
+	Credentials []string `json:"-"`
+	// Attempts counts the failed replays of the entry
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// queueRecord is a single line of the write-ahead log
+type queueRecord struct {
+	Op    string      `json:"op"` // "put", "ack", "fail" or "seq"
+	ID    uint64      `json:"id"`
+	Entry *QueueEntry `json:"entry,omitempty"`
+	// Sealed holds the encrypted credentials of the entry
+	Sealed string `json:"sealed,omitempty"`
+}
+
+// Queue is a durable, file-backed FIFO of raw payloads.
+// Entries are appended and fsynced before they are processed and only removed
+// once acknowledged, giving at-least-once processing across restarts.
+// Entries that keep failing are moved to the dead letters in path + ".dead".
+// IDs are never reused, the last assigned ID survives compaction.
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	nextID  uint64
+	pending map[uint64]QueueEntry
+	acked   int
+	// aead encrypts the credentials of entries with the key stored next
+	// to the queue file
+	aead cipher.AEAD
+}
+
+// OpenQueue opens or creates the queue file at path and replays all entries
+// that have not been acknowledged yet. The key encrypting credentials is
+// kept in path + ".key" and created on first use; entries whose
+// credentials can't be decrypted are dropped.
+func OpenQueue(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	aead, err := loadQueueKey(path + ".key")
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{
+		path:    path,
+		nextID:  1,
+		pending: make(map[uint64]QueueEntry),
+		aead:    aead,
+	}
+
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+
+	// Rewrite the file with only pending entries, dropping acknowledged
+	// entries and any torn record left behind by a crash
+	if err := q.compact(); err != nil {
+		return nil, fmt.Errorf("failed to compact queue file: %w", err)
+	}
+
+	return q, nil
+}
+
+// replay rebuilds the pending set from the queue file
+func (q *Queue) replay() error {
+	file, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open queue file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec queueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the end of the file is expected after a crash
+			log.Printf("⚠ Skipping corrupt ingest queue record: %v", err)
+			continue
+		}
+
+		switch rec.Op {
+		case "put":
+			if rec.Entry != nil {
+				entry, err := q.unseal(rec)
+				if err != nil {
+					log.Printf("⚠ Skipping ingest queue entry %d: %v", rec.ID, err)
+					break
+				}
+				q.pending[rec.ID] = entry
+			}
+		case "ack":
+			delete(q.pending, rec.ID)
+			q.acked++
+		case "fail":
+			if entry, ok := q.pending[rec.ID]; ok {
+				entry.Attempts++
+				q.pending[rec.ID] = entry
+			}
+			q.acked++
+		}
+		if rec.ID >= q.nextID {
+			q.nextID = rec.ID + 1
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Put appends a payload to the queue and returns its ID.
+// The entry is synced to disk before Put returns.
+func (q *Queue) Put(source string, payload url.Values, receivedAt time.Time, sourceIP string) (uint64, error) {
+	return q.PutEntry(QueueEntry{Source: source, Payload: payload, ReceivedAt: receivedAt, SourceIP: sourceIP})
+}
+
+// PutEntry appends an entry to the queue and returns its ID, which
+// replaces the ID of the entry. The entry is synced to disk before PutEntry
+// returns.
+func (q *Queue) PutEntry(entry QueueEntry) (uint64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry.ID = q.nextID
+	entry.ReceivedAt = entry.ReceivedAt.UTC()
+
+	rec, err := q.seal(entry)
+	if err != nil {
+		return 0, err
+	}
+	if err := q.write(rec); err != nil {
+		return 0, err
+	}
+	if err := q.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync queue file: %w", err)
+	}
+
+	q.nextID++
+	q.pending[entry.ID] = entry
+	return entry.ID, nil
+}
+
+// Ack marks an entry as processed
+func (q *Queue) Ack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.pending[id]; !ok {
+		return nil
+	}
+
+	return q.ack(id)
+}
+
+// ack removes a pending entry. Must be called with q.mu held.
+func (q *Queue) ack(id uint64) error {
+	if err := q.write(queueRecord{Op: "ack", ID: id}); err != nil {
+		return err
+	}
+
+	delete(q.pending, id)
+	q.acked++
+	q.compactIfDue()
+	return nil
+}
+
+// Fail records a failed replay of an entry and returns its number of
+// failed attempts
+func (q *Queue) Fail(id uint64) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.pending[id]
+	if !ok {
+		return 0, nil
+	}
+	// The count is advisory, so the record is not synced
+	if err := q.write(queueRecord{Op: "fail", ID: id}); err != nil {
+		return entry.Attempts, err
+	}
+
+	entry.Attempts++
+	q.pending[id] = entry
+	// Like acks, fail records are dropped by the next compaction
+	q.acked++
+	q.compactIfDue()
+	return entry.Attempts, nil
+}
+
+// DeadLetter moves an entry to the dead letters, where it is kept for
+// inspection but no longer replayed
+func (q *Queue) DeadLetter(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.pending[id]
+	if !ok {
+		return nil
+	}
+	rec, err := q.seal(entry)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue record: %w", err)
+	}
+
+	file, err := os.OpenFile(q.deadPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letters: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dead letters: %w", err)
+	}
+	return q.ack(id)
+}
+
+// DeadLetters returns the entries moved to the dead letters, oldest first
+func (q *Queue) DeadLetters() ([]QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	file, err := os.Open(q.deadPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead letters: %w", err)
+	}
+	defer file.Close()
+
+	var entries []QueueEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec queueRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Entry == nil {
+			continue
+		}
+		entry, err := q.unseal(rec)
+		if err != nil {
+			log.Printf("⚠ Skipping dead letter %d: %v", rec.ID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+func (q *Queue) deadPath() string {
+	return q.path + ".dead"
+}
+
+// compactIfDue compacts the queue file once enough records are obsolete.
+// Must be called with q.mu held.
+func (q *Queue) compactIfDue() {
+	if q.acked >= compactThreshold {
+		if err := q.compact(); err != nil {
+			log.Printf("⚠ Failed to compact ingest queue: %v", err)
+		}
+	}
+}
+
+// Pending returns all unacknowledged entries ordered by ID
+func (q *Queue) Pending() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]QueueEntry, 0, len(q.pending))
+	for _, e := range q.pending {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+// Len returns the number of unacknowledged entries
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Close closes the queue file
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.file.Close()
+}
+
+func (q *Queue) write(rec queueRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue record: %w", err)
+	}
+	if _, err := q.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write queue record: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the queue file with only the pending entries.
+// Must be called with q.mu held.
+func (q *Queue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint64, 0, len(q.pending))
+	for id := range q.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	w := bufio.NewWriter(tmp)
+	// The sequence record keeps IDs increasing when no entry is pending
+	if q.nextID > 1 {
+		line, err := json.Marshal(queueRecord{Op: "seq", ID: q.nextID - 1})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	for _, id := range ids {
+		rec, err := q.seal(q.pending[id])
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		line, err := json.Marshal(rec)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file = file
+	q.acked = 0
+	return nil
+}
+
+// ProcessFunc processes a queued entry. Returning an error leaves the entry
+// in the queue to be retried later.
+type ProcessFunc func(entry QueueEntry) error
+
+// Drainer periodically retries all pending queue entries
+type Drainer struct {
+	queue       *Queue
+	process     ProcessFunc
+	interval    time.Duration
+	maxAttempts int
+	stopChan    chan struct{}
+	doneChan    chan struct{}
+}
+
+// NewDrainer creates a new Drainer. Entries failing maxAttempts times are
+// moved to the dead letters so they don't block the queue; 0 retries them
+// forever.
+func NewDrainer(queue *Queue, process ProcessFunc, interval time.Duration, maxAttempts int) *Drainer {
+	return &Drainer{
+		queue:       queue,
+		process:     process,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		stopChan:    make(chan struct{}),
+		doneChan:    make(chan struct{}),
+	}
+}
+
+// Start begins draining the queue in the background
+func (d *Drainer) Start() {
+	go d.run()
+	log.Println("✓ Ingest queue drainer started")
+}
+
+// Stop halts the drainer and waits for the current drain to finish
+func (d *Drainer) Stop() {
+	close(d.stopChan)
+	<-d.doneChan
+	log.Println("✓ Ingest queue drainer stopped")
+}
+
+func (d *Drainer) run() {
+	defer close(d.doneChan)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	// Replay entries left over from a previous run immediately
+	d.Drain()
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case <-ticker.C:
+			d.Drain()
+		}
+	}
+}
+
+// Drain processes pending entries in order until one fails.
+// It returns the number of entries that were processed successfully.
+func (d *Drainer) Drain() int {
+	processed := 0
+	cutoff := time.Now().Add(-minRetryAge)
+	for _, entry := range d.queue.Pending() {
+		if entry.ReceivedAt.After(cutoff) {
+			break
+		}
+		if err := d.process(entry); err != nil {
+			attempts, failErr := d.queue.Fail(entry.ID)
+			if failErr != nil {
+				log.Printf("❌ Failed to record failed ingest queue entry %d: %v", entry.ID, failErr)
+			}
+			if d.maxAttempts <= 0 || attempts < d.maxAttempts {
+				log.Printf("⚠ Ingest queue entry %d still pending: %v", entry.ID, err)
+				break
+			}
+			if dlErr := d.queue.DeadLetter(entry.ID); dlErr != nil {
+				log.Printf("❌ Failed to move ingest queue entry %d to the dead letters: %v", entry.ID, dlErr)
+				break
+			}
+			log.Printf("❌ Moved ingest queue entry %d to the dead letters after %d attempts: %v", entry.ID, attempts, err)
+			continue
+		}
+		if err := d.queue.Ack(entry.ID); err != nil {
+			log.Printf("❌ Failed to acknowledge ingest queue entry %d: %v", entry.ID, err)
+			break
+		}
+		processed++
+	}
+
+	if processed > 0 {
+		log.Printf("✓ Replayed %d queued ingest payloads, %d pending", processed, d.queue.Len())
+	}
+	return processed
+}
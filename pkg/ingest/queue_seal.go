@@ -0,0 +1,120 @@
+package ingest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// queueKeySize is the size of the AES-256 key encrypting the credentials
+// of queued payloads
+const queueKeySize = 32
+
+// loadQueueKey reads the key encrypting the credentials of queued
+// payloads, creating it if it doesn't exist yet
+func loadQueueKey(path string) (cipher.AEAD, error) {
+	key, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, queueKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to create queue key: %w", err)
+		}
+		if err := os.WriteFile(path, key, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write queue key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read queue key: %w", err)
+	}
+	if len(key) != queueKeySize {
+		return nil, fmt.Errorf("queue key %s must be %d bytes, got %d", path, queueKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the put record of an entry with its credentials moved from
+// the payload into the encrypted part of the record
+func (q *Queue) seal(entry QueueEntry) (queueRecord, error) {
+	rec := queueRecord{Op: "put", ID: entry.ID, Entry: &entry}
+
+	secrets := url.Values{}
+	for _, param := range entry.Credentials {
+		if values, ok := entry.Payload[param]; ok {
+			secrets[param] = values
+		}
+	}
+	if len(secrets) == 0 {
+		return rec, nil
+	}
+
+	payload := make(url.Values, len(entry.Payload))
+	for key, values := range entry.Payload {
+		if _, ok := secrets[key]; !ok {
+			payload[key] = values
+		}
+	}
+	entry.Payload = payload
+
+	nonce := make([]byte, q.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return rec, fmt.Errorf("failed to encrypt credentials: %w", err)
+	}
+	// The ID is authenticated, so sealed credentials can't be moved to
+	// another entry
+	sealed := q.aead.Seal(nonce, nonce, []byte(secrets.Encode()), queueRecordID(entry.ID))
+	rec.Sealed = base64.StdEncoding.EncodeToString(sealed)
+	return rec, nil
+}
+
+// unseal returns the entry of a put record with its credentials decrypted
+// back into the payload
+func (q *Queue) unseal(rec queueRecord) (QueueEntry, error) {
+	entry := *rec.Entry
+	if rec.Sealed == "" {
+		return entry, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(rec.Sealed)
+	if err != nil || len(sealed) < q.aead.NonceSize() {
+		return entry, errors.New("malformed credentials")
+	}
+	nonce, ciphertext := sealed[:q.aead.NonceSize()], sealed[q.aead.NonceSize():]
+	plaintext, err := q.aead.Open(nil, nonce, ciphertext, queueRecordID(rec.ID))
+	if err != nil {
+		return entry, fmt.Errorf("failed to decrypt credentials: %w", err)
+	}
+	secrets, err := url.ParseQuery(string(plaintext))
+	if err != nil {
+		return entry, fmt.Errorf("malformed credentials: %w", err)
+	}
+
+	payload := make(url.Values, len(entry.Payload)+len(secrets))
+	for key, values := range entry.Payload {
+		payload[key] = values
+	}
+	entry.Credentials = make([]string, 0, len(secrets))
+	for key, values := range secrets {
+		payload[key] = values
+		entry.Credentials = append(entry.Credentials, key)
+	}
+	sort.Strings(entry.Credentials)
+	entry.Payload = payload
+	return entry, nil
+}
+
+// queueRecordID returns the additional data binding sealed credentials to
+// their entry
+func queueRecordID(id uint64) []byte {
+	return []byte(strconv.FormatUint(id, 10))
+}
@@ -0,0 +1,288 @@
+package ingest
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTestQueue(t *testing.T, path string) *Queue {
+	t.Helper()
+
+	q, err := OpenQueue(path)
+	if err != nil {
+		t.Fatalf("Failed to open queue: %v", err)
+	}
+	return q
+}
+
+func TestQueue_PutAckReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue", "ingest.log")
+	q := openTestQueue(t, path)
+
+	payload := url.Values{"PASSKEY": {"abc"}, "tempf": {"70.5"}}
+	first, err := q.Put("Ecowitt", payload, time.Now(), "")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	second, err := q.Put("Ecowitt", payload, time.Now(), "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if second <= first {
+		t.Errorf("Expected increasing IDs, got %d then %d", first, second)
+	}
+
+	if err := q.Ack(first); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	q.Close()
+
+	// Reopen and verify only the unacknowledged entry is replayed
+	q = openTestQueue(t, path)
+	defer q.Close()
+
+	pending := q.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending entry, got %d", len(pending))
+	}
+	if pending[0].ID != second {
+		t.Errorf("Expected pending ID %d, got %d", second, pending[0].ID)
+	}
+	if pending[0].Payload.Get("tempf") != "70.5" || pending[0].Source != "Ecowitt" || pending[0].SourceIP != "192.168.1.10" {
+		t.Errorf("Unexpected pending entry: %+v", pending[0])
+	}
+
+	// New IDs continue after the replayed ones
+	third, err := q.Put("Ecowitt", payload, time.Now(), "")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if third <= second {
+		t.Errorf("Expected ID after %d, got %d", second, third)
+	}
+}
+
+func TestQueue_TornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ingest.log")
+	q := openTestQueue(t, path)
+	if _, err := q.Put("Ecowitt", url.Values{"a": {"1"}}, time.Now(), ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	q.Close()
+
+	// Simulate a crash in the middle of a write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		t.Fatalf("Failed to open queue file: %v", err)
+	}
+	f.WriteString(`{"op":"put","id":2,"entry":{"id":2,`)
+	f.Close()
+
+	q = openTestQueue(t, path)
+	if q.Len() != 1 {
+		t.Errorf("Expected 1 pending entry after torn write, got %d", q.Len())
+	}
+	if _, err := q.Put("Ecowitt", url.Values{"b": {"2"}}, time.Now(), ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	q.Close()
+
+	q = openTestQueue(t, path)
+	defer q.Close()
+	if q.Len() != 2 {
+		t.Errorf("Expected 2 pending entries, got %d", q.Len())
+	}
+}
+
+func TestDrainer_Drain(t *testing.T) {
+	testCases := []struct {
+		name              string
+		failAt            int
+		expectedProcessed int
+		expectedPending   int
+	}{
+		{name: "All succeed", failAt: -1, expectedProcessed: 3, expectedPending: 0},
+		{name: "Stops at first failure", failAt: 1, expectedProcessed: 1, expectedPending: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := openTestQueue(t, filepath.Join(t.TempDir(), "ingest.log"))
+			defer q.Close()
+
+			old := time.Now().Add(-time.Hour)
+			for i := 0; i < 3; i++ {
+				if _, err := q.Put("Ecowitt", url.Values{}, old, ""); err != nil {
+					t.Fatalf("Put failed: %v", err)
+				}
+			}
+			// Recent entries are left for the request that queued them
+			if _, err := q.Put("Ecowitt", url.Values{}, time.Now(), ""); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+
+			calls := 0
+			d := NewDrainer(q, func(entry QueueEntry) error {
+				defer func() { calls++ }()
+				if calls == tc.failAt {
+					return errors.New("storage unavailable")
+				}
+				return nil
+			}, time.Minute, 0)
+
+			if processed := d.Drain(); processed != tc.expectedProcessed {
+				t.Errorf("Expected %d processed, got %d", tc.expectedProcessed, processed)
+			}
+			if q.Len() != tc.expectedPending+1 {
+				t.Errorf("Expected %d pending, got %d", tc.expectedPending+1, q.Len())
+			}
+		})
+	}
+}
+
+func TestQueue_PutEntryKeepsSignature(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ingest.log")
+	q := openTestQueue(t, path)
+
+	id, err := q.PutEntry(QueueEntry{ID: 42, Source: "Ecowitt", Payload: url.Values{"PASSKEY": {"abc"}}, ReceivedAt: time.Now(), Signed: true})
+	if err != nil {
+		t.Fatalf("PutEntry failed: %v", err)
+	}
+	if id == 42 {
+		t.Error("Expected the queue to assign the ID")
+	}
+	q.Close()
+
+	q = openTestQueue(t, path)
+	defer q.Close()
+	pending := q.Pending()
+	if len(pending) != 1 || pending[0].ID != id || !pending[0].Signed {
+		t.Errorf("Expected replayed signed entry %d, got %+v", id, pending)
+	}
+}
+
+func TestQueue_EncryptsCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ingest.log")
+	q := openTestQueue(t, path)
+
+	payload := url.Values{"PASSKEY": {"passkey-1234"}, "tempf": {"70.5"}}
+	if _, err := q.PutEntry(QueueEntry{Source: "Ecowitt", Payload: payload, ReceivedAt: time.Now(), Credentials: []string{"PASSKEY"}}); err != nil {
+		t.Fatalf("PutEntry failed: %v", err)
+	}
+	q.Close()
+
+	assertNotInFile := func() {
+		t.Helper()
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read queue file: %v", err)
+		}
+		if strings.Contains(string(content), "passkey-1234") {
+			t.Errorf("Expected the pass key to be encrypted, got %s", content)
+		}
+	}
+	assertNotInFile()
+
+	// Reopening compacts the file
+	q = openTestQueue(t, path)
+	pending := q.Pending()
+	if len(pending) != 1 || pending[0].Payload.Get("PASSKEY") != "passkey-1234" || pending[0].Payload.Get("tempf") != "70.5" {
+		t.Fatalf("Expected the replayed payload with pass key, got %+v", pending)
+	}
+	q.Close()
+	assertNotInFile()
+
+	// Without the key the credentials are lost, the entry can't be replayed
+	if err := os.Remove(path + ".key"); err != nil {
+		t.Fatalf("Failed to remove key: %v", err)
+	}
+	q = openTestQueue(t, path)
+	defer q.Close()
+	if n := q.Len(); n != 0 {
+		t.Errorf("Expected the entry to be dropped without key, got %d pending", n)
+	}
+}
+
+func TestQueue_IDsSurviveCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ingest.log")
+	q := openTestQueue(t, path)
+
+	id, err := q.Put("Ecowitt", url.Values{}, time.Now(), "")
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := q.Ack(id); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	q.Close()
+
+	// Reopening compacts the file to no entries
+	for i := 0; i < 2; i++ {
+		q = openTestQueue(t, path)
+		next, err := q.Put("Ecowitt", url.Values{}, time.Now(), "")
+		if err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if next <= id {
+			t.Fatalf("Expected an ID after %d, got %d", id, next)
+		}
+		id = next
+		if err := q.Ack(id); err != nil {
+			t.Fatalf("Ack failed: %v", err)
+		}
+		q.Close()
+	}
+}
+
+func TestDrainer_DeadLetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ingest.log")
+	q := openTestQueue(t, path)
+
+	old := time.Now().Add(-time.Hour)
+	poisoned, err := q.PutEntry(QueueEntry{Source: "Ecowitt", Payload: url.Values{"PASSKEY": {"abc"}}, ReceivedAt: old, Credentials: []string{"PASSKEY"}})
+	if err != nil {
+		t.Fatalf("PutEntry failed: %v", err)
+	}
+	if _, err := q.Put("Ecowitt", url.Values{}, old, ""); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	d := NewDrainer(q, func(entry QueueEntry) error {
+		if entry.ID == poisoned {
+			return errors.New("invalid payload")
+		}
+		return nil
+	}, time.Minute, 2)
+
+	if processed := d.Drain(); processed != 0 {
+		t.Errorf("Expected the failing entry to block the queue, got %d processed", processed)
+	}
+	q.Close()
+
+	// Attempts are counted across restarts
+	q = openTestQueue(t, path)
+	defer q.Close()
+	if pending := q.Pending(); len(pending) != 2 || pending[0].Attempts != 1 {
+		t.Fatalf("Expected 1 failed attempt, got %+v", pending)
+	}
+	d = NewDrainer(q, d.process, time.Minute, 2)
+	if processed := d.Drain(); processed != 1 {
+		t.Errorf("Expected the entry after the dead letter to be processed, got %d", processed)
+	}
+	if q.Len() != 0 {
+		t.Errorf("Expected no pending entries, got %d", q.Len())
+	}
+
+	dead, err := q.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != poisoned || dead[0].Attempts != 2 || dead[0].Payload.Get("PASSKEY") != "abc" {
+		t.Errorf("Expected the failing entry as dead letter, got %+v", dead)
+	}
+}
@@ -0,0 +1,49 @@
+package ingest
+
+import (
+	"context"
+	"log"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// RecordStore persists all-time sensor extremes
+type RecordStore interface {
+	UpdateSensorRecords(ctx context.Context, readings []models.SensorReading) error
+}
+
+// RecordsHook keeps the all-time minimum and maximum of every sensor up to
+// date. It runs with the derivation hooks but only updates the records once
+// the readings are stored, so readings of a failed store never become
+// records.
+type RecordsHook struct {
+	store RecordStore
+}
+
+// NewRecordsHook creates a new RecordsHook
+func NewRecordsHook(store RecordStore) *RecordsHook {
+	return &RecordsHook{store: store}
+}
+
+// Name returns the hook name
+func (h *RecordsHook) Name() string { return "records" }
+
+// Stage returns the hook stage
+func (h *RecordsHook) Stage() Stage { return StageDerivation }
+
+// Process leaves the batch to Stored
+func (h *RecordsHook) Process(ctx context.Context, batch *Batch) error {
+	return nil
+}
+
+// Stored updates the records with the readings of a stored batch.
+// A failed update is logged but does not reject the batch; the records can
+// be rebuilt from the stored readings at any time.
+func (h *RecordsHook) Stored(ctx context.Context, batch *Batch, err error) {
+	if err != nil || len(batch.Readings) == 0 {
+		return
+	}
+	if err := h.store.UpdateSensorRecords(ctx, batch.Readings); err != nil {
+		log.Printf("❌ Failed to update records for station %s: %v", batch.StationID, err)
+	}
+}
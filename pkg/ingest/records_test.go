@@ -0,0 +1,57 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+type fakeRecordStore struct {
+	calls int
+	err   error
+}
+
+func (s *fakeRecordStore) UpdateSensorRecords(ctx context.Context, readings []models.SensorReading) error {
+	s.calls++
+	return s.err
+}
+
+func TestRecordsHook_Process(t *testing.T) {
+	testCases := []struct {
+		name          string
+		readings      []models.SensorReading
+		storeErr      error
+		recordErr     error
+		expectedErr   bool
+		expectedCalls int
+	}{
+		{name: "Updates records", readings: []models.SensorReading{{SensorID: uuid.New(), Value: 1}}, expectedCalls: 1},
+		{name: "Empty batch is skipped", readings: nil, expectedCalls: 0},
+		{name: "Record error does not reject batch", readings: []models.SensorReading{{SensorID: uuid.New(), Value: 1}}, recordErr: errors.New("boom"), expectedCalls: 1},
+		{name: "Failed store is skipped", readings: []models.SensorReading{{SensorID: uuid.New(), Value: 1}}, storeErr: errors.New("boom"), expectedErr: true, expectedCalls: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeRecordStore{err: tc.recordErr}
+			pipeline := NewPipeline(func(ctx context.Context, batch *Batch) error {
+				if store.calls != 0 {
+					t.Error("Expected records to be updated after the store")
+				}
+				return tc.storeErr
+			})
+			pipeline.Register(NewRecordsHook(store))
+
+			err := pipeline.Process(context.Background(), &Batch{Readings: tc.readings})
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("Expected error %v, got %v", tc.expectedErr, err)
+			}
+			if store.calls != tc.expectedCalls {
+				t.Errorf("Expected %d record updates, got %d", tc.expectedCalls, store.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// SensorCommunityIDConfigKey is the station config key with the
+	// Sensor.Community sensor ID, e.g. "raspi-1234567890"
+	SensorCommunityIDConfigKey = "sensor_community_id"
+
+	// sensorCommunityInterval matches the measuring interval of the
+	// Sensor.Community firmware
+	sensorCommunityInterval = 145 * time.Second
+
+	sensorCommunityEndpoint = "https://api.sensor.community/v1/push-sensor-data/"
+)
+
+// sensorCommunityPin is a sensor slot of the Sensor.Community API. The pin
+// tells the API which kind of sensor the values come from.
+type sensorCommunityPin struct {
+	pin    string
+	values []sensorCommunityValue
+}
+
+// sensorCommunityValue maps a measurement to a value type of the API
+type sensorCommunityValue struct {
+	measurement string
+	valueType   string
+	scale       float64
+}
+
+// sensorCommunityPins lists the emulated sensors: an SDS011 for particulate
+// matter and a BME280 for temperature, humidity and pressure (in Pa)
+var sensorCommunityPins = []sensorCommunityPin{
+	{pin: "1", values: []sensorCommunityValue{
+		{measurementPM10, "P1", 1},
+		{measurementPM25, "P2", 1},
+	}},
+	{pin: "11", values: []sensorCommunityValue{
+		{measurementTemperature, "temperature", 1},
+		{measurementHumidity, "humidity", 1},
+		{measurementPressure, "pressure", 100},
+	}},
+}
+
+// SensorCommunityHook forwards outdoor readings to Sensor.Community
+// (formerly luftdaten.info) for stations with a sensor ID in their config
+type SensorCommunityHook struct {
+	*forwarder
+	endpoint string
+}
+
+// NewSensorCommunityHook creates a new SensorCommunityHook
+func NewSensorCommunityHook(lookup SensorLookup) *SensorCommunityHook {
+	return &SensorCommunityHook{
+		forwarder: newForwarder(lookup, sensorCommunityInterval),
+		endpoint:  sensorCommunityEndpoint,
+	}
+}
+
+// Name returns the hook name
+func (h *SensorCommunityHook) Name() string { return "sensor_community" }
+
+// Stage returns the hook stage
+func (h *SensorCommunityHook) Stage() Stage { return StageForwarding }
+
+// Process posts the particulate matter of the batch as SDS011 and the
+// temperature, humidity and pressure as BME280 to Sensor.Community, one
+// request per pin. Like the firmware, a station sends every 145 seconds
+// at most; batches in between are dropped.
+func (h *SensorCommunityHook) Process(ctx context.Context, batch *Batch) error {
+	sensorID := configString(batch.Station, SensorCommunityIDConfigKey)
+	if sensorID == "" {
+		return nil
+	}
+	return h.forward(h.Name(), batch, func(values map[string]measurement) (func(ctx context.Context) error, error) {
+		return h.build(sensorID, values)
+	})
+}
+
+// build returns the uploads of the pins, or nil without Sensor.Community
+// values
+func (h *SensorCommunityHook) build(sensorID string, values map[string]measurement) (func(ctx context.Context) error, error) {
+	type pinBody struct {
+		pin  string
+		body []byte
+	}
+	var bodies []pinBody
+	for _, pin := range sensorCommunityPins {
+		type dataValue struct {
+			ValueType string `json:"value_type"`
+			Value     string `json:"value"`
+		}
+		var data []dataValue
+		for _, v := range pin.values {
+			if m, ok := values[v.measurement]; ok {
+				data = append(data, dataValue{ValueType: v.valueType, Value: formatValue(m.Value * v.scale)})
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"software_version": "WeatherMaestro",
+			"sensordatavalues": data,
+		})
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, pinBody{pin: pin.pin, body: body})
+	}
+	if len(bodies) == 0 {
+		return nil, nil
+	}
+
+	return func(ctx context.Context) error {
+		for _, b := range bodies {
+			header := map[string]string{"X-Sensor": sensorID, "X-Pin": b.pin}
+			if err := h.post(ctx, h.endpoint, b.body, header); err != nil {
+				return fmt.Errorf("failed to send pin %s to Sensor.Community: %w", b.pin, err)
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,8 @@
+module github.com/sguter90/weathermaestro/pkg/jobs
+
+go 1.25
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/sguter90/weathermaestro/pkg/models v0.1.0
+)
@@ -0,0 +1,306 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// errShutdown is the cause of job contexts cancelled by Stop
+var errShutdown = errors.New("interrupted by server shutdown")
+
+// errCancelled is the cause of job contexts cancelled on request
+var errCancelled = errors.New("cancelled on request")
+
+// Store persists jobs
+type Store interface {
+	ClaimJob(ctx context.Context, runnerID string, types []string) (*models.Job, error)
+	UpdateJobProgress(ctx context.Context, id uuid.UUID, done, total int) (bool, error)
+	FinishJob(ctx context.Context, id uuid.UUID, status, errMsg string) error
+}
+
+// Handler executes a job. It should return promptly once ctx is cancelled.
+type Handler func(ctx context.Context, job *models.Job, progress *Progress) error
+
+// Progress tracks how much of a job is done. Updates are kept in memory and
+// written to the store periodically by the runner.
+type Progress struct {
+	done  atomic.Int64
+	total atomic.Int64
+}
+
+// SetTotal sets the number of steps of the job
+func (p *Progress) SetTotal(total int) {
+	p.total.Store(int64(total))
+}
+
+// Add marks n more steps as done
+func (p *Progress) Add(n int) {
+	p.done.Add(int64(n))
+}
+
+// Values returns the done and total steps
+func (p *Progress) Values() (done, total int) {
+	return int(p.done.Load()), int(p.total.Load())
+}
+
+// Runner executes queued jobs with a pool of workers. Jobs are claimed from
+// the store, so several runners can share one job table.
+type Runner struct {
+	store        Store
+	id           string
+	workers      int
+	pollInterval time.Duration
+
+	handlers map[string]Handler
+	types    []string
+
+	wake     chan struct{}
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelCauseFunc
+}
+
+// NewRunner creates a new Runner. id identifies the runner among those
+// sharing the store and should stay the same across restarts. Workers poll
+// for pending jobs every pollInterval and flush the progress of running
+// jobs, which doubles as their heartbeat, at the same rate.
+func NewRunner(store Store, id string, workers int, pollInterval time.Duration) *Runner {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Runner{
+		store:        store,
+		id:           id,
+		workers:      workers,
+		pollInterval: pollInterval,
+		handlers:     make(map[string]Handler),
+		wake:         make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+		running:      make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// Register sets the handler of a job type. It must be called before Start.
+func (r *Runner) Register(jobType string, handler Handler) {
+	if _, ok := r.handlers[jobType]; !ok {
+		r.types = append(r.types, jobType)
+	}
+	r.handlers[jobType] = handler
+}
+
+// ID returns the ID the runner claims jobs with
+func (r *Runner) ID() string {
+	return r.id
+}
+
+// Types returns the registered job types
+func (r *Runner) Types() []string {
+	return append([]string(nil), r.types...)
+}
+
+// Start launches the workers
+func (r *Runner) Start() {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	log.Printf("✓ Job runner started with %d workers", r.workers)
+}
+
+// Stop cancels running jobs and waits for the workers to exit
+func (r *Runner) Stop() {
+	close(r.stopChan)
+
+	r.mu.Lock()
+	for _, cancel := range r.running {
+		cancel(errShutdown)
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	log.Println("✓ Job runner stopped")
+}
+
+// Notify wakes an idle worker to pick up a newly queued job
+func (r *Runner) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel cancels a job running in this runner. It returns false if the job
+// is not running here; a job running in another runner stops at its next
+// progress flush once its cancellation was requested in the store.
+func (r *Runner) Cancel(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cancel, ok := r.running[id]
+	if ok {
+		cancel(errCancelled)
+	}
+	return ok
+}
+
+func (r *Runner) work() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Work through all pending jobs before waiting again
+		for r.runNext() {
+			select {
+			case <-r.stopChan:
+				return
+			default:
+			}
+		}
+
+		select {
+		case <-r.stopChan:
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and executes one job. It returns false if no job was pending.
+func (r *Runner) runNext() bool {
+	if len(r.types) == 0 {
+		return false
+	}
+
+	job, err := r.store.ClaimJob(context.Background(), r.id, r.types)
+	if err != nil {
+		log.Printf("❌ Failed to claim job: %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	r.execute(job)
+	return true
+}
+
+// execute runs the handler of a claimed job and stores its final status
+func (r *Runner) execute(job *models.Job) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	r.mu.Lock()
+	r.running[job.ID] = cancel
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, job.ID)
+		r.mu.Unlock()
+	}()
+
+	// A job claimed while stopping is handed back as interrupted
+	select {
+	case <-r.stopChan:
+		cancel(errShutdown)
+	default:
+	}
+
+	progress := &Progress{}
+	progress.SetTotal(job.Total)
+
+	log.Printf("▶ Job %s (%s) started", job.ID, job.Type)
+
+	flushDone := make(chan struct{})
+	go r.flushProgress(ctx, cancel, job.ID, progress, flushDone)
+
+	err := r.runHandler(ctx, job, progress)
+	cancel(nil)
+	<-flushDone
+
+	// Store the final progress; a cancellation requested now is too late
+	done, total := progress.Values()
+	if _, perr := r.store.UpdateJobProgress(context.Background(), job.ID, done, total); perr != nil {
+		log.Printf("❌ Failed to update progress of job %s: %v", job.ID, perr)
+	}
+
+	status, errMsg := models.JobStatusCompleted, ""
+	if err != nil {
+		status, errMsg = models.JobStatusFailed, err.Error()
+		if cause := context.Cause(ctx); ctx.Err() != nil && cause != context.Canceled {
+			if errors.Is(cause, errCancelled) {
+				status = models.JobStatusCancelled
+			}
+			errMsg = cause.Error()
+		}
+	}
+
+	if err := r.store.FinishJob(context.Background(), job.ID, status, errMsg); err != nil {
+		log.Printf("❌ Failed to finish job %s: %v", job.ID, err)
+	}
+
+	switch status {
+	case models.JobStatusCompleted:
+		log.Printf("✓ Job %s (%s) completed", job.ID, job.Type)
+	case models.JobStatusCancelled:
+		log.Printf("⚠ Job %s (%s) cancelled", job.ID, job.Type)
+	default:
+		log.Printf("❌ Job %s (%s) failed: %s", job.ID, job.Type, errMsg)
+	}
+}
+
+// runHandler calls the job's handler and turns a panic into an error
+func (r *Runner) runHandler(ctx context.Context, job *models.Job, progress *Progress) (err error) {
+	handler, ok := r.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for job type: %s", job.Type)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return handler(ctx, job, progress)
+}
+
+// flushProgress periodically writes the progress of a running job and
+// cancels it when a cancellation was requested in the store
+func (r *Runner) flushProgress(ctx context.Context, cancel context.CancelCauseFunc, id uuid.UUID, progress *Progress, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d, t := progress.Values()
+			cancelRequested, err := r.store.UpdateJobProgress(ctx, id, d, t)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("❌ Failed to update progress of job %s: %v", id, err)
+				}
+				continue
+			}
+			if cancelRequested {
+				cancel(errCancelled)
+				return
+			}
+		}
+	}
+}
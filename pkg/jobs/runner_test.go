@@ -0,0 +1,218 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// memoryStore is an in-memory Store for tests
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs []*models.Job
+}
+
+func (s *memoryStore) add(jobType string) uuid.UUID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := &models.Job{ID: uuid.New(), Type: jobType, Status: models.JobStatusPending, CreatedAt: time.Now()}
+	s.jobs = append(s.jobs, job)
+	return job.ID
+}
+
+func (s *memoryStore) get(id uuid.UUID) models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.ID == id {
+			return *job
+		}
+	}
+	return models.Job{}
+}
+
+func (s *memoryStore) requestCancel(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.ID == id {
+			job.CancelRequested = true
+		}
+	}
+}
+
+func (s *memoryStore) ClaimJob(ctx context.Context, runnerID string, types []string) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.Status != models.JobStatusPending {
+			continue
+		}
+		for _, t := range types {
+			if job.Type == t {
+				job.Status = models.JobStatusRunning
+				claimed := *job
+				return &claimed, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) UpdateJobProgress(ctx context.Context, id uuid.UUID, done, total int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.ID == id {
+			job.Done, job.Total = done, total
+			return job.CancelRequested, nil
+		}
+	}
+	return false, errors.New("job not found")
+}
+
+func (s *memoryStore) FinishJob(ctx context.Context, id uuid.UUID, status, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.ID == id {
+			job.Status, job.Error = status, errMsg
+			return nil
+		}
+	}
+	return errors.New("job not found")
+}
+
+// waitForStatus polls the store until the job is finished
+func waitForStatus(t *testing.T, store *memoryStore, id uuid.UUID) models.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job := store.get(id); job.IsFinished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %s did not finish, status %s", id, store.get(id).Status)
+	return models.Job{}
+}
+
+func TestRunner_CompletesJobWithProgress(t *testing.T) {
+	store := &memoryStore{}
+	runner := NewRunner(store, "test", 2, 10*time.Millisecond)
+	runner.Register("count", func(ctx context.Context, job *models.Job, progress *Progress) error {
+		progress.SetTotal(3)
+		for i := 0; i < 3; i++ {
+			progress.Add(1)
+		}
+		return nil
+	})
+
+	id := store.add("count")
+	runner.Start()
+	defer runner.Stop()
+
+	job := waitForStatus(t, store, id)
+	if job.Status != models.JobStatusCompleted {
+		t.Fatalf("Expected completed job, got %s (%s)", job.Status, job.Error)
+	}
+	if job.Done != 3 || job.Total != 3 {
+		t.Errorf("Expected progress 3/3, got %d/%d", job.Done, job.Total)
+	}
+}
+
+func TestRunner_FailedJob(t *testing.T) {
+	store := &memoryStore{}
+	runner := NewRunner(store, "test", 1, 10*time.Millisecond)
+	runner.Register("fail", func(ctx context.Context, job *models.Job, progress *Progress) error {
+		return errors.New("boom")
+	})
+	runner.Register("panic", func(ctx context.Context, job *models.Job, progress *Progress) error {
+		panic("unexpected")
+	})
+
+	failID := store.add("fail")
+	panicID := store.add("panic")
+	runner.Start()
+	defer runner.Stop()
+
+	if job := waitForStatus(t, store, failID); job.Status != models.JobStatusFailed || job.Error != "boom" {
+		t.Errorf("Expected failed job with error, got %s (%s)", job.Status, job.Error)
+	}
+	if job := waitForStatus(t, store, panicID); job.Status != models.JobStatusFailed {
+		t.Errorf("Expected panicking job to fail, got %s", job.Status)
+	}
+}
+
+func TestRunner_CancelRequestedInStore(t *testing.T) {
+	store := &memoryStore{}
+	runner := NewRunner(store, "test", 1, 10*time.Millisecond)
+
+	started := make(chan struct{})
+	runner.Register("wait", func(ctx context.Context, job *models.Job, progress *Progress) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	id := store.add("wait")
+	runner.Start()
+	defer runner.Stop()
+
+	<-started
+	store.requestCancel(id)
+
+	if job := waitForStatus(t, store, id); job.Status != models.JobStatusCancelled {
+		t.Errorf("Expected cancelled job, got %s (%s)", job.Status, job.Error)
+	}
+}
+
+func TestRunner_StopInterruptsRunningJob(t *testing.T) {
+	store := &memoryStore{}
+	runner := NewRunner(store, "test", 1, time.Hour)
+
+	started := make(chan struct{})
+	runner.Register("wait", func(ctx context.Context, job *models.Job, progress *Progress) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	id := store.add("wait")
+	runner.Start()
+	<-started
+	runner.Stop()
+
+	job := store.get(id)
+	if job.Status != models.JobStatusFailed || job.Error != errShutdown.Error() {
+		t.Errorf("Expected job interrupted by shutdown, got %s (%s)", job.Status, job.Error)
+	}
+}
+
+func TestRunner_IgnoresUnregisteredTypes(t *testing.T) {
+	store := &memoryStore{}
+	runner := NewRunner(store, "test", 1, 10*time.Millisecond)
+	runner.Register("known", func(ctx context.Context, job *models.Job, progress *Progress) error { return nil })
+
+	unknownID := store.add("unknown")
+	knownID := store.add("known")
+	runner.Start()
+	defer runner.Stop()
+
+	waitForStatus(t, store, knownID)
+	if job := store.get(unknownID); job.Status != models.JobStatusPending {
+		t.Errorf("Expected job of unregistered type to stay pending, got %s", job.Status)
+	}
+}
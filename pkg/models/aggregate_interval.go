@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units of an AggregateInterval. Minutes and hours have a fixed length, the
+// calendar units days, weeks and months are aligned to a time zone.
+const (
+	IntervalMinute = "m"
+	IntervalHour   = "h"
+	IntervalDay    = "d"
+	IntervalWeek   = "w"
+	IntervalMonth  = "mo"
+)
+
+// MaxAggregateInterval is the longest aggregation interval
+const MaxAggregateInterval = 366 * 24 * time.Hour
+
+// MaxAggregateBuckets is the most buckets per series a query with a time
+// range may produce
+const MaxAggregateBuckets = 100000
+
+// AggregateInterval is the bucket length of aggregated readings, e.g. 10m,
+// 3h, 1d, 2w or 1mo
+type AggregateInterval struct {
+	Count int
+	Unit  string
+}
+
+// ParseAggregateInterval parses an interval of a count and a unit (m, h, d,
+// w or mo). 1M is accepted as the former spelling of 1mo.
+func ParseAggregateInterval(value string) (AggregateInterval, error) {
+	if value == "1M" {
+		return AggregateInterval{Count: 1, Unit: IntervalMonth}, nil
+	}
+
+	digits := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	if digits <= 0 {
+		return AggregateInterval{}, fmt.Errorf("invalid aggregate interval: %s (e.g. 10m, 3h, 1d, 2w, 1mo)", value)
+	}
+	count, err := strconv.Atoi(value[:digits])
+	if err != nil || count < 1 || count > 527040 {
+		return AggregateInterval{}, fmt.Errorf("invalid aggregate interval: %s (e.g. 10m, 3h, 1d, 2w, 1mo)", value)
+	}
+
+	interval := AggregateInterval{Count: count, Unit: value[digits:]}
+	switch interval.Unit {
+	case IntervalMinute, IntervalHour, IntervalDay, IntervalWeek, IntervalMonth:
+	default:
+		return AggregateInterval{}, fmt.Errorf("invalid aggregate interval unit: %s (valid: m, h, d, w, mo)", interval.Unit)
+	}
+	if interval.Nominal() > MaxAggregateInterval {
+		return AggregateInterval{}, fmt.Errorf("aggregate interval %s is longer than a year", value)
+	}
+	return interval, nil
+}
+
+// String returns the canonical spelling of the interval
+func (i AggregateInterval) String() string {
+	return strconv.Itoa(i.Count) + i.Unit
+}
+
+// Calendar reports whether the buckets are aligned to calendar days in a
+// time zone
+func (i AggregateInterval) Calendar() bool {
+	return i.Unit == IntervalDay || i.Unit == IntervalWeek || i.Unit == IntervalMonth
+}
+
+// Fixed returns the length of minute and hour intervals. The second return
+// value is false for calendar intervals, whose length depends on daylight
+// saving time and the month.
+func (i AggregateInterval) Fixed() (time.Duration, bool) {
+	switch i.Unit {
+	case IntervalMinute:
+		return time.Duration(i.Count) * time.Minute, true
+	case IntervalHour:
+		return time.Duration(i.Count) * time.Hour, true
+	}
+	return 0, false
+}
+
+// Nominal returns the approximate length of the interval, counting a
+// month as 30 days
+func (i AggregateInterval) Nominal() time.Duration {
+	if d, ok := i.Fixed(); ok {
+		return d
+	}
+	day := 24 * time.Hour
+	switch i.Unit {
+	case IntervalWeek:
+		return time.Duration(i.Count) * 7 * day
+	case IntervalMonth:
+		return time.Duration(i.Count) * 30 * day
+	}
+	return time.Duration(i.Count) * day
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAggregateInterval(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+		fixed    time.Duration
+		calendar bool
+		wantErr  bool
+	}{
+		{value: "10m", expected: "10m", fixed: 10 * time.Minute},
+		{value: "3h", expected: "3h", fixed: 3 * time.Hour},
+		{value: "1d", expected: "1d", calendar: true},
+		{value: "2w", expected: "2w", calendar: true},
+		{value: "1mo", expected: "1mo", calendar: true},
+		{value: "1M", expected: "1mo", calendar: true},
+		{value: "0m", wantErr: true},
+		{value: "h", wantErr: true},
+		{value: "5s", wantErr: true},
+		{value: "2y", wantErr: true},
+		{value: "400d", wantErr: true},
+		{value: "99999999999999m", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			interval, err := ParseAggregateInterval(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", interval)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if interval.String() != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, interval)
+			}
+			if interval.Calendar() != tc.calendar {
+				t.Errorf("Expected calendar %v, got %v", tc.calendar, interval.Calendar())
+			}
+			if fixed, _ := interval.Fixed(); fixed != tc.fixed {
+				t.Errorf("Expected fixed length %v, got %v", tc.fixed, fixed)
+			}
+		})
+	}
+}
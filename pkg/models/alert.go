@@ -0,0 +1,199 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alert rule operators
+const (
+	AlertOperatorBelow = "below"
+	AlertOperatorAbove = "above"
+)
+
+// AlertTypeStorm is the sensor type of rules evaluating the storm severity
+// of a station (0 none, 1 moderate, 2 strong, 3 severe) instead of a sensor
+const AlertTypeStorm = "StormSeverity"
+
+// AlertTypeBattery is the sensor type of rules evaluating the battery
+// status of a station (0 ok, 1 low, 2 critical) instead of a sensor, see
+// AggregateBattery
+const AlertTypeBattery = "BatteryStatus"
+
+// Sensor types of rules evaluating the indoor climate of a station, see
+// ComputeIndoorClimate: AlertTypeVentilation is 1 while a room should be
+// ventilated and 0 otherwise, AlertTypeMoldRisk the mold risk index in %
+const (
+	AlertTypeVentilation = "Ventilation"
+	AlertTypeMoldRisk    = "MoldRisk"
+)
+
+// AlertTypeClockSkew is the sensor type of rules evaluating how far the
+// clock of a station is off the server clock, in seconds either way. It is
+// measured on pushed data only.
+const AlertTypeClockSkew = "ClockSkew"
+
+// alertRuleName restricts rule names to identifiers, they are used in MQTT
+// topics and as entity IDs in Home Assistant
+var alertRuleName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// AlertRule raises an alert while a sensor of a station is below or above a
+// threshold, e.g. a frost warning below 0.5 °C. The state is kept with the
+// rule and updated on every ingest.
+type AlertRule struct {
+	ID        uuid.UUID `json:"id"`
+	StationID uuid.UUID `json:"station_id"`
+	// Name identifies the rule within its station, e.g. frost_warning
+	Name       string `json:"name"`
+	SensorType string `json:"sensor_type"`
+	// Location limits the rule to sensors at a location, e.g. Outdoor
+	Location  string  `json:"location,omitempty"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	// Hysteresis keeps an active alert until the value is this far back
+	// over the threshold, so values around it don't toggle the alert
+	Hysteresis float64 `json:"hysteresis"`
+	// Channels are notified when the alert is raised or cleared
+	Channels []string `json:"channels"`
+	Enabled  bool     `json:"enabled"`
+	// Locale is the language of the notifications, empty for the default
+	Locale string `json:"locale,omitempty"`
+	// SubjectTemplate and BodyTemplate replace the notification texts of
+	// the channels with Go templates
+	SubjectTemplate string `json:"subject_template,omitempty"`
+	BodyTemplate    string `json:"body_template,omitempty"`
+
+	Active      bool       `json:"active"`
+	ActiveSince *time.Time `json:"active_since,omitempty"`
+	LastValue   *float64   `json:"last_value,omitempty"`
+	EvaluatedAt *time.Time `json:"evaluated_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Validate checks the name, sensor type, operator and hysteresis
+func (r *AlertRule) Validate() error {
+	if !alertRuleName.MatchString(r.Name) {
+		return errors.New("name must start with a lowercase letter and contain at most 50 lowercase letters, digits or underscores")
+	}
+	if r.SensorType == "" || len(r.SensorType) > 20 {
+		return errors.New("sensor_type must be between 1 and 20 characters")
+	}
+	if len(r.Location) > 100 {
+		return errors.New("location must be at most 100 characters")
+	}
+	if r.Operator != AlertOperatorBelow && r.Operator != AlertOperatorAbove {
+		return errors.New("operator must be below or above")
+	}
+	if math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+		return errors.New("threshold must be a number")
+	}
+	if r.Hysteresis < 0 || math.IsNaN(r.Hysteresis) {
+		return errors.New("hysteresis must not be negative")
+	}
+	if r.IsStorm() && (r.Threshold < 0 || r.Threshold > 3) {
+		return errors.New("threshold of storm rules must be a severity between 0 and 3")
+	}
+	if r.IsBattery() && (r.Threshold < 0 || r.Threshold > 2) {
+		return errors.New("threshold of battery rules must be a severity between 0 and 2")
+	}
+	if r.SensorType == AlertTypeVentilation && (r.Threshold < 0 || r.Threshold > 1) {
+		return errors.New("threshold of ventilation rules must be between 0 and 1")
+	}
+	if len(r.Locale) > 10 {
+		return errors.New("locale must be at most 10 characters")
+	}
+	if len(r.SubjectTemplate) > 500 || len(r.BodyTemplate) > 5000 {
+		return errors.New("subject_template must be at most 500 and body_template at most 5000 characters")
+	}
+	if r.Channels == nil {
+		r.Channels = []string{}
+	}
+	return nil
+}
+
+// Matches reports whether the rule evaluates readings of a sensor
+func (r AlertRule) Matches(sensor Sensor) bool {
+	if r.IsStorm() || r.IsBattery() || r.IsIndoorClimate() || r.IsClockSkew() {
+		return false
+	}
+	return sensor.SensorType == r.SensorType && (r.Location == "" || sensor.Location == r.Location)
+}
+
+// IsStorm reports whether the rule evaluates the storm severity
+func (r AlertRule) IsStorm() bool {
+	return r.SensorType == AlertTypeStorm
+}
+
+// IsBattery reports whether the rule evaluates the battery status of the
+// station
+func (r AlertRule) IsBattery() bool {
+	return r.SensorType == AlertTypeBattery
+}
+
+// IsIndoorClimate reports whether the rule evaluates the indoor climate of
+// the station
+func (r AlertRule) IsIndoorClimate() bool {
+	return r.SensorType == AlertTypeVentilation || r.SensorType == AlertTypeMoldRisk
+}
+
+// IsClockSkew reports whether the rule evaluates the clock skew of the
+// station
+func (r AlertRule) IsClockSkew() bool {
+	return r.SensorType == AlertTypeClockSkew
+}
+
+// Evaluate returns whether the alert is active after a new value. Raising
+// uses the threshold, clearing the threshold moved by the hysteresis.
+func (r AlertRule) Evaluate(value float64) bool {
+	threshold := r.Threshold
+	if r.Operator == AlertOperatorBelow {
+		if r.Active {
+			threshold += r.Hysteresis
+		}
+		return value < threshold
+	}
+	if r.Active {
+		threshold -= r.Hysteresis
+	}
+	return value > threshold
+}
+
+// alertTestSteps is the number of synthetic values of TestPath
+const alertTestSteps = 5
+
+// TestPath returns synthetic values moving from the clear side of the rule
+// beyond its threshold, to test-fire the rule. Storm rules get severities
+// between 0 and 3, battery rules between 0 and 2 and ventilation rules 0
+// and 1, so a rule that can never trigger gets a path that doesn't raise it
+// either.
+func (r AlertRule) TestPath() []float64 {
+	if r.IsStorm() || r.IsBattery() || r.SensorType == AlertTypeVentilation {
+		path := []float64{0, 1, 2, 3}
+		switch {
+		case r.IsBattery():
+			path = path[:3]
+		case r.SensorType == AlertTypeVentilation:
+			path = path[:2]
+		}
+		if r.Operator == AlertOperatorBelow {
+			slices.Reverse(path)
+		}
+		return path
+	}
+
+	start, end := r.Threshold-r.Hysteresis-1, r.Threshold+1
+	if r.Operator == AlertOperatorBelow {
+		start, end = r.Threshold+r.Hysteresis+1, r.Threshold-1
+	}
+	path := make([]float64, alertTestSteps)
+	for i := range path {
+		path[i] = start + (end-start)*float64(i)/float64(alertTestSteps-1)
+	}
+	return path
+}
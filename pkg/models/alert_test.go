@@ -0,0 +1,102 @@
+package models
+
+import "testing"
+
+func TestAlertRule_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    AlertRule
+		wantErr bool
+	}{
+		{name: "Valid", rule: AlertRule{Name: "frost_warning", SensorType: SensorTypeTemperatureOutdoor, Operator: AlertOperatorBelow, Threshold: 0.5}},
+		{name: "Name with spaces", rule: AlertRule{Name: "frost warning", SensorType: SensorTypeTemperature, Operator: AlertOperatorBelow}, wantErr: true},
+		{name: "Uppercase name", rule: AlertRule{Name: "Frost", SensorType: SensorTypeTemperature, Operator: AlertOperatorBelow}, wantErr: true},
+		{name: "Missing sensor type", rule: AlertRule{Name: "frost", Operator: AlertOperatorBelow}, wantErr: true},
+		{name: "Unknown operator", rule: AlertRule{Name: "frost", SensorType: SensorTypeTemperature, Operator: "<"}, wantErr: true},
+		{name: "Negative hysteresis", rule: AlertRule{Name: "frost", SensorType: SensorTypeTemperature, Operator: AlertOperatorBelow, Hysteresis: -1}, wantErr: true},
+		{name: "Storm", rule: AlertRule{Name: "storm_warning", SensorType: AlertTypeStorm, Operator: AlertOperatorAbove, Threshold: 1}},
+		{name: "Storm severity out of range", rule: AlertRule{Name: "storm_warning", SensorType: AlertTypeStorm, Operator: AlertOperatorAbove, Threshold: 5}, wantErr: true},
+		{name: "Battery", rule: AlertRule{Name: "battery_low", SensorType: AlertTypeBattery, Operator: AlertOperatorAbove, Threshold: 0}},
+		{name: "Battery severity out of range", rule: AlertRule{Name: "battery_low", SensorType: AlertTypeBattery, Operator: AlertOperatorAbove, Threshold: 3}, wantErr: true},
+		{name: "Ventilation", rule: AlertRule{Name: "ventilate", SensorType: AlertTypeVentilation, Operator: AlertOperatorAbove, Threshold: 0.5}},
+		{name: "Ventilation out of range", rule: AlertRule{Name: "ventilate", SensorType: AlertTypeVentilation, Operator: AlertOperatorAbove, Threshold: 2}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rule.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAlertRule_Evaluate(t *testing.T) {
+	frost := AlertRule{Operator: AlertOperatorBelow, Threshold: 0.5, Hysteresis: 1}
+	heat := AlertRule{Operator: AlertOperatorAbove, Threshold: 30, Hysteresis: 2}
+
+	testCases := []struct {
+		name     string
+		rule     AlertRule
+		active   bool
+		value    float64
+		expected bool
+	}{
+		{name: "Frost raised", rule: frost, value: 0.4, expected: true},
+		{name: "Frost not raised at threshold", rule: frost, value: 0.5, expected: false},
+		{name: "Frost kept within hysteresis", rule: frost, active: true, value: 1.2, expected: true},
+		{name: "Frost cleared", rule: frost, active: true, value: 1.5, expected: false},
+		{name: "Heat raised", rule: heat, value: 30.1, expected: true},
+		{name: "Heat kept within hysteresis", rule: heat, active: true, value: 28.5, expected: true},
+		{name: "Heat cleared", rule: heat, active: true, value: 27.9, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.rule.Active = tc.active
+			if got := tc.rule.Evaluate(tc.value); got != tc.expected {
+				t.Errorf("Evaluate(%g) = %v, want %v", tc.value, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAlertRule_Matches(t *testing.T) {
+	rule := AlertRule{SensorType: SensorTypeTemperature, Location: "Outdoor"}
+	if !rule.Matches(Sensor{SensorType: SensorTypeTemperature, Location: "Outdoor"}) {
+		t.Error("expected outdoor temperature to match")
+	}
+	if rule.Matches(Sensor{SensorType: SensorTypeTemperature, Location: "Indoor"}) {
+		t.Error("expected indoor temperature not to match")
+	}
+	rule.Location = ""
+	if !rule.Matches(Sensor{SensorType: SensorTypeTemperature, Location: "Indoor"}) {
+		t.Error("expected rule without location to match all locations")
+	}
+}
+
+func TestAlertRule_TestPath(t *testing.T) {
+	tests := []struct {
+		name string
+		rule AlertRule
+	}{
+		{"below", AlertRule{SensorType: SensorTypeTemperature, Operator: AlertOperatorBelow, Threshold: 0.5, Hysteresis: 1}},
+		{"above", AlertRule{SensorType: SensorTypeWindSpeed, Operator: AlertOperatorAbove, Threshold: 15}},
+		{"storm", AlertRule{SensorType: AlertTypeStorm, Operator: AlertOperatorAbove, Threshold: 1}},
+		{"battery", AlertRule{SensorType: AlertTypeBattery, Operator: AlertOperatorAbove, Threshold: 1}},
+		{"ventilation", AlertRule{SensorType: AlertTypeVentilation, Operator: AlertOperatorAbove, Threshold: 0.5}},
+		{"mold risk", AlertRule{SensorType: AlertTypeMoldRisk, Operator: AlertOperatorAbove, Threshold: 80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.rule.TestPath()
+			if tt.rule.Evaluate(path[0]) {
+				t.Errorf("first value %g raises the rule", path[0])
+			}
+			if !tt.rule.Evaluate(path[len(path)-1]) {
+				t.Errorf("last value %g doesn't raise the rule", path[len(path)-1])
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AllowedIPsConfigKey is the station config key of the source addresses
+// pushes of the station are accepted from
+const AllowedIPsConfigKey = "allowed_ips"
+
+// IPAllowlist holds the networks requests are accepted from
+type IPAllowlist []*net.IPNet
+
+// ParseIPAllowlist parses addresses and CIDR networks from a comma separated
+// string or a list of strings. Single addresses match only themselves.
+func ParseIPAllowlist(value interface{}) (IPAllowlist, error) {
+	var entries []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		entries = strings.Split(v, ",")
+	case []string:
+		entries = v
+	case []interface{}:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid address %v", item)
+			}
+			entries = append(entries, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid address list %v", value)
+	}
+
+	var list IPAllowlist
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			list = append(list, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", entry)
+		}
+		list = append(list, network)
+	}
+	return list, nil
+}
+
+// StationIPAllowlist reads the source address allowlist of a station config.
+// Stations without allowlist accept pushes from everywhere.
+func StationIPAllowlist(config map[string]interface{}) (IPAllowlist, error) {
+	list, err := ParseIPAllowlist(config[AllowedIPsConfigKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", AllowedIPsConfigKey, err)
+	}
+	return list, nil
+}
+
+// Contains reports whether an address is in one of the networks. IPv4
+// addresses mapped to IPv6 by dual-stack listeners match IPv4 networks.
+func (l IPAllowlist) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range l {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestParseIPAllowlist(t *testing.T) {
+	list, err := ParseIPAllowlist("192.168.1.10, 10.0.0.0/8,2001:db8::/32")
+	if err != nil {
+		t.Fatalf("ParseIPAllowlist() error = %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(list))
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"10.20.30.40", true},
+		{"::ffff:10.1.2.3", true},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		if got := list.Contains(tt.addr); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestParseIPAllowlist_List(t *testing.T) {
+	list, err := ParseIPAllowlist([]interface{}{"192.168.1.10", "fe80::1"})
+	if err != nil {
+		t.Fatalf("ParseIPAllowlist() error = %v", err)
+	}
+	if !list.Contains("fe80::1") || list.Contains("fe80::2") {
+		t.Errorf("unexpected matches for %v", list)
+	}
+}
+
+func TestParseIPAllowlist_Invalid(t *testing.T) {
+	for _, value := range []interface{}{"192.168.1", "10.0.0.0/33", []interface{}{1}, 42} {
+		if _, err := ParseIPAllowlist(value); err == nil {
+			t.Errorf("Expected error for %v", value)
+		}
+	}
+}
+
+func TestStationIPAllowlist_Unset(t *testing.T) {
+	list, err := StationIPAllowlist(map[string]interface{}{})
+	if err != nil || list != nil {
+		t.Errorf("StationIPAllowlist() = %v, %v", list, err)
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API key scopes
+const (
+	// ScopeReadReadings allows reading station data without privacy restrictions
+	ScopeReadReadings = "read:readings"
+	// ScopeWriteIngest allows pushing weather data to the pusher endpoints
+	ScopeWriteIngest = "write:ingest"
+	// ScopeAdmin allows everything, including management of API keys
+	ScopeAdmin = "admin"
+)
+
+// APIKeyScopes lists all valid scopes
+var APIKeyScopes = []string{ScopeReadReadings, ScopeWriteIngest, ScopeAdmin}
+
+// APIKeyPrefix starts every API key, which tells keys apart from JWTs
+const APIKeyPrefix = "wm_"
+
+// APIKey is a revocable key for machine access to the API. Only a hash of
+// the key is stored; Prefix identifies the key in listings.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key grants a scope; admin grants all scopes
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes checks that scopes is non-empty and only contains known scopes
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, s := range APIKeyScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+func TestAPIKey_HasScope(t *testing.T) {
+	readOnly := &APIKey{Scopes: []string{ScopeReadReadings}}
+	if !readOnly.HasScope(ScopeReadReadings) {
+		t.Error("Expected read scope")
+	}
+	if readOnly.HasScope(ScopeWriteIngest) || readOnly.HasScope(ScopeAdmin) {
+		t.Error("Expected read-only key to lack other scopes")
+	}
+
+	admin := &APIKey{Scopes: []string{ScopeAdmin}}
+	for _, scope := range APIKeyScopes {
+		if !admin.HasScope(scope) {
+			t.Errorf("Expected admin key to grant %s", scope)
+		}
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeReadReadings, ScopeWriteIngest}); err != nil {
+		t.Errorf("Expected valid scopes, got %v", err)
+	}
+	if err := ValidateScopes(nil); err == nil {
+		t.Error("Expected error for missing scopes")
+	}
+	if err := ValidateScopes([]string{"write:everything"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// archiveMonthLayout is the format of archived months in object keys and
+// commands
+const archiveMonthLayout = "2006-01"
+
+// ReadingArchive is a month of readings moved to object storage. The daily
+// rollups of archived months are kept, so daily and coarser aggregations
+// still cover them.
+type ReadingArchive struct {
+	// Month is the first day of the month in UTC
+	Month      time.Time  `json:"month"`
+	ObjectKey  string     `json:"object_key"`
+	Readings   int64      `json:"readings"`
+	Size       int64      `json:"size"`
+	SHA256     string     `json:"sha256"`
+	ArchivedAt time.Time  `json:"archived_at"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+	// Pending is set while the readings of the month are dropped after
+	// the upload; a pending archive may still have its readings stored
+	Pending bool `json:"pending,omitempty"`
+}
+
+// ArchiveCutoff returns the first day of the month the given number of
+// months before now in UTC. Readings before it are archived.
+func ArchiveCutoff(now time.Time, months int) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseArchiveMonth parses a month given as YYYY-MM
+func ParseArchiveMonth(value string) (time.Time, error) {
+	month, err := time.Parse(archiveMonthLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", value)
+	}
+	return month, nil
+}
+
+// ArchiveObjectKey returns the key of the archive object of a month below
+// prefix, e.g. readings/2024/2024-01.jsonl.gz
+func ArchiveObjectKey(prefix string, month time.Time) string {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + month.UTC().Format("2006/") + month.UTC().Format(archiveMonthLayout) + ".jsonl.gz"
+}
+
+// UnarchivedRanges splits the days from start to end (inclusive) into the
+// ranges outside of the given archived months. Derived data like daily
+// rollups must not be rebuilt for archived months, their readings are gone.
+func UnarchivedRanges(start, end time.Time, archived []time.Time) [][2]time.Time {
+	start = start.UTC().Truncate(24 * time.Hour)
+	end = end.UTC().Truncate(24 * time.Hour)
+
+	sorted := slices.Clone(archived)
+	slices.SortFunc(sorted, func(a, b time.Time) int { return a.Compare(b) })
+
+	var ranges [][2]time.Time
+	for _, month := range sorted {
+		month = month.UTC()
+		next := month.AddDate(0, 1, 0)
+		if !next.After(start) {
+			continue
+		}
+		if month.After(end) {
+			break
+		}
+		if month.After(start) {
+			ranges = append(ranges, [2]time.Time{start, month.AddDate(0, 0, -1)})
+		}
+		start = next
+	}
+	if !start.After(end) {
+		ranges = append(ranges, [2]time.Time{start, end})
+	}
+	return ranges
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestArchiveCutoff(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	if cutoff := ArchiveCutoff(now, 12); !cutoff.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-03-01, got %s", cutoff)
+	}
+	if cutoff := ArchiveCutoff(now, 4); !cutoff.Equal(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 2024-11-01, got %s", cutoff)
+	}
+}
+
+func TestParseArchiveMonth(t *testing.T) {
+	month, err := ParseArchiveMonth("2024-02")
+	if err != nil || !month.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseArchiveMonth() = %s, %v", month, err)
+	}
+	if _, err := ParseArchiveMonth("2024-02-01"); err == nil {
+		t.Error("Expected an error for a day")
+	}
+}
+
+func TestArchiveObjectKey(t *testing.T) {
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	if key := ArchiveObjectKey("readings", month); key != "readings/2024/2024-02.jsonl.gz" {
+		t.Errorf("Unexpected key %s", key)
+	}
+	if key := ArchiveObjectKey("", month); key != "2024/2024-02.jsonl.gz" {
+		t.Errorf("Unexpected key %s", key)
+	}
+}
+
+func TestUnarchivedRanges(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	months := []time.Time{day("2024-03-01"), day("2024-01-01")}
+
+	testCases := []struct {
+		name     string
+		start    string
+		end      string
+		expected [][2]string
+	}{
+		{name: "Around archived months", start: "2023-12-10", end: "2024-04-05", expected: [][2]string{{"2023-12-10", "2023-12-31"}, {"2024-02-01", "2024-02-29"}, {"2024-04-01", "2024-04-05"}}},
+		{name: "Inside an archived month", start: "2024-01-05", end: "2024-01-20", expected: nil},
+		{name: "Starting in an archived month", start: "2024-03-10", end: "2024-05-01", expected: [][2]string{{"2024-04-01", "2024-05-01"}}},
+		{name: "Without archived months", start: "2024-05-01", end: "2024-05-03", expected: [][2]string{{"2024-05-01", "2024-05-03"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ranges := UnarchivedRanges(day(tc.start), day(tc.end), months)
+			if len(ranges) != len(tc.expected) {
+				t.Fatalf("Expected %d ranges, got %v", len(tc.expected), ranges)
+			}
+			for i, r := range ranges {
+				if !r[0].Equal(day(tc.expected[i][0])) || !r[1].Equal(day(tc.expected[i][1])) {
+					t.Errorf("Range %d: expected %v, got %s - %s", i, tc.expected[i], r[0].Format(time.DateOnly), r[1].Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
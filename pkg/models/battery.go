@@ -0,0 +1,237 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BatteryConfigKey is the station config key with the thresholds of the
+// station battery status
+const BatteryConfigKey = "battery"
+
+// Battery statuses, ordered from best to worst
+const (
+	BatteryUnknown  = "unknown"
+	BatteryOK       = "ok"
+	BatteryLow      = "low"
+	BatteryCritical = "critical"
+)
+
+// batterySeverities rates the statuses for the worst-of aggregation and
+// rules of type AlertTypeBattery. Devices without readings don't raise
+// alerts.
+var batterySeverities = map[string]int{
+	BatteryUnknown:  0,
+	BatteryOK:       0,
+	BatteryLow:      1,
+	BatteryCritical: 2,
+}
+
+// Default thresholds of battery levels in percent
+const (
+	DefaultBatteryLow      = 25
+	DefaultBatteryCritical = 10
+)
+
+// BatterySensorPolicy overrides the thresholds of one battery sensor, e.g.
+// for sensors reporting a voltage
+type BatterySensorPolicy struct {
+	Low      *float64 `json:"low,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+	// Flag marks sensors reporting 0 for ok and 1 for a low battery, like
+	// wh65batt of Ecowitt gateways
+	Flag bool `json:"flag,omitempty"`
+	// Ignore leaves the sensor out of the station status
+	Ignore bool `json:"ignore,omitempty"`
+}
+
+// BatteryPolicy rates the battery sensors of a station. Levels at or below
+// Low are low, at or below Critical critical.
+type BatteryPolicy struct {
+	Low      float64 `json:"low"`
+	Critical float64 `json:"critical"`
+	// Sensors maps sensor IDs or remote IDs to their own thresholds
+	Sensors map[string]BatterySensorPolicy `json:"sensors,omitempty"`
+}
+
+// ParseBatteryPolicy reads the battery thresholds from a station config.
+// Stations without settings get the default thresholds.
+func ParseBatteryPolicy(config map[string]interface{}) (BatteryPolicy, error) {
+	policy := BatteryPolicy{Low: DefaultBatteryLow, Critical: DefaultBatteryCritical}
+	value, ok := config[BatteryConfigKey]
+	if !ok || value == nil {
+		return policy, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return BatteryPolicy{}, fmt.Errorf("invalid battery config: %w", err)
+	}
+
+	if policy.Critical > policy.Low {
+		return BatteryPolicy{}, fmt.Errorf("invalid battery config: critical must not be above low")
+	}
+	for key, s := range policy.Sensors {
+		low, critical := policy.thresholds(s)
+		if critical > low {
+			return BatteryPolicy{}, fmt.Errorf("invalid battery config: critical of %s must not be above low", key)
+		}
+	}
+	return policy, nil
+}
+
+// sensorPolicy returns the overrides of a sensor
+func (p BatteryPolicy) sensorPolicy(sensor Sensor) BatterySensorPolicy {
+	if s, ok := p.Sensors[sensor.ID.String()]; ok {
+		return s
+	}
+	if sensor.RemoteID != "" {
+		return p.Sensors[sensor.RemoteID]
+	}
+	return BatterySensorPolicy{}
+}
+
+// thresholds returns the low and critical thresholds with overrides
+func (p BatteryPolicy) thresholds(s BatterySensorPolicy) (low, critical float64) {
+	low, critical = p.Low, p.Critical
+	if s.Low != nil {
+		low = *s.Low
+	}
+	if s.Critical != nil {
+		critical = *s.Critical
+	}
+	return low, critical
+}
+
+// Rate returns the status of a battery level of a sensor and whether the
+// sensor is ignored
+func (p BatteryPolicy) Rate(sensor Sensor, value float64) (string, bool) {
+	s := p.sensorPolicy(sensor)
+	if s.Ignore {
+		return "", true
+	}
+	if s.Flag {
+		if value >= 1 {
+			return BatteryLow, false
+		}
+		return BatteryOK, false
+	}
+
+	low, critical := p.thresholds(s)
+	switch {
+	case value <= critical:
+		return BatteryCritical, false
+	case value <= low:
+		return BatteryLow, false
+	}
+	return BatteryOK, false
+}
+
+// BatteryDevice is the battery state of one sensor
+type BatteryDevice struct {
+	SensorID  uuid.UUID  `json:"sensor_id"`
+	Name      string     `json:"name,omitempty"`
+	Location  string     `json:"location,omitempty"`
+	RemoteID  string     `json:"remote_id,omitempty"`
+	Value     *float64   `json:"value,omitempty"`
+	Status    string     `json:"status"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// StationBattery is the worst status of the battery sensors of a station
+// with the state of each device
+type StationBattery struct {
+	Status   string          `json:"status"`
+	Low      int             `json:"low"`
+	Critical int             `json:"critical"`
+	Devices  []BatteryDevice `json:"devices"`
+}
+
+// Severity rates the status: 0 ok or unknown, 1 low, 2 critical
+func (b *StationBattery) Severity() int {
+	if b == nil {
+		return 0
+	}
+	return batterySeverities[b.Status]
+}
+
+// BatterySeverityStatus returns the status of a severity of
+// StationBattery.Severity, e.g. low for 1
+func BatterySeverityStatus(severity int) string {
+	switch {
+	case severity >= 2:
+		return BatteryCritical
+	case severity == 1:
+		return BatteryLow
+	}
+	return BatteryOK
+}
+
+// AggregateBattery rates the battery sensors of a station by their latest
+// reading and returns the worst status. Other sensors are skipped; it
+// returns nil if the station has no battery sensors.
+func AggregateBattery(policy BatteryPolicy, sensors []SensorWithLatestReading) *StationBattery {
+	var battery *StationBattery
+	for _, s := range sensors {
+		if s.Sensor.SensorType != SensorTypeBattery || !s.Sensor.Enabled {
+			continue
+		}
+
+		device := BatteryDevice{
+			SensorID: s.Sensor.ID,
+			Name:     s.Sensor.Name,
+			Location: s.Sensor.Location,
+			RemoteID: s.Sensor.RemoteID,
+			Status:   BatteryUnknown,
+		}
+		if r := s.LatestReading; r != nil {
+			status, ignored := policy.Rate(s.Sensor, r.Value)
+			if ignored {
+				continue
+			}
+			value, updatedAt := r.Value, r.DateUTC
+			device.Status, device.Value, device.UpdatedAt = status, &value, &updatedAt
+		} else if _, ignored := policy.Rate(s.Sensor, 0); ignored {
+			continue
+		}
+
+		if battery == nil {
+			battery = &StationBattery{Status: BatteryUnknown, Devices: []BatteryDevice{}}
+		}
+		battery.Devices = append(battery.Devices, device)
+		switch device.Status {
+		case BatteryLow:
+			battery.Low++
+		case BatteryCritical:
+			battery.Critical++
+		}
+		if worseBattery(device.Status, battery.Status) {
+			battery.Status = device.Status
+		}
+	}
+	if battery == nil {
+		return nil
+	}
+
+	// Worst devices first
+	sort.SliceStable(battery.Devices, func(i, j int) bool {
+		return batterySeverities[battery.Devices[i].Status] > batterySeverities[battery.Devices[j].Status]
+	})
+	return battery
+}
+
+// worseBattery reports whether status a is worse than b. Any rated device
+// is worse than unknown.
+func worseBattery(a, b string) bool {
+	if b == BatteryUnknown {
+		return a != BatteryUnknown
+	}
+	return batterySeverities[a] > batterySeverities[b]
+}
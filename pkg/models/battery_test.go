@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseBatteryPolicy(t *testing.T) {
+	policy, err := ParseBatteryPolicy(map[string]interface{}{})
+	if err != nil || policy.Low != DefaultBatteryLow || policy.Critical != DefaultBatteryCritical {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+
+	policy, err = ParseBatteryPolicy(map[string]interface{}{
+		BatteryConfigKey: map[string]interface{}{
+			"low": 30, "critical": 15,
+			"sensors": map[string]interface{}{"wh40batt": map[string]interface{}{"low": 1.3, "critical": 1.2}},
+		},
+	})
+	if err != nil || policy.Low != 30 || *policy.Sensors["wh40batt"].Low != 1.3 {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+
+	invalid := []interface{}{
+		"low",
+		map[string]interface{}{"low": 10, "critical": 20},
+		map[string]interface{}{"sensors": map[string]interface{}{"batt1": map[string]interface{}{"critical": 50}}},
+	}
+	for _, value := range invalid {
+		if _, err := ParseBatteryPolicy(map[string]interface{}{BatteryConfigKey: value}); err == nil {
+			t.Errorf("expected error for %v", value)
+		}
+	}
+}
+
+func TestBatteryPolicy_Rate(t *testing.T) {
+	voltage, flagSensor := 1.2, Sensor{ID: uuid.New(), RemoteID: "wh65batt"}
+	policy := BatteryPolicy{Low: 25, Critical: 10, Sensors: map[string]BatterySensorPolicy{
+		"wh65batt": {Flag: true},
+		"wh40batt": {Low: &voltage, Critical: new(float64)},
+		"batt8":    {Ignore: true},
+	}}
+	tests := []struct {
+		sensor Sensor
+		value  float64
+		want   string
+	}{
+		{Sensor{}, 80, BatteryOK},
+		{Sensor{}, 25, BatteryLow},
+		{Sensor{}, 5, BatteryCritical},
+		{flagSensor, 0, BatteryOK},
+		{flagSensor, 1, BatteryLow},
+		{Sensor{RemoteID: "wh40batt"}, 1.5, BatteryOK},
+		{Sensor{RemoteID: "wh40batt"}, 1.1, BatteryLow},
+	}
+	for _, tt := range tests {
+		if got, _ := policy.Rate(tt.sensor, tt.value); got != tt.want {
+			t.Errorf("Rate(%s, %g) = %s, want %s", tt.sensor.RemoteID, tt.value, got, tt.want)
+		}
+	}
+	if _, ignored := policy.Rate(Sensor{RemoteID: "batt8"}, 0); !ignored {
+		t.Error("expected batt8 to be ignored")
+	}
+}
+
+func TestAggregateBattery(t *testing.T) {
+	at := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	battery := func(remoteID string, value *float64) SensorWithLatestReading {
+		s := SensorWithLatestReading{Sensor: Sensor{ID: uuid.New(), SensorType: SensorTypeBattery, RemoteID: remoteID, Enabled: true}}
+		if value != nil {
+			s.LatestReading = &SensorReading{SensorID: s.Sensor.ID, Value: *value, DateUTC: at}
+		}
+		return s
+	}
+	full, low, critical := 90.0, 20.0, 5.0
+	policy := BatteryPolicy{Low: 25, Critical: 10, Sensors: map[string]BatterySensorPolicy{"batt8": {Ignore: true}}}
+
+	if got := AggregateBattery(policy, []SensorWithLatestReading{{Sensor: Sensor{SensorType: SensorTypeTemperature, Enabled: true}}}); got != nil {
+		t.Errorf("expected nil without battery sensors, got %+v", got)
+	}
+
+	got := AggregateBattery(policy, []SensorWithLatestReading{
+		battery("batt1", &full),
+		battery("batt2", nil),
+		battery("batt3", &low),
+		battery("batt8", &critical),
+	})
+	if got.Status != BatteryLow || got.Low != 1 || got.Critical != 0 || got.Severity() != 1 {
+		t.Errorf("unexpected status %+v", got)
+	}
+	if len(got.Devices) != 3 || got.Devices[0].RemoteID != "batt3" {
+		t.Errorf("unexpected devices %+v", got.Devices)
+	}
+
+	got = AggregateBattery(policy, []SensorWithLatestReading{battery("batt1", &full), battery("batt4", &critical)})
+	if got.Status != BatteryCritical || got.Severity() != 2 {
+		t.Errorf("unexpected status %+v", got)
+	}
+
+	got = AggregateBattery(policy, []SensorWithLatestReading{battery("batt2", nil)})
+	if got.Status != BatteryUnknown || got.Severity() != 0 {
+		t.Errorf("unexpected status %+v", got)
+	}
+}
+
+func TestBatterySeverityStatus(t *testing.T) {
+	for severity, want := range []string{BatteryOK, BatteryLow, BatteryCritical} {
+		if got := BatterySeverityStatus(severity); got != want {
+			t.Errorf("BatterySeverityStatus(%d) = %s, want %s", severity, got, want)
+		}
+	}
+}
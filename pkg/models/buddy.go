@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BuddyConfigKey is the station config key of the buddy station that
+// fills sensors while the station is offline
+const BuddyConfigKey = "buddy_station"
+
+// Buddy station defaults
+const (
+	DefaultBuddyMaxGap = 15 * time.Minute
+	minBuddyMaxGap     = time.Minute
+	maxBuddyMaxGap     = 24 * time.Hour
+)
+
+// BuddySensor fills one sensor from a sensor of the buddy station
+type BuddySensor struct {
+	// Source is the sensor ID or remote ID of the buddy sensor
+	Source string `json:"source"`
+	// Bias is added to the buddy values, e.g. -0.8 for a buddy in a warmer
+	// spot
+	Bias float64 `json:"bias"`
+}
+
+// BuddyPolicy configures the buddy station of a station:
+//
+//	"buddy_station": {"station": "<station-id>", "max_gap": "15m", "sensors": {"tempf": {"source": "tempf", "bias": -0.4}}}
+//
+// Sensors are keyed by sensor ID or remote ID. Once a sensor has no reading
+// for MaxGap, the readings of its buddy sensor plus the bias are stored as
+// estimated readings until the station reports again.
+type BuddyPolicy struct {
+	StationID uuid.UUID              `json:"station"`
+	MaxGap    time.Duration          `json:"-"`
+	Sensors   map[string]BuddySensor `json:"sensors"`
+}
+
+// buddyConfig is the stored form of a BuddyPolicy
+type buddyConfig struct {
+	Station string                 `json:"station"`
+	MaxGap  string                 `json:"max_gap"`
+	Sensors map[string]BuddySensor `json:"sensors"`
+}
+
+// BuddyPair is a sensor of a station with the buddy sensor it is filled
+// from
+type BuddyPair struct {
+	Target Sensor
+	Source Sensor
+	Bias   float64
+}
+
+// ParseBuddyPolicy reads the buddy station of a station config. ok is
+// false when no buddy station is set.
+func ParseBuddyPolicy(config map[string]interface{}) (policy BuddyPolicy, ok bool, err error) {
+	value, found := config[BuddyConfigKey]
+	if !found || value == nil {
+		return policy, false, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, false, err
+	}
+	var stored buddyConfig
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return policy, false, fmt.Errorf("invalid %s config: %w", BuddyConfigKey, err)
+	}
+
+	policy.StationID, err = uuid.Parse(stored.Station)
+	if err != nil {
+		return BuddyPolicy{}, false, fmt.Errorf("invalid %s config: station must be a station ID", BuddyConfigKey)
+	}
+
+	policy.MaxGap = DefaultBuddyMaxGap
+	if stored.MaxGap != "" {
+		policy.MaxGap, err = time.ParseDuration(stored.MaxGap)
+		if err != nil {
+			return BuddyPolicy{}, false, fmt.Errorf("invalid %s config: max_gap must be a duration like 15m", BuddyConfigKey)
+		}
+	}
+	if policy.MaxGap < minBuddyMaxGap || policy.MaxGap > maxBuddyMaxGap {
+		return BuddyPolicy{}, false, fmt.Errorf("invalid %s config: max_gap must be between %s and %s", BuddyConfigKey, minBuddyMaxGap, maxBuddyMaxGap)
+	}
+
+	if len(stored.Sensors) == 0 {
+		return BuddyPolicy{}, false, fmt.Errorf("invalid %s config: sensors must list at least one sensor", BuddyConfigKey)
+	}
+	for key, s := range stored.Sensors {
+		if s.Source == "" {
+			return BuddyPolicy{}, false, fmt.Errorf("invalid %s config: source of %s is missing", BuddyConfigKey, key)
+		}
+	}
+	policy.Sensors = stored.Sensors
+	return policy, true, nil
+}
+
+// Pairs matches the configured sensors of the station with the sensors of
+// the buddy station. Keys or sources that match no sensor, and sources in
+// another unit than their target, are returned as problems. Custom sensor
+// types only match the same type.
+func (p BuddyPolicy) Pairs(targets, sources []Sensor) ([]BuddyPair, []string) {
+	find := func(sensors []Sensor, key string) (Sensor, bool) {
+		for _, s := range sensors {
+			if s.ID.String() == key || (s.RemoteID != "" && s.RemoteID == key) {
+				return s, true
+			}
+		}
+		return Sensor{}, false
+	}
+
+	keys := make([]string, 0, len(p.Sensors))
+	for key := range p.Sensors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []BuddyPair
+	var problems []string
+	for _, key := range keys {
+		s := p.Sensors[key]
+		target, ok := find(targets, key)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("sensor %s not found", key))
+			continue
+		}
+		source, ok := find(sources, s.Source)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("buddy sensor %s not found", s.Source))
+			continue
+		}
+		targetType, known := SensorTypeRegistry[target.SensorType]
+		sourceType, sourceKnown := SensorTypeRegistry[source.SensorType]
+		if target.SensorType != source.SensorType && (!known || !sourceKnown || targetType.Unit != sourceType.Unit) {
+			problems = append(problems, fmt.Sprintf("buddy sensor %s is a %s, not a %s", s.Source, source.SensorType, target.SensorType))
+			continue
+		}
+		pairs = append(pairs, BuddyPair{Target: target, Source: source, Bias: s.Bias})
+	}
+	return pairs, problems
+}
+
+// Estimate returns the estimated reading of the target sensor for a reading
+// of the buddy sensor
+func (p BuddyPair) Estimate(r SensorReading) SensorReading {
+	return SensorReading{
+		SensorID:  p.Target.ID,
+		Value:     r.Value + p.Bias,
+		Unit:      r.Unit,
+		DateUTC:   r.DateUTC,
+		Estimated: true,
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseBuddyPolicy(t *testing.T) {
+	buddy := uuid.New()
+
+	if _, ok, err := ParseBuddyPolicy(map[string]interface{}{}); ok || err != nil {
+		t.Fatalf("ParseBuddyPolicy() ok = %v, error = %v, want no settings", ok, err)
+	}
+
+	policy, ok, err := ParseBuddyPolicy(map[string]interface{}{BuddyConfigKey: map[string]interface{}{
+		"station": buddy.String(),
+		"sensors": map[string]interface{}{"tempf": map[string]interface{}{"source": "tempf", "bias": -0.4}},
+	}})
+	if err != nil || !ok {
+		t.Fatalf("ParseBuddyPolicy() ok = %v, error = %v", ok, err)
+	}
+	if policy.StationID != buddy || policy.MaxGap != DefaultBuddyMaxGap || policy.Sensors["tempf"].Bias != -0.4 {
+		t.Errorf("ParseBuddyPolicy() = %+v", policy)
+	}
+
+	sensors := map[string]interface{}{"tempf": map[string]interface{}{"source": "tempf"}}
+	invalid := map[string]interface{}{
+		"Not an object":  "buddy",
+		"Station":        map[string]interface{}{"station": "home", "sensors": sensors},
+		"Max gap":        map[string]interface{}{"station": buddy.String(), "max_gap": "soon", "sensors": sensors},
+		"Short max gap":  map[string]interface{}{"station": buddy.String(), "max_gap": "10s", "sensors": sensors},
+		"No sensors":     map[string]interface{}{"station": buddy.String()},
+		"Missing source": map[string]interface{}{"station": buddy.String(), "sensors": map[string]interface{}{"tempf": map[string]interface{}{"bias": 1}}},
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseBuddyPolicy(map[string]interface{}{BuddyConfigKey: value}); err == nil {
+				t.Errorf("ParseBuddyPolicy(%v) expected an error", value)
+			}
+		})
+	}
+}
+
+func TestBuddyPolicy_Pairs(t *testing.T) {
+	temp := Sensor{ID: uuid.New(), SensorType: SensorTypeTemperature, RemoteID: "tempf"}
+	humidity := Sensor{ID: uuid.New(), SensorType: SensorTypeHumidity, RemoteID: "humidity"}
+	buddyTemp := Sensor{ID: uuid.New(), SensorType: SensorTypeTemperatureOutdoor, RemoteID: "70ee-TemperatureOutdoor"}
+	buddyWind := Sensor{ID: uuid.New(), SensorType: SensorTypeWindSpeed, RemoteID: "wind"}
+
+	policy := BuddyPolicy{Sensors: map[string]BuddySensor{
+		"tempf":              {Source: buddyTemp.ID.String(), Bias: -0.5},
+		humidity.ID.String(): {Source: "wind"},
+		"rainratein":         {Source: "rain"},
+	}}
+	pairs, problems := policy.Pairs([]Sensor{temp, humidity}, []Sensor{buddyTemp, buddyWind})
+	if len(pairs) != 1 || pairs[0].Target.ID != temp.ID || pairs[0].Source.ID != buddyTemp.ID {
+		t.Fatalf("Pairs() = %+v, want the temperature", pairs)
+	}
+	if len(problems) != 2 {
+		t.Errorf("Pairs() problems = %v, want the wind and the missing rain sensor", problems)
+	}
+
+	at := time.Now().UTC()
+	estimate := pairs[0].Estimate(SensorReading{SensorID: buddyTemp.ID, Value: 10, Unit: "°C", DateUTC: at})
+	if estimate.SensorID != temp.ID || estimate.Value != 9.5 || !estimate.Estimated || !estimate.DateUTC.Equal(at) {
+		t.Errorf("Estimate() = %+v", estimate)
+	}
+}
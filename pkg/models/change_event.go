@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entities of change events
+const (
+	ChangeEntityReading = "reading"
+	ChangeEntityStation = "station"
+	ChangeEntitySensor  = "sensor"
+)
+
+// Operations of change events
+const (
+	ChangeOperationInsert = "insert"
+	ChangeOperationUpdate = "update"
+	ChangeOperationDelete = "delete"
+)
+
+// ChangeEvent is a change of a reading, station or sensor published by the
+// change data capture stream. Delivery is at least once, consumers skip
+// events whose ID they already processed.
+type ChangeEvent struct {
+	ID        string    `json:"id"`
+	Entity    string    `json:"entity"`
+	Operation string    `json:"operation"`
+	EntityID  uuid.UUID `json:"entity_id"`
+	// Data is the reading, station or sensor after the change, before a
+	// delete
+	Data json.RawMessage `json:"data"`
+	Time time.Time       `json:"time"`
+
+	// Key keeps related events in order on partitioned brokers: the sensor
+	// of a reading, the entity itself otherwise
+	Key string `json:"-"`
+	// OutboxID is the position of station and sensor changes in the outbox
+	OutboxID int64 `json:"-"`
+}
+
+// ReadingChangeEvent is the event of a stored reading
+func ReadingChangeEvent(c ReadingChange) (ChangeEvent, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ChangeEvent{}, err
+	}
+	return ChangeEvent{
+		ID:        fmt.Sprintf("%s:%s", ChangeEntityReading, c.ID),
+		Entity:    ChangeEntityReading,
+		Operation: ChangeOperationInsert,
+		EntityID:  c.ID,
+		Data:      data,
+		Time:      c.InsertedAt,
+		Key:       c.SensorID.String(),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReadingChangeEvent(t *testing.T) {
+	change := ReadingChange{
+		SensorReading: SensorReading{ID: uuid.New(), SensorID: uuid.New(), Value: 14.2, DateUTC: time.Date(2026, 9, 30, 8, 0, 0, 0, time.UTC)},
+		InsertedAt:    time.Date(2026, 10, 1, 12, 0, 3, 0, time.UTC),
+	}
+	event, err := ReadingChangeEvent(change)
+	if err != nil {
+		t.Fatalf("ReadingChangeEvent() error = %v", err)
+	}
+	if event.ID != "reading:"+change.ID.String() || event.EntityID != change.ID || event.Key != change.SensorID.String() {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if !event.Time.Equal(change.InsertedAt) {
+		t.Errorf("Expected the storage time, got %s", event.Time)
+	}
+
+	var data ReadingChange
+	if err := json.Unmarshal(event.Data, &data); err != nil || data.Value != 14.2 {
+		t.Errorf("Expected the reading as data, got %s (%v)", event.Data, err)
+	}
+}
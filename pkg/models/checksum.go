@@ -0,0 +1,100 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReadingChecksum is the content checksum of the readings of a station on a
+// UTC day. Archives can be compared with the database years later: any
+// changed, added or removed reading changes the checksum.
+type ReadingChecksum struct {
+	StationID uuid.UUID `json:"station_id"`
+	Day       string    `json:"day"`
+	Readings  int64     `json:"readings"`
+	SHA256    string    `json:"sha256"`
+}
+
+// ReadingChecksummer computes the daily checksums of readings. Readings must
+// be added in canonical order: by sensor ID, then time, then value. Each
+// reading is hashed as one line
+//
+//	sensor_id|date_utc|value|unit|raw_value|raw_unit
+//
+// with the time in RFC 3339 with milliseconds and the shortest values that
+// parse back to the same numbers.
+type ReadingChecksummer struct {
+	days map[readingDay]*dayHash
+}
+
+type readingDay struct {
+	station uuid.UUID
+	day     string
+}
+
+type dayHash struct {
+	hash     hash.Hash
+	readings int64
+}
+
+// NewReadingChecksummer creates an empty checksummer
+func NewReadingChecksummer() *ReadingChecksummer {
+	return &ReadingChecksummer{days: make(map[readingDay]*dayHash)}
+}
+
+// Add hashes a reading of a station
+func (c *ReadingChecksummer) Add(stationID uuid.UUID, r SensorReading) {
+	t := r.DateUTC.UTC()
+	key := readingDay{station: stationID, day: t.Format(time.DateOnly)}
+	d, ok := c.days[key]
+	if !ok {
+		d = &dayHash{hash: sha256.New()}
+		c.days[key] = d
+	}
+
+	line := make([]byte, 0, 128)
+	line = append(line, r.SensorID.String()...)
+	line = append(line, '|')
+	line = t.AppendFormat(line, "2006-01-02T15:04:05.000Z07:00")
+	line = append(line, '|')
+	line = strconv.AppendFloat(line, r.Value, 'g', -1, 64)
+	line = append(line, '|')
+	line = append(line, r.Unit...)
+	line = append(line, '|')
+	if r.RawValue != nil {
+		line = strconv.AppendFloat(line, *r.RawValue, 'g', -1, 64)
+	}
+	line = append(line, '|')
+	line = append(line, r.RawUnit...)
+	line = append(line, '\n')
+
+	d.hash.Write(line)
+	d.readings++
+}
+
+// Checksums returns the checksums of all days with readings, ordered by
+// station and day
+func (c *ReadingChecksummer) Checksums() []ReadingChecksum {
+	checksums := make([]ReadingChecksum, 0, len(c.days))
+	for key, d := range c.days {
+		checksums = append(checksums, ReadingChecksum{
+			StationID: key.station,
+			Day:       key.day,
+			Readings:  d.readings,
+			SHA256:    hex.EncodeToString(d.hash.Sum(nil)),
+		})
+	}
+	sort.Slice(checksums, func(i, j int) bool {
+		if checksums[i].StationID != checksums[j].StationID {
+			return checksums[i].StationID.String() < checksums[j].StationID.String()
+		}
+		return checksums[i].Day < checksums[j].Day
+	})
+	return checksums
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReadingChecksummer(t *testing.T) {
+	station := uuid.MustParse("22c6d33f-d0ee-440c-a2b0-faae2bfe0bac")
+	sensor := uuid.MustParse("e507f902-27a5-4c83-9d9c-08a17e5855d9")
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	raw := 12000.0
+	readings := []SensorReading{
+		{SensorID: sensor, Value: 12.5, Unit: "°C", DateUTC: day.Add(time.Hour)},
+		{SensorID: sensor, Value: 94.8, Unit: "W/m²", RawValue: &raw, RawUnit: "lux", DateUTC: day.Add(2 * time.Hour)},
+		{SensorID: sensor, Value: 11, Unit: "°C", DateUTC: day.Add(25 * time.Hour)},
+	}
+
+	checksums := func(readings []SensorReading) []ReadingChecksum {
+		c := NewReadingChecksummer()
+		for _, r := range readings {
+			c.Add(station, r)
+		}
+		return c.Checksums()
+	}
+
+	got := checksums(readings)
+	if len(got) != 2 || got[0].Day != "2026-10-16" || got[0].Readings != 2 || got[1].Readings != 1 {
+		t.Fatalf("Checksums() = %+v, want two days with 2 and 1 readings", got)
+	}
+	if len(got[0].SHA256) != 64 || got[0].SHA256 == got[1].SHA256 {
+		t.Errorf("Checksums() = %+v, want distinct SHA-256 checksums", got)
+	}
+
+	// The time zone of the reading does not matter
+	local := append([]SensorReading(nil), readings...)
+	local[0].DateUTC = local[0].DateUTC.In(time.FixedZone("CET", 3600))
+	if again := checksums(local); again[0].SHA256 != got[0].SHA256 {
+		t.Error("Checksum depends on the time zone of the reading")
+	}
+
+	// Any changed value changes the checksum of its day only
+	changed := append([]SensorReading(nil), readings...)
+	changed[0].Value = 12.6
+	if again := checksums(changed); again[0].SHA256 == got[0].SHA256 || again[1].SHA256 != got[1].SHA256 {
+		t.Error("Changed reading not detected or affecting other days")
+	}
+}
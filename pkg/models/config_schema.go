@@ -0,0 +1,210 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// JSON Schema types of config properties
+const (
+	ConfigTypeString  = "string"
+	ConfigTypeInteger = "integer"
+	ConfigTypeNumber  = "number"
+	ConfigTypeBoolean = "boolean"
+	ConfigTypeObject  = "object"
+	ConfigTypeArray   = "array"
+)
+
+// configSchemaDialect is the JSON Schema version of ConfigSchema
+const configSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ConfigSchema is the JSON Schema of the station config a provider reads.
+// Keys not described are allowed, as the config also holds the settings of
+// the station itself. Properties keep the order they are added in, which is
+// the order they are asked for.
+type ConfigSchema struct {
+	Schema     string                     `json:"$schema"`
+	Type       string                     `json:"type"`
+	Properties map[string]*ConfigProperty `json:"properties"`
+	Required   []string                   `json:"required,omitempty"`
+
+	names []string
+}
+
+// ConfigProperty describes a config key
+type ConfigProperty struct {
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	Format      string        `json:"format,omitempty"`
+	Minimum     *float64      `json:"minimum,omitempty"`
+	Maximum     *float64      `json:"maximum,omitempty"`
+	// WriteOnly marks credentials, which are stored encrypted and redacted
+	// in responses
+	WriteOnly bool `json:"writeOnly,omitempty"`
+	// ReadOnly marks values set by the server, e.g. OAuth tokens
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// NewConfigSchema creates an empty config schema
+func NewConfigSchema() *ConfigSchema {
+	return &ConfigSchema{
+		Schema:     configSchemaDialect,
+		Type:       ConfigTypeObject,
+		Properties: make(map[string]*ConfigProperty),
+	}
+}
+
+// Add adds or replaces a property. Keys of SecretConfigKeys are marked
+// write-only.
+func (s *ConfigSchema) Add(name string, property ConfigProperty) *ConfigSchema {
+	if _, ok := s.Properties[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	property.WriteOnly = property.WriteOnly || IsSecretConfigKey(name)
+	s.Properties[name] = &property
+	return s
+}
+
+// Require marks properties as required
+func (s *ConfigSchema) Require(names ...string) *ConfigSchema {
+	for _, name := range names {
+		if !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// Extend adds the properties and requirements of another schema
+func (s *ConfigSchema) Extend(other *ConfigSchema) *ConfigSchema {
+	for _, name := range other.Names() {
+		s.Add(name, *other.Properties[name])
+	}
+	return s.Require(other.Required...)
+}
+
+// Names returns the property names in the order they were added
+func (s *ConfigSchema) Names() []string {
+	var extra []string
+	for name := range s.Properties {
+		if !slices.Contains(s.names, name) {
+			extra = append(extra, name)
+		}
+	}
+	slices.Sort(extra)
+	return append(slices.Clone(s.names), extra...)
+}
+
+// IsRequired reports whether a property is required
+func (s *ConfigSchema) IsRequired(name string) bool {
+	return slices.Contains(s.Required, name)
+}
+
+// Validate checks the required keys and the values of the described keys.
+// Empty strings count as missing.
+func (s *ConfigSchema) Validate(config map[string]interface{}) ValidationErrors {
+	var errs ValidationErrors
+	for _, name := range s.Required {
+		if value, ok := config[name]; !ok || value == nil || value == "" {
+			errs.Add(name, "%s is required", name)
+		}
+	}
+	for _, name := range s.Names() {
+		value, ok := config[name]
+		if !ok || value == nil || errs.Has(name) {
+			continue
+		}
+		if err := s.Properties[name].check(value); err != nil {
+			errs.Add(name, "%s %s", name, err)
+		}
+	}
+	return errs
+}
+
+// ValidateValue checks a single value. Values of keys not described pass.
+func (s *ConfigSchema) ValidateValue(name string, value interface{}) error {
+	property, ok := s.Properties[name]
+	if !ok || value == nil {
+		return nil
+	}
+	if err := property.check(value); err != nil {
+		return fmt.Errorf("%s %w", name, err)
+	}
+	return nil
+}
+
+// check validates a value against the property
+func (p *ConfigProperty) check(value interface{}) error {
+	number, isNumber := configNumber(value)
+	switch p.Type {
+	case ConfigTypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case ConfigTypeInteger:
+		if !isNumber || number != math.Trunc(number) {
+			return fmt.Errorf("must be an integer")
+		}
+	case ConfigTypeNumber:
+		if !isNumber {
+			return fmt.Errorf("must be a number")
+		}
+	case ConfigTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("must be true or false")
+		}
+	case ConfigTypeObject:
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("must be an object")
+		}
+	case ConfigTypeArray:
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("must be a list")
+		}
+	}
+
+	_, isString := value.(string)
+	_, isBool := value.(bool)
+	if len(p.Enum) > 0 && (isString || isBool || isNumber) && !slices.Contains(p.Enum, value) {
+		return fmt.Errorf("must be one of %v", p.Enum)
+	}
+	if s, ok := value.(string); ok && p.Pattern != "" {
+		pattern, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("has an invalid pattern: %w", err)
+		}
+		if !pattern.MatchString(s) {
+			return fmt.Errorf("must match %s", p.Pattern)
+		}
+	}
+	if isNumber && p.Minimum != nil && number < *p.Minimum {
+		return fmt.Errorf("must be at least %g", *p.Minimum)
+	}
+	if isNumber && p.Maximum != nil && number > *p.Maximum {
+		return fmt.Errorf("must be at most %g", *p.Maximum)
+	}
+	return nil
+}
+
+// configNumber returns the value of JSON numbers and Go integers
+func configNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// ConfigLimit returns a pointer to a minimum or maximum of a property
+func ConfigLimit(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func testConfigSchema() *ConfigSchema {
+	return NewConfigSchema().
+		Add("host", ConfigProperty{Type: ConfigTypeString, Description: "Server"}).
+		Add("port", ConfigProperty{Type: ConfigTypeInteger, Default: 993, Minimum: ConfigLimit(1), Maximum: ConfigLimit(65535)}).
+		Add("api_key", ConfigProperty{Type: ConfigTypeString}).
+		Add("code", ConfigProperty{Type: ConfigTypeString, Pattern: `^[A-Z]{4}$`}).
+		Add("mode", ConfigProperty{Type: ConfigTypeString, Enum: []interface{}{"fast", "slow"}}).
+		Add("tls", ConfigProperty{Type: ConfigTypeBoolean}).
+		Add("mapping", ConfigProperty{Type: ConfigTypeObject}).
+		Require("host", "api_key")
+}
+
+func TestConfigSchemaValidate(t *testing.T) {
+	schema := testConfigSchema()
+
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		fields []string
+	}{
+		{"valid", map[string]interface{}{"host": "mail", "api_key": "x", "port": float64(143), "tls": false, "code": "LOWW", "mode": "fast", "mapping": map[string]interface{}{}}, nil},
+		{"go integer", map[string]interface{}{"host": "mail", "api_key": "x", "port": 143}, nil},
+		{"unknown keys", map[string]interface{}{"host": "mail", "api_key": "x", "name": 42}, nil},
+		{"missing", map[string]interface{}{"api_key": "x"}, []string{"host"}},
+		{"empty and null", map[string]interface{}{"host": "", "api_key": nil}, []string{"host", "api_key"}},
+		{"wrong types", map[string]interface{}{"host": 1, "api_key": "x", "port": "993", "tls": "yes", "mapping": "{}"}, []string{"host", "port", "tls", "mapping"}},
+		{"fraction", map[string]interface{}{"host": "mail", "api_key": "x", "port": 1.5}, []string{"port"}},
+		{"limits", map[string]interface{}{"host": "mail", "api_key": "x", "port": float64(70000)}, []string{"port"}},
+		{"pattern and enum", map[string]interface{}{"host": "mail", "api_key": "x", "code": "loww", "mode": "medium"}, []string{"code", "mode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate(tt.config)
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			slices.Sort(fields)
+			want := slices.Clone(tt.fields)
+			slices.Sort(want)
+			if !slices.Equal(fields, want) {
+				t.Errorf("Expected errors of %v, got %v", want, errs)
+			}
+		})
+	}
+}
+
+func TestConfigSchemaValidateValue(t *testing.T) {
+	schema := testConfigSchema()
+	if err := schema.ValidateValue("port", float64(993)); err != nil {
+		t.Errorf("Expected valid port, got %v", err)
+	}
+	if err := schema.ValidateValue("port", "993"); err == nil || err.Error() != "port must be an integer" {
+		t.Errorf("Expected an integer error, got %v", err)
+	}
+	if err := schema.ValidateValue("unknown", []interface{}{1}); err != nil {
+		t.Errorf("Expected unknown keys to pass, got %v", err)
+	}
+}
+
+func TestConfigSchemaJSON(t *testing.T) {
+	data, err := json.Marshal(testConfigSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["type"] != "object" || decoded["$schema"] == nil {
+		t.Errorf("Expected an object schema, got %s", data)
+	}
+	properties := decoded["properties"].(map[string]interface{})
+	apiKey := properties["api_key"].(map[string]interface{})
+	if apiKey["writeOnly"] != true {
+		t.Errorf("Expected api_key to be write-only, got %v", apiKey)
+	}
+	if port := properties["port"].(map[string]interface{}); port["default"] != float64(993) || port["minimum"] != float64(1) {
+		t.Errorf("Expected default and minimum of port, got %v", port)
+	}
+}
+
+func TestConfigSchemaOrder(t *testing.T) {
+	schema := testConfigSchema().Extend(NewConfigSchema().
+		Add("allowed_ips", ConfigProperty{Type: ConfigTypeString}).
+		Add("host", ConfigProperty{Type: ConfigTypeString, Description: "Other"}).
+		Require("allowed_ips"))
+
+	want := []string{"host", "port", "api_key", "code", "mode", "tls", "mapping", "allowed_ips"}
+	if names := schema.Names(); !slices.Equal(names, want) {
+		t.Errorf("Names() = %v, want %v", names, want)
+	}
+	if !schema.IsRequired("allowed_ips") || !schema.IsRequired("host") || schema.IsRequired("port") {
+		t.Errorf("Unexpected required properties %v", schema.Required)
+	}
+	if schema.Properties["host"].Description != "Other" {
+		t.Errorf("Expected the extending property to replace host")
+	}
+}
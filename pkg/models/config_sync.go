@@ -0,0 +1,58 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ConfigConflict is a station config key changed differently on both
+// instances since their last sync. A nil value means the key was deleted.
+type ConfigConflict struct {
+	Key    string      `json:"key"`
+	Local  interface{} `json:"local"`
+	Remote interface{} `json:"remote"`
+}
+
+// MergeConfig merges the config of a station kept on two instances, e.g. an
+// edge instance and the central one, given base, the config both had after
+// their last sync. Keys changed on only one side take that value, deleted
+// keys are removed. Keys changed differently on both sides are conflicts;
+// they are resolved in favour of remote, the central instance. Without a
+// base, as on the first sync, keys of both sides are kept and keys set to
+// different values are conflicts.
+func MergeConfig(base, local, remote map[string]interface{}) (map[string]interface{}, []ConfigConflict) {
+	keys := make(map[string]bool)
+	for _, config := range []map[string]interface{}{base, local, remote} {
+		for key := range config {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	merged := make(map[string]interface{}, len(keys))
+	var conflicts []ConfigConflict
+	for _, key := range sorted {
+		b, inBase := base[key]
+		l, inLocal := local[key]
+		r, inRemote := remote[key]
+		localChanged := inLocal != inBase || !reflect.DeepEqual(l, b)
+		remoteChanged := inRemote != inBase || !reflect.DeepEqual(r, b)
+
+		value, present := r, inRemote
+		switch {
+		case !localChanged:
+		case !remoteChanged:
+			value, present = l, inLocal
+		case inLocal != inRemote || !reflect.DeepEqual(l, r):
+			conflicts = append(conflicts, ConfigConflict{Key: key, Local: l, Remote: r})
+		}
+		if present {
+			merged[key] = value
+		}
+	}
+	return merged, conflicts
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeConfig(t *testing.T) {
+	base := map[string]interface{}{"timezone": "Europe/Vienna", "altitude": 520.0, "lux": true}
+	local := map[string]interface{}{"timezone": "Europe/Vienna", "altitude": 530.0, "lux": true, "edge": "cabin"}
+	remote := map[string]interface{}{"timezone": "Europe/Berlin", "altitude": 520.0}
+
+	merged, conflicts := MergeConfig(base, local, remote)
+
+	expected := map[string]interface{}{"timezone": "Europe/Berlin", "altitude": 530.0, "edge": "cabin"}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", conflicts)
+	}
+}
+
+func TestMergeConfig_Conflicts(t *testing.T) {
+	base := map[string]interface{}{"timezone": "Europe/Vienna", "altitude": 520.0}
+	local := map[string]interface{}{"timezone": "Europe/Berlin", "sharing": map[string]interface{}{"license": "CC-BY-4.0"}}
+	remote := map[string]interface{}{"timezone": "Europe/Zurich", "altitude": 525.0, "sharing": map[string]interface{}{"license": "CC-BY-4.0"}}
+
+	merged, conflicts := MergeConfig(base, local, remote)
+
+	// Remote wins; equal changes on both sides are no conflict
+	if !reflect.DeepEqual(merged, remote) {
+		t.Errorf("Expected %v, got %v", remote, merged)
+	}
+	expected := []ConfigConflict{
+		{Key: "altitude", Local: nil, Remote: 525.0},
+		{Key: "timezone", Local: "Europe/Berlin", Remote: "Europe/Zurich"},
+	}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected conflicts %v, got %v", expected, conflicts)
+	}
+}
+
+func TestMergeConfig_WithoutBase(t *testing.T) {
+	local := map[string]interface{}{"timezone": "Europe/Vienna", "altitude": 520.0}
+	remote := map[string]interface{}{"timezone": "Europe/Berlin", "lux": true}
+
+	merged, conflicts := MergeConfig(nil, local, remote)
+
+	expected := map[string]interface{}{"timezone": "Europe/Berlin", "altitude": 520.0, "lux": true}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != "timezone" {
+		t.Errorf("Unexpected conflicts %v", conflicts)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrphanedReadings counts the stored readings of a sensor that no longer
+// exists, e.g. because its station was deleted
+type OrphanedReadings struct {
+	SensorID uuid.UUID `json:"sensor_id"`
+	Count    uint64    `json:"count"`
+}
+
+// DuplicateReadings are readings of a sensor stored more than once for the
+// same timestamp
+type DuplicateReadings struct {
+	SensorID uuid.UUID   `json:"sensor_id"`
+	DateUTC  time.Time   `json:"dateutc"`
+	IDs      []uuid.UUID `json:"ids"`
+	// Conflicting is set when the duplicates have different values, so it
+	// is unclear which one to keep
+	Conflicting bool `json:"conflicting"`
+}
+
+// RollupDrift is a day whose daily rollup does not match the raw readings
+type RollupDrift struct {
+	SensorID    uuid.UUID `json:"sensor_id"`
+	Day         time.Time `json:"day"`
+	RawCount    uint64    `json:"raw_count"`
+	RollupCount uint64    `json:"rollup_count"`
+	RawSum      float64   `json:"raw_sum"`
+	RollupSum   float64   `json:"rollup_sum"`
+}
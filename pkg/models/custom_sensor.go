@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// customSensorTypeName restricts custom type names to identifiers, they are
+// used as sensor types and in query parameters
+var customSensorTypeName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,19}$`)
+
+// CustomSensorType is a sensor type defined for a single station, e.g. for
+// the ADC inputs of a DIY gateway. Its sensors are stored and aggregated
+// like those of built-in types.
+type CustomSensorType struct {
+	StationID uuid.UUID `json:"station_id"`
+	Name      string    `json:"name"`
+	Unit      string    `json:"unit"`
+	Category  string    `json:"category"`
+	// MinValue and MaxValue bound plausible values; readings outside are
+	// dropped at ingest
+	MinValue  *float64  `json:"min_value,omitempty"`
+	MaxValue  *float64  `json:"max_value,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the name, unit and bounds and defaults the category
+func (t *CustomSensorType) Validate() error {
+	if !customSensorTypeName.MatchString(t.Name) {
+		return errors.New("name must start with a letter and contain at most 20 letters, digits or underscores")
+	}
+	if _, ok := SensorTypeRegistry[t.Name]; ok {
+		return fmt.Errorf("%s is a built-in sensor type", t.Name)
+	}
+	if len(t.Unit) > 20 {
+		return errors.New("unit must be at most 20 characters")
+	}
+	if len(t.Category) > 50 {
+		return errors.New("category must be at most 50 characters")
+	}
+	if t.MinValue != nil && t.MaxValue != nil && *t.MinValue > *t.MaxValue {
+		return errors.New("min_value must not be greater than max_value")
+	}
+	if t.Category == "" {
+		t.Category = SensorCategoryCustom
+	}
+	return nil
+}
+
+// Info returns the type metadata in the form of the built-in registry
+func (t CustomSensorType) Info() SensorTypeInfo {
+	return SensorTypeInfo{Name: t.Name, Category: t.Category, Unit: t.Unit}
+}
+
+// InBounds reports whether a value lies within the configured bounds
+func (t CustomSensorType) InBounds(value float64) bool {
+	if t.MinValue != nil && value < *t.MinValue {
+		return false
+	}
+	if t.MaxValue != nil && value > *t.MaxValue {
+		return false
+	}
+	return true
+}
+
+// LookupSensorType returns the metadata of a built-in type or of one of the
+// custom types of a station
+func LookupSensorType(name string, custom []CustomSensorType) (SensorTypeInfo, bool) {
+	if info, ok := SensorTypeRegistry[name]; ok {
+		return info, true
+	}
+	for _, t := range custom {
+		if t.Name == name {
+			return t.Info(), true
+		}
+	}
+	return SensorTypeInfo{}, false
+}
@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestCustomSensorType_Validate(t *testing.T) {
+	low, high := 10.0, 0.0
+
+	testCases := []struct {
+		name    string
+		t       CustomSensorType
+		wantErr bool
+	}{
+		{name: "Valid", t: CustomSensorType{Name: "SoilMoisture", Unit: "%"}},
+		{name: "Missing name", t: CustomSensorType{Unit: "%"}, wantErr: true},
+		{name: "Invalid name", t: CustomSensorType{Name: "soil moisture"}, wantErr: true},
+		{name: "Name too long", t: CustomSensorType{Name: "SoilMoistureOfTheFrontBed"}, wantErr: true},
+		{name: "Built-in type", t: CustomSensorType{Name: SensorTypeTemperature}, wantErr: true},
+		{name: "Inverted bounds", t: CustomSensorType{Name: "Voltage", MinValue: &low, MaxValue: &high}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.t.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && tc.t.Category != SensorCategoryCustom {
+				t.Errorf("Category = %q, want default %q", tc.t.Category, SensorCategoryCustom)
+			}
+		})
+	}
+}
+
+func TestCustomSensorType_InBounds(t *testing.T) {
+	low, high := 0.0, 3.3
+	voltage := CustomSensorType{Name: "Voltage", MinValue: &low, MaxValue: &high}
+
+	for value, want := range map[float64]bool{-0.1: false, 0: true, 1.5: true, 3.3: true, 5: false} {
+		if got := voltage.InBounds(value); got != want {
+			t.Errorf("InBounds(%v) = %v, want %v", value, got, want)
+		}
+	}
+	if !(CustomSensorType{Name: "Counter"}).InBounds(1e9) {
+		t.Error("type without bounds must accept all values")
+	}
+}
+
+func TestLookupSensorType(t *testing.T) {
+	custom := []CustomSensorType{{Name: "SoilMoisture", Unit: "%", Category: "Garden"}}
+
+	if info, ok := LookupSensorType(SensorTypeTemperature, custom); !ok || info.Unit != "°C" {
+		t.Errorf("LookupSensorType(built-in) = %+v, %v", info, ok)
+	}
+	if info, ok := LookupSensorType("SoilMoisture", custom); !ok || info.Category != "Garden" {
+		t.Errorf("LookupSensorType(custom) = %+v, %v", info, ok)
+	}
+	if _, ok := LookupSensorType("Unknown", custom); ok {
+		t.Error("expected unknown type not to be found")
+	}
+}
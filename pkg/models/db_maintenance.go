@@ -0,0 +1,145 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Database maintenance tasks
+const (
+	// DBMaintenanceAnalyze refreshes the planner statistics of all tables
+	DBMaintenanceAnalyze = "analyze"
+	// DBMaintenanceVacuum vacuums tables with many dead rows
+	DBMaintenanceVacuum = "vacuum"
+	// DBMaintenanceReindex rebuilds bloated indexes
+	DBMaintenanceReindex = "reindex"
+	// DBMaintenanceMerge merges the parts of finished months in ClickHouse
+	DBMaintenanceMerge = "merge"
+)
+
+// DBMaintenanceTasks are all database maintenance tasks in the order they run
+var DBMaintenanceTasks = []string{DBMaintenanceVacuum, DBMaintenanceAnalyze, DBMaintenanceReindex, DBMaintenanceMerge}
+
+// ParseDBMaintenanceTasks parses a comma separated list of maintenance
+// tasks and returns them in the order they run. "all" or an empty list
+// selects all tasks.
+func ParseDBMaintenanceTasks(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "all" {
+		return slices.Clone(DBMaintenanceTasks), nil
+	}
+
+	selected := make(map[string]bool)
+	for _, task := range strings.Split(value, ",") {
+		task = strings.ToLower(strings.TrimSpace(task))
+		if !slices.Contains(DBMaintenanceTasks, task) {
+			return nil, fmt.Errorf("unknown maintenance task %q, expected one of %s", task, strings.Join(DBMaintenanceTasks, ", "))
+		}
+		selected[task] = true
+	}
+
+	tasks := make([]string, 0, len(selected))
+	for _, task := range DBMaintenanceTasks {
+		if selected[task] {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// DBMaintenanceWindow is a daily time range in which scheduled maintenance
+// may start, e.g. 02:00-05:00. A window ending before it starts spans
+// midnight.
+type DBMaintenanceWindow struct {
+	// Start and End are offsets from midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseDBMaintenanceWindow parses a window given as HH:MM-HH:MM
+func ParseDBMaintenanceWindow(value string) (*DBMaintenanceWindow, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(value), "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid maintenance window %q, expected HH:MM-HH:MM", value)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", value, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window %q: %w", value, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid maintenance window %q: start and end are equal", value)
+	}
+	return &DBMaintenanceWindow{Start: start, End: end}, nil
+}
+
+// parseClock parses a time of day given as HH:MM into the offset from
+// midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether the time of day of t in its location lies in
+// the window
+func (w *DBMaintenanceWindow) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// String formats the window as HH:MM-HH:MM
+func (w *DBMaintenanceWindow) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(w.Start) + "-" + clock(w.End)
+}
+
+// IndexBloat estimates how many times larger a btree index is than a freshly
+// built one from the number of rows and the average width of the indexed
+// columns. Each entry takes a tuple header and a line pointer besides the
+// data, and pages are filled to 90% on build. It returns 0 without rows.
+func IndexBloat(sizeBytes, rows int64, keyWidth int) float64 {
+	if rows <= 0 || sizeBytes <= 0 {
+		return 0
+	}
+	// 8 byte index tuple header and 4 byte line pointer, aligned to 8 bytes
+	entry := int64((keyWidth+8+7)/8*8 + 4)
+	expected := float64(rows*entry) / 0.9
+	// The metapage and at least one leaf page
+	expected = max(expected, 2*8192)
+	return float64(sizeBytes) / expected
+}
+
+// DBIndexStats is the size of a Postgres btree index with the number of rows
+// and the average width of the indexed columns of its table
+type DBIndexStats struct {
+	Name     string `json:"name"`
+	Table    string `json:"table"`
+	Bytes    int64  `json:"bytes"`
+	Rows     int64  `json:"rows"`
+	KeyWidth int    `json:"key_width"`
+}
+
+// Bloat estimates how many times larger the index is than a fresh one
+func (s DBIndexStats) Bloat() float64 {
+	return IndexBloat(s.Bytes, s.Rows, s.KeyWidth)
+}
+
+// DBPartitionParts is a ClickHouse partition stored in several parts
+type DBPartitionParts struct {
+	Table     string `json:"table"`
+	Partition string `json:"partition"`
+	Parts     int    `json:"parts"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseDBMaintenanceTasks(t *testing.T) {
+	tasks, err := ParseDBMaintenanceTasks("")
+	if err != nil || !slices.Equal(tasks, DBMaintenanceTasks) {
+		t.Errorf("Expected all tasks, got %v, %v", tasks, err)
+	}
+
+	tasks, err = ParseDBMaintenanceTasks("merge, Analyze,merge")
+	if err != nil || !slices.Equal(tasks, []string{DBMaintenanceAnalyze, DBMaintenanceMerge}) {
+		t.Errorf("Expected analyze and merge in run order, got %v, %v", tasks, err)
+	}
+
+	if _, err := ParseDBMaintenanceTasks("analyze,defrag"); err == nil {
+		t.Error("Expected an error for an unknown task")
+	}
+}
+
+func TestParseDBMaintenanceWindow(t *testing.T) {
+	w, err := ParseDBMaintenanceWindow("02:00-05:30")
+	if err != nil {
+		t.Fatalf("ParseDBMaintenanceWindow() error = %v", err)
+	}
+	if w.Start != 2*time.Hour || w.End != 5*time.Hour+30*time.Minute {
+		t.Errorf("Unexpected window %+v", w)
+	}
+	if w.String() != "02:00-05:30" {
+		t.Errorf("Unexpected string %s", w)
+	}
+
+	for _, value := range []string{"02:00", "2-5", "02:00-25:00", "03:00-03:00"} {
+		if _, err := ParseDBMaintenanceWindow(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestDBMaintenanceWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 3, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	w, _ := ParseDBMaintenanceWindow("02:00-05:00")
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(1, 59), false},
+		{at(2, 0), true},
+		{at(4, 59), true},
+		{at(5, 0), false},
+	} {
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+
+	// Spanning midnight
+	w, _ = ParseDBMaintenanceWindow("23:00-01:00")
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(22, 59), false},
+		{at(23, 30), true},
+		{at(0, 30), true},
+		{at(1, 0), false},
+	} {
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestIndexBloat(t *testing.T) {
+	if bloat := IndexBloat(1<<20, 0, 16); bloat != 0 {
+		t.Errorf("Expected 0 without rows, got %f", bloat)
+	}
+
+	// 100000 uuids take 100000 * (24 + 4) / 0.9 bytes
+	fresh := int64(100000 * 28 * 10 / 9)
+	if bloat := IndexBloat(fresh, 100000, 16); bloat < 0.99 || bloat > 1.01 {
+		t.Errorf("Expected no bloat, got %f", bloat)
+	}
+	if bloat := IndexBloat(3*fresh, 100000, 16); bloat < 2.99 || bloat > 3.01 {
+		t.Errorf("Expected a bloat of 3, got %f", bloat)
+	}
+
+	// Small indexes never look bloated because of their fixed pages
+	if bloat := IndexBloat(16384, 10, 16); bloat > 1 {
+		t.Errorf("Expected no bloat for a small index, got %f", bloat)
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// UV risk categories of the WHO UV index
+const (
+	UVRiskLow      = "low"
+	UVRiskModerate = "moderate"
+	UVRiskHigh     = "high"
+	UVRiskVeryHigh = "very_high"
+	UVRiskExtreme  = "extreme"
+)
+
+// beaufortLimits are the upper wind speeds in m/s of Beaufort 0 to 11;
+// faster winds are Beaufort 12
+var beaufortLimits = []float64{0.5, 1.6, 3.4, 5.5, 8.0, 10.8, 13.9, 17.2, 20.8, 24.5, 28.5, 32.7}
+
+// BeaufortDescriptions are the English names of the Beaufort numbers
+var BeaufortDescriptions = []string{
+	"Calm", "Light air", "Light breeze", "Gentle breeze", "Moderate breeze", "Fresh breeze", "Strong breeze",
+	"Near gale", "Gale", "Strong gale", "Storm", "Violent storm", "Hurricane force",
+}
+
+// CompassDirections are the 16 compass points clockwise from north
+var CompassDirections = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// Beaufort returns the Beaufort number of a wind speed in m/s
+func Beaufort(speed float64) int {
+	for number, limit := range beaufortLimits {
+		if speed < limit {
+			return number
+		}
+	}
+	return len(beaufortLimits)
+}
+
+// CompassDirection returns the nearest of the 16 compass points of a
+// direction in degrees
+func CompassDirection(degrees float64) string {
+	normalized := math.Mod(math.Mod(degrees, 360)+360, 360)
+	index := int(math.Round(normalized/22.5)) % len(CompassDirections)
+	return CompassDirections[index]
+}
+
+// UVRisk returns the risk category of a UV index
+func UVRisk(index float64) string {
+	switch {
+	case index < 3:
+		return UVRiskLow
+	case index < 6:
+		return UVRiskModerate
+	case index < 8:
+		return UVRiskHigh
+	case index < 11:
+		return UVRiskVeryHigh
+	default:
+		return UVRiskExtreme
+	}
+}
+
+// DerivedValues are convenience fields computed from a reading, so clients
+// don't have to classify values themselves
+type DerivedValues struct {
+	Beaufort            *int   `json:"beaufort,omitempty"`
+	BeaufortDescription string `json:"beaufort_description,omitempty"`
+	Compass             string `json:"compass,omitempty"`
+	UVRisk              string `json:"uv_risk,omitempty"`
+}
+
+// DeriveValues returns the convenience fields of a reading of a sensor
+// type, or nil when the type has none
+func DeriveValues(sensorType string, value float64) *DerivedValues {
+	switch sensorType {
+	case SensorTypeWindSpeed, SensorTypeWindSpeedMaxDaily, SensorTypeWindGust, SensorTypeWindGustMaxDaily:
+		number := Beaufort(value)
+		return &DerivedValues{Beaufort: &number, BeaufortDescription: BeaufortDescriptions[number]}
+	case SensorTypeWindDirection, SensorTypeWindGustAngle:
+		return &DerivedValues{Compass: CompassDirection(value)}
+	case SensorTypeUVIndex:
+		return &DerivedValues{UVRisk: UVRisk(value)}
+	}
+	return nil
+}
+
+// MarshalJSON adds the derived values of the latest reading as "derived"
+func (s SensorWithLatestReading) MarshalJSON() ([]byte, error) {
+	// plain has the fields but not the methods, avoiding recursion
+	type plain SensorWithLatestReading
+	encoded := struct {
+		plain
+		Derived *DerivedValues `json:"derived,omitempty"`
+	}{plain: plain(s)}
+	if s.LatestReading != nil {
+		encoded.Derived = DeriveValues(s.Sensor.SensorType, s.LatestReading.Value)
+	}
+	return json.Marshal(encoded)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBeaufort(t *testing.T) {
+	testCases := []struct {
+		speed float64
+		want  int
+	}{
+		{0, 0},
+		{0.49, 0},
+		{0.5, 1},
+		{3.3, 2},
+		{5.5, 4},
+		{10.7, 5},
+		{24.4, 9},
+		{32.6, 11},
+		{32.7, 12},
+		{60, 12},
+	}
+
+	for _, tc := range testCases {
+		if got := Beaufort(tc.speed); got != tc.want {
+			t.Errorf("Beaufort(%v) = %d, want %d", tc.speed, got, tc.want)
+		}
+	}
+}
+
+func TestCompassDirection(t *testing.T) {
+	testCases := []struct {
+		degrees float64
+		want    string
+	}{
+		{0, "N"},
+		{11, "N"},
+		{12, "NNE"},
+		{90, "E"},
+		{200, "SSW"},
+		{349, "N"},
+		{360, "N"},
+		{-90, "W"},
+	}
+
+	for _, tc := range testCases {
+		if got := CompassDirection(tc.degrees); got != tc.want {
+			t.Errorf("CompassDirection(%v) = %s, want %s", tc.degrees, got, tc.want)
+		}
+	}
+}
+
+func TestUVRisk(t *testing.T) {
+	testCases := []struct {
+		index float64
+		want  string
+	}{
+		{0, UVRiskLow},
+		{2.9, UVRiskLow},
+		{3, UVRiskModerate},
+		{6, UVRiskHigh},
+		{8, UVRiskVeryHigh},
+		{11, UVRiskExtreme},
+	}
+
+	for _, tc := range testCases {
+		if got := UVRisk(tc.index); got != tc.want {
+			t.Errorf("UVRisk(%v) = %s, want %s", tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestSensorWithLatestReading_MarshalJSON(t *testing.T) {
+	t.Run("Wind speed", func(t *testing.T) {
+		s := SensorWithLatestReading{
+			Sensor:        Sensor{SensorType: SensorTypeWindSpeed},
+			LatestReading: &SensorReading{Value: 9},
+		}
+		encoded, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if !strings.Contains(string(encoded), `"derived":{"beaufort":5,"beaufort_description":"Fresh breeze"}`) {
+			t.Errorf("Marshal() = %s, want derived Beaufort 5", encoded)
+		}
+		if !strings.Contains(string(encoded), `"latest_reading":{`) {
+			t.Errorf("Marshal() = %s, want the latest reading", encoded)
+		}
+	})
+
+	t.Run("Without derived values", func(t *testing.T) {
+		s := SensorWithLatestReading{
+			Sensor:        Sensor{SensorType: SensorTypeTemperature},
+			LatestReading: &SensorReading{Value: 20},
+		}
+		encoded, err := json.Marshal(&s)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if strings.Contains(string(encoded), "derived") {
+			t.Errorf("Marshal() = %s, want no derived values", encoded)
+		}
+	})
+}
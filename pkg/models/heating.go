@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+)
+
+// HeatingConfigKey is the station config key of the heating curve settings
+const HeatingConfigKey = "heating"
+
+// Heating curve defaults
+const (
+	DefaultHeatingLimit  = 16
+	DefaultAverageHours  = 24
+	maxAverageHours      = 72
+	minHeatingCurvePoint = 2
+)
+
+// DefaultHeatingCurve suits a heat pump with radiators: 40 °C flow at
+// -10 °C outdoors, 25 °C at 15 °C
+var DefaultHeatingCurve = []HeatingCurvePoint{{Outdoor: -10, Flow: 40}, {Outdoor: 15, Flow: 25}}
+
+// HeatingCurvePoint is the flow temperature at an outdoor temperature, both
+// in °C
+type HeatingCurvePoint struct {
+	Outdoor float64 `json:"outdoor"`
+	Flow    float64 `json:"flow"`
+}
+
+// HeatingPolicy configures the weather-compensated flow temperature of a
+// station:
+//
+//	"heating": {"curve": [{"outdoor": -12, "flow": 35}, {"outdoor": 18, "flow": 22}], "heating_limit": 15, "webhook_url": "http://heatpump.local/flow"}
+//
+// The flow temperature is interpolated between the points of the curve and
+// kept at the first and last point beyond them.
+type HeatingPolicy struct {
+	// Curve holds the points of the heating curve, by outdoor temperature
+	Curve []HeatingCurvePoint `json:"curve"`
+	// Offset shifts the whole curve, e.g. +2 for a warmer house
+	Offset float64 `json:"offset"`
+	// HeatingLimit is the average outdoor temperature from which heating
+	// is off
+	HeatingLimit float64 `json:"heating_limit"`
+	// AverageHours is the time the outdoor temperature is averaged over,
+	// so the building's inertia smooths the curve
+	AverageHours int `json:"average_hours"`
+	// WebhookURL receives every hint as JSON
+	WebhookURL string `json:"-"`
+}
+
+// heatingConfig is the stored form of a HeatingPolicy
+type heatingConfig struct {
+	Curve        []HeatingCurvePoint `json:"curve"`
+	Offset       float64             `json:"offset"`
+	HeatingLimit *float64            `json:"heating_limit"`
+	AverageHours int                 `json:"average_hours"`
+	WebhookURL   string              `json:"webhook_url"`
+}
+
+// ParseHeatingPolicy reads the heating curve settings of a station config.
+// ok is false when the station has no heating settings.
+func ParseHeatingPolicy(config map[string]interface{}) (policy HeatingPolicy, ok bool, err error) {
+	value, found := config[HeatingConfigKey]
+	if !found || value == nil {
+		return policy, false, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, false, err
+	}
+	var stored heatingConfig
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return policy, false, fmt.Errorf("invalid %s config: %w", HeatingConfigKey, err)
+	}
+
+	policy.Curve = stored.Curve
+	if policy.Curve == nil {
+		policy.Curve = DefaultHeatingCurve
+	}
+	if len(policy.Curve) < minHeatingCurvePoint {
+		return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: curve needs at least %d points", HeatingConfigKey, minHeatingCurvePoint)
+	}
+	policy.Curve = append([]HeatingCurvePoint(nil), policy.Curve...)
+	sort.Slice(policy.Curve, func(i, j int) bool { return policy.Curve[i].Outdoor < policy.Curve[j].Outdoor })
+	for i := 1; i < len(policy.Curve); i++ {
+		prev, p := policy.Curve[i-1], policy.Curve[i]
+		if p.Outdoor == prev.Outdoor {
+			return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: curve has two points at %g °C", HeatingConfigKey, p.Outdoor)
+		}
+		if p.Flow > prev.Flow {
+			return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: flow must not rise with the outdoor temperature", HeatingConfigKey)
+		}
+	}
+	for _, p := range policy.Curve {
+		if p.Flow+stored.Offset < 10 || p.Flow+stored.Offset > 90 {
+			return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: flow temperatures must be between 10 and 90 °C", HeatingConfigKey)
+		}
+	}
+	policy.Offset = stored.Offset
+
+	policy.HeatingLimit = DefaultHeatingLimit
+	if stored.HeatingLimit != nil {
+		policy.HeatingLimit = *stored.HeatingLimit
+	}
+	if policy.HeatingLimit < -20 || policy.HeatingLimit > 30 {
+		return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: heating_limit must be between -20 and 30 °C", HeatingConfigKey)
+	}
+
+	policy.AverageHours = stored.AverageHours
+	if policy.AverageHours == 0 {
+		policy.AverageHours = DefaultAverageHours
+	}
+	if policy.AverageHours < 1 || policy.AverageHours > maxAverageHours {
+		return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: average_hours must be between 1 and %d", HeatingConfigKey, maxAverageHours)
+	}
+
+	if stored.WebhookURL != "" {
+		u, err := url.Parse(stored.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return HeatingPolicy{}, false, fmt.Errorf("invalid %s config: webhook_url must be an http or https URL", HeatingConfigKey)
+		}
+		policy.WebhookURL = stored.WebhookURL
+	}
+	return policy, true, nil
+}
+
+// FlowTemperature returns the flow temperature of the curve at an average
+// outdoor temperature, rounded to 0.1 °C
+func (p HeatingPolicy) FlowTemperature(outdoor float64) float64 {
+	curve := p.Curve
+	flow := curve[len(curve)-1].Flow
+	switch {
+	case outdoor <= curve[0].Outdoor:
+		flow = curve[0].Flow
+	case outdoor < curve[len(curve)-1].Outdoor:
+		i := sort.Search(len(curve), func(i int) bool { return curve[i].Outdoor >= outdoor })
+		lo, hi := curve[i-1], curve[i]
+		flow = lo.Flow + (hi.Flow-lo.Flow)*(outdoor-lo.Outdoor)/(hi.Outdoor-lo.Outdoor)
+	}
+	return math.Round((flow+p.Offset)*10) / 10
+}
+
+// Heating reports whether heating is on at an average outdoor temperature
+func (p HeatingPolicy) Heating(outdoor float64) bool {
+	return outdoor < p.HeatingLimit
+}
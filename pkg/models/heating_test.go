@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+func TestParseHeatingPolicy(t *testing.T) {
+	t.Run("Missing", func(t *testing.T) {
+		_, ok, err := ParseHeatingPolicy(map[string]interface{}{})
+		if err != nil || ok {
+			t.Fatalf("ParseHeatingPolicy() ok = %v, error = %v, want no settings", ok, err)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		policy, ok, err := ParseHeatingPolicy(map[string]interface{}{HeatingConfigKey: map[string]interface{}{}})
+		if err != nil || !ok {
+			t.Fatalf("ParseHeatingPolicy() ok = %v, error = %v", ok, err)
+		}
+		if len(policy.Curve) != len(DefaultHeatingCurve) || policy.HeatingLimit != DefaultHeatingLimit || policy.AverageHours != DefaultAverageHours {
+			t.Errorf("ParseHeatingPolicy() = %+v, want defaults", policy)
+		}
+	})
+
+	t.Run("Curve", func(t *testing.T) {
+		policy, _, err := ParseHeatingPolicy(map[string]interface{}{HeatingConfigKey: map[string]interface{}{
+			"curve":         []interface{}{map[string]interface{}{"outdoor": 18, "flow": 22}, map[string]interface{}{"outdoor": -12, "flow": 35}},
+			"heating_limit": 0,
+			"webhook_url":   "http://heatpump.local/flow",
+		}})
+		if err != nil {
+			t.Fatalf("ParseHeatingPolicy() error = %v", err)
+		}
+		if policy.Curve[0].Outdoor != -12 || policy.HeatingLimit != 0 || policy.WebhookURL != "http://heatpump.local/flow" {
+			t.Errorf("ParseHeatingPolicy() = %+v", policy)
+		}
+	})
+
+	invalid := map[string]interface{}{
+		"Not an object":  "steep",
+		"One point":      map[string]interface{}{"curve": []interface{}{map[string]interface{}{"outdoor": 0, "flow": 30}}},
+		"Duplicate":      map[string]interface{}{"curve": []interface{}{map[string]interface{}{"outdoor": 0, "flow": 30}, map[string]interface{}{"outdoor": 0, "flow": 25}}},
+		"Rising flow":    map[string]interface{}{"curve": []interface{}{map[string]interface{}{"outdoor": -10, "flow": 30}, map[string]interface{}{"outdoor": 10, "flow": 35}}},
+		"Hot flow":       map[string]interface{}{"offset": 60},
+		"Average hours":  map[string]interface{}{"average_hours": 100},
+		"Heating limit":  map[string]interface{}{"heating_limit": 40},
+		"Webhook scheme": map[string]interface{}{"webhook_url": "ftp://heatpump.local"},
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseHeatingPolicy(map[string]interface{}{HeatingConfigKey: value}); err == nil {
+				t.Errorf("ParseHeatingPolicy(%v) expected an error", value)
+			}
+		})
+	}
+}
+
+func TestHeatingPolicy_FlowTemperature(t *testing.T) {
+	policy := HeatingPolicy{
+		Curve:  []HeatingCurvePoint{{Outdoor: -10, Flow: 40}, {Outdoor: 0, Flow: 32}, {Outdoor: 15, Flow: 25}},
+		Offset: 1,
+	}
+	tests := map[float64]float64{
+		-20: 41,
+		-10: 41,
+		-5:  37,
+		0:   33,
+		7.5: 29.5,
+		15:  26,
+		20:  26,
+	}
+	for outdoor, want := range tests {
+		if got := policy.FlowTemperature(outdoor); got != want {
+			t.Errorf("FlowTemperature(%g) = %g, want %g", outdoor, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// HighFrequencyConfigKey is the station config key of the sensors that
+// report every few seconds, e.g. the rapid wind of a WeatherFlow Tempest.
+// Their raw readings are only kept in memory; sensor_readings receives one
+// aggregated reading per minute.
+const HighFrequencyConfigKey = "high_frequency"
+
+// HighFrequencySensors holds the remote IDs or sensor IDs of the
+// high-frequency sensors of a station
+type HighFrequencySensors map[string]bool
+
+// ParseHighFrequencySensors reads the high-frequency sensors of a station
+// config, a list of remote IDs or sensor IDs:
+//
+//	"high_frequency": ["windspeedmph", "winddir"]
+func ParseHighFrequencySensors(config map[string]interface{}) (HighFrequencySensors, error) {
+	value, ok := config[HighFrequencyConfigKey]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s config: expected a list of sensors", HighFrequencyConfigKey)
+	}
+
+	sensors := make(HighFrequencySensors, len(entries))
+	for _, v := range entries {
+		id, ok := v.(string)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid %s config: invalid sensor %v", HighFrequencyConfigKey, v)
+		}
+		sensors[id] = true
+	}
+	return sensors, nil
+}
+
+// Contains reports whether a sensor is a high-frequency sensor, looked up
+// by remote ID and then by sensor ID
+func (s HighFrequencySensors) Contains(sensor Sensor) bool {
+	return (sensor.RemoteID != "" && s[sensor.RemoteID]) || s[sensor.ID.String()]
+}
+
+// AggregateHighFrequency combines the values of a sensor within a minute:
+// the maximum of gusts, the vector mean of directions and the mean of all
+// other sensor types
+func AggregateHighFrequency(sensorType string, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	switch {
+	case sensorType == SensorTypeWindGust || sensorType == SensorTypeWindSpeedMaxDaily || sensorType == SensorTypeWindGustMaxDaily:
+		result := values[0]
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+		return result
+	case IsDirection(sensorType):
+		var sin, cos float64
+		for _, v := range values {
+			sin += math.Sin(v * math.Pi / 180)
+			cos += math.Cos(v * math.Pi / 180)
+		}
+		return math.Mod(math.Atan2(sin, cos)*180/math.Pi+360, 360)
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
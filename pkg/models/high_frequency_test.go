@@ -0,0 +1,43 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseHighFrequencySensors(t *testing.T) {
+	sensor := Sensor{ID: uuid.New(), RemoteID: "windspeedmph"}
+	pulled := Sensor{ID: uuid.New()}
+
+	sensors, err := ParseHighFrequencySensors(map[string]interface{}{
+		HighFrequencyConfigKey: []interface{}{"windspeedmph", pulled.ID.String()},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !sensors.Contains(sensor) || !sensors.Contains(pulled) || sensors.Contains(Sensor{ID: uuid.New(), RemoteID: "tempf"}) {
+		t.Errorf("Unexpected sensors: %v", sensors)
+	}
+
+	if _, err := ParseHighFrequencySensors(map[string]interface{}{HighFrequencyConfigKey: "windspeedmph"}); err == nil {
+		t.Error("Expected an error for a string instead of a list")
+	}
+	if sensors, err := ParseHighFrequencySensors(map[string]interface{}{}); err != nil || sensors != nil {
+		t.Errorf("Expected no sensors, got %v, %v", sensors, err)
+	}
+}
+
+func TestAggregateHighFrequency(t *testing.T) {
+	if got := AggregateHighFrequency(SensorTypeWindSpeed, []float64{2, 4, 6}); got != 4 {
+		t.Errorf("Expected the mean 4, got %v", got)
+	}
+	if got := AggregateHighFrequency(SensorTypeWindGust, []float64{2, 9, 6}); got != 9 {
+		t.Errorf("Expected the maximum 9, got %v", got)
+	}
+	// Directions around north average to north, not south
+	if got := AggregateHighFrequency(SensorTypeWindDirection, []float64{350, 10}); math.Abs(got) > 1e-9 && math.Abs(got-360) > 1e-9 {
+		t.Errorf("Expected 0°, got %v", got)
+	}
+}
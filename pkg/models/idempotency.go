@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request with an
+// Idempotency-Key. StatusCode is 0 while the first request is in progress.
+type IdempotencyRecord struct {
+	Source      string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IsComplete reports whether the response of the first request is stored
+func (r *IdempotencyRecord) IsComplete() bool {
+	return r.StatusCode != 0
+}
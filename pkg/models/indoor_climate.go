@@ -0,0 +1,275 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// IndoorClimateConfigKey is the station config key with the thresholds of
+// the indoor climate metrics
+const IndoorClimateConfigKey = "indoor_climate"
+
+// Default thresholds of the indoor climate
+const (
+	// DefaultIndoorCO2 is the CO2 level in ppm from which rooms should be
+	// ventilated
+	DefaultIndoorCO2 = 1000
+	// DefaultIndoorHumidity is the relative humidity in % from which rooms
+	// should be ventilated if the outdoor air is drier
+	DefaultIndoorHumidity = 60
+	// DefaultWallOffset is how much colder than the room air in °C walls
+	// are assumed for the mold risk, typical of outer walls and corners
+	DefaultWallOffset = 3
+)
+
+// ventilationMargin is how much drier in g/m³ the outdoor air must be for
+// ventilating to lower the indoor humidity
+const ventilationMargin = 1.0
+
+// Mold risk levels by the relative humidity at the wall, see MoldRiskIndex
+const (
+	MoldRiskLow      = "low"
+	MoldRiskElevated = "elevated"
+	MoldRiskHigh     = "high"
+)
+
+// Reasons to ventilate a room
+const (
+	VentilateHumidity = "humidity"
+	VentilateCO2      = "co2"
+)
+
+// IndoorClimatePolicy holds the thresholds of the indoor climate of a
+// station
+type IndoorClimatePolicy struct {
+	CO2        float64 `json:"co2"`
+	Humidity   float64 `json:"humidity"`
+	WallOffset float64 `json:"wall_offset"`
+}
+
+// ParseIndoorClimatePolicy reads the indoor climate thresholds from a
+// station config. Stations without settings get the default thresholds.
+func ParseIndoorClimatePolicy(config map[string]interface{}) (IndoorClimatePolicy, error) {
+	policy := IndoorClimatePolicy{CO2: DefaultIndoorCO2, Humidity: DefaultIndoorHumidity, WallOffset: DefaultWallOffset}
+	value, ok := config[IndoorClimateConfigKey]
+	if !ok || value == nil {
+		return policy, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return IndoorClimatePolicy{}, fmt.Errorf("invalid %s config: %w", IndoorClimateConfigKey, err)
+	}
+	if policy.CO2 <= 0 {
+		return IndoorClimatePolicy{}, fmt.Errorf("invalid %s config: co2 must be positive", IndoorClimateConfigKey)
+	}
+	if policy.Humidity <= 0 || policy.Humidity > 100 {
+		return IndoorClimatePolicy{}, fmt.Errorf("invalid %s config: humidity must be between 0 and 100", IndoorClimateConfigKey)
+	}
+	if policy.WallOffset < 0 || policy.WallOffset > 20 {
+		return IndoorClimatePolicy{}, fmt.Errorf("invalid %s config: wall_offset must be between 0 and 20", IndoorClimateConfigKey)
+	}
+	return policy, nil
+}
+
+// IndoorClimate holds the metrics derived from the temperature and
+// humidity of one indoor location
+type IndoorClimate struct {
+	Location    string  `json:"location"`
+	Temperature float64 `json:"temperature"`
+	Humidity    float64 `json:"humidity"`
+	DewPoint    float64 `json:"dew_point"`
+	Humidex     float64 `json:"humidex"`
+	// AbsoluteHumidity is the water content of the air in g/m³
+	AbsoluteHumidity float64 `json:"absolute_humidity"`
+	// MoldRisk is the relative humidity at the walls in %, see
+	// MoldRiskIndex
+	MoldRisk      float64  `json:"mold_risk"`
+	MoldRiskLevel string   `json:"mold_risk_level"`
+	CO2           *float64 `json:"co2,omitempty"`
+	// Ventilate recommends airing the room for the reasons listed
+	Ventilate          bool     `json:"ventilate"`
+	VentilationReasons []string `json:"ventilation_reasons,omitempty"`
+}
+
+// StationIndoorClimate holds the indoor climate of the locations of a
+// station
+type StationIndoorClimate struct {
+	// OutdoorAbsoluteHumidity is the water content of the outdoor air in
+	// g/m³, the indoor humidity is only lowered by ventilating if it is
+	// drier
+	OutdoorAbsoluteHumidity *float64        `json:"outdoor_absolute_humidity,omitempty"`
+	Rooms                   []IndoorClimate `json:"rooms"`
+}
+
+// ComputeIndoorClimate derives the indoor climate of every location with
+// an enabled temperature and humidity sensor with readings. Sensors at the
+// location "Outdoor" and the outdoor sensor types give the outdoor humidity
+// the rooms are compared to; CO2 sensors are matched by location. Stations
+// without indoor pairs get nil.
+func ComputeIndoorClimate(policy IndoorClimatePolicy, sensors []SensorWithLatestReading) *StationIndoorClimate {
+	type room struct {
+		temperature, humidity, co2 *float64
+	}
+	rooms := make(map[string]*room)
+	var outdoorTemperature, outdoorHumidity *float64
+	for _, s := range sensors {
+		if !s.Sensor.Enabled || s.LatestReading == nil {
+			continue
+		}
+		value := s.LatestReading.Value
+		outdoor := strings.EqualFold(s.Sensor.Location, "outdoor")
+		switch {
+		case s.Sensor.SensorType == SensorTypeTemperatureOutdoor,
+			s.Sensor.SensorType == SensorTypeTemperature && outdoor:
+			outdoorTemperature = &value
+			continue
+		case s.Sensor.SensorType == SensorTypeHumidityOutdoor,
+			s.Sensor.SensorType == SensorTypeHumidity && outdoor:
+			outdoorHumidity = &value
+			continue
+		case outdoor:
+			continue
+		}
+
+		r := rooms[s.Sensor.Location]
+		if r == nil {
+			r = &room{}
+		}
+		switch s.Sensor.SensorType {
+		case SensorTypeTemperature:
+			r.temperature = &value
+		case SensorTypeHumidity:
+			r.humidity = &value
+		case SensorTypeCO2:
+			r.co2 = &value
+		default:
+			continue
+		}
+		rooms[s.Sensor.Location] = r
+	}
+
+	climate := &StationIndoorClimate{}
+	if outdoorTemperature != nil && outdoorHumidity != nil {
+		ah := round1(AbsoluteHumidity(*outdoorTemperature, *outdoorHumidity))
+		climate.OutdoorAbsoluteHumidity = &ah
+	}
+	for location, r := range rooms {
+		if r.temperature == nil || r.humidity == nil {
+			continue
+		}
+		t, rh := *r.temperature, *r.humidity
+		moldRisk := MoldRiskIndex(t, rh, policy.WallOffset)
+		room := IndoorClimate{
+			Location:         location,
+			Temperature:      t,
+			Humidity:         rh,
+			DewPoint:         round1(DewPoint(t, rh)),
+			Humidex:          round1(Humidex(t, rh)),
+			AbsoluteHumidity: round1(AbsoluteHumidity(t, rh)),
+			MoldRisk:         round1(moldRisk),
+			MoldRiskLevel:    MoldRiskLevel(moldRisk),
+			CO2:              r.co2,
+		}
+		if rh >= policy.Humidity && climate.OutdoorAbsoluteHumidity != nil &&
+			*climate.OutdoorAbsoluteHumidity <= room.AbsoluteHumidity-ventilationMargin {
+			room.VentilationReasons = append(room.VentilationReasons, VentilateHumidity)
+		}
+		if r.co2 != nil && *r.co2 >= policy.CO2 {
+			room.VentilationReasons = append(room.VentilationReasons, VentilateCO2)
+		}
+		room.Ventilate = len(room.VentilationReasons) > 0
+		climate.Rooms = append(climate.Rooms, room)
+	}
+	if len(climate.Rooms) == 0 {
+		return nil
+	}
+	sort.Slice(climate.Rooms, func(i, j int) bool { return climate.Rooms[i].Location < climate.Rooms[j].Location })
+	return climate
+}
+
+// AlertValue returns the value of an indoor climate rule over the rooms at
+// its location, or all rooms without one: 1 if any should be ventilated for
+// AlertTypeVentilation, the highest mold risk for AlertTypeMoldRisk. It
+// reports false if no room matches.
+func (c *StationIndoorClimate) AlertValue(rule AlertRule) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	value, found := 0.0, false
+	for _, room := range c.Rooms {
+		if rule.Location != "" && room.Location != rule.Location {
+			continue
+		}
+		switch rule.SensorType {
+		case AlertTypeVentilation:
+			if room.Ventilate {
+				value = 1
+			}
+		case AlertTypeMoldRisk:
+			if !found || room.MoldRisk > value {
+				value = room.MoldRisk
+			}
+		default:
+			return 0, false
+		}
+		found = true
+	}
+	return value, found
+}
+
+// saturationVaporPressure returns the saturation vapor pressure over water
+// in hPa by the Magnus formula
+func saturationVaporPressure(tempC float64) float64 {
+	return 6.1094 * math.Exp(17.625*tempC/(tempC+243.04))
+}
+
+// DewPoint returns the dew point in °C by the Magnus formula
+func DewPoint(tempC, humidity float64) float64 {
+	const b, c = 17.625, 243.04
+	gamma := math.Log(math.Max(humidity, 1)/100) + b*tempC/(c+tempC)
+	return c * gamma / (b - gamma)
+}
+
+// Humidex returns the perceived temperature of the Canadian humidex in °C
+func Humidex(tempC, humidity float64) float64 {
+	dewPointK := DewPoint(tempC, humidity) + 273.15
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/dewPointK))
+	return tempC + 0.5555*(e-10)
+}
+
+// AbsoluteHumidity returns the water content of air in g/m³
+func AbsoluteHumidity(tempC, humidity float64) float64 {
+	vaporPressure := saturationVaporPressure(tempC) * humidity / 100
+	return 216.7 * vaporPressure / (tempC + 273.15)
+}
+
+// MoldRiskIndex returns the relative humidity in % of the air at a wall
+// wallOffset °C colder than the room. Mold grows from about 70 % at the
+// surface, condensation starts at 100 %.
+func MoldRiskIndex(tempC, humidity, wallOffset float64) float64 {
+	vaporPressure := saturationVaporPressure(tempC) * humidity / 100
+	return math.Min(100, 100*vaporPressure/saturationVaporPressure(tempC-wallOffset))
+}
+
+// MoldRiskLevel rates a mold risk index
+func MoldRiskLevel(index float64) string {
+	switch {
+	case index >= 80:
+		return MoldRiskHigh
+	case index >= 70:
+		return MoldRiskElevated
+	}
+	return MoldRiskLow
+}
+
+// round1 rounds to one decimal
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseIndoorClimatePolicy(t *testing.T) {
+	policy, err := ParseIndoorClimatePolicy(map[string]interface{}{})
+	if err != nil || policy.CO2 != DefaultIndoorCO2 || policy.Humidity != DefaultIndoorHumidity || policy.WallOffset != DefaultWallOffset {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+
+	policy, err = ParseIndoorClimatePolicy(map[string]interface{}{
+		IndoorClimateConfigKey: map[string]interface{}{"co2": 1400},
+	})
+	if err != nil || policy.CO2 != 1400 || policy.Humidity != DefaultIndoorHumidity {
+		t.Fatalf("got %+v, %v", policy, err)
+	}
+
+	invalid := []interface{}{
+		"1000",
+		map[string]interface{}{"co2": 0},
+		map[string]interface{}{"humidity": 120},
+		map[string]interface{}{"wall_offset": -1},
+	}
+	for _, value := range invalid {
+		if _, err := ParseIndoorClimatePolicy(map[string]interface{}{IndoorClimateConfigKey: value}); err == nil {
+			t.Errorf("expected error for %v", value)
+		}
+	}
+}
+
+func TestIndoorClimateFormulas(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"dew point", DewPoint(20, 50), 9.3},
+		{"humidex", Humidex(30, 70), 41.2},
+		{"absolute humidity", AbsoluteHumidity(20, 50), 8.6},
+		{"absolute humidity when freezing", AbsoluteHumidity(0, 80), 3.9},
+		{"mold risk", MoldRiskIndex(20, 60, 3), 72.4},
+		{"mold risk at condensation", MoldRiskIndex(20, 90, 5), 100},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 0.1 {
+			t.Errorf("%s = %.2f, want %.1f", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestComputeIndoorClimate(t *testing.T) {
+	reading := func(sensorType, location string, value float64) SensorWithLatestReading {
+		return SensorWithLatestReading{
+			Sensor:        Sensor{SensorType: sensorType, Location: location, Enabled: true},
+			LatestReading: &SensorReading{Value: value, DateUTC: time.Now()},
+		}
+	}
+	policy, _ := ParseIndoorClimatePolicy(nil)
+
+	sensors := []SensorWithLatestReading{
+		reading(SensorTypeTemperature, "Living room", 21),
+		reading(SensorTypeHumidity, "Living room", 45),
+		reading(SensorTypeCO2, "Living room", 1250),
+		reading(SensorTypeTemperature, "Bathroom", 22),
+		reading(SensorTypeHumidity, "Bathroom", 75),
+		reading(SensorTypeTemperature, "Hallway", 19),
+		reading(SensorTypeTemperatureOutdoor, "Outdoor", 5),
+		reading(SensorTypeHumidityOutdoor, "Outdoor", 80),
+	}
+	climate := ComputeIndoorClimate(policy, sensors)
+	if climate == nil || len(climate.Rooms) != 2 {
+		t.Fatalf("ComputeIndoorClimate() = %+v, want the bathroom and the living room", climate)
+	}
+	if climate.OutdoorAbsoluteHumidity == nil || *climate.OutdoorAbsoluteHumidity != 5.4 {
+		t.Errorf("OutdoorAbsoluteHumidity = %v, want 5.4", climate.OutdoorAbsoluteHumidity)
+	}
+
+	bathroom, living := climate.Rooms[0], climate.Rooms[1]
+	if bathroom.Location != "Bathroom" || !bathroom.Ventilate || !slices.Equal(bathroom.VentilationReasons, []string{VentilateHumidity}) {
+		t.Errorf("bathroom = %+v, want ventilation for the humidity", bathroom)
+	}
+	if bathroom.MoldRiskLevel != MoldRiskHigh {
+		t.Errorf("bathroom mold risk = %g %s, want high", bathroom.MoldRisk, bathroom.MoldRiskLevel)
+	}
+	if !living.Ventilate || !slices.Equal(living.VentilationReasons, []string{VentilateCO2}) || living.CO2 == nil {
+		t.Errorf("living room = %+v, want ventilation for the CO2", living)
+	}
+
+	// Humid outdoor air doesn't dry the bathroom
+	sensors[6], sensors[7] = reading(SensorTypeTemperatureOutdoor, "Outdoor", 25), reading(SensorTypeHumidityOutdoor, "Outdoor", 90)
+	if climate = ComputeIndoorClimate(policy, sensors); climate.Rooms[0].Ventilate {
+		t.Errorf("bathroom = %+v, want no ventilation with humid outdoor air", climate.Rooms[0])
+	}
+
+	if climate := ComputeIndoorClimate(policy, sensors[5:]); climate != nil {
+		t.Errorf("ComputeIndoorClimate() = %+v, want nil without indoor pairs", climate)
+	}
+}
+
+func TestStationIndoorClimate_AlertValue(t *testing.T) {
+	climate := &StationIndoorClimate{Rooms: []IndoorClimate{
+		{Location: "Bathroom", MoldRisk: 85, Ventilate: true},
+		{Location: "Bedroom", MoldRisk: 65},
+	}}
+	tests := []struct {
+		rule  AlertRule
+		want  float64
+		found bool
+	}{
+		{AlertRule{SensorType: AlertTypeVentilation}, 1, true},
+		{AlertRule{SensorType: AlertTypeVentilation, Location: "Bedroom"}, 0, true},
+		{AlertRule{SensorType: AlertTypeMoldRisk}, 85, true},
+		{AlertRule{SensorType: AlertTypeMoldRisk, Location: "Kitchen"}, 0, false},
+		{AlertRule{SensorType: SensorTypeTemperature}, 0, false},
+	}
+	for _, tt := range tests {
+		got, found := climate.AlertValue(tt.rule)
+		if got != tt.want || found != tt.found {
+			t.Errorf("AlertValue(%s %q) = %g, %v, want %g, %v", tt.rule.SensorType, tt.rule.Location, got, found, tt.want, tt.found)
+		}
+	}
+	if _, found := (*StationIndoorClimate)(nil).AlertValue(AlertRule{SensorType: AlertTypeVentilation}); found {
+		t.Error("AlertValue() of a station without indoor climate must not be found")
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// IrrigationConfigKey is the station config key of the irrigation advice
+// settings
+const IrrigationConfigKey = "irrigation"
+
+// Irrigation defaults
+const (
+	DefaultRainEfficiency = 0.8
+	DefaultIrrigationDays = 7
+	DefaultWindHeight     = 2
+	maxIrrigationDays     = 60
+)
+
+// IrrigationPolicy configures the irrigation advice of a station:
+//
+//	"irrigation": {"crop_coefficient": 0.8, "threshold": 5, "webhook_url": "http://controller.local/advice"}
+//
+// crop_coefficient is either one coefficient for the whole year or a list of
+// 12 monthly coefficients starting with January.
+type IrrigationPolicy struct {
+	// CropCoefficients are the monthly crop coefficients (Kc), January first
+	CropCoefficients [12]float64 `json:"crop_coefficients"`
+	// RainEfficiency is the part of the rain that reaches the roots
+	RainEfficiency float64 `json:"rain_efficiency"`
+	// Threshold is the water deficit in mm from which irrigation is advised
+	Threshold float64 `json:"threshold"`
+	// Days is the number of days the water balance is computed over
+	Days int `json:"days"`
+	// Elevation of the station in m, used without a pressure sensor
+	Elevation float64 `json:"elevation"`
+	// WindHeight is the height of the wind sensor in m
+	WindHeight float64 `json:"wind_height"`
+	// WebhookURL receives the daily advice as JSON
+	WebhookURL string `json:"-"`
+}
+
+// irrigationConfig is the stored form of an IrrigationPolicy
+type irrigationConfig struct {
+	CropCoefficient interface{} `json:"crop_coefficient"`
+	RainEfficiency  *float64    `json:"rain_efficiency"`
+	Threshold       float64     `json:"threshold"`
+	Days            int         `json:"days"`
+	Elevation       float64     `json:"elevation"`
+	WindHeight      *float64    `json:"wind_height"`
+	WebhookURL      string      `json:"webhook_url"`
+}
+
+// ParseIrrigationPolicy reads the irrigation settings of a station config.
+// ok is false when the station has no irrigation settings.
+func ParseIrrigationPolicy(config map[string]interface{}) (policy IrrigationPolicy, ok bool, err error) {
+	value, found := config[IrrigationConfigKey]
+	if !found || value == nil {
+		return policy, false, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, false, err
+	}
+	var stored irrigationConfig
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return policy, false, fmt.Errorf("invalid %s config: %w", IrrigationConfigKey, err)
+	}
+
+	switch kc := stored.CropCoefficient.(type) {
+	case nil:
+		for i := range policy.CropCoefficients {
+			policy.CropCoefficients[i] = 1
+		}
+	case float64:
+		for i := range policy.CropCoefficients {
+			policy.CropCoefficients[i] = kc
+		}
+	case []interface{}:
+		if len(kc) != len(policy.CropCoefficients) {
+			return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: crop_coefficient needs 12 monthly values", IrrigationConfigKey)
+		}
+		for i, v := range kc {
+			f, isFloat := v.(float64)
+			if !isFloat {
+				return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: crop_coefficient must contain numbers", IrrigationConfigKey)
+			}
+			policy.CropCoefficients[i] = f
+		}
+	default:
+		return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: crop_coefficient must be a number or a list of 12 numbers", IrrigationConfigKey)
+	}
+	for _, kc := range policy.CropCoefficients {
+		if kc < 0 || kc > 2 {
+			return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: crop coefficients must be between 0 and 2", IrrigationConfigKey)
+		}
+	}
+
+	policy.RainEfficiency = DefaultRainEfficiency
+	if stored.RainEfficiency != nil {
+		policy.RainEfficiency = *stored.RainEfficiency
+	}
+	if policy.RainEfficiency < 0 || policy.RainEfficiency > 1 {
+		return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: rain_efficiency must be between 0 and 1", IrrigationConfigKey)
+	}
+
+	policy.Threshold = stored.Threshold
+	if policy.Threshold < 0 {
+		return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: threshold must not be negative", IrrigationConfigKey)
+	}
+
+	policy.Days = stored.Days
+	if policy.Days == 0 {
+		policy.Days = DefaultIrrigationDays
+	}
+	if policy.Days < 1 || policy.Days > maxIrrigationDays {
+		return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: days must be between 1 and %d", IrrigationConfigKey, maxIrrigationDays)
+	}
+
+	policy.Elevation = stored.Elevation
+	policy.WindHeight = DefaultWindHeight
+	if stored.WindHeight != nil {
+		policy.WindHeight = *stored.WindHeight
+	}
+	if policy.WindHeight < 1 {
+		return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: wind_height must be at least 1 m", IrrigationConfigKey)
+	}
+
+	if stored.WebhookURL != "" {
+		u, err := url.Parse(stored.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return IrrigationPolicy{}, false, fmt.Errorf("invalid %s config: webhook_url must be an http or https URL", IrrigationConfigKey)
+		}
+		policy.WebhookURL = stored.WebhookURL
+	}
+	return policy, true, nil
+}
@@ -0,0 +1,58 @@
+package models
+
+import "testing"
+
+func TestParseIrrigationPolicy(t *testing.T) {
+	t.Run("Missing", func(t *testing.T) {
+		_, ok, err := ParseIrrigationPolicy(map[string]interface{}{})
+		if err != nil || ok {
+			t.Fatalf("ParseIrrigationPolicy() ok = %v, error = %v, want no settings", ok, err)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		policy, ok, err := ParseIrrigationPolicy(map[string]interface{}{IrrigationConfigKey: map[string]interface{}{}})
+		if err != nil || !ok {
+			t.Fatalf("ParseIrrigationPolicy() ok = %v, error = %v", ok, err)
+		}
+		if policy.CropCoefficients[6] != 1 || policy.RainEfficiency != DefaultRainEfficiency ||
+			policy.Days != DefaultIrrigationDays || policy.WindHeight != DefaultWindHeight {
+			t.Errorf("ParseIrrigationPolicy() = %+v, want defaults", policy)
+		}
+	})
+
+	t.Run("Monthly coefficients", func(t *testing.T) {
+		months := []interface{}{0.3, 0.3, 0.4, 0.6, 0.8, 1.0, 1.1, 1.0, 0.8, 0.5, 0.3, 0.3}
+		policy, _, err := ParseIrrigationPolicy(map[string]interface{}{IrrigationConfigKey: map[string]interface{}{
+			"crop_coefficient": months,
+			"webhook_url":      "http://controller.local/advice",
+		}})
+		if err != nil {
+			t.Fatalf("ParseIrrigationPolicy() error = %v", err)
+		}
+		if policy.CropCoefficients[0] != 0.3 || policy.CropCoefficients[6] != 1.1 {
+			t.Errorf("CropCoefficients = %v", policy.CropCoefficients)
+		}
+		if policy.WebhookURL != "http://controller.local/advice" {
+			t.Errorf("WebhookURL = %q", policy.WebhookURL)
+		}
+	})
+
+	invalid := map[string]map[string]interface{}{
+		"Too few months":        {"crop_coefficient": []interface{}{0.5, 0.5}},
+		"Coefficient too large": {"crop_coefficient": 3.0},
+		"Text coefficient":      {"crop_coefficient": "high"},
+		"Rain efficiency":       {"rain_efficiency": 1.5},
+		"Negative threshold":    {"threshold": -1.0},
+		"Too many days":         {"days": 90.0},
+		"Low wind sensor":       {"wind_height": 0.5},
+		"Webhook scheme":        {"webhook_url": "ftp://controller.local"},
+	}
+	for name, value := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseIrrigationPolicy(map[string]interface{}{IrrigationConfigKey: value}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job states
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job is a long-running background task executed by the job runner
+type Job struct {
+	ID              uuid.UUID       `json:"id"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	Params          json.RawMessage `json:"params"`
+	Done            int             `json:"done"`
+	Total           int             `json:"total"`
+	Error           string          `json:"error,omitempty"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedAt       time.Time       `json:"created_at"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
+
+// IsFinished reports whether the job reached a final state
+func (j *Job) IsFinished() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
+
+// JobQueryParams filters job listings
+type JobQueryParams struct {
+	Type   string
+	Status string
+	Limit  int
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ingest latency states of a station
+const (
+	IngestLatencyOK       = "ok"
+	IngestLatencyDegraded = "degraded"
+	IngestLatencyNoData   = "no_data"
+)
+
+// IngestLatency summarizes the time between the observation of readings and
+// their arrival at the server. Buffering gateways and unstable networks
+// raise it. Negative latencies stem from station clocks running ahead.
+type IngestLatency struct {
+	Readings uint64  `json:"readings"`
+	P50      float64 `json:"p50_seconds"`
+	P95      float64 `json:"p95_seconds"`
+	Max      float64 `json:"max_seconds"`
+}
+
+// IngestLatencyPoint is the latency of the readings received in the
+// interval starting at Time
+type IngestLatencyPoint struct {
+	Time time.Time `json:"time"`
+	IngestLatency
+}
+
+// StationIngestLatency is the latency of a station rated against a
+// threshold of the 95th percentile
+type StationIngestLatency struct {
+	StationID uuid.UUID `json:"station_id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	IngestLatency
+}
+
+// RateIngestLatency returns the status of a latency: no_data without
+// readings, degraded if the 95th percentile exceeds threshold
+func RateIngestLatency(latency IngestLatency, threshold time.Duration) string {
+	switch {
+	case latency.Readings == 0:
+		return IngestLatencyNoData
+	case latency.P95 > threshold.Seconds():
+		return IngestLatencyDegraded
+	}
+	return IngestLatencyOK
+}
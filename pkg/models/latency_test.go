@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateIngestLatency(t *testing.T) {
+	testCases := []struct {
+		latency IngestLatency
+		want    string
+	}{
+		{IngestLatency{}, IngestLatencyNoData},
+		{IngestLatency{Readings: 10, P50: 3, P95: 20}, IngestLatencyOK},
+		{IngestLatency{Readings: 10, P50: 3, P95: 900}, IngestLatencyDegraded},
+	}
+	for _, tc := range testCases {
+		if got := RateIngestLatency(tc.latency, 5*time.Minute); got != tc.want {
+			t.Errorf("RateIngestLatency(%+v) = %s, want %s", tc.latency, got, tc.want)
+		}
+	}
+}
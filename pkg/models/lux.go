@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// LuxConversionConfigKey is the station config key of the sensors that
+	// report illuminance in lux instead of solar radiation in W/m²
+	LuxConversionConfigKey = "lux_conversion"
+
+	// DefaultLuxFactor converts lux of direct sunlight to W/m²
+	// (about 126.7 lux per W/m²)
+	DefaultLuxFactor = 0.0079
+
+	// UnitLux is the raw unit of converted readings
+	UnitLux = "lux"
+)
+
+// LuxConversion maps sensor remote IDs or sensor IDs to the factor their
+// lux readings are multiplied with
+type LuxConversion map[string]float64
+
+// ParseLuxConversion reads the lux conversion of a station config. Each
+// sensor maps to a factor, or to true for DefaultLuxFactor:
+//
+//	"lux_conversion": {"solarradiation": 0.0079, "Solar1": true}
+func ParseLuxConversion(config map[string]interface{}) (LuxConversion, error) {
+	value, ok := config[LuxConversionConfigKey]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	entries, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s config: expected an object of sensors", LuxConversionConfigKey)
+	}
+
+	conversion := make(LuxConversion, len(entries))
+	for sensor, v := range entries {
+		var factor float64
+		switch f := v.(type) {
+		case bool:
+			if !f {
+				continue
+			}
+			factor = DefaultLuxFactor
+		case float64:
+			factor = f
+		case string:
+			parsed, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s config: invalid factor %q of sensor %s", LuxConversionConfigKey, f, sensor)
+			}
+			factor = parsed
+		default:
+			return nil, fmt.Errorf("invalid %s config: invalid factor %v of sensor %s", LuxConversionConfigKey, v, sensor)
+		}
+		if factor <= 0 {
+			return nil, fmt.Errorf("invalid %s config: factor of sensor %s must be positive", LuxConversionConfigKey, sensor)
+		}
+		conversion[sensor] = factor
+	}
+	return conversion, nil
+}
+
+// Factor returns the conversion factor of a sensor, looked up by remote ID
+// and then by sensor ID
+func (c LuxConversion) Factor(sensor Sensor) (float64, bool) {
+	if factor, ok := c[sensor.RemoteID]; ok && sensor.RemoteID != "" {
+		return factor, true
+	}
+	factor, ok := c[sensor.ID.String()]
+	return factor, ok
+}
+
+// ConvertLux converts a lux reading to W/m² and keeps the lux value as raw
+// value. Readings that were already converted are left unchanged.
+func ConvertLux(reading *SensorReading, factor float64) {
+	if reading.RawUnit != "" {
+		return
+	}
+	raw := reading.Value
+	reading.RawValue = &raw
+	reading.RawUnit = UnitLux
+	reading.Value = raw * factor
+	reading.Unit = SensorTypeRegistry[SensorTypeSolarRadiation].Unit
+}
@@ -0,0 +1,57 @@
+package models
+
+import "testing"
+
+func TestParseLuxConversion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   interface{}
+		want    LuxConversion
+		wantErr bool
+	}{
+		{name: "Missing", value: nil, want: nil},
+		{name: "Factor", value: map[string]interface{}{"solarradiation": 0.01}, want: LuxConversion{"solarradiation": 0.01}},
+		{name: "Default factor", value: map[string]interface{}{"solarradiation": true}, want: LuxConversion{"solarradiation": DefaultLuxFactor}},
+		{name: "Disabled", value: map[string]interface{}{"solarradiation": false}, want: LuxConversion{}},
+		{name: "String factor", value: map[string]interface{}{"solarradiation": "0.02"}, want: LuxConversion{"solarradiation": 0.02}},
+		{name: "Zero factor", value: map[string]interface{}{"solarradiation": 0.0}, wantErr: true},
+		{name: "Invalid factor", value: map[string]interface{}{"solarradiation": "fast"}, wantErr: true},
+		{name: "Not an object", value: "solarradiation", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := map[string]interface{}{}
+			if tc.value != nil {
+				config[LuxConversionConfigKey] = tc.value
+			}
+			got, err := ParseLuxConversion(config)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseLuxConversion() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("ParseLuxConversion() = %v, want %v", got, tc.want)
+			}
+			for sensor, factor := range tc.want {
+				if got[sensor] != factor {
+					t.Errorf("factor of %s = %v, want %v", sensor, got[sensor], factor)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertLux(t *testing.T) {
+	r := SensorReading{Value: 12670}
+	ConvertLux(&r, DefaultLuxFactor)
+	if r.RawUnit != UnitLux || *r.RawValue != 12670 || r.Unit != "W/m²" {
+		t.Fatalf("unexpected reading: %+v", r)
+	}
+
+	// Converting twice keeps the first conversion
+	value := r.Value
+	ConvertLux(&r, DefaultLuxFactor)
+	if r.Value != value || *r.RawValue != 12670 {
+		t.Errorf("reading converted twice: %+v", r)
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Annotation kinds
+const (
+	AnnotationKindNote        = "note"
+	AnnotationKindMaintenance = "maintenance"
+)
+
+// MaintenanceTask is a recurring maintenance of a station, e.g. cleaning
+// the rain gauge every 30 days
+type MaintenanceTask struct {
+	ID           uuid.UUID  `json:"id"`
+	StationID    uuid.UUID  `json:"station_id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description,omitempty"`
+	IntervalDays int        `json:"interval_days"`
+	LastDoneAt   *time.Time `json:"last_done_at,omitempty"`
+	NextDueAt    time.Time  `json:"next_due_at"`
+	// NotifiedAt is set when the reminder of the current due date was sent
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Validate checks the name and interval. A task without due date is due
+// one interval from now.
+func (t *MaintenanceTask) Validate() error {
+	if t.Name == "" || len(t.Name) > 100 {
+		return errors.New("name must be between 1 and 100 characters")
+	}
+	if t.IntervalDays < 1 || t.IntervalDays > 3650 {
+		return errors.New("interval_days must be between 1 and 3650")
+	}
+	if t.NextDueAt.IsZero() {
+		t.NextDueAt = time.Now().UTC().AddDate(0, 0, t.IntervalDays)
+	}
+	return nil
+}
+
+// Due reports whether the task is due at the given time
+func (t MaintenanceTask) Due(at time.Time) bool {
+	return !t.NextDueAt.After(at)
+}
+
+// MaintenanceCompletion records that a task was done
+type MaintenanceCompletion struct {
+	// DateUTC is when the task was done (zero = now)
+	DateUTC time.Time `json:"date_utc"`
+	Note    string    `json:"note,omitempty"`
+}
+
+// Annotation is a note on the data timeline of a station or one of its
+// sensors
+type Annotation struct {
+	ID        uuid.UUID  `json:"id"`
+	StationID uuid.UUID  `json:"station_id"`
+	SensorID  *uuid.UUID `json:"sensor_id,omitempty"`
+	// TaskID links maintenance annotations to their task
+	TaskID    *uuid.UUID `json:"task_id,omitempty"`
+	Kind      string     `json:"kind"`
+	Text      string     `json:"text"`
+	DateUTC   time.Time  `json:"date_utc"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Validate checks the text and defaults the kind and date of a note
+func (a *Annotation) Validate() error {
+	if a.Text == "" {
+		return errors.New("text is required")
+	}
+	if a.Kind == "" {
+		a.Kind = AnnotationKindNote
+	}
+	if a.Kind != AnnotationKindNote && a.Kind != AnnotationKindMaintenance {
+		return errors.New("kind must be note or maintenance")
+	}
+	if a.DateUTC.IsZero() {
+		a.DateUTC = time.Now().UTC()
+	}
+	return nil
+}
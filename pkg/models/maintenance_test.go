@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceTask_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		task    MaintenanceTask
+		wantErr bool
+	}{
+		{name: "Valid", task: MaintenanceTask{Name: "Clean rain gauge", IntervalDays: 30}},
+		{name: "Missing name", task: MaintenanceTask{IntervalDays: 30}, wantErr: true},
+		{name: "Zero interval", task: MaintenanceTask{Name: "Replace batteries"}, wantErr: true},
+		{name: "Interval too long", task: MaintenanceTask{Name: "Replace batteries", IntervalDays: 4000}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.task.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaintenanceTask_ValidateDefaultsDueDate(t *testing.T) {
+	task := MaintenanceTask{Name: "Clean rain gauge", IntervalDays: 30}
+	if err := task.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	expected := time.Now().AddDate(0, 0, 30)
+	if task.NextDueAt.Sub(expected).Abs() > time.Minute {
+		t.Errorf("NextDueAt = %s, want about %s", task.NextDueAt, expected)
+	}
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	task = MaintenanceTask{Name: "Clean rain gauge", IntervalDays: 30, NextDueAt: due}
+	if err := task.Validate(); err != nil || !task.NextDueAt.Equal(due) {
+		t.Errorf("Validate() changed explicit due date to %s, error = %v", task.NextDueAt, err)
+	}
+	if !task.Due(due) || task.Due(due.Add(-time.Second)) {
+		t.Error("unexpected Due() result")
+	}
+}
+
+func TestAnnotation_Validate(t *testing.T) {
+	note := Annotation{Text: "Moved the station to the roof"}
+	if err := note.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if note.Kind != AnnotationKindNote || note.DateUTC.IsZero() {
+		t.Errorf("unexpected defaults: %+v", note)
+	}
+
+	if err := (&Annotation{}).Validate(); err == nil {
+		t.Error("expected error for missing text")
+	}
+	if err := (&Annotation{Text: "x", Kind: "alert"}).Validate(); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
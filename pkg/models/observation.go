@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// observationRange bounds the values of a manually observed sensor type
+type observationRange struct {
+	min, max float64
+	// integer types are codes or counts without fractions
+	integer bool
+}
+
+// observationRanges lists the sensor types that can be observed manually.
+// Cloud cover is given in oktas (9 = sky obscured), present weather as WMO
+// code 4677 (ww).
+var observationRanges = map[string]observationRange{
+	SensorTypeVisibility:     {min: 0, max: 100000},
+	SensorTypeCloudCover:     {min: 0, max: 9, integer: true},
+	SensorTypeSnowfall:       {min: 0, max: 500},
+	SensorTypePresentWeather: {min: 0, max: 99, integer: true},
+}
+
+// ObservationSensorTypes returns the manually observable sensor types
+// sorted by name
+func ObservationSensorTypes() []string {
+	types := make([]string, 0, len(observationRanges))
+	for t := range observationRanges {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Observation is a value observed by a person, e.g. the snow depth
+// measured with a ruler. It is also stored as reading of the manual sensor
+// of its type.
+type Observation struct {
+	ID         uuid.UUID `json:"id"`
+	StationID  uuid.UUID `json:"station_id"`
+	SensorID   uuid.UUID `json:"sensor_id"`
+	SensorType string    `json:"sensor_type"`
+	Value      float64   `json:"value"`
+	ObservedAt time.Time `json:"observed_at"`
+	Observer   string    `json:"observer"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ObservationEntry is a set of values observed at the same time, as
+// entered on an observation form
+type ObservationEntry struct {
+	// ObservedAt is when the values were observed (zero = now)
+	ObservedAt time.Time          `json:"observed_at"`
+	Values     map[string]float64 `json:"values"`
+	Note       string             `json:"note,omitempty"`
+}
+
+// Observations validates the entry and returns one observation per value,
+// sorted by sensor type
+func (e ObservationEntry) Observations(stationID uuid.UUID, observer string) ([]Observation, error) {
+	if len(e.Values) == 0 {
+		return nil, errors.New("values are required")
+	}
+	observedAt := e.ObservedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now().UTC()
+	}
+
+	observations := make([]Observation, 0, len(e.Values))
+	for sensorType, value := range e.Values {
+		o := Observation{
+			StationID:  stationID,
+			SensorType: sensorType,
+			Value:      value,
+			ObservedAt: observedAt,
+			Observer:   observer,
+			Note:       e.Note,
+		}
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+		observations = append(observations, o)
+	}
+	sort.Slice(observations, func(i, j int) bool { return observations[i].SensorType < observations[j].SensorType })
+	return observations, nil
+}
+
+// Validate checks the sensor type, value, observer and time of an
+// observation
+func (o *Observation) Validate() error {
+	r, ok := observationRanges[o.SensorType]
+	if !ok {
+		return fmt.Errorf("%s cannot be observed manually", o.SensorType)
+	}
+	if math.IsNaN(o.Value) || o.Value < r.min || o.Value > r.max {
+		return fmt.Errorf("%s must be between %g and %g", o.SensorType, r.min, r.max)
+	}
+	if r.integer && o.Value != math.Trunc(o.Value) {
+		return fmt.Errorf("%s must be a whole number", o.SensorType)
+	}
+	if o.Observer == "" || len(o.Observer) > 100 {
+		return errors.New("observer must be between 1 and 100 characters")
+	}
+	if len(o.Note) > 1000 {
+		return errors.New("note must be at most 1000 characters")
+	}
+	// Allow for clock differences of the observer's device
+	if o.ObservedAt.After(time.Now().Add(5 * time.Minute)) {
+		return errors.New("observed_at must not be in the future")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestObservation_Validate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		observation Observation
+		wantErr     bool
+	}{
+		{name: "Snowfall", observation: Observation{SensorType: SensorTypeSnowfall, Value: 12.5, Observer: "anna"}},
+		{name: "Cloud cover", observation: Observation{SensorType: SensorTypeCloudCover, Value: 9, Observer: "anna"}},
+		{name: "Automatic type", observation: Observation{SensorType: SensorTypeTemperature, Value: 20, Observer: "anna"}, wantErr: true},
+		{name: "Negative visibility", observation: Observation{SensorType: SensorTypeVisibility, Value: -1, Observer: "anna"}, wantErr: true},
+		{name: "Fractional code", observation: Observation{SensorType: SensorTypePresentWeather, Value: 61.5, Observer: "anna"}, wantErr: true},
+		{name: "Code out of range", observation: Observation{SensorType: SensorTypePresentWeather, Value: 100, Observer: "anna"}, wantErr: true},
+		{name: "Missing observer", observation: Observation{SensorType: SensorTypeSnowfall, Value: 1}, wantErr: true},
+		{name: "Future", observation: Observation{SensorType: SensorTypeSnowfall, Value: 1, Observer: "anna", ObservedAt: time.Now().Add(time.Hour)}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.observation.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestObservationEntry_Observations(t *testing.T) {
+	stationID := uuid.New()
+	entry := ObservationEntry{
+		Values: map[string]float64{SensorTypeSnowfall: 5, SensorTypeCloudCover: 8},
+		Note:   "Snow showers since noon",
+	}
+
+	observations, err := entry.Observations(stationID, "anna")
+	if err != nil {
+		t.Fatalf("Observations() error = %v", err)
+	}
+	if len(observations) != 2 || observations[0].SensorType != SensorTypeCloudCover || observations[1].SensorType != SensorTypeSnowfall {
+		t.Fatalf("unexpected observations: %+v", observations)
+	}
+	for _, o := range observations {
+		if o.StationID != stationID || o.Observer != "anna" || o.Note != entry.Note || o.ObservedAt.IsZero() {
+			t.Errorf("unexpected observation: %+v", o)
+		}
+	}
+	if !observations[0].ObservedAt.Equal(observations[1].ObservedAt) {
+		t.Error("expected one observation time for the entry")
+	}
+
+	if _, err := (ObservationEntry{}).Observations(stationID, "anna"); err == nil {
+		t.Error("expected error for entry without values")
+	}
+	entry.Values[SensorTypeCloudCover] = 10
+	if _, err := entry.Observations(stationID, "anna"); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PrivacyConfigKey is the station config key with the privacy settings
+// applied to public responses
+const PrivacyConfigKey = "privacy"
+
+// PrivacyPolicy reduces the detail of station data shown to the public
+type PrivacyPolicy struct {
+	// Precision maps sensor types or categories to a rounding step,
+	// e.g. {"Temperature": 0.5} rounds temperatures to 0.5 °C
+	Precision map[string]float64 `json:"precision,omitempty"`
+	// HideIndoor suppresses all indoor sensors
+	HideIndoor bool `json:"hide_indoor,omitempty"`
+	// CoordinateDecimals limits the decimals of station coordinates
+	CoordinateDecimals *int `json:"coordinate_decimals,omitempty"`
+}
+
+// ParsePrivacyPolicy reads the privacy settings from a station config.
+// A station without settings gets an empty policy that changes nothing.
+func ParsePrivacyPolicy(config map[string]interface{}) (PrivacyPolicy, error) {
+	var policy PrivacyPolicy
+	value, ok := config[PrivacyConfigKey]
+	if !ok || value == nil {
+		return policy, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return PrivacyPolicy{}, fmt.Errorf("invalid privacy config: %w", err)
+	}
+
+	for key, step := range policy.Precision {
+		if step <= 0 {
+			return PrivacyPolicy{}, fmt.Errorf("invalid privacy config: precision of %s must be positive", key)
+		}
+	}
+	if d := policy.CoordinateDecimals; d != nil && (*d < 0 || *d > 6) {
+		return PrivacyPolicy{}, fmt.Errorf("invalid privacy config: coordinate_decimals must be between 0 and 6")
+	}
+	return policy, nil
+}
+
+// IsZero reports whether the policy leaves all data unchanged
+func (p PrivacyPolicy) IsZero() bool {
+	return len(p.Precision) == 0 && !p.HideIndoor && p.CoordinateDecimals == nil
+}
+
+// Hides reports whether a sensor is suppressed
+func (p PrivacyPolicy) Hides(sensor Sensor) bool {
+	return p.HideIndoor && strings.EqualFold(sensor.Location, "Indoor")
+}
+
+// Step returns the rounding step of a sensor type, looked up by type and
+// then by category; 0 means full precision
+func (p PrivacyPolicy) Step(sensorType string) float64 {
+	if step, ok := p.Precision[sensorType]; ok {
+		return step
+	}
+	if info, ok := SensorTypeRegistry[sensorType]; ok {
+		return p.Precision[info.Category]
+	}
+	return 0
+}
+
+// Round rounds a value of the sensor type to the configured step
+func (p PrivacyPolicy) Round(sensorType string, value float64) float64 {
+	return RoundToStep(value, p.Step(sensorType))
+}
+
+// RoundCoordinate rounds a latitude or longitude to the configured decimals
+func (p PrivacyPolicy) RoundCoordinate(value float64) float64 {
+	if p.CoordinateDecimals == nil {
+		return value
+	}
+	factor := math.Pow(10, float64(*p.CoordinateDecimals))
+	return math.Round(value*factor) / factor
+}
+
+// RoundToStep rounds value to the nearest multiple of step; a step of 0
+// returns the value unchanged
+func RoundToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	rounded := math.Round(value/step) * step
+	// Remove floating point noise like 0.30000000000000004
+	return math.Round(rounded*1e9) / 1e9
+}
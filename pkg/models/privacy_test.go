@@ -0,0 +1,91 @@
+package models
+
+import "testing"
+
+func TestParsePrivacyPolicy(t *testing.T) {
+	config := map[string]interface{}{
+		PrivacyConfigKey: map[string]interface{}{
+			"precision":           map[string]interface{}{"Temperature": 0.5, "PressureRelative": 1.0},
+			"hide_indoor":         true,
+			"coordinate_decimals": 2.0,
+		},
+	}
+
+	policy, err := ParsePrivacyPolicy(config)
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if policy.IsZero() || !policy.HideIndoor || *policy.CoordinateDecimals != 2 {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	empty, err := ParsePrivacyPolicy(map[string]interface{}{})
+	if err != nil || !empty.IsZero() {
+		t.Errorf("Expected empty policy, got %+v, %v", empty, err)
+	}
+}
+
+func TestParsePrivacyPolicy_Invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy interface{}
+	}{
+		{name: "Negative step", policy: map[string]interface{}{"precision": map[string]interface{}{"Temperature": -1.0}}},
+		{name: "Too many decimals", policy: map[string]interface{}{"coordinate_decimals": 8.0}},
+		{name: "Wrong type", policy: "yes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParsePrivacyPolicy(map[string]interface{}{PrivacyConfigKey: tc.policy}); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestPrivacyPolicy_Round(t *testing.T) {
+	decimals := 2
+	policy := PrivacyPolicy{
+		Precision:          map[string]float64{"Temperature": 0.5, "Humidity": 5, "PressureRelative": 0.1},
+		CoordinateDecimals: &decimals,
+	}
+
+	testCases := []struct {
+		sensorType string
+		value      float64
+		expected   float64
+	}{
+		{SensorTypeTemperature, 21.37, 21.5},
+		// Looked up by category
+		{SensorTypeTemperatureOutdoor, -3.2, -3},
+		{SensorTypeHumidityOutdoor, 63, 65},
+		{SensorTypePressureRelative, 1013.27, 1013.3},
+		// No precision configured
+		{SensorTypeWindSpeed, 3.14159, 3.14159},
+	}
+
+	for _, tc := range testCases {
+		if got := policy.Round(tc.sensorType, tc.value); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.sensorType, tc.expected, got)
+		}
+	}
+
+	if got := policy.RoundCoordinate(48.208176); got != 48.21 {
+		t.Errorf("Expected coordinate 48.21, got %v", got)
+	}
+}
+
+func TestPrivacyPolicy_Hides(t *testing.T) {
+	policy := PrivacyPolicy{HideIndoor: true}
+
+	if !policy.Hides(Sensor{Location: "Indoor"}) {
+		t.Error("Expected indoor sensor to be hidden")
+	}
+	if policy.Hides(Sensor{Location: "Outdoor"}) {
+		t.Error("Expected outdoor sensor to be shown")
+	}
+	if (PrivacyPolicy{}).Hides(Sensor{Location: "Indoor"}) {
+		t.Error("Expected indoor sensor to be shown without policy")
+	}
+}
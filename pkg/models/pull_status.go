@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Pull status values of a station
+const (
+	PullStatusOK                      = "ok"
+	PullStatusNoData                  = "no_data"
+	PullStatusReauthorizationRequired = "reauthorization_required"
+	PullStatusRateLimited             = "rate_limited"
+	PullStatusError                   = "error"
+)
+
+// PullStatus is the outcome of the last pull of a station
+type PullStatus struct {
+	Status string `json:"status"`
+	// Error is the message of the last failed pull
+	Error         string     `json:"error,omitempty"`
+	LastPullAt    time.Time  `json:"last_pull_at"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	// AuthorizationURL is where the user re-authorizes the station when
+	// Status is reauthorization_required
+	AuthorizationURL string `json:"authorization_url,omitempty"`
+}
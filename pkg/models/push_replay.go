@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// BackfillConfigKey is the station config key that accepts pushed readings
+// older than the maximum push age, e.g. while a station uploads its memory
+const BackfillConfigKey = "backfill"
+
+// StationBackfill reports whether a station accepts backdated pushes
+func StationBackfill(config map[string]interface{}) bool {
+	enabled, _ := config[BackfillConfigKey].(bool)
+	return enabled
+}
+
+// RequireNonceConfigKey is the station config key that only accepts signed
+// pushes carrying a nonce and a timestamp, so captured pushes can't be
+// replayed. It needs a webhook secret.
+const RequireNonceConfigKey = "require_nonce"
+
+// StationRequiresNonce reports whether a station only accepts signed pushes
+// with nonce and timestamp
+func StationRequiresNonce(config map[string]interface{}) bool {
+	enabled, _ := config[RequireNonceConfigKey].(bool)
+	return enabled
+}
+
+// ValidateRequireNonce checks that stations requiring a nonce have a
+// webhook secret to sign it with
+func ValidateRequireNonce(config map[string]interface{}) error {
+	if !StationRequiresNonce(config) {
+		return nil
+	}
+	if secret, _ := config[WebhookSecretConfigKey].(string); secret == "" {
+		return fmt.Errorf("%s needs a %s to sign pushes with", RequireNonceConfigKey, WebhookSecretConfigKey)
+	}
+	return nil
+}
+
+// PushAge returns how long before receivedAt the oldest of the readings was
+// taken. Readings from the future don't count as old.
+func PushAge(readings []SensorReading, receivedAt time.Time) time.Duration {
+	var age time.Duration
+	for _, r := range readings {
+		age = max(age, receivedAt.Sub(r.DateUTC))
+	}
+	return age
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStationBackfill(t *testing.T) {
+	if StationBackfill(nil) {
+		t.Error("Expected no backfill without config")
+	}
+	if StationBackfill(map[string]interface{}{BackfillConfigKey: "true"}) {
+		t.Error("Expected no backfill for a string")
+	}
+	if !StationBackfill(map[string]interface{}{BackfillConfigKey: true}) {
+		t.Error("Expected backfill")
+	}
+}
+
+func TestPushAge(t *testing.T) {
+	receivedAt := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	if age := PushAge(nil, receivedAt); age != 0 {
+		t.Errorf("Expected 0 without readings, got %s", age)
+	}
+
+	readings := []SensorReading{
+		{DateUTC: receivedAt.Add(-time.Minute)},
+		{DateUTC: receivedAt.Add(-2 * time.Hour)},
+		{DateUTC: receivedAt.Add(time.Minute)},
+	}
+	if age := PushAge(readings, receivedAt); age != 2*time.Hour {
+		t.Errorf("Expected 2h, got %s", age)
+	}
+
+	if age := PushAge(readings[2:], receivedAt); age != 0 {
+		t.Errorf("Expected 0 for readings from the future, got %s", age)
+	}
+}
+
+func TestValidateRequireNonce(t *testing.T) {
+	if err := ValidateRequireNonce(map[string]interface{}{RequireNonceConfigKey: false}); err != nil {
+		t.Errorf("Expected no error without require_nonce, got %v", err)
+	}
+	if err := ValidateRequireNonce(map[string]interface{}{RequireNonceConfigKey: true}); err == nil {
+		t.Error("Expected an error without webhook secret")
+	}
+	config := map[string]interface{}{RequireNonceConfigKey: true, WebhookSecretConfigKey: "secret"}
+	if err := ValidateRequireNonce(config); err != nil || !StationRequiresNonce(config) {
+		t.Errorf("Expected a valid config requiring a nonce, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "fmt"
+
+// RainGaugeConfigKey is the station config key of the rain gauge used for
+// the rain values of stations with both a tipping bucket and a piezo gauge
+const RainGaugeConfigKey = "rain_gauge"
+
+// Rain gauges of rain sensors
+const (
+	// RainGaugeTippingBucket counts the tips of a bucket, e.g. the WH40
+	RainGaugeTippingBucket = "tipping_bucket"
+	// RainGaugePiezo detects drops with a haptic sensor, e.g. the WS90.
+	// Piezo rain sensors carry it as their model.
+	RainGaugePiezo = "piezo"
+)
+
+// RainGauge returns the gauge of a rain sensor, or an empty string for
+// sensors of other categories. Rain sensors are tipping buckets unless their
+// model is RainGaugePiezo.
+func (s Sensor) RainGauge() string {
+	if SensorTypeRegistry[s.SensorType].Category != SensorCategoryRain {
+		return ""
+	}
+	if s.Model == RainGaugePiezo {
+		return RainGaugePiezo
+	}
+	return RainGaugeTippingBucket
+}
+
+// ParseRainGauge reads the preferred rain gauge of a station config. The
+// tipping bucket is preferred by default as it is the more accurate gauge.
+func ParseRainGauge(config map[string]interface{}) (string, error) {
+	value, ok := config[RainGaugeConfigKey]
+	if !ok || value == nil {
+		return RainGaugeTippingBucket, nil
+	}
+	gauge, _ := value.(string)
+	if gauge != RainGaugeTippingBucket && gauge != RainGaugePiezo {
+		return "", fmt.Errorf("invalid %s config: expected %s or %s", RainGaugeConfigKey, RainGaugeTippingBucket, RainGaugePiezo)
+	}
+	return gauge, nil
+}
+
+// PreferRainGauge drops the rain sensors of other gauges than gauge if the
+// sensors of a station have rain sensors of both gauges, so summaries and
+// reports use one gauge. Sensors of stations with one gauge are returned
+// as they are.
+func PreferRainGauge(sensors []SensorWithLatestReading, gauge string) []SensorWithLatestReading {
+	gauges := make(map[string]bool)
+	for _, s := range sensors {
+		if g := s.Sensor.RainGauge(); g != "" {
+			gauges[g] = true
+		}
+	}
+	if len(gauges) < 2 || !gauges[gauge] {
+		return sensors
+	}
+
+	preferred := make([]SensorWithLatestReading, 0, len(sensors))
+	for _, s := range sensors {
+		if g := s.Sensor.RainGauge(); g == "" || g == gauge {
+			preferred = append(preferred, s)
+		}
+	}
+	return preferred
+}
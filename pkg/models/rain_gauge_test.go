@@ -0,0 +1,68 @@
+package models
+
+import "testing"
+
+func TestSensor_RainGauge(t *testing.T) {
+	tests := []struct {
+		sensor Sensor
+		want   string
+	}{
+		{Sensor{SensorType: SensorTypeRainfallDaily}, RainGaugeTippingBucket},
+		{Sensor{SensorType: SensorTypeRainfallDaily, Model: RainGaugePiezo}, RainGaugePiezo},
+		{Sensor{SensorType: SensorTypeTemperature, Model: RainGaugePiezo}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.sensor.RainGauge(); got != tt.want {
+			t.Errorf("RainGauge() of %+v = %q, want %q", tt.sensor, got, tt.want)
+		}
+	}
+}
+
+func TestParseRainGauge(t *testing.T) {
+	if gauge, err := ParseRainGauge(nil); err != nil || gauge != RainGaugeTippingBucket {
+		t.Errorf("default = %q, %v", gauge, err)
+	}
+	if gauge, err := ParseRainGauge(map[string]interface{}{RainGaugeConfigKey: "piezo"}); err != nil || gauge != RainGaugePiezo {
+		t.Errorf("piezo = %q, %v", gauge, err)
+	}
+	if _, err := ParseRainGauge(map[string]interface{}{RainGaugeConfigKey: "haptic"}); err == nil {
+		t.Error("expected error for an unknown gauge")
+	}
+}
+
+func TestPreferRainGauge(t *testing.T) {
+	temp := SensorWithLatestReading{Sensor: Sensor{SensorType: SensorTypeTemperature, RemoteID: "tempf"}}
+	bucket := SensorWithLatestReading{Sensor: Sensor{SensorType: SensorTypeRainfallDaily, RemoteID: "dailyrainin"}}
+	piezo := SensorWithLatestReading{Sensor: Sensor{SensorType: SensorTypeRainfallDaily, RemoteID: "drain_piezo", Model: RainGaugePiezo}}
+
+	remoteIDs := func(sensors []SensorWithLatestReading) []string {
+		var ids []string
+		for _, s := range sensors {
+			ids = append(ids, s.Sensor.RemoteID)
+		}
+		return ids
+	}
+	tests := []struct {
+		name    string
+		sensors []SensorWithLatestReading
+		gauge   string
+		want    []string
+	}{
+		{"Both, tipping bucket preferred", []SensorWithLatestReading{temp, bucket, piezo}, RainGaugeTippingBucket, []string{"tempf", "dailyrainin"}},
+		{"Both, piezo preferred", []SensorWithLatestReading{temp, bucket, piezo}, RainGaugePiezo, []string{"tempf", "drain_piezo"}},
+		{"Piezo only", []SensorWithLatestReading{temp, piezo}, RainGaugeTippingBucket, []string{"tempf", "drain_piezo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := remoteIDs(PreferRainGauge(tt.sensors, tt.gauge))
+			if len(got) != len(tt.want) {
+				t.Fatalf("PreferRainGauge() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("PreferRainGauge() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RawPayload is a push request as received, kept so its readings can be
+// parsed again once a pusher bug is fixed. Credentials passed as parameters
+// are removed.
+type RawPayload struct {
+	ID        int64     `json:"id"`
+	StationID uuid.UUID `json:"station_id"`
+	// Source is the station type of the pusher
+	Source      string    `json:"source"`
+	ContentType string    `json:"content_type,omitempty"`
+	Query       string    `json:"query,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	SourceIP    string    `json:"source_ip,omitempty"`
+	ReceivedAt  time.Time `json:"received_at"`
+}
+
+// ReadingCorrection lists the stored readings replaced by re-parsed ones
+type ReadingCorrection struct {
+	// Delete are the IDs of the stored readings that are replaced
+	Delete []uuid.UUID
+	// Store are the re-parsed readings that differ from the stored ones
+	Store []SensorReading
+	// Changed counts the readings with a different value, Added the
+	// readings that were not stored before
+	Changed int
+	Added   int
+}
+
+// readingKey identifies the reading of a sensor at a time; ClickHouse keeps
+// milliseconds
+type readingKey struct {
+	sensorID uuid.UUID
+	date     int64
+}
+
+func keyOf(r SensorReading) readingKey {
+	return readingKey{sensorID: r.SensorID, date: r.DateUTC.UnixMilli()}
+}
+
+// CorrectReadings compares re-parsed readings with the stored readings of
+// the same sensors and times. Readings that match are left alone; later
+// re-parsed readings of the same sensor and time win.
+func CorrectReadings(stored, reparsed []SensorReading) ReadingCorrection {
+	existing := make(map[readingKey][]SensorReading, len(stored))
+	for _, r := range stored {
+		existing[keyOf(r)] = append(existing[keyOf(r)], r)
+	}
+
+	latest := make(map[readingKey]int, len(reparsed))
+	var order []readingKey
+	for i, r := range reparsed {
+		key := keyOf(r)
+		if _, ok := latest[key]; !ok {
+			order = append(order, key)
+		}
+		latest[key] = i
+	}
+
+	var c ReadingCorrection
+	for _, key := range order {
+		r := reparsed[latest[key]]
+		old := existing[key]
+		switch {
+		case len(old) == 0:
+			c.Added++
+		case len(old) == 1 && sameReading(old[0], r):
+			continue
+		default:
+			c.Changed++
+			for _, o := range old {
+				c.Delete = append(c.Delete, o.ID)
+			}
+		}
+		c.Store = append(c.Store, r)
+	}
+	return c
+}
+
+// sameReading reports whether two readings hold the same values
+func sameReading(a, b SensorReading) bool {
+	if a.Value != b.Value || a.Unit != b.Unit || a.RawUnit != b.RawUnit {
+		return false
+	}
+	if a.RawValue == nil || b.RawValue == nil {
+		return a.RawValue == nil && b.RawValue == nil
+	}
+	return *a.RawValue == *b.RawValue
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCorrectReadings(t *testing.T) {
+	temp, rain := uuid.New(), uuid.New()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	raw := 41.0
+
+	stored := []SensorReading{
+		{ID: uuid.New(), SensorID: temp, Value: 5, Unit: "°C", RawValue: &raw, RawUnit: "°F", DateUTC: at},
+		{ID: uuid.New(), SensorID: rain, Value: 25.4, Unit: "mm", DateUTC: at},
+		{ID: uuid.New(), SensorID: rain, Value: 25.4, Unit: "mm", DateUTC: at.Add(time.Minute)},
+		{ID: uuid.New(), SensorID: rain, Value: 25.4, Unit: "mm", DateUTC: at.Add(time.Minute)},
+	}
+	reparsed := []SensorReading{
+		// Unchanged
+		{SensorID: temp, Value: 5, Unit: "°C", RawValue: &raw, RawUnit: "°F", DateUTC: at},
+		// Wrong conversion fixed
+		{SensorID: rain, Value: 1, Unit: "mm", DateUTC: at},
+		// Duplicates are replaced even if one matches
+		{SensorID: rain, Value: 25.4, Unit: "mm", DateUTC: at.Add(time.Minute)},
+		// Not stored before, the later one wins
+		{SensorID: temp, Value: 6, Unit: "°C", DateUTC: at.Add(time.Minute)},
+		{SensorID: temp, Value: 7, Unit: "°C", DateUTC: at.Add(time.Minute)},
+	}
+
+	c := CorrectReadings(stored, reparsed)
+
+	if c.Changed != 2 || c.Added != 1 {
+		t.Errorf("Expected 2 changed and 1 added, got %d and %d", c.Changed, c.Added)
+	}
+	if len(c.Delete) != 3 || c.Delete[0] != stored[1].ID {
+		t.Errorf("Expected the rain readings to be deleted, got %v", c.Delete)
+	}
+	if len(c.Store) != 3 {
+		t.Fatalf("Expected 3 readings to store, got %v", c.Store)
+	}
+	if c.Store[0].Value != 1 || c.Store[2].Value != 7 {
+		t.Errorf("Expected the fixed and the latest readings, got %v", c.Store)
+	}
+}
+
+func TestCorrectReadingsMilliseconds(t *testing.T) {
+	sensor := uuid.New()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stored := []SensorReading{{ID: uuid.New(), SensorID: sensor, Value: 1, DateUTC: at}}
+
+	// Stored times have millisecond precision
+	c := CorrectReadings(stored, []SensorReading{{SensorID: sensor, Value: 1, DateUTC: at.Add(time.Microsecond)}})
+	if len(c.Store) != 0 {
+		t.Errorf("Expected no correction, got %v", c.Store)
+	}
+}
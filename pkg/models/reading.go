@@ -1,105 +1,181 @@
 package models
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// SensorReading represents a single measurement from a sensor
+// SensorReading represents a single measurement from a sensor.
+// Value is always stored in the metric unit of the sensor type. When the
+// station reported a different unit, the original value and unit are kept
+// in RawValue and RawUnit so conversions can be audited and redone.
+// ReceivedAt is the time the server received the reading, if known.
+// Estimated marks readings filled in from a buddy station while the station
+// was offline, see BuddyPolicy.
 type SensorReading struct {
-	ID       uuid.UUID `json:"id"`
-	SensorID uuid.UUID `json:"sensor_id"`
-	Value    float64   `json:"value"`
-	DateUTC  time.Time `json:"date_utc"`
+	ID         uuid.UUID  `json:"id"`
+	SensorID   uuid.UUID  `json:"sensor_id"`
+	Value      float64    `json:"value"`
+	Unit       string     `json:"unit,omitempty"`
+	RawValue   *float64   `json:"raw_value,omitempty"`
+	RawUnit    string     `json:"raw_unit,omitempty"`
+	DateUTC    time.Time  `json:"date_utc"`
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+	Estimated  bool       `json:"estimated,omitempty"`
 }
 
+// Valid values of the reading query params
+var (
+	ValidAggregateFuncs = []string{"avg", "min", "max", "sum", "count", "first", "last"}
+	ValidGroupBy        = []string{"sensor", "sensor_type", "location"}
+)
+
 // ReadingQueryParams holds all query parameters for reading queries
 type ReadingQueryParams struct {
-	StationID     *uuid.UUID
-	SensorIDs     []uuid.UUID
-	SensorType    string
-	Location      string
-	StartTime     string
-	EndTime       string
-	Limit         int
-	Page          int
-	Order         string
+	StationID  *uuid.UUID
+	SensorIDs  []uuid.UUID
+	SensorType string
+	Location   string
+	StartTime  string
+	EndTime    string
+	Limit      int
+	Page       int
+	Order      string
+	// Aggregate is the bucket length, see ParseAggregateInterval
 	Aggregate     string
 	AggregateFunc string
-	Latest        bool
-	GroupBy       string
+	// Timezone is the IANA time zone calendar intervals (days, weeks and
+	// months) are aligned to, UTC if empty
+	Timezone string
+	Latest   bool
+	GroupBy  string
+	// Pivot returns one row per timestamp with a column per series; Limit
+	// and Page then count timestamps instead of readings
+	Pivot bool
+	// Points downsamples each series to at most this many points; the
+	// whole time range is read instead of a page
+	Points int
 }
 
-// Validate checks if the query parameters are valid
+// Validate checks if the query parameters are valid. The error is a
+// ValidationErrors listing every invalid parameter by its query name.
 func (p *ReadingQueryParams) Validate() error {
+	var errs ValidationErrors
+
 	// Validate aggregate interval
+	var interval AggregateInterval
 	if p.Aggregate != "" {
-		validIntervals := []string{"1m", "5m", "15m", "30m", "1h", "6h", "12h", "1d", "1w", "1M"}
-		valid := false
-		for _, interval := range validIntervals {
-			if p.Aggregate == interval {
-				valid = true
-				break
-			}
+		var err error
+		if interval, err = ParseAggregateInterval(p.Aggregate); err != nil {
+			errs.Add("aggregate", "%s", err)
 		}
-		if !valid {
-			return fmt.Errorf("invalid aggregate interval: %s (valid: %s)", p.Aggregate, strings.Join(validIntervals, ", "))
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+			errs.Add("tz", "invalid time zone: %s", p.Timezone)
 		}
 	}
 
 	// Validate aggregate function
-	if p.AggregateFunc != "" {
-		validFuncs := []string{"avg", "min", "max", "sum", "count", "first", "last"}
-		valid := false
-		for _, fn := range validFuncs {
-			if p.AggregateFunc == fn {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("invalid aggregate function: %s (valid: %s)", p.AggregateFunc, strings.Join(validFuncs, ", "))
-		}
+	if p.AggregateFunc != "" && !slices.Contains(ValidAggregateFuncs, p.AggregateFunc) {
+		errs.Add("aggregate_func", "invalid aggregate function: %s (valid: %s)", p.AggregateFunc, strings.Join(ValidAggregateFuncs, ", "))
 	}
 
 	// Validate group_by
-	if p.GroupBy != "" {
-		validGroupBy := []string{"sensor", "sensor_type", "location"}
-		valid := false
-		for _, gb := range validGroupBy {
-			if p.GroupBy == gb {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return fmt.Errorf("invalid group_by: %s (valid: %s)", p.GroupBy, strings.Join(validGroupBy, ", "))
-		}
+	if p.GroupBy != "" && !slices.Contains(ValidGroupBy, p.GroupBy) {
+		errs.Add("group_by", "invalid group_by: %s (valid: %s)", p.GroupBy, strings.Join(ValidGroupBy, ", "))
 	}
 
 	// Validate that aggregate and latest are not used together
 	if p.Aggregate != "" && p.Latest {
-		return fmt.Errorf("cannot use 'aggregate' and 'latest' parameters together")
+		errs.Add("latest", "cannot use 'aggregate' and 'latest' parameters together")
+	}
+
+	// Validate time range
+	var start, end time.Time
+	var err error
+	if p.StartTime != "" {
+		if start, err = time.Parse(time.RFC3339, p.StartTime); err != nil {
+			errs.Add("start", "invalid start time, expected RFC3339")
+		}
+	}
+	if p.EndTime != "" {
+		if end, err = time.Parse(time.RFC3339, p.EndTime); err != nil {
+			errs.Add("end", "invalid end time, expected RFC3339")
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		errs.Add("start", "start must not be after end")
+	}
+	if interval.Count > 0 && !start.IsZero() {
+		if end.IsZero() {
+			end = time.Now()
+		}
+		if buckets := end.Sub(start) / interval.Nominal(); buckets > MaxAggregateBuckets {
+			errs.Add("aggregate", "aggregate interval %s yields %d buckets per series, at most %d are allowed; use a longer interval or a shorter time range", p.Aggregate, buckets, MaxAggregateBuckets)
+		}
+	}
+
+	if p.Points != 0 {
+		if p.Points < 3 || p.Points > 10000 {
+			errs.Add("points", "points must be between 3 and 10000")
+		}
+		if p.StartTime == "" {
+			errs.Add("points", "'points' requires 'start'")
+		}
+		if p.Pivot {
+			errs.Add("points", "cannot use 'points' and 'pivot' parameters together")
+		}
 	}
 
 	// Validate limit
 	if p.Limit < 1 || p.Limit > 10000 {
-		return fmt.Errorf("limit must be between 1 and 10000")
+		errs.Add("limit", "limit must be between 1 and 10000")
 	}
 
 	// Validate page
 	if p.Page < 1 {
-		return fmt.Errorf("page must be greater than 0")
+		errs.Add("offset", "page must be greater than 0")
 	}
 
 	if p.Order != "asc" && p.Order != "desc" {
-		return fmt.Errorf("invalid order: %s (valid: asc, desc)", p.Order)
+		errs.Add("order", "invalid order: %s (valid: asc, desc)", p.Order)
+	}
+
+	return errs.Err()
+}
+
+// ValidateStream checks the params of a streamed aggregation, which reads
+// the whole time range instead of a page: aggregate and start are required
+// and pivot and points are not supported. Like Validate, the error lists
+// every invalid parameter.
+func (p *ReadingQueryParams) ValidateStream() error {
+	var errs ValidationErrors
+	if err := p.Validate(); err != nil {
+		if !errors.As(err, &errs) {
+			return err
+		}
 	}
 
-	return nil
+	if p.Aggregate == "" && !errs.Has("aggregate") {
+		errs.Add("aggregate", "'aggregate' is required")
+	}
+	if p.StartTime == "" && !errs.Has("start") {
+		errs.Add("start", "'start' is required")
+	}
+	if p.Pivot {
+		errs.Add("pivot", "'pivot' is not supported by streams")
+	}
+	if p.Points != 0 && !errs.Has("points") {
+		errs.Add("points", "'points' is not supported by streams")
+	}
+	return errs.Err()
 }
 
 type AggregatedReading struct {
@@ -111,6 +187,92 @@ type AggregatedReading struct {
 	Count      int       `json:"count,omitempty"`
 	MinValue   float64   `json:"min_value,omitempty"`
 	MaxValue   float64   `json:"max_value,omitempty"`
+	// EstimatedCount is the number of the readings that were estimated,
+	// see SensorReading.Estimated
+	EstimatedCount int `json:"estimated_count,omitempty"`
+}
+
+// Series returns the column of the reading in pivoted output: the sensor,
+// or the sensor type or location it was grouped by
+func (r AggregatedReading) Series() string {
+	switch {
+	case r.SensorID != uuid.Nil:
+		return r.SensorID.String()
+	case r.SensorType != "":
+		return r.SensorType
+	default:
+		return r.Location
+	}
+}
+
+// PivotRow holds the values of all series at one timestamp. It is encoded
+// as a flat object with dateutc and one key per series; series without a
+// value at this timestamp are null.
+type PivotRow struct {
+	DateUTC time.Time
+	Values  map[string]*float64
+}
+
+func (r PivotRow) MarshalJSON() ([]byte, error) {
+	row := make(map[string]interface{}, len(r.Values)+1)
+	for series, value := range r.Values {
+		row[series] = value
+	}
+	row["dateutc"] = r.DateUTC
+	return json.Marshal(row)
+}
+
+// PivotReadings converts readings or aggregated readings into one row per
+// timestamp, keeping the order in which the timestamps appear. It returns
+// the sorted series names and the rows, each with a value or null for every
+// series.
+func PivotReadings(data interface{}) ([]string, []PivotRow) {
+	type point struct {
+		date   time.Time
+		series string
+		value  float64
+	}
+	var points []point
+	switch readings := data.(type) {
+	case []SensorReading:
+		for _, r := range readings {
+			points = append(points, point{r.DateUTC, r.SensorID.String(), r.Value})
+		}
+	case []AggregatedReading:
+		for _, r := range readings {
+			points = append(points, point{r.DateUTC, r.Series(), r.Value})
+		}
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	index := make(map[time.Time]int)
+	rows := []PivotRow{}
+	for _, p := range points {
+		if !seen[p.series] {
+			seen[p.series] = true
+			columns = append(columns, p.series)
+		}
+		date := p.date.UTC()
+		i, ok := index[date]
+		if !ok {
+			i = len(rows)
+			index[date] = i
+			rows = append(rows, PivotRow{DateUTC: date, Values: make(map[string]*float64)})
+		}
+		value := p.value
+		rows[i].Values[p.series] = &value
+	}
+	sort.Strings(columns)
+
+	for _, row := range rows {
+		for _, series := range columns {
+			if _, ok := row.Values[series]; !ok {
+				row.Values[series] = nil
+			}
+		}
+	}
+	return columns, rows
 }
 
 type ReadingsResponse struct {
@@ -122,3 +284,19 @@ type ReadingsResponse struct {
 	HasMore      bool        `json:"has_more"`
 	IsAggregated bool        `json:"is_aggregated"`
 }
+
+// DailyStat summarises the readings of a sensor on one calendar day
+type DailyStat struct {
+	SensorID uuid.UUID `json:"sensor_id"`
+	Day      time.Time `json:"day"`
+	Min      float64   `json:"min"`
+	MinTime  time.Time `json:"min_time"`
+	Max      float64   `json:"max"`
+	MaxTime  time.Time `json:"max_time"`
+	Avg      float64   `json:"avg"`
+	Sum      float64   `json:"sum"`
+	Count    int       `json:"count"`
+	// CircularMean is the mean of the values as angles in degrees (0-360);
+	// it is only meaningful for directions
+	CircularMean float64 `json:"circular_mean"`
+}
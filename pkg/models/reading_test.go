@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -35,6 +37,42 @@ func TestReadingQueryParams_Validate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Valid arbitrary and calendar intervals",
+			params: ReadingQueryParams{
+				Limit:     100,
+				Page:      1,
+				Order:     "asc",
+				Aggregate: "1mo",
+				Timezone:  "Europe/Vienna",
+			},
+			expectError: false,
+		},
+		{
+			name: "Invalid time zone",
+			params: ReadingQueryParams{
+				Limit:     100,
+				Page:      1,
+				Order:     "asc",
+				Aggregate: "1d",
+				Timezone:  "Mars/Olympus",
+			},
+			expectError: true,
+			errorMsg:    "invalid time zone",
+		},
+		{
+			name: "Too many buckets",
+			params: ReadingQueryParams{
+				Limit:     100,
+				Page:      1,
+				Order:     "asc",
+				Aggregate: "1m",
+				StartTime: "2020-01-01T00:00:00Z",
+				EndTime:   "2026-01-01T00:00:00Z",
+			},
+			expectError: true,
+			errorMsg:    "buckets per series",
+		},
 		{
 			name: "Invalid limit - too low",
 			params: ReadingQueryParams{
@@ -150,6 +188,40 @@ func TestReadingQueryParams_Validate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Valid points",
+			params: ReadingQueryParams{
+				Limit:     100,
+				Page:      1,
+				Order:     "desc",
+				StartTime: "2026-02-01T00:00:00Z",
+				Points:    1000,
+			},
+			expectError: false,
+		},
+		{
+			name: "Points without start",
+			params: ReadingQueryParams{
+				Limit:  100,
+				Page:   1,
+				Order:  "desc",
+				Points: 1000,
+			},
+			expectError: true,
+			errorMsg:    "requires 'start'",
+		},
+		{
+			name: "Points too small",
+			params: ReadingQueryParams{
+				Limit:     100,
+				Page:      1,
+				Order:     "desc",
+				StartTime: "2026-02-01T00:00:00Z",
+				Points:    2,
+			},
+			expectError: true,
+			errorMsg:    "points must be between",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -239,3 +311,110 @@ func TestReadingQueryParams_TimeRangeValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestReadingQueryParams_ValidateListsAllFields(t *testing.T) {
+	params := ReadingQueryParams{
+		StartTime: "yesterday",
+		Aggregate: "2x",
+		Limit:     0,
+		Page:      1,
+		Order:     "up",
+	}
+
+	var errs ValidationErrors
+	if !errors.As(params.Validate(), &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", params.Validate())
+	}
+	for _, field := range []string{"start", "aggregate", "limit", "order"} {
+		if !errs.Has(field) {
+			t.Errorf("Expected an error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 4 {
+		t.Errorf("Expected 4 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestReadingQueryParams_ValidateStream(t *testing.T) {
+	valid := ReadingQueryParams{
+		Limit:     100,
+		Page:      1,
+		Order:     "asc",
+		Aggregate: "1h",
+		StartTime: "2025-01-01T00:00:00Z",
+		EndTime:   "2026-01-01T00:00:00Z",
+	}
+	if err := valid.ValidateStream(); err != nil {
+		t.Errorf("Expected a year of hourly buckets to be valid, got %v", err)
+	}
+
+	params := ReadingQueryParams{Limit: 100, Page: 1, Order: "up", Pivot: true}
+	var errs ValidationErrors
+	if !errors.As(params.ValidateStream(), &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", params.ValidateStream())
+	}
+	for _, field := range []string{"aggregate", "start", "pivot", "order"} {
+		if !errs.Has(field) {
+			t.Errorf("Expected an error for %s, got %v", field, errs)
+		}
+	}
+
+	points := valid
+	points.Points = 500
+	if err := points.ValidateStream(); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("Expected points to be rejected, got %v", err)
+	}
+}
+
+func TestPivotReadings(t *testing.T) {
+	temp := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	hum := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	t1 := time.Date(2026, 2, 9, 16, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Minute)
+
+	columns, rows := PivotReadings([]SensorReading{
+		{SensorID: temp, Value: 21.5, DateUTC: t2},
+		{SensorID: hum, Value: 40, DateUTC: t2},
+		{SensorID: temp, Value: 21.0, DateUTC: t1},
+	})
+
+	if len(columns) != 2 || columns[0] != temp.String() || columns[1] != hum.String() {
+		t.Fatalf("columns = %v", columns)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if !rows[0].DateUTC.Equal(t2) || !rows[1].DateUTC.Equal(t1) {
+		t.Errorf("rows not in input order: %v, %v", rows[0].DateUTC, rows[1].DateUTC)
+	}
+	if v := rows[0].Values[hum.String()]; v == nil || *v != 40 {
+		t.Errorf("humidity at t2 = %v", v)
+	}
+	if v, ok := rows[1].Values[hum.String()]; !ok || v != nil {
+		t.Errorf("expected null humidity at t1, got %v", v)
+	}
+
+	encoded, err := json.Marshal(rows[1])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"00000000-0000-0000-0000-000000000001":21,"00000000-0000-0000-0000-000000000002":null,"dateutc":"2026-02-09T16:00:00Z"}`
+	if string(encoded) != want {
+		t.Errorf("Marshal() = %s, want %s", encoded, want)
+	}
+}
+
+func TestPivotReadings_Grouped(t *testing.T) {
+	t1 := time.Date(2026, 2, 9, 16, 0, 0, 0, time.UTC)
+	columns, rows := PivotReadings([]AggregatedReading{
+		{SensorType: "Temperature", Value: 21, DateUTC: t1},
+		{SensorType: "Humidity", Value: 40, DateUTC: t1},
+	})
+
+	if len(columns) != 2 || columns[0] != "Humidity" || columns[1] != "Temperature" {
+		t.Fatalf("columns = %v", columns)
+	}
+	if len(rows) != 1 || *rows[0].Values["Temperature"] != 21 {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record types of SensorRecord
+const (
+	RecordTypeMin = "min"
+	RecordTypeMax = "max"
+)
+
+// SensorRecord is an all-time extreme value of a sensor
+type SensorRecord struct {
+	SensorID   uuid.UUID `json:"sensor_id"`
+	SensorType string    `json:"sensor_type"`
+	Location   string    `json:"location"`
+	RecordType string    `json:"record_type"`
+	Value      float64   `json:"value"`
+	DateUTC    time.Time `json:"date_utc"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// LatitudeConfigKey and LongitudeConfigKey are the station config keys
+	// of the station coordinates in decimal degrees
+	LatitudeConfigKey  = "latitude"
+	LongitudeConfigKey = "longitude"
+
+	// ReferenceStationConfigKey is the station config key of the official
+	// station a station is compared with
+	ReferenceStationConfigKey = "reference_station"
+)
+
+// StationCoordinates reads the coordinates of a station config. ok is false
+// when the station has no coordinates.
+func StationCoordinates(config map[string]interface{}) (lat, lon float64, ok bool, err error) {
+	_, hasLat := config[LatitudeConfigKey]
+	_, hasLon := config[LongitudeConfigKey]
+	if !hasLat && !hasLon {
+		return 0, 0, false, nil
+	}
+	if !hasLat || !hasLon {
+		return 0, 0, false, fmt.Errorf("invalid coordinates: %s and %s must both be set", LatitudeConfigKey, LongitudeConfigKey)
+	}
+
+	lat, err = configFloat(config, LatitudeConfigKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	lon, err = configFloat(config, LongitudeConfigKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if lat < -90 || lat > 90 {
+		return 0, 0, false, fmt.Errorf("invalid %s config: must be between -90 and 90", LatitudeConfigKey)
+	}
+	if lon < -180 || lon > 180 {
+		return 0, 0, false, fmt.Errorf("invalid %s config: must be between -180 and 180", LongitudeConfigKey)
+	}
+	return lat, lon, true, nil
+}
+
+// ReferenceStationID reads the reference station of a station config. ok
+// is false when no reference station is set.
+func ReferenceStationID(config map[string]interface{}) (id uuid.UUID, ok bool, err error) {
+	value, set := config[ReferenceStationConfigKey]
+	if !set || value == nil {
+		return uuid.Nil, false, nil
+	}
+	s, isString := value.(string)
+	if !isString {
+		return uuid.Nil, false, fmt.Errorf("invalid %s config: expected a station ID", ReferenceStationConfigKey)
+	}
+	id, err = uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("invalid %s config: %q is not a station ID", ReferenceStationConfigKey, s)
+	}
+	return id, true, nil
+}
+
+// configFloat reads a number of a station config, accepting numeric strings
+func configFloat(config map[string]interface{}, key string) (float64, error) {
+	switch v := config[key].(type) {
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s config: %q is not a number", key, v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("invalid %s config: expected a number", key)
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestStationCoordinates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   map[string]interface{}
+		lat, lon float64
+		ok       bool
+		wantErr  bool
+	}{
+		{name: "Missing", config: map[string]interface{}{}},
+		{name: "Numbers", config: map[string]interface{}{"latitude": 48.2, "longitude": 16.37}, lat: 48.2, lon: 16.37, ok: true},
+		{name: "Strings", config: map[string]interface{}{"latitude": "-33.9", "longitude": "151.2"}, lat: -33.9, lon: 151.2, ok: true},
+		{name: "Only latitude", config: map[string]interface{}{"latitude": 48.2}, wantErr: true},
+		{name: "Out of range", config: map[string]interface{}{"latitude": 91.0, "longitude": 0.0}, wantErr: true},
+		{name: "Not a number", config: map[string]interface{}{"latitude": "north", "longitude": 0.0}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lat, lon, ok, err := StationCoordinates(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("StationCoordinates() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if ok != tc.ok || lat != tc.lat || lon != tc.lon {
+				t.Errorf("StationCoordinates() = %v, %v, %v, want %v, %v, %v", lat, lon, ok, tc.lat, tc.lon, tc.ok)
+			}
+		})
+	}
+}
+
+func TestReferenceStationID(t *testing.T) {
+	id := uuid.New()
+	got, ok, err := ReferenceStationID(map[string]interface{}{ReferenceStationConfigKey: id.String()})
+	if err != nil || !ok || got != id {
+		t.Errorf("ReferenceStationID() = %v, %v, %v", got, ok, err)
+	}
+
+	if _, ok, err := ReferenceStationID(map[string]interface{}{}); ok || err != nil {
+		t.Errorf("ReferenceStationID() of empty config = %v, %v", ok, err)
+	}
+	if _, _, err := ReferenceStationID(map[string]interface{}{ReferenceStationConfigKey: "LOWW"}); err == nil {
+		t.Error("expected error for invalid station ID")
+	}
+}
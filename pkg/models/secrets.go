@@ -0,0 +1,99 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretConfigKeys are the station config keys holding credentials. Their
+// values are stored encrypted when a secrets key is configured.
+var SecretConfigKeys = []string{
+	"access_token",
+	"api_key",
+	"app_key",
+	"awekas_password",
+	"client_secret",
+	"imap_password",
+	"opensensemap_token",
+	PushPasswordConfigKey,
+	"pwsweather_api_key",
+	"refresh_token",
+	"token",
+	WebhookSecretConfigKey,
+}
+
+// WebhookSecretConfigKey is the station config key of the secret the
+// webhooks of the station are signed with
+const WebhookSecretConfigKey = "webhook_secret"
+
+// PushPasswordConfigKey is the station config key of the password pushes
+// of stations uploading with a password, like the Weather Underground
+// protocol, must carry
+const PushPasswordConfigKey = "push_password"
+
+// WebhookSignatureHeaderConfigKey is the station config key overriding the
+// header that carries webhook signatures
+const WebhookSignatureHeaderConfigKey = "webhook_signature_header"
+
+// RedactedValue replaces secret values in API responses and logs
+const RedactedValue = "********"
+
+// IsSecretConfigKey reports whether a station config key holds a credential
+func IsSecretConfigKey(key string) bool {
+	for _, k := range SecretConfigKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactConfig returns a copy of a station config with the values of
+// secret keys masked. Empty values stay empty so unset credentials are
+// still visible.
+func RedactConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if s, ok := value.(string); IsSecretConfigKey(key) && (!ok || s != "") {
+			value = RedactedValue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// secretNames is the regexp alternation of SecretConfigKeys and generic
+// credential names, longest first
+func secretNames() string {
+	names := append([]string{"password", "secret"}, SecretConfigKeys...)
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return strings.Join(names, "|")
+}
+
+// secretPatterns match credentials in query strings, form data, JSON,
+// authorization headers and API keys
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)((?:` + secretNames() + `)=)[^&\s"']+`), "${1}" + RedactedValue},
+	{regexp.MustCompile(`(?i)("(?:` + secretNames() + `)"\s*:\s*")[^"]*`), "${1}" + RedactedValue},
+	{regexp.MustCompile(`(?i)((?:Bearer|Basic)\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + RedactedValue},
+	{regexp.MustCompile(`\b` + APIKeyPrefix + `[A-Za-z0-9]+`), APIKeyPrefix + RedactedValue},
+}
+
+// RedactString masks credentials in free text such as log lines and error
+// messages
+func RedactString(s string) string {
+	for _, p := range secretPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
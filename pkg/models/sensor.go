@@ -1,25 +1,36 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Sensor kinds
+const (
+	// SensorKindAutomatic sensors are measured by the station
+	SensorKindAutomatic = "automatic"
+	// SensorKindManual sensors hold observations entered by a person
+	SensorKindManual = "manual"
+)
+
 // Sensor represents a physical sensor on a weather station
 type Sensor struct {
-	ID             uuid.UUID `json:"id"`
-	StationID      uuid.UUID `json:"station_id"`
-	SensorType     string    `json:"sensor_type"`
-	Location       string    `json:"location"`
-	Name           string    `json:"name,omitempty"`
-	Model          string    `json:"model,omitempty"`
-	BatteryLevel   *int      `json:"battery_level,omitempty"`
-	SignalStrength *int      `json:"signal_strength,omitempty"`
-	Enabled        bool      `json:"enabled"`
-	RemoteID       string    `json:"remote_id,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uuid.UUID      `json:"id"`
+	StationID      uuid.UUID      `json:"station_id"`
+	SensorType     string         `json:"sensor_type"`
+	Location       string         `json:"location"`
+	Name           string         `json:"name,omitempty"`
+	Model          string         `json:"model,omitempty"`
+	BatteryLevel   *int           `json:"battery_level,omitempty"`
+	SignalStrength *int           `json:"signal_strength,omitempty"`
+	Enabled        bool           `json:"enabled"`
+	RemoteID       string         `json:"remote_id,omitempty"`
+	Kind           string         `json:"kind"`
+	Display        *SensorDisplay `json:"display,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
 }
 
 // SensorQueryParams holds query parameters for sensor queries
@@ -36,3 +47,47 @@ type SensorWithLatestReading struct {
 	Sensor        Sensor         `json:"sensor"`
 	LatestReading *SensorReading `json:"latest_reading,omitempty"`
 }
+
+// SensorTypeChange renames the sensor type of matching sensors. Without
+// station and sensor IDs all sensors of type From are changed.
+type SensorTypeChange struct {
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	StationIDs []uuid.UUID `json:"station_ids,omitempty"`
+	SensorIDs  []uuid.UUID `json:"sensor_ids,omitempty"`
+	// Merge moves the readings of a sensor into an existing sensor of the
+	// new type at the same station and location instead of failing
+	Merge bool `json:"merge"`
+	// DryRun reports the changes without applying them
+	DryRun bool `json:"dry_run"`
+}
+
+// Validate checks that both types are set and differ
+func (c *SensorTypeChange) Validate() error {
+	if c.From == "" || c.To == "" {
+		return errors.New("from and to are required")
+	}
+	if c.From == c.To {
+		return errors.New("from and to must differ")
+	}
+	if len(c.To) > 20 {
+		return errors.New("sensor type must be at most 20 characters")
+	}
+	return nil
+}
+
+// SensorMerge is a sensor whose readings were moved into another sensor
+type SensorMerge struct {
+	SourceID uuid.UUID `json:"source_id"`
+	TargetID uuid.UUID `json:"target_id"`
+	// Readings is the number of moved readings; readings at timestamps the
+	// target already has are dropped
+	Readings uint64 `json:"readings"`
+}
+
+// SensorTypeChangeResult lists the sensors affected by a SensorTypeChange
+type SensorTypeChangeResult struct {
+	Renamed []uuid.UUID   `json:"renamed"`
+	Merged  []SensorMerge `json:"merged"`
+	DryRun  bool          `json:"dry_run"`
+}
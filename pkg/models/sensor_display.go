@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxDisplayDecimals is the largest number of decimal places of a sensor
+const MaxDisplayDecimals = 6
+
+// displayIconPattern matches icon names like "thermometer" or
+// "mdi:thermometer-high"
+var displayIconPattern = regexp.MustCompile(`^[a-z0-9]+(:[a-z0-9]+)?(-[a-z0-9]+)*$`)
+
+// SensorDisplay holds how frontends show the readings of a sensor. Readings
+// are stored in the unit of the sensor type; Unit only changes how they are
+// shown. Empty fields keep the defaults of the sensor type.
+type SensorDisplay struct {
+	// Unit is the preferred unit, see DisplayUnits
+	Unit string `json:"unit,omitempty"`
+	// Decimals is the number of decimal places
+	Decimals *int `json:"decimals,omitempty"`
+	// Name replaces the name of the sensor
+	Name string `json:"name,omitempty"`
+	// Icon is an icon name, e.g. mdi:thermometer
+	Icon string `json:"icon,omitempty"`
+}
+
+// IsZero reports whether no display setting is set
+func (d *SensorDisplay) IsZero() bool {
+	return d == nil || (d.Unit == "" && d.Decimals == nil && d.Name == "" && d.Icon == "")
+}
+
+// Validate checks the settings of a sensor whose readings are stored in
+// unit
+func (d *SensorDisplay) Validate(unit string) error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Unit != "" && !slices.Contains(DisplayUnits(unit), d.Unit) {
+		return fmt.Errorf("unit must be one of %s", strings.Join(DisplayUnits(unit), ", "))
+	}
+	if d.Decimals != nil && (*d.Decimals < 0 || *d.Decimals > MaxDisplayDecimals) {
+		return fmt.Errorf("decimals must be between 0 and %d", MaxDisplayDecimals)
+	}
+	if len(d.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	if d.Icon != "" && (len(d.Icon) > 64 || !displayIconPattern.MatchString(d.Icon)) {
+		return fmt.Errorf("invalid icon %q, use lower case names like mdi:thermometer", d.Icon)
+	}
+	return nil
+}
+
+// Format converts a value stored in unit to the display unit and formats
+// it with the display decimals. Without decimals it is rounded to
+// defaultDecimals and trailing zeros are dropped. It returns the value and
+// the unit it is shown in.
+func (d *SensorDisplay) Format(value float64, unit string, defaultDecimals int) (string, string) {
+	if d != nil && d.Unit != "" {
+		if converted, err := ConvertUnit(value, unit, d.Unit); err == nil {
+			value, unit = converted, d.Unit
+		}
+	}
+	if d != nil && d.Decimals != nil {
+		return strconv.FormatFloat(value, 'f', *d.Decimals, 64), unit
+	}
+	scale := math.Pow(10, float64(defaultDecimals))
+	return strconv.FormatFloat(math.Round(value*scale)/scale, 'f', -1, 64), unit
+}
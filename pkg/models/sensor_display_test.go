@@ -0,0 +1,69 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertUnit(t *testing.T) {
+	testCases := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{20, "°C", "°F", 68},
+		{10, "m/s", "km/h", 36},
+		{1013.25, "hPa", "inHg", 29.92},
+		{25.4, "mm", "in", 1},
+		{5, "mm", "mm", 5},
+	}
+	for _, tc := range testCases {
+		got, err := ConvertUnit(tc.value, tc.from, tc.to)
+		if err != nil || math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("ConvertUnit(%v, %s, %s) = %v, %v, want %v", tc.value, tc.from, tc.to, got, err, tc.want)
+		}
+	}
+	if _, err := ConvertUnit(1, "°C", "mm"); err == nil {
+		t.Error("Expected an error for incompatible units")
+	}
+}
+
+func TestSensorDisplay_Validate(t *testing.T) {
+	decimals := 2
+	valid := SensorDisplay{Unit: "°F", Decimals: &decimals, Name: " Garden ", Icon: "mdi:thermometer-high"}
+	if err := valid.Validate("°C"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if valid.Name != "Garden" {
+		t.Errorf("Expected the trimmed name, got %q", valid.Name)
+	}
+
+	tooMany := 7
+	for _, d := range []SensorDisplay{
+		{Unit: "mph"},
+		{Decimals: &tooMany},
+		{Icon: "Thermo Meter"},
+	} {
+		if err := d.Validate("°C"); err == nil {
+			t.Errorf("Expected an error for %+v", d)
+		}
+	}
+}
+
+func TestSensorDisplay_Format(t *testing.T) {
+	var none *SensorDisplay
+	if value, unit := none.Format(21.25, "°C", 1); value != "21.3" || unit != "°C" {
+		t.Errorf("Expected the defaults, got %s %s", value, unit)
+	}
+
+	decimals := 0
+	d := &SensorDisplay{Unit: "°F", Decimals: &decimals}
+	if value, unit := d.Format(21.25, "°C", 1); value != "70" || unit != "°F" {
+		t.Errorf("Expected 70 °F, got %s %s", value, unit)
+	}
+
+	// Units of other sensor types are ignored
+	if value, unit := d.Format(3.5, "mm", 1); value != "4" || unit != "mm" {
+		t.Errorf("Expected 4 mm, got %s %s", value, unit)
+	}
+}
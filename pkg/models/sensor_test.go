@@ -0,0 +1,24 @@
+package models
+
+import "testing"
+
+func TestSensorTypeChange_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  SensorTypeChange
+		wantErr bool
+	}{
+		{"valid", SensorTypeChange{From: "Temprature", To: SensorTypeTemperature}, false},
+		{"missing from", SensorTypeChange{To: SensorTypeTemperature}, true},
+		{"missing to", SensorTypeChange{From: "Temprature"}, true},
+		{"same type", SensorTypeChange{From: SensorTypeTemperature, To: SensorTypeTemperature}, true},
+		{"too long", SensorTypeChange{From: "Temprature", To: "TemperatureOutdoorGarden"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
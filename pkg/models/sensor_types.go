@@ -30,6 +30,12 @@ const (
 	SensorTypeSignalStrength     = "SignalStrength"
 	SensorTypeCO2                = "CO2"
 	SensorTypeNoise              = "Noise"
+	SensorTypePM25               = "PM25"
+	SensorTypePM10               = "PM10"
+	SensorTypeVisibility         = "Visibility"
+	SensorTypeCloudCover         = "CloudCover"
+	SensorTypeSnowfall           = "Snowfall"
+	SensorTypePresentWeather     = "PresentWeather"
 )
 
 // SensorCategory constants for standard sensor categories
@@ -44,6 +50,9 @@ const (
 	SensorCategorySystem      = "System"
 	SensorCategoryC02         = "CO2"
 	SensorCategoryNoise       = "Noise"
+	SensorCategoryAirQuality  = "AirQuality"
+	SensorCategoryCustom      = "Custom"
+	SensorCategoryObservation = "Observation"
 )
 
 // SensorType represents a standardized sensor type
@@ -203,4 +212,41 @@ var SensorTypeRegistry = map[string]SensorTypeInfo{
 		Category: SensorCategoryNoise,
 		Unit:     "dB",
 	},
+	SensorTypePM25: {
+		Name:     SensorTypePM25,
+		Category: SensorCategoryAirQuality,
+		Unit:     "µg/m³",
+	},
+	SensorTypePM10: {
+		Name:     SensorTypePM10,
+		Category: SensorCategoryAirQuality,
+		Unit:     "µg/m³",
+	},
+	SensorTypeVisibility: {
+		Name:     SensorTypeVisibility,
+		Category: SensorCategoryObservation,
+		Unit:     "m",
+	},
+	SensorTypeCloudCover: {
+		Name:     SensorTypeCloudCover,
+		Category: SensorCategoryObservation,
+		Unit:     "okta",
+	},
+	SensorTypeSnowfall: {
+		Name:     SensorTypeSnowfall,
+		Category: SensorCategoryObservation,
+		Unit:     "cm",
+	},
+	SensorTypePresentWeather: {
+		Name:     SensorTypePresentWeather,
+		Category: SensorCategoryObservation,
+		Unit:     "code",
+	},
+}
+
+// IsDirection reports whether the readings of a sensor type are compass
+// directions in degrees, which are averaged as vectors and compared the
+// short way around the circle
+func IsDirection(sensorType string) bool {
+	return sensorType == SensorTypeWindDirection || sensorType == SensorTypeWindGustAngle
 }
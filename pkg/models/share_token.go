@@ -0,0 +1,31 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareTokenPrefix starts every share token
+const ShareTokenPrefix = "wms_"
+
+// ShareToken gives public read access to the current conditions of one
+// station, e.g. for widgets embedded in other websites. Only a hash of the
+// token is stored; Prefix identifies the token in listings.
+type ShareToken struct {
+	ID        uuid.UUID `json:"id"`
+	StationID uuid.UUID `json:"station_id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	// SensorIDs limits the token to these sensors of the station, e.g. the
+	// outdoor ones; empty shares all sensors
+	SensorIDs []uuid.UUID `json:"sensor_ids"`
+	CreatedAt time.Time   `json:"created_at"`
+	RevokedAt *time.Time  `json:"revoked_at,omitempty"`
+}
+
+// Allows reports whether the token shares a sensor
+func (t ShareToken) Allows(sensorID uuid.UUID) bool {
+	return len(t.SensorIDs) == 0 || slices.Contains(t.SensorIDs, sensorID)
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestShareToken_Allows(t *testing.T) {
+	outdoor, indoor := uuid.New(), uuid.New()
+
+	all := ShareToken{}
+	if !all.Allows(outdoor) || !all.Allows(indoor) {
+		t.Error("token without sensor selection should share all sensors")
+	}
+
+	selected := ShareToken{SensorIDs: []uuid.UUID{outdoor}}
+	if !selected.Allows(outdoor) || selected.Allows(indoor) {
+		t.Errorf("token should only share %s", outdoor)
+	}
+}
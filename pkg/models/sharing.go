@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SharingConfigKey is the station config key with the license and consent
+// of publicly shared data
+const SharingConfigKey = "sharing"
+
+// maxAttributionLength limits the attribution text shown with shared data
+const maxAttributionLength = 200
+
+// Licenses maps the supported SPDX license identifiers to their URL
+var Licenses = map[string]string{
+	"CC0-1.0":      "https://creativecommons.org/publicdomain/zero/1.0/",
+	"CC-BY-4.0":    "https://creativecommons.org/licenses/by/4.0/",
+	"CC-BY-SA-4.0": "https://creativecommons.org/licenses/by-sa/4.0/",
+	"CC-BY-NC-4.0": "https://creativecommons.org/licenses/by-nc/4.0/",
+	"ODbL-1.0":     "https://opendatacommons.org/licenses/odbl/1-0/",
+}
+
+// SharingConsent records what the station owner agreed to
+type SharingConsent struct {
+	// Map lists the station with its coordinates in the public GeoJSON feed
+	Map bool `json:"map"`
+	// Community allows forwarding readings to community networks like
+	// Sensor.Community and openSenseMap
+	Community bool `json:"community"`
+	// Commercial allows commercial reuse of the data
+	Commercial bool `json:"commercial"`
+}
+
+// SharingPolicy is the license and consent of the data of a station
+type SharingPolicy struct {
+	// License is an SPDX identifier out of Licenses, e.g. CC-BY-4.0
+	License string `json:"license,omitempty"`
+	// LicenseURL is filled in from License and cannot be configured
+	LicenseURL string `json:"license_url,omitempty"`
+	// Attribution is the credit line required when sharing the data
+	Attribution string         `json:"attribution,omitempty"`
+	Consent     SharingConsent `json:"consent"`
+}
+
+// ParseSharingPolicy reads the sharing settings from a station config.
+// A station without settings gets an empty policy.
+func ParseSharingPolicy(config map[string]interface{}) (SharingPolicy, error) {
+	var policy SharingPolicy
+	value, ok := config[SharingConfigKey]
+	if !ok || value == nil {
+		return policy, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return SharingPolicy{}, fmt.Errorf("invalid sharing config: %w", err)
+	}
+
+	policy.LicenseURL = ""
+	if policy.License != "" {
+		url, ok := Licenses[policy.License]
+		if !ok {
+			return SharingPolicy{}, fmt.Errorf("invalid sharing config: unknown license %q, use one of %s", policy.License, strings.Join(LicenseIDs(), ", "))
+		}
+		policy.LicenseURL = url
+	}
+	policy.Attribution = strings.TrimSpace(policy.Attribution)
+	if len(policy.Attribution) > maxAttributionLength {
+		return SharingPolicy{}, fmt.Errorf("invalid sharing config: attribution must be at most %d characters", maxAttributionLength)
+	}
+	if policy.Attribution == "" && policy.License != "" && policy.License != "CC0-1.0" {
+		return SharingPolicy{}, fmt.Errorf("invalid sharing config: %s requires an attribution", policy.License)
+	}
+	return policy, nil
+}
+
+// LicenseIDs returns the supported license identifiers in order
+func LicenseIDs() []string {
+	ids := make([]string, 0, len(Licenses))
+	for id := range Licenses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsZero reports whether the station has no sharing settings
+func (p SharingPolicy) IsZero() bool {
+	return p.License == "" && p.Attribution == "" && p.Consent == SharingConsent{}
+}
+
+// AllowsCommunity reports whether readings may be forwarded to community
+// networks. Stations without sharing settings keep forwarding as
+// configured, settings without community consent stop it.
+func (p SharingPolicy) AllowsCommunity() bool {
+	return p.IsZero() || p.Consent.Community
+}
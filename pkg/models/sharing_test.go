@@ -0,0 +1,77 @@
+package models
+
+import "testing"
+
+func TestParseSharingPolicy(t *testing.T) {
+	config := map[string]interface{}{
+		SharingConfigKey: map[string]interface{}{
+			"license":     "CC-BY-4.0",
+			"license_url": "https://example.com/ignored",
+			"attribution": " Weather station Vienna West ",
+			"consent":     map[string]interface{}{"map": true, "community": true},
+		},
+	}
+
+	policy, err := ParseSharingPolicy(config)
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	if policy.LicenseURL != Licenses["CC-BY-4.0"] {
+		t.Errorf("Expected license URL of CC-BY-4.0, got %q", policy.LicenseURL)
+	}
+	if policy.Attribution != "Weather station Vienna West" {
+		t.Errorf("Expected trimmed attribution, got %q", policy.Attribution)
+	}
+	if !policy.Consent.Map || !policy.Consent.Community || policy.Consent.Commercial {
+		t.Errorf("Unexpected consent %+v", policy.Consent)
+	}
+
+	empty, err := ParseSharingPolicy(map[string]interface{}{})
+	if err != nil || !empty.IsZero() {
+		t.Errorf("Expected empty policy, got %+v, %v", empty, err)
+	}
+}
+
+func TestParseSharingPolicy_Invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy interface{}
+	}{
+		{name: "Unknown license", policy: map[string]interface{}{"license": "GPL-3.0", "attribution": "me"}},
+		{name: "Missing attribution", policy: map[string]interface{}{"license": "CC-BY-SA-4.0"}},
+		{name: "Wrong type", policy: "yes"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseSharingPolicy(map[string]interface{}{SharingConfigKey: tc.policy}); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	// Public domain data needs no credit
+	if _, err := ParseSharingPolicy(map[string]interface{}{SharingConfigKey: map[string]interface{}{"license": "CC0-1.0"}}); err != nil {
+		t.Errorf("Expected CC0-1.0 without attribution to be valid, got %v", err)
+	}
+}
+
+func TestSharingPolicy_AllowsCommunity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   SharingPolicy
+		expected bool
+	}{
+		{name: "No settings", policy: SharingPolicy{}, expected: true},
+		{name: "Consent given", policy: SharingPolicy{License: "CC0-1.0", Consent: SharingConsent{Community: true}}, expected: true},
+		{name: "Consent missing", policy: SharingPolicy{License: "CC0-1.0", Consent: SharingConsent{Map: true}}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.policy.AllowsCommunity(); got != tc.expected {
+				t.Errorf("AllowsCommunity() = %v, want %v", got, tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SitePrimaryConfigKey designates a station as primary station of its site,
+// either for all sensor types or for a list of them:
+//
+//	"site_primary": true
+//	"site_primary": ["TemperatureOutdoor", "HumidityOutdoor"]
+const SitePrimaryConfigKey = "site_primary"
+
+// SitePrimary holds the sensor types a station is the primary station for
+type SitePrimary struct {
+	all   bool
+	types map[string]bool
+}
+
+// ParseSitePrimary reads the primary sensor types of a station config
+func ParseSitePrimary(config map[string]interface{}) (SitePrimary, error) {
+	switch value := config[SitePrimaryConfigKey].(type) {
+	case nil:
+		return SitePrimary{}, nil
+	case bool:
+		return SitePrimary{all: value}, nil
+	case []interface{}:
+		p := SitePrimary{types: make(map[string]bool, len(value))}
+		for _, v := range value {
+			sensorType, ok := v.(string)
+			if !ok || sensorType == "" {
+				return SitePrimary{}, fmt.Errorf("invalid %s config: invalid sensor type %v", SitePrimaryConfigKey, v)
+			}
+			p.types[sensorType] = true
+		}
+		return p, nil
+	}
+	return SitePrimary{}, fmt.Errorf("invalid %s config: expected true or a list of sensor types", SitePrimaryConfigKey)
+}
+
+// Covers reports whether the station is primary for a sensor type
+func (p SitePrimary) Covers(sensorType string) bool {
+	return p.all || p.types[sensorType]
+}
+
+// SiteReading is the latest reading of a sensor of a station at a site
+type SiteReading struct {
+	Sensor  Sensor
+	Reading SensorReading
+	Primary bool
+}
+
+// SiteCurrentValue is the current value of a sensor type and location at a
+// site and the station it is taken from
+type SiteCurrentValue struct {
+	SensorType string    `json:"sensor_type"`
+	Location   string    `json:"location"`
+	StationID  uuid.UUID `json:"station_id"`
+	SensorID   uuid.UUID `json:"sensor_id"`
+	Value      float64   `json:"value"`
+	Unit       string    `json:"unit,omitempty"`
+	DateUTC    time.Time `json:"date_utc"`
+	// Primary is set if the value is taken from the primary station
+	Primary bool `json:"primary"`
+}
+
+// SiteCurrent are the current conditions of a site merged from its stations
+type SiteCurrent struct {
+	Site     string             `json:"site"`
+	Stations []uuid.UUID        `json:"stations"`
+	Values   []SiteCurrentValue `json:"values"`
+}
+
+// MergeSiteReadings picks one reading per sensor type and location: the
+// newest reading of a primary station unless it is older than maxAge at
+// now, otherwise the newest reading of any station. Values are sorted by
+// location and sensor type.
+func MergeSiteReadings(readings []SiteReading, now time.Time, maxAge time.Duration) []SiteCurrentValue {
+	type group struct{ sensorType, location string }
+	best := make(map[group]SiteReading)
+	for _, r := range readings {
+		g := group{r.Sensor.SensorType, r.Sensor.Location}
+		current, ok := best[g]
+		if !ok || sitePrecedes(r, current, now, maxAge) {
+			best[g] = r
+		}
+	}
+
+	values := make([]SiteCurrentValue, 0, len(best))
+	for g, r := range best {
+		values = append(values, SiteCurrentValue{
+			SensorType: g.sensorType,
+			Location:   g.location,
+			StationID:  r.Sensor.StationID,
+			SensorID:   r.Sensor.ID,
+			Value:      r.Reading.Value,
+			Unit:       r.Reading.Unit,
+			DateUTC:    r.Reading.DateUTC,
+			Primary:    r.Primary,
+		})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Location != values[j].Location {
+			return values[i].Location < values[j].Location
+		}
+		return values[i].SensorType < values[j].SensorType
+	})
+	return values
+}
+
+// sitePrecedes reports whether reading a takes precedence over b
+func sitePrecedes(a, b SiteReading, now time.Time, maxAge time.Duration) bool {
+	aPrimary := a.Primary && now.Sub(a.Reading.DateUTC) <= maxAge
+	bPrimary := b.Primary && now.Sub(b.Reading.DateUTC) <= maxAge
+	if aPrimary != bPrimary {
+		return aPrimary
+	}
+	return a.Reading.DateUTC.After(b.Reading.DateUTC)
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseSitePrimary(t *testing.T) {
+	all, err := ParseSitePrimary(map[string]interface{}{SitePrimaryConfigKey: true})
+	if err != nil || !all.Covers(SensorTypeWindSpeed) {
+		t.Errorf("Expected primary for all types, got %v (%v)", all, err)
+	}
+
+	some, err := ParseSitePrimary(map[string]interface{}{SitePrimaryConfigKey: []interface{}{SensorTypeWindSpeed}})
+	if err != nil || !some.Covers(SensorTypeWindSpeed) || some.Covers(SensorTypeTemperatureOutdoor) {
+		t.Errorf("Expected primary for wind speed only, got %v (%v)", some, err)
+	}
+
+	if none, err := ParseSitePrimary(map[string]interface{}{}); err != nil || none.Covers(SensorTypeWindSpeed) {
+		t.Errorf("Expected no primary types, got %v (%v)", none, err)
+	}
+	if _, err := ParseSitePrimary(map[string]interface{}{SitePrimaryConfigKey: "yes"}); err == nil {
+		t.Error("Expected an error for a string")
+	}
+}
+
+func TestMergeSiteReadings(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	garden, roof := uuid.New(), uuid.New()
+	reading := func(station uuid.UUID, sensorType string, value float64, age time.Duration, primary bool) SiteReading {
+		return SiteReading{
+			Sensor:  Sensor{ID: uuid.New(), StationID: station, SensorType: sensorType, Location: "Outdoor"},
+			Reading: SensorReading{Value: value, DateUTC: now.Add(-age)},
+			Primary: primary,
+		}
+	}
+
+	values := MergeSiteReadings([]SiteReading{
+		// The primary station wins over a newer reading
+		reading(garden, SensorTypeTemperatureOutdoor, 14.2, 2*time.Minute, true),
+		reading(roof, SensorTypeTemperatureOutdoor, 15.0, time.Minute, false),
+		// A stale primary station loses
+		reading(garden, SensorTypeWindSpeed, 1, 2*time.Hour, true),
+		reading(roof, SensorTypeWindSpeed, 4, time.Minute, false),
+		// Without primary the newest wins
+		reading(garden, SensorTypeHumidityOutdoor, 70, 5*time.Minute, false),
+		reading(roof, SensorTypeHumidityOutdoor, 72, time.Minute, false),
+	}, now, 30*time.Minute)
+
+	expected := map[string]struct {
+		value   float64
+		station uuid.UUID
+		primary bool
+	}{
+		SensorTypeTemperatureOutdoor: {14.2, garden, true},
+		SensorTypeWindSpeed:          {4, roof, false},
+		SensorTypeHumidityOutdoor:    {72, roof, false},
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d values, got %v", len(expected), values)
+	}
+	for _, v := range values {
+		e := expected[v.SensorType]
+		if v.Value != e.value || v.StationID != e.station || v.Primary != e.primary {
+			t.Errorf("%s: expected %v from %s, got %v from %s", v.SensorType, e.value, e.station, v.Value, v.StationID)
+		}
+	}
+}
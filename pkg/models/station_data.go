@@ -14,6 +14,7 @@ type StationData struct {
 	Mode        string                 `json:"mode"`         // "push" or "pull"
 	ServiceName string                 `json:"service_name"` // "ecowitt", "netatmo", etc.
 	Config      map[string]interface{} `json:"config"`
+	OwnerID     *uuid.UUID             `json:"owner_id,omitempty"`
 	LastUpdate  *time.Time             `json:"last_update"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
@@ -27,4 +28,18 @@ type StationDetail struct {
 	TotalReadings int       `json:"total_readings"`
 	FirstReading  time.Time `json:"first_reading"`
 	LastReading   time.Time `json:"last_reading"`
+
+	// ClockSkewSeconds is the measured offset of the station clock;
+	// positive values mean the station clock is behind.
+	ClockSkewSeconds   *float64   `json:"clock_skew_seconds,omitempty"`
+	ClockSkewUpdatedAt *time.Time `json:"clock_skew_updated_at,omitempty"`
+
+	// PullStatus is the outcome of the last pull of pulled stations
+	PullStatus *PullStatus `json:"pull_status,omitempty"`
+
+	// Sharing is the license and consent of the station data
+	Sharing *SharingPolicy `json:"sharing,omitempty"`
+
+	// Battery is the worst status of the battery sensors
+	Battery *StationBattery `json:"battery,omitempty"`
 }
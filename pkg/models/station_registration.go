@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Station registration policies decide what happens to pushes of unknown
+// passkeys
+const (
+	// RegistrationAuto creates a station for every unknown passkey
+	RegistrationAuto = "auto"
+	// RegistrationManual lists unknown stations for approval by an admin;
+	// their data is not stored until then
+	RegistrationManual = "manual-approval"
+	// RegistrationClosed refuses pushes of unknown stations
+	RegistrationClosed = "closed"
+)
+
+// Statuses of pending stations
+const (
+	PendingStationPending  = "pending"
+	PendingStationRejected = "rejected"
+)
+
+// Station config keys assigned when approving a pending station
+const (
+	StationNameConfigKey = "name"
+	StationSiteConfigKey = "site"
+)
+
+// ParseRegistrationPolicy validates a station registration policy. An empty
+// value is the default, auto.
+func ParseRegistrationPolicy(value string) (string, error) {
+	switch value {
+	case "":
+		return RegistrationAuto, nil
+	case RegistrationAuto, RegistrationManual, RegistrationClosed:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid station registration policy: %s (valid: %s, %s, %s)", value, RegistrationAuto, RegistrationManual, RegistrationClosed)
+}
+
+// PendingStation is an unknown station that pushed data while registration
+// requires approval
+type PendingStation struct {
+	PassKey     string    `json:"pass_key"`
+	StationType string    `json:"station_type"`
+	Model       string    `json:"model"`
+	ServiceName string    `json:"service_name"`
+	Status      string    `json:"status"`
+	SourceIP    string    `json:"source_ip"`
+	PushCount   int       `json:"push_count"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+
+	// Sample holds the parsed values of the last push
+	Sample []PendingSampleValue `json:"sample"`
+}
+
+// PendingSampleValue is a value parsed from a push of a pending station
+type PendingSampleValue struct {
+	RemoteID   string  `json:"remote_id"`
+	SensorType string  `json:"sensor_type"`
+	Location   string  `json:"location"`
+	Value      float64 `json:"value"`
+	Unit       string  `json:"unit,omitempty"`
+}
+
+// StationApproval holds the optional settings of a station approved from
+// the pending list
+type StationApproval struct {
+	Name    string     `json:"name,omitempty"`
+	Site    string     `json:"site,omitempty"`
+	OwnerID *uuid.UUID `json:"owner_id,omitempty"`
+
+	// TemplateID is an existing station whose config is copied
+	TemplateID *uuid.UUID `json:"template_id,omitempty"`
+
+	// Settings are config values set on top of the template config
+	Settings map[string]interface{} `json:"config,omitempty"`
+}
+
+// Config returns the config of the approved station: the config of the
+// template station without its credentials, which belong to the template,
+// with the settings, name and site set
+func (a StationApproval) Config(template map[string]interface{}) map[string]interface{} {
+	config := make(map[string]interface{}, len(template)+len(a.Settings)+2)
+	for key, value := range template {
+		if !IsSecretConfigKey(key) {
+			config[key] = value
+		}
+	}
+	for key, value := range a.Settings {
+		config[key] = value
+	}
+	if a.Name != "" {
+		config[StationNameConfigKey] = a.Name
+	}
+	if a.Site != "" {
+		config[StationSiteConfigKey] = a.Site
+	}
+	return config
+}
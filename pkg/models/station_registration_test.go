@@ -0,0 +1,61 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRegistrationPolicy(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "", expected: RegistrationAuto},
+		{value: "auto", expected: RegistrationAuto},
+		{value: "manual-approval", expected: RegistrationManual},
+		{value: "closed", expected: RegistrationClosed},
+		{value: "manual", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		policy, err := ParseRegistrationPolicy(tc.value)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected an error for %q, got %s", tc.value, policy)
+			}
+			continue
+		}
+		if err != nil || policy != tc.expected {
+			t.Errorf("ParseRegistrationPolicy(%q) = %s, %v, expected %s", tc.value, policy, err, tc.expected)
+		}
+	}
+}
+
+func TestStationApprovalConfig(t *testing.T) {
+	template := map[string]interface{}{
+		"timezone":          "Europe/Vienna",
+		"clock_correction":  true,
+		SecretConfigKeys[0]: "secret",
+	}
+
+	config := StationApproval{
+		Name:     "Garden",
+		Site:     "Vienna",
+		Settings: map[string]interface{}{"timezone": "UTC", WebhookSecretConfigKey: "own"},
+	}.Config(template)
+
+	expected := map[string]interface{}{
+		"timezone":             "UTC",
+		"clock_correction":     true,
+		WebhookSecretConfigKey: "own",
+		StationNameConfigKey:   "Garden",
+		StationSiteConfigKey:   "Vienna",
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Expected %v, got %v", expected, config)
+	}
+	if _, ok := template[StationNameConfigKey]; ok {
+		t.Error("Expected the template config to be unchanged")
+	}
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StorageProjectionDays are the horizons of the disk usage projection
+var StorageProjectionDays = []int{30, 90, 365}
+
+// StorageReport describes the disk usage of an instance for capacity
+// planning
+type StorageReport struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	TotalBytes  int64          `json:"total_bytes"`
+	Postgres    []TableStorage `json:"postgres"`
+	ClickHouse  []TableStorage `json:"clickhouse"`
+	// BytesPerReading is the average size of a stored reading on disk
+	BytesPerReading float64             `json:"bytes_per_reading"`
+	Stations        []StationStorage    `json:"stations"`
+	Growth          StorageGrowth       `json:"growth"`
+	Projections     []StorageProjection `json:"projections"`
+}
+
+// TableStorage is the size of a table. Postgres row counts are the
+// planner's estimates.
+type TableStorage struct {
+	Name  string `json:"name"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// StationStorage are the readings of a station. Bytes are estimated from
+// the average reading size, ClickHouse doesn't track sizes per sensor.
+type StationStorage struct {
+	StationID uuid.UUID       `json:"station_id"`
+	Readings  int64           `json:"readings"`
+	Bytes     int64           `json:"bytes"`
+	Sensors   []SensorStorage `json:"sensors"`
+}
+
+// SensorStorage are the readings of a sensor
+type SensorStorage struct {
+	SensorID   uuid.UUID `json:"sensor_id"`
+	SensorType string    `json:"sensor_type"`
+	Location   string    `json:"location"`
+	Readings   int64     `json:"readings"`
+	Bytes      int64     `json:"bytes"`
+}
+
+// DailyReadingCount is the number of readings stored for a day
+type DailyReadingCount struct {
+	Day      time.Time `json:"day"`
+	Readings int64     `json:"readings"`
+}
+
+// StorageGrowth is the average growth over the last days
+type StorageGrowth struct {
+	Days           int                 `json:"days"`
+	ReadingsPerDay float64             `json:"readings_per_day"`
+	BytesPerDay    float64             `json:"bytes_per_day"`
+	Daily          []DailyReadingCount `json:"daily"`
+}
+
+// StorageProjection is the expected disk usage after a number of days
+type StorageProjection struct {
+	Days  int       `json:"days"`
+	Date  time.Time `json:"date"`
+	Bytes int64     `json:"bytes"`
+}
+
+// NewStorageGrowth averages the daily counts over the given number of days.
+// Days without readings count as zero; the current day is incomplete and
+// should not be part of daily.
+func NewStorageGrowth(daily []DailyReadingCount, days int, bytesPerReading float64) StorageGrowth {
+	growth := StorageGrowth{Days: days, Daily: daily}
+	if days <= 0 {
+		return growth
+	}
+	var total int64
+	for _, d := range daily {
+		total += d.Readings
+	}
+	growth.ReadingsPerDay = float64(total) / float64(days)
+	growth.BytesPerDay = growth.ReadingsPerDay * bytesPerReading
+	return growth
+}
+
+// ProjectStorage extrapolates the current disk usage linearly with the
+// growth for each of StorageProjectionDays
+func ProjectStorage(now time.Time, currentBytes int64, growth StorageGrowth) []StorageProjection {
+	projections := make([]StorageProjection, len(StorageProjectionDays))
+	for i, days := range StorageProjectionDays {
+		projections[i] = StorageProjection{
+			Days:  days,
+			Date:  now.UTC().AddDate(0, 0, days).Truncate(24 * time.Hour),
+			Bytes: currentBytes + int64(growth.BytesPerDay*float64(days)),
+		}
+	}
+	return projections
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewStorageGrowth(t *testing.T) {
+	daily := []DailyReadingCount{
+		{Day: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Readings: 1000},
+		{Day: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC), Readings: 2000},
+	}
+
+	// The missing day counts as zero
+	growth := NewStorageGrowth(daily, 3, 20)
+	if growth.ReadingsPerDay != 1000 || growth.BytesPerDay != 20000 {
+		t.Errorf("Expected 1000 readings and 20000 bytes per day, got %+v", growth)
+	}
+
+	if growth := NewStorageGrowth(nil, 0, 20); growth.ReadingsPerDay != 0 {
+		t.Errorf("Expected no growth without days, got %+v", growth)
+	}
+}
+
+func TestProjectStorage(t *testing.T) {
+	now := time.Date(2026, 1, 10, 15, 0, 0, 0, time.UTC)
+	projections := ProjectStorage(now, 1_000_000, StorageGrowth{BytesPerDay: 1000})
+
+	if len(projections) != len(StorageProjectionDays) {
+		t.Fatalf("Expected %d projections, got %d", len(StorageProjectionDays), len(projections))
+	}
+	first := projections[0]
+	if first.Days != 30 || first.Bytes != 1_030_000 || !first.Date.Equal(time.Date(2026, 2, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected projection %+v", first)
+	}
+	if last := projections[len(projections)-1]; last.Bytes != 1_365_000 {
+		t.Errorf("Expected 1365000 bytes after a year, got %d", last.Bytes)
+	}
+}
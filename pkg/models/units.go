@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// unitConversion converts a value of a stored unit to another unit:
+// value*Factor + Offset
+type unitConversion struct {
+	Factor float64
+	Offset float64
+}
+
+// unitConversions lists the display units each stored unit can be
+// converted to
+var unitConversions = map[string]map[string]unitConversion{
+	"°C": {
+		"°F": {Factor: 1.8, Offset: 32},
+		"K":  {Factor: 1, Offset: 273.15},
+	},
+	"m/s": {
+		"km/h": {Factor: 3.6},
+		"mph":  {Factor: 1 / 0.44704},
+		"kn":   {Factor: 3600.0 / 1852},
+		"ft/s": {Factor: 1 / 0.3048},
+	},
+	"hPa": {
+		"inHg": {Factor: 1 / 33.8639},
+		"mmHg": {Factor: 1 / 1.33322},
+		"kPa":  {Factor: 0.1},
+		"mbar": {Factor: 1},
+	},
+	"mm": {
+		"in": {Factor: 1 / 25.4},
+		"cm": {Factor: 0.1},
+	},
+	"mm/h": {
+		"in/h": {Factor: 1 / 25.4},
+	},
+	"cm": {
+		"in": {Factor: 1 / 2.54},
+		"mm": {Factor: 10},
+	},
+	"m": {
+		"ft": {Factor: 1 / 0.3048},
+		"km": {Factor: 0.001},
+	},
+	"kPa": {
+		"cb":  {Factor: 1},
+		"hPa": {Factor: 10},
+	},
+}
+
+// ConvertUnit converts a value from a stored unit to a display unit. It
+// fails for units that can't be converted into each other.
+func ConvertUnit(value float64, from, to string) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+	c, ok := unitConversions[from][to]
+	if !ok {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	return value*c.Factor + c.Offset, nil
+}
+
+// DisplayUnits returns the units values of a stored unit can be shown in,
+// starting with the unit itself
+func DisplayUnits(unit string) []string {
+	units := make([]string, 0, len(unitConversions[unit]))
+	for to := range unitConversions[unit] {
+		units = append(units, to)
+	}
+	sort.Strings(units)
+	return append([]string{unit}, units...)
+}
@@ -9,6 +9,61 @@ import (
 type User struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
+	Email        string    `json:"email,omitempty"`
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
+	// TwoFactorEnabled requires a TOTP code at login
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// Locale is the preferred language, e.g. "de"; empty to negotiate it
+	Locale string `json:"locale,omitempty"`
+	// DeletionScheduledAt is when the user and the stations it owns are
+	// deleted; nil without a deletion request
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// Session is a login of a user. Access tokens reference their session, so
+// revoking it logs out the device.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Current marks the session of the request
+	Current bool `json:"current"`
+}
+
+// Actions recorded in the user audit log
+const (
+	UserAuditExport            = "export"
+	UserAuditDeletionScheduled = "deletion_scheduled"
+	UserAuditDeletionCancelled = "deletion_cancelled"
+	UserAuditDeleted           = "deleted"
+	// UserAuditSecretsRevealed records credentials of a station of the
+	// user read in plain text through the config sync
+	UserAuditSecretsRevealed = "secrets_revealed"
+)
+
+// UserAuditEntry records an export or deletion step of a user's data. Entries
+// outlive the user, so they carry the username.
+type UserAuditEntry struct {
+	ID        uuid.UUID              `json:"id"`
+	UserID    uuid.UUID              `json:"user_id"`
+	Username  string                 `json:"username"`
+	Action    string                 `json:"action"`
+	Actor     string                 `json:"actor"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// UserDeletion describes the data removed with a user
+type UserDeletion struct {
+	Stations int `json:"stations"`
+	// SensorIDs are the sensors of the deleted stations; their readings
+	// are left in ClickHouse until they are deleted as orphans
+	SensorIDs []uuid.UUID `json:"-"`
+	Sensors   int         `json:"sensors"`
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists every invalid field of a request, so clients can
+// fix all of them at once
+type ValidationErrors []FieldError
+
+// Add records an invalid field
+func (e *ValidationErrors) Add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Has reports whether a field is invalid
+func (e ValidationErrors) Has(field string) bool {
+	for _, f := range e {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the errors as error, nil if there are none
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error joins the messages of all fields
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, f := range e {
+		messages[i] = f.Message
+	}
+	return strings.Join(messages, "; ")
+}
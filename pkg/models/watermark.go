@@ -0,0 +1,70 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReadingWatermark is the position of a consumer in the order readings were
+// stored in: the storage time of the last reading it received and its ID.
+// Readings are ordered by storage time and ID, so backfilled readings with
+// old observation times still come after the watermark. It is written as
+// "<unix seconds>_<reading id>".
+type ReadingWatermark struct {
+	InsertedAt time.Time
+	ID         uuid.UUID
+}
+
+// ParseReadingWatermark parses a watermark; the empty string is the zero
+// watermark before all readings
+func ParseReadingWatermark(value string) (ReadingWatermark, error) {
+	if value == "" {
+		return ReadingWatermark{}, nil
+	}
+	seconds, id, ok := strings.Cut(value, "_")
+	if !ok {
+		return ReadingWatermark{}, fmt.Errorf("invalid watermark %q", value)
+	}
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || unix < 0 {
+		return ReadingWatermark{}, fmt.Errorf("invalid watermark %q", value)
+	}
+	readingID, err := uuid.Parse(id)
+	if err != nil {
+		return ReadingWatermark{}, fmt.Errorf("invalid watermark %q", value)
+	}
+	return ReadingWatermark{InsertedAt: time.Unix(unix, 0).UTC(), ID: readingID}, nil
+}
+
+// IsZero reports whether the watermark is before all readings
+func (w ReadingWatermark) IsZero() bool {
+	return w.InsertedAt.IsZero() && w.ID == uuid.Nil
+}
+
+// String formats the watermark; the zero watermark is empty
+func (w ReadingWatermark) String() string {
+	if w.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d_%s", w.InsertedAt.Unix(), w.ID)
+}
+
+// MarshalText writes the watermark as string in JSON
+func (w ReadingWatermark) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// ReadingChange is a reading with the time it was stored
+type ReadingChange struct {
+	SensorReading
+	InsertedAt time.Time `json:"inserted_at"`
+}
+
+// Watermark returns the watermark after the reading
+func (c ReadingChange) Watermark() ReadingWatermark {
+	return ReadingWatermark{InsertedAt: c.InsertedAt, ID: c.ID}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseReadingWatermark(t *testing.T) {
+	id := uuid.New()
+	w := ReadingWatermark{InsertedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), ID: id}
+
+	parsed, err := ParseReadingWatermark(w.String())
+	if err != nil {
+		t.Fatalf("ParseReadingWatermark() error = %v", err)
+	}
+	if !parsed.InsertedAt.Equal(w.InsertedAt) || parsed.ID != id {
+		t.Errorf("Expected %v, got %v", w, parsed)
+	}
+
+	if zero, err := ParseReadingWatermark(""); err != nil || !zero.IsZero() {
+		t.Errorf("Expected the zero watermark, got %v (%v)", zero, err)
+	}
+	for _, invalid := range []string{"1759320000", "abc_" + id.String(), "1759320000_abc", "-1_" + id.String()} {
+		if _, err := ParseReadingWatermark(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestReadingWatermark_JSON(t *testing.T) {
+	id := uuid.MustParse("68f5e855-b9fe-49c4-a6bf-7c05beac4ba6")
+	data, err := json.Marshal(map[string]ReadingWatermark{"watermark": {InsertedAt: time.Unix(1759320000, 0), ID: id}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if expected := `{"watermark":"1759320000_68f5e855-b9fe-49c4-a6bf-7c05beac4ba6"}`; string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,245 @@
+// Package mqtt publishes messages to an MQTT 3.1.1 broker. Only QoS 0 is
+// supported, which is enough for retained states and discovery configs.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writeTimeout bounds writing a packet to the broker
+const writeTimeout = 10 * time.Second
+
+// Message is published to a topic. Retained messages are kept by the broker
+// and delivered to every new subscriber.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Config configures the connection to a broker
+type Config struct {
+	// Broker is host:port with an optional tcp:// or ssl:// scheme
+	Broker   string
+	ClientID string
+	Username string
+	Password string
+	// KeepAlive is the interval the broker expects packets in (default: 60s)
+	KeepAlive time.Duration
+	// Will is published by the broker when the connection is lost
+	Will *Message
+	// Birth messages are published after every connect, e.g. to undo the will
+	Birth []Message
+}
+
+// Client publishes messages to a broker. It connects on the first publish
+// and reconnects after the connection was lost.
+type Client struct {
+	cfg Config
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewClient creates a new Client
+func NewClient(cfg Config) *Client {
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 60 * time.Second
+	}
+	return &Client{cfg: cfg}
+}
+
+// Publish sends a message. A failed write is retried once on a new
+// connection.
+func (c *Client) Publish(ctx context.Context, msg Message) error {
+	data, err := publishPacket(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if c.conn == nil {
+			if err := c.connect(ctx); err != nil {
+				return err
+			}
+		}
+		err := c.write(data)
+		if err == nil {
+			return nil
+		}
+		c.closeConn()
+		if attempt > 0 {
+			return fmt.Errorf("failed to publish to %s: %w", msg.Topic, err)
+		}
+	}
+}
+
+// Close disconnects from the broker. The broker does not publish the will
+// on a clean disconnect.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	data, _ := packet(packetDisconnect, nil)
+	err := c.write(data)
+	c.closeConn()
+	return err
+}
+
+// connect opens the connection, waits for the broker to accept it and
+// publishes the birth messages. The caller holds mu.
+func (c *Client) connect(ctx context.Context) error {
+	conn, err := dial(ctx, c.cfg.Broker)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", c.cfg.Broker, err)
+	}
+
+	data, err := connectPacket(c.cfg)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(writeTimeout))
+	if _, err := conn.Write(data); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to %s: %w", c.cfg.Broker, err)
+	}
+
+	reader := bufio.NewReader(conn)
+	header, body, err := readPacket(reader)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read CONNACK: %w", err)
+	}
+	if header != packetConnAck || len(body) != 2 {
+		conn.Close()
+		return fmt.Errorf("unexpected packet 0x%02x instead of CONNACK", header)
+	}
+	if code := body[1]; code != 0 {
+		conn.Close()
+		if reason, ok := connAckErrors[code]; ok {
+			return fmt.Errorf("broker refused connection: %s", reason)
+		}
+		return fmt.Errorf("broker refused connection with code %d", code)
+	}
+	conn.SetDeadline(time.Time{})
+
+	c.conn = conn
+	go c.readLoop(conn, reader)
+	go c.pingLoop(conn)
+
+	for _, msg := range c.cfg.Birth {
+		data, err := publishPacket(msg)
+		if err != nil {
+			return err
+		}
+		if err := c.write(data); err != nil {
+			c.closeConn()
+			return fmt.Errorf("failed to publish birth message: %w", err)
+		}
+	}
+	return nil
+}
+
+// write sends a packet on the open connection. The caller holds mu.
+func (c *Client) write(data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// closeConn closes the open connection. The caller holds mu.
+func (c *Client) closeConn() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// readLoop discards the packets of the broker, which only sends ping
+// responses to a QoS 0 publisher, and drops the connection when it fails
+func (c *Client) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		if _, _, err := readPacket(reader); err != nil {
+			break
+		}
+	}
+	c.mu.Lock()
+	if c.conn == conn {
+		c.closeConn()
+	}
+	c.mu.Unlock()
+}
+
+// pingLoop keeps an idle connection alive until it is replaced or closed
+func (c *Client) pingLoop(conn net.Conn) {
+	ticker := time.NewTicker(c.cfg.KeepAlive / 2)
+	defer ticker.Stop()
+
+	ping, _ := packet(packetPingReq, nil)
+	for range ticker.C {
+		c.mu.Lock()
+		if c.conn != conn {
+			c.mu.Unlock()
+			return
+		}
+		if err := c.write(ping); err != nil {
+			c.closeConn()
+		}
+		c.mu.Unlock()
+	}
+}
+
+// dial opens a TCP or TLS connection to a broker address
+func dial(ctx context.Context, broker string) (net.Conn, error) {
+	address, useTLS, err := parseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: writeTimeout}
+	if useTLS {
+		return (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", address)
+	}
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// parseBroker splits the scheme off a broker address and adds the default
+// port of the scheme
+func parseBroker(broker string) (string, bool, error) {
+	address, useTLS := broker, false
+	if scheme, rest, ok := strings.Cut(broker, "://"); ok {
+		switch scheme {
+		case "tcp", "mqtt":
+		case "ssl", "tls", "mqtts":
+			useTLS = true
+		default:
+			return "", false, fmt.Errorf("unsupported broker scheme %q", scheme)
+		}
+		address = rest
+	}
+	if address == "" {
+		return "", false, errors.New("broker address is empty")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		address = net.JoinHostPort(address, port)
+	}
+	return address, useTLS, nil
+}
@@ -0,0 +1,194 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeBroker accepts connections and reports the packets it receives
+type fakeBroker struct {
+	listener net.Listener
+	packets  chan brokerPacket
+	conns    chan net.Conn
+}
+
+type brokerPacket struct {
+	header byte
+	body   []byte
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Skipping test that requires a local listener: %v", err)
+	}
+	b := &fakeBroker{listener: listener, packets: make(chan brokerPacket, 100), conns: make(chan net.Conn, 10)}
+	go b.serve()
+	t.Cleanup(func() { listener.Close() })
+	return b
+}
+
+func (b *fakeBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.conns <- conn
+		go func() {
+			reader := bufio.NewReader(conn)
+			for {
+				header, body, err := readPacket(reader)
+				if err != nil {
+					return
+				}
+				if header == packetConnect {
+					conn.Write([]byte{packetConnAck, 2, 0, 0})
+				}
+				b.packets <- brokerPacket{header: header, body: body}
+			}
+		}()
+	}
+}
+
+func (b *fakeBroker) next(t *testing.T) brokerPacket {
+	t.Helper()
+	select {
+	case p := <-b.packets:
+		return p
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for packet")
+		return brokerPacket{}
+	}
+}
+
+// publishTopic decodes the topic and payload of a PUBLISH body
+func publishTopic(body []byte) (string, string) {
+	n := binary.BigEndian.Uint16(body)
+	return string(body[2 : 2+n]), string(body[2+n:])
+}
+
+func TestClient_Publish(t *testing.T) {
+	broker := newFakeBroker(t)
+	client := NewClient(Config{
+		Broker:   "tcp://" + broker.listener.Addr().String(),
+		ClientID: "weathermaestro",
+		Username: "user",
+		Password: "secret",
+		Will:     &Message{Topic: "wm/status", Payload: []byte(Offline), Retain: true},
+		Birth:    []Message{{Topic: "wm/status", Payload: []byte(Online), Retain: true}},
+	})
+	defer client.Close()
+
+	if err := client.Publish(context.Background(), StateMessage("wm/frost", true)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	connect := broker.next(t)
+	if connect.header != packetConnect {
+		t.Fatalf("expected CONNECT, got 0x%02x", connect.header)
+	}
+	// Protocol name (6 bytes), level, then the flags
+	flags := connect.body[7]
+	expected := flagCleanSession | flagWill | flagWillRetain | flagUsername | flagPassword
+	if flags != expected {
+		t.Errorf("CONNECT flags = 0x%02x, want 0x%02x", flags, expected)
+	}
+
+	for _, want := range []struct{ topic, payload string }{{"wm/status", Online}, {"wm/frost", StateOn}} {
+		p := broker.next(t)
+		if p.header != packetPublish|0x01 {
+			t.Fatalf("expected retained PUBLISH, got 0x%02x", p.header)
+		}
+		if topic, payload := publishTopic(p.body); topic != want.topic || payload != want.payload {
+			t.Errorf("published %s=%s, want %s=%s", topic, payload, want.topic, want.payload)
+		}
+	}
+
+	// A lost connection is replaced on the next publish
+	conn := <-broker.conns
+	conn.Close()
+	time.Sleep(100 * time.Millisecond)
+	if err := client.Publish(context.Background(), StateMessage("wm/frost", false)); err != nil {
+		t.Fatalf("Publish() after lost connection error = %v", err)
+	}
+	// The new connection sends CONNECT and the birth message again
+	for _, want := range []byte{packetConnect, packetPublish | 0x01, packetPublish | 0x01} {
+		if p := broker.next(t); p.header != want {
+			t.Fatalf("expected packet 0x%02x after reconnect, got 0x%02x", want, p.header)
+		}
+	}
+}
+
+func TestClient_ConnectRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Skipping test that requires a local listener: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		readPacket(bufio.NewReader(conn))
+		conn.Write([]byte{packetConnAck, 2, 0, 4})
+	}()
+
+	client := NewClient(Config{Broker: listener.Addr().String(), ClientID: "weathermaestro"})
+	err = client.Publish(context.Background(), Message{Topic: "wm/test"})
+	if err == nil || err.Error() != "broker refused connection: bad user name or password" {
+		t.Errorf("Publish() error = %v", err)
+	}
+}
+
+func TestParseBroker(t *testing.T) {
+	testCases := []struct {
+		broker  string
+		address string
+		useTLS  bool
+		wantErr bool
+	}{
+		{broker: "localhost", address: "localhost:1883"},
+		{broker: "tcp://broker:1884", address: "broker:1884"},
+		{broker: "ssl://broker", address: "broker:8883", useTLS: true},
+		{broker: "mqtts://broker:443", address: "broker:443", useTLS: true},
+		{broker: "ws://broker", wantErr: true},
+		{broker: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.broker, func(t *testing.T) {
+			address, useTLS, err := parseBroker(tc.broker)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseBroker() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if address != tc.address || useTLS != tc.useTLS {
+				t.Errorf("parseBroker() = %s, %v, want %s, %v", address, useTLS, tc.address, tc.useTLS)
+			}
+		})
+	}
+}
+
+func TestPacket_RemainingLength(t *testing.T) {
+	data, err := packet(packetPublish, make([]byte, 321))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 321 = 0x41 + 2*128
+	if data[1] != 0xC1 || data[2] != 0x02 || len(data) != 3+321 {
+		t.Errorf("unexpected header % x", data[:3])
+	}
+
+	header, body, err := readPacket(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil || header != packetPublish || len(body) != 321 {
+		t.Errorf("readPacket() = 0x%02x, %d bytes, %v", header, len(body), err)
+	}
+}
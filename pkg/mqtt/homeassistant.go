@@ -0,0 +1,68 @@
+package mqtt
+
+import "encoding/json"
+
+// Payloads understood by Home Assistant
+const (
+	StateOn  = "ON"
+	StateOff = "OFF"
+	Online   = "online"
+	Offline  = "offline"
+)
+
+// DefaultDiscoveryPrefix is the topic prefix Home Assistant discovers
+// entities under
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// Device groups entities in Home Assistant, e.g. all alerts of a station
+type Device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+}
+
+// BinarySensor is the discovery config of a Home Assistant binary sensor
+type BinarySensor struct {
+	Name              string `json:"name"`
+	UniqueID          string `json:"unique_id"`
+	StateTopic        string `json:"state_topic"`
+	PayloadOn         string `json:"payload_on"`
+	PayloadOff        string `json:"payload_off"`
+	DeviceClass       string `json:"device_class,omitempty"`
+	AvailabilityTopic string `json:"availability_topic,omitempty"`
+	// JSONAttributesTopic holds additional state, e.g. the last value
+	JSONAttributesTopic string `json:"json_attributes_topic,omitempty"`
+	Device              Device `json:"device"`
+}
+
+// DiscoveryTopic returns the config topic of an entity. The node ID groups
+// the entities of one publisher.
+func DiscoveryTopic(prefix, component, nodeID, objectID string) string {
+	return prefix + "/" + component + "/" + nodeID + "/" + objectID + "/config"
+}
+
+// DiscoveryMessage returns the retained message announcing the binary
+// sensor at topic
+func (s BinarySensor) DiscoveryMessage(topic string) (Message, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Topic: topic, Payload: payload, Retain: true}, nil
+}
+
+// RemovalMessage returns the message removing the entity of a config topic
+// from Home Assistant
+func RemovalMessage(topic string) Message {
+	return Message{Topic: topic, Payload: []byte{}, Retain: true}
+}
+
+// StateMessage returns the retained ON or OFF state of a binary sensor
+func StateMessage(topic string, on bool) Message {
+	state := StateOff
+	if on {
+		state = StateOn
+	}
+	return Message{Topic: topic, Payload: []byte(state), Retain: true}
+}
@@ -0,0 +1,47 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBinarySensor_DiscoveryMessage(t *testing.T) {
+	sensor := BinarySensor{
+		Name:        "Frost warning",
+		UniqueID:    "weathermaestro_1_frost_warning",
+		StateTopic:  "weathermaestro/1/alerts/frost_warning",
+		PayloadOn:   StateOn,
+		PayloadOff:  StateOff,
+		DeviceClass: "cold",
+		Device:      Device{Identifiers: []string{"weathermaestro_1"}, Name: "Garden"},
+	}
+	topic := DiscoveryTopic(DefaultDiscoveryPrefix, "binary_sensor", "weathermaestro_1", "frost_warning")
+	if topic != "homeassistant/binary_sensor/weathermaestro_1/frost_warning/config" {
+		t.Errorf("unexpected topic %s", topic)
+	}
+
+	msg, err := sensor.DiscoveryMessage(topic)
+	if err != nil {
+		t.Fatalf("DiscoveryMessage() error = %v", err)
+	}
+	if !msg.Retain || msg.Topic != topic {
+		t.Errorf("expected retained message on %s, got %+v", topic, msg)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &decoded); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if decoded["device_class"] != "cold" || decoded["state_topic"] != sensor.StateTopic {
+		t.Errorf("unexpected payload %s", msg.Payload)
+	}
+	if _, ok := decoded["availability_topic"]; ok {
+		t.Error("expected empty availability topic to be left out")
+	}
+
+	if removal := RemovalMessage(topic); len(removal.Payload) != 0 || !removal.Retain {
+		t.Errorf("unexpected removal message %+v", removal)
+	}
+	if state := StateMessage(sensor.StateTopic, false); string(state.Payload) != StateOff {
+		t.Errorf("unexpected state %s", state.Payload)
+	}
+}
@@ -0,0 +1,151 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the first header byte
+const (
+	packetConnect    byte = 0x10
+	packetConnAck    byte = 0x20
+	packetPublish    byte = 0x30
+	packetPingReq    byte = 0xC0
+	packetPingResp   byte = 0xD0
+	packetDisconnect byte = 0xE0
+)
+
+// CONNECT flags
+const (
+	flagCleanSession byte = 0x02
+	flagWill         byte = 0x04
+	flagWillRetain   byte = 0x20
+	flagPassword     byte = 0x40
+	flagUsername     byte = 0x80
+)
+
+// maxRemainingLength is the largest packet body MQTT can encode
+const maxRemainingLength = 268435455
+
+// connAckErrors describes the return codes of a refused connection
+var connAckErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// appendBytes appends length-prefixed binary data
+func appendBytes(b []byte, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+// packet builds a control packet from its first header byte and body
+func packet(header byte, body []byte) ([]byte, error) {
+	if len(body) > maxRemainingLength {
+		return nil, fmt.Errorf("packet of %d bytes exceeds the MQTT limit", len(body))
+	}
+	b := []byte{header}
+	// The remaining length is encoded in 7 bit groups, least significant first
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...), nil
+}
+
+// connectPacket builds the CONNECT packet of a client
+func connectPacket(cfg Config) ([]byte, error) {
+	flags := flagCleanSession
+	if cfg.Will != nil {
+		flags |= flagWill
+		if cfg.Will.Retain {
+			flags |= flagWillRetain
+		}
+	}
+	if cfg.Username != "" {
+		flags |= flagUsername
+		if cfg.Password != "" {
+			flags |= flagPassword
+		}
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(cfg.KeepAlive.Seconds()))
+	body = appendString(body, cfg.ClientID)
+	if cfg.Will != nil {
+		body = appendString(body, cfg.Will.Topic)
+		body = appendBytes(body, cfg.Will.Payload)
+	}
+	if cfg.Username != "" {
+		body = appendString(body, cfg.Username)
+		if cfg.Password != "" {
+			body = appendString(body, cfg.Password)
+		}
+	}
+	return packet(packetConnect, body)
+}
+
+// publishPacket builds a PUBLISH packet with QoS 0
+func publishPacket(msg Message) ([]byte, error) {
+	if msg.Topic == "" {
+		return nil, errors.New("topic must not be empty")
+	}
+	header := packetPublish
+	if msg.Retain {
+		header |= 0x01
+	}
+	body := appendString(nil, msg.Topic)
+	return packet(header, append(body, msg.Payload...))
+}
+
+// readPacket reads a control packet and returns its first header byte and
+// body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelEmail is the name of the email channel
+const ChannelEmail = "email"
+
+// EmailConfig configures the SMTP server used by the email channel
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// EmailChannel sends messages by SMTP. Port 465 uses implicit TLS, other
+// ports upgrade with STARTTLS when the server supports it.
+type EmailChannel struct {
+	config EmailConfig
+	// tlsConfig is used for tests with self-signed certificates
+	tlsConfig *tls.Config
+}
+
+// NewEmailChannel creates a new EmailChannel
+func NewEmailChannel(config EmailConfig) (*EmailChannel, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, errors.New("email channel requires host and sender address")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &EmailChannel{config: config, tlsConfig: &tls.Config{ServerName: config.Host}}, nil
+}
+
+// Name returns the channel name
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+// Send delivers the message to its recipients
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if c.config.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: c.tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && c.config.Port != 465 {
+		if err := client.StartTLS(c.tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if c.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(c.config.From); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(c.buildMessage(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage formats the message as plain text mail
+func (c *EmailChannel) buildMessage(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts one mail without TLS or authentication and
+// returns the received data
+func fakeSMTPServer(t *testing.T) (int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 Go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 Queued")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				received <- data.String()
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+	channel, err := NewEmailChannel(EmailConfig{Host: "127.0.0.1", Port: port, From: "weather@example.com"})
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	msg := Message{To: []string{"admin@example.com"}, Subject: "Password reset", Body: "Line 1\nLine 2"}
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	data := <-received
+	for _, expected := range []string{"To: admin@example.com\r\n", "Subject: Password reset\r\n", "Line 1\r\nLine 2\r\n"} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("Expected %q in mail:\n%s", expected, data)
+		}
+	}
+}
+
+func TestEmailChannel_Config(t *testing.T) {
+	if _, err := NewEmailChannel(EmailConfig{Host: "smtp.example.com"}); err == nil {
+		t.Error("Expected error without sender address")
+	}
+
+	channel, err := NewEmailChannel(EmailConfig{Host: "smtp.example.com", From: "a@example.com"})
+	if err != nil || channel.config.Port != 587 {
+		t.Errorf("Expected default port 587, got %s, %v", strconv.Itoa(channel.config.Port), err)
+	}
+	if err := channel.Send(context.Background(), Message{}); err == nil {
+		t.Error("Expected error without recipients")
+	}
+}
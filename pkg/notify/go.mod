@@ -0,0 +1,3 @@
+module github.com/sguter90/weathermaestro/pkg/notify
+
+go 1.25
@@ -0,0 +1,75 @@
+// Package notify delivers messages to users through channels like email
+// or webhooks.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNoChannel is returned when a message is sent to a channel that is not
+// configured
+var ErrNoChannel = errors.New("notification channel not configured")
+
+// Message is a notification sent through a channel
+type Message struct {
+	// To lists the recipients; channels without addressing ignore it
+	To      []string `json:"to,omitempty"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+// Channel delivers messages
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Dispatcher routes messages to the configured channels by name
+type Dispatcher struct {
+	channels map[string]Channel
+}
+
+// NewDispatcher creates a dispatcher for the given channels
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	d := &Dispatcher{channels: make(map[string]Channel)}
+	for _, c := range channels {
+		d.channels[c.Name()] = c
+	}
+	return d
+}
+
+// Has reports whether a channel is configured
+func (d *Dispatcher) Has(name string) bool {
+	if d == nil {
+		return false
+	}
+	_, ok := d.channels[name]
+	return ok
+}
+
+// Channels returns the names of the configured channels
+func (d *Dispatcher) Channels() []string {
+	if d == nil {
+		return nil
+	}
+	names := make([]string, 0, len(d.channels))
+	for name := range d.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Send delivers a message through the named channel
+func (d *Dispatcher) Send(ctx context.Context, channel string, msg Message) error {
+	if !d.Has(channel) {
+		return fmt.Errorf("%s: %w", channel, ErrNoChannel)
+	}
+	if err := d.channels[channel].Send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingChannel struct {
+	messages []Message
+}
+
+func (c *recordingChannel) Name() string { return "test" }
+
+func (c *recordingChannel) Send(ctx context.Context, msg Message) error {
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+func TestDispatcher_Send(t *testing.T) {
+	channel := &recordingChannel{}
+	d := NewDispatcher(channel)
+
+	if err := d.Send(context.Background(), "test", Message{Subject: "Hello"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if len(channel.messages) != 1 || channel.messages[0].Subject != "Hello" {
+		t.Errorf("Unexpected messages %v", channel.messages)
+	}
+
+	if err := d.Send(context.Background(), ChannelEmail, Message{}); !errors.Is(err, ErrNoChannel) {
+		t.Errorf("Expected ErrNoChannel, got %v", err)
+	}
+
+	var nilDispatcher *Dispatcher
+	if nilDispatcher.Has("test") || len(nilDispatcher.Channels()) != 0 {
+		t.Error("Expected nil dispatcher without channels")
+	}
+}
+
+func TestWebhookChannel_Send(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+	}))
+	defer server.Close()
+
+	msg := Message{Subject: "Frost", Body: "-2.5 °C"}
+	if err := NewWebhookChannel(server.URL).Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if received.Subject != msg.Subject || received.Body != msg.Body {
+		t.Errorf("Unexpected message %+v", received)
+	}
+}
+
+func TestWebhookChannel_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookChannel(server.URL).Send(context.Background(), Message{}); err == nil {
+		t.Error("Expected error for failed request")
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template customizes the subject and body of messages with Go templates.
+// Empty parts keep the text of the message they are applied to.
+type Template struct {
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+// IsZero reports whether the template changes nothing
+func (t Template) IsZero() bool {
+	return t.Subject == "" && t.Body == ""
+}
+
+// Or returns the template with its empty parts taken from fallback
+func (t Template) Or(fallback Template) Template {
+	if t.Subject == "" {
+		t.Subject = fallback.Subject
+	}
+	if t.Body == "" {
+		t.Body = fallback.Body
+	}
+	return t
+}
+
+// Validate parses both parts with the functions available when rendering
+func (t Template) Validate(funcs template.FuncMap) error {
+	if _, err := parse("subject", t.Subject, funcs); err != nil {
+		return err
+	}
+	_, err := parse("body", t.Body, funcs)
+	return err
+}
+
+// Render executes the template with data and returns msg with the
+// rendered parts. Line breaks of the subject are replaced by spaces, they
+// are not allowed in email headers.
+func (t Template) Render(msg Message, data interface{}, funcs template.FuncMap) (Message, error) {
+	subject, err := execute("subject", t.Subject, data, funcs)
+	if err != nil {
+		return msg, err
+	}
+	body, err := execute("body", t.Body, data, funcs)
+	if err != nil {
+		return msg, err
+	}
+	if t.Subject != "" {
+		msg.Subject = strings.Join(strings.Fields(subject), " ")
+	}
+	if t.Body != "" {
+		msg.Body = body
+	}
+	return msg, nil
+}
+
+func parse(name, text string, funcs template.FuncMap) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func execute(name, text string, data interface{}, funcs template.FuncMap) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := parse(name, text, funcs)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	data := map[string]interface{}{"Station": "Garden", "Value": -1.5}
+	msg := Message{To: []string{"me@example.com"}, Subject: "Default", Body: "Default body"}
+
+	rendered, err := Template{Subject: "{{upper .Station}}\n{{.Value}} °C"}.Render(msg, data, funcs)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if rendered.Subject != "GARDEN -1.5 °C" || rendered.Body != "Default body" || len(rendered.To) != 1 {
+		t.Errorf("Unexpected message %+v", rendered)
+	}
+
+	if _, err := (Template{Body: "{{.Missing}}"}).Render(msg, data, funcs); err == nil {
+		t.Error("Expected error for a missing key")
+	}
+	if err := (Template{Subject: "{{lower .Station}}"}).Validate(funcs); err == nil {
+		t.Error("Expected error for an unknown function")
+	}
+}
+
+func TestTemplate_Or(t *testing.T) {
+	merged := Template{Body: "rule"}.Or(Template{Subject: "channel", Body: "channel"})
+	if merged.Subject != "channel" || merged.Body != "rule" {
+		t.Errorf("Unexpected template %+v", merged)
+	}
+	if !(Template{}).IsZero() || merged.IsZero() {
+		t.Error("Unexpected IsZero")
+	}
+}
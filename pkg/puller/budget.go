@@ -0,0 +1,144 @@
+package puller
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxStretch limits how far the pull interval is stretched when a quota
+// runs low
+const maxStretch = 10
+
+// RateLimited is implemented by pullers of APIs with a request quota.
+// Stations with the same quota key share one budget.
+type RateLimited interface {
+	// RateLimit returns the number of requests allowed per period
+	RateLimit() (requests int, period time.Duration)
+
+	// QuotaKey identifies the account or app a quota belongs to
+	QuotaKey(config map[string]interface{}) string
+}
+
+// Budget is a token bucket of API requests that refills continuously up to
+// the quota of a provider
+type Budget struct {
+	mu       sync.Mutex
+	limit    int
+	period   time.Duration
+	tokens   float64
+	updated  time.Time
+	spent    uint64
+	denied   uint64
+	timeFunc func() time.Time
+}
+
+// BudgetMetrics is a snapshot of a budget
+type BudgetMetrics struct {
+	Provider      string  `json:"provider"`
+	QuotaKey      string  `json:"quota_key"`
+	Limit         int     `json:"limit"`
+	PeriodSeconds float64 `json:"period_seconds"`
+	Remaining     int     `json:"remaining"`
+	Spent         uint64  `json:"spent"`
+	Denied        uint64  `json:"denied"`
+	// IntervalStretch is the factor applied to the pull interval
+	IntervalStretch float64 `json:"interval_stretch"`
+}
+
+// NewBudget creates a full budget of requests per period
+func NewBudget(requests int, period time.Duration) *Budget {
+	return &Budget{
+		limit:    requests,
+		period:   period,
+		tokens:   float64(requests),
+		updated:  time.Now(),
+		timeFunc: time.Now,
+	}
+}
+
+// refill adds the tokens accrued since the last update. Callers hold mu.
+func (b *Budget) refill() {
+	now := b.timeFunc()
+	elapsed := now.Sub(b.updated)
+	b.updated = now
+	if elapsed <= 0 || b.period <= 0 {
+		return
+	}
+	b.tokens = math.Min(float64(b.limit), b.tokens+float64(b.limit)*elapsed.Seconds()/b.period.Seconds())
+}
+
+// Take consumes n requests and reports whether the budget allowed them
+func (b *Budget) Take(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens < float64(n) {
+		b.denied++
+		return false
+	}
+	b.tokens -= float64(n)
+	b.spent += uint64(n)
+	return true
+}
+
+// Remaining returns the number of requests currently available
+func (b *Budget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	return int(b.tokens)
+}
+
+// Stretch returns the factor for the pull interval. It is 1 while at least
+// half of the quota is left and grows up to maxStretch as it runs out.
+func (b *Budget) Stretch() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.limit <= 0 {
+		return 1
+	}
+	fraction := b.tokens / float64(b.limit)
+	if fraction >= 0.5 {
+		return 1
+	}
+	return math.Min(maxStretch, 0.5/math.Max(fraction, 0.5/maxStretch))
+}
+
+// Metrics returns a snapshot of the budget
+func (b *Budget) Metrics() BudgetMetrics {
+	stretch := b.Stretch()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BudgetMetrics{
+		Limit:           b.limit,
+		PeriodSeconds:   b.period.Seconds(),
+		Remaining:       int(b.tokens),
+		Spent:           b.spent,
+		Denied:          b.denied,
+		IntervalStretch: stretch,
+	}
+}
+
+type budgetContextKey struct{}
+
+// WithBudget returns a context whose requests are counted against b
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetContextKey{}, b)
+}
+
+// SpendRequest takes one request from the budget of ctx and returns
+// ErrRateLimited when it is exhausted. Contexts without budget always pass.
+func SpendRequest(ctx context.Context) error {
+	b, ok := ctx.Value(budgetContextKey{}).(*Budget)
+	if !ok || b.Take(1) {
+		return nil
+	}
+	return ErrRateLimited
+}
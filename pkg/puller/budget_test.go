@@ -0,0 +1,116 @@
+package puller
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestBudget(requests int, period time.Duration) (*Budget, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBudget(requests, period)
+	b.updated = now
+	b.timeFunc = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBudget_TakeAndRefill(t *testing.T) {
+	b, now := newTestBudget(10, time.Hour)
+
+	for i := 0; i < 10; i++ {
+		if !b.Take(1) {
+			t.Fatalf("Take() denied request %d", i+1)
+		}
+	}
+	if b.Take(1) {
+		t.Error("Take() allowed request beyond the limit")
+	}
+
+	// 6 minutes refill one request of 10 per hour
+	*now = now.Add(6 * time.Minute)
+	if got := b.Remaining(); got != 1 {
+		t.Errorf("Remaining() = %d, want 1", got)
+	}
+
+	// The bucket never exceeds its limit
+	*now = now.Add(5 * time.Hour)
+	if got := b.Remaining(); got != 10 {
+		t.Errorf("Remaining() = %d, want 10", got)
+	}
+
+	m := b.Metrics()
+	if m.Spent != 10 || m.Denied != 1 || m.Limit != 10 || m.PeriodSeconds != 3600 {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestBudget_Stretch(t *testing.T) {
+	b, _ := newTestBudget(100, time.Hour)
+
+	tests := []struct {
+		take    int
+		stretch float64
+	}{
+		{0, 1},
+		{50, 1},
+		{25, 2},
+		{25, maxStretch},
+	}
+	for _, tt := range tests {
+		b.Take(tt.take)
+		if got := b.Stretch(); got != tt.stretch {
+			t.Errorf("Stretch() with %d left = %v, want %v", b.Remaining(), got, tt.stretch)
+		}
+	}
+}
+
+func TestSpendRequest(t *testing.T) {
+	if err := SpendRequest(context.Background()); err != nil {
+		t.Errorf("SpendRequest() without budget = %v", err)
+	}
+
+	b, _ := newTestBudget(1, time.Hour)
+	ctx := WithBudget(context.Background(), b)
+	if err := SpendRequest(ctx); err != nil {
+		t.Errorf("SpendRequest() = %v", err)
+	}
+	if err := SpendRequest(ctx); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+}
+
+// limitedPuller is a MockPuller with a request quota per client_id
+type limitedPuller struct {
+	*MockPuller
+}
+
+func (p *limitedPuller) RateLimit() (int, time.Duration) { return 100, time.Hour }
+
+func (p *limitedPuller) QuotaKey(config map[string]interface{}) string {
+	key, _ := config["client_id"].(string)
+	return key
+}
+
+func TestPullerService_BudgetsSharedPerQuotaKey(t *testing.T) {
+	ps := NewPullerService(nil, NewPullerRegistry(), nil, time.Minute)
+	p := &limitedPuller{MockPuller: &MockPuller{providerType: "mock"}}
+
+	a := ps.budgetFor(p, "mock", map[string]interface{}{"client_id": "app-a"})
+	if ps.budgetFor(p, "mock", map[string]interface{}{"client_id": "app-a"}) != a {
+		t.Error("Expected stations of the same app to share a budget")
+	}
+	if ps.budgetFor(p, "mock", map[string]interface{}{"client_id": "app-b"}) == a {
+		t.Error("Expected separate budgets per app")
+	}
+
+	a.Take(90)
+	metrics := ps.Budgets()
+	if len(metrics) != 2 {
+		t.Fatalf("Budgets() returned %d entries, want 2", len(metrics))
+	}
+	if metrics[0].QuotaKey != "app-a" || metrics[0].Remaining != 10 || math.Round(metrics[0].IntervalStretch) != 5 {
+		t.Errorf("unexpected metrics: %+v", metrics[0])
+	}
+}
@@ -0,0 +1,120 @@
+package davis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Data structure types of the conditions of a WeatherLink Live
+const (
+	structureISS       = 1
+	structureSoilLeaf  = 2
+	structureBarometer = 3
+	structureInside    = 4
+)
+
+// Client reads the current conditions of a WeatherLink Live on the LAN
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a client for the WeatherLink Live at host, e.g.
+// "192.168.1.50" or "http://weatherlink.local:80"
+func NewClient(host string) *Client {
+	baseURL := strings.TrimSuffix(host, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL: baseURL,
+	}
+}
+
+// Conditions are the current conditions of all transmitters and of the
+// WeatherLink Live itself
+type Conditions struct {
+	DeviceID string `json:"did"`
+	// TS is the time of the conditions in unix seconds
+	TS         int64       `json:"ts"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// Time returns the time of the conditions
+func (c Conditions) Time() time.Time {
+	return time.Unix(c.TS, 0).UTC()
+}
+
+// Condition holds the values of one data structure by field name, e.g.
+// "temp" or "rain_size". Missing values are null in the API and left out.
+type Condition map[string]float64
+
+// UnmarshalJSON decodes a condition, skipping null values
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var raw map[string]*float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = make(Condition, len(raw))
+	for key, value := range raw {
+		if value != nil {
+			(*c)[key] = *value
+		}
+	}
+	return nil
+}
+
+// Type returns the data structure type of the condition
+func (c Condition) Type() int {
+	return int(c["data_structure_type"])
+}
+
+// TxID returns the transmitter ID of ISS and soil/leaf conditions
+func (c Condition) TxID() int {
+	return int(c["txid"])
+}
+
+// CurrentConditions returns the current conditions of the WeatherLink Live
+func (c *Client) CurrentConditions(ctx context.Context) (*Conditions, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/current_conditions", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current conditions: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("WeatherLink Live returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data  *Conditions `json:"data"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse current conditions: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("WeatherLink Live returned error %d: %s", result.Error.Code, result.Error.Message)
+	}
+	if result.Data == nil {
+		return nil, fmt.Errorf("WeatherLink Live returned no conditions")
+	}
+	return result.Data, nil
+}
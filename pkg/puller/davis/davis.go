@@ -0,0 +1,406 @@
+package davis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+)
+
+const (
+	// ServiceName is the service of Davis WeatherLink Live devices
+	ServiceName = "davis"
+
+	// StationType is the station type of Davis WeatherLink Live devices
+	StationType = "WeatherLinkLive"
+
+	// HostConfigKey is the address of the WeatherLink Live on the LAN. It
+	// identifies the station.
+	HostConfigKey = "weatherlink_host"
+
+	// TransmittersConfigKey lists the transmitters to store
+	TransmittersConfigKey = "transmitters"
+
+	// defaultPullInterval matches the tick of the puller service
+	defaultPullInterval = 60
+
+	// pullSlack absorbs the jitter of the ticks, so an interval matching
+	// the tick doesn't skip every other tick
+	pullSlack = 5 * time.Second
+)
+
+// Sensor types of soil/leaf stations. They are not built in; define them as
+// custom sensor types of the station to set bounds and a category.
+const (
+	SensorTypeSoilMoisture = "SoilMoisture"
+	SensorTypeLeafWetness  = "LeafWetness"
+)
+
+// customUnits are the units of the sensor types that are not built in
+var customUnits = map[string]string{
+	SensorTypeSoilMoisture: "cb",
+	SensorTypeLeafWetness:  "",
+}
+
+// Transmitter selects a transmitter of the WeatherLink Live and sets the
+// location of its sensors
+type Transmitter struct {
+	ID       int    `json:"id"`
+	Location string `json:"location,omitempty"`
+}
+
+// Puller polls the current conditions of a WeatherLink Live on the LAN and
+// stores the values of its ISS, soil/leaf and console sensors
+type Puller struct {
+	dbManager *database.DatabaseManager
+
+	// Conditions are stored once per timestamp and the device is asked at
+	// most every pull_interval. Pulls run one at a time, so no locking is
+	// needed.
+	lastFetch map[string]time.Time
+	lastTS    map[string]int64
+}
+
+// NewPuller creates a new WeatherLink Live puller with database connection
+func NewPuller(dbManager *database.DatabaseManager) *Puller {
+	return &Puller{
+		dbManager: dbManager,
+		lastFetch: make(map[string]time.Time),
+		lastTS:    make(map[string]int64),
+	}
+}
+
+func (p *Puller) GetProviderType() string {
+	return ServiceName
+}
+
+// ConfigSchema returns the address of the device, the polling interval and
+// the transmitters to store
+func (p *Puller) ConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema().
+		Add(HostConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Host name or IP address of the WeatherLink Live, e.g. 192.168.1.50",
+		}).
+		Add("pull_interval", models.ConfigProperty{
+			Type:        models.ConfigTypeInteger,
+			Description: "Seconds between pulls",
+			Default:     defaultPullInterval,
+			Minimum:     models.ConfigLimit(60),
+		}).
+		Add(TransmittersConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeArray,
+			Description: `Transmitters to store, e.g. [{"id": 1}, {"id": 3, "location": "Garden"}]; all if empty`,
+		}).
+		Require(HostConfigKey)
+}
+
+// SensorTypes returns the sensor types of WeatherLink Live conditions
+func (p *Puller) SensorTypes() []string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, fields := range structureFields {
+		for _, f := range fields {
+			if !seen[f.sensorType] {
+				seen[f.sensorType] = true
+				types = append(types, f.sensorType)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (p *Puller) ValidateConfig(config map[string]interface{}) error {
+	if err := p.ConfigSchema().Validate(config).Err(); err != nil {
+		return err
+	}
+	if _, err := ParseTransmitters(config[TransmittersConfigKey]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Puller) Pull(ctx context.Context, config map[string]interface{}) (map[string]models.SensorReading, *models.StationData, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, nil, err
+	}
+	host := config[HostConfigKey].(string)
+	transmitters, _ := ParseTransmitters(config[TransmittersConfigKey])
+
+	stationID, err := p.dbManager.GetStationIDByConfigValue(HostConfigKey, host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load station ID: %w", err)
+	}
+	stationData := &models.StationData{ID: stationID, StationType: StationType}
+
+	if time.Since(p.lastFetch[host]) < pullInterval(config)-pullSlack {
+		return nil, stationData, nil
+	}
+	p.lastFetch[host] = time.Now()
+
+	conditions, err := NewClient(host).CurrentConditions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conditions.TS <= p.lastTS[host] {
+		return nil, stationData, nil
+	}
+
+	values := conditions.values(transmitters)
+	sensors, err := p.dbManager.EnsureSensorsByRemoteId(stationID, valueSensors(values))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to ensure sensors: %w", err)
+	}
+
+	readings := make(map[string]models.SensorReading, len(values))
+	for id, v := range values {
+		sensor, ok := sensors[id]
+		if !ok {
+			continue
+		}
+		reading := v.Reading
+		reading.SensorID = sensor.ID
+		readings[id] = reading
+	}
+	p.lastTS[host] = conditions.TS
+	return readings, stationData, nil
+}
+
+// Test reads the current conditions of the device without storing anything
+func (p *Puller) Test(ctx context.Context, config map[string]interface{}) (*puller.TestResult, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	transmitters, _ := ParseTransmitters(config[TransmittersConfigKey])
+
+	conditions, err := NewClient(config[HostConfigKey].(string)).CurrentConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := conditions.values(transmitters)
+	result := &puller.TestResult{
+		Sensors:  valueSensors(values),
+		Readings: make(map[string][]models.SensorReading, len(values)),
+	}
+	for id, v := range values {
+		result.Readings[id] = []models.SensorReading{v.Reading}
+	}
+	return result, nil
+}
+
+// ParseTransmitters parses the transmitters of the config. Nil selects all
+// transmitters.
+func ParseTransmitters(value interface{}) ([]Transmitter, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var transmitters []Transmitter
+	if err := json.Unmarshal(data, &transmitters); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TransmittersConfigKey, err)
+	}
+	seen := make(map[int]bool, len(transmitters))
+	for _, t := range transmitters {
+		if t.ID < 1 || t.ID > 8 {
+			return nil, fmt.Errorf("%s: transmitter ID %d must be between 1 and 8", TransmittersConfigKey, t.ID)
+		}
+		if seen[t.ID] {
+			return nil, fmt.Errorf("%s: transmitter %d is listed twice", TransmittersConfigKey, t.ID)
+		}
+		seen[t.ID] = true
+	}
+	return transmitters, nil
+}
+
+// pullInterval returns the configured time between pulls
+func pullInterval(config map[string]interface{}) time.Duration {
+	if seconds, ok := config["pull_interval"].(float64); ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if seconds, ok := config["pull_interval"].(int); ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultPullInterval * time.Second
+}
+
+// field maps a value of a data structure to a sensor
+type field struct {
+	key        string
+	sensorType string
+	name       string
+	// rawUnit is the unit the device reports in, converted to the unit of
+	// the sensor type; empty if the value is stored as is
+	rawUnit string
+}
+
+// Raw units of WeatherLink Live values
+const (
+	unitFahrenheit = "°F"
+	unitMPH        = "mph"
+	unitInHg       = "inHg"
+	// unitCounts are tips of the rain collector, see rainSizes
+	unitCounts = "counts"
+)
+
+// structureFields are the stored fields by data structure type
+var structureFields = map[int][]field{
+	structureISS: {
+		{"temp", models.SensorTypeTemperatureOutdoor, "Outdoor Temperature", unitFahrenheit},
+		{"hum", models.SensorTypeHumidityOutdoor, "Outdoor Humidity", ""},
+		{"wind_speed_last", models.SensorTypeWindSpeed, "Wind Speed", unitMPH},
+		{"wind_dir_last", models.SensorTypeWindDirection, "Wind Direction", ""},
+		{"wind_speed_hi_last_10_min", models.SensorTypeWindGust, "Wind Gust", unitMPH},
+		{"wind_dir_at_hi_speed_last_10_min", models.SensorTypeWindGustAngle, "Wind Gust Direction", ""},
+		{"rain_rate_last", models.SensorTypeRainfallRate, "Rain Rate", unitCounts},
+		{"rainfall_last_60_min", models.SensorTypeRainfallHourly, "Hourly Rain", unitCounts},
+		{"rainfall_daily", models.SensorTypeRainfallDaily, "Daily Rain", unitCounts},
+		{"rainfall_monthly", models.SensorTypeRainfallMonthly, "Monthly Rain", unitCounts},
+		{"rainfall_year", models.SensorTypeRainfallYearly, "Yearly Rain", unitCounts},
+		{"rain_storm", models.SensorTypeRainfallEvent, "Storm Rain", unitCounts},
+		{"solar_rad", models.SensorTypeSolarRadiation, "Solar Radiation", ""},
+		{"uv_index", models.SensorTypeUVIndex, "UV Index", ""},
+	},
+	structureSoilLeaf: {
+		{"temp_1", models.SensorTypeTemperature, "Soil Temperature 1", unitFahrenheit},
+		{"temp_2", models.SensorTypeTemperature, "Soil Temperature 2", unitFahrenheit},
+		{"temp_3", models.SensorTypeTemperature, "Soil Temperature 3", unitFahrenheit},
+		{"temp_4", models.SensorTypeTemperature, "Soil Temperature 4", unitFahrenheit},
+		{"moist_soil_1", SensorTypeSoilMoisture, "Soil Moisture 1", ""},
+		{"moist_soil_2", SensorTypeSoilMoisture, "Soil Moisture 2", ""},
+		{"moist_soil_3", SensorTypeSoilMoisture, "Soil Moisture 3", ""},
+		{"moist_soil_4", SensorTypeSoilMoisture, "Soil Moisture 4", ""},
+		{"wet_leaf_1", SensorTypeLeafWetness, "Leaf Wetness 1", ""},
+		{"wet_leaf_2", SensorTypeLeafWetness, "Leaf Wetness 2", ""},
+	},
+	structureBarometer: {
+		{"bar_sea_level", models.SensorTypePressureRelative, "Relative Pressure", unitInHg},
+		{"bar_absolute", models.SensorTypePressureAbsolute, "Absolute Pressure", unitInHg},
+	},
+	structureInside: {
+		{"temp_in", models.SensorTypeTemperature, "Indoor Temperature", unitFahrenheit},
+		{"hum_in", models.SensorTypeHumidity, "Indoor Humidity", ""},
+	},
+}
+
+// rainSizes are the mm per count of the rain collector sizes
+var rainSizes = map[int]float64{
+	1: 0.254, // 0.01 in
+	2: 0.2,
+	3: 0.1,
+	4: 0.0254, // 0.001 in
+}
+
+// value is a converted value of the conditions with the sensor it belongs to
+type value struct {
+	Sensor  models.Sensor
+	Reading models.SensorReading
+}
+
+// values converts the conditions of the selected transmitters and of the
+// device itself to sensor values keyed by remote ID, e.g. "tx1-temp" or
+// "lss-bar_sea_level". Rain values of collectors of unknown size are
+// skipped.
+func (c Conditions) values(transmitters []Transmitter) map[string]value {
+	locations := make(map[int]string, len(transmitters))
+	for _, t := range transmitters {
+		locations[t.ID] = t.Location
+	}
+
+	values := make(map[string]value)
+	for _, cond := range c.Conditions {
+		prefix, suffix, location := "lss-", "", "Indoor"
+		switch cond.Type() {
+		case structureISS, structureSoilLeaf:
+			txID := cond.TxID()
+			loc, ok := locations[txID]
+			if !ok && len(transmitters) > 0 {
+				continue
+			}
+			prefix = fmt.Sprintf("tx%d-", txID)
+			suffix = fmt.Sprintf(" (TX %d)", txID)
+			location = loc
+			if location == "" {
+				location = "Outdoor"
+			}
+		}
+
+		for _, f := range structureFields[cond.Type()] {
+			raw, ok := cond[f.key]
+			if !ok {
+				continue
+			}
+			converted, ok := convert(raw, f.rawUnit, cond)
+			if !ok {
+				continue
+			}
+
+			reading := models.SensorReading{
+				Value:   converted,
+				Unit:    unit(f.sensorType),
+				DateUTC: c.Time(),
+			}
+			if f.rawUnit != "" {
+				rawValue := raw
+				reading.RawValue = &rawValue
+				reading.RawUnit = f.rawUnit
+			}
+			values[prefix+f.key] = value{
+				Sensor: models.Sensor{
+					SensorType: f.sensorType,
+					Location:   location,
+					Name:       f.name + suffix,
+					Enabled:    true,
+				},
+				Reading: reading,
+			}
+		}
+	}
+	return values
+}
+
+// convert converts a value to the unit of its sensor type
+func convert(v float64, rawUnit string, cond Condition) (float64, bool) {
+	switch rawUnit {
+	case unitFahrenheit:
+		return round2((v - 32) * 5 / 9), true
+	case unitMPH:
+		return round2(v * 0.44704), true
+	case unitInHg:
+		return round2(v * 33.8639), true
+	case unitCounts:
+		size, ok := rainSizes[int(cond["rain_size"])]
+		return round2(v * size), ok
+	}
+	return v, true
+}
+
+// unit returns the unit of a sensor type
+func unit(sensorType string) string {
+	if info, ok := models.SensorTypeRegistry[sensorType]; ok {
+		return info.Unit
+	}
+	return customUnits[sensorType]
+}
+
+// valueSensors returns the sensors of values keyed by remote ID
+func valueSensors(values map[string]value) map[string]models.Sensor {
+	sensors := make(map[string]models.Sensor, len(values))
+	for id, v := range values {
+		sensors[id] = v.Sensor
+	}
+	return sensors
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,187 @@
+package davis
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const testConditions = `{
+	"data": {
+		"did": "001D0A700002",
+		"ts": 1760000400,
+		"conditions": [
+			{"lsid": 48308, "data_structure_type": 1, "txid": 1, "temp": 68.0, "hum": 55.2, "wind_speed_last": 10, "wind_dir_last": null, "wind_speed_hi_last_10_min": 20, "rain_size": 2, "rain_rate_last": 0, "rainfall_daily": 63, "solar_rad": 747, "uv_index": 5.5, "trans_battery_flag": 0},
+			{"lsid": 3187671188, "data_structure_type": 2, "txid": 3, "temp_1": 50.0, "temp_2": null, "moist_soil_1": 12, "wet_leaf_1": 7},
+			{"lsid": 48307, "data_structure_type": 4, "temp_in": 77.0, "hum_in": 41.1},
+			{"lsid": 48306, "data_structure_type": 3, "bar_sea_level": 30.0, "bar_absolute": 29.5}
+		]
+	},
+	"error": null
+}`
+
+func testServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/current_conditions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_CurrentConditions(t *testing.T) {
+	client := NewClient(testServer(t, testConditions).URL)
+
+	conditions, err := client.CurrentConditions(context.Background())
+	if err != nil {
+		t.Fatalf("CurrentConditions() error = %v", err)
+	}
+	if conditions.TS != 1760000400 || len(conditions.Conditions) != 4 {
+		t.Fatalf("CurrentConditions() = %+v", conditions)
+	}
+	if _, ok := conditions.Conditions[0]["wind_dir_last"]; ok {
+		t.Error("null values must be left out")
+	}
+
+	client = NewClient(testServer(t, `{"data": null, "error": {"code": 409, "message": "busy"}}`).URL)
+	if _, err := client.CurrentConditions(context.Background()); err == nil || !strings.Contains(err.Error(), "busy") {
+		t.Errorf("CurrentConditions() error = %v, want the device error", err)
+	}
+}
+
+func TestNewClient_BaseURL(t *testing.T) {
+	tests := map[string]string{
+		"192.168.1.50":               "http://192.168.1.50",
+		"weatherlink.local:8080/":    "http://weatherlink.local:8080",
+		"https://weatherlink.local/": "https://weatherlink.local",
+	}
+	for host, want := range tests {
+		if got := NewClient(host).baseURL; got != want {
+			t.Errorf("NewClient(%q).baseURL = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestConditions_Values(t *testing.T) {
+	conditions, err := NewClient(testServer(t, testConditions).URL).CurrentConditions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := conditions.values(nil)
+	want := map[string]float64{
+		"tx1-temp":                      20,
+		"tx1-hum":                       55.2,
+		"tx1-wind_speed_last":           4.47,
+		"tx1-wind_speed_hi_last_10_min": 8.94,
+		"tx1-rain_rate_last":            0,
+		"tx1-rainfall_daily":            12.6,
+		"tx1-solar_rad":                 747,
+		"tx1-uv_index":                  5.5,
+		"tx3-temp_1":                    10,
+		"tx3-moist_soil_1":              12,
+		"tx3-wet_leaf_1":                7,
+		"lss-temp_in":                   25,
+		"lss-hum_in":                    41.1,
+		"lss-bar_sea_level":             1015.92,
+		"lss-bar_absolute":              998.99,
+	}
+	if len(values) != len(want) {
+		t.Fatalf("values() = %v, want %d values", values, len(want))
+	}
+	for id, v := range want {
+		if math.Abs(values[id].Reading.Value-v) > 1e-9 {
+			t.Errorf("%s = %v, want %v", id, values[id].Reading.Value, v)
+		}
+	}
+
+	temp := values["tx1-temp"]
+	if temp.Sensor.SensorType != models.SensorTypeTemperatureOutdoor || temp.Sensor.Location != "Outdoor" || temp.Sensor.Name != "Outdoor Temperature (TX 1)" {
+		t.Errorf("sensor = %+v", temp.Sensor)
+	}
+	if temp.Reading.Unit != "°C" || temp.Reading.RawUnit != "°F" || *temp.Reading.RawValue != 68 {
+		t.Errorf("reading = %+v", temp.Reading)
+	}
+	if got := values["tx3-moist_soil_1"].Reading.Unit; got != "cb" {
+		t.Errorf("soil moisture unit = %q, want cb", got)
+	}
+	if got := values["lss-temp_in"].Sensor.Location; got != "Indoor" {
+		t.Errorf("indoor location = %q", got)
+	}
+}
+
+func TestConditions_ValuesOfTransmitters(t *testing.T) {
+	conditions, err := NewClient(testServer(t, testConditions).URL).CurrentConditions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := conditions.values([]Transmitter{{ID: 3, Location: "Garden"}})
+	if _, ok := values["tx1-temp"]; ok {
+		t.Error("values of unlisted transmitters must be skipped")
+	}
+	if got := values["tx3-temp_1"].Sensor.Location; got != "Garden" {
+		t.Errorf("location = %q, want Garden", got)
+	}
+	if _, ok := values["lss-bar_sea_level"]; !ok {
+		t.Error("values of the device itself must be kept")
+	}
+}
+
+func TestParseTransmitters(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{name: "Unset", value: nil},
+		{name: "Valid", value: []interface{}{map[string]interface{}{"id": float64(1)}, map[string]interface{}{"id": float64(3), "location": "Garden"}}, want: 2},
+		{name: "Out of range", value: []interface{}{map[string]interface{}{"id": float64(9)}}, wantErr: true},
+		{name: "Duplicate", value: []interface{}{map[string]interface{}{"id": float64(1)}, map[string]interface{}{"id": float64(1)}}, wantErr: true},
+		{name: "Not a list", value: "1,3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTransmitters(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransmitters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParseTransmitters() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestPuller_Test(t *testing.T) {
+	p := NewPuller(nil)
+	srv := testServer(t, testConditions)
+
+	result, err := p.Test(context.Background(), map[string]interface{}{
+		HostConfigKey:         srv.URL,
+		TransmittersConfigKey: []interface{}{map[string]interface{}{"id": float64(1)}},
+	})
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+	if _, ok := result.Sensors["tx1-temp"]; !ok {
+		t.Errorf("Test() sensors = %v", result.Sensors)
+	}
+	if r := result.Readings["lss-temp_in"]; len(r) != 1 || r[0].Value != 25 {
+		t.Errorf("Test() readings = %v", result.Readings)
+	}
+
+	if _, err := p.Test(context.Background(), map[string]interface{}{}); err == nil {
+		t.Error("Test() without host must fail")
+	}
+}
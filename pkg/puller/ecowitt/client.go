@@ -0,0 +1,105 @@
+package ecowitt
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultPort is the port of the local API of Ecowitt gateways
+	DefaultPort = "45000"
+
+	// cmdLiveData requests the current values of all sensors
+	cmdLiveData = 0x27
+
+	// timeout bounds connecting and reading a response
+	timeout = 5 * time.Second
+)
+
+// header starts every packet of the local API
+var header = []byte{0xff, 0xff}
+
+// Client queries the local TCP API of an Ecowitt gateway like the GW1000
+// or GW2000
+type Client struct {
+	addr string
+}
+
+// NewClient creates a client for the gateway at host, e.g. "192.168.1.60"
+// or "192.168.1.60:45000"
+func NewClient(host string) *Client {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, DefaultPort)
+	}
+	return &Client{addr: host}
+}
+
+// LiveData returns the items of the current sensor values
+func (c *Client) LiveData(ctx context.Context) ([]byte, error) {
+	return c.call(ctx, cmdLiveData)
+}
+
+// call sends a command without payload and returns the data of the
+// response. Live data responses have a two byte size.
+func (c *Client) call(ctx context.Context, cmd byte) ([]byte, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gateway: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := conn.Write(requestPacket(cmd)); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	// Header, command and size
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if head[0] != header[0] || head[1] != header[1] || head[2] != cmd {
+		return nil, fmt.Errorf("unexpected response % x", head)
+	}
+	// The size counts command, size, data and checksum
+	size := int(binary.BigEndian.Uint16(head[3:5]))
+	if size < 4 {
+		return nil, fmt.Errorf("invalid response size %d", size)
+	}
+	rest := make([]byte, size-3)
+	if _, err := io.ReadFull(conn, rest); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	data, sum := rest[:len(rest)-1], rest[len(rest)-1]
+	if checksum(head[2:], data) != sum {
+		return nil, fmt.Errorf("invalid response checksum")
+	}
+	return data, nil
+}
+
+// requestPacket builds the packet of a command without payload
+func requestPacket(cmd byte) []byte {
+	body := []byte{cmd, 3}
+	return append(append(append([]byte{}, header...), body...), checksum(body))
+}
+
+// checksum is the sum of the bytes from the command to the end of the data
+func checksum(parts ...[]byte) byte {
+	var sum byte
+	for _, part := range parts {
+		for _, b := range part {
+			sum += b
+		}
+	}
+	return sum
+}
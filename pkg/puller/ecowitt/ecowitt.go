@@ -0,0 +1,172 @@
+package ecowitt
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+	pushecowitt "github.com/sguter90/weathermaestro/pkg/pusher/ecowitt"
+)
+
+const (
+	// ServiceName is the service of Ecowitt stations, pushing or pulled
+	ServiceName = "ecowitt"
+
+	// StationType is the station type of pulled Ecowitt gateways
+	StationType = "Ecowitt"
+
+	// HostConfigKey is the address of the gateway on the LAN. It
+	// identifies the station.
+	HostConfigKey = "gateway_host"
+)
+
+// Puller queries the live data of an Ecowitt gateway (GW1000, GW1100,
+// GW2000 and consoles with the same local API) over TCP, for firmwares
+// whose custom upload doesn't work. Values are stored with the sensors of
+// the Ecowitt pusher, so a station keeps its sensors when it switches
+// between push and pull.
+type Puller struct {
+	dbManager *database.DatabaseManager
+}
+
+// NewPuller creates a new Ecowitt gateway puller with database connection
+func NewPuller(dbManager *database.DatabaseManager) *Puller {
+	return &Puller{dbManager: dbManager}
+}
+
+func (p *Puller) GetProviderType() string {
+	return ServiceName
+}
+
+// ConfigSchema returns the address of the gateway
+func (p *Puller) ConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema().
+		Add(HostConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Host name or IP address of the gateway, port " + DefaultPort + " unless given",
+		}).
+		Require(HostConfigKey)
+}
+
+// SensorTypes returns the sensor types of the live data
+func (p *Puller) SensorTypes() []string {
+	var types []string
+	for _, it := range items {
+		sensor, ok := pushecowitt.SensorByRemoteID(it.remoteID)
+		if ok && !slices.Contains(types, sensor.SensorType) {
+			types = append(types, sensor.SensorType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (p *Puller) ValidateConfig(config map[string]interface{}) error {
+	return p.ConfigSchema().Validate(config).Err()
+}
+
+func (p *Puller) Pull(ctx context.Context, config map[string]interface{}) (map[string]models.SensorReading, *models.StationData, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, nil, err
+	}
+	host := config[HostConfigKey].(string)
+
+	stationID, err := p.dbManager.GetStationIDByConfigValue(HostConfigKey, host)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load station ID: %w", err)
+	}
+	stationData := &models.StationData{ID: stationID, StationType: StationType}
+
+	values, err := liveValues(ctx, host)
+	if err != nil {
+		return nil, nil, err
+	}
+	sensors, err := p.dbManager.EnsureSensorsByRemoteId(stationID, valueSensors(values))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to ensure sensors: %w", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	readings := make(map[string]models.SensorReading, len(values))
+	for id, v := range values {
+		sensor, ok := sensors[id]
+		if !ok {
+			continue
+		}
+		reading := v.reading(sensor, now)
+		reading.SensorID = sensor.ID
+		readings[id] = reading
+	}
+	return readings, stationData, nil
+}
+
+// Test reads the live data of the gateway without storing anything
+func (p *Puller) Test(ctx context.Context, config map[string]interface{}) (*puller.TestResult, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+
+	values, err := liveValues(ctx, config[HostConfigKey].(string))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	result := &puller.TestResult{
+		Sensors:  valueSensors(values),
+		Readings: make(map[string][]models.SensorReading, len(values)),
+	}
+	for id, sensor := range result.Sensors {
+		result.Readings[id] = []models.SensorReading{values[id].reading(sensor, now)}
+	}
+	return result, nil
+}
+
+// liveValues queries and decodes the live data of a gateway. Values after
+// an unknown item are lost, which is logged rather than failing the pull.
+func liveValues(ctx context.Context, host string) (map[string]Value, error) {
+	data, err := NewClient(host).LiveData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := ParseLiveData(data)
+	if err != nil {
+		if len(values) == 0 {
+			return nil, err
+		}
+		log.Printf("⚠ Gateway %s: %v, later values are skipped", host, err)
+	}
+	return values, nil
+}
+
+// valueSensors returns the sensors of the Ecowitt pusher for values keyed
+// by remote ID
+func valueSensors(values map[string]Value) map[string]models.Sensor {
+	sensors := make(map[string]models.Sensor, len(values))
+	for id := range values {
+		if sensor, ok := pushecowitt.SensorByRemoteID(id); ok {
+			sensors[id] = sensor
+		}
+	}
+	return sensors
+}
+
+// reading returns the reading of a value for a sensor
+func (v Value) reading(sensor models.Sensor, dateUTC time.Time) models.SensorReading {
+	reading := models.SensorReading{
+		Value:   v.Value,
+		Unit:    models.SensorTypeRegistry[sensor.SensorType].Unit,
+		DateUTC: dateUTC,
+	}
+	if v.RawUnit != "" {
+		raw := v.RawValue
+		reading.RawValue = &raw
+		reading.RawUnit = v.RawUnit
+	}
+	return reading
+}
@@ -0,0 +1,166 @@
+package ecowitt
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// testLiveData holds indoor and outdoor temperature, outdoor humidity, the
+// relative pressure, the daily rain, light and the piezo daily rain
+var testLiveData = []byte{
+	0x01, 0x00, 0xd2, // 21.0 °C
+	0x02, 0xff, 0xce, // -5.0 °C
+	0x03, 0x00, 0x00, // dew point, skipped
+	0x07, 0x41, // 65 %
+	0x09, 0x27, 0x88, // 1012.0 hPa
+	0x10, 0x00, 0x19, // 2.5 mm
+	0x15, 0x00, 0x01, 0x86, 0xa0, // 10000.0 lux
+	0x2B, 0x00, 0x64, // soil temperature, skipped
+	0x2C, 0x28, // soil moisture, skipped
+	0x83, 0x00, 0x00, 0x00, 0x1e, // 3.0 mm
+}
+
+// responsePacket builds the live data response of data
+func responsePacket(data []byte) []byte {
+	size := make([]byte, 2)
+	binary.BigEndian.PutUint16(size, uint16(len(data)+4))
+	packet := append([]byte{0xff, 0xff, cmdLiveData}, size...)
+	packet = append(packet, data...)
+	return append(packet, checksum(packet[2:]))
+}
+
+// testGateway serves live data responses on a local port
+func testGateway(t *testing.T, response []byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			request := make([]byte, 5)
+			if _, err := io.ReadFull(conn, request); err == nil && request[2] == cmdLiveData {
+				conn.Write(response)
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRequestPacket(t *testing.T) {
+	want := []byte{0xff, 0xff, 0x27, 0x03, 0x2a}
+	if got := requestPacket(cmdLiveData); string(got) != string(want) {
+		t.Errorf("requestPacket() = % x, want % x", got, want)
+	}
+}
+
+func TestNewClient_Addr(t *testing.T) {
+	tests := map[string]string{
+		"192.168.1.60":       "192.168.1.60:45000",
+		"192.168.1.60:45001": "192.168.1.60:45001",
+		"gw2000.local":       "gw2000.local:45000",
+	}
+	for host, want := range tests {
+		if got := NewClient(host).addr; got != want {
+			t.Errorf("NewClient(%q).addr = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestClient_LiveData(t *testing.T) {
+	client := NewClient(testGateway(t, responsePacket(testLiveData)))
+	data, err := client.LiveData(context.Background())
+	if err != nil {
+		t.Fatalf("LiveData() error = %v", err)
+	}
+	if string(data) != string(testLiveData) {
+		t.Errorf("LiveData() = % x", data)
+	}
+
+	corrupt := responsePacket(testLiveData)
+	corrupt[len(corrupt)-1]++
+	client = NewClient(testGateway(t, corrupt))
+	if _, err := client.LiveData(context.Background()); err == nil {
+		t.Error("LiveData() must fail on a wrong checksum")
+	}
+}
+
+func TestParseLiveData(t *testing.T) {
+	values, err := ParseLiveData(testLiveData)
+	if err != nil {
+		t.Fatalf("ParseLiveData() error = %v", err)
+	}
+	want := map[string]float64{
+		"tempinf":        21,
+		"tempf":          -5,
+		"humidity":       65,
+		"baromrelin":     1012,
+		"dailyrainin":    2.5,
+		"solarradiation": 79,
+		"drain_piezo":    3,
+	}
+	if len(values) != len(want) {
+		t.Fatalf("ParseLiveData() = %v, want %v", values, want)
+	}
+	for id, v := range want {
+		if math.Abs(values[id].Value-v) > 1e-9 {
+			t.Errorf("%s = %v, want %v", id, values[id].Value, v)
+		}
+	}
+	if light := values["solarradiation"]; light.RawValue != 10000 || light.RawUnit != "lux" {
+		t.Errorf("solarradiation = %+v, want the raw lux", light)
+	}
+
+	values, err = ParseLiveData(append([]byte{0x07, 0x41, 0xfe, 0x01}, testLiveData...))
+	if err == nil || len(values) != 1 {
+		t.Errorf("ParseLiveData() = %v, %v, want the values before the unknown item", values, err)
+	}
+	if _, err := ParseLiveData([]byte{0x12, 0x00}); err == nil {
+		t.Error("ParseLiveData() must fail on truncated items")
+	}
+}
+
+func TestPuller_Test(t *testing.T) {
+	p := NewPuller(nil)
+	addr := testGateway(t, responsePacket(testLiveData))
+
+	result, err := p.Test(context.Background(), map[string]interface{}{HostConfigKey: addr})
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+	if s := result.Sensors["tempf"]; s.SensorType != models.SensorTypeTemperature || s.Location != "Outdoor" {
+		t.Errorf("Test() sensor tempf = %+v", s)
+	}
+	if s := result.Sensors["drain_piezo"]; s.RainGauge() != models.RainGaugePiezo {
+		t.Errorf("Test() sensor drain_piezo = %+v", s)
+	}
+	if r := result.Readings["baromrelin"]; len(r) != 1 || r[0].Value != 1012 || r[0].Unit != "hPa" {
+		t.Errorf("Test() readings of baromrelin = %+v", r)
+	}
+}
+
+func TestPuller_SensorTypes(t *testing.T) {
+	types := (&Puller{}).SensorTypes()
+	for _, want := range []string{models.SensorTypeTemperature, models.SensorTypePressureAbsolute, models.SensorTypeRainfallDaily} {
+		found := false
+		for _, sensorType := range types {
+			found = found || sensorType == want
+		}
+		if !found {
+			t.Errorf("SensorTypes() = %v, missing %s", types, want)
+		}
+	}
+}
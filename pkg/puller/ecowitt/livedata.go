@@ -0,0 +1,151 @@
+package ecowitt
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// item describes a value of the live data. Items are a one byte ID
+// followed by a value of fixed size; the size of unknown items isn't known,
+// so parsing stops at the first one.
+type item struct {
+	size int
+	// remoteID is the field of the Ecowitt push protocol the value maps
+	// to; items without one are skipped
+	remoteID string
+	decode   func([]byte) float64
+	// rawUnit is set for values converted to the unit of the sensor type
+	rawUnit string
+}
+
+// Decoders of the value encodings
+var (
+	// tenths of signed values, e.g. °C
+	signed10 = func(b []byte) float64 { return float64(int16(binary.BigEndian.Uint16(b))) / 10 }
+	// tenths of unsigned values, e.g. hPa, m/s and mm
+	unsigned10 = func(b []byte) float64 { return float64(uintN(b)) / 10 }
+	// unsigned values, e.g. % and degrees
+	unsigned = func(b []byte) float64 { return float64(uintN(b)) }
+	// lux in tenths, converted to W/m²
+	lux = func(b []byte) float64 { return float64(uintN(b)) / 10 * models.DefaultLuxFactor }
+)
+
+// items are the live data items of the GW1000/GW2000 API
+var items = map[byte]item{
+	0x01: {2, "tempinf", signed10, ""},
+	0x02: {2, "tempf", signed10, ""},
+	0x03: {2, "", nil, ""}, // dew point
+	0x04: {2, "", nil, ""}, // wind chill
+	0x05: {2, "", nil, ""}, // heat index
+	0x06: {1, "humidityin", unsigned, ""},
+	0x07: {1, "humidity", unsigned, ""},
+	0x08: {2, "baromabsin", unsigned10, ""},
+	0x09: {2, "baromrelin", unsigned10, ""},
+	0x0A: {2, "winddir", unsigned, ""},
+	0x0B: {2, "windspeedmph", unsigned10, ""},
+	0x0C: {2, "windgustmph", unsigned10, ""},
+	0x0D: {2, "eventrainin", unsigned10, ""},
+	0x0E: {2, "rainratein", unsigned10, ""},
+	0x0F: {2, "", nil, ""}, // rain gain
+	0x10: {2, "dailyrainin", unsigned10, ""},
+	0x11: {2, "weeklyrainin", unsigned10, ""},
+	0x12: {4, "monthlyrainin", unsigned10, ""},
+	0x13: {4, "yearlyrainin", unsigned10, ""},
+	0x14: {4, "totalrainin", unsigned10, ""},
+	0x15: {4, "solarradiation", lux, "lux"},
+	0x16: {2, "", nil, ""}, // UV in µW/cm²
+	0x17: {1, "uv", unsigned, ""},
+	0x18: {6, "", nil, ""}, // gateway time
+	0x19: {2, "maxdailygust", unsigned10, ""},
+	0x2A: {2, "pm25_ch1", unsigned10, ""},
+	0x4C: {16, "", nil, ""}, // battery states
+	0x4D: {2, "", nil, ""},  // PM2.5 24h averages
+	0x4E: {2, "", nil, ""},
+	0x4F: {2, "", nil, ""},
+	0x50: {2, "", nil, ""},
+	0x51: {2, "", nil, ""}, // PM2.5 of channels 2 to 4
+	0x52: {2, "", nil, ""},
+	0x53: {2, "", nil, ""},
+	0x60: {1, "", nil, ""},  // lightning distance
+	0x61: {4, "", nil, ""},  // lightning time
+	0x62: {4, "", nil, ""},  // lightning count
+	0x6C: {4, "", nil, ""},  // free heap
+	0x70: {16, "", nil, ""}, // CO2 sensor
+	0x7A: {1, "", nil, ""},  // rain priority
+	0x7B: {1, "", nil, ""},  // radiation compensation
+	0x80: {2, "rrain_piezo", unsigned10, ""},
+	0x81: {2, "erain_piezo", unsigned10, ""},
+	0x82: {2, "", nil, ""}, // reserved
+	0x83: {4, "drain_piezo", unsigned10, ""},
+	0x84: {4, "wrain_piezo", unsigned10, ""},
+	0x85: {4, "mrain_piezo", unsigned10, ""},
+	0x86: {4, "yrain_piezo", unsigned10, ""},
+	0x87: {20, "", nil, ""}, // piezo rain gains
+	0x88: {3, "", nil, ""},  // rain reset times
+}
+
+func init() {
+	// Temperature and humidity channels 1 to 8
+	for i := byte(0); i < 8; i++ {
+		items[0x1A+i] = item{size: 2}
+		items[0x22+i] = item{size: 1}
+		items[0x58+i/2] = item{size: 1} // leak channels 1 to 4
+		items[0x63+i] = item{size: 3}   // WN34 temperature and battery
+		items[0x72+i] = item{size: 1}   // leaf wetness
+	}
+	// Soil temperature and moisture channels 1 to 16
+	for i := byte(0); i < 16; i++ {
+		items[0x2B+2*i] = item{size: 2}
+		items[0x2C+2*i] = item{size: 1}
+	}
+}
+
+// uintN decodes a big endian unsigned value of 1 to 4 bytes
+func uintN(b []byte) uint32 {
+	var v uint32
+	for _, x := range b {
+		v = v<<8 | uint32(x)
+	}
+	return v
+}
+
+// Value is a value of the live data in the unit of its sensor type
+type Value struct {
+	Value float64
+	// RawValue and RawUnit are set for converted values
+	RawValue float64
+	RawUnit  string
+}
+
+// ParseLiveData decodes the items of live data to values keyed by the
+// fields of the Ecowitt push protocol, e.g. "tempf" holding °C. Parsing
+// stops at the first unknown item; the values before it are returned with
+// the error.
+func ParseLiveData(data []byte) (map[string]Value, error) {
+	values := make(map[string]Value)
+	for i := 0; i < len(data); {
+		id := data[i]
+		it, ok := items[id]
+		if !ok {
+			return values, fmt.Errorf("unknown live data item 0x%02X", id)
+		}
+		if i+1+it.size > len(data) {
+			return values, fmt.Errorf("truncated live data item 0x%02X", id)
+		}
+		raw := data[i+1 : i+1+it.size]
+		i += 1 + it.size
+
+		if it.remoteID == "" {
+			continue
+		}
+		value := Value{Value: it.decode(raw)}
+		if it.rawUnit != "" {
+			value.RawValue = float64(uintN(raw)) / 10
+			value.RawUnit = it.rawUnit
+		}
+		values[it.remoteID] = value
+	}
+	return values, nil
+}
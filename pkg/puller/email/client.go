@@ -0,0 +1,221 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client is a minimal IMAP4rev1 client covering what is needed to fetch
+// unseen report mails from one mailbox
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// Account holds the connection settings of a mailbox
+type Account struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Mailbox  string
+
+	// TLS connects with implicit TLS, the default of port 993
+	TLS bool
+}
+
+// response is the reply to a command: its untagged lines and the literals
+// they contained
+type response struct {
+	lines    []string
+	literals [][]byte
+}
+
+// Dial connects to the server of an account, logs in and selects the
+// mailbox
+func Dial(ctx context.Context, a Account) (*Client, error) {
+	addr := net.JoinHostPort(a.Host, strconv.Itoa(a.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	if a.TLS {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: a.Host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.login(ctx, a); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) login(ctx context.Context, a Account) error {
+	c.conn.SetDeadline(deadline(ctx))
+
+	greeting, _, err := c.readLine()
+	if err != nil {
+		return fmt.Errorf("failed to read greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected greeting: %s", greeting)
+	}
+
+	if _, err := c.cmd(ctx, "LOGIN %s %s", quote(a.User), quote(a.Password)); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if _, err := c.cmd(ctx, "SELECT %s", quote(a.Mailbox)); err != nil {
+		return fmt.Errorf("failed to select mailbox %s: %w", a.Mailbox, err)
+	}
+	return nil
+}
+
+// SearchUnseen returns the UIDs of the unseen messages, only those from
+// sender if it is set
+func (c *Client) SearchUnseen(ctx context.Context, sender string) ([]uint32, error) {
+	criteria := "UNSEEN"
+	if sender != "" {
+		criteria += " FROM " + quote(sender)
+	}
+	resp, err := c.cmd(ctx, "UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, line := range resp.lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "*" || !strings.EqualFold(fields[1], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[2:] {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid search response: %s", line)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// Fetch returns the raw message with a UID without marking it as seen
+func (c *Client) Fetch(ctx context.Context, uid uint32) ([]byte, error) {
+	resp, err := c.cmd(ctx, "UID FETCH %d BODY.PEEK[]", uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.literals) == 0 {
+		return nil, fmt.Errorf("message %d not found", uid)
+	}
+	return resp.literals[0], nil
+}
+
+// MarkSeen flags a message as seen so it is not fetched again
+func (c *Client) MarkSeen(ctx context.Context, uid uint32) error {
+	_, err := c.cmd(ctx, `UID STORE %d +FLAGS.SILENT (\Seen)`, uid)
+	return err
+}
+
+// Close logs out and closes the connection
+func (c *Client) Close() error {
+	c.cmd(context.Background(), "LOGOUT")
+	return c.conn.Close()
+}
+
+// cmd sends a tagged command and reads the untagged responses until its
+// completion. Responses other than OK are returned as error.
+func (c *Client) cmd(ctx context.Context, format string, args ...interface{}) (*response, error) {
+	c.conn.SetDeadline(deadline(ctx))
+
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s "+format+"\r\n", append([]interface{}{tag}, args...)...); err != nil {
+		return nil, err
+	}
+
+	resp := &response{}
+	for {
+		line, literals, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if rest, ok := strings.CutPrefix(line, tag+" "); ok {
+			if !strings.HasPrefix(strings.ToUpper(rest), "OK") {
+				return nil, fmt.Errorf("%s", rest)
+			}
+			return resp, nil
+		}
+		resp.lines = append(resp.lines, line)
+		resp.literals = append(resp.literals, literals...)
+	}
+}
+
+// readLine reads a response line. Literals ({n} followed by n bytes) are
+// returned separately and their content is left out of the line.
+func (c *Client) readLine() (string, [][]byte, error) {
+	var line strings.Builder
+	var literals [][]byte
+	for {
+		part, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", nil, err
+		}
+		part = strings.TrimRight(part, "\r\n")
+		line.WriteString(part)
+
+		size, ok := literalSize(part)
+		if !ok {
+			return line.String(), literals, nil
+		}
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return "", nil, err
+		}
+		literals = append(literals, literal)
+	}
+}
+
+// literalSize parses the {n} announcing a literal at the end of a line
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	start := strings.LastIndexByte(line, '{')
+	if start < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(line[start+1 : len(line)-1])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// quote returns s as IMAP quoted string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// deadline returns the deadline of ctx or a default for contexts without one
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(30 * time.Second)
+}
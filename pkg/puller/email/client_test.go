@@ -0,0 +1,146 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeIMAPServer implements the IMAP commands used by the client for one
+// mailbox
+type fakeIMAPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages map[uint32]string
+	seen     map[uint32]bool
+}
+
+func newFakeIMAPServer(t *testing.T, messages map[uint32]string) *fakeIMAPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeIMAPServer{listener: l, messages: messages, seen: make(map[uint32]bool)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeIMAPServer) account() Account {
+	return Account{
+		Host:     "127.0.0.1",
+		Port:     s.listener.Addr().(*net.TCPAddr).Port,
+		User:     "reports@example.com",
+		Password: `se"cret`,
+		Mailbox:  "INBOX",
+	}
+}
+
+func (s *fakeIMAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) { fmt.Fprintf(conn, format+"\r\n", args...) }
+
+	reply("* OK IMAP4rev1 ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		s.mu.Lock()
+		switch {
+		case strings.HasPrefix(cmd, "LOGIN "):
+			if cmd != `LOGIN "reports@example.com" "se\"cret"` {
+				reply("%s NO invalid credentials", tag)
+				break
+			}
+			reply("%s OK logged in", tag)
+		case cmd == `SELECT "INBOX"`:
+			reply("* %d EXISTS", len(s.messages))
+			reply("%s OK [READ-WRITE] selected", tag)
+		case cmd == `UID SEARCH UNSEEN FROM "console@example.com"`:
+			var uids []string
+			for uid := uint32(1); uid <= uint32(len(s.messages)); uid++ {
+				if !s.seen[uid] {
+					uids = append(uids, fmt.Sprint(uid))
+				}
+			}
+			reply("* SEARCH %s", strings.Join(uids, " "))
+			reply("%s OK search done", tag)
+		case strings.HasPrefix(cmd, "UID FETCH "):
+			var uid uint32
+			fmt.Sscanf(cmd, "UID FETCH %d BODY.PEEK[]", &uid)
+			msg := s.messages[uid]
+			reply("* %d FETCH (UID %d BODY[] {%d}", uid, uid, len(msg))
+			fmt.Fprint(conn, msg)
+			reply(")")
+			reply("%s OK fetch done", tag)
+		case strings.HasPrefix(cmd, "UID STORE "):
+			var uid uint32
+			fmt.Sscanf(cmd, "UID STORE %d", &uid)
+			s.seen[uid] = true
+			reply("%s OK store done", tag)
+		case cmd == "LOGOUT":
+			reply("* BYE")
+			reply("%s OK logout", tag)
+		default:
+			reply("%s BAD unknown command", tag)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestClient(t *testing.T) {
+	msg := "From: console@example.com\r\n\r\nTime,T\r\n{2}\r\n"
+	s := newFakeIMAPServer(t, map[uint32]string{1: msg, 2: msg})
+	ctx := context.Background()
+
+	client, err := Dial(ctx, s.account())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer client.Close()
+
+	if err := client.MarkSeen(ctx, 1); err != nil {
+		t.Fatalf("MarkSeen() error = %v", err)
+	}
+	uids, err := client.SearchUnseen(ctx, "console@example.com")
+	if err != nil {
+		t.Fatalf("SearchUnseen() error = %v", err)
+	}
+	if len(uids) != 1 || uids[0] != 2 {
+		t.Fatalf("Expected UID 2, got %v", uids)
+	}
+
+	raw, err := client.Fetch(ctx, 2)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(raw) != msg {
+		t.Errorf("Expected message %q, got %q", msg, raw)
+	}
+}
+
+func TestClient_LoginFailed(t *testing.T) {
+	s := newFakeIMAPServer(t, nil)
+	a := s.account()
+	a.Password = "wrong"
+
+	if _, err := Dial(context.Background(), a); err == nil || !strings.Contains(err.Error(), "invalid credentials") {
+		t.Errorf("Expected login error, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+)
+
+const (
+	// ServiceName is the service of stations reporting by email
+	ServiceName = "email"
+
+	// StationType is the station type of stations reporting by email
+	StationType = "Email"
+
+	// SenderConfigKey is the address the console sends its reports from.
+	// It identifies the station, so several stations can share a mailbox.
+	SenderConfigKey = "email_from"
+
+	// MappingConfigKey holds the Mapping of the CSV reports
+	MappingConfigKey = "csv_mapping"
+
+	// defaultPort and defaultMailbox are used unless the config sets others
+	defaultPort    = 993
+	defaultMailbox = "INBOX"
+
+	// maxMessages limits the messages fetched per pull; the rest follow
+	// with the next pulls
+	maxMessages = 20
+)
+
+// Puller fetches unseen report mails of a station from an IMAP mailbox and
+// imports the rows of their CSV attachments. Mails are marked as seen once
+// imported; mails without parseable reports are marked as well so they are
+// not fetched again.
+type Puller struct {
+	dbManager *database.DatabaseManager
+}
+
+// NewPuller creates a new email puller with database connection
+func NewPuller(dbManager *database.DatabaseManager) *Puller {
+	return &Puller{dbManager: dbManager}
+}
+
+func (p *Puller) GetProviderType() string {
+	return ServiceName
+}
+
+// ConfigSchema returns the mailbox settings and the CSV mapping
+func (p *Puller) ConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema().
+		Add("imap_host", models.ConfigProperty{Type: models.ConfigTypeString, Description: "IMAP server of the mailbox"}).
+		Add("imap_user", models.ConfigProperty{Type: models.ConfigTypeString, Description: "IMAP user name"}).
+		Add("imap_password", models.ConfigProperty{Type: models.ConfigTypeString, Description: "IMAP password"}).
+		Add("imap_mailbox", models.ConfigProperty{Type: models.ConfigTypeString, Description: "Mailbox receiving the reports", Default: defaultMailbox}).
+		Add("imap_port", models.ConfigProperty{
+			Type:        models.ConfigTypeInteger,
+			Description: "IMAP port, 143 for plain IMAP",
+			Default:     defaultPort,
+			Minimum:     models.ConfigLimit(1),
+			Maximum:     models.ConfigLimit(65535),
+		}).
+		Add("imap_tls", models.ConfigProperty{Type: models.ConfigTypeBoolean, Description: "false for plain IMAP", Default: true}).
+		Add(SenderConfigKey, models.ConfigProperty{Type: models.ConfigTypeString, Description: "Address the console sends its reports from", Format: "email"}).
+		Add(MappingConfigKey, models.ConfigProperty{Type: models.ConfigTypeObject, Description: "Mapping of the CSV columns to sensors"}).
+		Add("timezone", models.ConfigProperty{Type: models.ConfigTypeString, Description: "Time zone of the report times", Default: "UTC"}).
+		Require("imap_host", "imap_user", "imap_password", SenderConfigKey, MappingConfigKey)
+}
+
+// SensorTypes returns nil as the sensors are defined by the CSV mapping
+func (p *Puller) SensorTypes() []string {
+	return nil
+}
+
+func (p *Puller) ValidateConfig(config map[string]interface{}) error {
+	if err := p.ConfigSchema().Validate(config).Err(); err != nil {
+		return err
+	}
+	if _, err := ParseMapping(config[MappingConfigKey]); err != nil {
+		return err
+	}
+	if _, err := location(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Puller) Pull(ctx context.Context, config map[string]interface{}) (map[string]models.SensorReading, *models.StationData, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, nil, err
+	}
+	mapping, _ := ParseMapping(config[MappingConfigKey])
+	loc, _ := location(config)
+	sender := config[SenderConfigKey].(string)
+
+	stationID, err := p.dbManager.GetStationIDByConfigValue(SenderConfigKey, sender)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load station ID: %w", err)
+	}
+	stationData := &models.StationData{ID: stationID, StationType: StationType}
+
+	client, err := Dial(ctx, account(config))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Close()
+
+	uids, err := client.SearchUnseen(ctx, sender)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to search mailbox: %w", err)
+	}
+	if len(uids) == 0 {
+		return nil, stationData, nil
+	}
+	if len(uids) > maxMessages {
+		uids = uids[:maxMessages]
+	}
+
+	sensors, err := p.dbManager.EnsureSensorsByRemoteId(stationID, mappingSensors(mapping))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to ensure sensors: %w", err)
+	}
+
+	readings := make(map[string]models.SensorReading)
+	for _, uid := range uids {
+		raw, err := client.Fetch(ctx, uid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch message %d: %w", uid, err)
+		}
+		rows, err := parseMessage(raw, mapping, loc)
+		if err != nil {
+			log.Printf("⚠ Skipping report mail %d from %s: %v", uid, sender, err)
+		}
+		for _, row := range rows {
+			for name, value := range row.Values {
+				sensor, ok := sensors[name]
+				if !ok {
+					continue
+				}
+				readings[fmt.Sprintf("%s@%d", name, row.Time.Unix())] = models.SensorReading{
+					SensorID: sensor.ID,
+					Value:    value,
+					Unit:     models.SensorTypeRegistry[sensor.SensorType].Unit,
+					DateUTC:  row.Time,
+				}
+			}
+		}
+		if err := client.MarkSeen(ctx, uid); err != nil {
+			return nil, nil, fmt.Errorf("failed to mark message %d as seen: %w", uid, err)
+		}
+	}
+	return readings, stationData, nil
+}
+
+// Test reads the unseen report mails of the station without marking them
+// as seen, so they are still imported once the station is created
+func (p *Puller) Test(ctx context.Context, config map[string]interface{}) (*puller.TestResult, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	mapping, _ := ParseMapping(config[MappingConfigKey])
+	loc, _ := location(config)
+	sender := config[SenderConfigKey].(string)
+
+	client, err := Dial(ctx, account(config))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	uids, err := client.SearchUnseen(ctx, sender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search mailbox: %w", err)
+	}
+	if len(uids) > maxMessages {
+		uids = uids[:maxMessages]
+	}
+
+	result := &puller.TestResult{
+		Sensors:  mappingSensors(mapping),
+		Readings: make(map[string][]models.SensorReading),
+	}
+	for _, uid := range uids {
+		raw, err := client.Fetch(ctx, uid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch message %d: %w", uid, err)
+		}
+		rows, err := parseMessage(raw, mapping, loc)
+		if err != nil {
+			log.Printf("⚠ Report mail %d from %s can't be imported: %v", uid, sender, err)
+		}
+		for _, row := range rows {
+			for name, value := range row.Values {
+				sensor, ok := result.Sensors[name]
+				if !ok {
+					continue
+				}
+				result.Readings[name] = append(result.Readings[name], models.SensorReading{
+					Value:   value,
+					Unit:    models.SensorTypeRegistry[sensor.SensorType].Unit,
+					DateUTC: row.Time,
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// mappingSensors returns the sensors of the mapped columns keyed by column
+// name
+func mappingSensors(mapping Mapping) map[string]models.Sensor {
+	sensors := make(map[string]models.Sensor, len(mapping.Columns))
+	for name, column := range mapping.Columns {
+		sensors[name] = models.Sensor{
+			SensorType: column.SensorType,
+			Location:   column.Location,
+			Name:       name,
+			Enabled:    true,
+		}
+	}
+	return sensors
+}
+
+// parseMessage returns the rows of all CSV reports attached to a message
+func parseMessage(raw []byte, mapping Mapping, loc *time.Location) ([]Row, error) {
+	attachments, err := Attachments(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, fmt.Errorf("no CSV attachment")
+	}
+	var rows []Row
+	for _, attachment := range attachments {
+		parsed, err := mapping.ParseReport(bytes.NewReader(attachment), loc)
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, parsed...)
+	}
+	return rows, nil
+}
+
+// account reads the mailbox settings of a station config. Implicit TLS on
+// port 993 is used unless imap_tls is false.
+func account(config map[string]interface{}) Account {
+	a := Account{
+		Host:     config["imap_host"].(string),
+		Port:     defaultPort,
+		User:     config["imap_user"].(string),
+		Password: config["imap_password"].(string),
+		Mailbox:  defaultMailbox,
+		TLS:      true,
+	}
+	if useTLS, ok := config["imap_tls"].(bool); ok {
+		a.TLS = useTLS
+		if !useTLS {
+			a.Port = 143
+		}
+	}
+	if port, ok := config["imap_port"].(float64); ok && port > 0 {
+		a.Port = int(port)
+	}
+	if mailbox, ok := config["imap_mailbox"].(string); ok && mailbox != "" {
+		a.Mailbox = mailbox
+	}
+	return a
+}
+
+// location returns the time zone of the report times, the station time
+// zone or UTC
+func location(config map[string]interface{}) (*time.Location, error) {
+	name, _ := config["timezone"].(string)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,213 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// defaultTimeFormat is the time layout of Ecowitt console reports
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// Mapping maps the columns of the CSV reports of a station to sensors
+type Mapping struct {
+	// TimeColumn is the column holding the time of a row
+	TimeColumn string `json:"time_column"`
+
+	// TimeFormat is the Go layout of the time column, see time.Parse
+	TimeFormat string `json:"time_format,omitempty"`
+
+	// Delimiter separates the columns, a comma by default
+	Delimiter string `json:"delimiter,omitempty"`
+
+	// Columns maps column names to sensors; other columns are ignored
+	Columns map[string]Column `json:"columns"`
+}
+
+// Column is the sensor of a CSV column. Values must be in the unit of the
+// sensor type.
+type Column struct {
+	SensorType string `json:"sensor_type"`
+	Location   string `json:"location"`
+}
+
+// Row is a parsed CSV row with the values of its mapped columns
+type Row struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+// ParseMapping reads the mapping from its station config value
+func ParseMapping(value interface{}) (Mapping, error) {
+	var m Mapping
+	if value == nil {
+		return m, fmt.Errorf("%s is required", MappingConfigKey)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid %s: %w", MappingConfigKey, err)
+	}
+
+	if m.TimeColumn == "" {
+		return m, fmt.Errorf("%s.time_column is required", MappingConfigKey)
+	}
+	if m.TimeFormat == "" {
+		m.TimeFormat = defaultTimeFormat
+	}
+	if len([]rune(m.Delimiter)) > 1 {
+		return m, fmt.Errorf("%s.delimiter must be a single character", MappingConfigKey)
+	}
+	if len(m.Columns) == 0 {
+		return m, fmt.Errorf("%s.columns must map at least one column", MappingConfigKey)
+	}
+	for name, column := range m.Columns {
+		if _, ok := models.SensorTypeRegistry[column.SensorType]; !ok {
+			return m, fmt.Errorf("%s: column %q has unknown sensor type %q", MappingConfigKey, name, column.SensorType)
+		}
+	}
+	return m, nil
+}
+
+// ParseReport parses a CSV report. Times without zone are read in loc.
+// Empty and non-numeric values like "--" are skipped.
+func (m Mapping) ParseReport(r io.Reader, loc *time.Location) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if m.Delimiter != "" {
+		reader.Comma = []rune(m.Delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	timeIndex := -1
+	columns := make(map[int]string)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if name == m.TimeColumn {
+			timeIndex = i
+		}
+		if _, ok := m.Columns[name]; ok {
+			columns[i] = name
+		}
+	}
+	if timeIndex < 0 {
+		return nil, fmt.Errorf("time column %q not found", m.TimeColumn)
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if timeIndex >= len(record) || strings.TrimSpace(record[timeIndex]) == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(m.TimeFormat, strings.TrimSpace(record[timeIndex]), loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time: %w", err)
+		}
+
+		row := Row{Time: t.UTC(), Values: make(map[string]float64)}
+		for i, name := range columns {
+			if i >= len(record) {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				continue
+			}
+			row.Values[name] = value
+		}
+		rows = append(rows, row)
+	}
+}
+
+// Attachments returns the CSV attachments of a raw message. A message
+// whose body itself is CSV is returned as single attachment.
+func Attachments(raw []byte) ([][]byte, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return parts(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Disposition"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+}
+
+// parts collects the CSV parts of a message body, descending into nested
+// multipart bodies
+func parts(contentType, disposition, encoding string, body io.Reader) ([][]byte, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var found [][]byte
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return found, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			csvParts, err := parts(part.Header.Get("Content-Type"), part.Header.Get("Content-Disposition"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, csvParts...)
+		}
+	}
+
+	if !isCSV(mediaType, params, disposition) {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attachment: %w", err)
+	}
+	return [][]byte{data}, nil
+}
+
+// isCSV reports whether a part is a CSV file by its media type or the
+// extension of its file name
+func isCSV(mediaType string, params map[string]string, disposition string) bool {
+	if mediaType == "text/csv" || mediaType == "application/csv" {
+		return true
+	}
+	filename := params["name"]
+	if _, dispParams, err := mime.ParseMediaType(disposition); err == nil && dispParams["filename"] != "" {
+		filename = dispParams["filename"]
+	}
+	return strings.EqualFold(path.Ext(filename), ".csv")
+}
@@ -0,0 +1,91 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMapping(t *testing.T) Mapping {
+	t.Helper()
+	m, err := ParseMapping(map[string]interface{}{
+		"time_column": "Time",
+		"time_format": "2006/1/2 15:04",
+		"columns": map[string]interface{}{
+			"Outdoor Temperature": map[string]interface{}{"sensor_type": "Temperature", "location": "Outdoor"},
+			"Outdoor Humidity":    map[string]interface{}{"sensor_type": "Humidity", "location": "Outdoor"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseMapping() error = %v", err)
+	}
+	return m
+}
+
+func TestParseMapping_Invalid(t *testing.T) {
+	testCases := map[string]interface{}{
+		"missing":        nil,
+		"no time column": map[string]interface{}{"columns": map[string]interface{}{"T": map[string]interface{}{"sensor_type": "Temperature"}}},
+		"no columns":     map[string]interface{}{"time_column": "Time"},
+		"unknown type":   map[string]interface{}{"time_column": "Time", "columns": map[string]interface{}{"T": map[string]interface{}{"sensor_type": "flux"}}},
+		"delimiter":      map[string]interface{}{"time_column": "Time", "delimiter": ";;", "columns": map[string]interface{}{"T": map[string]interface{}{"sensor_type": "Temperature"}}},
+	}
+	for name, value := range testCases {
+		if _, err := ParseMapping(value); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMapping_ParseReport(t *testing.T) {
+	report := "\ufeffTime,Outdoor Temperature,Outdoor Humidity,Wind\n" +
+		"2025/10/1 12:00,12.5,80,3\n" +
+		"2025/10/1 12:05,--,81,3\n" +
+		",,,\n"
+	loc, _ := time.LoadLocation("Europe/Vienna")
+
+	rows, err := testMapping(t).ParseReport(strings.NewReader(report), loc)
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+	if !rows[0].Time.Equal(time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected local time converted to UTC, got %s", rows[0].Time)
+	}
+	if rows[0].Values["Outdoor Temperature"] != 12.5 || rows[0].Values["Outdoor Humidity"] != 80 || len(rows[0].Values) != 2 {
+		t.Errorf("Unexpected values %v", rows[0].Values)
+	}
+	if _, ok := rows[1].Values["Outdoor Temperature"]; ok || len(rows[1].Values) != 1 {
+		t.Errorf("Expected missing values to be skipped, got %v", rows[1].Values)
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	msg := "From: console@example.com\r\n" +
+		"Subject: Daily report\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Report attached\r\n" +
+		"--b1\r\n" +
+		"Content-Type: application/octet-stream; name=\"report.CSV\"\r\n" +
+		"Content-Disposition: attachment; filename=\"report.CSV\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"VGltZSxUCjIwMjUvMTAvMSAxMjowMCwx\r\n" +
+		"Mgo=\r\n" +
+		"--b1--\r\n"
+
+	attachments, err := Attachments([]byte(msg))
+	if err != nil {
+		t.Fatalf("Attachments() error = %v", err)
+	}
+	if len(attachments) != 1 || string(attachments[0]) != "Time,T\n2025/10/1 12:00,12\n" {
+		t.Errorf("Unexpected attachments %q", attachments)
+	}
+}
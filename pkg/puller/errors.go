@@ -0,0 +1,59 @@
+package puller
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ErrRateLimited is returned when a provider rejects requests because its
+// rate limit is exhausted
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// ErrStationNotPulled is returned when a station is not scheduled for pulling
+var ErrStationNotPulled = errors.New("station is not pulled")
+
+// PullTooSoonError is returned when a station is pulled manually before
+// enough time passed since its last pull
+type PullTooSoonError struct {
+	RetryAfter time.Duration
+}
+
+func (e *PullTooSoonError) Error() string {
+	return fmt.Sprintf("station was pulled too recently, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// ReauthorizationError is returned when the authorization of a provider
+// expired or was revoked and the user has to authorize again
+type ReauthorizationError struct {
+	Reason string
+	// URL starts the authorization flow
+	URL string
+}
+
+func (e *ReauthorizationError) Error() string {
+	return fmt.Sprintf("%s - you need to re-authorize by visiting the authorization URL: %s", e.Reason, e.URL)
+}
+
+// NewPullStatus describes the outcome of a pull. err is the pull error and
+// count the number of received readings.
+func NewPullStatus(err error, count int) models.PullStatus {
+	var reauthErr *ReauthorizationError
+	switch {
+	case errors.As(err, &reauthErr):
+		return models.PullStatus{
+			Status:           models.PullStatusReauthorizationRequired,
+			Error:            reauthErr.Reason,
+			AuthorizationURL: reauthErr.URL,
+		}
+	case errors.Is(err, ErrRateLimited):
+		return models.PullStatus{Status: models.PullStatusRateLimited, Error: models.RedactString(err.Error())}
+	case err != nil:
+		return models.PullStatus{Status: models.PullStatusError, Error: models.RedactString(err.Error())}
+	case count == 0:
+		return models.PullStatus{Status: models.PullStatusNoData, Error: "no weather data received"}
+	}
+	return models.PullStatus{Status: models.PullStatusOK}
+}
@@ -0,0 +1,51 @@
+package puller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestNewPullStatus(t *testing.T) {
+	reauth := fmt.Errorf("failed to initialize client: %w", &ReauthorizationError{Reason: "refresh token is invalid or expired", URL: "https://example.com/authorize"})
+
+	tests := []struct {
+		name   string
+		err    error
+		count  int
+		status string
+	}{
+		{"success", nil, 5, models.PullStatusOK},
+		{"no data", nil, 0, models.PullStatusNoData},
+		{"reauthorization", reauth, 0, models.PullStatusReauthorizationRequired},
+		{"rate limit", fmt.Errorf("netatmo API returned status 429: %w", ErrRateLimited), 0, models.PullStatusRateLimited},
+		{"error", errors.New("connection refused"), 0, models.PullStatusError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := NewPullStatus(tt.err, tt.count)
+			if status.Status != tt.status {
+				t.Errorf("Status = %q, want %q", status.Status, tt.status)
+			}
+		})
+	}
+
+	status := NewPullStatus(reauth, 0)
+	if status.AuthorizationURL != "https://example.com/authorize" {
+		t.Errorf("AuthorizationURL = %q", status.AuthorizationURL)
+	}
+	if strings.Contains(status.Error, "https://") {
+		t.Errorf("Error should not contain the authorization URL: %q", status.Error)
+	}
+}
+
+func TestNewPullStatus_RedactsError(t *testing.T) {
+	err := errors.New(`Get "https://api.netatmo.com/api/getstationsdata?access_token=abc123": timeout`)
+	status := NewPullStatus(err, 0)
+	if strings.Contains(status.Error, "abc123") {
+		t.Errorf("Error contains access token: %q", status.Error)
+	}
+}
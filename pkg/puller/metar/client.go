@@ -0,0 +1,153 @@
+package metar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/puller"
+)
+
+// DefaultBaseURL is the data API of the Aviation Weather Center
+const DefaultBaseURL = "https://aviationweather.gov/api/data"
+
+// Client fetches METAR reports from the Aviation Weather Center
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a client for the API at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Observation is a decoded METAR report. Missing values are nil.
+type Observation struct {
+	ICAO    string   `json:"icaoId"`
+	Name    string   `json:"name"`
+	ObsTime int64    `json:"obsTime"`
+	Lat     float64  `json:"lat"`
+	Lon     float64  `json:"lon"`
+	Temp    *float64 `json:"temp"`
+	Dewp    *float64 `json:"dewp"`
+	// Wdir is a number in degrees or "VRB" for variable wind
+	Wdir  interface{} `json:"wdir"`
+	Wspd  *float64    `json:"wspd"`
+	Wgst  *float64    `json:"wgst"`
+	Altim *float64    `json:"altim"`
+	Raw   string      `json:"rawOb"`
+}
+
+// Time returns the observation time
+func (o Observation) Time() time.Time {
+	return time.Unix(o.ObsTime, 0).UTC()
+}
+
+// Latest returns the most recent report of a station
+func (c *Client) Latest(ctx context.Context, icao string) (*Observation, error) {
+	observations, err := c.fetch(ctx, url.Values{"ids": {icao}})
+	if err != nil {
+		return nil, err
+	}
+	var latest *Observation
+	for i := range observations {
+		if latest == nil || observations[i].ObsTime > latest.ObsTime {
+			latest = &observations[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no METAR report for %s", icao)
+	}
+	return latest, nil
+}
+
+// Nearest returns the stations with a current report within radiusKm of
+// a location, nearest first
+func (c *Client) Nearest(ctx context.Context, lat, lon, radiusKm float64) ([]Observation, error) {
+	// One degree of latitude is about 111 km, longitude shrinks towards the poles
+	dLat := radiusKm / 111
+	dLon := radiusKm / (111 * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	bbox := fmt.Sprintf("%.3f,%.3f,%.3f,%.3f", lat-dLat, lon-dLon, lat+dLat, lon+dLon)
+
+	observations, err := c.fetch(ctx, url.Values{"bbox": {bbox}})
+	if err != nil {
+		return nil, err
+	}
+
+	nearest := make(map[string]Observation)
+	for _, o := range observations {
+		if DistanceKm(lat, lon, o.Lat, o.Lon) > radiusKm {
+			continue
+		}
+		if prev, ok := nearest[o.ICAO]; !ok || o.ObsTime > prev.ObsTime {
+			nearest[o.ICAO] = o
+		}
+	}
+	result := make([]Observation, 0, len(nearest))
+	for _, o := range nearest {
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return DistanceKm(lat, lon, result[i].Lat, result[i].Lon) < DistanceKm(lat, lon, result[j].Lat, result[j].Lon)
+	})
+	return result, nil
+}
+
+// fetch queries the METAR endpoint
+func (c *Client) fetch(ctx context.Context, query url.Values) ([]Observation, error) {
+	if err := puller.SpendRequest(ctx); err != nil {
+		return nil, err
+	}
+
+	query.Set("format", "json")
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/metar?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch METAR: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("METAR API returned status %d: %w", resp.StatusCode, puller.ErrRateLimited)
+	case resp.StatusCode == http.StatusNoContent:
+		// Returned when no station matches
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("METAR API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var observations []Observation
+	if err := json.Unmarshal(body, &observations); err != nil {
+		return nil, fmt.Errorf("failed to parse METAR response: %w", err)
+	}
+	return observations, nil
+}
+
+// DistanceKm returns the great-circle distance between two coordinates
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
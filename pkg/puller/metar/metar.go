@@ -0,0 +1,221 @@
+package metar
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
+)
+
+const (
+	// ServiceName is the service of METAR reference stations
+	ServiceName = "metar"
+
+	// StationType is the station type of METAR reference stations
+	StationType = "METAR"
+
+	// fetchInterval is the minimum time between requests for one station.
+	// Reports are issued every 30 or 60 minutes.
+	fetchInterval = 10 * time.Minute
+
+	// knotsToMS converts knots to m/s
+	knotsToMS = 0.514444
+)
+
+var icaoPattern = regexp.MustCompile(`^[A-Z0-9]{4}$`)
+
+// PassKey returns the pass key of the reference station of an airport
+func PassKey(icao string) string {
+	return "metar-" + strings.ToUpper(icao)
+}
+
+// Puller pulls the latest METAR report of an airport, used as an official
+// reference for nearby stations
+type Puller struct {
+	client    *Client
+	dbManager *database.DatabaseManager
+
+	// Reports are only stored once and the API is asked at most every
+	// fetchInterval. Pulls run one at a time, so no locking is needed.
+	lastFetch  map[string]time.Time
+	lastReport map[string]int64
+}
+
+// NewPuller creates a new METAR puller with database connection
+func NewPuller(dbManager *database.DatabaseManager) *Puller {
+	return &Puller{
+		client:     NewClient(DefaultBaseURL),
+		dbManager:  dbManager,
+		lastFetch:  make(map[string]time.Time),
+		lastReport: make(map[string]int64),
+	}
+}
+
+func (p *Puller) GetProviderType() string {
+	return ServiceName
+}
+
+// ConfigSchema returns the airport of the station
+func (p *Puller) ConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema().
+		Add("icao", models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "4 character ICAO code of the airport",
+			Pattern:     icaoPattern.String(),
+		}).
+		Require("icao")
+}
+
+// SensorTypes returns the sensor types derived from METAR reports
+func (p *Puller) SensorTypes() []string {
+	return []string{
+		models.SensorTypeTemperatureOutdoor,
+		models.SensorTypeHumidityOutdoor,
+		models.SensorTypePressureRelative,
+		models.SensorTypeWindSpeed,
+		models.SensorTypeWindDirection,
+		models.SensorTypeWindGust,
+	}
+}
+
+func (p *Puller) ValidateConfig(config map[string]interface{}) error {
+	return p.ConfigSchema().Validate(config).Err()
+}
+
+func (p *Puller) Pull(ctx context.Context, config map[string]interface{}) (map[string]models.SensorReading, *models.StationData, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, nil, err
+	}
+	icao := config["icao"].(string)
+
+	stationID, err := p.dbManager.GetStationIDByPassKey(ctx, PassKey(icao))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load station ID: %w", err)
+	}
+	stationData := &models.StationData{ID: stationID, StationType: StationType}
+
+	if time.Since(p.lastFetch[icao]) < fetchInterval {
+		return nil, stationData, nil
+	}
+	p.lastFetch[icao] = time.Now()
+
+	obs, err := p.client.Latest(ctx, icao)
+	if err != nil {
+		return nil, nil, err
+	}
+	if obs.ObsTime <= p.lastReport[icao] {
+		return nil, stationData, nil
+	}
+
+	values := obs.Values()
+	sensors, err := p.dbManager.EnsureSensorsByRemoteId(stationID, observationSensors(icao, values))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to ensure sensors: %w", err)
+	}
+
+	readings := make(map[string]models.SensorReading, len(values))
+	for sensorType, value := range values {
+		id := remoteID(icao, sensorType)
+		sensor, ok := sensors[id]
+		if !ok {
+			continue
+		}
+		readings[id] = models.SensorReading{
+			SensorID: sensor.ID,
+			Value:    value,
+			Unit:     models.SensorTypeRegistry[sensorType].Unit,
+			DateUTC:  obs.Time(),
+		}
+	}
+	p.lastReport[icao] = obs.ObsTime
+	return readings, stationData, nil
+}
+
+// Test fetches the latest report of the airport without storing anything
+func (p *Puller) Test(ctx context.Context, config map[string]interface{}) (*puller.TestResult, error) {
+	if err := p.ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	icao := config["icao"].(string)
+
+	obs, err := p.client.Latest(ctx, icao)
+	if err != nil {
+		return nil, err
+	}
+	values := obs.Values()
+	result := &puller.TestResult{
+		Sensors:  observationSensors(icao, values),
+		Readings: make(map[string][]models.SensorReading, len(values)),
+	}
+	for sensorType, value := range values {
+		result.Readings[remoteID(icao, sensorType)] = []models.SensorReading{{
+			Value:   value,
+			Unit:    models.SensorTypeRegistry[sensorType].Unit,
+			DateUTC: obs.Time(),
+		}}
+	}
+	return result, nil
+}
+
+// observationSensors returns the sensors of the values of a report keyed by
+// remote ID
+func observationSensors(icao string, values map[string]float64) map[string]models.Sensor {
+	sensors := make(map[string]models.Sensor, len(values))
+	for sensorType := range values {
+		sensors[remoteID(icao, sensorType)] = models.Sensor{
+			SensorType: sensorType,
+			Location:   "Outdoor",
+			Name:       fmt.Sprintf("%s (%s)", sensorType, icao),
+			Enabled:    true,
+		}
+	}
+	return sensors
+}
+
+// remoteID returns the remote ID of a sensor of a reference station
+func remoteID(icao, sensorType string) string {
+	return strings.ToUpper(icao) + "-" + sensorType
+}
+
+// Values converts a report to sensor values by sensor type. Variable wind
+// has no direction.
+func (o Observation) Values() map[string]float64 {
+	values := make(map[string]float64)
+	if o.Temp != nil {
+		values[models.SensorTypeTemperatureOutdoor] = *o.Temp
+		if o.Dewp != nil {
+			values[models.SensorTypeHumidityOutdoor] = math.Round(relativeHumidity(*o.Temp, *o.Dewp)*10) / 10
+		}
+	}
+	if o.Altim != nil {
+		values[models.SensorTypePressureRelative] = *o.Altim
+	}
+	if o.Wspd != nil {
+		values[models.SensorTypeWindSpeed] = round2(*o.Wspd * knotsToMS)
+		if dir, ok := o.Wdir.(float64); ok {
+			values[models.SensorTypeWindDirection] = dir
+		}
+	}
+	if o.Wgst != nil {
+		values[models.SensorTypeWindGust] = round2(*o.Wgst * knotsToMS)
+	}
+	return values
+}
+
+// relativeHumidity derives the relative humidity from temperature and dew
+// point with the Magnus formula
+func relativeHumidity(temp, dewPoint float64) float64 {
+	const b, c = 17.625, 243.04
+	rh := 100 * math.Exp(b*dewPoint/(c+dewPoint)) / math.Exp(b*temp/(c+temp))
+	return math.Min(rh, 100)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
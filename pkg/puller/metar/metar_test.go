@@ -0,0 +1,127 @@
+package metar
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+const testReports = `[
+	{"icaoId":"LOWW","name":"Vienna","obsTime":1760000400,"lat":48.11,"lon":16.57,"temp":12,"dewp":6,"wdir":140,"wspd":10,"wgst":20,"altim":1018},
+	{"icaoId":"LOWW","name":"Vienna","obsTime":1759998600,"lat":48.11,"lon":16.57,"temp":11,"dewp":6,"wdir":"VRB","wspd":2,"altim":1018},
+	{"icaoId":"LOAN","name":"Wiener Neustadt","obsTime":1760000400,"lat":47.84,"lon":16.22,"temp":11,"dewp":5,"wdir":"VRB","wspd":3,"altim":1018}
+]`
+
+func testServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metar" || r.URL.Query().Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testReports))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_Latest(t *testing.T) {
+	client := NewClient(testServer(t).URL)
+
+	obs, err := client.Latest(context.Background(), "LOWW")
+	if err != nil {
+		t.Fatalf("Latest() error = %v", err)
+	}
+	if obs.ObsTime != 1760000400 || *obs.Temp != 12 {
+		t.Errorf("Latest() = %+v, want the most recent report", obs)
+	}
+}
+
+func TestClient_Nearest(t *testing.T) {
+	client := NewClient(testServer(t).URL)
+
+	// Vienna city center
+	stations, err := client.Nearest(context.Background(), 48.21, 16.37, 50)
+	if err != nil {
+		t.Fatalf("Nearest() error = %v", err)
+	}
+	if len(stations) != 2 || stations[0].ICAO != "LOWW" || stations[1].ICAO != "LOAN" {
+		t.Fatalf("Nearest() = %+v", stations)
+	}
+
+	stations, _ = client.Nearest(context.Background(), 48.21, 16.37, 20)
+	if len(stations) != 1 || stations[0].ICAO != "LOWW" {
+		t.Errorf("Nearest() within 20 km = %+v", stations)
+	}
+}
+
+func TestObservation_Values(t *testing.T) {
+	temp, dewp, wspd, wgst, altim := 20.0, 20.0, 10.0, 20.0, 1013.0
+	obs := Observation{Temp: &temp, Dewp: &dewp, Wdir: 270.0, Wspd: &wspd, Wgst: &wgst, Altim: &altim}
+
+	values := obs.Values()
+	want := map[string]float64{
+		models.SensorTypeTemperatureOutdoor: 20,
+		models.SensorTypeHumidityOutdoor:    100,
+		models.SensorTypePressureRelative:   1013,
+		models.SensorTypeWindSpeed:          5.14,
+		models.SensorTypeWindGust:           10.29,
+		models.SensorTypeWindDirection:      270,
+	}
+	if len(values) != len(want) {
+		t.Fatalf("Values() = %v, want %v", values, want)
+	}
+	for sensorType, v := range want {
+		if math.Abs(values[sensorType]-v) > 1e-9 {
+			t.Errorf("%s = %v, want %v", sensorType, values[sensorType], v)
+		}
+	}
+
+	// Variable wind has no direction
+	obs.Wdir = "VRB"
+	if _, ok := obs.Values()[models.SensorTypeWindDirection]; ok {
+		t.Error("expected no wind direction for variable wind")
+	}
+}
+
+func TestRelativeHumidity(t *testing.T) {
+	if rh := relativeHumidity(12, 6); math.Abs(rh-66.5) > 0.5 {
+		t.Errorf("relativeHumidity(12, 6) = %v, want about 66.5", rh)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	p := NewPuller(nil)
+	if err := p.ValidateConfig(map[string]interface{}{"icao": "LOWW"}); err != nil {
+		t.Errorf("ValidateConfig() error = %v", err)
+	}
+	for _, icao := range []interface{}{"", "LOW", "loww", 42} {
+		if err := p.ValidateConfig(map[string]interface{}{"icao": icao}); err == nil {
+			t.Errorf("ValidateConfig(%v) expected error", icao)
+		}
+	}
+}
+
+func TestPuller_Test(t *testing.T) {
+	p := &Puller{client: NewClient(testServer(t).URL)}
+
+	result, err := p.Test(context.Background(), map[string]interface{}{"icao": "LOWW"})
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+	id := remoteID("LOWW", models.SensorTypeTemperatureOutdoor)
+	if _, ok := result.Sensors[id]; !ok {
+		t.Fatalf("Expected sensor %s, got %v", id, result.Sensors)
+	}
+	if readings := result.Readings[id]; len(readings) != 1 || readings[0].Value != 12 {
+		t.Errorf("Unexpected readings of %s: %+v", id, readings)
+	}
+
+	if _, err := p.Test(context.Background(), map[string]interface{}{"icao": "x"}); err == nil {
+		t.Error("Expected an error for an invalid ICAO code")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/sguter90/weathermaestro/pkg/puller"
 )
 
 // Client handles Netatmo API communication
@@ -139,7 +141,7 @@ func (c *Client) GetAccessTokenFromCode(ctx context.Context, code string, state
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to get access token: %w", err)
 	}
@@ -181,7 +183,7 @@ func (c *Client) RefreshAccessToken(ctx context.Context) error {
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to refresh access token: %w", err)
 	}
@@ -201,7 +203,7 @@ func (c *Client) RefreshAccessToken(ctx context.Context) error {
 						}
 					}
 
-					return fmt.Errorf("refresh token is invalid or expired - you need to re-authorize by visiting the authorization URL: %s", authUrl)
+					return &puller.ReauthorizationError{Reason: "refresh token is invalid or expired", URL: authUrl}
 				}
 			}
 		}
@@ -240,3 +242,31 @@ func (c *Client) ensureValidToken(ctx context.Context) error {
 	// Token is expired or about to expire, refresh it
 	return c.RefreshAccessToken(ctx)
 }
+
+// do sends a request, counting it against the request budget of its context
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := puller.SpendRequest(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
+
+// usageLimitErrorCode is the Netatmo API error code for exhausted rate limits
+const usageLimitErrorCode = 26
+
+// checkRateLimit returns puller.ErrRateLimited for responses rejected due
+// to the rate limit of the Netatmo API
+func checkRateLimit(statusCode int, body []byte) error {
+	if statusCode == http.StatusTooManyRequests {
+		return puller.ErrRateLimited
+	}
+	var errResp struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error.Code == usageLimitErrorCode {
+		return puller.ErrRateLimited
+	}
+	return nil
+}
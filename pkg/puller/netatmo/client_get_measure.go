@@ -80,7 +80,7 @@ func (c *Client) GetMeasure(ctx context.Context, deviceID, moduleID string, type
 	q.Add("real_time", "true")
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch getmeasure from Netatmo: %w", err)
 	}
@@ -88,6 +88,9 @@ func (c *Client) GetMeasure(ctx context.Context, deviceID, moduleID string, type
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
+		if err := checkRateLimit(resp.StatusCode, body); err != nil {
+			return nil, fmt.Errorf("netatmo getmeasure returned status %d: %w", resp.StatusCode, err)
+		}
 		return nil, fmt.Errorf("netatmo getmeasure returned status %d: %s", resp.StatusCode, string(body))
 	}
 
@@ -136,7 +136,7 @@ func (c *Client) GetStationsData(ctx context.Context, deviceID string) (*Station
 	}
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch data from Netatmo: %w", err)
 	}
@@ -144,6 +144,9 @@ func (c *Client) GetStationsData(ctx context.Context, deviceID string) (*Station
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
+		if err := checkRateLimit(resp.StatusCode, body); err != nil {
+			return nil, fmt.Errorf("netatmo API returned status %d: %w", resp.StatusCode, err)
+		}
 		return nil, fmt.Errorf("netatmo API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
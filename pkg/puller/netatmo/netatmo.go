@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/database"
 	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/puller"
 )
 
 // Puller implements the Netatmo weather data puller
@@ -30,9 +33,58 @@ func (p *Puller) GetProviderType() string {
 	return "netatmo"
 }
 
+// Netatmo allows 500 requests per hour and user of an app
+const (
+	rateLimitRequests = 500
+	rateLimitPeriod   = time.Hour
+)
+
+// RateLimit returns the request quota of the Netatmo API
+func (p *Puller) RateLimit() (int, time.Duration) {
+	return rateLimitRequests, rateLimitPeriod
+}
+
+// QuotaKey returns the client ID of the Netatmo app, which the quota of
+// its stations is counted against
+func (p *Puller) QuotaKey(config map[string]interface{}) string {
+	clientID, _ := config["client_id"].(string)
+	return clientID
+}
+
+// ConfigSchema returns the Netatmo app and the device. The tokens are set
+// by the OAuth authorization of the station.
+func (p *Puller) ConfigSchema() *models.ConfigSchema {
+	oauth := "Set by the OAuth authorization"
+	return models.NewConfigSchema().
+		Add("client_id", models.ConfigProperty{Type: models.ConfigTypeString, Description: "Client ID of the Netatmo app"}).
+		Add("client_secret", models.ConfigProperty{Type: models.ConfigTypeString, Description: "Client secret of the Netatmo app"}).
+		Add("pull_interval", models.ConfigProperty{Type: models.ConfigTypeInteger, Description: "Seconds between pulls", Default: 300, Minimum: models.ConfigLimit(60)}).
+		Add("redirect_uri", models.ConfigProperty{Type: models.ConfigTypeString, Description: "OAuth callback URL of the station", Format: "uri"}).
+		Add("device_id", models.ConfigProperty{Type: models.ConfigTypeString, Description: "MAC address of the main module"}).
+		Add("access_token", models.ConfigProperty{Type: models.ConfigTypeString, Description: oauth, ReadOnly: true}).
+		Add("refresh_token", models.ConfigProperty{Type: models.ConfigTypeString, Description: oauth, ReadOnly: true}).
+		Add("token_expiry", models.ConfigProperty{Type: models.ConfigTypeString, Description: oauth, Format: "date-time", ReadOnly: true}).
+		Add("state", models.ConfigProperty{Type: models.ConfigTypeString, Description: "OAuth state of a pending authorization", ReadOnly: true}).
+		Require("client_id", "client_secret", "redirect_uri", "device_id", "access_token", "refresh_token", "token_expiry")
+}
+
+// SensorTypes returns the sensor types of the supported modules
+func (p *Puller) SensorTypes() []string {
+	var types []string
+	for _, supported := range GetSupportedSensors() {
+		if !slices.Contains(types, supported.Sensor.SensorType) {
+			types = append(types, supported.Sensor.SensorType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateConfig checks that the required fields of ConfigSchema are not
+// empty. Tokens cleared for a reauthorization pass, so the pull reports the
+// reauthorization instead.
 func (p *Puller) ValidateConfig(config map[string]interface{}) error {
-	requiredFields := []string{"client_id", "client_secret", "redirect_uri", "device_id", "access_token", "refresh_token", "token_expiry"}
-	for _, field := range requiredFields {
+	for _, field := range p.ConfigSchema().Required {
 		if config[field] == "" {
 			return fmt.Errorf("%s is required", field)
 		}
@@ -152,6 +204,7 @@ func (p *Puller) pullMeasureReadings(
 		readings[remoteID] = models.SensorReading{
 			SensorID: sensor.ID,
 			Value:    values[i],
+			Unit:     models.SensorTypeRegistry[m.SensorType].Unit,
 			DateUTC:  timestamp,
 		}
 	}
@@ -184,66 +237,28 @@ func (p *Puller) loadStationID(ctx context.Context, deviceId string) error {
 
 // updateTokensInDatabase updates only the token fields in the station config
 func (p *Puller) updateTokensInDatabase(ctx context.Context, accessToken, refreshToken string, expiry time.Time) error {
-	query := `UPDATE stations 
-              SET config = config || jsonb_build_object(
-                    'access_token', $1::text,
-                    'refresh_token', $2::text,
-                    'token_expiry', $3::text
-                  ),
-                  updated_at = CURRENT_TIMESTAMP 
-              WHERE id = $4`
-
-	result, err := p.dbManager.GetDB().ExecContext(ctx, query,
-		accessToken,
-		refreshToken,
-		expiry.Format(time.RFC3339),
-		p.stationID.String(),
-	)
+	err := p.dbManager.UpdateStationConfigValues(ctx, p.stationID, map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_expiry":  expiry.Format(time.RFC3339),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update tokens: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("no station found with ID %s", p.stationID.String())
-	}
-
 	return nil
 }
 
 // updateConfigForReauthorizationInDatabase clears tokens and updates state for re-authorization
 func (p *Puller) updateConfigForReauthorizationInDatabase(ctx context.Context, state string) error {
-	query := `UPDATE stations 
-              SET config = config || jsonb_build_object(
-                    'access_token', null,
-                    'refresh_token', null,
-                    'token_expiry', null,
-                    'state', $1::text
-                  ),
-                  updated_at = CURRENT_TIMESTAMP 
-              WHERE id = $2`
-
-	result, err := p.dbManager.GetDB().ExecContext(ctx, query,
-		state,
-		p.stationID.String(),
-	)
+	err := p.dbManager.UpdateStationConfigValues(ctx, p.stationID, map[string]interface{}{
+		"access_token":  nil,
+		"refresh_token": nil,
+		"token_expiry":  nil,
+		"state":         state,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update config for reauthorization: %w", err)
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("no station found with ID %s", p.stationID.String())
-	}
-
 	return nil
 }
 
@@ -332,7 +347,7 @@ func (p *Puller) initClient(config map[string]interface{}) error {
 		if tokenCallbackErr != nil {
 			return tokenCallbackErr
 		}
-		return fmt.Errorf("token expiry invalid '%s' you need to re-authorize by visiting the authorization URL: %s", err.Error(), authUrl)
+		return &puller.ReauthorizationError{Reason: fmt.Sprintf("token expiry invalid '%s'", err.Error()), URL: authUrl}
 	}
 
 	p.client.SetAccessToken(config["access_token"].(string))
@@ -348,3 +363,68 @@ func (p *Puller) initClient(config map[string]interface{}) error {
 
 	return nil
 }
+
+// Test reads the sensors and latest readings of the device without touching
+// the database. Tokens refreshed on the way are returned in the config of the
+// result, as the refresh invalidates the old refresh token.
+func (p *Puller) Test(ctx context.Context, config map[string]interface{}) (*puller.TestResult, error) {
+	values := make(map[string]string)
+	for _, field := range []string{"client_id", "client_secret", "redirect_uri", "device_id", "access_token", "refresh_token", "token_expiry", "state"} {
+		values[field], _ = config[field].(string)
+		if values[field] == "" && field != "state" {
+			return nil, fmt.Errorf("%s is required", field)
+		}
+	}
+
+	result := &puller.TestResult{
+		Sensors:  make(map[string]models.Sensor),
+		Readings: make(map[string][]models.SensorReading),
+		Config:   make(map[string]interface{}),
+	}
+
+	client := NewClient(values["client_id"], values["client_secret"], values["redirect_uri"])
+	tokenExpiry, err := time.Parse(time.RFC3339, values["token_expiry"])
+	if err != nil {
+		authUrl, _ := client.GetAuthorizationURL(values["state"])
+		return nil, &puller.ReauthorizationError{Reason: fmt.Sprintf("token expiry invalid '%s'", err.Error()), URL: authUrl}
+	}
+	client.SetState(values["state"])
+	client.SetAccessToken(values["access_token"])
+	client.SetRefreshToken(values["refresh_token"])
+	client.SetTokenExpiry(tokenExpiry)
+	client.SetTokenRefreshCallback(func(accessToken, refreshToken string, expiry time.Time) error {
+		result.Config["access_token"] = accessToken
+		result.Config["refresh_token"] = refreshToken
+		result.Config["token_expiry"] = expiry.Format(time.RFC3339)
+		return nil
+	})
+
+	netatmoResp, err := client.GetStationsData(ctx, values["device_id"])
+	if err != nil {
+		return nil, err
+	}
+	if len(netatmoResp.Body.Devices) == 0 {
+		return nil, fmt.Errorf("no devices found in Netatmo response")
+	}
+	device := netatmoResp.Body.Devices[0]
+
+	tester := &Puller{client: client, deviceID: values["device_id"]}
+	result.Sensors = tester.getSensorsFromDevice(device)
+
+	readings := make(map[string]models.SensorReading)
+	if err := tester.pullMeasureReadings(ctx, device.Type, device.ID, "", result.Sensors, readings); err != nil {
+		return nil, fmt.Errorf("failed to pull main device readings: %w", err)
+	}
+	for _, module := range device.Modules {
+		if !module.Reachable {
+			continue
+		}
+		if err := tester.pullMeasureReadings(ctx, module.Type, device.ID, module.ID, result.Sensors, readings); err != nil {
+			return nil, fmt.Errorf("failed to pull module %s readings: %w", module.ID, err)
+		}
+	}
+	for remoteID, reading := range readings {
+		result.Readings[remoteID] = []models.SensorReading{reading}
+	}
+	return result, nil
+}
@@ -18,6 +18,37 @@ type Puller interface {
 
 	// ValidateConfig checks if the provided configuration is valid for this provider
 	ValidateConfig(config map[string]interface{}) error
+
+	// ConfigSchema returns the JSON Schema of the configuration
+	ConfigSchema() *models.ConfigSchema
+}
+
+// Tester is implemented by pullers that can fetch data for a config before
+// a station is created from it, e.g. to check credentials
+type Tester interface {
+	// Test validates the config and fetches the current data once. Nothing is
+	// written to the database or changed at the provider.
+	Test(ctx context.Context, config map[string]interface{}) (*TestResult, error)
+}
+
+// TestResult is the data fetched by a test pull
+type TestResult struct {
+	// Sensors are the sensors found, keyed by remote ID
+	Sensors map[string]models.Sensor
+	// Readings are the current values keyed by the remote ID of their sensor.
+	// Their sensor IDs are not set.
+	Readings map[string][]models.SensorReading
+	// Config holds config values the provider replaced, e.g. refreshed
+	// tokens, which must be used instead of the tested ones
+	Config map[string]interface{}
+}
+
+// Describer is implemented by pullers that know the sensors of their
+// provider, e.g. to render setup forms
+type Describer interface {
+	// SensorTypes returns the sensor types the provider delivers, or nil if
+	// they are defined by the station config
+	SensorTypes() []string
 }
 
 // PullerRegistry holds all registered data pullers
@@ -39,6 +39,10 @@ func (m *MockPuller) ValidateConfig(config map[string]interface{}) error {
 	return nil
 }
 
+func (m *MockPuller) ConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema()
+}
+
 func TestNewPullerRegistry(t *testing.T) {
 	registry := NewPullerRegistry()
 
@@ -402,3 +406,12 @@ func TestMockPuller_CallCounts(t *testing.T) {
 		t.Errorf("Expected ValidateConfig to be called 5 times, got %d", puller.validateCallCount)
 	}
 }
+
+func TestPullerService_PullStation_NotPulled(t *testing.T) {
+	ps := NewPullerService(nil, NewPullerRegistry(), nil, time.Minute)
+
+	_, _, err := ps.PullStation(context.Background(), uuid.New())
+	if !errors.Is(err, ErrStationNotPulled) {
+		t.Errorf("Expected ErrStationNotPulled, got %v", err)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/database"
+	"github.com/sguter90/weathermaestro/pkg/ingest"
 	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
@@ -15,21 +19,40 @@ import (
 type PullerService struct {
 	dbManager      *database.DatabaseManager
 	pullerRegistry *PullerRegistry
+	pipeline       *ingest.Pipeline
 	interval       time.Duration
 	stopChan       chan struct{}
 	stations       map[string]*models.StationData
 	mu             sync.RWMutex
+	pullMu         sync.Mutex
 	ticker         *time.Ticker
+
+	// budgets holds the request budgets of rate limited providers by
+	// provider and quota key; skipTicks counts the scheduler ticks a
+	// station waits while its budget is low
+	budgetMu  sync.Mutex
+	budgets   map[string]*providerBudget
+	skipTicks map[uuid.UUID]int
+}
+
+// providerBudget is the budget of one quota of a provider
+type providerBudget struct {
+	provider string
+	quotaKey string
+	budget   *Budget
 }
 
 // NewPullerService creates a new PullerService
-func NewPullerService(dbManager *database.DatabaseManager, registry *PullerRegistry, interval time.Duration) *PullerService {
+func NewPullerService(dbManager *database.DatabaseManager, registry *PullerRegistry, pipeline *ingest.Pipeline, interval time.Duration) *PullerService {
 	return &PullerService{
 		dbManager:      dbManager,
 		pullerRegistry: registry,
+		pipeline:       pipeline,
 		interval:       interval,
 		stopChan:       make(chan struct{}),
 		stations:       make(map[string]*models.StationData),
+		budgets:        make(map[string]*providerBudget),
+		skipTicks:      make(map[uuid.UUID]int),
 	}
 }
 
@@ -73,52 +96,192 @@ func (ps *PullerService) run() {
 	}
 }
 
+// manualPullInterval is the minimum time between a manual pull and the
+// previous pull of a station. Rate limited stations wait a full interval.
+const manualPullInterval = 30 * time.Second
+
 // pullAllProviders pulls data from all configured providers
 func (ps *PullerService) pullAllProviders() {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
 
 	for _, s := range ps.stations {
-		// fetch latest s config from database
-		s, err := ps.dbManager.LoadStation(s.ID)
-		if err != nil {
-			fmt.Printf("Failed to query s: %v\n", err)
+		if ps.skipTick(s.ID) {
 			continue
 		}
+		if _, _, err := ps.pullStation(context.Background(), s.ID); err != nil {
+			log.Printf("❌ Failed to pull station %s: %v", s.ID, err)
+		}
+	}
+}
 
-		p, ok := ps.pullerRegistry.Get(s.ServiceName)
-		if !ok {
-			log.Printf("⚠ Puller not found for provider type: %s", s.ServiceName)
-			continue
+// skipTick reports whether a station waits for a later tick because the
+// budget of its provider is low
+func (ps *PullerService) skipTick(stationID uuid.UUID) bool {
+	ps.budgetMu.Lock()
+	defer ps.budgetMu.Unlock()
+
+	if ps.skipTicks[stationID] > 0 {
+		ps.skipTicks[stationID]--
+		return true
+	}
+	return false
+}
+
+// budgetFor returns the shared budget of a rate limited puller for a
+// station config
+func (ps *PullerService) budgetFor(p RateLimited, provider string, config map[string]interface{}) *Budget {
+	quotaKey := p.QuotaKey(config)
+	key := provider + ":" + quotaKey
+
+	ps.budgetMu.Lock()
+	defer ps.budgetMu.Unlock()
+
+	entry, ok := ps.budgets[key]
+	if !ok {
+		requests, period := p.RateLimit()
+		entry = &providerBudget{provider: provider, quotaKey: quotaKey, budget: NewBudget(requests, period)}
+		ps.budgets[key] = entry
+	}
+	return entry.budget
+}
+
+// stretchInterval makes a station skip ticks while its budget is low
+func (ps *PullerService) stretchInterval(stationID uuid.UUID, provider string, budget *Budget) {
+	stretch := budget.Stretch()
+	skip := int(math.Ceil(stretch)) - 1
+
+	ps.budgetMu.Lock()
+	ps.skipTicks[stationID] = skip
+	ps.budgetMu.Unlock()
+
+	if skip > 0 {
+		log.Printf("⚠ %s quota low (%d requests left), pulling station %s every %s", provider, budget.Remaining(), stationID, ps.interval*time.Duration(skip+1))
+	}
+}
+
+// Budgets returns a snapshot of the request budgets of rate limited providers
+func (ps *PullerService) Budgets() []BudgetMetrics {
+	ps.budgetMu.Lock()
+	entries := make([]*providerBudget, 0, len(ps.budgets))
+	for _, entry := range ps.budgets {
+		entries = append(entries, entry)
+	}
+	ps.budgetMu.Unlock()
+
+	metrics := make([]BudgetMetrics, 0, len(entries))
+	for _, entry := range entries {
+		m := entry.budget.Metrics()
+		m.Provider = entry.provider
+		m.QuotaKey = entry.quotaKey
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Provider != metrics[j].Provider {
+			return metrics[i].Provider < metrics[j].Provider
 		}
+		return metrics[i].QuotaKey < metrics[j].QuotaKey
+	})
+	return metrics
+}
 
-		ps.pullFromProvider(p, s.Config)
+// PullStation pulls a station immediately and returns the stored readings
+// with the outcome of the pull. Errors are only returned when the pull could
+// not be attempted.
+func (ps *PullerService) PullStation(ctx context.Context, stationID uuid.UUID) ([]models.SensorReading, models.PullStatus, error) {
+	ps.mu.RLock()
+	_, ok := ps.stations[stationID.String()]
+	ps.mu.RUnlock()
+	if !ok {
+		return nil, models.PullStatus{}, ErrStationNotPulled
+	}
+
+	last, err := ps.dbManager.GetStationPullStatus(ctx, stationID)
+	if err != nil {
+		return nil, models.PullStatus{}, err
+	}
+	if last != nil {
+		wait := manualPullInterval
+		if last.Status == models.PullStatusRateLimited {
+			wait = ps.interval
+		}
+		if elapsed := time.Since(last.LastPullAt); elapsed < wait {
+			return nil, models.PullStatus{}, &PullTooSoonError{RetryAfter: wait - elapsed}
+		}
 	}
+
+	return ps.pullStation(ctx, stationID)
 }
 
-// pullFromProvider pulls data from a specific provider
-func (ps *PullerService) pullFromProvider(p Puller, config map[string]interface{}) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// pullStation pulls a station with its current config and stores the
+// outcome. Pulls run one at a time as pullers keep per-station state.
+func (ps *PullerService) pullStation(ctx context.Context, stationID uuid.UUID) ([]models.SensorReading, models.PullStatus, error) {
+	ps.pullMu.Lock()
+	defer ps.pullMu.Unlock()
+
+	// fetch latest station config from database
+	station, err := ps.dbManager.LoadStation(stationID)
+	if err != nil {
+		return nil, models.PullStatus{}, fmt.Errorf("failed to load station: %w", err)
+	}
+
+	p, ok := ps.pullerRegistry.Get(station.ServiceName)
+	if !ok {
+		return nil, models.PullStatus{}, fmt.Errorf("puller not found for provider type: %s", station.ServiceName)
+	}
+
+	var budget *Budget
+	if limited, ok := p.(RateLimited); ok {
+		budget = ps.budgetFor(limited, station.ServiceName, station.Config)
+		ctx = WithBudget(ctx, budget)
+	}
+
+	readings, err := ps.pullFromProvider(ctx, p, &station)
+	if budget != nil {
+		ps.stretchInterval(station.ID, station.ServiceName, budget)
+	}
+	status := NewPullStatus(err, len(readings))
+	status.LastPullAt = time.Now().UTC()
+	if err := ps.dbManager.UpdateStationPullStatus(ctx, station.ID, status); err != nil {
+		log.Printf("❌ Failed to store pull status for station %s: %v", station.ID, err)
+	}
+	return readings, status, nil
+}
+
+// pullFromProvider pulls data from a specific provider and returns the
+// stored readings
+func (ps *PullerService) pullFromProvider(ctx context.Context, p Puller, station *models.StationData) ([]models.SensorReading, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	sensorReadings, _, err := p.Pull(ctx, config)
+	sensorReadings, _, err := p.Pull(ctx, station.Config)
 	if err != nil {
 		log.Printf("❌ Error pulling from %s: %v", p.GetProviderType(), err)
-		return
+		return nil, err
 	}
 
 	if len(sensorReadings) == 0 {
 		log.Printf("❌ No weather data received from %s", p.GetProviderType())
-		return
+		return nil, nil
 	}
 
-	// Store weather data
+	batch := &ingest.Batch{
+		StationID:  station.ID,
+		Station:    station,
+		Readings:   make([]models.SensorReading, 0, len(sensorReadings)),
+		Source:     "pull",
+		ReceivedAt: time.Now().UTC(),
+	}
 	for _, reading := range sensorReadings {
-		if err := ps.dbManager.StoreSensorReading(reading.SensorID, reading.Value, reading.DateUTC); err != nil {
-			log.Printf("❌ Error storing weather data (%s, %f, %s): %v", reading.SensorID.String(), reading.Value, reading.DateUTC, err)
-			return
-		}
+		batch.Readings = append(batch.Readings, reading)
+	}
+
+	// Run readings through the ingest pipeline and store them
+	if err := ps.pipeline.Process(ctx, batch); err != nil {
+		log.Printf("❌ Error storing weather data from %s: %v", p.GetProviderType(), err)
+		return nil, fmt.Errorf("failed to store readings: %w", err)
 	}
 
 	log.Printf("✓ Pulled %d Weather readings for station: %s", len(sensorReadings), p.GetProviderType())
+	return batch.Readings, nil
 }
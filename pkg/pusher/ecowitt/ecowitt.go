@@ -2,11 +2,13 @@ package ecowitt
 
 import (
 	"net/url"
+	"slices"
+	"sort"
 	"strconv"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
 )
 
 // Pusher implements the Ecowitt weather station pusher
@@ -22,6 +24,38 @@ func (p *Pusher) GetStationType() string {
 	return "Ecowitt"
 }
 
+// CredentialParams returns the parameter carrying the pass key
+func (p *Pusher) CredentialParams() []string {
+	return []string{"PASSKEY"}
+}
+
+// Setup returns the custom server settings of Ecowitt consoles
+func (p *Pusher) Setup() pusher.Setup {
+	return pusher.Setup{
+		Protocol:     "Ecowitt",
+		Interval:     60,
+		Instructions: "Open Weather Services > Customized in the WS View Plus app or the console web interface, enable it and enter the settings.",
+	}
+}
+
+// ConfigSchema returns the push settings; Ecowitt stations are identified
+// by the pass key they push and need no further config
+func (p *Pusher) ConfigSchema() *models.ConfigSchema {
+	return pusher.BaseConfigSchema()
+}
+
+// SensorTypes returns the sensor types of the supported Ecowitt sensors
+func (p *Pusher) SensorTypes() []string {
+	var types []string
+	for _, sensor := range GetSupportedEcowittSensors() {
+		if !slices.Contains(types, sensor.SensorType) {
+			types = append(types, sensor.SensorType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
 func (p *Pusher) ParseStation(params url.Values) *models.StationData {
 	return &models.StationData{
 		PassKey:     params.Get("PASSKEY"),
@@ -45,27 +79,25 @@ func (p *Pusher) ParseSensors(params url.Values) map[string]models.Sensor {
 	return result
 }
 
+// Imperial units sent by Ecowitt stations
+const (
+	unitFahrenheit = "°F"
+	unitInHg       = "inHg"
+	unitMph        = "mph"
+	unitInch       = "in"
+)
+
 // ParseWeatherData Parse parses Ecowitt data with multiple sensors and returns structured sensor data
 func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.Sensor) (map[uuid.UUID]models.SensorReading, error) {
+	return p.ParseWeatherDataWithOptions(params, sensors, pusher.ParseOptions{})
+}
+
+// ParseWeatherDataWithOptions parses Ecowitt data using station specific options
+func (p *Pusher) ParseWeatherDataWithOptions(params url.Values, sensors map[string]models.Sensor, opts pusher.ParseOptions) (map[uuid.UUID]models.SensorReading, error) {
 	result := make(map[uuid.UUID]models.SensorReading)
 
 	// Parse date once
-	var dateUTC time.Time
-	if dateStr := params.Get("dateutc"); dateStr != "" {
-		formats := []string{
-			"2006-01-02 15:04:05",
-			"2006-01-02+15:04:05",
-		}
-		for _, format := range formats {
-			if t, err := time.Parse(format, dateStr); err == nil {
-				dateUTC = t
-				break
-			}
-		}
-	}
-	if dateUTC.IsZero() {
-		dateUTC = time.Now().UTC()
-	}
+	dateUTC := opts.Timestamp(params.Get("dateutc"))
 
 	// Helper functions
 	parseFloat := func(key string) (float64, bool) {
@@ -90,6 +122,9 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 	for remoteID, sensor := range sensors {
 		var value float64
 		var hasValue bool
+		// rawUnit is set for imperial values that are converted to metric
+		var raw float64
+		var rawUnit string
 
 		// Get raw value from params
 		rawValue := params.Get(remoteID)
@@ -103,6 +138,7 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 		case models.SensorTypeTemperature, models.SensorTypeTemperatureOutdoor:
 			if f, ok := parseFloat(remoteID); ok {
 				value = (f - 32) * 5 / 9
+				raw, rawUnit = f, unitFahrenheit
 				hasValue = true
 			}
 
@@ -117,6 +153,7 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 		case models.SensorTypePressureRelative, models.SensorTypePressureAbsolute:
 			if f, ok := parseFloat(remoteID); ok {
 				value = f * 33.8639
+				raw, rawUnit = f, unitInHg
 				hasValue = true
 			}
 
@@ -124,6 +161,7 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 		case models.SensorTypeWindSpeed, models.SensorTypeWindGust, models.SensorTypeWindGustMaxDaily:
 			if f, ok := parseFloat(remoteID); ok {
 				value = f * 0.44704
+				raw, rawUnit = f, unitMph
 				hasValue = true
 			}
 
@@ -145,6 +183,7 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 			models.SensorTypeRainfallTotal:
 			if f, ok := parseFloat(remoteID); ok {
 				value = f * 25.4
+				raw, rawUnit = f, unitInch
 				hasValue = true
 			}
 
@@ -169,6 +208,13 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 				hasValue = true
 			}
 
+		// Particulate matter (µg/m³)
+		case models.SensorTypePM25, models.SensorTypePM10:
+			if f, ok := parseFloat(remoteID); ok {
+				value = f
+				hasValue = true
+			}
+
 		// Battery (percentage)
 		case models.SensorTypeBattery:
 			if i, ok := parseInt(remoteID); ok {
@@ -193,11 +239,17 @@ func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.S
 
 		// Add reading to result if we have a valid value
 		if hasValue {
-			result[sensor.ID] = models.SensorReading{
+			reading := models.SensorReading{
 				SensorID: sensor.ID,
 				Value:    value,
+				Unit:     models.SensorTypeRegistry[sensor.SensorType].Unit,
 				DateUTC:  dateUTC,
 			}
+			if rawUnit != "" {
+				reading.RawValue = &raw
+				reading.RawUnit = rawUnit
+			}
+			result[sensor.ID] = reading
 		}
 	}
 
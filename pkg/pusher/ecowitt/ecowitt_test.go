@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
 )
 
 func TestPusher_GetEndpoint(t *testing.T) {
@@ -29,6 +30,29 @@ func TestPusher_GetStationType(t *testing.T) {
 	}
 }
 
+func TestPusher_CredentialParams(t *testing.T) {
+	params := pusher.CredentialParamsOf(&Pusher{})
+	if len(params) != 1 || params[0] != "PASSKEY" {
+		t.Errorf("Expected credential params [PASSKEY], got %v", params)
+	}
+}
+
+func TestPusher_Setup(t *testing.T) {
+	var p pusher.Pusher = &Pusher{}
+	describer, ok := p.(pusher.SetupDescriber)
+	if !ok {
+		t.Fatal("Expected pusher to implement SetupDescriber")
+	}
+
+	setup := describer.Setup()
+	if setup.Protocol != "Ecowitt" {
+		t.Errorf("Expected protocol Ecowitt, got %s", setup.Protocol)
+	}
+	if setup.Interval <= 0 {
+		t.Errorf("Expected positive interval, got %d", setup.Interval)
+	}
+}
+
 func TestPusher_ParseStation(t *testing.T) {
 	pusher := &Pusher{}
 
@@ -240,6 +264,61 @@ func TestPusher_ParseWeatherData_Humidity(t *testing.T) {
 	}
 }
 
+func TestPusher_ParseWeatherData_RawUnits(t *testing.T) {
+	pusher := &Pusher{}
+
+	testCases := []struct {
+		name        string
+		sensorType  string
+		value       string
+		expectUnit  string
+		expectRaw   float64
+		expectRawOK bool
+		rawUnit     string
+	}{
+		{name: "Temperature", sensorType: models.SensorTypeTemperature, value: "72.5", expectUnit: "°C", expectRaw: 72.5, expectRawOK: true, rawUnit: "°F"},
+		{name: "Pressure", sensorType: models.SensorTypePressureRelative, value: "29.92", expectUnit: "hPa", expectRaw: 29.92, expectRawOK: true, rawUnit: "inHg"},
+		{name: "Wind speed", sensorType: models.SensorTypeWindSpeed, value: "10", expectUnit: "m/s", expectRaw: 10, expectRawOK: true, rawUnit: "mph"},
+		{name: "Rainfall", sensorType: models.SensorTypeRainfallDaily, value: "0.5", expectUnit: "mm", expectRaw: 0.5, expectRawOK: true, rawUnit: "in"},
+		{name: "Humidity is not converted", sensorType: models.SensorTypeHumidity, value: "65", expectUnit: "%"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sensorID := uuid.New()
+			sensors := map[string]models.Sensor{
+				"field": {ID: sensorID, RemoteID: "field", SensorType: tc.sensorType},
+			}
+			params := url.Values{
+				"field":   []string{tc.value},
+				"dateutc": []string{"2024-01-15 12:00:00"},
+			}
+
+			result, err := pusher.ParseWeatherData(params, sensors)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			reading := result[sensorID]
+			if reading.Unit != tc.expectUnit {
+				t.Errorf("Expected unit %q, got %q", tc.expectUnit, reading.Unit)
+			}
+			if !tc.expectRawOK {
+				if reading.RawValue != nil || reading.RawUnit != "" {
+					t.Errorf("Expected no raw value, got %v %q", reading.RawValue, reading.RawUnit)
+				}
+				return
+			}
+			if reading.RawValue == nil || *reading.RawValue != tc.expectRaw {
+				t.Errorf("Expected raw value %v, got %v", tc.expectRaw, reading.RawValue)
+			}
+			if reading.RawUnit != tc.rawUnit {
+				t.Errorf("Expected raw unit %q, got %q", tc.rawUnit, reading.RawUnit)
+			}
+		})
+	}
+}
+
 func TestPusher_ParseWeatherData_Pressure(t *testing.T) {
 	pusher := &Pusher{}
 
@@ -642,6 +721,69 @@ func TestPusher_ParseWeatherData_DateParsing(t *testing.T) {
 	}
 }
 
+func TestPusher_ParseWeatherDataWithOptions(t *testing.T) {
+	p := &Pusher{}
+
+	sensorID := uuid.New()
+	sensors := map[string]models.Sensor{
+		"tempf": {
+			ID:         sensorID,
+			RemoteID:   "tempf",
+			SensorType: models.SensorTypeTemperature,
+		},
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+	received := time.Date(2024, 1, 15, 12, 5, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		dateStr  string
+		opts     pusher.ParseOptions
+		expected time.Time
+	}{
+		{
+			name:     "Local time override",
+			dateStr:  "2024-01-15 13:00:00",
+			opts:     pusher.ParseOptions{Location: berlin},
+			expected: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Explicit offset wins over override",
+			dateStr:  "2024-01-15T12:00:00Z",
+			opts:     pusher.ParseOptions{Location: berlin},
+			expected: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Now uses fallback",
+			dateStr:  "now",
+			opts:     pusher.ParseOptions{Fallback: received},
+			expected: received,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := url.Values{
+				"tempf":   []string{"68.0"},
+				"dateutc": []string{tc.dateStr},
+			}
+
+			result, err := p.ParseWeatherDataWithOptions(params, sensors, tc.opts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if reading := result[sensorID]; !reading.DateUTC.Equal(tc.expected) {
+				t.Errorf("Expected date %v, got %v", tc.expected, reading.DateUTC)
+			}
+		})
+	}
+}
+
 func TestPusher_ParseWeatherData_EmptyParams(t *testing.T) {
 	pusher := &Pusher{}
 
@@ -2021,3 +2163,51 @@ func TestPusher_ParseWeatherData_BatteryLevels(t *testing.T) {
 		})
 	}
 }
+
+func TestPusher_SensorTypes(t *testing.T) {
+	types := (&Pusher{}).SensorTypes()
+	if len(types) == 0 {
+		t.Fatal("Expected sensor types")
+	}
+	for i, sensorType := range types {
+		if _, ok := models.SensorTypeRegistry[sensorType]; !ok {
+			t.Errorf("Sensor type %s is not registered", sensorType)
+		}
+		if i > 0 && types[i-1] >= sensorType {
+			t.Errorf("Expected sorted unique types, got %v", types)
+		}
+	}
+}
+
+func TestPusher_PiezoRain(t *testing.T) {
+	p := &Pusher{}
+	params := url.Values{
+		"dailyrainin": []string{"0.5"},
+		"drain_piezo": []string{"0.6"},
+		"rrain_piezo": []string{"0.1"},
+	}
+
+	sensors := p.ParseSensors(params)
+	if len(sensors) != 3 {
+		t.Fatalf("Expected 3 sensors, got %d", len(sensors))
+	}
+	if gauge := sensors["dailyrainin"].RainGauge(); gauge != models.RainGaugeTippingBucket {
+		t.Errorf("Expected tipping bucket for dailyrainin, got %s", gauge)
+	}
+	if gauge := sensors["drain_piezo"].RainGauge(); gauge != models.RainGaugePiezo {
+		t.Errorf("Expected piezo gauge for drain_piezo, got %s", gauge)
+	}
+
+	for remoteID, sensor := range sensors {
+		sensor.ID = uuid.New()
+		sensors[remoteID] = sensor
+	}
+	result, err := p.ParseWeatherData(params, sensors)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reading := result[sensors["drain_piezo"].ID]
+	if reading.Value < 15.23 || reading.Value > 15.25 || reading.Unit != "mm" {
+		t.Errorf("Expected 15.24 mm, got %v %s", reading.Value, reading.Unit)
+	}
+}
@@ -4,6 +4,18 @@ import (
 	"github.com/sguter90/weathermaestro/pkg/models"
 )
 
+// SensorByRemoteID returns the supported sensor of a field of the Ecowitt
+// protocol, e.g. "tempf". Other Ecowitt protocols map their values to these
+// fields so a station keeps its sensors when it changes the protocol.
+func SensorByRemoteID(remoteID string) (models.Sensor, bool) {
+	for _, sensor := range GetSupportedEcowittSensors() {
+		if sensor.RemoteID == remoteID {
+			return sensor, true
+		}
+	}
+	return models.Sensor{}, false
+}
+
 func GetSupportedEcowittSensors() []models.Sensor {
 	return []models.Sensor{
 		// Indoor
@@ -29,11 +41,11 @@ func GetSupportedEcowittSensors() []models.Sensor {
 			RemoteID:   "baromrelin",
 		},
 		{
-			Name:       "Barometric Pressure (baromabsin)",
-			SensorType: models.SensorTypePressureRelative,
+			Name:       "Barometric Pressure (Absolute)",
+			SensorType: models.SensorTypePressureAbsolute,
 			Location:   "Indoor",
 			Enabled:    true,
-			RemoteID:   "baromrelin",
+			RemoteID:   "baromabsin",
 		},
 		// Outdoor
 		{
@@ -148,6 +160,63 @@ func GetSupportedEcowittSensors() []models.Sensor {
 			Enabled:    true,
 			RemoteID:   "totalrainin",
 		},
+		// Piezo rain gauge of the WS90, sent next to a tipping bucket if both are connected
+		{
+			Name:       "Rain Rate (Piezo)",
+			SensorType: models.SensorTypeRainfallRate,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "rrain_piezo",
+		},
+		{
+			Name:       "Rain (Event, Piezo)",
+			SensorType: models.SensorTypeRainfallEvent,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "erain_piezo",
+		},
+		{
+			Name:       "Rain (Hourly, Piezo)",
+			SensorType: models.SensorTypeRainfallHourly,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "hrain_piezo",
+		},
+		{
+			Name:       "Rain (Daily, Piezo)",
+			SensorType: models.SensorTypeRainfallDaily,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "drain_piezo",
+		},
+		{
+			Name:       "Rain (Weekly, Piezo)",
+			SensorType: models.SensorTypeRainfallWeekly,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "wrain_piezo",
+		},
+		{
+			Name:       "Rain (Monthly, Piezo)",
+			SensorType: models.SensorTypeRainfallMonthly,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "mrain_piezo",
+		},
+		{
+			Name:       "Rain (Yearly, Piezo)",
+			SensorType: models.SensorTypeRainfallYearly,
+			Location:   "Outdoor",
+			Model:      models.RainGaugePiezo,
+			Enabled:    true,
+			RemoteID:   "yrain_piezo",
+		},
 		{
 			Name:       "Vapour Pressure Deficit",
 			SensorType: models.SensorTypeVPD,
@@ -162,6 +231,27 @@ func GetSupportedEcowittSensors() []models.Sensor {
 			Enabled:    true,
 			RemoteID:   "vpd",
 		},
+		{
+			Name:       "PM2.5 (CH1)",
+			SensorType: models.SensorTypePM25,
+			Location:   "Outdoor",
+			Enabled:    true,
+			RemoteID:   "pm25_ch1",
+		},
+		{
+			Name:       "PM2.5",
+			SensorType: models.SensorTypePM25,
+			Location:   "Indoor",
+			Enabled:    true,
+			RemoteID:   "pm25_co2",
+		},
+		{
+			Name:       "PM10",
+			SensorType: models.SensorTypePM10,
+			Location:   "Indoor",
+			Enabled:    true,
+			RemoteID:   "pm10_co2",
+		},
 		{
 			Name:       "Battery (Outdoor Device)",
 			SensorType: models.SensorTypeBattery,
@@ -0,0 +1,221 @@
+package generic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// Station parameters; all other parameters without a dot are sensor values
+// keyed by the sensor ID, with their metadata in "<id>.type", "<id>.location"
+// and "<id>.name"
+const (
+	paramPassKey = "passkey"
+	paramModel   = "model"
+	paramDate    = "dateutc"
+)
+
+// defaultLocation is the location of sensors that don't send one
+const defaultLocation = "Outdoor"
+
+// Pusher implements a generic webhook for DIY stations and gateways. It
+// accepts JSON bodies as well as form parameters:
+//
+//	{
+//	  "passkey": "garden-gateway",
+//	  "dateutc": "2026-03-01T12:00:00Z",
+//	  "sensors": [
+//	    {"id": "adc0", "type": "SoilMoisture", "location": "Garden", "value": 41.2},
+//	    {"id": "Temperature", "value": 18.4}
+//	  ]
+//	}
+//
+// Sensors without type use their ID as type. Values are expected in the unit
+// of the sensor type; they are not converted.
+type Pusher struct{}
+
+// GetEndpoint returns the endpoint path for generic stations
+func (p *Pusher) GetEndpoint() string {
+	return "/data/generic"
+}
+
+// GetStationType returns the station type identifier
+func (p *Pusher) GetStationType() string {
+	return "Generic"
+}
+
+// CredentialParams returns the parameter carrying the pass key
+func (p *Pusher) CredentialParams() []string {
+	return []string{paramPassKey}
+}
+
+// Setup returns the upload settings of generic stations
+func (p *Pusher) Setup() pusher.Setup {
+	return pusher.Setup{
+		Protocol:     "JSON webhook",
+		Interval:     60,
+		Instructions: "POST a JSON body with passkey, dateutc and a list of sensors with id, type, location and value.",
+	}
+}
+
+// ConfigSchema returns the push settings; sensors and their types are
+// defined by the pushed data
+func (p *Pusher) ConfigSchema() *models.ConfigSchema {
+	return pusher.BaseConfigSchema()
+}
+
+// payload is the JSON body accepted by DecodeBody
+type payload struct {
+	PassKey string          `json:"passkey"`
+	Model   string          `json:"model"`
+	DateUTC json.RawMessage `json:"dateutc"`
+	Sensors []struct {
+		ID       string      `json:"id"`
+		Type     string      `json:"type"`
+		Location string      `json:"location"`
+		Name     string      `json:"name"`
+		Value    json.Number `json:"value"`
+	} `json:"sensors"`
+}
+
+// DecodesBody reports whether the content type is JSON
+func (p *Pusher) DecodesBody(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
+
+// DecodeBody converts a JSON body to the form parameters of the pusher
+func (p *Pusher) DecodeBody(body []byte) (url.Values, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var data payload
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if data.PassKey == "" {
+		return nil, fmt.Errorf("passkey is required")
+	}
+
+	params := url.Values{}
+	params.Set(paramPassKey, data.PassKey)
+	if data.Model != "" {
+		params.Set(paramModel, data.Model)
+	}
+	if len(data.DateUTC) > 0 && string(data.DateUTC) != "null" {
+		// Timestamps may be strings or unix epoch numbers
+		var date string
+		if err := json.Unmarshal(data.DateUTC, &date); err != nil {
+			date = string(data.DateUTC)
+		}
+		params.Set(paramDate, date)
+	}
+
+	for _, s := range data.Sensors {
+		if !isSensorParam(s.ID) {
+			return nil, fmt.Errorf("invalid sensor id %q", s.ID)
+		}
+		if s.Value == "" {
+			continue
+		}
+		params.Set(s.ID, s.Value.String())
+		if s.Type != "" {
+			params.Set(s.ID+".type", s.Type)
+		}
+		if s.Location != "" {
+			params.Set(s.ID+".location", s.Location)
+		}
+		if s.Name != "" {
+			params.Set(s.ID+".name", s.Name)
+		}
+	}
+	return params, nil
+}
+
+// isSensorParam reports whether a parameter holds a sensor value
+func isSensorParam(key string) bool {
+	switch key {
+	case "", paramPassKey, paramModel, paramDate:
+		return false
+	}
+	return !strings.Contains(key, ".")
+}
+
+func (p *Pusher) ParseStation(params url.Values) *models.StationData {
+	return &models.StationData{
+		PassKey:     params.Get(paramPassKey),
+		StationType: p.GetStationType(),
+		Model:       params.Get(paramModel),
+		Mode:        "push",
+		ServiceName: "generic",
+	}
+}
+
+func (p *Pusher) ParseSensors(params url.Values) map[string]models.Sensor {
+	result := make(map[string]models.Sensor)
+	for key := range params {
+		if !isSensorParam(key) || params.Get(key) == "" {
+			continue
+		}
+
+		sensor := models.Sensor{
+			Name:       params.Get(key + ".name"),
+			SensorType: params.Get(key + ".type"),
+			Location:   params.Get(key + ".location"),
+			Enabled:    true,
+			RemoteID:   key,
+		}
+		if sensor.SensorType == "" {
+			sensor.SensorType = key
+		}
+		if sensor.Location == "" {
+			sensor.Location = defaultLocation
+		}
+		if sensor.Name == "" {
+			sensor.Name = key
+		}
+		result[key] = sensor
+	}
+	return result
+}
+
+// ParseWeatherData parses the sensor values of a push
+func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.Sensor) (map[uuid.UUID]models.SensorReading, error) {
+	return p.ParseWeatherDataWithOptions(params, sensors, pusher.ParseOptions{})
+}
+
+// ParseWeatherDataWithOptions parses the sensor values of a push using
+// station specific options
+func (p *Pusher) ParseWeatherDataWithOptions(params url.Values, sensors map[string]models.Sensor, opts pusher.ParseOptions) (map[uuid.UUID]models.SensorReading, error) {
+	dateUTC := opts.Timestamp(params.Get(paramDate))
+
+	result := make(map[uuid.UUID]models.SensorReading)
+	for remoteID, sensor := range sensors {
+		raw := params.Get(remoteID)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("invalid value %q of sensor %s", raw, remoteID)
+		}
+
+		// Units of custom sensor types are set by the ingest pipeline
+		result[sensor.ID] = models.SensorReading{
+			SensorID: sensor.ID,
+			Value:    value,
+			Unit:     models.SensorTypeRegistry[sensor.SensorType].Unit,
+			DateUTC:  dateUTC,
+		}
+	}
+	return result, nil
+}
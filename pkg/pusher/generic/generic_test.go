@@ -0,0 +1,120 @@
+package generic
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+func TestPusher_DecodeBody(t *testing.T) {
+	p := &Pusher{}
+	if !p.DecodesBody("application/json; charset=utf-8") || p.DecodesBody("application/x-www-form-urlencoded") {
+		t.Fatal("unexpected DecodesBody result")
+	}
+
+	body := `{
+		"passkey": "garden-gateway",
+		"model": "esp32",
+		"dateutc": 1772366400,
+		"sensors": [
+			{"id": "adc0", "type": "SoilMoisture", "location": "Garden", "name": "Bed 1", "value": 41.2},
+			{"id": "Temperature", "value": 18.4},
+			{"id": "adc1"}
+		]
+	}`
+	params, err := p.DecodeBody([]byte(body))
+	if err != nil {
+		t.Fatalf("DecodeBody() error = %v", err)
+	}
+
+	expected := url.Values{
+		"passkey":       {"garden-gateway"},
+		"model":         {"esp32"},
+		"dateutc":       {"1772366400"},
+		"adc0":          {"41.2"},
+		"adc0.type":     {"SoilMoisture"},
+		"adc0.location": {"Garden"},
+		"adc0.name":     {"Bed 1"},
+		"Temperature":   {"18.4"},
+	}
+	if params.Encode() != expected.Encode() {
+		t.Errorf("DecodeBody() = %s, want %s", params.Encode(), expected.Encode())
+	}
+}
+
+func TestPusher_DecodeBody_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"Invalid JSON":      `{"passkey": `,
+		"Missing passkey":   `{"sensors": [{"id": "adc0", "value": 1}]}`,
+		"Reserved id":       `{"passkey": "x", "sensors": [{"id": "dateutc", "value": 1}]}`,
+		"Id with separator": `{"passkey": "x", "sensors": [{"id": "adc0.type", "value": 1}]}`,
+	}
+	for name, body := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := (&Pusher{}).DecodeBody([]byte(body)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestPusher_ParseSensors(t *testing.T) {
+	params := url.Values{
+		"passkey":       {"garden-gateway"},
+		"adc0":          {"41.2"},
+		"adc0.type":     {"SoilMoisture"},
+		"adc0.location": {"Garden"},
+		"Temperature":   {"18.4"},
+		"empty":         {""},
+	}
+
+	sensors := (&Pusher{}).ParseSensors(params)
+	if len(sensors) != 2 {
+		t.Fatalf("ParseSensors() returned %d sensors, want 2", len(sensors))
+	}
+	if s := sensors["adc0"]; s.SensorType != "SoilMoisture" || s.Location != "Garden" || s.Name != "adc0" {
+		t.Errorf("unexpected sensor: %+v", s)
+	}
+	if s := sensors["Temperature"]; s.SensorType != models.SensorTypeTemperature || s.Location != defaultLocation {
+		t.Errorf("unexpected sensor: %+v", s)
+	}
+}
+
+func TestPusher_ParseWeatherData(t *testing.T) {
+	p := &Pusher{}
+	params := url.Values{
+		"passkey":     {"garden-gateway"},
+		"dateutc":     {"2026-03-01 12:00:00"},
+		"adc0":        {"41.2"},
+		"adc0.type":   {"SoilMoisture"},
+		"Temperature": {"18.4"},
+	}
+	sensors := p.ParseSensors(params)
+	for id, s := range sensors {
+		s.ID = uuid.New()
+		sensors[id] = s
+	}
+
+	readings, err := p.ParseWeatherDataWithOptions(params, sensors, pusher.ParseOptions{})
+	if err != nil {
+		t.Fatalf("ParseWeatherDataWithOptions() error = %v", err)
+	}
+
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	soil := readings[sensors["adc0"].ID]
+	if soil.Value != 41.2 || soil.Unit != "" || !soil.DateUTC.Equal(date) {
+		t.Errorf("unexpected custom reading: %+v", soil)
+	}
+	if temp := readings[sensors["Temperature"].ID]; temp.Value != 18.4 || temp.Unit != "°C" {
+		t.Errorf("unexpected temperature reading: %+v", temp)
+	}
+
+	params.Set("adc0", "NaN")
+	if _, err := p.ParseWeatherData(params, sensors); err == nil {
+		t.Error("expected error for non-finite value")
+	}
+}
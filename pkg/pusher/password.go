@@ -0,0 +1,68 @@
+package pusher
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// ErrInvalidPassword is returned for missing or wrong push passwords
+var ErrInvalidPassword = errors.New("invalid password")
+
+// PasswordDescriber is implemented by pushers whose stations send a password
+// with every push, like the Weather Underground protocol
+type PasswordDescriber interface {
+	// PasswordParam returns the parameter carrying the password
+	PasswordParam() string
+}
+
+// CredentialDescriber is implemented by pushers whose payloads carry
+// credentials besides the password, like the pass key of Ecowitt stations
+type CredentialDescriber interface {
+	// CredentialParams returns the parameters carrying credentials
+	CredentialParams() []string
+}
+
+// CredentialParamsOf returns the parameters of a pusher's payloads that
+// must not be stored in plain text, including the password parameter
+func CredentialParamsOf(p Pusher) []string {
+	var params []string
+	if d, ok := p.(CredentialDescriber); ok {
+		params = append(params, d.CredentialParams()...)
+	}
+	if param := PasswordParamOf(p); param != "" {
+		params = append(params, param)
+	}
+	return params
+}
+
+// PasswordParamOf returns the password parameter of a pusher, or "" if its
+// stations don't send one
+func PasswordParamOf(p Pusher) string {
+	if d, ok := p.(PasswordDescriber); ok {
+		return d.PasswordParam()
+	}
+	return ""
+}
+
+// StationPassword returns the push password of a station config, or "" if
+// none is set
+func StationPassword(config map[string]interface{}) string {
+	password, _ := config[models.PushPasswordConfigKey].(string)
+	return password
+}
+
+// VerifyPassword checks a pushed password against the push password of a
+// station. Stations without push password accept any password, as firmwares
+// often require one to be entered.
+func VerifyPassword(config map[string]interface{}, password string) error {
+	expected := StationPassword(config)
+	if expected == "" {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(password)) != 1 {
+		return ErrInvalidPassword
+	}
+	return nil
+}
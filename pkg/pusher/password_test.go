@@ -0,0 +1,43 @@
+package pusher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	config := map[string]interface{}{models.PushPasswordConfigKey: "s3cret"}
+
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		password string
+		wantErr  bool
+	}{
+		{name: "Match", config: config, password: "s3cret"},
+		{name: "Wrong", config: config, password: "guess", wantErr: true},
+		{name: "Missing", config: config, password: "", wantErr: true},
+		{name: "No password configured", config: map[string]interface{}{}, password: "anything"},
+		{name: "Nil config", config: nil, password: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyPassword(tt.config, tt.password)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("VerifyPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPassword) {
+				t.Errorf("Expected ErrInvalidPassword, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPasswordParamOf(t *testing.T) {
+	if param := PasswordParamOf(&MockPusher{}); param != "" {
+		t.Errorf("Expected no password param, got %q", param)
+	}
+}
@@ -24,6 +24,82 @@ type Pusher interface {
 
 	// GetStationType returns the station type identifier
 	GetStationType() string
+
+	// ConfigSchema returns the JSON Schema of the station config
+	ConfigSchema() *models.ConfigSchema
+}
+
+// OptionsParser is implemented by pushers that support per-station parse
+// options such as a time zone override for stations sending local time.
+type OptionsParser interface {
+	// ParseWeatherDataWithOptions behaves like ParseWeatherData using opts
+	ParseWeatherDataWithOptions(params url.Values, sensors map[string]models.Sensor, opts ParseOptions) (map[uuid.UUID]models.SensorReading, error)
+}
+
+// BodyDecoder is implemented by pushers that receive a request body other
+// than form parameters. The decoded parameters are queued and parsed like
+// form parameters.
+type BodyDecoder interface {
+	// DecodesBody reports whether a body of the content type is decoded
+	DecodesBody(contentType string) bool
+
+	// DecodeBody converts a request body to parameters
+	DecodeBody(body []byte) (url.Values, error)
+}
+
+// Acknowledger is implemented by pushers whose station firmware only
+// accepts an upload with a fixed plain text response instead of JSON
+type Acknowledger interface {
+	// Acknowledgement returns the body of stored or queued pushes
+	Acknowledgement() string
+}
+
+// SetupDescriber is implemented by pushers that know how their stations are
+// configured to upload to a custom server
+type SetupDescriber interface {
+	// Setup returns the console settings of the upload protocol
+	Setup() Setup
+}
+
+// Describer is implemented by pushers that know the sensors of their
+// protocol, e.g. to render setup forms
+type Describer interface {
+	// SensorTypes returns the sensor types the protocol carries, or nil if
+	// they are defined by the pushed data
+	SensorTypes() []string
+}
+
+// BaseConfigSchema returns the config read for the pushes of every pusher
+func BaseConfigSchema() *models.ConfigSchema {
+	return models.NewConfigSchema().
+		Add(models.AllowedIPsConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Comma separated addresses and networks pushes are accepted from",
+		}).
+		Add(models.WebhookSecretConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Secret the pushes are signed with",
+		}).
+		Add(models.WebhookSignatureHeaderConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Header carrying the signature",
+			Default:     DefaultSignature.Header,
+		}).
+		Add(models.BackfillConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeBoolean,
+			Description: "Accept readings older than the maximum push age",
+			Default:     false,
+		})
+}
+
+// Setup describes the upload settings of a station console
+type Setup struct {
+	// Protocol is the upload protocol to select in the console
+	Protocol string
+	// Interval is the recommended upload interval in seconds
+	Interval int
+	// Instructions explain where the settings are entered
+	Instructions string
 }
 
 // Registry holds all registered pushers
@@ -22,6 +22,10 @@ func (m *MockPusher) GetStationType() string {
 	return m.stationType
 }
 
+func (m *MockPusher) ConfigSchema() *models.ConfigSchema {
+	return BaseConfigSchema()
+}
+
 func (m *MockPusher) ParseStation(params url.Values) *models.StationData {
 	return &models.StationData{
 		StationType: m.stationType,
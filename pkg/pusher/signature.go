@@ -0,0 +1,106 @@
+package pusher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidSignature is returned for missing or wrong webhook signatures
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signature encodings
+const (
+	SignatureHex    = "hex"
+	SignatureBase64 = "base64"
+)
+
+// Signature describes how webhooks are signed with a secret shared with the
+// station: an HMAC-SHA256 of the request body, or of the query string of
+// requests without body, sent in a header
+type Signature struct {
+	// Header carries the signature
+	Header string
+	// Prefix optionally precedes the signature, e.g. "sha256="
+	Prefix string
+	// Encoding is SignatureHex or SignatureBase64
+	Encoding string
+}
+
+// DefaultSignature is used by pushers that don't describe their own
+var DefaultSignature = Signature{Header: "X-Signature", Prefix: "sha256=", Encoding: SignatureHex}
+
+// SignatureDescriber is implemented by pushers whose vendors sign their
+// webhooks in a format other than DefaultSignature
+type SignatureDescriber interface {
+	// Signature returns the signature format of the vendor
+	Signature() Signature
+}
+
+// SignatureOf returns the signature format of a pusher
+func SignatureOf(p Pusher) Signature {
+	if d, ok := p.(SignatureDescriber); ok {
+		return d.Signature()
+	}
+	return DefaultSignature
+}
+
+// SignedContent returns the content the signature of a push covers. Pushes
+// with a nonce or timestamp sign both ahead of the body or query string,
+// "<timestamp>.<nonce>.<content>", so they can't be swapped in a captured
+// request.
+func SignedContent(content []byte, nonce, timestamp string) []byte {
+	if nonce == "" && timestamp == "" {
+		return content
+	}
+	signed := make([]byte, 0, len(timestamp)+len(nonce)+len(content)+2)
+	signed = append(signed, timestamp...)
+	signed = append(signed, '.')
+	signed = append(signed, nonce...)
+	signed = append(signed, '.')
+	return append(signed, content...)
+}
+
+// Sign returns the header value signing content with secret
+func (s Signature) Sign(secret string, content []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(content)
+	sum := mac.Sum(nil)
+	if s.Encoding == SignatureBase64 {
+		return s.Prefix + base64.StdEncoding.EncodeToString(sum)
+	}
+	return s.Prefix + hex.EncodeToString(sum)
+}
+
+// Verify checks the header value signing content with secret. The prefix
+// is optional and hex signatures are accepted in either case.
+func (s Signature) Verify(secret string, content []byte, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ErrInvalidSignature
+	}
+	if s.Prefix != "" && len(value) >= len(s.Prefix) && strings.EqualFold(value[:len(s.Prefix)], s.Prefix) {
+		value = value[len(s.Prefix):]
+	}
+
+	var got []byte
+	var err error
+	if s.Encoding == SignatureBase64 {
+		got, err = base64.StdEncoding.DecodeString(value)
+	} else {
+		got, err = hex.DecodeString(value)
+	}
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(content)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package pusher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignatureVerify(t *testing.T) {
+	body := []byte(`{"passkey":"garden","sensors":[]}`)
+
+	for _, s := range []Signature{DefaultSignature, {Header: "X-Vendor-Signature", Encoding: SignatureBase64}} {
+		signed := s.Sign("secret", body)
+		if !strings.HasPrefix(signed, s.Prefix) {
+			t.Errorf("Expected prefix %q, got %s", s.Prefix, signed)
+		}
+		if err := s.Verify("secret", body, signed); err != nil {
+			t.Errorf("Expected %s to verify, got %v", signed, err)
+		}
+		if err := s.Verify("other", body, signed); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected an invalid signature for another secret, got %v", err)
+		}
+		if err := s.Verify("secret", append(body, ' '), signed); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected an invalid signature for another body, got %v", err)
+		}
+		if err := s.Verify("secret", body, ""); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected an invalid signature without value, got %v", err)
+		}
+	}
+
+	// The prefix is optional and hex is accepted in upper case
+	signed := strings.TrimPrefix(DefaultSignature.Sign("secret", body), "sha256=")
+	if err := DefaultSignature.Verify("secret", body, strings.ToUpper(signed)); err != nil {
+		t.Errorf("Expected a signature without prefix to verify, got %v", err)
+	}
+	if err := DefaultSignature.Verify("secret", body, "sha256=zz"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected an invalid signature for bad hex, got %v", err)
+	}
+}
+
+type signingPusher struct{ Pusher }
+
+func (signingPusher) Signature() Signature { return Signature{Header: "X-Vendor"} }
+
+func TestSignatureOf(t *testing.T) {
+	if s := SignatureOf(signingPusher{}); s.Header != "X-Vendor" {
+		t.Errorf("Expected the signature of the pusher, got %+v", s)
+	}
+	var p Pusher
+	if s := SignatureOf(p); s != DefaultSignature {
+		t.Errorf("Expected the default signature, got %+v", s)
+	}
+}
+
+func TestSignedContent(t *testing.T) {
+	body := []byte("tempf=70")
+	if got := SignedContent(body, "", ""); string(got) != "tempf=70" {
+		t.Errorf("Expected the plain body without nonce, got %s", got)
+	}
+	if got := SignedContent(body, "n1", "1767225600"); string(got) != "1767225600.n1.tempf=70" {
+		t.Errorf("Expected timestamp and nonce ahead of the body, got %s", got)
+	}
+
+	// A captured signature doesn't verify with another nonce
+	signed := DefaultSignature.Sign("secret", SignedContent(body, "n1", "1767225600"))
+	if err := DefaultSignature.Verify("secret", SignedContent(body, "n2", "1767225600"), signed); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected an invalid signature for another nonce, got %v", err)
+	}
+}
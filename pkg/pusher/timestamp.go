@@ -0,0 +1,69 @@
+package pusher
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the timestamp formats accepted from station firmwares.
+// Layouts without zone information are interpreted in the caller's location.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02+15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// ParseTimestamp parses a timestamp sent by a station.
+// It accepts the common Ecowitt/Wunderground formats, ISO 8601 and unix epoch
+// seconds. Values without an explicit offset are interpreted in loc, or UTC
+// when loc is nil. The result is always in UTC. It returns false for empty
+// values, "now" and anything it cannot parse, so callers can fall back to the
+// receive time.
+func ParseTimestamp(value string, loc *time.Location) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "now") {
+		return time.Time{}, false
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil && epoch > 0 {
+		return time.Unix(epoch, 0).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// ParseOptions controls station specific parsing behaviour
+type ParseOptions struct {
+	// Location is used for timestamps without zone information (nil = UTC)
+	Location *time.Location
+
+	// Fallback is used when the payload has no usable timestamp (zero = now)
+	Fallback time.Time
+}
+
+// Timestamp parses value according to the options and falls back to
+// Fallback or the current time when it is not usable.
+func (o ParseOptions) Timestamp(value string) time.Time {
+	if t, ok := ParseTimestamp(value, o.Location); ok {
+		return t
+	}
+	if !o.Fallback.IsZero() {
+		return o.Fallback.UTC()
+	}
+	return time.Now().UTC()
+}
@@ -0,0 +1,64 @@
+package pusher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	plus2 := time.FixedZone("UTC+2", 2*60*60)
+	expected := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		value    string
+		loc      *time.Location
+		expected time.Time
+		ok       bool
+	}{
+		{name: "Ecowitt format", value: "2024-01-15 12:00:00", expected: expected, ok: true},
+		{name: "Plus separated", value: "2024-01-15+12:00:00", expected: expected, ok: true},
+		{name: "ISO 8601 UTC", value: "2024-01-15T12:00:00Z", expected: expected, ok: true},
+		{name: "ISO 8601 with offset", value: "2024-01-15T14:00:00+02:00", expected: expected, ok: true},
+		{name: "ISO 8601 without zone", value: "2024-01-15T12:00:00", expected: expected, ok: true},
+		{name: "Fractional seconds", value: "2024-01-15T12:00:00.000Z", expected: expected, ok: true},
+		{name: "Without seconds", value: "2024-01-15 12:00", expected: expected, ok: true},
+		{name: "Unix epoch", value: "1705320000", expected: expected, ok: true},
+		{name: "Local time in location", value: "2024-01-15 14:00:00", loc: plus2, expected: expected, ok: true},
+		{name: "Offset ignores location", value: "2024-01-15T12:00:00Z", loc: plus2, expected: expected, ok: true},
+		{name: "Surrounding whitespace", value: " 2024-01-15 12:00:00 ", expected: expected, ok: true},
+		{name: "Now", value: "now", ok: false},
+		{name: "Now uppercase", value: "NOW", ok: false},
+		{name: "Empty", value: "", ok: false},
+		{name: "Invalid", value: "invalid-date", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ParseTimestamp(tc.value, tc.loc)
+			if ok != tc.ok {
+				t.Fatalf("Expected ok=%v, got %v", tc.ok, ok)
+			}
+			if tc.ok && !got.Equal(tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+			if tc.ok && got.Location() != time.UTC {
+				t.Errorf("Expected UTC result, got %v", got.Location())
+			}
+		})
+	}
+}
+
+func TestParseOptions_Timestamp(t *testing.T) {
+	fallback := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	opts := ParseOptions{Fallback: fallback}
+	if got := opts.Timestamp("now"); !got.Equal(fallback) {
+		t.Errorf("Expected fallback %v, got %v", fallback, got)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if got := (ParseOptions{}).Timestamp(""); got.Before(before) {
+		t.Errorf("Expected current time without fallback, got %v", got)
+	}
+}
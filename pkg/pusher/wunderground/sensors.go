@@ -0,0 +1,80 @@
+package wunderground
+
+import (
+	"github.com/sguter90/weathermaestro/pkg/models"
+)
+
+// Imperial units of the Weather Underground protocol
+const (
+	unitFahrenheit = "°F"
+	unitInHg       = "inHg"
+	unitMph        = "mph"
+	unitInch       = "in"
+)
+
+// conversions convert the imperial units of the protocol to the units of the
+// sensor types
+var conversions = map[string]func(float64) float64{
+	unitFahrenheit: func(f float64) float64 { return (f - 32) * 5 / 9 },
+	unitInHg:       func(f float64) float64 { return f * 33.8639 },
+	unitMph:        func(f float64) float64 { return f * 0.44704 },
+	unitInch:       func(f float64) float64 { return f * 25.4 },
+}
+
+// sensor is a parameter of the protocol
+type sensor struct {
+	models.Sensor
+	// unit is the imperial unit of the parameter, or "" if it is sent in
+	// the unit of the sensor type
+	unit string
+}
+
+// supportedSensors are the parameters of the Weather Underground upload
+// protocol. Derived values like dewptf and windchillf are left out.
+var supportedSensors = []sensor{
+	// Outdoor
+	{Sensor: models.Sensor{Name: "Temperature", SensorType: models.SensorTypeTemperature, Location: "Outdoor", RemoteID: "tempf"}, unit: unitFahrenheit},
+	{Sensor: models.Sensor{Name: "Humidity", SensorType: models.SensorTypeHumidity, Location: "Outdoor", RemoteID: "humidity"}},
+	{Sensor: models.Sensor{Name: "Wind Direction", SensorType: models.SensorTypeWindDirection, Location: "Outdoor", RemoteID: "winddir"}},
+	{Sensor: models.Sensor{Name: "Wind Speed", SensorType: models.SensorTypeWindSpeed, Location: "Outdoor", RemoteID: "windspeedmph"}, unit: unitMph},
+	{Sensor: models.Sensor{Name: "Wind Gust", SensorType: models.SensorTypeWindGust, Location: "Outdoor", RemoteID: "windgustmph"}, unit: unitMph},
+	{Sensor: models.Sensor{Name: "Wind Gust Direction", SensorType: models.SensorTypeWindGustAngle, Location: "Outdoor", RemoteID: "windgustdir"}},
+	{Sensor: models.Sensor{Name: "Solar Radiation", SensorType: models.SensorTypeSolarRadiation, Location: "Outdoor", RemoteID: "solarradiation"}},
+	{Sensor: models.Sensor{Name: "UV Index", SensorType: models.SensorTypeUVIndex, Location: "Outdoor", RemoteID: "UV"}},
+	{Sensor: models.Sensor{Name: "PM2.5", SensorType: models.SensorTypePM25, Location: "Outdoor", RemoteID: "AqPM2.5"}},
+	{Sensor: models.Sensor{Name: "PM10", SensorType: models.SensorTypePM10, Location: "Outdoor", RemoteID: "AqPM10"}},
+
+	// Rain; rainin is the rain of the last 60 minutes
+	{Sensor: models.Sensor{Name: "Rain (Hourly)", SensorType: models.SensorTypeRainfallHourly, Location: "Outdoor", RemoteID: "rainin"}, unit: unitInch},
+	{Sensor: models.Sensor{Name: "Rain (Daily)", SensorType: models.SensorTypeRainfallDaily, Location: "Outdoor", RemoteID: "dailyrainin"}, unit: unitInch},
+	{Sensor: models.Sensor{Name: "Rain (Weekly)", SensorType: models.SensorTypeRainfallWeekly, Location: "Outdoor", RemoteID: "weeklyrainin"}, unit: unitInch},
+	{Sensor: models.Sensor{Name: "Rain (Monthly)", SensorType: models.SensorTypeRainfallMonthly, Location: "Outdoor", RemoteID: "monthlyrainin"}, unit: unitInch},
+	{Sensor: models.Sensor{Name: "Rain (Yearly)", SensorType: models.SensorTypeRainfallYearly, Location: "Outdoor", RemoteID: "yearlyrainin"}, unit: unitInch},
+
+	// Indoor, where consoles measure the pressure like Ecowitt stations
+	{Sensor: models.Sensor{Name: "Temperature", SensorType: models.SensorTypeTemperature, Location: "Indoor", RemoteID: "indoortempf"}, unit: unitFahrenheit},
+	{Sensor: models.Sensor{Name: "Humidity", SensorType: models.SensorTypeHumidity, Location: "Indoor", RemoteID: "indoorhumidity"}},
+	{Sensor: models.Sensor{Name: "Barometric Pressure (Relative)", SensorType: models.SensorTypePressureRelative, Location: "Indoor", RemoteID: "baromin"}, unit: unitInHg},
+	{Sensor: models.Sensor{Name: "Barometric Pressure (Absolute)", SensorType: models.SensorTypePressureAbsolute, Location: "Indoor", RemoteID: "absbaromin"}, unit: unitInHg},
+}
+
+// GetSupportedSensors returns the sensors of the Weather Underground protocol
+func GetSupportedSensors() []models.Sensor {
+	sensors := make([]models.Sensor, 0, len(supportedSensors))
+	for _, s := range supportedSensors {
+		s.Enabled = true
+		sensors = append(sensors, s.Sensor)
+	}
+	return sensors
+}
+
+// sensorUnits maps the remote IDs of imperial parameters to their unit
+var sensorUnits = func() map[string]string {
+	units := make(map[string]string)
+	for _, s := range supportedSensors {
+		if s.unit != "" {
+			units[s.RemoteID] = s.unit
+		}
+	}
+	return units
+}()
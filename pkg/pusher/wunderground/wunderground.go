@@ -0,0 +1,149 @@
+package wunderground
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// ServiceName is the service name of stations uploading in the Weather
+// Underground protocol
+const ServiceName = "wunderground"
+
+// Station parameters of the protocol
+const (
+	paramID       = "ID"
+	paramPassword = "PASSWORD"
+	paramDate     = "dateutc"
+	paramSoftware = "softwaretype"
+)
+
+// Pusher accepts uploads in the Weather Underground protocol, which many
+// consoles and legacy firmwares support as their only custom upload:
+//
+//	GET /weatherstation/updateweatherstation.php?ID=KXYZ123&PASSWORD=key&dateutc=now&tempf=64.4&baromin=29.92
+//
+// The station ID is the passkey of the station. Values are sent in imperial
+// units and converted to metric.
+type Pusher struct{}
+
+// GetEndpoint returns the upload path of the Weather Underground protocol
+func (p *Pusher) GetEndpoint() string {
+	return "/weatherstation/updateweatherstation.php"
+}
+
+// GetStationType returns the station type identifier
+func (p *Pusher) GetStationType() string {
+	return "WeatherUnderground"
+}
+
+// Setup returns the custom server settings of consoles uploading to
+// Weather Underground
+func (p *Pusher) Setup() pusher.Setup {
+	return pusher.Setup{
+		Protocol:     "Wunderground",
+		Interval:     60,
+		Instructions: "Enter this server as Weather Underground server in the console, or select the Wunderground protocol for a customized server, and use the passkey as station ID.",
+	}
+}
+
+// ConfigSchema returns the push settings with the password the uploads
+// must carry
+func (p *Pusher) ConfigSchema() *models.ConfigSchema {
+	return pusher.BaseConfigSchema().
+		Add(models.PushPasswordConfigKey, models.ConfigProperty{
+			Type:        models.ConfigTypeString,
+			Description: "Password (station key) uploads must carry; any password is accepted if empty",
+		})
+}
+
+// PasswordParam returns the parameter carrying the station key
+func (p *Pusher) PasswordParam() string {
+	return paramPassword
+}
+
+// Acknowledgement returns the body firmwares expect for accepted uploads
+func (p *Pusher) Acknowledgement() string {
+	return "success\n"
+}
+
+// SensorTypes returns the sensor types of the protocol
+func (p *Pusher) SensorTypes() []string {
+	var types []string
+	for _, sensor := range GetSupportedSensors() {
+		if !slices.Contains(types, sensor.SensorType) {
+			types = append(types, sensor.SensorType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (p *Pusher) ParseStation(params url.Values) *models.StationData {
+	return &models.StationData{
+		PassKey:     params.Get(paramID),
+		StationType: p.GetStationType(),
+		Model:       params.Get(paramSoftware),
+		Mode:        "push",
+		ServiceName: ServiceName,
+	}
+}
+
+func (p *Pusher) ParseSensors(params url.Values) map[string]models.Sensor {
+	result := make(map[string]models.Sensor)
+	for _, sensor := range GetSupportedSensors() {
+		if params.Get(sensor.RemoteID) != "" {
+			result[sensor.RemoteID] = sensor
+		}
+	}
+	return result
+}
+
+// ParseWeatherData parses the values of an upload
+func (p *Pusher) ParseWeatherData(params url.Values, sensors map[string]models.Sensor) (map[uuid.UUID]models.SensorReading, error) {
+	return p.ParseWeatherDataWithOptions(params, sensors, pusher.ParseOptions{})
+}
+
+// ParseWeatherDataWithOptions parses the values of an upload using station
+// specific options. dateutc is "now" for consoles without clock.
+func (p *Pusher) ParseWeatherDataWithOptions(params url.Values, sensors map[string]models.Sensor, opts pusher.ParseOptions) (map[uuid.UUID]models.SensorReading, error) {
+	dateUTC := opts.Timestamp(params.Get(paramDate))
+
+	result := make(map[uuid.UUID]models.SensorReading)
+	for remoteID, sensor := range sensors {
+		raw := params.Get(remoteID)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("invalid value %q of %s", raw, remoteID)
+		}
+		// Consoles send -9999 for sensors without reading
+		if value == -9999 {
+			continue
+		}
+
+		reading := models.SensorReading{
+			SensorID: sensor.ID,
+			Value:    value,
+			Unit:     models.SensorTypeRegistry[sensor.SensorType].Unit,
+			DateUTC:  dateUTC,
+		}
+		if unit, ok := sensorUnits[remoteID]; ok {
+			rawValue := value
+			reading.Value = conversions[unit](value)
+			reading.RawValue = &rawValue
+			reading.RawUnit = unit
+		}
+		result[sensor.ID] = reading
+	}
+	return result, nil
+}
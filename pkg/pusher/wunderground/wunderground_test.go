@@ -0,0 +1,158 @@
+package wunderground
+
+import (
+	"math"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sguter90/weathermaestro/pkg/models"
+	"github.com/sguter90/weathermaestro/pkg/pusher"
+)
+
+// withIDs assigns IDs to parsed sensors like the ingest does
+func withIDs(sensors map[string]models.Sensor) map[string]models.Sensor {
+	for remoteID, sensor := range sensors {
+		sensor.ID = uuid.New()
+		sensors[remoteID] = sensor
+	}
+	return sensors
+}
+
+func TestPusher_Interfaces(t *testing.T) {
+	var p pusher.Pusher = &Pusher{}
+	if p.GetEndpoint() != "/weatherstation/updateweatherstation.php" {
+		t.Errorf("Unexpected endpoint %s", p.GetEndpoint())
+	}
+	if param := pusher.PasswordParamOf(p); param != "PASSWORD" {
+		t.Errorf("Expected password param PASSWORD, got %q", param)
+	}
+	if params := pusher.CredentialParamsOf(p); len(params) != 1 || params[0] != "PASSWORD" {
+		t.Errorf("Expected credential params [PASSWORD], got %v", params)
+	}
+	ack, ok := p.(pusher.Acknowledger)
+	if !ok || ack.Acknowledgement() != "success\n" {
+		t.Error("Expected uploads to be acknowledged with success")
+	}
+	if _, ok := p.ConfigSchema().Properties[models.PushPasswordConfigKey]; !ok {
+		t.Error("Expected push password in config schema")
+	}
+}
+
+func TestPusher_ParseStation(t *testing.T) {
+	p := &Pusher{}
+	station := p.ParseStation(url.Values{
+		"ID":           {"KXYZ123"},
+		"PASSWORD":     {"key"},
+		"softwaretype": {"WS-2902"},
+	})
+	if station.PassKey != "KXYZ123" {
+		t.Errorf("Expected passkey KXYZ123, got %s", station.PassKey)
+	}
+	if station.Model != "WS-2902" || station.ServiceName != ServiceName || station.Mode != "push" {
+		t.Errorf("Unexpected station %+v", station)
+	}
+}
+
+func TestPusher_ParseSensors(t *testing.T) {
+	p := &Pusher{}
+	sensors := p.ParseSensors(url.Values{
+		"ID":          {"KXYZ123"},
+		"tempf":       {"64.4"},
+		"indoortempf": {"70"},
+		"baromin":     {"29.92"},
+		"dewptf":      {"50"},
+		"humidity":    {""},
+	})
+	if len(sensors) != 3 {
+		t.Fatalf("Expected 3 sensors, got %d: %v", len(sensors), sensors)
+	}
+	if s := sensors["indoortempf"]; s.Location != "Indoor" || s.SensorType != models.SensorTypeTemperature || !s.Enabled {
+		t.Errorf("Unexpected indoor temperature sensor %+v", s)
+	}
+	if _, ok := sensors["dewptf"]; ok {
+		t.Error("Derived dew point must not be a sensor")
+	}
+}
+
+func TestPusher_ParseWeatherData(t *testing.T) {
+	p := &Pusher{}
+	params := url.Values{
+		"ID":           {"KXYZ123"},
+		"dateutc":      {"2026-03-01 12:00:00"},
+		"tempf":        {"64.4"},
+		"humidity":     {"55"},
+		"baromin":      {"29.92"},
+		"windspeedmph": {"10"},
+		"dailyrainin":  {"0.5"},
+		"UV":           {"3"},
+		"indoortempf":  {"-9999"},
+	}
+	sensors := withIDs(p.ParseSensors(params))
+
+	readings, err := p.ParseWeatherData(params, sensors)
+	if err != nil {
+		t.Fatalf("ParseWeatherData() error = %v", err)
+	}
+	if len(readings) != 6 {
+		t.Fatalf("Expected 6 readings without the missing indoor temperature, got %d", len(readings))
+	}
+
+	wantDate := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		remoteID string
+		value    float64
+		rawUnit  string
+	}{
+		{"tempf", 18, unitFahrenheit},
+		{"humidity", 55, ""},
+		{"baromin", 1013.21, unitInHg},
+		{"windspeedmph", 4.4704, unitMph},
+		{"dailyrainin", 12.7, unitInch},
+		{"UV", 3, ""},
+	}
+	for _, tt := range tests {
+		reading, ok := readings[sensors[tt.remoteID].ID]
+		if !ok {
+			t.Errorf("Missing reading of %s", tt.remoteID)
+			continue
+		}
+		if math.Abs(reading.Value-tt.value) > 0.01 {
+			t.Errorf("%s: expected %.2f, got %.4f", tt.remoteID, tt.value, reading.Value)
+		}
+		if reading.RawUnit != tt.rawUnit {
+			t.Errorf("%s: expected raw unit %q, got %q", tt.remoteID, tt.rawUnit, reading.RawUnit)
+		}
+		if !reading.DateUTC.Equal(wantDate) {
+			t.Errorf("%s: expected date %s, got %s", tt.remoteID, wantDate, reading.DateUTC)
+		}
+	}
+}
+
+func TestPusher_ParseWeatherData_Now(t *testing.T) {
+	p := &Pusher{}
+	params := url.Values{"ID": {"KXYZ123"}, "dateutc": {"now"}, "tempf": {"32"}}
+	sensors := withIDs(p.ParseSensors(params))
+	received := time.Date(2026, 3, 1, 12, 0, 5, 0, time.UTC)
+
+	readings, err := p.ParseWeatherDataWithOptions(params, sensors, pusher.ParseOptions{Fallback: received})
+	if err != nil {
+		t.Fatalf("ParseWeatherDataWithOptions() error = %v", err)
+	}
+	reading := readings[sensors["tempf"].ID]
+	if !reading.DateUTC.Equal(received) {
+		t.Errorf("Expected receive time for dateutc=now, got %s", reading.DateUTC)
+	}
+	if math.Abs(reading.Value) > 1e-9 {
+		t.Errorf("Expected 0 °C, got %f", reading.Value)
+	}
+}
+
+func TestPusher_ParseWeatherData_Invalid(t *testing.T) {
+	p := &Pusher{}
+	params := url.Values{"ID": {"KXYZ123"}, "tempf": {"warm"}}
+	if _, err := p.ParseWeatherData(params, withIDs(p.ParseSensors(params))); err == nil {
+		t.Error("Expected error for invalid value")
+	}
+}
@@ -0,0 +1,49 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Replace atomically replaces the binary at path, keeping its permissions.
+// The old binary is moved aside first, as running binaries can't be
+// overwritten on every platform; it is removed afterwards if possible.
+func Replace(path string, binary []byte) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".weathermaestro-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to write next to %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	old := path + ".old"
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("failed to move %s aside: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		// Restore the old binary
+		os.Rename(old, path)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	os.Remove(old)
+	return nil
+}
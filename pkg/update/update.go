@@ -0,0 +1,311 @@
+// Package update finds the latest release of WeatherMaestro, verifies its
+// signed checksums and replaces the running binary.
+//
+// Every release carries one binary per platform, named by AssetName, a
+// SHA256SUMS file in the format of sha256sum and SHA256SUMS.sig, the
+// base64 encoded Ed25519 signature of SHA256SUMS. SHA256SUMS starts with
+// a "# version 1.4.0" line, so the version is covered by the signature and
+// a validly signed older release can't be passed off as the latest one.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the checksum files of a release
+const (
+	ChecksumsAsset = "SHA256SUMS"
+	SignatureAsset = "SHA256SUMS.sig"
+)
+
+// DefaultBaseURL is the API the releases are queried from
+const DefaultBaseURL = "https://api.github.com"
+
+// maxBinarySize limits downloaded binaries
+const maxBinarySize = 256 << 20
+
+// ErrNoAsset is returned if a release has no binary for a platform
+var ErrNoAsset = errors.New("release has no binary for this platform")
+
+// ErrNotNewer is returned if the signed version of a release is not newer
+// than the running one
+var ErrNotNewer = errors.New("release is not newer than the running version")
+
+// versionPrefix starts the line of SHA256SUMS with the release version
+const versionPrefix = "# version "
+
+// Asset is a file of a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a published release
+type Release struct {
+	// Version is the tag without a leading v, e.g. 1.4.0
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"-"`
+}
+
+// Asset returns the asset with the given name
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Client queries the releases of a GitHub repository
+type Client struct {
+	BaseURL    string
+	Repository string
+	HTTP       *http.Client
+}
+
+// NewClient creates a client for a repository like owner/name
+func NewClient(baseURL, repository string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Repository: repository,
+		HTTP:       &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Latest returns the newest release that is neither a draft nor a
+// pre-release
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s/releases/latest", c.BaseURL, c.Repository), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query releases: %s", resp.Status)
+	}
+
+	var data struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+		Assets      []Asset   `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid release: %w", err)
+	}
+	if data.TagName == "" {
+		return nil, errors.New("invalid release: tag is missing")
+	}
+	return &Release{
+		Version:     strings.TrimPrefix(data.TagName, "v"),
+		URL:         data.HTMLURL,
+		PublishedAt: data.PublishedAt,
+		Assets:      data.Assets,
+	}, nil
+}
+
+// Download fetches the binary of a platform and checks it against the
+// checksums of the release, whose signature must verify with publicKey.
+// The signed version must match the release and be newer than current;
+// an empty current skips the latter, e.g. to reinstall a release.
+func (c *Client) Download(ctx context.Context, release *Release, goos, goarch string, publicKey ed25519.PublicKey, current string) ([]byte, error) {
+	name := AssetName(goos, goarch)
+	asset, ok := release.Asset(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoAsset, name)
+	}
+	checksumsAsset, ok := release.Asset(ChecksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("release has no %s", ChecksumsAsset)
+	}
+	signatureAsset, ok := release.Asset(SignatureAsset)
+	if !ok {
+		return nil, fmt.Errorf("release has no %s", SignatureAsset)
+	}
+
+	checksums, err := c.fetch(ctx, checksumsAsset.URL, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := c.fetch(ctx, signatureAsset.URL, 4096)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifySignature(publicKey, checksums, signature); err != nil {
+		return nil, err
+	}
+	signed, err := SignedVersion(checksums)
+	if err != nil {
+		return nil, err
+	}
+	if signed != release.Version {
+		return nil, fmt.Errorf("signed version %s doesn't match release %s", signed, release.Version)
+	}
+	if current != "" && !Newer(current, signed) {
+		return nil, fmt.Errorf("%w: %s", ErrNotNewer, signed)
+	}
+	want, err := Checksum(checksums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := c.fetch(ctx, asset.URL, maxBinarySize)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	if hex.EncodeToString(sum[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch of %s", name)
+	}
+	return binary, nil
+}
+
+// fetch downloads a file of at most limit bytes
+func (c *Client) fetch(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// AssetName returns the name of the binary of a platform, e.g.
+// weathermaestro-linux-arm64. 32-bit ARM binaries are built for ARMv7.
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("weathermaestro-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// ParsePublicKey decodes a base64 encoded Ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("public key must be a base64 encoded Ed25519 key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifySignature checks the base64 encoded signature of the checksums
+func VerifySignature(publicKey ed25519.PublicKey, checksums, signature []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if !ed25519.Verify(publicKey, checksums, sig) {
+		return errors.New("signature of the checksums is invalid")
+	}
+	return nil
+}
+
+// Checksum returns the SHA-256 of a file listed in a SHA256SUMS file
+func Checksum(checksums []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		// Binary mode marks the name with a leading *
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not listed in %s", name, ChecksumsAsset)
+}
+
+// SignedVersion returns the release version of a SHA256SUMS file, without
+// a leading v
+func SignedVersion(checksums []byte) (string, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), versionPrefix); ok {
+			version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+			if _, ok := parseVersion(version); !ok {
+				return "", fmt.Errorf("invalid version %q in %s", version, ChecksumsAsset)
+			}
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no version line", ChecksumsAsset)
+}
+
+// Newer reports whether version latest is newer than current. Versions
+// are compared by their dotted numbers, e.g. 1.10.0 > 1.9.2; development
+// builds like "dev" are never older.
+func Newer(current, latest string) bool {
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(c) || i < len(l); i++ {
+		var a, b int
+		if i < len(c) {
+			a = c[i]
+		}
+		if i < len(l) {
+			b = l[i]
+		}
+		if a != b {
+			return b > a
+		}
+	}
+	return false
+}
+
+// parseVersion splits a version like v1.4.0 or 1.4.0-rc1 into its numbers
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	var numbers []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
@@ -0,0 +1,195 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"1.4.0", "1.4.1", true},
+		{"v1.9.2", "v1.10.0", true},
+		{"1.4.0", "1.4.0", false},
+		{"1.5.0", "1.4.9", false},
+		{"1.4", "1.4.1", true},
+		{"1.4.0-rc1", "1.4.0", false},
+		{"dev", "1.4.0", false},
+		{"1.4.0", "nightly", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.latest); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestAssetName(t *testing.T) {
+	if got := AssetName("linux", "arm64"); got != "weathermaestro-linux-arm64" {
+		t.Errorf("got %q", got)
+	}
+	if got := AssetName("windows", "amd64"); got != "weathermaestro-windows-amd64.exe" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	sums := []byte("aa11  weathermaestro-linux-amd64\nBB22 *weathermaestro-linux-arm64\n")
+	if got, err := Checksum(sums, "weathermaestro-linux-arm64"); err != nil || got != "bb22" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := Checksum(sums, "weathermaestro-darwin-arm64"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub) + "\n")
+	if err != nil || !key.Equal(pub) {
+		t.Fatalf("got %v, %v", key, err)
+	}
+	if _, err := ParsePublicKey("c2hvcnQ="); err == nil {
+		t.Error("expected error for short key")
+	}
+}
+
+// releaseServer serves a release with the given binary, signed by priv
+func releaseServer(t *testing.T, binary []byte, checksum, signedVersion string, priv ed25519.PrivateKey) *httptest.Server {
+	t.Helper()
+	name := AssetName("linux", "arm64")
+	if checksum == "" {
+		sum := sha256.Sum256(binary)
+		checksum = hex.EncodeToString(sum[:])
+	}
+	sums := []byte(fmt.Sprintf("# version %s\n%s  %s\n", signedVersion, checksum, name))
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sums))
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/owner/repo/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v1.5.0","html_url":"https://example.com/v1.5.0","published_at":"2026-10-01T12:00:00Z","assets":[
+			{"name":%q,"browser_download_url":"%s/bin"},
+			{"name":"SHA256SUMS","browser_download_url":"%s/sums"},
+			{"name":"SHA256SUMS.sig","browser_download_url":"%s/sig"}]}`, name, srv.URL, srv.URL, srv.URL)
+	})
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) { w.Write(sums) })
+	mux.HandleFunc("/sig", func(w http.ResponseWriter, r *http.Request) { fmt.Fprintln(w, sig) })
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientDownload(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	binary := []byte("new binary")
+	srv := releaseServer(t, binary, "", "1.5.0", priv)
+	c := NewClient(srv.URL, "owner/repo")
+
+	release, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if release.Version != "1.5.0" || release.URL != "https://example.com/v1.5.0" || release.PublishedAt.IsZero() {
+		t.Errorf("unexpected release %+v", release)
+	}
+
+	got, err := c.Download(context.Background(), release, "linux", "arm64", pub, "1.4.0")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if string(got) != string(binary) {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := c.Download(context.Background(), release, "linux", "arm64", pub, "1.5.0"); !errors.Is(err, ErrNotNewer) {
+		t.Errorf("expected ErrNotNewer, got %v", err)
+	}
+	if _, err := c.Download(context.Background(), release, "linux", "arm64", pub, ""); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if _, err := c.Download(context.Background(), release, "darwin", "arm64", pub, "1.4.0"); !errors.Is(err, ErrNoAsset) {
+		t.Errorf("expected ErrNoAsset, got %v", err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := c.Download(context.Background(), release, "linux", "arm64", other, "1.4.0"); err == nil {
+		t.Error("expected signature error for another key")
+	}
+}
+
+func TestClientDownloadChecksumMismatch(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	srv := releaseServer(t, []byte("tampered"), hex.EncodeToString(make([]byte, 32)), "1.5.0", priv)
+	c := NewClient(srv.URL, "owner/repo")
+
+	release, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if _, err := c.Download(context.Background(), release, "linux", "arm64", pub, "1.4.0"); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+}
+
+func TestClientDownloadSignedVersionMismatch(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	// An old, validly signed release served as the latest one
+	srv := releaseServer(t, []byte("old binary"), "", "1.2.0", priv)
+	c := NewClient(srv.URL, "owner/repo")
+
+	release, err := c.Latest(context.Background())
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if _, err := c.Download(context.Background(), release, "linux", "arm64", pub, "1.4.0"); err == nil {
+		t.Error("expected version mismatch")
+	}
+}
+
+func TestSignedVersion(t *testing.T) {
+	if v, err := SignedVersion([]byte("# version v1.4.0\nabc  weathermaestro-linux-arm64\n")); err != nil || v != "1.4.0" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	if _, err := SignedVersion([]byte("abc  weathermaestro-linux-arm64\n")); err == nil {
+		t.Error("expected error without version line")
+	}
+	if _, err := SignedVersion([]byte("# version latest\n")); err == nil {
+		t.Error("expected error for invalid version")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weathermaestro")
+	if err := os.WriteFile(path, []byte("old"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "new" {
+		t.Errorf("got %q", data)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0o750 {
+		t.Errorf("mode = %v", info.Mode().Perm())
+	}
+	if _, err := os.Stat(path + ".old"); !os.IsNotExist(err) {
+		t.Error("old binary was not removed")
+	}
+}
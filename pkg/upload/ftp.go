@@ -0,0 +1,219 @@
+package upload
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ftpUploader uploads files over FTP in passive mode, optionally secured
+// with explicit TLS
+type ftpUploader struct {
+	conn      net.Conn
+	text      *textproto.Conn
+	host      string
+	tlsConfig *tls.Config
+	dirs      map[string]bool
+}
+
+func dialFTP(ctx context.Context, t Target) (Uploader, error) {
+	port := t.Port
+	if port == 0 {
+		port = 21
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(t.Host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	c := &ftpUploader{
+		conn: conn,
+		text: textproto.NewConn(conn),
+		host: t.Host,
+		dirs: make(map[string]bool),
+	}
+	if err := c.login(ctx, t); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ftpUploader) login(ctx context.Context, t Target) error {
+	c.conn.SetDeadline(deadline(ctx))
+
+	if _, _, err := c.text.ReadResponse(220); err != nil {
+		return fmt.Errorf("unexpected greeting: %w", err)
+	}
+
+	if t.TLS {
+		if _, err := c.cmd(234, "AUTH TLS"); err != nil {
+			return err
+		}
+		c.tlsConfig = &tls.Config{
+			ServerName:         t.Host,
+			ClientSessionCache: tls.NewLRUClientSessionCache(4),
+		}
+		tlsConn := tls.Client(c.conn, c.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		c.conn = tlsConn
+		c.text = textproto.NewConn(tlsConn)
+		if _, err := c.cmd(200, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, err := c.cmd(200, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	code, err := c.cmd(0, "USER %s", t.User)
+	if err != nil {
+		return err
+	}
+	if code == 331 {
+		if _, err := c.cmd(230, "PASS %s", t.Password); err != nil {
+			return fmt.Errorf("login failed: %w", err)
+		}
+	} else if code != 230 {
+		return fmt.Errorf("login failed: unexpected response %d", code)
+	}
+
+	_, err = c.cmd(200, "TYPE I")
+	return err
+}
+
+// cmd sends a command and reads the response; expectCode 0 accepts any
+// reply that is not an error
+func (c *ftpUploader) cmd(expectCode int, format string, args ...interface{}) (int, error) {
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return 0, err
+	}
+	if expectCode == 0 {
+		code, msg, err := c.text.ReadResponse(0)
+		if err != nil {
+			return code, err
+		}
+		if code >= 400 {
+			return code, &textproto.Error{Code: code, Msg: msg}
+		}
+		return code, nil
+	}
+	code, _, err := c.text.ReadResponse(expectCode)
+	return code, err
+}
+
+func (c *ftpUploader) Upload(ctx context.Context, name string, data []byte) error {
+	c.conn.SetDeadline(deadline(ctx))
+
+	for _, dir := range parentDirs(name) {
+		if c.dirs[dir] {
+			continue
+		}
+		// The directory usually exists already, so errors are ignored and
+		// surface when storing the file
+		c.cmd(0, "MKD %s", dir)
+		c.dirs[dir] = true
+	}
+
+	// Upload to a temporary name and rename, so readers never see a
+	// partially written file
+	tmp := path.Join(path.Dir(name), "."+path.Base(name)+".tmp")
+	if err := c.store(ctx, tmp, data); err != nil {
+		return fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	if _, err := c.cmd(350, "RNFR %s", tmp); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", name, err)
+	}
+	if _, err := c.cmd(250, "RNTO %s", name); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", name, err)
+	}
+	return nil
+}
+
+// store transfers data over a passive mode data connection
+func (c *ftpUploader) store(ctx context.Context, name string, data []byte) error {
+	if err := c.text.PrintfLine("PASV"); err != nil {
+		return err
+	}
+	_, msg, err := c.text.ReadResponse(227)
+	if err != nil {
+		return err
+	}
+	port, err := parsePASV(msg)
+	if err != nil {
+		return err
+	}
+
+	// The advertised address is ignored, it is often wrong behind NAT
+	var d net.Dialer
+	dataConn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(c.host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to open data connection: %w", err)
+	}
+	defer dataConn.Close()
+	dataConn.SetDeadline(deadline(ctx))
+
+	if err := c.text.PrintfLine("STOR %s", name); err != nil {
+		return err
+	}
+	code, msg, err := c.text.ReadResponse(1)
+	if err != nil {
+		return err
+	}
+	if code != 125 && code != 150 {
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+
+	var w net.Conn = dataConn
+	if c.tlsConfig != nil {
+		tlsConn := tls.Client(dataConn, c.tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		w = tlsConn
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	_, _, err = c.text.ReadResponse(2)
+	return err
+}
+
+// parsePASV extracts the port of a "227 Entering Passive Mode
+// (h1,h2,h3,h4,p1,p2)" response
+func parsePASV(msg string) (int, error) {
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("invalid PASV response: %s", msg)
+	}
+
+	parts := strings.Split(msg[start+1:end], ",")
+	if len(parts) != 6 {
+		return 0, fmt.Errorf("invalid PASV response: %s", msg)
+	}
+	p1, err1 := strconv.Atoi(strings.TrimSpace(parts[4]))
+	p2, err2 := strconv.Atoi(strings.TrimSpace(parts[5]))
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("invalid PASV response: %s", msg)
+	}
+	return p1<<8 | p2, nil
+}
+
+func (c *ftpUploader) Close() error {
+	c.text.PrintfLine("QUIT")
+	return c.conn.Close()
+}
@@ -0,0 +1,172 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFTPServer implements the FTP commands used by the uploader
+type fakeFTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	files    map[string]string
+	dirs     []string
+}
+
+func newFakeFTPServer(t *testing.T) *fakeFTPServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeFTPServer{listener: l, files: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *fakeFTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeFTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) { fmt.Fprintf(conn, format+"\r\n", args...) }
+
+	var data net.Listener
+	var renameFrom string
+	reply("220 ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		switch cmd {
+		case "USER":
+			reply("331 password required")
+		case "PASS":
+			if arg != "secret" {
+				reply("530 login incorrect")
+				continue
+			}
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 ok")
+		case "MKD":
+			s.mu.Lock()
+			s.dirs = append(s.dirs, arg)
+			s.mu.Unlock()
+			reply("257 created")
+		case "PASV":
+			data, _ = net.Listen("tcp", "127.0.0.1:0")
+			port := data.Addr().(*net.TCPAddr).Port
+			reply("227 Entering Passive Mode (10,0,0,1,%d,%d)", port>>8, port&0xff)
+		case "STOR":
+			reply("150 opening data connection")
+			dataConn, err := data.Accept()
+			data.Close()
+			if err != nil {
+				reply("425 no data connection")
+				continue
+			}
+			content, _ := io.ReadAll(dataConn)
+			dataConn.Close()
+			s.mu.Lock()
+			s.files[arg] = string(content)
+			s.mu.Unlock()
+			reply("226 transfer complete")
+		case "RNFR":
+			renameFrom = arg
+			reply("350 ready for RNTO")
+		case "RNTO":
+			s.mu.Lock()
+			s.files[arg] = s.files[renameFrom]
+			delete(s.files, renameFrom)
+			s.mu.Unlock()
+			reply("250 renamed")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestFTPUpload(t *testing.T) {
+	server := newFakeFTPServer(t)
+
+	u, err := Dial(context.Background(), Target{
+		Type:     TypeFTP,
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		User:     "wx",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	if err := u.Upload(context.Background(), "www/NOAA/NOAA-2026-03.txt", []byte("report")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := u.Upload(context.Background(), "www/index.html", []byte("index")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	u.Close()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.files["www/NOAA/NOAA-2026-03.txt"] != "report" || server.files["www/index.html"] != "index" {
+		t.Errorf("Unexpected files %v", server.files)
+	}
+	if len(server.files) != 2 {
+		t.Errorf("Expected temporary files to be renamed, got %v", server.files)
+	}
+	if strings.Join(server.dirs, ",") != "www,www/NOAA" {
+		t.Errorf("Expected each directory to be created once, got %v", server.dirs)
+	}
+}
+
+func TestFTPUpload_LoginFailed(t *testing.T) {
+	server := newFakeFTPServer(t)
+
+	_, err := Dial(context.Background(), Target{
+		Type:     TypeFTP,
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		User:     "wx",
+		Password: "wrong",
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "530") {
+		t.Errorf("Expected login error, got %v", err)
+	}
+}
+
+func TestParsePASV(t *testing.T) {
+	port, err := parsePASV("Entering Passive Mode (192,168,1,2,195,80).")
+	if err != nil || port != 195*256+80 {
+		t.Errorf("Expected port %d, got %d: %v", 195*256+80, port, err)
+	}
+
+	if _, err := parsePASV("Entering Passive Mode"); err == nil {
+		t.Error("Expected error for invalid response")
+	}
+}
@@ -0,0 +1,267 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Uploader stores files as objects with signed PUT requests (AWS
+// Signature Version 4). It works with AWS S3 and compatible services like
+// MinIO, Cloudflare R2 or Backblaze B2.
+type s3Uploader struct {
+	target Target
+	client *http.Client
+	// streamClient transfers streamed objects; they may take longer than
+	// operationTimeout, so only the context limits them
+	streamClient *http.Client
+	now          func() time.Time
+}
+
+// ObjectStore reads and writes the objects of an S3-compatible bucket
+type ObjectStore interface {
+	Uploader
+
+	// UploadStream stores size bytes read from body under name.
+	// payloadHash is the hex encoded SHA-256 of the content, which S3
+	// requires before the upload.
+	UploadStream(ctx context.Context, name string, body io.Reader, size int64, payloadHash string) error
+
+	// Download returns the content of an object
+	Download(ctx context.Context, name string) ([]byte, error)
+
+	// Open returns a reader of the content of an object, which must be
+	// closed
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// NewObjectStore returns the object store of an S3 target
+func NewObjectStore(t Target) (ObjectStore, error) {
+	if t.Type != TypeS3 {
+		return nil, fmt.Errorf("object storage requires type s3, got %q", t.Type)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return newS3(t), nil
+}
+
+func newS3(t Target) *s3Uploader {
+	return &s3Uploader{
+		target:       t,
+		client:       &http.Client{Timeout: operationTimeout},
+		streamClient: &http.Client{},
+		now:          time.Now,
+	}
+}
+
+// objectURL returns the URL of an object. A custom endpoint uses path-style
+// addressing, AWS virtual-hosted-style.
+func (s *s3Uploader) objectURL(key string) string {
+	key = strings.TrimPrefix(key, "/")
+	if s.target.Endpoint != "" {
+		return strings.TrimSuffix(s.target.Endpoint, "/") + "/" + s.target.Bucket + "/" + s3EscapePath(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.target.Bucket, s.target.Region, s3EscapePath(key))
+}
+
+func (s *s3Uploader) Upload(ctx context.Context, name string, data []byte) error {
+	hash := sha256.Sum256(data)
+	return s.put(ctx, s.client, name, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(hash[:]))
+}
+
+func (s *s3Uploader) UploadStream(ctx context.Context, name string, body io.Reader, size int64, payloadHash string) error {
+	return s.put(ctx, s.streamClient, name, body, size, payloadHash)
+}
+
+// put stores an object with a PUT request
+func (s *s3Uploader) put(ctx context.Context, client *http.Client, name string, body io.Reader, size int64, payloadHash string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.target.ACL != "" {
+		req.Header.Set("X-Amz-Acl", s.target.ACL)
+	}
+
+	s.sign(req, payloadHash)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload %s: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (s *s3Uploader) Download(ctx context.Context, name string) ([]byte, error) {
+	body, err := s.get(ctx, s.client, name)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (s *s3Uploader) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.get(ctx, s.streamClient, name)
+}
+
+// get requests an object and returns the response body
+func (s *s3Uploader) get(ctx context.Context, client *http.Client, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to download %s: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Uploader) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign signs a request with the credentials of the target
+func (s *s3Uploader) sign(req *http.Request, payloadHash string) {
+	region := s.target.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	signV4(req, payloadHash, s.target.AccessKey, s.target.SecretKey, region, s.now())
+}
+
+// signV4 adds the AWS Signature Version 4 authorization to an S3 request.
+// The host, Content-Type, Range and all X-Amz-* headers are signed.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name
+func canonicalQuery(values url.Values) string {
+	var params []string
+	for name, vals := range values {
+		for _, v := range vals {
+			params = append(params, s3Escape(name)+"="+s3Escape(v))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3EscapePath percent-encodes each segment of an object key
+func s3EscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = s3Escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes all characters except the RFC 3986 unreserved ones
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
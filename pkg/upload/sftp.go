@@ -0,0 +1,430 @@
+package upload
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTP packet types and status codes (draft-ietf-secsh-filexfer-02)
+const (
+	sftpInit           = 1
+	sftpVersion        = 2
+	sftpOpen           = 3
+	sftpClose          = 4
+	sftpWrite          = 6
+	sftpRemove         = 13
+	sftpMkdir          = 14
+	sftpStat           = 17
+	sftpRename         = 18
+	sftpStatus         = 101
+	sftpHandle         = 102
+	sftpAttrs          = 105
+	sftpExtended       = 200
+	sftpStatusOK       = 0
+	sftpNoSuchFile     = 2
+	sftpOpenWrite      = 0x02
+	sftpOpenCreate     = 0x08
+	sftpOpenTruncate   = 0x10
+	sftpChunkSize      = 32 * 1024
+	sftpPosixRenameExt = "posix-rename@openssh.com"
+)
+
+// sftpUploader is a minimal SFTP client over SSH that supports writing
+// files and creating directories
+type sftpUploader struct {
+	client      *ssh.Client
+	session     *ssh.Session
+	conn        net.Conn
+	w           io.WriteCloser
+	r           io.Reader
+	id          uint32
+	posixRename bool
+	dirs        map[string]bool
+}
+
+// sftpError is a non-OK status returned by the server
+type sftpError struct {
+	Code uint32
+	Msg  string
+}
+
+func (e *sftpError) Error() string {
+	return fmt.Sprintf("sftp status %d: %s", e.Code, e.Msg)
+}
+
+func dialSFTP(ctx context.Context, t Target) (Uploader, error) {
+	config, err := sshClientConfig(t)
+	if err != nil {
+		return nil, err
+	}
+
+	port := t.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := net.JoinHostPort(t.Host, strconv.Itoa(port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	conn.SetDeadline(deadline(ctx))
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake failed: %w", err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	c := &sftpUploader{client: client, conn: conn, dirs: make(map[string]bool)}
+	if err := c.start(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// sshClientConfig builds the SSH authentication and host key settings
+func sshClientConfig(t Target) (*ssh.ClientConfig, error) {
+	config := &ssh.ClientConfig{User: t.User, Timeout: operationTimeout}
+
+	if t.KeyFile != "" {
+		key, err := os.ReadFile(expandHome(t.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		var signer ssh.Signer
+		if t.KeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(t.KeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key file: %w", err)
+		}
+		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+	}
+	if t.Password != "" {
+		config.Auth = append(config.Auth, ssh.Password(t.Password))
+	}
+
+	if t.InsecureIgnoreHostKey {
+		config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return config, nil
+	}
+	file := t.KnownHosts
+	if file == "" {
+		file = "~/.ssh/known_hosts"
+	}
+	callback, err := knownhosts.New(expandHome(file))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+	config.HostKeyCallback = callback
+	return config, nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(p string) string {
+	if len(p) < 2 || p[:2] != "~/" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[2:])
+}
+
+// start opens the sftp subsystem and negotiates the protocol version
+func (c *sftpUploader) start() error {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open session: %w", err)
+	}
+	c.session = session
+	if c.w, err = session.StdinPipe(); err != nil {
+		return err
+	}
+	if c.r, err = session.StdoutPipe(); err != nil {
+		return err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return fmt.Errorf("failed to start sftp subsystem: %w", err)
+	}
+
+	var init sftpPacket
+	init.uint32(3)
+	if err := c.send(sftpInit, init); err != nil {
+		return err
+	}
+	typ, data, err := c.recv()
+	if err != nil {
+		return err
+	}
+	if typ != sftpVersion || len(data) < 4 {
+		return fmt.Errorf("unexpected sftp version response %d", typ)
+	}
+
+	// Extensions follow the version as name/data string pairs
+	r := sftpReader(data[4:])
+	for len(r) > 0 {
+		name, ok1 := r.string()
+		_, ok2 := r.string()
+		if !ok1 || !ok2 {
+			break
+		}
+		if name == sftpPosixRenameExt {
+			c.posixRename = true
+		}
+	}
+	return nil
+}
+
+func (c *sftpUploader) Upload(ctx context.Context, name string, data []byte) error {
+	c.conn.SetDeadline(deadline(ctx))
+
+	for _, dir := range parentDirs(name) {
+		if c.dirs[dir] {
+			continue
+		}
+		if err := c.mkdir(dir); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+		c.dirs[dir] = true
+	}
+
+	// Upload to a temporary name and rename, so readers never see a
+	// partially written file
+	tmp := path.Join(path.Dir(name), "."+path.Base(name)+".tmp")
+	if err := c.write(tmp, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := c.rename(tmp, name); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", name, err)
+	}
+	return nil
+}
+
+// mkdir creates a directory unless it exists
+func (c *sftpUploader) mkdir(dir string) error {
+	var p sftpPacket
+	p.string(dir)
+	err := c.request(sftpStat, p, sftpAttrs)
+	var status *sftpError
+	if !errors.As(err, &status) || status.Code != sftpNoSuchFile {
+		return err
+	}
+
+	p = sftpPacket{}
+	p.string(dir)
+	p.uint32(0) // no attributes
+	return c.request(sftpMkdir, p, sftpStatus)
+}
+
+func (c *sftpUploader) write(name string, data []byte) error {
+	var p sftpPacket
+	p.string(name)
+	p.uint32(sftpOpenWrite | sftpOpenCreate | sftpOpenTruncate)
+	p.uint32(0) // no attributes
+	id, err := c.call(sftpOpen, p)
+	if err != nil {
+		return err
+	}
+	typ, resp, err := c.recvFor(id)
+	if err != nil {
+		return err
+	}
+	if typ != sftpHandle {
+		return statusError(typ, resp)
+	}
+	r := sftpReader(resp)
+	handle, ok := r.string()
+	if !ok {
+		return errors.New("invalid sftp handle")
+	}
+
+	var writeErr error
+	for offset := 0; offset < len(data) && writeErr == nil; offset += sftpChunkSize {
+		end := min(offset+sftpChunkSize, len(data))
+		p = sftpPacket{}
+		p.string(handle)
+		p.uint64(uint64(offset))
+		p.bytes(data[offset:end])
+		writeErr = c.request(sftpWrite, p, sftpStatus)
+	}
+
+	p = sftpPacket{}
+	p.string(handle)
+	if err := c.request(sftpClose, p, sftpStatus); writeErr == nil {
+		writeErr = err
+	}
+	return writeErr
+}
+
+// rename replaces newName with oldName. Plain SFTP rename fails when the
+// target exists, so the target is removed first without the OpenSSH
+// posix-rename extension.
+func (c *sftpUploader) rename(oldName, newName string) error {
+	var p sftpPacket
+	if c.posixRename {
+		p.string(sftpPosixRenameExt)
+		p.string(oldName)
+		p.string(newName)
+		return c.request(sftpExtended, p, sftpStatus)
+	}
+
+	p.string(newName)
+	err := c.request(sftpRemove, p, sftpStatus)
+	var status *sftpError
+	if err != nil && (!errors.As(err, &status) || status.Code != sftpNoSuchFile) {
+		return err
+	}
+
+	p = sftpPacket{}
+	p.string(oldName)
+	p.string(newName)
+	return c.request(sftpRename, p, sftpStatus)
+}
+
+// request sends a packet and waits for a response of the expected type.
+// Status responses other than OK are returned as *sftpError.
+func (c *sftpUploader) request(typ byte, p sftpPacket, expect byte) error {
+	id, err := c.call(typ, p)
+	if err != nil {
+		return err
+	}
+	respType, resp, err := c.recvFor(id)
+	if err != nil {
+		return err
+	}
+	if respType == expect && expect != sftpStatus {
+		return nil
+	}
+	return statusError(respType, resp)
+}
+
+// call sends a request with a new request id
+func (c *sftpUploader) call(typ byte, p sftpPacket) (uint32, error) {
+	c.id++
+	var req sftpPacket
+	req.uint32(c.id)
+	req = append(req, p...)
+	return c.id, c.send(typ, req)
+}
+
+// recvFor reads the response to a request; requests are not pipelined, so
+// it must be the next packet
+func (c *sftpUploader) recvFor(id uint32) (byte, []byte, error) {
+	typ, data, err := c.recv()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) != id {
+		return 0, nil, errors.New("unexpected sftp response id")
+	}
+	return typ, data[4:], nil
+}
+
+func (c *sftpUploader) send(typ byte, payload sftpPacket) error {
+	buf := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)+1))
+	buf[4] = typ
+	_, err := c.w.Write(append(buf, payload...))
+	return err
+}
+
+func (c *sftpUploader) recv() (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, fmt.Errorf("failed to read sftp response: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 256*1024 {
+		return 0, nil, fmt.Errorf("invalid sftp packet length %d", length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return 0, nil, fmt.Errorf("failed to read sftp response: %w", err)
+	}
+	return header[4], data, nil
+}
+
+func (c *sftpUploader) Close() error {
+	if c.session != nil {
+		c.session.Close()
+	}
+	return c.client.Close()
+}
+
+// statusError converts a response into an error; OK status yields nil
+func statusError(typ byte, resp []byte) error {
+	if typ != sftpStatus {
+		return fmt.Errorf("unexpected sftp response %d", typ)
+	}
+	r := sftpReader(resp)
+	code, ok := r.uint32()
+	if !ok {
+		return errors.New("invalid sftp status")
+	}
+	if code == sftpStatusOK {
+		return nil
+	}
+	msg, _ := r.string()
+	return &sftpError{Code: code, Msg: msg}
+}
+
+// sftpPacket builds the payload of a packet
+type sftpPacket []byte
+
+func (p *sftpPacket) uint32(v uint32) {
+	*p = binary.BigEndian.AppendUint32(*p, v)
+}
+
+func (p *sftpPacket) uint64(v uint64) {
+	*p = binary.BigEndian.AppendUint64(*p, v)
+}
+
+func (p *sftpPacket) bytes(b []byte) {
+	p.uint32(uint32(len(b)))
+	*p = append(*p, b...)
+}
+
+func (p *sftpPacket) string(s string) {
+	p.bytes([]byte(s))
+}
+
+// sftpReader decodes the payload of a packet
+type sftpReader []byte
+
+func (r *sftpReader) uint32() (uint32, bool) {
+	if len(*r) < 4 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint32(*r)
+	*r = (*r)[4:]
+	return v, true
+}
+
+func (r *sftpReader) string() (string, bool) {
+	n, ok := r.uint32()
+	if !ok || uint32(len(*r)) < n {
+		return "", false
+	}
+	s := string((*r)[:n])
+	*r = (*r)[n:]
+	return s, true
+}
@@ -0,0 +1,324 @@
+// Package upload copies generated files like the static site to remote
+// targets via FTP, SFTP or S3-compatible object storage.
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Target types
+const (
+	TypeFTP  = "ftp"
+	TypeSFTP = "sftp"
+	TypeS3   = "s3"
+)
+
+// DefaultRetries is the number of retries of a failed file upload
+const DefaultRetries = 2
+
+// retryDelay is the wait time before the first retry; it grows with each attempt
+var retryDelay = 2 * time.Second
+
+// Target configures an upload destination
+type Target struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// Source is the directory relative to the output directory to upload;
+	// empty uploads the whole output
+	Source string `json:"source,omitempty"`
+	// Path is the remote directory (FTP, SFTP) or key prefix (S3)
+	Path string `json:"path,omitempty"`
+	// Retries of a failed file upload, nil uses DefaultRetries
+	Retries *int `json:"retries,omitempty"`
+
+	// FTP and SFTP
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// FTP: use explicit TLS (AUTH TLS)
+	TLS bool `json:"tls,omitempty"`
+
+	// SFTP
+	KeyFile               string `json:"key_file,omitempty"`
+	KeyPassphrase         string `json:"key_passphrase,omitempty"`
+	KnownHosts            string `json:"known_hosts,omitempty"`
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key,omitempty"`
+
+	// S3; Endpoint is only needed for S3-compatible services and selects
+	// path-style addressing
+	Endpoint  string `json:"endpoint,omitempty"`
+	Bucket    string `json:"bucket,omitempty"`
+	Region    string `json:"region,omitempty"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+	ACL       string `json:"acl,omitempty"`
+}
+
+// Config is the content of an upload config file
+type Config struct {
+	Targets []Target `json:"targets"`
+}
+
+// LoadConfig reads upload targets from a JSON file. ${VAR} references are
+// replaced with environment variables so secrets can be kept out of the file.
+func LoadConfig(file string) ([]Target, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse upload config: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for i := range cfg.Targets {
+		t := &cfg.Targets[i]
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("%s-%d", t.Type, i+1)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("duplicate upload target %q", t.Name)
+		}
+		names[t.Name] = true
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("upload target %q: %w", t.Name, err)
+		}
+	}
+	return cfg.Targets, nil
+}
+
+// Validate checks that all settings required by the target type are present
+func (t *Target) Validate() error {
+	if t.Retries != nil && *t.Retries < 0 {
+		return errors.New("retries must not be negative")
+	}
+	if strings.Contains(t.Source, "..") || filepath.IsAbs(t.Source) {
+		return errors.New("source must be relative to the output directory")
+	}
+
+	switch t.Type {
+	case TypeFTP, TypeSFTP:
+		if t.Host == "" {
+			return errors.New("host is required")
+		}
+		if t.User == "" {
+			return errors.New("user is required")
+		}
+		if t.Type == TypeFTP && t.KeyFile != "" {
+			return errors.New("key_file is only supported for sftp")
+		}
+		if t.Type == TypeSFTP && t.Password == "" && t.KeyFile == "" {
+			return errors.New("password or key_file is required")
+		}
+	case TypeS3:
+		if t.Bucket == "" {
+			return errors.New("bucket is required")
+		}
+		if t.AccessKey == "" || t.SecretKey == "" {
+			return errors.New("access_key and secret_key are required")
+		}
+		if t.Region == "" && t.Endpoint == "" {
+			return errors.New("region or endpoint is required")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, expected ftp, sftp or s3", t.Type)
+	}
+	return nil
+}
+
+func (t *Target) retries() int {
+	if t.Retries == nil {
+		return DefaultRetries
+	}
+	return *t.Retries
+}
+
+// Uploader is a connection to a target
+type Uploader interface {
+	// Upload stores data under name, a slash-separated path relative to
+	// the target path. Existing files are replaced.
+	Upload(ctx context.Context, name string, data []byte) error
+	Close() error
+}
+
+// Dial connects to the target
+func Dial(ctx context.Context, t Target) (Uploader, error) {
+	switch t.Type {
+	case TypeFTP:
+		return dialFTP(ctx, t)
+	case TypeSFTP:
+		return dialSFTP(ctx, t)
+	case TypeS3:
+		return newS3(t), nil
+	}
+	return nil, fmt.Errorf("unknown upload type %q", t.Type)
+}
+
+// FileError is a file that could not be uploaded
+type FileError struct {
+	Name string
+	Err  error
+}
+
+// Report is the result of syncing a directory to a target
+type Report struct {
+	Target   string
+	Uploaded int
+	Skipped  int
+	Failed   []FileError
+	Duration time.Duration
+}
+
+// Err summarizes failed uploads; it is nil when all files were uploaded
+func (r *Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	total := r.Uploaded + len(r.Failed)
+	return fmt.Errorf("%d of %d files failed to upload to %s, first error: %s: %w",
+		len(r.Failed), total, r.Target, r.Failed[0].Name, r.Failed[0].Err)
+}
+
+// Syncer uploads the files of a directory to a target. It remembers the
+// content of uploaded files, so repeated syncs only upload changed files.
+type Syncer struct {
+	target Target
+	dial   func(ctx context.Context, t Target) (Uploader, error)
+	hashes map[string][sha256.Size]byte
+}
+
+// NewSyncer creates a syncer for the target
+func NewSyncer(target Target) *Syncer {
+	return &Syncer{
+		target: target,
+		dial:   Dial,
+		hashes: make(map[string][sha256.Size]byte),
+	}
+}
+
+// Sync uploads new and changed files below the target source of dir.
+// Failed files are retried and reported; they are uploaded again on the
+// next sync. The connection is re-established after a failed upload.
+func (s *Syncer) Sync(ctx context.Context, dir string) *Report {
+	start := time.Now()
+	report := &Report{Target: s.target.Name}
+	defer func() { report.Duration = time.Since(start) }()
+
+	root := filepath.Join(dir, filepath.FromSlash(s.target.Source))
+	files, err := listFiles(root)
+	if err != nil {
+		report.Failed = append(report.Failed, FileError{Name: s.target.Source, Err: err})
+		return report
+	}
+
+	var conn Uploader
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			report.Failed = append(report.Failed, FileError{Name: name, Err: err})
+			continue
+		}
+		hash := sha256.Sum256(data)
+		if prev, ok := s.hashes[name]; ok && prev == hash {
+			report.Skipped++
+			continue
+		}
+
+		for attempt := 0; ; attempt++ {
+			if conn == nil {
+				conn, err = s.dial(ctx, s.target)
+			}
+			if err == nil {
+				if err = conn.Upload(ctx, path.Join(s.target.Path, name), data); err != nil {
+					conn.Close()
+					conn = nil
+				}
+			}
+			if err == nil || attempt >= s.target.retries() || ctx.Err() != nil {
+				break
+			}
+
+			select {
+			case <-time.After(retryDelay * time.Duration(attempt+1)):
+			case <-ctx.Done():
+			}
+		}
+
+		if err != nil {
+			report.Failed = append(report.Failed, FileError{Name: name, Err: err})
+			continue
+		}
+		s.hashes[name] = hash
+		report.Uploaded++
+	}
+	return report
+}
+
+// listFiles returns the slash-separated paths of all regular files below
+// root, skipping temporary files of writes in progress
+func listFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// parentDirs returns all parent directories of a slash-separated path,
+// outermost first
+func parentDirs(name string) []string {
+	var dirs []string
+	for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// deadline returns the deadline of an operation, bounded by the context
+func deadline(ctx context.Context) time.Time {
+	d := time.Now().Add(operationTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(d) {
+		return ctxDeadline
+	}
+	return d
+}
+
+// operationTimeout bounds a single network operation
+const operationTimeout = 60 * time.Second
@@ -0,0 +1,169 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memoryUploader records uploads and fails for names in fail
+type memoryUploader struct {
+	files  map[string]string
+	fail   map[string]int
+	closed int
+}
+
+func (m *memoryUploader) Upload(ctx context.Context, name string, data []byte) error {
+	if m.fail[name] > 0 {
+		m.fail[name]--
+		return errors.New("connection reset")
+	}
+	m.files[name] = string(data)
+	return nil
+}
+
+func (m *memoryUploader) Close() error {
+	m.closed++
+	return nil
+}
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testSyncer(target Target, m *memoryUploader) *Syncer {
+	s := NewSyncer(target)
+	s.dial = func(ctx context.Context, t Target) (Uploader, error) { return m, nil }
+	return s
+}
+
+func TestSyncer_SkipsUnchangedFiles(t *testing.T) {
+	retryDelay = 0
+	dir := writeTestFiles(t, map[string]string{
+		"index.html":            "index",
+		"station/index.html":    "station",
+		"station/page.html.tmp": "partial",
+	})
+	m := &memoryUploader{files: make(map[string]string)}
+	s := testSyncer(Target{Name: "web", Path: "/www"}, m)
+
+	report := s.Sync(context.Background(), dir)
+
+	if report.Err() != nil || report.Uploaded != 2 {
+		t.Fatalf("Expected 2 uploads, got %d: %v", report.Uploaded, report.Err())
+	}
+	if m.files["/www/station/index.html"] != "station" {
+		t.Errorf("Expected file below target path, got %v", m.files)
+	}
+
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("changed"), 0644)
+	report = s.Sync(context.Background(), dir)
+
+	if report.Uploaded != 1 || report.Skipped != 1 {
+		t.Errorf("Expected 1 upload and 1 skip, got %d and %d", report.Uploaded, report.Skipped)
+	}
+	if m.files["/www/index.html"] != "changed" {
+		t.Errorf("Expected changed file to be uploaded")
+	}
+}
+
+func TestSyncer_RetriesAndReportsFailures(t *testing.T) {
+	retryDelay = 0
+	dir := writeTestFiles(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	retries := 1
+	m := &memoryUploader{
+		files: make(map[string]string),
+		fail:  map[string]int{"a.txt": 1, "b.txt": 5},
+	}
+	s := testSyncer(Target{Name: "web", Retries: &retries}, m)
+
+	report := s.Sync(context.Background(), dir)
+
+	if report.Uploaded != 2 {
+		t.Errorf("Expected 2 uploads, got %d", report.Uploaded)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Name != "b.txt" {
+		t.Fatalf("Expected b.txt to fail, got %v", report.Failed)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "1 of 3 files") {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	// The failed file is uploaded again on the next sync
+	m.fail = nil
+	report = s.Sync(context.Background(), dir)
+	if report.Uploaded != 1 || report.Err() != nil {
+		t.Errorf("Expected failed file to be uploaded, got %d: %v", report.Uploaded, report.Err())
+	}
+}
+
+func TestSyncer_Source(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"index.html":           "index",
+		"station/NOAA/a.txt":   "report",
+		"station/charts/a.svg": "chart",
+	})
+	m := &memoryUploader{files: make(map[string]string)}
+	s := testSyncer(Target{Name: "reports", Source: "station/NOAA", Path: "noaa"}, m)
+
+	report := s.Sync(context.Background(), dir)
+
+	if report.Uploaded != 1 || m.files["noaa/a.txt"] != "report" {
+		t.Errorf("Expected only the source directory to be uploaded, got %v", m.files)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TEST_FTP_PASSWORD", "secret")
+	dir := writeTestFiles(t, map[string]string{
+		"upload.json": `{"targets": [
+			{"name": "web", "type": "ftp", "host": "ftp.example.com", "user": "wx", "password": "${TEST_FTP_PASSWORD}"},
+			{"type": "s3", "bucket": "weather", "region": "eu-central-1", "access_key": "a", "secret_key": "b"}
+		]}`,
+		"invalid.json": `{"targets": [{"name": "web", "type": "sftp", "host": "example.com", "user": "wx"}]}`,
+	})
+
+	targets, err := LoadConfig(filepath.Join(dir, "upload.json"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("Expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].Password != "secret" {
+		t.Errorf("Expected password from environment, got %q", targets[0].Password)
+	}
+	if targets[1].Name != "s3-2" {
+		t.Errorf("Expected generated name, got %q", targets[1].Name)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "invalid.json")); err == nil {
+		t.Error("Expected error for sftp target without credentials")
+	}
+}
+
+func TestParentDirs(t *testing.T) {
+	dirs := parentDirs("/www/station/NOAA/a.txt")
+	expected := []string{"/www", "/www/station", "/www/station/NOAA"}
+
+	if strings.Join(dirs, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, dirs)
+	}
+	if len(parentDirs("index.html")) != 0 {
+		t.Error("Expected no parent directories")
+	}
+}